@@ -0,0 +1,289 @@
+// Command anonymize clones a database into the configured, empty one with personal data
+// scrambled, so developers can debug against production-shaped data:
+//
+//	anonymize -source "postgres://readonly@prod-db/scms?sslmode=require"
+//
+// The schema is copied with pg_dump, so it must be on the PATH or given with -pg-dump.
+// Every row is then copied from a single snapshot of the source, with names, emails,
+// phone numbers, addresses, account numbers and notes replaced by realistic stand-ins.
+// A value always gets the same stand-in, so customers still match their orders and
+// contacts, and keys, indexes and foreign keys are restored only after the copy so the
+// target validates that nothing was broken. Sessions, sign-in attempts and integration
+// credentials are not copied, and every user's password becomes -password.
+//
+// The target is configured as for the server and must have no tables; the source is only
+// read from.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/config"
+	"github.com/Cezzyy/SCMS/backend/internal/database"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func main() {
+	source := flag.String("source", os.Getenv("ANONYMIZE_SOURCE_URL"), "connection string of the database to clone (default $ANONYMIZE_SOURCE_URL)")
+	pgDump := flag.String("pg-dump", "pg_dump", "the pg_dump binary, matching the source's major version")
+	newPassword := flag.String("password", "staging", "the password every cloned user signs in with")
+	key := flag.String("key", "", "key the stand-ins are derived from; the same key gives the same stand-ins on every run (default random)")
+	flag.Parse()
+
+	if *source == "" {
+		log.Fatal("No source database; pass -source or set ANONYMIZE_SOURCE_URL")
+	}
+	if _, err := config.Load(); err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	scrambleKey := []byte(*key)
+	if len(scrambleKey) == 0 {
+		scrambleKey = make([]byte, 32)
+		rand.Read(scrambleKey)
+	}
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(*newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		log.Fatalf("Failed to hash the password: %v", err)
+	}
+
+	started := time.Now()
+	if err := run(context.Background(), *source, *pgDump, newScrambler(scrambleKey, string(passwordHash))); err != nil {
+		log.Fatalf("anonymize failed: %v", err)
+	}
+	fmt.Printf("Cloned in %s. Every user's password is %q.\n", time.Since(started).Round(time.Second), *newPassword)
+}
+
+func run(ctx context.Context, sourceURL, pgDump string, s *scrambler) error {
+	source, err := sqlx.Connect("postgres", sourceURL)
+	if err != nil {
+		return fmt.Errorf("connecting to the source: %w", err)
+	}
+	defer source.Close()
+	target, err := database.Connect()
+	if err != nil {
+		return fmt.Errorf("connecting to the target: %w", err)
+	}
+	defer target.Close()
+
+	var tables int
+	if err := target.GetContext(ctx, &tables, `
+		SELECT COUNT(*) FROM information_schema.tables
+		WHERE table_schema NOT IN ('pg_catalog', 'information_schema')`); err != nil {
+		return err
+	}
+	if tables > 0 {
+		return fmt.Errorf("the target database already has %d tables; clone into an empty database", tables)
+	}
+
+	// Tables first, then the data, then the keys and indexes that check it
+	schema, err := dumpSchema(ctx, pgDump, sourceURL, "pre-data")
+	if err != nil {
+		return err
+	}
+	constraints, err := dumpSchema(ctx, pgDump, sourceURL, "post-data")
+	if err != nil {
+		return err
+	}
+	if err := execScript(ctx, target, schema); err != nil {
+		return fmt.Errorf("creating the schema: %w", err)
+	}
+
+	tx, err := source.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var names []struct {
+		Schema string `db:"table_schema"`
+		Table  string `db:"table_name"`
+	}
+	if err := tx.SelectContext(ctx, &names, `
+		SELECT table_schema, table_name FROM information_schema.tables
+		WHERE table_type = 'BASE TABLE' AND table_schema NOT IN ('pg_catalog', 'information_schema')
+		ORDER BY table_schema, table_name`); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if skippedTables[name.Table] {
+			log.Printf("%s: skipped", name.Table)
+			continue
+		}
+		copied, err := copyTable(ctx, tx, target, s, name.Schema, name.Table)
+		if err != nil {
+			return fmt.Errorf("copying %s: %w", name.Table, err)
+		}
+		log.Printf("%s: %d rows", name.Table, copied)
+	}
+
+	if err := execScript(ctx, target, constraints); err != nil {
+		return fmt.Errorf("restoring keys and indexes: %w", err)
+	}
+	return copySequences(ctx, tx, target)
+}
+
+// dumpSchema runs pg_dump for one section of the source's schema
+func dumpSchema(ctx context.Context, pgDump, sourceURL, section string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, pgDump, "--section="+section, "--no-owner", "--no-privileges", "--dbname="+sourceURL)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("pg_dump --section=%s: %w: %s", section, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// execScript runs a pg_dump script on one connection, as it changes session settings such
+// as the search path. psql meta-commands are dropped.
+func execScript(ctx context.Context, db *sqlx.DB, script string) error {
+	var statements strings.Builder
+	lines := bufio.NewScanner(strings.NewReader(script))
+	lines.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for lines.Scan() {
+		if !strings.HasPrefix(lines.Text(), `\`) {
+			statements.WriteString(lines.Text())
+			statements.WriteByte('\n')
+		}
+	}
+	if err := lines.Err(); err != nil {
+		return err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, statements.String()); err != nil {
+		return err
+	}
+	_, err = conn.ExecContext(ctx, "RESET ALL")
+	return err
+}
+
+// copyTable streams a table's rows into the target with COPY, scrambling them on the way
+func copyTable(ctx context.Context, source *sqlx.Tx, target *sqlx.DB, s *scrambler, schema, table string) (int, error) {
+	var columns []string
+	if err := source.SelectContext(ctx, &columns, `
+		SELECT column_name FROM information_schema.columns
+		WHERE table_schema = $1 AND table_name = $2 AND is_generated = 'NEVER'
+		ORDER BY ordinal_position`, schema, table); err != nil {
+		return 0, err
+	}
+	if len(columns) == 0 {
+		return 0, nil
+	}
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = pq.QuoteIdentifier(column)
+	}
+
+	rows, err := source.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM %s.%s",
+		strings.Join(quoted, ", "), pq.QuoteIdentifier(schema), pq.QuoteIdentifier(table)))
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := target.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(ctx, pq.CopyInSchema(schema, table, columns...))
+	if err != nil {
+		return 0, err
+	}
+
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	copied := 0
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return 0, err
+		}
+		for i, column := range columns {
+			value, err := scrambleValue(s, table, column, types[i].DatabaseTypeName(), values[i])
+			if err != nil {
+				return 0, fmt.Errorf("%s: %w", column, err)
+			}
+			values[i] = value
+		}
+		if _, err := stmt.ExecContext(ctx, values...); err != nil {
+			return 0, err
+		}
+		copied++
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return 0, err
+	}
+	if err := stmt.Close(); err != nil {
+		return 0, err
+	}
+	return copied, tx.Commit()
+}
+
+// scrambleValue scrambles one scanned value. The driver returns numeric, JSON and array
+// values as bytes, which COPY would write as binary, so everything but BYTEA is passed on
+// as text.
+func scrambleValue(s *scrambler, table, column, databaseType string, value interface{}) (interface{}, error) {
+	if raw, ok := value.([]byte); ok && databaseType != "BYTEA" {
+		value = string(raw)
+	}
+	if document, ok := value.(string); ok && (databaseType == "JSON" || databaseType == "JSONB") {
+		if kindOf(table, column) != keep {
+			return s.scramble(kindOf(table, column), value), nil
+		}
+		return s.scrambleJSON(document)
+	}
+	return s.scramble(kindOf(table, column), value), nil
+}
+
+// copySequences moves every sequence to where it is in the source, so new rows in the
+// clone do not collide with copied ones
+func copySequences(ctx context.Context, source *sqlx.Tx, target *sqlx.DB) error {
+	var sequences []struct {
+		Schema    string        `db:"schemaname"`
+		Name      string        `db:"sequencename"`
+		LastValue sql.NullInt64 `db:"last_value"`
+	}
+	if err := source.SelectContext(ctx, &sequences,
+		`SELECT schemaname, sequencename, last_value FROM pg_sequences`); err != nil {
+		return err
+	}
+	for _, sequence := range sequences {
+		if !sequence.LastValue.Valid {
+			continue
+		}
+		name := pq.QuoteIdentifier(sequence.Schema) + "." + pq.QuoteIdentifier(sequence.Name)
+		if _, err := target.ExecContext(ctx, "SELECT setval($1::regclass, $2)", name, sequence.LastValue.Int64); err != nil {
+			return fmt.Errorf("setting sequence %s: %w", name, err)
+		}
+	}
+	return nil
+}
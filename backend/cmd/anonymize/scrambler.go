@@ -0,0 +1,321 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	mathrand "math/rand"
+	"strings"
+	"unicode"
+)
+
+// scrambleKind is how a column's values are replaced
+type scrambleKind int
+
+const (
+	keep scrambleKind = iota
+	firstName
+	lastName
+	fullName
+	companyName
+	email
+	phone
+	address
+	digits
+	ipAddress
+	freeText
+	secret
+	password
+	coordinate
+	emptyBytes
+)
+
+// columnKinds scramble columns by name, in every table and in the keys of JSON documents
+// such as audit snapshots and bulk-deleted rows. The same value always scrambles to the
+// same replacement, so a company name copied onto another table still matches.
+var columnKinds = map[string]scrambleKind{
+	"first_name":       firstName,
+	"last_name":        lastName,
+	"contact_name":     fullName,
+	"contact_person":   fullName,
+	"recipient_name":   fullName,
+	"company_name":     companyName,
+	"customer_name":    companyName,
+	"supplier_name":    companyName,
+	"email":            email,
+	"contact_email":    email,
+	"recipient":        email,
+	"phone":            phone,
+	"mobile":           phone,
+	"contact_phone":    phone,
+	"address":          address,
+	"billing_address":  address,
+	"shipping_address": address,
+	"delivery_address": address,
+	"tin":              digits,
+	"bank_account":     digits,
+	"account_number":   digits,
+	"check_number":     digits,
+	"license_number":   digits,
+	"ip_address":       ipAddress,
+	"last_seen_ip":     ipAddress,
+	"notes":            freeText,
+	"opening_notes":    freeText,
+	"closing_notes":    freeText,
+	"condition_notes":  freeText,
+	"approval_note":    freeText,
+	"remarks":          freeText,
+	"password_hash":    password,
+	"token_hash":       secret,
+	"key_hash":         secret,
+	"access_token":     secret,
+	"refresh_token":    secret,
+	"latitude":         coordinate,
+	"longitude":        coordinate,
+}
+
+// tableKinds scramble columns whose names are too generic to scramble everywhere
+var tableKinds = map[string]map[string]scrambleKind{
+	"suppliers":            {"name": companyName},
+	"drivers":              {"name": fullName},
+	"bank_statement_lines": {"description": freeText},
+	"attachments":          {"content": emptyBytes},
+}
+
+// skippedTables hold credentials and sign-in history; their rows are not copied at all
+var skippedTables = map[string]bool{
+	"sessions":                true,
+	"login_attempts":          true,
+	"integration_credentials": true,
+}
+
+// uniqueKinds are kinds whose replacements never collide, as the columns they are used for
+// may be unique
+var uniqueKinds = map[scrambleKind]bool{
+	email:       true,
+	companyName: true,
+	digits:      true,
+}
+
+var (
+	firstNames = []string{
+		"Maria", "Jose", "Juan", "Ana", "Mark", "John", "Michael", "Angelica", "Kristine", "Jerome",
+		"Carlo", "Patricia", "Paolo", "Camille", "Miguel", "Andrea", "Rafael", "Joy", "Ramon", "Liza",
+		"Antonio", "Grace", "Francis", "Rowena", "Eduardo", "Marites", "Gabriel", "Catherine", "Noel", "Jasmine",
+	}
+	lastNames = []string{
+		"Santos", "Reyes", "Cruz", "Bautista", "Ocampo", "Garcia", "Mendoza", "Torres", "Tomas", "Andrada",
+		"Castillo", "Flores", "Villanueva", "Ramos", "Castro", "Rivera", "Aquino", "Navarro", "Salazar", "Mercado",
+		"Dela Cruz", "Gonzales", "Lopez", "Del Rosario", "Aguilar", "Pascual", "Soriano", "Valdez", "Domingo", "Manalo",
+	}
+	companyWords = []string{
+		"Pacific", "Luzon", "Visayas", "Mindanao", "Golden", "Metro", "Northern", "Southern", "United", "Prime",
+		"Allied", "Summit", "Harbor", "Island", "Eastern", "Sterling", "Pioneer", "Premier", "Central", "Coastal",
+	}
+	companyTrades = []string{
+		"Steel Works", "Fabrication", "Construction", "Shipyard", "Engineering", "Industrial Supply",
+		"Builders", "Machine Shop", "Metal Products", "Welding Services", "Hardware", "Marine Services",
+	}
+	companySuffixes = []string{"Inc.", "Corp.", "Corporation", "Co.", "Enterprises", "Trading"}
+	streets         = []string{
+		"Rizal", "Mabini", "Bonifacio", "Quezon", "Del Pilar", "Luna", "Burgos", "Roxas", "Magsaysay", "Osmena",
+	}
+	streetTypes = []string{"St.", "Ave.", "Road", "Blvd."}
+	cities      = []string{
+		"Quezon City", "Manila", "Makati", "Pasig", "Taguig", "Caloocan", "Valenzuela", "Cebu City",
+		"Mandaue", "Davao City", "Cagayan de Oro", "Iloilo City", "Bacolod", "Batangas City", "Calamba",
+	}
+	emailDomains = []string{"example.com", "example.net", "example.org"}
+	textWords    = []string{
+		"customer", "requested", "delivery", "before", "noon", "follow", "up", "on", "payment", "terms",
+		"confirmed", "by", "phone", "site", "contact", "will", "receive", "the", "items", "call",
+		"ahead", "check", "stock", "for", "next", "order", "price", "approved", "pending", "review",
+	}
+)
+
+// scrambler replaces personal data with realistic stand-ins. Replacements are derived from
+// the value and a key, so they are consistent within a run and across runs with the same
+// key, but cannot be reversed without it.
+type scrambler struct {
+	key          []byte
+	passwordHash string
+	replacements map[string]interface{}
+	used         map[string]bool
+}
+
+func newScrambler(key []byte, passwordHash string) *scrambler {
+	return &scrambler{
+		key:          key,
+		passwordHash: passwordHash,
+		replacements: map[string]interface{}{},
+		used:         map[string]bool{},
+	}
+}
+
+// kindOf returns how a column of a table is scrambled
+func kindOf(table, column string) scrambleKind {
+	if kind, ok := tableKinds[table][column]; ok {
+		return kind
+	}
+	return columnKinds[column]
+}
+
+// rng returns a random source seeded from the keyed hash of a value
+func (s *scrambler) rng(kind scrambleKind, value string, attempt int) *mathrand.Rand {
+	mac := hmac.New(sha256.New, s.key)
+	fmt.Fprintf(mac, "%d\x00%d\x00%s", kind, attempt, value)
+	return mathrand.New(mathrand.NewSource(int64(binary.BigEndian.Uint64(mac.Sum(nil)))))
+}
+
+// scramble replaces one value of the given kind. NULLs and empty strings are kept so the
+// share of missing values stays realistic.
+func (s *scrambler) scramble(kind scrambleKind, value interface{}) interface{} {
+	if kind == keep || value == nil {
+		return value
+	}
+	switch kind {
+	case emptyBytes:
+		return []byte{}
+	case coordinate:
+		if f, ok := value.(float64); ok {
+			// Move the point by up to about a kilometre
+			return f + (s.rng(kind, fmt.Sprint(f), 0).Float64()-0.5)*0.02
+		}
+		return value
+	case password:
+		return s.passwordHash
+	case secret:
+		raw := make([]byte, 32)
+		rand.Read(raw)
+		return hex.EncodeToString(raw)
+	}
+
+	text, ok := value.(string)
+	if !ok || strings.TrimSpace(text) == "" {
+		return value
+	}
+	cacheKey := fmt.Sprintf("%d\x00%s", kind, text)
+	if replacement, ok := s.replacements[cacheKey]; ok {
+		return replacement
+	}
+
+	var replacement string
+	for attempt := 0; ; attempt++ {
+		replacement = s.generate(kind, text, s.rng(kind, text, attempt))
+		if attempt >= 10 {
+			replacement = withSuffix(kind, replacement, attempt)
+		}
+		if !uniqueKinds[kind] || !s.used[fmt.Sprintf("%d\x00%s", kind, replacement)] {
+			break
+		}
+	}
+	s.used[fmt.Sprintf("%d\x00%s", kind, replacement)] = true
+	s.replacements[cacheKey] = replacement
+	return replacement
+}
+
+// generate makes a stand-in for a value
+func (s *scrambler) generate(kind scrambleKind, value string, rng *mathrand.Rand) string {
+	pick := func(list []string) string { return list[rng.Intn(len(list))] }
+
+	switch kind {
+	case firstName:
+		return pick(firstNames)
+	case lastName:
+		return pick(lastNames)
+	case fullName:
+		return pick(firstNames) + " " + pick(lastNames)
+	case companyName:
+		return pick(companyWords) + " " + pick(companyTrades) + " " + pick(companySuffixes)
+	case email:
+		local := strings.ToLower(pick(firstNames) + "." + strings.ReplaceAll(pick(lastNames), " ", ""))
+		return fmt.Sprintf("%s%d@%s", local, rng.Intn(100), pick(emailDomains))
+	case phone:
+		// Keep the format and prefix, such as +63 or 09, and replace the rest
+		if strings.HasPrefix(value, "+") {
+			return replaceDigits(value, rng, 3)
+		}
+		return replaceDigits(value, rng, 2)
+	case digits:
+		return replaceDigits(value, rng, 0)
+	case address:
+		return fmt.Sprintf("%d %s %s, %s", 1+rng.Intn(2500), pick(streets), pick(streetTypes), pick(cities))
+	case ipAddress:
+		return fmt.Sprintf("10.%d.%d.%d", rng.Intn(256), rng.Intn(256), 1+rng.Intn(254))
+	case freeText:
+		var words []string
+		length := 0
+		for length < len(value) {
+			word := pick(textWords)
+			words = append(words, word)
+			length += len(word) + 1
+		}
+		sentence := strings.Join(words, " ")
+		return strings.ToUpper(sentence[:1]) + sentence[1:]
+	}
+	return value
+}
+
+// replaceDigits replaces the letters and digits of a value after the first keep characters
+// with random ones of the same class, so formatting such as dashes survives
+func replaceDigits(value string, rng *mathrand.Rand, keep int) string {
+	runes := []rune(value)
+	for i := keep; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case unicode.IsDigit(r):
+			runes[i] = rune('0' + rng.Intn(10))
+		case unicode.IsUpper(r):
+			runes[i] = rune('A' + rng.Intn(26))
+		case unicode.IsLower(r):
+			runes[i] = rune('a' + rng.Intn(26))
+		}
+	}
+	return string(runes)
+}
+
+// withSuffix keeps a replacement unique when the generator keeps colliding
+func withSuffix(kind scrambleKind, value string, n int) string {
+	if kind == email {
+		at := strings.LastIndex(value, "@")
+		return fmt.Sprintf("%s.%d%s", value[:at], n, value[at:])
+	}
+	return fmt.Sprintf("%s %d", value, n)
+}
+
+// scrambleJSON scrambles the keys of a JSON document that name personal data, at any depth
+func (s *scrambler) scrambleJSON(document string) (string, error) {
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(document), &decoded); err != nil {
+		return "", err
+	}
+	encoded, err := json.Marshal(s.scrambleNode(decoded))
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+func (s *scrambler) scrambleNode(node interface{}) interface{} {
+	switch node := node.(type) {
+	case map[string]interface{}:
+		for key, value := range node {
+			if kind := columnKinds[key]; kind != keep {
+				switch value.(type) {
+				case string, float64:
+					node[key] = s.scramble(kind, value)
+					continue
+				}
+			}
+			node[key] = s.scrambleNode(value)
+		}
+	case []interface{}:
+		for i, value := range node {
+			node[i] = s.scrambleNode(value)
+		}
+	}
+	return node
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/config"
+	"github.com/Cezzyy/SCMS/backend/internal/handlers"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// The benchmarks measure the hot paths against a database filled by "loadtest seed", configured
+// by the DB_* variables as for the server; they are skipped when DB_HOST is not set. Compare
+// runs with benchstat:
+//
+//	go test ./cmd/loadtest -run '^$' -bench . -benchmem -count 6
+//
+// BenchmarkQuotationCreate saves a quotation on every iteration: run it against a seeded copy.
+
+// benchEnv is what the benchmarks share: the seeded records and the repositories, services
+// and handlers of the hot paths, built once for the whole run
+type benchEnv struct {
+	data             scenarioData
+	customerRepo     *repository.CustomerRepository
+	productRepo      *repository.ProductRepository
+	quotationRepo    *repository.QuotationRepository
+	reportRepo       *repository.ReportRepository
+	currencyService  *services.CurrencyService
+	pricingService   *services.PricingService
+	pdfGenerator     *services.PDFGenerator
+	quotationHandler *handlers.QuotationHandler
+}
+
+var (
+	benchOnce sync.Once
+	bench     *benchEnv
+	benchErr  error
+)
+
+// loadBenchEnv connects to the seeded database on first use, skipping b when none is configured
+func loadBenchEnv(b *testing.B) *benchEnv {
+	b.Helper()
+
+	benchOnce.Do(func() {
+		var cfg *config.Config
+		cfg, benchErr = config.Load()
+		if benchErr != nil || os.Getenv("DB_HOST") == "" {
+			return
+		}
+		bench, benchErr = newBenchEnv(cfg)
+	})
+	if benchErr != nil {
+		b.Fatal(benchErr)
+	}
+	if bench == nil {
+		b.Skip("DB_HOST is not set; the benchmarks need a database filled by loadtest seed")
+	}
+
+	// The services log every step of a quotation or PDF; keep the benchmark output readable
+	log.SetOutput(io.Discard)
+	b.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return bench
+}
+
+func newBenchEnv(cfg *config.Config) (*benchEnv, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := loadScenarioData(context.Background(), db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	customerRepo := repository.NewCustomerRepository(db)
+	productRepo := repository.NewProductRepository(db)
+	quotationRepo := repository.NewQuotationRepository(db)
+	rulesService := services.NewRulesService(repository.NewSettingRepository(db))
+	currencyService := services.NewCurrencyService(repository.NewCurrencyRepository(db))
+	pricingService := services.NewPricingService(
+		customerRepo,
+		repository.NewLoyaltyTierRepository(db),
+		repository.NewOrderRepository(db),
+		services.NewFreightService(repository.NewFreightRepository(db), productRepo),
+		rulesService,
+		currencyService,
+		services.NewDiscountService(repository.NewQuantityBreakRepository(db)),
+	)
+	promiseService := services.NewPromiseService(repository.NewAvailabilityRepository(db), repository.NewSupplierRepository(db), rulesService)
+
+	// go test runs in the package directory, one level below the templates
+	if os.Getenv("TEMPLATES_DIR") == "" {
+		cfg.TemplatesDir = filepath.Join("..", "templates")
+		cfg.CSSDir = filepath.Join(cfg.TemplatesDir, "css")
+	}
+	wkhtmltopdfPath := cfg.WkhtmltopdfPath
+	if wkhtmltopdfPath == "" {
+		wkhtmltopdfPath = services.DetectWkhtmltopdfPath()
+	}
+	pdfGenerator := services.NewPDFGenerator(cfg.TemplatesDir, cfg.CSSDir, wkhtmltopdfPath)
+
+	// Quotation creation only reaches the repositories and services it prices and saves with
+	quotationHandler := handlers.NewQuotationHandler(
+		quotationRepo, customerRepo, productRepo, repository.NewCustomerProductRuleRepository(db),
+		pdfGenerator, nil, nil, pricingService, repository.NewAuditRepository(db), rulesService,
+		currencyService, nil, nil, nil, nil, nil, nil, nil, nil, promiseService,
+	)
+
+	return &benchEnv{
+		data:             data,
+		customerRepo:     customerRepo,
+		productRepo:      productRepo,
+		quotationRepo:    quotationRepo,
+		reportRepo:       repository.NewReportRepository(db),
+		currencyService:  currencyService,
+		pricingService:   pricingService,
+		pdfGenerator:     pdfGenerator,
+		quotationHandler: quotationHandler,
+	}, nil
+}
+
+func BenchmarkDashboard(b *testing.B) {
+	env := loadBenchEnv(b)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := env.reportRepo.GetDashboardSummary(ctx, 30); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkQuotationList(b *testing.B) {
+	env := loadBenchEnv(b)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := env.quotationRepo.GetAll(ctx, repository.ListQuery{}, repository.Page{Limit: 50}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkQuotationPricing(b *testing.B) {
+	env := loadBenchEnv(b)
+	ctx := context.Background()
+	rng := rand.New(rand.NewSource(1))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		quotation, items := randomQuotation(rng, env.data)
+		if _, err := env.pricingService.PreviewQuotation(ctx, &quotation, items, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkQuotationCreate posts quotations for seeded customers and products to the
+// quotation create handler, which checks, prices, promises and saves them
+func BenchmarkQuotationCreate(b *testing.B) {
+	env := loadBenchEnv(b)
+	rng := rand.New(rand.NewSource(1))
+
+	e := echo.New()
+	e.Validator = handlers.NewRequestValidator()
+	e.HTTPErrorHandler = handlers.HTTPErrorHandler
+	e.POST("/api/quotations", env.quotationHandler.CreateQuotation)
+
+	bodies := make([][]byte, 256)
+	for i := range bodies {
+		body, err := json.Marshal(quotationBody(rng, env.data))
+		if err != nil {
+			b.Fatal(err)
+		}
+		bodies[i] = body
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/api/quotations", bytes.NewReader(bodies[i%len(bodies)]))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		if rec.Code != http.StatusCreated {
+			b.Fatalf("Creating a quotation answered %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func BenchmarkQuotationPDF(b *testing.B) {
+	env := loadBenchEnv(b)
+	ctx := context.Background()
+	rng := rand.New(rand.NewSource(1))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := env.renderQuotationPDF(ctx, env.data.Quotations[rng.Intn(len(env.data.Quotations))]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// renderQuotationPDF generates a quotation's PDF as the quotation PDF endpoint does
+func (env *benchEnv) renderQuotationPDF(ctx context.Context, id int) error {
+	quotation, items, err := env.quotationRepo.GetFullQuotation(ctx, id)
+	if err != nil {
+		return err
+	}
+	customer, err := env.customerRepo.GetByIDWithDeleted(ctx, quotation.CustomerID)
+	if err != nil {
+		return err
+	}
+	currency, err := env.currencyService.Currency(ctx, quotation.Currency)
+	if err != nil {
+		return err
+	}
+
+	type itemWithProduct struct {
+		models.QuotationItem
+		ProductName string
+	}
+	lines := make([]itemWithProduct, len(items))
+	for i, item := range items {
+		product, err := env.productRepo.GetByIDWithDeleted(ctx, item.ProductID)
+		if err != nil {
+			return err
+		}
+		lines[i] = itemWithProduct{QuotationItem: item, ProductName: product.ProductName}
+	}
+
+	_, err = env.pdfGenerator.GenerateFromTemplate("quotation/template.html", "quotation.css", map[string]interface{}{
+		"Quotation":        quotation,
+		"Customer":         customer,
+		"Currency":         currency,
+		"ItemsWithProduct": lines,
+		"GenerationDate":   time.Now().Format("January 2, 2006"),
+	})
+	return err
+}
@@ -0,0 +1,69 @@
+// Command loadtest prepares and measures the API under month-end volumes:
+//
+//	loadtest seed -confirm         fills the configured database with synthetic customers,
+//	                               products, quotations and orders
+//	loadtest scenario -format k6   writes a k6 script, or vegeta targets, for the hot
+//	                               endpoints using the seeded records
+//
+// Go benchmarks of the hot paths run against the seeded database with "go test -bench";
+// see bench_test.go. The database and templates are configured as for the server. Seeded
+// records are named "Load Test ..." so scenarios can find them; seed a copy of production,
+// never production.
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Cezzyy/SCMS/backend/internal/config"
+	"github.com/Cezzyy/SCMS/backend/internal/database"
+	"github.com/jmoiron/sqlx"
+)
+
+const usage = `usage: loadtest <command> [flags]
+
+commands:
+  seed      fill the database with seedable synthetic data
+  scenario  write a k6 script or vegeta targets for the hot endpoints
+
+Run "loadtest <command> -h" for the flags of a command.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	commands := map[string]func(*config.Config, []string) error{
+		"seed":     runSeed,
+		"scenario": runScenario,
+	}
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if err := command(cfg, os.Args[2:]); err != nil {
+		log.Fatalf("%s failed: %v", os.Args[1], err)
+	}
+}
+
+// connect opens the configured database with its migrations applied
+func connect() (*sqlx.DB, error) {
+	db, err := database.Connect()
+	if err != nil {
+		return nil, err
+	}
+	if err := database.Migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
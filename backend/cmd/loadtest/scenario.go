@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/config"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/jmoiron/sqlx"
+)
+
+// scenarioData is what the generated scenarios pick requests from: the seeded customers,
+// products with their prices, and quotations to print
+type scenarioData struct {
+	Customers  []int              `json:"customers"`
+	Products   []scenarioProduct  `json:"products"`
+	Quotations []int              `json:"quotations"`
+	Rates      map[string]float64 `json:"-"`
+}
+
+type scenarioProduct struct {
+	ID    int     `db:"product_id" json:"id"`
+	Price float64 `db:"price" json:"price"`
+}
+
+// hotEndpoints are the endpoints load scenarios exercise, by scenario name
+var hotEndpoints = []string{"dashboard", "quotation_create", "quotation_pdf"}
+
+// runScenario writes a k6 script, or vegeta targets, exercising the dashboard, quotation
+// creation and quotation PDFs with the records seed created
+func runScenario(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("scenario", flag.ExitOnError)
+	format := flags.String("format", "k6", "k6 for a k6 script, vegeta for vegeta JSON targets")
+	baseURL := flags.String("base-url", "http://localhost:"+cfg.Port, "the server under test")
+	out := flags.String("out", "-", "file to write, - for standard output")
+	seed := flags.Int64("seed", 1, "random seed for the vegeta targets")
+	targets := flags.Int("targets", 1000, "vegeta targets to write, mixed in proportion to the rates")
+	duration := flags.Duration("duration", 5*time.Minute, "how long the k6 scenarios run")
+	dashboardRate := flags.Float64("dashboard-rate", 10, "dashboard requests per second")
+	createRate := flags.Float64("create-rate", 4, "quotations created per second")
+	pdfRate := flags.Float64("pdf-rate", 1, "quotation PDFs per second")
+	flags.Parse(args)
+
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	data, err := loadScenarioData(context.Background(), db)
+	if err != nil {
+		return err
+	}
+	data.Rates = map[string]float64{
+		"dashboard":        *dashboardRate,
+		"quotation_create": *createRate,
+		"quotation_pdf":    *pdfRate,
+	}
+
+	w := io.Writer(os.Stdout)
+	if *out != "-" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	switch *format {
+	case "k6":
+		return writeK6Script(w, strings.TrimSuffix(*baseURL, "/"), *duration, data)
+	case "vegeta":
+		return writeVegetaTargets(w, strings.TrimSuffix(*baseURL, "/"), rand.New(rand.NewSource(*seed)), *targets, data)
+	}
+	return fmt.Errorf("unknown format %q; use k6 or vegeta", *format)
+}
+
+// loadScenarioData reads the seeded records scenarios pick requests from
+func loadScenarioData(ctx context.Context, db *sqlx.DB) (scenarioData, error) {
+	var data scenarioData
+	err := db.SelectContext(ctx, &data.Customers, `
+		SELECT customer_id FROM customers
+		WHERE company_name LIKE $1 AND archived_at IS NULL AND deleted_at IS NULL
+		ORDER BY customer_id`, customerPrefix+"%")
+	if err != nil {
+		return data, err
+	}
+	err = db.SelectContext(ctx, &data.Products, `
+		SELECT product_id, price FROM products
+		WHERE product_name LIKE $1 AND deleted_at IS NULL AND discontinued_at IS NULL
+		ORDER BY product_id`, productPrefix+"%")
+	if err != nil {
+		return data, err
+	}
+	err = db.SelectContext(ctx, &data.Quotations, `
+		SELECT q.quotation_id FROM quotations q
+		JOIN customers c ON c.customer_id = q.customer_id
+		WHERE c.company_name LIKE $1
+		ORDER BY q.quotation_id
+		LIMIT 1000`, customerPrefix+"%")
+	if err != nil {
+		return data, err
+	}
+
+	if len(data.Customers) == 0 || len(data.Products) == 0 || len(data.Quotations) == 0 {
+		return data, fmt.Errorf("no seeded records found; run loadtest seed first")
+	}
+	return data, nil
+}
+
+// randomQuotation picks a seeded customer and one to eight lines of seeded products
+func randomQuotation(rng *rand.Rand, data scenarioData) (models.Quotation, []models.QuotationItem) {
+	quotation := models.Quotation{CustomerID: data.Customers[rng.Intn(len(data.Customers))]}
+	items := make([]models.QuotationItem, 1+rng.Intn(8))
+	for i := range items {
+		product := data.Products[rng.Intn(len(data.Products))]
+		items[i] = models.QuotationItem{
+			ProductID: product.ID,
+			Quantity:  1 + rng.Intn(20),
+			UnitPrice: product.Price,
+		}
+	}
+	return quotation, items
+}
+
+// quotationBody is a quotation create request for a random seeded customer and products
+func quotationBody(rng *rand.Rand, data scenarioData) map[string]interface{} {
+	quotation, items := randomQuotation(rng, data)
+	lines := make([]map[string]interface{}, len(items))
+	for i, item := range items {
+		lines[i] = map[string]interface{}{
+			"product_id": item.ProductID,
+			"quantity":   item.Quantity,
+			"unit_price": item.UnitPrice,
+		}
+	}
+	return map[string]interface{}{
+		"quotation": map[string]interface{}{"customer_id": quotation.CustomerID},
+		"items":     lines,
+	}
+}
+
+// vegetaTarget is a request in vegeta's JSON target format
+type vegetaTarget struct {
+	Method string              `json:"method"`
+	URL    string              `json:"url"`
+	Header map[string][]string `json:"header"`
+	Body   []byte              `json:"body,omitempty"`
+}
+
+// writeVegetaTargets writes count requests, one JSON target per line, mixed in proportion to
+// the scenario rates. The session cookie is read from SCMS_SESSION when the targets are
+// written, as vegeta cannot log in; use them with "vegeta attack -format=json".
+func writeVegetaTargets(w io.Writer, baseURL string, rng *rand.Rand, count int, data scenarioData) error {
+	session := strings.TrimSpace(os.Getenv("SCMS_SESSION"))
+	if session == "" {
+		return fmt.Errorf("set SCMS_SESSION to the %s cookie of a logged in user", services.SessionCookieName)
+	}
+
+	var total float64
+	for _, name := range hotEndpoints {
+		total += data.Rates[name]
+	}
+	if total <= 0 {
+		return fmt.Errorf("at least one rate must be positive")
+	}
+
+	encoder := json.NewEncoder(w)
+	for i := 0; i < count; i++ {
+		target := vegetaTarget{
+			Method: "GET",
+			Header: map[string][]string{
+				"Cookie": {services.SessionCookieName + "=" + session},
+				"Accept": {"application/json"},
+			},
+		}
+
+		pick := rng.Float64() * total
+		switch {
+		case pick < data.Rates["dashboard"]:
+			target.URL = baseURL + "/api/dashboard?days=30"
+		case pick < data.Rates["dashboard"]+data.Rates["quotation_create"]:
+			body, err := json.Marshal(quotationBody(rng, data))
+			if err != nil {
+				return err
+			}
+			target.Method = "POST"
+			target.URL = baseURL + "/api/quotations"
+			target.Header["Content-Type"] = []string{"application/json"}
+			target.Body = body
+		default:
+			target.URL = fmt.Sprintf("%s/api/quotations/%d/pdf", baseURL, data.Quotations[rng.Intn(len(data.Quotations))])
+		}
+
+		if err := encoder.Encode(target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeK6Script writes a k6 script running each hot endpoint at its own constant rate, with
+// thresholds that fail the run when latency or errors regress
+func writeK6Script(w io.Writer, baseURL string, duration time.Duration, data scenarioData) error {
+	seeded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return k6Script.Execute(w, map[string]interface{}{
+		"BaseURL":   baseURL,
+		"Duration":  duration.String(),
+		"Data":      string(seeded),
+		"Rates":     data.Rates,
+		"Cookie":    services.SessionCookieName,
+		"Endpoints": hotEndpoints,
+	})
+}
+
+var k6Script = template.Must(template.New("k6").Funcs(template.FuncMap{
+	"vus": func(rate float64) int { return int(rate*2) + 2 },
+}).Parse(`// Generated by "loadtest scenario -format k6". Run with:
+//   k6 run -e SCMS_EMAIL=... -e SCMS_PASSWORD=... script.js
+// Raise RATE_LIMIT_API_PER_MINUTE on the server under test, or every scenario is throttled.
+import http from "k6/http";
+import { check, fail } from "k6";
+
+const baseURL = __ENV.BASE_URL || "{{.BaseURL}}";
+const seeded = {{.Data}};
+
+export const options = {
+	scenarios: {
+{{- range $name := .Endpoints}}{{with index $.Rates $name}}
+		{{$name}}: {
+			executor: "constant-arrival-rate",
+			exec: "{{$name}}",
+			rate: {{.}},
+			timeUnit: "1s",
+			duration: "{{$.Duration}}",
+			preAllocatedVUs: {{vus .}},
+		},{{end}}{{end}}
+	},
+	thresholds: {
+		http_req_failed: ["rate<0.01"],
+		"http_req_duration{scenario:dashboard}": ["p(95)<800"],
+		"http_req_duration{scenario:quotation_create}": ["p(95)<1000"],
+		"http_req_duration{scenario:quotation_pdf}": ["p(95)<5000"],
+	},
+};
+
+function pick(list) {
+	return list[Math.floor(Math.random() * list.length)];
+}
+
+export function setup() {
+	const res = http.post(baseURL + "/api/auth/login", JSON.stringify({
+		email: __ENV.SCMS_EMAIL,
+		password: __ENV.SCMS_PASSWORD,
+	}), { headers: { "Content-Type": "application/json" } });
+	if (res.status !== 200 || !res.cookies["{{.Cookie}}"]) {
+		fail("login failed with status " + res.status);
+	}
+	return { session: res.cookies["{{.Cookie}}"][0].value };
+}
+
+function params(session, name) {
+	return {
+		headers: { "Content-Type": "application/json", Cookie: "{{.Cookie}}=" + session },
+		tags: { name: name },
+	};
+}
+
+export function dashboard(auth) {
+	const res = http.get(baseURL + "/api/dashboard?days=30", params(auth.session, "dashboard"));
+	check(res, { "dashboard 200": (r) => r.status === 200 });
+}
+
+export function quotation_create(auth) {
+	const items = [];
+	const lines = 1 + Math.floor(Math.random() * 8);
+	for (let i = 0; i < lines; i++) {
+		const product = pick(seeded.products);
+		items.push({ product_id: product.id, quantity: 1 + Math.floor(Math.random() * 20), unit_price: product.price });
+	}
+	const body = JSON.stringify({ quotation: { customer_id: pick(seeded.customers) }, items: items });
+	const res = http.post(baseURL + "/api/quotations", body, params(auth.session, "quotation_create"));
+	check(res, { "quotation created": (r) => r.status === 201 });
+}
+
+export function quotation_pdf(auth) {
+	const res = http.get(baseURL + "/api/quotations/" + pick(seeded.quotations) + "/pdf", params(auth.session, "quotation_pdf"));
+	check(res, { "pdf 200": (r) => r.status === 200 && r.headers["Content-Type"] === "application/pdf" });
+}
+`))
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/config"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// Seeded records are named with these prefixes so scenarios and benchmarks can find them
+const (
+	customerPrefix = "Load Test Customer"
+	productPrefix  = "Load Test Product"
+	seedCategory   = "Load Test"
+)
+
+// seedVATRate is the VAT rate, in percent, seeded documents are priced at
+const seedVATRate = 12
+
+// runSeed fills the database with synthetic records. The same -seed and counts always
+// produce the same records, dated relative to today, so runs against fresh copies of the
+// database are comparable.
+func runSeed(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	seed := flags.Int64("seed", 1, "random seed; the same seed gives the same data")
+	customers := flags.Int("customers", 200, "customers to create")
+	products := flags.Int("products", 500, "products to create, each with inventory")
+	quotations := flags.Int("quotations", 2000, "quotations to create")
+	orders := flags.Int("orders", 5000, "orders to create")
+	days := flags.Int("days", 90, "days back from today to spread quotations and orders over")
+	confirm := flags.Bool("confirm", false, "confirm writing to the configured database")
+	flags.Parse(args)
+
+	if !*confirm {
+		return fmt.Errorf("seed writes to the database %q; run it with -confirm against a copy", os.Getenv("DB_NAME"))
+	}
+	if *customers <= 0 || *products <= 0 || *days <= 0 || *quotations < 0 || *orders < 0 {
+		return fmt.Errorf("counts and days must be positive")
+	}
+
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	rng := rand.New(rand.NewSource(*seed))
+	customerRepo := repository.NewCustomerRepository(db)
+	productRepo := repository.NewProductRepository(db)
+	inventoryRepo := repository.NewInventoryRepository(db)
+	quotationRepo := repository.NewQuotationRepository(db)
+	orderRepo := repository.NewOrderRepository(db)
+
+	started := time.Now()
+	customerIDs := make([]int, *customers)
+	for i := range customerIDs {
+		terms := 30
+		customer := models.Customer{
+			CompanyName:       fmt.Sprintf("%s %05d", customerPrefix, i+1),
+			VATClassification: "vatable",
+			PaymentTermsDays:  &terms,
+		}
+		if err := customerRepo.Create(ctx, &customer); err != nil {
+			return fmt.Errorf("creating customer %d: %w", i+1, err)
+		}
+		customerIDs[i] = customer.CustomerID
+	}
+	log.Printf("Created %d customers", len(customerIDs))
+
+	category := seedCategory
+	catalogue := make([]models.Product, *products)
+	for i := range catalogue {
+		price := roundCents(50 + rng.Float64()*19950)
+		cost := roundCents(price * (0.6 + rng.Float64()*0.25))
+		catalogue[i] = models.Product{
+			ProductName: fmt.Sprintf("%s %05d", productPrefix, i+1),
+			Category:    &category,
+			Price:       price,
+			CostPrice:   &cost,
+			Currency:    models.BaseCurrency,
+		}
+	}
+	if err := productRepo.CreateMany(ctx, catalogue); err != nil {
+		return fmt.Errorf("creating products: %w", err)
+	}
+	for _, product := range catalogue {
+		inventory := models.Inventory{
+			ProductID:    product.ProductID,
+			CurrentStock: rng.Intn(500),
+			ReorderLevel: 20,
+		}
+		if err := inventoryRepo.Create(ctx, &inventory); err != nil {
+			return fmt.Errorf("creating inventory of product %d: %w", product.ProductID, err)
+		}
+	}
+	log.Printf("Created %d products with inventory", len(catalogue))
+
+	quotationStatuses := []string{"Pending", "Pending", "Approved", "Rejected", "Expired"}
+	for i := 0; i < *quotations; i++ {
+		items := seedLines(rng, catalogue)
+		quotation := models.Quotation{
+			CustomerID: customerIDs[rng.Intn(len(customerIDs))],
+			QuoteDate:  seedDate(rng, *days),
+			Status:     quotationStatuses[rng.Intn(len(quotationStatuses))],
+			Currency:   models.BaseCurrency,
+		}
+		quotation.ValidityDate = quotation.QuoteDate.AddDate(0, 0, 30)
+		quotation.TotalAmount, quotation.NetAmount, quotation.VATAmount = seedTotals(items)
+		quotation.VATRate = seedVATRate
+		if err := quotationRepo.CreateQuotationWithItems(ctx, &quotation, items); err != nil {
+			return fmt.Errorf("creating quotation %d: %w", i+1, err)
+		}
+		if (i+1)%500 == 0 {
+			log.Printf("Created %d of %d quotations", i+1, *quotations)
+		}
+	}
+	log.Printf("Created %d quotations", *quotations)
+
+	orderStatuses := []string{"Delivered", "Delivered", "Delivered", "Shipped", "Pending", "Cancelled"}
+	for i := 0; i < *orders; i++ {
+		lines := seedLines(rng, catalogue)
+		items := make([]models.OrderItem, len(lines))
+		for j, line := range lines {
+			items[j] = models.OrderItem{
+				ProductID:     line.ProductID,
+				Quantity:      line.Quantity,
+				UnitPrice:     line.UnitPrice,
+				Discount:      line.Discount,
+				DiscountType:  line.DiscountType,
+				DiscountValue: line.DiscountValue,
+				LineTotal:     line.LineTotal,
+			}
+		}
+		order := models.Order{
+			CustomerID:      customerIDs[rng.Intn(len(customerIDs))],
+			OrderDate:       seedDate(rng, *days),
+			ShippingAddress: "Load test address",
+			Status:          orderStatuses[rng.Intn(len(orderStatuses))],
+			Currency:        models.BaseCurrency,
+		}
+		if order.Status == "Delivered" {
+			delivered := order.OrderDate.Add(time.Duration(1+rng.Intn(72)) * time.Hour)
+			order.DeliveredAt = &delivered
+		}
+		order.TotalAmount, order.NetAmount, order.VATAmount = seedTotals(lines)
+		order.VATRate = seedVATRate
+		if err := orderRepo.CreateOrderWithItems(ctx, &order, items); err != nil {
+			return fmt.Errorf("creating order %d: %w", i+1, err)
+		}
+		if (i+1)%500 == 0 {
+			log.Printf("Created %d of %d orders", i+1, *orders)
+		}
+	}
+	log.Printf("Created %d orders", *orders)
+
+	log.Printf("Seeded in %s", time.Since(started).Round(time.Millisecond))
+	return nil
+}
+
+// seedLines picks one to eight lines, a quarter of them discounted by a percentage
+func seedLines(rng *rand.Rand, catalogue []models.Product) []models.QuotationItem {
+	lines := make([]models.QuotationItem, 1+rng.Intn(8))
+	for i := range lines {
+		product := catalogue[rng.Intn(len(catalogue))]
+		line := models.QuotationItem{
+			ProductID:    product.ProductID,
+			Quantity:     1 + rng.Intn(20),
+			UnitPrice:    product.Price,
+			DiscountType: models.DiscountNone,
+		}
+		gross := float64(line.Quantity) * line.UnitPrice
+		if rng.Intn(4) == 0 {
+			line.DiscountType = models.DiscountPercent
+			line.DiscountValue = float64(1 + rng.Intn(15))
+			line.Discount = roundCents(gross * line.DiscountValue / 100)
+		}
+		line.LineTotal = roundCents(gross - line.Discount)
+		lines[i] = line
+	}
+	return lines
+}
+
+// seedTotals returns the VAT-inclusive total of lines with its net and VAT parts
+func seedTotals(lines []models.QuotationItem) (total, net, vat float64) {
+	for _, line := range lines {
+		total += line.LineTotal
+	}
+	total = roundCents(total)
+	net = roundCents(total / (1 + seedVATRate/100.0))
+	return total, net, roundCents(total - net)
+}
+
+// seedDate picks a business-hours time within the last days
+func seedDate(rng *rand.Rand, days int) time.Time {
+	day := time.Now().AddDate(0, 0, -rng.Intn(days))
+	return time.Date(day.Year(), day.Month(), day.Day(), 8+rng.Intn(10), rng.Intn(60), 0, 0, time.Local)
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
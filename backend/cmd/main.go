@@ -1,13 +1,20 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/Cezzyy/SCMS/backend/internal/config"
 	"github.com/Cezzyy/SCMS/backend/internal/database"
 	"github.com/Cezzyy/SCMS/backend/internal/handlers"
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
 	"github.com/Cezzyy/SCMS/backend/internal/repository"
 	"github.com/Cezzyy/SCMS/backend/internal/services"
 	"github.com/labstack/echo/v4"
@@ -16,24 +23,46 @@ import (
 )
 
 func main() {
+	// Load configuration before anything reads the environment
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
 	e := echo.New()
 	// Initialize database connection
 	db, err := database.Connect()
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
-	defer db.Close()
+
+	// Apply pending schema migrations
+	if err := database.Migrate(db); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	// Request bodies are checked against their validate struct tags
+	e.Validator = handlers.NewRequestValidator()
+
+	// Panics and 5xx errors are sent to Sentry or GlitchTip when SENTRY_DSN is set
+	errorReporter, err := services.NewErrorReporter(services.ErrorReporterConfigFromEnv())
+	if err != nil {
+		log.Fatalf("Failed to configure error reporting: %v", err)
+	}
+
+	// Errors returned by handlers and middleware are written as {"error", "code", "details"}
+	e.HTTPErrorHandler = handlers.ReportingErrorHandler(errorReporter)
 
 	// Middleware
 	e.Use(middleware.Logger())
-	e.Use(middleware.Recover())
+	e.Use(middleware.RecoverWithConfig(middleware.RecoverConfig{LogErrorFunc: handlers.RecoverPanic}))
 
 	// CORS configuration - Must specify exact origins when using credentials
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins:     []string{"http://localhost:5173", "http://localhost:5174"},
+		AllowOrigins:     cfg.CORSOrigins,
 		AllowMethods:     []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch, http.MethodOptions},
-		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization},
-		ExposeHeaders:    []string{"Content-Length", "Content-Type"},
+		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, appmw.DeviceTokenHeader, appmw.ImpersonationTokenHeader, appmw.APIKeyHeader},
+		ExposeHeaders:    []string{"Content-Length", "Content-Type", appmw.ImpersonatingHeader, "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After"},
 		AllowCredentials: true,
 		MaxAge:           3600,
 	}))
@@ -48,21 +77,25 @@ func main() {
 	}))
 
 	// Initialize PDF generator service
-	templatesDir := "C:\\Users\\Desktop\\SCMS\\backend\\cmd\\templates"
-	cssDir := "C:\\Users\\Desktop\\SCMS\\backend\\cmd\\templates\\css"
+	templatesDir := cfg.TemplatesDir
+	cssDir := cfg.CSSDir
 
 	// Log the actual paths for debugging
-	log.Printf("Templates directory (fixed): %s", templatesDir)
-	log.Printf("CSS directory (fixed): %s", cssDir)
+	log.Printf("Templates directory: %s", templatesDir)
+	log.Printf("CSS directory: %s", cssDir)
 
 	// Ensure all template directories exist
-	err = services.EnsureTemplateDirectories(templatesDir, "css", "quotation")
-	if err != nil {
-		log.Printf("Warning: Failed to create template directories: %v", err)
+	for _, dir := range []string{"quotation", "picking_list", "label", "manifest", "receipt", "z_report"} {
+		if err := services.EnsureTemplateDirectories(templatesDir, "css", dir); err != nil {
+			log.Printf("Warning: Failed to create template directories: %v", err)
+		}
 	}
 
-	// Detect wkhtmltopdf location
-	wkhtmltopdfPath := "C:\\Program Files\\wkhtmltopdf\\bin\\wkhtmltopdf.exe"
+	// Detect wkhtmltopdf location unless it is configured
+	wkhtmltopdfPath := cfg.WkhtmltopdfPath
+	if wkhtmltopdfPath == "" {
+		wkhtmltopdfPath = services.DetectWkhtmltopdfPath()
+	}
 	log.Printf("Using wkhtmltopdf from: %s", wkhtmltopdfPath)
 
 	// Create PDF generator service
@@ -77,19 +110,280 @@ func main() {
 	orderRepo := repository.NewOrderRepository(db)
 	reportRepo := repository.NewReportRepository(db)
 	userRepo := repository.NewUserRepository(db)
+	integrationRepo := repository.NewIntegrationRepository(db)
+	printJobRepo := repository.NewPrintJobRepository(db)
+	syncRepo := repository.NewSyncRepository(db)
+	deviceRepo := repository.NewDeviceRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	impersonationRepo := repository.NewImpersonationRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	deletedRecordRepo := repository.NewDeletedRecordRepository(db)
+	specSchemaRepo := repository.NewSpecSchemaRepository(db)
+	productHistoryRepo := repository.NewProductHistoryRepository(db)
+	certificationRepo := repository.NewCertificationRepository(db)
+	safetyStandardRepo := repository.NewSafetyStandardRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	productRuleRepo := repository.NewCustomerProductRuleRepository(db)
+	loyaltyTierRepo := repository.NewLoyaltyTierRepository(db)
+	referenceDataRepo := repository.NewReferenceDataRepository(db)
+	industryRepo := repository.NewIndustryRepository(db)
+	loginAttemptRepo := repository.NewLoginAttemptRepository(db)
+	freightRepo := repository.NewFreightRepository(db)
+	vehicleRepo := repository.NewVehicleRepository(db)
+	driverRepo := repository.NewDriverRepository(db)
+	deliveryRepo := repository.NewDeliveryRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	receivingRepo := repository.NewReceivingRepository(db)
+	supplierRepo := repository.NewSupplierRepository(db)
+	purchaseOrderRepo := repository.NewPurchaseOrderRepository(db)
+	settingRepo := repository.NewSettingRepository(db)
+	supplierInvoiceRepo := repository.NewSupplierInvoiceRepository(db)
+	purchaseBudgetRepo := repository.NewPurchaseBudgetRepository(db)
+	adjustmentRepo := repository.NewInvoiceAdjustmentRepository(db)
+	invoiceRepo := repository.NewInvoiceRepository(db)
+	shiftRepo := repository.NewShiftRepository(db)
+	paymentRepo := repository.NewPaymentRepository(db)
+	checkRepo := repository.NewPostDatedCheckRepository(db)
+	bankStatementRepo := repository.NewBankStatementRepository(db)
+	currencyRepo := repository.NewCurrencyRepository(db)
+	quantityBreakRepo := repository.NewQuantityBreakRepository(db)
+	emailDeliveryRepo := repository.NewEmailDeliveryRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	quotationBuilderRepo := repository.NewQuotationBuilderRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	reportScheduleRepo := repository.NewReportScheduleRepository(db)
+	jobRepo := repository.NewJobRepository(db)
+	trashRepo := repository.NewTrashRepository(db)
+	watchRepo := repository.NewWatchRepository(db)
+	quoteRequestRepo := repository.NewQuoteRequestRepository(db)
+	downloadLinkRepo := repository.NewDownloadLinkRepository(db)
+	documentPreviewRepo := repository.NewDocumentPreviewRepository(db)
+	documentScanRepo := repository.NewDocumentScanRepository(db)
+	anomalyRepo := repository.NewAnomalyRepository(db)
+	availabilityRepo := repository.NewAvailabilityRepository(db)
+
+	// Cache product and customer lookups, such as those of every quotation PDF, when CACHE is
+	// set; repositories that change those rows invalidate the cached copies
+	if cache := services.NewCacheFromEnv(); cache != nil {
+		productRepo.UseCache(cache)
+		customerRepo.UseCache(cache)
+		purchaseOrderRepo.UseCache(cache)
+		loyaltyTierRepo.UseCache(cache)
+		industryRepo.UseCache(cache)
+		deletedRecordRepo.UseCache(cache)
+	}
 
 	// Initialize auth service
-	authService := services.NewAuthService(userRepo)
+	authService := services.NewAuthService(userRepo, sessionRepo, loginAttemptRepo)
+
+	// Initialize device registration for tablets and scanners, which sync stock and orders and
+	// print labels but act for no user
+	deviceService := services.NewDeviceService(deviceRepo)
+	e.Use(appmw.DeviceAuth(deviceService, "/api/sync", "/api/printers", "/api/print-jobs", "/api/devices"))
+
+	// Initialize admin impersonation; actions taken while impersonating are audited
+	impersonationService := services.NewImpersonationService(authService, userRepo, impersonationRepo, auditRepo)
+	e.Use(appmw.Impersonation(impersonationService, auditRepo))
+
+	// Initialize API keys with per-key daily quotas for external integrators
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo)
+	e.Use(appmw.APIKeyAuth(apiKeyService))
+
+	// Require a login session (or one of the credentials above) on every /api route
+	publicPaths := []string{"/api/auth/login", "/api/health", "/api/docs", "/api/openapi.json", "/api/webhooks/payments", "/api/public/quote-requests", "/api/public/downloads"}
+	e.Use(appmw.SessionAuth(authService, publicPaths...))
+
+	// Rate limit the API per user (or per IP before login), and login attempts per IP more strictly
+	rateLimiter := services.NewRateLimiterFromEnv()
+	e.Use(appmw.APIRateLimit(rateLimiter, "/api/health", "/api/auth/login", "/api/public/quote-requests"))
+
+	// Slow down and fail requests on purpose when fault injection is enabled, in staging only
+	if cfg.Faults.Enabled {
+		log.Printf("WARNING: fault injection is enabled; %.0f%% of requests are delayed and %.0f%% fail on purpose",
+			cfg.Faults.LatencyRate*100, cfg.Faults.ErrorRate*100)
+	}
+	e.Use(appmw.FaultInjection(cfg.Faults))
+
+	// Viewers get read-only access
+	e.Use(appmw.ReadOnlyRoles([]string{models.RoleViewer}, "/api/auth/logout", "/api/auth/refresh", "/api/impersonation"))
+
+	// Initialize technical spec validation and product history
+	productSpecService := services.NewProductSpecService(specSchemaRepo, productHistoryRepo)
+
+	// Initialize zone and weight based freight estimation
+	freightService := services.NewFreightService(freightRepo, productRepo)
+
+	// Initialize the business rules: thresholds admins tune, read by the services below
+	rulesService := services.NewRulesService(settingRepo)
+
+	// Initialize supplier comparison and purchase order generation
+	purchasingService := services.NewPurchasingService(supplierRepo, purchaseOrderRepo, inventoryRepo, productRepo, settingRepo)
+	invoiceMatchService := services.NewInvoiceMatchService(supplierInvoiceRepo, purchaseOrderRepo, rulesService)
+	bankReconciliationService := services.NewBankReconciliationService(bankStatementRepo, rulesService)
+
+	// Initialize the monthly sales book for BIR filing
+	salesBookService := services.NewSalesBookService(reportRepo, rulesService)
+
+	// Initialize the dashboard summary, reused for DASHBOARD_CACHE_SECONDS between requests
+	dashboardService := services.NewDashboardServiceFromEnv(reportRepo)
+
+	// Initialize storage for uploaded files such as proof of delivery images
+	attachmentService := services.NewAttachmentService(attachmentRepo)
+
+	// Initialize captcha checks for the public quote request form
+	captchaVerifier := services.NewCaptchaVerifierFromEnv()
+
+	// Initialize links for sharing documents outside the app, such as in emails
+	downloadLinkService := services.NewDownloadLinkService(downloadLinkRepo)
+	if !captchaVerifier.Enabled() {
+		log.Printf("Warning: CAPTCHA_SECRET is not set; public quote requests are only rate limited")
+	}
+
+	// Initialize currencies and exchange rates for documents written in foreign currencies
+	currencyService := services.NewCurrencyService(currencyRepo)
+
+	// Initialize line discounts: entered percentages and amounts, quantity breaks and tier discounts
+	discountService := services.NewDiscountService(quantityBreakRepo)
+
+	// Initialize loyalty tier pricing and the scheduled tier recalculation
+	pricingService := services.NewPricingService(customerRepo, loyaltyTierRepo, orderRepo, freightService, rulesService, currencyService, discountService)
+	tierService := services.NewTierService(loyaltyTierRepo)
+	tierService.Start()
+
+	// Promise quotation lines from stock, incoming purchase orders and supplier lead times
+	promiseService := services.NewPromiseService(availabilityRepo, supplierRepo, rulesService)
+
+	// Initialize Slack/Teams notifications for key business events
+	chatNotifier := services.NewChatNotifier(services.ChatNotifierConfigFromEnv(), rulesService)
+	pdfGenerator.OnResult(chatNotifier.RecordPDFResult)
+
+	// Initialize online payment gateways whose webhooks record payments
+	paymentGateways := services.NewPaymentGatewaysFromEnv()
+
+	// Initialize email delivery of documents through the configured SMTP server
+	emailService, err := services.NewEmailService(services.EmailConfigFromEnv())
+	if err != nil {
+		log.Fatalf("Failed to configure email: %v", err)
+	}
+
+	// Initialize the background job queue; job types are registered before it starts, which
+	// is once the handlers rendering the PDFs previews are made of exist
+	jobQueue := services.NewJobQueue(jobRepo)
+	jobQueue.Register(services.JobSendEmail, emailService.SendJob)
+
+	// Make previews of attachments as they are stored and of PDFs when first asked for
+	previewService := services.NewPreviewServiceFromEnv(documentPreviewRepo, jobQueue, attachmentService)
+	jobQueue.Register(services.JobGeneratePreview, previewService.GenerateJob)
+	attachmentService.OnSave(previewService.AttachmentSaved)
+
+	// Read uploaded supplier invoices and customer POs with the OCR provider named by OCR
+	documentScanService := services.NewDocumentScanService(documentScanRepo, attachmentService, jobQueue, services.NewOCRProviderFromEnv())
+	jobQueue.Register(services.JobScanDocument, documentScanService.ScanJob)
+
+	// Notify users watching customers and quotations of audited changes to them
+	watchService := services.NewWatchService(watchRepo, notificationRepo, emailService, jobQueue)
+	auditRepo.OnCreate(watchService.RecordChanged)
+
+	// Email reports on the schedules admins define
+	reportScheduler := services.NewReportScheduler(reportScheduleRepo, reportRepo, emailService)
+	reportScheduler.Start()
+
+	// Initialize reminders of post-dated checks about to mature
+	checkReminderService := services.NewCheckReminderService(checkRepo, chatNotifier, rulesService)
+	checkReminderService.Start()
+
+	// Flag sales far below their trend and stock that disappears without orders taking it
+	anomalyService := services.NewAnomalyService(anomalyRepo, notificationRepo, rulesService)
+	anomalyService.Start()
+
+	// Delete abandoned quotation builder sessions
+	quotationBuilderCleaner := services.NewQuotationBuilderCleaner(quotationBuilderRepo)
+	quotationBuilderCleaner.Start()
+
+	// Purge deleted customers, contacts and products once they have been in the trash long enough
+	trashPurger := services.NewTrashPurger(trashRepo, customerRepo, contactRepo, productRepo)
+	trashPurger.Start()
+
+	// Initialize signed webhook callbacks for order, quotation and stock events
+	webhookService := services.NewWebhookService(webhookRepo)
+	webhookService.Start()
+
+	// Initialize Google Drive/OneDrive archiving of generated PDFs
+	documentArchiver := services.NewDocumentArchiverFromEnv(integrationRepo)
+
+	// Initialize optional geocoding of customer addresses
+	geocodingService := services.NewGeocodingService(services.NewGeocoderFromEnv(), customerRepo)
+
+	// Initialize the warehouse print queue
+	printService := services.NewPrintServiceFromEnv(printJobRepo)
+	printService.Start()
 
 	// Initialize handlers
-	customerHandler := handlers.NewCustomerHandler(customerRepo)
+	authHandler := handlers.NewAuthHandler(authService)
+	customerHandler := handlers.NewCustomerHandler(customerRepo, industryRepo, geocodingService, auditRepo)
 	contactHandler := handlers.NewContactHandler(contactRepo, customerRepo)
-	productHandler := handlers.NewProductHandler(productRepo)
-	inventoryHandler := handlers.NewInventoryHandler(inventoryRepo, productRepo)
-	quotationHandler := handlers.NewQuotationHandler(quotationRepo, customerRepo, productRepo, pdfGenerator)
-	orderHandler := handlers.NewOrderHandler(orderRepo)
-	reportHandler := handlers.NewReportHandler(reportRepo)
-	userHandler := handlers.NewUserHandler(userRepo)
+	productHandler := handlers.NewProductHandler(productRepo, productHistoryRepo, productSpecService, auditRepo)
+	inventoryHandler := handlers.NewInventoryHandler(inventoryRepo, productRepo, chatNotifier, auditRepo, notificationRepo, webhookService)
+	quotationHandler := handlers.NewQuotationHandler(quotationRepo, customerRepo, productRepo, productRuleRepo, pdfGenerator, chatNotifier, documentArchiver, pricingService, auditRepo, rulesService, currencyService, contactRepo, emailService, emailDeliveryRepo, notificationRepo, quotationBuilderRepo, quotationBuilderCleaner, webhookService, quoteRequestRepo, promiseService)
+	orderHandler := handlers.NewOrderHandler(orderRepo, customerRepo, productRepo, productRuleRepo, chatNotifier, pricingService, auditRepo, pdfGenerator, shiftRepo, paymentRepo, currencyService, notificationRepo, webhookService, documentScanRepo)
+	reportHandler := handlers.NewReportHandler(reportRepo, salesBookService, dashboardService)
+	userHandler := handlers.NewUserHandler(userRepo, auditRepo)
+	integrationHandler := handlers.NewIntegrationHandler(documentArchiver)
+	printHandler := handlers.NewPrintHandler(printService, printJobRepo, orderRepo, customerRepo, productRepo, inventoryRepo, pdfGenerator)
+	syncHandler := handlers.NewSyncHandler(syncRepo, productRepo, inventoryRepo, orderRepo, customerRepo, productRuleRepo, productSpecService, pricingService)
+	deviceHandler := handlers.NewDeviceHandler(deviceRepo, deviceService)
+	impersonationHandler := handlers.NewImpersonationHandler(impersonationService)
+	auditHandler := handlers.NewAuditHandler(auditRepo)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyRepo, apiKeyService)
+	bulkHandler := handlers.NewBulkHandler(deletedRecordRepo, productRepo, customerRepo, auditRepo)
+	specSchemaHandler := handlers.NewSpecSchemaHandler(specSchemaRepo)
+	productRuleHandler := handlers.NewCustomerProductRuleHandler(productRuleRepo, customerRepo, productRepo)
+	loyaltyHandler := handlers.NewLoyaltyHandler(loyaltyTierRepo, tierService, pricingService)
+	complianceHandler := handlers.NewComplianceHandler(certificationRepo, safetyStandardRepo, productRepo)
+	referenceDataHandler := handlers.NewReferenceDataHandler(referenceDataRepo)
+	industryHandler := handlers.NewIndustryHandler(industryRepo)
+	freightHandler := handlers.NewFreightHandler(freightRepo, customerRepo, freightService)
+	shiftHandler := handlers.NewShiftHandler(shiftRepo, pdfGenerator)
+	paymentHandler := handlers.NewPaymentHandler(paymentRepo, orderRepo, adjustmentRepo, invoiceRepo, customerRepo, paymentGateways)
+	checkHandler := handlers.NewPostDatedCheckHandler(checkRepo, orderRepo, checkReminderService)
+	bankReconciliationHandler := handlers.NewBankReconciliationHandler(bankStatementRepo, bankReconciliationService)
+	dispatchHandler := handlers.NewDispatchHandler(vehicleRepo, driverRepo, deliveryRepo, orderRepo, pdfGenerator)
+	podHandler := handlers.NewProofOfDeliveryHandler(orderRepo, attachmentService, auditRepo, productRepo, chatNotifier, notificationRepo, webhookService)
+	receivingHandler := handlers.NewReceivingHandler(receivingRepo, inventoryRepo, productRepo, auditRepo)
+	supplierHandler := handlers.NewSupplierHandler(supplierRepo, productRepo, purchasingService)
+	purchaseOrderHandler := handlers.NewPurchaseOrderHandler(purchaseOrderRepo, supplierRepo, purchasingService, invoiceMatchService)
+	supplierInvoiceHandler := handlers.NewSupplierInvoiceHandler(supplierInvoiceRepo, purchaseOrderRepo, invoiceMatchService, documentScanRepo, auditRepo)
+	purchaseBudgetHandler := handlers.NewPurchaseBudgetHandler(purchaseBudgetRepo)
+	adjustmentHandler := handlers.NewInvoiceAdjustmentHandler(adjustmentRepo, customerRepo, auditRepo)
+	invoiceHandler := handlers.NewInvoiceHandler(invoiceRepo, orderRepo, customerRepo, auditRepo, pdfGenerator, documentArchiver, currencyService)
+	ruleHandler := handlers.NewRuleHandler(rulesService, auditRepo)
+	templateHandler := handlers.NewTemplateHandler(pdfGenerator, auditRepo)
+	currencyHandler := handlers.NewCurrencyHandler(currencyService, auditRepo)
+	discountHandler := handlers.NewDiscountHandler(discountService, productRepo, auditRepo)
+	adminHandler := handlers.NewAdminHandler()
+	notificationHandler := handlers.NewNotificationHandler(notificationRepo)
+	webhookHandler := handlers.NewWebhookHandler(webhookRepo, webhookService)
+	reportScheduleHandler := handlers.NewReportScheduleHandler(reportScheduleRepo, reportScheduler, emailService)
+	jobHandler := handlers.NewJobHandler(jobRepo, jobQueue)
+	quotationSLAHandler := handlers.NewQuotationSLAHandler(quotationRepo, rulesService)
+	quoteRequestHandler := handlers.NewQuoteRequestHandler(quoteRequestRepo, attachmentService, captchaVerifier, chatNotifier, notificationRepo, auditRepo)
+	downloadLinkHandler := handlers.NewDownloadLinkHandler(downloadLinkService, downloadLinkRepo, auditRepo, quotationHandler, orderHandler, invoiceHandler, podHandler)
+	previewHandler := handlers.NewPreviewHandler(previewService, documentPreviewRepo)
+	documentScanHandler := handlers.NewDocumentScanHandler(documentScanService, documentScanRepo, auditRepo)
+	anomalyHandler := handlers.NewAnomalyHandler(anomalyRepo)
+	trashHandler := handlers.NewTrashHandler(trashRepo, trashPurger)
+	watchHandler := handlers.NewWatchHandler(watchRepo, customerRepo, quotationRepo)
+	searchHandler := handlers.NewSearchHandler(customerRepo, contactRepo, productRepo, quotationRepo, orderRepo)
+
+	// Previews of generated PDFs are made of what their download serves; with every source
+	// set, background jobs can start
+	previewService.Source(models.DownloadQuotation, handlers.PreviewSource(e, quotationHandler.GenerateQuotationPDF))
+	previewService.Source(models.DownloadOrder, handlers.PreviewSource(e, orderHandler.GetOrderPDF))
+	previewService.Source(models.DownloadInvoice, handlers.PreviewSource(e, invoiceHandler.GetInvoicePDF))
+	jobQueue.Start()
+
+	// Destructive routes and user/admin management are restricted to admins
+	adminOnly := appmw.RequireRole(models.RoleAdmin)
 
 	// API Routes
 	// Health check
@@ -100,25 +394,101 @@ func main() {
 	})
 
 	// Auth routes
-	e.POST("/api/auth/login", func(c echo.Context) error {
-		var req services.LoginRequest
-		if err := c.Bind(&req); err != nil {
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
-		}
-		resp, err := authService.Login(c.Request().Context(), req)
-		if err != nil {
-			return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
-		}
-		return c.JSON(http.StatusOK, resp)
-	})
+	e.POST("/api/auth/login", authHandler.Login, appmw.LoginRateLimit(rateLimiter))
+	e.POST("/api/auth/logout", authHandler.Logout)
+	e.POST("/api/auth/refresh", authHandler.Refresh)
+	e.GET("/api/auth/session", authHandler.GetSession)
+
+	// Public "Request a Quote" form of the marketing site, rate limited per IP; add the site to CORS_ORIGINS
+	e.POST("/api/public/quote-requests", quoteRequestHandler.SubmitQuoteRequest, appmw.QuoteRequestRateLimit(rateLimiter), quoteRequestHandler.BodyLimit())
+
+	// Quote requests from the public form, followed up by the sales team
+	e.GET("/api/quote-requests", quoteRequestHandler.GetQuoteRequests)
+	e.GET("/api/quote-requests/:id", quoteRequestHandler.GetQuoteRequest)
+	e.POST("/api/quote-requests/:id/status", quoteRequestHandler.UpdateQuoteRequestStatus)
+	e.POST("/api/quote-requests/:id/quotation", quotationHandler.CreateQuotationFromRequest)
+
+	// Links for sharing document PDFs and attachments outside the app; downloads need no login
+	e.GET("/api/public/downloads", downloadLinkHandler.Download)
+	e.GET("/api/download-links", downloadLinkHandler.GetDownloadLinks)
+	e.POST("/api/download-links", downloadLinkHandler.CreateDownloadLink)
+	e.POST("/api/download-links/:id/revoke", downloadLinkHandler.RevokeDownloadLink)
+	e.GET("/api/previews/:type/:id", previewHandler.GetPreview)
+	e.GET("/api/document-scans", documentScanHandler.GetDocumentScans)
+	e.POST("/api/document-scans", documentScanHandler.UploadDocumentScan)
+	e.GET("/api/document-scans/:id", documentScanHandler.GetDocumentScan)
+	e.PUT("/api/document-scans/:id", documentScanHandler.UpdateDocumentScan)
+	e.POST("/api/document-scans/:id/discard", documentScanHandler.DiscardDocumentScan)
+	e.POST("/api/document-scans/:id/supplier-invoice", supplierInvoiceHandler.CreateSupplierInvoiceFromScan)
+	e.POST("/api/document-scans/:id/order", orderHandler.CreateOrderFromScan)
+
+	// Reference data for dropdowns
+	e.GET("/api/reference-data", referenceDataHandler.GetReferenceData)
+
+	// Notifications for the signed-in user
+	e.GET("/api/notifications", notificationHandler.GetNotifications)
+	e.GET("/api/notifications/summary", notificationHandler.GetNotificationSummary)
+	e.POST("/api/notifications/:id/read", notificationHandler.MarkNotificationRead)
+	e.POST("/api/notifications/read-all", notificationHandler.MarkAllNotificationsRead)
+
+	// Global typeahead search
+	e.GET("/api/search", searchHandler.Search)
 
 	// Customer routes
 	e.GET("/api/customers", customerHandler.GetAllCustomers)
 	e.GET("/api/customers/:id", customerHandler.GetCustomerByID)
 	e.POST("/api/customers", customerHandler.CreateCustomer)
 	e.PUT("/api/customers/:id", customerHandler.UpdateCustomer)
-	e.DELETE("/api/customers/:id", customerHandler.DeleteCustomer)
+	e.DELETE("/api/customers/:id", customerHandler.DeleteCustomer, adminOnly)
+	e.GET("/api/customers/:id/dependencies", customerHandler.GetCustomerDependencies)
+	e.GET("/api/customers/:id/changes", auditHandler.GetCustomerChanges)
+	e.PUT("/api/customers/:id/watch", watchHandler.WatchCustomer)
+	e.DELETE("/api/customers/:id/watch", watchHandler.UnwatchCustomer)
+	e.POST("/api/customers/:id/archive", customerHandler.ArchiveCustomer)
+	e.POST("/api/customers/:id/unarchive", customerHandler.UnarchiveCustomer)
+	e.POST("/api/customers/:id/restore", customerHandler.RestoreCustomer)
+	e.DELETE("/api/customers/:id/purge", customerHandler.PurgeCustomer, adminOnly)
 	e.GET("/api/customers/check", customerHandler.CheckCompanyExists)
+	e.GET("/api/customers/locations", customerHandler.GetCustomerLocations)
+	e.POST("/api/customers/:id/geocode", customerHandler.GeocodeCustomer)
+	e.PUT("/api/customers/:id/location", customerHandler.SetCustomerLocation)
+	e.POST("/api/customers/bulk-delete", bulkHandler.BulkDeleteCustomers, adminOnly)
+	e.POST("/api/customers/bulk-restore", bulkHandler.BulkRestoreCustomers)
+	e.GET("/api/customers/deleted", bulkHandler.GetDeletedCustomers)
+	e.GET("/api/customers/:id/product-rules", productRuleHandler.GetProductRules)
+	e.PUT("/api/customers/:id/product-rules/:product_id", productRuleHandler.SaveProductRule)
+	e.DELETE("/api/customers/:id/product-rules/:product_id", productRuleHandler.DeleteProductRule)
+
+	// Industry routes
+	e.GET("/api/industries", industryHandler.GetIndustries)
+	e.POST("/api/industries", industryHandler.CreateIndustry)
+	e.PUT("/api/industries/:id", industryHandler.UpdateIndustry)
+	e.DELETE("/api/industries/:id", industryHandler.DeleteIndustry, adminOnly)
+
+	// Freight routes
+	e.GET("/api/delivery-zones", freightHandler.GetDeliveryZones)
+	e.PUT("/api/delivery-zones/:zone", freightHandler.SaveDeliveryZone, adminOnly)
+	e.DELETE("/api/delivery-zones/:zone", freightHandler.DeleteDeliveryZone, adminOnly)
+	e.GET("/api/freight-rates", freightHandler.GetFreightRates)
+	e.POST("/api/freight-rates", freightHandler.CreateFreightRate, adminOnly)
+	e.PUT("/api/freight-rates/:id", freightHandler.UpdateFreightRate, adminOnly)
+	e.DELETE("/api/freight-rates/:id", freightHandler.DeleteFreightRate, adminOnly)
+	e.POST("/api/freight/estimate", freightHandler.EstimateFreight)
+
+	// Dispatch routes
+	e.GET("/api/vehicles", dispatchHandler.GetVehicles)
+	e.POST("/api/vehicles", dispatchHandler.CreateVehicle)
+	e.PUT("/api/vehicles/:id", dispatchHandler.UpdateVehicle)
+	e.DELETE("/api/vehicles/:id", dispatchHandler.DeleteVehicle, adminOnly)
+	e.GET("/api/drivers", dispatchHandler.GetDrivers)
+	e.POST("/api/drivers", dispatchHandler.CreateDriver)
+	e.PUT("/api/drivers/:id", dispatchHandler.UpdateDriver)
+	e.DELETE("/api/drivers/:id", dispatchHandler.DeleteDriver, adminOnly)
+	e.GET("/api/drivers/:id/manifest", dispatchHandler.GetDriverManifest)
+	e.GET("/api/drivers/:id/manifest/pdf", dispatchHandler.GetDriverManifestPDF)
+	e.GET("/api/deliveries", dispatchHandler.GetDeliveries)
+	e.POST("/api/deliveries", dispatchHandler.AssignDelivery)
+	e.DELETE("/api/deliveries/:id", dispatchHandler.UnassignDelivery)
 
 	// Contact routes - scoped under customer
 	e.GET("/api/customers/:customer_id/contacts", contactHandler.GetContactsByCustomer)
@@ -131,13 +501,57 @@ func main() {
 	e.GET("/api/contacts", contactHandler.GetAllContacts)
 	e.GET("/api/contacts/:id", contactHandler.GetContactByID)
 	e.GET("/api/contacts/check", contactHandler.CheckEmailExists)
+	e.POST("/api/contacts/:id/restore", contactHandler.RestoreContact)
+	e.DELETE("/api/contacts/:id/purge", contactHandler.PurgeContact, adminOnly)
 
 	// Product routes
 	e.GET("/api/products", productHandler.GetAllProducts)
 	e.GET("/api/products/:id", productHandler.GetProductByID)
 	e.POST("/api/products", productHandler.CreateProduct)
+	e.POST("/api/products/import", productHandler.ImportProducts)
 	e.PUT("/api/products/:id", productHandler.UpdateProduct)
-	e.DELETE("/api/products/:id", productHandler.DeleteProduct)
+	e.DELETE("/api/products/:id", productHandler.DeleteProduct, adminOnly)
+	e.GET("/api/products/:id/changes", auditHandler.GetProductChanges)
+	e.GET("/api/products/:id/history", productHandler.GetProductHistory)
+	e.POST("/api/products/:id/discontinue", productHandler.DiscontinueProduct)
+	e.POST("/api/products/:id/reinstate", productHandler.ReinstateProduct)
+	e.POST("/api/products/:id/restore", productHandler.RestoreProduct)
+	e.DELETE("/api/products/:id/purge", productHandler.PurgeProduct, adminOnly)
+	e.POST("/api/products/bulk-delete", bulkHandler.BulkDeleteProducts, adminOnly)
+	e.POST("/api/products/bulk-restore", bulkHandler.BulkRestoreProducts)
+	e.GET("/api/products/deleted", bulkHandler.GetDeletedProducts)
+
+	// Trash routes
+	e.GET("/api/trash", trashHandler.GetTrash)
+
+	// Watch routes
+	e.GET("/api/watches", watchHandler.GetWatches)
+
+	// Product compliance routes
+	e.GET("/api/products/:id/certifications", complianceHandler.GetProductCertifications)
+	e.PUT("/api/products/:id/certifications/:certification_id", complianceHandler.SetProductCertification)
+	e.DELETE("/api/products/:id/certifications/:certification_id", complianceHandler.RemoveProductCertification)
+	e.GET("/api/products/:id/safety-standards", complianceHandler.GetProductSafetyStandards)
+	e.PUT("/api/products/:id/safety-standards/:safety_standard_id", complianceHandler.AddProductSafetyStandard)
+	e.DELETE("/api/products/:id/safety-standards/:safety_standard_id", complianceHandler.RemoveProductSafetyStandard)
+
+	// Certification and safety standard reference routes
+	e.GET("/api/certifications", complianceHandler.GetCertifications)
+	e.GET("/api/certifications/:id", complianceHandler.GetCertification)
+	e.POST("/api/certifications", complianceHandler.CreateCertification)
+	e.PUT("/api/certifications/:id", complianceHandler.UpdateCertification)
+	e.DELETE("/api/certifications/:id", complianceHandler.DeleteCertification, adminOnly)
+	e.GET("/api/safety-standards", complianceHandler.GetSafetyStandards)
+	e.GET("/api/safety-standards/:id", complianceHandler.GetSafetyStandard)
+	e.POST("/api/safety-standards", complianceHandler.CreateSafetyStandard)
+	e.PUT("/api/safety-standards/:id", complianceHandler.UpdateSafetyStandard)
+	e.DELETE("/api/safety-standards/:id", complianceHandler.DeleteSafetyStandard, adminOnly)
+
+	// Technical spec schema routes
+	e.GET("/api/spec-schemas", specSchemaHandler.GetSpecSchemas)
+	e.GET("/api/spec-schemas/:category", specSchemaHandler.GetSpecSchema)
+	e.PUT("/api/spec-schemas/:category", specSchemaHandler.SaveSpecSchema)
+	e.DELETE("/api/spec-schemas/:category", specSchemaHandler.DeleteSpecSchema, adminOnly)
 
 	// Inventory routes
 	e.GET("/api/inventory", inventoryHandler.GetAllInventory)
@@ -146,46 +560,321 @@ func main() {
 	e.POST("/api/inventory", inventoryHandler.CreateInventory)
 	e.PUT("/api/inventory/:id", inventoryHandler.UpdateInventory)
 	e.PUT("/api/inventory/:id/stock", inventoryHandler.UpdateStock)
-	e.DELETE("/api/inventory/:id", inventoryHandler.DeleteInventory)
+	e.DELETE("/api/inventory/:id", inventoryHandler.DeleteInventory, adminOnly)
 
 	// Low stock routes
 	e.GET("/api/inventory/low-stock", inventoryHandler.GetLowStockItems)
 	e.GET("/api/inventory/low-stock/details", inventoryHandler.GetLowStockWithProductInfo)
 
+	// Receiving inspection routes
+	e.GET("/api/receiving-checklist", receivingHandler.GetChecklist)
+	e.POST("/api/receiving-checklist", receivingHandler.CreateChecklistItem, adminOnly)
+	e.PUT("/api/receiving-checklist/:id", receivingHandler.UpdateChecklistItem, adminOnly)
+	e.DELETE("/api/receiving-checklist/:id", receivingHandler.DeleteChecklistItem, adminOnly)
+	e.GET("/api/inventory/:id/receipts", receivingHandler.GetReceipts)
+	e.POST("/api/inventory/:id/receipts", receivingHandler.CreateReceipt)
+
+	// Supplier and purchasing routes
+	e.GET("/api/suppliers", supplierHandler.GetSuppliers)
+	e.GET("/api/suppliers/:id", supplierHandler.GetSupplier)
+	e.POST("/api/suppliers", supplierHandler.CreateSupplier, adminOnly)
+	e.PUT("/api/suppliers/:id", supplierHandler.UpdateSupplier, adminOnly)
+	e.DELETE("/api/suppliers/:id", supplierHandler.DeleteSupplier, adminOnly)
+	e.GET("/api/suppliers/:id/prices", supplierHandler.GetSupplierPrices)
+	e.PUT("/api/suppliers/:id/prices/:product_id", supplierHandler.SaveSupplierPrice, adminOnly)
+	e.DELETE("/api/suppliers/:id/prices/:product_id", supplierHandler.DeleteSupplierPrice, adminOnly)
+	e.GET("/api/products/:id/supplier-prices", supplierHandler.CompareSupplierPrices)
+
+	// Quantity break routes
+	e.GET("/api/products/:id/quantity-breaks", discountHandler.GetQuantityBreaks)
+	e.PUT("/api/products/:id/quantity-breaks", discountHandler.SetQuantityBreaks, adminOnly)
+	e.GET("/api/purchase-orders", purchaseOrderHandler.GetPurchaseOrders)
+	e.POST("/api/purchase-orders", purchaseOrderHandler.CreatePurchaseOrder)
+	e.POST("/api/purchase-orders/generate", purchaseOrderHandler.GeneratePurchaseOrders)
+	e.GET("/api/purchase-orders/:id", purchaseOrderHandler.GetPurchaseOrder)
+	e.PUT("/api/purchase-orders/:id/status", purchaseOrderHandler.UpdatePurchaseOrderStatus)
+	e.POST("/api/purchase-orders/:id/charges", purchaseOrderHandler.AddPurchaseOrderCharge)
+	e.DELETE("/api/purchase-orders/:id/charges/:charge_id", purchaseOrderHandler.DeletePurchaseOrderCharge)
+	e.GET("/api/purchase-orders/:id/landed-cost", purchaseOrderHandler.GetLandedCost)
+	e.POST("/api/purchase-orders/:id/receive", purchaseOrderHandler.ReceivePurchaseOrder)
+	e.POST("/api/purchase-orders/:id/approve", purchaseOrderHandler.ApprovePurchaseOrder, adminOnly)
+	e.POST("/api/purchase-orders/:id/reject", purchaseOrderHandler.RejectPurchaseOrder, adminOnly)
+	e.GET("/api/settings/cost-policy", purchaseOrderHandler.GetCostPolicy)
+	e.PUT("/api/settings/cost-policy", purchaseOrderHandler.UpdateCostPolicy, adminOnly)
+
+	// Business rule routes
+	e.GET("/api/rules", ruleHandler.GetRules)
+	e.GET("/api/rules/:key", ruleHandler.GetRule)
+	e.PUT("/api/rules/:key", ruleHandler.UpdateRule, adminOnly)
+	e.DELETE("/api/rules/:key", ruleHandler.ResetRule, adminOnly)
+
+	// Document template routes
+	e.GET("/api/templates", templateHandler.GetTemplates, adminOnly)
+	e.GET("/api/templates/*", templateHandler.GetTemplate, adminOnly)
+	e.PUT("/api/templates/*", templateHandler.UpdateTemplate, adminOnly)
+
+	// Currency routes
+	e.GET("/api/currencies", currencyHandler.GetCurrencies)
+	e.GET("/api/currencies/:code/rates", currencyHandler.GetRates)
+	e.PUT("/api/currencies/:code/rates", currencyHandler.SetRate, adminOnly)
+
+	// Admin panel for the operational tasks the frontend does not cover yet
+	e.GET("/admin", adminHandler.GetPanel, adminOnly)
+	e.GET("/admin/*", adminHandler.GetAsset, adminOnly)
+
+	// Department purchasing budget routes
+	e.GET("/api/budgets", purchaseBudgetHandler.GetBudgets)
+	e.GET("/api/budgets/:id", purchaseBudgetHandler.GetBudget)
+	e.POST("/api/budgets", purchaseBudgetHandler.CreateBudget, adminOnly)
+	e.PUT("/api/budgets/:id", purchaseBudgetHandler.UpdateBudget, adminOnly)
+	e.DELETE("/api/budgets/:id", purchaseBudgetHandler.DeleteBudget, adminOnly)
+
+	// Supplier invoice routes
+	e.GET("/api/supplier-invoices", supplierInvoiceHandler.GetSupplierInvoices)
+	e.GET("/api/supplier-invoices/:id", supplierInvoiceHandler.GetSupplierInvoice)
+	e.POST("/api/supplier-invoices", supplierInvoiceHandler.CreateSupplierInvoice)
+	e.DELETE("/api/supplier-invoices/:id", supplierInvoiceHandler.DeleteSupplierInvoice, adminOnly)
+	e.POST("/api/supplier-invoices/:id/match", supplierInvoiceHandler.MatchSupplierInvoice)
+
 	// Quotation routes
 	e.GET("/api/quotations", quotationHandler.GetAllQuotations)
+	e.GET("/api/quotations/follow-ups", quotationSLAHandler.GetFollowUpQueue)
 	e.GET("/api/quotations/:id", quotationHandler.GetQuotationByID)
+	e.GET("/api/quotations/:id/changes", auditHandler.GetQuotationChanges)
+	e.PUT("/api/quotations/:id/watch", watchHandler.WatchQuotation)
+	e.DELETE("/api/quotations/:id/watch", watchHandler.UnwatchQuotation)
 	e.POST("/api/quotations", quotationHandler.CreateQuotation)
+	e.POST("/api/quotations/price-preview", quotationHandler.PreviewQuotationPrice)
+	e.POST("/api/quotations/builder", quotationHandler.StartQuotationBuilder)
+	e.GET("/api/quotations/builder/:id", quotationHandler.GetQuotationBuilder)
+	e.DELETE("/api/quotations/builder/:id", quotationHandler.DiscardQuotationBuilder)
+	e.POST("/api/quotations/builder/:id/lines", quotationHandler.AddQuotationBuilderLine)
+	e.DELETE("/api/quotations/builder/:id/lines/:line_id", quotationHandler.RemoveQuotationBuilderLine)
+	e.POST("/api/quotations/builder/:id/price", quotationHandler.PriceQuotationBuilder)
+	e.POST("/api/quotations/builder/:id/submit", quotationHandler.SubmitQuotationBuilder)
 	e.GET("/api/quotations/:id/pdf", quotationHandler.GenerateQuotationPDF)
 	e.POST("/api/quotations/:id/status", quotationHandler.UpdateQuotationStatus)
+	e.GET("/api/quotations/:id/revisions", quotationHandler.GetQuotationRevisions)
+	e.POST("/api/quotations/:id/revisions", quotationHandler.ReviseQuotation)
+	e.GET("/api/quotations/:id/revisions/diff", quotationHandler.DiffQuotationRevisions)
+	e.POST("/api/quotations/:id/accept", quotationHandler.AcceptQuotation)
+	e.POST("/api/quotations/:id/send", quotationHandler.SendQuotation)
+	e.GET("/api/quotations/:id/deliveries", quotationHandler.GetQuotationDeliveries)
+	e.POST("/api/quotations/:id/sent", quotationHandler.MarkQuotationSent)
+	e.POST("/api/quotations/:id/promise-dates", quotationHandler.PromiseQuotation)
 
 	// Order routes
 	e.GET("/api/orders", orderHandler.GetAllOrders)
 	e.GET("/api/orders/:id", orderHandler.GetOrderByID)
 	e.POST("/api/orders", orderHandler.CreateOrder)
+	e.POST("/api/orders/cash-sale", orderHandler.CreateCashSale)
 	e.PUT("/api/orders/:id", orderHandler.UpdateOrder)
-	e.DELETE("/api/orders/:id", orderHandler.DeleteOrder)
+	e.DELETE("/api/orders/:id", orderHandler.DeleteOrder, adminOnly)
 	e.POST("/api/orders/:id/status", orderHandler.UpdateOrderStatus)
+	e.GET("/api/orders/:id/pdf", orderHandler.GetOrderPDF)
+	e.GET("/api/orders/:id/delivery-note", orderHandler.GetOrderDeliveryNote)
+	e.GET("/api/orders/:id/receipt", orderHandler.GetOrderReceiptPDF)
+	e.GET("/api/orders/:id/receipt/text", orderHandler.GetOrderReceiptText)
+	e.GET("/api/orders/:id/receipt/escpos", orderHandler.GetOrderReceiptESCPOS)
+	e.GET("/api/order-sources", orderHandler.GetOrderSources)
+	e.POST("/api/orders/:id/pod", podHandler.CaptureProofOfDelivery)
+	e.GET("/api/orders/:id/pod", podHandler.GetProofOfDelivery)
+	e.GET("/api/attachments/:id", podHandler.GetAttachment)
+
+	// Payment routes
+	e.GET("/api/payment-methods", paymentHandler.GetPaymentMethods)
+	e.POST("/api/payment-methods", paymentHandler.CreatePaymentMethod, adminOnly)
+	e.PUT("/api/payment-methods/:code", paymentHandler.UpdatePaymentMethod, adminOnly)
+	e.GET("/api/orders/:id/payments", paymentHandler.GetOrderPayments)
+	e.POST("/api/orders/:id/payments", paymentHandler.RecordPayment)
+	e.GET("/api/invoices/:id/payments", paymentHandler.GetInvoicePayments)
+	e.POST("/api/invoices/:id/payments", paymentHandler.RecordInvoicePayment)
+	e.GET("/api/customers/:id/payments", paymentHandler.GetCustomerPayments)
+	e.GET("/api/payments", paymentHandler.GetPayments)
+	e.GET("/api/payments/export", paymentHandler.ExportPaymentsCSV)
+	e.GET("/api/payments/outstanding-2307", paymentHandler.GetOutstanding2307)
+	e.PUT("/api/payments/:id/form-2307", paymentHandler.ReceiveForm2307)
+	e.DELETE("/api/payments/:id/form-2307", paymentHandler.ClearForm2307)
+	e.POST("/api/webhooks/payments", paymentHandler.ReceivePaymentEvent)
+
+	// Invoice routes: delivered orders are billed with an invoice due after the customer's terms
+	e.GET("/api/invoices", invoiceHandler.GetInvoices)
+	e.GET("/api/invoices/:id", invoiceHandler.GetInvoice)
+	e.GET("/api/invoices/:id/pdf", invoiceHandler.GetInvoicePDF)
+	e.GET("/api/orders/:id/invoice", invoiceHandler.GetOrderInvoice)
+	e.POST("/api/orders/:id/invoice", invoiceHandler.CreateOrderInvoice)
+
+	// Invoice adjustment routes: voids and credit notes correct issued sales invoices
+	e.GET("/api/orders/:id/adjustments", adjustmentHandler.GetOrderAdjustments)
+	e.POST("/api/orders/:id/void", adjustmentHandler.RequestVoid)
+	e.POST("/api/orders/:id/credit-notes", adjustmentHandler.RequestCreditNote)
+	e.GET("/api/invoice-adjustments", adjustmentHandler.GetAdjustments)
+	e.GET("/api/invoice-adjustments/:id", adjustmentHandler.GetAdjustment)
+	e.POST("/api/invoice-adjustments/:id/approve", adjustmentHandler.ApproveAdjustment, adminOnly)
+	e.POST("/api/invoice-adjustments/:id/reject", adjustmentHandler.RejectAdjustment, adminOnly)
+	e.GET("/api/customers/:id/balance", adjustmentHandler.GetCustomerBalance)
+
+	// Post-dated check routes
+	e.GET("/api/checks", checkHandler.GetChecks)
+	e.GET("/api/checks/maturing", checkHandler.GetMaturingChecks)
+	e.POST("/api/checks", checkHandler.CreateCheck)
+	e.GET("/api/checks/:id", checkHandler.GetCheck)
+	e.PUT("/api/checks/:id", checkHandler.UpdateCheck)
+	e.PUT("/api/checks/:id/status", checkHandler.UpdateCheckStatus)
+
+	// Bank reconciliation routes
+	e.GET("/api/bank-statements", bankReconciliationHandler.GetStatements)
+	e.POST("/api/bank-statements", bankReconciliationHandler.ImportStatement)
+	e.GET("/api/bank-statements/:id", bankReconciliationHandler.GetStatement)
+	e.DELETE("/api/bank-statements/:id", bankReconciliationHandler.DeleteStatement, adminOnly)
+	e.GET("/api/bank-statements/:id/suggestions", bankReconciliationHandler.GetStatementSuggestions)
+	e.GET("/api/bank-statement-lines/:id/suggestions", bankReconciliationHandler.GetLineSuggestions)
+	e.POST("/api/bank-statement-lines/:id/match", bankReconciliationHandler.ConfirmMatch)
+	e.DELETE("/api/bank-statement-lines/:id/match", bankReconciliationHandler.RemoveMatch)
+
+	// Cashier shift routes
+	e.GET("/api/shifts", shiftHandler.GetShifts)
+	e.GET("/api/shifts/current", shiftHandler.GetCurrentShift)
+	e.POST("/api/shifts", shiftHandler.OpenShift)
+	e.GET("/api/shifts/:id", shiftHandler.GetShift)
+	e.POST("/api/shifts/:id/close", shiftHandler.CloseShift)
+	e.GET("/api/shifts/:id/report", shiftHandler.GetShiftReport)
+	e.GET("/api/shifts/:id/report/pdf", shiftHandler.GetShiftReportPDF)
+	e.GET("/api/shifts/:id/report/export", shiftHandler.ExportShiftReportCSV)
+	e.GET("/api/reports/daily-closing", shiftHandler.GetDailyClosingReport)
+	e.GET("/api/reports/daily-closing/pdf", shiftHandler.GetDailyClosingReportPDF)
+	e.GET("/api/reports/daily-closing/export", shiftHandler.ExportDailyClosingReportCSV)
+
+	// Loyalty tier and pricing routes
+	e.GET("/api/loyalty-tiers", loyaltyHandler.GetTiers)
+	e.PUT("/api/loyalty-tiers/:tier", loyaltyHandler.UpdateTier, adminOnly)
+	e.POST("/api/loyalty-tiers/recalculate", loyaltyHandler.RecalculateTiers, adminOnly)
+	e.POST("/api/pricing/preview", loyaltyHandler.PreviewPricing)
 
 	// Dashboard & Report routes
 	e.GET("/api/dashboard", reportHandler.GetDashboardSummary)
 	e.GET("/api/reports/sales-trends", reportHandler.GetSalesTrends)
 	e.GET("/api/reports/low-stock", reportHandler.GetLowStockItems)
 	e.GET("/api/reports/top-customers", reportHandler.GetTopCustomers)
+	e.GET("/api/reports/sales-by-channel", reportHandler.GetSalesByChannel)
+	e.GET("/api/reports/sales-by-payment-method", reportHandler.GetSalesByPaymentMethod)
+	e.GET("/api/reports/bank-reconciliation/unmatched", bankReconciliationHandler.GetUnmatchedReport)
+	e.GET("/api/reports/withholding-tax", paymentHandler.GetWithholdingSummary)
+	e.GET("/api/reports/sales-book", reportHandler.GetSalesBook)
+	e.GET("/api/reports/document-numbering", reportHandler.GetDocumentNumbering)
+	e.GET("/api/reports/revenue-by-industry", reportHandler.GetRevenueByIndustry)
+	e.GET("/api/reports/expiring-certifications", complianceHandler.GetExpiringCertifications)
+	e.GET("/api/reports/receiving-inspections", receivingHandler.GetReceivingReport)
+	e.GET("/api/reports/purchase-discrepancies", supplierInvoiceHandler.GetDiscrepancyReport)
+	e.GET("/api/reports/quotation-sla", quotationSLAHandler.GetQuotationSLAReport)
+	e.GET("/api/anomalies", anomalyHandler.GetAnomalies)
 
 	// Export CSV routes
 	e.GET("/api/reports/sales-trends/export", reportHandler.ExportSalesTrendsCSV)
 	e.GET("/api/reports/low-stock/export", reportHandler.ExportLowStockItemsCSV)
 	e.GET("/api/reports/top-customers/export", reportHandler.ExportTopCustomersCSV)
+	e.GET("/api/reports/sales-by-channel/export", reportHandler.ExportSalesByChannelCSV)
+	e.GET("/api/reports/sales-by-payment-method/export", reportHandler.ExportSalesByPaymentMethodCSV)
+	e.GET("/api/reports/withholding-tax/export", paymentHandler.ExportWithholdingSummaryCSV)
+	e.GET("/api/reports/sales-book/export", reportHandler.ExportSalesBookCSV)
+	e.GET("/api/reports/document-numbering/export", reportHandler.ExportDocumentNumberingCSV)
+	e.GET("/api/reports/revenue-by-industry/export", reportHandler.ExportRevenueByIndustryCSV)
+	e.GET("/api/reports/receiving-inspections/export", receivingHandler.ExportReceivingReportCSV)
+	e.GET("/api/reports/purchase-discrepancies/export", supplierInvoiceHandler.ExportDiscrepancyReportCSV)
+	e.GET("/api/reports/quotation-sla/export", quotationSLAHandler.ExportQuotationSLAReportCSV)
 
 	// User routes
-	e.GET("/api/users", userHandler.GetUsers)
-	e.GET("/api/users/:id", userHandler.GetUser)
-	e.POST("/api/users", userHandler.Register)
-	e.PUT("/api/users/:id", userHandler.UpdateUser)
-	e.DELETE("/api/users/:id", userHandler.DeleteUser)
-	e.PUT("/api/users/:id/password", userHandler.UpdatePassword)
-	e.GET("/api/users/search", userHandler.SearchUsers)
+	e.GET("/api/users", userHandler.GetUsers, adminOnly)
+	e.GET("/api/users/:id", userHandler.GetUser, adminOnly)
+	e.POST("/api/users", userHandler.Register, adminOnly)
+	e.PUT("/api/users/:id", userHandler.UpdateUser, adminOnly)
+	e.DELETE("/api/users/:id", userHandler.DeleteUser, adminOnly)
+	e.PUT("/api/users/:id/password", userHandler.UpdatePassword, adminOnly)
+	e.POST("/api/users/:id/unlock", authHandler.UnlockUser, adminOnly)
+	e.GET("/api/users/search", userHandler.SearchUsers, adminOnly)
+
+	// Document archive integration routes
+	e.GET("/api/integrations", integrationHandler.GetIntegrations)
+	e.GET("/api/integrations/:provider/authorize", integrationHandler.Authorize, adminOnly)
+	e.GET("/api/integrations/:provider/callback", integrationHandler.Callback, adminOnly)
+	e.DELETE("/api/integrations/:provider", integrationHandler.Disconnect, adminOnly)
+
+	// Warehouse print queue routes
+	e.GET("/api/printers", printHandler.GetPrinters)
+	e.GET("/api/print-jobs", printHandler.GetPrintJobs)
+	e.GET("/api/print-jobs/:id", printHandler.GetPrintJobByID)
+	e.POST("/api/print-jobs", printHandler.CreatePrintJob)
+
+	// Offline sync routes for the warehouse tablet app
+	e.GET("/api/sync/changes", syncHandler.GetChanges)
+	e.POST("/api/sync/batch", syncHandler.PushBatch)
+
+	// Device routes
+	e.GET("/api/devices/me", deviceHandler.GetCurrentDevice)
+	e.GET("/api/admin/devices", deviceHandler.GetDevices, adminOnly)
+	e.POST("/api/admin/devices", deviceHandler.RegisterDevice, adminOnly)
+	e.POST("/api/admin/devices/:id/revoke", deviceHandler.RevokeDevice, adminOnly)
+
+	// Impersonation and audit routes
+	e.POST("/api/admin/impersonate", impersonationHandler.StartImpersonation, adminOnly)
+	e.GET("/api/impersonation", impersonationHandler.GetCurrentImpersonation)
+	e.DELETE("/api/impersonation", impersonationHandler.EndImpersonation)
+	e.GET("/api/admin/audit-logs", auditHandler.GetAuditLogs, adminOnly)
+	e.GET("/api/audit-logs", auditHandler.GetAuditLogs, adminOnly)
+
+	// API key routes
+	e.GET("/api/admin/api-keys", apiKeyHandler.GetAPIKeys, adminOnly)
+	e.GET("/api/admin/api-keys/:id", apiKeyHandler.GetAPIKey, adminOnly)
+	e.POST("/api/admin/api-keys", apiKeyHandler.CreateAPIKey, adminOnly)
+	e.PUT("/api/admin/api-keys/:id", apiKeyHandler.UpdateAPIKey, adminOnly)
+	e.POST("/api/admin/api-keys/:id/revoke", apiKeyHandler.RevokeAPIKey, adminOnly)
+	e.DELETE("/api/admin/api-keys/:id", apiKeyHandler.DeleteAPIKey, adminOnly)
+	e.PUT("/api/admin/api-keys/:id/quota", apiKeyHandler.UpdateQuota, adminOnly)
+	e.GET("/api/keys/:id/usage", apiKeyHandler.GetUsage)
+
+	// Webhook routes
+	e.GET("/api/admin/webhooks", webhookHandler.GetWebhookEndpoints, adminOnly)
+	e.GET("/api/admin/webhooks/:id", webhookHandler.GetWebhookEndpoint, adminOnly)
+	e.POST("/api/admin/webhooks", webhookHandler.CreateWebhookEndpoint, adminOnly)
+	e.PUT("/api/admin/webhooks/:id", webhookHandler.UpdateWebhookEndpoint, adminOnly)
+	e.DELETE("/api/admin/webhooks/:id", webhookHandler.DeleteWebhookEndpoint, adminOnly)
+	e.GET("/api/admin/webhooks/:id/deliveries", webhookHandler.GetWebhookDeliveries, adminOnly)
+	e.POST("/api/admin/webhooks/:id/deliveries/:delivery_id/redeliver", webhookHandler.RedeliverWebhook, adminOnly)
+
+	// Report schedule routes (admin only)
+	e.GET("/api/admin/report-schedules", reportScheduleHandler.GetReportSchedules, adminOnly)
+	e.GET("/api/admin/report-schedules/:id", reportScheduleHandler.GetReportSchedule, adminOnly)
+	e.POST("/api/admin/report-schedules", reportScheduleHandler.CreateReportSchedule, adminOnly)
+	e.PUT("/api/admin/report-schedules/:id", reportScheduleHandler.UpdateReportSchedule, adminOnly)
+	e.DELETE("/api/admin/report-schedules/:id", reportScheduleHandler.DeleteReportSchedule, adminOnly)
+	e.POST("/api/admin/report-schedules/:id/send", reportScheduleHandler.SendReportSchedule, adminOnly)
+
+	// Background job routes (admin only)
+	e.GET("/api/admin/jobs", jobHandler.GetJobs, adminOnly)
+	e.GET("/api/admin/jobs/:id", jobHandler.GetJob, adminOnly)
+	e.POST("/api/admin/jobs/:id/retry", jobHandler.RetryJob, adminOnly)
+	e.DELETE("/api/admin/jobs/:id", jobHandler.DeleteJob, adminOnly)
+
+	// API documentation, generated from the routes above so it must stay last
+	docsHandler, err := handlers.NewDocsHandler(e.Routes(), publicPaths...)
+	if err != nil {
+		log.Fatalf("Failed to generate API documentation: %v", err)
+	}
+	e.GET("/api/openapi.json", docsHandler.GetOpenAPISpec)
+	e.GET("/api/docs", docsHandler.GetSwaggerUI)
+
+	// Serve the built frontend from / when one is embedded or FRONTEND_DIR is set, so a small
+	// deployment runs this binary alone
+	frontend, err := handlers.FrontendFS(cfg.FrontendDir)
+	if err != nil {
+		log.Fatalf("Failed to load the frontend: %v", err)
+	}
+	if frontend != nil {
+		frontendHandler := handlers.NewFrontendHandler(frontend)
+		e.GET("/*", frontendHandler.ServeApp)
+		e.HEAD("/*", frontendHandler.ServeApp)
+		log.Printf("Serving the frontend from /")
+	}
 
 	// Start server
 	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
@@ -206,5 +895,61 @@ func main() {
 	for _, route := range e.Routes() {
 		fmt.Printf("%-6s %s\n", route.Method, route.Path)
 	}
-	e.Logger.Fatal(e.Start(":8081"))
+
+	// Serve until interrupted, then drain in-flight requests and background work before exiting.
+	// With TLS configured the API is served over HTTPS, optionally with plain HTTP redirected.
+	configureServer(e, cfg)
+	if len(cfg.AutocertDomains) > 0 {
+		configureAutocert(e, cfg)
+	}
+	redirectServer := newRedirectServer(e, cfg)
+	if redirectServer != nil {
+		go func() {
+			log.Printf("Redirecting HTTP on %s to HTTPS", redirectServer.Addr)
+			if err := redirectServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				e.Logger.Fatal(err)
+			}
+		}()
+	}
+	go func() {
+		if err := startServer(e, cfg); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			e.Logger.Fatal(err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	<-quit
+	log.Printf("Shutting down, waiting up to %s for requests and background work", cfg.ShutdownTimeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := e.Shutdown(ctx); err != nil {
+		log.Printf("Failed to drain in-flight requests: %v", err)
+	}
+	if redirectServer != nil {
+		if err := redirectServer.Shutdown(ctx); err != nil {
+			log.Printf("Failed to stop the HTTP redirect: %v", err)
+		}
+	}
+
+	// No new work can be started now; let the schedulers and queues finish what they have
+	tierService.Stop()
+	checkReminderService.Stop()
+	anomalyService.Stop()
+	quotationBuilderCleaner.Stop()
+	trashPurger.Stop()
+	webhookService.Stop()
+	reportScheduler.Stop()
+	jobQueue.Stop()
+	printService.Stop()
+	if err := services.WaitForBackground(ctx); err != nil {
+		log.Printf("Stopped waiting for background work: %v", err)
+	}
+
+	if err := db.Close(); err != nil {
+		log.Printf("Failed to close database connections: %v", err)
+	}
+	log.Printf("Shutdown complete")
 }
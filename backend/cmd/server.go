@@ -0,0 +1,89 @@
+package main
+
+import (
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/Cezzyy/SCMS/backend/internal/config"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/acme/autocert"
+	"golang.org/x/net/http2"
+)
+
+// configureServer applies the configured timeouts and header limit to the HTTP and HTTPS
+// servers of e
+func configureServer(e *echo.Echo, cfg *config.Config) {
+	for _, s := range []*http.Server{e.Server, e.TLSServer} {
+		applyLimits(s, cfg)
+	}
+}
+
+// applyLimits sets the connection timeouts and header limit of a server
+func applyLimits(s *http.Server, cfg *config.Config) {
+	s.ReadHeaderTimeout = cfg.ReadHeaderTimeout
+	s.ReadTimeout = cfg.ReadTimeout
+	s.WriteTimeout = cfg.WriteTimeout
+	s.IdleTimeout = cfg.IdleTimeout
+	s.MaxHeaderBytes = cfg.MaxHeaderBytes
+}
+
+// configureAutocert has e get certificates from Let's Encrypt for the configured domains,
+// and no others, keeping them in the cache directory across restarts
+func configureAutocert(e *echo.Echo, cfg *config.Config) {
+	e.AutoTLSManager.Prompt = autocert.AcceptTOS
+	e.AutoTLSManager.HostPolicy = autocert.HostWhitelist(cfg.AutocertDomains...)
+	e.AutoTLSManager.Cache = autocert.DirCache(cfg.AutocertCacheDir)
+	e.AutoTLSManager.Email = cfg.AutocertEmail
+}
+
+// startServer serves e over HTTPS when TLS is configured, or over plain HTTP (with HTTP/2 when
+// H2C is enabled) otherwise, until it is shut down
+func startServer(e *echo.Echo, cfg *config.Config) error {
+	switch {
+	case cfg.TLSCertFile != "":
+		log.Printf("Serving HTTPS on %s with the certificate in %s", cfg.Address(), cfg.TLSCertFile)
+		return e.StartTLS(cfg.Address(), cfg.TLSCertFile, cfg.TLSKeyFile)
+	case len(cfg.AutocertDomains) > 0:
+		log.Printf("Serving HTTPS on %s with Let's Encrypt certificates for %v", cfg.Address(), cfg.AutocertDomains)
+		return e.StartAutoTLS(cfg.Address())
+	case cfg.H2C:
+		log.Printf("Serving HTTP/1.1 and HTTP/2 (h2c) on %s", cfg.Address())
+		return e.StartH2CServer(cfg.Address(), &http2.Server{
+			MaxConcurrentStreams: 250,
+			IdleTimeout:          cfg.IdleTimeout,
+		})
+	default:
+		return e.Start(cfg.Address())
+	}
+}
+
+// newRedirectServer returns a plain HTTP server on HTTP_REDIRECT_PORT that redirects every
+// request to HTTPS, answering Let's Encrypt's challenges when certificates come from it, or
+// nil when no redirect port is configured. configureAutocert must be called first.
+func newRedirectServer(e *echo.Echo, cfg *config.Config) *http.Server {
+	if cfg.HTTPRedirectPort == "" {
+		return nil
+	}
+
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if cfg.Port != "443" {
+			host = net.JoinHostPort(host, cfg.Port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+	if len(cfg.AutocertDomains) > 0 {
+		handler = e.AutoTLSManager.HTTPHandler(handler)
+	}
+
+	s := &http.Server{
+		Addr:    ":" + cfg.HTTPRedirectPort,
+		Handler: handler,
+	}
+	applyLimits(s, cfg)
+	return s
+}
@@ -0,0 +1,303 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Config holds the server settings that vary between machines
+type Config struct {
+	Port            string
+	TemplatesDir    string
+	CSSDir          string
+	WkhtmltopdfPath string // empty means detect the binary at startup
+	FrontendDir     string // empty means the frontend embedded in the binary, if any
+	CORSOrigins     []string
+	ShutdownTimeout time.Duration
+
+	// Limits on each connection, so slow or idle clients cannot hold the server's resources
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	H2C               bool // serve HTTP/2 without TLS, for a proxy in front that speaks it
+
+	// HTTPS is served from TLSCertFile and TLSKeyFile, or with certificates Let's Encrypt
+	// issues for AutocertDomains; plain HTTP is served when neither is set
+	TLSCertFile      string
+	TLSKeyFile       string
+	AutocertDomains  []string
+	AutocertEmail    string
+	AutocertCacheDir string
+	HTTPRedirectPort string // empty means no plain HTTP listener next to HTTPS
+
+	// Faults slows down and fails requests on purpose, for exercising clients in staging
+	Faults FaultInjection
+}
+
+// FaultInjection makes a share of API requests slow or fail so the frontend's retries and
+// back-off can be tried before going live. It is off unless FAULT_INJECTION_ENABLED is set.
+type FaultInjection struct {
+	Enabled bool
+	// Routes are the routes faults are injected into, such as "/api/quotations/:id",
+	// "POST /api/orders" or "/api/reports/*"; empty means every API route but /api/health
+	Routes      []string
+	LatencyRate float64 // share of requests delayed, 0 to 1
+	LatencyMin  time.Duration
+	LatencyMax  time.Duration
+	ErrorRate   float64 // share of requests failed, 0 to 1
+	ErrorStatus []int   // statuses failed requests answer with, picked at random
+}
+
+// defaultEnvFiles are tried in order when CONFIG_FILE is not set; the first one found is loaded
+var defaultEnvFiles = []string{".env", "../.env"}
+
+// defaultTemplateDirs are the template locations tried relative to the working directory,
+// covering runs from the backend directory, from cmd, and from a container image
+var defaultTemplateDirs = []string{"cmd/templates", "templates", "backend/cmd/templates"}
+
+// Load reads the configuration from environment variables. Variables from the env file named
+// by CONFIG_FILE (or a .env file in the working or parent directory) are loaded first but never
+// override variables that are already set, so the file is optional when running in Docker.
+//
+// Recognised variables:
+//   - PORT (default 8081)
+//   - TEMPLATES_DIR (default cmd/templates, or the first existing default location)
+//   - CSS_DIR (default TEMPLATES_DIR/css)
+//   - WKHTMLTOPDF_PATH (default: detected)
+//   - FRONTEND_DIR, a built frontend to serve from / (default: the build embedded in the binary, if any)
+//   - CORS_ORIGINS, comma separated (default http://localhost:5173,http://localhost:5174)
+//   - SHUTDOWN_TIMEOUT_SECONDS, how long shutdown waits for requests and background work (default 30)
+//   - SERVER_READ_HEADER_TIMEOUT_SECONDS, how long a client may take to send request headers (default 10)
+//   - SERVER_READ_TIMEOUT_SECONDS, how long a client may take to send a whole request (default 30)
+//   - SERVER_WRITE_TIMEOUT_SECONDS, how long a response may take, PDFs and exports included (default 120)
+//   - SERVER_IDLE_TIMEOUT_SECONDS, how long a kept-alive connection may sit idle (default 120)
+//   - SERVER_MAX_HEADER_KB, the largest request headers accepted (default 64)
+//   - H2C_ENABLED, true to also serve HTTP/2 over plain HTTP (default false); HTTPS always
+//     offers HTTP/2
+//   - TLS_CERT_FILE and TLS_KEY_FILE, a certificate and key to serve HTTPS with
+//   - TLS_AUTOCERT_DOMAINS, comma separated, to serve HTTPS with Let's Encrypt certificates
+//     for these domains instead; Let's Encrypt must reach the server on port 443, or on port
+//     80 through HTTP_REDIRECT_PORT
+//   - TLS_AUTOCERT_EMAIL, the contact Let's Encrypt warns about expiring certificates (optional)
+//   - TLS_AUTOCERT_CACHE_DIR, where issued certificates are kept across restarts (default certs)
+//   - HTTP_REDIRECT_PORT, a port to redirect plain HTTP to HTTPS on, such as 80 (default: none)
+//   - FAULT_INJECTION_ENABLED, true to slow down and fail requests on purpose; for staging
+//     only (default false)
+//   - FAULT_ROUTES, comma separated routes to inject faults into, optionally prefixed with a
+//     method and ending in * to match a prefix (default: every API route but /api/health)
+//   - FAULT_LATENCY_RATE, the share of requests delayed, 0 to 1 (default 0.2)
+//   - FAULT_LATENCY_MIN_MS and FAULT_LATENCY_MAX_MS, the range of delays (default 200 to 3000)
+//   - FAULT_ERROR_RATE, the share of requests failed, 0 to 1 (default 0.1)
+//   - FAULT_ERROR_STATUSES, comma separated statuses failed requests answer with
+//     (default 500,502,503)
+func Load() (*Config, error) {
+	if err := loadEnvFile(); err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{
+		Port:            strings.TrimPrefix(envOrDefault("PORT", "8081"), ":"),
+		TemplatesDir:    envOrDefault("TEMPLATES_DIR", findTemplatesDir()),
+		WkhtmltopdfPath: strings.TrimSpace(os.Getenv("WKHTMLTOPDF_PATH")),
+		FrontendDir:     strings.TrimSpace(os.Getenv("FRONTEND_DIR")),
+		CORSOrigins:     envList("CORS_ORIGINS", []string{"http://localhost:5173", "http://localhost:5174"}),
+	}
+	cfg.CSSDir = envOrDefault("CSS_DIR", filepath.Join(cfg.TemplatesDir, "css"))
+
+	timeouts := []struct {
+		key      string
+		fallback int
+		target   *time.Duration
+	}{
+		{"SHUTDOWN_TIMEOUT_SECONDS", 30, &cfg.ShutdownTimeout},
+		{"SERVER_READ_HEADER_TIMEOUT_SECONDS", 10, &cfg.ReadHeaderTimeout},
+		{"SERVER_READ_TIMEOUT_SECONDS", 30, &cfg.ReadTimeout},
+		{"SERVER_WRITE_TIMEOUT_SECONDS", 120, &cfg.WriteTimeout},
+		{"SERVER_IDLE_TIMEOUT_SECONDS", 120, &cfg.IdleTimeout},
+	}
+	for _, timeout := range timeouts {
+		seconds, err := envPositiveInt(timeout.key, timeout.fallback)
+		if err != nil {
+			return nil, err
+		}
+		*timeout.target = time.Duration(seconds) * time.Second
+	}
+
+	headerKB, err := envPositiveInt("SERVER_MAX_HEADER_KB", 64)
+	if err != nil {
+		return nil, err
+	}
+	cfg.MaxHeaderBytes = headerKB << 10
+
+	cfg.H2C, err = strconv.ParseBool(envOrDefault("H2C_ENABLED", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("H2C_ENABLED must be true or false")
+	}
+
+	cfg.TLSCertFile = strings.TrimSpace(os.Getenv("TLS_CERT_FILE"))
+	cfg.TLSKeyFile = strings.TrimSpace(os.Getenv("TLS_KEY_FILE"))
+	cfg.AutocertDomains = envList("TLS_AUTOCERT_DOMAINS", nil)
+	cfg.AutocertEmail = strings.TrimSpace(os.Getenv("TLS_AUTOCERT_EMAIL"))
+	cfg.AutocertCacheDir = envOrDefault("TLS_AUTOCERT_CACHE_DIR", "certs")
+	cfg.HTTPRedirectPort = strings.TrimPrefix(strings.TrimSpace(os.Getenv("HTTP_REDIRECT_PORT")), ":")
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return nil, fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if cfg.TLSCertFile != "" && len(cfg.AutocertDomains) > 0 {
+		return nil, fmt.Errorf("set either TLS_CERT_FILE or TLS_AUTOCERT_DOMAINS, not both")
+	}
+	if cfg.HTTPRedirectPort != "" && !cfg.TLSEnabled() {
+		return nil, fmt.Errorf("HTTP_REDIRECT_PORT needs TLS_CERT_FILE or TLS_AUTOCERT_DOMAINS")
+	}
+	if cfg.H2C && cfg.TLSEnabled() {
+		return nil, fmt.Errorf("H2C_ENABLED is for plain HTTP; HTTPS already offers HTTP/2")
+	}
+
+	if cfg.Faults, err = loadFaultInjection(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// loadFaultInjection reads the FAULT_ variables; they are only checked when fault injection
+// is enabled
+func loadFaultInjection() (FaultInjection, error) {
+	var faults FaultInjection
+	var err error
+	faults.Enabled, err = strconv.ParseBool(envOrDefault("FAULT_INJECTION_ENABLED", "false"))
+	if err != nil {
+		return faults, fmt.Errorf("FAULT_INJECTION_ENABLED must be true or false")
+	}
+	if !faults.Enabled {
+		return faults, nil
+	}
+
+	faults.Routes = envList("FAULT_ROUTES", nil)
+	for _, rate := range []struct {
+		key      string
+		fallback string
+		target   *float64
+	}{
+		{"FAULT_LATENCY_RATE", "0.2", &faults.LatencyRate},
+		{"FAULT_ERROR_RATE", "0.1", &faults.ErrorRate},
+	} {
+		value, err := strconv.ParseFloat(envOrDefault(rate.key, rate.fallback), 64)
+		if err != nil || value < 0 || value > 1 {
+			return faults, fmt.Errorf("%s must be a number from 0 to 1", rate.key)
+		}
+		*rate.target = value
+	}
+
+	minMS, err := envPositiveInt("FAULT_LATENCY_MIN_MS", 200)
+	if err != nil {
+		return faults, err
+	}
+	maxMS, err := envPositiveInt("FAULT_LATENCY_MAX_MS", 3000)
+	if err != nil {
+		return faults, err
+	}
+	if maxMS < minMS {
+		return faults, fmt.Errorf("FAULT_LATENCY_MAX_MS must not be below FAULT_LATENCY_MIN_MS")
+	}
+	faults.LatencyMin = time.Duration(minMS) * time.Millisecond
+	faults.LatencyMax = time.Duration(maxMS) * time.Millisecond
+
+	for _, value := range envList("FAULT_ERROR_STATUSES", []string{"500", "502", "503"}) {
+		status, err := strconv.Atoi(value)
+		if err != nil || status < 500 || status > 599 {
+			return faults, fmt.Errorf("FAULT_ERROR_STATUSES must list 5xx statuses")
+		}
+		faults.ErrorStatus = append(faults.ErrorStatus, status)
+	}
+	return faults, nil
+}
+
+// TLSEnabled reports whether the server is configured to serve HTTPS
+func (c *Config) TLSEnabled() bool {
+	return c.TLSCertFile != "" || len(c.AutocertDomains) > 0
+}
+
+// Address returns the listen address for the HTTP server
+func (c *Config) Address() string {
+	return ":" + c.Port
+}
+
+// loadEnvFile loads CONFIG_FILE when set, otherwise the first default env file that exists
+func loadEnvFile() error {
+	if path := strings.TrimSpace(os.Getenv("CONFIG_FILE")); path != "" {
+		if err := godotenv.Load(path); err != nil {
+			return fmt.Errorf("failed to load config file %s: %w", path, err)
+		}
+		log.Printf("Loaded configuration from %s", path)
+		return nil
+	}
+
+	for _, path := range defaultEnvFiles {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if err := godotenv.Load(path); err != nil {
+			return fmt.Errorf("failed to load config file %s: %w", path, err)
+		}
+		log.Printf("Loaded configuration from %s", path)
+		return nil
+	}
+
+	log.Printf("No config file found, using environment variables only")
+	return nil
+}
+
+// findTemplatesDir returns the first default template location that exists
+func findTemplatesDir() string {
+	for _, dir := range defaultTemplateDirs {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			if abs, err := filepath.Abs(dir); err == nil {
+				return abs
+			}
+			return dir
+		}
+	}
+	return defaultTemplateDirs[0]
+}
+
+// envOrDefault returns the trimmed value of an environment variable, or fallback when it is unset
+func envOrDefault(key, fallback string) string {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// envPositiveInt parses a positive whole number from an environment variable, or returns
+// fallback when it is unset
+func envPositiveInt(key string, fallback int) (int, error) {
+	value, err := strconv.Atoi(envOrDefault(key, strconv.Itoa(fallback)))
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("%s must be a positive whole number", key)
+	}
+	return value, nil
+}
+
+// envList splits a comma separated environment variable, or returns fallback when it is unset
+func envList(key string, fallback []string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return fallback
+	}
+	return values
+}
@@ -2,22 +2,12 @@ package database
 
 import (
 	"fmt"
-	"log"
 	"os"
 
 	"github.com/jmoiron/sqlx"
-	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
 
-func init() {
-	// Load .env file
-	err := godotenv.Load("../.env")
-	if err != nil {
-		log.Fatalf("Error loading .env file")
-	}
-}
-
 func Connect() (*sqlx.DB, error) {
 	// Get environment variables
 	host := os.Getenv("DB_HOST")
@@ -0,0 +1,10 @@
+-- OAuth credentials for external document archives (Google Drive, OneDrive)
+CREATE TABLE IF NOT EXISTS integration_credentials (
+    provider      TEXT PRIMARY KEY,
+    access_token  TEXT NOT NULL,
+    refresh_token TEXT NOT NULL DEFAULT '',
+    token_type    TEXT NOT NULL DEFAULT 'Bearer',
+    expires_at    TIMESTAMPTZ,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
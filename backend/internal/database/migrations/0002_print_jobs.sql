@@ -0,0 +1,14 @@
+-- Print queue for warehouse picking lists and labels
+CREATE TABLE IF NOT EXISTS print_jobs (
+    print_job_id  SERIAL PRIMARY KEY,
+    printer_name  TEXT NOT NULL,
+    document_type TEXT NOT NULL,
+    order_id      INTEGER NOT NULL REFERENCES orders(order_id) ON DELETE CASCADE,
+    copies        INTEGER NOT NULL DEFAULT 1,
+    status        TEXT NOT NULL DEFAULT 'Queued',
+    error         TEXT,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    printed_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_print_jobs_created_at ON print_jobs (created_at DESC);
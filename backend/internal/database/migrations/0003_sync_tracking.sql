@@ -0,0 +1,36 @@
+-- Change tracking for the offline warehouse tablet sync
+ALTER TABLE inventory ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+CREATE INDEX IF NOT EXISTS idx_products_updated_at ON products (updated_at);
+CREATE INDEX IF NOT EXISTS idx_inventory_updated_at ON inventory (updated_at);
+CREATE INDEX IF NOT EXISTS idx_orders_updated_at ON orders (updated_at);
+
+-- Deleted rows are recorded so offline clients can drop their local copies
+CREATE TABLE IF NOT EXISTS sync_tombstones (
+    tombstone_id SERIAL PRIMARY KEY,
+    entity       TEXT NOT NULL,
+    entity_id    INTEGER NOT NULL,
+    deleted_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_tombstones_deleted_at ON sync_tombstones (deleted_at);
+
+CREATE OR REPLACE FUNCTION record_sync_tombstone() RETURNS TRIGGER AS $$
+BEGIN
+    EXECUTE format('INSERT INTO sync_tombstones (entity, entity_id) VALUES ($1, ($2).%I)', TG_ARGV[1])
+    USING TG_ARGV[0], OLD;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS products_sync_tombstone ON products;
+CREATE TRIGGER products_sync_tombstone AFTER DELETE ON products
+    FOR EACH ROW EXECUTE FUNCTION record_sync_tombstone('products', 'product_id');
+
+DROP TRIGGER IF EXISTS inventory_sync_tombstone ON inventory;
+CREATE TRIGGER inventory_sync_tombstone AFTER DELETE ON inventory
+    FOR EACH ROW EXECUTE FUNCTION record_sync_tombstone('inventory', 'inventory_id');
+
+DROP TRIGGER IF EXISTS orders_sync_tombstone ON orders;
+CREATE TRIGGER orders_sync_tombstone AFTER DELETE ON orders
+    FOR EACH ROW EXECUTE FUNCTION record_sync_tombstone('orders', 'order_id');
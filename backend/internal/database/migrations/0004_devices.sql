@@ -0,0 +1,13 @@
+-- Registered API client devices (warehouse tablets, barcode scanners)
+CREATE TABLE IF NOT EXISTS devices (
+    device_id     SERIAL PRIMARY KEY,
+    name          TEXT NOT NULL,
+    device_type   TEXT NOT NULL,
+    token_hash    TEXT NOT NULL UNIQUE,
+    token_prefix  TEXT NOT NULL,
+    registered_by INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    last_seen_at  TIMESTAMPTZ,
+    last_seen_ip  TEXT,
+    revoked_at    TIMESTAMPTZ,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,26 @@
+-- Audit trail of sensitive actions; impersonator_id is set for actions taken while impersonating
+CREATE TABLE IF NOT EXISTS audit_logs (
+    audit_log_id    SERIAL PRIMARY KEY,
+    user_id         INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    impersonator_id INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    action          TEXT NOT NULL,
+    entity          TEXT NOT NULL DEFAULT '',
+    entity_id       TEXT NOT NULL DEFAULT '',
+    details         JSONB NOT NULL DEFAULT '{}',
+    ip_address      TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at DESC);
+
+-- Time-limited sessions in which an admin acts as another user
+CREATE TABLE IF NOT EXISTS impersonation_sessions (
+    impersonation_id SERIAL PRIMARY KEY,
+    token_hash       TEXT NOT NULL UNIQUE,
+    admin_user_id    INTEGER NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    target_user_id   INTEGER NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    reason           TEXT NOT NULL,
+    expires_at       TIMESTAMPTZ NOT NULL,
+    ended_at         TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,18 @@
+-- API keys for external integrators, with a per-key daily request quota (0 = unlimited)
+CREATE TABLE IF NOT EXISTS api_keys (
+    api_key_id  SERIAL PRIMARY KEY,
+    name        TEXT NOT NULL,
+    key_hash    TEXT NOT NULL UNIQUE,
+    key_prefix  TEXT NOT NULL,
+    daily_quota INTEGER NOT NULL DEFAULT 10000,
+    revoked_at  TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Requests per key per UTC day; requests beyond the quota are counted but rejected
+CREATE TABLE IF NOT EXISTS api_key_usage (
+    api_key_id    INTEGER NOT NULL REFERENCES api_keys(api_key_id) ON DELETE CASCADE,
+    usage_date    DATE NOT NULL,
+    request_count INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (api_key_id, usage_date)
+);
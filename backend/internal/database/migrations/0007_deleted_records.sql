@@ -0,0 +1,15 @@
+-- Rows removed by bulk delete, kept as JSON so they can be restored.
+-- Child rows removed together with their parent (e.g. a product's empty inventory record)
+-- point at it through parent_entity/parent_id and are restored with it.
+CREATE TABLE IF NOT EXISTS deleted_records (
+    deleted_record_id SERIAL PRIMARY KEY,
+    entity            TEXT NOT NULL,
+    entity_id         INTEGER NOT NULL,
+    parent_entity     TEXT,
+    parent_id         INTEGER,
+    data              JSONB NOT NULL,
+    deleted_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_deleted_records_entity ON deleted_records (entity, entity_id);
+CREATE INDEX IF NOT EXISTS idx_deleted_records_parent ON deleted_records (parent_entity, parent_id);
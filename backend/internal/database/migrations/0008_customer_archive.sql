@@ -0,0 +1,2 @@
+-- Customers with order or quotation history are archived instead of deleted
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
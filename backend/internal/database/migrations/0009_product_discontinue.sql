@@ -0,0 +1,2 @@
+-- Products on historical documents are discontinued instead of deleted
+ALTER TABLE products ADD COLUMN IF NOT EXISTS discontinued_at TIMESTAMPTZ;
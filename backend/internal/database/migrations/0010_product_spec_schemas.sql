@@ -0,0 +1,21 @@
+-- Product categories with a technical spec schema per category
+ALTER TABLE products ADD COLUMN IF NOT EXISTS category TEXT;
+
+CREATE TABLE IF NOT EXISTS spec_schemas (
+    category   TEXT PRIMARY KEY,
+    fields     JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Change history for products, including a field-level diff of technical specs
+CREATE TABLE IF NOT EXISTS product_history (
+    product_history_id SERIAL PRIMARY KEY,
+    product_id         INTEGER NOT NULL,
+    action             TEXT NOT NULL,
+    changed_fields     JSONB NOT NULL DEFAULT '[]',
+    specs_diff         JSONB NOT NULL DEFAULT '[]',
+    changed_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_history_product ON product_history (product_id, changed_at DESC);
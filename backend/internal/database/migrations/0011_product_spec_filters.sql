@@ -0,0 +1,4 @@
+-- Indexes for filtering products by category and technical spec values.
+-- jsonb_path_ops supports both @> containment and @@ jsonpath predicates.
+CREATE INDEX IF NOT EXISTS idx_products_category ON products (category);
+CREATE INDEX IF NOT EXISTS idx_products_technical_specs ON products USING GIN (technical_specs jsonb_path_ops);
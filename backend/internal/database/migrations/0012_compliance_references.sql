@@ -0,0 +1,44 @@
+-- Reference tables for certifications and safety standards, replacing free-text product columns
+CREATE TABLE IF NOT EXISTS certifications (
+    certification_id SERIAL PRIMARY KEY,
+    code             TEXT NOT NULL UNIQUE,
+    name             TEXT NOT NULL,
+    issuing_body     TEXT,
+    description      TEXT,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS safety_standards (
+    safety_standard_id SERIAL PRIMARY KEY,
+    code               TEXT NOT NULL UNIQUE,
+    name               TEXT NOT NULL,
+    issuing_body       TEXT,
+    description        TEXT,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- A product's certificate for a certification, with its validity period
+CREATE TABLE IF NOT EXISTS product_certifications (
+    product_certification_id SERIAL PRIMARY KEY,
+    product_id               INTEGER NOT NULL REFERENCES products (product_id),
+    certification_id         INTEGER NOT NULL REFERENCES certifications (certification_id),
+    certificate_number       TEXT,
+    issued_on                DATE,
+    expires_on               DATE,
+    created_at               TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at               TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (product_id, certification_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_certifications_expires_on ON product_certifications (expires_on);
+
+CREATE TABLE IF NOT EXISTS product_safety_standards (
+    product_safety_standard_id SERIAL PRIMARY KEY,
+    product_id                 INTEGER NOT NULL REFERENCES products (product_id),
+    safety_standard_id         INTEGER NOT NULL REFERENCES safety_standards (safety_standard_id),
+    created_at                 TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at                 TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (product_id, safety_standard_id)
+);
@@ -0,0 +1,16 @@
+-- Restricted products (e.g. medical gases) can only be sold to customers with an allow rule
+ALTER TABLE products ADD COLUMN IF NOT EXISTS restricted BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Per-customer allow/block rules for individual products
+CREATE TABLE IF NOT EXISTS customer_product_rules (
+    customer_product_rule_id SERIAL PRIMARY KEY,
+    customer_id              INTEGER NOT NULL REFERENCES customers (customer_id),
+    product_id               INTEGER NOT NULL REFERENCES products (product_id),
+    rule                     TEXT NOT NULL CHECK (rule IN ('allow', 'block')),
+    reason                   TEXT,
+    created_at               TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at               TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (customer_id, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_customer_product_rules_product ON customer_product_rules (product_id);
@@ -0,0 +1,23 @@
+-- Loyalty tiers assigned from trailing 12-month revenue, with tier-based pricing
+CREATE TABLE IF NOT EXISTS loyalty_tiers (
+    tier                     TEXT PRIMARY KEY,
+    min_revenue              NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    discount_percent         NUMERIC(5, 2) NOT NULL DEFAULT 0,
+    free_delivery_threshold  NUMERIC(14, 2),
+    free_deliveries_per_month INTEGER NOT NULL DEFAULT 0,
+    updated_at               TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO loyalty_tiers (tier, min_revenue, discount_percent, free_delivery_threshold, free_deliveries_per_month) VALUES
+    ('Bronze', 0, 0, 50000, 0),
+    ('Silver', 250000, 3, 25000, 2),
+    ('Gold', 1000000, 5, 0, 0)
+ON CONFLICT (tier) DO NOTHING;
+
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS tier TEXT NOT NULL DEFAULT 'Bronze' REFERENCES loyalty_tiers (tier);
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS trailing_revenue NUMERIC(14, 2) NOT NULL DEFAULT 0;
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS tier_updated_at TIMESTAMPTZ;
+
+-- Delivery charge on each order; free_delivery_reason is 'threshold' or 'quota' when waived
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS delivery_fee NUMERIC(12, 2) NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS free_delivery_reason TEXT;
@@ -0,0 +1,7 @@
+-- Sales channel an order or quotation came in through
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS source TEXT
+    CHECK (source IN ('phone', 'walk_in', 'email', 'webshop', 'rep_visit'));
+ALTER TABLE quotations ADD COLUMN IF NOT EXISTS source TEXT
+    CHECK (source IN ('phone', 'walk_in', 'email', 'webshop', 'rep_visit'));
+
+CREATE INDEX IF NOT EXISTS idx_orders_source ON orders (source);
@@ -0,0 +1,13 @@
+-- Login attempts used to lock accounts and throttle addresses after repeated failures
+CREATE TABLE IF NOT EXISTS login_attempts (
+    login_attempt_id SERIAL PRIMARY KEY,
+    email            TEXT NOT NULL,
+    ip_address       TEXT NOT NULL DEFAULT '',
+    succeeded        BOOLEAN NOT NULL,
+    attempted_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_email ON login_attempts (email, attempted_at);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_ip ON login_attempts (ip_address, attempted_at) WHERE NOT succeeded;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locked_until TIMESTAMPTZ;
@@ -0,0 +1,29 @@
+-- Managed industry list replacing free-text customer industries
+CREATE TABLE IF NOT EXISTS industries (
+    industry_id SERIAL PRIMARY KEY,
+    name        TEXT NOT NULL UNIQUE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_industries_name_lower ON industries (LOWER(name));
+
+-- Seed from existing values, keeping the most common spelling of each case-insensitive variant
+INSERT INTO industries (name)
+SELECT DISTINCT ON (LOWER(TRIM(industry))) TRIM(industry)
+FROM customers
+WHERE TRIM(COALESCE(industry, '')) <> ''
+GROUP BY TRIM(industry)
+ORDER BY LOWER(TRIM(industry)), COUNT(*) DESC, TRIM(industry)
+ON CONFLICT DO NOTHING;
+
+-- Point every customer at the canonical spelling
+UPDATE customers SET industry = NULL WHERE TRIM(industry) = '';
+
+UPDATE customers c SET industry = i.name
+FROM industries i
+WHERE LOWER(TRIM(c.industry)) = LOWER(i.name) AND c.industry <> i.name;
+
+-- Renaming an industry carries through to its customers; industries in use cannot be deleted
+ALTER TABLE customers ADD CONSTRAINT customers_industry_fkey
+    FOREIGN KEY (industry) REFERENCES industries (name) ON UPDATE CASCADE;
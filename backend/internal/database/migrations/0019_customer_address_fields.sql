@@ -0,0 +1,11 @@
+-- Structured customer addresses with optional coordinates. The address column keeps the
+-- formatted single-line address; existing free-text addresses stay there until edited.
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS street TEXT;
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS city TEXT;
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS province TEXT;
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS postal_code TEXT;
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION;
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS geocoded_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_customers_city ON customers (province, city);
@@ -0,0 +1,4 @@
+-- Per-key scopes such as "products:read" ("*" grants everything) and last-used tracking.
+-- Existing keys keep full access.
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS scopes JSONB NOT NULL DEFAULT '["*"]';
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS last_used_at TIMESTAMPTZ;
@@ -0,0 +1,27 @@
+-- Shipping weight per unit, used to estimate freight
+ALTER TABLE products ADD COLUMN IF NOT EXISTS weight_kg NUMERIC(10, 3);
+
+-- Delivery zones group provinces; the default zone covers provinces not listed in any zone
+CREATE TABLE IF NOT EXISTS delivery_zones (
+    zone       TEXT PRIMARY KEY,
+    provinces  JSONB NOT NULL DEFAULT '[]',
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_delivery_zones_default ON delivery_zones (is_default) WHERE is_default;
+
+-- Freight per zone and weight bracket: base_fee plus per_kg_fee for each kg above min_weight_kg.
+-- A bracket covers min_weight_kg up to (not including) max_weight_kg; NULL max is open-ended.
+CREATE TABLE IF NOT EXISTS freight_rates (
+    freight_rate_id SERIAL PRIMARY KEY,
+    zone            TEXT NOT NULL REFERENCES delivery_zones (zone) ON UPDATE CASCADE ON DELETE CASCADE,
+    min_weight_kg   NUMERIC(10, 3) NOT NULL DEFAULT 0,
+    max_weight_kg   NUMERIC(10, 3),
+    base_fee        NUMERIC(12, 2) NOT NULL,
+    per_kg_fee      NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (zone, min_weight_kg)
+);
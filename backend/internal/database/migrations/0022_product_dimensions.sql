@@ -0,0 +1,4 @@
+-- Packed dimensions per unit, used with weight_kg for shipment totals
+ALTER TABLE products ADD COLUMN IF NOT EXISTS length_cm NUMERIC(10, 2);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS width_cm NUMERIC(10, 2);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS height_cm NUMERIC(10, 2);
@@ -0,0 +1,6 @@
+-- Record the state of a record before and after each change
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS before_data JSONB;
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS after_data JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_entity ON audit_logs (entity, entity_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_user ON audit_logs (user_id, created_at DESC);
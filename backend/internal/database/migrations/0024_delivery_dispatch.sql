@@ -0,0 +1,38 @@
+-- Delivery vehicles and their load limits; capacity_m3 is optional
+CREATE TABLE IF NOT EXISTS vehicles (
+    vehicle_id   SERIAL PRIMARY KEY,
+    plate_number TEXT NOT NULL UNIQUE,
+    description  TEXT NOT NULL DEFAULT '',
+    capacity_kg  NUMERIC(10, 2) NOT NULL CHECK (capacity_kg > 0),
+    capacity_m3  NUMERIC(10, 3) CHECK (capacity_m3 > 0),
+    active       BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Delivery drivers, optionally linked to a user account for the driver app
+CREATE TABLE IF NOT EXISTS drivers (
+    driver_id      SERIAL PRIMARY KEY,
+    name           TEXT NOT NULL,
+    phone          TEXT NOT NULL DEFAULT '',
+    license_number TEXT NOT NULL DEFAULT '',
+    user_id        INTEGER UNIQUE REFERENCES users(user_id) ON DELETE SET NULL,
+    active         BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- An order is delivered on one day by one vehicle and driver; stop_sequence orders the run
+CREATE TABLE IF NOT EXISTS delivery_assignments (
+    assignment_id SERIAL PRIMARY KEY,
+    order_id      INTEGER NOT NULL UNIQUE REFERENCES orders(order_id) ON DELETE CASCADE,
+    delivery_date DATE NOT NULL,
+    vehicle_id    INTEGER NOT NULL REFERENCES vehicles(vehicle_id),
+    driver_id     INTEGER NOT NULL REFERENCES drivers(driver_id),
+    stop_sequence INTEGER NOT NULL DEFAULT 0,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_delivery_assignments_vehicle ON delivery_assignments (delivery_date, vehicle_id);
+CREATE INDEX IF NOT EXISTS idx_delivery_assignments_driver ON delivery_assignments (delivery_date, driver_id);
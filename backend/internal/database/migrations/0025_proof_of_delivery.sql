@@ -0,0 +1,31 @@
+-- Files uploaded against business records, stored in the database so every instance sees them
+CREATE TABLE IF NOT EXISTS attachments (
+    attachment_id SERIAL PRIMARY KEY,
+    entity        TEXT NOT NULL,
+    entity_id     INTEGER NOT NULL,
+    kind          TEXT NOT NULL DEFAULT '',
+    file_name     TEXT NOT NULL,
+    content_type  TEXT NOT NULL,
+    size_bytes    INTEGER NOT NULL,
+    content       BYTEA NOT NULL,
+    uploaded_by   INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_entity ON attachments (entity, entity_id);
+
+-- When an order was delivered, set by status changes and proof of delivery
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS delivered_at TIMESTAMPTZ;
+
+-- Proof of delivery captured by the driver app; one per order
+CREATE TABLE IF NOT EXISTS proof_of_delivery (
+    order_id                INTEGER PRIMARY KEY REFERENCES orders(order_id) ON DELETE CASCADE,
+    recipient_name          TEXT NOT NULL,
+    signature_attachment_id INTEGER REFERENCES attachments(attachment_id) ON DELETE SET NULL,
+    photo_attachment_id     INTEGER REFERENCES attachments(attachment_id) ON DELETE SET NULL,
+    latitude                DOUBLE PRECISION,
+    longitude               DOUBLE PRECISION,
+    delivered_at            TIMESTAMPTZ NOT NULL,
+    captured_by             INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    created_at              TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,51 @@
+-- Inspection steps performed when stock is received. Items with a category apply only to
+-- products in that category (e.g. pressure checks for gases); items without one apply to all.
+CREATE TABLE IF NOT EXISTS receiving_checklist_items (
+    item_id    SERIAL PRIMARY KEY,
+    label      TEXT NOT NULL,
+    category   TEXT,
+    input_type TEXT NOT NULL DEFAULT 'pass_fail' CHECK (input_type IN ('pass_fail', 'number', 'text')),
+    min_value  NUMERIC(12, 3),
+    max_value  NUMERIC(12, 3),
+    unit       TEXT NOT NULL DEFAULT '',
+    required   BOOLEAN NOT NULL DEFAULT TRUE,
+    active     BOOLEAN NOT NULL DEFAULT TRUE,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (min_value IS NULL OR max_value IS NULL OR min_value <= max_value)
+);
+
+-- A delivery of stock into inventory; stock is only added when every check passed
+CREATE TABLE IF NOT EXISTS stock_receipts (
+    receipt_id      SERIAL PRIMARY KEY,
+    inventory_id    INTEGER NOT NULL REFERENCES inventory(inventory_id) ON DELETE CASCADE,
+    product_id      INTEGER NOT NULL REFERENCES products(product_id) ON DELETE CASCADE,
+    quantity        INTEGER NOT NULL CHECK (quantity > 0),
+    reference       TEXT NOT NULL DEFAULT '',
+    temperature_c   NUMERIC(6, 2),
+    condition_notes TEXT NOT NULL DEFAULT '',
+    passed          BOOLEAN NOT NULL,
+    received_by     INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    received_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_receipts_received_at ON stock_receipts (received_at);
+CREATE INDEX IF NOT EXISTS idx_stock_receipts_product ON stock_receipts (product_id, received_at);
+
+-- The checklist answers recorded with a receipt. The label and limits are copied so the
+-- record stays accurate after the checklist is edited.
+CREATE TABLE IF NOT EXISTS stock_receipt_checks (
+    check_id   SERIAL PRIMARY KEY,
+    receipt_id INTEGER NOT NULL REFERENCES stock_receipts(receipt_id) ON DELETE CASCADE,
+    item_id    INTEGER REFERENCES receiving_checklist_items(item_id) ON DELETE SET NULL,
+    label      TEXT NOT NULL,
+    input_type TEXT NOT NULL,
+    min_value  NUMERIC(12, 3),
+    max_value  NUMERIC(12, 3),
+    unit       TEXT NOT NULL DEFAULT '',
+    value      TEXT NOT NULL,
+    passed     BOOLEAN NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_receipt_checks_receipt ON stock_receipt_checks (receipt_id);
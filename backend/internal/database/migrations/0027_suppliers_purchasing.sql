@@ -0,0 +1,58 @@
+-- Suppliers that products are purchased from
+CREATE TABLE IF NOT EXISTS suppliers (
+    supplier_id  SERIAL PRIMARY KEY,
+    name         TEXT NOT NULL UNIQUE,
+    contact_name TEXT NOT NULL DEFAULT '',
+    email        TEXT NOT NULL DEFAULT '',
+    phone        TEXT NOT NULL DEFAULT '',
+    address      TEXT NOT NULL DEFAULT '',
+    active       BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- What each supplier charges for a product and how long it takes to arrive. At most one
+-- supplier per product can be marked preferred; otherwise the cheapest is chosen.
+CREATE TABLE IF NOT EXISTS supplier_products (
+    supplier_id    INTEGER NOT NULL REFERENCES suppliers(supplier_id) ON DELETE CASCADE,
+    product_id     INTEGER NOT NULL REFERENCES products(product_id) ON DELETE CASCADE,
+    supplier_sku   TEXT NOT NULL DEFAULT '',
+    unit_cost      NUMERIC(12, 2) NOT NULL CHECK (unit_cost >= 0),
+    lead_time_days INTEGER NOT NULL DEFAULT 0 CHECK (lead_time_days >= 0),
+    min_order_qty  INTEGER NOT NULL DEFAULT 1 CHECK (min_order_qty >= 1),
+    preferred      BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (supplier_id, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_supplier_products_product ON supplier_products (product_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_supplier_products_preferred ON supplier_products (product_id) WHERE preferred;
+
+-- Purchase orders placed with suppliers
+CREATE TABLE IF NOT EXISTS purchase_orders (
+    purchase_order_id SERIAL PRIMARY KEY,
+    supplier_id       INTEGER NOT NULL REFERENCES suppliers(supplier_id),
+    status            TEXT NOT NULL DEFAULT 'Draft' CHECK (status IN ('Draft', 'Ordered', 'Received', 'Cancelled')),
+    order_date        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expected_date     DATE,
+    notes             TEXT NOT NULL DEFAULT '',
+    total_amount      NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    created_by        INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_supplier ON purchase_orders (supplier_id, status);
+
+CREATE TABLE IF NOT EXISTS purchase_order_items (
+    purchase_order_item_id SERIAL PRIMARY KEY,
+    purchase_order_id      INTEGER NOT NULL REFERENCES purchase_orders(purchase_order_id) ON DELETE CASCADE,
+    product_id             INTEGER NOT NULL REFERENCES products(product_id),
+    quantity               INTEGER NOT NULL CHECK (quantity > 0),
+    unit_cost              NUMERIC(12, 2) NOT NULL CHECK (unit_cost >= 0),
+    line_total             NUMERIC(14, 2) GENERATED ALWAYS AS (quantity * unit_cost) STORED,
+    lead_time_days         INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_order_items_order ON purchase_order_items (purchase_order_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_order_items_product ON purchase_order_items (product_id);
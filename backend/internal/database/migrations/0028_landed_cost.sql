@@ -0,0 +1,49 @@
+-- What a unit of each product costs us, kept up to date as purchase orders are received
+ALTER TABLE products ADD COLUMN IF NOT EXISTS cost_price NUMERIC(12, 4);
+
+-- Application settings editable by admins
+CREATE TABLE IF NOT EXISTS settings (
+    key        TEXT PRIMARY KEY,
+    value      TEXT NOT NULL,
+    updated_by INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- How received goods change cost_price: 'moving_average' or 'fifo'
+INSERT INTO settings (key, value) VALUES ('cost_policy', 'moving_average') ON CONFLICT (key) DO NOTHING;
+
+-- Freight, duties and brokerage paid on a purchase order, spread across its lines by value,
+-- quantity or weight to give each product's landed cost
+CREATE TABLE IF NOT EXISTS purchase_order_charges (
+    charge_id         SERIAL PRIMARY KEY,
+    purchase_order_id INTEGER NOT NULL REFERENCES purchase_orders(purchase_order_id) ON DELETE CASCADE,
+    charge_type       TEXT NOT NULL CHECK (charge_type IN ('freight', 'duty', 'brokerage', 'other')),
+    description       TEXT NOT NULL DEFAULT '',
+    amount            NUMERIC(12, 2) NOT NULL CHECK (amount >= 0),
+    allocation        TEXT NOT NULL DEFAULT 'value' CHECK (allocation IN ('value', 'quantity', 'weight')),
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_order_charges_order ON purchase_order_charges (purchase_order_id);
+
+ALTER TABLE purchase_orders
+    ADD COLUMN IF NOT EXISTS received_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS received_by INTEGER REFERENCES users(user_id) ON DELETE SET NULL;
+
+ALTER TABLE purchase_order_items
+    ADD COLUMN IF NOT EXISTS received_quantity INTEGER CHECK (received_quantity >= 0),
+    ADD COLUMN IF NOT EXISTS allocated_charges NUMERIC(14, 2),
+    ADD COLUMN IF NOT EXISTS landed_unit_cost NUMERIC(12, 4);
+
+-- Each receipt of a product at its landed cost. Under FIFO the stock on hand is valued
+-- from the newest layers, since the oldest units are sold first.
+CREATE TABLE IF NOT EXISTS cost_layers (
+    layer_id               SERIAL PRIMARY KEY,
+    product_id             INTEGER NOT NULL REFERENCES products(product_id) ON DELETE CASCADE,
+    purchase_order_item_id INTEGER REFERENCES purchase_order_items(purchase_order_item_id) ON DELETE SET NULL,
+    quantity               INTEGER NOT NULL CHECK (quantity > 0),
+    unit_cost              NUMERIC(12, 4) NOT NULL,
+    received_at            TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_cost_layers_product ON cost_layers (product_id, received_at DESC);
@@ -0,0 +1,51 @@
+-- Invoices received from suppliers against purchase orders
+CREATE TABLE IF NOT EXISTS supplier_invoices (
+    invoice_id        SERIAL PRIMARY KEY,
+    supplier_id       INTEGER NOT NULL REFERENCES suppliers(supplier_id),
+    purchase_order_id INTEGER NOT NULL REFERENCES purchase_orders(purchase_order_id),
+    invoice_number    TEXT NOT NULL,
+    invoice_date      DATE NOT NULL,
+    total_amount      NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    match_status      TEXT NOT NULL DEFAULT 'pending' CHECK (match_status IN ('pending', 'matched', 'discrepancy')),
+    matched_at        TIMESTAMPTZ,
+    notes             TEXT NOT NULL DEFAULT '',
+    created_by        INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (supplier_id, invoice_number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_supplier_invoices_purchase_order ON supplier_invoices (purchase_order_id);
+
+CREATE TABLE IF NOT EXISTS supplier_invoice_items (
+    invoice_item_id        SERIAL PRIMARY KEY,
+    invoice_id             INTEGER NOT NULL REFERENCES supplier_invoices(invoice_id) ON DELETE CASCADE,
+    purchase_order_item_id INTEGER REFERENCES purchase_order_items(purchase_order_item_id) ON DELETE SET NULL,
+    product_id             INTEGER NOT NULL REFERENCES products(product_id),
+    quantity               INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price             NUMERIC(12, 2) NOT NULL CHECK (unit_price >= 0),
+    line_total             NUMERIC(14, 2) GENERATED ALWAYS AS (quantity * unit_price) STORED
+);
+
+CREATE INDEX IF NOT EXISTS idx_supplier_invoice_items_invoice ON supplier_invoice_items (invoice_id);
+CREATE INDEX IF NOT EXISTS idx_supplier_invoice_items_po_item ON supplier_invoice_items (purchase_order_item_id);
+
+-- Differences found when matching an invoice against its purchase order and the goods
+-- received. Replaced each time the invoice is matched.
+CREATE TABLE IF NOT EXISTS supplier_invoice_discrepancies (
+    discrepancy_id         SERIAL PRIMARY KEY,
+    invoice_id             INTEGER NOT NULL REFERENCES supplier_invoices(invoice_id) ON DELETE CASCADE,
+    purchase_order_item_id INTEGER REFERENCES purchase_order_items(purchase_order_item_id) ON DELETE SET NULL,
+    product_id             INTEGER REFERENCES products(product_id) ON DELETE SET NULL,
+    kind                   TEXT NOT NULL,
+    ordered_quantity       INTEGER,
+    received_quantity      INTEGER,
+    invoiced_quantity      INTEGER,
+    po_unit_cost           NUMERIC(12, 2),
+    invoiced_unit_price    NUMERIC(12, 2),
+    amount_difference      NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    detected_at            TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_supplier_invoice_discrepancies_invoice ON supplier_invoice_discrepancies (invoice_id);
+CREATE INDEX IF NOT EXISTS idx_supplier_invoice_discrepancies_detected ON supplier_invoice_discrepancies (detected_at);
@@ -0,0 +1,31 @@
+-- Purchasing budgets per department and period. Purchase orders placed for a department
+-- consume the budget covering their order date; orders that would exceed what remains are
+-- rejected or escalated for approval.
+CREATE TABLE IF NOT EXISTS purchase_budgets (
+    budget_id    SERIAL PRIMARY KEY,
+    department   TEXT NOT NULL,
+    period_start DATE NOT NULL,
+    period_end   DATE NOT NULL,
+    amount       NUMERIC(14, 2) NOT NULL CHECK (amount >= 0),
+    notes        TEXT NOT NULL DEFAULT '',
+    created_by   INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (period_end >= period_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_budgets_department ON purchase_budgets (department, period_start);
+
+ALTER TABLE purchase_orders
+    ADD COLUMN IF NOT EXISTS department    TEXT,
+    ADD COLUMN IF NOT EXISTS budget_id     INTEGER REFERENCES purchase_budgets(budget_id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS approved_by   INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS approved_at   TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS approval_note TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_budget ON purchase_orders (budget_id);
+
+-- Orders over budget wait for an admin in 'Pending Approval' and end up 'Rejected' if declined
+ALTER TABLE purchase_orders DROP CONSTRAINT IF EXISTS purchase_orders_status_check;
+ALTER TABLE purchase_orders ADD CONSTRAINT purchase_orders_status_check
+    CHECK (status IN ('Draft', 'Pending Approval', 'Ordered', 'Received', 'Cancelled', 'Rejected'));
@@ -0,0 +1,16 @@
+-- Counter sales are paid on the spot; payment_method is 'cash', 'card', 'bank_transfer' or 'e_wallet'
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS payment_method TEXT CHECK (payment_method IN ('cash', 'card', 'bank_transfer', 'e_wallet')),
+    ADD COLUMN IF NOT EXISTS amount_paid NUMERIC(14, 2),
+    ADD COLUMN IF NOT EXISTS paid_at TIMESTAMPTZ;
+
+-- Counter sales without a named customer are booked to a shared walk-in customer
+INSERT INTO customers (company_name, created_at, updated_at)
+SELECT 'Walk-in', NOW(), NOW()
+WHERE NOT EXISTS (SELECT 1 FROM customers WHERE company_name = 'Walk-in');
+
+INSERT INTO settings (key, value)
+SELECT 'walk_in_customer_id', customer_id::TEXT FROM customers WHERE company_name = 'Walk-in'
+ORDER BY customer_id
+LIMIT 1
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,29 @@
+-- Cashier shifts at the sales counter. A cashier opens a shift with the float in the drawer,
+-- counter sales made during the shift are linked to it, and closing the shift records the
+-- counted cash against what the drawer should hold.
+CREATE TABLE IF NOT EXISTS cashier_shifts (
+    shift_id      SERIAL PRIMARY KEY,
+    user_id       INTEGER NOT NULL REFERENCES users(user_id),
+    register      TEXT NOT NULL DEFAULT '',
+    status        TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'closed')),
+    opening_cash  NUMERIC(14, 2) NOT NULL CHECK (opening_cash >= 0),
+    opening_notes TEXT NOT NULL DEFAULT '',
+    opened_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    cash_counts   JSONB,
+    counted_cash  NUMERIC(14, 2) CHECK (counted_cash >= 0),
+    expected_cash NUMERIC(14, 2),
+    variance      NUMERIC(14, 2),
+    closing_notes TEXT NOT NULL DEFAULT '',
+    closed_by     INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    closed_at     TIMESTAMPTZ,
+    CHECK ((status = 'closed') = (closed_at IS NOT NULL))
+);
+
+-- A cashier works one shift at a time, and a register is used by one open shift at a time
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cashier_shifts_open_user ON cashier_shifts (user_id) WHERE status = 'open';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cashier_shifts_open_register ON cashier_shifts (register) WHERE status = 'open' AND register <> '';
+CREATE INDEX IF NOT EXISTS idx_cashier_shifts_opened_at ON cashier_shifts (opened_at);
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS shift_id INTEGER REFERENCES cashier_shifts(shift_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_orders_shift ON orders (shift_id);
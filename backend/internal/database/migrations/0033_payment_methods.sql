@@ -0,0 +1,47 @@
+-- Managed list of the ways customers pay. A method can require a reference number on every
+-- payment made with it, such as the transfer or GCash reference or the check number.
+CREATE TABLE IF NOT EXISTS payment_methods (
+    code               TEXT PRIMARY KEY CHECK (code ~ '^[a-z][a-z0-9_]*$'),
+    name               TEXT NOT NULL,
+    requires_reference BOOLEAN NOT NULL DEFAULT FALSE,
+    active             BOOLEAN NOT NULL DEFAULT TRUE,
+    sort_order         INTEGER NOT NULL DEFAULT 0,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO payment_methods (code, name, requires_reference, sort_order) VALUES
+    ('cash', 'Cash', FALSE, 1),
+    ('bank_transfer', 'Bank transfer', TRUE, 2),
+    ('gcash', 'GCash', TRUE, 3),
+    ('check', 'Check', TRUE, 4),
+    ('card', 'Card', TRUE, 5),
+    ('e_wallet', 'Other e-wallet', TRUE, 6)
+ON CONFLICT (code) DO NOTHING;
+
+-- Counter sales take any method in the list instead of a fixed set
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_payment_method_check;
+ALTER TABLE orders ADD CONSTRAINT orders_payment_method_fkey
+    FOREIGN KEY (payment_method) REFERENCES payment_methods (code);
+
+-- Payments received against an order; an order can be settled by several payments
+CREATE TABLE IF NOT EXISTS order_payments (
+    payment_id     SERIAL PRIMARY KEY,
+    order_id       INTEGER NOT NULL REFERENCES orders(order_id) ON DELETE CASCADE,
+    payment_method TEXT NOT NULL REFERENCES payment_methods (code),
+    amount         NUMERIC(14, 2) NOT NULL CHECK (amount > 0),
+    reference_no   TEXT,
+    paid_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    received_by    INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    notes          TEXT NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_payments_order ON order_payments (order_id);
+CREATE INDEX IF NOT EXISTS idx_order_payments_paid_at ON order_payments (paid_at);
+
+-- Counter sales made so far were paid in full with their method
+INSERT INTO order_payments (order_id, payment_method, amount, paid_at)
+SELECT order_id, payment_method, total_amount, paid_at
+FROM orders
+WHERE payment_method IS NOT NULL AND paid_at IS NOT NULL AND total_amount > 0;
@@ -0,0 +1,30 @@
+-- Post-dated checks received from customers. A check is held until its maturity date, then
+-- deposited; clearing records it as a payment on the order it pays for, bouncing does not.
+CREATE TABLE IF NOT EXISTS post_dated_checks (
+    check_id         SERIAL PRIMARY KEY,
+    customer_id      INTEGER NOT NULL REFERENCES customers(customer_id),
+    order_id         INTEGER REFERENCES orders(order_id) ON DELETE SET NULL,
+    check_number     TEXT NOT NULL,
+    bank             TEXT NOT NULL,
+    amount           NUMERIC(14, 2) NOT NULL CHECK (amount > 0),
+    maturity_date    DATE NOT NULL,
+    received_date    DATE NOT NULL DEFAULT CURRENT_DATE,
+    status           TEXT NOT NULL DEFAULT 'on_hand'
+                     CHECK (status IN ('on_hand', 'deposited', 'cleared', 'bounced', 'returned')),
+    deposited_date   DATE,
+    cleared_date     DATE,
+    bounced_date     DATE,
+    bounce_reason    TEXT NOT NULL DEFAULT '',
+    payment_id       INTEGER REFERENCES order_payments(payment_id) ON DELETE SET NULL,
+    reminder_sent_at TIMESTAMPTZ,
+    notes            TEXT NOT NULL DEFAULT '',
+    created_by       INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- The same check cannot be registered twice
+CREATE UNIQUE INDEX IF NOT EXISTS idx_post_dated_checks_number ON post_dated_checks (LOWER(bank), check_number);
+CREATE INDEX IF NOT EXISTS idx_post_dated_checks_customer ON post_dated_checks (customer_id);
+CREATE INDEX IF NOT EXISTS idx_post_dated_checks_order ON post_dated_checks (order_id);
+CREATE INDEX IF NOT EXISTS idx_post_dated_checks_maturity ON post_dated_checks (maturity_date) WHERE status = 'on_hand';
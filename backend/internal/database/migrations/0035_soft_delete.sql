@@ -0,0 +1,16 @@
+-- Customers, products and contacts are soft-deleted so orders and quotations keep the rows
+-- they reference. Deleted rows are hidden everywhere until restored or purged.
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE contacts ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_customers_deleted_at ON customers (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_products_deleted_at ON products (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_contacts_deleted_at ON contacts (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Offline clients drop soft-deleted products as if they had been deleted; restoring bumps
+-- updated_at so they are synced again
+DROP TRIGGER IF EXISTS products_soft_delete_tombstone ON products;
+CREATE TRIGGER products_soft_delete_tombstone AFTER UPDATE OF deleted_at ON products
+    FOR EACH ROW WHEN (OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL)
+    EXECUTE FUNCTION record_sync_tombstone('products', 'product_id');
@@ -0,0 +1,27 @@
+-- Bank statements imported from the bank's CSV export for reconciling payments received
+CREATE TABLE IF NOT EXISTS bank_statements (
+    statement_id SERIAL PRIMARY KEY,
+    bank_account TEXT NOT NULL DEFAULT '',
+    file_name    TEXT NOT NULL DEFAULT '',
+    imported_by  INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    imported_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- One transaction on a statement. Deposits are positive and withdrawals negative. A deposit
+-- is reconciled by confirming the payment it corresponds to; a payment matches one line only.
+CREATE TABLE IF NOT EXISTS bank_statement_lines (
+    line_id          SERIAL PRIMARY KEY,
+    statement_id     INTEGER NOT NULL REFERENCES bank_statements(statement_id) ON DELETE CASCADE,
+    line_no          INTEGER NOT NULL,
+    transaction_date DATE NOT NULL,
+    description      TEXT NOT NULL DEFAULT '',
+    reference        TEXT NOT NULL DEFAULT '',
+    amount           NUMERIC(14, 2) NOT NULL,
+    payment_id       INTEGER REFERENCES order_payments(payment_id) ON DELETE SET NULL,
+    matched_by       INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    matched_at       TIMESTAMPTZ,
+    UNIQUE (statement_id, line_no)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bank_statement_lines_payment ON bank_statement_lines (payment_id) WHERE payment_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_bank_statement_lines_date ON bank_statement_lines (transaction_date);
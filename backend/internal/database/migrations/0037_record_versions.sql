@@ -0,0 +1,4 @@
+-- Edit counters for optimistic concurrency: an update names the version it was made from and
+-- is rejected when someone else has changed the record since
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE quotations ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,13 @@
+-- Customers that are withholding agents pay net of expanded withholding tax (EWT) and issue
+-- a BIR Form 2307 certificate for the amount withheld. The tax withheld settles the order
+-- like cash, and the 2307 is tracked until it is received.
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS tin TEXT;
+
+ALTER TABLE order_payments
+    ADD COLUMN IF NOT EXISTS withholding_amount NUMERIC(14, 2) NOT NULL DEFAULT 0 CHECK (withholding_amount >= 0),
+    ADD COLUMN IF NOT EXISTS atc_code TEXT,
+    ADD COLUMN IF NOT EXISTS form_2307_received_on DATE,
+    ADD COLUMN IF NOT EXISTS form_2307_reference TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_order_payments_outstanding_2307 ON order_payments (paid_at)
+    WHERE withholding_amount > 0 AND form_2307_received_on IS NULL;
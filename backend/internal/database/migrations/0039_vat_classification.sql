@@ -0,0 +1,5 @@
+-- How a customer's sales are classified for VAT in the BIR sales book: vatable sales carry
+-- output VAT, while sales to exempt customers and zero-rated sales (e.g. to PEZA-registered
+-- or export customers) do not.
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS vat_classification TEXT NOT NULL DEFAULT 'vatable'
+    CHECK (vat_classification IN ('vatable', 'exempt', 'zero_rated'));
@@ -0,0 +1,40 @@
+-- Sales invoices (orders) and quotations are numbered per series without gaps. The next
+-- number is taken from document_series by the transaction that creates the document, so a
+-- failed insert gives it back, and cancelling or deleting a document records it as voided
+-- with a reason. Any other missing number is a gap to be explained.
+CREATE TABLE IF NOT EXISTS document_series (
+    series      TEXT PRIMARY KEY,
+    prefix      TEXT NOT NULL,
+    next_number INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE TABLE IF NOT EXISTS voided_documents (
+    series      TEXT NOT NULL REFERENCES document_series(series),
+    document_no INTEGER NOT NULL,
+    document_id INTEGER NOT NULL,
+    action      TEXT NOT NULL CHECK (action IN ('cancelled', 'deleted')),
+    reason      TEXT NOT NULL DEFAULT '',
+    voided_by   INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    voided_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (series, document_no)
+);
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS document_no INTEGER;
+ALTER TABLE quotations ADD COLUMN IF NOT EXISTS document_no INTEGER;
+
+-- Existing documents are numbered in the order they were created
+UPDATE orders o SET document_no = n.document_no
+FROM (SELECT order_id, ROW_NUMBER() OVER (ORDER BY order_id) AS document_no FROM orders) n
+WHERE o.order_id = n.order_id;
+
+UPDATE quotations q SET document_no = n.document_no
+FROM (SELECT quotation_id, ROW_NUMBER() OVER (ORDER BY quotation_id) AS document_no FROM quotations) n
+WHERE q.quotation_id = n.quotation_id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_document_no ON orders (document_no);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_quotations_document_no ON quotations (document_no);
+
+INSERT INTO document_series (series, prefix, next_number) VALUES
+    ('sales_invoice', 'SI', (SELECT COALESCE(MAX(document_no), 0) + 1 FROM orders)),
+    ('quotation', 'QT', (SELECT COALESCE(MAX(document_no), 0) + 1 FROM quotations))
+ON CONFLICT (series) DO NOTHING;
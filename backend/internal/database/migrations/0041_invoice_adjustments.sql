@@ -0,0 +1,34 @@
+-- Issued sales invoices are never edited. They are corrected by voiding them or by issuing a
+-- credit note against them, each requested with a reason and approved by an admin before it
+-- takes effect. A credit note takes its number from the credit_note series when approved;
+-- an approved void cancels the order and records its invoice number as voided.
+CREATE TABLE IF NOT EXISTS invoice_adjustments (
+    adjustment_id SERIAL PRIMARY KEY,
+    order_id      INTEGER NOT NULL REFERENCES orders(order_id) ON DELETE CASCADE,
+    kind          TEXT NOT NULL CHECK (kind IN ('void', 'credit_note')),
+    document_no   INTEGER UNIQUE,
+    reason        TEXT NOT NULL,
+    amount        NUMERIC(12, 2) NOT NULL CHECK (amount >= 0),
+    status        TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    requested_by  INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    requested_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    approved_by   INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    approved_at   TIMESTAMPTZ,
+    approval_note TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoice_adjustments_order ON invoice_adjustments (order_id);
+CREATE INDEX IF NOT EXISTS idx_invoice_adjustments_status ON invoice_adjustments (status);
+
+-- The order lines a credit note returns or corrects, with the part of the line's total credited
+CREATE TABLE IF NOT EXISTS invoice_adjustment_items (
+    adjustment_id INTEGER NOT NULL REFERENCES invoice_adjustments(adjustment_id) ON DELETE CASCADE,
+    order_item_id INTEGER NOT NULL REFERENCES order_items(order_item_id) ON DELETE CASCADE,
+    product_id    INTEGER NOT NULL,
+    quantity      INTEGER NOT NULL CHECK (quantity > 0),
+    amount        NUMERIC(12, 2) NOT NULL CHECK (amount >= 0),
+    PRIMARY KEY (adjustment_id, order_item_id)
+);
+
+INSERT INTO document_series (series, prefix, next_number) VALUES ('credit_note', 'CN', 1)
+ON CONFLICT (series) DO NOTHING;
@@ -0,0 +1,40 @@
+-- Invoices bill delivered orders. Each is numbered without gaps in its own series, copies
+-- the order's lines as they were billed and falls due the customer's payment terms after
+-- it is issued; 0 days means payment is due on receipt.
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS payment_terms_days INTEGER NOT NULL DEFAULT 0
+    CHECK (payment_terms_days >= 0);
+
+CREATE TABLE IF NOT EXISTS invoices (
+    invoice_id         SERIAL PRIMARY KEY,
+    document_no        INTEGER NOT NULL UNIQUE,
+    order_id           INTEGER NOT NULL UNIQUE REFERENCES orders(order_id),
+    customer_id        INTEGER NOT NULL REFERENCES customers(customer_id),
+    invoice_date       DATE NOT NULL DEFAULT CURRENT_DATE,
+    payment_terms_days INTEGER NOT NULL DEFAULT 0,
+    due_date           DATE NOT NULL,
+    subtotal           NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    delivery_fee       NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    total_amount       NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    created_by         INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoices_customer ON invoices (customer_id, invoice_date);
+CREATE INDEX IF NOT EXISTS idx_invoices_due_date ON invoices (due_date);
+
+CREATE TABLE IF NOT EXISTS invoice_items (
+    invoice_item_id SERIAL PRIMARY KEY,
+    invoice_id      INTEGER NOT NULL REFERENCES invoices(invoice_id) ON DELETE CASCADE,
+    order_item_id   INTEGER,
+    product_id      INTEGER NOT NULL,
+    description     TEXT NOT NULL,
+    quantity        INTEGER NOT NULL,
+    unit_price      NUMERIC(12, 2) NOT NULL,
+    discount        NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    line_total      NUMERIC(12, 2) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoice_items_invoice ON invoice_items (invoice_id);
+
+INSERT INTO document_series (series, prefix, next_number) VALUES ('invoice', 'INV', 1)
+ON CONFLICT (series) DO NOTHING;
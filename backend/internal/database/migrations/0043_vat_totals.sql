@@ -0,0 +1,47 @@
+-- Quotations, orders and invoices record the VAT in their totals when they are priced: the
+-- customer's VAT classification at the time, the rate applied and the total split into its
+-- net amount and VAT. Totals stay VAT-inclusive; sales to exempt and zero-rated customers
+-- carry no VAT. Records priced before this are split at the standard 12% rate.
+ALTER TABLE quotations
+    ADD COLUMN IF NOT EXISTS vat_classification TEXT NOT NULL DEFAULT 'vatable'
+        CHECK (vat_classification IN ('vatable', 'exempt', 'zero_rated')),
+    ADD COLUMN IF NOT EXISTS vat_rate NUMERIC(5, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS net_amount NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS vat_amount NUMERIC(12, 2) NOT NULL DEFAULT 0;
+
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS vat_classification TEXT NOT NULL DEFAULT 'vatable'
+        CHECK (vat_classification IN ('vatable', 'exempt', 'zero_rated')),
+    ADD COLUMN IF NOT EXISTS vat_rate NUMERIC(5, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS net_amount NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS vat_amount NUMERIC(12, 2) NOT NULL DEFAULT 0;
+
+ALTER TABLE invoices
+    ADD COLUMN IF NOT EXISTS vat_classification TEXT NOT NULL DEFAULT 'vatable',
+    ADD COLUMN IF NOT EXISTS vat_rate NUMERIC(5, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS net_amount NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS vat_amount NUMERIC(12, 2) NOT NULL DEFAULT 0;
+
+UPDATE quotations q SET
+    vat_classification = c.vat_classification,
+    vat_rate = CASE WHEN c.vat_classification = 'vatable' THEN 12 ELSE 0 END,
+    net_amount = CASE WHEN c.vat_classification = 'vatable' THEN ROUND(q.total_amount / 1.12, 2) ELSE q.total_amount END,
+    vat_amount = CASE WHEN c.vat_classification = 'vatable' THEN q.total_amount - ROUND(q.total_amount / 1.12, 2) ELSE 0 END
+FROM customers c
+WHERE c.customer_id = q.customer_id;
+
+UPDATE orders o SET
+    vat_classification = c.vat_classification,
+    vat_rate = CASE WHEN c.vat_classification = 'vatable' THEN 12 ELSE 0 END,
+    net_amount = CASE WHEN c.vat_classification = 'vatable' THEN ROUND(o.total_amount / 1.12, 2) ELSE o.total_amount END,
+    vat_amount = CASE WHEN c.vat_classification = 'vatable' THEN o.total_amount - ROUND(o.total_amount / 1.12, 2) ELSE 0 END
+FROM customers c
+WHERE c.customer_id = o.customer_id;
+
+UPDATE invoices i SET
+    vat_classification = o.vat_classification,
+    vat_rate = o.vat_rate,
+    net_amount = o.net_amount,
+    vat_amount = o.vat_amount
+FROM orders o
+WHERE o.order_id = i.order_id;
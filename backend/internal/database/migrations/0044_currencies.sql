@@ -0,0 +1,44 @@
+-- Products are priced, and quotations, orders and invoices written, in a currency of their
+-- own. Amounts stay in the document's currency; exchange_rate is the pesos one unit of it was
+-- worth when the document was priced, so reports can total documents in pesos. Rates are
+-- recorded by the day they take effect. Everything before this was in pesos.
+CREATE TABLE IF NOT EXISTS currencies (
+    code       CHAR(3) PRIMARY KEY CHECK (code = UPPER(code)),
+    name       TEXT NOT NULL,
+    symbol     TEXT NOT NULL,
+    decimals   INTEGER NOT NULL DEFAULT 2 CHECK (decimals BETWEEN 0 AND 4),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO currencies (code, name, symbol, decimals) VALUES
+    ('PHP', 'Philippine Peso', '₱', 2),
+    ('USD', 'US Dollar', '$', 2),
+    ('EUR', 'Euro', '€', 2),
+    ('JPY', 'Japanese Yen', '¥', 0),
+    ('SGD', 'Singapore Dollar', 'S$', 2)
+ON CONFLICT (code) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    exchange_rate_id SERIAL PRIMARY KEY,
+    currency_code    CHAR(3) NOT NULL REFERENCES currencies(code),
+    rate             NUMERIC(18, 6) NOT NULL CHECK (rate > 0),
+    effective_date   DATE NOT NULL,
+    created_by       INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (currency_code, effective_date)
+);
+
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'PHP' REFERENCES currencies(code);
+
+ALTER TABLE quotations
+    ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'PHP' REFERENCES currencies(code),
+    ADD COLUMN IF NOT EXISTS exchange_rate NUMERIC(18, 6) NOT NULL DEFAULT 1 CHECK (exchange_rate > 0);
+
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'PHP' REFERENCES currencies(code),
+    ADD COLUMN IF NOT EXISTS exchange_rate NUMERIC(18, 6) NOT NULL DEFAULT 1 CHECK (exchange_rate > 0);
+
+ALTER TABLE invoices
+    ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'PHP',
+    ADD COLUMN IF NOT EXISTS exchange_rate NUMERIC(18, 6) NOT NULL DEFAULT 1;
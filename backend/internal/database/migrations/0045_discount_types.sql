@@ -0,0 +1,32 @@
+-- Line discounts record how they were set instead of leaving documents to guess whether the
+-- discount column is a percentage or an amount. discount stays the amount taken off the line;
+-- discount_type says how it was worked out and discount_value is the percent or amount it was
+-- set as. Lines from before this were all entered as amounts.
+ALTER TABLE quotation_items
+    ADD COLUMN IF NOT EXISTS discount_type VARCHAR(20) NOT NULL DEFAULT 'none'
+        CHECK (discount_type IN ('none', 'percent', 'amount', 'tier', 'quantity_break')),
+    ADD COLUMN IF NOT EXISTS discount_value NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (discount_value >= 0);
+
+ALTER TABLE order_items
+    ADD COLUMN IF NOT EXISTS discount_type VARCHAR(20) NOT NULL DEFAULT 'none'
+        CHECK (discount_type IN ('none', 'percent', 'amount', 'tier', 'quantity_break')),
+    ADD COLUMN IF NOT EXISTS discount_value NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (discount_value >= 0);
+
+ALTER TABLE invoice_items
+    ADD COLUMN IF NOT EXISTS discount_type VARCHAR(20) NOT NULL DEFAULT 'none',
+    ADD COLUMN IF NOT EXISTS discount_value NUMERIC(12, 2) NOT NULL DEFAULT 0;
+
+UPDATE quotation_items SET discount_type = 'amount', discount_value = discount WHERE discount > 0 AND discount_type = 'none';
+UPDATE order_items SET discount_type = 'amount', discount_value = discount WHERE discount > 0 AND discount_type = 'none';
+UPDATE invoice_items SET discount_type = 'amount', discount_value = discount WHERE discount > 0 AND discount_type = 'none';
+
+-- Quantity breaks discount a product's lines by a percentage from a minimum quantity; the
+-- break with the highest minimum the line reaches applies
+CREATE TABLE IF NOT EXISTS quantity_breaks (
+    quantity_break_id SERIAL PRIMARY KEY,
+    product_id        INTEGER NOT NULL REFERENCES products(product_id) ON DELETE CASCADE,
+    min_quantity      INTEGER NOT NULL CHECK (min_quantity > 1),
+    discount_percent  NUMERIC(5, 2) NOT NULL CHECK (discount_percent > 0 AND discount_percent <= 100),
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (product_id, min_quantity)
+);
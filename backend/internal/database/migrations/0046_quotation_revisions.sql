@@ -0,0 +1,17 @@
+-- Revising a quotation saves the new content as another quotation under the same number, so
+-- every revision keeps its own items and totals. original_quotation_id links a revision to
+-- the first quotation of its chain (NULL on that quotation) and revision counts from 1.
+-- accepted_at marks the one revision of a chain the customer accepted.
+ALTER TABLE quotations
+    ADD COLUMN IF NOT EXISTS original_quotation_id INTEGER REFERENCES quotations(quotation_id),
+    ADD COLUMN IF NOT EXISTS revision INTEGER NOT NULL DEFAULT 1 CHECK (revision > 0),
+    ADD COLUMN IF NOT EXISTS accepted_at TIMESTAMPTZ;
+
+-- Revisions share their original's quotation number
+DROP INDEX IF EXISTS idx_quotations_document_no;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_quotations_document_no ON quotations (document_no, revision);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_quotations_revision
+    ON quotations ((COALESCE(original_quotation_id, quotation_id)), revision);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_quotations_accepted
+    ON quotations ((COALESCE(original_quotation_id, quotation_id))) WHERE accepted_at IS NOT NULL;
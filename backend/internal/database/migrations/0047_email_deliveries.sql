@@ -0,0 +1,16 @@
+-- Every email sent about a document, such as a quotation mailed to a customer contact, and
+-- whether the SMTP server accepted it
+CREATE TABLE IF NOT EXISTS email_deliveries (
+    email_delivery_id SERIAL PRIMARY KEY,
+    document_type     TEXT NOT NULL,
+    document_id       INTEGER NOT NULL,
+    contact_id        INTEGER REFERENCES contacts(contact_id) ON DELETE SET NULL,
+    recipient         TEXT NOT NULL,
+    subject           TEXT NOT NULL,
+    status            TEXT NOT NULL,
+    error             TEXT,
+    sent_by           INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_deliveries_document ON email_deliveries (document_type, document_id, created_at DESC);
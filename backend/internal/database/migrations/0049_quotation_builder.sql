@@ -0,0 +1,30 @@
+-- Quotations built a step at a time: a session holds the header and the lines added so far
+-- until it is submitted as a quotation. Sessions untouched until expires_at are abandoned
+-- and deleted with their lines.
+CREATE TABLE IF NOT EXISTS quotation_builder_sessions (
+    builder_session_id SERIAL PRIMARY KEY,
+    customer_id        INTEGER NOT NULL REFERENCES customers(customer_id) ON DELETE CASCADE,
+    currency           TEXT NOT NULL DEFAULT '',
+    source             TEXT,
+    validity_date      DATE,
+    created_by         INTEGER REFERENCES users(user_id) ON DELETE CASCADE,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at         TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_quotation_builder_sessions_expires ON quotation_builder_sessions (expires_at);
+
+CREATE TABLE IF NOT EXISTS quotation_builder_lines (
+    builder_line_id    SERIAL PRIMARY KEY,
+    builder_session_id INTEGER NOT NULL REFERENCES quotation_builder_sessions(builder_session_id) ON DELETE CASCADE,
+    product_id         INTEGER NOT NULL REFERENCES products(product_id) ON DELETE CASCADE,
+    quantity           INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price         NUMERIC(12, 2),
+    discount           NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    discount_type      TEXT NOT NULL DEFAULT '',
+    discount_value     NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_quotation_builder_lines_session ON quotation_builder_lines (builder_session_id, builder_line_id);
@@ -0,0 +1,31 @@
+-- Outbound webhooks: endpoints subscribe to business events and every callback is logged as
+-- a delivery, retried with backoff until the endpoint accepts it or attempts run out
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    webhook_endpoint_id SERIAL PRIMARY KEY,
+    url                 TEXT NOT NULL,
+    secret              TEXT NOT NULL,
+    events              JSONB NOT NULL DEFAULT '[]',
+    description         TEXT NOT NULL DEFAULT '',
+    active              BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    webhook_delivery_id SERIAL PRIMARY KEY,
+    webhook_endpoint_id INTEGER NOT NULL REFERENCES webhook_endpoints(webhook_endpoint_id) ON DELETE CASCADE,
+    event               TEXT NOT NULL,
+    event_id            TEXT NOT NULL,
+    payload             JSONB NOT NULL,
+    status              TEXT NOT NULL,
+    attempts            INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at     TIMESTAMPTZ,
+    response_status     INTEGER,
+    response_body       TEXT,
+    error               TEXT,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at        TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_endpoint ON webhook_deliveries (webhook_endpoint_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'Pending';
@@ -0,0 +1,9 @@
+-- Payments reported by online payment gateways, one row per gateway payment so the gateway
+-- retrying or sending several events for the same payment records it only once
+CREATE TABLE IF NOT EXISTS payment_gateway_payments (
+    provider    TEXT NOT NULL,
+    reference   TEXT NOT NULL,
+    payment_id  INTEGER REFERENCES order_payments(payment_id) ON DELETE SET NULL,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (provider, reference)
+);
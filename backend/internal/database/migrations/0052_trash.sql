@@ -0,0 +1,5 @@
+-- Find deleted customers, contacts and products quickly for the trash and its purge
+CREATE INDEX IF NOT EXISTS idx_customers_deleted ON customers (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_contacts_deleted ON contacts (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_products_deleted ON products (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_deleted_records_deleted ON deleted_records (deleted_at);
@@ -0,0 +1,14 @@
+-- Users watching a customer or quotation are notified when its watched fields change. An
+-- empty fields list watches the entity's default key fields.
+CREATE TABLE IF NOT EXISTS record_watches (
+    record_watch_id SERIAL PRIMARY KEY,
+    user_id         INTEGER NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    entity          TEXT NOT NULL,
+    entity_id       INTEGER NOT NULL,
+    fields          JSONB NOT NULL DEFAULT '[]',
+    email           BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, entity, entity_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_record_watches_entity ON record_watches (entity, entity_id);
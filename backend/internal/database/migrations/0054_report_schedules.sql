@@ -0,0 +1,24 @@
+-- Reports emailed on a schedule: each run attaches the CSV exports of the listed reports and
+-- sends them to the recipients, then moves next_run_at to the following run
+CREATE TABLE IF NOT EXISTS report_schedules (
+    report_schedule_id SERIAL PRIMARY KEY,
+    name               TEXT NOT NULL,
+    reports            JSONB NOT NULL DEFAULT '[]',
+    recipients         JSONB NOT NULL DEFAULT '[]',
+    frequency          TEXT NOT NULL,
+    weekday            INTEGER CHECK (weekday BETWEEN 0 AND 6),
+    day_of_month       INTEGER CHECK (day_of_month BETWEEN 1 AND 28),
+    hour               INTEGER NOT NULL CHECK (hour BETWEEN 0 AND 23),
+    minute             INTEGER NOT NULL DEFAULT 0 CHECK (minute BETWEEN 0 AND 59),
+    timezone           TEXT NOT NULL DEFAULT '',
+    days               INTEGER NOT NULL DEFAULT 7 CHECK (days > 0),
+    active             BOOLEAN NOT NULL DEFAULT TRUE,
+    next_run_at        TIMESTAMPTZ NOT NULL,
+    last_run_at        TIMESTAMPTZ,
+    last_error         TEXT,
+    created_by         INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_report_schedules_due ON report_schedules (next_run_at) WHERE active;
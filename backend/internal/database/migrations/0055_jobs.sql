@@ -0,0 +1,18 @@
+-- Background jobs: work queued to run outside the request that asked for it. Pending jobs
+-- run at run_at and are retried with backoff when they fail; jobs that fail on every
+-- attempt are kept as Failed, the dead letters, until an admin retries or discards them.
+CREATE TABLE IF NOT EXISTS jobs (
+    job_id       SERIAL PRIMARY KEY,
+    type         TEXT NOT NULL,
+    payload      JSONB NOT NULL DEFAULT '{}',
+    status       TEXT NOT NULL,
+    attempts     INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL,
+    run_at       TIMESTAMPTZ,
+    error        TEXT,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at  TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_due ON jobs (run_at) WHERE status = 'Pending';
+CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs (status, created_at DESC);
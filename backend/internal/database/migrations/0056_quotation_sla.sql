@@ -0,0 +1,26 @@
+-- Quotation turnaround is measured from when the customer's request was received to when
+-- the quotation was first sent to them. Requests received before this migration are taken
+-- to have arrived when their quotation was created, and quotations already emailed to
+-- have been sent with their first delivery.
+ALTER TABLE quotations
+    ADD COLUMN IF NOT EXISTS request_received_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS sent_at TIMESTAMPTZ;
+
+UPDATE quotations SET request_received_at = created_at WHERE request_received_at IS NULL;
+
+UPDATE quotations q SET sent_at = d.sent_at
+FROM (
+    SELECT document_id, MIN(created_at) AS sent_at
+    FROM email_deliveries
+    WHERE document_type = 'quotation' AND status = 'Sent'
+    GROUP BY document_id
+) d
+WHERE d.document_id = q.quotation_id AND q.sent_at IS NULL;
+
+ALTER TABLE quotations
+    ALTER COLUMN request_received_at SET DEFAULT NOW(),
+    ALTER COLUMN request_received_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_quotations_awaiting_response
+    ON quotations (request_received_at) WHERE sent_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_quotations_request_received ON quotations (request_received_at);
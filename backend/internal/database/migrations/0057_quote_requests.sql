@@ -0,0 +1,29 @@
+-- Quote requests sent in from the public "Request a Quote" form. Each is a sales lead:
+-- customer_id links it to the customer whose email it came from, if any, and quotation_id
+-- to the quotation prepared for it. Files sent with a request are attachments with entity
+-- 'quote_request'.
+CREATE TABLE IF NOT EXISTS quote_requests (
+    quote_request_id SERIAL PRIMARY KEY,
+    company_name     TEXT NOT NULL,
+    contact_name     TEXT NOT NULL,
+    email            TEXT NOT NULL,
+    phone            TEXT,
+    message          TEXT NOT NULL,
+    status           TEXT NOT NULL DEFAULT 'New',
+    customer_id      INTEGER REFERENCES customers(customer_id) ON DELETE SET NULL,
+    quotation_id     INTEGER REFERENCES quotations(quotation_id) ON DELETE SET NULL,
+    source_ip        TEXT,
+    handled_by       INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    handled_at       TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_quote_requests_status ON quote_requests (status, created_at DESC);
+
+-- Quotations prepared for quote requests came in through the website
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_source_check;
+ALTER TABLE orders ADD CONSTRAINT orders_source_check
+    CHECK (source IN ('phone', 'walk_in', 'email', 'webshop', 'rep_visit', 'website'));
+ALTER TABLE quotations DROP CONSTRAINT IF EXISTS quotations_source_check;
+ALTER TABLE quotations ADD CONSTRAINT quotations_source_check
+    CHECK (source IN ('phone', 'walk_in', 'email', 'webshop', 'rep_visit', 'website'));
@@ -0,0 +1,18 @@
+-- Links for downloading a quotation, order or invoice PDF or an attachment without logging
+-- in, such as from an email. Only a hash of the link's token is stored. A link stops working
+-- once it expires or is revoked, and single-use links after their first download.
+CREATE TABLE IF NOT EXISTS download_links (
+    download_link_id SERIAL PRIMARY KEY,
+    token_hash       TEXT NOT NULL UNIQUE,
+    document_type    TEXT NOT NULL CHECK (document_type IN ('quotation', 'order', 'invoice', 'attachment')),
+    document_id      INTEGER NOT NULL,
+    single_use       BOOLEAN NOT NULL DEFAULT FALSE,
+    expires_at       TIMESTAMPTZ NOT NULL,
+    download_count   INTEGER NOT NULL DEFAULT 0,
+    last_used_at     TIMESTAMPTZ,
+    revoked_at       TIMESTAMPTZ,
+    created_by       INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_download_links_document ON download_links (document_type, document_id);
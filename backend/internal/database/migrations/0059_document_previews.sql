@@ -0,0 +1,17 @@
+-- Small JPEG previews of attachments and of the quotation, order and invoice PDFs, made by
+-- background jobs so document cards can show them without downloading the whole file. A
+-- preview is Pending while its job runs, Ready once made and Unavailable when no preview can
+-- be made, such as of a PDF when pdftoppm is not installed; message says why. Previews made
+-- before the document last changed are served until a new one replaces them.
+CREATE TABLE IF NOT EXISTS document_previews (
+    entity       TEXT NOT NULL CHECK (entity IN ('quotation', 'order', 'invoice', 'attachment')),
+    entity_id    INTEGER NOT NULL,
+    status       TEXT NOT NULL DEFAULT 'Pending' CHECK (status IN ('Pending', 'Ready', 'Unavailable')),
+    content      BYTEA,
+    width        INTEGER NOT NULL DEFAULT 0,
+    height       INTEGER NOT NULL DEFAULT 0,
+    message      TEXT,
+    requested_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    generated_at TIMESTAMPTZ,
+    PRIMARY KEY (entity, entity_id)
+);
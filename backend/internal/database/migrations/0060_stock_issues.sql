@@ -0,0 +1,21 @@
+-- Stock taken out of inventory for an order as it ships; the counterpart of stock_receipts
+CREATE TABLE IF NOT EXISTS stock_issues (
+    issue_id     SERIAL PRIMARY KEY,
+    inventory_id INTEGER NOT NULL REFERENCES inventory(inventory_id) ON DELETE CASCADE,
+    product_id   INTEGER NOT NULL REFERENCES products(product_id) ON DELETE CASCADE,
+    order_id     INTEGER NOT NULL REFERENCES orders(order_id) ON DELETE CASCADE,
+    quantity     INTEGER NOT NULL CHECK (quantity > 0),
+    reference    TEXT NOT NULL DEFAULT '',
+    issued_by    INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    issued_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_issues_order ON stock_issues (order_id);
+CREATE INDEX IF NOT EXISTS idx_stock_issues_product ON stock_issues (product_id, issued_at);
+
+-- When an order's items were taken out of stock, so they are only taken once
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS stock_issued_at TIMESTAMPTZ;
+
+-- Orders that had shipped before stock was tracked are not taken out again
+UPDATE orders SET stock_issued_at = COALESCE(delivered_at, updated_at)
+WHERE status IN ('Shipped', 'Delivered') AND stock_issued_at IS NULL;
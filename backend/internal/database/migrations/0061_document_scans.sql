@@ -0,0 +1,28 @@
+-- Supplier invoices and customer purchase orders uploaded for OCR. The text read from the
+-- file is parsed into a draft (party, document number and date, total and lines) that is
+-- reviewed and corrected before it becomes a supplier invoice or an order, document_id.
+-- The uploaded file is an attachment with entity 'document_scan'.
+CREATE TABLE IF NOT EXISTS document_scans (
+    scan_id           SERIAL PRIMARY KEY,
+    kind              TEXT NOT NULL CHECK (kind IN ('supplier_invoice', 'customer_po')),
+    status            TEXT NOT NULL DEFAULT 'Processing',
+    attachment_id     INTEGER REFERENCES attachments(attachment_id) ON DELETE SET NULL,
+    provider          TEXT NOT NULL DEFAULT '',
+    text              TEXT NOT NULL DEFAULT '',
+    supplier_id       INTEGER REFERENCES suppliers(supplier_id) ON DELETE SET NULL,
+    purchase_order_id INTEGER REFERENCES purchase_orders(purchase_order_id) ON DELETE SET NULL,
+    customer_id       INTEGER REFERENCES customers(customer_id) ON DELETE SET NULL,
+    document_number   TEXT,
+    document_date     DATE,
+    total_amount      NUMERIC(14, 2),
+    lines             JSONB NOT NULL DEFAULT '[]',
+    message           TEXT,
+    document_id       INTEGER,
+    created_by        INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    confirmed_by      INTEGER REFERENCES users(user_id) ON DELETE SET NULL,
+    confirmed_at      TIMESTAMPTZ,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_document_scans_status ON document_scans (status, created_at DESC);
@@ -0,0 +1,23 @@
+-- Stock on hand when the anomaly check last looked at each inventory record. A drop since
+-- then that neither order issues nor receipts explain is flagged.
+CREATE TABLE IF NOT EXISTS stock_snapshots (
+    inventory_id INTEGER PRIMARY KEY REFERENCES inventory(inventory_id) ON DELETE CASCADE,
+    stock        INTEGER NOT NULL,
+    taken_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Anomalies flagged in sales and stock. The same metric is flagged at most once a day for
+-- the same inventory record, or once a day for sales, so users are only notified once.
+CREATE TABLE IF NOT EXISTS anomalies (
+    anomaly_id   SERIAL PRIMARY KEY,
+    metric       TEXT NOT NULL,
+    inventory_id INTEGER REFERENCES inventory(inventory_id) ON DELETE CASCADE,
+    day          DATE NOT NULL,
+    observed     NUMERIC(14, 2) NOT NULL,
+    expected     NUMERIC(14, 2) NOT NULL,
+    message      TEXT NOT NULL,
+    detected_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_anomalies_once ON anomalies (metric, COALESCE(inventory_id, 0), day);
+CREATE INDEX IF NOT EXISTS idx_anomalies_detected_at ON anomalies (detected_at);
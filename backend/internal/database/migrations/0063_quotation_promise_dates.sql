@@ -0,0 +1,8 @@
+-- The date each quotation line can be delivered by, promised from the stock on hand, the
+-- purchase orders on their way or the supplier's lead time, as promise_source says.
+-- promised_at is when it was worked out; lines are promised again on request.
+ALTER TABLE quotation_items
+    ADD COLUMN IF NOT EXISTS promise_date DATE,
+    ADD COLUMN IF NOT EXISTS promise_source VARCHAR(20)
+        CHECK (promise_source IN ('stock', 'incoming', 'lead_time', 'unavailable')),
+    ADD COLUMN IF NOT EXISTS promised_at TIMESTAMPTZ;
@@ -0,0 +1,280 @@
+// Admin panel: plain DOM code over the admin API routes, authenticated by the session cookie
+
+"use strict";
+
+const loaders = {
+	"print-jobs": loadPrintJobs,
+	"jobs": loadJobs,
+	"rules": loadRules,
+	"cost-policy": loadCostPolicy,
+	"templates": loadTemplates,
+};
+
+// api calls an API route and returns its JSON body, throwing the API's error message
+async function api(method, path, body) {
+	const options = { method, credentials: "same-origin", headers: { Accept: "application/json" } };
+	if (body !== undefined) {
+		options.headers["Content-Type"] = "application/json";
+		options.body = JSON.stringify(body);
+	}
+	const response = await fetch(path, options);
+	const data = await response.json().catch(() => null);
+	if (!response.ok) {
+		const message = data && data.error ? data.error : response.statusText;
+		const detail = data && data.details && data.details.error ? ": " + data.details.error : "";
+		throw new Error(message + detail);
+	}
+	return data;
+}
+
+// showMessage reports the outcome of an action above the current section
+function showMessage(text, isError) {
+	const message = document.getElementById("message");
+	message.textContent = text;
+	message.classList.toggle("error", Boolean(isError));
+	message.classList.remove("hidden");
+}
+
+function clearMessage() {
+	document.getElementById("message").classList.add("hidden");
+}
+
+// run performs an action, reporting its failure
+async function run(action) {
+	try {
+		await action();
+	} catch (err) {
+		showMessage(err.message, true);
+	}
+}
+
+// cell appends a table cell holding text or an element
+function cell(row, content, className) {
+	const td = document.createElement("td");
+	if (content instanceof Node) {
+		td.appendChild(content);
+	} else {
+		td.textContent = content === null || content === undefined ? "" : String(content);
+	}
+	if (className) {
+		td.className = className;
+	}
+	row.appendChild(td);
+	return td;
+}
+
+function button(label, onClick) {
+	const element = document.createElement("button");
+	element.type = "button";
+	element.textContent = label;
+	element.addEventListener("click", onClick);
+	return element;
+}
+
+function formatTime(value) {
+	return value ? new Date(value).toLocaleString() : "";
+}
+
+async function loadPrintJobs() {
+	const status = document.getElementById("print-job-status").value;
+	const jobs = await api("GET", "/api/print-jobs");
+	const rows = document.getElementById("print-job-rows");
+	rows.replaceChildren();
+	for (const job of jobs) {
+		if (status && job.status !== status) {
+			continue;
+		}
+		const row = document.createElement("tr");
+		cell(row, job.print_job_id);
+		cell(row, job.printer_name);
+		cell(row, job.document_type);
+		cell(row, job.order_id);
+		cell(row, job.copies);
+		cell(row, job.status, "status-" + job.status);
+		cell(row, job.error);
+		cell(row, formatTime(job.created_at));
+		cell(row, formatTime(job.printed_at));
+		rows.appendChild(row);
+	}
+}
+
+// jobPageSize is how many of the newest background jobs the jobs section lists
+const jobPageSize = 100;
+
+async function loadJobs() {
+	const status = document.getElementById("job-status").value;
+	const query = "?limit=" + jobPageSize + (status ? "&status=" + encodeURIComponent(status) : "");
+	const page = await api("GET", "/api/admin/jobs" + query);
+	document.getElementById("job-count").textContent = page.pagination.total > page.data.length
+		? "Newest " + page.data.length + " of " + page.pagination.total
+		: "";
+	const rows = document.getElementById("job-rows");
+	rows.replaceChildren();
+	for (const job of page.data) {
+		const row = document.createElement("tr");
+		cell(row, job.job_id);
+		cell(row, job.type);
+		cell(row, job.status, "status-" + job.status);
+		cell(row, job.attempts + " / " + job.max_attempts);
+		cell(row, job.error);
+		cell(row, formatTime(job.created_at));
+		cell(row, job.status === "Pending" ? formatTime(job.run_at) : formatTime(job.finished_at));
+
+		const actions = document.createElement("div");
+		actions.append(button("View", () => run(() => openJob(job.job_id))));
+		if (job.status === "Failed") {
+			actions.append(button("Retry", () => run(async () => {
+				await api("POST", "/api/admin/jobs/" + job.job_id + "/retry");
+				showMessage("Queued job " + job.job_id + " to run again");
+				await loadJobs();
+			})));
+		}
+		if (job.status !== "Pending") {
+			actions.append(button("Discard", () => run(async () => {
+				if (!confirm("Discard job " + job.job_id + "?")) {
+					return;
+				}
+				await api("DELETE", "/api/admin/jobs/" + job.job_id);
+				showMessage("Discarded job " + job.job_id);
+				document.getElementById("job-detail").classList.add("hidden");
+				await loadJobs();
+			})));
+		}
+		cell(row, actions);
+		rows.appendChild(row);
+	}
+}
+
+// openJob shows a job's payload and last error below the job list
+async function openJob(id) {
+	const job = await api("GET", "/api/admin/jobs/" + id);
+	document.getElementById("job-detail-title").textContent = "Job " + job.job_id + " (" + job.type + ", " + job.status + ")";
+	document.getElementById("job-detail-payload").textContent = JSON.stringify(job.payload, null, 2);
+	document.getElementById("job-detail-error").textContent = job.error || "";
+	document.getElementById("job-detail").classList.remove("hidden");
+}
+
+async function loadRules() {
+	const rules = await api("GET", "/api/rules");
+	const rows = document.getElementById("rule-rows");
+	rows.replaceChildren();
+	for (const rule of rules) {
+		const row = document.createElement("tr");
+
+		const name = document.createElement("div");
+		name.textContent = rule.key;
+		const description = document.createElement("div");
+		description.className = "description";
+		description.textContent = rule.description;
+		const label = document.createElement("div");
+		label.append(name, description);
+		cell(row, label);
+
+		const input = document.createElement("input");
+		input.type = "number";
+		input.step = rule.type === "int" ? "1" : "any";
+		input.min = rule.min;
+		if (rule.max !== undefined && rule.max !== null) {
+			input.max = rule.max;
+		}
+		input.value = rule.value;
+		cell(row, input);
+
+		cell(row, rule.default);
+		cell(row, rule.overridden ? formatTime(rule.updated_at) : "default");
+
+		const actions = document.createElement("div");
+		actions.append(
+			button("Save", () => run(async () => {
+				await api("PUT", "/api/rules/" + encodeURIComponent(rule.key), { value: Number(input.value) });
+				showMessage("Saved " + rule.key);
+				await loadRules();
+			})),
+			button("Reset", () => run(async () => {
+				await api("DELETE", "/api/rules/" + encodeURIComponent(rule.key));
+				showMessage("Reset " + rule.key + " to its default");
+				await loadRules();
+			})),
+		);
+		cell(row, actions);
+		rows.appendChild(row);
+	}
+}
+
+async function loadCostPolicy() {
+	const data = await api("GET", "/api/settings/cost-policy");
+	document.getElementById("cost-policy-value").value = data.policy;
+}
+
+async function loadTemplates() {
+	const templates = await api("GET", "/api/templates");
+	const list = document.getElementById("template-list");
+	list.replaceChildren();
+	for (const template of templates) {
+		const item = document.createElement("li");
+		item.textContent = template.name;
+		item.addEventListener("click", () => run(async () => {
+			for (const other of list.children) {
+				other.classList.toggle("active", other === item);
+			}
+			await openTemplate(template.name);
+		}));
+		list.appendChild(item);
+	}
+}
+
+// templatePath is the API route of a template, keeping the slashes of its name
+function templatePath(name) {
+	return "/api/templates/" + name.split("/").map(encodeURIComponent).join("/");
+}
+
+async function openTemplate(name) {
+	const template = await api("GET", templatePath(name));
+	const form = document.getElementById("template-form");
+	form.dataset.name = template.name;
+	document.getElementById("template-name").textContent = template.name + " (changed " + formatTime(template.updated_at) + ")";
+	document.getElementById("template-content").value = template.content;
+	form.classList.remove("hidden");
+}
+
+function showSection(id) {
+	clearMessage();
+	for (const section of document.querySelectorAll("main section")) {
+		section.classList.toggle("hidden", section.id !== id);
+	}
+	for (const tab of document.querySelectorAll("nav button")) {
+		tab.classList.toggle("active", tab.dataset.section === id);
+	}
+	run(loaders[id]);
+}
+
+document.querySelectorAll("nav button").forEach((tab) => {
+	tab.addEventListener("click", () => showSection(tab.dataset.section));
+});
+
+document.getElementById("refresh-print-jobs").addEventListener("click", () => run(loadPrintJobs));
+document.getElementById("print-job-status").addEventListener("change", () => run(loadPrintJobs));
+document.getElementById("refresh-jobs").addEventListener("click", () => run(loadJobs));
+document.getElementById("job-status").addEventListener("change", () => run(loadJobs));
+
+document.getElementById("cost-policy-form").addEventListener("submit", (event) => {
+	event.preventDefault();
+	run(async () => {
+		const policy = document.getElementById("cost-policy-value").value;
+		await api("PUT", "/api/settings/cost-policy", { policy });
+		showMessage("Cost policy set to " + policy);
+	});
+});
+
+document.getElementById("template-form").addEventListener("submit", (event) => {
+	event.preventDefault();
+	const form = event.target;
+	run(async () => {
+		const content = document.getElementById("template-content").value;
+		await api("PUT", templatePath(form.dataset.name), { content });
+		showMessage("Saved " + form.dataset.name);
+		await openTemplate(form.dataset.name);
+	});
+});
+
+showSection("print-jobs");
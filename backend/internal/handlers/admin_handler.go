@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"embed"
+
+	"github.com/labstack/echo/v4"
+)
+
+// adminAssets is the admin panel: a static page that works through the admin API routes
+//
+//go:embed admin
+var adminAssets embed.FS
+
+// AdminHandler serves the admin panel at /admin, for the operational tasks the frontend does
+// not cover yet: the print queue, background jobs, business rules, the cost policy and
+// document templates. There are no feature flags to manage; the business rules are the
+// settings admins tune at runtime.
+type AdminHandler struct {
+	page   echo.HandlerFunc
+	assets echo.HandlerFunc
+}
+
+// NewAdminHandler creates a new admin panel handler
+func NewAdminHandler() *AdminHandler {
+	assets := echo.MustSubFS(adminAssets, "admin")
+	return &AdminHandler{
+		page:   echo.StaticFileHandler("index.html", assets),
+		assets: echo.StaticDirectoryHandler(assets, false),
+	}
+}
+
+// GetPanel serves the admin panel's page
+func (h *AdminHandler) GetPanel(c echo.Context) error {
+	return h.page(c)
+}
+
+// GetAsset serves the admin panel's scripts and styles
+func (h *AdminHandler) GetAsset(c echo.Context) error {
+	return h.assets(c)
+}
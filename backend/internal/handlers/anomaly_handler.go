@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/labstack/echo/v4"
+)
+
+// AnomalyHandler handles HTTP requests for the anomalies the scheduled anomaly check flagged.
+// Users are notified of each as it is flagged; its sensitivity is set by the anomaly_* rules.
+type AnomalyHandler struct {
+	anomalyRepo *repository.AnomalyRepository
+}
+
+// NewAnomalyHandler creates a new anomaly handler
+func NewAnomalyHandler(anomalyRepo *repository.AnomalyRepository) *AnomalyHandler {
+	return &AnomalyHandler{
+		anomalyRepo: anomalyRepo,
+	}
+}
+
+// GetAnomalies returns flagged anomalies, newest first. ?metric= limits them to sales_drop or
+// stock_drop.
+func (h *AnomalyHandler) GetAnomalies(c echo.Context) error {
+	metric := c.QueryParam("metric")
+	if metric != "" && !containsString(models.AnomalyMetrics, metric) {
+		return models.NewAPIError(http.StatusBadRequest, "metric must be one of: "+strings.Join(models.AnomalyMetrics, ", "))
+	}
+
+	page, paged, message := parsePage(c)
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	anomalies, total, err := h.anomalyRepo.GetAll(c.Request().Context(), metric, page)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve anomalies")
+	}
+
+	return jsonPage(c, http.StatusOK, anomalies, page, paged, total)
+}
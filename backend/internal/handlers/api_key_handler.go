@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// APIKeyHandler handles HTTP requests for API keys and their quotas
+type APIKeyHandler struct {
+	apiKeyRepo    *repository.APIKeyRepository
+	apiKeyService *services.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new API key handler with the provided repository and service
+func NewAPIKeyHandler(apiKeyRepo *repository.APIKeyRepository, apiKeyService *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyRepo:    apiKeyRepo,
+		apiKeyService: apiKeyService,
+	}
+}
+
+// apiKeyRequest is the body for creating or updating an API key
+type apiKeyRequest struct {
+	Name       string           `json:"name"`
+	DailyQuota *int             `json:"daily_quota" validate:"omitnil,gte=0"`
+	Scopes     models.APIScopes `json:"scopes"`
+}
+
+// checkScopes returns a validation message for malformed scopes
+func checkScopes(scopes models.APIScopes) string {
+	if len(scopes) == 0 {
+		return "At least one scope is required"
+	}
+	for _, scope := range scopes {
+		if !models.ValidAPIScope(scope) {
+			return "Invalid scope \"" + scope + "\". Use \"*\" or \"<resource>:read\", \"<resource>:write\" or \"<resource>:*\""
+		}
+	}
+	return ""
+}
+
+// GetAPIKeys returns all API keys without their secrets
+func (h *APIKeyHandler) GetAPIKeys(c echo.Context) error {
+	keys, err := h.apiKeyRepo.GetAll(c.Request().Context())
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve API keys")
+	}
+
+	return jsonList(c, http.StatusOK, keys)
+}
+
+// GetAPIKey returns a single API key without its secret
+func (h *APIKeyHandler) GetAPIKey(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid API key ID")
+	}
+
+	key, err := h.apiKeyRepo.GetByID(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "API key not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve API key")
+	}
+
+	return c.JSON(http.StatusOK, key)
+}
+
+// CreateAPIKey issues a new API key and returns its secret, which is shown only once
+func (h *APIKeyHandler) CreateAPIKey(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req apiKeyRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return models.NewAPIError(http.StatusBadRequest, "API key name is required")
+	}
+
+	quota := 10000
+	if req.DailyQuota != nil {
+		quota = *req.DailyQuota
+	}
+
+	// Keys created without scopes get full access, as keys did before scopes existed
+	if req.Scopes == nil {
+		req.Scopes = models.APIScopes{models.APIScopeAll}
+	}
+	if message := checkScopes(req.Scopes); message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	key, secret, err := h.apiKeyService.Create(ctx, req.Name, quota, req.Scopes)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to create API key")
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"api_key": key,
+		"secret":  secret,
+	})
+}
+
+// UpdateQuota changes an API key's daily quota; 0 means unlimited
+func (h *APIKeyHandler) UpdateQuota(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid API key ID")
+	}
+
+	var req apiKeyRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+	if req.DailyQuota == nil {
+		return models.NewAPIError(http.StatusBadRequest, "A non-negative daily_quota is required")
+	}
+
+	if err := h.apiKeyRepo.UpdateQuota(ctx, id, *req.DailyQuota); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "API key not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update quota")
+	}
+
+	key, err := h.apiKeyRepo.GetByID(ctx, id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve API key")
+	}
+
+	return c.JSON(http.StatusOK, key)
+}
+
+// UpdateAPIKey changes an API key's name, quota and scopes. Omitted fields are left unchanged.
+func (h *APIKeyHandler) UpdateAPIKey(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid API key ID")
+	}
+
+	var req apiKeyRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	key, err := h.apiKeyRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "API key not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve API key")
+	}
+
+	if name := strings.TrimSpace(req.Name); name != "" {
+		key.Name = name
+	}
+	if req.DailyQuota != nil {
+		key.DailyQuota = *req.DailyQuota
+	}
+	if req.Scopes != nil {
+		if message := checkScopes(req.Scopes); message != "" {
+			return models.NewAPIError(http.StatusBadRequest, message)
+		}
+		key.Scopes = req.Scopes
+	}
+
+	if err := h.apiKeyRepo.Update(ctx, &key); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "API key not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update API key")
+	}
+
+	return c.JSON(http.StatusOK, key)
+}
+
+// RevokeAPIKey disables an API key; it stays listed with its usage history
+func (h *APIKeyHandler) RevokeAPIKey(c echo.Context) error {
+	return h.removeAPIKey(c, h.apiKeyRepo.Revoke, "Failed to revoke API key")
+}
+
+// DeleteAPIKey permanently removes an API key and its usage history
+func (h *APIKeyHandler) DeleteAPIKey(c echo.Context) error {
+	return h.removeAPIKey(c, h.apiKeyRepo.Delete, "Failed to delete API key")
+}
+
+// removeAPIKey applies a revoke or delete to the key in the path
+func (h *APIKeyHandler) removeAPIKey(c echo.Context, remove func(context.Context, int) error, failure string) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid API key ID")
+	}
+
+	if err := remove(c.Request().Context(), id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "API key not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, failure)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetUsage returns today's quota status and daily usage history (?days=, default 30).
+// A caller authenticated with an API key can only see that key's usage; otherwise only admins
+// can see it.
+func (h *APIKeyHandler) GetUsage(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid API key ID")
+	}
+
+	// Only the key itself and admins can see a key's usage
+	if key := appmw.APIKeyFromContext(c); key != nil {
+		if key.APIKeyID != id {
+			return models.NewAPIError(http.StatusForbidden, "You can only view usage for your own API key")
+		}
+	} else if user := appmw.UserFromContext(c); user == nil || user.Role != models.RoleAdmin {
+		return models.NewAPIError(http.StatusForbidden, "Only admins can view the usage of API keys")
+	}
+
+	days := 30
+	if value := c.QueryParam("days"); value != "" {
+		days, err = strconv.Atoi(value)
+		if err != nil || days < 1 || days > 366 {
+			return models.NewAPIError(http.StatusBadRequest, "days must be between 1 and 366")
+		}
+	}
+
+	report, err := h.apiKeyService.Usage(ctx, id, days)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "API key not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve API key usage")
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
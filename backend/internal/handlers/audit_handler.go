@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// AuditHandler handles HTTP requests for the audit trail
+type AuditHandler struct {
+	auditRepo *repository.AuditRepository
+}
+
+// NewAuditHandler creates a new audit handler with the provided repository
+func NewAuditHandler(auditRepo *repository.AuditRepository) *AuditHandler {
+	return &AuditHandler{
+		auditRepo: auditRepo,
+	}
+}
+
+// GetAuditLogs returns audit log entries, newest first. Entries can be narrowed with
+// ?user_id=, ?entity=, ?entity_id=, ?action=, ?from= and ?to= (RFC 3339), and
+// ?impersonated=true limits them to actions taken while impersonating.
+func (h *AuditHandler) GetAuditLogs(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	filter := repository.AuditLogFilter{
+		ImpersonatedOnly: c.QueryParam("impersonated") == "true",
+		Entity:           c.QueryParam("entity"),
+		EntityID:         c.QueryParam("entity_id"),
+		Action:           c.QueryParam("action"),
+	}
+	if userID := c.QueryParam("user_id"); userID != "" {
+		id, err := strconv.Atoi(userID)
+		if err != nil {
+			return models.NewAPIError(http.StatusBadRequest, "Invalid user ID")
+		}
+		filter.UserID = id
+	}
+	for param, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		value := c.QueryParam(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return models.NewAPIError(http.StatusBadRequest, "Invalid "+param+" timestamp, expected RFC 3339")
+		}
+		*target = &parsed
+	}
+	if limit := c.QueryParam("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > 1000 {
+			return models.NewAPIError(http.StatusBadRequest, "limit must be between 1 and 1000")
+		}
+		filter.Limit = n
+	}
+
+	logs, err := h.auditRepo.GetAll(ctx, filter)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve audit logs")
+	}
+
+	return jsonList(c, http.StatusOK, logs)
+}
+
+// GetCustomerChanges returns the changelog of a customer; see recordChanges
+func (h *AuditHandler) GetCustomerChanges(c echo.Context) error {
+	return h.recordChanges(c, models.AuditEntityCustomer, "customer")
+}
+
+// GetProductChanges returns the changelog of a product; see recordChanges
+func (h *AuditHandler) GetProductChanges(c echo.Context) error {
+	return h.recordChanges(c, models.AuditEntityProduct, "product")
+}
+
+// GetQuotationChanges returns the changelog of a quotation; see recordChanges
+func (h *AuditHandler) GetQuotationChanges(c echo.Context) error {
+	return h.recordChanges(c, models.AuditEntityQuotation, "quotation")
+}
+
+// recordChanges returns the audited changes to the record in the :id route parameter,
+// newest first: who made each change and when, and each field's old and new value. The
+// changes of deleted and purged records are still returned.
+func (h *AuditHandler) recordChanges(c echo.Context, entity, name string) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid "+name+" ID")
+	}
+
+	page, paged, message := parsePage(c)
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	entries, total, err := h.auditRepo.GetByRecord(c.Request().Context(), entity, strconv.Itoa(id), page)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve "+name+" changes")
+	}
+
+	return jsonPage(c, http.StatusOK, services.RecordChanges(entries), page, paged, total)
+}
+
+// recordAudit writes an audit log entry for a change made by the request. before and after
+// are stored as JSON snapshots; pass nil for the side that doesn't exist. Failures are
+// logged, not returned, so auditing never blocks the change itself.
+func recordAudit(c echo.Context, auditRepo *repository.AuditRepository, action, entity string, entityID int, before, after interface{}) {
+	recordAuditKey(c, auditRepo, action, entity, strconv.Itoa(entityID), before, after)
+}
+
+// recordAuditKey writes an audit log entry for a record identified by key rather than by a
+// numeric ID, such as a setting; see recordAudit
+func recordAuditKey(c echo.Context, auditRepo *repository.AuditRepository, action, entity, key string, before, after interface{}) {
+	entry := models.AuditLog{
+		Action:    action,
+		Entity:    entity,
+		EntityID:  key,
+		IPAddress: c.RealIP(),
+	}
+
+	details := map[string]interface{}{}
+	if impersonation := appmw.ImpersonationFromContext(c); impersonation != nil {
+		entry.UserID = &impersonation.Session.TargetUserID
+		entry.ImpersonatorID = &impersonation.Session.AdminUserID
+	} else if user := appmw.UserFromContext(c); user != nil {
+		entry.UserID = &user.UserID
+	}
+	if apiKey := appmw.APIKeyFromContext(c); apiKey != nil {
+		details["api_key_id"] = apiKey.APIKeyID
+	}
+	if device := appmw.DeviceFromContext(c); device != nil {
+		details["device_id"] = device.DeviceID
+	}
+	entry.Details, _ = json.Marshal(details)
+
+	entry.Before = auditSnapshot(before)
+	entry.After = auditSnapshot(after)
+
+	if err := auditRepo.Create(c.Request().Context(), &entry); err != nil {
+		log.Printf("Failed to write audit log for %s %s %s: %v", action, entity, key, err)
+	}
+}
+
+// auditSnapshot encodes a record for the audit log; nil records are left empty
+func auditSnapshot(record interface{}) json.RawMessage {
+	if record == nil {
+		return nil
+	}
+	data, err := json.Marshal(record)
+	if err != nil || string(data) == "null" {
+		return nil
+	}
+	return data
+}
@@ -1,10 +1,16 @@
 package handlers
 
 import (
-	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
 	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
 )
 
 // AuthHandler handles authentication related HTTP requests
@@ -20,63 +26,169 @@ func NewAuthHandler(authService *services.AuthService) *AuthHandler {
 }
 
 // Login handles user login requests
-func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
-	// Only accept POST requests
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
+func (h *AuthHandler) Login(c echo.Context) error {
 	// Parse request body
 	var loginReq services.LoginRequest
-	err := json.NewDecoder(r.Body).Decode(&loginReq)
-	if err != nil {
-		http.Error(w, "Invalid request", http.StatusBadRequest)
-		return
+	if err := c.Bind(&loginReq); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request")
 	}
 
-	// Validate input
-	if loginReq.Email == "" || loginReq.Password == "" {
-		http.Error(w, "Email and password are required", http.StatusBadRequest)
-		return
+	if err := c.Validate(&loginReq); err != nil {
+		return validationError(err)
 	}
 
+	loginReq.IPAddress = c.RealIP()
+	loginReq.UserAgent = c.Request().UserAgent()
+
 	// Attempt to login
-	authResponse, err := h.authService.Login(r.Context(), loginReq)
+	authResponse, err := h.authService.Login(c.Request().Context(), loginReq)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
-		return
+		var lockout *services.LockoutError
+		if errors.As(err, &lockout) {
+			return lockoutResponse(c, lockout)
+		}
+		if err == services.ErrInvalidCredentials {
+			return models.NewAPIError(http.StatusUnauthorized, err.Error()).WithCode(models.CodeInvalidCredentials)
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to create session")
 	}
 
-	// Set session cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "session_id",
-		Value:    authResponse.SessionID,
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   r.TLS != nil, // Set to true in production with HTTPS
-		SameSite: http.SameSiteLaxMode,
-		MaxAge:   86400, // 24 hours in seconds
-	})
+	// Set session cookie. The cookie lives as long as the session could be kept alive;
+	// the server expires the session earlier when it goes unused.
+	setSessionCookie(c, authResponse.SessionID, authResponse.SessionDeadline)
+
+	return c.JSON(http.StatusOK, authResponse)
+}
+
+// Refresh extends the current session by the session TTL, up to its maximum lifetime.
+// When bearer tokens are enabled a fresh access token is issued as well.
+func (h *AuthHandler) Refresh(c echo.Context) error {
+	user := appmw.UserFromContext(c)
+	if user == nil {
+		return models.NewAPIError(http.StatusUnauthorized, services.ErrInvalidSession.Error())
+	}
+
+	response := map[string]interface{}{}
+	if token := sessionToken(c); token != "" {
+		session, err := h.authService.RefreshSession(c.Request().Context(), token)
+		if err != nil {
+			if err == services.ErrInvalidSession {
+				return models.NewAPIError(http.StatusUnauthorized, err.Error())
+			}
+			return models.NewAPIError(http.StatusInternalServerError, "Failed to refresh session")
+		}
 
-	// Return user data
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(authResponse)
+		deadline := h.authService.SessionDeadline(session)
+		setSessionCookie(c, token, deadline)
+		response["expires_at"] = session.ExpiresAt
+		response["session_deadline"] = deadline
+	}
+
+	if h.authService.JWTEnabled() {
+		accessToken, expiresAt, err := h.authService.IssueToken(*user)
+		if err != nil {
+			return models.NewAPIError(http.StatusInternalServerError, "Failed to issue access token")
+		}
+		response["access_token"] = accessToken
+		response["token_type"] = "Bearer"
+		response["token_expires_at"] = expiresAt
+	}
+
+	if len(response) == 0 {
+		return models.NewAPIError(http.StatusBadRequest, "Only session and bearer token logins can be refreshed")
+	}
+
+	return c.JSON(http.StatusOK, response)
 }
 
-// Logout handles user logout requests
-func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+// GetSession returns the user and session the request is authenticated as
+func (h *AuthHandler) GetSession(c echo.Context) error {
+	user := appmw.UserFromContext(c)
+	if user == nil {
+		return models.NewAPIError(http.StatusUnauthorized, services.ErrInvalidSession.Error())
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"user":    user,
+		"session": appmw.SessionFromContext(c),
+	})
+}
+
+// Logout deletes the server-side session and clears the session cookie
+func (h *AuthHandler) Logout(c echo.Context) error {
+	if err := h.authService.Logout(c.Request().Context(), sessionToken(c)); err != nil && err != services.ErrInvalidSession {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to log out")
+	}
+
 	// Clear the session cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "session_id",
+	c.SetCookie(&http.Cookie{
+		Name:     services.SessionCookieName,
 		Value:    "",
 		Path:     "/",
 		HttpOnly: true,
-		Secure:   r.TLS != nil,
+		Secure:   c.Request().TLS != nil,
 		MaxAge:   -1, // Delete the cookie
 	})
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"message": "Logged out successfully"})
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Logged out successfully",
+	})
+}
+
+// UnlockUser clears a user's login lockout so they can sign in again
+func (h *AuthHandler) UnlockUser(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid user ID")
+	}
+
+	user, err := h.authService.UnlockUser(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "User not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to unlock user")
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
+
+// lockoutResponse reports a refused login: 423 for a locked account, 429 for a throttled address
+func lockoutResponse(c echo.Context, lockout *services.LockoutError) error {
+	status := http.StatusLocked
+	if lockout.Code == services.LockoutTooManyAttempts {
+		status = http.StatusTooManyRequests
+	}
+
+	retryAfter := int(time.Until(lockout.RetryAt).Seconds()) + 1
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	c.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+
+	return models.NewAPIError(status, lockout.Error()).WithCode(lockout.Code).WithDetails(map[string]interface{}{
+		"retry_at": lockout.RetryAt,
+	})
+}
+
+// setSessionCookie stores the session token in a cookie that expires at the given time
+func setSessionCookie(c echo.Context, token string, expiresAt time.Time) {
+	c.SetCookie(&http.Cookie{
+		Name:     services.SessionCookieName,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   c.Request().TLS != nil, // Set to true in production with HTTPS
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   max(int(time.Until(expiresAt)/time.Second), 1),
+	})
+}
+
+// sessionToken reads the session token from the session cookie
+func sessionToken(c echo.Context) string {
+	cookie, err := c.Cookie(services.SessionCookieName)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
 }
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// maxStatementBytes is the largest bank statement CSV accepted for import
+const maxStatementBytes = 5 << 20
+
+// MatchRequest confirms which payment a statement line is
+type MatchRequest struct {
+	PaymentID int `json:"payment_id" validate:"required"`
+}
+
+// BankReconciliationHandler handles HTTP requests for importing bank statements and
+// reconciling them against recorded payments
+type BankReconciliationHandler struct {
+	statementRepo         *repository.BankStatementRepository
+	reconciliationService *services.BankReconciliationService
+}
+
+// NewBankReconciliationHandler creates a new bank reconciliation handler with the provided repositories
+func NewBankReconciliationHandler(statementRepo *repository.BankStatementRepository, reconciliationService *services.BankReconciliationService) *BankReconciliationHandler {
+	return &BankReconciliationHandler{
+		statementRepo:         statementRepo,
+		reconciliationService: reconciliationService,
+	}
+}
+
+// ImportStatement imports a bank statement CSV uploaded as the "file" form field, with an
+// optional "bank_account" naming the account it is for. See services.ParseStatementCSV for
+// the columns read.
+func (h *BankReconciliationHandler) ImportStatement(c echo.Context) error {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "A statement CSV must be uploaded as the file field")
+	}
+	if file.Size > maxStatementBytes {
+		return models.NewAPIError(http.StatusRequestEntityTooLarge, fmt.Sprintf("Statement exceeds the %d MB limit", maxStatementBytes>>20))
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid statement upload")
+	}
+	defer src.Close()
+
+	lines, err := services.ParseStatementCSV(src)
+	if err != nil {
+		var formatErr *services.StatementFormatError
+		if errors.As(err, &formatErr) {
+			return models.NewAPIError(http.StatusBadRequest, "Invalid statement CSV: "+formatErr.Error())
+		}
+		return models.NewAPIError(http.StatusBadRequest, "Invalid statement upload")
+	}
+
+	statement := models.BankStatement{
+		BankAccount: strings.TrimSpace(c.FormValue("bank_account")),
+		FileName:    file.Filename,
+		Lines:       lines,
+	}
+	if user := appmw.UserFromContext(c); user != nil {
+		statement.ImportedBy = &user.UserID
+	}
+
+	if err := h.statementRepo.Create(c.Request().Context(), &statement); err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to import statement")
+	}
+
+	return c.JSON(http.StatusCreated, statement)
+}
+
+// GetStatements lists the imported statements, latest first, with how many of their lines
+// are matched
+func (h *BankReconciliationHandler) GetStatements(c echo.Context) error {
+	statements, err := h.statementRepo.GetAll(c.Request().Context())
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve statements")
+	}
+
+	return jsonList(c, http.StatusOK, statements)
+}
+
+// GetStatement returns a statement with its lines
+func (h *BankReconciliationHandler) GetStatement(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid statement ID")
+	}
+
+	statement, err := h.statementRepo.GetByID(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Statement not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve statement")
+	}
+
+	return c.JSON(http.StatusOK, statement)
+}
+
+// DeleteStatement removes an imported statement, releasing the payments matched to its lines
+func (h *BankReconciliationHandler) DeleteStatement(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid statement ID")
+	}
+
+	if err := h.statementRepo.Delete(c.Request().Context(), id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Statement not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to delete statement")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetStatementSuggestions suggests payments for every unmatched deposit on a statement
+func (h *BankReconciliationHandler) GetStatementSuggestions(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid statement ID")
+	}
+
+	suggestions, err := h.reconciliationService.Suggest(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Statement not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to suggest matches")
+	}
+
+	return jsonList(c, http.StatusOK, suggestions)
+}
+
+// GetLineSuggestions suggests payments for one unmatched statement line
+func (h *BankReconciliationHandler) GetLineSuggestions(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid statement line ID")
+	}
+
+	suggestions, err := h.reconciliationService.SuggestLine(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Statement line not found")
+		}
+		if err == repository.ErrLineMatched {
+			return models.NewAPIError(http.StatusConflict, "Statement line is already matched to a payment")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to suggest matches")
+	}
+
+	return c.JSON(http.StatusOK, suggestions)
+}
+
+// ConfirmMatch records that a statement line is the given payment
+func (h *BankReconciliationHandler) ConfirmMatch(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid statement line ID")
+	}
+
+	var req MatchRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	line, err := h.statementRepo.GetLine(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Statement line not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve statement line")
+	}
+	if line.Amount <= 0 {
+		return models.NewAPIError(http.StatusBadRequest, "Only deposits can be matched to payments")
+	}
+
+	var matchedBy *int
+	if user := appmw.UserFromContext(c); user != nil {
+		matchedBy = &user.UserID
+	}
+	if err := h.statementRepo.Match(ctx, id, req.PaymentID, matchedBy); err != nil {
+		if repository.IsNotFound(err, "payment") {
+			return models.NewAPIError(http.StatusBadRequest, "Payment not found")
+		}
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Statement line not found")
+		}
+		if err == repository.ErrLineMatched {
+			return models.NewAPIError(http.StatusConflict, "Statement line is already matched to a payment")
+		}
+		if err == repository.ErrPaymentMatched {
+			return models.NewAPIError(http.StatusConflict, "Payment is already matched to another statement line")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to match statement line")
+	}
+
+	line, err = h.statementRepo.GetLine(ctx, id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve statement line")
+	}
+
+	return c.JSON(http.StatusOK, line)
+}
+
+// RemoveMatch clears the payment confirmed for a statement line
+func (h *BankReconciliationHandler) RemoveMatch(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid statement line ID")
+	}
+
+	if err := h.statementRepo.Unmatch(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Statement line not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to unmatch statement line")
+	}
+
+	line, err := h.statementRepo.GetLine(ctx, id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve statement line")
+	}
+
+	return c.JSON(http.StatusOK, line)
+}
+
+// GetUnmatchedReport lists deposits on imported statements not matched to a payment and
+// payments found on no statement, optionally narrowed by ?payment_method= and a ?from=/?to=
+// range of days
+func (h *BankReconciliationHandler) GetUnmatchedReport(c echo.Context) error {
+	filter, message := paymentFilter(c)
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	report, err := h.statementRepo.GetUnmatched(c.Request().Context(), filter)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to generate unmatched items report")
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
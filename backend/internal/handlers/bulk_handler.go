@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/labstack/echo/v4"
+)
+
+// Bulk item outcomes
+const (
+	bulkStatusDeleted     = "deleted"
+	bulkStatusWouldDelete = "would_delete"
+	bulkStatusSkipped     = "skipped"
+	bulkStatusNotFound    = "not_found"
+	bulkStatusRestored    = "restored"
+)
+
+// BulkHandler handles bulk delete and restore requests
+type BulkHandler struct {
+	deletedRecordRepo *repository.DeletedRecordRepository
+	productRepo       *repository.ProductRepository
+	customerRepo      *repository.CustomerRepository
+	auditRepo         *repository.AuditRepository
+}
+
+// auditEntities maps bulk entity names to their audit log entity
+var auditEntities = map[string]string{
+	"products":  models.AuditEntityProduct,
+	"customers": models.AuditEntityCustomer,
+}
+
+// NewBulkHandler creates a new bulk handler with the provided repositories
+func NewBulkHandler(
+	deletedRecordRepo *repository.DeletedRecordRepository,
+	productRepo *repository.ProductRepository,
+	customerRepo *repository.CustomerRepository,
+	auditRepo *repository.AuditRepository,
+) *BulkHandler {
+	return &BulkHandler{
+		deletedRecordRepo: deletedRecordRepo,
+		productRepo:       productRepo,
+		customerRepo:      customerRepo,
+		auditRepo:         auditRepo,
+	}
+}
+
+// bulkRequest is the body for bulk delete and restore requests, of up to 500 IDs
+type bulkRequest struct {
+	IDs    []int `json:"ids" validate:"min=1,max=500"`
+	DryRun bool  `json:"dry_run"`
+}
+
+// dependencyChecker reports what references each of the given records
+type dependencyChecker func(ctx context.Context, ids []int) (map[int][]models.Dependency, error)
+
+// BulkDeleteProducts deletes products that nothing depends on and reports why the rest were skipped
+func (h *BulkHandler) BulkDeleteProducts(c echo.Context) error {
+	return h.bulkDelete(c, "products", h.productRepo.GetDependencies)
+}
+
+// BulkDeleteCustomers deletes customers without quotations or orders and reports why the rest were skipped
+func (h *BulkHandler) BulkDeleteCustomers(c echo.Context) error {
+	return h.bulkDelete(c, "customers", h.customerRepo.GetDependencies)
+}
+
+// BulkRestoreProducts restores products removed by a bulk delete
+func (h *BulkHandler) BulkRestoreProducts(c echo.Context) error {
+	return h.bulkRestore(c, "products")
+}
+
+// BulkRestoreCustomers restores customers removed by a bulk delete
+func (h *BulkHandler) BulkRestoreCustomers(c echo.Context) error {
+	return h.bulkRestore(c, "customers")
+}
+
+// GetDeletedProducts lists products that can be restored
+func (h *BulkHandler) GetDeletedProducts(c echo.Context) error {
+	return h.getDeleted(c, "products")
+}
+
+// GetDeletedCustomers lists customers that can be restored
+func (h *BulkHandler) GetDeletedCustomers(c echo.Context) error {
+	return h.getDeleted(c, "customers")
+}
+
+// parseBulkRequest reads and de-duplicates the requested IDs, returning an error when the request is invalid
+func parseBulkRequest(c echo.Context) (bulkRequest, error) {
+	var req bulkRequest
+	if err := c.Bind(&req); err != nil {
+		return req, errors.New("Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return req, err
+	}
+
+	seen := map[int]bool{}
+	ids := make([]int, 0, len(req.IDs))
+	for _, id := range req.IDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	req.IDs = ids
+	return req, nil
+}
+
+// bulkDelete pre-checks every record, then deletes all eligible records in one transaction.
+// With dry_run the checks are reported without deleting anything.
+func (h *BulkHandler) bulkDelete(c echo.Context, entity string, checkDependencies dependencyChecker) error {
+	ctx := c.Request().Context()
+
+	req, err := parseBulkRequest(c)
+	if err != nil {
+		return validationError(err)
+	}
+
+	existing, err := h.deletedRecordRepo.ExistingIDs(ctx, entity, req.IDs)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to look up "+entity)
+	}
+
+	dependencies, err := checkDependencies(ctx, req.IDs)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to check dependencies")
+	}
+
+	results := make([]models.BulkItemResult, 0, len(req.IDs))
+	eligible := []int{}
+	for _, id := range req.IDs {
+		result := models.BulkItemResult{ID: id, Dependencies: dependencies[id]}
+		switch {
+		case !existing[id]:
+			result.Status = bulkStatusNotFound
+		case repository.HasBlockingDependency(dependencies[id]):
+			result.Status = bulkStatusSkipped
+			for _, dependency := range dependencies[id] {
+				if dependency.Blocking {
+					result.Reasons = append(result.Reasons, dependency.Reason)
+				}
+			}
+		default:
+			result.Status = bulkStatusWouldDelete
+			eligible = append(eligible, id)
+		}
+		results = append(results, result)
+	}
+
+	deleted := 0
+	if !req.DryRun && len(eligible) > 0 {
+		if err := h.deletedRecordRepo.ArchiveDelete(ctx, entity, eligible); err != nil {
+			if err == repository.ErrReferencedRecord {
+				return models.NewAPIError(http.StatusConflict, "Nothing was deleted: a record became referenced by other data during the operation")
+			}
+			return models.NewAPIError(http.StatusInternalServerError, "Nothing was deleted: failed to delete "+entity)
+		}
+		for i := range results {
+			if results[i].Status == bulkStatusWouldDelete {
+				results[i].Status = bulkStatusDeleted
+				recordAudit(c, h.auditRepo, models.AuditDelete, auditEntities[entity], results[i].ID, nil, nil)
+			}
+		}
+		deleted = len(eligible)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"dry_run": req.DryRun,
+		"deleted": deleted,
+		"skipped": len(req.IDs) - len(eligible),
+		"results": results,
+	})
+}
+
+// bulkRestore restores archived records in one transaction
+func (h *BulkHandler) bulkRestore(c echo.Context, entity string) error {
+	ctx := c.Request().Context()
+
+	req, err := parseBulkRequest(c)
+	if err != nil {
+		return validationError(err)
+	}
+
+	restored, err := h.deletedRecordRepo.Restore(ctx, entity, req.IDs)
+	if err != nil {
+		if err == repository.ErrRestoreConflict || err == repository.ErrReferencedRecord {
+			return models.NewAPIError(http.StatusConflict, "Nothing was restored: "+err.Error())
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Nothing was restored: failed to restore "+entity)
+	}
+
+	restoredSet := map[int]bool{}
+	for _, id := range restored {
+		restoredSet[id] = true
+		recordAudit(c, h.auditRepo, "restore", auditEntities[entity], id, nil, nil)
+	}
+
+	results := make([]models.BulkItemResult, 0, len(req.IDs))
+	for _, id := range req.IDs {
+		result := models.BulkItemResult{ID: id, Status: bulkStatusRestored}
+		if !restoredSet[id] {
+			result.Status = bulkStatusNotFound
+			result.Reasons = []string{"no deleted record to restore"}
+		}
+		results = append(results, result)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"restored": len(restored),
+		"results":  results,
+	})
+}
+
+// getDeleted lists archived records of an entity
+func (h *BulkHandler) getDeleted(c echo.Context, entity string) error {
+	ctx := c.Request().Context()
+
+	records, err := h.deletedRecordRepo.GetAll(ctx, entity)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve deleted "+entity)
+	}
+
+	return c.JSON(http.StatusOK, records)
+}
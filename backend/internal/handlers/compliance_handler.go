@@ -0,0 +1,422 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/labstack/echo/v4"
+)
+
+// maxExpiryWindowDays caps the look-ahead of the expiring certifications report
+const maxExpiryWindowDays = 730
+
+// ComplianceHandler handles HTTP requests for certifications, safety standards and their product links
+type ComplianceHandler struct {
+	certificationRepo  *repository.CertificationRepository
+	safetyStandardRepo *repository.SafetyStandardRepository
+	productRepo        *repository.ProductRepository
+}
+
+// NewComplianceHandler creates a new compliance handler with the provided repositories
+func NewComplianceHandler(
+	certificationRepo *repository.CertificationRepository,
+	safetyStandardRepo *repository.SafetyStandardRepository,
+	productRepo *repository.ProductRepository,
+) *ComplianceHandler {
+	return &ComplianceHandler{
+		certificationRepo:  certificationRepo,
+		safetyStandardRepo: safetyStandardRepo,
+		productRepo:        productRepo,
+	}
+}
+
+// GetCertifications returns all certifications
+func (h *ComplianceHandler) GetCertifications(c echo.Context) error {
+	certifications, err := h.certificationRepo.GetAll(c.Request().Context())
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve certifications")
+	}
+
+	return jsonList(c, http.StatusOK, certifications)
+}
+
+// GetCertification returns a certification by ID
+func (h *ComplianceHandler) GetCertification(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid certification ID")
+	}
+
+	certification, err := h.certificationRepo.GetByID(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Certification not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve certification")
+	}
+
+	return c.JSON(http.StatusOK, certification)
+}
+
+// CreateCertification creates a new certification
+func (h *ComplianceHandler) CreateCertification(c echo.Context) error {
+	var certification models.Certification
+	if err := c.Bind(&certification); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(&certification); err != nil {
+		return validationError(err)
+	}
+	certification.Code = strings.TrimSpace(certification.Code)
+	certification.Name = strings.TrimSpace(certification.Name)
+
+	if err := h.certificationRepo.Create(c.Request().Context(), &certification); err != nil {
+		if err == repository.ErrDuplicateKey {
+			return models.NewAPIError(http.StatusConflict, "A certification with this code already exists")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to create certification")
+	}
+
+	return c.JSON(http.StatusCreated, certification)
+}
+
+// UpdateCertification updates an existing certification
+func (h *ComplianceHandler) UpdateCertification(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid certification ID")
+	}
+
+	var certification models.Certification
+	if err := c.Bind(&certification); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	certification.CertificationID = id
+	if err := c.Validate(&certification); err != nil {
+		return validationError(err)
+	}
+	certification.Code = strings.TrimSpace(certification.Code)
+	certification.Name = strings.TrimSpace(certification.Name)
+
+	if err := h.certificationRepo.Update(c.Request().Context(), &certification); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Certification not found")
+		}
+		if err == repository.ErrDuplicateKey {
+			return models.NewAPIError(http.StatusConflict, "A certification with this code already exists")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update certification")
+	}
+
+	return c.JSON(http.StatusOK, certification)
+}
+
+// DeleteCertification deletes a certification that no product holds
+func (h *ComplianceHandler) DeleteCertification(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid certification ID")
+	}
+
+	if err := h.certificationRepo.Delete(c.Request().Context(), id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Certification not found")
+		}
+		if err == repository.ErrReferencedRecord {
+			return models.NewAPIError(http.StatusConflict, "Certification is still linked to products")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to delete certification")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetSafetyStandards returns all safety standards
+func (h *ComplianceHandler) GetSafetyStandards(c echo.Context) error {
+	standards, err := h.safetyStandardRepo.GetAll(c.Request().Context())
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve safety standards")
+	}
+
+	return jsonList(c, http.StatusOK, standards)
+}
+
+// GetSafetyStandard returns a safety standard by ID
+func (h *ComplianceHandler) GetSafetyStandard(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid safety standard ID")
+	}
+
+	standard, err := h.safetyStandardRepo.GetByID(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Safety standard not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve safety standard")
+	}
+
+	return c.JSON(http.StatusOK, standard)
+}
+
+// CreateSafetyStandard creates a new safety standard
+func (h *ComplianceHandler) CreateSafetyStandard(c echo.Context) error {
+	var standard models.SafetyStandard
+	if err := c.Bind(&standard); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(&standard); err != nil {
+		return validationError(err)
+	}
+	standard.Code = strings.TrimSpace(standard.Code)
+	standard.Name = strings.TrimSpace(standard.Name)
+
+	if err := h.safetyStandardRepo.Create(c.Request().Context(), &standard); err != nil {
+		if err == repository.ErrDuplicateKey {
+			return models.NewAPIError(http.StatusConflict, "A safety standard with this code already exists")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to create safety standard")
+	}
+
+	return c.JSON(http.StatusCreated, standard)
+}
+
+// UpdateSafetyStandard updates an existing safety standard
+func (h *ComplianceHandler) UpdateSafetyStandard(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid safety standard ID")
+	}
+
+	var standard models.SafetyStandard
+	if err := c.Bind(&standard); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	standard.SafetyStandardID = id
+	if err := c.Validate(&standard); err != nil {
+		return validationError(err)
+	}
+	standard.Code = strings.TrimSpace(standard.Code)
+	standard.Name = strings.TrimSpace(standard.Name)
+
+	if err := h.safetyStandardRepo.Update(c.Request().Context(), &standard); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Safety standard not found")
+		}
+		if err == repository.ErrDuplicateKey {
+			return models.NewAPIError(http.StatusConflict, "A safety standard with this code already exists")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update safety standard")
+	}
+
+	return c.JSON(http.StatusOK, standard)
+}
+
+// DeleteSafetyStandard deletes a safety standard that no product is linked to
+func (h *ComplianceHandler) DeleteSafetyStandard(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid safety standard ID")
+	}
+
+	if err := h.safetyStandardRepo.Delete(c.Request().Context(), id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Safety standard not found")
+		}
+		if err == repository.ErrReferencedRecord {
+			return models.NewAPIError(http.StatusConflict, "Safety standard is still linked to products")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to delete safety standard")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetProductCertifications returns the certificates held by a product
+func (h *ComplianceHandler) GetProductCertifications(c echo.Context) error {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid product ID")
+	}
+
+	certificates, err := h.certificationRepo.GetByProductID(c.Request().Context(), productID)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve product certifications")
+	}
+
+	return jsonList(c, http.StatusOK, certificates)
+}
+
+// SetProductCertification adds or updates a product's certificate for a certification
+func (h *ComplianceHandler) SetProductCertification(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid product ID")
+	}
+	certificationID, err := strconv.Atoi(c.Param("certification_id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid certification ID")
+	}
+
+	var link models.ProductCertification
+	if err := c.Bind(&link); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+	link.ProductID = productID
+	link.CertificationID = certificationID
+
+	if link.IssuedOn != nil && link.ExpiresOn != nil && link.ExpiresOn.Before(*link.IssuedOn) {
+		return models.NewAPIError(http.StatusBadRequest, "Expiry date cannot be before the issue date")
+	}
+
+	if _, err := h.productRepo.GetByID(ctx, productID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Product not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve product")
+	}
+
+	certification, err := h.certificationRepo.GetByID(ctx, certificationID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Certification not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve certification")
+	}
+
+	if err := h.certificationRepo.LinkProduct(ctx, &link); err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to save product certification")
+	}
+	link.Code = certification.Code
+	link.Name = certification.Name
+
+	return c.JSON(http.StatusOK, link)
+}
+
+// RemoveProductCertification removes a certificate from a product
+func (h *ComplianceHandler) RemoveProductCertification(c echo.Context) error {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid product ID")
+	}
+	certificationID, err := strconv.Atoi(c.Param("certification_id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid certification ID")
+	}
+
+	if err := h.certificationRepo.UnlinkProduct(c.Request().Context(), productID, certificationID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Product does not hold this certification")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to remove product certification")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetProductSafetyStandards returns the safety standards a product complies with
+func (h *ComplianceHandler) GetProductSafetyStandards(c echo.Context) error {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid product ID")
+	}
+
+	standards, err := h.safetyStandardRepo.GetByProductID(c.Request().Context(), productID)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve product safety standards")
+	}
+
+	return jsonList(c, http.StatusOK, standards)
+}
+
+// AddProductSafetyStandard links a product to a safety standard
+func (h *ComplianceHandler) AddProductSafetyStandard(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid product ID")
+	}
+	safetyStandardID, err := strconv.Atoi(c.Param("safety_standard_id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid safety standard ID")
+	}
+
+	if _, err := h.productRepo.GetByID(ctx, productID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Product not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve product")
+	}
+
+	standard, err := h.safetyStandardRepo.GetByID(ctx, safetyStandardID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Safety standard not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve safety standard")
+	}
+
+	link := models.ProductSafetyStandard{
+		ProductID:        productID,
+		SafetyStandardID: safetyStandardID,
+		Code:             standard.Code,
+		Name:             standard.Name,
+	}
+	if err := h.safetyStandardRepo.LinkProduct(ctx, &link); err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to link safety standard")
+	}
+
+	return c.JSON(http.StatusOK, link)
+}
+
+// RemoveProductSafetyStandard unlinks a safety standard from a product
+func (h *ComplianceHandler) RemoveProductSafetyStandard(c echo.Context) error {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid product ID")
+	}
+	safetyStandardID, err := strconv.Atoi(c.Param("safety_standard_id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid safety standard ID")
+	}
+
+	if err := h.safetyStandardRepo.UnlinkProduct(c.Request().Context(), productID, safetyStandardID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Product is not linked to this safety standard")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to unlink safety standard")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetExpiringCertifications reports product certificates that have expired or expire
+// within ?days= days (default 30)
+func (h *ComplianceHandler) GetExpiringCertifications(c echo.Context) error {
+	days := 30
+	if value := c.QueryParam("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 || parsed > maxExpiryWindowDays {
+			return models.NewAPIError(http.StatusBadRequest, "days must be between 0 and "+strconv.Itoa(maxExpiryWindowDays))
+		}
+		days = parsed
+	}
+
+	expiring, err := h.certificationRepo.GetExpiring(c.Request().Context(), days)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve expiring certifications")
+	}
+
+	return jsonList(c, http.StatusOK, expiring)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -23,28 +24,35 @@ func NewContactHandler(contactRepo *repository.ContactRepository, customerRepo *
 	}
 }
 
-// GetAllContacts returns all contacts
+// GetAllContacts returns all contacts, or one page of them when ?page= and ?page_size=
+// (or ?limit= and ?offset=) are given; see parsePage.
 func (h *ContactHandler) GetAllContacts(c echo.Context) error {
 	ctx := c.Request().Context()
 
+	page, paged, message := parsePage(c)
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
 	// Check for search parameter
 	searchTerm := c.QueryParam("search")
 	var contacts []models.Contact
+	var total int
 	var err error
 
-	if searchTerm != "" {
-		contacts, err = h.contactRepo.SearchContacts(ctx, searchTerm)
+	if c.QueryParam("deleted") == "true" {
+		contacts, total, err = h.contactRepo.GetDeleted(ctx, page)
+	} else if searchTerm != "" {
+		contacts, total, err = h.contactRepo.SearchContacts(ctx, searchTerm, page)
 	} else {
-		contacts, err = h.contactRepo.GetAll(ctx)
+		contacts, total, err = h.contactRepo.GetAll(ctx, page)
 	}
 
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve contacts",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve contacts")
 	}
 
-	return c.JSON(http.StatusOK, contacts)
+	return jsonPage(c, http.StatusOK, contacts, page, paged, total)
 }
 
 // GetContactsByCustomer returns all contacts for a specific customer
@@ -53,32 +61,24 @@ func (h *ContactHandler) GetContactsByCustomer(c echo.Context) error {
 
 	customerID, err := strconv.Atoi(c.Param("customer_id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid customer ID",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid customer ID")
 	}
 
 	// Verify customer exists
 	_, err = h.customerRepo.GetByID(ctx, customerID)
 	if err != nil {
-		if err.Error() == "customer not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Customer not found",
-			})
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Customer not found")
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to verify customer",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to verify customer")
 	}
 
 	contacts, err := h.contactRepo.GetByCustomerID(ctx, customerID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve contacts",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve contacts")
 	}
 
-	return c.JSON(http.StatusOK, contacts)
+	return jsonList(c, http.StatusOK, contacts)
 }
 
 // GetContactByID returns a contact by ID
@@ -90,36 +90,26 @@ func (h *ContactHandler) GetContactByID(c echo.Context) error {
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid contact ID",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid contact ID")
 	}
 
 	contact, err := h.contactRepo.GetByID(ctx, id)
 	if err != nil {
-		if err.Error() == "contact not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Contact not found",
-			})
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Contact not found")
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve contact",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve contact")
 	}
 
 	// If request is scoped to a customer, verify contact belongs to that customer
 	if customerIDParam != "" {
 		customerID, err := strconv.Atoi(customerIDParam)
 		if err != nil {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "Invalid customer ID",
-			})
+			return models.NewAPIError(http.StatusBadRequest, "Invalid customer ID")
 		}
 
 		if contact.CustomerID != customerID {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Contact not found for this customer",
-			})
+			return models.NewAPIError(http.StatusNotFound, "Contact not found for this customer")
 		}
 	}
 
@@ -132,52 +122,37 @@ func (h *ContactHandler) CreateContact(c echo.Context) error {
 
 	customerID, err := strconv.Atoi(c.Param("customer_id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid customer ID",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid customer ID")
 	}
 
 	// Verify customer exists
 	_, err = h.customerRepo.GetByID(ctx, customerID)
 	if err != nil {
-		if err.Error() == "customer not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Customer not found",
-			})
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Customer not found")
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to verify customer",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to verify customer")
 	}
 
 	var contact models.Contact
 	if err := c.Bind(&contact); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request payload",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
 	}
 
 	// Override customerID with the one from the path parameter
 	contact.CustomerID = customerID
 
-	// Validate required fields
-	if contact.FirstName == "" || contact.LastName == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "First name and last name are required",
-		})
+	if err := c.Validate(&contact); err != nil {
+		return validationError(err)
 	}
 
 	err = h.contactRepo.Create(ctx, &contact)
 	if err != nil {
 		if err == repository.ErrDuplicateKey {
-			return c.JSON(http.StatusConflict, map[string]string{
-				"error": "A contact with this information already exists",
-			})
+			return models.NewAPIError(http.StatusConflict, "A contact with this information already exists")
 		}
 
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to create contact",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to create contact")
 	}
 
 	return c.JSON(http.StatusCreated, contact)
@@ -189,131 +164,140 @@ func (h *ContactHandler) UpdateContact(c echo.Context) error {
 
 	customerID, err := strconv.Atoi(c.Param("customer_id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid customer ID",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid customer ID")
 	}
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid contact ID",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid contact ID")
 	}
 
 	// Verify customer exists
 	_, err = h.customerRepo.GetByID(ctx, customerID)
 	if err != nil {
-		if err.Error() == "customer not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Customer not found",
-			})
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Customer not found")
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to verify customer",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to verify customer")
 	}
 
 	// Verify contact exists and belongs to the customer
 	existingContact, err := h.contactRepo.GetByID(ctx, id)
 	if err != nil {
-		if err.Error() == "contact not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Contact not found",
-			})
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Contact not found")
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve contact",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve contact")
 	}
 
 	if existingContact.CustomerID != customerID {
-		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "Contact not found for this customer",
-		})
+		return models.NewAPIError(http.StatusNotFound, "Contact not found for this customer")
 	}
 
 	var contact models.Contact
 	if err := c.Bind(&contact); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request payload",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
 	}
 
 	// Ensure ID and CustomerID in path match values in payload
 	contact.ContactID = id
 	contact.CustomerID = customerID
 
-	// Validate required fields
-	if contact.FirstName == "" || contact.LastName == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "First name and last name are required",
-		})
+	if err := c.Validate(&contact); err != nil {
+		return validationError(err)
 	}
 
 	err = h.contactRepo.Update(ctx, &contact)
 	if err != nil {
-		if err.Error() == "contact not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Contact not found",
-			})
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Contact not found")
 		}
 		if err == repository.ErrDuplicateKey {
-			return c.JSON(http.StatusConflict, map[string]string{
-				"error": "A contact with this information already exists",
-			})
+			return models.NewAPIError(http.StatusConflict, "A contact with this information already exists")
 		}
 
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to update contact",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update contact")
 	}
 
 	return c.JSON(http.StatusOK, contact)
 }
 
-// DeleteContact deletes a contact
+// DeleteContact soft-deletes a contact
 func (h *ContactHandler) DeleteContact(c echo.Context) error {
 	ctx := c.Request().Context()
 
 	customerID, err := strconv.Atoi(c.Param("customer_id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid customer ID",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid customer ID")
 	}
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid contact ID",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid contact ID")
 	}
 
 	// Verify contact belongs to customer
 	contact, err := h.contactRepo.GetByID(ctx, id)
 	if err != nil {
-		if err.Error() == "contact not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Contact not found",
-			})
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Contact not found")
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to verify contact",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to verify contact")
 	}
 
 	if contact.CustomerID != customerID {
-		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "Contact not found for this customer",
-		})
+		return models.NewAPIError(http.StatusNotFound, "Contact not found for this customer")
 	}
 
 	err = h.contactRepo.Delete(ctx, id)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to delete contact",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to delete contact")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RestoreContact brings back a soft-deleted contact
+func (h *ContactHandler) RestoreContact(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid contact ID")
+	}
+
+	if err := h.contactRepo.Restore(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Deleted contact not found; contacts of a deleted customer are restored with the customer")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to restore contact")
+	}
+
+	contact, err := h.contactRepo.GetByID(ctx, id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve contact")
+	}
+
+	return c.JSON(http.StatusOK, contact)
+}
+
+// PurgeContact permanently removes a soft-deleted contact
+func (h *ContactHandler) PurgeContact(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid contact ID")
+	}
+
+	if err := h.contactRepo.Purge(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Contact not found")
+		}
+		if err == repository.ErrNotDeleted {
+			return models.NewAPIError(http.StatusConflict, "Contact must be deleted before it can be purged")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to purge contact")
 	}
 
 	return c.NoContent(http.StatusNoContent)
@@ -325,16 +309,12 @@ func (h *ContactHandler) CheckEmailExists(c echo.Context) error {
 
 	email := c.QueryParam("email")
 	if email == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Email is required",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Email is required")
 	}
 
 	exists, err := h.contactRepo.CheckEmailExists(ctx, email)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to check email existence",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to check email existence")
 	}
 
 	return c.JSON(http.StatusOK, map[string]bool{
@@ -0,0 +1,441 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+)
+
+var updateContracts = flag.Bool("update", false, "write the current response shapes to testdata/contracts")
+
+// frontendContract is an endpoint the Vue frontend calls: the request its stores send, the
+// queries the handler runs for it and the handler answering it. The shape of the response,
+// its field names and value types, is compared with testdata/contracts/<name>.json.
+type frontendContract struct {
+	name    string
+	method  string
+	route   string
+	target  string
+	body    string
+	queries []stubQuery
+	handler func(db *sqlx.DB) echo.HandlerFunc
+}
+
+// TestFrontendContracts runs the handlers behind the endpoints the frontend calls and fails
+// when a struct or JSON tag change alters the shape of what they answer. Once the frontend
+// and its types follow the change, record the new shapes with
+//
+//	go test ./internal/handlers -run TestFrontendContracts -update
+func TestFrontendContracts(t *testing.T) {
+	customer := sampleCustomer()
+	contact := models.Contact{ContactID: 4, CustomerID: customer.CustomerID, FirstName: "Ana", LastName: "Reyes"}
+	fillSample(&contact)
+	contact.DeletedAt = nil
+	product := models.Product{ProductID: 12, ProductName: "Circuit breaker", TechnicalSpecs: json.RawMessage(`{"rating":"60A"}`), Currency: "PHP"}
+	fillSample(&product)
+	product.DiscontinuedAt, product.DeletedAt = nil, nil
+	inventory := models.Inventory{InventoryID: 3, ProductID: product.ProductID, CurrentStock: 2, ReorderLevel: 10}
+	fillSample(&inventory)
+	lowStock := repository.LowStockWithProductInfo{Inventory: inventory, ProductName: product.ProductName, Price: product.Price}
+	quotation := sampleQuotation(customer)
+	quotationItem := models.QuotationItem{QuotationItemID: 8, QuotationID: quotation.QuotationID, ProductID: product.ProductID, Quantity: 2, DiscountType: "percent"}
+	fillSample(&quotationItem)
+	order := models.Order{OrderID: 21, CustomerID: customer.CustomerID, Status: "Pending", Currency: "PHP", ExchangeRate: 1}
+	fillSample(&order)
+	orderItem := models.OrderItem{OrderItemID: 30, OrderID: order.OrderID, ProductID: product.ProductID, Quantity: 2, DiscountType: "percent"}
+	fillSample(&orderItem)
+	payment := models.Payment{PaymentID: 5, OrderID: order.OrderID, PaymentMethod: "cash"}
+	fillSample(&payment)
+	user := models.User{UserID: 2, Role: models.RoleAdmin, Email: "ana@example.com"}
+	fillSample(&user)
+	trend := models.SalesTrend{Day: "2026-03-01", TotalAmount: 1250}
+	lowStockItem := models.LowStockItem{ID: inventory.InventoryID, ProductID: product.ProductID}
+	fillSample(&lowStockItem)
+	topCustomer := models.TopCustomer{ID: customer.CustomerID, Name: customer.CompanyName}
+	fillSample(&topCustomer)
+
+	updatedQuotation := quotation
+	updatedQuotation.Status = "Rejected"
+	updatedQuotation.Version++
+
+	auditInsert := valuesOf("INSERT INTO audit_logs", "audit_log_id", 1, "created_at", sampleTime)
+	customers := func(db *sqlx.DB) *CustomerHandler {
+		customerRepo := repository.NewCustomerRepository(db)
+		return NewCustomerHandler(customerRepo, repository.NewIndustryRepository(db), services.NewGeocodingService(nil, customerRepo), repository.NewAuditRepository(db))
+	}
+	contacts := func(db *sqlx.DB) *ContactHandler {
+		return NewContactHandler(repository.NewContactRepository(db), repository.NewCustomerRepository(db))
+	}
+	products := func(db *sqlx.DB) *ProductHandler {
+		return NewProductHandler(repository.NewProductRepository(db), nil, nil, repository.NewAuditRepository(db))
+	}
+	inventories := func(db *sqlx.DB) *InventoryHandler {
+		return NewInventoryHandler(repository.NewInventoryRepository(db), repository.NewProductRepository(db), nil, repository.NewAuditRepository(db), nil, nil)
+	}
+	quotations := func(db *sqlx.DB) *QuotationHandler {
+		return &QuotationHandler{quotationRepo: repository.NewQuotationRepository(db), auditRepo: repository.NewAuditRepository(db)}
+	}
+	orders := func(db *sqlx.DB) *OrderHandler {
+		return &OrderHandler{orderRepo: repository.NewOrderRepository(db), paymentRepo: repository.NewPaymentRepository(db)}
+	}
+	users := func(db *sqlx.DB) *UserHandler {
+		return NewUserHandler(repository.NewUserRepository(db), repository.NewAuditRepository(db))
+	}
+	reports := func(db *sqlx.DB) *ReportHandler {
+		reportRepo := repository.NewReportRepository(db)
+		return NewReportHandler(reportRepo, nil, services.NewDashboardService(reportRepo, 0))
+	}
+
+	contracts := []frontendContract{
+		{
+			name: "customers", method: http.MethodGet, route: "/api/customers", target: "/api/customers",
+			queries: []stubQuery{rowsOf("FROM customers", customer)},
+			handler: func(db *sqlx.DB) echo.HandlerFunc { return customers(db).GetAllCustomers },
+		},
+		{
+			name: "customer", method: http.MethodGet, route: "/api/customers/:id", target: "/api/customers/7",
+			queries: []stubQuery{rowsOf("FROM customers WHERE customer_id", customer)},
+			handler: func(db *sqlx.DB) echo.HandlerFunc { return customers(db).GetCustomerByID },
+		},
+		{
+			// The edit modal sends the customer's fields with the version it was loaded at
+			name: "customer-update", method: http.MethodPut, route: "/api/customers/:id", target: "/api/customers/7",
+			body: `{"customer_id":7,"company_name":"Acme Industrial","industry":"","address":"12 Rizal St, Makati",` +
+				`"website":"","email":"buyer@acme.example","phone":"","version":3}`,
+			queries: []stubQuery{
+				rowsOf("FROM customers WHERE customer_id", customer),
+				valuesOf("UPDATE customers SET", "updated_at", sampleTime, "latitude", nil, "longitude", nil,
+					"geocoded_at", nil, "version", 4, "vat_classification", models.VATVatable, "payment_terms_days", 30),
+				auditInsert,
+			},
+			handler: func(db *sqlx.DB) echo.HandlerFunc { return customers(db).UpdateCustomer },
+		},
+		{
+			name: "contacts", method: http.MethodGet, route: "/api/contacts", target: "/api/contacts",
+			queries: []stubQuery{rowsOf("FROM contacts", contact)},
+			handler: func(db *sqlx.DB) echo.HandlerFunc { return contacts(db).GetAllContacts },
+		},
+		{
+			name: "contact", method: http.MethodGet, route: "/api/contacts/:id", target: "/api/contacts/4",
+			queries: []stubQuery{rowsOf("FROM contacts WHERE contact_id", contact)},
+			handler: func(db *sqlx.DB) echo.HandlerFunc { return contacts(db).GetContactByID },
+		},
+		{
+			name: "customer-contacts", method: http.MethodGet, route: "/api/customers/:customer_id/contacts", target: "/api/customers/7/contacts",
+			queries: []stubQuery{
+				rowsOf("FROM customers WHERE customer_id", customer),
+				rowsOf("FROM contacts WHERE customer_id", contact),
+			},
+			handler: func(db *sqlx.DB) echo.HandlerFunc { return contacts(db).GetContactsByCustomer },
+		},
+		{
+			name: "products", method: http.MethodGet, route: "/api/products", target: "/api/products",
+			queries: []stubQuery{rowsOf("FROM products", product)},
+			handler: func(db *sqlx.DB) echo.HandlerFunc { return products(db).GetAllProducts },
+		},
+		{
+			name: "product", method: http.MethodGet, route: "/api/products/:id", target: "/api/products/12",
+			queries: []stubQuery{rowsOf("FROM products WHERE product_id", product)},
+			handler: func(db *sqlx.DB) echo.HandlerFunc { return products(db).GetProductByID },
+		},
+		{
+			name: "inventory", method: http.MethodGet, route: "/api/inventory", target: "/api/inventory",
+			queries: []stubQuery{rowsOf("FROM inventory", inventory)},
+			handler: func(db *sqlx.DB) echo.HandlerFunc { return inventories(db).GetAllInventory },
+		},
+		{
+			name: "inventory-item", method: http.MethodGet, route: "/api/inventory/:id", target: "/api/inventory/3",
+			queries: []stubQuery{rowsOf("FROM inventory WHERE inventory_id", inventory)},
+			handler: func(db *sqlx.DB) echo.HandlerFunc { return inventories(db).GetInventoryByID },
+		},
+		{
+			name: "product-inventory", method: http.MethodGet, route: "/api/inventory/product/:product_id", target: "/api/inventory/product/12",
+			queries: []stubQuery{
+				rowsOf("FROM products WHERE product_id", product),
+				rowsOf("FROM inventory WHERE product_id", inventory),
+			},
+			handler: func(db *sqlx.DB) echo.HandlerFunc { return inventories(db).GetInventoryByProductID },
+		},
+		{
+			name: "inventory-low-stock", method: http.MethodGet, route: "/api/inventory/low-stock", target: "/api/inventory/low-stock",
+			queries: []stubQuery{rowsOf("FROM inventory", inventory)},
+			handler: func(db *sqlx.DB) echo.HandlerFunc { return inventories(db).GetLowStockItems },
+		},
+		{
+			name: "inventory-low-stock-details", method: http.MethodGet, route: "/api/inventory/low-stock/details", target: "/api/inventory/low-stock/details",
+			queries: []stubQuery{rowsOf("FROM inventory i", lowStock)},
+			handler: func(db *sqlx.DB) echo.HandlerFunc { return inventories(db).GetLowStockWithProductInfo },
+		},
+		{
+			name: "quotations", method: http.MethodGet, route: "/api/quotations", target: "/api/quotations",
+			queries: []stubQuery{rowsOf("FROM quotations", quotation)},
+			handler: func(db *sqlx.DB) echo.HandlerFunc { return quotations(db).GetAllQuotations },
+		},
+		{
+			name: "quotation", method: http.MethodGet, route: "/api/quotations/:id", target: "/api/quotations/15",
+			queries: []stubQuery{
+				rowsOf("FROM quotations WHERE quotation_id", quotation),
+				rowsOf("FROM quotation_items WHERE quotation_id", quotationItem),
+			},
+			handler: func(db *sqlx.DB) echo.HandlerFunc { return quotations(db).GetQuotationByID },
+		},
+		{
+			// The quotation store sends the new status with the version it loaded
+			name: "quotation-status", method: http.MethodPost, route: "/api/quotations/:id/status", target: "/api/quotations/15/status",
+			body: `{"status":"Rejected","version":2}`,
+			queries: []stubQuery{
+				rowsOf("FROM quotations WHERE quotation_id", quotation),
+				valuesOf("UPDATE quotations SET", "updated_at", sampleTime),
+				rowsOf("FROM quotations WHERE quotation_id", updatedQuotation),
+				auditInsert,
+			},
+			handler: func(db *sqlx.DB) echo.HandlerFunc { return quotations(db).UpdateQuotationStatus },
+		},
+		{
+			name: "orders", method: http.MethodGet, route: "/api/orders", target: "/api/orders",
+			queries: []stubQuery{rowsOf("FROM orders o", order)},
+			handler: func(db *sqlx.DB) echo.HandlerFunc { return orders(db).GetAllOrders },
+		},
+		{
+			name: "order", method: http.MethodGet, route: "/api/orders/:id", target: "/api/orders/21",
+			queries: []stubQuery{
+				rowsOf("WHERE order_id = $1", order),
+				rowsOf("FROM order_items WHERE order_id", orderItem),
+				valuesOf("FROM order_items oi", "weight_kg", 12.5, "volume_m3", 0.25,
+					"without_weight", pq.Int64Array{13}, "without_dimensions", pq.Int64Array{int64(product.ProductID)}),
+				rowsOf("FROM order_payments WHERE order_id", payment),
+			},
+			handler: func(db *sqlx.DB) echo.HandlerFunc { return orders(db).GetOrderByID },
+		},
+		{
+			name: "users", method: http.MethodGet, route: "/api/users", target: "/api/users",
+			queries: []stubQuery{rowsOf("FROM users", user)},
+			handler: func(db *sqlx.DB) echo.HandlerFunc { return users(db).GetUsers },
+		},
+		{
+			name: "user", method: http.MethodGet, route: "/api/users/:id", target: "/api/users/2",
+			queries: []stubQuery{rowsOf("FROM users WHERE user_id", user)},
+			handler: func(db *sqlx.DB) echo.HandlerFunc { return users(db).GetUser },
+		},
+		{
+			name: "users-search", method: http.MethodGet, route: "/api/users/search", target: "/api/users/search?q=ana",
+			queries: []stubQuery{rowsOf("FROM users", user)},
+			handler: func(db *sqlx.DB) echo.HandlerFunc { return users(db).SearchUsers },
+		},
+		{
+			name: "dashboard", method: http.MethodGet, route: "/api/dashboard", target: "/api/dashboard",
+			queries: []stubQuery{
+				rowsOf("TO_CHAR(order_date", trend),
+				valuesOf("AS total_sales", "total_sales", 1250.0),
+				valuesOf("COUNT(*) AS order_count", "order_count", 3),
+				rowsOf("i.current_stock < i.reorder_level", lowStockItem),
+				rowsOf("AS total_spent", topCustomer),
+			},
+			handler: func(db *sqlx.DB) echo.HandlerFunc { return reports(db).GetDashboardSummary },
+		},
+		{
+			name: "sales-trends", method: http.MethodGet, route: "/api/reports/sales-trends", target: "/api/reports/sales-trends?days=30",
+			queries: []stubQuery{rowsOf("TO_CHAR(order_date", trend)},
+			handler: func(db *sqlx.DB) echo.HandlerFunc { return reports(db).GetSalesTrends },
+		},
+		{
+			name: "report-low-stock", method: http.MethodGet, route: "/api/reports/low-stock", target: "/api/reports/low-stock",
+			queries: []stubQuery{rowsOf("i.current_stock < i.reorder_level", lowStockItem)},
+			handler: func(db *sqlx.DB) echo.HandlerFunc { return reports(db).GetLowStockItems },
+		},
+		{
+			name: "top-customers", method: http.MethodGet, route: "/api/reports/top-customers", target: "/api/reports/top-customers?limit=5",
+			queries: []stubQuery{rowsOf("AS total_spent", topCustomer)},
+			handler: func(db *sqlx.DB) echo.HandlerFunc { return reports(db).GetTopCustomers },
+		},
+	}
+
+	for _, contract := range contracts {
+		t.Run(contract.name, func(t *testing.T) {
+			db := newStubDB(t, contract.queries...)
+
+			e := echo.New()
+			e.Validator = NewRequestValidator()
+			e.HTTPErrorHandler = HTTPErrorHandler
+			e.Add(contract.method, contract.route, contract.handler(db))
+
+			req := httptest.NewRequest(contract.method, contract.target, strings.NewReader(contract.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code < 200 || rec.Code > 299 {
+				t.Fatalf("%s %s answered %d: %s", contract.method, contract.target, rec.Code, rec.Body.String())
+			}
+			var response interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to decode the response: %v", err)
+			}
+			checkContract(t, contract.name, shapeOf(response))
+		})
+	}
+}
+
+// checkContract compares a response shape with its snapshot, or records it with -update
+func checkContract(t *testing.T, name string, shape interface{}) {
+	t.Helper()
+
+	path := filepath.Join("testdata", "contracts", name+".json")
+	encoded, err := json.MarshalIndent(shape, "", "  ")
+	if err != nil {
+		t.Fatalf("Failed to encode the response shape: %v", err)
+	}
+	encoded = append(encoded, '\n')
+
+	if *updateContracts {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, encoded, 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+		return
+	}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("No snapshot at %s; record it with -update", path)
+	}
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	var snapshot interface{}
+	if err := json.Unmarshal(raw, &snapshot); err != nil {
+		t.Fatalf("Failed to parse %s: %v", path, err)
+	}
+
+	var changes []string
+	compareShapes("response", snapshot, shape, &changes)
+	for _, change := range changes {
+		t.Error(change)
+	}
+	if len(changes) > 0 {
+		t.Log("Update the frontend and its types, then record the new shape with -update")
+	}
+}
+
+// shapeOf describes a decoded JSON value by its type: objects keep their field names, arrays
+// the shape of their first element and strings holding a time are told apart
+func shapeOf(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		shape := map[string]interface{}{}
+		for name, field := range value {
+			shape[name] = shapeOf(field)
+		}
+		return shape
+	case []interface{}:
+		if len(value) == 0 {
+			return []interface{}{}
+		}
+		return []interface{}{shapeOf(value[0])}
+	case string:
+		if _, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			return "date-time"
+		}
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return "null"
+	}
+}
+
+// compareShapes records under path how the shape now differs from the one it was
+func compareShapes(path string, was, now interface{}, changes *[]string) {
+	switch was := was.(type) {
+	case map[string]interface{}:
+		now, ok := now.(map[string]interface{})
+		if !ok {
+			*changes = append(*changes, fmt.Sprintf("%s: changed from an object to %s", path, shapeLabel(now)))
+			return
+		}
+		names := make([]string, 0, len(was)+len(now))
+		for name := range was {
+			names = append(names, name)
+		}
+		for name := range now {
+			if _, ok := was[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			wasField, wasOK := was[name]
+			nowField, nowOK := now[name]
+			switch {
+			case !nowOK:
+				*changes = append(*changes, fmt.Sprintf("%s.%s: removed", path, name))
+			case !wasOK:
+				*changes = append(*changes, fmt.Sprintf("%s.%s: added", path, name))
+			default:
+				compareShapes(path+"."+name, wasField, nowField, changes)
+			}
+		}
+	case []interface{}:
+		now, ok := now.([]interface{})
+		if !ok {
+			*changes = append(*changes, fmt.Sprintf("%s: changed from an array to %s", path, shapeLabel(now)))
+			return
+		}
+		if len(was) > 0 && len(now) > 0 {
+			compareShapes(path+"[]", was[0], now[0], changes)
+		} else if len(was) != len(now) {
+			*changes = append(*changes, fmt.Sprintf("%s: elements changed from %d to %d", path, len(was), len(now)))
+		}
+	default:
+		if was != now {
+			*changes = append(*changes, fmt.Sprintf("%s: changed from %v to %s", path, was, shapeLabel(now)))
+		}
+	}
+}
+
+// shapeLabel names a shape in a change message
+func shapeLabel(shape interface{}) string {
+	switch shape.(type) {
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	default:
+		return fmt.Sprint(shape)
+	}
+}
+
+// sampleCustomer is an active customer with every field set
+func sampleCustomer() models.Customer {
+	customer := models.Customer{CustomerID: 7, CompanyName: "Acme Industrial", VATClassification: models.VATVatable, Tier: "gold", Version: 3}
+	fillSample(&customer)
+	customer.ArchivedAt, customer.DeletedAt = nil, nil
+	return customer
+}
+
+// sampleQuotation is a pending quotation for customer with every field set
+func sampleQuotation(customer models.Customer) models.Quotation {
+	quotation := models.Quotation{QuotationID: 15, CustomerID: customer.CustomerID, Status: "Pending", Currency: "PHP", ExchangeRate: 1, Version: 2}
+	fillSample(&quotation)
+	return quotation
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// ExchangeRateRequest records what one unit of a currency is worth in pesos from a day,
+// today when no day is given
+type ExchangeRateRequest struct {
+	Rate          float64 `json:"rate" validate:"gt=0"`
+	EffectiveDate string  `json:"effective_date" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// CurrencyHandler handles HTTP requests for currencies and their exchange rates
+type CurrencyHandler struct {
+	currencyService *services.CurrencyService
+	auditRepo       *repository.AuditRepository
+}
+
+// NewCurrencyHandler creates a new currency handler
+func NewCurrencyHandler(currencyService *services.CurrencyService, auditRepo *repository.AuditRepository) *CurrencyHandler {
+	return &CurrencyHandler{
+		currencyService: currencyService,
+		auditRepo:       auditRepo,
+	}
+}
+
+// GetCurrencies returns every currency documents can be written in
+func (h *CurrencyHandler) GetCurrencies(c echo.Context) error {
+	currencies, err := h.currencyService.Currencies(c.Request().Context())
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve currencies")
+	}
+
+	return jsonList(c, http.StatusOK, currencies)
+}
+
+// GetRates returns the exchange rates recorded for a currency, latest first
+func (h *CurrencyHandler) GetRates(c echo.Context) error {
+	rates, err := h.currencyService.Rates(c.Request().Context(), c.Param("code"))
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownCurrency) {
+			return models.NewAPIError(http.StatusNotFound, "Currency not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve exchange rates")
+	}
+
+	return jsonList(c, http.StatusOK, rates)
+}
+
+// SetRate records a currency's exchange rate from a day, replacing one already recorded for
+// that day. Documents already written keep the rate they were priced at.
+func (h *CurrencyHandler) SetRate(c echo.Context) error {
+	var req ExchangeRateRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	effective := time.Now()
+	if req.EffectiveDate != "" {
+		effective, _ = time.Parse(deliveryDateLayout, req.EffectiveDate)
+	}
+
+	rate := models.ExchangeRate{
+		CurrencyCode:  c.Param("code"),
+		Rate:          req.Rate,
+		EffectiveDate: time.Date(effective.Year(), effective.Month(), effective.Day(), 0, 0, 0, 0, time.UTC),
+	}
+	if user := appmw.UserFromContext(c); user != nil {
+		rate.CreatedBy = &user.UserID
+	}
+
+	if err := h.currencyService.SetRate(c.Request().Context(), &rate); err != nil {
+		switch {
+		case errors.Is(err, services.ErrUnknownCurrency):
+			return models.NewAPIError(http.StatusNotFound, "Currency not found")
+		case errors.Is(err, services.ErrBaseCurrencyRate):
+			return models.NewAPIError(http.StatusBadRequest, "The peso's exchange rate is always 1")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to record exchange rate")
+	}
+
+	recordAudit(c, h.auditRepo, models.AuditUpdate, models.AuditEntityExchangeRate, rate.ExchangeRateID, nil, rate)
+
+	return c.JSON(http.StatusOK, rate)
+}
+
+// currencyError describes a document currency that cannot be priced in, or returns nil for
+// other pricing errors
+func currencyError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrUnknownCurrency):
+		return models.NewAPIError(http.StatusBadRequest, "Unknown currency")
+	case errors.Is(err, services.ErrNoExchangeRate):
+		return models.NewAPIError(http.StatusBadRequest, "No exchange rate is recorded for the currency")
+	}
+	return nil
+}
@@ -1,48 +1,77 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Cezzyy/SCMS/backend/internal/models"
 	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
 	"github.com/labstack/echo/v4"
 )
 
+// geocodeTimeout bounds the geocoding lookup done while saving a customer
+const geocodeTimeout = 5 * time.Second
+
 // CustomerHandler handles HTTP requests for customers
 type CustomerHandler struct {
-	customerRepo *repository.CustomerRepository
+	customerRepo     *repository.CustomerRepository
+	industryRepo     *repository.IndustryRepository
+	geocodingService *services.GeocodingService
+	auditRepo        *repository.AuditRepository
 }
 
-// NewCustomerHandler creates a new customer handler with the provided repository
-func NewCustomerHandler(customerRepo *repository.CustomerRepository) *CustomerHandler {
+// NewCustomerHandler creates a new customer handler with the provided repositories
+func NewCustomerHandler(
+	customerRepo *repository.CustomerRepository,
+	industryRepo *repository.IndustryRepository,
+	geocodingService *services.GeocodingService,
+	auditRepo *repository.AuditRepository,
+) *CustomerHandler {
 	return &CustomerHandler{
-		customerRepo: customerRepo,
+		customerRepo:     customerRepo,
+		industryRepo:     industryRepo,
+		geocodingService: geocodingService,
+		auditRepo:        auditRepo,
 	}
 }
 
-// GetAllCustomers returns all customers
+// GetAllCustomers returns all customers, or one page of them when ?page= and ?page_size=
+// (or ?limit= and ?offset=) are given; see parsePage.
 func (h *CustomerHandler) GetAllCustomers(c echo.Context) error {
 	ctx := c.Request().Context()
 
+	page, paged, message := parsePage(c)
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
 	// Check for search parameter
 	searchTerm := c.QueryParam("search")
 	var customers []models.Customer
+	var total int
 	var err error
 
-	if searchTerm != "" {
-		customers, err = h.customerRepo.SearchCustomers(ctx, searchTerm)
+	if c.QueryParam("deleted") == "true" {
+		customers, total, err = h.customerRepo.GetDeleted(ctx, page)
+	} else if c.QueryParam("archived") == "true" {
+		customers, total, err = h.customerRepo.GetArchived(ctx, page)
+	} else if searchTerm != "" {
+		customers, total, err = h.customerRepo.SearchCustomers(ctx, searchTerm, page)
 	} else {
-		customers, err = h.customerRepo.GetAll(ctx)
+		customers, total, err = h.customerRepo.GetAll(ctx, page)
 	}
 
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve customers",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve customers")
 	}
 
-	return c.JSON(http.StatusOK, customers)
+	return jsonPage(c, http.StatusOK, customers, page, paged, total)
 }
 
 // GetCustomerByID returns a customer by ID
@@ -51,21 +80,15 @@ func (h *CustomerHandler) GetCustomerByID(c echo.Context) error {
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid customer ID",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid customer ID")
 	}
 
 	customer, err := h.customerRepo.GetByID(ctx, id)
 	if err != nil {
-		if err.Error() == "customer not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Customer not found",
-			})
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Customer not found")
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve customer",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve customer")
 	}
 
 	return c.JSON(http.StatusOK, customer)
@@ -77,129 +100,426 @@ func (h *CustomerHandler) CreateCustomer(c echo.Context) error {
 
 	var customer models.Customer
 	if err := c.Bind(&customer); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request payload",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(&customer); err != nil {
+		return validationError(err)
 	}
 
-	// Validate required fields
-	if customer.CompanyName == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Company name is required",
-		})
+	if message, err := h.resolveIndustry(ctx, &customer); err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to validate industry")
+	} else if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	// Keep the single-line address in step with the structured fields
+	if customer.HasStructuredAddress() {
+		formatted := customer.FormattedAddress()
+		customer.Address = &formatted
 	}
 
 	err := h.customerRepo.Create(ctx, &customer)
 	if err != nil {
 		if err == repository.ErrDuplicateKey {
-			return c.JSON(http.StatusConflict, map[string]string{
-				"error": "A customer with this information already exists",
-			})
+			return models.NewAPIError(http.StatusConflict, "A customer with this information already exists")
 		}
 
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to create customer",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to create customer")
 	}
 
+	h.geocode(ctx, &customer)
+	recordAudit(c, h.auditRepo, models.AuditCreate, models.AuditEntityCustomer, customer.CustomerID, nil, customer)
+
 	return c.JSON(http.StatusCreated, customer)
 }
 
-// UpdateCustomer updates an existing customer
+// UpdateCustomer updates an existing customer. The version taken from the customer is required
+// and guards against overwriting someone else's change: without it 428 is returned, and when
+// the customer has changed since, 409 with the current customer.
 func (h *CustomerHandler) UpdateCustomer(c echo.Context) error {
 	ctx := c.Request().Context()
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid customer ID",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid customer ID")
 	}
 
 	var customer models.Customer
 	if err := c.Bind(&customer); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request payload",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
 	}
 
 	// Ensure ID in path matches ID in payload
 	customer.CustomerID = id
 
-	// Validate required fields
-	if customer.CompanyName == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Company name is required",
-		})
+	if err := c.Validate(&customer); err != nil {
+		return validationError(err)
+	}
+	if customer.Version == 0 {
+		return versionRequiredError("Customer")
+	}
+
+	if message, err := h.resolveIndustry(ctx, &customer); err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to validate industry")
+	} else if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	// Keep the single-line address in step with the structured fields
+	if customer.HasStructuredAddress() {
+		formatted := customer.FormattedAddress()
+		customer.Address = &formatted
+	}
+
+	before, err := h.customerRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Customer not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve customer")
 	}
 
 	err = h.customerRepo.Update(ctx, &customer)
 	if err != nil {
-		if err.Error() == "customer not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Customer not found",
-			})
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Customer not found")
 		}
 		if err == repository.ErrDuplicateKey {
-			return c.JSON(http.StatusConflict, map[string]string{
-				"error": "A customer with this information already exists",
-			})
+			return models.NewAPIError(http.StatusConflict, "A customer with this information already exists")
+		}
+		if err == repository.ErrStaleVersion {
+			current, getErr := h.customerRepo.GetByID(ctx, id)
+			if getErr != nil {
+				return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve customer")
+			}
+			return staleVersionError("Customer", current)
 		}
 
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to update customer",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update customer")
 	}
 
+	h.geocode(ctx, &customer)
+	recordAudit(c, h.auditRepo, models.AuditUpdate, models.AuditEntityCustomer, id, before, customer)
+
 	return c.JSON(http.StatusOK, customer)
 }
 
-// DeleteCustomer deletes a customer
+// DeleteCustomer soft-deletes a customer and their contacts; orders and quotations keep
+// referring to the customer
 func (h *CustomerHandler) DeleteCustomer(c echo.Context) error {
 	ctx := c.Request().Context()
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid customer ID",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid customer ID")
+	}
+
+	before, err := h.customerRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Customer not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve customer")
 	}
 
 	err = h.customerRepo.Delete(ctx, id)
 	if err != nil {
-		if err.Error() == "customer not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Customer not found",
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Customer not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to delete customer")
+	}
+
+	recordAudit(c, h.auditRepo, models.AuditDelete, models.AuditEntityCustomer, id, before, nil)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RestoreCustomer brings back a soft-deleted customer with the contacts deleted with them
+func (h *CustomerHandler) RestoreCustomer(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid customer ID")
+	}
+
+	if err := h.customerRepo.Restore(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Deleted customer not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to restore customer")
+	}
+
+	customer, err := h.customerRepo.GetByID(ctx, id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve customer")
+	}
+
+	recordAudit(c, h.auditRepo, "restore", models.AuditEntityCustomer, id, nil, customer)
+
+	return c.JSON(http.StatusOK, customer)
+}
+
+// PurgeCustomer permanently removes a soft-deleted customer that has no orders or quotations
+func (h *CustomerHandler) PurgeCustomer(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid customer ID")
+	}
+
+	before, err := h.customerRepo.GetByIDWithDeleted(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Customer not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve customer")
+	}
+
+	err = h.customerRepo.Purge(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Customer not found")
+		}
+		if err == repository.ErrNotDeleted {
+			return models.NewAPIError(http.StatusConflict, "Customer must be deleted before it can be purged")
+		}
+		if err == repository.ErrCustomerHasHistory {
+			dependencies, depErr := h.customerRepo.GetDependencies(ctx, []int{id})
+			if depErr != nil {
+				return models.NewAPIError(http.StatusConflict, "Customer has orders or quotations and can only be archived")
+			}
+			return models.NewAPIError(http.StatusConflict, "Customer has orders or quotations and can only be archived").WithDetails(map[string]interface{}{
+				"dependencies": dependencies[id],
 			})
 		}
 
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to delete customer",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to purge customer")
 	}
 
+	recordAudit(c, h.auditRepo, "purge", models.AuditEntityCustomer, id, before, nil)
+
 	return c.NoContent(http.StatusNoContent)
 }
 
+// GetCustomerDependencies reports what references a customer and whether it can be deleted or only archived
+func (h *CustomerHandler) GetCustomerDependencies(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid customer ID")
+	}
+
+	customer, err := h.customerRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Customer not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve customer")
+	}
+
+	dependencies, err := h.customerRepo.GetDependencies(ctx, []int{id})
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to check customer dependencies")
+	}
+
+	report := dependencies[id]
+	if report == nil {
+		report = []models.Dependency{}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"customer_id":  id,
+		"archived":     customer.ArchivedAt != nil,
+		"can_delete":   !repository.HasBlockingDependency(report),
+		"can_archive":  customer.ArchivedAt == nil,
+		"dependencies": report,
+	})
+}
+
+// ArchiveCustomer archives a customer, hiding them from active lists while keeping their history
+func (h *CustomerHandler) ArchiveCustomer(c echo.Context) error {
+	return h.setArchived(c, true)
+}
+
+// UnarchiveCustomer restores an archived customer to the active list
+func (h *CustomerHandler) UnarchiveCustomer(c echo.Context) error {
+	return h.setArchived(c, false)
+}
+
+// setArchived archives or unarchives the customer in the path and returns it
+func (h *CustomerHandler) setArchived(c echo.Context, archived bool) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid customer ID")
+	}
+
+	before, err := h.customerRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Customer not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve customer")
+	}
+
+	if archived {
+		err = h.customerRepo.Archive(ctx, id)
+	} else {
+		err = h.customerRepo.Unarchive(ctx, id)
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Customer not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update customer")
+	}
+
+	customer, err := h.customerRepo.GetByID(ctx, id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve customer")
+	}
+
+	action := "archive"
+	if !archived {
+		action = "unarchive"
+	}
+	recordAudit(c, h.auditRepo, action, models.AuditEntityCustomer, id, before, customer)
+
+	return c.JSON(http.StatusOK, customer)
+}
+
 // CheckCompanyExists checks if a company name already exists
 func (h *CustomerHandler) CheckCompanyExists(c echo.Context) error {
 	ctx := c.Request().Context()
 
 	companyName := c.QueryParam("company_name")
 	if companyName == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Company name is required",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Company name is required")
 	}
 
 	exists, err := h.customerRepo.CheckCompanyExists(ctx, companyName)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to check company existence",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to check company existence")
 	}
 
 	return c.JSON(http.StatusOK, map[string]bool{
 		"exists": exists,
 	})
 }
+
+// resolveIndustry replaces the customer's industry with its canonical spelling from the
+// industry list, returning a validation message when the industry is not in the list
+func (h *CustomerHandler) resolveIndustry(ctx context.Context, customer *models.Customer) (string, error) {
+	if customer.Industry == nil {
+		return "", nil
+	}
+
+	name := strings.TrimSpace(*customer.Industry)
+	if name == "" {
+		customer.Industry = nil
+		return "", nil
+	}
+
+	industry, err := h.industryRepo.GetByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return "Unknown industry: " + name, nil
+		}
+		return "", err
+	}
+
+	customer.Industry = &industry.Name
+	return "", nil
+}
+
+// geocode looks up coordinates for a saved customer that has an address but no location yet.
+// Failures are logged; the customer can be geocoded again later.
+func (h *CustomerHandler) geocode(ctx context.Context, customer *models.Customer) {
+	if !h.geocodingService.Enabled() || customer.Latitude != nil || customer.Address == nil || *customer.Address == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, geocodeTimeout)
+	defer cancel()
+	if err := h.geocodingService.GeocodeCustomer(ctx, customer); err != nil {
+		log.Printf("Failed to geocode customer %d: %v", customer.CustomerID, err)
+	}
+}
+
+// GetCustomerLocations returns the coordinates of active customers, optionally filtered by ?province=
+func (h *CustomerHandler) GetCustomerLocations(c echo.Context) error {
+	locations, err := h.customerRepo.GetLocations(c.Request().Context(), strings.TrimSpace(c.QueryParam("province")))
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve customer locations")
+	}
+
+	return jsonList(c, http.StatusOK, locations)
+}
+
+// GeocodeCustomer looks up and stores coordinates for a customer's current address
+func (h *CustomerHandler) GeocodeCustomer(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid customer ID")
+	}
+
+	customer, err := h.customerRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Customer not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve customer")
+	}
+
+	if err := h.geocodingService.GeocodeCustomer(ctx, &customer); err != nil {
+		switch err {
+		case services.ErrGeocodingDisabled:
+			return models.NewAPIError(http.StatusServiceUnavailable, err.Error())
+		case services.ErrAddressNotFound:
+			return models.NewAPIError(http.StatusUnprocessableEntity, err.Error())
+		}
+		return models.NewAPIError(http.StatusBadGateway, "Geocoding failed: "+err.Error())
+	}
+
+	return c.JSON(http.StatusOK, customer)
+}
+
+// SetCustomerLocation stores coordinates entered by hand, e.g. when geocoding is not configured
+func (h *CustomerHandler) SetCustomerLocation(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid customer ID")
+	}
+
+	var point services.GeoPoint
+	if err := c.Bind(&point); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(&point); err != nil {
+		return validationError(err)
+	}
+
+	geocodedAt, err := h.customerRepo.SetLocation(c.Request().Context(), id, point.Latitude, point.Longitude)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Customer not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to save customer location")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"customer_id": id,
+		"latitude":    point.Latitude,
+		"longitude":   point.Longitude,
+		"geocoded_at": geocodedAt,
+	})
+}
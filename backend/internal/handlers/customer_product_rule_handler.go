@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/labstack/echo/v4"
+)
+
+// CustomerProductRuleHandler handles HTTP requests for per-customer allowed and blocked products
+type CustomerProductRuleHandler struct {
+	ruleRepo     *repository.CustomerProductRuleRepository
+	customerRepo *repository.CustomerRepository
+	productRepo  *repository.ProductRepository
+}
+
+// NewCustomerProductRuleHandler creates a new customer product rule handler with the provided repositories
+func NewCustomerProductRuleHandler(
+	ruleRepo *repository.CustomerProductRuleRepository,
+	customerRepo *repository.CustomerRepository,
+	productRepo *repository.ProductRepository,
+) *CustomerProductRuleHandler {
+	return &CustomerProductRuleHandler{
+		ruleRepo:     ruleRepo,
+		customerRepo: customerRepo,
+		productRepo:  productRepo,
+	}
+}
+
+// GetProductRules returns a customer's allowed and blocked products
+func (h *CustomerProductRuleHandler) GetProductRules(c echo.Context) error {
+	customerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid customer ID")
+	}
+
+	rules, err := h.ruleRepo.GetByCustomerID(c.Request().Context(), customerID)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve product rules")
+	}
+
+	return jsonList(c, http.StatusOK, rules)
+}
+
+// SaveProductRule allows or blocks a product for a customer
+func (h *CustomerProductRuleHandler) SaveProductRule(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	customerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid customer ID")
+	}
+	productID, err := strconv.Atoi(c.Param("product_id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid product ID")
+	}
+
+	var rule models.CustomerProductRule
+	if err := c.Bind(&rule); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&rule); err != nil {
+		return validationError(err)
+	}
+	rule.CustomerID = customerID
+	rule.ProductID = productID
+
+	if _, err := h.customerRepo.GetByID(ctx, customerID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Customer not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve customer")
+	}
+
+	product, err := h.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Product not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve product")
+	}
+
+	if err := h.ruleRepo.Save(ctx, &rule); err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to save product rule")
+	}
+	rule.ProductName = product.ProductName
+
+	return c.JSON(http.StatusOK, rule)
+}
+
+// DeleteProductRule removes a customer's rule for a product
+func (h *CustomerProductRuleHandler) DeleteProductRule(c echo.Context) error {
+	customerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid customer ID")
+	}
+	productID, err := strconv.Atoi(c.Param("product_id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid product ID")
+	}
+
+	if err := h.ruleRepo.Delete(c.Request().Context(), customerID, productID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Product rule not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to delete product rule")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// DeviceHandler handles HTTP requests for registered API client devices
+type DeviceHandler struct {
+	deviceRepo    *repository.DeviceRepository
+	deviceService *services.DeviceService
+}
+
+// NewDeviceHandler creates a new device handler with the provided repository and service
+func NewDeviceHandler(deviceRepo *repository.DeviceRepository, deviceService *services.DeviceService) *DeviceHandler {
+	return &DeviceHandler{
+		deviceRepo:    deviceRepo,
+		deviceService: deviceService,
+	}
+}
+
+// registerDeviceRequest is the body for registering a device
+type registerDeviceRequest struct {
+	Name       string `json:"name" validate:"notblank"`
+	DeviceType string `json:"device_type" validate:"oneof=tablet scanner other"`
+}
+
+// GetDevices returns all registered devices with their last-seen details
+func (h *DeviceHandler) GetDevices(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	devices, err := h.deviceRepo.GetAll(ctx)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve devices")
+	}
+
+	return jsonList(c, http.StatusOK, devices)
+}
+
+// RegisterDevice registers a device and returns its token, which is shown only once
+func (h *DeviceHandler) RegisterDevice(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req registerDeviceRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+	req.Name = strings.TrimSpace(req.Name)
+
+	var registeredBy *int
+	if user := appmw.UserFromContext(c); user != nil {
+		registeredBy = &user.UserID
+	}
+
+	device, token, err := h.deviceService.Register(ctx, req.Name, req.DeviceType, registeredBy)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to register device")
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"device": device,
+		"token":  token,
+	})
+}
+
+// RevokeDevice revokes a device token so the device can no longer call the API
+func (h *DeviceHandler) RevokeDevice(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid device ID")
+	}
+
+	if err := h.deviceRepo.Revoke(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Device not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to revoke device")
+	}
+
+	device, err := h.deviceRepo.GetByID(ctx, id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve device")
+	}
+
+	return c.JSON(http.StatusOK, device)
+}
+
+// GetCurrentDevice returns the device identified by the request's device token
+func (h *DeviceHandler) GetCurrentDevice(c echo.Context) error {
+	device := appmw.DeviceFromContext(c)
+	if device == nil {
+		return models.NewAPIError(http.StatusUnauthorized, "Device token required")
+	}
+
+	return c.JSON(http.StatusOK, device)
+}
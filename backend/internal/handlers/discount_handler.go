@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// QuantityBreaksRequest replaces a product's quantity breaks; an empty list removes them
+type QuantityBreaksRequest struct {
+	Breaks []models.QuantityBreak `json:"breaks" validate:"dive"`
+}
+
+// DiscountHandler handles HTTP requests for the quantity breaks that discount products
+// bought in bulk
+type DiscountHandler struct {
+	discountService *services.DiscountService
+	productRepo     *repository.ProductRepository
+	auditRepo       *repository.AuditRepository
+}
+
+// NewDiscountHandler creates a new discount handler
+func NewDiscountHandler(
+	discountService *services.DiscountService,
+	productRepo *repository.ProductRepository,
+	auditRepo *repository.AuditRepository,
+) *DiscountHandler {
+	return &DiscountHandler{
+		discountService: discountService,
+		productRepo:     productRepo,
+		auditRepo:       auditRepo,
+	}
+}
+
+// GetQuantityBreaks returns a product's quantity breaks, smallest minimum quantity first
+func (h *DiscountHandler) GetQuantityBreaks(c echo.Context) error {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid product ID")
+	}
+
+	breaks, err := h.discountService.QuantityBreaks(c.Request().Context(), productID)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve quantity breaks")
+	}
+
+	return jsonList(c, http.StatusOK, breaks)
+}
+
+// SetQuantityBreaks replaces a product's quantity breaks. Lines priced from then on that
+// are entered without a discount get the break with the highest minimum quantity they
+// reach, unless the customer's tier discount is larger.
+func (h *DiscountHandler) SetQuantityBreaks(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid product ID")
+	}
+
+	var req QuantityBreaksRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	if _, err := h.productRepo.GetByID(ctx, productID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Product not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve product")
+	}
+
+	before, err := h.discountService.QuantityBreaks(ctx, productID)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve quantity breaks")
+	}
+
+	breaks := req.Breaks
+	if breaks == nil {
+		breaks = []models.QuantityBreak{}
+	}
+	if err := h.discountService.SetQuantityBreaks(ctx, productID, breaks); err != nil {
+		if errors.Is(err, repository.ErrDuplicateKey) {
+			return models.NewAPIError(http.StatusBadRequest, "Two quantity breaks have the same minimum quantity")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to save quantity breaks")
+	}
+
+	recordAudit(c, h.auditRepo, models.AuditUpdate, models.AuditEntityProduct, productID,
+		map[string]interface{}{"quantity_breaks": before},
+		map[string]interface{}{"quantity_breaks": breaks})
+
+	return jsonList(c, http.StatusOK, breaks)
+}
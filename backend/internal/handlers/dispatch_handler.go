@@ -0,0 +1,428 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// deliveryDateLayout is the format of delivery dates in requests
+const deliveryDateLayout = "2006-01-02"
+
+// DispatchHandler handles HTTP requests for vehicles, drivers and delivery assignments
+type DispatchHandler struct {
+	vehicleRepo  *repository.VehicleRepository
+	driverRepo   *repository.DriverRepository
+	deliveryRepo *repository.DeliveryRepository
+	orderRepo    *repository.OrderRepository
+	pdfGenerator *services.PDFGenerator
+}
+
+// NewDispatchHandler creates a new dispatch handler with the provided repositories
+func NewDispatchHandler(
+	vehicleRepo *repository.VehicleRepository,
+	driverRepo *repository.DriverRepository,
+	deliveryRepo *repository.DeliveryRepository,
+	orderRepo *repository.OrderRepository,
+	pdfGenerator *services.PDFGenerator,
+) *DispatchHandler {
+	return &DispatchHandler{
+		vehicleRepo:  vehicleRepo,
+		driverRepo:   driverRepo,
+		deliveryRepo: deliveryRepo,
+		orderRepo:    orderRepo,
+		pdfGenerator: pdfGenerator,
+	}
+}
+
+// GetVehicles returns all vehicles
+func (h *DispatchHandler) GetVehicles(c echo.Context) error {
+	vehicles, err := h.vehicleRepo.GetAll(c.Request().Context())
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve vehicles")
+	}
+
+	return jsonList(c, http.StatusOK, vehicles)
+}
+
+// CreateVehicle registers a vehicle
+func (h *DispatchHandler) CreateVehicle(c echo.Context) error {
+	vehicle := models.Vehicle{Active: true}
+	if err := c.Bind(&vehicle); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(&vehicle); err != nil {
+		return validationError(err)
+	}
+	vehicle.PlateNumber = strings.ToUpper(strings.TrimSpace(vehicle.PlateNumber))
+
+	if err := h.vehicleRepo.Create(c.Request().Context(), &vehicle); err != nil {
+		if err == repository.ErrDuplicateKey {
+			return models.NewAPIError(http.StatusConflict, "A vehicle with this plate number already exists")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to create vehicle")
+	}
+
+	return c.JSON(http.StatusCreated, vehicle)
+}
+
+// UpdateVehicle updates a vehicle
+func (h *DispatchHandler) UpdateVehicle(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid vehicle ID")
+	}
+
+	var vehicle models.Vehicle
+	if err := c.Bind(&vehicle); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+	vehicle.VehicleID = id
+
+	if err := c.Validate(&vehicle); err != nil {
+		return validationError(err)
+	}
+	vehicle.PlateNumber = strings.ToUpper(strings.TrimSpace(vehicle.PlateNumber))
+
+	if err := h.vehicleRepo.Update(c.Request().Context(), &vehicle); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Vehicle not found")
+		}
+		if err == repository.ErrDuplicateKey {
+			return models.NewAPIError(http.StatusConflict, "A vehicle with this plate number already exists")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update vehicle")
+	}
+
+	return c.JSON(http.StatusOK, vehicle)
+}
+
+// DeleteVehicle deletes a vehicle that has never been assigned a delivery
+func (h *DispatchHandler) DeleteVehicle(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid vehicle ID")
+	}
+
+	if err := h.vehicleRepo.Delete(c.Request().Context(), id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Vehicle not found")
+		}
+		if err == repository.ErrReferencedRecord {
+			return models.NewAPIError(http.StatusConflict, "Vehicle has deliveries and can only be deactivated")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to delete vehicle")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetDrivers returns all drivers
+func (h *DispatchHandler) GetDrivers(c echo.Context) error {
+	drivers, err := h.driverRepo.GetAll(c.Request().Context())
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve drivers")
+	}
+
+	return jsonList(c, http.StatusOK, drivers)
+}
+
+// CreateDriver registers a driver
+func (h *DispatchHandler) CreateDriver(c echo.Context) error {
+	driver := models.Driver{Active: true}
+	if err := c.Bind(&driver); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(&driver); err != nil {
+		return validationError(err)
+	}
+	driver.Name = strings.TrimSpace(driver.Name)
+
+	if err := h.driverRepo.Create(c.Request().Context(), &driver); err != nil {
+		return driverSaveError(c, err, "Failed to create driver")
+	}
+
+	return c.JSON(http.StatusCreated, driver)
+}
+
+// UpdateDriver updates a driver
+func (h *DispatchHandler) UpdateDriver(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid driver ID")
+	}
+
+	var driver models.Driver
+	if err := c.Bind(&driver); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+	driver.DriverID = id
+
+	if err := c.Validate(&driver); err != nil {
+		return validationError(err)
+	}
+	driver.Name = strings.TrimSpace(driver.Name)
+
+	if err := h.driverRepo.Update(c.Request().Context(), &driver); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Driver not found")
+		}
+		return driverSaveError(c, err, "Failed to update driver")
+	}
+
+	return c.JSON(http.StatusOK, driver)
+}
+
+// DeleteDriver deletes a driver who has never been assigned a delivery
+func (h *DispatchHandler) DeleteDriver(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid driver ID")
+	}
+
+	if err := h.driverRepo.Delete(c.Request().Context(), id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Driver not found")
+		}
+		if err == repository.ErrReferencedRecord {
+			return models.NewAPIError(http.StatusConflict, "Driver has deliveries and can only be deactivated")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to delete driver")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetDeliveries returns the delivery assignments for ?date= (default today), optionally for one ?vehicle_id=
+func (h *DispatchHandler) GetDeliveries(c echo.Context) error {
+	date, err := deliveryDate(c.QueryParam("date"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid date, expected YYYY-MM-DD")
+	}
+
+	vehicleID := 0
+	if value := c.QueryParam("vehicle_id"); value != "" {
+		if vehicleID, err = strconv.Atoi(value); err != nil {
+			return models.NewAPIError(http.StatusBadRequest, "Invalid vehicle ID")
+		}
+	}
+
+	assignments, err := h.deliveryRepo.GetByDate(c.Request().Context(), date, vehicleID)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve deliveries")
+	}
+
+	return jsonList(c, http.StatusOK, assignments)
+}
+
+// DeliveryAssignmentRequest schedules an order for delivery
+type DeliveryAssignmentRequest struct {
+	OrderID      int    `json:"order_id" validate:"required"`
+	DeliveryDate string `json:"delivery_date" validate:"omitempty,datetime=2006-01-02"`
+	VehicleID    int    `json:"vehicle_id" validate:"required"`
+	DriverID     int    `json:"driver_id" validate:"required"`
+	StopSequence int    `json:"stop_sequence"`
+}
+
+// AssignDelivery schedules an order on a vehicle and driver for a day, or moves an existing
+// assignment. Assignments that would overload the vehicle are refused with 409.
+func (h *DispatchHandler) AssignDelivery(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req DeliveryAssignmentRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+	date, err := deliveryDate(req.DeliveryDate)
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid delivery date, expected YYYY-MM-DD")
+	}
+
+	order, err := h.orderRepo.GetByID(ctx, req.OrderID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Order not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve order")
+	}
+	if order.Status == "Delivered" || order.Status == "Cancelled" {
+		return models.NewAPIError(http.StatusBadRequest, "Order is "+strings.ToLower(order.Status)+" and cannot be scheduled for delivery")
+	}
+	if order.ShippingAddress == "" {
+		return models.NewAPIError(http.StatusBadRequest, "Order has no shipping address")
+	}
+
+	vehicle, err := h.vehicleRepo.GetByID(ctx, req.VehicleID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusBadRequest, "Vehicle not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve vehicle")
+	}
+	driver, err := h.driverRepo.GetByID(ctx, req.DriverID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusBadRequest, "Driver not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve driver")
+	}
+	if !vehicle.Active || !driver.Active {
+		return models.NewAPIError(http.StatusBadRequest, "Vehicle and driver must be active")
+	}
+
+	assignment := models.DeliveryAssignment{
+		OrderID:      req.OrderID,
+		DeliveryDate: date,
+		VehicleID:    req.VehicleID,
+		DriverID:     req.DriverID,
+		StopSequence: req.StopSequence,
+	}
+	if err := h.deliveryRepo.Assign(ctx, &assignment); err != nil {
+		var capacity *repository.CapacityExceededError
+		if errors.As(err, &capacity) {
+			return models.NewAPIError(http.StatusConflict, capacity.Error()).WithDetails(map[string]interface{}{
+				"capacity": capacity,
+			})
+		}
+		if err == repository.ErrVehicleTaken || err == repository.ErrDriverTaken {
+			return models.NewAPIError(http.StatusConflict, err.Error())
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to assign delivery")
+	}
+
+	return c.JSON(http.StatusOK, assignment)
+}
+
+// UnassignDelivery removes a delivery assignment
+func (h *DispatchHandler) UnassignDelivery(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid assignment ID")
+	}
+
+	if err := h.deliveryRepo.Delete(c.Request().Context(), id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Delivery assignment not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to remove delivery assignment")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetDriverManifest returns a driver's deliveries for ?date= (default today) in stop order
+func (h *DispatchHandler) GetDriverManifest(c echo.Context) error {
+	manifest, status, message := h.buildManifest(c)
+	if message != "" {
+		return models.NewAPIError(status, message)
+	}
+
+	return c.JSON(http.StatusOK, manifest)
+}
+
+// GetDriverManifestPDF renders a driver's manifest for ?date= (default today) as a PDF
+func (h *DispatchHandler) GetDriverManifestPDF(c echo.Context) error {
+	manifest, status, message := h.buildManifest(c)
+	if message != "" {
+		return models.NewAPIError(status, message)
+	}
+
+	templateData := map[string]interface{}{
+		"Manifest":       manifest,
+		"GenerationDate": time.Now().Format("January 2, 2006 3:04 PM"),
+	}
+	content, err := h.pdfGenerator.GenerateFromTemplate("manifest/template.html", "", templateData)
+	if err != nil {
+		log.Printf("Failed to render manifest for driver %d: %v", manifest.Driver.DriverID, err)
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to render manifest")
+	}
+
+	filename := fmt.Sprintf("manifest_%s_driver_%d.pdf", manifest.DeliveryDate, manifest.Driver.DriverID)
+	c.Response().Header().Set("Content-Disposition", "attachment; filename="+filename)
+	return c.Blob(http.StatusOK, "application/pdf", content)
+}
+
+// buildManifest loads the manifest for the driver in the path, returning a status and
+// message when the request cannot be served
+func (h *DispatchHandler) buildManifest(c echo.Context) (*models.DeliveryManifest, int, string) {
+	ctx := c.Request().Context()
+
+	driverID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return nil, http.StatusBadRequest, "Invalid driver ID"
+	}
+	date, err := deliveryDate(c.QueryParam("date"))
+	if err != nil {
+		return nil, http.StatusBadRequest, "Invalid date, expected YYYY-MM-DD"
+	}
+
+	driver, err := h.driverRepo.GetByID(ctx, driverID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, http.StatusNotFound, "Driver not found"
+		}
+		return nil, http.StatusInternalServerError, "Failed to retrieve driver"
+	}
+
+	stops, err := h.deliveryRepo.GetManifestStops(ctx, date, driverID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, "Failed to retrieve deliveries"
+	}
+
+	manifest := &models.DeliveryManifest{
+		DeliveryDate: date.Format(deliveryDateLayout),
+		Driver:       driver,
+		Stops:        stops,
+	}
+	for _, stop := range stops {
+		manifest.TotalWeightKg += stop.WeightKg
+		manifest.TotalVolumeM3 += stop.VolumeM3
+	}
+
+	// A driver drives one vehicle per day, so any stop identifies it
+	if len(stops) > 0 {
+		assignment, err := h.deliveryRepo.GetByOrderID(ctx, stops[0].OrderID)
+		if err == nil {
+			if vehicle, err := h.vehicleRepo.GetByID(ctx, assignment.VehicleID); err == nil {
+				manifest.Vehicle = &vehicle
+			}
+		}
+	}
+
+	return manifest, 0, ""
+}
+
+// driverSaveError reports a failed driver save
+func driverSaveError(c echo.Context, err error, message string) error {
+	switch err {
+	case repository.ErrDuplicateKey:
+		return models.NewAPIError(http.StatusConflict, "This user account is already linked to another driver")
+	case repository.ErrReferencedRecord:
+		return models.NewAPIError(http.StatusBadRequest, "User not found")
+	}
+	return models.NewAPIError(http.StatusInternalServerError, message)
+}
+
+// deliveryDate parses a YYYY-MM-DD date, defaulting to today
+func deliveryDate(value string) (time.Time, error) {
+	if value == "" {
+		now := time.Now()
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), nil
+	}
+	return time.Parse(deliveryDateLayout, value)
+}
@@ -0,0 +1,478 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// swaggerUIVersion is the Swagger UI release loaded from the CDN by /api/docs
+const swaggerUIVersion = "5.17.14"
+
+// swaggerUIPage renders the spec at /api/openapi.json with Swagger UI
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8">
+	<title>SCMS API</title>
+	<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@` + swaggerUIVersion + `/swagger-ui.css">
+</head>
+<body>
+	<div id="swagger-ui"></div>
+	<script src="https://unpkg.com/swagger-ui-dist@` + swaggerUIVersion + `/swagger-ui-bundle.js"></script>
+	<script>
+		window.ui = SwaggerUIBundle({ url: "/api/openapi.json", dom_id: "#swagger-ui", withCredentials: true });
+	</script>
+</body>
+</html>`
+
+// swaggerUIPolicy relaxes the API's content security policy just enough for the docs page
+// to load Swagger UI from the CDN
+const swaggerUIPolicy = "default-src 'self'; script-src 'self' 'unsafe-inline' https://unpkg.com; " +
+	"style-src 'self' 'unsafe-inline' https://unpkg.com; img-src 'self' data: https://unpkg.com"
+
+// DocsHandler serves the OpenAPI specification and the Swagger UI that renders it
+type DocsHandler struct {
+	spec []byte
+}
+
+// NewDocsHandler generates the OpenAPI specification from the registered routes. It must be
+// created after every route is registered; routes registered later are left out.
+// publicPaths are the routes that need no authentication.
+func NewDocsHandler(routes []*echo.Route, publicPaths ...string) (*DocsHandler, error) {
+	spec, err := json.Marshal(BuildOpenAPISpec(routes, publicPaths))
+	if err != nil {
+		return nil, err
+	}
+	return &DocsHandler{
+		spec: spec,
+	}, nil
+}
+
+// GetOpenAPISpec returns the OpenAPI 3 specification of the API
+func (h *DocsHandler) GetOpenAPISpec(c echo.Context) error {
+	return c.JSONBlob(http.StatusOK, h.spec)
+}
+
+// GetSwaggerUI serves Swagger UI for browsing and trying the API
+func (h *DocsHandler) GetSwaggerUI(c echo.Context) error {
+	c.Response().Header().Set("Content-Security-Policy", swaggerUIPolicy)
+	return c.HTML(http.StatusOK, swaggerUIPage)
+}
+
+// BuildOpenAPISpec generates an OpenAPI 3 document describing every route. Paths, methods,
+// path parameters and operation names come from the routes themselves; request and response
+// bodies and query parameters come from openAPIOperations, so handlers without an entry are
+// still listed, just without schemas.
+func BuildOpenAPISpec(routes []*echo.Route, publicPaths []string) map[string]interface{} {
+	public := map[string]bool{}
+	for _, path := range publicPaths {
+		public[path] = true
+	}
+
+	schemas := &schemaBuilder{schemas: map[string]interface{}{
+		"Error": map[string]interface{}{
+			"type":     "object",
+			"required": []string{"error", "code"},
+			"properties": map[string]interface{}{
+				"error":   map[string]interface{}{"type": "string"},
+				"code":    map[string]interface{}{"type": "string"},
+				"details": map[string]interface{}{"type": "object"},
+			},
+		},
+	}}
+
+	// Sort so operation IDs shared by several routes are numbered the same way every time
+	sorted := make([]*echo.Route, 0, len(routes))
+	for _, route := range routes {
+		if strings.HasPrefix(route.Path, "/api/") && openAPIMethods[route.Method] {
+			sorted = append(sorted, route)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return sorted[i].Method < sorted[j].Method
+	})
+
+	paths := map[string]interface{}{}
+	operationIDs := map[string]int{}
+	for _, route := range sorted {
+		key, method := handlerName(route.Name)
+		doc := openAPIOperations[key]
+
+		operationID := method
+		if operationID == "" {
+			operationID = strings.ToLower(route.Method)
+			for _, word := range strings.FieldsFunc(strings.TrimPrefix(route.Path, "/api/"), func(r rune) bool { return !unicode.IsLetter(r) }) {
+				operationID += strings.ToUpper(word[:1]) + word[1:]
+			}
+		}
+		operationIDs[operationID]++
+		if n := operationIDs[operationID]; n > 1 {
+			operationID += strconv.Itoa(n)
+		}
+
+		path, parameters := openAPIPath(route.Path)
+		for _, name := range doc.Query {
+			parameters = append(parameters, map[string]interface{}{
+				"name":   name,
+				"in":     "query",
+				"schema": map[string]interface{}{"type": "string"},
+			})
+		}
+		if doc.List != nil {
+			parameters = append(parameters, listParameters(*doc.List)...)
+		}
+		if doc.Paged {
+			for _, name := range []string{"page", "page_size", "limit", "offset"} {
+				parameters = append(parameters, map[string]interface{}{
+					"name":   name,
+					"in":     "query",
+					"schema": map[string]interface{}{"type": "integer", "minimum": 0},
+				})
+			}
+		}
+
+		summary := doc.Summary
+		if summary == "" {
+			summary = humanize(method)
+		}
+		if summary == "" {
+			summary = route.Method + " " + route.Path
+		}
+
+		operation := map[string]interface{}{
+			"operationId": operationID,
+			"summary":     summary,
+			"tags":        []string{openAPITag(route.Path)},
+			"responses":   openAPIResponses(route.Method, method, doc, schemas),
+		}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+		if doc.Request != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": schemas.schemaFor(reflect.TypeOf(doc.Request))},
+				},
+			}
+		}
+		if public[route.Path] {
+			operation["security"] = []interface{}{}
+		}
+
+		item, _ := paths[path].(map[string]interface{})
+		if item == nil {
+			item = map[string]interface{}{}
+			paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "SCMS API",
+			"version":     "1.0",
+			"description": "Sales and customer management API. Authenticate with the session cookie set by /api/auth/login, a bearer token, an API key or a device token.",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas.schemas,
+			"securitySchemes": map[string]interface{}{
+				"sessionCookie": map[string]interface{}{"type": "apiKey", "in": "cookie", "name": services.SessionCookieName},
+				"bearerAuth":    map[string]interface{}{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+				"apiKey":        map[string]interface{}{"type": "apiKey", "in": "header", "name": appmw.APIKeyHeader},
+				"deviceToken":   map[string]interface{}{"type": "apiKey", "in": "header", "name": appmw.DeviceTokenHeader},
+			},
+		},
+		"security": []interface{}{
+			map[string]interface{}{"sessionCookie": []string{}},
+			map[string]interface{}{"bearerAuth": []string{}},
+			map[string]interface{}{"apiKey": []string{}},
+			map[string]interface{}{"deviceToken": []string{}},
+		},
+	}
+}
+
+// openAPIMethods are the HTTP methods documented in the spec
+var openAPIMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// openAPIResponses describes the success response of an operation and the error body every
+// operation may return
+func openAPIResponses(httpMethod, method string, doc openAPIOperation, schemas *schemaBuilder) map[string]interface{} {
+	status := doc.Status
+	if status == 0 {
+		switch {
+		case httpMethod == http.MethodPost && (strings.HasPrefix(method, "Create") || strings.HasPrefix(method, "Register")):
+			status = http.StatusCreated
+		case httpMethod == http.MethodDelete && doc.Response == nil:
+			status = http.StatusNoContent
+		default:
+			status = http.StatusOK
+		}
+	}
+
+	success := map[string]interface{}{"description": http.StatusText(status)}
+	switch {
+	case strings.HasSuffix(method, "CSV"):
+		success["content"] = map[string]interface{}{
+			"text/csv": map[string]interface{}{"schema": map[string]interface{}{"type": "string"}},
+		}
+	case strings.Contains(method, "PDF"):
+		success["content"] = map[string]interface{}{
+			"application/pdf": map[string]interface{}{"schema": map[string]interface{}{"type": "string", "format": "binary"}},
+		}
+	case doc.Response != nil:
+		schema := schemas.schemaFor(reflect.TypeOf(doc.Response))
+		if doc.Paged {
+			schema = map[string]interface{}{
+				"oneOf": []interface{}{
+					schema,
+					map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"data":       schema,
+							"pagination": schemas.schemaFor(reflect.TypeOf(Pagination{})),
+						},
+					},
+				},
+			}
+		}
+		success["content"] = map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schema},
+		}
+	}
+
+	return map[string]interface{}{
+		strconv.Itoa(status): success,
+		"default": map[string]interface{}{
+			"description": "Error",
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": map[string]interface{}{"$ref": "#/components/schemas/Error"}},
+			},
+		},
+	}
+}
+
+// listParameters describes the filter and sort parameters a list accepts: ?column= for any
+// of several comma-separated values, ?column_min= and ?column_max= for ranges, and ?sort=
+func listParameters(columns repository.ListColumns) []interface{} {
+	names := make([]string, 0, len(columns.Filterable))
+	for name := range columns.Filterable {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parameters := []interface{}{}
+	for _, name := range names {
+		parameters = append(parameters, map[string]interface{}{
+			"name":        name,
+			"in":          "query",
+			"description": "Comma-separated values to match",
+			"schema":      map[string]interface{}{"type": "string"},
+		})
+		if columnType := columns.Filterable[name]; columnType == repository.ColumnText || columnType == repository.ColumnBool {
+			continue
+		}
+		for _, bound := range []string{"_min", "_max"} {
+			parameters = append(parameters, map[string]interface{}{
+				"name":   name + bound,
+				"in":     "query",
+				"schema": map[string]interface{}{"type": "string"},
+			})
+		}
+	}
+	return append(parameters, map[string]interface{}{
+		"name":        "sort",
+		"in":          "query",
+		"description": "Comma-separated columns, each optionally suffixed with :asc or :desc. Sortable: " + strings.Join(columns.Sortable, ", "),
+		"schema":      map[string]interface{}{"type": "string"},
+	})
+}
+
+// openAPIPath converts an Echo path such as /api/customers/:id into /api/customers/{id} and
+// describes its path parameters. IDs are integers; other parameters are strings.
+func openAPIPath(path string) (string, []interface{}) {
+	parameters := []interface{}{}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		name := ""
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			name = segment[1:]
+		case segment == "*":
+			name = "path"
+		default:
+			continue
+		}
+		segments[i] = "{" + name + "}"
+
+		schemaType := "string"
+		if name == "id" || strings.HasSuffix(name, "_id") {
+			schemaType = "integer"
+		}
+		parameters = append(parameters, map[string]interface{}{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": schemaType},
+		})
+	}
+	return strings.Join(segments, "/"), parameters
+}
+
+// openAPITag groups an operation by the resource it belongs to, e.g. /api/orders/:id → orders
+// and /api/admin/api-keys → api-keys
+func openAPITag(path string) string {
+	segments := strings.Split(strings.TrimPrefix(path, "/api/"), "/")
+	if segments[0] == "admin" && len(segments) > 1 {
+		return segments[1]
+	}
+	return segments[0]
+}
+
+// handlerName extracts the receiver and method of a route's handler, e.g.
+// ".../handlers.(*OrderHandler).GetAllOrders-fm" gives "OrderHandler.GetAllOrders" and
+// "GetAllOrders". Both are empty for handlers that aren't methods.
+func handlerName(name string) (string, string) {
+	name = strings.TrimSuffix(name, "-fm")
+	open := strings.Index(name, "(*")
+	if open < 0 {
+		return "", ""
+	}
+	closing := strings.Index(name[open:], ").")
+	if closing < 0 {
+		return "", ""
+	}
+	receiver := name[open+2 : open+closing]
+	method := name[open+closing+2:]
+	return receiver + "." + method, method
+}
+
+// humanize turns a handler name such as GetCustomerByID into "Get customer by ID"
+func humanize(name string) string {
+	words := []string{}
+	runes := []rune(name)
+	start := 0
+	for i := 1; i <= len(runes); i++ {
+		boundary := i == len(runes) ||
+			(unicode.IsUpper(runes[i]) && (unicode.IsLower(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]))))
+		if !boundary {
+			continue
+		}
+		word := string(runes[start:i])
+		if len(words) > 0 && strings.ToUpper(word) != word {
+			word = strings.ToLower(word)
+		}
+		words = append(words, word)
+		start = i
+	}
+	return strings.Join(words, " ")
+}
+
+// schemaBuilder derives JSON schemas from Go types by their JSON tags, collecting named
+// structs as reusable component schemas
+type schemaBuilder struct {
+	schemas map[string]interface{}
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaFor returns the schema of a type, referencing named structs by component
+func (b *schemaBuilder) schemaFor(t reflect.Type) map[string]interface{} {
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == rawMessageType:
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return b.schemaFor(t.Elem())
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": b.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.schemaFor(t.Elem())}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.objectSchema(t)
+		}
+		if _, ok := b.schemas[t.Name()]; !ok {
+			// Register before descending so self-referencing types terminate
+			b.schemas[t.Name()] = map[string]interface{}{}
+			b.schemas[t.Name()] = b.objectSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	}
+	return map[string]interface{}{}
+}
+
+// objectSchema lists a struct's JSON properties, flattening embedded structs as encoding/json does
+func (b *schemaBuilder) objectSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	b.collectProperties(t, properties)
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+// collectProperties adds the JSON properties of a struct's exported fields
+func (b *schemaBuilder) collectProperties(t reflect.Type, properties map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				b.collectProperties(embedded, properties)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = b.schemaFor(field.Type)
+	}
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// DocumentScanHandler handles supplier invoices and customer purchase orders uploaded for
+// OCR, and the review of the drafts read from them. Reviewed drafts are confirmed as supplier
+// invoices at /api/document-scans/:id/supplier-invoice and as orders at
+// /api/document-scans/:id/order.
+type DocumentScanHandler struct {
+	scanService *services.DocumentScanService
+	scanRepo    *repository.DocumentScanRepository
+	auditRepo   *repository.AuditRepository
+}
+
+// NewDocumentScanHandler creates a new document scan handler
+func NewDocumentScanHandler(scanService *services.DocumentScanService, scanRepo *repository.DocumentScanRepository, auditRepo *repository.AuditRepository) *DocumentScanHandler {
+	return &DocumentScanHandler{
+		scanService: scanService,
+		scanRepo:    scanRepo,
+		auditRepo:   auditRepo,
+	}
+}
+
+// DocumentScanDraft is a reviewed draft of a document scan. It replaces the draft read from
+// the document, lines included.
+type DocumentScanDraft struct {
+	SupplierID      *int              `json:"supplier_id"`
+	PurchaseOrderID *int              `json:"purchase_order_id"`
+	CustomerID      *int              `json:"customer_id"`
+	DocumentNumber  *string           `json:"document_number"`
+	DocumentDate    string            `json:"document_date" validate:"omitempty,datetime=2006-01-02"`
+	TotalAmount     *float64          `json:"total_amount" validate:"omitnil,gte=0"`
+	Lines           []models.ScanLine `json:"lines" validate:"dive"`
+}
+
+// UploadDocumentScan uploads a supplier invoice or customer purchase order to be read. The
+// multipart form has the document's kind, supplier_invoice or customer_po, and the file: a
+// PNG, JPEG, WebP or GIF image or a PDF. The scan is returned as Processing; its draft is
+// ready for review once its status is Review.
+func (h *DocumentScanHandler) UploadDocumentScan(c echo.Context) error {
+	kind := c.FormValue("kind")
+	if !containsString(models.ScanKinds, kind) {
+		return models.NewAPIError(http.StatusBadRequest, "kind must be one of: "+strings.Join(models.ScanKinds, ", "))
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Choose the file to upload")
+	}
+
+	var uploadedBy *int
+	if user := appmw.UserFromContext(c); user != nil {
+		uploadedBy = &user.UserID
+	}
+	scan, err := h.scanService.Upload(c.Request().Context(), kind, file, uploadedBy)
+	if err != nil {
+		var tooLarge *services.AttachmentTooLargeError
+		switch {
+		case errors.Is(err, services.ErrOCRDisabled):
+			return models.NewAPIError(http.StatusServiceUnavailable, "Documents cannot be scanned: OCR is not configured")
+		case errors.As(err, &tooLarge):
+			return models.NewAPIError(http.StatusRequestEntityTooLarge, "The file "+err.Error())
+		case err == services.ErrAttachmentType || err == services.ErrAttachmentEmpty:
+			return models.NewAPIError(http.StatusBadRequest, "The file must be a PNG, JPEG, WebP or GIF image or a PDF")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to upload document")
+	}
+
+	recordAudit(c, h.auditRepo, models.AuditCreate, models.AuditEntityDocumentScan, scan.ScanID, nil, scan)
+
+	return c.JSON(http.StatusAccepted, scan)
+}
+
+// GetDocumentScans returns document scans, newest first. ?kind= limits them to supplier
+// invoices or customer POs and ?status= to Processing, Review, Confirmed, Failed or Discarded.
+func (h *DocumentScanHandler) GetDocumentScans(c echo.Context) error {
+	kind := c.QueryParam("kind")
+	if kind != "" && !containsString(models.ScanKinds, kind) {
+		return models.NewAPIError(http.StatusBadRequest, "kind must be one of: "+strings.Join(models.ScanKinds, ", "))
+	}
+	status := c.QueryParam("status")
+	if status != "" && !containsString(models.ScanStatuses, status) {
+		return models.NewAPIError(http.StatusBadRequest, "status must be one of: "+strings.Join(models.ScanStatuses, ", "))
+	}
+
+	page, paged, message := parsePage(c)
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	scans, total, err := h.scanRepo.GetAll(c.Request().Context(), kind, status, page)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve document scans")
+	}
+
+	return jsonPage(c, http.StatusOK, scans, page, paged, total)
+}
+
+// GetDocumentScan returns a document scan with the text read from it. The uploaded file is
+// downloaded from /api/attachments/:id.
+func (h *DocumentScanHandler) GetDocumentScan(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid document scan ID")
+	}
+
+	scan, err := h.scanRepo.GetByID(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Document scan not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve document scan")
+	}
+
+	return c.JSON(http.StatusOK, scan)
+}
+
+// UpdateDocumentScan saves corrections to the draft of a document scan in review
+func (h *DocumentScanHandler) UpdateDocumentScan(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid document scan ID")
+	}
+
+	var req DocumentScanDraft
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	before, err := h.scanRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Document scan not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve document scan")
+	}
+
+	scan := before
+	scan.SupplierID = req.SupplierID
+	scan.PurchaseOrderID = req.PurchaseOrderID
+	scan.CustomerID = req.CustomerID
+	scan.DocumentNumber = req.DocumentNumber
+	scan.DocumentDate = nil
+	if req.DocumentDate != "" {
+		date, _ := time.Parse(deliveryDateLayout, req.DocumentDate)
+		scan.DocumentDate = &date
+	}
+	scan.TotalAmount = req.TotalAmount
+	scan.Lines = req.Lines
+
+	if err := h.scanRepo.UpdateDraft(ctx, &scan); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return models.NewAPIError(http.StatusNotFound, "Document scan not found")
+		case err == repository.ErrScanNotInReview:
+			return models.NewAPIError(http.StatusConflict, "Only document scans in review can be changed")
+		case err == repository.ErrReferencedRecord:
+			return models.NewAPIError(http.StatusBadRequest, "The supplier, purchase order or customer does not exist")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update document scan")
+	}
+
+	recordAudit(c, h.auditRepo, models.AuditUpdate, models.AuditEntityDocumentScan, id, before, scan)
+
+	return c.JSON(http.StatusOK, scan)
+}
+
+// DiscardDocumentScan sets aside a document scan that is not wanted, such as a duplicate
+// upload. Confirmed scans cannot be discarded.
+func (h *DocumentScanHandler) DiscardDocumentScan(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid document scan ID")
+	}
+
+	before, err := h.scanRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Document scan not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve document scan")
+	}
+
+	scan, err := h.scanRepo.Discard(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return models.NewAPIError(http.StatusNotFound, "Document scan not found")
+		case errors.Is(err, repository.ErrConflict):
+			return models.NewAPIError(http.StatusConflict, "Confirmed document scans cannot be discarded")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to discard document scan")
+	}
+
+	recordAudit(c, h.auditRepo, "discard", models.AuditEntityDocumentScan, id, before, scan)
+
+	return c.JSON(http.StatusOK, scan)
+}
+
+// scanInReview retrieves a document scan of a kind that is waiting to be confirmed, or
+// returns the error response for one that is not
+func scanInReview(c echo.Context, scanRepo *repository.DocumentScanRepository, kind string) (models.DocumentScan, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.DocumentScan{}, models.NewAPIError(http.StatusBadRequest, "Invalid document scan ID")
+	}
+
+	scan, err := scanRepo.GetByID(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return scan, models.NewAPIError(http.StatusNotFound, "Document scan not found")
+		}
+		return scan, models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve document scan")
+	}
+	if scan.Kind != kind {
+		return scan, models.NewAPIError(http.StatusBadRequest, "The document scan is not a "+strings.ReplaceAll(kind, "_", " "))
+	}
+	if scan.Status != models.ScanReview {
+		return scan, models.NewAPIError(http.StatusConflict, "Only document scans in review can be confirmed").WithDetails(map[string]interface{}{
+			"status":      scan.Status,
+			"document_id": scan.DocumentID,
+		})
+	}
+	return scan, nil
+}
+
+// confirmScan marks a document scan confirmed as the document created from it. The document
+// has been created either way, so a failure is only logged.
+func confirmScan(c echo.Context, scanRepo *repository.DocumentScanRepository, auditRepo *repository.AuditRepository, before models.DocumentScan, documentID int) {
+	var confirmedBy *int
+	if user := appmw.UserFromContext(c); user != nil {
+		confirmedBy = &user.UserID
+	}
+	scan, err := scanRepo.Confirm(c.Request().Context(), before.ScanID, documentID, confirmedBy)
+	if err != nil {
+		log.Printf("Failed to confirm document scan %d as document %d: %v", before.ScanID, documentID, err)
+		return
+	}
+	recordAudit(c, auditRepo, "confirm", models.AuditEntityDocumentScan, scan.ScanID, before, scan)
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// defaultDownloadLinkHours is how long download links work unless asked otherwise
+const defaultDownloadLinkHours = 72
+
+// DownloadLinkHandler handles links for sharing documents outside the app, such as in an
+// email to a customer: who follows one downloads the document without logging in
+type DownloadLinkHandler struct {
+	downloadLinkService *services.DownloadLinkService
+	downloadLinkRepo    *repository.DownloadLinkRepository
+	auditRepo           *repository.AuditRepository
+	// documents serve each document type, reading the document's ID from the id param
+	documents map[string]echo.HandlerFunc
+}
+
+// NewDownloadLinkHandler creates a new download link handler. Documents are served by the
+// same handlers as their authenticated routes.
+func NewDownloadLinkHandler(
+	downloadLinkService *services.DownloadLinkService,
+	downloadLinkRepo *repository.DownloadLinkRepository,
+	auditRepo *repository.AuditRepository,
+	quotationHandler *QuotationHandler,
+	orderHandler *OrderHandler,
+	invoiceHandler *InvoiceHandler,
+	podHandler *ProofOfDeliveryHandler,
+) *DownloadLinkHandler {
+	return &DownloadLinkHandler{
+		downloadLinkService: downloadLinkService,
+		downloadLinkRepo:    downloadLinkRepo,
+		auditRepo:           auditRepo,
+		documents: map[string]echo.HandlerFunc{
+			models.DownloadQuotation:  quotationHandler.GenerateQuotationPDF,
+			models.DownloadOrder:      orderHandler.GetOrderPDF,
+			models.DownloadInvoice:    invoiceHandler.GetInvoicePDF,
+			models.DownloadAttachment: podHandler.GetAttachment,
+		},
+	}
+}
+
+// DownloadLinkRequest asks for a link to a document. ExpiresInHours defaults to 72 and can be
+// at most 720; a SingleUse link stops working after its first download.
+type DownloadLinkRequest struct {
+	DocumentType   string `json:"document_type" validate:"required,oneof=quotation order invoice attachment"`
+	DocumentID     int    `json:"document_id" validate:"required,gt=0"`
+	ExpiresInHours *int   `json:"expires_in_hours" validate:"omitempty,min=1,max=720"`
+	SingleUse      bool   `json:"single_use"`
+}
+
+// DownloadLinkResponse is a new download link with the URL to share. The URL holds the link's
+// token and cannot be shown again.
+type DownloadLinkResponse struct {
+	DownloadLink *models.DownloadLink `json:"download_link"`
+	URL          string               `json:"url"`
+}
+
+// CreateDownloadLink issues a link to a quotation, order or invoice PDF or an attachment
+func (h *DownloadLinkHandler) CreateDownloadLink(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req DownloadLinkRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	hours := defaultDownloadLinkHours
+	if req.ExpiresInHours != nil {
+		hours = *req.ExpiresInHours
+	}
+
+	var createdBy *int
+	if user := appmw.UserFromContext(c); user != nil {
+		createdBy = &user.UserID
+	}
+
+	link, token, err := h.downloadLinkService.Create(ctx, req.DocumentType, req.DocumentID,
+		time.Now().Add(time.Duration(hours)*time.Hour), req.SingleUse, createdBy)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Document not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to create download link")
+	}
+
+	recordAudit(c, h.auditRepo, models.AuditCreate, models.AuditEntityDownloadLink, link.DownloadLinkID, nil, link)
+
+	return c.JSON(http.StatusCreated, DownloadLinkResponse{
+		DownloadLink: link,
+		URL:          c.Scheme() + "://" + c.Request().Host + "/api/public/downloads?token=" + url.QueryEscape(token),
+	})
+}
+
+// GetDownloadLinks returns the links made for the document given by ?document_type= and
+// ?document_id=, newest first, with how often each was downloaded from
+func (h *DownloadLinkHandler) GetDownloadLinks(c echo.Context) error {
+	documentType := c.QueryParam("document_type")
+	if !containsString(models.DownloadDocumentTypes, documentType) {
+		return models.NewAPIError(http.StatusBadRequest, "document_type must be one of: "+strings.Join(models.DownloadDocumentTypes, ", "))
+	}
+	documentID, err := strconv.Atoi(c.QueryParam("document_id"))
+	if err != nil || documentID <= 0 {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid document_id parameter. Must be a positive integer.")
+	}
+
+	links, err := h.downloadLinkRepo.GetByDocument(c.Request().Context(), documentType, documentID)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve download links")
+	}
+
+	return jsonList(c, http.StatusOK, links)
+}
+
+// RevokeDownloadLink stops a download link from working before it expires
+func (h *DownloadLinkHandler) RevokeDownloadLink(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid download link ID")
+	}
+
+	before, err := h.downloadLinkRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Download link not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve download link")
+	}
+
+	link, err := h.downloadLinkRepo.Revoke(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Download link not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to revoke download link")
+	}
+
+	recordAudit(c, h.auditRepo, "revoke", models.AuditEntityDownloadLink, id, before, link)
+
+	return c.JSON(http.StatusOK, link)
+}
+
+// Download serves the document a download link was made for, without a login. The link's
+// token is read from ?token=; links that expired, were revoked or were single-use and already
+// downloaded from answer 410 Gone.
+func (h *DownloadLinkHandler) Download(c echo.Context) error {
+	link, err := h.downloadLinkService.Use(c.Request().Context(), c.QueryParam("token"))
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return models.NewAPIError(http.StatusNotFound, "Download link not found")
+		case errors.Is(err, repository.ErrDownloadLinkUnavailable):
+			return models.NewAPIError(http.StatusGone, "This download link has expired or has already been used")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve download link")
+	}
+
+	serve, ok := h.documents[link.DocumentType]
+	if !ok {
+		return models.NewAPIError(http.StatusNotFound, "Download link not found")
+	}
+
+	c.Response().Header().Set("Cache-Control", "no-store")
+	c.SetParamNames("id")
+	c.SetParamValues(strconv.Itoa(link.DocumentID))
+	return serve(c)
+}
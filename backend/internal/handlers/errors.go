@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// HTTPErrorHandler is the Echo error handler. It writes every error as the standard
+// envelope, {"error": message, "code": code, "details": ...}:
+//
+//   - *models.APIError is written as is
+//   - repository not found and conflict errors become 404 and 409
+//   - request validation errors become 400 with the failing fields as details
+//   - Echo's own errors (unknown route, body too large, ...) keep their status
+//   - anything else is logged and reported as a 500 without its message
+//
+// Field validation errors and the handler's own messages are written in the language the
+// client asks for with Accept-Language; see requestLanguage.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	lang := requestLanguage(c)
+	c.Response().Header().Set("Content-Language", lang)
+	c.Response().Header().Add(echo.HeaderVary, "Accept-Language")
+
+	apiErr := toAPIError(err, lang)
+	if apiErr.Status >= http.StatusInternalServerError && !errors.As(err, new(*models.APIError)) {
+		log.Printf("%s %s failed: %v", c.Request().Method, c.Request().URL.Path, err)
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(apiErr.Status)
+	} else {
+		err = c.JSON(apiErr.Status, apiErr)
+	}
+	if err != nil {
+		log.Printf("Failed to write error response: %v", err)
+	}
+}
+
+// ReportingErrorHandler returns HTTPErrorHandler sending panics and 5xx errors to reporter,
+// with the request and user they happened to. Faults injected on purpose are not reported.
+func ReportingErrorHandler(reporter *services.ErrorReporter) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if reporter.Enabled() && !errors.As(err, new(*appmw.InjectedFault)) {
+			if status := toAPIError(err, langEnglish).Status; status >= http.StatusInternalServerError {
+				reporter.Report(errorEvent(c, err, status))
+			}
+		}
+		HTTPErrorHandler(err, c)
+	}
+}
+
+// RecoverPanic is the Recover middleware's LogErrorFunc: it logs a recovered panic with its
+// stack, as the middleware does by default, and keeps the stack for the error report
+func RecoverPanic(c echo.Context, err error, stack []byte) error {
+	log.Printf("[PANIC RECOVER] %s %s: %v %s", c.Request().Method, c.Request().URL.Path, err, stack)
+	return services.NewPanicError(err, stack)
+}
+
+// reportedHeaders are the request headers sent with error reports; credentials, cookies and
+// tokens never are
+var reportedHeaders = []string{
+	echo.HeaderAccept, "Accept-Language", echo.HeaderContentType, echo.HeaderContentLength,
+	echo.HeaderOrigin, "Referer", "User-Agent", echo.HeaderXRequestID, echo.HeaderXForwardedFor,
+}
+
+// errorEvent describes an error for the error reporter
+func errorEvent(c echo.Context, err error, status int) services.ErrorEvent {
+	r := c.Request()
+	headers := map[string]string{}
+	for _, name := range reportedHeaders {
+		if value := r.Header.Get(name); value != "" {
+			headers[name] = value
+		}
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	event := services.ErrorEvent{
+		Err:    err,
+		Status: status,
+		Request: &services.ErrorRequest{
+			Method:    r.Method,
+			URL:       scheme + "://" + r.Host + r.URL.Path,
+			Query:     r.URL.RawQuery,
+			Route:     c.Path(),
+			Headers:   headers,
+			IPAddress: c.RealIP(),
+		},
+	}
+	if user := appmw.UserFromContext(c); user != nil {
+		event.UserID = user.UserID
+		event.UserEmail = user.Email
+	}
+	return event
+}
+
+// toAPIError maps an error returned by a handler or middleware to its response, with the
+// messages it writes itself in the given language
+func toAPIError(err error, lang string) *models.APIError {
+	var apiErr *models.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	var fields ValidationErrors
+	if errors.As(err, &fields) && len(fields) > 0 {
+		fields = fields.Localize(lang)
+		return models.NewAPIError(http.StatusBadRequest, capitalize(fields[0].Message)).
+			WithCode(models.CodeValidationFailed).
+			WithDetails(map[string]interface{}{"fields": fields})
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		message := http.StatusText(httpErr.Code)
+		if text, ok := httpErr.Message.(string); ok && text != "" {
+			message = text
+		}
+		return models.NewAPIError(httpErr.Code, capitalize(message))
+	}
+
+	switch {
+	case err == repository.ErrDuplicateKey:
+		return models.NewAPIError(http.StatusConflict, translate(lang, msgDuplicate)).
+			WithCode(models.CodeDuplicate)
+	case err == repository.ErrReferencedRecord:
+		return models.NewAPIError(http.StatusConflict, translate(lang, msgReferenced)).
+			WithCode(models.CodeReferenced)
+	case err == repository.ErrStaleVersion:
+		return models.NewAPIError(http.StatusConflict, translate(lang, msgStaleVersion)).
+			WithCode(models.CodeStaleVersion)
+	case errors.Is(err, repository.ErrNotFound):
+		return models.NewAPIError(http.StatusNotFound, capitalize(err.Error()))
+	case errors.Is(err, repository.ErrConflict):
+		return models.NewAPIError(http.StatusConflict, capitalize(err.Error()))
+	}
+
+	return models.NewAPIError(http.StatusInternalServerError, translate(lang, msgInternal))
+}
+
+// versionRequiredError refuses an update that does not name the version of the record it was
+// made from, since it could overwrite someone else's change unseen
+func versionRequiredError(entity string) *models.APIError {
+	return models.NewAPIError(http.StatusPreconditionRequired, entity+" version is required; send the version you loaded")
+}
+
+// staleVersionError reports an update made from an outdated version of a record, returning
+// the current record so the client can merge and retry
+func staleVersionError(entity string, current interface{}) *models.APIError {
+	return models.NewAPIError(http.StatusConflict, entity+" was changed by someone else; reload it and try again").
+		WithCode(models.CodeStaleVersion).
+		WithDetails(map[string]interface{}{"current": current})
+}
+
+// capitalize upper-cases the first letter of a message
+func capitalize(message string) string {
+	if message == "" {
+		return message
+	}
+	return strings.ToUpper(message[:1]) + message[1:]
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/labstack/echo/v4"
+)
+
+// jsonList writes a list response, trimming each element to the fields requested via
+// ?fields=id,name,status so lightweight clients only download the columns they need.
+// Unknown field names are rejected with 400 so typos don't silently return empty objects.
+func jsonList(c echo.Context, code int, items interface{}) error {
+	list, status, message := selectFields(c, items)
+	if message != "" {
+		return models.NewAPIError(status, message)
+	}
+	return c.JSON(code, list)
+}
+
+// selectFields trims each element of items to the fields requested via ?fields=, returning
+// items unchanged when no fields are requested, or a status and message when they are invalid
+func selectFields(c echo.Context, items interface{}) (interface{}, int, string) {
+	fieldsParam := strings.TrimSpace(c.QueryParam("fields"))
+	if fieldsParam == "" {
+		return items, 0, ""
+	}
+
+	requested := []string{}
+	for _, field := range strings.Split(fieldsParam, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			requested = append(requested, field)
+		}
+	}
+
+	encoded, err := json.Marshal(items)
+	if err != nil {
+		return nil, http.StatusInternalServerError, "Failed to encode response"
+	}
+
+	rows := []map[string]json.RawMessage{}
+	if err := json.Unmarshal(encoded, &rows); err != nil {
+		return nil, http.StatusInternalServerError, "Failed to encode response"
+	}
+
+	// Without rows we cannot validate names, so just return the empty list
+	if len(rows) == 0 {
+		return rows, 0, ""
+	}
+
+	known := fieldNames(items)
+	for _, field := range requested {
+		if !known[field] {
+			return nil, http.StatusBadRequest, "Unknown field: " + field
+		}
+	}
+
+	sparse := make([]map[string]json.RawMessage, len(rows))
+	for i, row := range rows {
+		trimmed := make(map[string]json.RawMessage, len(requested))
+		for _, field := range requested {
+			if value, ok := row[field]; ok {
+				trimmed[field] = value
+			}
+		}
+		sparse[i] = trimmed
+	}
+
+	return sparse, 0, ""
+}
+
+// fieldNames returns the JSON field names of the element type of a slice, including
+// omitempty fields that may be missing from individual rows
+func fieldNames(items interface{}) map[string]bool {
+	names := map[string]bool{}
+	collectJSONFields(sliceElemType(items), names)
+	return names
+}
+
+// sliceElemType returns the struct type held by a slice value
+func sliceElemType(items interface{}) reflect.Type {
+	t := reflect.TypeOf(items)
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice) {
+		t = t.Elem()
+	}
+	return t
+}
+
+// collectJSONFields adds the JSON names of a struct's fields, descending into embedded structs
+func collectJSONFields(t reflect.Type, names map[string]bool) {
+	if t == nil || t.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			collectJSONFields(field.Type, names)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names[name] = true
+	}
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// FreightHandler handles HTTP requests for delivery zones, freight rates and freight estimates
+type FreightHandler struct {
+	freightRepo    *repository.FreightRepository
+	customerRepo   *repository.CustomerRepository
+	freightService *services.FreightService
+}
+
+// NewFreightHandler creates a new freight handler
+func NewFreightHandler(
+	freightRepo *repository.FreightRepository,
+	customerRepo *repository.CustomerRepository,
+	freightService *services.FreightService,
+) *FreightHandler {
+	return &FreightHandler{
+		freightRepo:    freightRepo,
+		customerRepo:   customerRepo,
+		freightService: freightService,
+	}
+}
+
+// GetDeliveryZones returns all delivery zones
+func (h *FreightHandler) GetDeliveryZones(c echo.Context) error {
+	zones, err := h.freightRepo.GetZones(c.Request().Context())
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve delivery zones")
+	}
+
+	return jsonList(c, http.StatusOK, zones)
+}
+
+// SaveDeliveryZone creates a delivery zone or replaces its provinces
+func (h *FreightHandler) SaveDeliveryZone(c echo.Context) error {
+	var zone models.DeliveryZone
+	if err := c.Bind(&zone); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	zone.Zone = strings.TrimSpace(c.Param("zone"))
+	if zone.Zone == "" {
+		return models.NewAPIError(http.StatusBadRequest, "Zone is required")
+	}
+	provinces := models.StringList{}
+	for _, province := range zone.Provinces {
+		if province = strings.TrimSpace(province); province != "" {
+			provinces = append(provinces, province)
+		}
+	}
+	zone.Provinces = provinces
+	if len(zone.Provinces) == 0 && !zone.IsDefault {
+		return models.NewAPIError(http.StatusBadRequest, "A zone must list at least one province or be the default zone")
+	}
+
+	if err := h.freightRepo.SaveZone(c.Request().Context(), &zone); err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to save delivery zone")
+	}
+
+	return c.JSON(http.StatusOK, zone)
+}
+
+// DeleteDeliveryZone deletes a delivery zone and its freight rates
+func (h *FreightHandler) DeleteDeliveryZone(c echo.Context) error {
+	if err := h.freightRepo.DeleteZone(c.Request().Context(), c.Param("zone")); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Delivery zone not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to delete delivery zone")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetFreightRates returns the freight rates, optionally for a single ?zone=
+func (h *FreightHandler) GetFreightRates(c echo.Context) error {
+	rates, err := h.freightRepo.GetRates(c.Request().Context(), c.QueryParam("zone"))
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve freight rates")
+	}
+
+	return jsonList(c, http.StatusOK, rates)
+}
+
+// CreateFreightRate adds a weight bracket to a zone
+func (h *FreightHandler) CreateFreightRate(c echo.Context) error {
+	var rate models.FreightRate
+	if err := c.Bind(&rate); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(&rate); err != nil {
+		return validationError(err)
+	}
+	if rate.MaxWeightKg != nil && *rate.MaxWeightKg <= rate.MinWeightKg {
+		return models.NewAPIError(http.StatusBadRequest, "Maximum weight must be greater than the minimum weight")
+	}
+
+	if err := h.freightRepo.CreateRate(c.Request().Context(), &rate); err != nil {
+		return freightRateError(c, err, "Failed to create freight rate")
+	}
+
+	return c.JSON(http.StatusCreated, rate)
+}
+
+// UpdateFreightRate updates a weight bracket
+func (h *FreightHandler) UpdateFreightRate(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid freight rate ID")
+	}
+
+	var rate models.FreightRate
+	if err := c.Bind(&rate); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+	rate.FreightRateID = id
+
+	if err := c.Validate(&rate); err != nil {
+		return validationError(err)
+	}
+	if rate.MaxWeightKg != nil && *rate.MaxWeightKg <= rate.MinWeightKg {
+		return models.NewAPIError(http.StatusBadRequest, "Maximum weight must be greater than the minimum weight")
+	}
+
+	if err := h.freightRepo.UpdateRate(c.Request().Context(), &rate); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Freight rate not found")
+		}
+		return freightRateError(c, err, "Failed to update freight rate")
+	}
+
+	return c.JSON(http.StatusOK, rate)
+}
+
+// DeleteFreightRate deletes a weight bracket
+func (h *FreightHandler) DeleteFreightRate(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid freight rate ID")
+	}
+
+	if err := h.freightRepo.DeleteRate(c.Request().Context(), id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Freight rate not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to delete freight rate")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// FreightEstimateRequest describes a draft shipment. The destination is the customer's
+// province unless a province is given.
+type FreightEstimateRequest struct {
+	CustomerID int                    `json:"customer_id"`
+	Province   string                 `json:"province"`
+	Items      []services.FreightItem `json:"items" validate:"min=1,dive"`
+}
+
+// EstimateFreight estimates the freight for a draft order
+func (h *FreightHandler) EstimateFreight(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req FreightEstimateRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	province := strings.TrimSpace(req.Province)
+	if province == "" && req.CustomerID != 0 {
+		customer, err := h.customerRepo.GetByID(ctx, req.CustomerID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return models.NewAPIError(http.StatusBadRequest, "Customer not found")
+			}
+			return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve customer")
+		}
+		if customer.Province != nil {
+			province = *customer.Province
+		}
+	}
+
+	estimate, err := h.freightService.Estimate(ctx, province, req.Items)
+	if err != nil {
+		if err == services.ErrNoFreightRate {
+			return models.NewAPIError(http.StatusUnprocessableEntity, err.Error())
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to estimate freight")
+	}
+
+	return c.JSON(http.StatusOK, estimate)
+}
+
+// freightRateError reports a failed freight rate save
+func freightRateError(c echo.Context, err error, message string) error {
+	switch err {
+	case repository.ErrDuplicateKey:
+		return models.NewAPIError(http.StatusConflict, "The zone already has a bracket starting at this weight")
+	case repository.ErrReferencedRecord:
+		return models.NewAPIError(http.StatusBadRequest, "Delivery zone not found")
+	}
+	return models.NewAPIError(http.StatusInternalServerError, message)
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// frontendBuild is the built Vue app, for deployments that run the API binary alone. It is
+// empty unless the frontend's dist directory is copied into handlers/frontend before building:
+//
+//	cd frontend && npm run build && cp -r dist/. ../backend/internal/handlers/frontend/
+//
+//go:embed all:frontend
+var frontendBuild embed.FS
+
+// frontendAssetCache is the Cache-Control of the build's assets, whose file names carry a
+// hash of their content so they never change; index.html and other files are revalidated
+const frontendAssetCache = "public, max-age=31536000, immutable"
+
+// FrontendFS returns the built frontend to serve: the directory dir when it is set,
+// otherwise the build embedded in the binary. It returns nil when nothing was embedded.
+func FrontendFS(dir string) (fs.FS, error) {
+	if dir != "" {
+		app := os.DirFS(dir)
+		if _, err := fs.Stat(app, "index.html"); err != nil {
+			return nil, fmt.Errorf("frontend directory %s has no index.html: %w", dir, err)
+		}
+		return app, nil
+	}
+
+	app := echo.MustSubFS(frontendBuild, "frontend")
+	if _, err := fs.Stat(app, "index.html"); err != nil {
+		return nil, nil
+	}
+	return app, nil
+}
+
+// FrontendHandler serves the built frontend next to the API, so a small deployment needs no
+// separate web server
+type FrontendHandler struct {
+	app fs.FS
+}
+
+// NewFrontendHandler creates a new handler for the built frontend in app
+func NewFrontendHandler(app fs.FS) *FrontendHandler {
+	return &FrontendHandler{
+		app: app,
+	}
+}
+
+// ServeApp serves a file of the build, or index.html for any other path without a file
+// extension so the app's router can handle it. Unknown API routes stay not found.
+func (h *FrontendHandler) ServeApp(c echo.Context) error {
+	urlPath := c.Request().URL.Path
+	if urlPath == "/api" || strings.HasPrefix(urlPath, "/api/") {
+		return echo.ErrNotFound
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+urlPath), "/")
+	if name == "" {
+		name = "index.html"
+	}
+	info, err := fs.Stat(h.app, name)
+	if err != nil || info.IsDir() {
+		if path.Ext(name) != "" {
+			return echo.ErrNotFound
+		}
+		name = "index.html"
+	}
+
+	if strings.HasPrefix(name, "assets/") {
+		c.Response().Header().Set("Cache-Control", frontendAssetCache)
+	} else {
+		c.Response().Header().Set("Cache-Control", "no-cache")
+	}
+	return h.serveFile(c, name)
+}
+
+// serveFile writes a file of the build, answering conditional and range requests
+func (h *FrontendHandler) serveFile(c echo.Context, name string) error {
+	file, err := h.app.Open(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return echo.ErrNotFound
+		}
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	content, ok := file.(io.ReadSeeker)
+	if !ok {
+		return fmt.Errorf("frontend file %s cannot seek", name)
+	}
+	http.ServeContent(c.Response(), c.Request(), info.Name(), info.ModTime(), content)
+	return nil
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// ImpersonationHandler handles admin impersonation
+type ImpersonationHandler struct {
+	impersonationService *services.ImpersonationService
+}
+
+// NewImpersonationHandler creates a new impersonation handler
+func NewImpersonationHandler(impersonationService *services.ImpersonationService) *ImpersonationHandler {
+	return &ImpersonationHandler{
+		impersonationService: impersonationService,
+	}
+}
+
+// StartImpersonation issues a scoped session acting as another user.
+// The admin must re-enter their credentials.
+func (h *ImpersonationHandler) StartImpersonation(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req services.ImpersonationRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+
+	resp, err := h.impersonationService.Start(ctx, req, c.RealIP())
+	if err != nil {
+		var lockout *services.LockoutError
+		switch {
+		case errors.As(err, &lockout):
+			return lockoutResponse(c, lockout)
+		case err == services.ErrInvalidCredentials:
+			return models.NewAPIError(http.StatusUnauthorized, "Invalid credentials")
+		case err == services.ErrNotAdmin || err == services.ErrCannotImpersonate:
+			return models.NewAPIError(http.StatusForbidden, err.Error())
+		case err == services.ErrImpersonationReasonMissing:
+			return models.NewAPIError(http.StatusBadRequest, err.Error())
+		case errors.Is(err, repository.ErrNotFound):
+			return models.NewAPIError(http.StatusNotFound, "User not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to start impersonation")
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// EndImpersonation ends the impersonation session identified by the request's token
+func (h *ImpersonationHandler) EndImpersonation(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	token := c.Request().Header.Get(appmw.ImpersonationTokenHeader)
+	if token == "" {
+		return models.NewAPIError(http.StatusBadRequest, "Impersonation token required")
+	}
+
+	if err := h.impersonationService.End(ctx, token, c.RealIP()); err != nil {
+		if err == services.ErrInvalidImpersonationToken {
+			return models.NewAPIError(http.StatusNotFound, "Impersonation session not found or already ended")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to end impersonation")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Impersonation ended",
+	})
+}
+
+// GetCurrentImpersonation returns the impersonated user and who is behind the session
+func (h *ImpersonationHandler) GetCurrentImpersonation(c echo.Context) error {
+	impersonation := appmw.ImpersonationFromContext(c)
+	if impersonation == nil {
+		return models.NewAPIError(http.StatusNotFound, "Not impersonating")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"session": impersonation.Session,
+		"user":    impersonation.User,
+	})
+}
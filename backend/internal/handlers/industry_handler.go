@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/labstack/echo/v4"
+)
+
+// IndustryHandler handles HTTP requests for the managed industry list
+type IndustryHandler struct {
+	industryRepo *repository.IndustryRepository
+}
+
+// NewIndustryHandler creates a new industry handler with the provided repository
+func NewIndustryHandler(industryRepo *repository.IndustryRepository) *IndustryHandler {
+	return &IndustryHandler{
+		industryRepo: industryRepo,
+	}
+}
+
+// GetIndustries returns all industries, or those matching ?search= for pickers
+func (h *IndustryHandler) GetIndustries(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var industries []models.Industry
+	var err error
+	if search := strings.TrimSpace(c.QueryParam("search")); search != "" {
+		industries, err = h.industryRepo.Search(ctx, search)
+	} else {
+		industries, err = h.industryRepo.GetAll(ctx)
+	}
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve industries")
+	}
+
+	return jsonList(c, http.StatusOK, industries)
+}
+
+// CreateIndustry adds an industry to the list
+func (h *IndustryHandler) CreateIndustry(c echo.Context) error {
+	var industry models.Industry
+	if err := c.Bind(&industry); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(&industry); err != nil {
+		return validationError(err)
+	}
+	industry.Name = strings.TrimSpace(industry.Name)
+
+	if err := h.industryRepo.Create(c.Request().Context(), &industry); err != nil {
+		if err == repository.ErrDuplicateKey {
+			return models.NewAPIError(http.StatusConflict, "An industry with this name already exists")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to create industry")
+	}
+
+	return c.JSON(http.StatusCreated, industry)
+}
+
+// UpdateIndustry renames an industry; customers assigned to it are updated as well
+func (h *IndustryHandler) UpdateIndustry(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid industry ID")
+	}
+
+	var industry models.Industry
+	if err := c.Bind(&industry); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	industry.IndustryID = id
+	if err := c.Validate(&industry); err != nil {
+		return validationError(err)
+	}
+	industry.Name = strings.TrimSpace(industry.Name)
+
+	if err := h.industryRepo.Update(c.Request().Context(), &industry); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Industry not found")
+		}
+		if err == repository.ErrDuplicateKey {
+			return models.NewAPIError(http.StatusConflict, "An industry with this name already exists")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update industry")
+	}
+
+	return c.JSON(http.StatusOK, industry)
+}
+
+// DeleteIndustry deletes an industry that no customer is assigned to
+func (h *IndustryHandler) DeleteIndustry(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid industry ID")
+	}
+
+	if err := h.industryRepo.Delete(c.Request().Context(), id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Industry not found")
+		}
+		if err == repository.ErrReferencedRecord {
+			return models.NewAPIError(http.StatusConflict, "Industry is still assigned to customers")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to delete industry")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
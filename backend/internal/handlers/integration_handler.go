@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// IntegrationHandler handles HTTP requests for external document archive integrations
+type IntegrationHandler struct {
+	archiver *services.DocumentArchiver
+}
+
+// NewIntegrationHandler creates a new integration handler with the provided archiver
+func NewIntegrationHandler(archiver *services.DocumentArchiver) *IntegrationHandler {
+	return &IntegrationHandler{
+		archiver: archiver,
+	}
+}
+
+// GetIntegrations returns the configuration and connection state of each archive provider
+func (h *IntegrationHandler) GetIntegrations(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	statuses, err := h.archiver.Status(ctx)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve integrations")
+	}
+
+	return c.JSON(http.StatusOK, statuses)
+}
+
+// Authorize redirects an admin to the provider consent page. Only admins connect the archive,
+// since the account connected receives every archived document.
+func (h *IntegrationHandler) Authorize(c echo.Context) error {
+	provider := c.Param("provider")
+
+	stateBytes := make([]byte, 16)
+	if _, err := rand.Read(stateBytes); err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to start authorization")
+	}
+	state := hex.EncodeToString(stateBytes)
+
+	authURL, err := h.archiver.AuthCodeURL(provider, state)
+	if err != nil {
+		return models.NewAPIError(http.StatusNotFound, "Integration provider not configured")
+	}
+
+	// Remember the state so the callback can reject forged requests
+	c.SetCookie(&http.Cookie{
+		Name:     "oauth_state_" + provider,
+		Value:    state,
+		Path:     "/api/integrations",
+		HttpOnly: true,
+		Secure:   c.Request().TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+		Expires:  time.Now().Add(10 * time.Minute),
+	})
+
+	return c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback completes the OAuth flow and stores the provider credential
+func (h *IntegrationHandler) Callback(c echo.Context) error {
+	ctx := c.Request().Context()
+	provider := c.Param("provider")
+
+	if errParam := c.QueryParam("error"); errParam != "" {
+		return models.NewAPIError(http.StatusBadRequest, "Authorization was declined: "+errParam)
+	}
+
+	cookie, err := c.Cookie("oauth_state_" + provider)
+	if err != nil || cookie.Value == "" || cookie.Value != c.QueryParam("state") {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid authorization state")
+	}
+
+	code := c.QueryParam("code")
+	if code == "" {
+		return models.NewAPIError(http.StatusBadRequest, "Authorization code is required")
+	}
+
+	if err := h.archiver.Connect(ctx, provider, code); err != nil {
+		if err == services.ErrUnknownArchiveProvider {
+			return models.NewAPIError(http.StatusNotFound, "Integration provider not configured")
+		}
+		return models.NewAPIError(http.StatusBadGateway, "Failed to connect integration: "+err.Error())
+	}
+
+	// Clear the state cookie
+	c.SetCookie(&http.Cookie{
+		Name:   "oauth_state_" + provider,
+		Value:  "",
+		Path:   "/api/integrations",
+		MaxAge: -1,
+	})
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Integration connected successfully",
+	})
+}
+
+// Disconnect removes the stored credential for a provider
+func (h *IntegrationHandler) Disconnect(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	if err := h.archiver.Disconnect(ctx, c.Param("provider")); err != nil {
+		if err == repository.ErrIntegrationNotConnected {
+			return models.NewAPIError(http.StatusNotFound, "Integration not connected")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to disconnect integration")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
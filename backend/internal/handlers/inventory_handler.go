@@ -1,25 +1,42 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/Cezzyy/SCMS/backend/internal/models"
 	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
 	"github.com/labstack/echo/v4"
 )
 
 // InventoryHandler handles HTTP requests for inventory
 type InventoryHandler struct {
-	inventoryRepo *repository.InventoryRepository
-	productRepo   *repository.ProductRepository
+	inventoryRepo    *repository.InventoryRepository
+	productRepo      *repository.ProductRepository
+	chatNotifier     *services.ChatNotifier
+	auditRepo        *repository.AuditRepository
+	notificationRepo *repository.NotificationRepository
+	webhooks         *services.WebhookService
 }
 
 // NewInventoryHandler creates a new inventory handler with the provided repositories
-func NewInventoryHandler(inventoryRepo *repository.InventoryRepository, productRepo *repository.ProductRepository) *InventoryHandler {
+func NewInventoryHandler(
+	inventoryRepo *repository.InventoryRepository,
+	productRepo *repository.ProductRepository,
+	chatNotifier *services.ChatNotifier,
+	auditRepo *repository.AuditRepository,
+	notificationRepo *repository.NotificationRepository,
+	webhooks *services.WebhookService,
+) *InventoryHandler {
 	return &InventoryHandler{
-		inventoryRepo: inventoryRepo,
-		productRepo:   productRepo,
+		inventoryRepo:    inventoryRepo,
+		productRepo:      productRepo,
+		chatNotifier:     chatNotifier,
+		auditRepo:        auditRepo,
+		notificationRepo: notificationRepo,
+		webhooks:         webhooks,
 	}
 }
 
@@ -29,12 +46,10 @@ func (h *InventoryHandler) GetAllInventory(c echo.Context) error {
 
 	inventory, err := h.inventoryRepo.GetAll(ctx)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve inventory items",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve inventory items")
 	}
 
-	return c.JSON(http.StatusOK, inventory)
+	return jsonList(c, http.StatusOK, inventory)
 }
 
 // GetInventoryByID returns an inventory item by ID
@@ -43,21 +58,15 @@ func (h *InventoryHandler) GetInventoryByID(c echo.Context) error {
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid inventory ID",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid inventory ID")
 	}
 
 	inventory, err := h.inventoryRepo.GetByID(ctx, id)
 	if err != nil {
-		if err.Error() == "inventory item not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Inventory item not found",
-			})
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Inventory item not found")
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve inventory item",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve inventory item")
 	}
 
 	return c.JSON(http.StatusOK, inventory)
@@ -69,34 +78,24 @@ func (h *InventoryHandler) GetInventoryByProductID(c echo.Context) error {
 
 	productID, err := strconv.Atoi(c.Param("product_id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid product ID",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid product ID")
 	}
 
 	// First check if product exists
 	_, err = h.productRepo.GetByID(ctx, productID)
 	if err != nil {
-		if err.Error() == "product not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Product not found",
-			})
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Product not found")
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to verify product",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to verify product")
 	}
 
 	inventory, err := h.inventoryRepo.GetByProductID(ctx, productID)
 	if err != nil {
-		if err.Error() == "inventory for product not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Inventory for product not found",
-			})
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Inventory for product not found")
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve inventory",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve inventory")
 	}
 
 	return c.JSON(http.StatusOK, inventory)
@@ -108,61 +107,36 @@ func (h *InventoryHandler) CreateInventory(c echo.Context) error {
 
 	var inventory models.Inventory
 	if err := c.Bind(&inventory); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request payload",
-		})
-	}
-
-	// Validate required fields and values
-	if inventory.ProductID <= 0 {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Valid product ID is required",
-		})
-	}
-
-	if inventory.CurrentStock < 0 {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Current stock cannot be negative",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
 	}
 
-	if inventory.ReorderLevel < 0 {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Reorder level cannot be negative",
-		})
+	if err := c.Validate(&inventory); err != nil {
+		return validationError(err)
 	}
 
 	// Verify product exists
 	_, err := h.productRepo.GetByID(ctx, inventory.ProductID)
 	if err != nil {
-		if err.Error() == "product not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Product not found",
-			})
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Product not found")
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to verify product",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to verify product")
 	}
 
 	err = h.inventoryRepo.Create(ctx, &inventory)
 	if err != nil {
 		if err == repository.ErrDuplicateKey {
-			return c.JSON(http.StatusConflict, map[string]string{
-				"error": "Inventory for this product already exists",
-			})
+			return models.NewAPIError(http.StatusConflict, "Inventory for this product already exists")
 		}
-		if err.Error() == "product not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Product not found",
-			})
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Product not found")
 		}
 
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to create inventory item",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to create inventory item")
 	}
 
+	recordAudit(c, h.auditRepo, models.AuditCreate, models.AuditEntityInventory, inventory.InventoryID, nil, inventory)
+
 	return c.JSON(http.StatusCreated, inventory)
 }
 
@@ -172,63 +146,47 @@ func (h *InventoryHandler) UpdateInventory(c echo.Context) error {
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid inventory ID",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid inventory ID")
 	}
 
 	var inventory models.Inventory
 	if err := c.Bind(&inventory); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request payload",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
 	}
 
 	// Ensure ID in path matches ID in payload
 	inventory.InventoryID = id
 
-	// Validate required fields and values
-	if inventory.ProductID <= 0 {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Valid product ID is required",
-		})
-	}
-
-	if inventory.CurrentStock < 0 {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Current stock cannot be negative",
-		})
+	if err := c.Validate(&inventory); err != nil {
+		return validationError(err)
 	}
 
-	if inventory.ReorderLevel < 0 {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Reorder level cannot be negative",
-		})
+	// Remember the previous state for stock-out detection and the audit log
+	var before *models.Inventory
+	previousStock := 0
+	if existing, err := h.inventoryRepo.GetByID(ctx, id); err == nil {
+		previousStock = existing.CurrentStock
+		before = &existing
 	}
 
 	err = h.inventoryRepo.Update(ctx, &inventory)
 	if err != nil {
-		if err.Error() == "inventory item not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Inventory item not found",
-			})
+		if repository.IsNotFound(err, "inventory item") {
+			return models.NewAPIError(http.StatusNotFound, "Inventory item not found")
 		}
 		if err == repository.ErrDuplicateKey {
-			return c.JSON(http.StatusConflict, map[string]string{
-				"error": "Inventory with this information already exists",
-			})
+			return models.NewAPIError(http.StatusConflict, "Inventory with this information already exists")
 		}
-		if err.Error() == "product not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Product not found",
-			})
+		if repository.IsNotFound(err, "product") {
+			return models.NewAPIError(http.StatusNotFound, "Product not found")
 		}
 
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to update inventory item",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update inventory item")
 	}
 
+	notifyStockChanges(c, h.productRepo, h.chatNotifier, h.notificationRepo, h.webhooks, models.StockChange{Inventory: inventory, PreviousStock: previousStock})
+	recordAudit(c, h.auditRepo, models.AuditUpdate, models.AuditEntityInventory, id, before, inventory)
+
 	return c.JSON(http.StatusOK, inventory)
 }
 
@@ -238,48 +196,47 @@ func (h *InventoryHandler) UpdateStock(c echo.Context) error {
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid inventory ID",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid inventory ID")
 	}
 
 	// Simple payload with just the new stock level
 	var stockUpdate struct {
-		CurrentStock int `json:"current_stock"`
+		CurrentStock int `json:"current_stock" validate:"gte=0"`
 	}
 
 	if err := c.Bind(&stockUpdate); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request payload",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
 	}
 
-	if stockUpdate.CurrentStock < 0 {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Current stock cannot be negative",
-		})
+	if err := c.Validate(&stockUpdate); err != nil {
+		return validationError(err)
+	}
+
+	// Remember the previous state for stock-out detection and the audit log
+	var before *models.Inventory
+	previousStock := 0
+	if existing, err := h.inventoryRepo.GetByID(ctx, id); err == nil {
+		previousStock = existing.CurrentStock
+		before = &existing
 	}
 
 	err = h.inventoryRepo.UpdateStock(ctx, id, stockUpdate.CurrentStock)
 	if err != nil {
-		if err.Error() == "inventory item not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Inventory item not found",
-			})
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Inventory item not found")
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to update stock level",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update stock level")
 	}
 
 	// Get the updated inventory item to return
 	inventory, err := h.inventoryRepo.GetByID(ctx, id)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Stock updated but failed to retrieve updated inventory",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Stock updated but failed to retrieve updated inventory")
 	}
 
+	notifyStockChanges(c, h.productRepo, h.chatNotifier, h.notificationRepo, h.webhooks, models.StockChange{Inventory: inventory, PreviousStock: previousStock})
+	recordAudit(c, h.auditRepo, models.AuditUpdate, models.AuditEntityInventory, id, before, inventory)
+
 	return c.JSON(http.StatusOK, inventory)
 }
 
@@ -289,24 +246,28 @@ func (h *InventoryHandler) DeleteInventory(c echo.Context) error {
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid inventory ID",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid inventory ID")
+	}
+
+	before, err := h.inventoryRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Inventory item not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve inventory item")
 	}
 
 	err = h.inventoryRepo.Delete(ctx, id)
 	if err != nil {
-		if err.Error() == "inventory item not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Inventory item not found",
-			})
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Inventory item not found")
 		}
 
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to delete inventory item",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to delete inventory item")
 	}
 
+	recordAudit(c, h.auditRepo, models.AuditDelete, models.AuditEntityInventory, id, before, nil)
+
 	return c.NoContent(http.StatusNoContent)
 }
 
@@ -316,12 +277,10 @@ func (h *InventoryHandler) GetLowStockItems(c echo.Context) error {
 
 	inventory, err := h.inventoryRepo.GetLowStockItems(ctx)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve low stock items",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve low stock items")
 	}
 
-	return c.JSON(http.StatusOK, inventory)
+	return jsonList(c, http.StatusOK, inventory)
 }
 
 // GetLowStockWithProductInfo returns low stock items with product details
@@ -330,10 +289,8 @@ func (h *InventoryHandler) GetLowStockWithProductInfo(c echo.Context) error {
 
 	items, err := h.inventoryRepo.GetLowStockWithProductInfo(ctx)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve low stock items with product info",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve low stock items with product info")
 	}
 
-	return c.JSON(http.StatusOK, items)
+	return jsonList(c, http.StatusOK, items)
 } 
\ No newline at end of file
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/labstack/echo/v4"
+)
+
+// VoidRequest asks for an issued invoice to be voided
+type VoidRequest struct {
+	Reason string `json:"reason" validate:"notblank"`
+}
+
+// CreditNoteRequest asks for a credit note against an issued invoice: for the order lines
+// listed, in proportion to the quantities returned, or else for Amount
+type CreditNoteRequest struct {
+	Reason string                         `json:"reason" validate:"notblank"`
+	Amount float64                        `json:"amount" validate:"gte=0"`
+	Items  []models.InvoiceAdjustmentItem `json:"items" validate:"dive"`
+}
+
+// AdjustmentDecisionRequest approves or rejects an invoice adjustment
+type AdjustmentDecisionRequest struct {
+	Note string `json:"note"`
+}
+
+// InvoiceAdjustmentHandler handles HTTP requests for voiding issued sales invoices and
+// issuing credit notes against them
+type InvoiceAdjustmentHandler struct {
+	adjustmentRepo *repository.InvoiceAdjustmentRepository
+	customerRepo   *repository.CustomerRepository
+	auditRepo      *repository.AuditRepository
+}
+
+// NewInvoiceAdjustmentHandler creates a new invoice adjustment handler with the provided repositories
+func NewInvoiceAdjustmentHandler(adjustmentRepo *repository.InvoiceAdjustmentRepository, customerRepo *repository.CustomerRepository, auditRepo *repository.AuditRepository) *InvoiceAdjustmentHandler {
+	return &InvoiceAdjustmentHandler{
+		adjustmentRepo: adjustmentRepo,
+		customerRepo:   customerRepo,
+		auditRepo:      auditRepo,
+	}
+}
+
+// GetAdjustments lists invoice adjustments, only those with ?status= (pending, approved or
+// rejected) when given, e.g. the requests waiting for approval
+func (h *InvoiceAdjustmentHandler) GetAdjustments(c echo.Context) error {
+	status := c.QueryParam("status")
+	if status != "" && status != models.AdjustmentPending && status != models.AdjustmentApproved && status != models.AdjustmentRejected {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid status. Must be one of: pending, approved, rejected")
+	}
+
+	adjustments, err := h.adjustmentRepo.GetAll(c.Request().Context(), status)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve invoice adjustments")
+	}
+
+	return jsonList(c, http.StatusOK, adjustments)
+}
+
+// GetAdjustment returns an invoice adjustment with its items
+func (h *InvoiceAdjustmentHandler) GetAdjustment(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid adjustment ID")
+	}
+
+	adjustment, err := h.adjustmentRepo.GetByID(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Invoice adjustment not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve invoice adjustment")
+	}
+
+	return c.JSON(http.StatusOK, adjustment)
+}
+
+// GetOrderAdjustments lists the voids and credit notes requested against an order's invoice
+func (h *InvoiceAdjustmentHandler) GetOrderAdjustments(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid order ID")
+	}
+
+	adjustments, err := h.adjustmentRepo.GetByOrder(c.Request().Context(), id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve invoice adjustments")
+	}
+
+	return jsonList(c, http.StatusOK, adjustments)
+}
+
+// RequestVoid asks for an order's issued invoice to be voided. The order is cancelled once
+// an admin approves.
+func (h *InvoiceAdjustmentHandler) RequestVoid(c echo.Context) error {
+	var req VoidRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	return h.request(c, models.InvoiceAdjustment{Kind: models.AdjustmentVoid, Reason: req.Reason})
+}
+
+// RequestCreditNote asks for a credit note against an order's issued invoice, taking effect
+// once an admin approves. Items list the order lines returned, each credited in proportion to
+// the quantity; without items the note is for amount.
+func (h *InvoiceAdjustmentHandler) RequestCreditNote(c echo.Context) error {
+	var req CreditNoteRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+	if len(req.Items) == 0 && req.Amount <= 0 {
+		return models.NewAPIError(http.StatusBadRequest, "A credit note needs items or an amount")
+	}
+	if len(req.Items) > 0 && req.Amount > 0 {
+		return models.NewAPIError(http.StatusBadRequest, "A credit note with items is priced from them; leave amount out")
+	}
+
+	return h.request(c, models.InvoiceAdjustment{
+		Kind:   models.AdjustmentCreditNote,
+		Reason: req.Reason,
+		Amount: req.Amount,
+		Items:  req.Items,
+	})
+}
+
+// request records an adjustment against the order in the path
+func (h *InvoiceAdjustmentHandler) request(c echo.Context, adjustment models.InvoiceAdjustment) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid order ID")
+	}
+
+	adjustment.OrderID = id
+	adjustment.Reason = strings.TrimSpace(adjustment.Reason)
+	if user := appmw.UserFromContext(c); user != nil {
+		adjustment.RequestedBy = &user.UserID
+	}
+
+	if err := h.adjustmentRepo.Create(ctx, &adjustment); err != nil {
+		var exceeded *repository.CreditExceededError
+		switch {
+		case errors.As(err, &exceeded):
+			return models.NewAPIError(http.StatusBadRequest, "Credit exceeds what remains on the invoice").WithDetails(map[string]interface{}{
+				"order_item_id": exceeded.OrderItemID,
+				"remaining":     exceeded.Remaining,
+			})
+		case repository.IsNotFound(err, "order item"):
+			return models.NewAPIError(http.StatusBadRequest, "Items must be lines of the order, each listed once")
+		case errors.Is(err, repository.ErrNotFound):
+			return models.NewAPIError(http.StatusNotFound, "Order not found")
+		case errors.Is(err, repository.ErrConflict):
+			return models.NewAPIError(http.StatusConflict, err.Error())
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to record invoice adjustment")
+	}
+
+	recordAudit(c, h.auditRepo, "request_"+adjustment.Kind, models.AuditEntityOrder, id, nil, adjustment)
+
+	return c.JSON(http.StatusCreated, adjustment)
+}
+
+// ApproveAdjustment approves a pending void or credit note, putting it into effect
+func (h *InvoiceAdjustmentHandler) ApproveAdjustment(c echo.Context) error {
+	return h.decide(c, true)
+}
+
+// RejectAdjustment rejects a pending void or credit note. A note explaining why is required.
+func (h *InvoiceAdjustmentHandler) RejectAdjustment(c echo.Context) error {
+	return h.decide(c, false)
+}
+
+// decide records an admin's decision on a pending adjustment
+func (h *InvoiceAdjustmentHandler) decide(c echo.Context, approve bool) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid adjustment ID")
+	}
+
+	var req AdjustmentDecisionRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+	req.Note = strings.TrimSpace(req.Note)
+	if !approve && req.Note == "" {
+		return models.NewAPIError(http.StatusBadRequest, "A note explaining the rejection is required")
+	}
+
+	var decidedBy *int
+	if user := appmw.UserFromContext(c); user != nil {
+		decidedBy = &user.UserID
+	}
+
+	if err := h.adjustmentRepo.Decide(ctx, id, approve, decidedBy, req.Note); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Invoice adjustment not found")
+		}
+		if err == repository.ErrAdjustmentDecided {
+			return models.NewAPIError(http.StatusConflict, "Invoice adjustment is not pending approval")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to record approval decision")
+	}
+
+	adjustment, err := h.adjustmentRepo.GetByID(ctx, id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve invoice adjustment")
+	}
+
+	action := "reject_" + adjustment.Kind
+	if approve {
+		action = "approve_" + adjustment.Kind
+	}
+	recordAudit(c, h.auditRepo, action, models.AuditEntityOrder, adjustment.OrderID, nil, adjustment)
+
+	return c.JSON(http.StatusOK, adjustment)
+}
+
+// GetCustomerBalance returns what a customer owes across their orders, net of voids, credit
+// notes, payments and tax withheld
+func (h *InvoiceAdjustmentHandler) GetCustomerBalance(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid customer ID")
+	}
+
+	if _, err := h.customerRepo.GetByIDWithDeleted(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Customer not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve customer")
+	}
+
+	balance, err := h.adjustmentRepo.GetCustomerBalance(ctx, id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to calculate customer balance")
+	}
+
+	return c.JSON(http.StatusOK, balance)
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// InvoiceHandler handles HTTP requests for the invoices that bill delivered orders
+type InvoiceHandler struct {
+	invoiceRepo  *repository.InvoiceRepository
+	orderRepo    *repository.OrderRepository
+	customerRepo *repository.CustomerRepository
+	auditRepo    *repository.AuditRepository
+	pdfGenerator *services.PDFGenerator
+	archiver     *services.DocumentArchiver
+	currencies   *services.CurrencyService
+}
+
+// NewInvoiceHandler creates a new invoice handler with the provided repositories
+func NewInvoiceHandler(
+	invoiceRepo *repository.InvoiceRepository,
+	orderRepo *repository.OrderRepository,
+	customerRepo *repository.CustomerRepository,
+	auditRepo *repository.AuditRepository,
+	pdfGenerator *services.PDFGenerator,
+	archiver *services.DocumentArchiver,
+	currencies *services.CurrencyService,
+) *InvoiceHandler {
+	return &InvoiceHandler{
+		invoiceRepo:  invoiceRepo,
+		orderRepo:    orderRepo,
+		customerRepo: customerRepo,
+		auditRepo:    auditRepo,
+		pdfGenerator: pdfGenerator,
+		archiver:     archiver,
+		currencies:   currencies,
+	}
+}
+
+// GetInvoices returns all invoices, or one page of them; see parsePage. Invoices can be
+// filtered and sorted with parameters such as ?status=overdue&customer_id=4&sort=due_date;
+// see repository.ParseListQuery.
+func (h *InvoiceHandler) GetInvoices(c echo.Context) error {
+	page, paged, message := parsePage(c)
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	list, err := repository.ParseListQuery(c.QueryParams(), repository.InvoiceListColumns)
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	for _, filter := range list.Filters {
+		if filter.Column != "status" {
+			continue
+		}
+		for _, status := range filter.Values {
+			if !containsString(models.InvoiceStatuses, status) {
+				return models.NewAPIError(http.StatusBadRequest, "Invalid status. Must be one of: open, paid, overdue, void")
+			}
+		}
+	}
+
+	invoices, total, err := h.invoiceRepo.GetAll(c.Request().Context(), list, page)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve invoices")
+	}
+
+	return jsonPage(c, http.StatusOK, invoices, page, paged, total)
+}
+
+// GetInvoice returns an invoice with its items
+func (h *InvoiceHandler) GetInvoice(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid invoice ID")
+	}
+
+	invoice, err := h.invoiceRepo.GetByID(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Invoice not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve invoice")
+	}
+
+	return c.JSON(http.StatusOK, invoice)
+}
+
+// GetOrderInvoice returns the invoice of an order
+func (h *InvoiceHandler) GetOrderInvoice(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid order ID")
+	}
+
+	invoice, err := h.invoiceRepo.GetByOrder(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Order has not been invoiced")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve invoice")
+	}
+
+	return c.JSON(http.StatusOK, invoice)
+}
+
+// CreateOrderInvoice issues the invoice of a delivered order, due after the customer's
+// payment terms
+func (h *InvoiceHandler) CreateOrderInvoice(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid order ID")
+	}
+
+	var createdBy *int
+	if user := appmw.UserFromContext(c); user != nil {
+		createdBy = &user.UserID
+	}
+
+	invoice, err := h.invoiceRepo.CreateFromOrder(c.Request().Context(), id, createdBy)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Order not found")
+		}
+		if err == repository.ErrOrderInvoiced {
+			return models.NewAPIError(http.StatusConflict, "Order has already been invoiced")
+		}
+		if err == repository.ErrOrderNotDelivered {
+			return models.NewAPIError(http.StatusConflict, "Only delivered orders can be invoiced")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to create invoice")
+	}
+
+	recordAudit(c, h.auditRepo, models.AuditCreate, models.AuditEntityInvoice, invoice.InvoiceID, nil, invoice)
+
+	return c.JSON(http.StatusCreated, invoice)
+}
+
+// GetInvoicePDF renders an invoice for the customer with its lines, due date and what is
+// left to pay
+func (h *InvoiceHandler) GetInvoicePDF(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid invoice ID")
+	}
+
+	invoice, err := h.invoiceRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Invoice not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve invoice")
+	}
+
+	customer, err := h.customerRepo.GetByIDWithDeleted(ctx, invoice.CustomerID)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve customer information")
+	}
+
+	order, err := h.orderRepo.GetByID(ctx, invoice.OrderID)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve order")
+	}
+
+	currency, err := h.currencies.Currency(ctx, invoice.Currency)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve currency")
+	}
+
+	templateData := map[string]interface{}{
+		"Invoice":        invoice,
+		"Customer":       customer,
+		"Order":          order,
+		"Currency":       currency,
+		"GenerationDate": time.Now().Format("January 2, 2006"),
+	}
+
+	content, err := h.pdfGenerator.GenerateFromTemplate("invoice/template.html", "", templateData)
+	if err != nil {
+		log.Printf("Failed to render invoice %d: %v", invoice.InvoiceID, err)
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to render invoice")
+	}
+
+	// Copy the document into the connected Drive/OneDrive archive
+	h.archiver.ArchiveAsync(services.ArchiveDocument{
+		CustomerName: customer.CompanyName,
+		Year:         invoice.InvoiceDate.Year(),
+		FileName:     invoice.Number + ".pdf",
+		Content:      content,
+	})
+
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.pdf", invoice.Number))
+	return c.Blob(http.StatusOK, "application/pdf", content)
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// JobHandler handles HTTP requests for inspecting and retrying background jobs
+type JobHandler struct {
+	jobRepo  *repository.JobRepository
+	jobQueue *services.JobQueue
+}
+
+// NewJobHandler creates a new job handler with the provided repository and queue
+func NewJobHandler(jobRepo *repository.JobRepository, jobQueue *services.JobQueue) *JobHandler {
+	return &JobHandler{
+		jobRepo:  jobRepo,
+		jobQueue: jobQueue,
+	}
+}
+
+// GetJobs returns background jobs, newest first. ?status= limits them to Pending, Succeeded
+// or Failed; Failed jobs failed on every attempt and are not retried unless an admin asks.
+func (h *JobHandler) GetJobs(c echo.Context) error {
+	status := c.QueryParam("status")
+	if status != "" && !containsString(models.JobStatuses, status) {
+		return models.NewAPIError(http.StatusBadRequest, "status must be one of: "+strings.Join(models.JobStatuses, ", "))
+	}
+
+	page, paged, message := parsePage(c)
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	jobs, total, err := h.jobRepo.GetAll(c.Request().Context(), status, page)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve jobs")
+	}
+
+	return jsonPage(c, http.StatusOK, jobs, page, paged, total)
+}
+
+// GetJob returns a single background job with its payload and last error
+func (h *JobHandler) GetJob(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid job ID")
+	}
+
+	job, err := h.jobRepo.GetByID(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Job not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve job")
+	}
+
+	return c.JSON(http.StatusOK, job)
+}
+
+// RetryJob queues a failed job to run again now with a fresh set of attempts
+func (h *JobHandler) RetryJob(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid job ID")
+	}
+
+	job, err := h.jobQueue.Retry(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Job not found")
+		}
+		if errors.Is(err, repository.ErrConflict) {
+			return models.NewAPIError(http.StatusConflict, "Only failed jobs can be retried")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retry job")
+	}
+
+	return c.JSON(http.StatusOK, job)
+}
+
+// DeleteJob discards a job that succeeded or failed. Pending jobs cannot be discarded.
+func (h *JobHandler) DeleteJob(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid job ID")
+	}
+
+	if err := h.jobRepo.Delete(c.Request().Context(), id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Job not found")
+		}
+		if errors.Is(err, repository.ErrConflict) {
+			return models.NewAPIError(http.StatusConflict, "Pending jobs cannot be discarded")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to delete job")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
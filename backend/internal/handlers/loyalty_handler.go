@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// LoyaltyHandler handles HTTP requests for loyalty tiers and tier-based pricing
+type LoyaltyHandler struct {
+	tierRepo       *repository.LoyaltyTierRepository
+	tierService    *services.TierService
+	pricingService *services.PricingService
+}
+
+// NewLoyaltyHandler creates a new loyalty handler
+func NewLoyaltyHandler(
+	tierRepo *repository.LoyaltyTierRepository,
+	tierService *services.TierService,
+	pricingService *services.PricingService,
+) *LoyaltyHandler {
+	return &LoyaltyHandler{
+		tierRepo:       tierRepo,
+		tierService:    tierService,
+		pricingService: pricingService,
+	}
+}
+
+// GetTiers returns all loyalty tiers
+func (h *LoyaltyHandler) GetTiers(c echo.Context) error {
+	tiers, err := h.tierRepo.GetAll(c.Request().Context())
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve loyalty tiers")
+	}
+
+	return jsonList(c, http.StatusOK, tiers)
+}
+
+// UpdateTier changes a tier's revenue threshold, default discount and delivery benefits
+func (h *LoyaltyHandler) UpdateTier(c echo.Context) error {
+	var tier models.LoyaltyTier
+	if err := c.Bind(&tier); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+	tier.Tier = c.Param("tier")
+
+	if err := c.Validate(&tier); err != nil {
+		return validationError(err)
+	}
+
+	if err := h.tierRepo.Update(c.Request().Context(), &tier); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Loyalty tier not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update loyalty tier")
+	}
+
+	return c.JSON(http.StatusOK, tier)
+}
+
+// RecalculateTiers reassigns customer tiers immediately instead of waiting for the scheduled job
+func (h *LoyaltyHandler) RecalculateTiers(c echo.Context) error {
+	changed, err := h.tierService.Recalculate(c.Request().Context())
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to recalculate loyalty tiers")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"updated": changed,
+	})
+}
+
+// PreviewPricing prices an order without creating it
+func (h *LoyaltyHandler) PreviewPricing(c echo.Context) error {
+	var req CreateOrderRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	pricing, err := h.pricingService.PriceOrder(c.Request().Context(), &req.Order, req.Items)
+	if err != nil {
+		if apiErr := discountLimitError(err); apiErr != nil {
+			return apiErr
+		}
+		if apiErr := currencyError(err); apiErr != nil {
+			return apiErr
+		}
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Customer not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to price order")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"order":   req.Order,
+		"items":   req.Items,
+		"pricing": pricing,
+	})
+}
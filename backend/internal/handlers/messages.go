@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Languages API error messages are written in
+const (
+	langEnglish  = "en"
+	langFilipino = "fil"
+)
+
+// Keys of the messages in the catalog. Field messages take the field's name and the rule's
+// parameter as their first and second arguments.
+const (
+	msgRequired        = "required"
+	msgGreaterThan     = "gt"
+	msgAtLeast         = "gte"
+	msgLessThan        = "lt"
+	msgAtMost          = "lte"
+	msgMinLength       = "min_length"
+	msgMinItems        = "min_items"
+	msgNotEmpty        = "not_empty"
+	msgMaxLength       = "max_length"
+	msgMaxItems        = "max_items"
+	msgOneOf           = "oneof"
+	msgEmail           = "email"
+	msgURL             = "url"
+	msgDatetime        = "datetime"
+	msgLatitude        = "latitude"
+	msgLongitude       = "longitude"
+	msgRequiredWithout = "required_without"
+	msgNotBefore       = "gtefield"
+	msgInvalid         = "invalid"
+	msgDuplicate       = "duplicate"
+	msgReferenced      = "referenced"
+	msgStaleVersion    = "stale_version"
+	msgInternal        = "internal"
+)
+
+// messageCatalog holds the error messages the shared error handler writes, by language and
+// key. Every key must be in English, the fallback; translations use the same fmt arguments.
+var messageCatalog = map[string]map[string]string{
+	langEnglish: {
+		msgRequired:        "%[1]s is required",
+		msgGreaterThan:     "%[1]s must be greater than %[2]s",
+		msgAtLeast:         "%[1]s must be %[2]s or more",
+		msgLessThan:        "%[1]s must be less than %[2]s",
+		msgAtMost:          "%[1]s must be %[2]s or less",
+		msgMinLength:       "%[1]s must be at least %[2]s characters",
+		msgMinItems:        "%[1]s must have at least %[2]s items",
+		msgNotEmpty:        "%[1]s cannot be empty",
+		msgMaxLength:       "%[1]s must be at most %[2]s characters",
+		msgMaxItems:        "%[1]s must have at most %[2]s items",
+		msgOneOf:           "%[1]s must be one of: %[2]s",
+		msgEmail:           "%[1]s must be a valid email address",
+		msgURL:             "%[1]s must be a valid URL",
+		msgDatetime:        "%[1]s must be a date in the format %[2]s",
+		msgLatitude:        "%[1]s must be a valid latitude",
+		msgLongitude:       "%[1]s must be a valid longitude",
+		msgRequiredWithout: "%[1]s is required when %[2]s is not given",
+		msgNotBefore:       "%[1]s cannot be before %[2]s",
+		msgInvalid:         "%[1]s is invalid",
+		msgDuplicate:       "A record with these details already exists",
+		msgReferenced:      "The record is still referenced by other data",
+		msgStaleVersion:    "The record was changed by someone else",
+		msgInternal:        "Internal server error",
+	},
+	langFilipino: {
+		msgRequired:        "kailangan ang %[1]s",
+		msgGreaterThan:     "ang %[1]s ay dapat higit sa %[2]s",
+		msgAtLeast:         "ang %[1]s ay dapat %[2]s o higit pa",
+		msgLessThan:        "ang %[1]s ay dapat mas mababa sa %[2]s",
+		msgAtMost:          "ang %[1]s ay dapat %[2]s o mas mababa",
+		msgMinLength:       "ang %[1]s ay dapat may hindi bababa sa %[2]s na character",
+		msgMinItems:        "ang %[1]s ay dapat may hindi bababa sa %[2]s na item",
+		msgNotEmpty:        "hindi maaaring walang laman ang %[1]s",
+		msgMaxLength:       "ang %[1]s ay dapat may hindi hihigit sa %[2]s na character",
+		msgMaxItems:        "ang %[1]s ay dapat may hindi hihigit sa %[2]s na item",
+		msgOneOf:           "ang %[1]s ay dapat isa sa: %[2]s",
+		msgEmail:           "ang %[1]s ay dapat wastong email address",
+		msgURL:             "ang %[1]s ay dapat wastong URL",
+		msgDatetime:        "ang %[1]s ay dapat petsa sa format na %[2]s",
+		msgLatitude:        "ang %[1]s ay dapat wastong latitude",
+		msgLongitude:       "ang %[1]s ay dapat wastong longitude",
+		msgRequiredWithout: "kailangan ang %[1]s kapag walang %[2]s",
+		msgNotBefore:       "ang %[1]s ay hindi maaaring mas maaga sa %[2]s",
+		msgInvalid:         "hindi wasto ang %[1]s",
+		msgDuplicate:       "Mayroon nang record na may ganitong mga detalye",
+		msgReferenced:      "Ginagamit pa ng ibang data ang record",
+		msgStaleVersion:    "Binago na ng ibang tao ang record",
+		msgInternal:        "Nagkaroon ng error sa server",
+	},
+}
+
+// translate looks up a message in the given language, falling back to English, and fills
+// in its arguments
+func translate(lang, key string, args ...interface{}) string {
+	format, ok := messageCatalog[lang][key]
+	if !ok {
+		format = messageCatalog[langEnglish][key]
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// requestLanguage picks the language of error messages from the request's Accept-Language
+// header, e.g. "fil-PH, en;q=0.8": the supported language the client prefers most, English
+// when it names none. Tagalog (tl) is answered in Filipino.
+func requestLanguage(c echo.Context) string {
+	best, bestQuality := langEnglish, 0.0
+	for _, part := range strings.Split(c.Request().Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = q
+		}
+
+		primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		var lang string
+		switch primary {
+		case "en":
+			lang = langEnglish
+		case "fil", "tl":
+			lang = langFilipino
+		default:
+			continue
+		}
+		if quality > bestQuality {
+			best, bestQuality = lang, quality
+		}
+	}
+	return best
+}
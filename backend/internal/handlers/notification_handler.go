@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/labstack/echo/v4"
+)
+
+// NotificationHandler handles HTTP requests for the signed-in user's notifications
+type NotificationHandler struct {
+	notificationRepo *repository.NotificationRepository
+}
+
+// NewNotificationHandler creates a new notification handler with the provided repository
+func NewNotificationHandler(notificationRepo *repository.NotificationRepository) *NotificationHandler {
+	return &NotificationHandler{
+		notificationRepo: notificationRepo,
+	}
+}
+
+// NotificationSummary is the number of unread notifications, for the badge in the header
+type NotificationSummary struct {
+	Unread int `json:"unread"`
+}
+
+// MarkAllReadResponse says how many notifications were marked as read
+type MarkAllReadResponse struct {
+	Marked int64 `json:"marked"`
+}
+
+// GetNotifications returns the user's notifications, newest first. ?unread=true returns only
+// the unread ones; ?page= and ?page_size= (or ?limit= and ?offset=) return one page of them.
+func (h *NotificationHandler) GetNotifications(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user := appmw.UserFromContext(c)
+	if user == nil {
+		return models.NewAPIError(http.StatusUnauthorized, "Notifications require a signed-in user")
+	}
+	page, paged, message := parsePage(c)
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	notifications, total, err := h.notificationRepo.GetByUser(ctx, user.UserID, c.QueryParam("unread") == "true", page)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve notifications")
+	}
+
+	return jsonPage(c, http.StatusOK, notifications, page, paged, total)
+}
+
+// GetNotificationSummary returns how many notifications the user has not read
+func (h *NotificationHandler) GetNotificationSummary(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user := appmw.UserFromContext(c)
+	if user == nil {
+		return models.NewAPIError(http.StatusUnauthorized, "Notifications require a signed-in user")
+	}
+
+	unread, err := h.notificationRepo.CountUnread(ctx, user.UserID)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to count notifications")
+	}
+
+	return c.JSON(http.StatusOK, NotificationSummary{Unread: unread})
+}
+
+// MarkNotificationRead marks one of the user's notifications as read
+func (h *NotificationHandler) MarkNotificationRead(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user := appmw.UserFromContext(c)
+	if user == nil {
+		return models.NewAPIError(http.StatusUnauthorized, "Notifications require a signed-in user")
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid notification ID")
+	}
+
+	notification, err := h.notificationRepo.MarkRead(ctx, user.UserID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Notification not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to mark notification as read")
+	}
+
+	return c.JSON(http.StatusOK, notification)
+}
+
+// MarkAllNotificationsRead marks all of the user's notifications as read
+func (h *NotificationHandler) MarkAllNotificationsRead(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user := appmw.UserFromContext(c)
+	if user == nil {
+		return models.NewAPIError(http.StatusUnauthorized, "Notifications require a signed-in user")
+	}
+
+	marked, err := h.notificationRepo.MarkAllRead(ctx, user.UserID)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to mark notifications as read")
+	}
+
+	return c.JSON(http.StatusOK, MarkAllReadResponse{Marked: marked})
+}
+
+// notifyUsers sends an in-app notification about a record to every user except the one
+// whose request caused it. Failures are logged rather than failing the request.
+func notifyUsers(c echo.Context, notificationRepo *repository.NotificationRepository, notificationType, entity string, entityID int, title, message string) {
+	notification := models.Notification{
+		Type:       notificationType,
+		Title:      title,
+		Message:    message,
+		EntityType: &entity,
+		EntityID:   &entityID,
+	}
+
+	var actor *int
+	if user := appmw.UserFromContext(c); user != nil {
+		actor = &user.UserID
+	}
+	if _, err := notificationRepo.CreateForAllUsers(c.Request().Context(), notification, actor); err != nil {
+		log.Printf("Failed to send %s notification for %s %d: %v", notificationType, entity, entityID, err)
+	}
+}
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+)
+
+// openAPIOperation documents what a handler reads and writes for the OpenAPI spec. Request
+// and Response are zero values of the body types, so the schemas follow the structs' JSON
+// tags. Paged lists also accept the page parameters and may be wrapped in a page envelope;
+// List adds the whitelisted filter and sort parameters of a list.
+type openAPIOperation struct {
+	Summary  string
+	Query    []string
+	Paged    bool
+	List     *repository.ListColumns
+	Request  interface{}
+	Response interface{}
+	Status   int
+}
+
+// openAPIOperations documents handlers by receiver and method name. Handlers left out are
+// still in the spec, without bodies.
+var openAPIOperations = map[string]openAPIOperation{
+	"AuthHandler.Login": {Request: services.LoginRequest{}, Response: services.AuthResponse{}},
+
+	"SearchHandler.Search": {Query: []string{"q", "limit"}, Response: SearchResults{}},
+
+	"ReferenceDataHandler.GetReferenceData": {Response: models.ReferenceData{}},
+
+	"CustomerHandler.GetAllCustomers": {Query: []string{"search", "archived", "fields"}, Paged: true, Response: []models.Customer{}},
+	"CustomerHandler.GetCustomerByID": {Response: models.Customer{}},
+	"CustomerHandler.CreateCustomer":  {Request: models.Customer{}, Response: models.Customer{}},
+	"CustomerHandler.UpdateCustomer":  {Request: models.Customer{}, Response: models.Customer{}},
+
+	"ContactHandler.GetAllContacts":        {Query: []string{"search", "fields"}, Paged: true, Response: []models.Contact{}},
+	"ContactHandler.GetContactsByCustomer": {Query: []string{"fields"}, Response: []models.Contact{}},
+	"ContactHandler.GetContactByID":        {Response: models.Contact{}},
+	"ContactHandler.CreateContact":         {Request: models.Contact{}, Response: models.Contact{}},
+	"ContactHandler.UpdateContact":         {Request: models.Contact{}, Response: models.Contact{}},
+
+	"IndustryHandler.GetIndustries":  {Response: []models.Industry{}},
+	"IndustryHandler.CreateIndustry": {Request: models.Industry{}, Response: models.Industry{}},
+	"IndustryHandler.UpdateIndustry": {Request: models.Industry{}, Response: models.Industry{}},
+
+	"ProductHandler.GetAllProducts": {Query: []string{"search", "category", "discontinued", "fields"}, Paged: true, List: &repository.ProductListColumns, Response: []models.Product{}},
+	"ProductHandler.GetProductByID": {Response: models.Product{}},
+	"ProductHandler.CreateProduct":  {Request: models.Product{}, Response: models.Product{}},
+	"ProductHandler.UpdateProduct":  {Request: models.Product{}, Response: models.Product{}},
+
+	"InventoryHandler.GetAllInventory":            {Query: []string{"fields"}, Response: []models.Inventory{}},
+	"InventoryHandler.GetInventoryByID":           {Response: models.Inventory{}},
+	"InventoryHandler.GetInventoryByProductID":    {Response: models.Inventory{}},
+	"InventoryHandler.CreateInventory":            {Request: models.Inventory{}, Response: models.Inventory{}},
+	"InventoryHandler.UpdateInventory":            {Request: models.Inventory{}, Response: models.Inventory{}},
+	"InventoryHandler.GetLowStockItems":           {Response: []models.Inventory{}},
+	"InventoryHandler.GetLowStockWithProductInfo": {Query: []string{"fields"}, Response: []repository.LowStockWithProductInfo{}},
+
+	"ReceivingHandler.GetChecklist":  {Response: []models.ReceivingChecklistItem{}},
+	"ReceivingHandler.GetReceipts":   {Response: []models.StockReceipt{}},
+	"ReceivingHandler.CreateReceipt": {Request: StockReceiptRequest{}},
+
+	"QuotationHandler.GetAllQuotations":           {Query: []string{"fields"}, Paged: true, List: &repository.QuotationListColumns, Response: []models.Quotation{}},
+	"QuotationHandler.UpdateQuotationStatus":      {Request: StatusUpdate{}},
+	"QuotationHandler.PreviewQuotationPrice":      {Request: QuotationPreviewRequest{}, Response: services.QuotationPreview{}},
+	"QuotationHandler.ReviseQuotation":            {Request: QuotationRevisionRequest{}, Status: http.StatusCreated},
+	"QuotationHandler.GetQuotationRevisions":      {Response: []QuotationRevision{}},
+	"QuotationHandler.DiffQuotationRevisions":     {Query: []string{"from", "to"}, Response: services.QuotationDiff{}},
+	"QuotationHandler.AcceptQuotation":            {Request: AcceptQuotationRequest{}, Response: QuotationRevision{}},
+	"QuotationHandler.SendQuotation":              {Request: SendQuotationRequest{}, Response: models.EmailDelivery{}},
+	"QuotationHandler.GetQuotationDeliveries":     {Response: []models.EmailDelivery{}},
+	"QuotationHandler.MarkQuotationSent":          {Request: MarkQuotationSentRequest{}, Response: models.Quotation{}},
+	"QuotationHandler.CreateQuotationFromRequest": {Request: QuoteRequestQuotation{}, Status: http.StatusCreated},
+	"QuotationHandler.StartQuotationBuilder":      {Request: QuotationBuilderRequest{}, Response: models.QuotationBuilderSession{}, Status: http.StatusCreated},
+	"QuotationHandler.GetQuotationBuilder":        {Response: models.QuotationBuilderSession{}},
+	"QuotationHandler.AddQuotationBuilderLine":    {Request: QuotationPreviewItem{}, Response: models.QuotationBuilderSession{}, Status: http.StatusCreated},
+	"QuotationHandler.RemoveQuotationBuilderLine": {Response: models.QuotationBuilderSession{}},
+	"QuotationHandler.PriceQuotationBuilder":      {Response: services.QuotationPreview{}},
+	"QuotationHandler.SubmitQuotationBuilder":     {Status: http.StatusCreated},
+	"QuotationHandler.DiscardQuotationBuilder":    {Status: http.StatusNoContent},
+
+	"OrderHandler.GetAllOrders":          {Query: []string{"fields"}, Paged: true, List: &repository.OrderListColumns, Response: []models.Order{}},
+	"OrderHandler.CreateOrder":           {Request: CreateOrderRequest{}},
+	"OrderHandler.CreateOrderFromScan":   {Request: CreateOrderRequest{}, Status: http.StatusCreated},
+	"OrderHandler.CreateCashSale":        {Request: CashSaleRequest{}},
+	"OrderHandler.GetOrderReceiptPDF":    {Query: []string{"width"}},
+	"OrderHandler.GetOrderReceiptText":   {Query: []string{"width"}},
+	"OrderHandler.GetOrderReceiptESCPOS": {Query: []string{"width"}},
+	"OrderHandler.UpdateOrderStatus":     {Request: StatusUpdate{}, Response: models.Order{}},
+	"OrderHandler.GetOrderSources":       {Response: []models.OrderSource{}},
+
+	"ShiftHandler.GetShifts":                   {Query: []string{"user_id", "status", "from", "to"}, Response: []models.CashierShift{}},
+	"ShiftHandler.GetShift":                    {Response: models.CashierShift{}},
+	"ShiftHandler.GetCurrentShift":             {Response: models.CashierShift{}},
+	"ShiftHandler.OpenShift":                   {Request: OpenShiftRequest{}, Response: models.CashierShift{}},
+	"ShiftHandler.CloseShift":                  {Request: CloseShiftRequest{}, Response: models.ShiftReport{}},
+	"ShiftHandler.GetShiftReport":              {Response: models.ShiftReport{}},
+	"ShiftHandler.GetDailyClosingReport":       {Query: []string{"date"}, Response: models.DailyClosingReport{}},
+	"ShiftHandler.GetDailyClosingReportPDF":    {Query: []string{"date"}},
+	"ShiftHandler.ExportDailyClosingReportCSV": {Query: []string{"date"}},
+
+	"FreightHandler.GetDeliveryZones":  {Response: []models.DeliveryZone{}},
+	"FreightHandler.GetFreightRates":   {Response: []models.FreightRate{}},
+	"FreightHandler.CreateFreightRate": {Request: models.FreightRate{}, Response: models.FreightRate{}},
+	"FreightHandler.UpdateFreightRate": {Request: models.FreightRate{}, Response: models.FreightRate{}},
+	"FreightHandler.EstimateFreight":   {Request: FreightEstimateRequest{}},
+
+	"DispatchHandler.GetVehicles":    {Response: []models.Vehicle{}},
+	"DispatchHandler.CreateVehicle":  {Request: models.Vehicle{}, Response: models.Vehicle{}},
+	"DispatchHandler.UpdateVehicle":  {Request: models.Vehicle{}, Response: models.Vehicle{}},
+	"DispatchHandler.GetDrivers":     {Response: []models.Driver{}},
+	"DispatchHandler.CreateDriver":   {Request: models.Driver{}, Response: models.Driver{}},
+	"DispatchHandler.UpdateDriver":   {Request: models.Driver{}, Response: models.Driver{}},
+	"DispatchHandler.GetDeliveries":  {Query: []string{"date", "vehicle_id"}, Response: []models.DeliveryAssignment{}},
+	"DispatchHandler.AssignDelivery": {Request: DeliveryAssignmentRequest{}, Response: models.DeliveryAssignment{}},
+
+	"ComplianceHandler.GetCertifications":    {Response: []models.Certification{}},
+	"ComplianceHandler.GetCertification":     {Response: models.Certification{}},
+	"ComplianceHandler.CreateCertification":  {Request: models.Certification{}, Response: models.Certification{}},
+	"ComplianceHandler.UpdateCertification":  {Request: models.Certification{}, Response: models.Certification{}},
+	"ComplianceHandler.GetSafetyStandards":   {Response: []models.SafetyStandard{}},
+	"ComplianceHandler.GetSafetyStandard":    {Response: models.SafetyStandard{}},
+	"ComplianceHandler.CreateSafetyStandard": {Request: models.SafetyStandard{}, Response: models.SafetyStandard{}},
+	"ComplianceHandler.UpdateSafetyStandard": {Request: models.SafetyStandard{}, Response: models.SafetyStandard{}},
+
+	"SupplierHandler.GetSuppliers":          {Response: []models.Supplier{}},
+	"SupplierHandler.GetSupplier":           {Response: models.Supplier{}},
+	"SupplierHandler.CreateSupplier":        {Request: models.Supplier{}, Response: models.Supplier{}},
+	"SupplierHandler.UpdateSupplier":        {Request: models.Supplier{}, Response: models.Supplier{}},
+	"SupplierHandler.GetSupplierPrices":     {Response: []models.SupplierPrice{}},
+	"SupplierHandler.SaveSupplierPrice":     {Request: models.SupplierProduct{}, Response: models.SupplierProduct{}},
+	"SupplierHandler.CompareSupplierPrices": {Query: []string{"need_by"}, Response: services.SupplierComparison{}},
+
+	"PurchaseOrderHandler.GetPurchaseOrders":         {Query: []string{"supplier_id", "status", "department", "budget_id", "fields"}, Response: []models.PurchaseOrder{}},
+	"PurchaseOrderHandler.GetPurchaseOrder":          {Response: models.PurchaseOrder{}},
+	"PurchaseOrderHandler.CreatePurchaseOrder":       {Request: PurchaseOrderRequest{}, Response: models.PurchaseOrder{}},
+	"PurchaseOrderHandler.GeneratePurchaseOrders":    {Request: GeneratePurchaseOrdersRequest{}, Response: services.GeneratePurchaseOrdersResult{}, Status: http.StatusCreated},
+	"PurchaseOrderHandler.UpdatePurchaseOrderStatus": {Request: PurchaseOrderStatusRequest{}, Response: models.PurchaseOrder{}},
+	"PurchaseOrderHandler.ApprovePurchaseOrder":      {Request: PurchaseOrderApprovalRequest{}, Response: models.PurchaseOrder{}},
+	"PurchaseOrderHandler.RejectPurchaseOrder":       {Request: PurchaseOrderApprovalRequest{}, Response: models.PurchaseOrder{}},
+	"PurchaseOrderHandler.AddPurchaseOrderCharge":    {Request: models.PurchaseOrderCharge{}, Response: models.PurchaseOrderCharge{}, Status: http.StatusCreated},
+	"PurchaseOrderHandler.GetLandedCost":             {Response: services.LandedCost{}},
+	"PurchaseOrderHandler.ReceivePurchaseOrder":      {Request: ReceivePurchaseOrderRequest{}, Response: services.LandedCost{}},
+	"PurchaseOrderHandler.UpdateCostPolicy":          {Request: CostPolicyRequest{}},
+
+	"PurchaseBudgetHandler.GetBudgets":   {Query: []string{"department", "on", "fields"}, Response: []models.BudgetUsage{}},
+	"PurchaseBudgetHandler.GetBudget":    {Response: models.BudgetUsage{}},
+	"PurchaseBudgetHandler.CreateBudget": {Request: PurchaseBudgetRequest{}, Response: models.BudgetUsage{}},
+	"PurchaseBudgetHandler.UpdateBudget": {Request: PurchaseBudgetRequest{}, Response: models.BudgetUsage{}},
+
+	"SupplierInvoiceHandler.GetSupplierInvoices":           {Query: []string{"supplier_id", "purchase_order_id", "match_status", "fields"}, Response: []models.SupplierInvoice{}},
+	"SupplierInvoiceHandler.GetSupplierInvoice":            {Response: models.SupplierInvoice{}},
+	"SupplierInvoiceHandler.CreateSupplierInvoice":         {Request: SupplierInvoiceRequest{}, Response: models.SupplierInvoice{}},
+	"SupplierInvoiceHandler.CreateSupplierInvoiceFromScan": {Request: SupplierInvoiceRequest{}, Response: models.SupplierInvoice{}, Status: http.StatusCreated},
+	"SupplierInvoiceHandler.MatchSupplierInvoice":          {Response: models.SupplierInvoice{}},
+	"SupplierInvoiceHandler.GetDiscrepancyReport":          {Query: []string{"from", "to", "supplier_id", "kind", "fields"}, Response: []models.DiscrepancyReportRow{}},
+	"SupplierInvoiceHandler.ExportDiscrepancyReportCSV":    {Query: []string{"from", "to", "supplier_id", "kind"}},
+
+	"LoyaltyHandler.GetTiers": {Response: []models.LoyaltyTier{}},
+
+	"PaymentHandler.GetPaymentMethods":    {Query: []string{"active"}, Response: []models.PaymentMethod{}},
+	"PaymentHandler.CreatePaymentMethod":  {Request: models.PaymentMethod{}, Response: models.PaymentMethod{}},
+	"PaymentHandler.UpdatePaymentMethod":  {Request: models.PaymentMethod{}, Response: models.PaymentMethod{}},
+	"PaymentHandler.RecordPayment":        {Request: models.Payment{}},
+	"PaymentHandler.RecordInvoicePayment": {Request: models.Payment{}},
+	"PaymentHandler.GetPayments":          {Query: []string{"payment_method", "from", "to"}, Response: []models.PaymentRecord{}},
+	"PaymentHandler.GetCustomerPayments":  {Query: []string{"payment_method", "from", "to"}, Response: []models.PaymentRecord{}},
+	"PaymentHandler.ExportPaymentsCSV":    {Query: []string{"payment_method", "from", "to"}},
+
+	"InvoiceHandler.GetInvoices":        {Query: []string{"fields"}, Paged: true, List: &repository.InvoiceListColumns, Response: []models.Invoice{}},
+	"InvoiceHandler.GetInvoice":         {Response: models.Invoice{}},
+	"InvoiceHandler.GetOrderInvoice":    {Response: models.Invoice{}},
+	"InvoiceHandler.CreateOrderInvoice": {Response: models.Invoice{}, Status: http.StatusCreated},
+
+	"RuleHandler.GetRules":   {Response: []models.BusinessRule{}},
+	"RuleHandler.GetRule":    {Response: models.BusinessRule{}},
+	"RuleHandler.UpdateRule": {Request: RuleRequest{}, Response: models.BusinessRule{}},
+	"RuleHandler.ResetRule":  {Response: models.BusinessRule{}},
+
+	"TemplateHandler.GetTemplates":      {Response: []services.DocumentTemplate{}},
+	"TemplateHandler.GetTemplate":       {Response: services.DocumentTemplate{}},
+	"TemplateHandler.UpdateTemplate":    {Request: TemplateRequest{}, Response: services.DocumentTemplate{}},
+	"CurrencyHandler.GetCurrencies":     {Response: []models.Currency{}},
+	"CurrencyHandler.GetRates":          {Response: []models.ExchangeRate{}},
+	"CurrencyHandler.SetRate":           {Request: ExchangeRateRequest{}, Response: models.ExchangeRate{}},
+	"DiscountHandler.GetQuantityBreaks": {Response: []models.QuantityBreak{}},
+	"DiscountHandler.SetQuantityBreaks": {Request: QuantityBreaksRequest{}, Response: []models.QuantityBreak{}},
+
+	"PostDatedCheckHandler.GetChecks":         {Query: []string{"customer_id", "order_id", "status", "from", "to"}, Response: []models.PostDatedCheck{}},
+	"PostDatedCheckHandler.GetMaturingChecks": {Query: []string{"days"}, Response: []models.PostDatedCheck{}},
+	"PostDatedCheckHandler.GetCheck":          {Response: models.PostDatedCheck{}},
+	"PostDatedCheckHandler.CreateCheck":       {Request: CheckRequest{}, Response: models.PostDatedCheck{}},
+	"PostDatedCheckHandler.UpdateCheck":       {Request: CheckRequest{}, Response: models.PostDatedCheck{}},
+	"PostDatedCheckHandler.UpdateCheckStatus": {Request: CheckStatusRequest{}, Response: models.PostDatedCheck{}},
+
+	"ReportHandler.GetDashboardSummary":           {Query: []string{"days", "refresh"}, Response: models.DashboardSummary{}},
+	"ReportHandler.GetSalesTrends":                {Query: []string{"days"}, Response: []models.SalesTrend{}},
+	"ReportHandler.GetTopCustomers":               {Query: []string{"limit"}, Response: []models.TopCustomer{}},
+	"ReportHandler.GetSalesByChannel":             {Response: []models.SalesByChannel{}},
+	"ReportHandler.GetSalesByPaymentMethod":       {Query: []string{"days"}, Response: []models.SalesByPaymentMethod{}},
+	"ReportHandler.ExportSalesByPaymentMethodCSV": {Query: []string{"days"}},
+	"ReportHandler.GetRevenueByIndustry":          {Response: []models.IndustryRevenue{}},
+	"ReportHandler.GetLowStockItems":              {Response: []models.LowStockItem{}},
+
+	"UserHandler.GetUsers":    {Query: []string{"fields"}, Response: []models.User{}},
+	"UserHandler.GetUser":     {Response: models.User{}},
+	"UserHandler.SearchUsers": {Query: []string{"q"}, Response: []models.User{}},
+	"UserHandler.Register":    {Request: models.User{}, Response: models.User{}},
+	"UserHandler.UpdateUser":  {Request: models.User{}, Response: models.User{}},
+
+	"NotificationHandler.GetNotifications":         {Query: []string{"unread"}, Paged: true, Response: []models.Notification{}},
+	"NotificationHandler.GetNotificationSummary":   {Response: NotificationSummary{}},
+	"NotificationHandler.MarkNotificationRead":     {Response: models.Notification{}},
+	"NotificationHandler.MarkAllNotificationsRead": {Response: MarkAllReadResponse{}},
+
+	"PaymentHandler.ReceivePaymentEvent": {Response: PaymentEventResponse{}},
+
+	"TrashHandler.GetTrash": {Query: []string{"type"}, Paged: true, Response: []models.TrashItem{}},
+
+	"WatchHandler.GetWatches":       {Response: []models.RecordWatch{}},
+	"WatchHandler.WatchCustomer":    {Request: WatchRequest{}, Response: models.RecordWatch{}},
+	"WatchHandler.UnwatchCustomer":  {Status: http.StatusNoContent},
+	"WatchHandler.WatchQuotation":   {Request: WatchRequest{}, Response: models.RecordWatch{}},
+	"WatchHandler.UnwatchQuotation": {Status: http.StatusNoContent},
+
+	"WebhookHandler.GetWebhookEndpoints":   {Response: []models.WebhookEndpoint{}},
+	"WebhookHandler.GetWebhookEndpoint":    {Response: models.WebhookEndpoint{}},
+	"WebhookHandler.CreateWebhookEndpoint": {Request: WebhookEndpointRequest{}, Response: WebhookEndpointCreated{}, Status: http.StatusCreated},
+	"WebhookHandler.UpdateWebhookEndpoint": {Request: WebhookEndpointRequest{}, Response: models.WebhookEndpoint{}},
+	"WebhookHandler.DeleteWebhookEndpoint": {Status: http.StatusNoContent},
+	"WebhookHandler.GetWebhookDeliveries":  {Paged: true, Response: []models.WebhookDelivery{}},
+	"WebhookHandler.RedeliverWebhook":      {Response: models.WebhookDelivery{}, Status: http.StatusCreated},
+
+	"ReportScheduleHandler.GetReportSchedules":   {Response: []models.ReportSchedule{}},
+	"ReportScheduleHandler.GetReportSchedule":    {Response: models.ReportSchedule{}},
+	"ReportScheduleHandler.CreateReportSchedule": {Request: ReportScheduleRequest{}, Response: models.ReportSchedule{}, Status: http.StatusCreated},
+	"ReportScheduleHandler.UpdateReportSchedule": {Request: ReportScheduleRequest{}, Response: models.ReportSchedule{}},
+	"ReportScheduleHandler.DeleteReportSchedule": {Status: http.StatusNoContent},
+	"ReportScheduleHandler.SendReportSchedule":   {Response: models.ReportSchedule{}},
+
+	"JobHandler.GetJobs":   {Query: []string{"status"}, Paged: true, Response: []models.Job{}},
+	"JobHandler.GetJob":    {Response: models.Job{}},
+	"JobHandler.RetryJob":  {Response: models.Job{}},
+	"JobHandler.DeleteJob": {Status: http.StatusNoContent},
+
+	"QuotationSLAHandler.GetFollowUpQueue":            {Query: []string{"sla_status", "fields"}, Response: []models.QuotationSLA{}},
+	"QuotationSLAHandler.GetQuotationSLAReport":       {Query: []string{"days"}, Response: models.QuotationSLAReport{}},
+	"QuotationSLAHandler.ExportQuotationSLAReportCSV": {Query: []string{"days"}},
+
+	"AnomalyHandler.GetAnomalies": {Query: []string{"metric"}, Paged: true, Response: []models.Anomaly{}},
+
+	"QuoteRequestHandler.SubmitQuoteRequest":       {Response: QuoteRequestReceipt{}, Status: http.StatusCreated},
+	"QuoteRequestHandler.GetQuoteRequests":         {Query: []string{"status"}, Paged: true, Response: []models.QuoteRequest{}},
+	"QuoteRequestHandler.GetQuoteRequest":          {Response: models.QuoteRequest{}},
+	"QuoteRequestHandler.UpdateQuoteRequestStatus": {Request: QuoteRequestStatusRequest{}, Response: models.QuoteRequest{}},
+
+	"DownloadLinkHandler.Download":           {Query: []string{"token"}},
+	"DownloadLinkHandler.GetDownloadLinks":   {Query: []string{"document_type", "document_id"}, Response: []models.DownloadLink{}},
+	"DownloadLinkHandler.CreateDownloadLink": {Request: DownloadLinkRequest{}, Response: DownloadLinkResponse{}, Status: http.StatusCreated},
+	"DownloadLinkHandler.RevokeDownloadLink": {Response: models.DownloadLink{}},
+
+	"PreviewHandler.GetPreview": {Response: models.DocumentPreview{}, Status: http.StatusAccepted},
+
+	"DocumentScanHandler.GetDocumentScans":    {Query: []string{"kind", "status"}, Paged: true, Response: []models.DocumentScan{}},
+	"DocumentScanHandler.UploadDocumentScan":  {Response: models.DocumentScan{}, Status: http.StatusAccepted},
+	"DocumentScanHandler.GetDocumentScan":     {Response: models.DocumentScan{}},
+	"DocumentScanHandler.UpdateDocumentScan":  {Request: DocumentScanDraft{}, Response: models.DocumentScan{}},
+	"DocumentScanHandler.DiscardDocumentScan": {Response: models.DocumentScan{}},
+
+	"AuditHandler.GetAuditLogs":        {Query: []string{"user_id", "entity", "entity_id", "action", "impersonated", "limit"}, Response: []models.AuditLog{}},
+	"AuditHandler.GetCustomerChanges":  {Paged: true, Response: []services.RecordChange{}},
+	"AuditHandler.GetProductChanges":   {Paged: true, Response: []services.RecordChange{}},
+	"AuditHandler.GetQuotationChanges": {Paged: true, Response: []services.RecordChange{}},
+
+	"PrintHandler.CreatePrintJob":  {Request: PrintJobRequest{}, Response: models.PrintJob{}, Status: http.StatusAccepted},
+	"PrintHandler.GetPrintJobs":    {Response: []models.PrintJob{}},
+	"PrintHandler.GetPrintJobByID": {Response: models.PrintJob{}},
+
+	"SyncHandler.GetChanges": {Response: models.SyncChanges{}},
+	"SyncHandler.PushBatch":  {Request: models.SyncBatchRequest{}},
+}
@@ -1,111 +1,228 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
 	"github.com/Cezzyy/SCMS/backend/internal/models"
 	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
 	"github.com/labstack/echo/v4"
 )
 
 // OrderHandler handles HTTP requests for orders
 type OrderHandler struct {
-	orderRepo *repository.OrderRepository
+	orderRepo        *repository.OrderRepository
+	customerRepo     *repository.CustomerRepository
+	productRepo      *repository.ProductRepository
+	ruleRepo         *repository.CustomerProductRuleRepository
+	chatNotifier     *services.ChatNotifier
+	pricingService   *services.PricingService
+	auditRepo        *repository.AuditRepository
+	pdfGenerator     *services.PDFGenerator
+	shiftRepo        *repository.ShiftRepository
+	paymentRepo      *repository.PaymentRepository
+	currencies       *services.CurrencyService
+	notificationRepo *repository.NotificationRepository
+	webhooks         *services.WebhookService
+	scanRepo         *repository.DocumentScanRepository
 }
 
-// NewOrderHandler creates a new order handler with the provided repository
-func NewOrderHandler(orderRepo *repository.OrderRepository) *OrderHandler {
+// NewOrderHandler creates a new order handler with the provided repositories
+func NewOrderHandler(
+	orderRepo *repository.OrderRepository,
+	customerRepo *repository.CustomerRepository,
+	productRepo *repository.ProductRepository,
+	ruleRepo *repository.CustomerProductRuleRepository,
+	chatNotifier *services.ChatNotifier,
+	pricingService *services.PricingService,
+	auditRepo *repository.AuditRepository,
+	pdfGenerator *services.PDFGenerator,
+	shiftRepo *repository.ShiftRepository,
+	paymentRepo *repository.PaymentRepository,
+	currencies *services.CurrencyService,
+	notificationRepo *repository.NotificationRepository,
+	webhooks *services.WebhookService,
+	scanRepo *repository.DocumentScanRepository,
+) *OrderHandler {
 	return &OrderHandler{
-		orderRepo: orderRepo,
+		orderRepo:        orderRepo,
+		customerRepo:     customerRepo,
+		productRepo:      productRepo,
+		ruleRepo:         ruleRepo,
+		chatNotifier:     chatNotifier,
+		pricingService:   pricingService,
+		auditRepo:        auditRepo,
+		pdfGenerator:     pdfGenerator,
+		shiftRepo:        shiftRepo,
+		paymentRepo:      paymentRepo,
+		currencies:       currencies,
+		notificationRepo: notificationRepo,
+		webhooks:         webhooks,
+		scanRepo:         scanRepo,
 	}
 }
 
-// GetAllOrders returns all orders
+// GetAllOrders returns all orders, or one page of them when ?page= and ?page_size=
+// (or ?limit= and ?offset=) are given; see parsePage. Orders can be filtered and sorted
+// with parameters such as ?status=Pending&sort=order_date:desc; see repository.ParseListQuery.
 func (h *OrderHandler) GetAllOrders(c echo.Context) error {
 	ctx := c.Request().Context()
 
-	orders, err := h.orderRepo.GetAll(ctx)
+	page, paged, message := parsePage(c)
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	list, err := repository.ParseListQuery(c.QueryParams(), repository.OrderListColumns)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve orders",
-		})
+		return models.NewAPIError(http.StatusBadRequest, err.Error())
 	}
 
-	return c.JSON(http.StatusOK, orders)
+	orders, total, err := h.orderRepo.GetAll(ctx, list, page)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve orders")
+	}
+
+	return jsonPage(c, http.StatusOK, orders, page, paged, total)
 }
 
-// GetOrderByID returns an order by ID
+// GetOrderByID returns an order by ID with its items, shipment totals and payments; the
+// order carries what has been paid and the balance left
 func (h *OrderHandler) GetOrderByID(c echo.Context) error {
 	ctx := c.Request().Context()
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid order ID",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid order ID")
 	}
 
 	order, err := h.orderRepo.GetByID(ctx, id)
 	if err != nil {
-		if err.Error() == "order not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Order not found",
-			})
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Order not found")
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve order",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve order")
 	}
 
 	// Get order items
 	items, err := h.orderRepo.GetOrderItems(ctx, id)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve order items",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve order items")
 	}
 
-	// Return order with items
+	shipment, err := h.orderRepo.GetShipmentTotals(ctx, id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to calculate shipment totals")
+	}
+
+	payments, err := h.paymentRepo.GetByOrder(ctx, id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve payments")
+	}
+
+	// Return order with items and the payments making up its balance
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"order": order,
-		"items": items,
+		"order":    order,
+		"items":    items,
+		"shipment": shipment,
+		"payments": payments,
 	})
 }
 
 // CreateOrderRequest represents the structure of the JSON payload for creating orders
 type CreateOrderRequest struct {
 	Order     models.Order       `json:"order"`
-	Items     []models.OrderItem `json:"items"`
+	Items     []models.OrderItem `json:"items" validate:"min=1,dive"`
 	Quotation *struct {
 		QuotationID int `json:"quotation_id"`
 	} `json:"quotation,omitempty"`
 }
 
-// CreateOrder creates a new order with items
+// CreateOrder creates a new Pending order with items
 func (h *OrderHandler) CreateOrder(c echo.Context) error {
-	ctx := c.Request().Context()
-
 	// Define a struct to receive the order data with items
 	var orderData CreateOrderRequest
 
 	if err := c.Bind(&orderData); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request payload: " + err.Error(),
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload: "+err.Error())
 	}
 
-	// Validate required fields
-	if orderData.Order.CustomerID == 0 {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Customer ID is required",
-		})
+	if err := c.Validate(&orderData); err != nil {
+		return validationError(err)
+	}
+
+	created, err := h.createOrder(c, &orderData)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, created)
+}
+
+// CreateOrderFromScan confirms a scanned customer purchase order in review as an order,
+// priced and checked as CreateOrder does. The order's customer and items are taken from the
+// scan's draft when the request leaves them out; each line of the draft needs a product.
+func (h *OrderHandler) CreateOrderFromScan(c echo.Context) error {
+	scan, err := scanInReview(c, h.scanRepo, models.ScanCustomerPO)
+	if err != nil {
+		return err
 	}
 
+	var orderData CreateOrderRequest
+	if err := c.Bind(&orderData); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload: "+err.Error())
+	}
+	if orderData.Order.CustomerID == 0 && scan.CustomerID != nil {
+		orderData.Order.CustomerID = *scan.CustomerID
+	}
+	if orderData.Order.OrderDate.IsZero() {
+		orderData.Order.OrderDate = time.Now()
+	}
 	if len(orderData.Items) == 0 {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Order must have at least one item",
-		})
+		for _, line := range scan.Lines {
+			if line.ProductID == nil {
+				return models.NewAPIError(http.StatusBadRequest, "Choose the product of every line: \""+line.Description+"\" has none")
+			}
+			orderData.Items = append(orderData.Items, models.OrderItem{
+				ProductID: *line.ProductID,
+				Quantity:  line.Quantity,
+				UnitPrice: line.UnitPrice,
+			})
+		}
+	}
+	if err := c.Validate(&orderData); err != nil {
+		return validationError(err)
+	}
+
+	created, err := h.createOrder(c, &orderData)
+	if err != nil {
+		return err
+	}
+	confirmScan(c, h.scanRepo, h.auditRepo, scan, orderData.Order.OrderID)
+
+	return c.JSON(http.StatusCreated, created)
+}
+
+// createOrder prices and saves a validated order request, returning the created order with
+// its items and pricing, or the error response when it cannot be created
+func (h *OrderHandler) createOrder(c echo.Context, orderData *CreateOrderRequest) (map[string]interface{}, error) {
+	ctx := c.Request().Context()
+
+	// Orders start Pending whatever the request says, so their stock is issued when
+	// UpdateStatus ships them; only CreateCashSale creates orders already delivered
+	orderData.Order.Status = "Pending"
+	orderData.Order.DeliveredAt = nil
+
+	if err := checkNewOrder(ctx, h.customerRepo, h.productRepo, h.ruleRepo, &orderData.Order, orderData.Items); err != nil {
+		return nil, err
 	}
 
 	// If the request includes a quotation reference, set the quotation ID in the order
@@ -114,25 +231,416 @@ func (h *OrderHandler) CreateOrder(c echo.Context) error {
 		orderData.Order.QuotationID = &quotationID
 	}
 
+	// Apply the customer's tier discount and delivery charge and calculate the total
+	pricing, err := h.pricingService.PriceOrder(ctx, &orderData.Order, orderData.Items)
+	if err != nil {
+		if apiErr := discountLimitError(err); apiErr != nil {
+			return nil, apiErr
+		}
+		if apiErr := currencyError(err); apiErr != nil {
+			return nil, apiErr
+		}
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, models.NewAPIError(http.StatusBadRequest, "Customer not found")
+		}
+		return nil, models.NewAPIError(http.StatusInternalServerError, "Failed to price order")
+	}
+
 	// Create the order with items in a single transaction
-	err := h.orderRepo.CreateOrderWithItems(ctx, &orderData.Order, orderData.Items)
+	err = h.orderRepo.CreateOrderWithItems(ctx, &orderData.Order, orderData.Items)
 	if err != nil {
 		if err == repository.ErrDuplicateKey {
-			return c.JSON(http.StatusConflict, map[string]string{
-				"error": "An order with this information already exists",
-			})
+			return nil, models.NewAPIError(http.StatusConflict, "An order with this information already exists")
 		}
 
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to create order: " + err.Error(),
-		})
+		return nil, models.NewAPIError(http.StatusInternalServerError, "Failed to create order: "+err.Error())
 	}
 
-	// Return the created order with items
-	return c.JSON(http.StatusCreated, map[string]interface{}{
+	// Announce large orders in the team chat
+	customerName := "Customer #" + strconv.Itoa(orderData.Order.CustomerID)
+	if customer, err := h.customerRepo.GetByID(ctx, orderData.Order.CustomerID); err == nil {
+		customerName = customer.CompanyName
+	}
+	h.chatNotifier.NotifyOrderCreated(orderData.Order.OrderID, customerName, services.ToBase(orderData.Order.TotalAmount, orderData.Order.ExchangeRate))
+	h.webhooks.Publish(ctx, models.WebhookOrderCreated, map[string]interface{}{
 		"order": orderData.Order,
 		"items": orderData.Items,
 	})
+
+	recordAudit(c, h.auditRepo, models.AuditCreate, models.AuditEntityOrder, orderData.Order.OrderID, nil, map[string]interface{}{
+		"order": orderData.Order,
+		"items": orderData.Items,
+	})
+
+	// Return the created order with items
+	return map[string]interface{}{
+		"order":   orderData.Order,
+		"items":   orderData.Items,
+		"pricing": pricing,
+	}, nil
+}
+
+// CashSaleRequest is a counter sale. Without a customer_id the sale is booked to the
+// walk-in customer; unit prices default to the product's list price and amount_paid to
+// the order total. The payment method defaults to cash; methods that require a reference
+// need reference_no.
+type CashSaleRequest struct {
+	CustomerID    *int               `json:"customer_id,omitempty"`
+	Items         []models.OrderItem `json:"items" validate:"min=1,dive"`
+	PaymentMethod string             `json:"payment_method"`
+	ReferenceNo   *string            `json:"reference_no,omitempty"`
+	AmountPaid    *float64           `json:"amount_paid,omitempty" validate:"omitnil,gte=0"`
+}
+
+// CreateCashSale records a counter sale in one call: the order is created paid and
+// delivered, and the goods are taken out of stock. The receipt is at /api/orders/:id/receipt.
+// A sale made while the cashier has a shift open is counted in that shift's report.
+func (h *OrderHandler) CreateCashSale(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req CashSaleRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload: "+err.Error())
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+	if req.PaymentMethod == "" {
+		req.PaymentMethod = models.PaymentCash
+	}
+	req.ReferenceNo = trimReference(req.ReferenceNo)
+
+	message, err := checkPaymentMethod(ctx, h.paymentRepo, req.PaymentMethod, req.ReferenceNo)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to validate payment method")
+	}
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	var customer models.Customer
+	if req.CustomerID != nil {
+		customer, err = h.customerRepo.GetByID(ctx, *req.CustomerID)
+	} else {
+		customer, err = h.customerRepo.GetWalkIn(ctx)
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusBadRequest, "Customer not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve customer")
+	}
+	if customer.ArchivedAt != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Customer is archived")
+	}
+
+	productIDs := make([]int, len(req.Items))
+	for i := range req.Items {
+		item := &req.Items[i]
+		if item.UnitPrice == 0 {
+			product, err := h.productRepo.GetByID(ctx, item.ProductID)
+			if err != nil {
+				if errors.Is(err, repository.ErrNotFound) {
+					return models.NewAPIError(http.StatusBadRequest, "Product "+strconv.Itoa(item.ProductID)+" not found")
+				}
+				return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve product")
+			}
+			item.UnitPrice = product.Price
+		}
+		productIDs[i] = item.ProductID
+	}
+
+	message, err = checkProductsAvailable(ctx, h.productRepo, productIDs)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to validate products")
+	}
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	restrictions, err := h.ruleRepo.CheckProducts(ctx, customer.CustomerID, productIDs)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to validate products")
+	}
+	if len(restrictions) > 0 {
+		return models.NewAPIError(http.StatusBadRequest, "Some products cannot be sold to this customer").WithDetails(map[string]interface{}{
+			"restricted_products": restrictions,
+		})
+	}
+
+	// Counter sales are collected, so pricing adds no delivery charge
+	source := models.SourceWalkIn
+	order := models.Order{
+		CustomerID:    customer.CustomerID,
+		Source:        &source,
+		PaymentMethod: &req.PaymentMethod,
+	}
+	pricing, err := h.pricingService.PriceOrder(ctx, &order, req.Items)
+	if err != nil {
+		if apiErr := discountLimitError(err); apiErr != nil {
+			return apiErr
+		}
+		if apiErr := currencyError(err); apiErr != nil {
+			return apiErr
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to price sale")
+	}
+
+	amountPaid := order.TotalAmount
+	if req.AmountPaid != nil {
+		amountPaid = *req.AmountPaid
+	}
+	if amountPaid < order.TotalAmount {
+		return models.NewAPIError(http.StatusBadRequest, "Amount paid is less than the sale total").WithDetails(map[string]interface{}{
+			"total": order.TotalAmount,
+		})
+	}
+	order.AmountPaid = &amountPaid
+
+	if user := appmw.UserFromContext(c); user != nil {
+		shift, err := h.shiftRepo.GetOpenForUser(ctx, user.UserID)
+		if err == nil {
+			order.ShiftID = &shift.ShiftID
+		} else if !errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve shift")
+		}
+	}
+
+	// The payment is what the sale came to; change handed back is not part of it
+	payment := models.Payment{
+		PaymentMethod: req.PaymentMethod,
+		Amount:        order.TotalAmount,
+		ReferenceNo:   req.ReferenceNo,
+	}
+	if user := appmw.UserFromContext(c); user != nil {
+		payment.ReceivedBy = &user.UserID
+	}
+
+	stockChanges, err := h.orderRepo.CreateCashSale(ctx, &order, req.Items, &payment)
+	if err != nil {
+		if apiErr := stockError(err); apiErr != nil {
+			return apiErr
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to record sale")
+	}
+	notifyStockChanges(c, h.productRepo, h.chatNotifier, h.notificationRepo, h.webhooks, stockChanges...)
+
+	h.chatNotifier.NotifyOrderCreated(order.OrderID, customer.CompanyName, services.ToBase(order.TotalAmount, order.ExchangeRate))
+	h.webhooks.Publish(ctx, models.WebhookOrderCreated, map[string]interface{}{
+		"order": order,
+		"items": req.Items,
+	})
+
+	recordAudit(c, h.auditRepo, models.AuditCreate, models.AuditEntityOrder, order.OrderID, nil, map[string]interface{}{
+		"order": order,
+		"items": req.Items,
+	})
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"order":       order,
+		"items":       req.Items,
+		"pricing":     pricing,
+		"change_due":  math.Round((amountPaid-order.TotalAmount)*100) / 100,
+		"receipt_url": "/api/orders/" + strconv.Itoa(order.OrderID) + "/receipt",
+	})
+}
+
+// orderReceipt loads the receipt of the order in the path and the requested paper width.
+// It returns handled=true when an error response has been written.
+func (h *OrderHandler) orderReceipt(c echo.Context) (services.Receipt, int, bool, error) {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return services.Receipt{}, 0, true, models.NewAPIError(http.StatusBadRequest, "Invalid order ID")
+	}
+
+	width, err := receiptPaperWidth(c)
+	if err != nil {
+		return services.Receipt{}, 0, true, models.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+
+	order, err := h.orderRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return services.Receipt{}, 0, true, models.NewAPIError(http.StatusNotFound, "Order not found")
+		}
+		return services.Receipt{}, 0, true, models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve order")
+	}
+
+	receipt, err := buildReceipt(ctx, h.orderRepo, h.customerRepo, h.productRepo, order)
+	if err != nil {
+		if err == errOrderNotPaid {
+			return services.Receipt{}, 0, true, models.NewAPIError(http.StatusConflict, "Order has not been paid")
+		}
+		log.Printf("Failed to build receipt for order %d: %v", id, err)
+		return services.Receipt{}, 0, true, models.NewAPIError(http.StatusInternalServerError, "Failed to build receipt")
+	}
+
+	return receipt, width, false, nil
+}
+
+// GetOrderReceiptPDF renders the receipt of a paid order as a PDF sized for a 58mm or 80mm
+// receipt printer (?width=, default 80)
+func (h *OrderHandler) GetOrderReceiptPDF(c echo.Context) error {
+	receipt, width, handled, err := h.orderReceipt(c)
+	if handled {
+		return err
+	}
+
+	templateData := map[string]interface{}{
+		"Receipt": receipt,
+		"Narrow":  width == services.ReceiptPaper58mm,
+	}
+
+	// Receipt paper is a continuous roll, so the page grows with the number of lines
+	content, err := h.pdfGenerator.GenerateFromTemplateWithOptions("receipt/template.html", "", templateData, services.PDFOptions{
+		PageWidth:  strconv.Itoa(width) + "mm",
+		PageHeight: strconv.Itoa(120+12*len(receipt.Lines)) + "mm",
+		Margin:     "3mm",
+	})
+	if err != nil {
+		log.Printf("Failed to render receipt for order %d: %v", receipt.OrderID, err)
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to render receipt")
+	}
+
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=receipt_%d.pdf", receipt.OrderID))
+	return c.Blob(http.StatusOK, "application/pdf", content)
+}
+
+// GetOrderReceiptText returns the receipt of a paid order as plain text wrapped to the line
+// width of a 58mm or 80mm receipt printer (?width=, default 80)
+func (h *OrderHandler) GetOrderReceiptText(c echo.Context) error {
+	receipt, width, handled, err := h.orderReceipt(c)
+	if handled {
+		return err
+	}
+
+	return c.Blob(http.StatusOK, echo.MIMETextPlainCharsetUTF8, receipt.Text(services.ReceiptColumns[width]))
+}
+
+// GetOrderReceiptESCPOS returns the receipt of a paid order as ESC/POS commands that can be
+// sent as-is to a 58mm or 80mm thermal printer (?width=, default 80)
+func (h *OrderHandler) GetOrderReceiptESCPOS(c echo.Context) error {
+	receipt, width, handled, err := h.orderReceipt(c)
+	if handled {
+		return err
+	}
+
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=receipt_%d.bin", receipt.OrderID))
+	return c.Blob(http.StatusOK, services.MimeTypeESCPOS, receipt.ESCPOS(services.ReceiptColumns[width]))
+}
+
+// orderDocumentLine is an order item with the product details printed on order documents
+type orderDocumentLine struct {
+	models.OrderItem
+	ProductName string
+	Model       string
+}
+
+// orderDocument loads the order in the path with its customer and items for an order
+// document template. It returns handled=true when an error response has been written.
+func (h *OrderHandler) orderDocument(c echo.Context) (models.Order, map[string]interface{}, bool, error) {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.Order{}, nil, true, models.NewAPIError(http.StatusBadRequest, "Invalid order ID")
+	}
+
+	order, err := h.orderRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return order, nil, true, models.NewAPIError(http.StatusNotFound, "Order not found")
+		}
+		return order, nil, true, models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve order")
+	}
+
+	customer, err := h.customerRepo.GetByIDWithDeleted(ctx, order.CustomerID)
+	if err != nil {
+		return order, nil, true, models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve customer information")
+	}
+
+	items, err := h.orderRepo.GetOrderItems(ctx, id)
+	if err != nil {
+		return order, nil, true, models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve order items")
+	}
+
+	lines := make([]orderDocumentLine, len(items))
+	itemCount := 0
+	for i, item := range items {
+		product, err := h.productRepo.GetByIDWithDeleted(ctx, item.ProductID)
+		if err != nil {
+			return order, nil, true, models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve product information")
+		}
+		lines[i] = orderDocumentLine{OrderItem: item, ProductName: product.ProductName}
+		if product.Model != nil {
+			lines[i].Model = *product.Model
+		}
+		itemCount += item.Quantity
+	}
+
+	shipment, err := h.orderRepo.GetShipmentTotals(ctx, id)
+	if err != nil {
+		return order, nil, true, models.NewAPIError(http.StatusInternalServerError, "Failed to calculate shipment totals")
+	}
+
+	currency, err := h.currencies.Currency(ctx, order.Currency)
+	if err != nil {
+		return order, nil, true, models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve currency")
+	}
+
+	return order, map[string]interface{}{
+		"Order":          order,
+		"Currency":       currency,
+		"Customer":       customer,
+		"Lines":          lines,
+		"ItemCount":      itemCount,
+		"Subtotal":       math.Round((order.TotalAmount-order.DeliveryFee)*100) / 100,
+		"Shipment":       shipment,
+		"GenerationDate": time.Now().Format("January 2, 2006"),
+	}, false, nil
+}
+
+// GetOrderPDF renders an order confirmation for the customer: the order's items with their
+// prices, the delivery fee and the total
+func (h *OrderHandler) GetOrderPDF(c echo.Context) error {
+	order, templateData, handled, err := h.orderDocument(c)
+	if handled {
+		return err
+	}
+
+	content, err := h.pdfGenerator.GenerateFromTemplate("order_confirmation/template.html", "", templateData)
+	if err != nil {
+		log.Printf("Failed to render order confirmation for order %d: %v", order.OrderID, err)
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to render order confirmation")
+	}
+
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=order_%d.pdf", order.OrderID))
+	return c.Blob(http.StatusOK, "application/pdf", content)
+}
+
+// GetOrderDeliveryNote renders the delivery note that travels with an order's goods: the
+// items and quantities without prices, the shipping address and space for the recipient
+// to sign
+func (h *OrderHandler) GetOrderDeliveryNote(c echo.Context) error {
+	order, templateData, handled, err := h.orderDocument(c)
+	if handled {
+		return err
+	}
+	if order.Status == "Cancelled" {
+		return models.NewAPIError(http.StatusConflict, "Order has been cancelled")
+	}
+
+	content, err := h.pdfGenerator.GenerateFromTemplate("delivery_note/template.html", "", templateData)
+	if err != nil {
+		log.Printf("Failed to render delivery note for order %d: %v", order.OrderID, err)
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to render delivery note")
+	}
+
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=delivery_note_%d.pdf", order.OrderID))
+	return c.Blob(http.StatusOK, "application/pdf", content)
 }
 
 // UpdateOrder updates an existing order
@@ -141,139 +649,275 @@ func (h *OrderHandler) UpdateOrder(c echo.Context) error {
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid order ID",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid order ID")
 	}
 
 	var order models.Order
 	if err := c.Bind(&order); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request payload",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
 	}
 
 	// Ensure ID in path matches ID in payload
 	order.OrderID = id
 
-	// Validate required fields
-	if order.CustomerID == 0 {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Customer ID is required",
-		})
+	if err := c.Validate(&order); err != nil {
+		return validationError(err)
+	}
+
+	if message := checkSource(order.Source); message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	before, err := h.orderRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Order not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve order")
+	}
+
+	// Split the edited total into its net amount and VAT again, in the currency and at the
+	// exchange rate the order was placed with
+	order.Currency = before.Currency
+	order.ExchangeRate = before.ExchangeRate
+	if err := h.pricingService.TaxOrder(ctx, &order); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusBadRequest, "Customer not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to calculate VAT")
 	}
 
 	err = h.orderRepo.Update(ctx, &order)
 	if err != nil {
-		if err.Error() == "order not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Order not found",
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Order not found")
+		}
+		if err == repository.ErrInvoiceIssued {
+			return models.NewAPIError(http.StatusConflict, "The order's sales invoice has been issued; void it or issue a credit note instead")
+		}
+		if err == repository.ErrStatusChange {
+			return models.NewAPIError(http.StatusConflict, "Change the order's status through /api/orders/:id/status").WithDetails(map[string]interface{}{
+				"status": before.Status,
 			})
 		}
 		if err == repository.ErrDuplicateKey {
-			return c.JSON(http.StatusConflict, map[string]string{
-				"error": "An order with this information already exists",
-			})
+			return models.NewAPIError(http.StatusConflict, "An order with this information already exists")
 		}
 
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to update order",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update order")
 	}
 
+	recordAudit(c, h.auditRepo, models.AuditUpdate, models.AuditEntityOrder, id, before, order)
+
 	return c.JSON(http.StatusOK, order)
 }
 
-// DeleteOrder deletes an order
+// DeleteOrder deletes an order. Its sales invoice number is recorded as voided, with the
+// reason given as ?reason=.
 func (h *OrderHandler) DeleteOrder(c echo.Context) error {
 	ctx := c.Request().Context()
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid order ID",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid order ID")
 	}
 
-	err = h.orderRepo.Delete(ctx, id)
+	before, err := h.orderRepo.GetByID(ctx, id)
 	if err != nil {
-		if err.Error() == "order not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Order not found",
-			})
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Order not found")
 		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve order")
+	}
 
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to delete order",
-		})
+	var deletedBy *int
+	if user := appmw.UserFromContext(c); user != nil {
+		deletedBy = &user.UserID
+	}
+	err = h.orderRepo.Delete(ctx, id, strings.TrimSpace(c.QueryParam("reason")), deletedBy)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Order not found")
+		}
+		if err == repository.ErrInvoiceIssued {
+			return models.NewAPIError(http.StatusConflict, "The order's sales invoice has been issued; void it instead")
+		}
+
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to delete order")
 	}
 
+	recordAudit(c, h.auditRepo, models.AuditDelete, models.AuditEntityOrder, id, before, nil)
+
 	return c.NoContent(http.StatusNoContent)
 }
 
-// StatusUpdate represents the status update request
+// StatusUpdate represents the status update request. Reason explains a cancellation and
+// is recorded with the voided sales invoice number.
 type StatusUpdate struct {
-	Status string `json:"status"`
+	Status string `json:"status" validate:"required"`
+	Reason string `json:"reason"`
 }
 
-// UpdateOrderStatus updates just the status of an order
+// UpdateOrderStatus updates just the status of an order. Shipping or delivering it takes its
+// items out of stock, and is refused with 409 Conflict when there is not enough.
 func (h *OrderHandler) UpdateOrderStatus(c echo.Context) error {
 	ctx := c.Request().Context()
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid order ID",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid order ID")
 	}
 
 	var statusUpdate StatusUpdate
 	if err := c.Bind(&statusUpdate); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request payload",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
 	}
 
-	// Validate required field
-	if statusUpdate.Status == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Status is required",
-		})
+	if err := c.Validate(&statusUpdate); err != nil {
+		return validationError(err)
 	}
 
 	// Validate status value
-	validStatuses := map[string]bool{
-		"Pending":   true,
-		"Shipped":   true,
-		"Delivered": true,
-		"Cancelled": true,
-	}
-	if !validStatuses[statusUpdate.Status] {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid status value. Must be one of: Pending, Shipped, Delivered, Cancelled",
-		})
+	if !containsString(models.OrderStatuses, statusUpdate.Status) {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid status value. Must be one of: "+strings.Join(models.OrderStatuses, ", "))
+	}
+
+	before, err := h.orderRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Order not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve order")
 	}
 
 	// Update the status
-	err = h.orderRepo.UpdateStatus(ctx, id, statusUpdate.Status)
+	var updatedBy *int
+	if user := appmw.UserFromContext(c); user != nil {
+		updatedBy = &user.UserID
+	}
+	stockChanges, err := h.orderRepo.UpdateStatus(ctx, id, statusUpdate.Status, strings.TrimSpace(statusUpdate.Reason), updatedBy)
 	if err != nil {
-		if err.Error() == "order not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Order not found",
-			})
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Order not found")
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to update order status: " + err.Error(),
-		})
+		if err == repository.ErrInvoiceIssued {
+			return models.NewAPIError(http.StatusConflict, "The order's sales invoice has been issued; void it instead of cancelling the order")
+		}
+		if err == repository.ErrStockIssued {
+			return models.NewAPIError(http.StatusConflict, "The order has shipped and its stock has been issued; it can no longer be cancelled")
+		}
+		if apiErr := stockError(err); apiErr != nil {
+			return apiErr
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update order status: "+err.Error())
 	}
 
 	// Return updated order
 	order, err := h.orderRepo.GetByID(ctx, id)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Order status updated but failed to retrieve updated order",
+		return models.NewAPIError(http.StatusInternalServerError, "Order status updated but failed to retrieve updated order")
+	}
+
+	recordAudit(c, h.auditRepo, "status_change", models.AuditEntityOrder, id, before, order)
+	notifyStockChanges(c, h.productRepo, h.chatNotifier, h.notificationRepo, h.webhooks, stockChanges...)
+	if order.Status != before.Status {
+		notifyUsers(c, h.notificationRepo, models.NotificationOrderStatus, models.AuditEntityOrder, id,
+			fmt.Sprintf("Order #%d is %s", id, order.Status),
+			fmt.Sprintf("Order #%d moved from %s to %s.", id, before.Status, order.Status))
+		h.webhooks.Publish(ctx, models.WebhookOrderStatusChanged, map[string]interface{}{
+			"order":           order,
+			"previous_status": before.Status,
 		})
 	}
 
 	return c.JSON(http.StatusOK, order)
 }
+
+// stockError describes an order that could not be taken out of stock, or returns nil for
+// other errors
+func stockError(err error) *models.APIError {
+	var stockErr *repository.InsufficientStockError
+	if errors.As(err, &stockErr) {
+		return models.NewAPIError(http.StatusConflict, "Not enough stock").WithCode(models.CodeInsufficientStock).WithDetails(map[string]interface{}{
+			"product_id": stockErr.ProductID,
+			"available":  stockErr.Available,
+			"requested":  stockErr.Requested,
+		})
+	}
+	var missingErr *repository.MissingInventoryError
+	if errors.As(err, &missingErr) {
+		return models.NewAPIError(http.StatusConflict, "Product "+strconv.Itoa(missingErr.ProductID)+" has no inventory record")
+	}
+	return nil
+}
+
+// GetOrderSources returns the sales channels an order or quotation can be recorded under
+func (h *OrderHandler) GetOrderSources(c echo.Context) error {
+	return c.JSON(http.StatusOK, models.OrderSources)
+}
+
+// checkNewOrder checks that an order can be placed with its items: its source is known, its
+// customer is not archived, and every product is still sold and may be sold to the customer.
+// The error returned is the response to send.
+func checkNewOrder(
+	ctx context.Context,
+	customerRepo *repository.CustomerRepository,
+	productRepo *repository.ProductRepository,
+	ruleRepo *repository.CustomerProductRuleRepository,
+	order *models.Order,
+	items []models.OrderItem,
+) error {
+	if message := checkSource(order.Source); message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	// Archived customers keep their history but cannot place new orders
+	if customer, err := customerRepo.GetByID(ctx, order.CustomerID); err == nil && customer.ArchivedAt != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Customer is archived")
+	}
+
+	productIDs := make([]int, len(items))
+	for i, item := range items {
+		productIDs[i] = item.ProductID
+	}
+	message, err := checkProductsAvailable(ctx, productRepo, productIDs)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to validate products")
+	}
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	restrictions, err := ruleRepo.CheckProducts(ctx, order.CustomerID, productIDs)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to validate products")
+	}
+	if len(restrictions) > 0 {
+		return models.NewAPIError(http.StatusBadRequest, "Some products cannot be sold to this customer").WithDetails(map[string]interface{}{
+			"restricted_products": restrictions,
+		})
+	}
+	return nil
+}
+
+// checkSource returns a validation message when a sales channel is set but not recognised
+func checkSource(source *string) string {
+	if source == nil || models.IsValidOrderSource(*source) {
+		return ""
+	}
+	values := make([]string, len(models.OrderSources))
+	for i, s := range models.OrderSources {
+		values[i] = s.Value
+	}
+	return "Source must be one of: " + strings.Join(values, ", ")
+}
+
+// containsString reports whether a list contains a value
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// Pagination describes the window of a paginated list response
+type Pagination struct {
+	Page       int  `json:"page"`
+	PageSize   int  `json:"page_size"`
+	Offset     int  `json:"offset"`
+	Total      int  `json:"total"`
+	TotalPages int  `json:"total_pages"`
+	HasMore    bool `json:"has_more"`
+}
+
+// parsePage reads ?page= and ?page_size= (1-based), or ?limit= and ?offset=, into a page
+// window. paged is false when none of them are given, in which case the whole list is
+// returned as before. Returns a message when a parameter is invalid.
+func parsePage(c echo.Context) (page repository.Page, paged bool, message string) {
+	values := map[string]int{}
+	for _, param := range []string{"page", "page_size", "limit", "offset"} {
+		value := c.QueryParam(param)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return page, false, param + " must be a non-negative integer"
+		}
+		values[param] = n
+	}
+	if len(values) == 0 {
+		return page, false, ""
+	}
+
+	_, hasPage := values["page"]
+	_, hasPageSize := values["page_size"]
+	_, hasLimit := values["limit"]
+	_, hasOffset := values["offset"]
+	if (hasPage || hasPageSize) && (hasLimit || hasOffset) {
+		return page, false, "Use either page and page_size or limit and offset, not both"
+	}
+
+	if hasLimit || hasOffset {
+		page.Limit = defaultPageSize
+		if hasLimit {
+			page.Limit = values["limit"]
+		}
+		page.Offset = values["offset"]
+	} else {
+		page.Limit = defaultPageSize
+		if hasPageSize {
+			page.Limit = values["page_size"]
+		}
+		number := 1
+		if hasPage {
+			number = values["page"]
+		}
+		if number < 1 {
+			return page, false, "page must be 1 or greater"
+		}
+		page.Offset = (number - 1) * page.Limit
+	}
+
+	if page.Limit < 1 || page.Limit > maxPageSize {
+		return page, false, "page_size and limit must be between 1 and " + strconv.Itoa(maxPageSize)
+	}
+	return page, true, ""
+}
+
+// jsonPage writes a list response. Unpaged requests get the plain list as from jsonList;
+// paged requests get {"data": [...], "pagination": {...}} with the total matching rows.
+func jsonPage(c echo.Context, code int, items interface{}, page repository.Page, paged bool, total int) error {
+	if !paged {
+		return jsonList(c, code, items)
+	}
+
+	list, status, message := selectFields(c, items)
+	if message != "" {
+		return models.NewAPIError(status, message)
+	}
+
+	return c.JSON(code, map[string]interface{}{
+		"data": list,
+		"pagination": Pagination{
+			Page:       page.Offset/page.Limit + 1,
+			PageSize:   page.Limit,
+			Offset:     page.Offset,
+			Total:      total,
+			TotalPages: (total + page.Limit - 1) / page.Limit,
+			HasMore:    page.Offset+page.Limit < total,
+		},
+	})
+}
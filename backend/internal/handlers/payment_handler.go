@@ -0,0 +1,657 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// paymentMethodCode is the form of payment method codes, e.g. "bank_transfer"
+var paymentMethodCode = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// atcCode is the form of BIR alphanumeric tax codes, e.g. "WC158"
+var atcCode = regexp.MustCompile(`^[A-Z]{2}[0-9]{3}$`)
+
+// Form2307Request records the BIR Form 2307 received for a payment's withheld tax
+type Form2307Request struct {
+	ReceivedOn string `json:"received_on" validate:"omitempty,datetime=2006-01-02"`
+	Reference  string `json:"reference"`
+}
+
+// PaymentHandler handles HTTP requests for payment methods and the payments received on
+// orders and their invoices
+type PaymentHandler struct {
+	paymentRepo    *repository.PaymentRepository
+	orderRepo      *repository.OrderRepository
+	adjustmentRepo *repository.InvoiceAdjustmentRepository
+	invoiceRepo    *repository.InvoiceRepository
+	customerRepo   *repository.CustomerRepository
+	gateways       *services.PaymentGateways
+}
+
+// NewPaymentHandler creates a new payment handler with the provided repositories
+func NewPaymentHandler(
+	paymentRepo *repository.PaymentRepository,
+	orderRepo *repository.OrderRepository,
+	adjustmentRepo *repository.InvoiceAdjustmentRepository,
+	invoiceRepo *repository.InvoiceRepository,
+	customerRepo *repository.CustomerRepository,
+	gateways *services.PaymentGateways,
+) *PaymentHandler {
+	return &PaymentHandler{
+		paymentRepo:    paymentRepo,
+		orderRepo:      orderRepo,
+		adjustmentRepo: adjustmentRepo,
+		invoiceRepo:    invoiceRepo,
+		customerRepo:   customerRepo,
+		gateways:       gateways,
+	}
+}
+
+// GetPaymentMethods returns the payment methods in display order; ?active=true leaves out
+// retired ones for pickers
+func (h *PaymentHandler) GetPaymentMethods(c echo.Context) error {
+	methods, err := h.paymentRepo.GetMethods(c.Request().Context(), c.QueryParam("active") == "true")
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve payment methods")
+	}
+
+	return jsonList(c, http.StatusOK, methods)
+}
+
+// CreatePaymentMethod adds a payment method
+func (h *PaymentHandler) CreatePaymentMethod(c echo.Context) error {
+	var method models.PaymentMethod
+	if err := c.Bind(&method); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(&method); err != nil {
+		return validationError(err)
+	}
+	method.Code = strings.ToLower(strings.TrimSpace(method.Code))
+	method.Name = strings.TrimSpace(method.Name)
+	if !paymentMethodCode.MatchString(method.Code) {
+		return models.NewAPIError(http.StatusBadRequest, "Code must start with a letter and contain only lowercase letters, digits and underscores")
+	}
+
+	if err := h.paymentRepo.CreateMethod(c.Request().Context(), &method); err != nil {
+		if err == repository.ErrDuplicateKey {
+			return models.NewAPIError(http.StatusConflict, "A payment method with this code already exists")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to create payment method")
+	}
+
+	return c.JSON(http.StatusCreated, method)
+}
+
+// UpdatePaymentMethod changes a payment method's name, reference requirement, display order
+// and whether it can still be used
+func (h *PaymentHandler) UpdatePaymentMethod(c echo.Context) error {
+	var method models.PaymentMethod
+	if err := c.Bind(&method); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+	method.Code = c.Param("code")
+
+	if err := c.Validate(&method); err != nil {
+		return validationError(err)
+	}
+	method.Name = strings.TrimSpace(method.Name)
+
+	if err := h.paymentRepo.UpdateMethod(c.Request().Context(), &method); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Payment method not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update payment method")
+	}
+
+	return c.JSON(http.StatusOK, method)
+}
+
+// GetOrderPayments returns the payments received against an order and what is still owed
+func (h *PaymentHandler) GetOrderPayments(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid order ID")
+	}
+
+	return h.orderPayments(c, id)
+}
+
+// GetInvoicePayments returns the payments received against an invoice's order and what is
+// still owed
+func (h *PaymentHandler) GetInvoicePayments(c echo.Context) error {
+	orderID, err := h.invoiceOrder(c)
+	if err != nil {
+		return err
+	}
+
+	return h.orderPayments(c, orderID)
+}
+
+// orderPayments responds with the payments on an order and its balance
+func (h *PaymentHandler) orderPayments(c echo.Context, id int) error {
+	ctx := c.Request().Context()
+
+	order, err := h.orderRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Order not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve order")
+	}
+
+	payments, err := h.paymentRepo.GetByOrder(ctx, id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve payments")
+	}
+	credited, err := h.adjustmentRepo.GetCredited(ctx, id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve credit notes")
+	}
+
+	return c.JSON(http.StatusOK, orderPaymentSummary(order, payments, credited))
+}
+
+// RecordPayment records a payment received against an order. Tax the customer withheld is
+// given as withholding_amount with its atc_code and counts towards settling the order.
+// Payments may be partial; what is left is reported as the balance.
+func (h *PaymentHandler) RecordPayment(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid order ID")
+	}
+
+	return h.recordPayment(c, id)
+}
+
+// RecordInvoicePayment records a payment received against an invoice. Invoices bill a single
+// order, so the payment is recorded on the invoice's order as with RecordPayment.
+func (h *PaymentHandler) RecordInvoicePayment(c echo.Context) error {
+	orderID, err := h.invoiceOrder(c)
+	if err != nil {
+		return err
+	}
+
+	return h.recordPayment(c, orderID)
+}
+
+// invoiceOrder returns the order billed by the invoice in the request path
+func (h *PaymentHandler) invoiceOrder(c echo.Context) (int, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return 0, models.NewAPIError(http.StatusBadRequest, "Invalid invoice ID")
+	}
+
+	invoice, err := h.invoiceRepo.GetByID(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return 0, models.NewAPIError(http.StatusNotFound, "Invoice not found")
+		}
+		return 0, models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve invoice")
+	}
+	return invoice.OrderID, nil
+}
+
+// recordPayment records the payment in the request body against an order and responds with
+// the order's payments and balance
+func (h *PaymentHandler) recordPayment(c echo.Context, id int) error {
+	ctx := c.Request().Context()
+
+	var payment models.Payment
+	if err := c.Bind(&payment); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload: "+err.Error())
+	}
+	if err := c.Validate(&payment); err != nil {
+		return validationError(err)
+	}
+
+	order, err := h.orderRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Order not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve order")
+	}
+	if order.Status == "Cancelled" {
+		return models.NewAPIError(http.StatusBadRequest, "Cannot record a payment on a cancelled order")
+	}
+
+	message, err := checkPaymentMethod(ctx, h.paymentRepo, payment.PaymentMethod, payment.ReferenceNo)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to validate payment method")
+	}
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+	if message := checkWithholding(&payment); message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	payment.PaymentID = 0
+	payment.OrderID = id
+	payment.ReferenceNo = trimReference(payment.ReferenceNo)
+	payment.Amount = math.Round(payment.Amount*100) / 100
+	payment.Form2307ReceivedOn = nil
+	payment.Form2307Reference = ""
+	payment.ReceivedBy = nil
+	if user := appmw.UserFromContext(c); user != nil {
+		payment.ReceivedBy = &user.UserID
+	}
+	if err := h.paymentRepo.Create(ctx, &payment); err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to record payment")
+	}
+
+	payments, err := h.paymentRepo.GetByOrder(ctx, id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve payments")
+	}
+	credited, err := h.adjustmentRepo.GetCredited(ctx, id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve credit notes")
+	}
+
+	summary := orderPaymentSummary(order, payments, credited)
+	summary["payment"] = payment
+	return c.JSON(http.StatusCreated, summary)
+}
+
+// GetPayments lists payments received, optionally filtered by ?payment_method= and a
+// ?from=/?to= range of days, for reconciling against bank statements
+func (h *PaymentHandler) GetPayments(c echo.Context) error {
+	filter, message := paymentFilter(c)
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	payments, err := h.paymentRepo.GetAll(c.Request().Context(), filter)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve payments")
+	}
+
+	return jsonList(c, http.StatusOK, payments)
+}
+
+// GetCustomerPayments returns the payment history of a customer across their orders, latest
+// first, with the GetPayments filters
+func (h *PaymentHandler) GetCustomerPayments(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid customer ID")
+	}
+
+	filter, message := paymentFilter(c)
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+	filter.CustomerID = id
+
+	if _, err := h.customerRepo.GetByIDWithDeleted(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Customer not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve customer")
+	}
+
+	payments, err := h.paymentRepo.GetAll(ctx, filter)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve payments")
+	}
+
+	return jsonList(c, http.StatusOK, payments)
+}
+
+// ExportPaymentsCSV exports the payments matching the GetPayments filters as CSV
+func (h *PaymentHandler) ExportPaymentsCSV(c echo.Context) error {
+	filter, message := paymentFilter(c)
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	payments, err := h.paymentRepo.GetAll(c.Request().Context(), filter)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve payments")
+	}
+
+	// Set headers for CSV download
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv")
+	c.Response().Header().Set(echo.HeaderContentDisposition, "attachment; filename=payments.csv")
+
+	csvWriter := csv.NewWriter(c.Response().Writer)
+	csvWriter.Write([]string{"Payment ID", "Paid At", "Order ID", "Customer", "Payment Method", "Reference No", "Amount", "Tax Withheld", "ATC", "Notes"})
+
+	for _, payment := range payments {
+		reference := ""
+		if payment.ReferenceNo != nil {
+			reference = *payment.ReferenceNo
+		}
+		atc := ""
+		if payment.ATCCode != nil {
+			atc = *payment.ATCCode
+		}
+		csvWriter.Write([]string{
+			strconv.Itoa(payment.PaymentID),
+			payment.PaidAt.Format(time.RFC3339),
+			strconv.Itoa(payment.OrderID),
+			payment.CompanyName,
+			payment.MethodName,
+			reference,
+			fmt.Sprintf("%.2f", payment.Amount),
+			fmt.Sprintf("%.2f", payment.WithholdingAmount),
+			atc,
+			payment.Notes,
+		})
+	}
+
+	csvWriter.Flush()
+	return nil
+}
+
+// paymentFilter reads the payment list filters, returning a message when one is invalid
+func paymentFilter(c echo.Context) (repository.PaymentFilter, string) {
+	filter := repository.PaymentFilter{PaymentMethod: c.QueryParam("payment_method")}
+	if from := c.QueryParam("from"); from != "" {
+		day, err := time.Parse(deliveryDateLayout, from)
+		if err != nil {
+			return filter, "Invalid from date, expected YYYY-MM-DD"
+		}
+		filter.From = &day
+	}
+	if to := c.QueryParam("to"); to != "" {
+		day, err := time.Parse(deliveryDateLayout, to)
+		if err != nil {
+			return filter, "Invalid to date, expected YYYY-MM-DD"
+		}
+		end := day.AddDate(0, 0, 1)
+		filter.To = &end
+	}
+	return filter, ""
+}
+
+// orderPaymentSummary describes the payments on an order and the balance left to pay. Tax
+// withheld by the customer is reported separately and reduces the balance like a payment;
+// approved credit notes reduce what is owed, and nothing is owed on a voided invoice, so
+// payments on one are left as the customer's credit.
+func orderPaymentSummary(order models.Order, payments []models.Payment, credited float64) map[string]interface{} {
+	paid, withheld := 0.0, 0.0
+	for _, payment := range payments {
+		paid += payment.Amount
+		withheld += payment.WithholdingAmount
+	}
+	paid = math.Round(paid*100) / 100
+	withheld = math.Round(withheld*100) / 100
+
+	owed := order.TotalAmount - credited
+	if order.Status == "Cancelled" {
+		owed = 0
+	}
+
+	return map[string]interface{}{
+		"order_id":       order.OrderID,
+		"total_amount":   order.TotalAmount,
+		"total_credited": credited,
+		"total_paid":     paid,
+		"total_withheld": withheld,
+		"balance":        math.Round((owed-paid-withheld)*100) / 100,
+		"payments":       payments,
+	}
+}
+
+// checkWithholding normalizes the tax withheld on a payment, returning a message when it is
+// not acceptable
+func checkWithholding(payment *models.Payment) string {
+	payment.WithholdingAmount = math.Round(payment.WithholdingAmount*100) / 100
+	if payment.ATCCode != nil {
+		code := strings.ToUpper(strings.TrimSpace(*payment.ATCCode))
+		payment.ATCCode = &code
+		if code == "" {
+			payment.ATCCode = nil
+		}
+	}
+
+	if payment.WithholdingAmount == 0 {
+		payment.ATCCode = nil
+		return ""
+	}
+	if payment.WithholdingAmount >= payment.Amount {
+		return "Tax withheld must be less than the amount paid"
+	}
+	if payment.ATCCode == nil {
+		return "An ATC code is required when tax is withheld"
+	}
+	if !atcCode.MatchString(*payment.ATCCode) {
+		return "ATC code must be two letters followed by three digits, e.g. WC158"
+	}
+	return ""
+}
+
+// GetOutstanding2307 lists, per customer, the payments with tax withheld whose BIR Form 2307
+// has not been received yet; ?customer_id= narrows it to one customer
+func (h *PaymentHandler) GetOutstanding2307(c echo.Context) error {
+	customerID := 0
+	if value := c.QueryParam("customer_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			return models.NewAPIError(http.StatusBadRequest, "Invalid customer ID")
+		}
+		customerID = id
+	}
+
+	payments, err := h.paymentRepo.GetOutstanding2307(c.Request().Context(), customerID)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve outstanding 2307 forms")
+	}
+
+	customers := []models.Outstanding2307{}
+	for _, payment := range payments {
+		if n := len(customers); n == 0 || customers[n-1].CustomerID != payment.CustomerID {
+			customers = append(customers, models.Outstanding2307{
+				CustomerID:   payment.CustomerID,
+				CompanyName:  payment.CompanyName,
+				TIN:          payment.TIN,
+				OldestPaidAt: payment.PaidAt,
+				Payments:     []models.PaymentRecord{},
+			})
+		}
+		customer := &customers[len(customers)-1]
+		customer.PaymentCount++
+		customer.WithheldTotal = math.Round((customer.WithheldTotal+payment.WithholdingAmount)*100) / 100
+		customer.Payments = append(customer.Payments, payment)
+	}
+
+	return jsonList(c, http.StatusOK, customers)
+}
+
+// ReceiveForm2307 records the BIR Form 2307 received for a payment's withheld tax, on today
+// unless received_on is given
+func (h *PaymentHandler) ReceiveForm2307(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid payment ID")
+	}
+
+	var req Form2307Request
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	receivedOn := time.Now()
+	if req.ReceivedOn != "" {
+		receivedOn, _ = time.Parse(deliveryDateLayout, req.ReceivedOn)
+	}
+	receivedOn = time.Date(receivedOn.Year(), receivedOn.Month(), receivedOn.Day(), 0, 0, 0, 0, time.UTC)
+
+	return h.setForm2307(c, id, &receivedOn, strings.TrimSpace(req.Reference))
+}
+
+// ClearForm2307 marks a payment's Form 2307 as not received, such as after recording it by mistake
+func (h *PaymentHandler) ClearForm2307(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid payment ID")
+	}
+
+	return h.setForm2307(c, id, nil, "")
+}
+
+// setForm2307 records or clears a payment's Form 2307 and returns the payment
+func (h *PaymentHandler) setForm2307(c echo.Context, id int, receivedOn *time.Time, reference string) error {
+	payment, err := h.paymentRepo.SetForm2307(c.Request().Context(), id, receivedOn, reference)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Payment not found")
+		}
+		if err == repository.ErrNoWithholding {
+			return models.NewAPIError(http.StatusBadRequest, "No tax was withheld on this payment")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update Form 2307")
+	}
+
+	return c.JSON(http.StatusOK, payment)
+}
+
+// trimReference trims a reference number, dropping it when blank
+func trimReference(reference *string) *string {
+	if reference == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*reference)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+// checkPaymentMethod checks that a payment method exists and is active, and that a reference
+// number is given when the method requires one. It returns a message for the client when
+// the payment is not acceptable.
+func checkPaymentMethod(ctx context.Context, paymentRepo *repository.PaymentRepository, code string, reference *string) (string, error) {
+	method, err := paymentRepo.GetMethod(ctx, code)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return "Unknown payment method " + strconv.Quote(code), nil
+		}
+		return "", err
+	}
+	if !method.Active {
+		return method.Name + " is no longer accepted", nil
+	}
+	if method.RequiresReference && (reference == nil || strings.TrimSpace(*reference) == "") {
+		return method.Name + " payments require a reference number", nil
+	}
+	return "", nil
+}
+
+// GetWithholdingSummary reports the tax customers withheld in a quarter per customer and ATC
+// code, with what is still awaiting a Form 2307; see withholdingQuarter for the parameters
+func (h *PaymentHandler) GetWithholdingSummary(c echo.Context) error {
+	year, quarter, from, message := withholdingQuarter(c)
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	rows, err := h.paymentRepo.GetWithholdingSummary(c.Request().Context(), from, from.AddDate(0, 3, 0))
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to generate withholding tax summary")
+	}
+
+	withheld, outstanding := 0.0, 0.0
+	for _, row := range rows {
+		withheld += row.Withheld
+		outstanding += row.Outstanding
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"year":              year,
+		"quarter":           quarter,
+		"rows":              rows,
+		"total_withheld":    math.Round(withheld*100) / 100,
+		"total_outstanding": math.Round(outstanding*100) / 100,
+	})
+}
+
+// ExportWithholdingSummaryCSV exports the quarterly withholding tax summary as CSV, or as an
+// Excel workbook with ?format=xlsx
+func (h *PaymentHandler) ExportWithholdingSummaryCSV(c echo.Context) error {
+	format, err := exportFormat(c)
+	if err != nil {
+		return err
+	}
+
+	year, quarter, from, message := withholdingQuarter(c)
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	rows, err := h.paymentRepo.GetWithholdingSummary(c.Request().Context(), from, from.AddDate(0, 3, 0))
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to export withholding tax summary")
+	}
+
+	report := &reportExport{
+		Title:    "Withholding Tax",
+		FileName: fmt.Sprintf("withholding_tax_%d_q%d", year, quarter),
+		Columns: []exportColumn{
+			{Header: "Customer ID", Kind: columnInteger},
+			{Header: "Customer"},
+			{Header: "TIN"},
+			{Header: "ATC"},
+			{Header: "Payments", Kind: columnInteger, Total: true},
+			{Header: "Gross Amount", Kind: columnAmount, Total: true},
+			{Header: "Tax Withheld", Kind: columnAmount, Total: true},
+			{Header: "2307 Received", Kind: columnAmount, Total: true},
+			{Header: "2307 Outstanding", Kind: columnAmount, Total: true},
+		},
+	}
+	report.addFilter("Year", strconv.Itoa(year))
+	report.addFilter("Quarter", fmt.Sprintf("Q%d", quarter))
+	for _, row := range rows {
+		report.addRow(row.CustomerID, row.CompanyName, row.TIN, row.ATCCode, row.PaymentCount,
+			row.Gross, row.Withheld, row.Received, row.Outstanding)
+	}
+
+	return writeExport(c, format, report)
+}
+
+// withholdingQuarter reads the ?year= and ?quarter= (1-4) of a withholding tax report,
+// defaulting to the current quarter, returning the quarter's first day or a message when
+// either is invalid
+func withholdingQuarter(c echo.Context) (int, int, time.Time, string) {
+	now := time.Now()
+	year, quarter := now.Year(), (int(now.Month())-1)/3+1
+	if value := c.QueryParam("year"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 2000 || parsed > 9999 {
+			return 0, 0, time.Time{}, "Invalid year"
+		}
+		year = parsed
+	}
+	if value := c.QueryParam("quarter"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 4 {
+			return 0, 0, time.Time{}, "Invalid quarter, expected 1 to 4"
+		}
+		quarter = parsed
+	}
+
+	return year, quarter, time.Date(year, time.Month((quarter-1)*3+1), 1, 0, 0, 0, 0, time.Local), ""
+}
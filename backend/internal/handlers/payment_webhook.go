@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// maxPaymentEventSize is the largest payment gateway event read
+const maxPaymentEventSize = 1 << 20
+
+// PaymentEventResponse acknowledges a payment gateway event. Status is "recorded" when a
+// payment was recorded, "duplicate" when it had been already, and "ignored" for events that
+// do not report a payment against an order or invoice.
+type PaymentEventResponse struct {
+	Status  string          `json:"status"`
+	Payment *models.Payment `json:"payment,omitempty"`
+}
+
+// ReceivePaymentEvent records a payment reported by an online payment gateway against the
+// invoice or order it was made for, marking it paid when the payment settles its balance.
+// The gateway is told apart by the header it signs with, and the request is refused unless
+// the signature matches one of the configured webhook secrets. Gateways send an event more
+// than once and several events about the same payment, so each payment is recorded once.
+// Payments are recorded even on cancelled orders since the money was received; they are
+// left as the customer's credit.
+func (h *PaymentHandler) ReceivePaymentEvent(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, maxPaymentEventSize))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Failed to read request body")
+	}
+
+	event, err := h.gateways.Parse(c.Request().Header, body)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUnknownPaymentGateway):
+			return models.NewAPIError(http.StatusBadRequest, "Request is not from a configured payment gateway")
+		case errors.Is(err, services.ErrInvalidPaymentSignature):
+			return models.NewAPIError(http.StatusUnauthorized, "Invalid payment gateway signature")
+		}
+		return models.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	if !event.Paid {
+		return c.JSON(http.StatusOK, PaymentEventResponse{Status: "ignored"})
+	}
+
+	orderID := event.OrderID
+	if event.InvoiceID != 0 {
+		invoice, err := h.invoiceRepo.GetByID(ctx, event.InvoiceID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return models.NewAPIError(http.StatusNotFound, "Invoice not found")
+			}
+			return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve invoice")
+		}
+		orderID = invoice.OrderID
+	}
+	if orderID == 0 {
+		log.Printf("Ignoring %s %s payment %s without an order_id or invoice_id", event.Provider, event.Event, event.Reference)
+		return c.JSON(http.StatusOK, PaymentEventResponse{Status: "ignored"})
+	}
+
+	order, err := h.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Order not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve order")
+	}
+	if !strings.EqualFold(event.Currency, order.Currency) {
+		return models.NewAPIError(http.StatusBadRequest, fmt.Sprintf("Payment is in %s but order #%d is in %s", event.Currency, orderID, order.Currency))
+	}
+	if event.Amount <= 0 {
+		return models.NewAPIError(http.StatusBadRequest, "Payment amount must be greater than 0")
+	}
+
+	reference := event.Reference
+	payment := models.Payment{
+		OrderID:       orderID,
+		PaymentMethod: event.Method,
+		Amount:        math.Round(event.Amount*100) / 100,
+		ReferenceNo:   &reference,
+		PaidAt:        event.PaidAt,
+		Notes:         "Paid online through " + event.Provider,
+	}
+	recorded, err := h.paymentRepo.CreateFromGateway(ctx, event.Provider, event.Reference, &payment)
+	if err != nil {
+		log.Printf("Failed to record %s payment %s on order %d: %v", event.Provider, event.Reference, orderID, err)
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to record payment")
+	}
+	if !recorded {
+		return c.JSON(http.StatusOK, PaymentEventResponse{Status: "duplicate"})
+	}
+	if order.Status == "Cancelled" {
+		log.Printf("Recorded %s payment %s on cancelled order %d", event.Provider, event.Reference, orderID)
+	}
+
+	return c.JSON(http.StatusOK, PaymentEventResponse{Status: "recorded", Payment: &payment})
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// maxCaptureClockSkew is how far in the future a device's capture time may be before it is rejected
+const maxCaptureClockSkew = 5 * time.Minute
+
+// ProofOfDeliveryHandler handles proof of delivery captured by the driver app
+type ProofOfDeliveryHandler struct {
+	orderRepo         *repository.OrderRepository
+	attachmentService *services.AttachmentService
+	auditRepo         *repository.AuditRepository
+	productRepo       *repository.ProductRepository
+	chatNotifier      *services.ChatNotifier
+	notificationRepo  *repository.NotificationRepository
+	webhooks          *services.WebhookService
+}
+
+// NewProofOfDeliveryHandler creates a new proof of delivery handler
+func NewProofOfDeliveryHandler(
+	orderRepo *repository.OrderRepository,
+	attachmentService *services.AttachmentService,
+	auditRepo *repository.AuditRepository,
+	productRepo *repository.ProductRepository,
+	chatNotifier *services.ChatNotifier,
+	notificationRepo *repository.NotificationRepository,
+	webhooks *services.WebhookService,
+) *ProofOfDeliveryHandler {
+	return &ProofOfDeliveryHandler{
+		orderRepo:         orderRepo,
+		attachmentService: attachmentService,
+		auditRepo:         auditRepo,
+		productRepo:       productRepo,
+		chatNotifier:      chatNotifier,
+		notificationRepo:  notificationRepo,
+		webhooks:          webhooks,
+	}
+}
+
+// CaptureProofOfDelivery records a multipart proof of delivery and marks the order Delivered.
+//
+// Form fields: recipient_name (required), signature and/or photo image files (at least one),
+// latitude and longitude (optional, together), captured_at (optional RFC 3339, defaults to now).
+func (h *ProofOfDeliveryHandler) CaptureProofOfDelivery(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid order ID")
+	}
+
+	before, err := h.orderRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Order not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve order")
+	}
+	if before.Status != "Pending" && before.Status != "Shipped" {
+		return models.NewAPIError(http.StatusConflict, "Only pending or shipped orders can be delivered")
+	}
+
+	pod := models.ProofOfDelivery{
+		OrderID:       id,
+		RecipientName: strings.TrimSpace(c.FormValue("recipient_name")),
+	}
+	if pod.RecipientName == "" {
+		return models.NewAPIError(http.StatusBadRequest, "Recipient name is required")
+	}
+
+	if msg := parseDeliveryLocation(c, &pod); msg != "" {
+		return models.NewAPIError(http.StatusBadRequest, msg)
+	}
+
+	pod.DeliveredAt = time.Now()
+	if capturedAt := strings.TrimSpace(c.FormValue("captured_at")); capturedAt != "" {
+		pod.DeliveredAt, err = time.Parse(time.RFC3339, capturedAt)
+		if err != nil {
+			return models.NewAPIError(http.StatusBadRequest, "captured_at must be an RFC 3339 timestamp")
+		}
+		if pod.DeliveredAt.After(time.Now().Add(maxCaptureClockSkew)) {
+			return models.NewAPIError(http.StatusBadRequest, "captured_at cannot be in the future")
+		}
+	}
+
+	signature, err := optionalFormFile(c, "signature")
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid signature upload")
+	}
+	photo, err := optionalFormFile(c, "photo")
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid photo upload")
+	}
+	if signature == nil && photo == nil {
+		return models.NewAPIError(http.StatusBadRequest, "A signature or photo is required")
+	}
+
+	if user := appmw.UserFromContext(c); user != nil {
+		pod.CapturedBy = &user.UserID
+	}
+
+	// Store the images first; they are removed again if the delivery can't be recorded
+	var saved []int
+	for _, upload := range []struct {
+		kind string
+		file *multipart.FileHeader
+		id   **int
+	}{
+		{models.AttachmentSignature, signature, &pod.SignatureAttachmentID},
+		{models.AttachmentPhoto, photo, &pod.PhotoAttachmentID},
+	} {
+		if upload.file == nil {
+			continue
+		}
+		attachment, err := h.attachmentService.Save(ctx, models.AuditEntityOrder, id, upload.kind, upload.file, pod.CapturedBy)
+		if err == nil && !strings.HasPrefix(attachment.ContentType, "image/") {
+			h.removeAttachments(ctx, []int{attachment.AttachmentID})
+			err = services.ErrAttachmentType
+		}
+		if err != nil {
+			h.removeAttachments(ctx, saved)
+			var tooLarge *services.AttachmentTooLargeError
+			if errors.As(err, &tooLarge) {
+				return models.NewAPIError(http.StatusRequestEntityTooLarge, "The "+upload.kind+" "+err.Error())
+			}
+			if err == services.ErrAttachmentType || err == services.ErrAttachmentEmpty {
+				return models.NewAPIError(http.StatusBadRequest, "The "+upload.kind+" must be a PNG, JPEG, WebP or GIF image")
+			}
+			return models.NewAPIError(http.StatusInternalServerError, "Failed to store the "+upload.kind)
+		}
+		saved = append(saved, attachment.AttachmentID)
+		*upload.id = &attachment.AttachmentID
+	}
+
+	stockChanges, err := h.orderRepo.RecordDelivery(ctx, &pod)
+	if err != nil {
+		h.removeAttachments(ctx, saved)
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Order not found")
+		}
+		if err == repository.ErrOrderNotDeliverable {
+			return models.NewAPIError(http.StatusConflict, "Only pending or shipped orders can be delivered")
+		}
+		if apiErr := stockError(err); apiErr != nil {
+			return apiErr
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to record proof of delivery")
+	}
+
+	order, err := h.orderRepo.GetByID(ctx, id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Proof of delivery recorded but failed to retrieve updated order")
+	}
+
+	recordAudit(c, h.auditRepo, "status_change", models.AuditEntityOrder, id, before, order)
+	notifyStockChanges(c, h.productRepo, h.chatNotifier, h.notificationRepo, h.webhooks, stockChanges...)
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"order":             order,
+		"proof_of_delivery": pod,
+	})
+}
+
+// GetProofOfDelivery returns the proof of delivery recorded for an order
+func (h *ProofOfDeliveryHandler) GetProofOfDelivery(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid order ID")
+	}
+
+	pod, err := h.orderRepo.GetProofOfDelivery(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "No proof of delivery recorded for this order")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve proof of delivery")
+	}
+
+	return c.JSON(http.StatusOK, pod)
+}
+
+// GetAttachment downloads an uploaded file
+func (h *ProofOfDeliveryHandler) GetAttachment(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid attachment ID")
+	}
+
+	attachment, err := h.attachmentService.Get(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Attachment not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve attachment")
+	}
+
+	c.Response().Header().Set("Content-Disposition", "inline; filename="+strconv.Quote(attachment.FileName))
+	return c.Blob(http.StatusOK, attachment.ContentType, attachment.Content)
+}
+
+// removeAttachments deletes attachments stored for a capture that could not be completed
+func (h *ProofOfDeliveryHandler) removeAttachments(ctx context.Context, ids []int) {
+	for _, id := range ids {
+		if err := h.attachmentService.Delete(ctx, id); err != nil {
+			log.Printf("Failed to remove attachment %d: %v", id, err)
+		}
+	}
+}
+
+// parseDeliveryLocation reads the optional latitude and longitude form values into the proof
+// of delivery, returning a validation message when they are incomplete or out of range
+func parseDeliveryLocation(c echo.Context, pod *models.ProofOfDelivery) string {
+	latValue := strings.TrimSpace(c.FormValue("latitude"))
+	lngValue := strings.TrimSpace(c.FormValue("longitude"))
+	if latValue == "" && lngValue == "" {
+		return ""
+	}
+	if latValue == "" || lngValue == "" {
+		return "Latitude and longitude must be given together"
+	}
+
+	latitude, err := strconv.ParseFloat(latValue, 64)
+	if err != nil {
+		return "Latitude must be a number"
+	}
+	longitude, err := strconv.ParseFloat(lngValue, 64)
+	if err != nil {
+		return "Longitude must be a number"
+	}
+	if latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+		return "Latitude must be between -90 and 90 and longitude between -180 and 180"
+	}
+
+	pod.Latitude = &latitude
+	pod.Longitude = &longitude
+	return ""
+}
+
+// optionalFormFile returns an uploaded file, or nil when the field was not sent
+func optionalFormFile(c echo.Context, name string) (*multipart.FileHeader, error) {
+	file, err := c.FormFile(name)
+	if err == http.ErrMissingFile {
+		return nil, nil
+	}
+	return file, err
+}
@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// CheckRequest registers or edits a post-dated check received from a customer
+type CheckRequest struct {
+	CustomerID   int     `json:"customer_id" validate:"required"`
+	OrderID      *int    `json:"order_id"`
+	CheckNumber  string  `json:"check_number" validate:"notblank"`
+	Bank         string  `json:"bank" validate:"notblank"`
+	Amount       float64 `json:"amount" validate:"gt=0"`
+	MaturityDate string  `json:"maturity_date" validate:"datetime=2006-01-02"`
+	ReceivedDate string  `json:"received_date" validate:"omitempty,datetime=2006-01-02"`
+	Notes        string  `json:"notes"`
+}
+
+// CheckStatusRequest moves a check to a new status, on today unless a date is given
+type CheckStatusRequest struct {
+	Status string `json:"status" validate:"oneof=deposited cleared bounced returned"`
+	Date   string `json:"date" validate:"omitempty,datetime=2006-01-02"`
+	Reason string `json:"reason"`
+}
+
+// PostDatedCheckHandler handles HTTP requests for the post-dated check register
+type PostDatedCheckHandler struct {
+	checkRepo       *repository.PostDatedCheckRepository
+	orderRepo       *repository.OrderRepository
+	reminderService *services.CheckReminderService
+}
+
+// NewPostDatedCheckHandler creates a new post-dated check handler with the provided repositories
+func NewPostDatedCheckHandler(checkRepo *repository.PostDatedCheckRepository, orderRepo *repository.OrderRepository, reminderService *services.CheckReminderService) *PostDatedCheckHandler {
+	return &PostDatedCheckHandler{
+		checkRepo:       checkRepo,
+		orderRepo:       orderRepo,
+		reminderService: reminderService,
+	}
+}
+
+// GetChecks lists checks by maturity date, optionally filtered by ?customer_id=, ?order_id=,
+// ?status= and a ?from=/?to= range of maturity dates
+func (h *PostDatedCheckHandler) GetChecks(c echo.Context) error {
+	filter := repository.CheckFilter{Status: c.QueryParam("status")}
+	switch filter.Status {
+	case "", models.CheckOnHand, models.CheckDeposited, models.CheckCleared, models.CheckBounced, models.CheckReturned:
+	default:
+		return models.NewAPIError(http.StatusBadRequest, "Invalid status")
+	}
+	if customerID := c.QueryParam("customer_id"); customerID != "" {
+		id, err := strconv.Atoi(customerID)
+		if err != nil {
+			return models.NewAPIError(http.StatusBadRequest, "Invalid customer ID")
+		}
+		filter.CustomerID = id
+	}
+	if orderID := c.QueryParam("order_id"); orderID != "" {
+		id, err := strconv.Atoi(orderID)
+		if err != nil {
+			return models.NewAPIError(http.StatusBadRequest, "Invalid order ID")
+		}
+		filter.OrderID = id
+	}
+	if from := c.QueryParam("from"); from != "" {
+		day, err := time.Parse(deliveryDateLayout, from)
+		if err != nil {
+			return models.NewAPIError(http.StatusBadRequest, "Invalid from date, expected YYYY-MM-DD")
+		}
+		filter.MaturityFrom = &day
+	}
+	if to := c.QueryParam("to"); to != "" {
+		day, err := time.Parse(deliveryDateLayout, to)
+		if err != nil {
+			return models.NewAPIError(http.StatusBadRequest, "Invalid to date, expected YYYY-MM-DD")
+		}
+		filter.MaturityTo = &day
+	}
+
+	checks, err := h.checkRepo.GetAll(c.Request().Context(), filter)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve checks")
+	}
+
+	return jsonList(c, http.StatusOK, checks)
+}
+
+// GetMaturingChecks lists the checks on hand maturing within ?days= days (default: the
+// reminder window), overdue ones included, for planning deposits
+func (h *PostDatedCheckHandler) GetMaturingChecks(c echo.Context) error {
+	days := h.reminderService.Days(c.Request().Context())
+	if value := c.QueryParam("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return models.NewAPIError(http.StatusBadRequest, "Invalid days")
+		}
+		days = parsed
+	}
+
+	today, _ := deliveryDate("")
+	checks, err := h.checkRepo.GetMaturing(c.Request().Context(), today.AddDate(0, 0, days))
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve checks")
+	}
+
+	return jsonList(c, http.StatusOK, checks)
+}
+
+// GetCheck returns a check by ID
+func (h *PostDatedCheckHandler) GetCheck(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid check ID")
+	}
+
+	check, err := h.checkRepo.GetByID(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Check not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve check")
+	}
+
+	return c.JSON(http.StatusOK, check)
+}
+
+// CreateCheck registers a check received from a customer
+func (h *PostDatedCheckHandler) CreateCheck(c echo.Context) error {
+	check, handled, err := h.bindCheck(c)
+	if handled {
+		return err
+	}
+	if user := appmw.UserFromContext(c); user != nil {
+		check.CreatedBy = &user.UserID
+	}
+
+	if err := h.checkRepo.Create(c.Request().Context(), &check); err != nil {
+		return checkSaveError(err, "Failed to register check")
+	}
+
+	return c.JSON(http.StatusCreated, check)
+}
+
+// UpdateCheck edits a check still on hand
+func (h *PostDatedCheckHandler) UpdateCheck(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid check ID")
+	}
+
+	check, handled, err := h.bindCheck(c)
+	if handled {
+		return err
+	}
+	check.CheckID = id
+
+	if err := h.checkRepo.Update(c.Request().Context(), &check); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Check not found")
+		}
+		return checkSaveError(err, "Failed to update check")
+	}
+
+	return c.JSON(http.StatusOK, check)
+}
+
+// UpdateCheckStatus deposits, clears, bounces or returns a check. Clearing a check that pays
+// for an order records a check payment on the order.
+func (h *PostDatedCheckHandler) UpdateCheckStatus(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid check ID")
+	}
+
+	var req CheckStatusRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload: "+err.Error())
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Status == models.CheckBounced && req.Reason == "" {
+		return models.NewAPIError(http.StatusBadRequest, "A reason is required when a check bounces")
+	}
+
+	day, err := deliveryDate(req.Date)
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid date, expected YYYY-MM-DD")
+	}
+
+	var userID *int
+	if user := appmw.UserFromContext(c); user != nil {
+		userID = &user.UserID
+	}
+
+	check, err := h.checkRepo.UpdateStatus(c.Request().Context(), id, req.Status, day, req.Reason, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Check not found")
+		}
+		if errors.Is(err, repository.ErrCheckTransition) {
+			return models.NewAPIError(http.StatusConflict, "Check cannot move to "+req.Status).WithDetails(map[string]interface{}{
+				"status":  check.Status,
+				"allowed": models.CheckTransitions[check.Status],
+			})
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update check status")
+	}
+
+	return c.JSON(http.StatusOK, check)
+}
+
+// bindCheck reads a check from the request, checking that its order belongs to its customer
+func (h *PostDatedCheckHandler) bindCheck(c echo.Context) (models.PostDatedCheck, bool, error) {
+	var req CheckRequest
+	if err := c.Bind(&req); err != nil {
+		return models.PostDatedCheck{}, true, models.NewAPIError(http.StatusBadRequest, "Invalid request payload: "+err.Error())
+	}
+	if err := c.Validate(&req); err != nil {
+		return models.PostDatedCheck{}, true, validationError(err)
+	}
+
+	check := models.PostDatedCheck{
+		CustomerID:  req.CustomerID,
+		OrderID:     req.OrderID,
+		CheckNumber: strings.TrimSpace(req.CheckNumber),
+		Bank:        strings.TrimSpace(req.Bank),
+		Amount:      math.Round(req.Amount*100) / 100,
+		Notes:       req.Notes,
+	}
+	check.MaturityDate, _ = time.Parse(deliveryDateLayout, req.MaturityDate)
+	check.ReceivedDate, _ = deliveryDate(req.ReceivedDate)
+	if check.MaturityDate.Before(check.ReceivedDate) {
+		return check, true, models.NewAPIError(http.StatusBadRequest, "Maturity date cannot be before the received date")
+	}
+
+	if check.OrderID != nil {
+		order, err := h.orderRepo.GetByID(c.Request().Context(), *check.OrderID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return check, true, models.NewAPIError(http.StatusBadRequest, "Order not found")
+			}
+			return check, true, models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve order")
+		}
+		if order.CustomerID != check.CustomerID {
+			return check, true, models.NewAPIError(http.StatusBadRequest, "Order belongs to a different customer")
+		}
+		if order.Status == "Cancelled" {
+			return check, true, models.NewAPIError(http.StatusBadRequest, "Cannot register a check for a cancelled order")
+		}
+	}
+
+	return check, false, nil
+}
+
+// checkSaveError maps a failed check save to an API error
+func checkSaveError(err error, message string) error {
+	switch {
+	case errors.Is(err, repository.ErrCheckNotEditable):
+		return models.NewAPIError(http.StatusConflict, "Only checks on hand can be edited")
+	case errors.Is(err, repository.ErrDuplicateKey):
+		return models.NewAPIError(http.StatusConflict, "A check with this number from this bank is already registered")
+	case errors.Is(err, repository.ErrReferencedRecord):
+		return models.NewAPIError(http.StatusBadRequest, "Customer not found")
+	}
+	return models.NewAPIError(http.StatusInternalServerError, message)
+}
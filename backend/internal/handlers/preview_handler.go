@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// PreviewHandler serves the small previews document cards show of attachments and of the
+// quotation, order and invoice PDFs
+type PreviewHandler struct {
+	previewService *services.PreviewService
+	previewRepo    *repository.DocumentPreviewRepository
+}
+
+// NewPreviewHandler creates a new preview handler
+func NewPreviewHandler(previewService *services.PreviewService, previewRepo *repository.DocumentPreviewRepository) *PreviewHandler {
+	return &PreviewHandler{
+		previewService: previewService,
+		previewRepo:    previewRepo,
+	}
+}
+
+// GetPreview returns a JPEG preview of an attachment or of the first page of a quotation,
+// order or invoice PDF. Previews are made in the background: until one is ready the preview
+// is asked for and 202 Accepted is returned with its status, to be tried again after the
+// Retry-After seconds. A preview made before the document last changed is returned while a
+// new one is made, and 404 is returned when no preview can be made of the document.
+func (h *PreviewHandler) GetPreview(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	entity := c.Param("type")
+	if !containsString(models.DownloadDocumentTypes, entity) {
+		return models.NewAPIError(http.StatusBadRequest, "type must be one of: "+strings.Join(models.DownloadDocumentTypes, ", "))
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid document ID")
+	}
+
+	preview, err := h.previewRepo.Get(ctx, entity, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Document not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve preview")
+	}
+
+	if preview.Status == models.PreviewUnavailable && !preview.Stale {
+		apiErr := models.NewAPIError(http.StatusNotFound, "No preview can be made of this document")
+		if preview.Message != nil {
+			return apiErr.WithDetails(*preview.Message)
+		}
+		return apiErr
+	}
+
+	// Asking again is cheap: a preview already queued is not queued twice
+	if preview.Content == nil || preview.Stale {
+		if err := h.previewService.Request(ctx, entity, id); err != nil {
+			log.Printf("Failed to queue preview of %s %d: %v", entity, id, err)
+			return models.NewAPIError(http.StatusInternalServerError, "Failed to queue preview")
+		}
+		preview.Status = models.PreviewPending
+	}
+
+	if preview.Content == nil {
+		c.Response().Header().Set("Retry-After", "5")
+		return c.JSON(http.StatusAccepted, preview)
+	}
+
+	c.Response().Header().Set("Cache-Control", "private, max-age=60")
+	return c.Blob(http.StatusOK, "image/jpeg", preview.Content)
+}
+
+// capturedResponse keeps what a handler writes, for rendering a document outside a request
+type capturedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *capturedResponse) Header() http.Header { return r.header }
+
+func (r *capturedResponse) WriteHeader(status int) { r.status = status }
+
+func (r *capturedResponse) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(b)
+}
+
+// PreviewSource loads documents for their previews through the handler serving their PDF,
+// such as QuotationHandler.GenerateQuotationPDF, so previews show what is downloaded
+func PreviewSource(e *echo.Echo, serve echo.HandlerFunc) services.PreviewSource {
+	return func(ctx context.Context, id int) ([]byte, string, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+		if err != nil {
+			return nil, "", err
+		}
+		res := &capturedResponse{header: http.Header{}}
+		c := e.NewContext(req, res)
+		c.SetParamNames("id")
+		c.SetParamValues(strconv.Itoa(id))
+
+		if err := serve(c); err != nil {
+			var apiErr *models.APIError
+			if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+				return nil, "", &repository.NotFoundError{Entity: "document"}
+			}
+			return nil, "", err
+		}
+		if res.status != http.StatusOK {
+			return nil, "", errors.New("document could not be rendered: " + http.StatusText(res.status))
+		}
+		return res.body.Bytes(), res.header.Get(echo.HeaderContentType), nil
+	}
+}
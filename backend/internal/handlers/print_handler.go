@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// Printable warehouse document types
+const (
+	documentPickingList = "picking_list"
+	documentLabel       = "label"
+	documentReceipt     = "receipt"
+)
+
+// PrintHandler handles HTTP requests for the warehouse print queue
+type PrintHandler struct {
+	printService  *services.PrintService
+	printJobRepo  *repository.PrintJobRepository
+	orderRepo     *repository.OrderRepository
+	customerRepo  *repository.CustomerRepository
+	productRepo   *repository.ProductRepository
+	inventoryRepo *repository.InventoryRepository
+	pdfGenerator  *services.PDFGenerator
+}
+
+// NewPrintHandler creates a new print handler with the provided services and repositories
+func NewPrintHandler(
+	printService *services.PrintService,
+	printJobRepo *repository.PrintJobRepository,
+	orderRepo *repository.OrderRepository,
+	customerRepo *repository.CustomerRepository,
+	productRepo *repository.ProductRepository,
+	inventoryRepo *repository.InventoryRepository,
+	pdfGenerator *services.PDFGenerator,
+) *PrintHandler {
+	return &PrintHandler{
+		printService:  printService,
+		printJobRepo:  printJobRepo,
+		orderRepo:     orderRepo,
+		customerRepo:  customerRepo,
+		productRepo:   productRepo,
+		inventoryRepo: inventoryRepo,
+		pdfGenerator:  pdfGenerator,
+	}
+}
+
+// GetPrinters returns the configured printers
+func (h *PrintHandler) GetPrinters(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.printService.Printers())
+}
+
+// GetPrintJobs returns the most recent print jobs
+func (h *PrintHandler) GetPrintJobs(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	jobs, err := h.printJobRepo.GetRecent(ctx, 100)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve print jobs")
+	}
+
+	return jsonList(c, http.StatusOK, jobs)
+}
+
+// GetPrintJobByID returns a print job by ID
+func (h *PrintHandler) GetPrintJobByID(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid print job ID")
+	}
+
+	job, err := h.printJobRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Print job not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve print job")
+	}
+
+	return c.JSON(http.StatusOK, job)
+}
+
+// PrintJobRequest represents the payload for queueing a warehouse document. Receipts are
+// sent to thermal printers as ESC/POS for the given paper width in millimetres (default 80).
+type PrintJobRequest struct {
+	Printer      string `json:"printer" validate:"required"`
+	DocumentType string `json:"document_type" validate:"oneof=picking_list label receipt"`
+	OrderID      int    `json:"order_id" validate:"gt=0"`
+	Copies       int    `json:"copies" validate:"gte=0,lte=50"`
+	PaperWidth   int    `json:"paper_width" validate:"omitempty,oneof=58 80"`
+}
+
+// CreatePrintJob renders a picking list, label or receipt for an order and queues it for printing
+func (h *PrintHandler) CreatePrintJob(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req PrintJobRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+	if req.Copies == 0 {
+		req.Copies = 1
+	}
+	if req.PaperWidth == 0 {
+		req.PaperWidth = services.ReceiptPaper80mm
+	}
+
+	order, err := h.orderRepo.GetByID(ctx, req.OrderID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Order not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve order")
+	}
+
+	doc := services.PrintDocument{
+		Title:  fmt.Sprintf("%s-order-%d", req.DocumentType, order.OrderID),
+		Copies: req.Copies,
+	}
+	if req.DocumentType == documentReceipt {
+		receipt, err := buildReceipt(ctx, h.orderRepo, h.customerRepo, h.productRepo, order)
+		if err != nil {
+			if err == errOrderNotPaid {
+				return models.NewAPIError(http.StatusConflict, "Order has not been paid")
+			}
+			log.Printf("Failed to build receipt for order %d: %v", order.OrderID, err)
+			return models.NewAPIError(http.StatusInternalServerError, "Failed to render document: "+err.Error())
+		}
+		doc.Content = receipt.ESCPOS(services.ReceiptColumns[req.PaperWidth])
+		doc.Format = services.MimeTypeESCPOS
+	} else {
+		doc.Content, err = h.renderDocument(ctx, req.DocumentType, order)
+		if err != nil {
+			log.Printf("Failed to render %s for order %d: %v", req.DocumentType, order.OrderID, err)
+			return models.NewAPIError(http.StatusInternalServerError, "Failed to render document: "+err.Error())
+		}
+	}
+
+	job := models.PrintJob{
+		PrinterName:  req.Printer,
+		DocumentType: req.DocumentType,
+		OrderID:      order.OrderID,
+		Copies:       req.Copies,
+	}
+	err = h.printService.Enqueue(ctx, &job, doc)
+	if err != nil {
+		if err == services.ErrUnknownPrinter {
+			return models.NewAPIError(http.StatusBadRequest, "Unknown printer: "+req.Printer)
+		}
+		if err == services.ErrPrintQueueFull {
+			return models.NewAPIError(http.StatusServiceUnavailable, "Print queue is full, please try again shortly")
+		}
+		if err == services.ErrPrintServiceStopped {
+			return models.NewAPIError(http.StatusServiceUnavailable, "Server is restarting, please try again shortly")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to queue print job")
+	}
+
+	return c.JSON(http.StatusAccepted, job)
+}
+
+// pickingLine is a single row on the picking list
+type pickingLine struct {
+	ProductID   int
+	ProductName string
+	Model       string
+	Quantity    int
+	InStock     string
+}
+
+// renderDocument produces the PDF for a warehouse document type
+func (h *PrintHandler) renderDocument(ctx context.Context, documentType string, order models.Order) ([]byte, error) {
+	customer, err := h.customerRepo.GetByIDWithDeleted(ctx, order.CustomerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve customer: %w", err)
+	}
+
+	items, err := h.orderRepo.GetOrderItems(ctx, order.OrderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve order items: %w", err)
+	}
+
+	shipment, err := h.orderRepo.GetShipmentTotals(ctx, order.OrderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate shipment totals: %w", err)
+	}
+
+	templateData := map[string]interface{}{
+		"Order":          order,
+		"Customer":       customer,
+		"Shipment":       shipment,
+		"GenerationDate": time.Now().Format("January 2, 2006 3:04 PM"),
+	}
+
+	if documentType == documentLabel {
+		itemCount := 0
+		for _, item := range items {
+			itemCount += item.Quantity
+		}
+		templateData["ItemCount"] = itemCount
+
+		// 4x6 inch thermal shipping label
+		return h.pdfGenerator.GenerateFromTemplateWithOptions("label/template.html", "", templateData, services.PDFOptions{
+			PageWidth:  "4in",
+			PageHeight: "6in",
+			Margin:     "3mm",
+		})
+	}
+
+	lines := make([]pickingLine, len(items))
+	for i, item := range items {
+		line := pickingLine{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			InStock:   "-",
+		}
+		if product, err := h.productRepo.GetByIDWithDeleted(ctx, item.ProductID); err == nil {
+			line.ProductName = product.ProductName
+			if product.Model != nil {
+				line.Model = *product.Model
+			}
+		}
+		if inventory, err := h.inventoryRepo.GetByProductID(ctx, item.ProductID); err == nil {
+			line.InStock = strconv.Itoa(inventory.CurrentStock)
+		}
+		lines[i] = line
+	}
+	templateData["Lines"] = lines
+
+	return h.pdfGenerator.GenerateFromTemplate("picking_list/template.html", "", templateData)
+}
@@ -1,48 +1,176 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/Cezzyy/SCMS/backend/internal/models"
 	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
 	"github.com/labstack/echo/v4"
 )
 
+// Limits on product imports
+const (
+	maxImportBytes = 5 << 20
+	maxImportRows  = 5000
+)
+
+// specFieldName limits spec filter names to the characters allowed in spec schemas
+var specFieldName = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
 // ProductHandler handles HTTP requests for products
 type ProductHandler struct {
-	productRepo *repository.ProductRepository
+	productRepo        *repository.ProductRepository
+	productHistoryRepo *repository.ProductHistoryRepository
+	specService        *services.ProductSpecService
+	auditRepo          *repository.AuditRepository
 }
 
-// NewProductHandler creates a new product handler with the provided repository
-func NewProductHandler(productRepo *repository.ProductRepository) *ProductHandler {
+// NewProductHandler creates a new product handler with the provided repositories
+func NewProductHandler(
+	productRepo *repository.ProductRepository,
+	productHistoryRepo *repository.ProductHistoryRepository,
+	specService *services.ProductSpecService,
+	auditRepo *repository.AuditRepository,
+) *ProductHandler {
 	return &ProductHandler{
-		productRepo: productRepo,
+		productRepo:        productRepo,
+		productHistoryRepo: productHistoryRepo,
+		specService:        specService,
+		auditRepo:          auditRepo,
 	}
 }
 
-// GetAllProducts returns all products
+// validateSpecs writes a 400 response when the product's technical specs don't match its category schema.
+// It returns handled=true when a response has been written.
+func (h *ProductHandler) validateSpecs(c echo.Context, product *models.Product) (bool, error) {
+	specErrors, err := h.specService.Validate(c.Request().Context(), product)
+	if err != nil {
+		return true, models.NewAPIError(http.StatusInternalServerError, "Failed to validate technical specs")
+	}
+	if len(specErrors) > 0 {
+		return true, models.NewAPIError(http.StatusBadRequest, "Technical specs do not match the category spec schema").WithDetails(map[string]interface{}{
+			"fields": specErrors,
+		})
+	}
+	return false, nil
+}
+
+// GetAllProducts returns all products. Products can be narrowed with ?category=, technical
+// spec filters such as ?spec.amperage_min=200&spec.phase=3 and column filters such as
+// ?price_max=5000&sort=price:desc (see repository.ParseListQuery), and paginated with
+// ?page= and ?page_size= (or ?limit= and ?offset=). ?deleted=true lists soft-deleted products.
 func (h *ProductHandler) GetAllProducts(c echo.Context) error {
 	ctx := c.Request().Context()
 
 	// Check for search parameter
 	searchTerm := c.QueryParam("search")
+	category := c.QueryParam("category")
+	discontinued := c.QueryParam("discontinued") == "true"
 	var products []models.Product
+	var total int
 	var err error
 
-	if searchTerm != "" {
-		products, err = h.productRepo.SearchProducts(ctx, searchTerm)
+	page, paged, message := parsePage(c)
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	specFilters, message := parseSpecFilters(c)
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	list, err := repository.ParseListQuery(c.QueryParams(), repository.ProductListColumns)
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+
+	if c.QueryParam("deleted") != "true" && (category != "" || len(specFilters) > 0 || !list.IsZero()) {
+		if category != "" && len(specFilters) > 0 {
+			specErrors, err := h.specService.ValidateFilters(ctx, category, specFilters)
+			if err != nil {
+				return models.NewAPIError(http.StatusInternalServerError, "Failed to validate spec filters")
+			}
+			if len(specErrors) > 0 {
+				return models.NewAPIError(http.StatusBadRequest, "Invalid spec filters").WithDetails(map[string]interface{}{
+					"fields": specErrors,
+				})
+			}
+		}
+		products, total, err = h.productRepo.Filter(ctx, repository.ProductFilter{
+			Search:       searchTerm,
+			Category:     category,
+			Discontinued: discontinued,
+			Specs:        specFilters,
+			List:         list,
+		}, page)
+	} else if c.QueryParam("deleted") == "true" {
+		products, total, err = h.productRepo.GetDeleted(ctx, page)
+	} else if discontinued {
+		products, total, err = h.productRepo.GetDiscontinued(ctx, page)
+	} else if searchTerm != "" {
+		products, total, err = h.productRepo.SearchProducts(ctx, searchTerm, page)
 	} else {
-		products, err = h.productRepo.GetAll(ctx)
+		products, total, err = h.productRepo.GetAll(ctx, page)
 	}
 
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve products",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve products")
+	}
+
+	return jsonPage(c, http.StatusOK, products, page, paged, total)
+}
+
+// parseSpecFilters reads spec.<name>, spec.<name>_min and spec.<name>_max query parameters,
+// returning a message when a filter is invalid
+func parseSpecFilters(c echo.Context) ([]repository.SpecFilter, string) {
+	filters := []repository.SpecFilter{}
+
+	names := []string{}
+	params := c.QueryParams()
+	for key := range params {
+		if strings.HasPrefix(key, "spec.") {
+			names = append(names, key)
+		}
+	}
+	sort.Strings(names)
+
+	for _, key := range names {
+		filter := repository.SpecFilter{
+			Field:  strings.TrimPrefix(key, "spec."),
+			Op:     repository.SpecFilterEquals,
+			Values: params[key],
+		}
+		if field, ok := strings.CutSuffix(filter.Field, "_min"); ok {
+			filter.Field, filter.Op = field, repository.SpecFilterMin
+		} else if field, ok := strings.CutSuffix(filter.Field, "_max"); ok {
+			filter.Field, filter.Op = field, repository.SpecFilterMax
+		}
+
+		if !specFieldName.MatchString(filter.Field) {
+			return nil, "Invalid spec filter " + key
+		}
+		if filter.Op != repository.SpecFilterEquals {
+			if len(filter.Values) != 1 {
+				return nil, key + " must be given once"
+			}
+			if _, err := strconv.ParseFloat(filter.Values[0], 64); err != nil {
+				return nil, key + " must be a number"
+			}
+		}
+		filters = append(filters, filter)
 	}
 
-	return c.JSON(http.StatusOK, products)
+	return filters, ""
 }
 
 // GetProductByID returns a product by ID
@@ -51,21 +179,15 @@ func (h *ProductHandler) GetProductByID(c echo.Context) error {
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid product ID",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid product ID")
 	}
 
 	product, err := h.productRepo.GetByID(ctx, id)
 	if err != nil {
-		if err.Error() == "product not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Product not found",
-			})
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Product not found")
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve product",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve product")
 	}
 
 	return c.JSON(http.StatusOK, product)
@@ -77,31 +199,32 @@ func (h *ProductHandler) CreateProduct(c echo.Context) error {
 
 	var product models.Product
 	if err := c.Bind(&product); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request payload",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
 	}
 
-	// Validate required fields
-	if product.ProductName == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Product name is required",
-		})
+	if err := c.Validate(&product); err != nil {
+		return validationError(err)
+	}
+
+	if handled, err := h.validateSpecs(c, &product); handled {
+		return err
 	}
 
 	err := h.productRepo.Create(ctx, &product)
 	if err != nil {
+		if repository.IsNotFound(err, "currency") {
+			return models.NewAPIError(http.StatusBadRequest, "Unknown currency")
+		}
 		if err == repository.ErrDuplicateKey {
-			return c.JSON(http.StatusConflict, map[string]string{
-				"error": "A product with this information already exists",
-			})
+			return models.NewAPIError(http.StatusConflict, "A product with this information already exists")
 		}
 
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to create product",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to create product")
 	}
 
+	h.specService.RecordChange(ctx, nil, product)
+	recordAudit(c, h.auditRepo, models.AuditCreate, models.AuditEntityProduct, product.ProductID, nil, product)
+
 	return c.JSON(http.StatusCreated, product)
 }
 
@@ -111,72 +234,366 @@ func (h *ProductHandler) UpdateProduct(c echo.Context) error {
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid product ID",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid product ID")
 	}
 
 	var product models.Product
 	if err := c.Bind(&product); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request payload",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
 	}
 
 	// Ensure ID in path matches ID in payload
 	product.ProductID = id
 
-	// Validate required fields
-	if product.ProductName == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Product name is required",
-		})
+	if err := c.Validate(&product); err != nil {
+		return validationError(err)
+	}
+
+	before, err := h.productRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Product not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve product")
+	}
+
+	if handled, err := h.validateSpecs(c, &product); handled {
+		return err
 	}
 
 	err = h.productRepo.Update(ctx, &product)
 	if err != nil {
-		if err.Error() == "product not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Product not found",
-			})
+		if repository.IsNotFound(err, "currency") {
+			return models.NewAPIError(http.StatusBadRequest, "Unknown currency")
+		}
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Product not found")
 		}
 		if err == repository.ErrDuplicateKey {
-			return c.JSON(http.StatusConflict, map[string]string{
-				"error": "A product with this information already exists",
-			})
+			return models.NewAPIError(http.StatusConflict, "A product with this information already exists")
 		}
 
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to update product",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update product")
 	}
 
+	h.specService.RecordChange(ctx, &before, product)
+	recordAudit(c, h.auditRepo, models.AuditUpdate, models.AuditEntityProduct, id, before, product)
+
 	return c.JSON(http.StatusOK, product)
 }
 
+// GetProductHistory returns a product's change history including technical spec diffs
+func (h *ProductHandler) GetProductHistory(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid product ID")
+	}
+
+	history, err := h.productHistoryRepo.GetByProductID(ctx, id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve product history")
+	}
+
+	return c.JSON(http.StatusOK, history)
+}
+
 // DeleteProduct deletes a product
 func (h *ProductHandler) DeleteProduct(c echo.Context) error {
 	ctx := c.Request().Context()
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid product ID",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid product ID")
+	}
+
+	before, err := h.productRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Product not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve product")
 	}
 
 	err = h.productRepo.Delete(ctx, id)
 	if err != nil {
-		if err.Error() == "product not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Product not found",
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Product not found")
+		}
+
+		var inUse *repository.ProductInUseError
+		if errors.As(err, &inUse) {
+			return models.NewAPIError(http.StatusConflict, "Product still has stock and cannot be deleted; discontinue it instead").WithDetails(map[string]interface{}{
+				"documents":     inUse.Documents,
+				"stock_on_hand": inUse.StockOnHand,
 			})
 		}
 
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to delete product",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to delete product")
 	}
 
+	recordAudit(c, h.auditRepo, models.AuditDelete, models.AuditEntityProduct, id, before, nil)
+
 	return c.NoContent(http.StatusNoContent)
-} 
\ No newline at end of file
+}
+
+// RestoreProduct brings back a soft-deleted product
+func (h *ProductHandler) RestoreProduct(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid product ID")
+	}
+
+	if err := h.productRepo.Restore(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Deleted product not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to restore product")
+	}
+
+	product, err := h.productRepo.GetByID(ctx, id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve product")
+	}
+
+	recordAudit(c, h.auditRepo, "restore", models.AuditEntityProduct, id, nil, product)
+
+	return c.JSON(http.StatusOK, product)
+}
+
+// PurgeProduct permanently removes a soft-deleted product that is on no quotation or order
+func (h *ProductHandler) PurgeProduct(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid product ID")
+	}
+
+	before, err := h.productRepo.GetByIDWithDeleted(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Product not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve product")
+	}
+
+	err = h.productRepo.Purge(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Product not found")
+		}
+		if err == repository.ErrNotDeleted {
+			return models.NewAPIError(http.StatusConflict, "Product must be deleted before it can be purged")
+		}
+
+		var inUse *repository.ProductInUseError
+		if errors.As(err, &inUse) {
+			return models.NewAPIError(http.StatusConflict, "Product is in use and cannot be purged").WithDetails(map[string]interface{}{
+				"documents":     inUse.Documents,
+				"stock_on_hand": inUse.StockOnHand,
+			})
+		}
+
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to purge product")
+	}
+
+	recordAudit(c, h.auditRepo, "purge", models.AuditEntityProduct, id, before, nil)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// DiscontinueProduct stops a product from being sold while keeping it on historical documents
+func (h *ProductHandler) DiscontinueProduct(c echo.Context) error {
+	return h.setDiscontinued(c, true)
+}
+
+// ReinstateProduct makes a discontinued product available again
+func (h *ProductHandler) ReinstateProduct(c echo.Context) error {
+	return h.setDiscontinued(c, false)
+}
+
+// setDiscontinued discontinues or reinstates the product in the path and returns it
+func (h *ProductHandler) setDiscontinued(c echo.Context, discontinued bool) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid product ID")
+	}
+
+	before, err := h.productRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Product not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve product")
+	}
+
+	if discontinued {
+		err = h.productRepo.Discontinue(ctx, id)
+	} else {
+		err = h.productRepo.Reinstate(ctx, id)
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Product not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update product")
+	}
+
+	product, err := h.productRepo.GetByID(ctx, id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve product")
+	}
+
+	action := "discontinue"
+	if !discontinued {
+		action = "reinstate"
+	}
+	recordAudit(c, h.auditRepo, action, models.AuditEntityProduct, id, before, product)
+
+	return c.JSON(http.StatusOK, product)
+}
+
+// ImportProducts creates products from a CSV or Excel (.xlsx) file uploaded as the "file"
+// form field. Columns are matched to product fields by their header, or by an optional
+// "mapping" form field holding a JSON object of header name to field name; see
+// services.ProductSpecService.ParseProductImport. Every row is validated like a created
+// product, including its technical specs against the category schema. With dry_run=true the
+// rows are only checked and returned as a preview; otherwise the products are created in one
+// transaction, and nothing is imported if any row has errors.
+func (h *ProductHandler) ImportProducts(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "A CSV or Excel file must be uploaded as the file field")
+	}
+	if file.Size > maxImportBytes {
+		return models.NewAPIError(http.StatusRequestEntityTooLarge, fmt.Sprintf("File exceeds the %d MB limit", maxImportBytes>>20))
+	}
+
+	mapping := map[string]string{}
+	if value := c.FormValue("mapping"); value != "" {
+		if err := json.Unmarshal([]byte(value), &mapping); err != nil {
+			return models.NewAPIError(http.StatusBadRequest, "mapping must be a JSON object of column header to product field")
+		}
+	}
+	dryRun := c.FormValue("dry_run") == "true"
+
+	src, err := file.Open()
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid file upload")
+	}
+	defer src.Close()
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid file upload")
+	}
+
+	spreadsheet, err := services.ReadSpreadsheet(file.Filename, data)
+	if err != nil {
+		return importFileError(err)
+	}
+	rows, err := h.specService.ParseProductImport(ctx, spreadsheet, mapping)
+	if err != nil {
+		return importFileError(err)
+	}
+
+	return h.importProducts(c, rows, dryRun)
+}
+
+// importFileError is returned for an import file that could not be read
+func importFileError(err error) error {
+	var formatErr *services.SpreadsheetError
+	if errors.As(err, &formatErr) {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid import file: "+formatErr.Error())
+	}
+	return models.NewAPIError(http.StatusInternalServerError, "Failed to read import file")
+}
+
+// importProducts validates the rows read from an import file and, unless dryRun is set and
+// all of them are valid, creates their products
+func (h *ProductHandler) importProducts(c echo.Context, rows []models.ProductImportRow, dryRun bool) error {
+	ctx := c.Request().Context()
+
+	if len(rows) == 0 {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid import file: the file has no products")
+	}
+	if len(rows) > maxImportRows {
+		return models.NewAPIError(http.StatusBadRequest, fmt.Sprintf("Invalid import file: at most %d products can be imported at once", maxImportRows))
+	}
+
+	rejected := 0
+	for i := range rows {
+		row := &rows[i]
+		if err := c.Validate(&row.Product); err != nil {
+			var fields ValidationErrors
+			if !errors.As(err, &fields) {
+				return models.NewAPIError(http.StatusInternalServerError, "Failed to validate products")
+			}
+			for _, field := range fields {
+				row.Errors = append(row.Errors, models.ImportError{Field: field.Field, Message: field.Message})
+			}
+		}
+
+		specErrors, err := h.specService.Validate(ctx, &row.Product)
+		if err != nil {
+			return models.NewAPIError(http.StatusInternalServerError, "Failed to validate technical specs")
+		}
+		for _, specErr := range specErrors {
+			row.Errors = append(row.Errors, models.ImportError{Field: "spec." + specErr.Field, Message: specErr.Message})
+		}
+
+		if len(row.Errors) > 0 {
+			rejected++
+		}
+	}
+
+	if dryRun {
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"dry_run":  true,
+			"valid":    len(rows) - rejected,
+			"rejected": rejected,
+			"rows":     rows,
+		})
+	}
+
+	if rejected > 0 {
+		invalid := []models.ProductImportRow{}
+		for _, row := range rows {
+			if len(row.Errors) > 0 {
+				invalid = append(invalid, row)
+			}
+		}
+		return models.NewAPIError(http.StatusUnprocessableEntity, fmt.Sprintf("Nothing was imported: %d row(s) have errors", rejected)).WithDetails(map[string]interface{}{
+			"rows": invalid,
+		})
+	}
+
+	products := make([]models.Product, len(rows))
+	for i, row := range rows {
+		products[i] = row.Product
+	}
+	if err := h.productRepo.CreateMany(ctx, products); err != nil {
+		if err == repository.ErrDuplicateKey {
+			return models.NewAPIError(http.StatusConflict, "Nothing was imported: a product in the file already exists")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Nothing was imported: failed to create products")
+	}
+
+	for _, product := range products {
+		h.specService.RecordChange(ctx, nil, product)
+		recordAudit(c, h.auditRepo, models.AuditCreate, models.AuditEntityProduct, product.ProductID, nil, product)
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"dry_run":  false,
+		"imported": len(products),
+		"products": products,
+	})
+}
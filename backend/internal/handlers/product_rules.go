@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// checkProductsAvailable returns a validation message when any of the products is deleted
+// or discontinued
+func checkProductsAvailable(ctx context.Context, productRepo *repository.ProductRepository, productIDs []int) (string, error) {
+	if len(productIDs) == 0 {
+		return "", nil
+	}
+
+	deleted, err := productRepo.GetDeletedIDs(ctx, productIDs)
+	if err != nil {
+		return "", err
+	}
+	if len(deleted) > 0 {
+		return "Deleted products cannot be sold: " + joinIDs(deleted), nil
+	}
+
+	discontinued, err := productRepo.GetDiscontinuedIDs(ctx, productIDs)
+	if err != nil {
+		return "", err
+	}
+	if len(discontinued) == 0 {
+		return "", nil
+	}
+
+	return "Discontinued products cannot be sold: " + joinIDs(discontinued), nil
+}
+
+// joinIDs formats IDs as a comma separated list
+func joinIDs(values []int) string {
+	ids := make([]string, len(values))
+	for i, id := range values {
+		ids[i] = strconv.Itoa(id)
+	}
+	return strings.Join(ids, ", ")
+}
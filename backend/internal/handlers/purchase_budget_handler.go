@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/labstack/echo/v4"
+)
+
+// PurchaseBudgetHandler handles HTTP requests for department purchasing budgets
+type PurchaseBudgetHandler struct {
+	budgetRepo *repository.PurchaseBudgetRepository
+}
+
+// NewPurchaseBudgetHandler creates a new purchase budget handler
+func NewPurchaseBudgetHandler(budgetRepo *repository.PurchaseBudgetRepository) *PurchaseBudgetHandler {
+	return &PurchaseBudgetHandler{
+		budgetRepo: budgetRepo,
+	}
+}
+
+// PurchaseBudgetRequest creates or updates a budget. Periods are YYYY-MM-DD and include
+// both ends.
+type PurchaseBudgetRequest struct {
+	Department  string  `json:"department" validate:"notblank"`
+	PeriodStart string  `json:"period_start" validate:"datetime=2006-01-02"`
+	PeriodEnd   string  `json:"period_end" validate:"datetime=2006-01-02"`
+	Amount      float64 `json:"amount" validate:"gte=0"`
+	Notes       string  `json:"notes"`
+}
+
+// GetBudgets returns budgets with their consumption, optionally filtered by ?department=
+// and ?on= (YYYY-MM-DD) for the budgets covering that day
+func (h *PurchaseBudgetHandler) GetBudgets(c echo.Context) error {
+	filter := repository.BudgetFilter{Department: c.QueryParam("department")}
+	if value := c.QueryParam("on"); value != "" {
+		on, err := time.Parse(deliveryDateLayout, value)
+		if err != nil {
+			return models.NewAPIError(http.StatusBadRequest, "Invalid on date, expected YYYY-MM-DD")
+		}
+		filter.On = &on
+	}
+
+	budgets, err := h.budgetRepo.GetAll(c.Request().Context(), filter)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve budgets")
+	}
+
+	return jsonList(c, http.StatusOK, budgets)
+}
+
+// GetBudget returns a budget with what has been consumed, what is pending approval and what remains
+func (h *PurchaseBudgetHandler) GetBudget(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid budget ID")
+	}
+
+	budget, err := h.budgetRepo.GetByID(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Budget not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve budget")
+	}
+
+	return c.JSON(http.StatusOK, budget)
+}
+
+// CreateBudget sets a department's purchasing budget for a period
+func (h *PurchaseBudgetHandler) CreateBudget(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	budget, err := bindPurchaseBudget(c)
+	if err != nil {
+		return validationError(err)
+	}
+	if user := appmw.UserFromContext(c); user != nil {
+		budget.CreatedBy = &user.UserID
+	}
+
+	if err := h.budgetRepo.Create(ctx, &budget); err != nil {
+		if err == repository.ErrBudgetOverlap {
+			return models.NewAPIError(http.StatusConflict, "This period overlaps another budget for the department")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to create budget")
+	}
+
+	usage, err := h.budgetRepo.GetByID(ctx, budget.BudgetID)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve budget")
+	}
+
+	return c.JSON(http.StatusCreated, usage)
+}
+
+// UpdateBudget changes a budget's department, period, amount or notes
+func (h *PurchaseBudgetHandler) UpdateBudget(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid budget ID")
+	}
+
+	budget, err := bindPurchaseBudget(c)
+	if err != nil {
+		return validationError(err)
+	}
+	budget.BudgetID = id
+
+	if err := h.budgetRepo.Update(ctx, &budget); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Budget not found")
+		}
+		if err == repository.ErrBudgetOverlap {
+			return models.NewAPIError(http.StatusConflict, "This period overlaps another budget for the department")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update budget")
+	}
+
+	usage, err := h.budgetRepo.GetByID(ctx, id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve budget")
+	}
+
+	return c.JSON(http.StatusOK, usage)
+}
+
+// DeleteBudget removes a budget
+func (h *PurchaseBudgetHandler) DeleteBudget(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid budget ID")
+	}
+
+	if err := h.budgetRepo.Delete(c.Request().Context(), id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Budget not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to delete budget")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// bindPurchaseBudget reads and validates a budget from the request body, returning an
+// error when it is invalid
+func bindPurchaseBudget(c echo.Context) (models.PurchaseBudget, error) {
+	var req PurchaseBudgetRequest
+	if err := c.Bind(&req); err != nil {
+		return models.PurchaseBudget{}, errors.New("Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return models.PurchaseBudget{}, err
+	}
+
+	budget := models.PurchaseBudget{
+		Department: strings.TrimSpace(req.Department),
+		Amount:     req.Amount,
+		Notes:      strings.TrimSpace(req.Notes),
+	}
+	budget.PeriodStart, _ = time.Parse(deliveryDateLayout, req.PeriodStart)
+	budget.PeriodEnd, _ = time.Parse(deliveryDateLayout, req.PeriodEnd)
+	if budget.PeriodEnd.Before(budget.PeriodStart) {
+		return budget, errors.New("period_end cannot be before period_start")
+	}
+
+	return budget, nil
+}
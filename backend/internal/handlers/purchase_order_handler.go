@@ -0,0 +1,521 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// PurchaseOrderHandler handles HTTP requests for purchase orders
+type PurchaseOrderHandler struct {
+	purchaseOrderRepo *repository.PurchaseOrderRepository
+	supplierRepo      *repository.SupplierRepository
+	purchasingService *services.PurchasingService
+	matchService      *services.InvoiceMatchService
+}
+
+// NewPurchaseOrderHandler creates a new purchase order handler
+func NewPurchaseOrderHandler(
+	purchaseOrderRepo *repository.PurchaseOrderRepository,
+	supplierRepo *repository.SupplierRepository,
+	purchasingService *services.PurchasingService,
+	matchService *services.InvoiceMatchService,
+) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{
+		purchaseOrderRepo: purchaseOrderRepo,
+		supplierRepo:      supplierRepo,
+		purchasingService: purchasingService,
+		matchService:      matchService,
+	}
+}
+
+// PurchaseOrderRequest creates a purchase order with a chosen supplier. Items without a unit
+// cost use the supplier's listed price. The department whose budget pays for it defaults to
+// the creator's.
+type PurchaseOrderRequest struct {
+	SupplierID   int                        `json:"supplier_id" validate:"required"`
+	ExpectedDate string                     `json:"expected_date" validate:"omitempty,datetime=2006-01-02"`
+	Notes        string                     `json:"notes"`
+	Department   string                     `json:"department"`
+	Items        []models.PurchaseOrderItem `json:"items" validate:"min=1,dive"`
+}
+
+// GeneratePurchaseOrdersRequest asks for draft purchase orders with suppliers chosen
+// automatically. Without items, every low-stock product is reordered.
+type GeneratePurchaseOrdersRequest struct {
+	NeedBy     string                  `json:"need_by" validate:"omitempty,datetime=2006-01-02"`
+	Department string                  `json:"department"`
+	Items      []services.PurchaseItem `json:"items" validate:"dive"`
+}
+
+// PurchaseOrderStatusRequest changes the status of a purchase order
+type PurchaseOrderStatusRequest struct {
+	Status string `json:"status" validate:"required"`
+}
+
+// PurchaseOrderApprovalRequest approves or rejects a purchase order that exceeded its budget
+type PurchaseOrderApprovalRequest struct {
+	Note string `json:"note"`
+}
+
+// ReceivePurchaseOrderRequest lists the quantities received per purchase order line. Lines
+// left out are taken as received in full.
+type ReceivePurchaseOrderRequest struct {
+	Items []struct {
+		PurchaseOrderItemID int `json:"purchase_order_item_id" validate:"required"`
+		Quantity            int `json:"quantity" validate:"gte=0"`
+	} `json:"items" validate:"dive"`
+}
+
+// CostPolicyRequest changes how received goods update product cost prices
+type CostPolicyRequest struct {
+	Policy string `json:"policy" validate:"oneof=moving_average fifo"`
+}
+
+// GetPurchaseOrders returns purchase orders, optionally filtered by ?supplier_id=, ?status=,
+// ?department= and ?budget_id=
+func (h *PurchaseOrderHandler) GetPurchaseOrders(c echo.Context) error {
+	filter := repository.PurchaseOrderFilter{
+		Status:     c.QueryParam("status"),
+		Department: c.QueryParam("department"),
+	}
+	if value := c.QueryParam("supplier_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return models.NewAPIError(http.StatusBadRequest, "Invalid supplier ID")
+		}
+		filter.SupplierID = id
+	}
+	if value := c.QueryParam("budget_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return models.NewAPIError(http.StatusBadRequest, "Invalid budget ID")
+		}
+		filter.BudgetID = id
+	}
+
+	orders, err := h.purchaseOrderRepo.GetAll(c.Request().Context(), filter)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve purchase orders")
+	}
+
+	return jsonList(c, http.StatusOK, orders)
+}
+
+// GetPurchaseOrder returns a purchase order with its items
+func (h *PurchaseOrderHandler) GetPurchaseOrder(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid purchase order ID")
+	}
+
+	order, err := h.purchaseOrderRepo.GetByID(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Purchase order not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve purchase order")
+	}
+
+	return c.JSON(http.StatusOK, order)
+}
+
+// CreatePurchaseOrder creates a draft purchase order with a chosen supplier
+func (h *PurchaseOrderHandler) CreatePurchaseOrder(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req PurchaseOrderRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	expected, err := parseNeedBy(req.ExpectedDate)
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid expected_date, expected YYYY-MM-DD")
+	}
+
+	if _, err := h.supplierRepo.GetByID(ctx, req.SupplierID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Supplier not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve supplier")
+	}
+
+	prices, err := h.supplierRepo.GetSupplierPrices(ctx, req.SupplierID)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve supplier prices")
+	}
+	listed := map[int]models.SupplierPrice{}
+	for _, price := range prices {
+		listed[price.ProductID] = price
+	}
+
+	for i := range req.Items {
+		item := &req.Items[i]
+		if price, ok := listed[item.ProductID]; ok {
+			if item.UnitCost == 0 {
+				item.UnitCost = price.UnitCost
+			}
+			item.LeadTimeDays = price.LeadTimeDays
+		}
+	}
+
+	order := models.PurchaseOrder{
+		SupplierID:   req.SupplierID,
+		Status:       models.PurchaseOrderDraft,
+		ExpectedDate: expected,
+		Notes:        strings.TrimSpace(req.Notes),
+		Department:   purchaseDepartment(c, req.Department),
+		Items:        req.Items,
+	}
+	if user := appmw.UserFromContext(c); user != nil {
+		order.CreatedBy = &user.UserID
+	}
+
+	if err := h.purchaseOrderRepo.Create(ctx, &order); err != nil {
+		if err == repository.ErrReferencedRecord {
+			return models.NewAPIError(http.StatusBadRequest, "One or more products do not exist")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to create purchase order")
+	}
+
+	return c.JSON(http.StatusCreated, order)
+}
+
+// GeneratePurchaseOrders creates draft purchase orders, choosing each product's supplier
+// automatically from the preferred flag, price and lead time
+func (h *PurchaseOrderHandler) GeneratePurchaseOrders(c echo.Context) error {
+	var req GeneratePurchaseOrdersRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	needBy, err := parseNeedBy(req.NeedBy)
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid need_by date, expected YYYY-MM-DD")
+	}
+
+	var createdBy *int
+	if user := appmw.UserFromContext(c); user != nil {
+		createdBy = &user.UserID
+	}
+
+	result, err := h.purchasingService.GeneratePurchaseOrders(c.Request().Context(), req.Items, needBy, purchaseDepartment(c, req.Department), createdBy)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to generate purchase orders")
+	}
+
+	return c.JSON(http.StatusCreated, result)
+}
+
+// UpdatePurchaseOrderStatus places or cancels a purchase order. Placing an order checks its
+// department's budget and may leave it pending approval instead.
+func (h *PurchaseOrderHandler) UpdatePurchaseOrderStatus(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid purchase order ID")
+	}
+
+	var req PurchaseOrderStatusRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	if req.Status == models.PurchaseOrderOrdered {
+		_, err = h.purchasingService.PlaceOrder(c.Request().Context(), id)
+	} else {
+		err = h.purchaseOrderRepo.UpdateStatus(c.Request().Context(), id, req.Status)
+	}
+	if err != nil {
+		var overrun *repository.BudgetExceededError
+		if errors.As(err, &overrun) {
+			return models.NewAPIError(http.StatusConflict, "Purchase order exceeds the department's remaining budget").WithDetails(map[string]interface{}{
+				"budget_id": overrun.BudgetID,
+				"remaining": overrun.Remaining,
+				"amount":    overrun.Amount,
+			})
+		}
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Purchase order not found")
+		}
+		if err == repository.ErrPurchaseOrderTransition {
+			return models.NewAPIError(http.StatusConflict, "Purchase order cannot be changed to "+req.Status)
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update purchase order status")
+	}
+
+	order, err := h.purchaseOrderRepo.GetByID(c.Request().Context(), id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve purchase order")
+	}
+
+	return c.JSON(http.StatusOK, order)
+}
+
+// ApprovePurchaseOrder approves a purchase order that exceeded its budget, placing it
+func (h *PurchaseOrderHandler) ApprovePurchaseOrder(c echo.Context) error {
+	return h.decideApproval(c, true)
+}
+
+// RejectPurchaseOrder rejects a purchase order that exceeded its budget. A note explaining
+// why is required.
+func (h *PurchaseOrderHandler) RejectPurchaseOrder(c echo.Context) error {
+	return h.decideApproval(c, false)
+}
+
+// decideApproval records an admin's decision on a purchase order pending approval
+func (h *PurchaseOrderHandler) decideApproval(c echo.Context, approve bool) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid purchase order ID")
+	}
+
+	var req PurchaseOrderApprovalRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+	req.Note = strings.TrimSpace(req.Note)
+	if !approve && req.Note == "" {
+		return models.NewAPIError(http.StatusBadRequest, "A note explaining the rejection is required")
+	}
+
+	var decidedBy *int
+	if user := appmw.UserFromContext(c); user != nil {
+		decidedBy = &user.UserID
+	}
+
+	if err := h.purchaseOrderRepo.DecideApproval(ctx, id, approve, decidedBy, req.Note); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Purchase order not found")
+		}
+		if err == repository.ErrPurchaseOrderTransition {
+			return models.NewAPIError(http.StatusConflict, "Purchase order is not pending approval")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to record approval decision")
+	}
+
+	order, err := h.purchaseOrderRepo.GetByID(ctx, id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve purchase order")
+	}
+
+	return c.JSON(http.StatusOK, order)
+}
+
+// AddPurchaseOrderCharge adds a freight, duty or brokerage charge to a purchase order
+func (h *PurchaseOrderHandler) AddPurchaseOrderCharge(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid purchase order ID")
+	}
+
+	charge := models.PurchaseOrderCharge{Allocation: models.AllocateByValue}
+	if err := c.Bind(&charge); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&charge); err != nil {
+		return validationError(err)
+	}
+	charge.PurchaseOrderID = id
+	charge.Description = strings.TrimSpace(charge.Description)
+
+	if err := h.purchaseOrderRepo.AddCharge(c.Request().Context(), &charge); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Purchase order not found")
+		}
+		if err == repository.ErrPurchaseOrderClosed {
+			return models.NewAPIError(http.StatusConflict, "Charges cannot be changed once a purchase order is received, cancelled or rejected")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to add purchase order charge")
+	}
+
+	return c.JSON(http.StatusCreated, charge)
+}
+
+// DeletePurchaseOrderCharge removes a charge from a purchase order
+func (h *PurchaseOrderHandler) DeletePurchaseOrderCharge(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid purchase order ID")
+	}
+	chargeID, err := strconv.Atoi(c.Param("charge_id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid charge ID")
+	}
+
+	if err := h.purchaseOrderRepo.DeleteCharge(c.Request().Context(), id, chargeID); err != nil {
+		switch {
+		case repository.IsNotFound(err, "purchase order"):
+			return models.NewAPIError(http.StatusNotFound, "Purchase order not found")
+		case repository.IsNotFound(err, "purchase order charge"):
+			return models.NewAPIError(http.StatusNotFound, "Charge not found")
+		case err == repository.ErrPurchaseOrderClosed:
+			return models.NewAPIError(http.StatusConflict, "Charges cannot be changed once a purchase order is received, cancelled or rejected")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to delete purchase order charge")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetLandedCost shows how a purchase order's charges are spread across its lines and the
+// resulting landed unit cost of each product
+func (h *PurchaseOrderHandler) GetLandedCost(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid purchase order ID")
+	}
+
+	order, err := h.purchaseOrderRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Purchase order not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve purchase order")
+	}
+
+	landed, err := h.purchasingService.LandedCost(ctx, order)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to calculate landed cost")
+	}
+
+	return c.JSON(http.StatusOK, landed)
+}
+
+// ReceivePurchaseOrder records the goods received on an ordered purchase order, adding them
+// to stock and updating product cost prices from their landed cost
+func (h *PurchaseOrderHandler) ReceivePurchaseOrder(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid purchase order ID")
+	}
+
+	var req ReceivePurchaseOrderRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	received := map[int]int{}
+	for _, item := range req.Items {
+		received[item.PurchaseOrderItemID] = item.Quantity
+	}
+
+	var receivedBy *int
+	if user := appmw.UserFromContext(c); user != nil {
+		receivedBy = &user.UserID
+	}
+
+	landed, err := h.purchasingService.Receive(c.Request().Context(), id, received, receivedBy)
+	if err != nil {
+		var missing *repository.MissingInventoryError
+		switch {
+		case repository.IsNotFound(err, "purchase order"):
+			return models.NewAPIError(http.StatusNotFound, "Purchase order not found")
+		case err == repository.ErrPurchaseOrderNotReceivable:
+			return models.NewAPIError(http.StatusConflict, "Only ordered purchase orders can be received")
+		case err == services.ErrUnknownPurchaseOrderItem:
+			return models.NewAPIError(http.StatusBadRequest, "One or more items are not on this purchase order")
+		case errors.As(err, &missing):
+			return models.NewAPIError(http.StatusConflict, fmt.Sprintf("Product %d has no inventory record to receive into", missing.ProductID))
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to receive purchase order")
+	}
+
+	// Invoices that arrived before the goods can now be matched on quantities too
+	if err := h.matchService.MatchPurchaseOrder(c.Request().Context(), id); err != nil {
+		log.Printf("Failed to match invoices for purchase order %d: %v", id, err)
+	}
+
+	return c.JSON(http.StatusOK, landed)
+}
+
+// GetCostPolicy returns how received goods update product cost prices
+func (h *PurchaseOrderHandler) GetCostPolicy(c echo.Context) error {
+	policy, err := h.purchasingService.CostPolicy(c.Request().Context())
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve cost policy")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"policy": policy,
+	})
+}
+
+// UpdateCostPolicy switches product costing between moving_average and fifo
+func (h *PurchaseOrderHandler) UpdateCostPolicy(c echo.Context) error {
+	var req CostPolicyRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	var updatedBy *int
+	if user := appmw.UserFromContext(c); user != nil {
+		updatedBy = &user.UserID
+	}
+
+	if _, err := h.purchasingService.SetCostPolicy(c.Request().Context(), req.Policy, updatedBy); err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update cost policy")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"policy": req.Policy,
+	})
+}
+
+// purchaseDepartment returns the department a purchase order is charged to: the one requested,
+// or else the department of the user creating it
+func purchaseDepartment(c echo.Context, requested string) *string {
+	if department := strings.TrimSpace(requested); department != "" {
+		return &department
+	}
+	if user := appmw.UserFromContext(c); user != nil && user.Department != nil && *user.Department != "" {
+		department := *user.Department
+		return &department
+	}
+	return nil
+}
+
+// parseNeedBy parses an optional YYYY-MM-DD date
+func parseNeedBy(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.Parse(deliveryDateLayout, value)
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/labstack/echo/v4"
+)
+
+// maxBuilderLines is the most lines a quotation built a step at a time can have
+const maxBuilderLines = 500
+
+// QuotationBuilderRequest is the header of a quotation to build a step at a time. The quote
+// date is the day it is submitted; without a validity date it gets the default validity.
+type QuotationBuilderRequest struct {
+	CustomerID   int        `json:"customer_id" validate:"required"`
+	Currency     string     `json:"currency" validate:"omitempty,len=3"`
+	Source       *string    `json:"source"`
+	ValidityDate *time.Time `json:"validity_date"`
+}
+
+// StartQuotationBuilder starts a session for building a quotation a line at a time instead of
+// in one request. Lines are added and removed one by one, priced as the quotation stands, and
+// the session is submitted as a quotation once complete. Sessions left unchanged for a day,
+// or QUOTATION_BUILDER_TTL_HOURS, are abandoned.
+func (h *QuotationHandler) StartQuotationBuilder(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req QuotationBuilderRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	if message := checkSource(req.Source); message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+	customer, err := h.customerRepo.GetByID(ctx, req.CustomerID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusBadRequest, "Customer not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve customer")
+	}
+	if customer.ArchivedAt != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Customer is archived")
+	}
+	if _, err := h.currencies.Rate(ctx, req.Currency, time.Now()); err != nil {
+		if apiErr := currencyError(err); apiErr != nil {
+			return apiErr
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to look up exchange rate")
+	}
+
+	session := models.QuotationBuilderSession{
+		CustomerID:   req.CustomerID,
+		Currency:     req.Currency,
+		Source:       req.Source,
+		ValidityDate: req.ValidityDate,
+		ExpiresAt:    h.builderCleaner.ExpiresAt(),
+	}
+	if user := appmw.UserFromContext(c); user != nil {
+		session.CreatedBy = &user.UserID
+	}
+	if err := h.builderRepo.Create(ctx, &session); err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to start quotation builder")
+	}
+
+	return c.JSON(http.StatusCreated, session)
+}
+
+// GetQuotationBuilder returns a builder session with its lines
+func (h *QuotationHandler) GetQuotationBuilder(c echo.Context) error {
+	session, err := h.builderSession(c)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, session)
+}
+
+// AddQuotationBuilderLine adds a line to a builder session, refusing products that are not
+// available or not sold to the customer, and returns the session
+func (h *QuotationHandler) AddQuotationBuilderLine(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	session, err := h.builderSession(c)
+	if err != nil {
+		return err
+	}
+
+	var req QuotationPreviewItem
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	if len(session.Lines) >= maxBuilderLines {
+		return models.NewAPIError(http.StatusBadRequest, "A quotation can have at most "+strconv.Itoa(maxBuilderLines)+" lines")
+	}
+	if apiErr := h.checkProducts(ctx, session.CustomerID, []models.QuotationItem{{ProductID: req.ProductID}}); apiErr != nil {
+		return apiErr
+	}
+
+	line := models.QuotationBuilderLine{
+		BuilderSessionID: session.BuilderSessionID,
+		ProductID:        req.ProductID,
+		Quantity:         req.Quantity,
+		UnitPrice:        req.UnitPrice,
+		Discount:         req.Discount,
+		DiscountType:     req.DiscountType,
+		DiscountValue:    req.DiscountValue,
+	}
+	if err := h.builderRepo.AddLine(ctx, &line, h.builderCleaner.ExpiresAt()); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Quotation builder session not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to add line")
+	}
+
+	session, err = h.builderSession(c)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, session)
+}
+
+// RemoveQuotationBuilderLine removes a line from a builder session and returns the session
+func (h *QuotationHandler) RemoveQuotationBuilderLine(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	session, err := h.builderSession(c)
+	if err != nil {
+		return err
+	}
+	lineID, err := strconv.Atoi(c.Param("line_id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid line ID")
+	}
+
+	if err := h.builderRepo.RemoveLine(ctx, session.BuilderSessionID, lineID, h.builderCleaner.ExpiresAt()); err != nil {
+		if repository.IsNotFound(err, "quotation builder line") {
+			return models.NewAPIError(http.StatusNotFound, "Line not found")
+		}
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Quotation builder session not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to remove line")
+	}
+
+	session, err = h.builderSession(c)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, session)
+}
+
+// PriceQuotationBuilder prices a builder session's lines as they stand, as
+// PreviewQuotationPrice does, with flags for anything that would stop it being submitted
+func (h *QuotationHandler) PriceQuotationBuilder(c echo.Context) error {
+	session, err := h.builderSession(c)
+	if err != nil {
+		return err
+	}
+
+	preview, _, err := h.previewQuotation(c.Request().Context(), builderPreviewRequest(session))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, preview)
+}
+
+// SubmitQuotationBuilder creates the quotation a builder session holds, validating and pricing
+// it as CreateQuotation does, and ends the session
+func (h *QuotationHandler) SubmitQuotationBuilder(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	session, err := h.builderSession(c)
+	if err != nil {
+		return err
+	}
+	if len(session.Lines) == 0 {
+		return models.NewAPIError(http.StatusBadRequest, "Add at least one line before submitting")
+	}
+
+	_, items, err := h.previewQuotation(ctx, builderPreviewRequest(session))
+	if err != nil {
+		return err
+	}
+	draft := models.Quotation{
+		CustomerID: session.CustomerID,
+		Currency:   session.Currency,
+		Source:     session.Source,
+	}
+	if session.ValidityDate != nil {
+		draft.ValidityDate = *session.ValidityDate
+	}
+
+	quotation, items, pricing, err := h.createQuotation(c, draft, items)
+	if err != nil {
+		return err
+	}
+	if err := h.builderRepo.Delete(ctx, session.BuilderSessionID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		log.Printf("Failed to end quotation builder session %d: %v", session.BuilderSessionID, err)
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"quotation": quotation,
+		"items":     items,
+		"pricing":   pricing,
+	})
+}
+
+// DiscardQuotationBuilder ends a builder session without creating a quotation
+func (h *QuotationHandler) DiscardQuotationBuilder(c echo.Context) error {
+	session, err := h.builderSession(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.builderRepo.Delete(c.Request().Context(), session.BuilderSessionID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Quotation builder session not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to discard quotation builder session")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// builderSession loads the builder session named by the :id parameter. Sessions belong to the
+// user who started them and are not found for anyone else.
+func (h *QuotationHandler) builderSession(c echo.Context) (models.QuotationBuilderSession, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.QuotationBuilderSession{}, models.NewAPIError(http.StatusBadRequest, "Invalid quotation builder session ID")
+	}
+
+	session, err := h.builderRepo.GetByID(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return session, models.NewAPIError(http.StatusNotFound, "Quotation builder session not found")
+		}
+		return session, models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve quotation builder session")
+	}
+
+	var userID *int
+	if user := appmw.UserFromContext(c); user != nil {
+		userID = &user.UserID
+	}
+	if (session.CreatedBy == nil) != (userID == nil) || (userID != nil && *session.CreatedBy != *userID) {
+		return session, models.NewAPIError(http.StatusNotFound, "Quotation builder session not found")
+	}
+	return session, nil
+}
+
+// builderPreviewRequest is the draft quotation a builder session holds
+func builderPreviewRequest(session models.QuotationBuilderSession) QuotationPreviewRequest {
+	req := QuotationPreviewRequest{
+		CustomerID: session.CustomerID,
+		Currency:   session.Currency,
+		Items:      make([]QuotationPreviewItem, len(session.Lines)),
+	}
+	for i, line := range session.Lines {
+		req.Items[i] = QuotationPreviewItem{
+			ProductID:     line.ProductID,
+			Quantity:      line.Quantity,
+			UnitPrice:     line.UnitPrice,
+			Discount:      line.Discount,
+			DiscountType:  line.DiscountType,
+			DiscountValue: line.DiscountValue,
+		}
+	}
+	return req
+}
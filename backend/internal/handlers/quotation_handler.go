@@ -2,28 +2,65 @@ package handlers
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
-	"os"
-	"os/exec"
+	"net/mail"
 	"strconv"
 	"strings"
 	"time"
 
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
 	"github.com/Cezzyy/SCMS/backend/internal/models"
 	"github.com/Cezzyy/SCMS/backend/internal/repository"
 	"github.com/Cezzyy/SCMS/backend/internal/services"
 	"github.com/labstack/echo/v4"
 )
 
+// QuotationPreviewItem is a draft quotation line. Lines without a unit price are priced at
+// the product's list price, converted to the quotation's currency; lines without a discount
+// get their quantity break or the customer's tier discount.
+type QuotationPreviewItem struct {
+	ProductID     int      `json:"product_id" validate:"required"`
+	Quantity      int      `json:"quantity" validate:"gt=0"`
+	UnitPrice     *float64 `json:"unit_price" validate:"omitnil,gte=0"`
+	Discount      float64  `json:"discount" validate:"gte=0"`
+	DiscountType  string   `json:"discount_type" validate:"omitempty,oneof=none percent amount"`
+	DiscountValue float64  `json:"discount_value" validate:"gte=0"`
+}
+
+// QuotationPreviewRequest is a draft quotation to price without saving it
+type QuotationPreviewRequest struct {
+	CustomerID int                    `json:"customer_id" validate:"required"`
+	Currency   string                 `json:"currency" validate:"omitempty,len=3"`
+	Items      []QuotationPreviewItem `json:"items" validate:"dive"`
+}
+
 // QuotationHandler handles HTTP requests for quotations
 type QuotationHandler struct {
-	quotationRepo *repository.QuotationRepository
-	customerRepo  *repository.CustomerRepository
-	productRepo   *repository.ProductRepository
-	pdfGenerator  *services.PDFGenerator
+	quotationRepo    *repository.QuotationRepository
+	customerRepo     *repository.CustomerRepository
+	productRepo      *repository.ProductRepository
+	ruleRepo         *repository.CustomerProductRuleRepository
+	pdfGenerator     *services.PDFGenerator
+	chatNotifier     *services.ChatNotifier
+	archiver         *services.DocumentArchiver
+	pricingService   *services.PricingService
+	auditRepo        *repository.AuditRepository
+	rulesService     *services.RulesService
+	currencies       *services.CurrencyService
+	contactRepo      *repository.ContactRepository
+	emailService     *services.EmailService
+	deliveryRepo     *repository.EmailDeliveryRepository
+	notificationRepo *repository.NotificationRepository
+	builderRepo      *repository.QuotationBuilderRepository
+	builderCleaner   *services.QuotationBuilderCleaner
+	webhooks         *services.WebhookService
+	quoteRequestRepo *repository.QuoteRequestRepository
+	promiseService   *services.PromiseService
 }
 
 // NewQuotationHandler creates a new quotation handler with the provided repositories
@@ -31,44 +68,87 @@ func NewQuotationHandler(
 	quotationRepo *repository.QuotationRepository,
 	customerRepo *repository.CustomerRepository,
 	productRepo *repository.ProductRepository,
+	ruleRepo *repository.CustomerProductRuleRepository,
 	pdfGenerator *services.PDFGenerator,
+	chatNotifier *services.ChatNotifier,
+	archiver *services.DocumentArchiver,
+	pricingService *services.PricingService,
+	auditRepo *repository.AuditRepository,
+	rulesService *services.RulesService,
+	currencies *services.CurrencyService,
+	contactRepo *repository.ContactRepository,
+	emailService *services.EmailService,
+	deliveryRepo *repository.EmailDeliveryRepository,
+	notificationRepo *repository.NotificationRepository,
+	builderRepo *repository.QuotationBuilderRepository,
+	builderCleaner *services.QuotationBuilderCleaner,
+	webhooks *services.WebhookService,
+	quoteRequestRepo *repository.QuoteRequestRepository,
+	promiseService *services.PromiseService,
 ) *QuotationHandler {
 	return &QuotationHandler{
-		quotationRepo: quotationRepo,
-		customerRepo:  customerRepo,
-		productRepo:   productRepo,
-		pdfGenerator:  pdfGenerator,
+		quotationRepo:    quotationRepo,
+		customerRepo:     customerRepo,
+		productRepo:      productRepo,
+		ruleRepo:         ruleRepo,
+		pdfGenerator:     pdfGenerator,
+		chatNotifier:     chatNotifier,
+		archiver:         archiver,
+		pricingService:   pricingService,
+		auditRepo:        auditRepo,
+		rulesService:     rulesService,
+		currencies:       currencies,
+		contactRepo:      contactRepo,
+		emailService:     emailService,
+		deliveryRepo:     deliveryRepo,
+		notificationRepo: notificationRepo,
+		builderRepo:      builderRepo,
+		builderCleaner:   builderCleaner,
+		webhooks:         webhooks,
+		quoteRequestRepo: quoteRequestRepo,
+		promiseService:   promiseService,
 	}
 }
 
-// GetAllQuotations returns all quotations
+// announceApproval announces a newly approved quotation in the team chat, to every other
+// user in the app and to webhooks
+func (h *QuotationHandler) announceApproval(c echo.Context, quotation models.Quotation) {
+	customerName := "Customer #" + strconv.Itoa(quotation.CustomerID)
+	if customer, err := h.customerRepo.GetByID(c.Request().Context(), quotation.CustomerID); err == nil {
+		customerName = customer.CompanyName
+	}
+	h.chatNotifier.NotifyQuotationApproved(quotation.Reference(), customerName, services.ToBase(quotation.TotalAmount, quotation.ExchangeRate))
+	notifyUsers(c, h.notificationRepo, models.NotificationQuotationApproved, models.AuditEntityQuotation, quotation.QuotationID,
+		"Quotation "+quotation.Reference()+" approved",
+		"Quotation "+quotation.Reference()+" for "+customerName+" was approved.")
+	h.webhooks.Publish(c.Request().Context(), models.WebhookQuotationApproved, map[string]interface{}{
+		"quotation": quotation,
+	})
+}
+
+// GetAllQuotations returns all quotations, or one page of them when ?page= and ?page_size=
+// (or ?limit= and ?offset=) are given; see parsePage. Quotations can be filtered and sorted
+// with parameters such as ?customer_id=4&status=Pending&sort=quote_date:desc; see
+// repository.ParseListQuery.
 func (h *QuotationHandler) GetAllQuotations(c echo.Context) error {
 	ctx := c.Request().Context()
 
-	// Check for customer filter
-	customerIDStr := c.QueryParam("customer_id")
-	var quotations []models.Quotation
-	var err error
+	page, paged, message := parsePage(c)
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
 
-	if customerIDStr != "" {
-		customerID, parseErr := strconv.Atoi(customerIDStr)
-		if parseErr != nil {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "Invalid customer ID",
-			})
-		}
-		quotations, err = h.quotationRepo.GetByCustomerID(ctx, customerID)
-	} else {
-		quotations, err = h.quotationRepo.GetAll(ctx)
+	list, err := repository.ParseListQuery(c.QueryParams(), repository.QuotationListColumns)
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, err.Error())
 	}
 
+	quotations, total, err := h.quotationRepo.GetAll(ctx, list, page)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve quotations",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve quotations")
 	}
 
-	return c.JSON(http.StatusOK, quotations)
+	return jsonPage(c, http.StatusOK, quotations, page, paged, total)
 }
 
 // GetQuotationByID returns a quotation by ID
@@ -77,22 +157,16 @@ func (h *QuotationHandler) GetQuotationByID(c echo.Context) error {
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid quotation ID",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid quotation ID")
 	}
 
 	// Get the quotation with its items
 	quotation, items, err := h.quotationRepo.GetFullQuotation(ctx, id)
 	if err != nil {
-		if err.Error() == "quotation not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Quotation not found",
-			})
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Quotation not found")
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve quotation",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve quotation")
 	}
 
 	// Return both the quotation and its items
@@ -104,14 +178,10 @@ func (h *QuotationHandler) GetQuotationByID(c echo.Context) error {
 
 // CreateQuotation creates a new quotation with items
 func (h *QuotationHandler) CreateQuotation(c echo.Context) error {
-	ctx := c.Request().Context()
-
 	// Read the raw request body
 	bodyBytes, err := io.ReadAll(c.Request().Body)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Failed to read request body: " + err.Error(),
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Failed to read request body: "+err.Error())
 	}
 
 	// Log the raw body for debugging
@@ -123,110 +193,351 @@ func (h *QuotationHandler) CreateQuotation(c echo.Context) error {
 	// Define a struct to hold the request body
 	type QuotationRequest struct {
 		Quotation models.Quotation       `json:"quotation"`
-		Items     []models.QuotationItem `json:"items"`
+		Items     []models.QuotationItem `json:"items" validate:"dive"`
 	}
 
 	var req QuotationRequest
 	if err := c.Bind(&req); err != nil {
 		fmt.Println("Binding error:", err.Error())
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request payload: " + err.Error(),
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload: "+err.Error())
 	}
 
 	// Log the bound request for debugging
 	fmt.Printf("Bound request: %+v\n", req)
 	fmt.Printf("Quotation CustomerID: %d\n", req.Quotation.CustomerID)
 
-	// Validate required fields
-	if req.Quotation.CustomerID == 0 {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Customer ID is required",
-		})
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	quotation, items, pricing, err := h.createQuotation(c, req.Quotation, req.Items)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"quotation": quotation,
+		"items":     items,
+		"pricing":   pricing,
+	})
+}
+
+// createQuotation validates, prices and saves a new quotation with its items, returning it
+// as saved
+func (h *QuotationHandler) createQuotation(c echo.Context, quotation models.Quotation, items []models.QuotationItem) (models.Quotation, []models.QuotationItem, *services.PriceBreakdown, error) {
+	ctx := c.Request().Context()
+
+	if message := checkSource(quotation.Source); message != "" {
+		return quotation, nil, nil, models.NewAPIError(http.StatusBadRequest, message)
 	}
 
-	if req.Quotation.QuoteDate.IsZero() {
-		req.Quotation.QuoteDate = time.Now()
+	// Archived customers keep their history but cannot receive new quotations
+	if customer, err := h.customerRepo.GetByID(ctx, quotation.CustomerID); err == nil && customer.ArchivedAt != nil {
+		return quotation, nil, nil, models.NewAPIError(http.StatusBadRequest, "Customer is archived")
 	}
 
-	if req.Quotation.ValidityDate.IsZero() {
-		// Default validity: 30 days from quote date
-		req.Quotation.ValidityDate = req.Quotation.QuoteDate.AddDate(0, 0, 30)
+	if apiErr := h.checkProducts(ctx, quotation.CustomerID, items); apiErr != nil {
+		return quotation, nil, nil, apiErr
 	}
 
-	if req.Quotation.Status == "" {
-		req.Quotation.Status = "PENDING"
+	if quotation.QuoteDate.IsZero() {
+		quotation.QuoteDate = time.Now()
 	}
 
-	// Calculate total if not provided
-	if req.Quotation.TotalAmount == 0 && len(req.Items) > 0 {
-		var total float64
-		for _, item := range req.Items {
-			// Calculate line total based on the exact same formula as the database
-			lineTotal := (float64(item.Quantity) * item.UnitPrice) - item.Discount
-			total += lineTotal
-		}
-		req.Quotation.TotalAmount = total
+	// The response target is measured from when the request was received, now unless given
+	if quotation.RequestReceivedAt.After(time.Now()) {
+		return quotation, nil, nil, models.NewAPIError(http.StatusBadRequest, "request_received_at cannot be in the future")
+	}
+	quotation.SentAt = nil
+
+	if quotation.ValidityDate.IsZero() {
+		// Default validity: the quotation_validity_days rule's days from quote date
+		days := h.rulesService.Int(ctx, models.RuleQuotationValidityDays)
+		quotation.ValidityDate = quotation.QuoteDate.AddDate(0, 0, days)
+	}
+
+	if quotation.Status == "" {
+		quotation.Status = "PENDING"
 	}
 
+	// Apply the customer's tier discount and calculate the total
+	pricing, err := h.price(ctx, &quotation, items)
+	if err != nil {
+		return quotation, nil, nil, err
+	}
+
+	h.promise(ctx, items)
+
 	// Create the quotation with its items
-	err = h.quotationRepo.CreateQuotationWithItems(ctx, &req.Quotation, req.Items)
+	err = h.quotationRepo.CreateQuotationWithItems(ctx, &quotation, items)
 	if err != nil {
 		if err == repository.ErrDuplicateKey {
-			return c.JSON(http.StatusConflict, map[string]string{
-				"error": "A quotation with this information already exists",
-			})
+			return quotation, nil, nil, models.NewAPIError(http.StatusConflict, "A quotation with this information already exists")
 		}
 
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to create quotation: " + err.Error(),
-		})
+		return quotation, nil, nil, models.NewAPIError(http.StatusInternalServerError, "Failed to create quotation: "+err.Error())
 	}
 
 	// Get the newly created quotation with its items
-	quotation, items, err := h.quotationRepo.GetFullQuotation(ctx, req.Quotation.QuotationID)
+	created, createdItems, err := h.quotationRepo.GetFullQuotation(ctx, quotation.QuotationID)
+	if err != nil {
+		return quotation, nil, nil, models.NewAPIError(http.StatusInternalServerError, "Quotation created but failed to retrieve it")
+	}
+
+	recordAudit(c, h.auditRepo, models.AuditCreate, models.AuditEntityQuotation, created.QuotationID, nil, map[string]interface{}{
+		"quotation": created,
+		"items":     createdItems,
+	})
+
+	return created, createdItems, pricing, nil
+}
+
+// checkProducts returns an error when a quotation line's product is deleted, discontinued or
+// not sold to the customer
+func (h *QuotationHandler) checkProducts(ctx context.Context, customerID int, items []models.QuotationItem) *models.APIError {
+	productIDs := make([]int, len(items))
+	for i, item := range items {
+		productIDs[i] = item.ProductID
+	}
+	message, err := checkProductsAvailable(ctx, h.productRepo, productIDs)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Quotation created but failed to retrieve it",
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to validate products")
+	}
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	restrictions, err := h.ruleRepo.CheckProducts(ctx, customerID, productIDs)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to validate products")
+	}
+	if len(restrictions) > 0 {
+		return models.NewAPIError(http.StatusBadRequest, "Some products cannot be sold to this customer").WithDetails(map[string]interface{}{
+			"restricted_products": restrictions,
 		})
 	}
+	return nil
+}
 
-	return c.JSON(http.StatusCreated, map[string]interface{}{
+// price prices a quotation and its lines, reporting pricing errors the client can fix as 400s
+func (h *QuotationHandler) price(ctx context.Context, quotation *models.Quotation, items []models.QuotationItem) (*services.PriceBreakdown, error) {
+	pricing, err := h.pricingService.PriceQuotation(ctx, quotation, items)
+	if err != nil {
+		if apiErr := discountLimitError(err); apiErr != nil {
+			return nil, apiErr
+		}
+		if apiErr := currencyError(err); apiErr != nil {
+			return nil, apiErr
+		}
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, models.NewAPIError(http.StatusBadRequest, "Customer not found")
+		}
+		return nil, models.NewAPIError(http.StatusInternalServerError, "Failed to price quotation")
+	}
+	return pricing, nil
+}
+
+// promise sets the promise dates of a quotation's lines. Quoting goes ahead without them when
+// they cannot be worked out.
+func (h *QuotationHandler) promise(ctx context.Context, items []models.QuotationItem) {
+	if err := h.promiseService.Promise(ctx, items); err != nil {
+		log.Printf("Failed to work out quotation promise dates: %v", err)
+		for i := range items {
+			items[i].PromiseDate, items[i].PromiseSource, items[i].PromisedAt = nil, nil, nil
+		}
+	}
+}
+
+// PromiseQuotation works a quotation's promise dates out again from the stock, purchase orders
+// and supplier lead times of today, returning the quotation with its items. Revised quotations
+// are replaced by their latest revision and keep the dates they had.
+func (h *QuotationHandler) PromiseQuotation(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid quotation ID")
+	}
+
+	quotation, items, err := h.quotationRepo.GetFullQuotation(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Quotation not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve quotation")
+	}
+	if quotation.Status == models.QuotationStatusRevised {
+		return models.NewAPIError(http.StatusConflict, "Quotation "+quotation.Reference()+" was revised; promise its latest revision instead")
+	}
+
+	if err := h.promiseService.Promise(ctx, items); err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to work out promise dates")
+	}
+	if err := h.quotationRepo.SetPromises(ctx, items); err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to save promise dates")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
 		"quotation": quotation,
 		"items":     items,
 	})
 }
 
+// PreviewQuotationPrice prices a draft quotation without saving it, so totals can be shown
+// while it is being written: resolved unit prices, discounts, VAT, margins, and flags for
+// anything that would stop the quotation being created or needs a second look
+func (h *QuotationHandler) PreviewQuotationPrice(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req QuotationPreviewRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	preview, _, err := h.previewQuotation(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, preview)
+}
+
+// previewQuotation prices a draft quotation as PreviewQuotationPrice does, also returning its
+// lines with their unit prices resolved
+func (h *QuotationHandler) previewQuotation(ctx context.Context, req QuotationPreviewRequest) (*services.QuotationPreview, []models.QuotationItem, error) {
+	customer, err := h.customerRepo.GetByID(ctx, req.CustomerID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, models.NewAPIError(http.StatusBadRequest, "Customer not found")
+		}
+		return nil, nil, models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve customer")
+	}
+
+	productIDs := make([]int, len(req.Items))
+	for i, item := range req.Items {
+		productIDs[i] = item.ProductID
+	}
+	products, err := h.productRepo.GetByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, nil, models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve products")
+	}
+	restrictions, err := h.ruleRepo.CheckProducts(ctx, req.CustomerID, productIDs)
+	if err != nil {
+		return nil, nil, models.NewAPIError(http.StatusInternalServerError, "Failed to validate products")
+	}
+
+	var flags []services.PriceFlag
+	if customer.ArchivedAt != nil {
+		flags = append(flags, services.PriceFlag{Code: services.PriceFlagArchivedCustomer, Message: "Customer is archived"})
+	}
+
+	items := make([]models.QuotationItem, len(req.Items))
+	costs := map[int]float64{}
+	for i, line := range req.Items {
+		items[i] = models.QuotationItem{
+			ProductID:     line.ProductID,
+			Quantity:      line.Quantity,
+			Discount:      line.Discount,
+			DiscountType:  line.DiscountType,
+			DiscountValue: line.DiscountValue,
+		}
+		product, ok := products[line.ProductID]
+		switch {
+		case !ok:
+			flags = append(flags, services.PriceFlag{Code: services.PriceFlagUnavailableProduct, ProductID: line.ProductID, Message: "Product not found"})
+		case product.DeletedAt != nil:
+			flags = append(flags, services.PriceFlag{Code: services.PriceFlagUnavailableProduct, ProductID: line.ProductID, Message: "Product is deleted"})
+		case product.DiscontinuedAt != nil:
+			flags = append(flags, services.PriceFlag{Code: services.PriceFlagUnavailableProduct, ProductID: line.ProductID, Message: "Product is discontinued"})
+		}
+		if line.UnitPrice != nil {
+			items[i].UnitPrice = *line.UnitPrice
+		} else if ok {
+			items[i].UnitPrice, err = h.currencies.Convert(ctx, product.Price, product.Currency, req.Currency, time.Now())
+			if err != nil {
+				if apiErr := currencyError(err); apiErr != nil {
+					return nil, nil, apiErr
+				}
+				return nil, nil, models.NewAPIError(http.StatusInternalServerError, "Failed to convert product price")
+			}
+		}
+		if product.CostPrice != nil {
+			costs[line.ProductID] = *product.CostPrice
+		}
+	}
+	for _, restriction := range restrictions {
+		flags = append(flags, services.PriceFlag{Code: services.PriceFlagRestrictedProduct, ProductID: restriction.ProductID, Message: restriction.Reason})
+	}
+
+	// Pricing fills in the lines' discounts; return them as entered so they can be priced again
+	resolved := append([]models.QuotationItem(nil), items...)
+	quotation := &models.Quotation{CustomerID: req.CustomerID, Currency: req.Currency}
+	preview, err := h.pricingService.PreviewQuotation(ctx, quotation, items, costs)
+	if err != nil {
+		if apiErr := currencyError(err); apiErr != nil {
+			return nil, nil, apiErr
+		}
+		return nil, nil, models.NewAPIError(http.StatusInternalServerError, "Failed to price quotation")
+	}
+	preview.Flags = append(flags, preview.Flags...)
+	if preview.Flags == nil {
+		preview.Flags = []services.PriceFlag{}
+	}
+
+	return preview, resolved, nil
+}
+
 // GenerateQuotationPDF generates a PDF for a quotation using wkhtmltopdf
 func (h *QuotationHandler) GenerateQuotationPDF(c echo.Context) error {
 	ctx := c.Request().Context()
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid quotation ID",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid quotation ID")
 	}
 
 	// Get the quotation with its items
 	quotation, items, err := h.quotationRepo.GetFullQuotation(ctx, id)
 	if err != nil {
-		if err.Error() == "quotation not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Quotation not found",
-			})
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Quotation not found")
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve quotation",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve quotation")
+	}
+
+	pdfContent, customer, err := h.renderPDF(ctx, quotation, items)
+	if err != nil {
+		return err
 	}
+	log.Printf("PDF generation successful, content length: %d bytes", len(pdfContent))
 
+	// Copy the document into the connected Drive/OneDrive archive
+	h.archiver.ArchiveAsync(services.ArchiveDocument{
+		CustomerName: customer.CompanyName,
+		Year:         quotation.QuoteDate.Year(),
+		FileName:     quotation.Reference() + ".pdf",
+		Content:      pdfContent,
+	})
+
+	// Set headers
+	c.Response().Header().Set("Content-Type", "application/pdf")
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.pdf", quotation.Reference()))
+
+	// Write the PDF to the response
+	return c.Blob(http.StatusOK, "application/pdf", pdfContent)
+}
+
+// renderPDF renders a quotation with its customer and items as a PDF, falling back to a
+// plain layout when the template cannot be rendered
+func (h *QuotationHandler) renderPDF(ctx context.Context, quotation models.Quotation, items []models.QuotationItem) ([]byte, models.Customer, error) {
 	// Get customer information
-	customer, err := h.customerRepo.GetByID(ctx, quotation.CustomerID)
+	customer, err := h.customerRepo.GetByIDWithDeleted(ctx, quotation.CustomerID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve customer information",
-		})
+		return nil, customer, models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve customer information")
 	}
 
 	// Get product details for each item
@@ -237,11 +548,9 @@ func (h *QuotationHandler) GenerateQuotationPDF(c echo.Context) error {
 
 	itemsWithProducts := make([]ItemWithProduct, len(items))
 	for i, item := range items {
-		product, err := h.productRepo.GetByID(ctx, item.ProductID)
+		product, err := h.productRepo.GetByIDWithDeleted(ctx, item.ProductID)
 		if err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{
-				"error": "Failed to retrieve product information",
-			})
+			return nil, customer, models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve product information")
 		}
 
 		itemsWithProducts[i] = ItemWithProduct{
@@ -250,10 +559,16 @@ func (h *QuotationHandler) GenerateQuotationPDF(c echo.Context) error {
 		}
 	}
 
+	currency, err := h.currencies.Currency(ctx, quotation.Currency)
+	if err != nil {
+		return nil, customer, models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve currency")
+	}
+
 	// Create a data structure for the template
 	templateData := map[string]interface{}{
 		"Quotation":        quotation,
 		"Customer":         customer,
+		"Currency":         currency,
 		"ItemsWithProduct": itemsWithProducts,
 		"GenerationDate":   time.Now().Format("January 2, 2006"),
 		// CSS will be injected by the PDF generator
@@ -262,7 +577,7 @@ func (h *QuotationHandler) GenerateQuotationPDF(c echo.Context) error {
 	log.Printf("Prepared template data with %d items", len(itemsWithProducts))
 
 	// Generate the PDF using our PDF service
-	log.Printf("Generating PDF for quotation ID: %d", id)
+	log.Printf("Generating PDF for quotation ID: %d", quotation.QuotationID)
 
 	// Use relative paths as expected by the PDF generator
 	templateName := "quotation/template.html"
@@ -465,7 +780,7 @@ func (h *QuotationHandler) GenerateQuotationPDF(c echo.Context) error {
     <div class="header">
         <div>
             <div class="document-title">QUOTATION</div>
-            <div class="generation-date">Reference: CISC-Q-%d | Generated on %s</div>
+            <div class="generation-date">Reference: %s | Generated on %s</div>
         </div>
         <div class="company-header">
             <div class="company-name">CENTER INDUSTRIAL SUPPLY CORPORATION</div>
@@ -502,55 +817,31 @@ func (h *QuotationHandler) GenerateQuotationPDF(c echo.Context) error {
     <table>
         <thead>
             <tr>
-                <th style="width: 40%%;">Product</th>
+                <th style="width: 30%%;">Product</th>
                 <th style="width: 10%%;">Quantity</th>
                 <th style="width: 20%%;">Unit Price</th>
                 <th style="width: 10%%;">Discount</th>
-                <th style="width: 20%%;">Line Total</th>
+                <th style="width: 12%%;">Delivery By</th>
+                <th style="width: 18%%;">Line Total</th>
             </tr>
         </thead>
         <tbody>`,
 			quotation.QuotationID,
-			quotation.QuotationID,
+			quotation.Reference(),
 			time.Now().Format("January 2, 2006"),
 			customer.CompanyName,
 			quotation.QuoteDate.Format("January 2, 2006"),
 			quotation.ValidityDate.Format("January 2, 2006"),
 			quotation.Status)
 
-		// Format money values with thousand separators
+		// Format money values in the quotation's currency
 		formatMoney := func(amount float64) string {
-			// Format with two decimal places
-			formattedAmount := fmt.Sprintf("%.2f", amount)
-
-			// Split into integer and decimal parts
-			parts := strings.Split(formattedAmount, ".")
-			integerPart := parts[0]
-			decimalPart := parts[1]
-
-			// Add thousand separators to integer part
-			for i := len(integerPart) - 3; i > 0; i -= 3 {
-				integerPart = integerPart[:i] + "," + integerPart[i:]
-			}
-
-			return "₱" + integerPart + "." + decimalPart
+			return services.FormatCurrency(currency, amount)
 		}
 
 		// Add item rows
 		for _, item := range itemsWithProducts {
-			// Calculate discount percentage if applicable
-			discountText := "-"
-
-			// Get discount from the database item record directly
-			if item.QuotationItem.Discount > 0 {
-				discountPercent := 0.0
-				// Calculate discount percentage based on line total before discount
-				beforeDiscountTotal := float64(item.QuotationItem.Quantity) * item.QuotationItem.UnitPrice
-				if beforeDiscountTotal > 0 {
-					discountPercent = (item.QuotationItem.Discount / beforeDiscountTotal) * 100
-				}
-				discountText = fmt.Sprintf("%.1f%%", discountPercent)
-			}
+			discountText := services.FormatDiscount(currency, item.QuotationItem.DiscountType, item.QuotationItem.DiscountValue)
 
 			fallbackHTML += fmt.Sprintf(`
         <tr>
@@ -558,12 +849,14 @@ func (h *QuotationHandler) GenerateQuotationPDF(c echo.Context) error {
             <td class="amount-cell">%d</td>
             <td class="amount-cell">%s</td>
             <td class="amount-cell">%s</td>
+            <td>%s</td>
             <td class="amount-cell">%s</td>
         </tr>`,
 				item.ProductName,
 				item.QuotationItem.Quantity,
 				formatMoney(item.QuotationItem.UnitPrice),
 				discountText,
+				services.FormatPromise(item.QuotationItem),
 				formatMoney(item.QuotationItem.LineTotal))
 		}
 
@@ -581,7 +874,7 @@ func (h *QuotationHandler) GenerateQuotationPDF(c echo.Context) error {
         <div class="terms-heading">Terms and Conditions</div>
         <ol class="terms-list">
             <li>This quotation is valid until the date specified above.</li>
-            <li>Prices are in Philippine Peso (₱) and subject to change without notice after the validity period.</li>
+            <li>Prices are in %s (%s) and subject to change without notice after the validity period.</li>
             <li>Payment terms: 50%% advance payment upon order confirmation, 50%% prior to delivery.</li>
             <li>Delivery timeframes are subject to stock availability.</li>
             <li>All prices are exclusive of applicable taxes unless otherwise stated.</li>
@@ -593,131 +886,630 @@ func (h *QuotationHandler) GenerateQuotationPDF(c echo.Context) error {
         <p>Center Industrial Supply Corporation | Your Welding and Cutting Solutions Provider</p>
     </div>
 </body>
-</html>`, formatMoney(quotation.TotalAmount))
-
-		// Create a temporary file for the fallback HTML
-		tempFile, err := os.CreateTemp("", "fallback-*.html")
-		if err != nil {
-			log.Printf("Failed to create temp file for fallback: %v", err)
-			return c.JSON(http.StatusInternalServerError, map[string]string{
-				"error": fmt.Sprintf("Failed to generate PDF: %v", err),
-			})
-		}
-		tempPath := tempFile.Name()
-		defer os.Remove(tempPath) // Clean up
-
-		// Write the fallback HTML
-		tempFile.WriteString(fallbackHTML)
-		tempFile.Close()
-
-		// Output path for the PDF
-		pdfPath := tempPath + ".pdf"
-		defer os.Remove(pdfPath) // Clean up
-
-		// Call wkhtmltopdf directly with minimal options
-		cmd := exec.Command(
-			"C:\\Program Files\\wkhtmltopdf\\bin\\wkhtmltopdf.exe",
-			"--quiet",
-			tempPath,
-			pdfPath,
-		)
-
-		cmdOutput, cmdErr := cmd.CombinedOutput()
-		if cmdErr != nil {
-			log.Printf("Fallback PDF generation failed: %v\nOutput: %s", cmdErr, string(cmdOutput))
-			return c.JSON(http.StatusInternalServerError, map[string]string{
-				"error": fmt.Sprintf("Failed to generate PDF: %v", err),
-			})
-		}
-
-		// Read the fallback PDF
-		pdfContent, err = os.ReadFile(pdfPath)
-		if err != nil {
-			log.Printf("Failed to read fallback PDF: %v", err)
-			return c.JSON(http.StatusInternalServerError, map[string]string{
-				"error": fmt.Sprintf("Failed to generate PDF: %v", err),
-			})
+</html>`, formatMoney(quotation.TotalAmount), currency.Name, currency.Symbol)
+
+		// Convert it with the same wkhtmltopdf binary, without the template
+		var fallbackErr error
+		pdfContent, fallbackErr = h.pdfGenerator.GenerateFromHTML(fallbackHTML)
+		if fallbackErr != nil {
+			log.Printf("Fallback PDF generation failed: %v", fallbackErr)
+			return nil, customer, models.NewAPIError(http.StatusInternalServerError, fmt.Sprintf("Failed to generate PDF: %v", err))
 		}
 
 		log.Printf("Fallback PDF generation successful, size: %d bytes", len(pdfContent))
 	}
-	log.Printf("PDF generation successful, content length: %d bytes", len(pdfContent))
 
-	// Set headers
-	c.Response().Header().Set("Content-Type", "application/pdf")
-	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=quotation_%d.pdf", quotation.QuotationID))
-
-	// Write the PDF to the response
-	return c.Blob(http.StatusOK, "application/pdf", pdfContent)
+	return pdfContent, customer, nil
 }
 
-// UpdateQuotationStatus updates the status of an existing quotation
+// UpdateQuotationStatus updates the status of an existing quotation. The version taken from the
+// quotation is required and guards against overwriting someone else's change: without it 428 is
+// returned, and when the quotation has changed since, 409 with the current quotation.
 func (h *QuotationHandler) UpdateQuotationStatus(c echo.Context) error {
 	ctx := c.Request().Context()
 
 	// Parse the quotation ID from the URL
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid quotation ID",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid quotation ID")
 	}
 
 	// Define a struct to hold the status data
 	type StatusUpdate struct {
-		Status string `json:"status"`
+		Status  string `json:"status" validate:"required"`
+		Version int    `json:"version" validate:"gte=0"`
 	}
 
 	// Bind the request body to the struct
 	var statusUpdate StatusUpdate
 	if err := c.Bind(&statusUpdate); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request format",
-		})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request format")
 	}
 
-	// Validate the status
-	validStatuses := map[string]bool{
-		"Pending":  true,
-		"Approved": true,
-		"Rejected": true,
-		"Expired":  true,
+	if err := c.Validate(&statusUpdate); err != nil {
+		return validationError(err)
+	}
+	if statusUpdate.Version == 0 {
+		return versionRequiredError("Quotation")
 	}
 
-	if !validStatuses[statusUpdate.Status] {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid status. Must be one of: Pending, Approved, Rejected, Expired",
-		})
+	// Validate the status
+	if !containsString(models.QuotationStatuses, statusUpdate.Status) {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid status. Must be one of: "+strings.Join(models.QuotationStatuses, ", "))
+	}
+	if statusUpdate.Status == models.QuotationStatusRevised {
+		return models.NewAPIError(http.StatusBadRequest, "A quotation is marked Revised by revising it")
 	}
 
 	// Get the quotation to check if it exists
-	_, err = h.quotationRepo.GetByID(ctx, id)
+	quotation, err := h.quotationRepo.GetByID(ctx, id)
 	if err != nil {
-		if err.Error() == "quotation not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Quotation not found",
-			})
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Quotation not found")
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve quotation",
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve quotation")
 	}
 
 	// Update the status
-	err = h.quotationRepo.UpdateStatus(ctx, id, statusUpdate.Status)
+	err = h.quotationRepo.UpdateStatus(ctx, id, statusUpdate.Status, statusUpdate.Version)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to update quotation status: " + err.Error(),
-		})
+		if err == repository.ErrStaleVersion {
+			current, getErr := h.quotationRepo.GetByID(ctx, id)
+			if getErr != nil {
+				return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve quotation")
+			}
+			return staleVersionError("Quotation", current)
+		}
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Quotation not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update quotation status: "+err.Error())
+	}
+
+	if statusUpdate.Status == "Approved" && quotation.Status != "Approved" {
+		h.announceApproval(c, quotation)
 	}
 
 	// Get the updated quotation
 	updatedQuotation, err := h.quotationRepo.GetByID(ctx, id)
 	if err != nil {
+		recordAudit(c, h.auditRepo, "status_change", models.AuditEntityQuotation, id, quotation, nil)
 		return c.JSON(http.StatusOK, map[string]string{
 			"message": "Status updated successfully, but failed to retrieve updated quotation",
 		})
 	}
 
+	recordAudit(c, h.auditRepo, "status_change", models.AuditEntityQuotation, id, quotation, updatedQuotation)
+
 	return c.JSON(http.StatusOK, updatedQuotation)
 }
+
+// QuotationRevisionRequest is the new content of a quotation being revised. Version is the
+// version of the revision being replaced; a validity date left out is worked out from today.
+type QuotationRevisionRequest struct {
+	Version      int                    `json:"version" validate:"gte=0"`
+	ValidityDate time.Time              `json:"validity_date"`
+	Items        []models.QuotationItem `json:"items" validate:"required,min=1,dive"`
+}
+
+// QuotationRevision is a revision of a quotation with its number as quoted to customers
+type QuotationRevision struct {
+	models.Quotation
+	Reference string `json:"reference"`
+}
+
+// AcceptQuotationRequest names the version of the revision the customer accepted
+type AcceptQuotationRequest struct {
+	Version int `json:"version" validate:"gte=0"`
+}
+
+// ReviseQuotation saves new items for a quotation as its next revision under the same number,
+// keeping the revised quotation's items and totals and marking it Revised. Only the latest
+// revision of a quotation no revision of which was accepted can be revised.
+func (h *QuotationHandler) ReviseQuotation(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid quotation ID")
+	}
+
+	var req QuotationRevisionRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+	if req.Version == 0 {
+		return versionRequiredError("Quotation")
+	}
+
+	revisions, err := h.quotationRepo.GetRevisions(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Quotation not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve quotation")
+	}
+	var revised models.Quotation
+	for _, revision := range revisions {
+		if revision.AcceptedAt != nil {
+			return models.NewAPIError(http.StatusConflict, "Quotation "+revision.Reference()+" was accepted and can no longer be revised")
+		}
+		if revision.QuotationID == id {
+			revised = revision
+		}
+	}
+	latest := revisions[len(revisions)-1]
+	if revised.QuotationID != latest.QuotationID {
+		return models.NewAPIError(http.StatusConflict, "Only the latest revision of a quotation can be revised").WithDetails(map[string]interface{}{
+			"latest": QuotationRevision{Quotation: latest, Reference: latest.Reference()},
+		})
+	}
+
+	if apiErr := h.checkProducts(ctx, revised.CustomerID, req.Items); apiErr != nil {
+		return apiErr
+	}
+
+	quotation := models.Quotation{
+		CustomerID:   revised.CustomerID,
+		QuoteDate:    time.Now(),
+		ValidityDate: req.ValidityDate,
+		Status:       "Pending",
+		Source:       revised.Source,
+		Currency:     revised.Currency,
+	}
+	if quotation.ValidityDate.IsZero() {
+		days := h.rulesService.Int(ctx, models.RuleQuotationValidityDays)
+		quotation.ValidityDate = quotation.QuoteDate.AddDate(0, 0, days)
+	}
+
+	pricing, err := h.price(ctx, &quotation, req.Items)
+	if err != nil {
+		return err
+	}
+	h.promise(ctx, req.Items)
+
+	err = h.quotationRepo.CreateRevision(ctx, revised, req.Version, &quotation, req.Items)
+	if err != nil {
+		if err == repository.ErrStaleVersion {
+			current, getErr := h.quotationRepo.GetByID(ctx, id)
+			if getErr != nil {
+				return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve quotation")
+			}
+			return staleVersionError("Quotation", current)
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to revise quotation")
+	}
+
+	saved, items, err := h.quotationRepo.GetFullQuotation(ctx, quotation.QuotationID)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Quotation revised but failed to retrieve it")
+	}
+
+	recordAudit(c, h.auditRepo, "revise", models.AuditEntityQuotation, saved.QuotationID, revised, map[string]interface{}{
+		"quotation": saved,
+		"items":     items,
+	})
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"quotation": saved,
+		"reference": saved.Reference(),
+		"items":     items,
+		"pricing":   pricing,
+	})
+}
+
+// GetQuotationRevisions returns every revision of the quotation's chain, oldest first
+func (h *QuotationHandler) GetQuotationRevisions(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid quotation ID")
+	}
+
+	quotations, err := h.quotationRepo.GetRevisions(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Quotation not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve quotation revisions")
+	}
+
+	revisions := make([]QuotationRevision, len(quotations))
+	for i, quotation := range quotations {
+		revisions[i] = QuotationRevision{Quotation: quotation, Reference: quotation.Reference()}
+	}
+	return c.JSON(http.StatusOK, revisions)
+}
+
+// DiffQuotationRevisions compares two revisions of the quotation's chain, given by revision
+// number as ?from=1&to=3. To defaults to the quotation's own revision and from to the one
+// before it.
+func (h *QuotationHandler) DiffQuotationRevisions(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid quotation ID")
+	}
+
+	quotations, err := h.quotationRepo.GetRevisions(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Quotation not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve quotation revisions")
+	}
+	byRevision := map[int]models.Quotation{}
+	to := 0
+	for _, quotation := range quotations {
+		byRevision[quotation.Revision] = quotation
+		if quotation.QuotationID == id {
+			to = quotation.Revision
+		}
+	}
+
+	if param := c.QueryParam("to"); param != "" {
+		if to, err = strconv.Atoi(param); err != nil {
+			return models.NewAPIError(http.StatusBadRequest, "to must be a revision number")
+		}
+	}
+	from := to - 1
+	if param := c.QueryParam("from"); param != "" {
+		if from, err = strconv.Atoi(param); err != nil {
+			return models.NewAPIError(http.StatusBadRequest, "from must be a revision number")
+		}
+	}
+
+	fromQuotation, ok := byRevision[from]
+	if !ok {
+		return models.NewAPIError(http.StatusBadRequest, fmt.Sprintf("Quotation has no revision %d to compare from", from))
+	}
+	toQuotation, ok := byRevision[to]
+	if !ok {
+		return models.NewAPIError(http.StatusBadRequest, fmt.Sprintf("Quotation has no revision %d to compare to", to))
+	}
+
+	fromItems, err := h.quotationRepo.GetQuotationItems(ctx, fromQuotation.QuotationID)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve quotation items")
+	}
+	toItems, err := h.quotationRepo.GetQuotationItems(ctx, toQuotation.QuotationID)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve quotation items")
+	}
+
+	return c.JSON(http.StatusOK, services.DiffQuotations(fromQuotation, fromItems, toQuotation, toItems))
+}
+
+// AcceptQuotation marks a revision as the one the customer accepted and approves it. One
+// revision of a quotation can be accepted; accepting another is refused with 409.
+func (h *QuotationHandler) AcceptQuotation(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid quotation ID")
+	}
+
+	var req AcceptQuotationRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+	if req.Version == 0 {
+		return versionRequiredError("Quotation")
+	}
+
+	quotation, err := h.quotationRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Quotation not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve quotation")
+	}
+	if quotation.AcceptedAt != nil {
+		return c.JSON(http.StatusOK, QuotationRevision{Quotation: quotation, Reference: quotation.Reference()})
+	}
+
+	err = h.quotationRepo.Accept(ctx, id, req.Version)
+	if err != nil {
+		switch {
+		case err == repository.ErrStaleVersion:
+			current, getErr := h.quotationRepo.GetByID(ctx, id)
+			if getErr != nil {
+				return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve quotation")
+			}
+			return staleVersionError("Quotation", current)
+		case err == repository.ErrDuplicateKey:
+			return models.NewAPIError(http.StatusConflict, "Another revision of this quotation was accepted already")
+		case errors.Is(err, repository.ErrNotFound):
+			return models.NewAPIError(http.StatusNotFound, "Quotation not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to accept quotation")
+	}
+
+	accepted, err := h.quotationRepo.GetByID(ctx, id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Quotation accepted but failed to retrieve it")
+	}
+
+	if quotation.Status != "Approved" {
+		h.announceApproval(c, quotation)
+	}
+
+	recordAudit(c, h.auditRepo, "accept", models.AuditEntityQuotation, id, quotation, accepted)
+
+	return c.JSON(http.StatusOK, QuotationRevision{Quotation: accepted, Reference: accepted.Reference()})
+}
+
+// SendQuotationRequest names the customer contact a quotation is emailed to, with an optional
+// note added to the email
+type SendQuotationRequest struct {
+	ContactID int    `json:"contact_id" validate:"required"`
+	Message   string `json:"message" validate:"max=2000"`
+}
+
+// SendQuotation emails a quotation's PDF to one of its customer's contacts and records the
+// delivery. A delivery the SMTP server refused is recorded as Failed and answered with 502.
+func (h *QuotationHandler) SendQuotation(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid quotation ID")
+	}
+
+	var req SendQuotationRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	if !h.emailService.Enabled() {
+		return models.NewAPIError(http.StatusServiceUnavailable, "Email is not configured")
+	}
+
+	quotation, items, err := h.quotationRepo.GetFullQuotation(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Quotation not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve quotation")
+	}
+
+	contact, err := h.contactRepo.GetByID(ctx, req.ContactID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusBadRequest, "Contact not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve contact")
+	}
+	if contact.CustomerID != quotation.CustomerID {
+		return models.NewAPIError(http.StatusBadRequest, "The contact is not a contact of the quotation's customer")
+	}
+	if contact.Email == nil || strings.TrimSpace(*contact.Email) == "" {
+		return models.NewAPIError(http.StatusBadRequest, "The contact has no email address")
+	}
+	recipient, err := mail.ParseAddress(strings.TrimSpace(*contact.Email))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "The contact's email address is not valid")
+	}
+	recipient.Name = contact.FirstName + " " + contact.LastName
+
+	pdfContent, _, err := h.renderPDF(ctx, quotation, items)
+	if err != nil {
+		return err
+	}
+
+	text := fmt.Sprintf("Dear %s,\n\nPlease find attached our quotation %s, valid until %s.\n\n",
+		contact.FirstName, quotation.Reference(), quotation.ValidityDate.Format("January 2, 2006"))
+	if message := strings.TrimSpace(req.Message); message != "" {
+		text += message + "\n\n"
+	}
+	text += "Thank you for your business."
+
+	delivery := models.EmailDelivery{
+		DocumentType: models.EmailDocumentQuotation,
+		DocumentID:   quotation.QuotationID,
+		ContactID:    &contact.ContactID,
+		Recipient:    recipient.Address,
+		Subject:      "Quotation " + quotation.Reference(),
+		Status:       models.EmailDeliverySent,
+	}
+	if user := appmw.UserFromContext(c); user != nil {
+		delivery.SentBy = &user.UserID
+	}
+
+	sendErr := h.emailService.Send(ctx, services.EmailMessage{
+		To:      []mail.Address{*recipient},
+		Subject: delivery.Subject,
+		Text:    text,
+		Attachments: []services.EmailAttachment{{
+			FileName:    quotation.Reference() + ".pdf",
+			ContentType: "application/pdf",
+			Content:     pdfContent,
+		}},
+	})
+	if sendErr != nil {
+		message := sendErr.Error()
+		delivery.Status = models.EmailDeliveryFailed
+		delivery.Error = &message
+	}
+
+	if err := h.deliveryRepo.Create(ctx, &delivery); err != nil {
+		log.Printf("Failed to record the delivery of quotation %d to %s: %v", quotation.QuotationID, delivery.Recipient, err)
+	}
+	if sendErr != nil {
+		log.Printf("Failed to email quotation %d to %s: %v", quotation.QuotationID, delivery.Recipient, sendErr)
+		return models.NewAPIError(http.StatusBadGateway, "Failed to send the quotation: "+sendErr.Error())
+	}
+
+	if err := h.quotationRepo.MarkSent(ctx, quotation.QuotationID, delivery.CreatedAt); err != nil {
+		log.Printf("Failed to record quotation %d as sent: %v", quotation.QuotationID, err)
+	}
+
+	recordAudit(c, h.auditRepo, "send", models.AuditEntityQuotation, quotation.QuotationID, nil, delivery)
+
+	return c.JSON(http.StatusOK, delivery)
+}
+
+// GetQuotationDeliveries returns the emails sent with a quotation, newest first
+func (h *QuotationHandler) GetQuotationDeliveries(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid quotation ID")
+	}
+
+	deliveries, err := h.deliveryRepo.GetByDocument(c.Request().Context(), models.EmailDocumentQuotation, id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve deliveries")
+	}
+
+	return c.JSON(http.StatusOK, deliveries)
+}
+
+// MarkQuotationSentRequest records when a quotation was sent outside the app, now when sent_at
+// is left out
+type MarkQuotationSentRequest struct {
+	SentAt *time.Time `json:"sent_at"`
+}
+
+// MarkQuotationSent records that a quotation was sent to its customer some other way than by
+// email from the app, such as by hand, to stop its response clock. Quotations already sent
+// keep the time they were first sent.
+func (h *QuotationHandler) MarkQuotationSent(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid quotation ID")
+	}
+
+	var req MarkQuotationSentRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	quotation, err := h.quotationRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Quotation not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve quotation")
+	}
+
+	sentAt := time.Now()
+	if req.SentAt != nil {
+		sentAt = *req.SentAt
+	}
+	if sentAt.After(time.Now()) {
+		return models.NewAPIError(http.StatusBadRequest, "sent_at cannot be in the future")
+	}
+	if sentAt.Before(quotation.RequestReceivedAt) {
+		return models.NewAPIError(http.StatusBadRequest, "sent_at cannot be before the request was received")
+	}
+
+	if err := h.quotationRepo.MarkSent(ctx, id, sentAt); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Quotation not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to mark quotation as sent")
+	}
+
+	updated, err := h.quotationRepo.GetByID(ctx, id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Quotation marked as sent but failed to retrieve it")
+	}
+
+	if quotation.SentAt == nil {
+		recordAudit(c, h.auditRepo, "mark_sent", models.AuditEntityQuotation, id, quotation, updated)
+	}
+
+	return c.JSON(http.StatusOK, updated)
+}
+
+// QuoteRequestQuotation is the quotation prepared for a quote request with its items. The
+// quotation is for the request's customer unless it names one.
+type QuoteRequestQuotation struct {
+	Quotation models.Quotation       `json:"quotation"`
+	Items     []models.QuotationItem `json:"items" validate:"dive"`
+}
+
+// CreateQuotationFromRequest creates the quotation for a quote request from the public form
+// and marks the request Quoted. The quotation's response time is measured from when the
+// request came in, and it is sourced from the website unless it says otherwise.
+func (h *QuotationHandler) CreateQuotationFromRequest(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid quote request ID")
+	}
+
+	var req QuoteRequestQuotation
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	request, err := h.quoteRequestRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Quote request not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve quote request")
+	}
+	if request.Status == models.QuoteRequestQuoted {
+		return models.NewAPIError(http.StatusConflict, "A quotation has already been prepared for this quote request").WithDetails(map[string]interface{}{
+			"quotation_id": request.QuotationID,
+		})
+	}
+
+	quotation := req.Quotation
+	if quotation.CustomerID == 0 {
+		if request.CustomerID == nil {
+			return models.NewAPIError(http.StatusBadRequest, "The quote request is not from a customer; choose or add the customer the quotation is for")
+		}
+		quotation.CustomerID = *request.CustomerID
+	}
+	if quotation.Source == nil {
+		source := models.SourceWebsite
+		quotation.Source = &source
+	}
+	quotation.RequestReceivedAt = request.CreatedAt
+
+	created, items, pricing, err := h.createQuotation(c, quotation, req.Items)
+	if err != nil {
+		return err
+	}
+
+	var handledBy *int
+	if user := appmw.UserFromContext(c); user != nil {
+		handledBy = &user.UserID
+	}
+	if err := h.quoteRequestRepo.MarkQuoted(ctx, id, created.QuotationID, created.CustomerID, handledBy); err != nil {
+		log.Printf("Failed to mark quote request %d as quoted by quotation %d: %v", id, created.QuotationID, err)
+	} else {
+		after := request
+		after.Status = models.QuoteRequestQuoted
+		after.QuotationID = &created.QuotationID
+		after.CustomerID = &created.CustomerID
+		recordAudit(c, h.auditRepo, "quote", models.AuditEntityQuoteRequest, id, request, after)
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"quotation": created,
+		"items":     items,
+		"pricing":   pricing,
+	})
+}
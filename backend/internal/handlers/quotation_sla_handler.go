@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// QuotationSLAHandler handles HTTP requests about quotation turnaround: the follow-up queue
+// of quotations still to be sent and the report of those that missed the response target
+type QuotationSLAHandler struct {
+	quotationRepo *repository.QuotationRepository
+	rulesService  *services.RulesService
+}
+
+// NewQuotationSLAHandler creates a new quotation SLA handler
+func NewQuotationSLAHandler(quotationRepo *repository.QuotationRepository, rulesService *services.RulesService) *QuotationSLAHandler {
+	return &QuotationSLAHandler{
+		quotationRepo: quotationRepo,
+		rulesService:  rulesService,
+	}
+}
+
+// targets returns the response target and how long before it unsent quotations are at risk,
+// from the quotation_response_hours and quotation_sla_warning_hours rules
+func (h *QuotationSLAHandler) targets(c echo.Context) (time.Duration, time.Duration) {
+	ctx := c.Request().Context()
+	target := h.rulesService.Float(ctx, models.RuleQuotationResponseHours)
+	warning := h.rulesService.Float(ctx, models.RuleQuotationSLAWarningHours)
+	return time.Duration(target * float64(time.Hour)), time.Duration(warning * float64(time.Hour))
+}
+
+// GetFollowUpQueue returns the Pending quotations not yet sent to their customers, the one due
+// soonest first, each flagged on_track, at_risk or breached against the response target.
+// ?sla_status= limits the queue to one of those.
+func (h *QuotationSLAHandler) GetFollowUpQueue(c echo.Context) error {
+	slaStatus := c.QueryParam("sla_status")
+	if slaStatus != "" && !containsString(models.SLAOpenStatuses, slaStatus) {
+		return models.NewAPIError(http.StatusBadRequest, "sla_status must be one of: "+strings.Join(models.SLAOpenStatuses, ", "))
+	}
+
+	quotations, err := h.quotationRepo.GetAwaitingResponse(c.Request().Context())
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve the follow-up queue")
+	}
+
+	target, warning := h.targets(c)
+	now := time.Now()
+	queue := []models.QuotationSLA{}
+	for _, quotation := range quotations {
+		sla := quotation.SLA(quotation.CompanyName, target, warning, now)
+		if slaStatus == "" || sla.SLAStatus == slaStatus {
+			queue = append(queue, sla)
+		}
+	}
+
+	return jsonList(c, http.StatusOK, queue)
+}
+
+// GetQuotationSLAReport sums up how the quotations requested in the last ?days= days (30 by
+// default) did against the response target and lists those that missed it or are overdue
+func (h *QuotationSLAHandler) GetQuotationSLAReport(c echo.Context) error {
+	report, err := h.slaReport(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// ExportQuotationSLAReportCSV exports the quotations that missed the response target or are
+// overdue as CSV, or as an Excel workbook with ?format=xlsx
+func (h *QuotationSLAHandler) ExportQuotationSLAReportCSV(c echo.Context) error {
+	format, err := exportFormat(c)
+	if err != nil {
+		return err
+	}
+
+	report, err := h.slaReport(c)
+	if err != nil {
+		return err
+	}
+
+	export := &reportExport{
+		Title:    "Quotation SLA Breaches",
+		FileName: "quotation_sla_breaches_" + strconv.Itoa(report.Days) + "_days",
+		Columns: []exportColumn{
+			{Header: "Quotation ID", Kind: columnInteger},
+			{Header: "Reference"},
+			{Header: "Customer"},
+			{Header: "Status"},
+			{Header: "Request Received"},
+			{Header: "Due"},
+			{Header: "Sent"},
+			{Header: "SLA Status"},
+			{Header: "Turnaround (hours)", Kind: columnAmount},
+			{Header: "Overdue (hours)", Kind: columnAmount},
+			{Header: "Total Amount", Kind: columnAmount, Total: true},
+		},
+	}
+	export.addFilter("days", strconv.Itoa(report.Days))
+	export.addFilter("target hours", strconv.FormatFloat(report.TargetHours, 'f', -1, 64))
+
+	for _, breach := range report.Breaches {
+		var sentAt *string
+		if breach.SentAt != nil {
+			formatted := breach.SentAt.Format(time.RFC3339)
+			sentAt = &formatted
+		}
+		export.addRow(breach.QuotationID, breach.Reference, breach.CompanyName, breach.Status,
+			breach.RequestReceivedAt.Format(time.RFC3339), breach.DueAt.Format(time.RFC3339), sentAt,
+			breach.SLAStatus, breach.TurnaroundHours, breach.OverdueHours, breach.TotalAmount)
+	}
+
+	return writeExport(c, format, export)
+}
+
+// slaReport builds the SLA report for the request's ?days=
+func (h *QuotationSLAHandler) slaReport(c echo.Context) (models.QuotationSLAReport, error) {
+	days := 30
+	if value := c.QueryParam("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			return models.QuotationSLAReport{}, models.NewAPIError(http.StatusBadRequest, "Invalid days parameter. Must be a positive integer.")
+		}
+		days = parsed
+	}
+
+	now := time.Now()
+	quotations, err := h.quotationRepo.GetReceivedSince(c.Request().Context(), now.AddDate(0, 0, -days))
+	if err != nil {
+		return models.QuotationSLAReport{}, models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve quotation SLA report")
+	}
+
+	target, warning := h.targets(c)
+	report := models.QuotationSLAReport{
+		Days:        days,
+		TargetHours: target.Hours(),
+		Received:    len(quotations),
+		Breaches:    []models.QuotationSLA{},
+	}
+	var turnaround float64
+	for _, quotation := range quotations {
+		sla := quotation.SLA(quotation.CompanyName, target, warning, now)
+		switch sla.SLAStatus {
+		case models.SLAMet:
+			report.Met++
+		case models.SLAMissed:
+			report.Missed++
+		case models.SLABreached:
+			report.Breached++
+		}
+		if sla.TurnaroundHours != nil {
+			report.Sent++
+			turnaround += *sla.TurnaroundHours
+		} else {
+			report.Open++
+		}
+		if sla.SLAStatus == models.SLAMissed || sla.SLAStatus == models.SLABreached {
+			report.Breaches = append(report.Breaches, sla)
+		}
+	}
+
+	if due := report.Met + report.Missed + report.Breached; due > 0 {
+		pct := math.Round(float64(report.Met)/float64(due)*10000) / 100
+		report.OnTimePct = &pct
+	}
+	if report.Sent > 0 {
+		average := math.Round(turnaround/float64(report.Sent)*100) / 100
+		report.AverageTurnaroundHours = &average
+	}
+
+	return report, nil
+}
@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// maxQuoteRequestAttachments is how many files can be sent with a quote request
+const maxQuoteRequestAttachments = 5
+
+// quoteRequestFieldBytes allows for a quote request's text fields and multipart framing on
+// top of its files
+const quoteRequestFieldBytes = 64 << 10
+
+// captchaFields are the form fields a captcha token is read from: captcha_token, or the
+// field the Turnstile, hCaptcha or reCAPTCHA widget adds to the form itself
+var captchaFields = []string{"captcha_token", "cf-turnstile-response", "h-captcha-response", "g-recaptcha-response"}
+
+// QuoteRequestHandler handles quote requests: the public "Request a Quote" form of the
+// marketing site, and the sales team's list of requests to follow up
+type QuoteRequestHandler struct {
+	quoteRequestRepo  *repository.QuoteRequestRepository
+	attachmentService *services.AttachmentService
+	captchaVerifier   *services.CaptchaVerifier
+	chatNotifier      *services.ChatNotifier
+	notificationRepo  *repository.NotificationRepository
+	auditRepo         *repository.AuditRepository
+}
+
+// NewQuoteRequestHandler creates a new quote request handler
+func NewQuoteRequestHandler(
+	quoteRequestRepo *repository.QuoteRequestRepository,
+	attachmentService *services.AttachmentService,
+	captchaVerifier *services.CaptchaVerifier,
+	chatNotifier *services.ChatNotifier,
+	notificationRepo *repository.NotificationRepository,
+	auditRepo *repository.AuditRepository,
+) *QuoteRequestHandler {
+	return &QuoteRequestHandler{
+		quoteRequestRepo:  quoteRequestRepo,
+		attachmentService: attachmentService,
+		captchaVerifier:   captchaVerifier,
+		chatNotifier:      chatNotifier,
+		notificationRepo:  notificationRepo,
+		auditRepo:         auditRepo,
+	}
+}
+
+// BodyLimit refuses quote request bodies larger than every attachment at the largest size
+// allowed plus the form fields, before any of the upload is read
+func (h *QuoteRequestHandler) BodyLimit() echo.MiddlewareFunc {
+	limit := maxQuoteRequestAttachments*h.attachmentService.MaxBytes() + quoteRequestFieldBytes
+	return middleware.BodyLimit(strconv.FormatInt(limit, 10))
+}
+
+// QuoteRequestReceipt acknowledges a quote request sent from the public form
+type QuoteRequestReceipt struct {
+	QuoteRequestID int    `json:"quote_request_id"`
+	Message        string `json:"message"`
+}
+
+// QuoteRequestStatusRequest reopens or dismisses a quote request
+type QuoteRequestStatusRequest struct {
+	Status string `json:"status" validate:"required,oneof=New Dismissed"`
+}
+
+// SubmitQuoteRequest records a quote request from the marketing site's public form, without
+// a login. It is rate limited per IP and, when CAPTCHA_SECRET is set, needs a captcha token.
+//
+// Form fields (multipart or URL-encoded): company_name, contact_name, email and message
+// (required), phone, captcha_token, and up to 5 PNG, JPEG, WebP, GIF or PDF files as
+// attachments. The request is linked to the customer with the same email, if any, and the
+// sales team is notified in the app and in the team chat.
+func (h *QuoteRequestHandler) SubmitQuoteRequest(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	request := models.QuoteRequest{
+		CompanyName: strings.TrimSpace(c.FormValue("company_name")),
+		ContactName: strings.TrimSpace(c.FormValue("contact_name")),
+		Email:       strings.TrimSpace(c.FormValue("email")),
+		Message:     strings.TrimSpace(c.FormValue("message")),
+		Status:      models.QuoteRequestNew,
+	}
+	if phone := strings.TrimSpace(c.FormValue("phone")); phone != "" {
+		request.Phone = &phone
+	}
+	if msg := checkQuoteRequest(request); msg != "" {
+		return models.NewAPIError(http.StatusBadRequest, msg)
+	}
+
+	files, err := quoteRequestFiles(c)
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid attachment upload")
+	}
+	if len(files) > maxQuoteRequestAttachments {
+		return models.NewAPIError(http.StatusBadRequest, fmt.Sprintf("At most %d files can be attached", maxQuoteRequestAttachments))
+	}
+
+	var token string
+	for _, field := range captchaFields {
+		if token = strings.TrimSpace(c.FormValue(field)); token != "" {
+			break
+		}
+	}
+	if err := h.captchaVerifier.Verify(ctx, token, c.RealIP()); err != nil {
+		if errors.Is(err, services.ErrCaptchaFailed) {
+			return models.NewAPIError(http.StatusBadRequest, "Captcha verification failed, please try again")
+		}
+		log.Printf("Failed to verify quote request captcha: %v", err)
+		return models.NewAPIError(http.StatusServiceUnavailable, "The captcha could not be checked, please try again later")
+	}
+
+	sourceIP := c.RealIP()
+	request.SourceIP = &sourceIP
+	if err := h.quoteRequestRepo.Create(ctx, &request); err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to save quote request")
+	}
+
+	// Store the files; the request is removed again if one cannot be stored
+	for _, file := range files {
+		attachment, err := h.attachmentService.Save(ctx, models.AuditEntityQuoteRequest, request.QuoteRequestID, models.AttachmentDocument, file, nil)
+		if err != nil {
+			h.discard(ctx, request)
+			var tooLarge *services.AttachmentTooLargeError
+			if errors.As(err, &tooLarge) {
+				return models.NewAPIError(http.StatusRequestEntityTooLarge, "\""+file.Filename+"\" "+err.Error())
+			}
+			if err == services.ErrAttachmentType || err == services.ErrAttachmentEmpty {
+				return models.NewAPIError(http.StatusBadRequest, "\""+file.Filename+"\" must be a PNG, JPEG, WebP or GIF image or a PDF")
+			}
+			return models.NewAPIError(http.StatusInternalServerError, "Failed to store the attachments")
+		}
+		request.Attachments = append(request.Attachments, *attachment)
+	}
+
+	notifyUsers(c, h.notificationRepo, models.NotificationQuoteRequest, models.AuditEntityQuoteRequest, request.QuoteRequestID,
+		"Quote request from "+request.CompanyName,
+		fmt.Sprintf("%s (%s) asked for a quotation.", request.ContactName, request.Email))
+	h.chatNotifier.NotifyQuoteRequest(request)
+
+	return c.JSON(http.StatusCreated, QuoteRequestReceipt{
+		QuoteRequestID: request.QuoteRequestID,
+		Message:        "Thank you, we have received your request and will send you a quotation soon.",
+	})
+}
+
+// checkQuoteRequest returns a message describing what is wrong with a quote request from
+// the public form, or "" when it can be saved
+func checkQuoteRequest(request models.QuoteRequest) string {
+	switch {
+	case request.CompanyName == "":
+		return "Company name is required"
+	case len(request.CompanyName) > 200:
+		return "Company name must be at most 200 characters"
+	case request.ContactName == "":
+		return "Contact name is required"
+	case len(request.ContactName) > 200:
+		return "Contact name must be at most 200 characters"
+	case request.Email == "":
+		return "Email is required"
+	case request.Phone != nil && len(*request.Phone) > 50:
+		return "Phone must be at most 50 characters"
+	case request.Message == "":
+		return "Tell us what you would like quoted"
+	case len(request.Message) > 5000:
+		return "Message must be at most 5000 characters"
+	}
+	if address, err := mail.ParseAddress(request.Email); err != nil || address.Address != request.Email {
+		return "Email is not a valid email address"
+	}
+	return ""
+}
+
+// quoteRequestFiles returns the files sent as attachments, none for forms that are not
+// multipart
+func quoteRequestFiles(c echo.Context) ([]*multipart.FileHeader, error) {
+	if !strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		return nil, nil
+	}
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, err
+	}
+	return form.File["attachments"], nil
+}
+
+// discard removes a quote request whose attachments could not be stored, with those that were
+func (h *QuoteRequestHandler) discard(ctx context.Context, request models.QuoteRequest) {
+	for _, attachment := range request.Attachments {
+		if err := h.attachmentService.Delete(ctx, attachment.AttachmentID); err != nil {
+			log.Printf("Failed to remove attachment %d: %v", attachment.AttachmentID, err)
+		}
+	}
+	if err := h.quoteRequestRepo.Delete(ctx, request.QuoteRequestID); err != nil {
+		log.Printf("Failed to remove quote request %d: %v", request.QuoteRequestID, err)
+	}
+}
+
+// GetQuoteRequests returns quote requests, newest first. ?status= limits them to New, Quoted
+// or Dismissed.
+func (h *QuoteRequestHandler) GetQuoteRequests(c echo.Context) error {
+	status := c.QueryParam("status")
+	if status != "" && !containsString(models.QuoteRequestStatuses, status) {
+		return models.NewAPIError(http.StatusBadRequest, "status must be one of: "+strings.Join(models.QuoteRequestStatuses, ", "))
+	}
+
+	page, paged, message := parsePage(c)
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	requests, total, err := h.quoteRequestRepo.GetAll(c.Request().Context(), status, page)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve quote requests")
+	}
+
+	return jsonPage(c, http.StatusOK, requests, page, paged, total)
+}
+
+// GetQuoteRequest returns a quote request with its attachments, which are downloaded from
+// /api/attachments/:id
+func (h *QuoteRequestHandler) GetQuoteRequest(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid quote request ID")
+	}
+
+	request, err := h.quoteRequestRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Quote request not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve quote request")
+	}
+
+	request.Attachments, err = h.attachmentService.List(ctx, models.AuditEntityQuoteRequest, id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve quote request attachments")
+	}
+
+	return c.JSON(http.StatusOK, request)
+}
+
+// UpdateQuoteRequestStatus dismisses a quote request, such as spam, or reopens a dismissed
+// one. Requests a quotation has been prepared for cannot be changed.
+func (h *QuoteRequestHandler) UpdateQuoteRequestStatus(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid quote request ID")
+	}
+
+	var req QuoteRequestStatusRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	before, err := h.quoteRequestRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Quote request not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve quote request")
+	}
+
+	var handledBy *int
+	if user := appmw.UserFromContext(c); user != nil {
+		handledBy = &user.UserID
+	}
+	updated, err := h.quoteRequestRepo.UpdateStatus(ctx, id, req.Status, handledBy)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return models.NewAPIError(http.StatusNotFound, "Quote request not found")
+		case errors.Is(err, repository.ErrConflict):
+			return models.NewAPIError(http.StatusConflict, "A quotation has already been prepared for this quote request")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update quote request")
+	}
+
+	recordAudit(c, h.auditRepo, "status", models.AuditEntityQuoteRequest, id, before, updated)
+
+	return c.JSON(http.StatusOK, updated)
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// errOrderNotPaid is returned when a receipt is requested for an order that has not been paid
+var errOrderNotPaid = errors.New("order has not been paid")
+
+// receiptPaperWidth reads the ?width= paper width in millimetres, defaulting to 80mm
+func receiptPaperWidth(c echo.Context) (int, error) {
+	value := c.QueryParam("width")
+	if value == "" {
+		return services.ReceiptPaper80mm, nil
+	}
+	width, err := strconv.Atoi(value)
+	if err != nil || services.ReceiptColumns[width] == 0 {
+		return 0, errors.New("Invalid width, expected 58 or 80")
+	}
+	return width, nil
+}
+
+// buildReceipt lays out the receipt of a paid order
+func buildReceipt(
+	ctx context.Context,
+	orderRepo *repository.OrderRepository,
+	customerRepo *repository.CustomerRepository,
+	productRepo *repository.ProductRepository,
+	order models.Order,
+) (services.Receipt, error) {
+	if order.PaidAt == nil {
+		return services.Receipt{}, errOrderNotPaid
+	}
+
+	items, err := orderRepo.GetOrderItems(ctx, order.OrderID)
+	if err != nil {
+		return services.Receipt{}, fmt.Errorf("failed to retrieve order items: %w", err)
+	}
+
+	customer, err := customerRepo.GetByIDWithDeleted(ctx, order.CustomerID)
+	if err != nil {
+		return services.Receipt{}, fmt.Errorf("failed to retrieve customer: %w", err)
+	}
+
+	amountPaid := order.TotalAmount
+	if order.AmountPaid != nil {
+		amountPaid = *order.AmountPaid
+	}
+	receipt := services.Receipt{
+		Title:      "CENTER INDUSTRIAL SUPPLY CORPORATION",
+		Subtitle:   "SALES RECEIPT",
+		OrderID:    order.OrderID,
+		Date:       order.PaidAt.Format("Jan 2, 2006 3:04 PM"),
+		Customer:   customer.CompanyName,
+		Lines:      make([]services.ReceiptLine, len(items)),
+		Total:      order.TotalAmount,
+		AmountPaid: amountPaid,
+		ChangeDue:  math.Round((amountPaid-order.TotalAmount)*100) / 100,
+		Footer:     "Thank you for your purchase!",
+	}
+	if order.PaymentMethod != nil {
+		receipt.PaymentMethod = *order.PaymentMethod
+	}
+
+	for i, item := range items {
+		receipt.Lines[i] = services.ReceiptLine{
+			ProductName: "Product #" + strconv.Itoa(item.ProductID),
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			Discount:    item.Discount,
+			LineTotal:   item.LineTotal,
+		}
+		if product, err := productRepo.GetByIDWithDeleted(ctx, item.ProductID); err == nil {
+			receipt.Lines[i].ProductName = product.ProductName
+		}
+	}
+
+	return receipt, nil
+}
@@ -0,0 +1,440 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/labstack/echo/v4"
+)
+
+// ReceivingHandler handles HTTP requests for the receiving checklist and stock receipts
+type ReceivingHandler struct {
+	receivingRepo *repository.ReceivingRepository
+	inventoryRepo *repository.InventoryRepository
+	productRepo   *repository.ProductRepository
+	auditRepo     *repository.AuditRepository
+}
+
+// NewReceivingHandler creates a new receiving handler with the provided repositories
+func NewReceivingHandler(
+	receivingRepo *repository.ReceivingRepository,
+	inventoryRepo *repository.InventoryRepository,
+	productRepo *repository.ProductRepository,
+	auditRepo *repository.AuditRepository,
+) *ReceivingHandler {
+	return &ReceivingHandler{
+		receivingRepo: receivingRepo,
+		inventoryRepo: inventoryRepo,
+		productRepo:   productRepo,
+		auditRepo:     auditRepo,
+	}
+}
+
+// ReceiptCheckAnswer is the answer to one checklist item
+type ReceiptCheckAnswer struct {
+	ItemID int    `json:"item_id"`
+	Value  string `json:"value"`
+}
+
+// StockReceiptRequest records stock arriving for an inventory item
+type StockReceiptRequest struct {
+	Quantity       int                  `json:"quantity" validate:"gt=0"`
+	Reference      string               `json:"reference"`
+	TemperatureC   *float64             `json:"temperature_c"`
+	ConditionNotes string               `json:"condition_notes"`
+	Checks         []ReceiptCheckAnswer `json:"checks"`
+}
+
+// GetChecklist returns every checklist item, or with ?product_id= the active items that
+// apply to that product and must be answered when receiving it
+func (h *ReceivingHandler) GetChecklist(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	productID := c.QueryParam("product_id")
+	if productID == "" {
+		items, err := h.receivingRepo.GetChecklistItems(ctx)
+		if err != nil {
+			return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve receiving checklist")
+		}
+		return jsonList(c, http.StatusOK, items)
+	}
+
+	id, err := strconv.Atoi(productID)
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid product ID")
+	}
+	product, err := h.productRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Product not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve product")
+	}
+
+	items, err := h.receivingRepo.GetChecklistForCategory(ctx, product.Category)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve receiving checklist")
+	}
+	return jsonList(c, http.StatusOK, items)
+}
+
+// CreateChecklistItem adds an item to the receiving checklist
+func (h *ReceivingHandler) CreateChecklistItem(c echo.Context) error {
+	item := models.ReceivingChecklistItem{Required: true, Active: true}
+	if err := c.Bind(&item); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&item); err != nil {
+		return validationError(err)
+	}
+
+	if msg := checkChecklistItem(&item); msg != "" {
+		return models.NewAPIError(http.StatusBadRequest, msg)
+	}
+
+	if err := h.receivingRepo.CreateChecklistItem(c.Request().Context(), &item); err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to create checklist item")
+	}
+
+	return c.JSON(http.StatusCreated, item)
+}
+
+// UpdateChecklistItem updates a receiving checklist item
+func (h *ReceivingHandler) UpdateChecklistItem(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid checklist item ID")
+	}
+
+	var item models.ReceivingChecklistItem
+	if err := c.Bind(&item); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&item); err != nil {
+		return validationError(err)
+	}
+	item.ItemID = id
+
+	if msg := checkChecklistItem(&item); msg != "" {
+		return models.NewAPIError(http.StatusBadRequest, msg)
+	}
+
+	if err := h.receivingRepo.UpdateChecklistItem(c.Request().Context(), &item); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Checklist item not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update checklist item")
+	}
+
+	return c.JSON(http.StatusOK, item)
+}
+
+// DeleteChecklistItem removes a receiving checklist item; answers already recorded are kept
+func (h *ReceivingHandler) DeleteChecklistItem(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid checklist item ID")
+	}
+
+	if err := h.receivingRepo.DeleteChecklistItem(c.Request().Context(), id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Checklist item not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to delete checklist item")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// CreateReceipt records stock received for an inventory item together with the answers
+// to its receiving checklist. Receipts where every check passed add the quantity to
+// stock; failed receipts are recorded for QA but leave stock unchanged.
+func (h *ReceivingHandler) CreateReceipt(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid inventory ID")
+	}
+
+	var req StockReceiptRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	before, err := h.inventoryRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Inventory item not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve inventory item")
+	}
+
+	product, err := h.productRepo.GetByID(ctx, before.ProductID)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve product")
+	}
+	items, err := h.receivingRepo.GetChecklistForCategory(ctx, product.Category)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve receiving checklist")
+	}
+
+	checks, passed, msg := evaluateChecklist(items, req.Checks)
+	if msg != "" {
+		return models.NewAPIError(http.StatusBadRequest, msg)
+	}
+
+	receipt := models.StockReceipt{
+		InventoryID:    id,
+		Quantity:       req.Quantity,
+		Reference:      strings.TrimSpace(req.Reference),
+		TemperatureC:   req.TemperatureC,
+		ConditionNotes: strings.TrimSpace(req.ConditionNotes),
+		Passed:         passed,
+		Checks:         checks,
+	}
+	if user := appmw.UserFromContext(c); user != nil {
+		receipt.ReceivedBy = &user.UserID
+	}
+
+	if err := h.receivingRepo.CreateReceipt(ctx, &receipt); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Inventory item not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to record stock receipt")
+	}
+
+	inventory, err := h.inventoryRepo.GetByID(ctx, id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Receipt recorded but failed to retrieve updated inventory")
+	}
+	if receipt.Passed {
+		recordAudit(c, h.auditRepo, models.AuditUpdate, models.AuditEntityInventory, id, before, inventory)
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"receipt":   receipt,
+		"inventory": inventory,
+	})
+}
+
+// GetReceipts returns the receipts recorded for an inventory item, newest first
+func (h *ReceivingHandler) GetReceipts(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid inventory ID")
+	}
+
+	receipts, err := h.receivingRepo.GetReceiptsByInventory(c.Request().Context(), id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve stock receipts")
+	}
+
+	return jsonList(c, http.StatusOK, receipts)
+}
+
+// GetReceivingReport returns receipts and their inspection results for QA audits. Filter
+// with ?from= and ?to= (YYYY-MM-DD, inclusive), ?product_id= and ?failed=true.
+func (h *ReceivingHandler) GetReceivingReport(c echo.Context) error {
+	rows, status, msg := h.receivingReport(c)
+	if msg != "" {
+		return models.NewAPIError(status, msg)
+	}
+	return jsonList(c, http.StatusOK, rows)
+}
+
+// ExportReceivingReportCSV exports the receiving report as CSV with one row per check, or as
+// an Excel workbook with ?format=xlsx
+func (h *ReceivingHandler) ExportReceivingReportCSV(c echo.Context) error {
+	format, err := exportFormat(c)
+	if err != nil {
+		return err
+	}
+
+	rows, status, msg := h.receivingReport(c)
+	if msg != "" {
+		return models.NewAPIError(status, msg)
+	}
+
+	report := &reportExport{
+		Title:    "Receiving Inspections",
+		FileName: "receiving_inspections",
+		Columns: []exportColumn{
+			{Header: "Receipt ID", Kind: columnInteger},
+			{Header: "Received At"},
+			{Header: "Product ID", Kind: columnInteger},
+			{Header: "Product Name"},
+			{Header: "Quantity", Kind: columnInteger},
+			{Header: "Reference"},
+			{Header: "Temperature C", Kind: columnAmount},
+			{Header: "Condition Notes"},
+			{Header: "Receipt Passed"},
+			{Header: "Check"},
+			{Header: "Value"},
+			{Header: "Check Passed"},
+		},
+	}
+	report.addQueryFilters(c, "from", "to", "product_id", "failed")
+
+	for _, row := range rows {
+		line := []interface{}{
+			row.ReceiptID,
+			row.ReceivedAt.Format(time.RFC3339),
+			row.ProductID,
+			row.ProductName,
+			row.Quantity,
+			row.Reference,
+			row.TemperatureC,
+			row.ConditionNotes,
+			row.Passed,
+		}
+		if len(row.Checks) == 0 {
+			report.addRow(append(line, "", "", nil)...)
+			continue
+		}
+		for _, check := range row.Checks {
+			value := check.Value
+			if check.Unit != "" && check.InputType == models.ChecklistNumber {
+				value += " " + check.Unit
+			}
+			report.addRow(append(line[:len(line):len(line)], check.Label, value, check.Passed)...)
+		}
+	}
+
+	return writeExport(c, format, report)
+}
+
+// receivingReport loads the report for the request's filters, returning a status and
+// message when the filters are invalid or the query fails
+func (h *ReceivingHandler) receivingReport(c echo.Context) ([]models.ReceivingReportRow, int, string) {
+	filter := repository.ReceivingReportFilter{
+		FailedOnly: c.QueryParam("failed") == "true",
+	}
+	if productID := c.QueryParam("product_id"); productID != "" {
+		id, err := strconv.Atoi(productID)
+		if err != nil {
+			return nil, http.StatusBadRequest, "Invalid product ID"
+		}
+		filter.ProductID = id
+	}
+	for param, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		value := c.QueryParam(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return nil, http.StatusBadRequest, "Invalid " + param + " date, expected YYYY-MM-DD"
+		}
+		if param == "to" {
+			// Include the whole end day
+			parsed = parsed.AddDate(0, 0, 1)
+		}
+		*target = &parsed
+	}
+
+	rows, err := h.receivingRepo.GetReport(c.Request().Context(), filter)
+	if err != nil {
+		return nil, http.StatusInternalServerError, "Failed to retrieve receiving report"
+	}
+	return rows, 0, ""
+}
+
+// checkChecklistItem normalises a checklist item and returns a validation message when it is invalid
+func checkChecklistItem(item *models.ReceivingChecklistItem) string {
+	item.Label = strings.TrimSpace(item.Label)
+	if item.Category != nil {
+		if category := strings.TrimSpace(*item.Category); category != "" {
+			item.Category = &category
+		} else {
+			item.Category = nil
+		}
+	}
+	if item.InputType == "" {
+		item.InputType = models.ChecklistPassFail
+	}
+	if item.InputType != models.ChecklistNumber {
+		item.MinValue, item.MaxValue = nil, nil
+	}
+	if item.MinValue != nil && item.MaxValue != nil && *item.MinValue > *item.MaxValue {
+		return "Minimum value cannot be greater than maximum value"
+	}
+	item.Unit = strings.TrimSpace(item.Unit)
+	return ""
+}
+
+// evaluateChecklist matches answers to the checklist items that apply to a receipt and
+// works out whether each passed. Every required item must be answered; optional items
+// may be skipped. Returns a validation message for missing, unknown or malformed answers.
+func evaluateChecklist(items []models.ReceivingChecklistItem, answers []ReceiptCheckAnswer) ([]models.StockReceiptCheck, bool, string) {
+	values := map[int]string{}
+	for _, answer := range answers {
+		values[answer.ItemID] = strings.TrimSpace(answer.Value)
+	}
+
+	checks := []models.StockReceiptCheck{}
+	passed := true
+	for _, item := range items {
+		value, answered := values[item.ItemID]
+		delete(values, item.ItemID)
+		if !answered || value == "" {
+			if item.Required {
+				return nil, false, "Checklist item \"" + item.Label + "\" must be answered"
+			}
+			continue
+		}
+
+		check := models.StockReceiptCheck{
+			ItemID:    &item.ItemID,
+			Label:     item.Label,
+			InputType: item.InputType,
+			MinValue:  item.MinValue,
+			MaxValue:  item.MaxValue,
+			Unit:      item.Unit,
+			Value:     value,
+			Passed:    true,
+		}
+
+		switch item.InputType {
+		case models.ChecklistPassFail:
+			switch strings.ToLower(value) {
+			case "pass", "yes", "true":
+				check.Value = "pass"
+			case "fail", "no", "false":
+				check.Value = "fail"
+				check.Passed = false
+			default:
+				return nil, false, "Checklist item \"" + item.Label + "\" must be pass or fail"
+			}
+		case models.ChecklistNumber:
+			number, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, false, "Checklist item \"" + item.Label + "\" must be a number"
+			}
+			if (item.MinValue != nil && number < *item.MinValue) || (item.MaxValue != nil && number > *item.MaxValue) {
+				check.Passed = false
+			}
+		}
+
+		if !check.Passed {
+			passed = false
+		}
+		checks = append(checks, check)
+	}
+
+	for itemID := range values {
+		return nil, false, fmt.Sprintf("Checklist item %d does not apply to this product", itemID)
+	}
+
+	return checks, passed, ""
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/labstack/echo/v4"
+)
+
+// ReferenceDataHandler serves the combined dropdown and enumeration payload
+type ReferenceDataHandler struct {
+	referenceRepo *repository.ReferenceDataRepository
+}
+
+// NewReferenceDataHandler creates a new reference data handler
+func NewReferenceDataHandler(referenceRepo *repository.ReferenceDataRepository) *ReferenceDataHandler {
+	return &ReferenceDataHandler{
+		referenceRepo: referenceRepo,
+	}
+}
+
+// GetReferenceData returns all reference lists in one payload. The response carries an ETag
+// computed from its content, and a matching If-None-Match gets 304 Not Modified.
+func (h *ReferenceDataHandler) GetReferenceData(c echo.Context) error {
+	data, err := h.referenceRepo.Get(c.Request().Context())
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve reference data")
+	}
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to encode reference data")
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	header := c.Response().Header()
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", "private, no-cache")
+
+	if etagMatches(c.Request().Header.Get("If-None-Match"), etag) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	return c.JSONBlob(http.StatusOK, body)
+}
+
+// etagMatches reports whether an If-None-Match header value matches the given ETag
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/labstack/echo/v4"
+	"github.com/xuri/excelize/v2"
+)
+
+// Report export formats, chosen with ?format=
+const (
+	exportCSV  = "csv"
+	exportXLSX = "xlsx"
+)
+
+// mimeTypeXLSX is the content type of Excel workbooks
+const mimeTypeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// Kinds of report columns, deciding how their cells are written
+const (
+	columnText = iota
+	columnInteger
+	columnAmount
+	columnPercent
+)
+
+// exportColumn is a column of an exported report. Total adds the column's sum to the
+// summary sheet of Excel exports.
+type exportColumn struct {
+	Header string
+	Kind   int
+	Total  bool
+}
+
+// reportExport is a report laid out as a table for download. Cells are strings, ints or
+// float64s, or pointers to them with nil for an empty cell; Filters describe the parameters
+// the report was run with. TotalsRow marks the last row as a totals line.
+type reportExport struct {
+	Title     string
+	FileName  string
+	Filters   [][2]string
+	Columns   []exportColumn
+	Rows      [][]interface{}
+	TotalsRow bool
+}
+
+// addFilter records a report parameter for the summary sheet
+func (r *reportExport) addFilter(label, value string) {
+	r.Filters = append(r.Filters, [2]string{label, value})
+}
+
+// addQueryFilters records the given query parameters of the request that are set
+func (r *reportExport) addQueryFilters(c echo.Context, params ...string) {
+	for _, param := range params {
+		if value := c.QueryParam(param); value != "" {
+			r.addFilter(param, value)
+		}
+	}
+}
+
+// addRow appends a row of cells, one per column
+func (r *reportExport) addRow(cells ...interface{}) {
+	r.Rows = append(r.Rows, cells)
+}
+
+// exportFormat reads ?format=, csv (the default) or xlsx
+func exportFormat(c echo.Context) (string, error) {
+	switch format := strings.ToLower(c.QueryParam("format")); format {
+	case "", exportCSV:
+		return exportCSV, nil
+	case exportXLSX:
+		return exportXLSX, nil
+	default:
+		return "", models.NewAPIError(http.StatusBadRequest, "Invalid format. Must be one of: csv, xlsx")
+	}
+}
+
+// writeExport sends a report as a download in the given format
+func writeExport(c echo.Context, format string, report *reportExport) error {
+	if format == exportXLSX {
+		return writeXLSXExport(c, report)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv")
+	c.Response().Header().Set(echo.HeaderContentDisposition, "attachment; filename="+report.FileName+".csv")
+
+	csvWriter := csv.NewWriter(c.Response().Writer)
+	headers := make([]string, len(report.Columns))
+	for i, column := range report.Columns {
+		headers[i] = column.Header
+	}
+	csvWriter.Write(headers)
+
+	for _, row := range report.Rows {
+		record := make([]string, len(row))
+		for i, cell := range row {
+			record[i] = csvCell(report.Columns[i], cell)
+		}
+		csvWriter.Write(record)
+	}
+
+	csvWriter.Flush()
+	return nil
+}
+
+// csvCell writes a cell the way CSV exports always have: amounts and percentages with two
+// decimals
+func csvCell(column exportColumn, cell interface{}) string {
+	switch value := cellValue(cell).(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case int:
+		return strconv.Itoa(value)
+	case float64:
+		if column.Kind == columnInteger {
+			return strconv.FormatFloat(value, 'f', -1, 64)
+		}
+		return fmt.Sprintf("%.2f", value)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// cellValue dereferences a pointer cell, returning nil for a nil pointer
+func cellValue(cell interface{}) interface{} {
+	v := reflect.ValueOf(cell)
+	if v.Kind() != reflect.Ptr {
+		return cell
+	}
+	if v.IsNil() {
+		return nil
+	}
+	return v.Elem().Interface()
+}
+
+// writeXLSXExport sends a report as an Excel workbook: the report on its first sheet, with a
+// frozen, filterable header row and number formats for amounts and percentages, and a
+// Summary sheet with the parameters, row count and column totals
+func writeXLSXExport(c echo.Context, report *reportExport) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := sheetName(report.Title)
+	f.SetSheetName("Sheet1", sheet)
+
+	styles, err := newExportStyles(f)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to build Excel export")
+	}
+
+	widths := make([]int, len(report.Columns))
+	for i, column := range report.Columns {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheet, cell, column.Header)
+		widths[i] = len(column.Header)
+	}
+	lastHeader, _ := excelize.CoordinatesToCellName(len(report.Columns), 1)
+	f.SetCellStyle(sheet, "A1", lastHeader, styles.header)
+
+	totals := make([]float64, len(report.Columns))
+	dataRows := len(report.Rows)
+	if report.TotalsRow && dataRows > 0 {
+		dataRows--
+	}
+	for r, row := range report.Rows {
+		for i, cell := range row {
+			name, _ := excelize.CoordinatesToCellName(i+1, r+2)
+			value := cellValue(cell)
+			if value == nil {
+				continue
+			}
+			f.SetCellValue(sheet, name, value)
+
+			style := styles.forKind(report.Columns[i].Kind)
+			if report.TotalsRow && r == len(report.Rows)-1 {
+				style = styles.totalForKind(report.Columns[i].Kind)
+			}
+			if style != 0 {
+				f.SetCellStyle(sheet, name, name, style)
+			}
+
+			if width := len(csvCell(report.Columns[i], value)); width > widths[i] {
+				widths[i] = width
+			}
+			if r < dataRows {
+				switch number := value.(type) {
+				case int:
+					totals[i] += float64(number)
+				case float64:
+					totals[i] += number
+				}
+			}
+		}
+	}
+
+	for i, width := range widths {
+		column, _ := excelize.ColumnNumberToName(i + 1)
+		f.SetColWidth(sheet, column, column, float64(min(width, 60)+2))
+	}
+	f.SetPanes(sheet, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"})
+	if len(report.Rows) > 0 && len(report.Columns) > 0 {
+		lastCell, _ := excelize.CoordinatesToCellName(len(report.Columns), dataRows+1)
+		f.AutoFilter(sheet, "A1:"+lastCell, nil)
+	}
+
+	writeSummarySheet(f, report, styles, dataRows, totals)
+
+	c.Response().Header().Set(echo.HeaderContentType, mimeTypeXLSX)
+	c.Response().Header().Set(echo.HeaderContentDisposition, "attachment; filename="+report.FileName+".xlsx")
+	c.Response().WriteHeader(http.StatusOK)
+	_, err = f.WriteTo(c.Response().Writer)
+	return err
+}
+
+// writeSummarySheet adds the Summary sheet of an Excel export
+func writeSummarySheet(f *excelize.File, report *reportExport, styles exportStyles, rows int, totals []float64) {
+	const summary = "Summary"
+	f.NewSheet(summary)
+	f.SetColWidth(summary, "A", "A", 28)
+	f.SetColWidth(summary, "B", "B", 40)
+
+	f.SetCellValue(summary, "A1", report.Title)
+	f.SetCellStyle(summary, "A1", "A1", styles.title)
+	f.SetCellValue(summary, "A3", "Generated")
+	f.SetCellValue(summary, "B3", time.Now().Format("2006-01-02 15:04"))
+
+	line := 4
+	for _, filter := range report.Filters {
+		f.SetCellValue(summary, fmt.Sprintf("A%d", line), filter[0])
+		f.SetCellValue(summary, fmt.Sprintf("B%d", line), filter[1])
+		line++
+	}
+	f.SetCellValue(summary, fmt.Sprintf("A%d", line), "Rows")
+	f.SetCellValue(summary, fmt.Sprintf("B%d", line), rows)
+	line++
+
+	for i, column := range report.Columns {
+		if !column.Total {
+			continue
+		}
+		label, value := fmt.Sprintf("A%d", line), fmt.Sprintf("B%d", line)
+		f.SetCellValue(summary, label, "Total "+column.Header)
+		f.SetCellValue(summary, value, totals[i])
+		if style := styles.forKind(column.Kind); style != 0 {
+			f.SetCellStyle(summary, value, value, style)
+		}
+		line++
+	}
+	f.SetCellStyle(summary, "A3", fmt.Sprintf("A%d", line-1), styles.label)
+}
+
+// exportStyles are the cell styles of an Excel export
+type exportStyles struct {
+	title, header, label                 int
+	integer, amount, percent             int
+	totalText, totalInteger, totalAmount int
+	totalPercent                         int
+}
+
+// newExportStyles registers the cell styles of an Excel export with the workbook
+func newExportStyles(f *excelize.File) (exportStyles, error) {
+	var styles exportStyles
+	integerFormat, amountFormat, percentFormat := "0", "#,##0.00", `0.00"%"`
+	definitions := []struct {
+		target *int
+		style  *excelize.Style
+	}{
+		{&styles.title, &excelize.Style{Font: &excelize.Font{Bold: true, Size: 14}}},
+		{&styles.header, &excelize.Style{
+			Font:      &excelize.Font{Bold: true, Color: "FFFFFF"},
+			Fill:      excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"1F4E78"}},
+			Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center", WrapText: true},
+		}},
+		{&styles.label, &excelize.Style{Font: &excelize.Font{Bold: true}}},
+		{&styles.integer, &excelize.Style{CustomNumFmt: &integerFormat}},
+		{&styles.amount, &excelize.Style{CustomNumFmt: &amountFormat}},
+		{&styles.percent, &excelize.Style{CustomNumFmt: &percentFormat}},
+		{&styles.totalText, &excelize.Style{Font: &excelize.Font{Bold: true}}},
+		{&styles.totalInteger, &excelize.Style{Font: &excelize.Font{Bold: true}, CustomNumFmt: &integerFormat}},
+		{&styles.totalAmount, &excelize.Style{Font: &excelize.Font{Bold: true}, CustomNumFmt: &amountFormat}},
+		{&styles.totalPercent, &excelize.Style{Font: &excelize.Font{Bold: true}, CustomNumFmt: &percentFormat}},
+	}
+	for _, definition := range definitions {
+		id, err := f.NewStyle(definition.style)
+		if err != nil {
+			return styles, err
+		}
+		*definition.target = id
+	}
+	return styles, nil
+}
+
+// forKind returns the style of a data cell of the given column kind, 0 for none
+func (s exportStyles) forKind(kind int) int {
+	switch kind {
+	case columnInteger:
+		return s.integer
+	case columnAmount:
+		return s.amount
+	case columnPercent:
+		return s.percent
+	}
+	return 0
+}
+
+// totalForKind returns the style of a totals row cell of the given column kind
+func (s exportStyles) totalForKind(kind int) int {
+	switch kind {
+	case columnInteger:
+		return s.totalInteger
+	case columnAmount:
+		return s.totalAmount
+	case columnPercent:
+		return s.totalPercent
+	}
+	return s.totalText
+}
+
+// sheetName makes a report title usable as a worksheet name, which is limited to 31
+// characters without []:*?/\
+func sheetName(title string) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '-'
+		}
+		return r
+	}, title)
+	if len(name) > 31 {
+		name = name[:31]
+	}
+	if name == "" || name == "Summary" {
+		return "Report"
+	}
+	return name
+}
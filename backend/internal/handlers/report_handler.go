@@ -1,28 +1,36 @@
 package handlers
 
 import (
-	"encoding/csv"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Cezzyy/SCMS/backend/internal/models"
 	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
 	"github.com/labstack/echo/v4"
 )
 
 // ReportHandler handles HTTP requests for dashboard reports
 type ReportHandler struct {
-	reportRepo *repository.ReportRepository
+	reportRepo       *repository.ReportRepository
+	salesBookService *services.SalesBookService
+	dashboardService *services.DashboardService
 }
 
-// NewReportHandler creates a new report handler with the provided repository
-func NewReportHandler(reportRepo *repository.ReportRepository) *ReportHandler {
+// NewReportHandler creates a new report handler with the provided repository and services
+func NewReportHandler(reportRepo *repository.ReportRepository, salesBookService *services.SalesBookService, dashboardService *services.DashboardService) *ReportHandler {
 	return &ReportHandler{
-		reportRepo: reportRepo,
+		reportRepo:       reportRepo,
+		salesBookService: salesBookService,
+		dashboardService: dashboardService,
 	}
 }
 
-// GetDashboardSummary returns all dashboard data in a single request
+// GetDashboardSummary returns all dashboard data in a single request. The summary is reused
+// for a short while; ?refresh=true computes it afresh.
 func (h *ReportHandler) GetDashboardSummary(c echo.Context) error {
 	ctx := c.Request().Context()
 
@@ -33,18 +41,14 @@ func (h *ReportHandler) GetDashboardSummary(c echo.Context) error {
 		var err error
 		days, err = strconv.Atoi(daysStr)
 		if err != nil || days <= 0 {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "Invalid days parameter. Must be a positive integer.",
-			})
+			return models.NewAPIError(http.StatusBadRequest, "Invalid days parameter. Must be a positive integer.")
 		}
 	}
 
 	// Get dashboard summary
-	summary, err := h.reportRepo.GetDashboardSummary(ctx, days)
+	summary, err := h.dashboardService.Summary(ctx, days, c.QueryParam("refresh") == "true")
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve dashboard data: " + err.Error(),
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve dashboard data: "+err.Error())
 	}
 
 	return c.JSON(http.StatusOK, summary)
@@ -61,18 +65,14 @@ func (h *ReportHandler) GetSalesTrends(c echo.Context) error {
 		var err error
 		days, err = strconv.Atoi(daysStr)
 		if err != nil || days <= 0 {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "Invalid days parameter. Must be a positive integer.",
-			})
+			return models.NewAPIError(http.StatusBadRequest, "Invalid days parameter. Must be a positive integer.")
 		}
 	}
 
 	// Get sales trends
 	trends, err := h.reportRepo.GetSalesTrends(ctx, days)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve sales trends: " + err.Error(),
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve sales trends: "+err.Error())
 	}
 
 	return c.JSON(http.StatusOK, trends)
@@ -85,9 +85,7 @@ func (h *ReportHandler) GetLowStockItems(c echo.Context) error {
 	// Get low stock items
 	items, err := h.reportRepo.GetLowStockItems(ctx)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve low stock items: " + err.Error(),
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve low stock items: "+err.Error())
 	}
 
 	return c.JSON(http.StatusOK, items)
@@ -104,9 +102,7 @@ func (h *ReportHandler) GetTopCustomers(c echo.Context) error {
 		var err error
 		limit, err = strconv.Atoi(limitStr)
 		if err != nil || limit <= 0 {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "Invalid limit parameter. Must be a positive integer.",
-			})
+			return models.NewAPIError(http.StatusBadRequest, "Invalid limit parameter. Must be a positive integer.")
 		}
 	}
 
@@ -117,27 +113,29 @@ func (h *ReportHandler) GetTopCustomers(c echo.Context) error {
 		var err error
 		days, err = strconv.Atoi(daysStr)
 		if err != nil || days <= 0 {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "Invalid days parameter. Must be a positive integer.",
-			})
+			return models.NewAPIError(http.StatusBadRequest, "Invalid days parameter. Must be a positive integer.")
 		}
 	}
 
 	// Get top customers
 	customers, err := h.reportRepo.GetTopCustomers(ctx, limit, days)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve top customers: " + err.Error(),
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve top customers: "+err.Error())
 	}
 
 	return c.JSON(http.StatusOK, customers)
 }
 
-// ExportSalesTrendsCSV exports sales trend data as CSV
+// ExportSalesTrendsCSV exports sales trend data as CSV, or as an Excel workbook with
+// ?format=xlsx
 func (h *ReportHandler) ExportSalesTrendsCSV(c echo.Context) error {
 	ctx := c.Request().Context()
 
+	format, err := exportFormat(c)
+	if err != nil {
+		return err
+	}
+
 	// Get days parameter, default to 7 if not provided
 	daysStr := c.QueryParam("days")
 	days := 7
@@ -145,80 +143,77 @@ func (h *ReportHandler) ExportSalesTrendsCSV(c echo.Context) error {
 		var err error
 		days, err = strconv.Atoi(daysStr)
 		if err != nil || days <= 0 {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "Invalid days parameter. Must be a positive integer.",
-			})
+			return models.NewAPIError(http.StatusBadRequest, "Invalid days parameter. Must be a positive integer.")
 		}
 	}
 
 	// Get sales trends
 	trends, err := h.reportRepo.GetSalesTrends(ctx, days)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve sales trends: " + err.Error(),
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve sales trends: "+err.Error())
 	}
 
-	// Set headers for CSV download
-	c.Response().Header().Set(echo.HeaderContentType, "text/csv")
-	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=sales_trends_%d_days.csv", days))
-
-	// Write CSV headers
-	csvWriter := csv.NewWriter(c.Response().Writer)
-	csvWriter.Write([]string{"Date", "Total Sales"})
-
-	// Write CSV data
+	report := &reportExport{
+		Title:    "Sales Trends",
+		FileName: fmt.Sprintf("sales_trends_%d_days", days),
+		Columns: []exportColumn{
+			{Header: "Date"},
+			{Header: "Total Sales", Kind: columnAmount, Total: true},
+		},
+	}
+	report.addFilter("Days", strconv.Itoa(days))
 	for _, trend := range trends {
-		csvWriter.Write([]string{
-			trend.Day,
-			fmt.Sprintf("%.2f", trend.TotalAmount),
-		})
+		report.addRow(trend.Day, trend.TotalAmount)
 	}
 
-	csvWriter.Flush()
-	return nil
+	return writeExport(c, format, report)
 }
 
-// ExportLowStockItemsCSV exports low stock items data as CSV
+// ExportLowStockItemsCSV exports low stock items data as CSV, or as an Excel workbook with
+// ?format=xlsx
 func (h *ReportHandler) ExportLowStockItemsCSV(c echo.Context) error {
 	ctx := c.Request().Context()
 
+	format, err := exportFormat(c)
+	if err != nil {
+		return err
+	}
+
 	// Get low stock items
 	items, err := h.reportRepo.GetLowStockItems(ctx)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve low stock items: " + err.Error(),
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve low stock items: "+err.Error())
 	}
 
-	// Set headers for CSV download
-	c.Response().Header().Set(echo.HeaderContentType, "text/csv")
-	c.Response().Header().Set(echo.HeaderContentDisposition, "attachment; filename=low_stock_items.csv")
-
-	// Write CSV headers
-	csvWriter := csv.NewWriter(c.Response().Writer)
-	csvWriter.Write([]string{"ID", "Product ID", "Product Name", "Current Stock", "Reorder Level", "Unit Price"})
-
-	// Write CSV data
+	report := &reportExport{
+		Title:    "Low Stock Items",
+		FileName: "low_stock_items",
+		Columns: []exportColumn{
+			{Header: "ID", Kind: columnInteger},
+			{Header: "Product ID", Kind: columnInteger},
+			{Header: "Product Name"},
+			{Header: "Current Stock", Kind: columnInteger},
+			{Header: "Reorder Level", Kind: columnInteger},
+			{Header: "Unit Price", Kind: columnAmount},
+		},
+	}
 	for _, item := range items {
-		csvWriter.Write([]string{
-			fmt.Sprintf("%d", item.ID),
-			fmt.Sprintf("%d", item.ProductID),
-			item.ProductName,
-			fmt.Sprintf("%d", item.CurrentStock),
-			fmt.Sprintf("%d", item.ReorderLevel),
-			fmt.Sprintf("%.2f", item.UnitPrice),
-		})
-	}
-
-	csvWriter.Flush()
-	return nil
+		report.addRow(item.ID, item.ProductID, item.ProductName, item.CurrentStock, item.ReorderLevel, item.UnitPrice)
+	}
+
+	return writeExport(c, format, report)
 }
 
-// ExportTopCustomersCSV exports top customers data as CSV
+// ExportTopCustomersCSV exports top customers data as CSV, or as an Excel workbook with
+// ?format=xlsx
 func (h *ReportHandler) ExportTopCustomersCSV(c echo.Context) error {
 	ctx := c.Request().Context()
 
+	format, err := exportFormat(c)
+	if err != nil {
+		return err
+	}
+
 	// Get limit parameter, default to 20 if not provided (export more than displayed)
 	limitStr := c.QueryParam("limit")
 	limit := 20
@@ -226,9 +221,7 @@ func (h *ReportHandler) ExportTopCustomersCSV(c echo.Context) error {
 		var err error
 		limit, err = strconv.Atoi(limitStr)
 		if err != nil || limit <= 0 {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "Invalid limit parameter. Must be a positive integer.",
-			})
+			return models.NewAPIError(http.StatusBadRequest, "Invalid limit parameter. Must be a positive integer.")
 		}
 	}
 
@@ -239,39 +232,385 @@ func (h *ReportHandler) ExportTopCustomersCSV(c echo.Context) error {
 		var err error
 		days, err = strconv.Atoi(daysStr)
 		if err != nil || days <= 0 {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "Invalid days parameter. Must be a positive integer.",
-			})
+			return models.NewAPIError(http.StatusBadRequest, "Invalid days parameter. Must be a positive integer.")
 		}
 	}
 
 	// Get top customers
 	customers, err := h.reportRepo.GetTopCustomers(ctx, limit, days)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to retrieve top customers: " + err.Error(),
-		})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve top customers: "+err.Error())
 	}
 
-	// Set headers for CSV download
-	c.Response().Header().Set(echo.HeaderContentType, "text/csv")
-	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=top_customers_%d_days.csv", days))
+	report := &reportExport{
+		Title:    "Top Customers",
+		FileName: fmt.Sprintf("top_customers_%d_days", days),
+		Columns: []exportColumn{
+			{Header: "Customer ID", Kind: columnInteger},
+			{Header: "Company Name"},
+			{Header: "Contact Name"},
+			{Header: "Total Spent", Kind: columnAmount, Total: true},
+			{Header: "Order Count", Kind: columnInteger, Total: true},
+		},
+	}
+	report.addFilter("Days", strconv.Itoa(days))
+	report.addFilter("Limit", strconv.Itoa(limit))
+	for _, customer := range customers {
+		report.addRow(customer.ID, customer.Name, customer.ContactName, customer.TotalSpent, customer.OrderCount)
+	}
 
-	// Write CSV headers
-	csvWriter := csv.NewWriter(c.Response().Writer)
-	csvWriter.Write([]string{"Customer ID", "Company Name", "Contact Name", "Total Spent", "Order Count"})
+	return writeExport(c, format, report)
+}
 
-	// Write CSV data
-	for _, customer := range customers {
-		csvWriter.Write([]string{
-			fmt.Sprintf("%d", customer.ID),
-			customer.Name,
-			customer.ContactName,
-			fmt.Sprintf("%.2f", customer.TotalSpent),
-			fmt.Sprintf("%d", customer.OrderCount),
-		})
+// GetSalesByChannel returns order totals per sales channel for the specified period
+func (h *ReportHandler) GetSalesByChannel(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	// Get days parameter, default to 30 if not provided
+	daysStr := c.QueryParam("days")
+	days := 30
+	if daysStr != "" {
+		var err error
+		days, err = strconv.Atoi(daysStr)
+		if err != nil || days <= 0 {
+			return models.NewAPIError(http.StatusBadRequest, "Invalid days parameter. Must be a positive integer.")
+		}
+	}
+
+	// Get sales by channel
+	channels, err := h.reportRepo.GetSalesByChannel(ctx, days)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve sales by channel: "+err.Error())
+	}
+
+	return c.JSON(http.StatusOK, channels)
+}
+
+// ExportSalesByChannelCSV exports sales by channel data as CSV, or as an Excel workbook with
+// ?format=xlsx
+func (h *ReportHandler) ExportSalesByChannelCSV(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	format, err := exportFormat(c)
+	if err != nil {
+		return err
+	}
+
+	// Get days parameter, default to 30 if not provided
+	daysStr := c.QueryParam("days")
+	days := 30
+	if daysStr != "" {
+		var err error
+		days, err = strconv.Atoi(daysStr)
+		if err != nil || days <= 0 {
+			return models.NewAPIError(http.StatusBadRequest, "Invalid days parameter. Must be a positive integer.")
+		}
+	}
+
+	// Get sales by channel
+	channels, err := h.reportRepo.GetSalesByChannel(ctx, days)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve sales by channel: "+err.Error())
+	}
+
+	report := &reportExport{
+		Title:    "Sales by Channel",
+		FileName: fmt.Sprintf("sales_by_channel_%d_days", days),
+		Columns: []exportColumn{
+			{Header: "Channel"},
+			{Header: "Order Count", Kind: columnInteger, Total: true},
+			{Header: "Total Sales", Kind: columnAmount, Total: true},
+			{Header: "Share (%)", Kind: columnPercent},
+		},
+	}
+	report.addFilter("Days", strconv.Itoa(days))
+	for _, channel := range channels {
+		report.addRow(channel.Source, channel.OrderCount, channel.TotalAmount, channel.Share)
+	}
+
+	return writeExport(c, format, report)
+}
+
+// GetSalesByPaymentMethod returns the payments received per payment method for the specified period
+func (h *ReportHandler) GetSalesByPaymentMethod(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	// Get days parameter, default to 30 if not provided
+	daysStr := c.QueryParam("days")
+	days := 30
+	if daysStr != "" {
+		var err error
+		days, err = strconv.Atoi(daysStr)
+		if err != nil || days <= 0 {
+			return models.NewAPIError(http.StatusBadRequest, "Invalid days parameter. Must be a positive integer.")
+		}
+	}
+
+	methods, err := h.reportRepo.GetSalesByPaymentMethod(ctx, days)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve sales by payment method: "+err.Error())
+	}
+
+	return c.JSON(http.StatusOK, methods)
+}
+
+// ExportSalesByPaymentMethodCSV exports sales by payment method data as CSV, or as an Excel
+// workbook with ?format=xlsx
+func (h *ReportHandler) ExportSalesByPaymentMethodCSV(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	format, err := exportFormat(c)
+	if err != nil {
+		return err
+	}
+
+	// Get days parameter, default to 30 if not provided
+	daysStr := c.QueryParam("days")
+	days := 30
+	if daysStr != "" {
+		var err error
+		days, err = strconv.Atoi(daysStr)
+		if err != nil || days <= 0 {
+			return models.NewAPIError(http.StatusBadRequest, "Invalid days parameter. Must be a positive integer.")
+		}
+	}
+
+	methods, err := h.reportRepo.GetSalesByPaymentMethod(ctx, days)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve sales by payment method: "+err.Error())
+	}
+
+	report := &reportExport{
+		Title:    "Sales by Payment Method",
+		FileName: fmt.Sprintf("sales_by_payment_method_%d_days", days),
+		Columns: []exportColumn{
+			{Header: "Payment Method"},
+			{Header: "Payment Count", Kind: columnInteger, Total: true},
+			{Header: "Total Received", Kind: columnAmount, Total: true},
+			{Header: "Share (%)", Kind: columnPercent},
+		},
+	}
+	report.addFilter("Days", strconv.Itoa(days))
+	for _, method := range methods {
+		report.addRow(method.Name, method.PaymentCount, method.TotalAmount, method.Share)
+	}
+
+	return writeExport(c, format, report)
+}
+
+// GetRevenueByIndustry returns order totals per customer industry for the specified period
+func (h *ReportHandler) GetRevenueByIndustry(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	// Get days parameter, default to 365 if not provided (1 year)
+	daysStr := c.QueryParam("days")
+	days := 365
+	if daysStr != "" {
+		var err error
+		days, err = strconv.Atoi(daysStr)
+		if err != nil || days <= 0 {
+			return models.NewAPIError(http.StatusBadRequest, "Invalid days parameter. Must be a positive integer.")
+		}
+	}
+
+	// Get revenue by industry
+	industries, err := h.reportRepo.GetRevenueByIndustry(ctx, days)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve revenue by industry: "+err.Error())
+	}
+
+	return c.JSON(http.StatusOK, industries)
+}
+
+// ExportRevenueByIndustryCSV exports revenue by industry data as CSV, or as an Excel workbook
+// with ?format=xlsx
+func (h *ReportHandler) ExportRevenueByIndustryCSV(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	format, err := exportFormat(c)
+	if err != nil {
+		return err
+	}
+
+	// Get days parameter, default to 365 if not provided (1 year)
+	daysStr := c.QueryParam("days")
+	days := 365
+	if daysStr != "" {
+		var err error
+		days, err = strconv.Atoi(daysStr)
+		if err != nil || days <= 0 {
+			return models.NewAPIError(http.StatusBadRequest, "Invalid days parameter. Must be a positive integer.")
+		}
+	}
+
+	// Get revenue by industry
+	industries, err := h.reportRepo.GetRevenueByIndustry(ctx, days)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve revenue by industry: "+err.Error())
+	}
+
+	report := &reportExport{
+		Title:    "Revenue by Industry",
+		FileName: fmt.Sprintf("revenue_by_industry_%d_days", days),
+		Columns: []exportColumn{
+			{Header: "Industry"},
+			{Header: "Customer Count", Kind: columnInteger, Total: true},
+			{Header: "Order Count", Kind: columnInteger, Total: true},
+			{Header: "Total Sales", Kind: columnAmount, Total: true},
+			{Header: "Share (%)", Kind: columnPercent},
+		},
+	}
+	report.addFilter("Days", strconv.Itoa(days))
+	for _, industry := range industries {
+		report.addRow(industry.Industry, industry.CustomerCount, industry.OrderCount, industry.TotalAmount, industry.Share)
+	}
+
+	return writeExport(c, format, report)
+}
+
+// GetSalesBook returns the sales book for ?month= (YYYY-MM, default the current month): each
+// order with its customer's TIN and address and its amount split into VATable, exempt and
+// zero-rated sales and output VAT
+func (h *ReportHandler) GetSalesBook(c echo.Context) error {
+	month, message := salesBookMonth(c)
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	book, err := h.salesBookService.Build(c.Request().Context(), month)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to generate sales book")
+	}
+
+	return c.JSON(http.StatusOK, book)
+}
+
+// ExportSalesBookCSV exports the sales book for ?month= as CSV in the column layout of the
+// BIR sales journal, ending with a totals row, or as an Excel workbook with ?format=xlsx
+func (h *ReportHandler) ExportSalesBookCSV(c echo.Context) error {
+	format, err := exportFormat(c)
+	if err != nil {
+		return err
+	}
+
+	month, message := salesBookMonth(c)
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	book, err := h.salesBookService.Build(c.Request().Context(), month)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to export sales book")
+	}
+
+	report := &reportExport{
+		Title:    "Sales Book",
+		FileName: "sales_book_" + book.Month,
+		Columns: []exportColumn{
+			{Header: "Date"},
+			{Header: "Invoice No", Kind: columnInteger},
+			{Header: "Customer"},
+			{Header: "TIN"},
+			{Header: "Address"},
+			{Header: "Gross Sales", Kind: columnAmount, Total: true},
+			{Header: "VAT-Exempt Sales", Kind: columnAmount, Total: true},
+			{Header: "Zero-Rated Sales", Kind: columnAmount, Total: true},
+			{Header: "VATable Sales", Kind: columnAmount, Total: true},
+			{Header: "Output VAT", Kind: columnAmount, Total: true},
+		},
+		TotalsRow: true,
+	}
+	report.addFilter("Month", book.Month)
+	report.addFilter("VAT Rate (%)", fmt.Sprintf("%.2f", book.VATRate))
+	for _, entry := range book.Entries {
+		invoiceNo := entry.OrderID
+		if entry.DocumentNo != nil {
+			invoiceNo = *entry.DocumentNo
+		}
+		report.addRow(entry.OrderDate.Format("2006-01-02"), invoiceNo, entry.CompanyName, entry.TIN, entry.Address,
+			entry.Gross, entry.ExemptSales, entry.ZeroRatedSales, entry.VatableSales, entry.OutputVAT)
+	}
+	report.addRow("Total", nil, "", "", "", book.Gross, book.ExemptSales, book.ZeroRatedSales, book.VatableSales, book.OutputVAT)
+
+	return writeExport(c, format, report)
+}
+
+// salesBookMonth reads the ?month= (YYYY-MM) of a sales book, defaulting to the current
+// month, returning its first day or a message when it is invalid
+func salesBookMonth(c echo.Context) (time.Time, string) {
+	value := c.QueryParam("month")
+	if value == "" {
+		now := time.Now()
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local), ""
+	}
+
+	month, err := time.ParseInLocation("2006-01", value, time.Local)
+	if err != nil {
+		return time.Time{}, "Invalid month, expected YYYY-MM"
+	}
+	return month, ""
+}
+
+// GetDocumentNumbering reports, per document number series (or only ?series=, one of
+// sales_invoice, quotation, credit_note and invoice), the numbers voided with their reasons and any gaps
+func (h *ReportHandler) GetDocumentNumbering(c echo.Context) error {
+	series := c.QueryParam("series")
+	if series != "" && !containsString(models.DocumentSeriesNames, series) {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid series. Must be one of: "+strings.Join(models.DocumentSeriesNames, ", "))
+	}
+
+	report, err := h.reportRepo.GetDocumentNumbering(c.Request().Context(), series)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to generate document numbering report")
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// ExportDocumentNumberingCSV exports the document numbering report as CSV, one line per gap
+// and per voided number, or as an Excel workbook with ?format=xlsx
+func (h *ReportHandler) ExportDocumentNumberingCSV(c echo.Context) error {
+	format, err := exportFormat(c)
+	if err != nil {
+		return err
+	}
+
+	series := c.QueryParam("series")
+	if series != "" && !containsString(models.DocumentSeriesNames, series) {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid series. Must be one of: "+strings.Join(models.DocumentSeriesNames, ", "))
+	}
+
+	numberings, err := h.reportRepo.GetDocumentNumbering(c.Request().Context(), series)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to export document numbering report")
+	}
+
+	report := &reportExport{
+		Title:    "Document Numbering",
+		FileName: "document_numbering",
+		Columns: []exportColumn{
+			{Header: "Series"},
+			{Header: "Type"},
+			{Header: "Number"},
+			{Header: "Count", Kind: columnInteger, Total: true},
+			{Header: "Document ID", Kind: columnInteger},
+			{Header: "Action"},
+			{Header: "Reason"},
+			{Header: "Voided At"},
+		},
+	}
+	if series != "" {
+		report.addFilter("Series", series)
+	}
+	for _, numbering := range numberings {
+		for _, gap := range numbering.Gaps {
+			report.addRow(numbering.Series, "Gap", gap.Range, gap.Count, nil, "", "", "")
+		}
+		for _, voided := range numbering.Voided {
+			report.addRow(numbering.Series, "Voided", voided.Number, 1, voided.DocumentID,
+				voided.Action, voided.Reason, voided.VoidedAt.Format(time.RFC3339))
+		}
 	}
 
-	csvWriter.Flush()
-	return nil
+	return writeExport(c, format, report)
 }
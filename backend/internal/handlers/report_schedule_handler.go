@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// ReportScheduleHandler handles HTTP requests for reports emailed on a schedule
+type ReportScheduleHandler struct {
+	scheduleRepo    *repository.ReportScheduleRepository
+	reportScheduler *services.ReportScheduler
+	emailService    *services.EmailService
+}
+
+// NewReportScheduleHandler creates a new report schedule handler
+func NewReportScheduleHandler(scheduleRepo *repository.ReportScheduleRepository, reportScheduler *services.ReportScheduler, emailService *services.EmailService) *ReportScheduleHandler {
+	return &ReportScheduleHandler{
+		scheduleRepo:    scheduleRepo,
+		reportScheduler: reportScheduler,
+		emailService:    emailService,
+	}
+}
+
+// ReportScheduleRequest is the body for creating or updating a report schedule. Weekly
+// schedules need a weekday (0 is Sunday) and monthly ones a day_of_month up to 28. days is
+// the period reports cover, 7 by default; timezone is an IANA name such as "Asia/Manila",
+// the server's time zone when empty. Schedules are active unless active is false.
+type ReportScheduleRequest struct {
+	Name       string   `json:"name" validate:"required"`
+	Reports    []string `json:"reports"`
+	Recipients []string `json:"recipients"`
+	Frequency  string   `json:"frequency" validate:"required,oneof=daily weekly monthly"`
+	Weekday    *int     `json:"weekday" validate:"omitempty,gte=0,lte=6"`
+	DayOfMonth *int     `json:"day_of_month" validate:"omitempty,gte=1,lte=28"`
+	Hour       int      `json:"hour" validate:"gte=0,lte=23"`
+	Minute     int      `json:"minute" validate:"gte=0,lte=59"`
+	Timezone   string   `json:"timezone"`
+	Days       int      `json:"days" validate:"gte=0,lte=366"`
+	Active     *bool    `json:"active"`
+}
+
+// schedule checks the request and returns the schedule it describes, due at its next run
+func (req ReportScheduleRequest) schedule() (models.ReportSchedule, string) {
+	schedule := models.ReportSchedule{
+		Name:       strings.TrimSpace(req.Name),
+		Reports:    models.StringList{},
+		Recipients: models.StringList{},
+		Frequency:  req.Frequency,
+		Hour:       req.Hour,
+		Minute:     req.Minute,
+		Timezone:   strings.TrimSpace(req.Timezone),
+		Days:       req.Days,
+		Active:     req.Active == nil || *req.Active,
+	}
+	if schedule.Days == 0 {
+		schedule.Days = 7
+	}
+
+	if len(req.Reports) == 0 {
+		return schedule, "Choose at least one report: " + strings.Join(models.ScheduledReports, ", ")
+	}
+	for _, report := range req.Reports {
+		if !containsString(models.ScheduledReports, report) {
+			return schedule, "Unknown report \"" + report + "\". Use " + strings.Join(models.ScheduledReports, ", ")
+		}
+		if !containsString(schedule.Reports, report) {
+			schedule.Reports = append(schedule.Reports, report)
+		}
+	}
+
+	if len(req.Recipients) == 0 {
+		return schedule, "Add at least one recipient"
+	}
+	for _, recipient := range req.Recipients {
+		address, err := mail.ParseAddress(strings.TrimSpace(recipient))
+		if err != nil {
+			return schedule, "Invalid recipient \"" + recipient + "\""
+		}
+		if !containsString(schedule.Recipients, address.String()) {
+			schedule.Recipients = append(schedule.Recipients, address.String())
+		}
+	}
+
+	switch schedule.Frequency {
+	case models.ScheduleWeekly:
+		if req.Weekday == nil {
+			return schedule, "Weekly schedules need a weekday from 0 (Sunday) to 6 (Saturday)"
+		}
+		schedule.Weekday = req.Weekday
+	case models.ScheduleMonthly:
+		if req.DayOfMonth == nil {
+			return schedule, "Monthly schedules need a day_of_month from 1 to 28"
+		}
+		schedule.DayOfMonth = req.DayOfMonth
+	}
+
+	next, err := services.NextReportRun(schedule, time.Now())
+	if err != nil {
+		return schedule, err.Error()
+	}
+	schedule.NextRunAt = next
+	return schedule, ""
+}
+
+// GetReportSchedules returns all report schedules
+func (h *ReportScheduleHandler) GetReportSchedules(c echo.Context) error {
+	schedules, err := h.scheduleRepo.GetAll(c.Request().Context())
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve report schedules")
+	}
+
+	return jsonList(c, http.StatusOK, schedules)
+}
+
+// GetReportSchedule returns a single report schedule with when it next runs and how its
+// last run went
+func (h *ReportScheduleHandler) GetReportSchedule(c echo.Context) error {
+	schedule, err := h.reportSchedule(c)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, schedule)
+}
+
+// CreateReportSchedule schedules reports to be emailed
+func (h *ReportScheduleHandler) CreateReportSchedule(c echo.Context) error {
+	var req ReportScheduleRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	schedule, message := req.schedule()
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+	if user := appmw.UserFromContext(c); user != nil {
+		schedule.CreatedBy = &user.UserID
+	}
+
+	if err := h.scheduleRepo.Create(c.Request().Context(), &schedule); err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to create report schedule")
+	}
+
+	return c.JSON(http.StatusCreated, schedule)
+}
+
+// UpdateReportSchedule changes a schedule's reports, recipients, timing or whether it is
+// active. Its next run is worked out again from now.
+func (h *ReportScheduleHandler) UpdateReportSchedule(c echo.Context) error {
+	existing, err := h.reportSchedule(c)
+	if err != nil {
+		return err
+	}
+
+	var req ReportScheduleRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	schedule, message := req.schedule()
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+	schedule.ReportScheduleID = existing.ReportScheduleID
+	schedule.LastRunAt = existing.LastRunAt
+	schedule.LastError = existing.LastError
+	schedule.CreatedBy = existing.CreatedBy
+	schedule.CreatedAt = existing.CreatedAt
+
+	if err := h.scheduleRepo.Update(c.Request().Context(), &schedule); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Report schedule not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update report schedule")
+	}
+
+	return c.JSON(http.StatusOK, schedule)
+}
+
+// DeleteReportSchedule removes a report schedule
+func (h *ReportScheduleHandler) DeleteReportSchedule(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid report schedule ID")
+	}
+
+	if err := h.scheduleRepo.Delete(c.Request().Context(), id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Report schedule not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to delete report schedule")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// SendReportSchedule emails a schedule's reports now, to check it, without moving its next
+// run. The outcome is recorded as its last run.
+func (h *ReportScheduleHandler) SendReportSchedule(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	schedule, err := h.reportSchedule(c)
+	if err != nil {
+		return err
+	}
+	if !h.emailService.Enabled() {
+		return models.NewAPIError(http.StatusServiceUnavailable, "Email is not configured")
+	}
+
+	sentAt := time.Now()
+	sendErr := h.reportScheduler.Send(ctx, schedule)
+	if err := h.scheduleRepo.RecordRun(ctx, schedule.ReportScheduleID, sentAt, sendErr); err != nil {
+		log.Printf("Failed to record run of report schedule %d: %v", schedule.ReportScheduleID, err)
+	}
+	if sendErr != nil {
+		return models.NewAPIError(http.StatusBadGateway, "Failed to send reports: "+sendErr.Error())
+	}
+
+	schedule.LastRunAt = &sentAt
+	schedule.LastError = nil
+	return c.JSON(http.StatusOK, schedule)
+}
+
+// reportSchedule loads the schedule named by the :id route parameter
+func (h *ReportScheduleHandler) reportSchedule(c echo.Context) (models.ReportSchedule, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.ReportSchedule{}, models.NewAPIError(http.StatusBadRequest, "Invalid report schedule ID")
+	}
+
+	schedule, err := h.scheduleRepo.GetByID(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return schedule, models.NewAPIError(http.StatusNotFound, "Report schedule not found")
+		}
+		return schedule, models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve report schedule")
+	}
+	return schedule, nil
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// RuleRequest sets a business rule's value
+type RuleRequest struct {
+	Value *float64 `json:"value" validate:"required"`
+}
+
+// RuleHandler handles HTTP requests for the business rules admins tune
+type RuleHandler struct {
+	rulesService *services.RulesService
+	auditRepo    *repository.AuditRepository
+}
+
+// NewRuleHandler creates a new business rule handler
+func NewRuleHandler(rulesService *services.RulesService, auditRepo *repository.AuditRepository) *RuleHandler {
+	return &RuleHandler{
+		rulesService: rulesService,
+		auditRepo:    auditRepo,
+	}
+}
+
+// GetRules returns every business rule with its current value, default and bounds
+func (h *RuleHandler) GetRules(c echo.Context) error {
+	rules, err := h.rulesService.Rules(c.Request().Context())
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve business rules")
+	}
+
+	return jsonList(c, http.StatusOK, rules)
+}
+
+// GetRule returns a business rule
+func (h *RuleHandler) GetRule(c echo.Context) error {
+	rule, err := h.rulesService.Rule(c.Request().Context(), c.Param("key"))
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownRule) {
+			return models.NewAPIError(http.StatusNotFound, "Business rule not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve business rule")
+	}
+
+	return c.JSON(http.StatusOK, rule)
+}
+
+// UpdateRule overrides a business rule's value. The change applies to pricing, alerts and
+// matching from the next request.
+func (h *RuleHandler) UpdateRule(c echo.Context) error {
+	ctx := c.Request().Context()
+	key := c.Param("key")
+
+	var req RuleRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	before, err := h.rulesService.Rule(ctx, key)
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownRule) {
+			return models.NewAPIError(http.StatusNotFound, "Business rule not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve business rule")
+	}
+
+	var updatedBy *int
+	if user := appmw.UserFromContext(c); user != nil {
+		updatedBy = &user.UserID
+	}
+
+	rule, err := h.rulesService.Set(ctx, key, *req.Value, updatedBy)
+	if err != nil {
+		var invalid *services.RuleValueError
+		if errors.As(err, &invalid) {
+			return models.NewAPIError(http.StatusBadRequest, "Value "+invalid.Message)
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update business rule")
+	}
+
+	recordAuditKey(c, h.auditRepo, models.AuditUpdate, models.AuditEntityRule, key, before, rule)
+
+	return c.JSON(http.StatusOK, rule)
+}
+
+// ResetRule removes a business rule's override so its default applies again
+func (h *RuleHandler) ResetRule(c echo.Context) error {
+	ctx := c.Request().Context()
+	key := c.Param("key")
+
+	before, err := h.rulesService.Rule(ctx, key)
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownRule) {
+			return models.NewAPIError(http.StatusNotFound, "Business rule not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve business rule")
+	}
+
+	rule, err := h.rulesService.Reset(ctx, key)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to reset business rule")
+	}
+
+	if before.Overridden {
+		recordAuditKey(c, h.auditRepo, models.AuditDelete, models.AuditEntityRule, key, before, rule)
+	}
+
+	return c.JSON(http.StatusOK, rule)
+}
+
+// discountLimitError describes a line discounted more than the max_discount_percent rule
+// allows, or returns nil for other pricing errors
+func discountLimitError(err error) error {
+	var limit *services.DiscountLimitError
+	if !errors.As(err, &limit) {
+		return nil
+	}
+	return models.NewAPIError(http.StatusBadRequest, "Discount exceeds the allowed limit").WithDetails(map[string]interface{}{
+		"product_id":           limit.ProductID,
+		"max_discount_percent": limit.MaxPercent,
+	})
+}
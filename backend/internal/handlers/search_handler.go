@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	defaultSearchLimit = 5
+	maxSearchLimit     = 20
+)
+
+// SearchHandler handles the global typeahead search across customers, contacts, products,
+// quotations and orders
+type SearchHandler struct {
+	customerRepo  *repository.CustomerRepository
+	contactRepo   *repository.ContactRepository
+	productRepo   *repository.ProductRepository
+	quotationRepo *repository.QuotationRepository
+	orderRepo     *repository.OrderRepository
+}
+
+// NewSearchHandler creates a new search handler with the provided repositories
+func NewSearchHandler(
+	customerRepo *repository.CustomerRepository,
+	contactRepo *repository.ContactRepository,
+	productRepo *repository.ProductRepository,
+	quotationRepo *repository.QuotationRepository,
+	orderRepo *repository.OrderRepository,
+) *SearchHandler {
+	return &SearchHandler{
+		customerRepo:  customerRepo,
+		contactRepo:   contactRepo,
+		productRepo:   productRepo,
+		quotationRepo: quotationRepo,
+		orderRepo:     orderRepo,
+	}
+}
+
+// SearchHit is one typeahead suggestion
+type SearchHit struct {
+	ID     int    `json:"id"`
+	Label  string `json:"label"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// SearchGroup holds the top matches of one entity type and how many matched in total
+type SearchGroup struct {
+	Total int         `json:"total"`
+	Items []SearchHit `json:"items"`
+}
+
+// SearchResults groups the matches by entity type
+type SearchResults struct {
+	Query      string      `json:"query"`
+	Customers  SearchGroup `json:"customers"`
+	Contacts   SearchGroup `json:"contacts"`
+	Products   SearchGroup `json:"products"`
+	Quotations SearchGroup `json:"quotations"`
+	Orders     SearchGroup `json:"orders"`
+}
+
+// Search runs ?q= against customers, contacts, products, quotations and orders in parallel
+// and returns the top ?limit= matches of each (default 5, at most 20)
+func (h *SearchHandler) Search(c echo.Context) error {
+	term := strings.TrimSpace(c.QueryParam("q"))
+	if term == "" {
+		return models.NewAPIError(http.StatusBadRequest, "Search term is required")
+	}
+
+	limit := defaultSearchLimit
+	if value := c.QueryParam("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxSearchLimit {
+			return models.NewAPIError(http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxSearchLimit))
+		}
+		limit = n
+	}
+	page := repository.Page{Limit: limit}
+
+	results := SearchResults{Query: term}
+	g, ctx := errgroup.WithContext(c.Request().Context())
+
+	g.Go(func() error {
+		customers, total, err := h.customerRepo.SearchCustomers(ctx, term, page)
+		if err != nil {
+			return err
+		}
+		results.Customers = SearchGroup{Total: total, Items: []SearchHit{}}
+		for _, customer := range customers {
+			hit := SearchHit{ID: customer.CustomerID, Label: customer.CompanyName}
+			if customer.Industry != nil {
+				hit.Detail = *customer.Industry
+			}
+			results.Customers.Items = append(results.Customers.Items, hit)
+		}
+		return nil
+	})
+
+	g.Go(func() error {
+		contacts, total, err := h.contactRepo.SearchContacts(ctx, term, page)
+		if err != nil {
+			return err
+		}
+		results.Contacts = SearchGroup{Total: total, Items: []SearchHit{}}
+		for _, contact := range contacts {
+			hit := SearchHit{ID: contact.ContactID, Label: contact.FirstName + " " + contact.LastName}
+			if contact.Email != nil {
+				hit.Detail = *contact.Email
+			}
+			results.Contacts.Items = append(results.Contacts.Items, hit)
+		}
+		return nil
+	})
+
+	g.Go(func() error {
+		products, total, err := h.productRepo.SearchProducts(ctx, term, page)
+		if err != nil {
+			return err
+		}
+		results.Products = SearchGroup{Total: total, Items: []SearchHit{}}
+		for _, product := range products {
+			hit := SearchHit{ID: product.ProductID, Label: product.ProductName}
+			if product.Model != nil {
+				hit.Detail = *product.Model
+			}
+			results.Products.Items = append(results.Products.Items, hit)
+		}
+		return nil
+	})
+
+	g.Go(func() error {
+		quotations, total, err := h.quotationRepo.Search(ctx, term, page)
+		if err != nil {
+			return err
+		}
+		results.Quotations = SearchGroup{Total: total, Items: []SearchHit{}}
+		for _, quotation := range quotations {
+			results.Quotations.Items = append(results.Quotations.Items, SearchHit{
+				ID:     quotation.QuotationID,
+				Label:  fmt.Sprintf("Quotation #%d", quotation.QuotationID),
+				Detail: quotation.CompanyName + " · " + quotation.Status,
+			})
+		}
+		return nil
+	})
+
+	g.Go(func() error {
+		orders, total, err := h.orderRepo.Search(ctx, term, page)
+		if err != nil {
+			return err
+		}
+		results.Orders = SearchGroup{Total: total, Items: []SearchHit{}}
+		for _, order := range orders {
+			results.Orders.Items = append(results.Orders.Items, SearchHit{
+				ID:     order.OrderID,
+				Label:  fmt.Sprintf("Order #%d", order.OrderID),
+				Detail: order.CompanyName + " · " + order.Status,
+			})
+		}
+		return nil
+	})
+
+	if err := g.Wait(); err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to search")
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
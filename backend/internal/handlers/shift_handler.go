@@ -0,0 +1,420 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// OpenShiftRequest opens a cashier shift with the float in the drawer
+type OpenShiftRequest struct {
+	Register    string  `json:"register"`
+	OpeningCash float64 `json:"opening_cash" validate:"gte=0"`
+	Notes       string  `json:"notes"`
+}
+
+// CloseShiftRequest closes a shift with the cash counted in the drawer, either as counts per
+// denomination or as a single amount
+type CloseShiftRequest struct {
+	CashCounts  models.CashCounts `json:"cash_counts"`
+	CountedCash *float64          `json:"counted_cash" validate:"omitnil,gte=0"`
+	Notes       string            `json:"notes"`
+}
+
+// ShiftHandler handles HTTP requests for cashier shifts and the closing reports
+type ShiftHandler struct {
+	shiftRepo    *repository.ShiftRepository
+	pdfGenerator *services.PDFGenerator
+}
+
+// NewShiftHandler creates a new shift handler with the provided repository
+func NewShiftHandler(shiftRepo *repository.ShiftRepository, pdfGenerator *services.PDFGenerator) *ShiftHandler {
+	return &ShiftHandler{
+		shiftRepo:    shiftRepo,
+		pdfGenerator: pdfGenerator,
+	}
+}
+
+// GetShifts lists shifts, optionally filtered by ?user_id=, ?status= and a ?from=/?to= range
+// of opening days
+func (h *ShiftHandler) GetShifts(c echo.Context) error {
+	filter := repository.ShiftFilter{Status: c.QueryParam("status")}
+	if filter.Status != "" && filter.Status != models.ShiftOpen && filter.Status != models.ShiftClosed {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid status")
+	}
+	if userID := c.QueryParam("user_id"); userID != "" {
+		id, err := strconv.Atoi(userID)
+		if err != nil {
+			return models.NewAPIError(http.StatusBadRequest, "Invalid user ID")
+		}
+		filter.UserID = id
+	}
+	if from := c.QueryParam("from"); from != "" {
+		day, err := time.Parse(deliveryDateLayout, from)
+		if err != nil {
+			return models.NewAPIError(http.StatusBadRequest, "Invalid from date, expected YYYY-MM-DD")
+		}
+		filter.From = &day
+	}
+	if to := c.QueryParam("to"); to != "" {
+		day, err := time.Parse(deliveryDateLayout, to)
+		if err != nil {
+			return models.NewAPIError(http.StatusBadRequest, "Invalid to date, expected YYYY-MM-DD")
+		}
+		end := day.AddDate(0, 0, 1)
+		filter.To = &end
+	}
+
+	shifts, err := h.shiftRepo.GetAll(c.Request().Context(), filter)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve shifts")
+	}
+
+	return jsonList(c, http.StatusOK, shifts)
+}
+
+// GetShift returns a shift by ID
+func (h *ShiftHandler) GetShift(c echo.Context) error {
+	shift, handled, err := h.pathShift(c)
+	if handled {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, shift)
+}
+
+// GetCurrentShift returns the shift the logged-in cashier has open
+func (h *ShiftHandler) GetCurrentShift(c echo.Context) error {
+	user := appmw.UserFromContext(c)
+	if user == nil {
+		return models.NewAPIError(http.StatusUnauthorized, "A user session is required")
+	}
+
+	shift, err := h.shiftRepo.GetOpenForUser(c.Request().Context(), user.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "No open shift")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve shift")
+	}
+
+	return c.JSON(http.StatusOK, shift)
+}
+
+// OpenShift opens a shift for the logged-in cashier. Counter sales they make until it is
+// closed are counted in its report.
+func (h *ShiftHandler) OpenShift(c echo.Context) error {
+	user := appmw.UserFromContext(c)
+	if user == nil {
+		return models.NewAPIError(http.StatusUnauthorized, "A user session is required")
+	}
+
+	var req OpenShiftRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload: "+err.Error())
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	shift := models.CashierShift{
+		UserID:       user.UserID,
+		Register:     req.Register,
+		OpeningCash:  math.Round(req.OpeningCash*100) / 100,
+		OpeningNotes: req.Notes,
+	}
+	if err := h.shiftRepo.Open(c.Request().Context(), &shift); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return models.NewAPIError(http.StatusConflict, capitalize(err.Error()))
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to open shift")
+	}
+
+	return c.JSON(http.StatusCreated, shift)
+}
+
+// CloseShift closes a shift with the counted cash and returns its Z-report. A cashier closes
+// their own shift; admins can close anyone's.
+func (h *ShiftHandler) CloseShift(c echo.Context) error {
+	user := appmw.UserFromContext(c)
+	if user == nil {
+		return models.NewAPIError(http.StatusUnauthorized, "A user session is required")
+	}
+
+	shift, handled, err := h.pathShift(c)
+	if handled {
+		return err
+	}
+	if shift.UserID != user.UserID && user.Role != models.RoleAdmin {
+		return models.NewAPIError(http.StatusForbidden, "Only the cashier or an admin can close this shift")
+	}
+
+	var req CloseShiftRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload: "+err.Error())
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	if len(req.CashCounts) > 0 {
+		total, err := req.CashCounts.Total()
+		if err != nil {
+			return models.NewAPIError(http.StatusBadRequest, "Invalid cash counts: "+err.Error())
+		}
+		total = math.Round(total*100) / 100
+		if req.CountedCash != nil && math.Abs(*req.CountedCash-total) >= 0.005 {
+			return models.NewAPIError(http.StatusBadRequest, "Counted cash does not match the cash counts").WithDetails(map[string]interface{}{
+				"counts_total": total,
+			})
+		}
+		req.CountedCash = &total
+	}
+	if req.CountedCash == nil {
+		return models.NewAPIError(http.StatusBadRequest, "Either cash_counts or counted_cash is required")
+	}
+
+	shift.CashCounts = req.CashCounts
+	shift.CountedCash = req.CountedCash
+	shift.ClosingNotes = req.Notes
+	shift.ClosedBy = &user.UserID
+	if err := h.shiftRepo.Close(c.Request().Context(), &shift); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return models.NewAPIError(http.StatusConflict, capitalize(err.Error()))
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to close shift")
+	}
+
+	report, err := h.shiftRepo.Report(c.Request().Context(), shift)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to build shift report")
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// GetShiftReport returns the report of a shift: the Z-report once it is closed, or the
+// running totals while it is open
+func (h *ShiftHandler) GetShiftReport(c echo.Context) error {
+	report, handled, err := h.shiftReport(c)
+	if handled {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// GetShiftReportPDF renders the report of a shift as a PDF
+func (h *ShiftHandler) GetShiftReportPDF(c echo.Context) error {
+	report, handled, err := h.shiftReport(c)
+	if handled {
+		return err
+	}
+
+	daily := models.DailyClosingReport{
+		Date:       report.Shift.OpenedAt.Format(deliveryDateLayout),
+		Shifts:     []models.ShiftReport{report},
+		Sales:      report.Sales,
+		TotalSales: report.TotalSales,
+		Payments:   report.Payments,
+	}
+	if report.Variance != nil {
+		daily.TotalVariance = *report.Variance
+	}
+	if report.Shift.Status == models.ShiftOpen {
+		daily.OpenShifts = 1
+	}
+
+	filename := fmt.Sprintf("z_report_shift_%d.pdf", report.Shift.ShiftID)
+	return h.renderClosingReport(c, daily, "SHIFT REPORT", filename)
+}
+
+// ExportShiftReportCSV exports the report of a shift as CSV, or as an Excel workbook with
+// ?format=xlsx
+func (h *ShiftHandler) ExportShiftReportCSV(c echo.Context) error {
+	format, err := exportFormat(c)
+	if err != nil {
+		return err
+	}
+
+	report, handled, err := h.shiftReport(c)
+	if handled {
+		return err
+	}
+
+	export := closingReportExport("Shift Report", fmt.Sprintf("z_report_shift_%d", report.Shift.ShiftID), []models.ShiftReport{report})
+	export.addFilter("Shift", strconv.Itoa(report.Shift.ShiftID))
+	return writeExport(c, format, export)
+}
+
+// GetDailyClosingReport returns the closing report of the shifts opened on ?date= (default today)
+func (h *ShiftHandler) GetDailyClosingReport(c echo.Context) error {
+	report, handled, err := h.dailyReport(c)
+	if handled {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// GetDailyClosingReportPDF renders the closing report of ?date= (default today) as a PDF
+func (h *ShiftHandler) GetDailyClosingReportPDF(c echo.Context) error {
+	report, handled, err := h.dailyReport(c)
+	if handled {
+		return err
+	}
+
+	return h.renderClosingReport(c, report, "DAILY CLOSING REPORT", "daily_closing_"+report.Date+".pdf")
+}
+
+// ExportDailyClosingReportCSV exports the closing report of ?date= (default today) as CSV,
+// one row per shift and payment method, or as an Excel workbook with ?format=xlsx
+func (h *ShiftHandler) ExportDailyClosingReportCSV(c echo.Context) error {
+	format, err := exportFormat(c)
+	if err != nil {
+		return err
+	}
+
+	report, handled, err := h.dailyReport(c)
+	if handled {
+		return err
+	}
+
+	export := closingReportExport("Daily Closing Report", "daily_closing_"+report.Date, report.Shifts)
+	export.addFilter("Date", report.Date)
+	return writeExport(c, format, export)
+}
+
+// pathShift loads the shift in the path. It returns handled=true when the request cannot
+// be served, with the error response to return.
+func (h *ShiftHandler) pathShift(c echo.Context) (models.CashierShift, bool, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.CashierShift{}, true, models.NewAPIError(http.StatusBadRequest, "Invalid shift ID")
+	}
+
+	shift, err := h.shiftRepo.GetByID(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return shift, true, models.NewAPIError(http.StatusNotFound, "Shift not found")
+		}
+		return shift, true, models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve shift")
+	}
+
+	return shift, false, nil
+}
+
+// shiftReport builds the report of the shift in the path
+func (h *ShiftHandler) shiftReport(c echo.Context) (models.ShiftReport, bool, error) {
+	shift, handled, err := h.pathShift(c)
+	if handled {
+		return models.ShiftReport{}, true, err
+	}
+
+	report, err := h.shiftRepo.Report(c.Request().Context(), shift)
+	if err != nil {
+		return report, true, models.NewAPIError(http.StatusInternalServerError, "Failed to build shift report")
+	}
+
+	return report, false, nil
+}
+
+// dailyReport builds the closing report for ?date=
+func (h *ShiftHandler) dailyReport(c echo.Context) (models.DailyClosingReport, bool, error) {
+	date, err := deliveryDate(c.QueryParam("date"))
+	if err != nil {
+		return models.DailyClosingReport{}, true, models.NewAPIError(http.StatusBadRequest, "Invalid date, expected YYYY-MM-DD")
+	}
+
+	report, err := h.shiftRepo.DailyReport(c.Request().Context(), date)
+	if err != nil {
+		return report, true, models.NewAPIError(http.StatusInternalServerError, "Failed to build closing report")
+	}
+
+	return report, false, nil
+}
+
+// renderClosingReport writes a closing report as a PDF
+func (h *ShiftHandler) renderClosingReport(c echo.Context, report models.DailyClosingReport, title, filename string) error {
+	templateData := map[string]interface{}{
+		"Title":          title,
+		"Report":         report,
+		"GenerationDate": time.Now().Format("January 2, 2006 3:04 PM"),
+	}
+	content, err := h.pdfGenerator.GenerateFromTemplate("z_report/template.html", "", templateData)
+	if err != nil {
+		log.Printf("Failed to render closing report %s: %v", filename, err)
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to render report")
+	}
+
+	c.Response().Header().Set("Content-Disposition", "attachment; filename="+filename)
+	return c.Blob(http.StatusOK, "application/pdf", content)
+}
+
+// closingReportExport lays out one row per shift and payment method, with the shift's cash
+// figures repeated on each of its rows
+func closingReportExport(title, fileName string, shifts []models.ShiftReport) *reportExport {
+	export := &reportExport{
+		Title:    title,
+		FileName: fileName,
+		Columns: []exportColumn{
+			{Header: "Shift ID", Kind: columnInteger},
+			{Header: "Cashier"},
+			{Header: "Register"},
+			{Header: "Status"},
+			{Header: "Opened At"},
+			{Header: "Closed At"},
+			{Header: "Payment Method"},
+			{Header: "Sales", Kind: columnInteger, Total: true},
+			{Header: "Total", Kind: columnAmount, Total: true},
+			{Header: "Tendered", Kind: columnAmount, Total: true},
+			{Header: "Opening Cash", Kind: columnAmount},
+			{Header: "Cash Sales", Kind: columnAmount},
+			{Header: "Expected Cash", Kind: columnAmount},
+			{Header: "Counted Cash", Kind: columnAmount},
+			{Header: "Variance", Kind: columnAmount},
+		},
+	}
+
+	for _, report := range shifts {
+		closedAt := ""
+		if report.Shift.ClosedAt != nil {
+			closedAt = report.Shift.ClosedAt.Format(time.RFC3339)
+		}
+		payments := report.Payments
+		if len(payments) == 0 {
+			payments = []models.ShiftPaymentTotal{{}}
+		}
+		for _, payment := range payments {
+			export.addRow(
+				report.Shift.ShiftID,
+				report.Cashier,
+				report.Shift.Register,
+				report.Shift.Status,
+				report.Shift.OpenedAt.Format(time.RFC3339),
+				closedAt,
+				payment.PaymentMethod,
+				payment.Sales,
+				payment.Total,
+				payment.Tendered,
+				report.Shift.OpeningCash,
+				report.CashSales,
+				report.ExpectedCash,
+				report.CountedCash,
+				report.Variance,
+			)
+		}
+	}
+
+	return export
+}
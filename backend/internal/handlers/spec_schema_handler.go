@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// SpecSchemaHandler handles HTTP requests for per-category technical spec schemas
+type SpecSchemaHandler struct {
+	specSchemaRepo *repository.SpecSchemaRepository
+}
+
+// NewSpecSchemaHandler creates a new spec schema handler with the provided repository
+func NewSpecSchemaHandler(specSchemaRepo *repository.SpecSchemaRepository) *SpecSchemaHandler {
+	return &SpecSchemaHandler{
+		specSchemaRepo: specSchemaRepo,
+	}
+}
+
+// GetSpecSchemas returns all spec schemas
+func (h *SpecSchemaHandler) GetSpecSchemas(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	schemas, err := h.specSchemaRepo.GetAll(ctx)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve spec schemas")
+	}
+
+	return c.JSON(http.StatusOK, schemas)
+}
+
+// GetSpecSchema returns the spec schema for a category
+func (h *SpecSchemaHandler) GetSpecSchema(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	schema, err := h.specSchemaRepo.GetByCategory(ctx, c.Param("category"))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Spec schema not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve spec schema")
+	}
+
+	return c.JSON(http.StatusOK, schema)
+}
+
+// SaveSpecSchema creates or replaces the spec schema for a category
+func (h *SpecSchemaHandler) SaveSpecSchema(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var schema models.SpecSchema
+	if err := c.Bind(&schema); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	schema.Category = strings.TrimSpace(c.Param("category"))
+	if schema.Category == "" {
+		return models.NewAPIError(http.StatusBadRequest, "Category is required")
+	}
+
+	for i := range schema.Fields {
+		schema.Fields[i].Name = strings.TrimSpace(schema.Fields[i].Name)
+	}
+	if errs := services.ValidateSchema(schema.Fields); len(errs) > 0 {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid spec schema").WithDetails(map[string]interface{}{
+			"fields": errs,
+		})
+	}
+
+	if err := h.specSchemaRepo.Save(ctx, &schema); err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to save spec schema")
+	}
+
+	return c.JSON(http.StatusOK, schema)
+}
+
+// DeleteSpecSchema removes the spec schema for a category
+func (h *SpecSchemaHandler) DeleteSpecSchema(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	if err := h.specSchemaRepo.Delete(ctx, c.Param("category")); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Spec schema not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to delete spec schema")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// notifyStockChanges announces each item that just ran out of stock in the team chat, and each
+// that just fell to its reorder level in the team chat, to every user in the app and to
+// webhooks, whether its stock was edited or taken by an order
+func notifyStockChanges(
+	c echo.Context,
+	productRepo *repository.ProductRepository,
+	chatNotifier *services.ChatNotifier,
+	notificationRepo *repository.NotificationRepository,
+	webhooks *services.WebhookService,
+	changes ...models.StockChange,
+) {
+	for _, change := range changes {
+		inventory := change.Inventory
+		outOfStock := change.PreviousStock > 0 && inventory.CurrentStock <= 0
+		lowStock := change.PreviousStock > inventory.ReorderLevel && inventory.CurrentStock <= inventory.ReorderLevel
+		if !outOfStock && !lowStock {
+			continue
+		}
+
+		productName := "Product #" + strconv.Itoa(inventory.ProductID)
+		if product, err := productRepo.GetByID(c.Request().Context(), inventory.ProductID); err == nil {
+			productName = product.ProductName
+		}
+		if outOfStock {
+			chatNotifier.NotifyStockOut(inventory.ProductID, productName, inventory.ReorderLevel)
+		} else if lowStock {
+			chatNotifier.NotifyLowStock(inventory.ProductID, productName, inventory.CurrentStock, inventory.ReorderLevel)
+		}
+		if lowStock {
+			notifyUsers(c, notificationRepo, models.NotificationLowStock, models.AuditEntityInventory, inventory.InventoryID,
+				"Low stock: "+productName,
+				fmt.Sprintf("%s is down to %d in stock, at or below its reorder level of %d.", productName, inventory.CurrentStock, inventory.ReorderLevel))
+			webhooks.Publish(c.Request().Context(), models.WebhookInventoryLowStock, map[string]interface{}{
+				"inventory":    inventory,
+				"product_name": productName,
+			})
+		}
+	}
+}
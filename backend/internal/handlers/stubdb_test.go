@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// stubQuery answers the first query run that contains match with rows of columns. Statements
+// that return no rows are answered the same way and report one row affected.
+type stubQuery struct {
+	match   string
+	columns []string
+	rows    [][]driver.Value
+}
+
+// stubDB is a scripted database: each query of the script answers one query run by the
+// code under test, and any other query fails
+type stubDB struct {
+	mu         sync.Mutex
+	script     []stubQuery
+	answered   []bool
+	unexpected []string
+}
+
+// stubDBs holds the stub databases open in tests by name
+var stubDBs sync.Map
+
+func init() {
+	sql.Register("stub", stubDriver{})
+}
+
+// newStubDB opens a database answering queries from script. The test fails when a query of
+// the script is never run.
+func newStubDB(tb testing.TB, script ...stubQuery) *sqlx.DB {
+	tb.Helper()
+
+	stub := &stubDB{script: script, answered: make([]bool, len(script))}
+	name := fmt.Sprintf("%s/%p", tb.Name(), stub)
+	stubDBs.Store(name, stub)
+
+	db, err := sql.Open("stub", name)
+	if err != nil {
+		tb.Fatalf("Failed to open the stub database: %v", err)
+	}
+	tb.Cleanup(func() {
+		db.Close()
+		stubDBs.Delete(name)
+		for i, query := range stub.script {
+			if !stub.answered[i] {
+				tb.Errorf("No query containing %q was run", query.match)
+			}
+		}
+		for _, query := range stub.unexpected {
+			tb.Errorf("Unexpected query: %s", strings.Join(strings.Fields(query), " "))
+		}
+	})
+	return sqlx.NewDb(db, "postgres")
+}
+
+// answer finds the script entry for query
+func (s *stubDB) answer(query string) (stubQuery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, scripted := range s.script {
+		if !s.answered[i] && strings.Contains(query, scripted.match) {
+			s.answered[i] = true
+			return scripted, nil
+		}
+	}
+	s.unexpected = append(s.unexpected, query)
+	return stubQuery{}, errors.New("unexpected query")
+}
+
+// rowsOf answers the query containing match with one row per record, with a column for
+// every db tag of the record type the way sqlx maps them
+func rowsOf(match string, records ...interface{}) stubQuery {
+	query := stubQuery{match: match}
+	for i, record := range records {
+		columns, values := recordColumns(reflect.ValueOf(record))
+		if i == 0 {
+			query.columns = columns
+		}
+		query.rows = append(query.rows, values)
+	}
+	return query
+}
+
+// valuesOf answers the query containing match with a single row of the given columns and
+// values, for queries that scan into variables rather than a record
+func valuesOf(match string, columnsAndValues ...interface{}) stubQuery {
+	query := stubQuery{match: match}
+	row := []driver.Value{}
+	for i := 0; i+1 < len(columnsAndValues); i += 2 {
+		query.columns = append(query.columns, columnsAndValues[i].(string))
+		row = append(row, driverValue(reflect.ValueOf(columnsAndValues[i+1])))
+	}
+	query.rows = [][]driver.Value{row}
+	return query
+}
+
+// recordColumns lists the db columns of a struct and their values, with the fields of
+// embedded structs inlined
+func recordColumns(record reflect.Value) ([]string, []driver.Value) {
+	record = reflect.Indirect(record)
+	columns := []string{}
+	values := []driver.Value{}
+	for i := 0; i < record.NumField(); i++ {
+		field := record.Type().Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			embeddedColumns, embeddedValues := recordColumns(record.Field(i))
+			columns = append(columns, embeddedColumns...)
+			values = append(values, embeddedValues...)
+			continue
+		}
+		column := field.Tag.Get("db")
+		if column == "" || column == "-" || !field.IsExported() {
+			continue
+		}
+		columns = append(columns, column)
+		values = append(values, driverValue(record.Field(i)))
+	}
+	return columns, values
+}
+
+// driverValue converts a record field to the value a Postgres driver would return for it
+func driverValue(value reflect.Value) driver.Value {
+	if !value.IsValid() {
+		return nil
+	}
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil
+		}
+		return driverValue(value.Elem())
+	}
+	if valuer, ok := value.Interface().(driver.Valuer); ok {
+		converted, err := valuer.Value()
+		if err != nil {
+			panic(err)
+		}
+		return converted
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		return value.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		return value.Float()
+	case reflect.Bool:
+		return value.Bool()
+	case reflect.Slice:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			return append([]byte(nil), value.Bytes()...)
+		}
+	case reflect.Struct:
+		if t, ok := value.Interface().(time.Time); ok {
+			return t
+		}
+	}
+	panic(fmt.Sprintf("no driver value for %s", value.Type()))
+}
+
+// sampleTime is the time sample records are stamped with
+var sampleTime = time.Date(2026, time.March, 2, 9, 30, 0, 0, time.UTC)
+
+// fillSample sets every empty field of the record record points to, and of the records it
+// holds, to a sample value, so that fields left out of responses when empty show up in
+// their shape too. Fields already set are kept.
+func fillSample(record interface{}) {
+	fillValue(reflect.ValueOf(record).Elem())
+}
+
+func fillValue(value reflect.Value) {
+	if !value.CanSet() {
+		return
+	}
+	switch value.Kind() {
+	case reflect.Pointer:
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		fillValue(value.Elem())
+	case reflect.Struct:
+		if _, ok := value.Interface().(time.Time); ok {
+			if value.IsZero() {
+				value.Set(reflect.ValueOf(sampleTime))
+			}
+			return
+		}
+		for i := 0; i < value.NumField(); i++ {
+			if value.Type().Field(i).IsExported() {
+				fillValue(value.Field(i))
+			}
+		}
+	case reflect.Slice:
+		if value.Len() > 0 {
+			return
+		}
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			// Byte slices hold JSON documents such as technical specs
+			value.SetBytes([]byte(`{}`))
+			return
+		}
+		value.Set(reflect.MakeSlice(value.Type(), 1, 1))
+		fillValue(value.Index(0))
+	case reflect.String:
+		if value.String() == "" {
+			value.SetString("sample")
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if value.Int() == 0 {
+			value.SetInt(1)
+		}
+	case reflect.Float32, reflect.Float64:
+		if value.Float() == 0 {
+			value.SetFloat(1.5)
+		}
+	case reflect.Bool:
+		value.SetBool(true)
+	}
+}
+
+type stubDriver struct{}
+
+func (stubDriver) Open(name string) (driver.Conn, error) {
+	stub, ok := stubDBs.Load(name)
+	if !ok {
+		return nil, fmt.Errorf("no stub database %s", name)
+	}
+	return &stubConn{db: stub.(*stubDB)}, nil
+}
+
+type stubConn struct {
+	db *stubDB
+}
+
+func (c *stubConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not scripted")
+}
+
+func (c *stubConn) Close() error {
+	return nil
+}
+
+func (c *stubConn) Begin() (driver.Tx, error) {
+	return stubTx{}, nil
+}
+
+func (c *stubConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return stubTx{}, nil
+}
+
+// CheckNamedValue accepts every argument as it is; the stub does not look at them
+func (c *stubConn) CheckNamedValue(*driver.NamedValue) error {
+	return nil
+}
+
+func (c *stubConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	answer, err := c.db.answer(query)
+	if err != nil {
+		return nil, err
+	}
+	return &stubRows{columns: answer.columns, rows: answer.rows}, nil
+}
+
+func (c *stubConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if _, err := c.db.answer(query); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+type stubTx struct{}
+
+func (stubTx) Commit() error {
+	return nil
+}
+
+func (stubTx) Rollback() error {
+	return nil
+}
+
+type stubRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *stubRows) Columns() []string {
+	return r.columns
+}
+
+func (r *stubRows) Close() error {
+	return nil
+}
+
+func (r *stubRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
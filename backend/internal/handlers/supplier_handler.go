@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// SupplierHandler handles HTTP requests for suppliers and their product prices
+type SupplierHandler struct {
+	supplierRepo      *repository.SupplierRepository
+	productRepo       *repository.ProductRepository
+	purchasingService *services.PurchasingService
+}
+
+// NewSupplierHandler creates a new supplier handler
+func NewSupplierHandler(
+	supplierRepo *repository.SupplierRepository,
+	productRepo *repository.ProductRepository,
+	purchasingService *services.PurchasingService,
+) *SupplierHandler {
+	return &SupplierHandler{
+		supplierRepo:      supplierRepo,
+		productRepo:       productRepo,
+		purchasingService: purchasingService,
+	}
+}
+
+// GetSuppliers returns all suppliers
+func (h *SupplierHandler) GetSuppliers(c echo.Context) error {
+	suppliers, err := h.supplierRepo.GetAll(c.Request().Context())
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve suppliers")
+	}
+
+	return jsonList(c, http.StatusOK, suppliers)
+}
+
+// GetSupplier returns a supplier by ID
+func (h *SupplierHandler) GetSupplier(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid supplier ID")
+	}
+
+	supplier, err := h.supplierRepo.GetByID(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Supplier not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve supplier")
+	}
+
+	return c.JSON(http.StatusOK, supplier)
+}
+
+// CreateSupplier adds a supplier
+func (h *SupplierHandler) CreateSupplier(c echo.Context) error {
+	supplier := models.Supplier{Active: true}
+	if err := c.Bind(&supplier); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(&supplier); err != nil {
+		return validationError(err)
+	}
+	supplier.Name = strings.TrimSpace(supplier.Name)
+
+	if err := h.supplierRepo.Create(c.Request().Context(), &supplier); err != nil {
+		if err == repository.ErrDuplicateKey {
+			return models.NewAPIError(http.StatusConflict, "A supplier with this name already exists")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to create supplier")
+	}
+
+	return c.JSON(http.StatusCreated, supplier)
+}
+
+// UpdateSupplier updates a supplier
+func (h *SupplierHandler) UpdateSupplier(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid supplier ID")
+	}
+
+	var supplier models.Supplier
+	if err := c.Bind(&supplier); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+	supplier.SupplierID = id
+
+	if err := c.Validate(&supplier); err != nil {
+		return validationError(err)
+	}
+	supplier.Name = strings.TrimSpace(supplier.Name)
+
+	if err := h.supplierRepo.Update(c.Request().Context(), &supplier); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Supplier not found")
+		}
+		if err == repository.ErrDuplicateKey {
+			return models.NewAPIError(http.StatusConflict, "A supplier with this name already exists")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update supplier")
+	}
+
+	return c.JSON(http.StatusOK, supplier)
+}
+
+// DeleteSupplier removes a supplier that has no purchase orders
+func (h *SupplierHandler) DeleteSupplier(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid supplier ID")
+	}
+
+	if err := h.supplierRepo.Delete(c.Request().Context(), id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Supplier not found")
+		}
+		if err == repository.ErrReferencedRecord {
+			return models.NewAPIError(http.StatusConflict, "Supplier has purchase orders; deactivate it instead")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to delete supplier")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetSupplierPrices returns the product prices of a supplier
+func (h *SupplierHandler) GetSupplierPrices(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid supplier ID")
+	}
+
+	prices, err := h.supplierRepo.GetSupplierPrices(c.Request().Context(), id)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve supplier prices")
+	}
+
+	return jsonList(c, http.StatusOK, prices)
+}
+
+// SaveSupplierPrice sets a supplier's price, lead time and minimum order quantity for a product
+func (h *SupplierHandler) SaveSupplierPrice(c echo.Context) error {
+	supplierID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid supplier ID")
+	}
+	productID, err := strconv.Atoi(c.Param("product_id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid product ID")
+	}
+
+	price := models.SupplierProduct{MinOrderQty: 1}
+	if err := c.Bind(&price); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+	price.SupplierID = supplierID
+	price.ProductID = productID
+	price.SupplierSKU = strings.TrimSpace(price.SupplierSKU)
+
+	if err := c.Validate(&price); err != nil {
+		return validationError(err)
+	}
+
+	if err := h.supplierRepo.SavePrice(c.Request().Context(), &price); err != nil {
+		if err == repository.ErrReferencedRecord {
+			return models.NewAPIError(http.StatusNotFound, "Supplier or product not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to save supplier price")
+	}
+
+	return c.JSON(http.StatusOK, price)
+}
+
+// DeleteSupplierPrice removes a supplier's price for a product
+func (h *SupplierHandler) DeleteSupplierPrice(c echo.Context) error {
+	supplierID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid supplier ID")
+	}
+	productID, err := strconv.Atoi(c.Param("product_id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid product ID")
+	}
+
+	if err := h.supplierRepo.DeletePrice(c.Request().Context(), supplierID, productID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Supplier price not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to delete supplier price")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// CompareSupplierPrices compares every supplier's offer for a product and shows which
+// supplier purchase orders would use. An optional ?need_by=YYYY-MM-DD excludes suppliers
+// that cannot deliver in time.
+func (h *SupplierHandler) CompareSupplierPrices(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid product ID")
+	}
+
+	needBy, err := parseNeedBy(c.QueryParam("need_by"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid need_by date, expected YYYY-MM-DD")
+	}
+
+	if _, err := h.productRepo.GetByID(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Product not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve product")
+	}
+
+	comparison, err := h.purchasingService.Compare(ctx, id, needBy)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to compare supplier prices")
+	}
+
+	return c.JSON(http.StatusOK, comparison)
+}
@@ -0,0 +1,386 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// SupplierInvoiceHandler handles HTTP requests for supplier invoices and the three-way match
+type SupplierInvoiceHandler struct {
+	invoiceRepo       *repository.SupplierInvoiceRepository
+	purchaseOrderRepo *repository.PurchaseOrderRepository
+	matchService      *services.InvoiceMatchService
+	scanRepo          *repository.DocumentScanRepository
+	auditRepo         *repository.AuditRepository
+}
+
+// NewSupplierInvoiceHandler creates a new supplier invoice handler
+func NewSupplierInvoiceHandler(
+	invoiceRepo *repository.SupplierInvoiceRepository,
+	purchaseOrderRepo *repository.PurchaseOrderRepository,
+	matchService *services.InvoiceMatchService,
+	scanRepo *repository.DocumentScanRepository,
+	auditRepo *repository.AuditRepository,
+) *SupplierInvoiceHandler {
+	return &SupplierInvoiceHandler{
+		invoiceRepo:       invoiceRepo,
+		purchaseOrderRepo: purchaseOrderRepo,
+		matchService:      matchService,
+		scanRepo:          scanRepo,
+		auditRepo:         auditRepo,
+	}
+}
+
+// SupplierInvoiceRequest records a supplier invoice against a purchase order. Lines name
+// the purchase order line they bill, or just the product to bill its line on the purchase
+// order. Without a total_amount the total is the sum of the lines.
+type SupplierInvoiceRequest struct {
+	PurchaseOrderID int                          `json:"purchase_order_id" validate:"required"`
+	InvoiceNumber   string                       `json:"invoice_number" validate:"notblank"`
+	InvoiceDate     string                       `json:"invoice_date" validate:"datetime=2006-01-02"`
+	TotalAmount     *float64                     `json:"total_amount" validate:"omitnil,gte=0"`
+	Notes           string                       `json:"notes"`
+	Items           []SupplierInvoiceLineRequest `json:"items" validate:"min=1,dive"`
+}
+
+// SupplierInvoiceLineRequest is a line of a SupplierInvoiceRequest
+type SupplierInvoiceLineRequest struct {
+	PurchaseOrderItemID *int    `json:"purchase_order_item_id"`
+	ProductID           int     `json:"product_id"`
+	Quantity            int     `json:"quantity" validate:"gt=0"`
+	UnitPrice           float64 `json:"unit_price" validate:"gte=0"`
+}
+
+// GetSupplierInvoices returns supplier invoices, optionally filtered by ?supplier_id=,
+// ?purchase_order_id= and ?match_status=
+func (h *SupplierInvoiceHandler) GetSupplierInvoices(c echo.Context) error {
+	filter := repository.SupplierInvoiceFilter{MatchStatus: c.QueryParam("match_status")}
+	for param, target := range map[string]*int{"supplier_id": &filter.SupplierID, "purchase_order_id": &filter.PurchaseOrderID} {
+		value := c.QueryParam(param)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return models.NewAPIError(http.StatusBadRequest, "Invalid "+param)
+		}
+		*target = id
+	}
+
+	invoices, err := h.invoiceRepo.GetAll(c.Request().Context(), filter)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve supplier invoices")
+	}
+
+	return jsonList(c, http.StatusOK, invoices)
+}
+
+// GetSupplierInvoice returns a supplier invoice with its items and discrepancies
+func (h *SupplierInvoiceHandler) GetSupplierInvoice(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid supplier invoice ID")
+	}
+
+	invoice, err := h.invoiceRepo.GetByID(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Supplier invoice not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve supplier invoice")
+	}
+
+	return c.JSON(http.StatusOK, invoice)
+}
+
+// CreateSupplierInvoice records a supplier invoice and matches it against its purchase
+// order and the goods received
+func (h *SupplierInvoiceHandler) CreateSupplierInvoice(c echo.Context) error {
+	var req SupplierInvoiceRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	invoice, err := h.createInvoice(c, req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, invoice)
+}
+
+// CreateSupplierInvoiceFromScan confirms a scanned supplier invoice in review as a supplier
+// invoice, matched as CreateSupplierInvoice does. Fields left out of the request are taken
+// from the scan's draft: its purchase order, invoice number and date, total and lines, which
+// must each have a product.
+func (h *SupplierInvoiceHandler) CreateSupplierInvoiceFromScan(c echo.Context) error {
+	scan, err := scanInReview(c, h.scanRepo, models.ScanSupplierInvoice)
+	if err != nil {
+		return err
+	}
+
+	var req SupplierInvoiceRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if req.PurchaseOrderID == 0 && scan.PurchaseOrderID != nil {
+		req.PurchaseOrderID = *scan.PurchaseOrderID
+	}
+	if req.InvoiceNumber == "" && scan.DocumentNumber != nil {
+		req.InvoiceNumber = *scan.DocumentNumber
+	}
+	if req.InvoiceDate == "" && scan.DocumentDate != nil {
+		req.InvoiceDate = scan.DocumentDate.Format(deliveryDateLayout)
+	}
+	if req.TotalAmount == nil {
+		req.TotalAmount = scan.TotalAmount
+	}
+	if len(req.Items) == 0 {
+		for _, line := range scan.Lines {
+			item := SupplierInvoiceLineRequest{Quantity: line.Quantity, UnitPrice: line.UnitPrice}
+			if line.ProductID != nil {
+				item.ProductID = *line.ProductID
+			}
+			req.Items = append(req.Items, item)
+		}
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	invoice, err := h.createInvoice(c, req)
+	if err != nil {
+		return err
+	}
+	confirmScan(c, h.scanRepo, h.auditRepo, scan, invoice.InvoiceID)
+
+	return c.JSON(http.StatusCreated, invoice)
+}
+
+// createInvoice records and matches a validated supplier invoice request, returning the
+// error response when it cannot be recorded
+func (h *SupplierInvoiceHandler) createInvoice(c echo.Context, req SupplierInvoiceRequest) (*models.SupplierInvoice, error) {
+	ctx := c.Request().Context()
+
+	req.InvoiceNumber = strings.TrimSpace(req.InvoiceNumber)
+	invoiceDate, _ := time.Parse(deliveryDateLayout, req.InvoiceDate)
+
+	order, err := h.purchaseOrderRepo.GetByID(ctx, req.PurchaseOrderID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, models.NewAPIError(http.StatusNotFound, "Purchase order not found")
+		}
+		return nil, models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve purchase order")
+	}
+	if order.Status == models.PurchaseOrderDraft || order.Status == models.PurchaseOrderPendingApproval || order.Status == models.PurchaseOrderRejected {
+		return nil, models.NewAPIError(http.StatusConflict, "Purchase orders must be placed before they are invoiced")
+	}
+
+	invoice := models.SupplierInvoice{
+		SupplierID:      order.SupplierID,
+		PurchaseOrderID: order.PurchaseOrderID,
+		InvoiceNumber:   req.InvoiceNumber,
+		InvoiceDate:     invoiceDate,
+		Notes:           strings.TrimSpace(req.Notes),
+	}
+	if user := appmw.UserFromContext(c); user != nil {
+		invoice.CreatedBy = &user.UserID
+	}
+
+	linesTotal := 0.0
+	for _, reqItem := range req.Items {
+		item := models.SupplierInvoiceItem{
+			ProductID: reqItem.ProductID,
+			Quantity:  reqItem.Quantity,
+			UnitPrice: reqItem.UnitPrice,
+		}
+		for _, line := range order.Items {
+			if reqItem.PurchaseOrderItemID != nil && line.PurchaseOrderItemID != *reqItem.PurchaseOrderItemID {
+				continue
+			}
+			if reqItem.PurchaseOrderItemID == nil && line.ProductID != reqItem.ProductID {
+				continue
+			}
+			lineID := line.PurchaseOrderItemID
+			item.PurchaseOrderItemID = &lineID
+			item.ProductID = line.ProductID
+			break
+		}
+		if reqItem.PurchaseOrderItemID != nil && item.PurchaseOrderItemID == nil {
+			return nil, models.NewAPIError(http.StatusBadRequest, fmt.Sprintf("Purchase order line %d is not on this purchase order", *reqItem.PurchaseOrderItemID))
+		}
+		if item.ProductID == 0 {
+			return nil, models.NewAPIError(http.StatusBadRequest, "Each item needs a product or purchase order line")
+		}
+
+		invoice.Items = append(invoice.Items, item)
+		linesTotal += float64(item.Quantity) * item.UnitPrice
+	}
+
+	invoice.TotalAmount = linesTotal
+	if req.TotalAmount != nil {
+		invoice.TotalAmount = *req.TotalAmount
+	}
+
+	if err := h.invoiceRepo.Create(ctx, &invoice); err != nil {
+		if err == repository.ErrDuplicateKey {
+			return nil, models.NewAPIError(http.StatusConflict, "This invoice number is already recorded for the supplier")
+		}
+		if err == repository.ErrReferencedRecord {
+			return nil, models.NewAPIError(http.StatusBadRequest, "One or more products do not exist")
+		}
+		return nil, models.NewAPIError(http.StatusInternalServerError, "Failed to create supplier invoice")
+	}
+
+	matched, err := h.matchService.Match(ctx, invoice.InvoiceID)
+	if err != nil {
+		log.Printf("Failed to match supplier invoice %d: %v", invoice.InvoiceID, err)
+		return &invoice, nil
+	}
+
+	return matched, nil
+}
+
+// DeleteSupplierInvoice removes a supplier invoice
+func (h *SupplierInvoiceHandler) DeleteSupplierInvoice(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid supplier invoice ID")
+	}
+
+	invoice, err := h.invoiceRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Supplier invoice not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve supplier invoice")
+	}
+
+	if err := h.invoiceRepo.Delete(ctx, id); err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to delete supplier invoice")
+	}
+
+	// The purchase order's other invoices no longer share billed quantities with this one
+	if err := h.matchService.MatchPurchaseOrder(ctx, invoice.PurchaseOrderID); err != nil {
+		log.Printf("Failed to rematch invoices for purchase order %d: %v", invoice.PurchaseOrderID, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// MatchSupplierInvoice reruns the three-way match for an invoice
+func (h *SupplierInvoiceHandler) MatchSupplierInvoice(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid supplier invoice ID")
+	}
+
+	invoice, err := h.matchService.Match(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Supplier invoice not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to match supplier invoice")
+	}
+
+	return c.JSON(http.StatusOK, invoice)
+}
+
+// GetDiscrepancyReport returns the discrepancies found by the three-way match. Filter with
+// ?from= and ?to= (invoice date, YYYY-MM-DD, inclusive), ?supplier_id= and ?kind=.
+func (h *SupplierInvoiceHandler) GetDiscrepancyReport(c echo.Context) error {
+	rows, status, msg := h.discrepancyReport(c)
+	if msg != "" {
+		return models.NewAPIError(status, msg)
+	}
+	return jsonList(c, http.StatusOK, rows)
+}
+
+// ExportDiscrepancyReportCSV exports the discrepancy report as CSV, or as an Excel workbook
+// with ?format=xlsx
+func (h *SupplierInvoiceHandler) ExportDiscrepancyReportCSV(c echo.Context) error {
+	format, err := exportFormat(c)
+	if err != nil {
+		return err
+	}
+
+	rows, status, msg := h.discrepancyReport(c)
+	if msg != "" {
+		return models.NewAPIError(status, msg)
+	}
+
+	report := &reportExport{
+		Title:    "Purchase Discrepancies",
+		FileName: "purchase_discrepancies",
+		Columns: []exportColumn{
+			{Header: "Invoice ID", Kind: columnInteger},
+			{Header: "Invoice Number"},
+			{Header: "Supplier"},
+			{Header: "Purchase Order ID", Kind: columnInteger},
+			{Header: "Product ID", Kind: columnInteger},
+			{Header: "Product Name"},
+			{Header: "Discrepancy"},
+			{Header: "Ordered Qty", Kind: columnInteger},
+			{Header: "Received Qty", Kind: columnInteger},
+			{Header: "Invoiced Qty", Kind: columnInteger},
+			{Header: "PO Unit Cost", Kind: columnAmount},
+			{Header: "Invoiced Unit Price", Kind: columnAmount},
+			{Header: "Amount Difference", Kind: columnAmount, Total: true},
+		},
+	}
+	report.addQueryFilters(c, "supplier_id", "kind", "from", "to")
+
+	for _, row := range rows {
+		report.addRow(row.InvoiceID, row.InvoiceNumber, row.SupplierName, row.PurchaseOrderID, row.ProductID,
+			row.ProductName, row.Kind, row.OrderedQuantity, row.ReceivedQuantity, row.InvoicedQuantity,
+			row.POUnitCost, row.InvoicedUnitPrice, row.AmountDifference)
+	}
+
+	return writeExport(c, format, report)
+}
+
+// discrepancyReport loads the report for the request's filters, returning a status and
+// message when the filters are invalid or the query fails
+func (h *SupplierInvoiceHandler) discrepancyReport(c echo.Context) ([]models.DiscrepancyReportRow, int, string) {
+	filter := repository.DiscrepancyReportFilter{Kind: c.QueryParam("kind")}
+	if supplierID := c.QueryParam("supplier_id"); supplierID != "" {
+		id, err := strconv.Atoi(supplierID)
+		if err != nil {
+			return nil, http.StatusBadRequest, "Invalid supplier ID"
+		}
+		filter.SupplierID = id
+	}
+	for param, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		value := c.QueryParam(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return nil, http.StatusBadRequest, "Invalid " + param + " date, expected YYYY-MM-DD"
+		}
+		*target = &parsed
+	}
+
+	rows, err := h.invoiceRepo.GetDiscrepancyReport(c.Request().Context(), filter)
+	if err != nil {
+		return nil, http.StatusInternalServerError, "Failed to retrieve discrepancy report"
+	}
+	return rows, 0, ""
+}
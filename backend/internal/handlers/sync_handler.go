@@ -0,0 +1,412 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// Sync entities, operations, outcomes and conflict strategies
+const (
+	syncEntityProducts  = "products"
+	syncEntityInventory = "inventory"
+	syncEntityOrders    = "orders"
+
+	syncOpUpsert = "upsert"
+	syncOpDelete = "delete"
+
+	syncStatusApplied  = "applied"
+	syncStatusConflict = "conflict"
+	syncStatusError    = "error"
+
+	syncServerWins = "server_wins"
+	syncClientWins = "client_wins"
+)
+
+// errSyncConflict signals that the server copy changed after the client's base version
+var errSyncConflict = errors.New("record changed on server")
+
+// SyncHandler handles offline sync requests from the warehouse tablet app
+type SyncHandler struct {
+	syncRepo      *repository.SyncRepository
+	productRepo   *repository.ProductRepository
+	inventoryRepo *repository.InventoryRepository
+	orderRepo     *repository.OrderRepository
+	customerRepo  *repository.CustomerRepository
+	ruleRepo      *repository.CustomerProductRuleRepository
+	specService   *services.ProductSpecService
+	pricing       *services.PricingService
+}
+
+// NewSyncHandler creates a new sync handler with the provided repositories
+func NewSyncHandler(
+	syncRepo *repository.SyncRepository,
+	productRepo *repository.ProductRepository,
+	inventoryRepo *repository.InventoryRepository,
+	orderRepo *repository.OrderRepository,
+	customerRepo *repository.CustomerRepository,
+	ruleRepo *repository.CustomerProductRuleRepository,
+	specService *services.ProductSpecService,
+	pricing *services.PricingService,
+) *SyncHandler {
+	return &SyncHandler{
+		syncRepo:      syncRepo,
+		productRepo:   productRepo,
+		inventoryRepo: inventoryRepo,
+		orderRepo:     orderRepo,
+		customerRepo:  customerRepo,
+		ruleRepo:      ruleRepo,
+		specService:   specService,
+		pricing:       pricing,
+	}
+}
+
+// GetChanges returns products, inventory and orders changed since ?since= (RFC 3339) plus deletions.
+// Clients store server_time from the response and send it as since on their next pull. It trails
+// the clock while other writes are in progress, so a change can be returned more than once.
+func (h *SyncHandler) GetChanges(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	since := time.Time{}
+	if value := c.QueryParam("since"); value != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return models.NewAPIError(http.StatusBadRequest, "Invalid since timestamp, expected RFC 3339")
+		}
+		since = parsed
+	}
+
+	// Take the cursor first so changes made during this request are picked up next time
+	serverTime, err := h.syncRepo.Cursor(ctx)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve changes")
+	}
+
+	changes := models.SyncChanges{ServerTime: serverTime}
+	if changes.Products, err = h.productRepo.GetUpdatedSince(ctx, since); err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve product changes")
+	}
+	if changes.Inventory, err = h.inventoryRepo.GetUpdatedSince(ctx, since); err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve inventory changes")
+	}
+	if changes.Orders, err = h.orderRepo.GetUpdatedSince(ctx, since); err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve order changes")
+	}
+	if changes.Deleted, err = h.syncRepo.GetTombstonesSince(ctx, since); err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve deletions")
+	}
+
+	return c.JSON(http.StatusOK, changes)
+}
+
+// PushBatch applies a batch of offline changes. Each item is applied independently and
+// reported back as applied, conflict or error so a single bad record doesn't block the rest.
+func (h *SyncHandler) PushBatch(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req models.SyncBatchRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+	if req.ConflictStrategy == "" {
+		req.ConflictStrategy = syncServerWins
+	}
+
+	results := make([]models.SyncBatchResult, 0, len(req.Items))
+	for _, item := range req.Items {
+		results = append(results, h.applyItem(ctx, item, req.ConflictStrategy))
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"results": results,
+	})
+}
+
+// applyItem applies a single batch item and converts the outcome into a result
+func (h *SyncHandler) applyItem(ctx context.Context, item models.SyncBatchItem, strategy string) models.SyncBatchResult {
+	result := models.SyncBatchResult{
+		ClientRef: item.ClientRef,
+		Entity:    item.Entity,
+		ID:        item.ID,
+	}
+
+	var record interface{}
+	var err error
+	switch item.Entity {
+	case syncEntityProducts:
+		record, err = h.applyProduct(ctx, item, strategy)
+	case syncEntityInventory:
+		record, err = h.applyInventory(ctx, item, strategy)
+	case syncEntityOrders:
+		record, err = h.applyOrder(ctx, item, strategy)
+	default:
+		err = errors.New("unknown entity: " + item.Entity)
+	}
+
+	switch {
+	case err == errSyncConflict:
+		result.Status = syncStatusConflict
+		result.Resolution = syncServerWins
+		result.Record = record
+	case err != nil:
+		result.Status = syncStatusError
+		result.Error = err.Error()
+	default:
+		result.Status = syncStatusApplied
+		result.Record = record
+		if item.BaseUpdatedAt != nil && strategy == syncClientWins {
+			result.Resolution = syncClientWins
+		}
+	}
+
+	if id := syncRecordID(record); id != 0 {
+		result.ID = id
+	}
+	return result
+}
+
+// isStale reports whether the server copy changed after the client's base version
+func isStale(serverUpdatedAt time.Time, base *time.Time, strategy string) bool {
+	if base == nil || strategy == syncClientWins {
+		return false
+	}
+	return serverUpdatedAt.After(*base)
+}
+
+// applyProduct creates, updates or deletes a product from a batch item
+func (h *SyncHandler) applyProduct(ctx context.Context, item models.SyncBatchItem, strategy string) (interface{}, error) {
+	if item.ID == 0 {
+		if item.Operation != syncOpUpsert {
+			return nil, errors.New("id is required")
+		}
+		var product models.Product
+		if err := json.Unmarshal(item.Data, &product); err != nil {
+			return nil, errors.New("invalid product data")
+		}
+		if product.ProductName == "" {
+			return nil, errors.New("product name is required")
+		}
+		if err := h.checkSpecs(ctx, &product); err != nil {
+			return nil, err
+		}
+		if err := h.productRepo.Create(ctx, &product); err != nil {
+			return nil, err
+		}
+		h.specService.RecordChange(ctx, nil, product)
+		return product, nil
+	}
+
+	current, err := h.productRepo.GetByID(ctx, item.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) && item.Operation == syncOpDelete {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if isStale(current.UpdatedAt, item.BaseUpdatedAt, strategy) {
+		return current, errSyncConflict
+	}
+
+	switch item.Operation {
+	case syncOpDelete:
+		if err := h.productRepo.Delete(ctx, item.ID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+		return nil, nil
+	case syncOpUpsert:
+		product := current
+		if err := json.Unmarshal(item.Data, &product); err != nil {
+			return nil, errors.New("invalid product data")
+		}
+		product.ProductID = item.ID
+		if err := h.checkSpecs(ctx, &product); err != nil {
+			return nil, err
+		}
+		if err := h.productRepo.Update(ctx, &product); err != nil {
+			return nil, err
+		}
+		h.specService.RecordChange(ctx, &current, product)
+		return product, nil
+	default:
+		return nil, errors.New("unknown operation: " + item.Operation)
+	}
+}
+
+// checkSpecs validates a product's technical specs against its category schema
+func (h *SyncHandler) checkSpecs(ctx context.Context, product *models.Product) error {
+	specErrors, err := h.specService.Validate(ctx, product)
+	if err != nil {
+		return err
+	}
+	if len(specErrors) > 0 {
+		return errors.New("invalid technical specs: " + specErrors[0].Field + " " + specErrors[0].Message)
+	}
+	return nil
+}
+
+// applyInventory creates, updates or deletes an inventory item from a batch item
+func (h *SyncHandler) applyInventory(ctx context.Context, item models.SyncBatchItem, strategy string) (interface{}, error) {
+	if item.ID == 0 {
+		if item.Operation != syncOpUpsert {
+			return nil, errors.New("id is required")
+		}
+		var inventory models.Inventory
+		if err := json.Unmarshal(item.Data, &inventory); err != nil {
+			return nil, errors.New("invalid inventory data")
+		}
+		if inventory.ProductID == 0 {
+			return nil, errors.New("product ID is required")
+		}
+		if err := h.inventoryRepo.Create(ctx, &inventory); err != nil {
+			return nil, err
+		}
+		return inventory, nil
+	}
+
+	current, err := h.inventoryRepo.GetByID(ctx, item.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) && item.Operation == syncOpDelete {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if isStale(current.UpdatedAt, item.BaseUpdatedAt, strategy) {
+		return current, errSyncConflict
+	}
+
+	switch item.Operation {
+	case syncOpDelete:
+		if err := h.inventoryRepo.Delete(ctx, item.ID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+		return nil, nil
+	case syncOpUpsert:
+		inventory := current
+		if err := json.Unmarshal(item.Data, &inventory); err != nil {
+			return nil, errors.New("invalid inventory data")
+		}
+		inventory.InventoryID = item.ID
+		if inventory.CurrentStock < 0 {
+			return nil, errors.New("stock cannot be negative")
+		}
+		if err := h.inventoryRepo.Update(ctx, &inventory); err != nil {
+			return nil, err
+		}
+		return inventory, nil
+	default:
+		return nil, errors.New("unknown operation: " + item.Operation)
+	}
+}
+
+// syncNewOrder is an order created offline with the items it was taken with
+type syncNewOrder struct {
+	models.Order
+	Items []models.OrderItem `json:"items"`
+}
+
+// applyOrder creates, updates or deletes an order from a batch item. New orders need items.
+func (h *SyncHandler) applyOrder(ctx context.Context, item models.SyncBatchItem, strategy string) (interface{}, error) {
+	if item.ID == 0 {
+		if item.Operation != syncOpUpsert {
+			return nil, errors.New("id is required")
+		}
+		var data syncNewOrder
+		if err := json.Unmarshal(item.Data, &data); err != nil {
+			return nil, errors.New("invalid order data")
+		}
+		order := data.Order
+		if order.CustomerID == 0 {
+			return nil, errors.New("customer ID is required")
+		}
+		if len(data.Items) == 0 {
+			return nil, errors.New("at least one item is required")
+		}
+		for _, line := range data.Items {
+			if line.ProductID == 0 || line.Quantity <= 0 {
+				return nil, errors.New("every item needs a product and a quantity above zero")
+			}
+		}
+
+		// Offline orders are checked, priced and created Pending as CreateOrder does, so
+		// their stock is issued when they ship
+		order.Status = "Pending"
+		order.DeliveredAt = nil
+		if order.OrderDate.IsZero() {
+			order.OrderDate = time.Now()
+		}
+		if err := checkNewOrder(ctx, h.customerRepo, h.productRepo, h.ruleRepo, &order, data.Items); err != nil {
+			return nil, err
+		}
+		if _, err := h.pricing.PriceOrder(ctx, &order, data.Items); err != nil {
+			if apiErr := discountLimitError(err); apiErr != nil {
+				return nil, apiErr
+			}
+			if apiErr := currencyError(err); apiErr != nil {
+				return nil, apiErr
+			}
+			return nil, err
+		}
+		if err := h.orderRepo.CreateOrderWithItems(ctx, &order, data.Items); err != nil {
+			return nil, err
+		}
+		return order, nil
+	}
+
+	current, err := h.orderRepo.GetByID(ctx, item.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) && item.Operation == syncOpDelete {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if isStale(current.UpdatedAt, item.BaseUpdatedAt, strategy) {
+		return current, errSyncConflict
+	}
+
+	switch item.Operation {
+	case syncOpDelete:
+		if err := h.orderRepo.Delete(ctx, item.ID, "Deleted by offline sync", nil); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+		return nil, nil
+	case syncOpUpsert:
+		order := current
+		if err := json.Unmarshal(item.Data, &order); err != nil {
+			return nil, errors.New("invalid order data")
+		}
+		order.OrderID = item.ID
+		if err := h.pricing.TaxOrder(ctx, &order); err != nil {
+			return nil, err
+		}
+		if err := h.orderRepo.Update(ctx, &order); err != nil {
+			return nil, err
+		}
+		return order, nil
+	default:
+		return nil, errors.New("unknown operation: " + item.Operation)
+	}
+}
+
+// syncRecordID returns the primary key of a synced record, or 0 when there is none
+func syncRecordID(record interface{}) int {
+	switch r := record.(type) {
+	case models.Product:
+		return r.ProductID
+	case models.Inventory:
+		return r.InventoryID
+	case models.Order:
+		return r.OrderID
+	}
+	return 0
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// TemplateRequest replaces a document template's content
+type TemplateRequest struct {
+	Content string `json:"content" validate:"required"`
+}
+
+// TemplateHandler handles HTTP requests for the HTML templates quotations, invoices and
+// warehouse documents are rendered from
+type TemplateHandler struct {
+	pdfGenerator *services.PDFGenerator
+	auditRepo    *repository.AuditRepository
+}
+
+// NewTemplateHandler creates a new document template handler
+func NewTemplateHandler(pdfGenerator *services.PDFGenerator, auditRepo *repository.AuditRepository) *TemplateHandler {
+	return &TemplateHandler{
+		pdfGenerator: pdfGenerator,
+		auditRepo:    auditRepo,
+	}
+}
+
+// GetTemplates lists the document templates without their content
+func (h *TemplateHandler) GetTemplates(c echo.Context) error {
+	templates, err := h.pdfGenerator.Templates()
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve document templates")
+	}
+
+	return jsonList(c, http.StatusOK, templates)
+}
+
+// GetTemplate returns a document template with its content, named by its path such as
+// /api/templates/invoice/template.html
+func (h *TemplateHandler) GetTemplate(c echo.Context) error {
+	template, err := h.pdfGenerator.Template(c.Param("*"))
+	if err != nil {
+		if errors.Is(err, services.ErrTemplateNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Document template not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve document template")
+	}
+
+	return c.JSON(http.StatusOK, template)
+}
+
+// UpdateTemplate replaces a document template's content. Documents are rendered with it
+// from the next request; content that does not parse is refused.
+func (h *TemplateHandler) UpdateTemplate(c echo.Context) error {
+	name := c.Param("*")
+
+	var req TemplateRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	before, err := h.pdfGenerator.Template(name)
+	if err != nil {
+		if errors.Is(err, services.ErrTemplateNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Document template not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve document template")
+	}
+
+	template, err := h.pdfGenerator.SaveTemplate(name, req.Content)
+	if err != nil {
+		var invalid *services.TemplateParseError
+		if errors.As(err, &invalid) {
+			return models.NewAPIError(http.StatusBadRequest, "Template does not parse").WithDetails(map[string]interface{}{
+				"error": invalid.Error(),
+			})
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to save document template")
+	}
+
+	recordAuditKey(c, h.auditRepo, models.AuditUpdate, models.AuditEntityTemplate, template.Name, before, template)
+
+	return c.JSON(http.StatusOK, template)
+}
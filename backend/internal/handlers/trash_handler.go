@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// TrashHandler handles HTTP requests for the trash of deleted customers, contacts and products
+type TrashHandler struct {
+	trashRepo   *repository.TrashRepository
+	trashPurger *services.TrashPurger
+}
+
+// NewTrashHandler creates a new trash handler with the provided repository and purger
+func NewTrashHandler(trashRepo *repository.TrashRepository, trashPurger *services.TrashPurger) *TrashHandler {
+	return &TrashHandler{
+		trashRepo:   trashRepo,
+		trashPurger: trashPurger,
+	}
+}
+
+// GetTrash lists deleted customers, contacts and products, most recently deleted first, with
+// when each is purged; ?type= limits it to one of them. Items are restored with the restore
+// route of their type, e.g. POST /api/contacts/:id/restore, until they are purged.
+func (h *TrashHandler) GetTrash(c echo.Context) error {
+	itemType := c.QueryParam("type")
+	if itemType != "" && !containsString(models.TrashTypes, itemType) {
+		return models.NewAPIError(http.StatusBadRequest, "type must be one of: "+strings.Join(models.TrashTypes, ", "))
+	}
+
+	page, paged, message := parsePage(c)
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	items, total, err := h.trashRepo.GetAll(c.Request().Context(), itemType, h.trashPurger.Retention(), page)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve trash")
+	}
+
+	return jsonPage(c, http.StatusOK, items, page, paged, total)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -11,12 +12,14 @@ import (
 )
 
 type UserHandler struct {
-	userRepo *repository.UserRepository
+	userRepo  *repository.UserRepository
+	auditRepo *repository.AuditRepository
 }
 
-func NewUserHandler(userRepo *repository.UserRepository) *UserHandler {
+func NewUserHandler(userRepo *repository.UserRepository, auditRepo *repository.AuditRepository) *UserHandler {
 	return &UserHandler{
-		userRepo: userRepo,
+		userRepo:  userRepo,
+		auditRepo: auditRepo,
 	}
 }
 
@@ -24,24 +27,29 @@ func NewUserHandler(userRepo *repository.UserRepository) *UserHandler {
 func (h *UserHandler) Register(c echo.Context) error {
 	var user models.User
 	if err := c.Bind(&user); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return models.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(&user); err != nil {
+		return validationError(err)
 	}
 
 	// Hash the password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.PasswordHash), bcrypt.DefaultCost)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to hash password"})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to hash password")
 	}
 	user.PasswordHash = string(hashedPassword)
 
 	// Create the user
 	if err := h.userRepo.Create(c.Request().Context(), &user); err != nil {
 		if err == repository.ErrDuplicateKey {
-			return c.JSON(http.StatusConflict, map[string]string{"error": "Email already exists"})
+			return models.NewAPIError(http.StatusConflict, "Email already exists")
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create user"})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to create user")
 	}
 
+	recordAudit(c, h.auditRepo, models.AuditCreate, models.AuditEntityUser, user.UserID, nil, user)
+
 	return c.JSON(http.StatusCreated, user)
 }
 
@@ -53,24 +61,27 @@ func (h *UserHandler) Login(c echo.Context) error {
 	}
 
 	if err := c.Bind(&loginRequest); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return models.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(&loginRequest); err != nil {
+		return validationError(err)
 	}
 
 	// Get user by email
 	users, err := h.userRepo.SearchUsers(c.Request().Context(), loginRequest.Email)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to find user"})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to find user")
 	}
 
 	if len(users) == 0 {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid credentials"})
+		return models.NewAPIError(http.StatusUnauthorized, "Invalid credentials")
 	}
 
 	user := users[0]
 
 	// Compare passwords
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(loginRequest.Password)); err != nil {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid credentials"})
+		return models.NewAPIError(http.StatusUnauthorized, "Invalid credentials")
 	}
 
 	// Update last login
@@ -90,25 +101,25 @@ func (h *UserHandler) Login(c echo.Context) error {
 func (h *UserHandler) GetUsers(c echo.Context) error {
 	users, err := h.userRepo.GetAll(c.Request().Context())
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve users"})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve users")
 	}
 
-	return c.JSON(http.StatusOK, users)
+	return jsonList(c, http.StatusOK, users)
 }
 
 // GetUser retrieves a single user by ID
 func (h *UserHandler) GetUser(c echo.Context) error {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid user ID")
 	}
 
 	user, err := h.userRepo.GetByID(c.Request().Context(), id)
 	if err != nil {
-		if err.Error() == "user not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "User not found")
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve user"})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve user")
 	}
 
 	return c.JSON(http.StatusOK, user)
@@ -118,23 +129,36 @@ func (h *UserHandler) GetUser(c echo.Context) error {
 func (h *UserHandler) UpdateUser(c echo.Context) error {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid user ID")
 	}
 
 	var user models.User
 	if err := c.Bind(&user); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return models.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(&user); err != nil {
+		return validationError(err)
 	}
 
 	user.UserID = id
 
+	before, err := h.userRepo.GetByID(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "User not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve user")
+	}
+
 	if err := h.userRepo.Update(c.Request().Context(), &user); err != nil {
 		if err == repository.ErrDuplicateKey {
-			return c.JSON(http.StatusConflict, map[string]string{"error": "Email already exists"})
+			return models.NewAPIError(http.StatusConflict, "Email already exists")
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update user"})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update user")
 	}
 
+	recordAudit(c, h.auditRepo, models.AuditUpdate, models.AuditEntityUser, id, before, user)
+
 	return c.JSON(http.StatusOK, user)
 }
 
@@ -142,7 +166,7 @@ func (h *UserHandler) UpdateUser(c echo.Context) error {
 func (h *UserHandler) UpdatePassword(c echo.Context) error {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid user ID")
 	}
 
 	var passwordRequest struct {
@@ -151,31 +175,37 @@ func (h *UserHandler) UpdatePassword(c echo.Context) error {
 	}
 
 	if err := c.Bind(&passwordRequest); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return models.NewAPIError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(&passwordRequest); err != nil {
+		return validationError(err)
 	}
 
 	// Get user to verify current password
 	user, err := h.userRepo.GetByID(c.Request().Context(), id)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve user"})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve user")
 	}
 
 	// Verify current password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(passwordRequest.CurrentPassword)); err != nil {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Current password is incorrect"})
+		return models.NewAPIError(http.StatusUnauthorized, "Current password is incorrect")
 	}
 
 	// Hash new password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(passwordRequest.NewPassword), bcrypt.DefaultCost)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to hash password"})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to hash password")
 	}
 
 	// Update password
 	if err := h.userRepo.UpdatePassword(c.Request().Context(), id, string(hashedPassword)); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update password"})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update password")
 	}
 
+	// Only the fact of the change is recorded, never the password hashes
+	recordAudit(c, h.auditRepo, "password_change", models.AuditEntityUser, id, nil, nil)
+
 	return c.JSON(http.StatusOK, map[string]string{"message": "Password updated successfully"})
 }
 
@@ -183,16 +213,26 @@ func (h *UserHandler) UpdatePassword(c echo.Context) error {
 func (h *UserHandler) DeleteUser(c echo.Context) error {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+		return models.NewAPIError(http.StatusBadRequest, "Invalid user ID")
+	}
+
+	before, err := h.userRepo.GetByID(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "User not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve user")
 	}
 
 	if err := h.userRepo.Delete(c.Request().Context(), id); err != nil {
-		if err.Error() == "user not found" {
-			return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "User not found")
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete user"})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to delete user")
 	}
 
+	recordAudit(c, h.auditRepo, models.AuditDelete, models.AuditEntityUser, id, before, nil)
+
 	return c.JSON(http.StatusOK, map[string]string{"message": "User deleted successfully"})
 }
 
@@ -200,12 +240,12 @@ func (h *UserHandler) DeleteUser(c echo.Context) error {
 func (h *UserHandler) SearchUsers(c echo.Context) error {
 	term := c.QueryParam("q")
 	if term == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Search term is required"})
+		return models.NewAPIError(http.StatusBadRequest, "Search term is required")
 	}
 
 	users, err := h.userRepo.SearchUsers(c.Request().Context(), term)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to search users"})
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to search users")
 	}
 
 	return c.JSON(http.StatusOK, users)
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError describes why one field of a request body was rejected. The failed rule is
+// kept so the message can be written in the client's language; see ValidationErrors.Localize.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+
+	name  string
+	param string
+	kind  reflect.Kind
+}
+
+// ValidationErrors is returned by RequestValidator when a request body breaks its
+// validate tags
+type ValidationErrors []FieldError
+
+// Localize returns the errors with their messages in the given language
+func (e ValidationErrors) Localize(lang string) ValidationErrors {
+	localized := make(ValidationErrors, len(e))
+	for i, field := range e {
+		localized[i] = field
+		if field.Rule != "" {
+			localized[i].Message = fieldMessage(lang, field)
+		}
+	}
+	return localized
+}
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, field := range e {
+		messages[i] = field.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// RequestValidator checks request bodies against their validate struct tags. It is set as
+// the Echo validator, so handlers call c.Validate after c.Bind.
+type RequestValidator struct {
+	validate *validator.Validate
+}
+
+// NewRequestValidator creates a validator that reports fields by their JSON names
+func NewRequestValidator() *RequestValidator {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+	validate.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+		field := fl.Field()
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				return true
+			}
+			field = field.Elem()
+		}
+		return field.Kind() != reflect.String || strings.TrimSpace(field.String()) != ""
+	})
+
+	return &RequestValidator{validate: validate}
+}
+
+// Validate implements echo.Validator
+func (v *RequestValidator) Validate(i interface{}) error {
+	err := v.validate.Struct(i)
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return err
+	}
+
+	result := make(ValidationErrors, len(fieldErrs))
+	for i, fe := range fieldErrs {
+		result[i] = FieldError{
+			Field: fieldPath(fe.Namespace()),
+			Rule:  fe.Tag(),
+			name:  fe.Field(),
+			param: fe.Param(),
+			kind:  fe.Kind(),
+		}
+		result[i].Message = fieldMessage(langEnglish, result[i])
+	}
+	return result
+}
+
+// fieldPath drops the struct name from a validator namespace, so CreateOrderRequest.items[0].quantity
+// becomes items[0].quantity
+func fieldPath(namespace string) string {
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+// fieldMessage turns a failed rule into a sentence for the client in the given language
+func fieldMessage(lang string, fe FieldError) string {
+	field, param := fe.name, fe.param
+	collection := fe.kind == reflect.Slice || fe.kind == reflect.Map
+	key := msgInvalid
+	switch fe.Rule {
+	case "required", "notblank":
+		key = msgRequired
+	case "gt":
+		key = msgGreaterThan
+	case "gte":
+		key = msgAtLeast
+	case "lt":
+		key = msgLessThan
+	case "lte":
+		key = msgAtMost
+	case "min":
+		switch {
+		case fe.kind == reflect.String:
+			key = msgMinLength
+		case collection && param == "1":
+			key = msgNotEmpty
+		case collection:
+			key = msgMinItems
+		default:
+			key = msgAtLeast
+		}
+	case "max":
+		switch {
+		case fe.kind == reflect.String:
+			key = msgMaxLength
+		case collection:
+			key = msgMaxItems
+		default:
+			key = msgAtMost
+		}
+	case "oneof":
+		key, param = msgOneOf, strings.Join(strings.Fields(param), ", ")
+	case "email":
+		key = msgEmail
+	case "url":
+		key = msgURL
+	case "datetime":
+		key, param = msgDatetime, dateFormatHint(param)
+	case "latitude":
+		key = msgLatitude
+	case "longitude":
+		key = msgLongitude
+	case "required_without":
+		key = msgRequiredWithout
+	case "gtefield":
+		key = msgNotBefore
+	}
+	return translate(lang, key, field, param)
+}
+
+// dateFormatHint describes a Go time layout the way clients are told elsewhere
+func dateFormatHint(layout string) string {
+	if layout == deliveryDateLayout {
+		return "YYYY-MM-DD"
+	}
+	return layout
+}
+
+// validationError is returned for a request body that failed validation. Field errors from
+// the validate tags are passed on for the error handler to list under details.fields, with
+// the first one as the message so clients that only show "error" still explain the
+// problem; any other error is reported by its message.
+func validationError(err error) error {
+	var fields ValidationErrors
+	if errors.As(err, &fields) && len(fields) > 0 {
+		return fields
+	}
+	return models.NewAPIError(http.StatusBadRequest, err.Error()).WithCode(models.CodeValidationFailed)
+}
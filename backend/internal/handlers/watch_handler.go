@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	appmw "github.com/Cezzyy/SCMS/backend/internal/middleware"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/labstack/echo/v4"
+)
+
+// WatchHandler handles HTTP requests for the signed-in user's watches on customers and
+// quotations
+type WatchHandler struct {
+	watchRepo     *repository.WatchRepository
+	customerRepo  *repository.CustomerRepository
+	quotationRepo *repository.QuotationRepository
+}
+
+// NewWatchHandler creates a new watch handler with the provided repositories
+func NewWatchHandler(watchRepo *repository.WatchRepository, customerRepo *repository.CustomerRepository, quotationRepo *repository.QuotationRepository) *WatchHandler {
+	return &WatchHandler{
+		watchRepo:     watchRepo,
+		customerRepo:  customerRepo,
+		quotationRepo: quotationRepo,
+	}
+}
+
+// WatchRequest sets which fields of a record are watched, the record's key fields when
+// none are given, and whether changes are also emailed
+type WatchRequest struct {
+	Fields []string `json:"fields"`
+	Email  bool     `json:"email"`
+}
+
+// GetWatches returns the user's watches, newest first
+func (h *WatchHandler) GetWatches(c echo.Context) error {
+	user := appmw.UserFromContext(c)
+	if user == nil {
+		return models.NewAPIError(http.StatusUnauthorized, "Watches require a signed-in user")
+	}
+
+	watches, err := h.watchRepo.GetByUser(c.Request().Context(), user.UserID)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve watches")
+	}
+
+	return jsonList(c, http.StatusOK, watches)
+}
+
+// WatchCustomer watches a customer; see watch
+func (h *WatchHandler) WatchCustomer(c echo.Context) error {
+	return h.watch(c, models.AuditEntityCustomer, "Customer", func(ctx context.Context, id int) error {
+		_, err := h.customerRepo.GetByID(ctx, id)
+		return err
+	})
+}
+
+// UnwatchCustomer stops watching a customer
+func (h *WatchHandler) UnwatchCustomer(c echo.Context) error {
+	return h.unwatch(c, models.AuditEntityCustomer, "Customer")
+}
+
+// WatchQuotation watches a quotation; see watch
+func (h *WatchHandler) WatchQuotation(c echo.Context) error {
+	return h.watch(c, models.AuditEntityQuotation, "Quotation", func(ctx context.Context, id int) error {
+		_, err := h.quotationRepo.GetByID(ctx, id)
+		return err
+	})
+}
+
+// UnwatchQuotation stops watching a quotation
+func (h *WatchHandler) UnwatchQuotation(c echo.Context) error {
+	return h.unwatch(c, models.AuditEntityQuotation, "Quotation")
+}
+
+// watch sets the user's watch on the record in the :id route parameter, replacing any
+// existing one. The user is then notified when someone else changes a watched field of the
+// record or deletes it. exists reports whether the record exists.
+func (h *WatchHandler) watch(c echo.Context, entity, name string, exists func(context.Context, int) error) error {
+	ctx := c.Request().Context()
+
+	user := appmw.UserFromContext(c)
+	if user == nil {
+		return models.NewAPIError(http.StatusUnauthorized, "Watches require a signed-in user")
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid "+strings.ToLower(name)+" ID")
+	}
+
+	var req WatchRequest
+	if c.Request().ContentLength != 0 {
+		if err := c.Bind(&req); err != nil {
+			return models.NewAPIError(http.StatusBadRequest, "Invalid request payload")
+		}
+	}
+	fields := models.StringList{}
+	for _, field := range req.Fields {
+		if !containsString(models.WatchableFields[entity], field) {
+			return models.NewAPIError(http.StatusBadRequest, "Unknown "+strings.ToLower(name)+" field: "+field).
+				WithDetails(map[string]interface{}{"watchable_fields": models.WatchableFields[entity]})
+		}
+		if !containsString(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+
+	if err := exists(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, name+" not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve "+strings.ToLower(name))
+	}
+
+	watch := models.RecordWatch{
+		UserID:   user.UserID,
+		Entity:   entity,
+		EntityID: id,
+		Fields:   fields,
+		Email:    req.Email,
+	}
+	if err := h.watchRepo.Save(ctx, &watch); err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to save watch")
+	}
+
+	return c.JSON(http.StatusOK, watch)
+}
+
+// unwatch removes the user's watch on the record in the :id route parameter
+func (h *WatchHandler) unwatch(c echo.Context, entity, name string) error {
+	user := appmw.UserFromContext(c)
+	if user == nil {
+		return models.NewAPIError(http.StatusUnauthorized, "Watches require a signed-in user")
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid "+strings.ToLower(name)+" ID")
+	}
+
+	if err := h.watchRepo.Delete(c.Request().Context(), user.UserID, entity, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "You are not watching this "+strings.ToLower(name))
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to remove watch")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
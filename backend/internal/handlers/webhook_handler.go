@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// WebhookHandler handles HTTP requests for webhook endpoints and their delivery log
+type WebhookHandler struct {
+	webhookRepo    *repository.WebhookRepository
+	webhookService *services.WebhookService
+}
+
+// NewWebhookHandler creates a new webhook handler with the provided repository and service
+func NewWebhookHandler(webhookRepo *repository.WebhookRepository, webhookService *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookRepo:    webhookRepo,
+		webhookService: webhookService,
+	}
+}
+
+// WebhookEndpointRequest is the body for registering or updating a webhook endpoint. Endpoints
+// are active unless active is false.
+type WebhookEndpointRequest struct {
+	URL         string   `json:"url" validate:"required"`
+	Events      []string `json:"events"`
+	Description string   `json:"description"`
+	Active      *bool    `json:"active"`
+}
+
+// WebhookEndpointCreated is an endpoint just registered with its signing secret, which is
+// shown only once
+type WebhookEndpointCreated struct {
+	WebhookEndpoint models.WebhookEndpoint `json:"webhook_endpoint"`
+	Secret          string                 `json:"secret"`
+}
+
+// endpoint checks the request and returns the endpoint it describes
+func (req WebhookEndpointRequest) endpoint() (models.WebhookEndpoint, string) {
+	endpoint := models.WebhookEndpoint{
+		URL:         strings.TrimSpace(req.URL),
+		Description: strings.TrimSpace(req.Description),
+		Active:      req.Active == nil || *req.Active,
+	}
+
+	parsed, err := url.Parse(endpoint.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return endpoint, "url must be an absolute http or https URL"
+	}
+
+	if len(req.Events) == 0 {
+		return endpoint, "Subscribe to at least one event: " + strings.Join(models.WebhookEvents, ", ")
+	}
+	for _, event := range req.Events {
+		if !containsString(models.WebhookEvents, event) {
+			return endpoint, "Unknown event \"" + event + "\". Use " + strings.Join(models.WebhookEvents, ", ")
+		}
+		if !containsString(endpoint.Events, event) {
+			endpoint.Events = append(endpoint.Events, event)
+		}
+	}
+	return endpoint, ""
+}
+
+// GetWebhookEndpoints returns all webhook endpoints without their secrets
+func (h *WebhookHandler) GetWebhookEndpoints(c echo.Context) error {
+	endpoints, err := h.webhookRepo.GetAll(c.Request().Context())
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve webhook endpoints")
+	}
+
+	return jsonList(c, http.StatusOK, endpoints)
+}
+
+// GetWebhookEndpoint returns a single webhook endpoint without its secret
+func (h *WebhookHandler) GetWebhookEndpoint(c echo.Context) error {
+	endpoint, err := h.webhookEndpoint(c)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, endpoint)
+}
+
+// CreateWebhookEndpoint registers an endpoint for the events it lists and returns the secret
+// its callbacks are signed with, which is shown only once
+func (h *WebhookHandler) CreateWebhookEndpoint(c echo.Context) error {
+	var req WebhookEndpointRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	endpoint, message := req.endpoint()
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	secret, err := h.webhookService.GenerateSecret()
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to generate webhook secret")
+	}
+	endpoint.Secret = secret
+
+	if err := h.webhookRepo.Create(c.Request().Context(), &endpoint); err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to create webhook endpoint")
+	}
+
+	return c.JSON(http.StatusCreated, WebhookEndpointCreated{
+		WebhookEndpoint: endpoint,
+		Secret:          secret,
+	})
+}
+
+// UpdateWebhookEndpoint changes an endpoint's URL, events, description or whether it is
+// active. Its secret is kept.
+func (h *WebhookHandler) UpdateWebhookEndpoint(c echo.Context) error {
+	existing, err := h.webhookEndpoint(c)
+	if err != nil {
+		return err
+	}
+
+	var req WebhookEndpointRequest
+	if err := c.Bind(&req); err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(err)
+	}
+
+	endpoint, message := req.endpoint()
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+	endpoint.WebhookEndpointID = existing.WebhookEndpointID
+	endpoint.CreatedAt = existing.CreatedAt
+
+	if err := h.webhookRepo.Update(c.Request().Context(), &endpoint); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Webhook endpoint not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to update webhook endpoint")
+	}
+
+	return c.JSON(http.StatusOK, endpoint)
+}
+
+// DeleteWebhookEndpoint removes an endpoint with its delivery log
+func (h *WebhookHandler) DeleteWebhookEndpoint(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid webhook endpoint ID")
+	}
+
+	if err := h.webhookRepo.Delete(c.Request().Context(), id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Webhook endpoint not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to delete webhook endpoint")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetWebhookDeliveries returns an endpoint's delivery log, newest first, with each
+// delivery's status, attempts and the endpoint's last response
+func (h *WebhookHandler) GetWebhookDeliveries(c echo.Context) error {
+	endpoint, err := h.webhookEndpoint(c)
+	if err != nil {
+		return err
+	}
+
+	page, paged, message := parsePage(c)
+	if message != "" {
+		return models.NewAPIError(http.StatusBadRequest, message)
+	}
+
+	deliveries, total, err := h.webhookRepo.GetDeliveries(c.Request().Context(), endpoint.WebhookEndpointID, page)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve webhook deliveries")
+	}
+
+	return jsonPage(c, http.StatusOK, deliveries, page, paged, total)
+}
+
+// RedeliverWebhook sends a delivery's event to its endpoint again, whatever became of the
+// original, and returns the new delivery
+func (h *WebhookHandler) RedeliverWebhook(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	endpoint, err := h.webhookEndpoint(c)
+	if err != nil {
+		return err
+	}
+	deliveryID, err := strconv.Atoi(c.Param("delivery_id"))
+	if err != nil {
+		return models.NewAPIError(http.StatusBadRequest, "Invalid webhook delivery ID")
+	}
+
+	delivery, err := h.webhookRepo.GetDelivery(ctx, endpoint.WebhookEndpointID, deliveryID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.NewAPIError(http.StatusNotFound, "Webhook delivery not found")
+		}
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve webhook delivery")
+	}
+
+	redelivery, err := h.webhookService.Redeliver(ctx, delivery)
+	if err != nil {
+		return models.NewAPIError(http.StatusInternalServerError, "Failed to queue redelivery")
+	}
+
+	return c.JSON(http.StatusCreated, redelivery)
+}
+
+// webhookEndpoint loads the endpoint named by the :id parameter
+func (h *WebhookHandler) webhookEndpoint(c echo.Context) (models.WebhookEndpoint, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return models.WebhookEndpoint{}, models.NewAPIError(http.StatusBadRequest, "Invalid webhook endpoint ID")
+	}
+
+	endpoint, err := h.webhookRepo.GetByID(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return endpoint, models.NewAPIError(http.StatusNotFound, "Webhook endpoint not found")
+		}
+		return endpoint, models.NewAPIError(http.StatusInternalServerError, "Failed to retrieve webhook endpoint")
+	}
+	return endpoint, nil
+}
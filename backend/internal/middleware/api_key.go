@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// APIKeyHeader carries an API key for external integrators
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyContextKey is the echo context key holding the authenticated API key
+const apiKeyContextKey = "api_key"
+
+// APIKeyAuth authenticates requests that carry an X-API-Key header, checks the key's scopes
+// and enforces its daily quota. Quota headers are set on every keyed response; requests over
+// the quota get 429 with Retry-After pointing at the next UTC midnight. Requests without a
+// key pass through.
+func APIKeyAuth(apiKeyService *services.APIKeyService) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			secret := strings.TrimSpace(c.Request().Header.Get(APIKeyHeader))
+			if secret == "" {
+				return next(c)
+			}
+
+			ctx := c.Request().Context()
+			key, err := apiKeyService.Authenticate(ctx, secret)
+			if err != nil {
+				if err == services.ErrInvalidAPIKey {
+					return models.NewAPIError(http.StatusUnauthorized, "Invalid or revoked API key")
+				}
+				return models.NewAPIError(http.StatusInternalServerError, "Failed to authenticate API key")
+			}
+
+			// Keys may always read their own usage; everything else needs a matching scope
+			path := c.Request().URL.Path
+			if !strings.HasPrefix(path, "/api/keys/") && !key.Scopes.Allows(c.Request().Method, path) {
+				return models.NewAPIError(http.StatusForbidden, "API key does not have the scope for this request")
+			}
+
+			status, err := apiKeyService.Consume(ctx, key)
+			if err != nil {
+				return models.NewAPIError(http.StatusInternalServerError, "Failed to record API usage")
+			}
+
+			header := c.Response().Header()
+			if status.Limit > 0 {
+				header.Set("X-RateLimit-Limit", strconv.Itoa(status.Limit))
+				header.Set("X-RateLimit-Remaining", strconv.Itoa(status.Remaining))
+				header.Set("X-RateLimit-Reset", strconv.FormatInt(status.ResetAt.Unix(), 10))
+			}
+
+			if status.Exceeded {
+				retryAfter := int(time.Until(status.ResetAt).Seconds()) + 1
+				header.Set("Retry-After", strconv.Itoa(retryAfter))
+				return models.NewAPIError(http.StatusTooManyRequests, "Daily API quota exceeded").WithDetails(map[string]interface{}{
+					"quota": status,
+				})
+			}
+
+			c.Set(apiKeyContextKey, key)
+			return next(c)
+		}
+	}
+}
+
+// APIKeyFromContext returns the API key authenticated for this request, if any
+func APIKeyFromContext(c echo.Context) *models.APIKey {
+	key, _ := c.Get(apiKeyContextKey).(*models.APIKey)
+	return key
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// Echo context keys holding the logged-in user and their session
+const (
+	userContextKey    = "user"
+	sessionContextKey = "session"
+)
+
+// SessionAuth requires every /api request, and the /admin panel, to be authenticated, except
+// the given public paths. Browser clients authenticate with the session_id cookie, which is validated against the
+// session store; integrations and the mobile client may instead send an
+// "Authorization: Bearer <jwt>" header. Requests already authenticated by a device token,
+// API key or impersonation token are let through, so this must be registered after those middlewares.
+func SessionAuth(authService *services.AuthService, publicPaths ...string) echo.MiddlewareFunc {
+	public := map[string]bool{}
+	for _, path := range publicPaths {
+		public[path] = true
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			path := c.Request().URL.Path
+			protected := strings.HasPrefix(path, "/api/") || path == "/admin" || strings.HasPrefix(path, "/admin/")
+			if !protected || public[path] || c.Request().Method == http.MethodOptions {
+				return next(c)
+			}
+
+			if impersonation := ImpersonationFromContext(c); impersonation != nil {
+				c.Set(userContextKey, &impersonation.User)
+				return next(c)
+			}
+			if DeviceFromContext(c) != nil || APIKeyFromContext(c) != nil {
+				return next(c)
+			}
+
+			if token := bearerToken(c.Request()); token != "" {
+				user, err := authService.ValidateToken(c.Request().Context(), token)
+				if err != nil {
+					if err == services.ErrInvalidToken {
+						return models.NewAPIError(http.StatusUnauthorized, "Bearer token is invalid or has expired")
+					}
+					return models.NewAPIError(http.StatusInternalServerError, "Failed to validate token")
+				}
+
+				c.Set(userContextKey, &user)
+				return next(c)
+			}
+
+			cookie, err := c.Cookie(services.SessionCookieName)
+			if err != nil || cookie.Value == "" {
+				return models.NewAPIError(http.StatusUnauthorized, "Authentication required")
+			}
+
+			session, user, err := authService.ValidateSession(c.Request().Context(), cookie.Value)
+			if err != nil {
+				if err == services.ErrInvalidSession {
+					return models.NewAPIError(http.StatusUnauthorized, "Session is invalid or has expired")
+				}
+				return models.NewAPIError(http.StatusInternalServerError, "Failed to validate session")
+			}
+
+			c.Set(userContextKey, &user)
+			c.Set(sessionContextKey, &session)
+			return next(c)
+		}
+	}
+}
+
+// UserFromContext returns the user this request acts as: the logged-in user, or the
+// impersonated user during impersonation. It is nil for device and API key requests.
+func UserFromContext(c echo.Context) *models.User {
+	user, _ := c.Get(userContextKey).(*models.User)
+	return user
+}
+
+// SessionFromContext returns the login session behind this request, if any
+func SessionFromContext(c echo.Context) *models.Session {
+	session, _ := c.Get(sessionContextKey).(*models.Session)
+	return session
+}
+
+// bearerToken extracts a JWT from an "Authorization: Bearer" header
+func bearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get(echo.HeaderAuthorization), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
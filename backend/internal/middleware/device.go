@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// DeviceTokenHeader carries a device token for registered API clients
+const DeviceTokenHeader = "X-Device-Token"
+
+// deviceContextKey is the echo context key holding the authenticated device
+const deviceContextKey = "device"
+
+// DeviceAuth authenticates requests that carry a device token, either in the
+// X-Device-Token header or as "Authorization: Device <token>". Requests without
+// a device token pass through untouched; invalid or revoked tokens are rejected.
+// Devices act for no user, so they may only call the routes under the given paths,
+// such as /api/sync; anything else is refused with 403.
+func DeviceAuth(deviceService *services.DeviceService, allowedPaths ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			token := deviceToken(c.Request())
+			if token == "" {
+				return next(c)
+			}
+
+			device, err := deviceService.Authenticate(c.Request().Context(), token, c.RealIP())
+			if err != nil {
+				if err == services.ErrInvalidDeviceToken {
+					return models.NewAPIError(http.StatusUnauthorized, "Invalid or revoked device token")
+				}
+				return models.NewAPIError(http.StatusInternalServerError, "Failed to authenticate device")
+			}
+
+			if !underPaths(c.Request().URL.Path, allowedPaths) {
+				return models.NewAPIError(http.StatusForbidden, "Device tokens cannot be used for this request")
+			}
+
+			c.Set(deviceContextKey, device)
+			return next(c)
+		}
+	}
+}
+
+// underPaths reports whether a request path is one of the paths or below one of them
+func underPaths(path string, paths []string) bool {
+	for _, allowed := range paths {
+		if path == allowed || strings.HasPrefix(path, allowed+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// DeviceFromContext returns the device authenticated for this request, if any
+func DeviceFromContext(c echo.Context) *models.Device {
+	device, _ := c.Get(deviceContextKey).(*models.Device)
+	return device
+}
+
+// deviceToken extracts a device token from the request headers
+func deviceToken(r *http.Request) string {
+	if token := strings.TrimSpace(r.Header.Get(DeviceTokenHeader)); token != "" {
+		return token
+	}
+	scheme, token, ok := strings.Cut(r.Header.Get(echo.HeaderAuthorization), " ")
+	if ok && strings.EqualFold(scheme, "Device") {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
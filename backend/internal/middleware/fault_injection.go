@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/config"
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/labstack/echo/v4"
+)
+
+// FaultHeader marks responses a fault was injected into, with the delay added and "error"
+// when the request was failed
+const FaultHeader = "X-Fault-Injected"
+
+// InjectedFault is the error of a request failed on purpose. It is answered like any API
+// error but is not an incident, so it is not reported.
+type InjectedFault struct {
+	*models.APIError
+}
+
+func (f *InjectedFault) Unwrap() error {
+	return f.APIError
+}
+
+// faultRoute is a route faults are injected into: a route such as /api/orders/:id, or a
+// prefix ending in *, optionally for one method only
+type faultRoute struct {
+	method string
+	path   string
+	prefix bool
+}
+
+func (r faultRoute) matches(method, route, path string) bool {
+	if r.method != "" && r.method != method {
+		return false
+	}
+	if r.prefix {
+		return strings.HasPrefix(path, r.path)
+	}
+	return r.path == route || r.path == path
+}
+
+// FaultInjection delays and fails a random share of the configured routes, so clients'
+// retries and back-off can be exercised against a staging server. Preflight requests and
+// /api/health are never touched, and it does nothing unless faults are enabled.
+func FaultInjection(faults config.FaultInjection) echo.MiddlewareFunc {
+	if !faults.Enabled {
+		return func(next echo.HandlerFunc) echo.HandlerFunc { return next }
+	}
+
+	routes := make([]faultRoute, 0, len(faults.Routes))
+	for _, pattern := range faults.Routes {
+		var route faultRoute
+		if method, path, ok := strings.Cut(pattern, " "); ok {
+			route.method = strings.ToUpper(method)
+			pattern = strings.TrimSpace(path)
+		}
+		route.path = strings.TrimSuffix(pattern, "*")
+		route.prefix = strings.HasSuffix(pattern, "*")
+		routes = append(routes, route)
+	}
+	if len(routes) == 0 {
+		routes = append(routes, faultRoute{path: "/api/", prefix: true})
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if req.Method == http.MethodOptions || req.URL.Path == "/api/health" {
+				return next(c)
+			}
+			selected := false
+			for _, route := range routes {
+				if route.matches(req.Method, c.Path(), req.URL.Path) {
+					selected = true
+					break
+				}
+			}
+			if !selected {
+				return next(c)
+			}
+
+			if rand.Float64() < faults.LatencyRate {
+				delay := faults.LatencyMin + time.Duration(rand.Int63n(int64(faults.LatencyMax-faults.LatencyMin)+1))
+				c.Response().Header().Add(FaultHeader, delay.Round(time.Millisecond).String())
+				select {
+				case <-time.After(delay):
+				case <-req.Context().Done():
+					return req.Context().Err()
+				}
+			}
+
+			if rand.Float64() < faults.ErrorRate {
+				status := faults.ErrorStatus[rand.Intn(len(faults.ErrorStatus))]
+				c.Response().Header().Add(FaultHeader, "error")
+				if status == http.StatusServiceUnavailable {
+					c.Response().Header().Set("Retry-After", strconv.Itoa(1+rand.Intn(5)))
+				}
+				return &InjectedFault{models.NewAPIError(status, "Injected fault").WithDetails(map[string]interface{}{
+					"fault_injection": true,
+				})}
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// ImpersonationTokenHeader carries an impersonation session token
+const ImpersonationTokenHeader = "X-Impersonation-Token"
+
+// ImpersonatingHeader is set on responses served under impersonation so clients can show a banner
+const ImpersonatingHeader = "X-Impersonating-User"
+
+// impersonationContextKey is the echo context key holding the active impersonation
+const impersonationContextKey = "impersonation"
+
+// Impersonation resolves the X-Impersonation-Token header and flags the request as acting
+// as another user. Every state-changing request made while impersonating is written to the
+// audit log with both the impersonated user and the admin behind it.
+func Impersonation(impersonationService *services.ImpersonationService, auditRepo *repository.AuditRepository) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			token := strings.TrimSpace(c.Request().Header.Get(ImpersonationTokenHeader))
+			if token == "" {
+				return next(c)
+			}
+
+			impersonation, err := impersonationService.Resolve(c.Request().Context(), token)
+			if err != nil {
+				if err == services.ErrInvalidImpersonationToken {
+					return models.NewAPIError(http.StatusUnauthorized, "Impersonation session is invalid or has expired")
+				}
+				return models.NewAPIError(http.StatusInternalServerError, "Failed to resolve impersonation session")
+			}
+
+			c.Set(impersonationContextKey, impersonation)
+			c.Response().Header().Set(ImpersonatingHeader, impersonation.User.Email)
+
+			// Write any error response now so the audit entry records its status
+			if err = next(c); err != nil {
+				c.Error(err)
+				err = nil
+			}
+
+			method := c.Request().Method
+			if method != http.MethodGet && method != http.MethodHead && method != http.MethodOptions {
+				details, _ := json.Marshal(map[string]interface{}{
+					"status":           c.Response().Status,
+					"impersonation_id": impersonation.Session.ImpersonationID,
+				})
+				entry := models.AuditLog{
+					UserID:         &impersonation.Session.TargetUserID,
+					ImpersonatorID: &impersonation.Session.AdminUserID,
+					Action:         method + " " + c.Path(),
+					Entity:         c.Path(),
+					EntityID:       c.Param("id"),
+					Details:        details,
+					IPAddress:      c.RealIP(),
+				}
+				if auditErr := auditRepo.Create(c.Request().Context(), &entry); auditErr != nil {
+					log.Printf("Failed to write impersonation audit log: %v", auditErr)
+				}
+			}
+
+			return err
+		}
+	}
+}
+
+// ImpersonationFromContext returns the active impersonation for this request, if any
+func ImpersonationFromContext(c echo.Context) *services.ImpersonationContext {
+	impersonation, _ := c.Get(impersonationContextKey).(*services.ImpersonationContext)
+	return impersonation
+}
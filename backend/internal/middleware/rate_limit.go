@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// LoginRateLimit limits login attempts per client IP. It is strict because the login
+// route is public; account lockout still applies per account on top of it.
+func LoginRateLimit(limiter *services.RateLimiter) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			result := limiter.Take(c.Request().Context(), "login", "ip:"+c.RealIP(), limiter.Login)
+			if err := applyRateLimit(c, result, "Too many login attempts, try again later"); err != nil {
+				return err
+			}
+			return next(c)
+		}
+	}
+}
+
+// QuoteRequestRateLimit limits quote requests from the public form per client IP. Like
+// login, the route is public, so it is kept well below the API limit.
+func QuoteRequestRateLimit(limiter *services.RateLimiter) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			result := limiter.Take(c.Request().Context(), "quote_request", "ip:"+c.RealIP(), limiter.QuoteRequest)
+			if err := applyRateLimit(c, result, "Too many quote requests, try again later"); err != nil {
+				return err
+			}
+			return next(c)
+		}
+	}
+}
+
+// APIRateLimit limits /api requests per user, per device for tablets and scanners, and per
+// client IP for requests without either, except the given paths. API keys are left to
+// their daily quotas. It must be registered after SessionAuth so the user is known.
+func APIRateLimit(limiter *services.RateLimiter, skipPaths ...string) echo.MiddlewareFunc {
+	skip := map[string]bool{}
+	for _, path := range skipPaths {
+		skip[path] = true
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			path := c.Request().URL.Path
+			if !strings.HasPrefix(path, "/api/") || skip[path] || c.Request().Method == http.MethodOptions || APIKeyFromContext(c) != nil {
+				return next(c)
+			}
+
+			key := "ip:" + c.RealIP()
+			if user := UserFromContext(c); user != nil {
+				key = "user:" + strconv.Itoa(user.UserID)
+			} else if device := DeviceFromContext(c); device != nil {
+				key = "device:" + strconv.Itoa(device.DeviceID)
+			}
+
+			result := limiter.Take(c.Request().Context(), "api", key, limiter.API)
+			if err := applyRateLimit(c, result, "Too many requests, try again later"); err != nil {
+				return err
+			}
+			return next(c)
+		}
+	}
+}
+
+// applyRateLimit sets the rate limit headers and returns a 429 when the request is not allowed
+func applyRateLimit(c echo.Context, result services.RateLimitResult, message string) error {
+	if result.Limit == 0 {
+		return nil
+	}
+
+	header := c.Response().Header()
+	header.Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	header.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	header.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(result.ResetAfter).Unix(), 10))
+
+	if result.Allowed {
+		return nil
+	}
+
+	retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	header.Set("Retry-After", strconv.Itoa(retryAfter))
+	return models.NewAPIError(http.StatusTooManyRequests, message).WithDetails(map[string]interface{}{
+		"retry_after": retryAfter,
+	})
+}
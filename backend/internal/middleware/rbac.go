@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/labstack/echo/v4"
+)
+
+// RequireRole restricts a route to users with one of the given roles. Requests without a
+// user, such as device and API key requests, are refused. Roles compare case-insensitively.
+func RequireRole(roles ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			user := UserFromContext(c)
+			if user == nil || !hasRole(user, roles) {
+				return models.NewAPIError(http.StatusForbidden, "You do not have permission to perform this action")
+			}
+			return next(c)
+		}
+	}
+}
+
+// ReadOnlyRoles limits users with the given roles to GET requests, except for the given
+// paths (such as logout) that every user must be able to call
+func ReadOnlyRoles(roles []string, allowedPaths ...string) echo.MiddlewareFunc {
+	allowed := map[string]bool{}
+	for _, path := range allowedPaths {
+		allowed[path] = true
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			method := c.Request().Method
+			if method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions {
+				return next(c)
+			}
+
+			user := UserFromContext(c)
+			if user != nil && hasRole(user, roles) && !allowed[c.Request().URL.Path] {
+				return models.NewAPIError(http.StatusForbidden, "Your role has read-only access")
+			}
+			return next(c)
+		}
+	}
+}
+
+// hasRole reports whether the user has one of the roles
+func hasRole(user *models.User, roles []string) bool {
+	for _, role := range roles {
+		if strings.EqualFold(user.Role, role) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+)
+
+// Anomaly metrics
+const (
+	AnomalySalesDrop = "sales_drop"
+	AnomalyStockDrop = "stock_drop"
+)
+
+// AnomalyMetrics lists the metrics anomalies can be filtered by
+var AnomalyMetrics = []string{AnomalySalesDrop, AnomalyStockDrop}
+
+// Anomaly is an unusual day flagged by the scheduled anomaly check: sales well below their
+// trend, or stock of InventoryID that disappeared without orders taking it. Observed is what
+// happened and Expected what the check expected instead, both in units of the metric.
+type Anomaly struct {
+	AnomalyID   int       `db:"anomaly_id" json:"anomaly_id"`
+	Metric      string    `db:"metric" json:"metric"`
+	InventoryID *int      `db:"inventory_id" json:"inventory_id,omitempty"`
+	Day         time.Time `db:"day" json:"day"`
+	Observed    float64   `db:"observed" json:"observed"`
+	Expected    float64   `db:"expected" json:"expected"`
+	Message     string    `db:"message" json:"message"`
+	DetectedAt  time.Time `db:"detected_at" json:"detected_at"`
+}
+
+// DailySales is the base currency total of a day's orders
+type DailySales struct {
+	Day         time.Time `db:"day" json:"day"`
+	TotalAmount float64   `db:"total_amount" json:"total_amount"`
+}
+
+// StockDrop is an inventory record holding less stock than its last snapshot, plus what was
+// received and less what orders took since, would leave
+type StockDrop struct {
+	InventoryID   int       `db:"inventory_id"`
+	ProductID     int       `db:"product_id"`
+	ProductName   string    `db:"product_name"`
+	PreviousStock int       `db:"previous_stock"`
+	Received      int       `db:"received"`
+	Issued        int       `db:"issued"`
+	CurrentStock  int       `db:"current_stock"`
+	TakenAt       time.Time `db:"taken_at"`
+}
+
+// Expected returns the stock the movements since the snapshot account for
+func (d StockDrop) Expected() int {
+	return d.PreviousStock + d.Received - d.Issued
+}
+
+// Unexplained returns how many units disappeared without a movement recording them
+func (d StockDrop) Unexplained() int {
+	return d.Expected() - d.CurrentStock
+}
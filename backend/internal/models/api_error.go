@@ -0,0 +1,94 @@
+package models
+
+import "net/http"
+
+// Error codes returned in the "code" field of API error responses. Clients should branch
+// on the code; the message is for people and may change.
+const (
+	CodeBadRequest         = "bad_request"
+	CodeValidationFailed   = "validation_failed"
+	CodeUnauthorized       = "unauthorized"
+	CodeInvalidCredentials = "invalid_credentials"
+	CodeForbidden          = "forbidden"
+	CodeNotFound           = "not_found"
+	CodeMethodNotAllowed   = "method_not_allowed"
+	CodeConflict           = "conflict"
+	CodeStaleVersion       = "stale_version"
+	CodeVersionRequired    = "version_required"
+	CodeDuplicate          = "duplicate"
+	CodeReferenced         = "referenced"
+	CodeInsufficientStock  = "insufficient_stock"
+	CodePayloadTooLarge    = "payload_too_large"
+	CodeAccountLocked      = "account_locked"
+	CodeRateLimited        = "rate_limited"
+	CodeInternal           = "internal_error"
+	CodeBadGateway         = "bad_gateway"
+	CodeUnavailable        = "service_unavailable"
+)
+
+// statusCodes is the default error code for each HTTP status
+var statusCodes = map[int]string{
+	http.StatusBadRequest:            CodeBadRequest,
+	http.StatusUnauthorized:          CodeUnauthorized,
+	http.StatusForbidden:             CodeForbidden,
+	http.StatusNotFound:              CodeNotFound,
+	http.StatusMethodNotAllowed:      CodeMethodNotAllowed,
+	http.StatusConflict:              CodeConflict,
+	http.StatusRequestEntityTooLarge: CodePayloadTooLarge,
+	http.StatusUnprocessableEntity:   CodeValidationFailed,
+	http.StatusLocked:                CodeAccountLocked,
+	http.StatusPreconditionRequired:  CodeVersionRequired,
+	http.StatusTooManyRequests:       CodeRateLimited,
+	http.StatusInternalServerError:   CodeInternal,
+	http.StatusBadGateway:            CodeBadGateway,
+	http.StatusServiceUnavailable:    CodeUnavailable,
+}
+
+// CodeForStatus returns the default error code for an HTTP status
+func CodeForStatus(status int) string {
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
+
+// APIError is the body of every error response:
+//
+//	{"error": "Order not found", "code": "not_found", "details": {...}}
+//
+// Handlers and middleware return it as their error and the central error handler writes it.
+type APIError struct {
+	Status  int         `json:"-"`
+	Message string      `json:"error"`
+	Code    string      `json:"code"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// NewAPIError creates an error response with the default code for its status
+func NewAPIError(status int, message string) *APIError {
+	return &APIError{
+		Status:  status,
+		Message: message,
+		Code:    CodeForStatus(status),
+	}
+}
+
+// Error implements error
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// WithCode replaces the error's code
+func (e *APIError) WithCode(code string) *APIError {
+	e.Code = code
+	return e
+}
+
+// WithDetails attaches extra machine-readable information about the error
+func (e *APIError) WithDetails(details interface{}) *APIError {
+	e.Details = details
+	return e
+}
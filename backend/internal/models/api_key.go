@@ -0,0 +1,91 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// APIScopeAll grants an API key access to every resource
+const APIScopeAll = "*"
+
+// apiScopePattern matches "<resource>:<read|write|*>"
+var apiScopePattern = regexp.MustCompile(`^[a-z][a-z0-9-]*:(read|write|\*)$`)
+
+// APIKey authenticates an external system calling the API
+type APIKey struct {
+	APIKeyID   int        `db:"api_key_id" json:"api_key_id"`
+	Name       string     `db:"name" json:"name"`
+	KeyHash    string     `db:"key_hash" json:"-"`
+	KeyPrefix  string     `db:"key_prefix" json:"key_prefix"`
+	DailyQuota int        `db:"daily_quota" json:"daily_quota"`
+	RevokedAt  *time.Time `db:"revoked_at" json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	Scopes     APIScopes  `db:"scopes" json:"scopes"`
+	LastUsedAt *time.Time `db:"last_used_at" json:"last_used_at,omitempty"`
+}
+
+// APIScopes lists what an API key may access. Scopes name the first path segment after
+// /api/ and an access level, e.g. "products:read" or "orders:write"; write access includes read.
+type APIScopes []string
+
+// ValidAPIScope reports whether a scope is "*" or of the form "<resource>:<read|write|*>"
+func ValidAPIScope(scope string) bool {
+	return scope == APIScopeAll || apiScopePattern.MatchString(scope)
+}
+
+// Allows reports whether the scopes permit a request with the given method and path
+func (s APIScopes) Allows(method, path string) bool {
+	resource, _, _ := strings.Cut(strings.TrimPrefix(path, "/api/"), "/")
+	read := method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+
+	for _, scope := range s {
+		if scope == APIScopeAll {
+			return true
+		}
+		name, level, ok := strings.Cut(scope, ":")
+		if !ok || name != resource {
+			continue
+		}
+		if level == "*" || level == "write" || (level == "read" && read) {
+			return true
+		}
+	}
+	return false
+}
+
+// Value encodes the scopes for storage
+func (s APIScopes) Value() (driver.Value, error) {
+	if s == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(s)
+	return string(b), err
+}
+
+// Scan decodes the scopes from a JSONB column
+func (s *APIScopes) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	case nil:
+		*s = APIScopes{}
+		return nil
+	}
+	return errors.New("unsupported type for api key scopes")
+}
+
+// APIKeyUsage is the request count for a key on a single UTC day
+type APIKeyUsage struct {
+	APIKeyID     int       `db:"api_key_id" json:"-"`
+	UsageDate    time.Time `db:"usage_date" json:"date"`
+	RequestCount int       `db:"request_count" json:"requests"`
+	Allowed      int       `db:"-" json:"allowed"`
+	Rejected     int       `db:"-" json:"rejected"`
+}
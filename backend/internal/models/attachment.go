@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// Attachment kinds
+const (
+	AttachmentSignature = "signature"
+	AttachmentPhoto     = "photo"
+)
+
+// Attachment is a file uploaded against a business record. Content is only loaded for downloads.
+type Attachment struct {
+	AttachmentID int       `db:"attachment_id" json:"attachment_id"`
+	Entity       string    `db:"entity" json:"entity"`
+	EntityID     int       `db:"entity_id" json:"entity_id"`
+	Kind         string    `db:"kind" json:"kind"`
+	FileName     string    `db:"file_name" json:"file_name"`
+	ContentType  string    `db:"content_type" json:"content_type"`
+	SizeBytes    int       `db:"size_bytes" json:"size_bytes"`
+	Content      []byte    `db:"content" json:"-"`
+	UploadedBy   *int      `db:"uploaded_by" json:"uploaded_by,omitempty"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+}
+
+// ProofOfDelivery records who received an order, where and when
+type ProofOfDelivery struct {
+	OrderID               int       `db:"order_id" json:"order_id"`
+	RecipientName         string    `db:"recipient_name" json:"recipient_name"`
+	SignatureAttachmentID *int      `db:"signature_attachment_id" json:"signature_attachment_id,omitempty"`
+	PhotoAttachmentID     *int      `db:"photo_attachment_id" json:"photo_attachment_id,omitempty"`
+	Latitude              *float64  `db:"latitude" json:"latitude,omitempty"`
+	Longitude             *float64  `db:"longitude" json:"longitude,omitempty"`
+	DeliveredAt           time.Time `db:"delivered_at" json:"delivered_at"`
+	CapturedBy            *int      `db:"captured_by" json:"captured_by,omitempty"`
+	CreatedAt             time.Time `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audited actions on business records
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+// Entities whose changes are audited
+const (
+	AuditEntityCustomer     = "customer"
+	AuditEntityProduct      = "product"
+	AuditEntityOrder        = "order"
+	AuditEntityQuotation    = "quotation"
+	AuditEntityInventory    = "inventory"
+	AuditEntityUser         = "user"
+	AuditEntityInvoice      = "invoice"
+	AuditEntityRule         = "business_rule"
+	AuditEntityTemplate     = "document_template"
+	AuditEntityExchangeRate = "exchange_rate"
+	AuditEntityQuoteRequest = "quote_request"
+	AuditEntityDownloadLink = "download_link"
+	AuditEntityDocumentScan = "document_scan"
+)
+
+// AuditLog records a sensitive action and who performed it
+type AuditLog struct {
+	AuditLogID     int             `db:"audit_log_id" json:"audit_log_id"`
+	UserID         *int            `db:"user_id" json:"user_id,omitempty"`
+	ImpersonatorID *int            `db:"impersonator_id" json:"impersonator_id,omitempty"`
+	Action         string          `db:"action" json:"action"`
+	Entity         string          `db:"entity" json:"entity"`
+	EntityID       string          `db:"entity_id" json:"entity_id"`
+	Details        json.RawMessage `db:"details" json:"details"`
+	Before         json.RawMessage `db:"before_data" json:"before,omitempty"`
+	After          json.RawMessage `db:"after_data" json:"after,omitempty"`
+	IPAddress      string          `db:"ip_address" json:"ip_address"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
+}
+
+// ImpersonationSession lets an admin act as another user for a limited time
+type ImpersonationSession struct {
+	ImpersonationID int        `db:"impersonation_id" json:"impersonation_id"`
+	TokenHash       string     `db:"token_hash" json:"-"`
+	AdminUserID     int        `db:"admin_user_id" json:"admin_user_id"`
+	TargetUserID    int        `db:"target_user_id" json:"target_user_id"`
+	Reason          string     `db:"reason" json:"reason"`
+	ExpiresAt       time.Time  `db:"expires_at" json:"expires_at"`
+	EndedAt         *time.Time `db:"ended_at" json:"ended_at,omitempty"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+)
+
+// BankStatement is a bank statement imported from CSV for reconciling payments
+type BankStatement struct {
+	StatementID  int                 `db:"statement_id" json:"statement_id"`
+	BankAccount  string              `db:"bank_account" json:"bank_account"`
+	FileName     string              `db:"file_name" json:"file_name"`
+	ImportedBy   *int                `db:"imported_by" json:"imported_by,omitempty"`
+	ImportedAt   time.Time           `db:"imported_at" json:"imported_at"`
+	LineCount    int                 `db:"line_count" json:"line_count"`
+	MatchedCount int                 `db:"matched_count" json:"matched_count"`
+	Lines        []BankStatementLine `db:"-" json:"lines,omitempty"`
+}
+
+// BankStatementLine is one transaction on a bank statement. Deposits are positive and
+// withdrawals negative; PaymentID is the payment the deposit was confirmed to be.
+type BankStatementLine struct {
+	LineID          int        `db:"line_id" json:"line_id"`
+	StatementID     int        `db:"statement_id" json:"statement_id"`
+	LineNo          int        `db:"line_no" json:"line_no"`
+	TransactionDate time.Time  `db:"transaction_date" json:"transaction_date"`
+	Description     string     `db:"description" json:"description"`
+	Reference       string     `db:"reference" json:"reference"`
+	Amount          float64    `db:"amount" json:"amount"`
+	PaymentID       *int       `db:"payment_id" json:"payment_id,omitempty"`
+	MatchedBy       *int       `db:"matched_by" json:"matched_by,omitempty"`
+	MatchedAt       *time.Time `db:"matched_at" json:"matched_at,omitempty"`
+}
+
+// MatchSuggestion is a recorded payment that may correspond to a statement line. Score runs
+// from 0 to 100; Reasons explains which of amount, date and reference agree.
+type MatchSuggestion struct {
+	Payment PaymentRecord `json:"payment"`
+	Score   int           `json:"score"`
+	Reasons []string      `json:"reasons"`
+}
+
+// LineSuggestions are the match suggestions for an unmatched statement line, best first
+type LineSuggestions struct {
+	Line        BankStatementLine `json:"line"`
+	Suggestions []MatchSuggestion `json:"suggestions"`
+}
+
+// UnmatchedReport lists what is left to reconcile in a period: deposits on imported
+// statements not matched to a payment, and payments not found on any statement
+type UnmatchedReport struct {
+	Lines         []BankStatementLine `json:"lines"`
+	Payments      []PaymentRecord     `json:"payments"`
+	LinesTotal    float64             `json:"lines_total"`
+	PaymentsTotal float64             `json:"payments_total"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+)
+
+// Certification is a reference entry for a product certification such as CE or UL
+type Certification struct {
+	CertificationID int       `db:"certification_id" json:"certification_id"`
+	Code            string    `db:"code" json:"code" validate:"notblank"`
+	Name            string    `db:"name" json:"name" validate:"notblank"`
+	IssuingBody     *string   `db:"issuing_body" json:"issuing_body,omitempty"`
+	Description     *string   `db:"description" json:"description,omitempty"`
+	CreatedAt       time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// SafetyStandard is a reference entry for a safety standard such as IEC 60204-1
+type SafetyStandard struct {
+	SafetyStandardID int       `db:"safety_standard_id" json:"safety_standard_id"`
+	Code             string    `db:"code" json:"code" validate:"notblank"`
+	Name             string    `db:"name" json:"name" validate:"notblank"`
+	IssuingBody      *string   `db:"issuing_body" json:"issuing_body,omitempty"`
+	Description      *string   `db:"description" json:"description,omitempty"`
+	CreatedAt        time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt        time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// ProductCertification links a product to a certification with its certificate details
+type ProductCertification struct {
+	ProductCertificationID int        `db:"product_certification_id" json:"product_certification_id"`
+	ProductID              int        `db:"product_id" json:"product_id"`
+	CertificationID        int        `db:"certification_id" json:"certification_id"`
+	CertificateNumber      *string    `db:"certificate_number" json:"certificate_number,omitempty"`
+	IssuedOn               *time.Time `db:"issued_on" json:"issued_on,omitempty"`
+	ExpiresOn              *time.Time `db:"expires_on" json:"expires_on,omitempty"`
+	CreatedAt              time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt              time.Time  `db:"updated_at" json:"updated_at"`
+	Code                   string     `db:"code" json:"code"`
+	Name                   string     `db:"name" json:"name"`
+}
+
+// ProductSafetyStandard links a product to a safety standard it complies with
+type ProductSafetyStandard struct {
+	ProductSafetyStandardID int       `db:"product_safety_standard_id" json:"product_safety_standard_id"`
+	ProductID               int       `db:"product_id" json:"product_id"`
+	SafetyStandardID        int       `db:"safety_standard_id" json:"safety_standard_id"`
+	CreatedAt               time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt               time.Time `db:"updated_at" json:"updated_at"`
+	Code                    string    `db:"code" json:"code"`
+	Name                    string    `db:"name" json:"name"`
+}
+
+// ExpiringCertification is a row of the expiring certifications compliance report
+type ExpiringCertification struct {
+	ProductCertificationID int       `db:"product_certification_id" json:"product_certification_id"`
+	ProductID              int       `db:"product_id" json:"product_id"`
+	ProductName            string    `db:"product_name" json:"product_name"`
+	Model                  *string   `db:"model" json:"model,omitempty"`
+	CertificationID        int       `db:"certification_id" json:"certification_id"`
+	Code                   string    `db:"code" json:"code"`
+	Name                   string    `db:"name" json:"name"`
+	CertificateNumber      *string   `db:"certificate_number" json:"certificate_number,omitempty"`
+	ExpiresOn              time.Time `db:"expires_on" json:"expires_on"`
+	DaysRemaining          int       `db:"days_remaining" json:"days_remaining"`
+	Expired                bool      `db:"expired" json:"expired"`
+}
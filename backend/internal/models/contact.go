@@ -6,13 +6,14 @@ import (
 
 // Contact represents an individual contact of a customer
 type Contact struct {
-	ContactID  int       `db:"contact_id" json:"contact_id"`
-	CustomerID int       `db:"customer_id" json:"customer_id"`
-	FirstName  string    `db:"first_name" json:"first_name"`
-	LastName   string    `db:"last_name" json:"last_name"`
-	Position   *string   `db:"position" json:"position,omitempty"`
-	Phone      *string   `db:"phone" json:"phone,omitempty"`
-	Email      *string   `db:"email" json:"email,omitempty"`
-	CreatedAt  time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
+	ContactID  int        `db:"contact_id" json:"contact_id"`
+	CustomerID int        `db:"customer_id" json:"customer_id"`
+	FirstName  string     `db:"first_name" json:"first_name" validate:"required"`
+	LastName   string     `db:"last_name" json:"last_name" validate:"required"`
+	Position   *string    `db:"position" json:"position,omitempty"`
+	Phone      *string    `db:"phone" json:"phone,omitempty"`
+	Email      *string    `db:"email" json:"email,omitempty"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time  `db:"updated_at" json:"updated_at"`
+	DeletedAt  *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
 }
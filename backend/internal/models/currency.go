@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+// BaseCurrency is the currency the books are kept in. Exchange rates are in pesos per unit
+// of a currency, and reports total documents in pesos.
+const BaseCurrency = "PHP"
+
+// Currency is a currency products can be priced and documents written in. Amounts in it are
+// shown with Symbol and rounded to Decimals places.
+type Currency struct {
+	Code      string    `db:"code" json:"code"`
+	Name      string    `db:"name" json:"name"`
+	Symbol    string    `db:"symbol" json:"symbol"`
+	Decimals  int       `db:"decimals" json:"decimals"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// ExchangeRate is what one unit of a currency is worth in pesos from EffectiveDate until the
+// next rate recorded for it
+type ExchangeRate struct {
+	ExchangeRateID int       `db:"exchange_rate_id" json:"exchange_rate_id"`
+	CurrencyCode   string    `db:"currency_code" json:"currency_code"`
+	Rate           float64   `db:"rate" json:"rate" validate:"gt=0"`
+	EffectiveDate  time.Time `db:"effective_date" json:"effective_date"`
+	CreatedBy      *int      `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+}
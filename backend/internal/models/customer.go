@@ -1,18 +1,89 @@
 package models
 
 import (
+	"strings"
 	"time"
 )
 
-// Customer represents a client company
+// VAT classifications of a customer's sales
+const (
+	VATVatable   = "vatable"
+	VATExempt    = "exempt"
+	VATZeroRated = "zero_rated"
+)
+
+// Customer represents a client company. VATClassification decides how its sales appear in
+// the sales book, and defaults to vatable. PaymentTermsDays is how many days after an invoice
+// is issued it falls due, 0 for payment on receipt. Version counts the edits made to it so that an
+// update from an outdated copy can be refused.
 type Customer struct {
-	CustomerID  int       `db:"customer_id" json:"customer_id"`
-	CompanyName string    `db:"company_name" json:"company_name"`
-	Industry    *string   `db:"industry" json:"industry,omitempty"`
-	Address     *string   `db:"address" json:"address,omitempty"`
-	Phone       *string   `db:"phone" json:"phone,omitempty"`
-	Email       *string   `db:"email" json:"email,omitempty"`
-	Website     *string   `db:"website" json:"website,omitempty"`
-	CreatedAt   time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+	CustomerID        int        `db:"customer_id" json:"customer_id"`
+	CompanyName       string     `db:"company_name" json:"company_name" validate:"required"`
+	Industry          *string    `db:"industry" json:"industry,omitempty"`
+	Address           *string    `db:"address" json:"address,omitempty"`
+	Phone             *string    `db:"phone" json:"phone,omitempty"`
+	Email             *string    `db:"email" json:"email,omitempty"`
+	Website           *string    `db:"website" json:"website,omitempty"`
+	TIN               *string    `db:"tin" json:"tin,omitempty"`
+	VATClassification string     `db:"vat_classification" json:"vat_classification" validate:"omitempty,oneof=vatable exempt zero_rated"`
+	PaymentTermsDays  *int       `db:"payment_terms_days" json:"payment_terms_days" validate:"omitempty,gte=0,lte=365"`
+	CreatedAt         time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time  `db:"updated_at" json:"updated_at"`
+	ArchivedAt        *time.Time `db:"archived_at" json:"archived_at,omitempty"`
+	DeletedAt         *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+	Tier              string     `db:"tier" json:"tier"`
+	TrailingRevenue   float64    `db:"trailing_revenue" json:"trailing_revenue"`
+	TierUpdatedAt     *time.Time `db:"tier_updated_at" json:"tier_updated_at,omitempty"`
+	Street            *string    `db:"street" json:"street,omitempty"`
+	City              *string    `db:"city" json:"city,omitempty"`
+	Province          *string    `db:"province" json:"province,omitempty"`
+	PostalCode        *string    `db:"postal_code" json:"postal_code,omitempty"`
+	Latitude          *float64   `db:"latitude" json:"latitude,omitempty"`
+	Longitude         *float64   `db:"longitude" json:"longitude,omitempty"`
+	GeocodedAt        *time.Time `db:"geocoded_at" json:"geocoded_at,omitempty"`
+	Version           int        `db:"version" json:"version"`
+}
+
+// HasStructuredAddress reports whether any of the structured address fields are set
+func (c *Customer) HasStructuredAddress() bool {
+	for _, part := range []*string{c.Street, c.City, c.Province, c.PostalCode} {
+		if part != nil && strings.TrimSpace(*part) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// FormattedAddress joins the structured address fields into a single line,
+// e.g. "12 Rizal St, Makati, Metro Manila 1200"
+func (c *Customer) FormattedAddress() string {
+	parts := []string{}
+	for _, part := range []*string{c.Street, c.City} {
+		if part != nil && strings.TrimSpace(*part) != "" {
+			parts = append(parts, strings.TrimSpace(*part))
+		}
+	}
+
+	region := []string{}
+	for _, part := range []*string{c.Province, c.PostalCode} {
+		if part != nil && strings.TrimSpace(*part) != "" {
+			region = append(region, strings.TrimSpace(*part))
+		}
+	}
+	if len(region) > 0 {
+		parts = append(parts, strings.Join(region, " "))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// CustomerLocation is a geocoded customer position for map and delivery route views
+type CustomerLocation struct {
+	CustomerID  int     `db:"customer_id" json:"customer_id"`
+	CompanyName string  `db:"company_name" json:"company_name"`
+	Address     *string `db:"address" json:"address,omitempty"`
+	City        *string `db:"city" json:"city,omitempty"`
+	Province    *string `db:"province" json:"province,omitempty"`
+	Latitude    float64 `db:"latitude" json:"latitude"`
+	Longitude   float64 `db:"longitude" json:"longitude"`
 }
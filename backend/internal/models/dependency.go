@@ -0,0 +1,34 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Dependency lists records of one kind that reference an entity.
+// Blocking dependencies prevent the entity from being deleted.
+type Dependency struct {
+	Entity   string `json:"entity"`
+	IDs      []int  `json:"ids"`
+	Reason   string `json:"reason"`
+	Blocking bool   `json:"blocking"`
+}
+
+// BulkItemResult reports what happened to a single record in a bulk operation
+type BulkItemResult struct {
+	ID           int          `json:"id"`
+	Status       string       `json:"status"`
+	Reasons      []string     `json:"reasons,omitempty"`
+	Dependencies []Dependency `json:"dependencies,omitempty"`
+}
+
+// DeletedRecord is a row removed by a bulk delete, kept so it can be restored
+type DeletedRecord struct {
+	DeletedRecordID int             `db:"deleted_record_id" json:"deleted_record_id"`
+	Entity          string          `db:"entity" json:"entity"`
+	EntityID        int             `db:"entity_id" json:"entity_id"`
+	ParentEntity    *string         `db:"parent_entity" json:"parent_entity,omitempty"`
+	ParentID        *int            `db:"parent_id" json:"parent_id,omitempty"`
+	Data            json.RawMessage `db:"data" json:"data"`
+	DeletedAt       time.Time       `db:"deleted_at" json:"deleted_at"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// Device is a registered API client such as a warehouse tablet or barcode scanner
+type Device struct {
+	DeviceID     int        `db:"device_id" json:"device_id"`
+	Name         string     `db:"name" json:"name"`
+	DeviceType   string     `db:"device_type" json:"device_type"`
+	TokenHash    string     `db:"token_hash" json:"-"`
+	TokenPrefix  string     `db:"token_prefix" json:"token_prefix"`
+	RegisteredBy *int       `db:"registered_by" json:"registered_by,omitempty"`
+	LastSeenAt   *time.Time `db:"last_seen_at" json:"last_seen_at,omitempty"`
+	LastSeenIP   *string    `db:"last_seen_ip" json:"last_seen_ip,omitempty"`
+	RevokedAt    *time.Time `db:"revoked_at" json:"revoked_at,omitempty"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+// How a quotation or order line's discount was set. Percent and amount discounts are entered
+// on the line; lines entered without one get the better of their product's quantity break
+// and the customer's tier discount.
+const (
+	DiscountNone          = "none"
+	DiscountPercent       = "percent"
+	DiscountAmount        = "amount"
+	DiscountTier          = "tier"
+	DiscountQuantityBreak = "quantity_break"
+)
+
+// QuantityBreak discounts a product's lines by DiscountPercent from MinQuantity units
+type QuantityBreak struct {
+	QuantityBreakID int       `db:"quantity_break_id" json:"quantity_break_id"`
+	ProductID       int       `db:"product_id" json:"product_id"`
+	MinQuantity     int       `db:"min_quantity" json:"min_quantity" validate:"gt=1"`
+	DiscountPercent float64   `db:"discount_percent" json:"discount_percent" validate:"gt=0,lte=100"`
+	CreatedAt       time.Time `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+)
+
+// Vehicle is a delivery vehicle and its load limits
+type Vehicle struct {
+	VehicleID   int       `db:"vehicle_id" json:"vehicle_id"`
+	PlateNumber string    `db:"plate_number" json:"plate_number" validate:"notblank"`
+	Description string    `db:"description" json:"description"`
+	CapacityKg  float64   `db:"capacity_kg" json:"capacity_kg" validate:"gt=0"`
+	CapacityM3  *float64  `db:"capacity_m3" json:"capacity_m3,omitempty" validate:"omitnil,gt=0"`
+	Active      bool      `db:"active" json:"active"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// Driver is a delivery driver
+type Driver struct {
+	DriverID      int       `db:"driver_id" json:"driver_id"`
+	Name          string    `db:"name" json:"name" validate:"notblank"`
+	Phone         string    `db:"phone" json:"phone"`
+	LicenseNumber string    `db:"license_number" json:"license_number"`
+	UserID        *int      `db:"user_id" json:"user_id,omitempty"`
+	Active        bool      `db:"active" json:"active"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// DeliveryAssignment schedules an order for delivery by a vehicle and driver on a day
+type DeliveryAssignment struct {
+	AssignmentID int       `db:"assignment_id" json:"assignment_id"`
+	OrderID      int       `db:"order_id" json:"order_id"`
+	DeliveryDate time.Time `db:"delivery_date" json:"delivery_date"`
+	VehicleID    int       `db:"vehicle_id" json:"vehicle_id"`
+	DriverID     int       `db:"driver_id" json:"driver_id"`
+	StopSequence int       `db:"stop_sequence" json:"stop_sequence"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// ManifestStop is one delivery on a driver's manifest
+type ManifestStop struct {
+	AssignmentID    int     `db:"assignment_id" json:"assignment_id"`
+	StopSequence    int     `db:"stop_sequence" json:"stop_sequence"`
+	OrderID         int     `db:"order_id" json:"order_id"`
+	CustomerID      int     `db:"customer_id" json:"customer_id"`
+	CompanyName     string  `db:"company_name" json:"company_name"`
+	Phone           *string `db:"phone" json:"phone,omitempty"`
+	ShippingAddress string  `db:"shipping_address" json:"shipping_address"`
+	ItemCount       int     `db:"item_count" json:"item_count"`
+	WeightKg        float64 `db:"weight_kg" json:"weight_kg"`
+	VolumeM3        float64 `db:"volume_m3" json:"volume_m3"`
+	TotalAmount     float64 `db:"total_amount" json:"total_amount"`
+}
+
+// DeliveryManifest lists a driver's deliveries for one day in stop order
+type DeliveryManifest struct {
+	DeliveryDate  string         `json:"delivery_date"`
+	Driver        Driver         `json:"driver"`
+	Vehicle       *Vehicle       `json:"vehicle,omitempty"`
+	Stops         []ManifestStop `json:"stops"`
+	TotalWeightKg float64        `json:"total_weight_kg"`
+	TotalVolumeM3 float64        `json:"total_volume_m3"`
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Document number series
+const (
+	DocumentSeriesSalesInvoice = "sales_invoice"
+	DocumentSeriesQuotation    = "quotation"
+	DocumentSeriesCreditNote   = "credit_note"
+	DocumentSeriesInvoice      = "invoice"
+)
+
+// DocumentSeriesNames lists the document number series
+var DocumentSeriesNames = []string{DocumentSeriesSalesInvoice, DocumentSeriesQuotation, DocumentSeriesCreditNote, DocumentSeriesInvoice}
+
+// Ways a document number is voided
+const (
+	VoidCancelled = "cancelled"
+	VoidDeleted   = "deleted"
+)
+
+// DocumentSeries is a sequence of document numbers; NextNumber is the number the next
+// document will get
+type DocumentSeries struct {
+	Series     string `db:"series" json:"series"`
+	Prefix     string `db:"prefix" json:"prefix"`
+	NextNumber int    `db:"next_number" json:"next_number"`
+}
+
+// Format writes a number of the series the way it is printed, e.g. "SI-000042"
+func (s DocumentSeries) Format(number int) string {
+	return fmt.Sprintf("%s-%06d", s.Prefix, number)
+}
+
+// VoidedDocument is a document number that was issued and then voided, by cancelling or
+// deleting the document
+type VoidedDocument struct {
+	Series     string    `db:"series" json:"series"`
+	DocumentNo int       `db:"document_no" json:"document_no"`
+	Number     string    `db:"-" json:"number"`
+	DocumentID int       `db:"document_id" json:"document_id"`
+	Action     string    `db:"action" json:"action"`
+	Reason     string    `db:"reason" json:"reason"`
+	VoidedBy   *int      `db:"voided_by" json:"voided_by,omitempty"`
+	VoidedAt   time.Time `db:"voided_at" json:"voided_at"`
+}
+
+// NumberGap is a run of numbers in a series that were issued to no document still on file
+// and were not voided
+type NumberGap struct {
+	From  int    `db:"gap_from" json:"from"`
+	To    int    `db:"gap_to" json:"to"`
+	Count int    `db:"count" json:"count"`
+	Range string `db:"-" json:"range"`
+}
+
+// SeriesNumbering reports whether the numbers issued in a series are accounted for: Issued
+// is how many numbers have been used, Documents how many documents hold one, and every other
+// number is either voided or in a gap
+type SeriesNumbering struct {
+	DocumentSeries
+	Issued    int              `json:"issued"`
+	Documents int              `json:"documents"`
+	Gapless   bool             `json:"gapless"`
+	Gaps      []NumberGap      `json:"gaps"`
+	Voided    []VoidedDocument `json:"voided"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// Document preview statuses
+const (
+	PreviewPending     = "Pending"
+	PreviewReady       = "Ready"
+	PreviewUnavailable = "Unavailable"
+)
+
+// DocumentPreview is a small JPEG of an attachment or of the first page of a quotation, order
+// or invoice PDF. Entity is one of DownloadDocumentTypes. Status is empty when no preview has
+// been asked for yet, and Stale is set when the document has changed since the preview was
+// asked for.
+type DocumentPreview struct {
+	Entity      string     `db:"entity" json:"entity"`
+	EntityID    int        `db:"entity_id" json:"entity_id"`
+	Status      string     `db:"status" json:"status"`
+	Content     []byte     `db:"content" json:"-"`
+	Width       int        `db:"width" json:"width"`
+	Height      int        `db:"height" json:"height"`
+	Message     *string    `db:"message" json:"message,omitempty"`
+	RequestedAt *time.Time `db:"requested_at" json:"requested_at,omitempty"`
+	GeneratedAt *time.Time `db:"generated_at" json:"generated_at,omitempty"`
+	Stale       bool       `db:"stale" json:"stale"`
+}
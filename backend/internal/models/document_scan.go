@@ -0,0 +1,96 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Documents that can be scanned
+const (
+	ScanSupplierInvoice = "supplier_invoice"
+	ScanCustomerPO      = "customer_po"
+)
+
+// ScanKinds lists the documents that can be scanned
+var ScanKinds = []string{ScanSupplierInvoice, ScanCustomerPO}
+
+// Document scan statuses. Processing scans are being read; Review ones wait for someone to
+// check the draft, and become Confirmed when it is made into a supplier invoice or order.
+// Failed scans could not be read, and Discarded ones were not wanted.
+const (
+	ScanProcessing = "Processing"
+	ScanReview     = "Review"
+	ScanConfirmed  = "Confirmed"
+	ScanFailed     = "Failed"
+	ScanDiscarded  = "Discarded"
+)
+
+// ScanStatuses lists the statuses document scans can be filtered by
+var ScanStatuses = []string{ScanProcessing, ScanReview, ScanConfirmed, ScanFailed, ScanDiscarded}
+
+// AttachmentScan is the kind of file uploaded for a document scan
+const AttachmentScan = "scan"
+
+// ScanLine is a line item read from a scanned document. ProductID is the product its
+// description was matched to, if any.
+type ScanLine struct {
+	Description string  `json:"description"`
+	ProductID   *int    `json:"product_id,omitempty"`
+	Quantity    int     `json:"quantity" validate:"gte=0"`
+	UnitPrice   float64 `json:"unit_price" validate:"gte=0"`
+}
+
+// ScanLines are the line items of a scanned document, stored as JSONB
+type ScanLines []ScanLine
+
+// Value encodes the lines for storage
+func (l ScanLines) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(l)
+	return string(b), err
+}
+
+// Scan decodes the lines from a JSONB column
+func (l *ScanLines) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, l)
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	case nil:
+		*l = nil
+		return nil
+	}
+	return errors.New("unsupported type for scan lines")
+}
+
+// DocumentScan is a supplier invoice or customer purchase order uploaded for OCR, with the
+// draft read from it. SupplierID and PurchaseOrderID (supplier invoices) or CustomerID
+// (customer POs) are the records the text was matched to. The draft is corrected in review
+// and then confirmed as the supplier invoice or order DocumentID.
+type DocumentScan struct {
+	ScanID          int        `db:"scan_id" json:"scan_id"`
+	Kind            string     `db:"kind" json:"kind"`
+	Status          string     `db:"status" json:"status"`
+	AttachmentID    *int       `db:"attachment_id" json:"attachment_id,omitempty"`
+	Provider        string     `db:"provider" json:"provider"`
+	Text            string     `db:"text" json:"text"`
+	SupplierID      *int       `db:"supplier_id" json:"supplier_id,omitempty"`
+	PurchaseOrderID *int       `db:"purchase_order_id" json:"purchase_order_id,omitempty"`
+	CustomerID      *int       `db:"customer_id" json:"customer_id,omitempty"`
+	DocumentNumber  *string    `db:"document_number" json:"document_number,omitempty"`
+	DocumentDate    *time.Time `db:"document_date" json:"document_date,omitempty"`
+	TotalAmount     *float64   `db:"total_amount" json:"total_amount,omitempty"`
+	Lines           ScanLines  `db:"lines" json:"lines"`
+	Message         *string    `db:"message" json:"message,omitempty"`
+	DocumentID      *int       `db:"document_id" json:"document_id,omitempty"`
+	CreatedBy       *int       `db:"created_by" json:"created_by,omitempty"`
+	ConfirmedBy     *int       `db:"confirmed_by" json:"confirmed_by,omitempty"`
+	ConfirmedAt     *time.Time `db:"confirmed_at" json:"confirmed_at,omitempty"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+// Documents a download link can be made for
+const (
+	DownloadQuotation  = "quotation"
+	DownloadOrder      = "order"
+	DownloadInvoice    = "invoice"
+	DownloadAttachment = "attachment"
+)
+
+// DownloadDocumentTypes lists the documents a download link can be made for
+var DownloadDocumentTypes = []string{DownloadQuotation, DownloadOrder, DownloadInvoice, DownloadAttachment}
+
+// DownloadLink lets someone without a login download a quotation, order or invoice PDF or an
+// attachment until ExpiresAt, e.g. from a link in an email. Only a hash of its token is kept,
+// so the URL is only shown when the link is created.
+type DownloadLink struct {
+	DownloadLinkID int        `db:"download_link_id" json:"download_link_id"`
+	TokenHash      string     `db:"token_hash" json:"-"`
+	DocumentType   string     `db:"document_type" json:"document_type"`
+	DocumentID     int        `db:"document_id" json:"document_id"`
+	SingleUse      bool       `db:"single_use" json:"single_use"`
+	ExpiresAt      time.Time  `db:"expires_at" json:"expires_at"`
+	DownloadCount  int        `db:"download_count" json:"download_count"`
+	LastUsedAt     *time.Time `db:"last_used_at" json:"last_used_at,omitempty"`
+	RevokedAt      *time.Time `db:"revoked_at" json:"revoked_at,omitempty"`
+	CreatedBy      *int       `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+}
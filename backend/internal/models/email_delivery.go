@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// EmailDocumentQuotation is the document type of quotations sent by email
+const EmailDocumentQuotation = "quotation"
+
+// Email delivery statuses
+const (
+	EmailDeliverySent   = "Sent"
+	EmailDeliveryFailed = "Failed"
+)
+
+// EmailDelivery records an email sent about a document and whether the SMTP server
+// accepted it
+type EmailDelivery struct {
+	EmailDeliveryID int       `db:"email_delivery_id" json:"email_delivery_id"`
+	DocumentType    string    `db:"document_type" json:"document_type"`
+	DocumentID      int       `db:"document_id" json:"document_id"`
+	ContactID       *int      `db:"contact_id" json:"contact_id,omitempty"`
+	Recipient       string    `db:"recipient" json:"recipient"`
+	Subject         string    `db:"subject" json:"subject"`
+	Status          string    `db:"status" json:"status"`
+	Error           *string   `db:"error" json:"error,omitempty"`
+	SentBy          *int      `db:"sent_by" json:"sent_by,omitempty"`
+	CreatedAt       time.Time `db:"created_at" json:"created_at"`
+}
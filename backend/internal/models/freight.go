@@ -0,0 +1,55 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// StringList is a list of strings stored as a JSONB array
+type StringList []string
+
+// Value encodes the list for storage
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(l)
+	return string(b), err
+}
+
+// Scan decodes the list from a JSONB column
+func (l *StringList) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, l)
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	case nil:
+		*l = StringList{}
+		return nil
+	}
+	return errors.New("unsupported type for string list")
+}
+
+// DeliveryZone groups the provinces that share freight rates
+type DeliveryZone struct {
+	Zone      string     `db:"zone" json:"zone"`
+	Provinces StringList `db:"provinces" json:"provinces"`
+	IsDefault bool       `db:"is_default" json:"is_default"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// FreightRate is the charge for one weight bracket within a delivery zone
+type FreightRate struct {
+	FreightRateID int       `db:"freight_rate_id" json:"freight_rate_id"`
+	Zone          string    `db:"zone" json:"zone" validate:"notblank"`
+	MinWeightKg   float64   `db:"min_weight_kg" json:"min_weight_kg" validate:"gte=0"`
+	MaxWeightKg   *float64  `db:"max_weight_kg" json:"max_weight_kg,omitempty"`
+	BaseFee       float64   `db:"base_fee" json:"base_fee" validate:"gte=0"`
+	PerKgFee      float64   `db:"per_kg_fee" json:"per_kg_fee" validate:"gte=0"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
+}
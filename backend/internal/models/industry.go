@@ -0,0 +1,13 @@
+package models
+
+import (
+	"time"
+)
+
+// Industry is an entry in the managed list of customer industries
+type Industry struct {
+	IndustryID int       `db:"industry_id" json:"industry_id"`
+	Name       string    `db:"name" json:"name" validate:"notblank"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+)
+
+// IntegrationCredential stores the OAuth token for an external document archive
+type IntegrationCredential struct {
+	Provider     string     `db:"provider" json:"provider"`
+	AccessToken  string     `db:"access_token" json:"-"`
+	RefreshToken string     `db:"refresh_token" json:"-"`
+	TokenType    string     `db:"token_type" json:"token_type"`
+	ExpiresAt    *time.Time `db:"expires_at" json:"expires_at,omitempty"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time  `db:"updated_at" json:"updated_at"`
+}
@@ -7,8 +7,15 @@ import (
 // Inventory tracks stock levels
 type Inventory struct {
 	InventoryID     int        `db:"inventory_id" json:"inventory_id"`
-	ProductID       int        `db:"product_id" json:"product_id"`
-	CurrentStock    int        `db:"current_stock" json:"current_stock"`
-	ReorderLevel    int        `db:"reorder_level" json:"reorder_level"`
+	ProductID       int        `db:"product_id" json:"product_id" validate:"gt=0"`
+	CurrentStock    int        `db:"current_stock" json:"current_stock" validate:"gte=0"`
+	ReorderLevel    int        `db:"reorder_level" json:"reorder_level" validate:"gte=0"`
 	LastRestockDate *time.Time `db:"last_restock_date" json:"last_restock_date,omitempty"`
-} 
\ No newline at end of file
+	UpdatedAt       time.Time  `db:"updated_at" json:"updated_at"`
+} 
+
+// StockChange is an inventory record just after its stock moved, with the stock it held before
+type StockChange struct {
+	Inventory     Inventory
+	PreviousStock int
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+)
+
+// Invoice payment states, worked out from what has been credited and paid against it
+const (
+	InvoiceOpen    = "open"
+	InvoicePaid    = "paid"
+	InvoiceOverdue = "overdue"
+	InvoiceVoid    = "void"
+)
+
+// InvoiceStatuses lists the states an invoice can be in
+var InvoiceStatuses = []string{InvoiceOpen, InvoicePaid, InvoiceOverdue, InvoiceVoid}
+
+// Invoice bills a delivered order. It is numbered (e.g. "INV-000012") when issued, copies the
+// order's lines as billed and falls due PaymentTermsDays after InvoiceDate, the customer's
+// payment terms at the time. The VAT split of its total, its currency and exchange rate are
+// copied from the order. Credited, Paid, Balance and Status follow the order's approved
+// credit notes and payments; an invoice whose order is voided is void.
+type Invoice struct {
+	InvoiceID         int           `db:"invoice_id" json:"invoice_id"`
+	DocumentNo        int           `db:"document_no" json:"document_no"`
+	Number            string        `db:"number" json:"number"`
+	OrderID           int           `db:"order_id" json:"order_id"`
+	CustomerID        int           `db:"customer_id" json:"customer_id"`
+	CompanyName       string        `db:"company_name" json:"company_name"`
+	InvoiceDate       time.Time     `db:"invoice_date" json:"invoice_date"`
+	PaymentTermsDays  int           `db:"payment_terms_days" json:"payment_terms_days"`
+	DueDate           time.Time     `db:"due_date" json:"due_date"`
+	Subtotal          float64       `db:"subtotal" json:"subtotal"`
+	DeliveryFee       float64       `db:"delivery_fee" json:"delivery_fee"`
+	TotalAmount       float64       `db:"total_amount" json:"total_amount"`
+	VATClassification string        `db:"vat_classification" json:"vat_classification"`
+	VATRate           float64       `db:"vat_rate" json:"vat_rate"`
+	NetAmount         float64       `db:"net_amount" json:"net_amount"`
+	VATAmount         float64       `db:"vat_amount" json:"vat_amount"`
+	Currency          string        `db:"currency" json:"currency"`
+	ExchangeRate      float64       `db:"exchange_rate" json:"exchange_rate"`
+	Credited          float64       `db:"credited" json:"credited"`
+	Paid              float64       `db:"paid" json:"paid"`
+	Balance           float64       `db:"balance" json:"balance"`
+	Status            string        `db:"status" json:"status"`
+	CreatedBy         *int          `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt         time.Time     `db:"created_at" json:"created_at"`
+	Items             []InvoiceItem `db:"-" json:"items,omitempty"`
+}
+
+// InvoiceItem is a line of an invoice, copied from the order with the product's description
+type InvoiceItem struct {
+	InvoiceItemID int     `db:"invoice_item_id" json:"invoice_item_id"`
+	InvoiceID     int     `db:"invoice_id" json:"invoice_id"`
+	OrderItemID   *int    `db:"order_item_id" json:"order_item_id,omitempty"`
+	ProductID     int     `db:"product_id" json:"product_id"`
+	Description   string  `db:"description" json:"description"`
+	Quantity      int     `db:"quantity" json:"quantity"`
+	UnitPrice     float64 `db:"unit_price" json:"unit_price"`
+	Discount      float64 `db:"discount" json:"discount"`
+	DiscountType  string  `db:"discount_type" json:"discount_type"`
+	DiscountValue float64 `db:"discount_value" json:"discount_value"`
+	LineTotal     float64 `db:"line_total" json:"line_total"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+)
+
+// Kinds of invoice adjustment
+const (
+	AdjustmentVoid       = "void"
+	AdjustmentCreditNote = "credit_note"
+)
+
+// Approval states of an invoice adjustment
+const (
+	AdjustmentPending  = "pending"
+	AdjustmentApproved = "approved"
+	AdjustmentRejected = "rejected"
+)
+
+// InvoiceAdjustment corrects an issued sales invoice, which is never edited: a void cancels
+// the invoice as a whole and a credit note takes Amount off what the customer owes on it.
+// Either takes effect only once approved; a credit note is numbered (e.g. "CN-000007") then.
+type InvoiceAdjustment struct {
+	AdjustmentID int                     `db:"adjustment_id" json:"adjustment_id"`
+	OrderID      int                     `db:"order_id" json:"order_id"`
+	Kind         string                  `db:"kind" json:"kind"`
+	DocumentNo   *int                    `db:"document_no" json:"document_no,omitempty"`
+	Number       string                  `db:"number" json:"number,omitempty"`
+	Reason       string                  `db:"reason" json:"reason"`
+	Amount       float64                 `db:"amount" json:"amount"`
+	Status       string                  `db:"status" json:"status"`
+	RequestedBy  *int                    `db:"requested_by" json:"requested_by,omitempty"`
+	RequestedAt  time.Time               `db:"requested_at" json:"requested_at"`
+	ApprovedBy   *int                    `db:"approved_by" json:"approved_by,omitempty"`
+	ApprovedAt   *time.Time              `db:"approved_at" json:"approved_at,omitempty"`
+	ApprovalNote string                  `db:"approval_note" json:"approval_note"`
+	Items        []InvoiceAdjustmentItem `db:"-" json:"items"`
+}
+
+// InvoiceAdjustmentItem is an order line a credit note returns or corrects, with the part of
+// the line's total credited for the quantity
+type InvoiceAdjustmentItem struct {
+	AdjustmentID int     `db:"adjustment_id" json:"adjustment_id"`
+	OrderItemID  int     `db:"order_item_id" json:"order_item_id" validate:"required"`
+	ProductID    int     `db:"product_id" json:"product_id"`
+	Quantity     int     `db:"quantity" json:"quantity" validate:"gt=0"`
+	Amount       float64 `db:"amount" json:"amount"`
+}
+
+// CustomerBalance is what a customer owes across their orders: the invoices not voided, less
+// approved credit notes, payments and tax withheld. Payments on voided invoices stay with the
+// customer, so the balance is negative when they are owed money.
+type CustomerBalance struct {
+	CustomerID int     `db:"customer_id" json:"customer_id"`
+	Invoiced   float64 `db:"invoiced" json:"invoiced"`
+	Credited   float64 `db:"credited" json:"credited"`
+	Paid       float64 `db:"paid" json:"paid"`
+	Withheld   float64 `db:"withheld" json:"withheld"`
+	Balance    float64 `db:"balance" json:"balance"`
+}
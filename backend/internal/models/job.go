@@ -0,0 +1,31 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Job statuses. Failed jobs failed on every attempt and are the queue's dead letters.
+const (
+	JobPending   = "Pending"
+	JobSucceeded = "Succeeded"
+	JobFailed    = "Failed"
+)
+
+// JobStatuses lists the statuses jobs can be filtered by
+var JobStatuses = []string{JobPending, JobSucceeded, JobFailed}
+
+// Job is a unit of background work of a registered type. Pending jobs run at RunAt; Error
+// is why the last attempt failed.
+type Job struct {
+	JobID       int             `db:"job_id" json:"job_id"`
+	Type        string          `db:"type" json:"type"`
+	Payload     json.RawMessage `db:"payload" json:"payload"`
+	Status      string          `db:"status" json:"status"`
+	Attempts    int             `db:"attempts" json:"attempts"`
+	MaxAttempts int             `db:"max_attempts" json:"max_attempts"`
+	RunAt       *time.Time      `db:"run_at" json:"run_at,omitempty"`
+	Error       *string         `db:"error" json:"error,omitempty"`
+	CreatedAt   time.Time       `db:"created_at" json:"created_at"`
+	FinishedAt  *time.Time      `db:"finished_at" json:"finished_at,omitempty"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// Loyalty tier names
+const (
+	TierBronze = "Bronze"
+	TierSilver = "Silver"
+	TierGold   = "Gold"
+)
+
+// LoyaltyTier holds the revenue threshold and pricing benefits of a customer tier
+type LoyaltyTier struct {
+	Tier                   string    `db:"tier" json:"tier"`
+	MinRevenue             float64   `db:"min_revenue" json:"min_revenue" validate:"gte=0"`
+	DiscountPercent        float64   `db:"discount_percent" json:"discount_percent" validate:"gte=0,lte=100"`
+	FreeDeliveryThreshold  *float64  `db:"free_delivery_threshold" json:"free_delivery_threshold" validate:"omitnil,gte=0"`
+	FreeDeliveriesPerMonth int       `db:"free_deliveries_per_month" json:"free_deliveries_per_month" validate:"gte=0"`
+	UpdatedAt              time.Time `db:"updated_at" json:"updated_at"`
+}
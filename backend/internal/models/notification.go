@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// Notification types
+const (
+	NotificationLowStock          = "inventory.low_stock"
+	NotificationQuotationApproved = "quotation.approved"
+	NotificationOrderStatus       = "order.status_changed"
+	NotificationWatchedChange     = "watch.changed"
+	NotificationAnomaly           = "anomaly.detected"
+)
+
+// Notification is an in-app notice of a business event for one user. EntityType and
+// EntityID, when set, name the record it is about using the audit entity names.
+type Notification struct {
+	NotificationID int        `db:"notification_id" json:"notification_id"`
+	UserID         int        `db:"user_id" json:"user_id"`
+	Type           string     `db:"type" json:"type"`
+	Title          string     `db:"title" json:"title"`
+	Message        string     `db:"message" json:"message"`
+	EntityType     *string    `db:"entity_type" json:"entity_type,omitempty"`
+	EntityID       *int       `db:"entity_id" json:"entity_id,omitempty"`
+	ReadAt         *time.Time `db:"read_at" json:"read_at,omitempty"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+}
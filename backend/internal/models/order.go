@@ -4,26 +4,64 @@ import (
 	"time"
 )
 
-// Order records sales transactions
+// Order records sales transactions. DocumentNo is its sales invoice number, assigned
+// without gaps when the order is created. AmountPaid is what was tendered at a cash sale;
+// Credited, Paid and Balance follow the order's approved credit notes and recorded payments,
+// tax withheld included, and are set when orders are listed or looked up. The total is
+// VAT-inclusive; NetAmount and VATAmount split it at VATRate under the customer's
+// VATClassification when the order is priced. Amounts are in Currency, one unit of which
+// was worth ExchangeRate pesos when the order was priced. StockIssuedAt is when its items
+// were taken out of stock, as it shipped.
 type Order struct {
-	OrderID         int       `db:"order_id" json:"order_id"`
-	CustomerID      int       `db:"customer_id" json:"customer_id"`
-	QuotationID     *int      `db:"quotation_id" json:"quotation_id,omitempty"`
-	OrderDate       time.Time `db:"order_date" json:"order_date"`
-	ShippingAddress string    `db:"shipping_address" json:"shipping_address"`
-	Status          string    `db:"status" json:"status"`
-	TotalAmount     float64   `db:"total_amount" json:"total_amount"`
-	CreatedAt       time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt       time.Time `db:"updated_at" json:"updated_at"`
+	OrderID            int        `db:"order_id" json:"order_id"`
+	DocumentNo         *int       `db:"document_no" json:"document_no,omitempty"`
+	CustomerID         int        `db:"customer_id" json:"customer_id" validate:"required"`
+	QuotationID        *int       `db:"quotation_id" json:"quotation_id,omitempty"`
+	OrderDate          time.Time  `db:"order_date" json:"order_date"`
+	ShippingAddress    string     `db:"shipping_address" json:"shipping_address"`
+	Status             string     `db:"status" json:"status"`
+	TotalAmount        float64    `db:"total_amount" json:"total_amount"`
+	CreatedAt          time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt          time.Time  `db:"updated_at" json:"updated_at"`
+	DeliveryFee        float64    `db:"delivery_fee" json:"delivery_fee"`
+	FreeDeliveryReason *string    `db:"free_delivery_reason" json:"free_delivery_reason,omitempty"`
+	Source             *string    `db:"source" json:"source,omitempty"`
+	DeliveredAt        *time.Time `db:"delivered_at" json:"delivered_at,omitempty"`
+	StockIssuedAt      *time.Time `db:"stock_issued_at" json:"stock_issued_at,omitempty"`
+	PaymentMethod      *string    `db:"payment_method" json:"payment_method,omitempty"`
+	AmountPaid         *float64   `db:"amount_paid" json:"amount_paid,omitempty"`
+	PaidAt             *time.Time `db:"paid_at" json:"paid_at,omitempty"`
+	ShiftID            *int       `db:"shift_id" json:"shift_id,omitempty"`
+	VATClassification  string     `db:"vat_classification" json:"vat_classification"`
+	VATRate            float64    `db:"vat_rate" json:"vat_rate"`
+	NetAmount          float64    `db:"net_amount" json:"net_amount"`
+	VATAmount          float64    `db:"vat_amount" json:"vat_amount"`
+	Currency           string     `db:"currency" json:"currency"`
+	ExchangeRate       float64    `db:"exchange_rate" json:"exchange_rate"`
+	Credited           *float64   `db:"credited" json:"credited,omitempty"`
+	Paid               *float64   `db:"paid" json:"paid,omitempty"`
+	Balance            *float64   `db:"balance" json:"balance,omitempty"`
 }
 
-// OrderItem lists products within an order
+// OrderItem lists products within an order. Discount is the amount taken off the line;
+// DiscountType says how it was set and DiscountValue is the percent or amount it was set as.
 type OrderItem struct {
-	OrderItemID int     `db:"order_item_id" json:"order_item_id"`
-	OrderID     int     `db:"order_id" json:"order_id"`
-	ProductID   int     `db:"product_id" json:"product_id"`
-	Quantity    int     `db:"quantity" json:"quantity"`
-	UnitPrice   float64 `db:"unit_price" json:"unit_price"`
-	Discount    float64 `db:"discount" json:"discount"`
-	LineTotal   float64 `db:"line_total" json:"line_total"`
+	OrderItemID   int     `db:"order_item_id" json:"order_item_id"`
+	OrderID       int     `db:"order_id" json:"order_id"`
+	ProductID     int     `db:"product_id" json:"product_id" validate:"required"`
+	Quantity      int     `db:"quantity" json:"quantity" validate:"gt=0"`
+	UnitPrice     float64 `db:"unit_price" json:"unit_price" validate:"gte=0"`
+	Discount      float64 `db:"discount" json:"discount" validate:"gte=0"`
+	DiscountType  string  `db:"discount_type" json:"discount_type" validate:"omitempty,oneof=none percent amount tier quantity_break"`
+	DiscountValue float64 `db:"discount_value" json:"discount_value" validate:"gte=0"`
+	LineTotal     float64 `db:"line_total" json:"line_total"`
+}
+
+// ShipmentTotals is the combined weight and volume of an order's items. Products without a
+// weight or without all three dimensions are left out of the totals and listed instead.
+type ShipmentTotals struct {
+	WeightKg                  float64 `db:"weight_kg" json:"weight_kg"`
+	VolumeM3                  float64 `db:"volume_m3" json:"volume_m3"`
+	ProductsWithoutWeight     []int   `db:"-" json:"products_without_weight"`
+	ProductsWithoutDimensions []int   `db:"-" json:"products_without_dimensions"`
 }
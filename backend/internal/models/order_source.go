@@ -0,0 +1,37 @@
+package models
+
+// Sales channels an order or quotation can come in through
+const (
+	SourcePhone    = "phone"
+	SourceWalkIn   = "walk_in"
+	SourceEmail    = "email"
+	SourceWebshop  = "webshop"
+	SourceRepVisit = "rep_visit"
+	SourceWebsite  = "website"
+)
+
+// OrderSource describes a sales channel for selection lists
+type OrderSource struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+}
+
+// OrderSources lists the supported sales channels in display order
+var OrderSources = []OrderSource{
+	{Value: SourcePhone, Label: "Phone"},
+	{Value: SourceWalkIn, Label: "Walk-in"},
+	{Value: SourceEmail, Label: "Email"},
+	{Value: SourceWebshop, Label: "Webshop"},
+	{Value: SourceRepVisit, Label: "Rep visit"},
+	{Value: SourceWebsite, Label: "Website quote request"},
+}
+
+// IsValidOrderSource reports whether a value is one of the supported sales channels
+func IsValidOrderSource(source string) bool {
+	for _, s := range OrderSources {
+		if s.Value == source {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+)
+
+// Codes of the payment methods the catalog is seeded with
+const (
+	PaymentCash         = "cash"
+	PaymentBankTransfer = "bank_transfer"
+	PaymentGCash        = "gcash"
+	PaymentCheck        = "check"
+	PaymentCard         = "card"
+	PaymentEWallet      = "e_wallet"
+)
+
+// PaymentMethod is an entry in the managed list of ways customers pay. Payments made with a
+// method that requires a reference must carry one, e.g. the check number.
+type PaymentMethod struct {
+	Code              string    `db:"code" json:"code" validate:"required,max=32"`
+	Name              string    `db:"name" json:"name" validate:"notblank"`
+	RequiresReference bool      `db:"requires_reference" json:"requires_reference"`
+	Active            bool      `db:"active" json:"active"`
+	SortOrder         int       `db:"sort_order" json:"sort_order"`
+	CreatedAt         time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// Payment is money received against an order. WithholdingAmount is expanded withholding tax
+// the customer kept back under the ATC code given; it settles the order like the amount paid,
+// and the customer owes a BIR Form 2307 for it until Form2307ReceivedOn is set.
+type Payment struct {
+	PaymentID          int        `db:"payment_id" json:"payment_id"`
+	OrderID            int        `db:"order_id" json:"order_id"`
+	PaymentMethod      string     `db:"payment_method" json:"payment_method" validate:"required"`
+	Amount             float64    `db:"amount" json:"amount" validate:"gt=0"`
+	ReferenceNo        *string    `db:"reference_no" json:"reference_no,omitempty"`
+	PaidAt             time.Time  `db:"paid_at" json:"paid_at"`
+	ReceivedBy         *int       `db:"received_by" json:"received_by,omitempty"`
+	Notes              string     `db:"notes" json:"notes"`
+	CreatedAt          time.Time  `db:"created_at" json:"created_at"`
+	WithholdingAmount  float64    `db:"withholding_amount" json:"withholding_amount" validate:"gte=0"`
+	ATCCode            *string    `db:"atc_code" json:"atc_code,omitempty"`
+	Form2307ReceivedOn *time.Time `db:"form_2307_received_on" json:"form_2307_received_on,omitempty"`
+	Form2307Reference  string     `db:"form_2307_reference" json:"form_2307_reference"`
+}
+
+// Outstanding2307 is a customer's payments with tax withheld whose BIR Form 2307 has not been
+// received yet
+type Outstanding2307 struct {
+	CustomerID    int             `json:"customer_id"`
+	CompanyName   string          `json:"company_name"`
+	TIN           *string         `json:"tin,omitempty"`
+	PaymentCount  int             `json:"payment_count"`
+	WithheldTotal float64         `json:"withheld_total"`
+	OldestPaidAt  time.Time       `json:"oldest_paid_at"`
+	Payments      []PaymentRecord `json:"payments"`
+}
+
+// PaymentRecord is a payment with the order and customer it was received from, for
+// reconciling payments against bank statements and deposits
+type PaymentRecord struct {
+	Payment
+	MethodName  string  `db:"method_name" json:"method_name"`
+	CustomerID  int     `db:"customer_id" json:"customer_id"`
+	CompanyName string  `db:"company_name" json:"company_name"`
+	TIN         *string `db:"tin" json:"tin,omitempty"`
+}
+
+// WithholdingSummaryRow totals the tax one customer withheld under one ATC code in a period,
+// as the accountant needs it for the quarterly summary alphalist. Gross is what the payments
+// settled, the amount paid plus the tax withheld; Outstanding is withheld tax whose Form 2307
+// has not been received.
+type WithholdingSummaryRow struct {
+	CustomerID   int     `db:"customer_id" json:"customer_id"`
+	CompanyName  string  `db:"company_name" json:"company_name"`
+	TIN          *string `db:"tin" json:"tin,omitempty"`
+	ATCCode      string  `db:"atc_code" json:"atc_code"`
+	PaymentCount int     `db:"payment_count" json:"payment_count"`
+	Gross        float64 `db:"gross" json:"gross"`
+	Withheld     float64 `db:"withheld" json:"withheld"`
+	Received     float64 `db:"received" json:"received"`
+	Outstanding  float64 `db:"outstanding" json:"outstanding"`
+}
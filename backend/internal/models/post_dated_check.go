@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+)
+
+// Post-dated check statuses. A check is on hand until deposited; a deposited check clears or
+// bounces. Bounced checks can be deposited again, and checks on hand or bounced can be
+// returned to the customer.
+const (
+	CheckOnHand    = "on_hand"
+	CheckDeposited = "deposited"
+	CheckCleared   = "cleared"
+	CheckBounced   = "bounced"
+	CheckReturned  = "returned"
+)
+
+// CheckTransitions lists the statuses a check can move to from each status
+var CheckTransitions = map[string][]string{
+	CheckOnHand:    {CheckDeposited, CheckReturned},
+	CheckDeposited: {CheckCleared, CheckBounced},
+	CheckBounced:   {CheckDeposited, CheckReturned},
+}
+
+// CanTransitionCheck reports whether a check can move from one status to another
+func CanTransitionCheck(from, to string) bool {
+	for _, status := range CheckTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// PostDatedCheck is a customer's check held until its maturity date, optionally paying for an
+// order. PaymentID is the payment recorded on the order when the check cleared.
+type PostDatedCheck struct {
+	CheckID        int        `db:"check_id" json:"check_id"`
+	CustomerID     int        `db:"customer_id" json:"customer_id"`
+	OrderID        *int       `db:"order_id" json:"order_id,omitempty"`
+	CheckNumber    string     `db:"check_number" json:"check_number"`
+	Bank           string     `db:"bank" json:"bank"`
+	Amount         float64    `db:"amount" json:"amount"`
+	MaturityDate   time.Time  `db:"maturity_date" json:"maturity_date"`
+	ReceivedDate   time.Time  `db:"received_date" json:"received_date"`
+	Status         string     `db:"status" json:"status"`
+	DepositedDate  *time.Time `db:"deposited_date" json:"deposited_date,omitempty"`
+	ClearedDate    *time.Time `db:"cleared_date" json:"cleared_date,omitempty"`
+	BouncedDate    *time.Time `db:"bounced_date" json:"bounced_date,omitempty"`
+	BounceReason   string     `db:"bounce_reason" json:"bounce_reason"`
+	PaymentID      *int       `db:"payment_id" json:"payment_id,omitempty"`
+	ReminderSentAt *time.Time `db:"reminder_sent_at" json:"reminder_sent_at,omitempty"`
+	Notes          string     `db:"notes" json:"notes"`
+	CreatedBy      *int       `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at" json:"updated_at"`
+	CompanyName    string     `db:"company_name" json:"company_name"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// PrintJob is a document queued for a networked warehouse printer
+type PrintJob struct {
+	PrintJobID   int        `db:"print_job_id" json:"print_job_id"`
+	PrinterName  string     `db:"printer_name" json:"printer_name"`
+	DocumentType string     `db:"document_type" json:"document_type"`
+	OrderID      int        `db:"order_id" json:"order_id"`
+	Copies       int        `db:"copies" json:"copies"`
+	Status       string     `db:"status" json:"status"`
+	Error        *string    `db:"error" json:"error,omitempty"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	PrintedAt    *time.Time `db:"printed_at" json:"printed_at,omitempty"`
+}
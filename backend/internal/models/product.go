@@ -8,14 +8,46 @@ import (
 // Product maintains equipment details
 type Product struct {
 	ProductID       int             `db:"product_id" json:"product_id"`
-	ProductName     string          `db:"product_name" json:"product_name"`
+	ProductName     string          `db:"product_name" json:"product_name" validate:"required"`
 	Model           *string         `db:"model" json:"model,omitempty"`
+	Category        *string         `db:"category" json:"category,omitempty"`
 	Description     *string         `db:"description" json:"description,omitempty"`
 	TechnicalSpecs  json.RawMessage `db:"technical_specs" json:"technical_specs,omitempty"`
 	Certifications  *string         `db:"certifications" json:"certifications,omitempty"`
 	SafetyStandards *string         `db:"safety_standards" json:"safety_standards,omitempty"`
-	WarrantyPeriod  int             `db:"warranty_period" json:"warranty_period"`
-	Price           float64         `db:"price" json:"price"`
+	WarrantyPeriod  int             `db:"warranty_period" json:"warranty_period" validate:"gte=0"`
+	Price           float64         `db:"price" json:"price" validate:"gte=0"`
+	Restricted      bool            `db:"restricted" json:"restricted"`
 	CreatedAt       time.Time       `db:"created_at" json:"created_at"`
 	UpdatedAt       time.Time       `db:"updated_at" json:"updated_at"`
+	DiscontinuedAt  *time.Time      `db:"discontinued_at" json:"discontinued_at,omitempty"`
+	DeletedAt       *time.Time      `db:"deleted_at" json:"deleted_at,omitempty"`
+	WeightKg        *float64        `db:"weight_kg" json:"weight_kg,omitempty" validate:"omitnil,gt=0"`
+	LengthCm        *float64        `db:"length_cm" json:"length_cm,omitempty" validate:"omitnil,gt=0"`
+	WidthCm         *float64        `db:"width_cm" json:"width_cm,omitempty" validate:"omitnil,gt=0"`
+	HeightCm        *float64        `db:"height_cm" json:"height_cm,omitempty" validate:"omitnil,gt=0"`
+	CostPrice       *float64        `db:"cost_price" json:"cost_price,omitempty"`
+	Currency        string          `db:"currency" json:"currency" validate:"omitempty,len=3"`
+}
+
+// DocumentRef identifies a quotation or order that references a record
+type DocumentRef struct {
+	Type   string    `db:"type" json:"type"`
+	ID     int       `db:"id" json:"id"`
+	Status string    `db:"status" json:"status"`
+	Date   time.Time `db:"date" json:"date"`
+}
+
+// ImportError describes why one field of an imported row was rejected
+type ImportError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ProductImportRow is one spreadsheet row of a product import, numbered as in the file, with
+// the product read from it and anything wrong with it
+type ProductImportRow struct {
+	Row     int           `json:"row"`
+	Product Product       `json:"product"`
+	Errors  []ImportError `json:"errors"`
 }
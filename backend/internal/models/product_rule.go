@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+// Customer product rule kinds
+const (
+	ProductRuleAllow = "allow"
+	ProductRuleBlock = "block"
+)
+
+// CustomerProductRule allows or blocks the sale of a product to a customer
+type CustomerProductRule struct {
+	CustomerProductRuleID int       `db:"customer_product_rule_id" json:"customer_product_rule_id"`
+	CustomerID            int       `db:"customer_id" json:"customer_id"`
+	ProductID             int       `db:"product_id" json:"product_id"`
+	Rule                  string    `db:"rule" json:"rule" validate:"oneof=allow block"`
+	Reason                *string   `db:"reason" json:"reason,omitempty"`
+	CreatedAt             time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt             time.Time `db:"updated_at" json:"updated_at"`
+	ProductName           string    `db:"product_name" json:"product_name"`
+}
+
+// ProductRestriction explains why a product cannot be sold to a customer
+type ProductRestriction struct {
+	ProductID   int    `db:"product_id" json:"product_id"`
+	ProductName string `db:"product_name" json:"product_name"`
+	Reason      string `db:"reason" json:"reason"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// What happens when placing a purchase order would exceed its department's remaining budget
+const (
+	BudgetOverrunEscalate = "escalate"
+	BudgetOverrunReject   = "reject"
+)
+
+// PurchaseBudget caps what a department may spend on purchase orders within a period
+type PurchaseBudget struct {
+	BudgetID    int       `db:"budget_id" json:"budget_id"`
+	Department  string    `db:"department" json:"department"`
+	PeriodStart time.Time `db:"period_start" json:"period_start"`
+	PeriodEnd   time.Time `db:"period_end" json:"period_end"`
+	Amount      float64   `db:"amount" json:"amount"`
+	Notes       string    `db:"notes" json:"notes"`
+	CreatedBy   *int      `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// BudgetUsage is a budget with what its purchase orders have consumed. Consumed counts
+// ordered and received purchase orders including their charges; pending counts orders
+// waiting for approval.
+type BudgetUsage struct {
+	PurchaseBudget
+	Consumed  float64 `db:"consumed" json:"consumed"`
+	Pending   float64 `db:"pending" json:"pending"`
+	Remaining float64 `db:"remaining" json:"remaining"`
+}
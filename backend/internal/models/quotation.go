@@ -1,28 +1,107 @@
 package models
 
 import (
+	"fmt"
 	"time"
 )
 
-// Quotation stores generated quotes
+// Quotation stores generated quotes. DocumentNo is its quotation number, assigned without
+// gaps when it is created. The total is VAT-inclusive; NetAmount and VATAmount split it at
+// VATRate under the customer's VATClassification when the quotation is priced. Amounts are
+// in Currency, one unit of which was worth ExchangeRate pesos when it was priced. Version
+// counts the changes made to it so that an edit from an outdated copy can be refused.
+//
+// Revising a quotation creates another one under the same number: Revision counts from 1 and
+// OriginalQuotationID links revisions to the first quotation of their chain. AcceptedAt marks
+// the one revision of a chain the customer accepted.
+//
+// RequestReceivedAt is when the customer asked for the quotation and SentAt when it was first
+// sent to them; the time between them is measured against the response target.
 type Quotation struct {
-	QuotationID  int       `db:"quotation_id" json:"quotation_id"`
-	CustomerID   int       `db:"customer_id" json:"customer_id"`
-	QuoteDate    time.Time `db:"quote_date" json:"quote_date"`
-	ValidityDate time.Time `db:"validity_date" json:"validity_date"`
-	Status       string    `db:"status" json:"status"`
-	TotalAmount  float64   `db:"total_amount" json:"total_amount"`
-	CreatedAt    time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+	QuotationID       int       `db:"quotation_id" json:"quotation_id"`
+	DocumentNo        *int      `db:"document_no" json:"document_no,omitempty"`
+	CustomerID        int       `db:"customer_id" json:"customer_id" validate:"required"`
+	QuoteDate         time.Time `db:"quote_date" json:"quote_date"`
+	ValidityDate      time.Time `db:"validity_date" json:"validity_date"`
+	Status            string    `db:"status" json:"status"`
+	TotalAmount       float64   `db:"total_amount" json:"total_amount"`
+	CreatedAt         time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time `db:"updated_at" json:"updated_at"`
+	Source            *string   `db:"source" json:"source,omitempty"`
+	Version           int       `db:"version" json:"version"`
+	VATClassification string    `db:"vat_classification" json:"vat_classification"`
+	VATRate           float64   `db:"vat_rate" json:"vat_rate"`
+	NetAmount         float64   `db:"net_amount" json:"net_amount"`
+	VATAmount         float64   `db:"vat_amount" json:"vat_amount"`
+	Currency          string    `db:"currency" json:"currency"`
+	ExchangeRate      float64   `db:"exchange_rate" json:"exchange_rate"`
+
+	OriginalQuotationID *int       `db:"original_quotation_id" json:"original_quotation_id,omitempty"`
+	Revision            int        `db:"revision" json:"revision"`
+	AcceptedAt          *time.Time `db:"accepted_at" json:"accepted_at,omitempty"`
+
+	RequestReceivedAt time.Time  `db:"request_received_at" json:"request_received_at"`
+	SentAt            *time.Time `db:"sent_at" json:"sent_at,omitempty"`
+}
+
+// QuotationStatusRevised is the status of a quotation that has been replaced by a revision
+const QuotationStatusRevised = "Revised"
+
+// ChainID returns the ID of the first quotation of the revision chain q belongs to
+func (q Quotation) ChainID() int {
+	if q.OriginalQuotationID != nil {
+		return *q.OriginalQuotationID
+	}
+	return q.QuotationID
+}
+
+// Reference returns the quotation's number as quoted to customers, such as Q-123, with the
+// revision for revised quotations, such as Q-123-R2
+func (q Quotation) Reference() string {
+	number := q.QuotationID
+	if q.DocumentNo != nil {
+		number = *q.DocumentNo
+	}
+	if q.Revision > 1 {
+		return fmt.Sprintf("Q-%d-R%d", number, q.Revision)
+	}
+	return fmt.Sprintf("Q-%d", number)
 }
 
-// QuotationItem details each line in a quotation
+// Promise sources: where the stock for a quotation line's promise date comes from. Lines
+// neither in stock, on order nor sold by an active supplier are unavailable and have no date.
+const (
+	PromiseFromStock    = "stock"
+	PromiseFromIncoming = "incoming"
+	PromiseFromLeadTime = "lead_time"
+	PromiseUnavailable  = "unavailable"
+)
+
+// QuotationItem details each line in a quotation. Discount is the amount taken off the line;
+// DiscountType says how it was set and DiscountValue is the percent or amount it was set as.
+// PromiseDate is the day the line can be delivered by as of PromisedAt, worked out from
+// PromiseSource.
 type QuotationItem struct {
 	QuotationItemID int     `db:"quotation_item_id" json:"quotation_item_id"`
 	QuotationID     int     `db:"quotation_id" json:"quotation_id"`
-	ProductID       int     `db:"product_id" json:"product_id"`
-	Quantity        int     `db:"quantity" json:"quantity"`
-	UnitPrice       float64 `db:"unit_price" json:"unit_price"`
-	Discount        float64 `db:"discount" json:"discount"`
+	ProductID       int     `db:"product_id" json:"product_id" validate:"required"`
+	Quantity        int     `db:"quantity" json:"quantity" validate:"gt=0"`
+	UnitPrice       float64 `db:"unit_price" json:"unit_price" validate:"gte=0"`
+	Discount        float64 `db:"discount" json:"discount" validate:"gte=0"`
+	DiscountType    string  `db:"discount_type" json:"discount_type" validate:"omitempty,oneof=none percent amount tier quantity_break"`
+	DiscountValue   float64 `db:"discount_value" json:"discount_value" validate:"gte=0"`
 	LineTotal       float64 `db:"line_total" json:"line_total"`
+
+	PromiseDate   *time.Time `db:"promise_date" json:"promise_date,omitempty"`
+	PromiseSource *string    `db:"promise_source" json:"promise_source,omitempty"`
+	PromisedAt    *time.Time `db:"promised_at" json:"promised_at,omitempty"`
+}
+
+// IncomingStock is a quantity of a product on a purchase order that has been placed but not
+// received, expected on ExpectedDate
+type IncomingStock struct {
+	ProductID       int       `db:"product_id"`
+	PurchaseOrderID int       `db:"purchase_order_id"`
+	Quantity        int       `db:"quantity"`
+	ExpectedDate    time.Time `db:"expected_date"`
 }
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+)
+
+// QuotationBuilderSession is a quotation being built a step at a time: its header and the
+// lines added so far. Each change extends ExpiresAt; a session left alone past it is
+// abandoned and cleaned up.
+type QuotationBuilderSession struct {
+	BuilderSessionID int                    `db:"builder_session_id" json:"builder_session_id"`
+	CustomerID       int                    `db:"customer_id" json:"customer_id"`
+	Currency         string                 `db:"currency" json:"currency"`
+	Source           *string                `db:"source" json:"source,omitempty"`
+	ValidityDate     *time.Time             `db:"validity_date" json:"validity_date,omitempty"`
+	CreatedBy        *int                   `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt        time.Time              `db:"created_at" json:"created_at"`
+	UpdatedAt        time.Time              `db:"updated_at" json:"updated_at"`
+	ExpiresAt        time.Time              `db:"expires_at" json:"expires_at"`
+	Lines            []QuotationBuilderLine `db:"-" json:"lines"`
+}
+
+// QuotationBuilderLine is a line of a quotation being built. A line without a unit price is
+// priced at the product's list price.
+type QuotationBuilderLine struct {
+	BuilderLineID    int       `db:"builder_line_id" json:"builder_line_id"`
+	BuilderSessionID int       `db:"builder_session_id" json:"builder_session_id"`
+	ProductID        int       `db:"product_id" json:"product_id"`
+	Quantity         int       `db:"quantity" json:"quantity"`
+	UnitPrice        *float64  `db:"unit_price" json:"unit_price,omitempty"`
+	Discount         float64   `db:"discount" json:"discount"`
+	DiscountType     string    `db:"discount_type" json:"discount_type"`
+	DiscountValue    float64   `db:"discount_value" json:"discount_value"`
+	CreatedAt        time.Time `db:"created_at" json:"created_at"`
+}
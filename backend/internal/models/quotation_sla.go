@@ -0,0 +1,105 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// How a quotation is doing against the response target. Unsent quotations are on track, at
+// risk once their response is nearly due, and breached once it is overdue; sent ones met the
+// target or missed it.
+const (
+	SLAOnTrack  = "on_track"
+	SLAAtRisk   = "at_risk"
+	SLABreached = "breached"
+	SLAMet      = "met"
+	SLAMissed   = "missed"
+)
+
+// SLAOpenStatuses lists the states of quotations that have not been sent yet
+var SLAOpenStatuses = []string{SLAOnTrack, SLAAtRisk, SLABreached}
+
+// QuotationSLA is a quotation's turnaround measured against the response target. DueAt is
+// when it should be sent by; TurnaroundHours is how long it took once sent, HoursLeft how
+// long remains while unsent and OverdueHours how late it was sent or is.
+type QuotationSLA struct {
+	QuotationID       int        `json:"quotation_id"`
+	Reference         string     `json:"reference"`
+	CustomerID        int        `json:"customer_id"`
+	CompanyName       string     `json:"company_name"`
+	Status            string     `json:"status"`
+	TotalAmount       float64    `json:"total_amount"`
+	RequestReceivedAt time.Time  `json:"request_received_at"`
+	SentAt            *time.Time `json:"sent_at,omitempty"`
+	DueAt             time.Time  `json:"due_at"`
+	SLAStatus         string     `json:"sla_status"`
+	TurnaroundHours   *float64   `json:"turnaround_hours,omitempty"`
+	HoursLeft         *float64   `json:"hours_left,omitempty"`
+	OverdueHours      float64    `json:"overdue_hours"`
+}
+
+// QuotationSLAReport sums up how quotations whose requests were received in a period did
+// against the response target. Unsent quotations that were closed without being sent are
+// left out. OnTimePct is the share of the quotations sent or overdue that were sent in
+// time; it and AverageTurnaroundHours are omitted when there is nothing to average.
+type QuotationSLAReport struct {
+	Days                   int            `json:"days"`
+	TargetHours            float64        `json:"target_hours"`
+	Received               int            `json:"received"`
+	Sent                   int            `json:"sent"`
+	Met                    int            `json:"met"`
+	Missed                 int            `json:"missed"`
+	Open                   int            `json:"open"`
+	Breached               int            `json:"breached"`
+	OnTimePct              *float64       `json:"on_time_pct,omitempty"`
+	AverageTurnaroundHours *float64       `json:"average_turnaround_hours,omitempty"`
+	Breaches               []QuotationSLA `json:"breaches"`
+}
+
+// SLA measures the quotation against a response target at now. Unsent quotations are at risk
+// once no more than warning remains before they are due.
+func (q Quotation) SLA(companyName string, target, warning time.Duration, now time.Time) QuotationSLA {
+	sla := QuotationSLA{
+		QuotationID:       q.QuotationID,
+		Reference:         q.Reference(),
+		CustomerID:        q.CustomerID,
+		CompanyName:       companyName,
+		Status:            q.Status,
+		TotalAmount:       q.TotalAmount,
+		RequestReceivedAt: q.RequestReceivedAt,
+		SentAt:            q.SentAt,
+		DueAt:             q.RequestReceivedAt.Add(target),
+	}
+
+	if q.SentAt != nil {
+		turnaround := slaHours(q.SentAt.Sub(q.RequestReceivedAt))
+		sla.TurnaroundHours = &turnaround
+		sla.SLAStatus = SLAMet
+		if q.SentAt.After(sla.DueAt) {
+			sla.SLAStatus = SLAMissed
+			sla.OverdueHours = slaHours(q.SentAt.Sub(sla.DueAt))
+		}
+		return sla
+	}
+
+	left := sla.DueAt.Sub(now)
+	switch {
+	case left < 0:
+		sla.SLAStatus = SLABreached
+		sla.OverdueHours = slaHours(-left)
+	case left <= warning:
+		sla.SLAStatus = SLAAtRisk
+	default:
+		sla.SLAStatus = SLAOnTrack
+	}
+	if left >= 0 {
+		hours := slaHours(left)
+		sla.HoursLeft = &hours
+	}
+	return sla
+}
+
+// slaHours expresses a duration in hours rounded to two decimals
+func slaHours(d time.Duration) float64 {
+	return math.Round(d.Hours()*100) / 100
+}
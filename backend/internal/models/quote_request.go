@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+)
+
+// Quote request statuses. New requests are waiting for sales; Quoted ones have had a
+// quotation prepared and Dismissed ones were spam or not followed up.
+const (
+	QuoteRequestNew       = "New"
+	QuoteRequestQuoted    = "Quoted"
+	QuoteRequestDismissed = "Dismissed"
+)
+
+// QuoteRequestStatuses lists the statuses quote requests can be filtered by
+var QuoteRequestStatuses = []string{QuoteRequestNew, QuoteRequestQuoted, QuoteRequestDismissed}
+
+// AttachmentDocument is the kind of files sent with a quote request
+const AttachmentDocument = "document"
+
+// NotificationQuoteRequest notifies users of a quote request from the public form
+const NotificationQuoteRequest = "quote_request.received"
+
+// QuoteRequest is a request for a quotation sent from the public "Request a Quote" form, kept
+// as a sales lead until a quotation is prepared for it. CustomerID is the existing customer
+// whose email it came from, if any; QuotationID the quotation prepared for it.
+type QuoteRequest struct {
+	QuoteRequestID int          `db:"quote_request_id" json:"quote_request_id"`
+	CompanyName    string       `db:"company_name" json:"company_name"`
+	ContactName    string       `db:"contact_name" json:"contact_name"`
+	Email          string       `db:"email" json:"email"`
+	Phone          *string      `db:"phone" json:"phone,omitempty"`
+	Message        string       `db:"message" json:"message"`
+	Status         string       `db:"status" json:"status"`
+	CustomerID     *int         `db:"customer_id" json:"customer_id,omitempty"`
+	QuotationID    *int         `db:"quotation_id" json:"quotation_id,omitempty"`
+	SourceIP       *string      `db:"source_ip" json:"source_ip,omitempty"`
+	HandledBy      *int         `db:"handled_by" json:"handled_by,omitempty"`
+	HandledAt      *time.Time   `db:"handled_at" json:"handled_at,omitempty"`
+	CreatedAt      time.Time    `db:"created_at" json:"created_at"`
+	Attachments    []Attachment `db:"-" json:"attachments,omitempty"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+)
+
+// Receiving checklist input types
+const (
+	ChecklistPassFail = "pass_fail"
+	ChecklistNumber   = "number"
+	ChecklistText     = "text"
+)
+
+// ChecklistInputTypes lists the supported checklist input types
+var ChecklistInputTypes = []string{ChecklistPassFail, ChecklistNumber, ChecklistText}
+
+// ReceivingChecklistItem is an inspection step performed when stock is received.
+// A nil Category applies the item to every product.
+type ReceivingChecklistItem struct {
+	ItemID    int       `db:"item_id" json:"item_id"`
+	Label     string    `db:"label" json:"label" validate:"notblank"`
+	Category  *string   `db:"category" json:"category,omitempty"`
+	InputType string    `db:"input_type" json:"input_type" validate:"omitempty,oneof=pass_fail number text"`
+	MinValue  *float64  `db:"min_value" json:"min_value,omitempty"`
+	MaxValue  *float64  `db:"max_value" json:"max_value,omitempty"`
+	Unit      string    `db:"unit" json:"unit"`
+	Required  bool      `db:"required" json:"required"`
+	Active    bool      `db:"active" json:"active"`
+	SortOrder int       `db:"sort_order" json:"sort_order"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// StockReceipt records stock received into inventory and the outcome of its inspection
+type StockReceipt struct {
+	ReceiptID      int                 `db:"receipt_id" json:"receipt_id"`
+	InventoryID    int                 `db:"inventory_id" json:"inventory_id"`
+	ProductID      int                 `db:"product_id" json:"product_id"`
+	Quantity       int                 `db:"quantity" json:"quantity"`
+	Reference      string              `db:"reference" json:"reference"`
+	TemperatureC   *float64            `db:"temperature_c" json:"temperature_c,omitempty"`
+	ConditionNotes string              `db:"condition_notes" json:"condition_notes"`
+	Passed         bool                `db:"passed" json:"passed"`
+	ReceivedBy     *int                `db:"received_by" json:"received_by,omitempty"`
+	ReceivedAt     time.Time           `db:"received_at" json:"received_at"`
+	Checks         []StockReceiptCheck `db:"-" json:"checks"`
+}
+
+// StockReceiptCheck is one checklist answer recorded with a receipt
+type StockReceiptCheck struct {
+	CheckID   int      `db:"check_id" json:"check_id"`
+	ReceiptID int      `db:"receipt_id" json:"receipt_id"`
+	ItemID    *int     `db:"item_id" json:"item_id,omitempty"`
+	Label     string   `db:"label" json:"label"`
+	InputType string   `db:"input_type" json:"input_type"`
+	MinValue  *float64 `db:"min_value" json:"min_value,omitempty"`
+	MaxValue  *float64 `db:"max_value" json:"max_value,omitempty"`
+	Unit      string   `db:"unit" json:"unit"`
+	Value     string   `db:"value" json:"value"`
+	Passed    bool     `db:"passed" json:"passed"`
+}
+
+// ReceivingReportRow is a receipt with its product for QA reporting
+type ReceivingReportRow struct {
+	StockReceipt
+	ProductName string  `db:"product_name" json:"product_name"`
+	Category    *string `db:"category" json:"category,omitempty"`
+}
@@ -0,0 +1,33 @@
+package models
+
+// OrderStatuses lists the statuses an order can have
+var OrderStatuses = []string{"Pending", "Shipped", "Delivered", "Cancelled"}
+
+// QuotationStatuses lists the statuses a quotation can have
+var QuotationStatuses = []string{"Pending", "Approved", "Rejected", "Expired", QuotationStatusRevised}
+
+// DiscountTypes lists the discounts that can be entered on a quotation or order line
+var DiscountTypes = []string{DiscountPercent, DiscountAmount}
+
+// ReferenceOption is a coded reference entry for selection lists
+type ReferenceOption struct {
+	ID   int    `json:"id" db:"id"`
+	Code string `json:"code" db:"code"`
+	Name string `json:"name" db:"name"`
+}
+
+// ReferenceData bundles the enumerations and reference lists used by the frontend's dropdowns
+type ReferenceData struct {
+	OrderStatuses     []string          `json:"order_statuses"`
+	QuotationStatuses []string          `json:"quotation_statuses"`
+	DiscountTypes     []string          `json:"discount_types"`
+	OrderSources      []OrderSource     `json:"order_sources"`
+	Roles             []string          `json:"roles"`
+	Industries        []string          `json:"industries"`
+	Categories        []string          `json:"categories"`
+	Units             []string          `json:"units"`
+	LoyaltyTiers      []string          `json:"loyalty_tiers"`
+	Currencies        []string          `json:"currencies"`
+	Certifications    []ReferenceOption `json:"certifications"`
+	SafetyStandards   []ReferenceOption `json:"safety_standards"`
+}
@@ -27,6 +27,32 @@ type TopCustomer struct {
 	ContactName string  `json:"contact_name,omitempty" db:"contact_name"`
 }
 
+// SalesByChannel represents order totals for one sales channel; Share is the percentage of total sales
+type SalesByChannel struct {
+	Source      string  `json:"source" db:"source"`
+	OrderCount  int     `json:"order_count" db:"order_count"`
+	TotalAmount float64 `json:"total_amount" db:"total_amount"`
+	Share       float64 `json:"share" db:"share"`
+}
+
+// SalesByPaymentMethod represents the payments received with one payment method; Share is the percentage of all payments
+type SalesByPaymentMethod struct {
+	PaymentMethod string  `json:"payment_method" db:"payment_method"`
+	Name          string  `json:"name" db:"name"`
+	PaymentCount  int     `json:"payment_count" db:"payment_count"`
+	TotalAmount   float64 `json:"total_amount" db:"total_amount"`
+	Share         float64 `json:"share" db:"share"`
+}
+
+// IndustryRevenue represents order totals for the customers in one industry; Share is the percentage of total sales
+type IndustryRevenue struct {
+	Industry      string  `json:"industry" db:"industry"`
+	CustomerCount int     `json:"customer_count" db:"customer_count"`
+	OrderCount    int     `json:"order_count" db:"order_count"`
+	TotalAmount   float64 `json:"total_amount" db:"total_amount"`
+	Share         float64 `json:"share" db:"share"`
+}
+
 // DashboardSummary represents the complete dashboard data
 type DashboardSummary struct {
 	TotalSales    float64        `json:"total_sales"`
@@ -37,4 +63,40 @@ type DashboardSummary struct {
 	TopCustomers  []TopCustomer  `json:"top_customers"`
 	Period        string         `json:"period"`
 	LastUpdated   time.Time      `json:"last_updated"`
+	// Cached is set when the summary was computed for an earlier request, CacheAgeSeconds ago
+	Cached          bool `json:"cached"`
+	CacheAgeSeconds int  `json:"cache_age_seconds"`
+}
+
+// SalesBookEntry is one sale in the sales book: an order with the customer details the BIR
+// requires and its amount broken down by the VAT classification it was priced under. Gross
+// is the order total, VAT-inclusive; a vatable sale is split into VatableSales and OutputVAT
+// as recorded on the order.
+type SalesBookEntry struct {
+	OrderID           int       `json:"order_id" db:"order_id"`
+	DocumentNo        *int      `json:"document_no,omitempty" db:"document_no"`
+	OrderDate         time.Time `json:"order_date" db:"order_date"`
+	CustomerID        int       `json:"customer_id" db:"customer_id"`
+	CompanyName       string    `json:"company_name" db:"company_name"`
+	TIN               *string   `json:"tin,omitempty" db:"tin"`
+	Address           *string   `json:"address,omitempty" db:"address"`
+	VATClassification string    `json:"vat_classification" db:"vat_classification"`
+	Gross             float64   `json:"gross" db:"total_amount"`
+	VatableSales      float64   `json:"vatable_sales" db:"net_amount"`
+	OutputVAT         float64   `json:"output_vat" db:"vat_amount"`
+	ExemptSales       float64   `json:"exempt_sales" db:"-"`
+	ZeroRatedSales    float64   `json:"zero_rated_sales" db:"-"`
+}
+
+// SalesBook is the sales journal for one month with its column totals; VATRate is the
+// current VAT rate in percent, each sale having been split at the rate it was priced at
+type SalesBook struct {
+	Month          string           `json:"month"`
+	VATRate        float64          `json:"vat_rate"`
+	Entries        []SalesBookEntry `json:"entries"`
+	Gross          float64          `json:"gross"`
+	VatableSales   float64          `json:"vatable_sales"`
+	OutputVAT      float64          `json:"output_vat"`
+	ExemptSales    float64          `json:"exempt_sales"`
+	ZeroRatedSales float64          `json:"zero_rated_sales"`
 }
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+)
+
+// Reports that can be emailed on a schedule, named after their export routes
+const (
+	ScheduledSalesTrends       = "sales_trends"
+	ScheduledLowStock          = "low_stock"
+	ScheduledTopCustomers      = "top_customers"
+	ScheduledSalesByChannel    = "sales_by_channel"
+	ScheduledRevenueByIndustry = "revenue_by_industry"
+)
+
+// ScheduledReports lists the reports that can be emailed on a schedule
+var ScheduledReports = []string{
+	ScheduledSalesTrends,
+	ScheduledLowStock,
+	ScheduledTopCustomers,
+	ScheduledSalesByChannel,
+	ScheduledRevenueByIndustry,
+}
+
+// Report schedule frequencies
+const (
+	ScheduleDaily   = "daily"
+	ScheduleWeekly  = "weekly"
+	ScheduleMonthly = "monthly"
+)
+
+// ReportSchedule emails the CSV exports of Reports to Recipients every day, every week on
+// Weekday (0 is Sunday) or every month on DayOfMonth, at Hour:Minute in Timezone, the
+// server's time zone when empty. Reports over a period cover the Days before the run.
+// LastError is the reason the last run failed, if it did.
+type ReportSchedule struct {
+	ReportScheduleID int        `db:"report_schedule_id" json:"report_schedule_id"`
+	Name             string     `db:"name" json:"name"`
+	Reports          StringList `db:"reports" json:"reports"`
+	Recipients       StringList `db:"recipients" json:"recipients"`
+	Frequency        string     `db:"frequency" json:"frequency"`
+	Weekday          *int       `db:"weekday" json:"weekday,omitempty"`
+	DayOfMonth       *int       `db:"day_of_month" json:"day_of_month,omitempty"`
+	Hour             int        `db:"hour" json:"hour"`
+	Minute           int        `db:"minute" json:"minute"`
+	Timezone         string     `db:"timezone" json:"timezone"`
+	Days             int        `db:"days" json:"days"`
+	Active           bool       `db:"active" json:"active"`
+	NextRunAt        time.Time  `db:"next_run_at" json:"next_run_at"`
+	LastRunAt        *time.Time `db:"last_run_at" json:"last_run_at,omitempty"`
+	LastError        *string    `db:"last_error" json:"last_error,omitempty"`
+	CreatedBy        *int       `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt        time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt        time.Time  `db:"updated_at" json:"updated_at"`
+}
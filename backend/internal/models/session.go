@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// Session is a server-side login session referenced by the session cookie
+type Session struct {
+	SessionID  int       `db:"session_id" json:"session_id"`
+	TokenHash  string    `db:"token_hash" json:"-"`
+	UserID     int       `db:"user_id" json:"user_id"`
+	IPAddress  string    `db:"ip_address" json:"ip_address"`
+	UserAgent  string    `db:"user_agent" json:"user_agent"`
+	ExpiresAt  time.Time `db:"expires_at" json:"expires_at"`
+	LastSeenAt time.Time `db:"last_seen_at" json:"last_seen_at"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+)
+
+// Setting keys
+const (
+	SettingCostPolicy       = "cost_policy"
+	SettingWalkInCustomerID = "walk_in_customer_id"
+)
+
+// Business rule keys. Rules are thresholds the business tunes without a deploy; they are
+// stored as settings under their key.
+const (
+	RuleDeliveryFee              = "delivery_fee"
+	RuleVATRatePct               = "vat_rate_pct"
+	RuleMaxDiscountPercent       = "max_discount_percent"
+	RuleQuotationValidityDays    = "quotation_validity_days"
+	RuleLargeOrderAlert          = "large_order_alert_threshold"
+	RuleQuotationApprovedAlert   = "quotation_approved_alert_threshold"
+	RuleCheckReminderDays        = "check_reminder_days"
+	RuleInvoicePriceTolerancePct = "invoice_price_tolerance_pct"
+	RuleInvoiceQuantityTolerance = "invoice_quantity_tolerance"
+	RuleBankMatchWindowDays      = "bank_match_window_days"
+	RuleQuotationResponseHours   = "quotation_response_hours"
+	RuleQuotationSLAWarningHours = "quotation_sla_warning_hours"
+	RuleAnomalySalesSigma        = "anomaly_sales_sigma"
+	RuleAnomalySalesHistoryDays  = "anomaly_sales_history_days"
+	RuleAnomalyStockDropPct      = "anomaly_stock_drop_pct"
+	RulePromiseHandlingDays      = "promise_handling_days"
+)
+
+// Business rule value types
+const (
+	RuleTypeInt    = "int"
+	RuleTypeNumber = "number"
+)
+
+// Setting is an application setting editable by admins
+type Setting struct {
+	Key       string    `db:"key" json:"key"`
+	Value     string    `db:"value" json:"value"`
+	UpdatedBy *int      `db:"updated_by" json:"updated_by,omitempty"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// BusinessRule is a typed threshold, such as the largest discount allowed on a line. Value is
+// the admin's setting when Overridden, the default otherwise; Min and Max bound what can be
+// set.
+type BusinessRule struct {
+	Key         string     `json:"key"`
+	Type        string     `json:"type"`
+	Description string     `json:"description"`
+	Value       float64    `json:"value"`
+	Default     float64    `json:"default"`
+	Min         float64    `json:"min"`
+	Max         *float64   `json:"max,omitempty"`
+	Overridden  bool       `json:"overridden"`
+	UpdatedBy   *int       `json:"updated_by,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// Cashier shift statuses
+const (
+	ShiftOpen   = "open"
+	ShiftClosed = "closed"
+)
+
+// CashCounts is the number of notes and coins of each denomination counted in a drawer,
+// keyed by face value, e.g. {"1000": 3, "0.25": 8}
+type CashCounts map[string]int
+
+// Total adds up the counted denominations. Keys that are not amounts are an error.
+func (c CashCounts) Total() (float64, error) {
+	total := 0.0
+	for denomination, count := range c {
+		value, err := strconv.ParseFloat(denomination, 64)
+		if err != nil || value <= 0 {
+			return 0, errors.New("invalid denomination " + strconv.Quote(denomination))
+		}
+		if count < 0 {
+			return 0, errors.New("count for " + denomination + " cannot be negative")
+		}
+		total += value * float64(count)
+	}
+	return total, nil
+}
+
+// Value encodes the counts for storage
+func (c CashCounts) Value() (driver.Value, error) {
+	if c == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(c)
+	return string(b), err
+}
+
+// Scan decodes the counts from a JSONB column
+func (c *CashCounts) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	case nil:
+		*c = nil
+		return nil
+	}
+	return errors.New("unsupported type for cash counts")
+}
+
+// CashierShift is a cashier's session at a counter register, from the opening float to the
+// closing cash count
+type CashierShift struct {
+	ShiftID      int        `db:"shift_id" json:"shift_id"`
+	UserID       int        `db:"user_id" json:"user_id"`
+	Register     string     `db:"register" json:"register"`
+	Status       string     `db:"status" json:"status"`
+	OpeningCash  float64    `db:"opening_cash" json:"opening_cash"`
+	OpeningNotes string     `db:"opening_notes" json:"opening_notes"`
+	OpenedAt     time.Time  `db:"opened_at" json:"opened_at"`
+	CashCounts   CashCounts `db:"cash_counts" json:"cash_counts,omitempty"`
+	CountedCash  *float64   `db:"counted_cash" json:"counted_cash,omitempty"`
+	ExpectedCash *float64   `db:"expected_cash" json:"expected_cash,omitempty"`
+	Variance     *float64   `db:"variance" json:"variance,omitempty"`
+	ClosingNotes string     `db:"closing_notes" json:"closing_notes"`
+	ClosedBy     *int       `db:"closed_by" json:"closed_by,omitempty"`
+	ClosedAt     *time.Time `db:"closed_at" json:"closed_at,omitempty"`
+}
+
+// ShiftPaymentTotal is what a shift took with one payment method
+type ShiftPaymentTotal struct {
+	PaymentMethod string  `db:"payment_method" json:"payment_method"`
+	Sales         int     `db:"sales" json:"sales"`
+	Total         float64 `db:"total" json:"total"`
+	Tendered      float64 `db:"tendered" json:"tendered"`
+}
+
+// ShiftReport is the Z-report of a shift: its counter sales by payment method and how the
+// counted cash compares with what the drawer should hold. For an open shift it is the
+// running X-report and has no count or variance yet.
+type ShiftReport struct {
+	Shift        CashierShift        `json:"shift"`
+	Cashier      string              `json:"cashier"`
+	Sales        int                 `json:"sales"`
+	ItemsSold    int                 `json:"items_sold"`
+	Discounts    float64             `json:"discounts"`
+	TotalSales   float64             `json:"total_sales"`
+	Payments     []ShiftPaymentTotal `json:"payments"`
+	CashSales    float64             `json:"cash_sales"`
+	ExpectedCash float64             `json:"expected_cash"`
+	CountedCash  *float64            `json:"counted_cash,omitempty"`
+	Variance     *float64            `json:"variance,omitempty"`
+}
+
+// DailyClosingReport sums up the Z-reports of every shift opened on a day
+type DailyClosingReport struct {
+	Date          string              `json:"date"`
+	Shifts        []ShiftReport       `json:"shifts"`
+	Sales         int                 `json:"sales"`
+	TotalSales    float64             `json:"total_sales"`
+	Payments      []ShiftPaymentTotal `json:"payments"`
+	TotalVariance float64             `json:"total_variance"`
+	OpenShifts    int                 `json:"open_shifts"`
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Spec field types
+const (
+	SpecTypeString  = "string"
+	SpecTypeNumber  = "number"
+	SpecTypeInteger = "integer"
+	SpecTypeBoolean = "boolean"
+	SpecTypeEnum    = "enum"
+)
+
+// SpecField describes one entry of a category's technical spec sheet
+type SpecField struct {
+	Name     string   `json:"name"`
+	Label    string   `json:"label,omitempty"`
+	Type     string   `json:"type"`
+	Unit     string   `json:"unit,omitempty"`
+	Required bool     `json:"required"`
+	Options  []string `json:"options,omitempty"`
+	Min      *float64 `json:"min,omitempty"`
+	Max      *float64 `json:"max,omitempty"`
+}
+
+// SpecFields is a list of spec fields stored as a JSONB column
+type SpecFields []SpecField
+
+// Value encodes the fields for storage
+func (f SpecFields) Value() (driver.Value, error) {
+	if f == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(f)
+	return string(b), err
+}
+
+// Scan decodes the fields from a JSONB column
+func (f *SpecFields) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, f)
+	case string:
+		return json.Unmarshal([]byte(v), f)
+	case nil:
+		*f = SpecFields{}
+		return nil
+	}
+	return errors.New("unsupported type for spec fields")
+}
+
+// SpecSchema defines the technical specs expected for products in a category
+type SpecSchema struct {
+	Category  string     `db:"category" json:"category"`
+	Fields    SpecFields `db:"fields" json:"fields"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// SpecChange is a single technical spec value that was added, removed or changed
+type SpecChange struct {
+	Field string          `json:"field"`
+	Old   json.RawMessage `json:"old,omitempty"`
+	New   json.RawMessage `json:"new,omitempty"`
+}
+
+// ProductHistory records a change to a product
+type ProductHistory struct {
+	ProductHistoryID int             `db:"product_history_id" json:"product_history_id"`
+	ProductID        int             `db:"product_id" json:"product_id"`
+	Action           string          `db:"action" json:"action"`
+	ChangedFields    json.RawMessage `db:"changed_fields" json:"changed_fields"`
+	SpecsDiff        json.RawMessage `db:"specs_diff" json:"specs_diff"`
+	ChangedAt        time.Time       `db:"changed_at" json:"changed_at"`
+}
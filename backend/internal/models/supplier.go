@@ -0,0 +1,117 @@
+package models
+
+import (
+	"time"
+)
+
+// Purchase order statuses
+const (
+	PurchaseOrderDraft           = "Draft"
+	PurchaseOrderPendingApproval = "Pending Approval"
+	PurchaseOrderOrdered         = "Ordered"
+	PurchaseOrderReceived        = "Received"
+	PurchaseOrderCancelled       = "Cancelled"
+	PurchaseOrderRejected        = "Rejected"
+)
+
+// Purchase order charge types
+const (
+	ChargeFreight   = "freight"
+	ChargeDuty      = "duty"
+	ChargeBrokerage = "brokerage"
+	ChargeOther     = "other"
+)
+
+// How a purchase order charge is spread across its lines
+const (
+	AllocateByValue    = "value"
+	AllocateByQuantity = "quantity"
+	AllocateByWeight   = "weight"
+)
+
+// Cost policies for updating product cost prices when goods are received
+const (
+	CostPolicyMovingAverage = "moving_average"
+	CostPolicyFIFO          = "fifo"
+)
+
+// Supplier is a company products are purchased from
+type Supplier struct {
+	SupplierID  int       `db:"supplier_id" json:"supplier_id"`
+	Name        string    `db:"name" json:"name" validate:"notblank"`
+	ContactName string    `db:"contact_name" json:"contact_name"`
+	Email       string    `db:"email" json:"email"`
+	Phone       string    `db:"phone" json:"phone"`
+	Address     string    `db:"address" json:"address"`
+	Active      bool      `db:"active" json:"active"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// SupplierProduct is a supplier's price and lead time for a product
+type SupplierProduct struct {
+	SupplierID   int       `db:"supplier_id" json:"supplier_id"`
+	ProductID    int       `db:"product_id" json:"product_id"`
+	SupplierSKU  string    `db:"supplier_sku" json:"supplier_sku"`
+	UnitCost     float64   `db:"unit_cost" json:"unit_cost" validate:"gte=0"`
+	LeadTimeDays int       `db:"lead_time_days" json:"lead_time_days" validate:"gte=0"`
+	MinOrderQty  int       `db:"min_order_qty" json:"min_order_qty" validate:"gte=1"`
+	Preferred    bool      `db:"preferred" json:"preferred"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// SupplierPrice is a supplier product price together with the supplier it belongs to
+type SupplierPrice struct {
+	SupplierProduct
+	SupplierName   string `db:"supplier_name" json:"supplier_name"`
+	SupplierActive bool   `db:"supplier_active" json:"supplier_active"`
+}
+
+// PurchaseOrder is an order placed with a supplier
+type PurchaseOrder struct {
+	PurchaseOrderID int                   `db:"purchase_order_id" json:"purchase_order_id"`
+	SupplierID      int                   `db:"supplier_id" json:"supplier_id"`
+	Status          string                `db:"status" json:"status"`
+	OrderDate       time.Time             `db:"order_date" json:"order_date"`
+	ExpectedDate    *time.Time            `db:"expected_date" json:"expected_date,omitempty"`
+	Notes           string                `db:"notes" json:"notes"`
+	TotalAmount     float64               `db:"total_amount" json:"total_amount"`
+	CreatedBy       *int                  `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt       time.Time             `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time             `db:"updated_at" json:"updated_at"`
+	ReceivedAt      *time.Time            `db:"received_at" json:"received_at,omitempty"`
+	ReceivedBy      *int                  `db:"received_by" json:"received_by,omitempty"`
+	Department      *string               `db:"department" json:"department,omitempty"`
+	BudgetID        *int                  `db:"budget_id" json:"budget_id,omitempty"`
+	ApprovedBy      *int                  `db:"approved_by" json:"approved_by,omitempty"`
+	ApprovedAt      *time.Time            `db:"approved_at" json:"approved_at,omitempty"`
+	ApprovalNote    string                `db:"approval_note" json:"approval_note"`
+	Items           []PurchaseOrderItem   `db:"-" json:"items,omitempty"`
+	Charges         []PurchaseOrderCharge `db:"-" json:"charges,omitempty"`
+}
+
+// PurchaseOrderItem is one product line on a purchase order. The received quantity and
+// landed cost are filled in when the purchase order is received.
+type PurchaseOrderItem struct {
+	PurchaseOrderItemID int      `db:"purchase_order_item_id" json:"purchase_order_item_id"`
+	PurchaseOrderID     int      `db:"purchase_order_id" json:"purchase_order_id"`
+	ProductID           int      `db:"product_id" json:"product_id" validate:"required"`
+	Quantity            int      `db:"quantity" json:"quantity" validate:"gt=0"`
+	UnitCost            float64  `db:"unit_cost" json:"unit_cost" validate:"gte=0"`
+	LineTotal           float64  `db:"line_total" json:"line_total"`
+	LeadTimeDays        int      `db:"lead_time_days" json:"lead_time_days"`
+	ReceivedQuantity    *int     `db:"received_quantity" json:"received_quantity,omitempty"`
+	AllocatedCharges    *float64 `db:"allocated_charges" json:"allocated_charges,omitempty"`
+	LandedUnitCost      *float64 `db:"landed_unit_cost" json:"landed_unit_cost,omitempty"`
+}
+
+// PurchaseOrderCharge is freight, duty or brokerage paid on a purchase order
+type PurchaseOrderCharge struct {
+	ChargeID        int       `db:"charge_id" json:"charge_id"`
+	PurchaseOrderID int       `db:"purchase_order_id" json:"purchase_order_id"`
+	ChargeType      string    `db:"charge_type" json:"charge_type" validate:"oneof=freight duty brokerage other"`
+	Description     string    `db:"description" json:"description"`
+	Amount          float64   `db:"amount" json:"amount" validate:"gte=0"`
+	Allocation      string    `db:"allocation" json:"allocation" validate:"oneof=value quantity weight"`
+	CreatedAt       time.Time `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+)
+
+// Supplier invoice match statuses
+const (
+	InvoiceMatchPending     = "pending"
+	InvoiceMatchMatched     = "matched"
+	InvoiceMatchDiscrepancy = "discrepancy"
+)
+
+// Kinds of discrepancy found by the three-way match
+const (
+	// DiscrepancyNotOnPO is an invoiced product the purchase order doesn't include
+	DiscrepancyNotOnPO = "not_on_po"
+	// DiscrepancyReceiptQuantity is a line received short or over the quantity ordered
+	DiscrepancyReceiptQuantity = "receipt_quantity"
+	// DiscrepancyInvoiceQuantity is a line billed for more than was received
+	DiscrepancyInvoiceQuantity = "invoice_quantity"
+	// DiscrepancyPrice is a line billed at a different price than the purchase order
+	DiscrepancyPrice = "price"
+	// DiscrepancyTotal is an invoice total that doesn't add up to its lines
+	DiscrepancyTotal = "total"
+)
+
+// SupplierInvoice is a supplier's bill for a purchase order
+type SupplierInvoice struct {
+	InvoiceID       int                   `db:"invoice_id" json:"invoice_id"`
+	SupplierID      int                   `db:"supplier_id" json:"supplier_id"`
+	PurchaseOrderID int                   `db:"purchase_order_id" json:"purchase_order_id"`
+	InvoiceNumber   string                `db:"invoice_number" json:"invoice_number"`
+	InvoiceDate     time.Time             `db:"invoice_date" json:"invoice_date"`
+	TotalAmount     float64               `db:"total_amount" json:"total_amount"`
+	MatchStatus     string                `db:"match_status" json:"match_status"`
+	MatchedAt       *time.Time            `db:"matched_at" json:"matched_at,omitempty"`
+	Notes           string                `db:"notes" json:"notes"`
+	CreatedBy       *int                  `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt       time.Time             `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time             `db:"updated_at" json:"updated_at"`
+	Items           []SupplierInvoiceItem `db:"-" json:"items,omitempty"`
+	Discrepancies   []InvoiceDiscrepancy  `db:"-" json:"discrepancies,omitempty"`
+}
+
+// SupplierInvoiceItem is one billed line on a supplier invoice
+type SupplierInvoiceItem struct {
+	InvoiceItemID       int     `db:"invoice_item_id" json:"invoice_item_id"`
+	InvoiceID           int     `db:"invoice_id" json:"invoice_id"`
+	PurchaseOrderItemID *int    `db:"purchase_order_item_id" json:"purchase_order_item_id,omitempty"`
+	ProductID           int     `db:"product_id" json:"product_id"`
+	Quantity            int     `db:"quantity" json:"quantity"`
+	UnitPrice           float64 `db:"unit_price" json:"unit_price"`
+	LineTotal           float64 `db:"line_total" json:"line_total"`
+}
+
+// InvoiceDiscrepancy is a difference between a supplier invoice, its purchase order and
+// the goods received
+type InvoiceDiscrepancy struct {
+	DiscrepancyID       int       `db:"discrepancy_id" json:"discrepancy_id"`
+	InvoiceID           int       `db:"invoice_id" json:"invoice_id"`
+	PurchaseOrderItemID *int      `db:"purchase_order_item_id" json:"purchase_order_item_id,omitempty"`
+	ProductID           *int      `db:"product_id" json:"product_id,omitempty"`
+	Kind                string    `db:"kind" json:"kind"`
+	OrderedQuantity     *int      `db:"ordered_quantity" json:"ordered_quantity,omitempty"`
+	ReceivedQuantity    *int      `db:"received_quantity" json:"received_quantity,omitempty"`
+	InvoicedQuantity    *int      `db:"invoiced_quantity" json:"invoiced_quantity,omitempty"`
+	POUnitCost          *float64  `db:"po_unit_cost" json:"po_unit_cost,omitempty"`
+	InvoicedUnitPrice   *float64  `db:"invoiced_unit_price" json:"invoiced_unit_price,omitempty"`
+	AmountDifference    float64   `db:"amount_difference" json:"amount_difference"`
+	DetectedAt          time.Time `db:"detected_at" json:"detected_at"`
+}
+
+// DiscrepancyReportRow is a discrepancy with the invoice, supplier and product it concerns
+type DiscrepancyReportRow struct {
+	InvoiceDiscrepancy
+	InvoiceNumber   string  `db:"invoice_number" json:"invoice_number"`
+	PurchaseOrderID int     `db:"purchase_order_id" json:"purchase_order_id"`
+	SupplierID      int     `db:"supplier_id" json:"supplier_id"`
+	SupplierName    string  `db:"supplier_name" json:"supplier_name"`
+	ProductName     *string `db:"product_name" json:"product_name,omitempty"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// SyncTombstone records a deleted row so offline clients can remove their copy
+type SyncTombstone struct {
+	TombstoneID int       `db:"tombstone_id" json:"-"`
+	Entity      string    `db:"entity" json:"entity"`
+	EntityID    int       `db:"entity_id" json:"id"`
+	DeletedAt   time.Time `db:"deleted_at" json:"deleted_at"`
+}
+
+// SyncChanges lists everything that changed since a client's last sync
+type SyncChanges struct {
+	ServerTime time.Time       `json:"server_time"`
+	Products   []Product       `json:"products"`
+	Inventory  []Inventory     `json:"inventory"`
+	Orders     []Order         `json:"orders"`
+	Deleted    []SyncTombstone `json:"deleted"`
+}
+
+// SyncBatchItem is a single offline change pushed by a client.
+// BaseUpdatedAt is the updated_at the client last saw for the record and is used for conflict detection.
+type SyncBatchItem struct {
+	ClientRef     string          `json:"client_ref,omitempty"`
+	Entity        string          `json:"entity"`
+	Operation     string          `json:"op"`
+	ID            int             `json:"id"`
+	BaseUpdatedAt *time.Time      `json:"base_updated_at,omitempty"`
+	Data          json.RawMessage `json:"data,omitempty"`
+}
+
+// SyncBatchRequest groups offline changes with the conflict strategy to apply
+type SyncBatchRequest struct {
+	ConflictStrategy string          `json:"conflict_strategy" validate:"omitempty,oneof=server_wins client_wins"`
+	Items            []SyncBatchItem `json:"items" validate:"max=500"`
+}
+
+// SyncBatchResult reports the outcome of a single batch item
+type SyncBatchResult struct {
+	ClientRef  string      `json:"client_ref,omitempty"`
+	Entity     string      `json:"entity"`
+	ID         int         `json:"id"`
+	Status     string      `json:"status"`
+	Resolution string      `json:"resolution,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	Record     interface{} `json:"record,omitempty"`
+}
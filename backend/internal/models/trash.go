@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Types of records in the trash
+const (
+	TrashCustomer = "customer"
+	TrashContact  = "contact"
+	TrashProduct  = "product"
+)
+
+// TrashTypes are the types of records that go to the trash when deleted
+var TrashTypes = []string{TrashCustomer, TrashContact, TrashProduct}
+
+// TrashItem is a deleted record that can still be restored until PurgeAt, when it is removed
+// for good. Detail is the customer a contact belonged to.
+type TrashItem struct {
+	Type      string    `db:"type" json:"type"`
+	ID        int       `db:"id" json:"id"`
+	Name      string    `db:"name" json:"name"`
+	Detail    *string   `db:"detail" json:"detail,omitempty"`
+	DeletedAt time.Time `db:"deleted_at" json:"deleted_at"`
+	PurgeAt   time.Time `db:"purge_at" json:"purge_at"`
+}
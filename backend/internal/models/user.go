@@ -4,6 +4,12 @@ import (
 	"time"
 )
 
+// User roles with special meaning to access control
+const (
+	RoleAdmin  = "admin"
+	RoleViewer = "viewer"
+)
+
 // User represents an application user (admin or regular)
 type User struct {
 	UserID       int        `db:"user_id" json:"user_id"`
@@ -11,11 +17,12 @@ type User struct {
 	Role         string     `db:"role" json:"role"`
 	FirstName    string     `db:"first_name" json:"first_name"`
 	LastName     string     `db:"last_name" json:"last_name"`
-	Email        string     `db:"email" json:"email"`
+	Email        string     `db:"email" json:"email" validate:"required,email"`
 	Phone        *string    `db:"phone" json:"phone,omitempty"`
 	Department   *string    `db:"department" json:"department,omitempty"`
 	Position     *string    `db:"position" json:"position,omitempty"`
 	LastLogin    *time.Time `db:"last_login" json:"last_login,omitempty"`
 	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
 	UpdatedAt    time.Time  `db:"updated_at" json:"updated_at"`
+	LockedUntil  *time.Time `db:"locked_until" json:"locked_until,omitempty"`
 }
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+)
+
+// WatchableFields lists the fields a watch on each entity that can be watched can name
+var WatchableFields = map[string][]string{
+	AuditEntityCustomer: {
+		"company_name", "industry", "address", "street", "city", "province", "postal_code",
+		"phone", "email", "website", "tin", "vat_classification", "payment_terms_days", "tier", "archived_at",
+	},
+	AuditEntityQuotation: {
+		"status", "quote_date", "validity_date", "total_amount", "net_amount", "vat_amount",
+		"vat_classification", "currency", "exchange_rate", "accepted_at",
+	},
+}
+
+// WatchDefaultFields are the key fields watched when a watch names none
+var WatchDefaultFields = map[string][]string{
+	AuditEntityCustomer:  {"company_name", "vat_classification", "payment_terms_days", "tier", "archived_at"},
+	AuditEntityQuotation: {"status", "validity_date", "total_amount", "currency", "accepted_at"},
+}
+
+// RecordWatch is a user's watch on a customer or quotation. The user is notified in the
+// app, and by email when Email is set, when someone else changes one of its Fields or
+// deletes it.
+type RecordWatch struct {
+	RecordWatchID int        `db:"record_watch_id" json:"record_watch_id"`
+	UserID        int        `db:"user_id" json:"user_id"`
+	Entity        string     `db:"entity" json:"entity"`
+	EntityID      int        `db:"entity_id" json:"entity_id"`
+	Fields        StringList `db:"fields" json:"fields"`
+	Email         bool       `db:"email" json:"email"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+}
+
+// WatchedFields returns the fields the watch notifies on
+func (w RecordWatch) WatchedFields() []string {
+	if len(w.Fields) > 0 {
+		return w.Fields
+	}
+	return WatchDefaultFields[w.Entity]
+}
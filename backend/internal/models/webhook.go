@@ -0,0 +1,56 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Webhook events
+const (
+	WebhookOrderCreated       = "order.created"
+	WebhookOrderStatusChanged = "order.status_changed"
+	WebhookQuotationApproved  = "quotation.approved"
+	WebhookInventoryLowStock  = "inventory.low_stock"
+)
+
+// WebhookEvents are the events an endpoint can subscribe to
+var WebhookEvents = []string{WebhookOrderCreated, WebhookOrderStatusChanged, WebhookQuotationApproved, WebhookInventoryLowStock}
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "Pending"
+	WebhookDeliveryDelivered = "Delivered"
+	WebhookDeliveryFailed    = "Failed"
+)
+
+// WebhookEndpoint is a URL that receives signed callbacks for the events it subscribes to.
+// Its secret signs every callback and is only shown when the endpoint is created.
+type WebhookEndpoint struct {
+	WebhookEndpointID int        `db:"webhook_endpoint_id" json:"webhook_endpoint_id"`
+	URL               string     `db:"url" json:"url"`
+	Secret            string     `db:"secret" json:"-"`
+	Events            StringList `db:"events" json:"events"`
+	Description       string     `db:"description" json:"description"`
+	Active            bool       `db:"active" json:"active"`
+	CreatedAt         time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// WebhookDelivery is one event sent to an endpoint. Pending deliveries are retried at
+// NextAttemptAt; EventID is shared by every delivery of the same event, including
+// redeliveries, so receivers can ignore duplicates.
+type WebhookDelivery struct {
+	WebhookDeliveryID int             `db:"webhook_delivery_id" json:"webhook_delivery_id"`
+	WebhookEndpointID int             `db:"webhook_endpoint_id" json:"webhook_endpoint_id"`
+	Event             string          `db:"event" json:"event"`
+	EventID           string          `db:"event_id" json:"event_id"`
+	Payload           json.RawMessage `db:"payload" json:"payload"`
+	Status            string          `db:"status" json:"status"`
+	Attempts          int             `db:"attempts" json:"attempts"`
+	NextAttemptAt     *time.Time      `db:"next_attempt_at" json:"next_attempt_at,omitempty"`
+	ResponseStatus    *int            `db:"response_status" json:"response_status,omitempty"`
+	ResponseBody      *string         `db:"response_body" json:"response_body,omitempty"`
+	Error             *string         `db:"error" json:"error,omitempty"`
+	CreatedAt         time.Time       `db:"created_at" json:"created_at"`
+	DeliveredAt       *time.Time      `db:"delivered_at" json:"delivered_at,omitempty"`
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// AnomalyRepository handles database operations for the anomaly check: the sales and stock
+// movements it looks at and the anomalies it flags
+type AnomalyRepository struct {
+	db *sqlx.DB
+}
+
+// NewAnomalyRepository creates a new repository with the provided database connection
+func NewAnomalyRepository(db *sqlx.DB) *AnomalyRepository {
+	return &AnomalyRepository{
+		db: db,
+	}
+}
+
+// GetDailySales returns the base currency sales of each of the last days days up to
+// yesterday, oldest first. Days without sales are included as zero and cancelled orders are
+// left out.
+func (r *AnomalyRepository) GetDailySales(ctx context.Context, days int) ([]models.DailySales, error) {
+	sales := []models.DailySales{}
+	query := `
+		SELECT d::DATE AS day, COALESCE(SUM(o.total_amount * o.exchange_rate), 0) AS total_amount
+		FROM GENERATE_SERIES(CURRENT_DATE - $1 * INTERVAL '1 day', CURRENT_DATE - INTERVAL '1 day', INTERVAL '1 day') d
+		LEFT JOIN orders o ON o.order_date >= d AND o.order_date < d + INTERVAL '1 day' AND o.status <> 'Cancelled'
+		GROUP BY d
+		ORDER BY d`
+	err := r.db.SelectContext(ctx, &sales, query, days)
+	return sales, err
+}
+
+// CheckStock returns the inventory records holding less stock than their last snapshot
+// and the receipts and order issues since then account for, and then snapshots the stock on
+// hand of every record for the next check. Records seen for the first time are snapshotted
+// only.
+func (r *AnomalyRepository) CheckStock(ctx context.Context) ([]models.StockDrop, error) {
+	// Both statements see the same stock, so no movement falls between the check and the snapshot
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	drops := []models.StockDrop{}
+	query := `
+		SELECT i.inventory_id, i.product_id, p.product_name, s.stock AS previous_stock,
+			COALESCE(rc.quantity, 0) AS received, COALESCE(si.quantity, 0) AS issued,
+			i.current_stock, s.taken_at
+		FROM stock_snapshots s
+		JOIN inventory i ON i.inventory_id = s.inventory_id
+		JOIN products p ON p.product_id = i.product_id
+		LEFT JOIN LATERAL (
+			SELECT SUM(quantity) AS quantity FROM stock_receipts
+			WHERE inventory_id = i.inventory_id AND passed AND received_at > s.taken_at
+		) rc ON TRUE
+		LEFT JOIN LATERAL (
+			SELECT SUM(quantity) AS quantity FROM stock_issues
+			WHERE inventory_id = i.inventory_id AND issued_at > s.taken_at
+		) si ON TRUE
+		WHERE s.stock + COALESCE(rc.quantity, 0) - COALESCE(si.quantity, 0) > i.current_stock
+		ORDER BY p.product_name, i.inventory_id`
+	if err = tx.SelectContext(ctx, &drops, query); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO stock_snapshots (inventory_id, stock, taken_at)
+		SELECT inventory_id, current_stock, NOW() FROM inventory
+		ON CONFLICT (inventory_id) DO UPDATE SET stock = EXCLUDED.stock, taken_at = EXCLUDED.taken_at`)
+	if err != nil {
+		return nil, err
+	}
+
+	return drops, tx.Commit()
+}
+
+// Record saves an anomaly unless the same one was already flagged that day, returning
+// whether it is new
+func (r *AnomalyRepository) Record(ctx context.Context, anomaly *models.Anomaly) (bool, error) {
+	query := `
+		INSERT INTO anomalies (metric, inventory_id, day, observed, expected, message)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (metric, COALESCE(inventory_id, 0), day) DO NOTHING
+		RETURNING anomaly_id, detected_at`
+	err := r.db.QueryRowContext(ctx, query,
+		anomaly.Metric,
+		anomaly.InventoryID,
+		anomaly.Day,
+		anomaly.Observed,
+		anomaly.Expected,
+		anomaly.Message,
+	).Scan(&anomaly.AnomalyID, &anomaly.DetectedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// GetAll retrieves flagged anomalies, newest first, optionally only those of a metric
+func (r *AnomalyRepository) GetAll(ctx context.Context, metric string, page Page) ([]models.Anomaly, int, error) {
+	anomalies := []models.Anomaly{}
+	query := `
+		SELECT * FROM anomalies
+		WHERE $1 = '' OR metric = $1
+		ORDER BY detected_at DESC, anomaly_id DESC`
+	total, err := selectPage(ctx, r.db, &anomalies, page, query, metric)
+	return anomalies, total, err
+}
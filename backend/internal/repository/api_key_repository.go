@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// APIKeyRepository handles database operations for API keys and their usage
+type APIKeyRepository struct {
+	db *sqlx.DB
+}
+
+// NewAPIKeyRepository creates a new repository with the provided database connection
+func NewAPIKeyRepository(db *sqlx.DB) *APIKeyRepository {
+	return &APIKeyRepository{
+		db: db,
+	}
+}
+
+// GetAll retrieves all API keys, newest first
+func (r *APIKeyRepository) GetAll(ctx context.Context) ([]models.APIKey, error) {
+	keys := []models.APIKey{}
+	query := `SELECT * FROM api_keys ORDER BY created_at DESC`
+	err := r.db.SelectContext(ctx, &keys, query)
+	return keys, err
+}
+
+// GetByID retrieves an API key by ID
+func (r *APIKeyRepository) GetByID(ctx context.Context, id int) (models.APIKey, error) {
+	var key models.APIKey
+	query := `SELECT * FROM api_keys WHERE api_key_id = $1`
+	err := r.db.GetContext(ctx, &key, query, id)
+	if err == sql.ErrNoRows {
+		return key, notFound("api key")
+	}
+	return key, err
+}
+
+// GetByKeyHash retrieves an API key by the hash of its secret
+func (r *APIKeyRepository) GetByKeyHash(ctx context.Context, keyHash string) (models.APIKey, error) {
+	var key models.APIKey
+	query := `SELECT * FROM api_keys WHERE key_hash = $1`
+	err := r.db.GetContext(ctx, &key, query, keyHash)
+	if err == sql.ErrNoRows {
+		return key, notFound("api key")
+	}
+	return key, err
+}
+
+// Create inserts a new API key into the database
+func (r *APIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	query := `
+		INSERT INTO api_keys (
+			name, key_hash, key_prefix, daily_quota, scopes
+		) VALUES (
+			$1, $2, $3, $4, $5
+		) RETURNING api_key_id, created_at`
+
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		key.Name,
+		key.KeyHash,
+		key.KeyPrefix,
+		key.DailyQuota,
+		key.Scopes,
+	).Scan(&key.APIKeyID, &key.CreatedAt)
+}
+
+// Update changes the name, quota and scopes of an API key
+func (r *APIKeyRepository) Update(ctx context.Context, key *models.APIKey) error {
+	query := `
+		UPDATE api_keys SET
+			name = $1,
+			daily_quota = $2,
+			scopes = $3
+		WHERE api_key_id = $4
+		RETURNING revoked_at, last_used_at`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		key.Name,
+		key.DailyQuota,
+		key.Scopes,
+		key.APIKeyID,
+	).Scan(&key.RevokedAt, &key.LastUsedAt)
+	if err == sql.ErrNoRows {
+		return notFound("api key")
+	}
+	return err
+}
+
+// Revoke disables an API key while keeping it and its usage history
+func (r *APIKeyRepository) Revoke(ctx context.Context, id int) error {
+	return r.execByID(ctx, `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, NOW()) WHERE api_key_id = $1`, id)
+}
+
+// Delete removes an API key and its usage history
+func (r *APIKeyRepository) Delete(ctx context.Context, id int) error {
+	return r.execByID(ctx, `DELETE FROM api_keys WHERE api_key_id = $1`, id)
+}
+
+// Touch records that a key was used, at most once a minute to keep writes down
+func (r *APIKeyRepository) Touch(ctx context.Context, id int) error {
+	query := `
+		UPDATE api_keys SET last_used_at = NOW()
+		WHERE api_key_id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// execByID runs a statement for a single key and reports a missing key as not found
+func (r *APIKeyRepository) execByID(ctx context.Context, query string, id int) error {
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound("api key")
+	}
+
+	return nil
+}
+
+// UpdateQuota changes the daily quota of an API key
+func (r *APIKeyRepository) UpdateQuota(ctx context.Context, id int, dailyQuota int) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE api_keys SET daily_quota = $1 WHERE api_key_id = $2`, dailyQuota, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound("api key")
+	}
+
+	return nil
+}
+
+// IncrementUsage counts a request against today's (UTC) usage and returns the new total
+func (r *APIKeyRepository) IncrementUsage(ctx context.Context, id int) (int, error) {
+	query := `
+		INSERT INTO api_key_usage (api_key_id, usage_date, request_count)
+		VALUES ($1, (NOW() AT TIME ZONE 'UTC')::date, 1)
+		ON CONFLICT (api_key_id, usage_date)
+		DO UPDATE SET request_count = api_key_usage.request_count + 1
+		RETURNING request_count`
+
+	var count int
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&count)
+	return count, err
+}
+
+// GetUsage retrieves daily usage for the last number of days, newest first
+func (r *APIKeyRepository) GetUsage(ctx context.Context, id int, days int) ([]models.APIKeyUsage, error) {
+	usage := []models.APIKeyUsage{}
+	query := `
+		SELECT * FROM api_key_usage
+		WHERE api_key_id = $1
+		AND usage_date > (NOW() AT TIME ZONE 'UTC')::date - $2::int
+		ORDER BY usage_date DESC`
+	err := r.db.SelectContext(ctx, &usage, query, id, days)
+	return usage, err
+}
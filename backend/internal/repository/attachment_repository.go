@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// attachmentColumns lists every attachment column except the file content
+const attachmentColumns = `attachment_id, entity, entity_id, kind, file_name, content_type, size_bytes, uploaded_by, created_at`
+
+// AttachmentRepository handles database operations for uploaded files
+type AttachmentRepository struct {
+	db *sqlx.DB
+}
+
+// NewAttachmentRepository creates a new repository with the provided database connection
+func NewAttachmentRepository(db *sqlx.DB) *AttachmentRepository {
+	return &AttachmentRepository{
+		db: db,
+	}
+}
+
+// GetByEntity lists the attachments of a record without their content
+func (r *AttachmentRepository) GetByEntity(ctx context.Context, entity string, entityID int) ([]models.Attachment, error) {
+	attachments := []models.Attachment{}
+	query := `SELECT ` + attachmentColumns + ` FROM attachments WHERE entity = $1 AND entity_id = $2 ORDER BY attachment_id`
+	err := r.db.SelectContext(ctx, &attachments, query, entity, entityID)
+	return attachments, err
+}
+
+// GetByID retrieves an attachment including its content
+func (r *AttachmentRepository) GetByID(ctx context.Context, id int) (models.Attachment, error) {
+	var attachment models.Attachment
+	query := `SELECT * FROM attachments WHERE attachment_id = $1`
+	err := r.db.GetContext(ctx, &attachment, query, id)
+	if err == sql.ErrNoRows {
+		return attachment, notFound("attachment")
+	}
+	return attachment, err
+}
+
+// Create stores a new attachment
+func (r *AttachmentRepository) Create(ctx context.Context, attachment *models.Attachment) error {
+	query := `
+		INSERT INTO attachments (entity, entity_id, kind, file_name, content_type, size_bytes, content, uploaded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING attachment_id, created_at`
+
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		attachment.Entity,
+		attachment.EntityID,
+		attachment.Kind,
+		attachment.FileName,
+		attachment.ContentType,
+		attachment.SizeBytes,
+		attachment.Content,
+		attachment.UploadedBy,
+	).Scan(&attachment.AttachmentID, &attachment.CreatedAt)
+}
+
+// Delete removes an attachment
+func (r *AttachmentRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM attachments WHERE attachment_id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound("attachment")
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// AuditRepository handles database operations for audit logs
+type AuditRepository struct {
+	db       *sqlx.DB
+	onCreate func(entry models.AuditLog)
+}
+
+// NewAuditRepository creates a new repository with the provided database connection
+func NewAuditRepository(db *sqlx.DB) *AuditRepository {
+	return &AuditRepository{
+		db: db,
+	}
+}
+
+// OnCreate sets a function told of every audit log entry written, such as
+// WatchService.RecordChanged. It must be set before entries are written.
+func (r *AuditRepository) OnCreate(fn func(entry models.AuditLog)) {
+	r.onCreate = fn
+}
+
+// AuditLogFilter narrows an audit log query
+type AuditLogFilter struct {
+	UserID           int
+	ImpersonatedOnly bool
+	Entity           string
+	EntityID         string
+	Action           string
+	From             *time.Time
+	To               *time.Time
+	Limit            int
+}
+
+// GetAll retrieves audit logs matching the filter, newest first
+func (r *AuditRepository) GetAll(ctx context.Context, filter AuditLogFilter) ([]models.AuditLog, error) {
+	logs := []models.AuditLog{}
+
+	conditions := []string{}
+	args := []interface{}{}
+	if filter.UserID != 0 {
+		args = append(args, filter.UserID)
+		conditions = append(conditions, fmt.Sprintf("(user_id = $%d OR impersonator_id = $%d)", len(args), len(args)))
+	}
+	if filter.ImpersonatedOnly {
+		conditions = append(conditions, "impersonator_id IS NOT NULL")
+	}
+	if filter.Entity != "" {
+		args = append(args, filter.Entity)
+		conditions = append(conditions, fmt.Sprintf("entity = $%d", len(args)))
+	}
+	if filter.EntityID != "" {
+		args = append(args, filter.EntityID)
+		conditions = append(conditions, fmt.Sprintf("entity_id = $%d", len(args)))
+	}
+	if filter.Action != "" {
+		args = append(args, filter.Action)
+		conditions = append(conditions, fmt.Sprintf("action = $%d", len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+
+	query := `SELECT * FROM audit_logs`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = 100
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d", len(args))
+
+	err := r.db.SelectContext(ctx, &logs, query, args...)
+	return logs, err
+}
+
+// AuditEntry is an audit log entry with the name of the user who made the change
+type AuditEntry struct {
+	models.AuditLog
+	UserName *string `db:"user_name"`
+}
+
+// GetByRecord retrieves a page of the audit log entries for one record, newest first, and
+// the total number of entries for it
+func (r *AuditRepository) GetByRecord(ctx context.Context, entity, entityID string, page Page) ([]AuditEntry, int, error) {
+	entries := []AuditEntry{}
+	query := `
+		SELECT a.*, NULLIF(TRIM(u.first_name || ' ' || u.last_name), '') AS user_name
+		FROM audit_logs a
+		LEFT JOIN users u ON u.user_id = a.user_id
+		WHERE a.entity = $1 AND a.entity_id = $2
+		ORDER BY a.created_at DESC, a.audit_log_id DESC`
+	total, err := selectPage(ctx, r.db, &entries, page, query, entity, entityID)
+	return entries, total, err
+}
+
+// Create inserts a new audit log entry
+func (r *AuditRepository) Create(ctx context.Context, entry *models.AuditLog) error {
+	if len(entry.Details) == 0 {
+		entry.Details = json.RawMessage(`{}`)
+	}
+
+	query := `
+		INSERT INTO audit_logs (
+			user_id, impersonator_id, action, entity, entity_id, details, ip_address,
+			before_data, after_data
+		) VALUES (
+			$1, $2, $3, $4, $5, $6::jsonb, $7, $8::jsonb, $9::jsonb
+		) RETURNING audit_log_id, created_at`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		entry.UserID,
+		entry.ImpersonatorID,
+		entry.Action,
+		entry.Entity,
+		entry.EntityID,
+		entry.Details,
+		entry.IPAddress,
+		nullableJSON(entry.Before),
+		nullableJSON(entry.After),
+	).Scan(&entry.AuditLogID, &entry.CreatedAt)
+	if err == nil && r.onCreate != nil {
+		r.onCreate(*entry)
+	}
+	return err
+}
+
+// nullableJSON stores an empty JSON value as NULL
+func nullableJSON(data json.RawMessage) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// AvailabilityRepository reads what stock of products is free to promise: the stock on hand
+// less what open orders will take, and the purchase orders on their way
+type AvailabilityRepository struct {
+	db *sqlx.DB
+}
+
+// NewAvailabilityRepository creates a new repository with the provided database connection
+func NewAvailabilityRepository(db *sqlx.DB) *AvailabilityRepository {
+	return &AvailabilityRepository{
+		db: db,
+	}
+}
+
+// GetAvailable returns each product's stock on hand less the quantities of Pending orders
+// not yet taken out of stock. Products that are oversold have negative stock available.
+func (r *AvailabilityRepository) GetAvailable(ctx context.Context, productIDs []int) (map[int]int, error) {
+	var rows []struct {
+		ProductID int `db:"product_id"`
+		Available int `db:"available"`
+	}
+	query := `
+		SELECT p.product_id,
+			COALESCE((SELECT SUM(current_stock) FROM inventory WHERE product_id = p.product_id), 0)
+			- COALESCE((
+				SELECT SUM(oi.quantity) FROM order_items oi
+				JOIN orders o ON o.order_id = oi.order_id
+				WHERE oi.product_id = p.product_id AND o.status = 'Pending' AND o.stock_issued_at IS NULL
+			), 0) AS available
+		FROM products p
+		WHERE p.product_id = ANY($1)`
+	if err := r.db.SelectContext(ctx, &rows, query, pq.Array(productIDs)); err != nil {
+		return nil, err
+	}
+
+	available := make(map[int]int, len(rows))
+	for _, row := range rows {
+		available[row.ProductID] = row.Available
+	}
+	return available, nil
+}
+
+// GetIncoming returns the products' lines on purchase orders placed and not yet received,
+// grouped by product and the soonest expected first. Lines of orders without an expected date
+// are expected their lead time after the order was placed.
+func (r *AvailabilityRepository) GetIncoming(ctx context.Context, productIDs []int) (map[int][]models.IncomingStock, error) {
+	incoming := []models.IncomingStock{}
+	query := `
+		SELECT poi.product_id, po.purchase_order_id, poi.quantity,
+			COALESCE(po.expected_date, (po.order_date + poi.lead_time_days * INTERVAL '1 day')::DATE) AS expected_date
+		FROM purchase_order_items poi
+		JOIN purchase_orders po ON po.purchase_order_id = poi.purchase_order_id
+		WHERE poi.product_id = ANY($1) AND po.status = $2
+		ORDER BY poi.product_id, expected_date, po.purchase_order_id`
+	if err := r.db.SelectContext(ctx, &incoming, query, pq.Array(productIDs), models.PurchaseOrderOrdered); err != nil {
+		return nil, err
+	}
+
+	grouped := map[int][]models.IncomingStock{}
+	for _, line := range incoming {
+		grouped[line.ProductID] = append(grouped[line.ProductID], line)
+	}
+	return grouped, nil
+}
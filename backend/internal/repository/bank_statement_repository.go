@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrLineMatched is returned when confirming a match for a statement line that is already matched
+	ErrLineMatched = conflict("statement line is already matched to a payment")
+
+	// ErrPaymentMatched is returned when confirming a payment that is already matched to another statement line
+	ErrPaymentMatched = conflict("payment is already matched to another statement line")
+)
+
+// statementQuery selects statements with how many of their lines there are and are matched
+const statementQuery = `
+	SELECT s.*,
+		(SELECT COUNT(*) FROM bank_statement_lines l WHERE l.statement_id = s.statement_id) AS line_count,
+		(SELECT COUNT(*) FROM bank_statement_lines l WHERE l.statement_id = s.statement_id AND l.payment_id IS NOT NULL) AS matched_count
+	FROM bank_statements s`
+
+// unmatchedPayment holds for payments not matched to any statement line
+const unmatchedPayment = `NOT EXISTS (SELECT 1 FROM bank_statement_lines l WHERE l.payment_id = p.payment_id)`
+
+// BankStatementRepository handles database operations for imported bank statements and
+// their reconciliation against payments
+type BankStatementRepository struct {
+	db *sqlx.DB
+}
+
+// NewBankStatementRepository creates a new repository with the provided database connection
+func NewBankStatementRepository(db *sqlx.DB) *BankStatementRepository {
+	return &BankStatementRepository{
+		db: db,
+	}
+}
+
+// GetAll retrieves the imported statements, latest first
+func (r *BankStatementRepository) GetAll(ctx context.Context) ([]models.BankStatement, error) {
+	statements := []models.BankStatement{}
+	err := r.db.SelectContext(ctx, &statements, statementQuery+` ORDER BY s.imported_at DESC, s.statement_id DESC`)
+	return statements, err
+}
+
+// GetByID retrieves a statement with its lines in statement order
+func (r *BankStatementRepository) GetByID(ctx context.Context, id int) (models.BankStatement, error) {
+	var statement models.BankStatement
+	err := r.db.GetContext(ctx, &statement, statementQuery+` WHERE s.statement_id = $1`, id)
+	if err == sql.ErrNoRows {
+		return statement, notFound("bank statement")
+	}
+	if err != nil {
+		return statement, err
+	}
+
+	statement.Lines = []models.BankStatementLine{}
+	query := `SELECT * FROM bank_statement_lines WHERE statement_id = $1 ORDER BY line_no`
+	err = r.db.SelectContext(ctx, &statement.Lines, query, id)
+	return statement, err
+}
+
+// Create stores a statement and its lines in one transaction
+func (r *BankStatementRepository) Create(ctx context.Context, statement *models.BankStatement) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO bank_statements (bank_account, file_name, imported_by)
+		VALUES ($1, $2, $3)
+		RETURNING statement_id, imported_at`,
+		statement.BankAccount, statement.FileName, statement.ImportedBy).
+		Scan(&statement.StatementID, &statement.ImportedAt)
+	if err != nil {
+		return err
+	}
+
+	for i := range statement.Lines {
+		line := &statement.Lines[i]
+		line.StatementID = statement.StatementID
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO bank_statement_lines (statement_id, line_no, transaction_date, description, reference, amount)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING line_id`,
+			line.StatementID, line.LineNo, line.TransactionDate, line.Description, line.Reference, line.Amount).
+			Scan(&line.LineID)
+		if err != nil {
+			return err
+		}
+	}
+	statement.LineCount = len(statement.Lines)
+
+	return tx.Commit()
+}
+
+// Delete removes a statement and its lines, releasing the payments matched to them
+func (r *BankStatementRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM bank_statements WHERE statement_id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound("bank statement")
+	}
+
+	return nil
+}
+
+// GetLine retrieves a statement line by ID
+func (r *BankStatementRepository) GetLine(ctx context.Context, id int) (models.BankStatementLine, error) {
+	var line models.BankStatementLine
+	err := r.db.GetContext(ctx, &line, `SELECT * FROM bank_statement_lines WHERE line_id = $1`, id)
+	if err == sql.ErrNoRows {
+		return line, notFound("statement line")
+	}
+	return line, err
+}
+
+// GetUnmatchedDeposits retrieves a statement's deposits not yet matched to a payment
+func (r *BankStatementRepository) GetUnmatchedDeposits(ctx context.Context, statementID int) ([]models.BankStatementLine, error) {
+	lines := []models.BankStatementLine{}
+	query := `
+		SELECT * FROM bank_statement_lines
+		WHERE statement_id = $1 AND amount > 0 AND payment_id IS NULL
+		ORDER BY line_no`
+	err := r.db.SelectContext(ctx, &lines, query, statementID)
+	return lines, err
+}
+
+// GetCandidatePayments retrieves unmatched payments that may correspond to a deposit: those
+// of the same amount received between from and to (inclusive days), and those whose
+// reference number appears in text, such as the line's reference and description
+func (r *BankStatementRepository) GetCandidatePayments(ctx context.Context, amount float64, from, to time.Time, text string) ([]models.PaymentRecord, error) {
+	payments := []models.PaymentRecord{}
+	query := paymentRecordQuery + `
+		WHERE ` + unmatchedPayment + `
+		AND (
+			(ABS(p.amount - $1) < 0.005 AND p.paid_at::date BETWEEN $2 AND $3)
+			OR (LENGTH(p.reference_no) >= 4 AND STRPOS(LOWER($4), LOWER(p.reference_no)) > 0)
+		)
+		ORDER BY p.paid_at, p.payment_id`
+	err := r.db.SelectContext(ctx, &payments, query, amount, from, to, text)
+	return payments, err
+}
+
+// Match confirms that a statement line is the given payment
+func (r *BankStatementRepository) Match(ctx context.Context, lineID, paymentID int, matchedBy *int) error {
+	query := `
+		UPDATE bank_statement_lines SET payment_id = $2, matched_by = $3, matched_at = NOW()
+		WHERE line_id = $1 AND payment_id IS NULL`
+	result, err := r.db.ExecContext(ctx, query, lineID, paymentID, matchedBy)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code {
+			case "23505":
+				return ErrPaymentMatched
+			case "23503":
+				return notFound("payment")
+			}
+		}
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		if _, err := r.GetLine(ctx, lineID); err != nil {
+			return err
+		}
+		return ErrLineMatched
+	}
+
+	return nil
+}
+
+// Unmatch clears the payment confirmed for a statement line
+func (r *BankStatementRepository) Unmatch(ctx context.Context, lineID int) error {
+	query := `UPDATE bank_statement_lines SET payment_id = NULL, matched_by = NULL, matched_at = NULL WHERE line_id = $1`
+	result, err := r.db.ExecContext(ctx, query, lineID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound("statement line")
+	}
+
+	return nil
+}
+
+// GetUnmatched reports the unmatched deposits on statements and the payments matching the
+// filter that are on no statement, in date order
+func (r *BankStatementRepository) GetUnmatched(ctx context.Context, filter PaymentFilter) (models.UnmatchedReport, error) {
+	report := models.UnmatchedReport{
+		Lines:    []models.BankStatementLine{},
+		Payments: []models.PaymentRecord{},
+	}
+
+	lineConditions := []string{"l.amount > 0", "l.payment_id IS NULL"}
+	paymentConditions := []string{unmatchedPayment}
+	lineArgs := []interface{}{}
+	paymentArgs := []interface{}{}
+	if filter.PaymentMethod != "" {
+		paymentArgs = append(paymentArgs, filter.PaymentMethod)
+		paymentConditions = append(paymentConditions, fmt.Sprintf("p.payment_method = $%d", len(paymentArgs)))
+	}
+	if filter.From != nil {
+		lineArgs = append(lineArgs, *filter.From)
+		lineConditions = append(lineConditions, fmt.Sprintf("l.transaction_date >= $%d", len(lineArgs)))
+		paymentArgs = append(paymentArgs, *filter.From)
+		paymentConditions = append(paymentConditions, fmt.Sprintf("p.paid_at >= $%d", len(paymentArgs)))
+	}
+	if filter.To != nil {
+		lineArgs = append(lineArgs, *filter.To)
+		lineConditions = append(lineConditions, fmt.Sprintf("l.transaction_date < $%d", len(lineArgs)))
+		paymentArgs = append(paymentArgs, *filter.To)
+		paymentConditions = append(paymentConditions, fmt.Sprintf("p.paid_at < $%d", len(paymentArgs)))
+	}
+
+	query := `SELECT l.* FROM bank_statement_lines l` + whereClause(lineConditions) + ` ORDER BY l.transaction_date, l.line_id`
+	if err := r.db.SelectContext(ctx, &report.Lines, query, lineArgs...); err != nil {
+		return report, err
+	}
+
+	query = paymentRecordQuery + whereClause(paymentConditions) + ` ORDER BY p.paid_at, p.payment_id`
+	if err := r.db.SelectContext(ctx, &report.Payments, query, paymentArgs...); err != nil {
+		return report, err
+	}
+
+	for _, line := range report.Lines {
+		report.LinesTotal += line.Amount
+	}
+	for _, payment := range report.Payments {
+		report.PaymentsTotal += payment.Amount
+	}
+	report.LinesTotal = math.Round(report.LinesTotal*100) / 100
+	report.PaymentsTotal = math.Round(report.PaymentsTotal*100) / 100
+	return report, nil
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+)
+
+// Cache keeps copies of records that are read far more often than they change, such as the
+// products and customers looked up for every quotation PDF. The memory and Redis caches are
+// in the services package. A cache that cannot be reached behaves as if it were empty.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool)
+	Set(ctx context.Context, key string, value []byte)
+	Delete(ctx context.Context, keys ...string)
+}
+
+// productCacheKey and customerCacheKey name a record's cached copy
+func productCacheKey(id int) string {
+	return "product:" + strconv.Itoa(id)
+}
+
+func customerCacheKey(id int) string {
+	return "customer:" + strconv.Itoa(id)
+}
+
+// cachedGet fills dest from its cached copy under key, or with load and caches what was
+// loaded. Without a cache it just loads.
+func cachedGet(ctx context.Context, cache Cache, key string, dest interface{}, load func() error) error {
+	if cache == nil {
+		return load()
+	}
+	if data, ok := cache.Get(ctx, key); ok && json.Unmarshal(data, dest) == nil {
+		return nil
+	}
+	if err := load(); err != nil {
+		return err
+	}
+	if data, err := json.Marshal(dest); err == nil {
+		cache.Set(ctx, key, data)
+	}
+	return nil
+}
+
+// invalidate drops the cached copies of records that have changed. Call it after the change
+// is committed so the old row cannot be cached again.
+func invalidate(ctx context.Context, cache Cache, keyFor func(int) string, ids ...int) {
+	if cache == nil || len(ids) == 0 {
+		return
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = keyFor(id)
+	}
+	cache.Delete(ctx, keys...)
+}
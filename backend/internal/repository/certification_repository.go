@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// CertificationRepository handles database operations for certifications and product certificates
+type CertificationRepository struct {
+	db *sqlx.DB
+}
+
+// NewCertificationRepository creates a new repository with the provided database connection
+func NewCertificationRepository(db *sqlx.DB) *CertificationRepository {
+	return &CertificationRepository{
+		db: db,
+	}
+}
+
+// GetAll retrieves all certifications ordered by code
+func (r *CertificationRepository) GetAll(ctx context.Context) ([]models.Certification, error) {
+	certifications := []models.Certification{}
+	query := `SELECT * FROM certifications ORDER BY code`
+	err := r.db.SelectContext(ctx, &certifications, query)
+	return certifications, err
+}
+
+// GetByID retrieves a certification by ID
+func (r *CertificationRepository) GetByID(ctx context.Context, id int) (models.Certification, error) {
+	var certification models.Certification
+	query := `SELECT * FROM certifications WHERE certification_id = $1`
+	err := r.db.GetContext(ctx, &certification, query, id)
+	if err == sql.ErrNoRows {
+		return certification, notFound("certification")
+	}
+	return certification, err
+}
+
+// Create inserts a new certification
+func (r *CertificationRepository) Create(ctx context.Context, certification *models.Certification) error {
+	query := `
+		INSERT INTO certifications (code, name, issuing_body, description)
+		VALUES ($1, $2, $3, $4)
+		RETURNING certification_id, created_at, updated_at`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		certification.Code,
+		certification.Name,
+		certification.IssuingBody,
+		certification.Description,
+	).Scan(&certification.CertificationID, &certification.CreatedAt, &certification.UpdatedAt)
+
+	return translateReferenceError(err)
+}
+
+// Update updates an existing certification
+func (r *CertificationRepository) Update(ctx context.Context, certification *models.Certification) error {
+	query := `
+		UPDATE certifications SET
+			code = $1,
+			name = $2,
+			issuing_body = $3,
+			description = $4,
+			updated_at = NOW()
+		WHERE certification_id = $5
+		RETURNING created_at, updated_at`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		certification.Code,
+		certification.Name,
+		certification.IssuingBody,
+		certification.Description,
+		certification.CertificationID,
+	).Scan(&certification.CreatedAt, &certification.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return notFound("certification")
+	}
+
+	return translateReferenceError(err)
+}
+
+// Delete removes a certification. Certifications still linked to products cannot be deleted.
+func (r *CertificationRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM certifications WHERE certification_id = $1`, id)
+	if err != nil {
+		return translateReferenceError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound("certification")
+	}
+
+	return nil
+}
+
+// GetByProductID retrieves the certificates held by a product
+func (r *CertificationRepository) GetByProductID(ctx context.Context, productID int) ([]models.ProductCertification, error) {
+	certificates := []models.ProductCertification{}
+	query := `
+		SELECT pc.*, c.code, c.name
+		FROM product_certifications pc
+		JOIN certifications c ON c.certification_id = pc.certification_id
+		WHERE pc.product_id = $1
+		ORDER BY c.code`
+	err := r.db.SelectContext(ctx, &certificates, query, productID)
+	return certificates, err
+}
+
+// LinkProduct adds a certificate to a product, or updates it if the product already holds that certification
+func (r *CertificationRepository) LinkProduct(ctx context.Context, link *models.ProductCertification) error {
+	query := `
+		INSERT INTO product_certifications (
+			product_id, certification_id, certificate_number, issued_on, expires_on
+		) VALUES (
+			$1, $2, $3, $4, $5
+		)
+		ON CONFLICT (product_id, certification_id) DO UPDATE SET
+			certificate_number = EXCLUDED.certificate_number,
+			issued_on = EXCLUDED.issued_on,
+			expires_on = EXCLUDED.expires_on,
+			updated_at = NOW()
+		RETURNING product_certification_id, created_at, updated_at`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		link.ProductID,
+		link.CertificationID,
+		link.CertificateNumber,
+		link.IssuedOn,
+		link.ExpiresOn,
+	).Scan(&link.ProductCertificationID, &link.CreatedAt, &link.UpdatedAt)
+
+	return translateReferenceError(err)
+}
+
+// UnlinkProduct removes a certificate from a product
+func (r *CertificationRepository) UnlinkProduct(ctx context.Context, productID, certificationID int) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		`DELETE FROM product_certifications WHERE product_id = $1 AND certification_id = $2`,
+		productID, certificationID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound("product certification")
+	}
+
+	return nil
+}
+
+// GetExpiring retrieves certificates that have expired or expire within the given number of days,
+// soonest first. Certificates of discontinued products are left out.
+func (r *CertificationRepository) GetExpiring(ctx context.Context, days int) ([]models.ExpiringCertification, error) {
+	expiring := []models.ExpiringCertification{}
+	query := `
+		SELECT
+			pc.product_certification_id,
+			p.product_id,
+			p.product_name,
+			p.model,
+			c.certification_id,
+			c.code,
+			c.name,
+			pc.certificate_number,
+			pc.expires_on,
+			(pc.expires_on - CURRENT_DATE) AS days_remaining,
+			pc.expires_on < CURRENT_DATE AS expired
+		FROM product_certifications pc
+		JOIN certifications c ON c.certification_id = pc.certification_id
+		JOIN products p ON p.product_id = pc.product_id
+		WHERE pc.expires_on IS NOT NULL
+		AND pc.expires_on <= CURRENT_DATE + $1::int
+		AND p.discontinued_at IS NULL AND p.deleted_at IS NULL
+		ORDER BY pc.expires_on, p.product_name`
+	err := r.db.SelectContext(ctx, &expiring, query, days)
+	return expiring, err
+}
+
+// translateReferenceError maps constraint violations on reference tables to repository errors
+func translateReferenceError(err error) error {
+	if pqErr, ok := err.(*pq.Error); ok {
+		switch pqErr.Code {
+		case "23505":
+			return ErrDuplicateKey
+		case "23503":
+			return ErrReferencedRecord
+		}
+	}
+	return err
+}
@@ -3,7 +3,6 @@ package repository
 import (
 	"context"
 	"database/sql"
-	"errors"
 	"time"
 
 	"github.com/Cezzyy/SCMS/backend/internal/models"
@@ -23,21 +22,35 @@ func NewContactRepository(db *sqlx.DB) *ContactRepository {
 	}
 }
 
-// GetAll retrieves all contacts from the database
-func (r *ContactRepository) GetAll(ctx context.Context) ([]models.Contact, error) {
+// GetAll retrieves a page of contacts and the total number of them
+func (r *ContactRepository) GetAll(ctx context.Context, page Page) ([]models.Contact, int, error) {
 	contacts := []models.Contact{}
-	query := `SELECT * FROM contacts ORDER BY last_name, first_name`
-	err := r.db.SelectContext(ctx, &contacts, query)
-	return contacts, err
+	query := `SELECT * FROM contacts WHERE deleted_at IS NULL ORDER BY last_name, first_name, contact_id`
+	total, err := selectPage(ctx, r.db, &contacts, page, query)
+	return contacts, total, err
 }
 
-// GetByID retrieves a contact by ID
+// GetDeleted retrieves a page of soft-deleted contacts, most recently deleted first, and the
+// total number of them. Contacts of deleted customers are left out; they come back when the
+// customer is restored.
+func (r *ContactRepository) GetDeleted(ctx context.Context, page Page) ([]models.Contact, int, error) {
+	contacts := []models.Contact{}
+	query := `
+		SELECT co.* FROM contacts co
+		JOIN customers c ON c.customer_id = co.customer_id AND c.deleted_at IS NULL
+		WHERE co.deleted_at IS NOT NULL
+		ORDER BY co.deleted_at DESC, co.contact_id`
+	total, err := selectPage(ctx, r.db, &contacts, page, query)
+	return contacts, total, err
+}
+
+// GetByID retrieves a contact by ID; soft-deleted contacts are not found
 func (r *ContactRepository) GetByID(ctx context.Context, id int) (models.Contact, error) {
 	var contact models.Contact
-	query := `SELECT * FROM contacts WHERE contact_id = $1`
+	query := `SELECT * FROM contacts WHERE contact_id = $1 AND deleted_at IS NULL`
 	err := r.db.GetContext(ctx, &contact, query, id)
 	if err == sql.ErrNoRows {
-		return contact, errors.New("contact not found")
+		return contact, notFound("contact")
 	}
 	return contact, err
 }
@@ -45,7 +58,7 @@ func (r *ContactRepository) GetByID(ctx context.Context, id int) (models.Contact
 // GetByCustomerID retrieves all contacts for a specific customer
 func (r *ContactRepository) GetByCustomerID(ctx context.Context, customerID int) ([]models.Contact, error) {
 	contacts := []models.Contact{}
-	query := `SELECT * FROM contacts WHERE customer_id = $1 ORDER BY last_name, first_name`
+	query := `SELECT * FROM contacts WHERE customer_id = $1 AND deleted_at IS NULL ORDER BY last_name, first_name`
 	err := r.db.SelectContext(ctx, &contacts, query, customerID)
 	return contacts, err
 }
@@ -85,7 +98,7 @@ func (r *ContactRepository) Create(ctx context.Context, contact *models.Contact)
 			}
 			// 23503 is the PostgreSQL error code for foreign_key_violation
 			if pqErr.Code == "23503" {
-				return errors.New("customer not found")
+				return notFound("customer")
 			}
 		}
 	}
@@ -106,7 +119,7 @@ func (r *ContactRepository) Update(ctx context.Context, contact *models.Contact)
 			phone = $5,
 			email = $6,
 			updated_at = $7
-		WHERE contact_id = $8
+		WHERE contact_id = $8 AND deleted_at IS NULL
 		RETURNING updated_at`
 
 	result := r.db.QueryRowContext(
@@ -124,14 +137,14 @@ func (r *ContactRepository) Update(ctx context.Context, contact *models.Contact)
 
 	err := result.Scan(&contact.UpdatedAt)
 	if err == sql.ErrNoRows {
-		return errors.New("contact not found")
+		return notFound("contact")
 	}
 
 	if err != nil {
 		// Check for foreign key violation
 		if pqErr, ok := err.(*pq.Error); ok {
 			if pqErr.Code == "23503" {
-				return errors.New("customer not found")
+				return notFound("customer")
 			}
 		}
 	}
@@ -139,40 +152,71 @@ func (r *ContactRepository) Update(ctx context.Context, contact *models.Contact)
 	return err
 }
 
-// Delete removes a contact by ID
+// Delete soft-deletes a contact by ID
 func (r *ContactRepository) Delete(ctx context.Context, id int) error {
-	// Using PostgreSQL's WITH clause for the deletion and getting count in one query
-	query := `
-		WITH deleted AS (
-			DELETE FROM contacts 
-			WHERE contact_id = $1 
-			RETURNING contact_id
-		)
-		SELECT COUNT(*) FROM deleted`
+	return r.setDeleted(ctx, id, `deleted_at = NOW()`, `deleted_at IS NULL`, "contact")
+}
+
+// Restore brings back a soft-deleted contact. Contacts of a deleted customer are restored
+// with the customer instead.
+func (r *ContactRepository) Restore(ctx context.Context, id int) error {
+	return r.setDeleted(ctx, id, `deleted_at = NULL`, `deleted_at IS NOT NULL
+		AND EXISTS(SELECT 1 FROM customers c WHERE c.customer_id = contacts.customer_id AND c.deleted_at IS NULL)`, "deleted contact")
+}
+
+// setDeleted applies a deleted_at assignment to a contact in the given state
+func (r *ContactRepository) setDeleted(ctx context.Context, id int, assignment, state, entity string) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE contacts SET `+assignment+`, updated_at = NOW() WHERE contact_id = $1 AND `+state, id)
+	if err != nil {
+		return err
+	}
 
-	var count int
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&count)
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
 
-	if count == 0 {
-		return errors.New("contact not found")
+	if rowsAffected == 0 {
+		return notFound(entity)
 	}
 
 	return nil
 }
 
-// SearchContacts searches for contacts by name using PostgreSQL's ILIKE
-func (r *ContactRepository) SearchContacts(ctx context.Context, term string) ([]models.Contact, error) {
+// Purge permanently removes a soft-deleted contact; contacts that were not deleted first
+// return ErrNotDeleted
+func (r *ContactRepository) Purge(ctx context.Context, id int) error {
+	var deleted bool
+	err := r.db.QueryRowContext(ctx, `
+		WITH purged AS (
+			DELETE FROM contacts WHERE contact_id = $1 AND deleted_at IS NOT NULL
+			RETURNING contact_id
+		)
+		SELECT EXISTS(SELECT 1 FROM purged)`, id).Scan(&deleted)
+	if err != nil {
+		return translateReferenceError(err)
+	}
+	if deleted {
+		return nil
+	}
+
+	if _, err := r.GetByID(ctx, id); err != nil {
+		return err
+	}
+	return ErrNotDeleted
+}
+
+// SearchContacts searches for contacts by name using PostgreSQL's ILIKE, returning a page of
+// matches and the total number of matches
+func (r *ContactRepository) SearchContacts(ctx context.Context, term string, page Page) ([]models.Contact, int, error) {
 	contacts := []models.Contact{}
 	// Using PostgreSQL's CONCAT and ILIKE for case-insensitive search
 	query := `
 		SELECT * FROM contacts 
-		WHERE CONCAT(first_name, ' ', last_name) ILIKE $1 
-		ORDER BY last_name, first_name`
-	err := r.db.SelectContext(ctx, &contacts, query, "%"+term+"%")
-	return contacts, err
+		WHERE CONCAT(first_name, ' ', last_name) ILIKE $1 AND deleted_at IS NULL
+		ORDER BY last_name, first_name, contact_id`
+	total, err := selectPage(ctx, r.db, &contacts, page, query, "%"+term+"%")
+	return contacts, total, err
 }
 
 // CheckEmailExists checks if an email already exists
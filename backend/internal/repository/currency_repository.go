@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// CurrencyRepository handles database operations for currencies and their exchange rates
+type CurrencyRepository struct {
+	db *sqlx.DB
+}
+
+// NewCurrencyRepository creates a new repository with the provided database connection
+func NewCurrencyRepository(db *sqlx.DB) *CurrencyRepository {
+	return &CurrencyRepository{
+		db: db,
+	}
+}
+
+// GetAll retrieves all currencies ordered by code
+func (r *CurrencyRepository) GetAll(ctx context.Context) ([]models.Currency, error) {
+	currencies := []models.Currency{}
+	query := `SELECT * FROM currencies ORDER BY code`
+	err := r.db.SelectContext(ctx, &currencies, query)
+	return currencies, err
+}
+
+// GetByCode retrieves a currency by its code
+func (r *CurrencyRepository) GetByCode(ctx context.Context, code string) (models.Currency, error) {
+	var currency models.Currency
+	query := `SELECT * FROM currencies WHERE code = $1`
+	err := r.db.GetContext(ctx, &currency, query, code)
+	if err == sql.ErrNoRows {
+		return currency, notFound("currency")
+	}
+	return currency, err
+}
+
+// GetRates retrieves the exchange rates recorded for a currency, latest first
+func (r *CurrencyRepository) GetRates(ctx context.Context, code string) ([]models.ExchangeRate, error) {
+	rates := []models.ExchangeRate{}
+	query := `SELECT * FROM exchange_rates WHERE currency_code = $1 ORDER BY effective_date DESC`
+	err := r.db.SelectContext(ctx, &rates, query, code)
+	return rates, err
+}
+
+// GetRateOn retrieves the exchange rate of a currency in effect on a day: the latest one
+// recorded to take effect on or before it
+func (r *CurrencyRepository) GetRateOn(ctx context.Context, code string, day time.Time) (models.ExchangeRate, error) {
+	var rate models.ExchangeRate
+	query := `
+		SELECT * FROM exchange_rates
+		WHERE currency_code = $1 AND effective_date <= $2::DATE
+		ORDER BY effective_date DESC
+		LIMIT 1`
+	err := r.db.GetContext(ctx, &rate, query, code, day.Format("2006-01-02"))
+	if err == sql.ErrNoRows {
+		return rate, notFound("exchange rate")
+	}
+	return rate, err
+}
+
+// SetRate records the exchange rate of a currency from a day, replacing one already
+// recorded for that day
+func (r *CurrencyRepository) SetRate(ctx context.Context, rate *models.ExchangeRate) error {
+	query := `
+		INSERT INTO exchange_rates (currency_code, rate, effective_date, created_by)
+		VALUES ($1, $2, $3::DATE, $4)
+		ON CONFLICT (currency_code, effective_date) DO UPDATE SET
+			rate = EXCLUDED.rate,
+			created_by = EXCLUDED.created_by,
+			created_at = NOW()
+		RETURNING exchange_rate_id, created_at`
+
+	return r.db.QueryRowContext(ctx, query, rate.CurrencyCode, rate.Rate, rate.EffectiveDate.Format("2006-01-02"), rate.CreatedBy).
+		Scan(&rate.ExchangeRateID, &rate.CreatedAt)
+}
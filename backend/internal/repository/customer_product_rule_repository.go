@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// CustomerProductRuleRepository handles database operations for per-customer product rules
+type CustomerProductRuleRepository struct {
+	db *sqlx.DB
+}
+
+// NewCustomerProductRuleRepository creates a new repository with the provided database connection
+func NewCustomerProductRuleRepository(db *sqlx.DB) *CustomerProductRuleRepository {
+	return &CustomerProductRuleRepository{
+		db: db,
+	}
+}
+
+// GetByCustomerID retrieves a customer's product rules ordered by product name
+func (r *CustomerProductRuleRepository) GetByCustomerID(ctx context.Context, customerID int) ([]models.CustomerProductRule, error) {
+	rules := []models.CustomerProductRule{}
+	query := `
+		SELECT r.*, p.product_name
+		FROM customer_product_rules r
+		JOIN products p ON p.product_id = r.product_id
+		WHERE r.customer_id = $1
+		ORDER BY p.product_name`
+	err := r.db.SelectContext(ctx, &rules, query, customerID)
+	return rules, err
+}
+
+// Save creates or replaces the rule for a customer and product
+func (r *CustomerProductRuleRepository) Save(ctx context.Context, rule *models.CustomerProductRule) error {
+	query := `
+		INSERT INTO customer_product_rules (customer_id, product_id, rule, reason)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (customer_id, product_id) DO UPDATE SET
+			rule = EXCLUDED.rule,
+			reason = EXCLUDED.reason,
+			updated_at = NOW()
+		RETURNING customer_product_rule_id, created_at, updated_at`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		rule.CustomerID,
+		rule.ProductID,
+		rule.Rule,
+		rule.Reason,
+	).Scan(&rule.CustomerProductRuleID, &rule.CreatedAt, &rule.UpdatedAt)
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+		return notFound("customer or product")
+	}
+	return err
+}
+
+// Delete removes the rule for a customer and product
+func (r *CustomerProductRuleRepository) Delete(ctx context.Context, customerID, productID int) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		`DELETE FROM customer_product_rules WHERE customer_id = $1 AND product_id = $2`,
+		customerID, productID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound("product rule")
+	}
+
+	return nil
+}
+
+// CheckProducts returns the products that cannot be sold to a customer: products the customer
+// is blocked from, and restricted products the customer has no allow rule for
+func (r *CustomerProductRuleRepository) CheckProducts(ctx context.Context, customerID int, productIDs []int) ([]models.ProductRestriction, error) {
+	restrictions := []models.ProductRestriction{}
+	if len(productIDs) == 0 {
+		return restrictions, nil
+	}
+
+	query := `
+		SELECT
+			p.product_id,
+			p.product_name,
+			CASE
+				WHEN r.rule = 'block' THEN COALESCE(r.reason, 'blocked for this customer')
+				ELSE 'restricted product; this customer is not approved to buy it'
+			END AS reason
+		FROM products p
+		LEFT JOIN customer_product_rules r ON r.product_id = p.product_id AND r.customer_id = $1
+		WHERE p.product_id = ANY($2)
+		AND (r.rule = 'block' OR (p.restricted AND r.rule IS DISTINCT FROM 'allow'))
+		ORDER BY p.product_id`
+	err := r.db.SelectContext(ctx, &restrictions, query, customerID, pq.Array(productIDs))
+	return restrictions, err
+}
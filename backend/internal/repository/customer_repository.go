@@ -11,14 +11,13 @@ import (
 	"github.com/lib/pq"
 )
 
-var (
-	// ErrDuplicateKey is returned when a unique constraint is violated
-	ErrDuplicateKey = errors.New("duplicate key value violates unique constraint")
-)
+// ErrCustomerHasHistory is returned when purging a customer that has orders or quotations
+var ErrCustomerHasHistory = conflict("customer has orders or quotations and can only be archived")
 
 // CustomerRepository handles database operations for customers
 type CustomerRepository struct {
-	db *sqlx.DB
+	db    *sqlx.DB
+	cache Cache
 }
 
 // NewCustomerRepository creates a new repository with the provided database connection
@@ -28,21 +27,74 @@ func NewCustomerRepository(db *sqlx.DB) *CustomerRepository {
 	}
 }
 
-// GetAll retrieves all customers from the database
-func (r *CustomerRepository) GetAll(ctx context.Context) ([]models.Customer, error) {
+// UseCache keeps customers read by ID in cache until they change
+func (r *CustomerRepository) UseCache(cache Cache) {
+	r.cache = cache
+}
+
+// GetAll retrieves a page of active (non-archived) customers and the total number of them
+func (r *CustomerRepository) GetAll(ctx context.Context, page Page) ([]models.Customer, int, error) {
+	customers := []models.Customer{}
+	query := `SELECT * FROM customers WHERE archived_at IS NULL AND deleted_at IS NULL ORDER BY company_name, customer_id`
+	total, err := selectPage(ctx, r.db, &customers, page, query)
+	return customers, total, err
+}
+
+// GetArchived retrieves a page of archived customers and the total number of them
+func (r *CustomerRepository) GetArchived(ctx context.Context, page Page) ([]models.Customer, int, error) {
+	customers := []models.Customer{}
+	query := `SELECT * FROM customers WHERE archived_at IS NOT NULL AND deleted_at IS NULL ORDER BY company_name, customer_id`
+	total, err := selectPage(ctx, r.db, &customers, page, query)
+	return customers, total, err
+}
+
+// GetDeleted retrieves a page of soft-deleted customers, most recently deleted first, and
+// the total number of them
+func (r *CustomerRepository) GetDeleted(ctx context.Context, page Page) ([]models.Customer, int, error) {
 	customers := []models.Customer{}
-	query := `SELECT * FROM customers ORDER BY company_name`
-	err := r.db.SelectContext(ctx, &customers, query)
-	return customers, err
+	query := `SELECT * FROM customers WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC, customer_id`
+	total, err := selectPage(ctx, r.db, &customers, page, query)
+	return customers, total, err
 }
 
-// GetByID retrieves a customer by ID
+// GetByID retrieves a customer by ID; soft-deleted customers are not found
 func (r *CustomerRepository) GetByID(ctx context.Context, id int) (models.Customer, error) {
+	customer, err := r.GetByIDWithDeleted(ctx, id)
+	if err == nil && customer.DeletedAt != nil {
+		return models.Customer{}, notFound("customer")
+	}
+	return customer, err
+}
+
+// GetByIDWithDeleted retrieves a customer by ID even when soft-deleted, for showing the
+// customer on historical documents
+func (r *CustomerRepository) GetByIDWithDeleted(ctx context.Context, id int) (models.Customer, error) {
 	var customer models.Customer
-	query := `SELECT * FROM customers WHERE customer_id = $1`
-	err := r.db.GetContext(ctx, &customer, query, id)
+	err := cachedGet(ctx, r.cache, customerCacheKey(id), &customer, func() error {
+		return r.db.GetContext(ctx, &customer, `SELECT * FROM customers WHERE customer_id = $1`, id)
+	})
 	if err == sql.ErrNoRows {
-		return customer, errors.New("customer not found")
+		return customer, notFound("customer")
+	}
+	return customer, err
+}
+
+// forget drops the cached copies of changed customers
+func (r *CustomerRepository) forget(ctx context.Context, ids ...int) {
+	invalidate(ctx, r.cache, customerCacheKey, ids...)
+}
+
+// GetWalkIn retrieves the shared customer that counter sales without a named customer are
+// booked to
+func (r *CustomerRepository) GetWalkIn(ctx context.Context) (models.Customer, error) {
+	var customer models.Customer
+	query := `
+		SELECT c.* FROM customers c
+		JOIN settings s ON s.key = $1 AND c.customer_id = s.value::INTEGER
+		WHERE c.deleted_at IS NULL`
+	err := r.db.GetContext(ctx, &customer, query, models.SettingWalkInCustomerID)
+	if err == sql.ErrNoRows {
+		return customer, notFound("customer")
 	}
 	return customer, err
 }
@@ -55,10 +107,12 @@ func (r *CustomerRepository) Create(ctx context.Context, customer *models.Custom
 
 	query := `
 		INSERT INTO customers (
-			company_name, industry, address, phone, email, website, created_at, updated_at
+			company_name, industry, address, phone, email, website, created_at, updated_at,
+			street, city, province, postal_code, tin, vat_classification, payment_terms_days
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8
-		) RETURNING customer_id, created_at, updated_at`
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, COALESCE(NULLIF($14, ''), 'vatable'),
+			COALESCE($15, 0)
+		) RETURNING customer_id, created_at, updated_at, tier, vat_classification, payment_terms_days`
 
 	err := r.db.QueryRowContext(
 		ctx,
@@ -71,7 +125,14 @@ func (r *CustomerRepository) Create(ctx context.Context, customer *models.Custom
 		customer.Website,
 		customer.CreatedAt,
 		customer.UpdatedAt,
-	).Scan(&customer.CustomerID, &customer.CreatedAt, &customer.UpdatedAt)
+		customer.Street,
+		customer.City,
+		customer.Province,
+		customer.PostalCode,
+		customer.TIN,
+		customer.VATClassification,
+		customer.PaymentTermsDays,
+	).Scan(&customer.CustomerID, &customer.CreatedAt, &customer.UpdatedAt, &customer.Tier, &customer.VATClassification, &customer.PaymentTermsDays)
 
 	if err != nil {
 		// Check for PostgreSQL-specific errors
@@ -86,7 +147,10 @@ func (r *CustomerRepository) Create(ctx context.Context, customer *models.Custom
 	return err
 }
 
-// Update updates an existing customer
+// Update updates an existing customer. Stored coordinates are cleared when the address changes.
+// ErrStaleVersion is returned if the customer has been changed since customer.Version; the
+// version is advanced on every update. An empty VAT classification
+// or missing payment terms leave the stored ones unchanged.
 func (r *CustomerRepository) Update(ctx context.Context, customer *models.Customer) error {
 	customer.UpdatedAt = time.Now()
 
@@ -98,9 +162,20 @@ func (r *CustomerRepository) Update(ctx context.Context, customer *models.Custom
 			phone = $4,
 			email = $5,
 			website = $6,
-			updated_at = $7
-		WHERE customer_id = $8
-		RETURNING updated_at`
+			updated_at = $7,
+			street = $9,
+			city = $10,
+			province = $11,
+			postal_code = $12,
+			tin = $14,
+			vat_classification = COALESCE(NULLIF($15, ''), vat_classification),
+			payment_terms_days = COALESCE($16, payment_terms_days),
+			latitude = CASE WHEN address IS DISTINCT FROM $3 THEN NULL ELSE latitude END,
+			longitude = CASE WHEN address IS DISTINCT FROM $3 THEN NULL ELSE longitude END,
+			geocoded_at = CASE WHEN address IS DISTINCT FROM $3 THEN NULL ELSE geocoded_at END,
+			version = version + 1
+		WHERE customer_id = $8 AND deleted_at IS NULL AND version = $13
+		RETURNING updated_at, latitude, longitude, geocoded_at, version, vat_classification, payment_terms_days`
 
 	result := r.db.QueryRowContext(
 		ctx,
@@ -113,45 +188,271 @@ func (r *CustomerRepository) Update(ctx context.Context, customer *models.Custom
 		customer.Website,
 		customer.UpdatedAt,
 		customer.CustomerID,
+		customer.Street,
+		customer.City,
+		customer.Province,
+		customer.PostalCode,
+		customer.Version,
+		customer.TIN,
+		customer.VATClassification,
+		customer.PaymentTermsDays,
 	)
 
-	err := result.Scan(&customer.UpdatedAt)
+	err := result.Scan(&customer.UpdatedAt, &customer.Latitude, &customer.Longitude, &customer.GeocodedAt, &customer.Version, &customer.VATClassification, &customer.PaymentTermsDays)
 	if err == sql.ErrNoRows {
-		return errors.New("customer not found")
+		if _, err := r.GetByID(ctx, customer.CustomerID); err != nil {
+			return err
+		}
+		return ErrStaleVersion
 	}
-	return err
+	if err != nil {
+		return err
+	}
+
+	r.forget(ctx, customer.CustomerID)
+	return nil
 }
 
-// Delete removes a customer by ID
+// Delete soft-deletes a customer together with their contacts. Orders and quotations keep
+// referring to the customer, who can be restored until purged.
 func (r *CustomerRepository) Delete(ctx context.Context, id int) error {
-	// Using PostgreSQL's WITH clause for the deletion and getting count in one query
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var deletedAt time.Time
+	err = tx.QueryRowContext(ctx, `
+		UPDATE customers SET deleted_at = NOW(), updated_at = NOW()
+		WHERE customer_id = $1 AND deleted_at IS NULL
+		RETURNING deleted_at`, id).Scan(&deletedAt)
+	if err == sql.ErrNoRows {
+		err = notFound("customer")
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	// Contacts share the customer's deletion time so a restore brings back exactly these
+	if _, err = tx.ExecContext(ctx, `
+		UPDATE contacts SET deleted_at = $1, updated_at = NOW()
+		WHERE customer_id = $2 AND deleted_at IS NULL`, deletedAt, id); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	r.forget(ctx, id)
+	return nil
+}
+
+// Restore brings back a soft-deleted customer and the contacts deleted with them
+func (r *CustomerRepository) Restore(ctx context.Context, id int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var deletedAt time.Time
+	err = tx.QueryRowContext(ctx, `
+		SELECT deleted_at FROM customers
+		WHERE customer_id = $1 AND deleted_at IS NOT NULL
+		FOR UPDATE`, id).Scan(&deletedAt)
+	if err == sql.ErrNoRows {
+		err = notFound("deleted customer")
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE customers SET deleted_at = NULL, updated_at = NOW() WHERE customer_id = $1`, id); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `
+		UPDATE contacts SET deleted_at = NULL, updated_at = NOW()
+		WHERE customer_id = $1 AND deleted_at = $2`, id, deletedAt); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	r.forget(ctx, id)
+	return nil
+}
+
+// Purge permanently removes a soft-deleted customer and their contacts. Customers with
+// orders or quotations cannot be purged and ErrCustomerHasHistory is returned; customers
+// that were not deleted first return ErrNotDeleted.
+func (r *CustomerRepository) Purge(ctx context.Context, id int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Lock the customer so no order or quotation can be added between the check and the delete
+	var deleted, hasHistory bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT c.deleted_at IS NOT NULL,
+			EXISTS(SELECT 1 FROM orders WHERE customer_id = c.customer_id)
+			OR EXISTS(SELECT 1 FROM quotations WHERE customer_id = c.customer_id)
+		FROM customers c
+		WHERE c.customer_id = $1
+		FOR UPDATE`, id).Scan(&deleted, &hasHistory)
+	if err == sql.ErrNoRows {
+		err = notFound("customer")
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		err = ErrNotDeleted
+		return err
+	}
+	if hasHistory {
+		err = ErrCustomerHasHistory
+		return err
+	}
+
+	for _, table := range []string{"contacts", "customer_product_rules"} {
+		if _, err = tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE customer_id = $1`, id); err != nil {
+			return err
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM customers WHERE customer_id = $1`, id); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			err = ErrCustomerHasHistory
+		}
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	r.forget(ctx, id)
+	return nil
+}
+
+// SetLocation stores coordinates for a customer
+func (r *CustomerRepository) SetLocation(ctx context.Context, id int, latitude, longitude float64) (time.Time, error) {
+	var geocodedAt time.Time
 	query := `
-		WITH deleted AS (
-			DELETE FROM customers 
-			WHERE customer_id = $1 
-			RETURNING customer_id
-		)
-		SELECT COUNT(*) FROM deleted`
-
-	var count int
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&count)
+		UPDATE customers SET latitude = $1, longitude = $2, geocoded_at = NOW()
+		WHERE customer_id = $3 AND deleted_at IS NULL
+		RETURNING geocoded_at`
+	err := r.db.QueryRowContext(ctx, query, latitude, longitude, id).Scan(&geocodedAt)
+	if err == sql.ErrNoRows {
+		return geocodedAt, notFound("customer")
+	}
+	if err == nil {
+		r.forget(ctx, id)
+	}
+	return geocodedAt, err
+}
+
+// GetLocations retrieves the coordinates of active customers, optionally limited to a province
+func (r *CustomerRepository) GetLocations(ctx context.Context, province string) ([]models.CustomerLocation, error) {
+	locations := []models.CustomerLocation{}
+	query := `
+		SELECT customer_id, company_name, address, city, province, latitude, longitude
+		FROM customers
+		WHERE archived_at IS NULL AND deleted_at IS NULL AND latitude IS NOT NULL AND longitude IS NOT NULL
+			AND ($1 = '' OR province ILIKE $1)
+		ORDER BY province, city, company_name`
+	err := r.db.SelectContext(ctx, &locations, query, province)
+	return locations, err
+}
+
+// Archive hides a customer from active lists while keeping their history
+func (r *CustomerRepository) Archive(ctx context.Context, id int) error {
+	return r.setArchived(ctx, id, `archived_at = COALESCE(archived_at, NOW())`)
+}
+
+// Unarchive makes an archived customer active again
+func (r *CustomerRepository) Unarchive(ctx context.Context, id int) error {
+	return r.setArchived(ctx, id, `archived_at = NULL`)
+}
+
+// setArchived applies an archived_at assignment to a customer
+func (r *CustomerRepository) setArchived(ctx context.Context, id int, assignment string) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE customers SET `+assignment+`, updated_at = NOW(), version = version + 1 WHERE customer_id = $1 AND deleted_at IS NULL`, id)
 	if err != nil {
 		return err
 	}
 
-	if count == 0 {
-		return errors.New("customer not found")
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
 	}
 
+	if rowsAffected == 0 {
+		return notFound("customer")
+	}
+
+	r.forget(ctx, id)
 	return nil
 }
 
-// SearchCustomers searches for customers by company name using PostgreSQL's ILIKE
-func (r *CustomerRepository) SearchCustomers(ctx context.Context, term string) ([]models.Customer, error) {
+// GetDependencies reports the quotations, orders and contacts that reference each customer.
+// Quotations and orders block deletion; contacts are removed together with the customer.
+func (r *CustomerRepository) GetDependencies(ctx context.Context, ids []int) (map[int][]models.Dependency, error) {
+	rows := []dependencyRow{}
+	query := `
+		SELECT customer_id AS owner_id, 'quotations' AS entity, quotation_id AS ref_id,
+			CASE WHEN UPPER(status) IN ('PENDING', 'APPROVED') THEN 'open' ELSE 'closed' END AS state
+		FROM quotations
+		WHERE customer_id = ANY($1)
+		UNION ALL
+		SELECT customer_id, 'orders', order_id,
+			CASE WHEN status IN ('Pending', 'Shipped') THEN 'open' ELSE 'closed' END
+		FROM orders
+		WHERE customer_id = ANY($1)
+		UNION ALL
+		SELECT customer_id, 'contacts', contact_id, 'any'
+		FROM contacts
+		WHERE customer_id = ANY($1) AND deleted_at IS NULL
+		ORDER BY owner_id, entity, ref_id`
+
+	if err := r.db.SelectContext(ctx, &rows, query, pq.Array(ids)); err != nil {
+		return nil, errors.New("failed to check customer dependencies: " + err.Error())
+	}
+
+	return groupDependencies(rows, map[string]dependencyRule{
+		"quotations/open":   {reason: "has open quotations", blocking: true},
+		"quotations/closed": {reason: "has historical quotations", blocking: true},
+		"orders/open":       {reason: "has open orders", blocking: true},
+		"orders/closed":     {reason: "has historical orders", blocking: true},
+		"contacts/any":      {reason: "contacts will be removed with the customer", blocking: false},
+	}), nil
+}
+
+// SearchCustomers searches for customers by company name using PostgreSQL's ILIKE,
+// returning a page of matches and the total number of matches
+func (r *CustomerRepository) SearchCustomers(ctx context.Context, term string, page Page) ([]models.Customer, int, error) {
 	customers := []models.Customer{}
-	query := `SELECT * FROM customers WHERE company_name ILIKE $1 ORDER BY company_name`
-	err := r.db.SelectContext(ctx, &customers, query, "%"+term+"%")
-	return customers, err
+	query := `SELECT * FROM customers WHERE company_name ILIKE $1 AND archived_at IS NULL AND deleted_at IS NULL ORDER BY company_name, customer_id`
+	total, err := selectPage(ctx, r.db, &customers, page, query, "%"+term+"%")
+	return customers, total, err
 }
 
 // CheckCompanyExists checks if a company name already exists
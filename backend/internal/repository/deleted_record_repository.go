@@ -0,0 +1,264 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// ErrRestoreConflict is returned when an archived row can no longer be restored,
+// e.g. because a row with the same unique values has been created since
+var ErrRestoreConflict = conflict("record conflicts with existing data")
+
+// archivedTable describes a table that supports bulk delete and restore.
+// Names are fixed here and never taken from requests.
+type archivedTable struct {
+	table    string
+	key      string
+	children []archivedChild
+	cacheKey func(int) string
+}
+
+// archivedChild is a dependent table whose rows are archived along with their parent
+type archivedChild struct {
+	entity     string
+	foreignKey string
+}
+
+var archivedTables = map[string]archivedTable{
+	"products": {
+		table: "products",
+		key:   "product_id",
+		children: []archivedChild{
+			{entity: "inventory", foreignKey: "product_id"},
+			{entity: "product_certifications", foreignKey: "product_id"},
+			{entity: "product_safety_standards", foreignKey: "product_id"},
+			{entity: "customer_product_rules", foreignKey: "product_id"},
+		},
+		cacheKey: productCacheKey,
+	},
+	"customers": {
+		table: "customers",
+		key:   "customer_id",
+		children: []archivedChild{
+			{entity: "contacts", foreignKey: "customer_id"},
+			{entity: "customer_product_rules", foreignKey: "customer_id"},
+		},
+		cacheKey: customerCacheKey,
+	},
+	"inventory": {table: "inventory", key: "inventory_id"},
+	"contacts":  {table: "contacts", key: "contact_id"},
+
+	"product_certifications":   {table: "product_certifications", key: "product_certification_id"},
+	"product_safety_standards": {table: "product_safety_standards", key: "product_safety_standard_id"},
+	"customer_product_rules":   {table: "customer_product_rules", key: "customer_product_rule_id"},
+}
+
+// DeletedRecordRepository archives and restores rows removed by bulk deletes
+type DeletedRecordRepository struct {
+	db    *sqlx.DB
+	cache Cache
+}
+
+// NewDeletedRecordRepository creates a new repository with the provided database connection
+func NewDeletedRecordRepository(db *sqlx.DB) *DeletedRecordRepository {
+	return &DeletedRecordRepository{
+		db: db,
+	}
+}
+
+// UseCache sets the product and customer cache, whose copies bulk deletes and restores
+// invalidate
+func (r *DeletedRecordRepository) UseCache(cache Cache) {
+	r.cache = cache
+}
+
+// lookupTable returns the archive configuration for an entity
+func lookupTable(entity string) (archivedTable, error) {
+	t, ok := archivedTables[entity]
+	if !ok {
+		return t, fmt.Errorf("unsupported entity: %s", entity)
+	}
+	return t, nil
+}
+
+// GetAll retrieves archived top-level records of an entity, newest first
+func (r *DeletedRecordRepository) GetAll(ctx context.Context, entity string) ([]models.DeletedRecord, error) {
+	records := []models.DeletedRecord{}
+	query := `
+		SELECT * FROM deleted_records
+		WHERE entity = $1 AND parent_entity IS NULL
+		ORDER BY deleted_at DESC`
+	err := r.db.SelectContext(ctx, &records, query, entity)
+	return records, err
+}
+
+// ExistingIDs returns which of the given IDs exist in the entity's table
+func (r *DeletedRecordRepository) ExistingIDs(ctx context.Context, entity string, ids []int) (map[int]bool, error) {
+	t, err := lookupTable(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	found := []int{}
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE %s = ANY($1)`, t.key, t.table, t.key)
+	if err := r.db.SelectContext(ctx, &found, query, pq.Array(ids)); err != nil {
+		return nil, err
+	}
+
+	existing := make(map[int]bool, len(found))
+	for _, id := range found {
+		existing[id] = true
+	}
+	return existing, nil
+}
+
+// ArchiveDelete copies the rows and their dependent child rows into deleted_records and
+// deletes them in one transaction. Either every row is deleted or none is.
+func (r *DeletedRecordRepository) ArchiveDelete(ctx context.Context, entity string, ids []int) error {
+	t, err := lookupTable(entity)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for _, child := range t.children {
+		ct := archivedTables[child.entity]
+		query := fmt.Sprintf(`
+			WITH deleted AS (
+				DELETE FROM %s WHERE %s = ANY($1) RETURNING *
+			)
+			INSERT INTO deleted_records (entity, entity_id, parent_entity, parent_id, data)
+			SELECT $2, deleted.%s, $3, deleted.%s, to_jsonb(deleted) FROM deleted`,
+			ct.table, child.foreignKey, ct.key, child.foreignKey)
+		if _, err = tx.ExecContext(ctx, query, pq.Array(ids), child.entity, entity); err != nil {
+			return translateArchiveError(err)
+		}
+	}
+
+	query := fmt.Sprintf(`
+		WITH deleted AS (
+			DELETE FROM %s WHERE %s = ANY($1) RETURNING *
+		)
+		INSERT INTO deleted_records (entity, entity_id, data)
+		SELECT $2, deleted.%s, to_jsonb(deleted) FROM deleted`,
+		t.table, t.key, t.key)
+	result, err := tx.ExecContext(ctx, query, pq.Array(ids), entity)
+	if err != nil {
+		return translateArchiveError(err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if int(deleted) != len(ids) {
+		err = fmt.Errorf("expected to delete %d %s but deleted %d", len(ids), entity, deleted)
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	if t.cacheKey != nil {
+		invalidate(ctx, r.cache, t.cacheKey, ids...)
+	}
+	return nil
+}
+
+// Restore re-inserts archived rows, and the child rows archived with them, in one transaction.
+// It returns the IDs that were restored; IDs with no archived row are ignored.
+func (r *DeletedRecordRepository) Restore(ctx context.Context, entity string, ids []int) ([]int, error) {
+	t, err := lookupTable(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	restored := []int{}
+	query := fmt.Sprintf(`
+		WITH archived AS (
+			DELETE FROM deleted_records
+			WHERE entity = $1 AND parent_entity IS NULL AND entity_id = ANY($2)
+			RETURNING data
+		)
+		INSERT INTO %s SELECT (jsonb_populate_record(NULL::%s, data)).* FROM archived
+		RETURNING %s`,
+		t.table, t.table, t.key)
+	if err = tx.SelectContext(ctx, &restored, query, entity, pq.Array(ids)); err != nil {
+		return nil, translateArchiveError(err)
+	}
+	if len(restored) == 0 {
+		err = tx.Commit()
+		return restored, err
+	}
+
+	// Bump updated_at so offline sync clients pick the rows up again
+	if _, err = tx.ExecContext(ctx, fmt.Sprintf(`UPDATE %s SET updated_at = NOW() WHERE %s = ANY($1)`, t.table, t.key), pq.Array(restored)); err != nil {
+		return nil, err
+	}
+
+	for _, child := range t.children {
+		ct := archivedTables[child.entity]
+		query := fmt.Sprintf(`
+			WITH archived AS (
+				DELETE FROM deleted_records
+				WHERE entity = $1 AND parent_entity = $2 AND parent_id = ANY($3)
+				RETURNING data
+			)
+			INSERT INTO %s SELECT (jsonb_populate_record(NULL::%s, data)).* FROM archived
+			RETURNING %s`,
+			ct.table, ct.table, ct.key)
+		childIDs := []int{}
+		if err = tx.SelectContext(ctx, &childIDs, query, child.entity, entity, pq.Array(restored)); err != nil {
+			return nil, translateArchiveError(err)
+		}
+		if len(childIDs) > 0 {
+			if _, err = tx.ExecContext(ctx, fmt.Sprintf(`UPDATE %s SET updated_at = NOW() WHERE %s = ANY($1)`, ct.table, ct.key), pq.Array(childIDs)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	if t.cacheKey != nil {
+		invalidate(ctx, r.cache, t.cacheKey, restored...)
+	}
+	return restored, nil
+}
+
+// translateArchiveError maps constraint violations to errors the handlers can report
+func translateArchiveError(err error) error {
+	if pqErr, ok := err.(*pq.Error); ok {
+		switch pqErr.Code {
+		case "23503":
+			return ErrReferencedRecord
+		case "23505":
+			return ErrRestoreConflict
+		}
+	}
+	return err
+}
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// ErrVehicleTaken is returned when a vehicle is already driven by another driver that day
+	ErrVehicleTaken = conflict("vehicle is already assigned to another driver that day")
+
+	// ErrDriverTaken is returned when a driver is already driving another vehicle that day
+	ErrDriverTaken = conflict("driver is already assigned to another vehicle that day")
+)
+
+// CapacityExceededError is returned when an assignment would overload a vehicle for the day.
+// Loads include the order being assigned; products without a weight or dimensions count as zero.
+type CapacityExceededError struct {
+	VehicleID  int      `json:"vehicle_id"`
+	LoadKg     float64  `json:"load_kg"`
+	CapacityKg float64  `json:"capacity_kg"`
+	LoadM3     float64  `json:"load_m3"`
+	CapacityM3 *float64 `json:"capacity_m3,omitempty"`
+}
+
+// Error describes which limit the load exceeds
+func (e *CapacityExceededError) Error() string {
+	if e.LoadKg > e.CapacityKg {
+		return fmt.Sprintf("load of %.2f kg exceeds the vehicle capacity of %.2f kg", e.LoadKg, e.CapacityKg)
+	}
+	return fmt.Sprintf("load of %.3f m3 exceeds the vehicle capacity of %.3f m3", e.LoadM3, *e.CapacityM3)
+}
+
+// DeliveryRepository handles database operations for delivery assignments
+type DeliveryRepository struct {
+	db *sqlx.DB
+}
+
+// NewDeliveryRepository creates a new repository with the provided database connection
+func NewDeliveryRepository(db *sqlx.DB) *DeliveryRepository {
+	return &DeliveryRepository{
+		db: db,
+	}
+}
+
+// GetByDate retrieves the assignments for a day, optionally limited to one vehicle
+func (r *DeliveryRepository) GetByDate(ctx context.Context, date time.Time, vehicleID int) ([]models.DeliveryAssignment, error) {
+	assignments := []models.DeliveryAssignment{}
+	query := `
+		SELECT * FROM delivery_assignments
+		WHERE delivery_date = $1 AND ($2 = 0 OR vehicle_id = $2)
+		ORDER BY vehicle_id, stop_sequence, assignment_id`
+	err := r.db.SelectContext(ctx, &assignments, query, date, vehicleID)
+	return assignments, err
+}
+
+// GetByOrderID retrieves the assignment of an order
+func (r *DeliveryRepository) GetByOrderID(ctx context.Context, orderID int) (models.DeliveryAssignment, error) {
+	var assignment models.DeliveryAssignment
+	query := `SELECT * FROM delivery_assignments WHERE order_id = $1`
+	err := r.db.GetContext(ctx, &assignment, query, orderID)
+	if err == sql.ErrNoRows {
+		return assignment, notFound("delivery assignment")
+	}
+	return assignment, err
+}
+
+// Assign schedules an order on a vehicle and driver, replacing any earlier assignment of the
+// order. A vehicle has one driver per day, and its load for the day must stay within capacity;
+// otherwise ErrVehicleTaken, ErrDriverTaken or a *CapacityExceededError is returned.
+func (r *DeliveryRepository) Assign(ctx context.Context, assignment *models.DeliveryAssignment) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the vehicle so concurrent assignments cannot overload it together
+	var vehicle models.Vehicle
+	err = tx.GetContext(ctx, &vehicle, `SELECT * FROM vehicles WHERE vehicle_id = $1 FOR UPDATE`, assignment.VehicleID)
+	if err == sql.ErrNoRows {
+		return notFound("vehicle")
+	}
+	if err != nil {
+		return err
+	}
+
+	var conflicts struct {
+		VehicleTaken bool `db:"vehicle_taken"`
+		DriverTaken  bool `db:"driver_taken"`
+	}
+	err = tx.GetContext(ctx, &conflicts, `
+		SELECT
+			EXISTS(SELECT 1 FROM delivery_assignments
+				WHERE delivery_date = $1 AND vehicle_id = $2 AND driver_id <> $3 AND order_id <> $4) AS vehicle_taken,
+			EXISTS(SELECT 1 FROM delivery_assignments
+				WHERE delivery_date = $1 AND driver_id = $3 AND vehicle_id <> $2 AND order_id <> $4) AS driver_taken`,
+		assignment.DeliveryDate, assignment.VehicleID, assignment.DriverID, assignment.OrderID)
+	if err != nil {
+		return err
+	}
+	if conflicts.VehicleTaken {
+		return ErrVehicleTaken
+	}
+	if conflicts.DriverTaken {
+		return ErrDriverTaken
+	}
+
+	var load struct {
+		WeightKg float64 `db:"weight_kg"`
+		VolumeM3 float64 `db:"volume_m3"`
+	}
+	err = tx.GetContext(ctx, &load, `
+		SELECT
+			COALESCE(SUM(oi.quantity * p.weight_kg), 0) AS weight_kg,
+			COALESCE(SUM(oi.quantity * p.length_cm * p.width_cm * p.height_cm) / 1000000, 0) AS volume_m3
+		FROM order_items oi
+		JOIN products p ON p.product_id = oi.product_id
+		WHERE oi.order_id = $3
+			OR oi.order_id IN (
+				SELECT order_id FROM delivery_assignments
+				WHERE delivery_date = $1 AND vehicle_id = $2 AND order_id <> $3
+			)`,
+		assignment.DeliveryDate, assignment.VehicleID, assignment.OrderID)
+	if err != nil {
+		return err
+	}
+	if load.WeightKg > vehicle.CapacityKg || (vehicle.CapacityM3 != nil && load.VolumeM3 > *vehicle.CapacityM3) {
+		return &CapacityExceededError{
+			VehicleID:  vehicle.VehicleID,
+			LoadKg:     load.WeightKg,
+			CapacityKg: vehicle.CapacityKg,
+			LoadM3:     load.VolumeM3,
+			CapacityM3: vehicle.CapacityM3,
+		}
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO delivery_assignments (order_id, delivery_date, vehicle_id, driver_id, stop_sequence)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (order_id) DO UPDATE SET
+			delivery_date = EXCLUDED.delivery_date,
+			vehicle_id = EXCLUDED.vehicle_id,
+			driver_id = EXCLUDED.driver_id,
+			stop_sequence = EXCLUDED.stop_sequence,
+			updated_at = NOW()
+		RETURNING assignment_id, created_at, updated_at`,
+		assignment.OrderID, assignment.DeliveryDate, assignment.VehicleID, assignment.DriverID, assignment.StopSequence,
+	).Scan(&assignment.AssignmentID, &assignment.CreatedAt, &assignment.UpdatedAt)
+	if err != nil {
+		return translateReferenceError(err)
+	}
+
+	return tx.Commit()
+}
+
+// Delete removes a delivery assignment
+func (r *DeliveryRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM delivery_assignments WHERE assignment_id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound("delivery assignment")
+	}
+
+	return nil
+}
+
+// GetManifestStops retrieves a driver's deliveries for a day in stop order
+func (r *DeliveryRepository) GetManifestStops(ctx context.Context, date time.Time, driverID int) ([]models.ManifestStop, error) {
+	stops := []models.ManifestStop{}
+	query := `
+		SELECT
+			da.assignment_id, da.stop_sequence, o.order_id, c.customer_id, c.company_name, c.phone,
+			o.shipping_address, o.total_amount,
+			COALESCE(SUM(oi.quantity), 0) AS item_count,
+			COALESCE(ROUND(SUM(oi.quantity * p.weight_kg), 3), 0) AS weight_kg,
+			COALESCE(ROUND(SUM(oi.quantity * p.length_cm * p.width_cm * p.height_cm) / 1000000, 4), 0) AS volume_m3
+		FROM delivery_assignments da
+		JOIN orders o ON o.order_id = da.order_id
+		JOIN customers c ON c.customer_id = o.customer_id
+		LEFT JOIN order_items oi ON oi.order_id = o.order_id
+		LEFT JOIN products p ON p.product_id = oi.product_id
+		WHERE da.delivery_date = $1 AND da.driver_id = $2
+		GROUP BY da.assignment_id, o.order_id, c.customer_id
+		ORDER BY da.stop_sequence, da.assignment_id`
+	err := r.db.SelectContext(ctx, &stops, query, date, driverID)
+	return stops, err
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+)
+
+// dependencyRow is a single reference to an owner record found by a dependency query
+type dependencyRow struct {
+	OwnerID int    `db:"owner_id"`
+	Entity  string `db:"entity"`
+	RefID   int    `db:"ref_id"`
+	State   string `db:"state"`
+}
+
+// dependencyRule describes how a group of references is reported
+type dependencyRule struct {
+	reason   string
+	blocking bool
+}
+
+// groupDependencies groups reference rows by owner, entity and state using the rules keyed by "entity/state"
+func groupDependencies(rows []dependencyRow, rules map[string]dependencyRule) map[int][]models.Dependency {
+	result := map[int][]models.Dependency{}
+	index := map[string]int{}
+
+	for _, row := range rows {
+		key := fmt.Sprintf("%d/%s/%s", row.OwnerID, row.Entity, row.State)
+		if i, ok := index[key]; ok {
+			result[row.OwnerID][i].IDs = append(result[row.OwnerID][i].IDs, row.RefID)
+			continue
+		}
+
+		rule, ok := rules[row.Entity+"/"+row.State]
+		if !ok {
+			rule = dependencyRule{reason: "referenced by " + row.Entity, blocking: true}
+		}
+		index[key] = len(result[row.OwnerID])
+		result[row.OwnerID] = append(result[row.OwnerID], models.Dependency{
+			Entity:   row.Entity,
+			IDs:      []int{row.RefID},
+			Reason:   rule.reason,
+			Blocking: rule.blocking,
+		})
+	}
+
+	return result
+}
+
+// HasBlockingDependency reports whether any dependency prevents deletion
+func HasBlockingDependency(dependencies []models.Dependency) bool {
+	for _, dependency := range dependencies {
+		if dependency.Blocking {
+			return true
+		}
+	}
+	return false
+}
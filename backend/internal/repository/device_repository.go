@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// DeviceRepository handles database operations for registered devices
+type DeviceRepository struct {
+	db *sqlx.DB
+}
+
+// NewDeviceRepository creates a new repository with the provided database connection
+func NewDeviceRepository(db *sqlx.DB) *DeviceRepository {
+	return &DeviceRepository{
+		db: db,
+	}
+}
+
+// GetAll retrieves all registered devices, newest first
+func (r *DeviceRepository) GetAll(ctx context.Context) ([]models.Device, error) {
+	devices := []models.Device{}
+	query := `SELECT * FROM devices ORDER BY created_at DESC`
+	err := r.db.SelectContext(ctx, &devices, query)
+	return devices, err
+}
+
+// GetByID retrieves a device by ID
+func (r *DeviceRepository) GetByID(ctx context.Context, id int) (models.Device, error) {
+	var device models.Device
+	query := `SELECT * FROM devices WHERE device_id = $1`
+	err := r.db.GetContext(ctx, &device, query, id)
+	if err == sql.ErrNoRows {
+		return device, notFound("device")
+	}
+	return device, err
+}
+
+// GetByTokenHash retrieves a device by the hash of its token
+func (r *DeviceRepository) GetByTokenHash(ctx context.Context, tokenHash string) (models.Device, error) {
+	var device models.Device
+	query := `SELECT * FROM devices WHERE token_hash = $1`
+	err := r.db.GetContext(ctx, &device, query, tokenHash)
+	if err == sql.ErrNoRows {
+		return device, notFound("device")
+	}
+	return device, err
+}
+
+// Create inserts a new device into the database
+func (r *DeviceRepository) Create(ctx context.Context, device *models.Device) error {
+	query := `
+		INSERT INTO devices (
+			name, device_type, token_hash, token_prefix, registered_by
+		) VALUES (
+			$1, $2, $3, $4, $5
+		) RETURNING device_id, created_at`
+
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		device.Name,
+		device.DeviceType,
+		device.TokenHash,
+		device.TokenPrefix,
+		device.RegisteredBy,
+	).Scan(&device.DeviceID, &device.CreatedAt)
+}
+
+// Touch records that a device was seen, at most once a minute to keep writes down
+func (r *DeviceRepository) Touch(ctx context.Context, id int, ip string) error {
+	query := `
+		UPDATE devices SET
+			last_seen_at = NOW(),
+			last_seen_ip = $2
+		WHERE device_id = $1
+		AND (last_seen_at IS NULL OR last_seen_at < NOW() - INTERVAL '1 minute' OR last_seen_ip IS DISTINCT FROM $2)`
+
+	_, err := r.db.ExecContext(ctx, query, id, ip)
+	return err
+}
+
+// Revoke marks a device token as revoked
+func (r *DeviceRepository) Revoke(ctx context.Context, id int) error {
+	query := `UPDATE devices SET revoked_at = COALESCE(revoked_at, NOW()) WHERE device_id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound("device")
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// numberedTable describes the table holding the documents of a number series. Names are
+// fixed here and never taken from requests.
+type numberedTable struct {
+	table string
+	key   string
+	// cancelledStatus is the status of documents that are voided but kept, if any
+	cancelledStatus string
+}
+
+var numberedTables = map[string]numberedTable{
+	models.DocumentSeriesSalesInvoice: {table: "orders", key: "order_id", cancelledStatus: "Cancelled"},
+	models.DocumentSeriesQuotation:    {table: "quotations", key: "quotation_id"},
+	models.DocumentSeriesCreditNote:   {table: "invoice_adjustments", key: "adjustment_id"},
+	models.DocumentSeriesInvoice:      {table: "invoices", key: "invoice_id"},
+}
+
+// nextDocumentNo takes the next number of a series. The series row stays locked until tx
+// ends, so numbers are handed out in order and one taken by a rolled back transaction is
+// reused.
+func nextDocumentNo(ctx context.Context, tx *sqlx.Tx, series string) (int, error) {
+	var number int
+	query := `UPDATE document_series SET next_number = next_number + 1 WHERE series = $1 RETURNING next_number - 1`
+	err := tx.QueryRowContext(ctx, query, series).Scan(&number)
+	return number, err
+}
+
+// voidDocument records that a document's number was voided by cancelling or deleting it. It
+// must run before a deleted document is removed; documents without a number are skipped.
+func voidDocument(ctx context.Context, tx *sqlx.Tx, series string, documentID int, action, reason string, voidedBy *int) error {
+	t := numberedTables[series]
+	query := `
+		INSERT INTO voided_documents (series, document_no, document_id, action, reason, voided_by)
+		SELECT $1, document_no, $2, $3, $4, $5 FROM ` + t.table + `
+		WHERE ` + t.key + ` = $2 AND document_no IS NOT NULL
+		ON CONFLICT (series, document_no) DO UPDATE SET
+			action = EXCLUDED.action,
+			reason = CASE WHEN EXCLUDED.reason = '' THEN voided_documents.reason ELSE EXCLUDED.reason END,
+			voided_by = EXCLUDED.voided_by,
+			voided_at = NOW()`
+	_, err := tx.ExecContext(ctx, query, series, documentID, action, reason, voidedBy)
+	return err
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// DocumentPreviewRepository handles database operations for document previews
+type DocumentPreviewRepository struct {
+	db *sqlx.DB
+}
+
+// NewDocumentPreviewRepository creates a new repository with the provided database connection
+func NewDocumentPreviewRepository(db *sqlx.DB) *DocumentPreviewRepository {
+	return &DocumentPreviewRepository{
+		db: db,
+	}
+}
+
+// Get retrieves a document's preview. A *NotFoundError naming the entity is returned when the
+// document does not exist; a document without a preview yet has an empty Status.
+func (r *DocumentPreviewRepository) Get(ctx context.Context, entity string, id int) (models.DocumentPreview, error) {
+	var preview models.DocumentPreview
+	t, ok := documentTables[entity]
+	if !ok {
+		return preview, fmt.Errorf("unsupported document type: %s", entity)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT $1::TEXT AS entity, d.%[2]s AS entity_id, COALESCE(p.status, '') AS status, p.content,
+			COALESCE(p.width, 0) AS width, COALESCE(p.height, 0) AS height, p.message,
+			p.requested_at, p.generated_at, COALESCE(d.%[3]s > p.requested_at, FALSE) AS stale
+		FROM %[1]s d
+		LEFT JOIN document_previews p ON p.entity = $1 AND p.entity_id = d.%[2]s
+		WHERE d.%[2]s = $2`, t.table, t.key, t.version)
+	err := r.db.GetContext(ctx, &preview, query, entity, id)
+	if err == sql.ErrNoRows {
+		return preview, notFound(entity)
+	}
+	return preview, err
+}
+
+// Request marks a document's preview as being made, keeping any earlier preview until the new
+// one is saved. It reports false when the preview was already asked for in the last 10
+// minutes and has not been made yet, so one is not queued twice.
+func (r *DocumentPreviewRepository) Request(ctx context.Context, entity string, id int) (bool, error) {
+	query := `
+		INSERT INTO document_previews (entity, entity_id, status)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (entity, entity_id) DO UPDATE SET status = $3, requested_at = NOW()
+		WHERE document_previews.status <> $3 OR document_previews.requested_at < NOW() - INTERVAL '10 minutes'
+		RETURNING TRUE`
+	var requested bool
+	err := r.db.GetContext(ctx, &requested, query, entity, id, models.PreviewPending)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return requested, err
+}
+
+// Save stores a document's new preview
+func (r *DocumentPreviewRepository) Save(ctx context.Context, entity string, id int, content []byte, width, height int) error {
+	query := `
+		INSERT INTO document_previews (entity, entity_id, status, content, width, height, generated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (entity, entity_id) DO UPDATE SET
+			status = $3, content = $4, width = $5, height = $6, message = NULL, generated_at = NOW()`
+	_, err := r.db.ExecContext(ctx, query, entity, id, models.PreviewReady, content, width, height)
+	return err
+}
+
+// MarkUnavailable records why no preview can be made of a document, dropping any earlier one
+func (r *DocumentPreviewRepository) MarkUnavailable(ctx context.Context, entity string, id int, message string) error {
+	query := `
+		INSERT INTO document_previews (entity, entity_id, status, message, generated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (entity, entity_id) DO UPDATE SET
+			status = $3, content = NULL, width = 0, height = 0, message = $4, generated_at = NOW()`
+	_, err := r.db.ExecContext(ctx, query, entity, id, models.PreviewUnavailable, message)
+	return err
+}
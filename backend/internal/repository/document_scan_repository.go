@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// ErrScanNotInReview is returned when changing or confirming a document scan that is not
+// waiting for review
+var ErrScanNotInReview = conflict("only document scans in review can be changed or confirmed")
+
+// DocumentScanRepository handles database operations for document scans
+type DocumentScanRepository struct {
+	db *sqlx.DB
+}
+
+// NewDocumentScanRepository creates a new repository with the provided database connection
+func NewDocumentScanRepository(db *sqlx.DB) *DocumentScanRepository {
+	return &DocumentScanRepository{
+		db: db,
+	}
+}
+
+// Create saves a new document scan, to be read once its file is stored
+func (r *DocumentScanRepository) Create(ctx context.Context, scan *models.DocumentScan) error {
+	query := `
+		INSERT INTO document_scans (kind, status, created_by)
+		VALUES ($1, $2, $3)
+		RETURNING scan_id, created_at, updated_at`
+	return r.db.QueryRowContext(ctx, query, scan.Kind, scan.Status, scan.CreatedBy).
+		Scan(&scan.ScanID, &scan.CreatedAt, &scan.UpdatedAt)
+}
+
+// SetAttachment records the uploaded file of a document scan
+func (r *DocumentScanRepository) SetAttachment(ctx context.Context, id, attachmentID int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE document_scans SET attachment_id = $2 WHERE scan_id = $1`, id, attachmentID)
+	return err
+}
+
+// GetAll retrieves document scans, newest first, optionally only those of a kind or status
+func (r *DocumentScanRepository) GetAll(ctx context.Context, kind, status string, page Page) ([]models.DocumentScan, int, error) {
+	scans := []models.DocumentScan{}
+	query := `
+		SELECT * FROM document_scans
+		WHERE ($1 = '' OR kind = $1) AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC, scan_id DESC`
+	total, err := selectPage(ctx, r.db, &scans, page, query, kind, status)
+	return scans, total, err
+}
+
+// GetByID retrieves a document scan by ID
+func (r *DocumentScanRepository) GetByID(ctx context.Context, id int) (models.DocumentScan, error) {
+	var scan models.DocumentScan
+	err := r.db.GetContext(ctx, &scan, `SELECT * FROM document_scans WHERE scan_id = $1`, id)
+	if err == sql.ErrNoRows {
+		return scan, notFound("document scan")
+	}
+	return scan, err
+}
+
+// SaveDraft stores what was read from a document scan and puts it up for review. Scans no
+// longer Processing, such as discarded ones, are left as they are.
+func (r *DocumentScanRepository) SaveDraft(ctx context.Context, scan *models.DocumentScan) error {
+	query := `
+		UPDATE document_scans SET
+			status = $2, provider = $3, text = $4, supplier_id = $5, purchase_order_id = $6,
+			customer_id = $7, document_number = $8, document_date = $9, total_amount = $10,
+			lines = $11, message = NULL, updated_at = NOW()
+		WHERE scan_id = $1 AND status = $12`
+	_, err := r.db.ExecContext(ctx, query,
+		scan.ScanID,
+		models.ScanReview,
+		scan.Provider,
+		scan.Text,
+		scan.SupplierID,
+		scan.PurchaseOrderID,
+		scan.CustomerID,
+		scan.DocumentNumber,
+		scan.DocumentDate,
+		scan.TotalAmount,
+		scan.Lines,
+		models.ScanProcessing,
+	)
+	return err
+}
+
+// MarkFailed records why a document scan could not be read
+func (r *DocumentScanRepository) MarkFailed(ctx context.Context, id int, message string) error {
+	query := `
+		UPDATE document_scans SET status = $2, message = $3, updated_at = NOW()
+		WHERE scan_id = $1 AND status = $4`
+	_, err := r.db.ExecContext(ctx, query, id, models.ScanFailed, message, models.ScanProcessing)
+	return err
+}
+
+// UpdateDraft saves corrections made to a document scan's draft in review.
+// ErrScanNotInReview is returned for scans not in review, and ErrReferencedRecord when the
+// supplier, purchase order or customer does not exist.
+func (r *DocumentScanRepository) UpdateDraft(ctx context.Context, scan *models.DocumentScan) error {
+	query := `
+		UPDATE document_scans SET
+			supplier_id = $2, purchase_order_id = $3, customer_id = $4, document_number = $5,
+			document_date = $6, total_amount = $7, lines = $8, updated_at = NOW()
+		WHERE scan_id = $1 AND status = $9
+		RETURNING *`
+	err := r.db.GetContext(ctx, scan, query,
+		scan.ScanID,
+		scan.SupplierID,
+		scan.PurchaseOrderID,
+		scan.CustomerID,
+		scan.DocumentNumber,
+		scan.DocumentDate,
+		scan.TotalAmount,
+		scan.Lines,
+		models.ScanReview,
+	)
+	if err == sql.ErrNoRows {
+		return r.notInReview(ctx, scan.ScanID)
+	}
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+		return ErrReferencedRecord
+	}
+	return err
+}
+
+// Confirm records the supplier invoice or order a document scan was confirmed as.
+// ErrScanNotInReview is returned for scans not in review.
+func (r *DocumentScanRepository) Confirm(ctx context.Context, id, documentID int, confirmedBy *int) (models.DocumentScan, error) {
+	var scan models.DocumentScan
+	query := `
+		UPDATE document_scans SET
+			status = $2, document_id = $3, confirmed_by = $4, confirmed_at = NOW(), updated_at = NOW()
+		WHERE scan_id = $1 AND status = $5
+		RETURNING *`
+	err := r.db.GetContext(ctx, &scan, query, id, models.ScanConfirmed, documentID, confirmedBy, models.ScanReview)
+	if err == sql.ErrNoRows {
+		return scan, r.notInReview(ctx, id)
+	}
+	return scan, err
+}
+
+// Discard sets a document scan aside. Confirmed scans cannot be discarded: ErrConflict is
+// returned for them.
+func (r *DocumentScanRepository) Discard(ctx context.Context, id int) (models.DocumentScan, error) {
+	var scan models.DocumentScan
+	query := `
+		UPDATE document_scans SET status = $2, updated_at = NOW()
+		WHERE scan_id = $1 AND status <> $3
+		RETURNING *`
+	err := r.db.GetContext(ctx, &scan, query, id, models.ScanDiscarded, models.ScanConfirmed)
+	if err == sql.ErrNoRows {
+		if _, getErr := r.GetByID(ctx, id); getErr != nil {
+			return scan, getErr
+		}
+		return scan, conflict("confirmed document scans cannot be discarded")
+	}
+	return scan, err
+}
+
+// Delete removes a document scan, used when its file could not be stored
+func (r *DocumentScanRepository) Delete(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM document_scans WHERE scan_id = $1`, id)
+	return err
+}
+
+// notInReview returns the error for a scan that was not updated: not found, or not in review
+func (r *DocumentScanRepository) notInReview(ctx context.Context, id int) error {
+	if _, err := r.GetByID(ctx, id); err != nil {
+		return err
+	}
+	return ErrScanNotInReview
+}
+
+// MatchSupplier returns the active supplier whose name appears in a scanned text, the longest
+// name winning, or nil
+func (r *DocumentScanRepository) MatchSupplier(ctx context.Context, text string) (*int, error) {
+	query := `
+		SELECT supplier_id FROM suppliers
+		WHERE active AND LENGTH(name) >= 3 AND POSITION(LOWER(name) IN LOWER($1)) > 0
+		ORDER BY LENGTH(name) DESC, supplier_id
+		LIMIT 1`
+	return r.matchID(ctx, query, text)
+}
+
+// MatchCustomer returns the active customer whose company name appears in a scanned text, the
+// longest name winning, or nil
+func (r *DocumentScanRepository) MatchCustomer(ctx context.Context, text string) (*int, error) {
+	query := `
+		SELECT customer_id FROM customers
+		WHERE deleted_at IS NULL AND archived_at IS NULL
+			AND LENGTH(company_name) >= 3 AND POSITION(LOWER(company_name) IN LOWER($1)) > 0
+		ORDER BY LENGTH(company_name) DESC, customer_id
+		LIMIT 1`
+	return r.matchID(ctx, query, text)
+}
+
+// MatchPurchaseOrder returns the first of the given purchase orders that was placed with the
+// supplier, or with any supplier when supplierID is nil
+func (r *DocumentScanRepository) MatchPurchaseOrder(ctx context.Context, ids []int, supplierID *int) (*int, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	query := `
+		SELECT purchase_order_id FROM purchase_orders
+		WHERE purchase_order_id = ANY($1::INTEGER[]) AND ($2::INTEGER IS NULL OR supplier_id = $2)
+		ORDER BY ARRAY_POSITION($1::INTEGER[], purchase_order_id)
+		LIMIT 1`
+	return r.matchID(ctx, query, pq.Array(ids), supplierID)
+}
+
+// MatchProduct returns the product a scanned line's description names, or nil. The
+// supplier's own SKUs are tried first, then product models and then product names, the
+// longest match winning.
+func (r *DocumentScanRepository) MatchProduct(ctx context.Context, description string, supplierID *int) (*int, error) {
+	query := `
+		SELECT product_id FROM (
+			SELECT sp.product_id, 1 AS rank, LENGTH(sp.supplier_sku) AS length
+			FROM supplier_products sp
+			WHERE sp.supplier_id = $2 AND LENGTH(sp.supplier_sku) >= 3
+				AND POSITION(LOWER(sp.supplier_sku) IN LOWER($1)) > 0
+			UNION ALL
+			SELECT product_id, 2, LENGTH(model) FROM products
+			WHERE LENGTH(model) >= 3 AND POSITION(LOWER(model) IN LOWER($1)) > 0
+			UNION ALL
+			SELECT product_id, 3, LENGTH(product_name) FROM products
+			WHERE LENGTH(product_name) >= 3 AND POSITION(LOWER(product_name) IN LOWER($1)) > 0
+		) m
+		WHERE product_id IN (SELECT product_id FROM products WHERE deleted_at IS NULL)
+		ORDER BY rank, length DESC, product_id
+		LIMIT 1`
+	return r.matchID(ctx, query, description, supplierID)
+}
+
+// matchID runs a query selecting at most one ID, returning nil when there is no row
+func (r *DocumentScanRepository) matchID(ctx context.Context, query string, args ...interface{}) (*int, error) {
+	var id int
+	err := r.db.GetContext(ctx, &id, query, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
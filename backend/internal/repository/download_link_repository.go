@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrDownloadLinkUnavailable is returned when downloading from a link that has expired, been
+// revoked or, if single-use, already been downloaded from
+var ErrDownloadLinkUnavailable = conflict("download link has expired or has already been used")
+
+// documentTables maps each document type links and previews are made for to its table, key
+// and the column that changes whenever the document does. Names are fixed here and never
+// taken from requests.
+var documentTables = map[string]struct{ table, key, version string }{
+	models.DownloadQuotation:  {table: "quotations", key: "quotation_id", version: "updated_at"},
+	models.DownloadOrder:      {table: "orders", key: "order_id", version: "updated_at"},
+	models.DownloadInvoice:    {table: "invoices", key: "invoice_id", version: "created_at"},
+	models.DownloadAttachment: {table: "attachments", key: "attachment_id", version: "created_at"},
+}
+
+// DownloadLinkRepository handles database operations for download links
+type DownloadLinkRepository struct {
+	db *sqlx.DB
+}
+
+// NewDownloadLinkRepository creates a new repository with the provided database connection
+func NewDownloadLinkRepository(db *sqlx.DB) *DownloadLinkRepository {
+	return &DownloadLinkRepository{
+		db: db,
+	}
+}
+
+// Create saves a new download link. The document must exist: a *NotFoundError naming the
+// document type is returned otherwise.
+func (r *DownloadLinkRepository) Create(ctx context.Context, link *models.DownloadLink) error {
+	t, ok := documentTables[link.DocumentType]
+	if !ok {
+		return fmt.Errorf("unsupported document type: %s", link.DocumentType)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO download_links (token_hash, document_type, document_id, single_use, expires_at, created_by)
+		SELECT $1, $2, $3, $4, $5, $6
+		WHERE EXISTS (SELECT 1 FROM %s WHERE %s = $3)
+		RETURNING download_link_id, created_at`, t.table, t.key)
+
+	err := r.db.QueryRowContext(ctx, query,
+		link.TokenHash,
+		link.DocumentType,
+		link.DocumentID,
+		link.SingleUse,
+		link.ExpiresAt,
+		link.CreatedBy,
+	).Scan(&link.DownloadLinkID, &link.CreatedAt)
+	if err == sql.ErrNoRows {
+		return notFound(link.DocumentType)
+	}
+	return err
+}
+
+// GetByDocument retrieves the links made for a document, newest first
+func (r *DownloadLinkRepository) GetByDocument(ctx context.Context, documentType string, documentID int) ([]models.DownloadLink, error) {
+	links := []models.DownloadLink{}
+	query := `
+		SELECT * FROM download_links
+		WHERE document_type = $1 AND document_id = $2
+		ORDER BY created_at DESC, download_link_id DESC`
+	err := r.db.SelectContext(ctx, &links, query, documentType, documentID)
+	return links, err
+}
+
+// GetByID retrieves a download link by ID
+func (r *DownloadLinkRepository) GetByID(ctx context.Context, id int) (models.DownloadLink, error) {
+	var link models.DownloadLink
+	err := r.db.GetContext(ctx, &link, `SELECT * FROM download_links WHERE download_link_id = $1`, id)
+	if err == sql.ErrNoRows {
+		return link, notFound("download link")
+	}
+	return link, err
+}
+
+// Use counts a download from the link with the given token hash and returns the link. The
+// check and the count are one statement, so a single-use link is only ever downloaded once;
+// ErrDownloadLinkUnavailable is returned for links that can no longer be used.
+func (r *DownloadLinkRepository) Use(ctx context.Context, tokenHash string) (models.DownloadLink, error) {
+	var link models.DownloadLink
+	query := `
+		UPDATE download_links SET download_count = download_count + 1, last_used_at = NOW()
+		WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > NOW()
+			AND (NOT single_use OR download_count = 0)
+		RETURNING *`
+	err := r.db.GetContext(ctx, &link, query, tokenHash)
+	if err != sql.ErrNoRows {
+		return link, err
+	}
+
+	var exists bool
+	if err := r.db.GetContext(ctx, &exists, `SELECT EXISTS(SELECT 1 FROM download_links WHERE token_hash = $1)`, tokenHash); err != nil {
+		return link, err
+	}
+	if !exists {
+		return link, notFound("download link")
+	}
+	return link, ErrDownloadLinkUnavailable
+}
+
+// Revoke stops a download link from working. Revoking a link twice keeps the first time.
+func (r *DownloadLinkRepository) Revoke(ctx context.Context, id int) (models.DownloadLink, error) {
+	var link models.DownloadLink
+	query := `
+		UPDATE download_links SET revoked_at = COALESCE(revoked_at, NOW())
+		WHERE download_link_id = $1
+		RETURNING *`
+	err := r.db.GetContext(ctx, &link, query, id)
+	if err == sql.ErrNoRows {
+		return link, notFound("download link")
+	}
+	return link, err
+}
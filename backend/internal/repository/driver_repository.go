@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// DriverRepository handles database operations for delivery drivers
+type DriverRepository struct {
+	db *sqlx.DB
+}
+
+// NewDriverRepository creates a new repository with the provided database connection
+func NewDriverRepository(db *sqlx.DB) *DriverRepository {
+	return &DriverRepository{
+		db: db,
+	}
+}
+
+// GetAll retrieves all drivers ordered by name
+func (r *DriverRepository) GetAll(ctx context.Context) ([]models.Driver, error) {
+	drivers := []models.Driver{}
+	query := `SELECT * FROM drivers ORDER BY name`
+	err := r.db.SelectContext(ctx, &drivers, query)
+	return drivers, err
+}
+
+// GetByID retrieves a driver by ID
+func (r *DriverRepository) GetByID(ctx context.Context, id int) (models.Driver, error) {
+	var driver models.Driver
+	query := `SELECT * FROM drivers WHERE driver_id = $1`
+	err := r.db.GetContext(ctx, &driver, query, id)
+	if err == sql.ErrNoRows {
+		return driver, notFound("driver")
+	}
+	return driver, err
+}
+
+// Create inserts a new driver
+func (r *DriverRepository) Create(ctx context.Context, driver *models.Driver) error {
+	query := `
+		INSERT INTO drivers (name, phone, license_number, user_id, active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING driver_id, created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query, driver.Name, driver.Phone, driver.LicenseNumber, driver.UserID, driver.Active).
+		Scan(&driver.DriverID, &driver.CreatedAt, &driver.UpdatedAt)
+
+	return translateReferenceError(err)
+}
+
+// Update updates an existing driver
+func (r *DriverRepository) Update(ctx context.Context, driver *models.Driver) error {
+	query := `
+		UPDATE drivers SET
+			name = $1,
+			phone = $2,
+			license_number = $3,
+			user_id = $4,
+			active = $5,
+			updated_at = NOW()
+		WHERE driver_id = $6
+		RETURNING created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query, driver.Name, driver.Phone, driver.LicenseNumber, driver.UserID, driver.Active, driver.DriverID).
+		Scan(&driver.CreatedAt, &driver.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return notFound("driver")
+	}
+
+	return translateReferenceError(err)
+}
+
+// Delete removes a driver. Drivers with delivery assignments cannot be deleted; deactivate them instead.
+func (r *DriverRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM drivers WHERE driver_id = $1`, id)
+	if err != nil {
+		return translateReferenceError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound("driver")
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// EmailDeliveryRepository handles database operations for email deliveries
+type EmailDeliveryRepository struct {
+	db *sqlx.DB
+}
+
+// NewEmailDeliveryRepository creates a new repository with the provided database connection
+func NewEmailDeliveryRepository(db *sqlx.DB) *EmailDeliveryRepository {
+	return &EmailDeliveryRepository{
+		db: db,
+	}
+}
+
+// GetByDocument retrieves the emails sent about a document, newest first
+func (r *EmailDeliveryRepository) GetByDocument(ctx context.Context, documentType string, documentID int) ([]models.EmailDelivery, error) {
+	deliveries := []models.EmailDelivery{}
+	query := `
+		SELECT * FROM email_deliveries
+		WHERE document_type = $1 AND document_id = $2
+		ORDER BY created_at DESC, email_delivery_id DESC`
+	err := r.db.SelectContext(ctx, &deliveries, query, documentType, documentID)
+	return deliveries, err
+}
+
+// Create records an email delivery
+func (r *EmailDeliveryRepository) Create(ctx context.Context, delivery *models.EmailDelivery) error {
+	delivery.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO email_deliveries (
+			document_type, document_id, contact_id, recipient, subject, status, error, sent_by, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9
+		) RETURNING email_delivery_id`
+
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		delivery.DocumentType,
+		delivery.DocumentID,
+		delivery.ContactID,
+		delivery.Recipient,
+		delivery.Subject,
+		delivery.Status,
+		delivery.Error,
+		delivery.SentBy,
+		delivery.CreatedAt,
+	).Scan(&delivery.EmailDeliveryID)
+}
@@ -0,0 +1,75 @@
+package repository
+
+import "errors"
+
+var (
+	// ErrNotFound matches every error returned when a record does not exist; use
+	// errors.Is(err, ErrNotFound)
+	ErrNotFound = errors.New("record not found")
+
+	// ErrConflict matches every error returned when a write clashes with existing data;
+	// use errors.Is(err, ErrConflict)
+	ErrConflict = errors.New("record conflicts with existing data")
+
+	// ErrDuplicateKey is returned when a unique constraint is violated
+	ErrDuplicateKey = conflict("duplicate key value violates unique constraint")
+
+	// ErrReferencedRecord is returned when a delete fails because another row still references the record
+	ErrReferencedRecord = conflict("record is referenced by other data")
+
+	// ErrStaleVersion is returned when updating a record from a version that someone else has
+	// changed since
+	ErrStaleVersion = conflict("record was changed by someone else")
+
+	// ErrNotDeleted is returned when purging a record that has not been soft-deleted first
+	ErrNotDeleted = conflict("only deleted records can be purged")
+)
+
+// NotFoundError reports that a record of an entity does not exist. Its message names the
+// entity, e.g. "order not found".
+type NotFoundError struct {
+	Entity string
+}
+
+// Error implements error
+func (e *NotFoundError) Error() string {
+	return e.Entity + " not found"
+}
+
+// Is makes errors.Is(err, ErrNotFound) match
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// notFound returns the error for a missing record of an entity
+func notFound(entity string) error {
+	return &NotFoundError{Entity: entity}
+}
+
+// ConflictError is a write rejected because of existing data, such as a duplicate key or an
+// overlapping period
+type ConflictError struct {
+	message string
+}
+
+// Error implements error
+func (e *ConflictError) Error() string {
+	return e.message
+}
+
+// Is makes errors.Is(err, ErrConflict) match
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// conflict creates a conflict error with the given message
+func conflict(message string) error {
+	return &ConflictError{message: message}
+}
+
+// IsNotFound reports whether err says that a record of the given entity does not exist, for
+// callers that need to tell apart which of several records was missing
+func IsNotFound(err error, entity string) bool {
+	var notFoundErr *NotFoundError
+	return errors.As(err, &notFoundErr) && notFoundErr.Entity == entity
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// FreightRepository handles database operations for delivery zones and freight rates
+type FreightRepository struct {
+	db *sqlx.DB
+}
+
+// NewFreightRepository creates a new repository with the provided database connection
+func NewFreightRepository(db *sqlx.DB) *FreightRepository {
+	return &FreightRepository{
+		db: db,
+	}
+}
+
+// GetZones retrieves all delivery zones ordered by name
+func (r *FreightRepository) GetZones(ctx context.Context) ([]models.DeliveryZone, error) {
+	zones := []models.DeliveryZone{}
+	query := `SELECT * FROM delivery_zones ORDER BY zone`
+	err := r.db.SelectContext(ctx, &zones, query)
+	return zones, err
+}
+
+// FindZone retrieves the zone that lists a province, ignoring case, or the default zone
+// when no zone lists it
+func (r *FreightRepository) FindZone(ctx context.Context, province string) (models.DeliveryZone, error) {
+	var zone models.DeliveryZone
+	query := `
+		SELECT * FROM delivery_zones
+		WHERE is_default
+			OR EXISTS (SELECT 1 FROM jsonb_array_elements_text(provinces) p WHERE LOWER(p) = LOWER($1))
+		ORDER BY is_default
+		LIMIT 1`
+	err := r.db.GetContext(ctx, &zone, query, province)
+	if err == sql.ErrNoRows {
+		return zone, notFound("delivery zone")
+	}
+	return zone, err
+}
+
+// SaveZone creates a delivery zone or replaces its provinces. Marking a zone as the
+// default clears the flag on every other zone.
+func (r *FreightRepository) SaveZone(ctx context.Context, zone *models.DeliveryZone) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if zone.IsDefault {
+		if _, err := tx.ExecContext(ctx, `UPDATE delivery_zones SET is_default = FALSE, updated_at = NOW() WHERE is_default AND zone <> $1`, zone.Zone); err != nil {
+			return err
+		}
+	}
+
+	query := `
+		INSERT INTO delivery_zones (zone, provinces, is_default)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (zone) DO UPDATE SET
+			provinces = EXCLUDED.provinces,
+			is_default = EXCLUDED.is_default,
+			updated_at = NOW()
+		RETURNING created_at, updated_at`
+	err = tx.QueryRowContext(ctx, query, zone.Zone, zone.Provinces, zone.IsDefault).
+		Scan(&zone.CreatedAt, &zone.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteZone removes a delivery zone together with its rates
+func (r *FreightRepository) DeleteZone(ctx context.Context, zone string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM delivery_zones WHERE zone = $1`, zone)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound("delivery zone")
+	}
+
+	return nil
+}
+
+// GetRates retrieves freight rates, optionally limited to one zone
+func (r *FreightRepository) GetRates(ctx context.Context, zone string) ([]models.FreightRate, error) {
+	rates := []models.FreightRate{}
+	query := `SELECT * FROM freight_rates WHERE $1 = '' OR zone = $1 ORDER BY zone, min_weight_kg`
+	err := r.db.SelectContext(ctx, &rates, query, zone)
+	return rates, err
+}
+
+// GetRateByID retrieves a freight rate by ID
+func (r *FreightRepository) GetRateByID(ctx context.Context, id int) (models.FreightRate, error) {
+	var rate models.FreightRate
+	query := `SELECT * FROM freight_rates WHERE freight_rate_id = $1`
+	err := r.db.GetContext(ctx, &rate, query, id)
+	if err == sql.ErrNoRows {
+		return rate, notFound("freight rate")
+	}
+	return rate, err
+}
+
+// FindRate retrieves the bracket of a zone that covers a weight
+func (r *FreightRepository) FindRate(ctx context.Context, zone string, weightKg float64) (models.FreightRate, error) {
+	var rate models.FreightRate
+	query := `
+		SELECT * FROM freight_rates
+		WHERE zone = $1 AND min_weight_kg <= $2 AND (max_weight_kg IS NULL OR max_weight_kg > $2)
+		ORDER BY min_weight_kg DESC
+		LIMIT 1`
+	err := r.db.GetContext(ctx, &rate, query, zone, weightKg)
+	if err == sql.ErrNoRows {
+		return rate, notFound("freight rate")
+	}
+	return rate, err
+}
+
+// CreateRate inserts a new freight rate
+func (r *FreightRepository) CreateRate(ctx context.Context, rate *models.FreightRate) error {
+	query := `
+		INSERT INTO freight_rates (zone, min_weight_kg, max_weight_kg, base_fee, per_kg_fee)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING freight_rate_id, created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query, rate.Zone, rate.MinWeightKg, rate.MaxWeightKg, rate.BaseFee, rate.PerKgFee).
+		Scan(&rate.FreightRateID, &rate.CreatedAt, &rate.UpdatedAt)
+
+	return translateReferenceError(err)
+}
+
+// UpdateRate updates an existing freight rate
+func (r *FreightRepository) UpdateRate(ctx context.Context, rate *models.FreightRate) error {
+	query := `
+		UPDATE freight_rates SET
+			zone = $1,
+			min_weight_kg = $2,
+			max_weight_kg = $3,
+			base_fee = $4,
+			per_kg_fee = $5,
+			updated_at = NOW()
+		WHERE freight_rate_id = $6
+		RETURNING created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query, rate.Zone, rate.MinWeightKg, rate.MaxWeightKg, rate.BaseFee, rate.PerKgFee, rate.FreightRateID).
+		Scan(&rate.CreatedAt, &rate.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return notFound("freight rate")
+	}
+
+	return translateReferenceError(err)
+}
+
+// DeleteRate removes a freight rate
+func (r *FreightRepository) DeleteRate(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM freight_rates WHERE freight_rate_id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound("freight rate")
+	}
+
+	return nil
+}
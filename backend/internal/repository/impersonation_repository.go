@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// ImpersonationRepository handles database operations for impersonation sessions
+type ImpersonationRepository struct {
+	db *sqlx.DB
+}
+
+// NewImpersonationRepository creates a new repository with the provided database connection
+func NewImpersonationRepository(db *sqlx.DB) *ImpersonationRepository {
+	return &ImpersonationRepository{
+		db: db,
+	}
+}
+
+// GetActiveByTokenHash retrieves an unexpired, unended session by token hash
+func (r *ImpersonationRepository) GetActiveByTokenHash(ctx context.Context, tokenHash string) (models.ImpersonationSession, error) {
+	var session models.ImpersonationSession
+	query := `
+		SELECT * FROM impersonation_sessions
+		WHERE token_hash = $1 AND ended_at IS NULL AND expires_at > NOW()`
+	err := r.db.GetContext(ctx, &session, query, tokenHash)
+	if err == sql.ErrNoRows {
+		return session, notFound("impersonation session")
+	}
+	return session, err
+}
+
+// Create inserts a new impersonation session
+func (r *ImpersonationRepository) Create(ctx context.Context, session *models.ImpersonationSession) error {
+	query := `
+		INSERT INTO impersonation_sessions (
+			token_hash, admin_user_id, target_user_id, reason, expires_at
+		) VALUES (
+			$1, $2, $3, $4, $5
+		) RETURNING impersonation_id, created_at`
+
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		session.TokenHash,
+		session.AdminUserID,
+		session.TargetUserID,
+		session.Reason,
+		session.ExpiresAt,
+	).Scan(&session.ImpersonationID, &session.CreatedAt)
+}
+
+// End marks a session as ended
+func (r *ImpersonationRepository) End(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE impersonation_sessions SET ended_at = NOW() WHERE impersonation_id = $1 AND ended_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound("impersonation session")
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// IndustryRepository handles database operations for the industry list
+type IndustryRepository struct {
+	db    *sqlx.DB
+	cache Cache
+}
+
+// NewIndustryRepository creates a new repository with the provided database connection
+func NewIndustryRepository(db *sqlx.DB) *IndustryRepository {
+	return &IndustryRepository{
+		db: db,
+	}
+}
+
+// UseCache sets the customer cache, whose copies renames invalidate as customers follow the new name
+func (r *IndustryRepository) UseCache(cache Cache) {
+	r.cache = cache
+}
+
+// GetAll retrieves all industries ordered by name
+func (r *IndustryRepository) GetAll(ctx context.Context) ([]models.Industry, error) {
+	industries := []models.Industry{}
+	query := `SELECT * FROM industries ORDER BY name`
+	err := r.db.SelectContext(ctx, &industries, query)
+	return industries, err
+}
+
+// Search retrieves industries whose name contains the term
+func (r *IndustryRepository) Search(ctx context.Context, term string) ([]models.Industry, error) {
+	industries := []models.Industry{}
+	query := `SELECT * FROM industries WHERE name ILIKE $1 ORDER BY name`
+	err := r.db.SelectContext(ctx, &industries, query, "%"+term+"%")
+	return industries, err
+}
+
+// GetByID retrieves an industry by ID
+func (r *IndustryRepository) GetByID(ctx context.Context, id int) (models.Industry, error) {
+	var industry models.Industry
+	query := `SELECT * FROM industries WHERE industry_id = $1`
+	err := r.db.GetContext(ctx, &industry, query, id)
+	if err == sql.ErrNoRows {
+		return industry, notFound("industry")
+	}
+	return industry, err
+}
+
+// GetByName retrieves an industry by name, ignoring case
+func (r *IndustryRepository) GetByName(ctx context.Context, name string) (models.Industry, error) {
+	var industry models.Industry
+	query := `SELECT * FROM industries WHERE LOWER(name) = LOWER($1)`
+	err := r.db.GetContext(ctx, &industry, query, name)
+	if err == sql.ErrNoRows {
+		return industry, notFound("industry")
+	}
+	return industry, err
+}
+
+// Create inserts a new industry
+func (r *IndustryRepository) Create(ctx context.Context, industry *models.Industry) error {
+	query := `
+		INSERT INTO industries (name)
+		VALUES ($1)
+		RETURNING industry_id, created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query, industry.Name).
+		Scan(&industry.IndustryID, &industry.CreatedAt, &industry.UpdatedAt)
+
+	return translateReferenceError(err)
+}
+
+// Update renames an industry; customers in the industry follow the new name
+func (r *IndustryRepository) Update(ctx context.Context, industry *models.Industry) error {
+	query := `
+		UPDATE industries SET
+			name = $1,
+			updated_at = NOW()
+		WHERE industry_id = $2
+		RETURNING created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query, industry.Name, industry.IndustryID).
+		Scan(&industry.CreatedAt, &industry.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return notFound("industry")
+	}
+	if err != nil {
+		return translateReferenceError(err)
+	}
+
+	if r.cache != nil {
+		renamed := []int{}
+		if err := r.db.SelectContext(ctx, &renamed, `SELECT customer_id FROM customers WHERE industry = $1`, industry.Name); err != nil {
+			return err
+		}
+		invalidate(ctx, r.cache, customerCacheKey, renamed...)
+	}
+	return nil
+}
+
+// Delete removes an industry. Industries still assigned to customers cannot be deleted.
+func (r *IndustryRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM industries WHERE industry_id = $1`, id)
+	if err != nil {
+		return translateReferenceError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound("industry")
+	}
+
+	return nil
+}
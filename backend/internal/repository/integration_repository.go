@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrIntegrationNotConnected is returned when no credential is stored for a provider
+var ErrIntegrationNotConnected = &NotFoundError{Entity: "integration"}
+
+// IntegrationRepository handles database operations for external integration credentials
+type IntegrationRepository struct {
+	db *sqlx.DB
+}
+
+// NewIntegrationRepository creates a new repository with the provided database connection
+func NewIntegrationRepository(db *sqlx.DB) *IntegrationRepository {
+	return &IntegrationRepository{
+		db: db,
+	}
+}
+
+// GetAll retrieves all stored integration credentials
+func (r *IntegrationRepository) GetAll(ctx context.Context) ([]models.IntegrationCredential, error) {
+	credentials := []models.IntegrationCredential{}
+	query := `SELECT * FROM integration_credentials ORDER BY provider`
+	err := r.db.SelectContext(ctx, &credentials, query)
+	return credentials, err
+}
+
+// GetByProvider retrieves the credential for a provider
+func (r *IntegrationRepository) GetByProvider(ctx context.Context, provider string) (models.IntegrationCredential, error) {
+	var credential models.IntegrationCredential
+	query := `SELECT * FROM integration_credentials WHERE provider = $1`
+	err := r.db.GetContext(ctx, &credential, query, provider)
+	if err == sql.ErrNoRows {
+		return credential, ErrIntegrationNotConnected
+	}
+	return credential, err
+}
+
+// Save inserts or replaces the credential for a provider
+func (r *IntegrationRepository) Save(ctx context.Context, credential *models.IntegrationCredential) error {
+	now := time.Now()
+	credential.UpdatedAt = now
+
+	// Keep the existing refresh token when the provider does not return a new one
+	query := `
+		INSERT INTO integration_credentials (
+			provider, access_token, refresh_token, token_type, expires_at, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $6
+		)
+		ON CONFLICT (provider) DO UPDATE SET
+			access_token = EXCLUDED.access_token,
+			refresh_token = COALESCE(NULLIF(EXCLUDED.refresh_token, ''), integration_credentials.refresh_token),
+			token_type = EXCLUDED.token_type,
+			expires_at = EXCLUDED.expires_at,
+			updated_at = EXCLUDED.updated_at
+		RETURNING refresh_token, created_at`
+
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		credential.Provider,
+		credential.AccessToken,
+		credential.RefreshToken,
+		credential.TokenType,
+		credential.ExpiresAt,
+		now,
+	).Scan(&credential.RefreshToken, &credential.CreatedAt)
+}
+
+// Delete removes the credential for a provider
+func (r *IntegrationRepository) Delete(ctx context.Context, provider string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM integration_credentials WHERE provider = $1`, provider)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrIntegrationNotConnected
+	}
+
+	return nil
+}
@@ -3,7 +3,6 @@ package repository
 import (
 	"context"
 	"database/sql"
-	"errors"
 	"time"
 
 	"github.com/Cezzyy/SCMS/backend/internal/models"
@@ -37,7 +36,7 @@ func (r *InventoryRepository) GetByID(ctx context.Context, id int) (models.Inven
 	query := `SELECT * FROM inventory WHERE inventory_id = $1`
 	err := r.db.GetContext(ctx, &inventory, query, id)
 	if err == sql.ErrNoRows {
-		return inventory, errors.New("inventory item not found")
+		return inventory, notFound("inventory item")
 	}
 	return inventory, err
 }
@@ -48,7 +47,7 @@ func (r *InventoryRepository) GetByProductID(ctx context.Context, productID int)
 	query := `SELECT * FROM inventory WHERE product_id = $1`
 	err := r.db.GetContext(ctx, &inventory, query, productID)
 	if err == sql.ErrNoRows {
-		return inventory, errors.New("inventory for product not found")
+		return inventory, notFound("inventory for product")
 	}
 	return inventory, err
 }
@@ -60,7 +59,7 @@ func (r *InventoryRepository) Create(ctx context.Context, inventory *models.Inve
 			product_id, current_stock, reorder_level, last_restock_date
 		) VALUES (
 			$1, $2, $3, $4
-		) RETURNING inventory_id`
+		) RETURNING inventory_id, updated_at`
 
 	err := r.db.QueryRowContext(
 		ctx,
@@ -69,7 +68,7 @@ func (r *InventoryRepository) Create(ctx context.Context, inventory *models.Inve
 		inventory.CurrentStock,
 		inventory.ReorderLevel,
 		inventory.LastRestockDate,
-	).Scan(&inventory.InventoryID)
+	).Scan(&inventory.InventoryID, &inventory.UpdatedAt)
 
 	if err != nil {
 		// Check for PostgreSQL-specific errors
@@ -80,7 +79,7 @@ func (r *InventoryRepository) Create(ctx context.Context, inventory *models.Inve
 			}
 			// 23503 is the PostgreSQL error code for foreign_key_violation
 			if pqErr.Code == "23503" {
-				return errors.New("product not found")
+				return notFound("product")
 			}
 		}
 	}
@@ -95,10 +94,12 @@ func (r *InventoryRepository) Update(ctx context.Context, inventory *models.Inve
 			product_id = $1,
 			current_stock = $2,
 			reorder_level = $3,
-			last_restock_date = $4
-		WHERE inventory_id = $5`
+			last_restock_date = $4,
+			updated_at = NOW()
+		WHERE inventory_id = $5
+		RETURNING updated_at`
 
-	result, err := r.db.ExecContext(
+	err := r.db.QueryRowContext(
 		ctx,
 		query,
 		inventory.ProductID,
@@ -106,7 +107,11 @@ func (r *InventoryRepository) Update(ctx context.Context, inventory *models.Inve
 		inventory.ReorderLevel,
 		inventory.LastRestockDate,
 		inventory.InventoryID,
-	)
+	).Scan(&inventory.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return notFound("inventory item")
+	}
 
 	if err != nil {
 		// Check for unique constraint or foreign key violations
@@ -115,21 +120,12 @@ func (r *InventoryRepository) Update(ctx context.Context, inventory *models.Inve
 				return ErrDuplicateKey
 			}
 			if pqErr.Code == "23503" {
-				return errors.New("product not found")
+				return notFound("product")
 			}
 		}
 		return err
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-
-	if rowsAffected == 0 {
-		return errors.New("inventory item not found")
-	}
-
 	return nil
 }
 
@@ -140,7 +136,8 @@ func (r *InventoryRepository) UpdateStock(ctx context.Context, inventoryID int,
 	query := `
 		UPDATE inventory SET
 			current_stock = $1,
-			last_restock_date = $2
+			last_restock_date = $2,
+			updated_at = NOW()
 		WHERE inventory_id = $3`
 
 	result, err := r.db.ExecContext(ctx, query, newStock, now, inventoryID)
@@ -154,7 +151,7 @@ func (r *InventoryRepository) UpdateStock(ctx context.Context, inventoryID int,
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("inventory item not found")
+		return notFound("inventory item")
 	}
 
 	return nil
@@ -177,7 +174,7 @@ func (r *InventoryRepository) Delete(ctx context.Context, id int) error {
 	}
 
 	if count == 0 {
-		return errors.New("inventory item not found")
+		return notFound("inventory item")
 	}
 
 	return nil
@@ -195,6 +192,14 @@ func (r *InventoryRepository) GetLowStockItems(ctx context.Context) ([]models.In
 	return inventory, err
 }
 
+// GetUpdatedSince retrieves inventory items changed after the given time
+func (r *InventoryRepository) GetUpdatedSince(ctx context.Context, since time.Time) ([]models.Inventory, error) {
+	inventory := []models.Inventory{}
+	query := `SELECT * FROM inventory WHERE updated_at > $1 ORDER BY updated_at`
+	err := r.db.SelectContext(ctx, &inventory, query, since)
+	return inventory, err
+}
+
 // LowStockWithProductInfo combines product and inventory details for low stock items
 type LowStockWithProductInfo struct {
 	models.Inventory
@@ -209,7 +214,7 @@ func (r *InventoryRepository) GetLowStockWithProductInfo(ctx context.Context) ([
 		SELECT i.*, p.product_name, p.price 
 		FROM inventory i
 		JOIN products p ON i.product_id = p.product_id
-		WHERE i.current_stock <= i.reorder_level
+		WHERE i.current_stock <= i.reorder_level AND p.deleted_at IS NULL
 		ORDER BY (i.reorder_level - i.current_stock) DESC`
 	
 	err := r.db.SelectContext(ctx, &items, query)
@@ -0,0 +1,371 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// ErrInvoiceNotIssued is returned when adjusting an order whose invoice has not been issued yet
+	ErrInvoiceNotIssued = conflict("the order's sales invoice has not been issued; edit or cancel the order instead")
+
+	// ErrInvoiceVoided is returned when adjusting an invoice that is voided or has a void pending approval
+	ErrInvoiceVoided = conflict("the invoice is voided or has a void pending approval")
+
+	// ErrInvoiceCredited is returned when voiding an invoice that has credit notes
+	ErrInvoiceCredited = conflict("the invoice has credit notes; credit the remainder instead of voiding it")
+
+	// ErrAdjustmentDecided is returned when approving or rejecting an adjustment that is not pending
+	ErrAdjustmentDecided = conflict("the adjustment is not pending approval")
+)
+
+// CreditExceededError is returned when a credit note would credit more of an invoice, or of
+// one of its lines, than remains
+type CreditExceededError struct {
+	OrderItemID int
+	Remaining   float64
+}
+
+// Error implements error
+func (e *CreditExceededError) Error() string {
+	if e.OrderItemID != 0 {
+		return fmt.Sprintf("order item %d has %.0f left to credit", e.OrderItemID, e.Remaining)
+	}
+	return fmt.Sprintf("only %.2f of the invoice is left to credit", e.Remaining)
+}
+
+// adjustmentQuery selects adjustments with the printed number of credit notes
+const adjustmentQuery = `
+	SELECT a.*, COALESCE(s.prefix || '-' || LPAD(a.document_no::TEXT, 6, '0'), '') AS number
+	FROM invoice_adjustments a
+	LEFT JOIN document_series s ON s.series = 'credit_note' AND a.document_no IS NOT NULL`
+
+// openAdjustment holds for adjustments a that are pending or approved
+const openAdjustment = `a.status IN ('pending', 'approved')`
+
+// InvoiceAdjustmentRepository handles database operations for voids and credit notes
+// against issued sales invoices
+type InvoiceAdjustmentRepository struct {
+	db *sqlx.DB
+}
+
+// NewInvoiceAdjustmentRepository creates a new repository with the provided database connection
+func NewInvoiceAdjustmentRepository(db *sqlx.DB) *InvoiceAdjustmentRepository {
+	return &InvoiceAdjustmentRepository{
+		db: db,
+	}
+}
+
+// GetAll retrieves adjustments with the given status, or all of them when status is empty,
+// oldest request first
+func (r *InvoiceAdjustmentRepository) GetAll(ctx context.Context, status string) ([]models.InvoiceAdjustment, error) {
+	adjustments := []models.InvoiceAdjustment{}
+	query := adjustmentQuery + ` WHERE $1 = '' OR a.status = $1 ORDER BY a.requested_at, a.adjustment_id`
+	if err := r.db.SelectContext(ctx, &adjustments, query, status); err != nil {
+		return nil, err
+	}
+	return adjustments, r.loadItems(ctx, adjustments)
+}
+
+// GetByOrder retrieves the adjustments requested against an order, oldest first
+func (r *InvoiceAdjustmentRepository) GetByOrder(ctx context.Context, orderID int) ([]models.InvoiceAdjustment, error) {
+	adjustments := []models.InvoiceAdjustment{}
+	query := adjustmentQuery + ` WHERE a.order_id = $1 ORDER BY a.requested_at, a.adjustment_id`
+	if err := r.db.SelectContext(ctx, &adjustments, query, orderID); err != nil {
+		return nil, err
+	}
+	return adjustments, r.loadItems(ctx, adjustments)
+}
+
+// GetByID retrieves an adjustment with its items
+func (r *InvoiceAdjustmentRepository) GetByID(ctx context.Context, id int) (models.InvoiceAdjustment, error) {
+	var adjustment models.InvoiceAdjustment
+	err := r.db.GetContext(ctx, &adjustment, adjustmentQuery+` WHERE a.adjustment_id = $1`, id)
+	if err == sql.ErrNoRows {
+		return adjustment, notFound("invoice adjustment")
+	}
+	if err != nil {
+		return adjustment, err
+	}
+
+	adjustments := []models.InvoiceAdjustment{adjustment}
+	err = r.loadItems(ctx, adjustments)
+	return adjustments[0], err
+}
+
+// loadItems fills in the items of the given adjustments
+func (r *InvoiceAdjustmentRepository) loadItems(ctx context.Context, adjustments []models.InvoiceAdjustment) error {
+	if len(adjustments) == 0 {
+		return nil
+	}
+	ids := make([]int, len(adjustments))
+	byID := map[int]*models.InvoiceAdjustment{}
+	for i := range adjustments {
+		adjustments[i].Items = []models.InvoiceAdjustmentItem{}
+		ids[i] = adjustments[i].AdjustmentID
+		byID[ids[i]] = &adjustments[i]
+	}
+
+	query, args, err := sqlx.In(`SELECT * FROM invoice_adjustment_items WHERE adjustment_id IN (?) ORDER BY order_item_id`, ids)
+	if err != nil {
+		return err
+	}
+	items := []models.InvoiceAdjustmentItem{}
+	if err := r.db.SelectContext(ctx, &items, r.db.Rebind(query), args...); err != nil {
+		return err
+	}
+	for _, item := range items {
+		adjustment := byID[item.AdjustmentID]
+		adjustment.Items = append(adjustment.Items, item)
+	}
+	return nil
+}
+
+// Create records a void or credit note requested against an issued invoice, pending
+// approval. A void is for the invoice's total. A credit note with items credits each line's
+// total in proportion to the quantity returned; one without is for adjustment.Amount. What
+// pending and approved credit notes take off an invoice or line can never exceed it.
+func (r *InvoiceAdjustmentRepository) Create(ctx context.Context, adjustment *models.InvoiceAdjustment) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Lock the order so concurrent requests can't both take what remains of it
+	var order struct {
+		Status      string  `db:"status"`
+		TotalAmount float64 `db:"total_amount"`
+		Issued      bool    `db:"issued"`
+	}
+	query := `SELECT o.status, o.total_amount, ` + issuedInvoice + ` AS issued FROM orders o WHERE o.order_id = $1 FOR UPDATE`
+	err = tx.GetContext(ctx, &order, query, adjustment.OrderID)
+	if err == sql.ErrNoRows {
+		return notFound("order")
+	}
+	if err != nil {
+		return err
+	}
+	if !order.Issued {
+		err = ErrInvoiceNotIssued
+		return err
+	}
+
+	var open struct {
+		Voids    int     `db:"voids"`
+		Credits  int     `db:"credits"`
+		Credited float64 `db:"credited"`
+	}
+	query = `
+		SELECT COUNT(*) FILTER (WHERE a.kind = 'void') AS voids,
+			COUNT(*) FILTER (WHERE a.kind = 'credit_note') AS credits,
+			COALESCE(SUM(a.amount) FILTER (WHERE a.kind = 'credit_note'), 0) AS credited
+		FROM invoice_adjustments a
+		WHERE a.order_id = $1 AND ` + openAdjustment
+	if err = tx.GetContext(ctx, &open, query, adjustment.OrderID); err != nil {
+		return err
+	}
+	if order.Status == "Cancelled" || open.Voids > 0 {
+		err = ErrInvoiceVoided
+		return err
+	}
+
+	if adjustment.Kind == models.AdjustmentVoid {
+		if open.Credits > 0 {
+			err = ErrInvoiceCredited
+			return err
+		}
+		adjustment.Amount = order.TotalAmount
+		adjustment.Items = []models.InvoiceAdjustmentItem{}
+	} else if len(adjustment.Items) > 0 {
+		if err = r.priceItems(ctx, tx, adjustment); err != nil {
+			return err
+		}
+	}
+	adjustment.Amount = math.Round(adjustment.Amount*100) / 100
+
+	remaining := math.Round((order.TotalAmount-open.Credited)*100) / 100
+	if adjustment.Kind == models.AdjustmentCreditNote && adjustment.Amount > remaining {
+		err = &CreditExceededError{Remaining: remaining}
+		return err
+	}
+
+	adjustment.Status = models.AdjustmentPending
+	err = tx.QueryRowxContext(ctx, `
+		INSERT INTO invoice_adjustments (order_id, kind, reason, amount, status, requested_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING adjustment_id, requested_at`,
+		adjustment.OrderID, adjustment.Kind, adjustment.Reason, adjustment.Amount, adjustment.Status, adjustment.RequestedBy).
+		Scan(&adjustment.AdjustmentID, &adjustment.RequestedAt)
+	if err != nil {
+		return err
+	}
+
+	for i := range adjustment.Items {
+		item := &adjustment.Items[i]
+		item.AdjustmentID = adjustment.AdjustmentID
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO invoice_adjustment_items (adjustment_id, order_item_id, product_id, quantity, amount)
+			VALUES ($1, $2, $3, $4, $5)`,
+			item.AdjustmentID, item.OrderItemID, item.ProductID, item.Quantity, item.Amount)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// priceItems checks the lines of a credit note against the order's items and what open
+// credit notes already return of them, and prices them from the lines' totals
+func (r *InvoiceAdjustmentRepository) priceItems(ctx context.Context, tx *sqlx.Tx, adjustment *models.InvoiceAdjustment) error {
+	lines := []struct {
+		OrderItemID int     `db:"order_item_id"`
+		ProductID   int     `db:"product_id"`
+		Quantity    int     `db:"quantity"`
+		LineTotal   float64 `db:"line_total"`
+		Credited    int     `db:"credited"`
+	}{}
+	query := `
+		SELECT oi.order_item_id, oi.product_id, oi.quantity, oi.line_total,
+			COALESCE((
+				SELECT SUM(ai.quantity) FROM invoice_adjustment_items ai
+				JOIN invoice_adjustments a ON a.adjustment_id = ai.adjustment_id
+				WHERE ai.order_item_id = oi.order_item_id AND ` + openAdjustment + `
+			), 0) AS credited
+		FROM order_items oi
+		WHERE oi.order_id = $1`
+	if err := tx.SelectContext(ctx, &lines, query, adjustment.OrderID); err != nil {
+		return err
+	}
+
+	byID := map[int]int{}
+	for i, line := range lines {
+		byID[line.OrderItemID] = i
+	}
+
+	seen := map[int]bool{}
+	adjustment.Amount = 0
+	for i := range adjustment.Items {
+		item := &adjustment.Items[i]
+		index, ok := byID[item.OrderItemID]
+		if !ok || seen[item.OrderItemID] {
+			return notFound("order item")
+		}
+		seen[item.OrderItemID] = true
+
+		line := lines[index]
+		if remaining := line.Quantity - line.Credited; item.Quantity > remaining {
+			return &CreditExceededError{OrderItemID: item.OrderItemID, Remaining: float64(remaining)}
+		}
+		item.ProductID = line.ProductID
+		item.Amount = math.Round(line.LineTotal*float64(item.Quantity)/float64(line.Quantity)*100) / 100
+		adjustment.Amount += item.Amount
+	}
+	return nil
+}
+
+// Decide approves or rejects a pending adjustment. Approving a void cancels the order and
+// records its invoice number as voided with the adjustment's reason; approving a credit note
+// gives it the next credit note number.
+func (r *InvoiceAdjustmentRepository) Decide(ctx context.Context, id int, approve bool, decidedBy *int, note string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var adjustment models.InvoiceAdjustment
+	err = tx.GetContext(ctx, &adjustment, `SELECT a.*, '' AS number FROM invoice_adjustments a WHERE a.adjustment_id = $1 FOR UPDATE`, id)
+	if err == sql.ErrNoRows {
+		return notFound("invoice adjustment")
+	}
+	if err != nil {
+		return err
+	}
+	if adjustment.Status != models.AdjustmentPending {
+		err = ErrAdjustmentDecided
+		return err
+	}
+
+	status := models.AdjustmentRejected
+	var documentNo *int
+	if approve {
+		status = models.AdjustmentApproved
+		switch adjustment.Kind {
+		case models.AdjustmentVoid:
+			_, err = tx.ExecContext(ctx, `UPDATE orders SET status = 'Cancelled', updated_at = NOW() WHERE order_id = $1`, adjustment.OrderID)
+			if err != nil {
+				return err
+			}
+			err = voidDocument(ctx, tx, models.DocumentSeriesSalesInvoice, adjustment.OrderID, models.VoidCancelled, adjustment.Reason, decidedBy)
+			if err != nil {
+				return err
+			}
+		case models.AdjustmentCreditNote:
+			var number int
+			if number, err = nextDocumentNo(ctx, tx, models.DocumentSeriesCreditNote); err != nil {
+				return err
+			}
+			documentNo = &number
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE invoice_adjustments SET
+			status = $1,
+			document_no = $2,
+			approved_by = $3,
+			approved_at = NOW(),
+			approval_note = $4
+		WHERE adjustment_id = $5`, status, documentNo, decidedBy, note, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetCredited returns the total of the approved credit notes against an order
+func (r *InvoiceAdjustmentRepository) GetCredited(ctx context.Context, orderID int) (float64, error) {
+	var credited float64
+	query := `
+		SELECT COALESCE(SUM(amount), 0) FROM invoice_adjustments
+		WHERE order_id = $1 AND kind = 'credit_note' AND status = 'approved'`
+	err := r.db.GetContext(ctx, &credited, query, orderID)
+	return credited, err
+}
+
+// GetCustomerBalance works out what a customer owes across all their orders
+func (r *InvoiceAdjustmentRepository) GetCustomerBalance(ctx context.Context, customerID int) (models.CustomerBalance, error) {
+	balance := models.CustomerBalance{CustomerID: customerID}
+	query := `
+		SELECT
+			COALESCE((SELECT SUM(o.total_amount) FROM orders o
+				WHERE o.customer_id = $1 AND o.status <> 'Cancelled'), 0) AS invoiced,
+			COALESCE((SELECT SUM(a.amount) FROM invoice_adjustments a JOIN orders o ON o.order_id = a.order_id
+				WHERE o.customer_id = $1 AND o.status <> 'Cancelled' AND a.kind = 'credit_note' AND a.status = 'approved'), 0) AS credited,
+			COALESCE((SELECT SUM(p.amount) FROM order_payments p JOIN orders o ON o.order_id = p.order_id
+				WHERE o.customer_id = $1), 0) AS paid,
+			COALESCE((SELECT SUM(p.withholding_amount) FROM order_payments p JOIN orders o ON o.order_id = p.order_id
+				WHERE o.customer_id = $1), 0) AS withheld`
+	if err := r.db.GetContext(ctx, &balance, query, customerID); err != nil {
+		return balance, err
+	}
+
+	balance.Balance = math.Round((balance.Invoiced-balance.Credited-balance.Paid-balance.Withheld)*100) / 100
+	return balance, nil
+}
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// ErrOrderNotDelivered is returned when invoicing an order that has not been delivered
+	ErrOrderNotDelivered = conflict("only delivered orders can be invoiced")
+
+	// ErrOrderInvoiced is returned when invoicing an order that already has an invoice
+	ErrOrderInvoiced = conflict("the order has already been invoiced")
+)
+
+// InvoiceListColumns are the invoice columns lists can be filtered and sorted by
+var InvoiceListColumns = ListColumns{
+	Filterable: map[string]ColumnType{
+		"invoice_id":   ColumnInt,
+		"document_no":  ColumnInt,
+		"order_id":     ColumnInt,
+		"customer_id":  ColumnInt,
+		"status":       ColumnText,
+		"invoice_date": ColumnDate,
+		"due_date":     ColumnDate,
+		"total_amount": ColumnNumber,
+		"balance":      ColumnNumber,
+	},
+	Sortable:    []string{"invoice_id", "document_no", "customer_id", "company_name", "status", "invoice_date", "due_date", "total_amount", "balance"},
+	DefaultSort: []SortField{{Column: "invoice_date", Desc: true}},
+	Tiebreaker:  "invoice_id",
+}
+
+// invoiceQuery selects invoices with their printed number, customer name and what has been
+// credited and paid against them. Wrapped as a subquery, its columns can be filtered and
+// sorted on by name.
+const invoiceQuery = `
+	SELECT * FROM (
+		SELECT i.*, s.prefix || '-' || LPAD(i.document_no::TEXT, 6, '0') AS number,
+			c.company_name, t.credited, t.paid, t.balance,
+			CASE
+				WHEN o.status = 'Cancelled' THEN 'void'
+				WHEN t.balance <= 0 THEN 'paid'
+				WHEN i.due_date < CURRENT_DATE THEN 'overdue'
+				ELSE 'open'
+			END AS status
+		FROM invoices i
+		JOIN orders o ON o.order_id = i.order_id
+		JOIN customers c ON c.customer_id = i.customer_id
+		JOIN document_series s ON s.series = 'invoice'
+		CROSS JOIN LATERAL (
+			SELECT credited, paid, ROUND(i.total_amount - credited - paid, 2) AS balance
+			FROM (
+				SELECT
+					COALESCE((SELECT SUM(a.amount) FROM invoice_adjustments a
+						WHERE a.order_id = i.order_id AND a.kind = 'credit_note' AND a.status = 'approved'), 0) AS credited,
+					COALESCE((SELECT SUM(p.amount + p.withholding_amount) FROM order_payments p
+						WHERE p.order_id = i.order_id), 0) AS paid
+			) sums
+		) t
+	) invoices`
+
+// InvoiceRepository handles database operations for invoices
+type InvoiceRepository struct {
+	db *sqlx.DB
+}
+
+// NewInvoiceRepository creates a new repository with the provided database connection
+func NewInvoiceRepository(db *sqlx.DB) *InvoiceRepository {
+	return &InvoiceRepository{
+		db: db,
+	}
+}
+
+// GetAll retrieves a page of invoices matching the list query, newest first unless it sorts
+// otherwise, and the total number of matches. Items are not loaded.
+func (r *InvoiceRepository) GetAll(ctx context.Context, list ListQuery, page Page) ([]models.Invoice, int, error) {
+	invoices := []models.Invoice{}
+	conditions, args, order := list.apply(InvoiceListColumns, nil, nil)
+	query := invoiceQuery + whereClause(conditions) + ` ORDER BY ` + order
+	total, err := selectPage(ctx, r.db, &invoices, page, query, args...)
+	return invoices, total, err
+}
+
+// GetByID retrieves an invoice with its items
+func (r *InvoiceRepository) GetByID(ctx context.Context, id int) (models.Invoice, error) {
+	return r.get(ctx, `invoice_id = $1`, id)
+}
+
+// GetByOrder retrieves the invoice of an order with its items
+func (r *InvoiceRepository) GetByOrder(ctx context.Context, orderID int) (models.Invoice, error) {
+	return r.get(ctx, `order_id = $1`, orderID)
+}
+
+// get retrieves the invoice matching condition, with its items
+func (r *InvoiceRepository) get(ctx context.Context, condition string, arg int) (models.Invoice, error) {
+	var invoice models.Invoice
+	err := r.db.GetContext(ctx, &invoice, invoiceQuery+` WHERE `+condition, arg)
+	if err == sql.ErrNoRows {
+		return invoice, notFound("invoice")
+	}
+	if err != nil {
+		return invoice, err
+	}
+
+	invoice.Items = []models.InvoiceItem{}
+	query := `SELECT * FROM invoice_items WHERE invoice_id = $1 ORDER BY invoice_item_id`
+	err = r.db.SelectContext(ctx, &invoice.Items, query, invoice.InvoiceID)
+	return invoice, err
+}
+
+// CreateFromOrder issues the invoice of a delivered order, numbered in the invoice series
+// and due after the customer's payment terms. The order's lines are copied with their
+// products' names and models. An order is invoiced once.
+func (r *InvoiceRepository) CreateFromOrder(ctx context.Context, orderID int, createdBy *int) (models.Invoice, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.Invoice{}, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Lock the order so it is invoiced only once
+	var order struct {
+		Status     string `db:"status"`
+		CustomerID int    `db:"customer_id"`
+		Invoiced   bool   `db:"invoiced"`
+	}
+	query := `
+		SELECT o.status, o.customer_id, EXISTS (SELECT 1 FROM invoices i WHERE i.order_id = o.order_id) AS invoiced
+		FROM orders o WHERE o.order_id = $1 FOR UPDATE`
+	err = tx.GetContext(ctx, &order, query, orderID)
+	if err == sql.ErrNoRows {
+		return models.Invoice{}, notFound("order")
+	}
+	if err != nil {
+		return models.Invoice{}, err
+	}
+	if order.Invoiced {
+		err = ErrOrderInvoiced
+		return models.Invoice{}, err
+	}
+	if order.Status != "Delivered" {
+		err = ErrOrderNotDelivered
+		return models.Invoice{}, err
+	}
+
+	documentNo, err := nextDocumentNo(ctx, tx, models.DocumentSeriesInvoice)
+	if err != nil {
+		return models.Invoice{}, err
+	}
+
+	var invoiceID int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO invoices (
+			document_no, order_id, customer_id, invoice_date, payment_terms_days, due_date,
+			subtotal, delivery_fee, total_amount, vat_classification, vat_rate, net_amount,
+			vat_amount, currency, exchange_rate, created_by
+		)
+		SELECT $1, o.order_id, o.customer_id, CURRENT_DATE, c.payment_terms_days,
+			CURRENT_DATE + c.payment_terms_days,
+			o.total_amount - o.delivery_fee, o.delivery_fee, o.total_amount, o.vat_classification,
+			o.vat_rate, o.net_amount, o.vat_amount, o.currency, o.exchange_rate, $3
+		FROM orders o
+		JOIN customers c ON c.customer_id = o.customer_id
+		WHERE o.order_id = $2
+		RETURNING invoice_id`,
+		documentNo, orderID, createdBy).Scan(&invoiceID)
+	if err != nil {
+		return models.Invoice{}, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO invoice_items (
+			invoice_id, order_item_id, product_id, description, quantity, unit_price, discount,
+			discount_type, discount_value, line_total
+		)
+		SELECT $1, oi.order_item_id, oi.product_id,
+			p.product_name || COALESCE(' (' || NULLIF(p.model, '') || ')', ''),
+			oi.quantity, oi.unit_price, oi.discount, oi.discount_type, oi.discount_value, oi.line_total
+		FROM order_items oi
+		JOIN products p ON p.product_id = oi.product_id
+		WHERE oi.order_id = $2
+		ORDER BY oi.order_item_id`,
+		invoiceID, orderID)
+	if err != nil {
+		return models.Invoice{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return models.Invoice{}, err
+	}
+
+	return r.GetByID(ctx, invoiceID)
+}
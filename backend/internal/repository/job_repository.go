@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// JobRepository handles database operations for background jobs
+type JobRepository struct {
+	db *sqlx.DB
+}
+
+// NewJobRepository creates a new repository with the provided database connection
+func NewJobRepository(db *sqlx.DB) *JobRepository {
+	return &JobRepository{
+		db: db,
+	}
+}
+
+// Create queues a job to run at its RunAt
+func (r *JobRepository) Create(ctx context.Context, job *models.Job) error {
+	query := `
+		INSERT INTO jobs (type, payload, status, max_attempts, run_at)
+		VALUES ($1, $2::jsonb, $3, $4, $5)
+		RETURNING job_id, created_at`
+
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		job.Type,
+		string(job.Payload),
+		job.Status,
+		job.MaxAttempts,
+		job.RunAt,
+	).Scan(&job.JobID, &job.CreatedAt)
+}
+
+// GetAll retrieves jobs, newest first, optionally only those with a status
+func (r *JobRepository) GetAll(ctx context.Context, status string, page Page) ([]models.Job, int, error) {
+	jobs := []models.Job{}
+	query := `
+		SELECT * FROM jobs
+		WHERE $1 = '' OR status = $1
+		ORDER BY created_at DESC, job_id DESC`
+	total, err := selectPage(ctx, r.db, &jobs, page, query, status)
+	return jobs, total, err
+}
+
+// GetByID retrieves a job by ID
+func (r *JobRepository) GetByID(ctx context.Context, id int) (models.Job, error) {
+	var job models.Job
+	err := r.db.GetContext(ctx, &job, `SELECT * FROM jobs WHERE job_id = $1`, id)
+	if err == sql.ErrNoRows {
+		return job, notFound("job")
+	}
+	return job, err
+}
+
+// ClaimDue takes up to limit pending jobs that are due and holds them for lease, so other
+// servers sharing the database skip them while they run
+func (r *JobRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.Job, error) {
+	jobs := []models.Job{}
+	query := `
+		WITH due AS (
+			SELECT job_id FROM jobs
+			WHERE status = $1 AND run_at <= NOW()
+			ORDER BY run_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE jobs j SET run_at = NOW() + $3 * INTERVAL '1 second'
+		FROM due
+		WHERE j.job_id = due.job_id
+		RETURNING j.*`
+	err := r.db.SelectContext(ctx, &jobs, query, models.JobPending, limit, lease.Seconds())
+	return jobs, err
+}
+
+// RecordAttempt saves the outcome of running a job: its status, attempts so far, when it
+// runs next if still pending, and the error
+func (r *JobRepository) RecordAttempt(ctx context.Context, job *models.Job) error {
+	query := `
+		UPDATE jobs SET status = $1, attempts = $2, run_at = $3, error = $4, finished_at = $5
+		WHERE job_id = $6`
+
+	_, err := r.db.ExecContext(ctx, query, job.Status, job.Attempts, job.RunAt, job.Error, job.FinishedAt, job.JobID)
+	return err
+}
+
+// Retry queues a failed job to run again now with a fresh set of attempts
+func (r *JobRepository) Retry(ctx context.Context, id int) (models.Job, error) {
+	var job models.Job
+	query := `
+		UPDATE jobs SET status = $1, attempts = 0, run_at = NOW(), finished_at = NULL
+		WHERE job_id = $2 AND status = $3
+		RETURNING *`
+	err := r.db.GetContext(ctx, &job, query, models.JobPending, id, models.JobFailed)
+	if err == sql.ErrNoRows {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return job, err
+		}
+		return job, conflict("only failed jobs can be retried")
+	}
+	return job, err
+}
+
+// Delete discards a job that is not pending
+func (r *JobRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM jobs WHERE job_id = $1 AND status <> $2`, id, models.JobPending)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return err
+		}
+		return conflict("pending jobs cannot be discarded")
+	}
+	return nil
+}
+
+// DeleteSucceededBefore removes the jobs that succeeded before the given time and returns
+// how many there were
+func (r *JobRepository) DeleteSucceededBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM jobs WHERE status = $1 AND finished_at < $2`, models.JobSucceeded, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,286 @@
+package repository
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ColumnType is the kind of value a filterable column holds, used to validate filter values
+type ColumnType int
+
+// Filterable column types
+const (
+	ColumnText ColumnType = iota
+	ColumnInt
+	ColumnNumber
+	ColumnDate
+	ColumnBool
+)
+
+// ListColumns whitelists the columns of an entity that list queries may filter and sort
+// by. Tiebreaker is a unique column appended to every order so pages don't overlap.
+type ListColumns struct {
+	Filterable  map[string]ColumnType
+	Sortable    []string
+	DefaultSort []SortField
+	Tiebreaker  string
+}
+
+// SortField orders a list by a column
+type SortField struct {
+	Column string
+	Desc   bool
+}
+
+// ColumnFilter restricts a column to one of several values, or to a range with Min/Max
+type ColumnFilter struct {
+	Column string
+	Values []string
+	Min    string
+	Max    string
+}
+
+// ListQuery is the filters and sort order requested for a list
+type ListQuery struct {
+	Filters []ColumnFilter
+	Sort    []SortField
+}
+
+// OrderListColumns are the order columns lists can be filtered and sorted by
+var OrderListColumns = ListColumns{
+	Filterable: map[string]ColumnType{
+		"order_id":     ColumnInt,
+		"customer_id":  ColumnInt,
+		"quotation_id": ColumnInt,
+		"status":       ColumnText,
+		"source":       ColumnText,
+		"order_date":   ColumnDate,
+		"delivered_at": ColumnDate,
+		"total_amount": ColumnNumber,
+		"balance":      ColumnNumber,
+	},
+	Sortable:    []string{"order_id", "customer_id", "status", "order_date", "delivered_at", "total_amount", "balance", "created_at", "updated_at"},
+	DefaultSort: []SortField{{Column: "order_date", Desc: true}},
+	Tiebreaker:  "order_id",
+}
+
+// QuotationListColumns are the quotation columns lists can be filtered and sorted by
+var QuotationListColumns = ListColumns{
+	Filterable: map[string]ColumnType{
+		"quotation_id":  ColumnInt,
+		"customer_id":   ColumnInt,
+		"status":        ColumnText,
+		"source":        ColumnText,
+		"quote_date":    ColumnDate,
+		"validity_date": ColumnDate,
+		"total_amount":  ColumnNumber,
+	},
+	Sortable:    []string{"quotation_id", "customer_id", "status", "quote_date", "validity_date", "total_amount", "created_at", "updated_at"},
+	DefaultSort: []SortField{{Column: "quote_date", Desc: true}},
+	Tiebreaker:  "quotation_id",
+}
+
+// ProductListColumns are the product columns lists can be filtered and sorted by. Category
+// and technical specs have their own filters; see ProductFilter.
+var ProductListColumns = ListColumns{
+	Filterable: map[string]ColumnType{
+		"product_id":      ColumnInt,
+		"model":           ColumnText,
+		"price":           ColumnNumber,
+		"warranty_period": ColumnInt,
+		"restricted":      ColumnBool,
+		"weight_kg":       ColumnNumber,
+		"created_at":      ColumnDate,
+	},
+	Sortable:    []string{"product_id", "product_name", "model", "category", "price", "warranty_period", "weight_kg", "created_at", "updated_at"},
+	DefaultSort: []SortField{{Column: "product_name"}},
+	Tiebreaker:  "product_id",
+}
+
+// ParseListQuery reads filters and a sort order from query parameters, accepting only the
+// whitelisted columns. A filterable column given as ?status=Pending,Shipped matches any of
+// the values and ?total_amount_min= / ?total_amount_max= bound it (dates are inclusive).
+// ?sort=order_date:desc,total_amount sorts by several columns, ascending by default.
+// Parameters that name no column are left for the caller.
+func ParseListQuery(params url.Values, columns ListColumns) (ListQuery, error) {
+	var query ListQuery
+
+	names := make([]string, 0, len(columns.Filterable))
+	for column := range columns.Filterable {
+		names = append(names, column)
+	}
+	sort.Strings(names)
+
+	for _, column := range names {
+		columnType := columns.Filterable[column]
+		filter := ColumnFilter{Column: column}
+		if value := params.Get(column); value != "" {
+			for _, v := range strings.Split(value, ",") {
+				v = strings.TrimSpace(v)
+				if err := checkColumnValue(column, columnType, v); err != nil {
+					return query, err
+				}
+				filter.Values = append(filter.Values, v)
+			}
+		}
+		for _, bound := range []struct {
+			suffix string
+			dest   *string
+		}{{"_min", &filter.Min}, {"_max", &filter.Max}} {
+			value := params.Get(column + bound.suffix)
+			if value == "" {
+				continue
+			}
+			if columnType == ColumnText || columnType == ColumnBool {
+				return query, fmt.Errorf("%s cannot be filtered by range", column)
+			}
+			if err := checkColumnValue(column+bound.suffix, columnType, value); err != nil {
+				return query, err
+			}
+			*bound.dest = value
+		}
+		if len(filter.Values) > 0 || filter.Min != "" || filter.Max != "" {
+			query.Filters = append(query.Filters, filter)
+		}
+	}
+
+	if value := params.Get("sort"); value != "" {
+		for _, part := range strings.Split(value, ",") {
+			column, direction, _ := strings.Cut(strings.TrimSpace(part), ":")
+			if !containsColumn(columns.Sortable, column) {
+				return query, fmt.Errorf("cannot sort by %q; sortable columns are %s", column, strings.Join(columns.Sortable, ", "))
+			}
+			field := SortField{Column: column}
+			switch strings.ToLower(direction) {
+			case "", "asc":
+			case "desc":
+				field.Desc = true
+			default:
+				return query, fmt.Errorf("sort direction for %s must be asc or desc", column)
+			}
+			query.Sort = append(query.Sort, field)
+		}
+	}
+
+	return query, nil
+}
+
+// IsZero reports whether the query has neither filters nor a sort order
+func (q ListQuery) IsZero() bool {
+	return len(q.Filters) == 0 && len(q.Sort) == 0
+}
+
+// apply appends the query's filters to conditions and args and returns the ORDER BY
+// clause, falling back to the entity's default order. Column names come from the
+// whitelist checked by ParseListQuery, so only values are passed as parameters.
+func (q ListQuery) apply(columns ListColumns, conditions []string, args []interface{}) ([]string, []interface{}, string) {
+	for _, filter := range q.Filters {
+		columnType := columns.Filterable[filter.Column]
+		column := filter.Column
+		if columnType == ColumnDate {
+			column += "::date"
+		}
+		if len(filter.Values) > 0 {
+			args = append(args, pq.Array(filter.Values))
+			conditions = append(conditions, fmt.Sprintf("%s = ANY($%d::%s[])", column, len(args), columnSQLType(columnType)))
+		}
+		if filter.Min != "" {
+			args = append(args, filter.Min)
+			conditions = append(conditions, fmt.Sprintf("%s >= $%d::%s", column, len(args), columnSQLType(columnType)))
+		}
+		if filter.Max != "" {
+			args = append(args, filter.Max)
+			conditions = append(conditions, fmt.Sprintf("%s <= $%d::%s", column, len(args), columnSQLType(columnType)))
+		}
+	}
+
+	fields := q.Sort
+	if len(fields) == 0 {
+		fields = columns.DefaultSort
+	}
+	order := []string{}
+	tiebreaker := false
+	for _, field := range fields {
+		if field.Desc {
+			order = append(order, field.Column+" DESC")
+		} else {
+			order = append(order, field.Column)
+		}
+		tiebreaker = tiebreaker || field.Column == columns.Tiebreaker
+	}
+	if !tiebreaker {
+		last := SortField{Column: columns.Tiebreaker}
+		if len(fields) > 0 {
+			last.Desc = fields[0].Desc
+		}
+		if last.Desc {
+			order = append(order, last.Column+" DESC")
+		} else {
+			order = append(order, last.Column)
+		}
+	}
+
+	return conditions, args, strings.Join(order, ", ")
+}
+
+// whereClause joins conditions into a WHERE clause, or returns an empty string
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// checkColumnValue returns an error when a filter value doesn't fit its column type
+func checkColumnValue(param string, columnType ColumnType, value string) error {
+	switch columnType {
+	case ColumnInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("%s must be a whole number", param)
+		}
+	case ColumnNumber:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("%s must be a number", param)
+		}
+	case ColumnDate:
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return fmt.Errorf("%s must be a date in YYYY-MM-DD format", param)
+		}
+	case ColumnBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%s must be true or false", param)
+		}
+	}
+	return nil
+}
+
+// columnSQLType is the PostgreSQL type filter values are cast to
+func columnSQLType(columnType ColumnType) string {
+	switch columnType {
+	case ColumnInt:
+		return "integer"
+	case ColumnNumber:
+		return "numeric"
+	case ColumnDate:
+		return "date"
+	case ColumnBool:
+		return "boolean"
+	}
+	return "text"
+}
+
+// containsColumn reports whether a column is in a whitelist
+func containsColumn(columns []string, column string) bool {
+	for _, c := range columns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// LoginAttemptRepository handles database operations for login attempts
+type LoginAttemptRepository struct {
+	db *sqlx.DB
+}
+
+// NewLoginAttemptRepository creates a new repository with the provided database connection
+func NewLoginAttemptRepository(db *sqlx.DB) *LoginAttemptRepository {
+	return &LoginAttemptRepository{
+		db: db,
+	}
+}
+
+// Record stores the outcome of a login attempt
+func (r *LoginAttemptRepository) Record(ctx context.Context, email, ipAddress string, succeeded bool) error {
+	query := `INSERT INTO login_attempts (email, ip_address, succeeded) VALUES ($1, $2, $3)`
+	_, err := r.db.ExecContext(ctx, query, email, ipAddress, succeeded)
+	return err
+}
+
+// CountFailuresByEmail counts failed attempts for an email since the given time,
+// ignoring failures from before the last successful login
+func (r *LoginAttemptRepository) CountFailuresByEmail(ctx context.Context, email string, since time.Time) (int, error) {
+	var count int
+	query := `
+		SELECT COUNT(*) FROM login_attempts
+		WHERE email = $1 AND NOT succeeded AND attempted_at >= $2
+			AND attempted_at > COALESCE(
+				(SELECT MAX(attempted_at) FROM login_attempts WHERE email = $1 AND succeeded),
+				'-infinity'
+			)`
+	err := r.db.GetContext(ctx, &count, query, email, since)
+	return count, err
+}
+
+// CountFailuresByIP counts failed attempts from an address since the given time,
+// and returns when the oldest of them happened
+func (r *LoginAttemptRepository) CountFailuresByIP(ctx context.Context, ipAddress string, since time.Time) (int, time.Time, error) {
+	var result struct {
+		Count  int        `db:"count"`
+		Oldest *time.Time `db:"oldest"`
+	}
+	query := `
+		SELECT COUNT(*) AS count, MIN(attempted_at) AS oldest FROM login_attempts
+		WHERE ip_address = $1 AND NOT succeeded AND attempted_at >= $2`
+	if err := r.db.GetContext(ctx, &result, query, ipAddress, since); err != nil {
+		return 0, time.Time{}, err
+	}
+	if result.Oldest == nil {
+		return result.Count, time.Time{}, nil
+	}
+	return result.Count, *result.Oldest, nil
+}
+
+// ClearFailures forgets the failed attempts for an email, e.g. when an admin unlocks the account
+func (r *LoginAttemptRepository) ClearFailures(ctx context.Context, email string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM login_attempts WHERE email = $1 AND NOT succeeded`, email)
+	return err
+}
+
+// DeleteBefore removes attempts older than the given time and returns how many were removed
+func (r *LoginAttemptRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM login_attempts WHERE attempted_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
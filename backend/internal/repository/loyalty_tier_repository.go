@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// LoyaltyTierRepository handles database operations for loyalty tiers and customer tier assignment
+type LoyaltyTierRepository struct {
+	db    *sqlx.DB
+	cache Cache
+}
+
+// NewLoyaltyTierRepository creates a new repository with the provided database connection
+func NewLoyaltyTierRepository(db *sqlx.DB) *LoyaltyTierRepository {
+	return &LoyaltyTierRepository{
+		db: db,
+	}
+}
+
+// UseCache sets the customer cache, whose copies recalculations invalidate as they change tiers
+func (r *LoyaltyTierRepository) UseCache(cache Cache) {
+	r.cache = cache
+}
+
+// GetAll retrieves all tiers from the lowest revenue threshold to the highest
+func (r *LoyaltyTierRepository) GetAll(ctx context.Context) ([]models.LoyaltyTier, error) {
+	tiers := []models.LoyaltyTier{}
+	query := `SELECT * FROM loyalty_tiers ORDER BY min_revenue`
+	err := r.db.SelectContext(ctx, &tiers, query)
+	return tiers, err
+}
+
+// GetByName retrieves a tier by name
+func (r *LoyaltyTierRepository) GetByName(ctx context.Context, tier string) (models.LoyaltyTier, error) {
+	var loyaltyTier models.LoyaltyTier
+	query := `SELECT * FROM loyalty_tiers WHERE tier = $1`
+	err := r.db.GetContext(ctx, &loyaltyTier, query, tier)
+	if err == sql.ErrNoRows {
+		return loyaltyTier, notFound("loyalty tier")
+	}
+	return loyaltyTier, err
+}
+
+// Update changes a tier's revenue threshold and benefits
+func (r *LoyaltyTierRepository) Update(ctx context.Context, tier *models.LoyaltyTier) error {
+	query := `
+		UPDATE loyalty_tiers SET
+			min_revenue = $1,
+			discount_percent = $2,
+			free_delivery_threshold = $3,
+			free_deliveries_per_month = $4,
+			updated_at = NOW()
+		WHERE tier = $5
+		RETURNING updated_at`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		tier.MinRevenue,
+		tier.DiscountPercent,
+		tier.FreeDeliveryThreshold,
+		tier.FreeDeliveriesPerMonth,
+		tier.Tier,
+	).Scan(&tier.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return notFound("loyalty tier")
+	}
+	return err
+}
+
+// Recalculate assigns every customer the highest tier their revenue over the trailing
+// 12 months qualifies for. Delivery fees and cancelled orders don't count as revenue.
+// It returns the number of customers whose tier or revenue changed.
+func (r *LoyaltyTierRepository) Recalculate(ctx context.Context) (int64, error) {
+	query := `
+		UPDATE customers c SET
+			tier = t.tier,
+			trailing_revenue = rev.revenue,
+			tier_updated_at = NOW()
+		FROM (
+			SELECT cu.customer_id, COALESCE(SUM((o.total_amount - o.delivery_fee) * o.exchange_rate), 0) AS revenue
+			FROM customers cu
+			LEFT JOIN orders o ON o.customer_id = cu.customer_id
+				AND o.status <> 'Cancelled'
+				AND o.order_date >= NOW() - INTERVAL '12 months'
+			GROUP BY cu.customer_id
+		) rev
+		CROSS JOIN LATERAL (
+			SELECT tier FROM loyalty_tiers
+			WHERE min_revenue <= rev.revenue
+			ORDER BY min_revenue DESC
+			LIMIT 1
+		) t
+		WHERE c.customer_id = rev.customer_id
+		AND (c.tier IS DISTINCT FROM t.tier OR c.trailing_revenue IS DISTINCT FROM rev.revenue)
+		RETURNING c.customer_id`
+
+	changed := []int{}
+	if err := r.db.SelectContext(ctx, &changed, query); err != nil {
+		return 0, err
+	}
+	invalidate(ctx, r.cache, customerCacheKey, changed...)
+	return int64(len(changed)), nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// NotificationRepository handles database operations for in-app notifications
+type NotificationRepository struct {
+	db *sqlx.DB
+}
+
+// NewNotificationRepository creates a new repository with the provided database connection
+func NewNotificationRepository(db *sqlx.DB) *NotificationRepository {
+	return &NotificationRepository{
+		db: db,
+	}
+}
+
+// Create sends a notification to one user
+func (r *NotificationRepository) Create(ctx context.Context, notification *models.Notification) error {
+	query := `
+		INSERT INTO notifications (user_id, type, title, message, entity_type, entity_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING notification_id, created_at`
+
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		notification.UserID,
+		notification.Type,
+		notification.Title,
+		notification.Message,
+		notification.EntityType,
+		notification.EntityID,
+	).Scan(&notification.NotificationID, &notification.CreatedAt)
+}
+
+// CreateForAllUsers sends a notification to every user except the one who caused it, if
+// any, and returns how many users were notified
+func (r *NotificationRepository) CreateForAllUsers(ctx context.Context, notification models.Notification, exceptUserID *int) (int64, error) {
+	query := `
+		INSERT INTO notifications (user_id, type, title, message, entity_type, entity_id)
+		SELECT user_id, $1, $2, $3, $4, $5 FROM users
+		WHERE $6::INTEGER IS NULL OR user_id <> $6`
+
+	result, err := r.db.ExecContext(
+		ctx,
+		query,
+		notification.Type,
+		notification.Title,
+		notification.Message,
+		notification.EntityType,
+		notification.EntityID,
+		exceptUserID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// GetByUser retrieves a user's notifications, newest first, optionally only the unread ones
+func (r *NotificationRepository) GetByUser(ctx context.Context, userID int, unreadOnly bool, page Page) ([]models.Notification, int, error) {
+	notifications := []models.Notification{}
+	query := `
+		SELECT * FROM notifications
+		WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY created_at DESC, notification_id DESC`
+	total, err := selectPage(ctx, r.db, &notifications, page, query, userID, unreadOnly)
+	return notifications, total, err
+}
+
+// CountUnread returns how many notifications a user has not read
+func (r *NotificationRepository) CountUnread(ctx context.Context, userID int) (int, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`, userID)
+	return count, err
+}
+
+// MarkRead marks one of a user's notifications as read. Marking it again keeps the time it
+// was first read.
+func (r *NotificationRepository) MarkRead(ctx context.Context, userID, id int) (models.Notification, error) {
+	var notification models.Notification
+	query := `
+		UPDATE notifications SET read_at = COALESCE(read_at, NOW())
+		WHERE notification_id = $1 AND user_id = $2
+		RETURNING *`
+	err := r.db.GetContext(ctx, &notification, query, id, userID)
+	if err == sql.ErrNoRows {
+		return notification, notFound("notification")
+	}
+	return notification, err
+}
+
+// MarkAllRead marks all of a user's unread notifications as read and returns how many there were
+func (r *NotificationRepository) MarkAllRead(ctx context.Context, userID int) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `UPDATE notifications SET read_at = NOW() WHERE user_id = $1 AND read_at IS NULL`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
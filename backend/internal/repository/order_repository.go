@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/Cezzyy/SCMS/backend/internal/models"
@@ -12,6 +13,35 @@ import (
 	"github.com/lib/pq"
 )
 
+// ErrOrderNotDeliverable is returned when recording delivery of a cancelled or already delivered order
+var ErrOrderNotDeliverable = errors.New("only pending or shipped orders can be delivered")
+
+// ErrInvoiceIssued is returned when editing, deleting or cancelling an order whose sales
+// invoice has been issued
+var ErrInvoiceIssued = conflict("the order's sales invoice has been issued; void it or issue a credit note instead")
+
+// ErrStatusChange is returned when an edit to an order would change its status, which only
+// UpdateStatus does so that stock is issued and invoices voided with it
+var ErrStatusChange = conflict("an order's status can only be changed through the order status endpoint")
+
+// ErrStockIssued is returned when cancelling an order whose items have been taken out of stock
+var ErrStockIssued = conflict("the order's stock has been issued; it can no longer be cancelled")
+
+// issuedInvoice holds for orders o whose sales invoice has been issued: orders that have left
+// Pending or been paid. Issued invoices are only corrected with invoice adjustments.
+const issuedInvoice = `(o.status <> 'Pending' OR o.paid_at IS NOT NULL OR EXISTS (SELECT 1 FROM order_payments p WHERE p.order_id = o.order_id))`
+
+// InsufficientStockError is returned when a sale asks for more of a product than is in stock
+type InsufficientStockError struct {
+	ProductID int
+	Available int
+	Requested int
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("product %d has %d in stock, %d requested", e.ProductID, e.Available, e.Requested)
+}
+
 // OrderRepository handles database operations for orders and order items
 type OrderRepository struct {
 	db *sqlx.DB
@@ -24,21 +54,63 @@ func NewOrderRepository(db *sqlx.DB) *OrderRepository {
 	}
 }
 
-// GetAll retrieves all orders from the database
-func (r *OrderRepository) GetAll(ctx context.Context) ([]models.Order, error) {
+// orderBalanceQuery selects orders with what has been credited and paid against them and
+// the balance left to pay, worked out as in invoiceQuery: nothing is owed on a cancelled
+// order. Wrapped as a subquery, its columns can be filtered and sorted on by name.
+const orderBalanceQuery = `
+	SELECT * FROM (
+		SELECT o.*, t.credited, t.paid,
+			ROUND(CASE WHEN o.status = 'Cancelled' THEN 0 ELSE o.total_amount - t.credited END - t.paid, 2) AS balance
+		FROM orders o
+		CROSS JOIN LATERAL (
+			SELECT
+				COALESCE((SELECT SUM(a.amount) FROM invoice_adjustments a
+					WHERE a.order_id = o.order_id AND a.kind = 'credit_note' AND a.status = 'approved'), 0) AS credited,
+				COALESCE((SELECT SUM(p.amount + p.withholding_amount) FROM order_payments p
+					WHERE p.order_id = o.order_id), 0) AS paid
+		) t
+	) orders`
+
+// GetAll retrieves a page of orders matching the list query with their balances, newest
+// first unless it sorts otherwise, and the total number of matches
+func (r *OrderRepository) GetAll(ctx context.Context, list ListQuery, page Page) ([]models.Order, int, error) {
 	orders := []models.Order{}
-	query := `SELECT * FROM orders ORDER BY order_date DESC`
-	err := r.db.SelectContext(ctx, &orders, query)
-	return orders, err
+	conditions, args, order := list.apply(OrderListColumns, nil, nil)
+	query := orderBalanceQuery + whereClause(conditions) + ` ORDER BY ` + order
+	total, err := selectPage(ctx, r.db, &orders, page, query, args...)
+	return orders, total, err
 }
 
-// GetByID retrieves an order by ID
+// OrderSearchResult is an order matched by Search together with its customer's name
+type OrderSearchResult struct {
+	models.Order
+	CompanyName string `db:"company_name" json:"company_name"`
+}
+
+// Search finds orders by number (with or without a leading #), customer name, status or
+// shipping address, returning a page of matches, newest first, and the total number of matches
+func (r *OrderRepository) Search(ctx context.Context, term string, page Page) ([]OrderSearchResult, int, error) {
+	orders := []OrderSearchResult{}
+	query := `
+		SELECT o.*, c.company_name
+		FROM orders o
+		JOIN customers c ON c.customer_id = o.customer_id
+		WHERE CAST(o.order_id AS TEXT) = $2
+			OR c.company_name ILIKE $1
+			OR o.status ILIKE $1
+			OR o.shipping_address ILIKE $1
+		ORDER BY o.order_date DESC, o.order_id DESC`
+	total, err := selectPage(ctx, r.db, &orders, page, query, "%"+term+"%", strings.TrimPrefix(term, "#"))
+	return orders, total, err
+}
+
+// GetByID retrieves an order by ID with its balance
 func (r *OrderRepository) GetByID(ctx context.Context, id int) (models.Order, error) {
 	var order models.Order
-	query := `SELECT * FROM orders WHERE order_id = $1`
+	query := orderBalanceQuery + ` WHERE order_id = $1`
 	err := r.db.GetContext(ctx, &order, query, id)
 	if err == sql.ErrNoRows {
-		return order, errors.New("order not found")
+		return order, notFound("order")
 	}
 	return order, err
 }
@@ -51,72 +123,26 @@ func (r *OrderRepository) GetByCustomerID(ctx context.Context, customerID int) (
 	return orders, err
 }
 
-// Create inserts a new order into the database
-func (r *OrderRepository) Create(ctx context.Context, order *models.Order) error {
-	tx, err := r.db.BeginTxx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-		}
-	}()
-
-	now := time.Now()
-	order.CreatedAt = now
-	order.UpdatedAt = now
-
-	query := `
-		INSERT INTO orders (
-			customer_id, quotation_id, order_date, shipping_address, 
-			status, total_amount, created_at, updated_at
-		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8
-		) RETURNING order_id, created_at, updated_at`
-
-	err = tx.QueryRowContext(
-		ctx,
-		query,
-		order.CustomerID,
-		order.QuotationID,
-		order.OrderDate,
-		order.ShippingAddress,
-		order.Status,
-		order.TotalAmount,
-		order.CreatedAt,
-		order.UpdatedAt,
-	).Scan(&order.OrderID, &order.CreatedAt, &order.UpdatedAt)
-
-	if err != nil {
-		// Check for PostgreSQL-specific errors
-		if pqErr, ok := err.(*pq.Error); ok {
-			// 23505 is the PostgreSQL error code for unique_violation
-			if pqErr.Code == "23505" {
-				return ErrDuplicateKey
-			}
-		}
-		return err
-	}
-
-	return tx.Commit()
-}
-
-// Update updates an existing order
+// Update updates an existing order. Its status is left as it is: ErrStatusChange is returned
+// when order has another one, and an empty status keeps the current one.
 func (r *OrderRepository) Update(ctx context.Context, order *models.Order) error {
 	order.UpdatedAt = time.Now()
 
 	query := `
-		UPDATE orders SET
+		UPDATE orders o SET
 			customer_id = $1,
 			quotation_id = $2,
 			order_date = $3,
 			shipping_address = $4,
-			status = $5,
 			total_amount = $6,
-			updated_at = $7
-		WHERE order_id = $8
-		RETURNING updated_at`
+			source = $7,
+			updated_at = $8,
+			vat_classification = COALESCE(NULLIF($10, ''), vat_classification),
+			vat_rate = $11,
+			net_amount = $12,
+			vat_amount = $13
+		WHERE order_id = $9 AND NOT ` + issuedInvoice + ` AND ($5 = '' OR o.status = $5)
+		RETURNING updated_at, status`
 
 	result := r.db.QueryRowContext(
 		ctx,
@@ -127,19 +153,45 @@ func (r *OrderRepository) Update(ctx context.Context, order *models.Order) error
 		order.ShippingAddress,
 		order.Status,
 		order.TotalAmount,
+		order.Source,
 		order.UpdatedAt,
 		order.OrderID,
+		order.VATClassification,
+		order.VATRate,
+		order.NetAmount,
+		order.VATAmount,
 	)
 
-	err := result.Scan(&order.UpdatedAt)
+	err := result.Scan(&order.UpdatedAt, &order.Status)
 	if err == sql.ErrNoRows {
-		return errors.New("order not found")
+		if err := r.checkNotIssued(ctx, r.db, order.OrderID); err != nil {
+			return err
+		}
+		return ErrStatusChange
 	}
 	return err
 }
 
-// Delete removes an order by ID
-func (r *OrderRepository) Delete(ctx context.Context, id int) error {
+// checkNotIssued explains why an order could not be changed: ErrInvoiceIssued when its
+// invoice has been issued, not found when there is no such order
+func (r *OrderRepository) checkNotIssued(ctx context.Context, db sqlx.QueryerContext, id int) error {
+	var issued bool
+	err := sqlx.GetContext(ctx, db, &issued, `SELECT `+issuedInvoice+` FROM orders o WHERE o.order_id = $1`, id)
+	if err == sql.ErrNoRows {
+		return notFound("order")
+	}
+	if err != nil {
+		return err
+	}
+	if issued {
+		return ErrInvoiceIssued
+	}
+	return nil
+}
+
+// Delete removes an order by ID, recording its sales invoice number as voided with the
+// given reason. Orders whose invoice has been issued cannot be deleted.
+func (r *OrderRepository) Delete(ctx context.Context, id int, reason string, deletedBy *int) error {
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
@@ -150,6 +202,19 @@ func (r *OrderRepository) Delete(ctx context.Context, id int) error {
 		}
 	}()
 
+	_, err = tx.ExecContext(ctx, `SELECT 1 FROM orders WHERE order_id = $1 FOR UPDATE`, id)
+	if err != nil {
+		return err
+	}
+	if err = r.checkNotIssued(ctx, tx, id); err != nil {
+		return err
+	}
+
+	err = voidDocument(ctx, tx, models.DocumentSeriesSalesInvoice, id, models.VoidDeleted, reason, deletedBy)
+	if err != nil {
+		return err
+	}
+
 	// First delete all order items associated with this order
 	_, err = tx.ExecContext(ctx, `DELETE FROM order_items WHERE order_id = $1`, id)
 	if err != nil {
@@ -168,12 +233,26 @@ func (r *OrderRepository) Delete(ctx context.Context, id int) error {
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("order not found")
+		return notFound("order")
+	}
+
+	// Files uploaded against the order, e.g. proof of delivery images, go with it
+	_, err = tx.ExecContext(ctx, `DELETE FROM attachments WHERE entity = 'order' AND entity_id = $1`, id)
+	if err != nil {
+		return err
 	}
 
 	return tx.Commit()
 }
 
+// GetUpdatedSince retrieves orders changed after the given time
+func (r *OrderRepository) GetUpdatedSince(ctx context.Context, since time.Time) ([]models.Order, error) {
+	orders := []models.Order{}
+	query := `SELECT * FROM orders WHERE updated_at > $1 ORDER BY updated_at`
+	err := r.db.SelectContext(ctx, &orders, query, since)
+	return orders, err
+}
+
 // GetOrderItems retrieves all items for a specific order
 func (r *OrderRepository) GetOrderItems(ctx context.Context, orderID int) ([]models.OrderItem, error) {
 	items := []models.OrderItem{}
@@ -182,14 +261,40 @@ func (r *OrderRepository) GetOrderItems(ctx context.Context, orderID int) ([]mod
 	return items, err
 }
 
+// GetShipmentTotals adds up the weight and volume of an order's items
+func (r *OrderRepository) GetShipmentTotals(ctx context.Context, orderID int) (models.ShipmentTotals, error) {
+	var totals models.ShipmentTotals
+	var withoutWeight, withoutDimensions pq.Int64Array
+	query := `
+		SELECT
+			COALESCE(ROUND(SUM(oi.quantity * p.weight_kg), 3), 0) AS weight_kg,
+			COALESCE(ROUND(SUM(oi.quantity * p.length_cm * p.width_cm * p.height_cm) / 1000000, 4), 0) AS volume_m3,
+			COALESCE(ARRAY_AGG(DISTINCT p.product_id) FILTER (WHERE p.weight_kg IS NULL), '{}') AS without_weight,
+			COALESCE(ARRAY_AGG(DISTINCT p.product_id) FILTER (
+				WHERE p.length_cm IS NULL OR p.width_cm IS NULL OR p.height_cm IS NULL
+			), '{}') AS without_dimensions
+		FROM order_items oi
+		JOIN products p ON p.product_id = oi.product_id
+		WHERE oi.order_id = $1`
+	err := r.db.QueryRowContext(ctx, query, orderID).
+		Scan(&totals.WeightKg, &totals.VolumeM3, &withoutWeight, &withoutDimensions)
+	if err != nil {
+		return totals, err
+	}
+
+	totals.ProductsWithoutWeight = intList(withoutWeight)
+	totals.ProductsWithoutDimensions = intList(withoutDimensions)
+	return totals, nil
+}
+
 // CreateOrderItem inserts a new order item into the database
 func (r *OrderRepository) CreateOrderItem(ctx context.Context, item *models.OrderItem) error {
 	query := `
 		INSERT INTO order_items (
-			order_id, product_id, quantity, unit_price, discount
+			order_id, product_id, quantity, unit_price, discount, discount_type, discount_value
 		) VALUES (
-			$1, $2, $3, $4, $5
-		) RETURNING order_item_id, line_total`
+			$1, $2, $3, $4, $5, COALESCE(NULLIF($6, ''), 'none'), $7
+		) RETURNING order_item_id, line_total, discount_type`
 
 	err := r.db.QueryRowContext(
 		ctx,
@@ -199,7 +304,9 @@ func (r *OrderRepository) CreateOrderItem(ctx context.Context, item *models.Orde
 		item.Quantity,
 		item.UnitPrice,
 		item.Discount,
-	).Scan(&item.OrderItemID, &item.LineTotal)
+		item.DiscountType,
+		item.DiscountValue,
+	).Scan(&item.OrderItemID, &item.LineTotal, &item.DiscountType)
 
 	return err
 }
@@ -212,9 +319,11 @@ func (r *OrderRepository) UpdateOrderItem(ctx context.Context, item *models.Orde
 			product_id = $2,
 			quantity = $3,
 			unit_price = $4,
-			discount = $5
-		WHERE order_item_id = $6
-		RETURNING line_total`
+			discount = $5,
+			discount_type = COALESCE(NULLIF($6, ''), 'none'),
+			discount_value = $7
+		WHERE order_item_id = $8
+		RETURNING line_total, discount_type`
 
 	result := r.db.QueryRowContext(
 		ctx,
@@ -224,12 +333,14 @@ func (r *OrderRepository) UpdateOrderItem(ctx context.Context, item *models.Orde
 		item.Quantity,
 		item.UnitPrice,
 		item.Discount,
+		item.DiscountType,
+		item.DiscountValue,
 		item.OrderItemID,
 	)
 
-	err := result.Scan(&item.LineTotal)
+	err := result.Scan(&item.LineTotal, &item.DiscountType)
 	if err == sql.ErrNoRows {
-		return errors.New("order item not found")
+		return notFound("order item")
 	}
 	return err
 }
@@ -247,7 +358,7 @@ func (r *OrderRepository) DeleteOrderItem(ctx context.Context, id int) error {
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("order item not found")
+		return notFound("order item")
 	}
 
 	return nil
@@ -265,18 +376,156 @@ func (r *OrderRepository) CreateOrderWithItems(ctx context.Context, order *model
 		}
 	}()
 
+	if err = insertOrderWithItems(ctx, tx, order, items); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateCashSale records a counter sale in a single transaction: the order is created paid
+// and delivered, the payment is recorded against it, and its items are taken out of stock
+// straight away. Nothing is written when a product has no inventory record or not enough
+// stock; see issueOrderStock. The stock changes are returned for stock alerts.
+func (r *OrderRepository) CreateCashSale(ctx context.Context, order *models.Order, items []models.OrderItem, payment *models.Payment) ([]models.StockChange, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	now := time.Now()
+	order.Status = "Delivered"
+	order.OrderDate = now
+	order.DeliveredAt = &now
+	order.PaidAt = &now
+	if err = insertOrderWithItems(ctx, tx, order, items); err != nil {
+		return nil, err
+	}
+
+	if payment.Amount > 0 {
+		payment.OrderID = order.OrderID
+		payment.PaidAt = now
+		if err = insertPayment(ctx, tx, payment); err != nil {
+			return nil, err
+		}
+	}
+
+	changes, err := issueOrderStock(ctx, tx, order.OrderID, payment.ReceivedBy)
+	if err != nil {
+		return nil, err
+	}
+	order.StockIssuedAt = &now
+
+	return changes, tx.Commit()
+}
+
+// issueOrderStock takes an order's items out of stock as it ships, recording what was taken
+// in stock_issues, and returns the inventory records it took from with their stock before.
+// Stock is only taken once per order. An *InsufficientStockError or
+// *MissingInventoryError is returned when a product falls short, and the caller must then
+// roll back.
+func issueOrderStock(ctx context.Context, tx *sqlx.Tx, orderID int, issuedBy *int) ([]models.StockChange, error) {
+	var issued bool
+	err := tx.QueryRowContext(ctx, `SELECT stock_issued_at IS NOT NULL FROM orders WHERE order_id = $1 FOR UPDATE`,
+		orderID).Scan(&issued)
+	if err == sql.ErrNoRows {
+		return nil, notFound("order")
+	}
+	if err != nil || issued {
+		return nil, err
+	}
+
+	// The same product can appear on several lines; stock is checked against the total
+	var lines []struct {
+		ProductID int `db:"product_id"`
+		Quantity  int `db:"quantity"`
+	}
+	err = tx.SelectContext(ctx, &lines, `
+		SELECT product_id, SUM(quantity) AS quantity FROM order_items
+		WHERE order_id = $1
+		GROUP BY product_id
+		ORDER BY product_id`, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	reference := fmt.Sprintf("SO-%d", orderID)
+	changes := make([]models.StockChange, 0, len(lines))
+	for _, line := range lines {
+		// Lock the inventory row, in product order, so concurrent orders cannot ship the same units
+		var inventoryID, stock int
+		err = tx.QueryRowContext(ctx, `SELECT inventory_id, current_stock FROM inventory WHERE product_id = $1 FOR UPDATE`,
+			line.ProductID).Scan(&inventoryID, &stock)
+		if err == sql.ErrNoRows {
+			return nil, &MissingInventoryError{ProductID: line.ProductID}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if stock < line.Quantity {
+			return nil, &InsufficientStockError{ProductID: line.ProductID, Available: stock, Requested: line.Quantity}
+		}
+
+		change := models.StockChange{PreviousStock: stock}
+		err = tx.GetContext(ctx, &change.Inventory, `
+			UPDATE inventory SET
+				current_stock = current_stock - $1,
+				updated_at = NOW()
+			WHERE inventory_id = $2
+			RETURNING *`, line.Quantity, inventoryID)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO stock_issues (inventory_id, product_id, order_id, quantity, reference, issued_by)
+			VALUES ($1, $2, $3, $4, $5, $6)`, inventoryID, line.ProductID, orderID, line.Quantity, reference, issuedBy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE orders SET stock_issued_at = NOW() WHERE order_id = $1`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// insertOrderWithItems inserts an order and its items inside a transaction, numbering it in
+// the sales invoice series
+func insertOrderWithItems(ctx context.Context, tx *sqlx.Tx, order *models.Order, items []models.OrderItem) error {
 	now := time.Now()
 	order.CreatedAt = now
 	order.UpdatedAt = now
 
+	documentNo, err := nextDocumentNo(ctx, tx, models.DocumentSeriesSalesInvoice)
+	if err != nil {
+		return err
+	}
+	order.DocumentNo = &documentNo
+
 	// Insert the order first
 	query := `
 		INSERT INTO orders (
 			customer_id, quotation_id, order_date, shipping_address, 
-			status, total_amount, created_at, updated_at
+			status, total_amount, created_at, updated_at, delivery_fee,
+			free_delivery_reason, source, delivered_at, payment_method,
+			amount_paid, paid_at, shift_id, document_no, vat_classification, vat_rate,
+			net_amount, vat_amount, currency, exchange_rate
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8
-		) RETURNING order_id, created_at, updated_at`
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
+			COALESCE($11, (SELECT source FROM quotations WHERE quotation_id = $2)),
+			$12, $13, $14, $15, $16, $17,
+			COALESCE(NULLIF($18, ''), (SELECT vat_classification FROM customers WHERE customer_id = $1)), $19, $20, $21,
+			COALESCE(NULLIF($22, ''), '` + models.BaseCurrency + `'), COALESCE(NULLIF($23::NUMERIC, 0), 1)
+		) RETURNING order_id, created_at, updated_at, source, vat_classification, currency, exchange_rate`
 
 	err = tx.QueryRowContext(
 		ctx,
@@ -289,7 +538,22 @@ func (r *OrderRepository) CreateOrderWithItems(ctx context.Context, order *model
 		order.TotalAmount,
 		order.CreatedAt,
 		order.UpdatedAt,
-	).Scan(&order.OrderID, &order.CreatedAt, &order.UpdatedAt)
+		order.DeliveryFee,
+		order.FreeDeliveryReason,
+		order.Source,
+		order.DeliveredAt,
+		order.PaymentMethod,
+		order.AmountPaid,
+		order.PaidAt,
+		order.ShiftID,
+		order.DocumentNo,
+		order.VATClassification,
+		order.VATRate,
+		order.NetAmount,
+		order.VATAmount,
+		order.Currency,
+		order.ExchangeRate,
+	).Scan(&order.OrderID, &order.CreatedAt, &order.UpdatedAt, &order.Source, &order.VATClassification, &order.Currency, &order.ExchangeRate)
 
 	if err != nil {
 		return err
@@ -298,10 +562,10 @@ func (r *OrderRepository) CreateOrderWithItems(ctx context.Context, order *model
 	// Then insert all the items
 	itemQuery := `
 		INSERT INTO order_items (
-			order_id, product_id, quantity, unit_price, discount
+			order_id, product_id, quantity, unit_price, discount, discount_type, discount_value
 		) VALUES (
-			$1, $2, $3, $4, $5
-		) RETURNING order_item_id, line_total`
+			$1, $2, $3, $4, $5, COALESCE(NULLIF($6, ''), 'none'), $7
+		) RETURNING order_item_id, line_total, discount_type`
 
 	for i := range items {
 		items[i].OrderID = order.OrderID
@@ -313,18 +577,34 @@ func (r *OrderRepository) CreateOrderWithItems(ctx context.Context, order *model
 			items[i].Quantity,
 			items[i].UnitPrice,
 			items[i].Discount,
-		).Scan(&items[i].OrderItemID, &items[i].LineTotal)
+			items[i].DiscountType,
+			items[i].DiscountValue,
+		).Scan(&items[i].OrderItemID, &items[i].LineTotal, &items[i].DiscountType)
 
 		if err != nil {
 			return err
 		}
 	}
 
-	return tx.Commit()
+	return nil
 }
 
-// UpdateStatus updates only the status of an existing order
-func (r *OrderRepository) UpdateStatus(ctx context.Context, id int, status string) error {
+// CountFreeDeliveries counts a customer's non-cancelled orders since a time whose delivery was waived for the given reason
+func (r *OrderRepository) CountFreeDeliveries(ctx context.Context, customerID int, reason string, since time.Time) (int, error) {
+	var count int
+	query := `
+		SELECT COUNT(*) FROM orders
+		WHERE customer_id = $1 AND free_delivery_reason = $2 AND order_date >= $3 AND status <> 'Cancelled'`
+	err := r.db.GetContext(ctx, &count, query, customerID, reason, since)
+	return count, err
+}
+
+// UpdateStatus updates only the status of an existing order. Cancelling an order voids its
+// sales invoice number, recording reason and who cancelled it; once the invoice has been
+// issued the order can only be cancelled by an approved void. Shipping or delivering an
+// order takes its items out of stock in the same transaction, failing as issueOrderStock does,
+// and returns the stock changes.
+func (r *OrderRepository) UpdateStatus(ctx context.Context, id int, status, reason string, updatedBy *int) ([]models.StockChange, error) {
 	// Validate status
 	validStatuses := map[string]bool{
 		"Pending":   true,
@@ -334,58 +614,178 @@ func (r *OrderRepository) UpdateStatus(ctx context.Context, id int, status strin
 	}
 
 	if !validStatuses[status] {
-		return fmt.Errorf("invalid status: %s", status)
+		return nil, fmt.Errorf("invalid status: %s", status)
 	}
 
-	// Get the current status of the order
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Get the current status of the order, locking it until the change is saved
 	var currentStatus string
-	err := r.db.QueryRowContext(ctx, "SELECT status FROM orders WHERE order_id = $1", id).Scan(&currentStatus)
+	var issued, stockIssued bool
+	err = tx.QueryRowContext(ctx, "SELECT status, "+issuedInvoice+", stock_issued_at IS NOT NULL FROM orders o WHERE order_id = $1 FOR UPDATE", id).
+		Scan(&currentStatus, &issued, &stockIssued)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return errors.New("order not found")
+			err = notFound("order")
+			return nil, err
 		}
-		return fmt.Errorf("failed to get current order status: %w", err)
+		return nil, fmt.Errorf("failed to get current order status: %w", err)
 	}
 
 	// Validate status flow
 	if currentStatus == "Cancelled" {
-		return errors.New("cancelled orders cannot be updated")
+		err = errors.New("cancelled orders cannot be updated")
+		return nil, err
 	}
 
 	if currentStatus == "Delivered" {
-		return errors.New("delivered orders cannot be updated")
+		err = errors.New("delivered orders cannot be updated")
+		return nil, err
 	}
 
 	if currentStatus == "Shipped" && status == "Pending" {
-		return errors.New("shipped orders cannot go back to pending status")
+		err = errors.New("shipped orders cannot go back to pending status")
+		return nil, err
+	}
+
+	if status == "Cancelled" && issued {
+		err = ErrInvoiceIssued
+		return nil, err
+	}
+
+	// Shipped goods come back as a return, not by cancelling the order they left with
+	if status == "Cancelled" && stockIssued {
+		err = ErrStockIssued
+		return nil, err
 	}
 
 	// Update the status in the database
 	query := `
 		UPDATE orders 
-		SET status = $1, updated_at = NOW() 
+		SET status = $1, updated_at = NOW(),
+			delivered_at = CASE WHEN $1 = 'Delivered' THEN NOW() ELSE delivered_at END
 		WHERE order_id = $2
-		RETURNING *`
+		RETURNING order_id`
 
-	var order models.Order
-	err = r.db.QueryRowContext(ctx, query, status, id).Scan(
-		&order.OrderID,
-		&order.CustomerID,
-		&order.QuotationID,
-		&order.OrderDate,
-		&order.ShippingAddress,
-		&order.Status,
-		&order.TotalAmount,
-		&order.CreatedAt,
-		&order.UpdatedAt,
-	)
+	var orderID int
+	err = tx.QueryRowContext(ctx, query, status, id).Scan(&orderID)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return errors.New("order not found")
+			return nil, notFound("order")
 		}
-		return fmt.Errorf("failed to update order status: %w", err)
+		return nil, fmt.Errorf("failed to update order status: %w", err)
 	}
 
-	return nil
+	if status == "Cancelled" {
+		err = voidDocument(ctx, tx, models.DocumentSeriesSalesInvoice, id, models.VoidCancelled, reason, updatedBy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var changes []models.StockChange
+	if status == "Shipped" || status == "Delivered" {
+		changes, err = issueOrderStock(ctx, tx, id, updatedBy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return changes, tx.Commit()
+}
+
+// GetProofOfDelivery retrieves the proof of delivery recorded for an order
+func (r *OrderRepository) GetProofOfDelivery(ctx context.Context, orderID int) (models.ProofOfDelivery, error) {
+	var pod models.ProofOfDelivery
+	query := `SELECT * FROM proof_of_delivery WHERE order_id = $1`
+	err := r.db.GetContext(ctx, &pod, query, orderID)
+	if err == sql.ErrNoRows {
+		return pod, notFound("proof of delivery")
+	}
+	return pod, err
+}
+
+// RecordDelivery stores a proof of delivery and marks the order Delivered at the time it was
+// captured, in one transaction, taking its items out of stock if it had not shipped. Only
+// pending or shipped orders can be delivered. Any stock changes are returned.
+func (r *OrderRepository) RecordDelivery(ctx context.Context, pod *models.ProofOfDelivery) ([]models.StockChange, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Lock the order so a concurrent status change can't slip in between the check and the update
+	var status string
+	err = tx.QueryRowContext(ctx, `SELECT status FROM orders WHERE order_id = $1 FOR UPDATE`, pod.OrderID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return nil, notFound("order")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status != "Pending" && status != "Shipped" {
+		err = ErrOrderNotDeliverable
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO proof_of_delivery (
+			order_id, recipient_name, signature_attachment_id, photo_attachment_id,
+			latitude, longitude, delivered_at, captured_by
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8
+		) RETURNING created_at`
+
+	err = tx.QueryRowContext(
+		ctx,
+		query,
+		pod.OrderID,
+		pod.RecipientName,
+		pod.SignatureAttachmentID,
+		pod.PhotoAttachmentID,
+		pod.Latitude,
+		pod.Longitude,
+		pod.DeliveredAt,
+		pod.CapturedBy,
+	).Scan(&pod.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE orders SET status = 'Delivered', delivered_at = $1, updated_at = NOW()
+		WHERE order_id = $2`, pod.DeliveredAt, pod.OrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := issueOrderStock(ctx, tx, pod.OrderID, pod.CapturedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, tx.Commit()
+}
+
+// intList converts a scanned integer array to a list of IDs
+func intList(values pq.Int64Array) []int {
+	list := make([]int, len(values))
+	for i, value := range values {
+		list[i] = int(value)
+	}
+	return list
 }
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Page limits a list query to a window of rows. A zero Limit returns every row.
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+// selectPage runs a list query into dest, applying the page window, and returns the total
+// number of rows the query matches regardless of the window. The query must not already
+// contain LIMIT or OFFSET and should order by a unique column so pages don't overlap.
+func selectPage(ctx context.Context, db *sqlx.DB, dest interface{}, page Page, query string, args ...interface{}) (int, error) {
+	if page.Limit <= 0 {
+		if err := db.SelectContext(ctx, dest, query, args...); err != nil {
+			return 0, err
+		}
+		return reflect.ValueOf(dest).Elem().Len(), nil
+	}
+
+	var total int
+	if err := db.GetContext(ctx, &total, `SELECT COUNT(*) FROM (`+query+`) AS page_source`, args...); err != nil {
+		return 0, err
+	}
+
+	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	args = append(args, page.Limit, page.Offset)
+	if err := db.SelectContext(ctx, dest, query, args...); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
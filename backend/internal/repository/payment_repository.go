@@ -0,0 +1,256 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// PaymentFilter narrows a payment query. From and To bound when the payment was received;
+// CustomerID, when set, keeps the payments on that customer's orders.
+type PaymentFilter struct {
+	CustomerID    int
+	PaymentMethod string
+	From          *time.Time
+	To            *time.Time
+}
+
+// ErrNoWithholding is returned when recording a Form 2307 for a payment without tax withheld
+var ErrNoWithholding = conflict("no tax was withheld on this payment")
+
+// paymentRecordQuery selects payments with their method's name and the order's customer
+const paymentRecordQuery = `
+	SELECT p.*, m.name AS method_name, o.customer_id, c.company_name, c.tin
+	FROM order_payments p
+	JOIN payment_methods m ON m.code = p.payment_method
+	JOIN orders o ON o.order_id = p.order_id
+	JOIN customers c ON c.customer_id = o.customer_id`
+
+// PaymentRepository handles database operations for payment methods and order payments
+type PaymentRepository struct {
+	db *sqlx.DB
+}
+
+// NewPaymentRepository creates a new repository with the provided database connection
+func NewPaymentRepository(db *sqlx.DB) *PaymentRepository {
+	return &PaymentRepository{
+		db: db,
+	}
+}
+
+// GetMethods retrieves the payment methods in display order, optionally only the active ones
+func (r *PaymentRepository) GetMethods(ctx context.Context, activeOnly bool) ([]models.PaymentMethod, error) {
+	methods := []models.PaymentMethod{}
+	query := `SELECT * FROM payment_methods`
+	if activeOnly {
+		query += ` WHERE active`
+	}
+	query += ` ORDER BY sort_order, name`
+	err := r.db.SelectContext(ctx, &methods, query)
+	return methods, err
+}
+
+// GetMethod retrieves a payment method by code
+func (r *PaymentRepository) GetMethod(ctx context.Context, code string) (models.PaymentMethod, error) {
+	var method models.PaymentMethod
+	err := r.db.GetContext(ctx, &method, `SELECT * FROM payment_methods WHERE code = $1`, code)
+	if err == sql.ErrNoRows {
+		return method, notFound("payment method")
+	}
+	return method, err
+}
+
+// CreateMethod adds a payment method
+func (r *PaymentRepository) CreateMethod(ctx context.Context, method *models.PaymentMethod) error {
+	query := `
+		INSERT INTO payment_methods (code, name, requires_reference, active, sort_order)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query, method.Code, method.Name, method.RequiresReference, method.Active, method.SortOrder).
+		Scan(&method.CreatedAt, &method.UpdatedAt)
+
+	return translateReferenceError(err)
+}
+
+// UpdateMethod changes a payment method's name and settings. The code cannot change since
+// orders and payments refer to it; retired methods are deactivated instead.
+func (r *PaymentRepository) UpdateMethod(ctx context.Context, method *models.PaymentMethod) error {
+	query := `
+		UPDATE payment_methods SET
+			name = $1,
+			requires_reference = $2,
+			active = $3,
+			sort_order = $4,
+			updated_at = NOW()
+		WHERE code = $5
+		RETURNING created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query, method.Name, method.RequiresReference, method.Active, method.SortOrder, method.Code).
+		Scan(&method.CreatedAt, &method.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return notFound("payment method")
+	}
+	return err
+}
+
+// GetAll retrieves payments matching the filter with their order's customer, latest first
+func (r *PaymentRepository) GetAll(ctx context.Context, filter PaymentFilter) ([]models.PaymentRecord, error) {
+	payments := []models.PaymentRecord{}
+
+	conditions := []string{}
+	args := []interface{}{}
+	if filter.CustomerID != 0 {
+		args = append(args, filter.CustomerID)
+		conditions = append(conditions, fmt.Sprintf("o.customer_id = $%d", len(args)))
+	}
+	if filter.PaymentMethod != "" {
+		args = append(args, filter.PaymentMethod)
+		conditions = append(conditions, fmt.Sprintf("p.payment_method = $%d", len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("p.paid_at >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("p.paid_at < $%d", len(args)))
+	}
+
+	query := paymentRecordQuery + whereClause(conditions) + ` ORDER BY p.paid_at DESC, p.payment_id DESC`
+
+	err := r.db.SelectContext(ctx, &payments, query, args...)
+	return payments, err
+}
+
+// GetByOrder retrieves the payments received against an order, oldest first
+func (r *PaymentRepository) GetByOrder(ctx context.Context, orderID int) ([]models.Payment, error) {
+	payments := []models.Payment{}
+	query := `SELECT * FROM order_payments WHERE order_id = $1 ORDER BY paid_at, payment_id`
+	err := r.db.SelectContext(ctx, &payments, query, orderID)
+	return payments, err
+}
+
+// Create records a payment against an order
+func (r *PaymentRepository) Create(ctx context.Context, payment *models.Payment) error {
+	return insertPayment(ctx, r.db, payment)
+}
+
+// CreateFromGateway records a payment a gateway reported under the gateway's reference for it.
+// It returns false without recording anything when that payment was already recorded.
+func (r *PaymentRepository) CreateFromGateway(ctx context.Context, provider, reference string, payment *models.Payment) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO payment_gateway_payments (provider, reference) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`, provider, reference)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	if err := insertPayment(ctx, tx, payment); err != nil {
+		return false, err
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE payment_gateway_payments SET payment_id = $1 WHERE provider = $2 AND reference = $3`,
+		payment.PaymentID, provider, reference)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// insertPayment inserts a payment, defaulting its time to now
+func insertPayment(ctx context.Context, db sqlx.QueryerContext, payment *models.Payment) error {
+	if payment.PaidAt.IsZero() {
+		payment.PaidAt = time.Now()
+	}
+
+	query := `
+		INSERT INTO order_payments (
+			order_id, payment_method, amount, reference_no, paid_at, received_by, notes,
+			withholding_amount, atc_code
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING payment_id, created_at, form_2307_reference`
+
+	err := db.QueryRowxContext(ctx, query, payment.OrderID, payment.PaymentMethod, payment.Amount,
+		payment.ReferenceNo, payment.PaidAt, payment.ReceivedBy, payment.Notes,
+		payment.WithholdingAmount, payment.ATCCode).
+		Scan(&payment.PaymentID, &payment.CreatedAt, &payment.Form2307Reference)
+
+	return translateReferenceError(err)
+}
+
+// GetOutstanding2307 retrieves the payments with tax withheld whose Form 2307 has not been
+// received, oldest first, for one customer or all when customerID is 0
+func (r *PaymentRepository) GetOutstanding2307(ctx context.Context, customerID int) ([]models.PaymentRecord, error) {
+	payments := []models.PaymentRecord{}
+	query := paymentRecordQuery + `
+		WHERE p.withholding_amount > 0 AND p.form_2307_received_on IS NULL
+		AND ($1 = 0 OR o.customer_id = $1)
+		ORDER BY c.company_name, o.customer_id, p.paid_at, p.payment_id`
+	err := r.db.SelectContext(ctx, &payments, query, customerID)
+	return payments, err
+}
+
+// SetForm2307 records the Form 2307 received for a payment's withheld tax, or clears it when
+// receivedOn is nil. ErrNoWithholding is returned for payments without tax withheld.
+func (r *PaymentRepository) SetForm2307(ctx context.Context, paymentID int, receivedOn *time.Time, reference string) (models.Payment, error) {
+	var payment models.Payment
+	query := `
+		UPDATE order_payments SET form_2307_received_on = $2, form_2307_reference = $3
+		WHERE payment_id = $1 AND withholding_amount > 0
+		RETURNING *`
+	err := r.db.GetContext(ctx, &payment, query, paymentID, receivedOn, reference)
+	if err == sql.ErrNoRows {
+		var exists bool
+		if err := r.db.GetContext(ctx, &exists, `SELECT EXISTS(SELECT 1 FROM order_payments WHERE payment_id = $1)`, paymentID); err != nil {
+			return payment, err
+		}
+		if !exists {
+			return payment, notFound("payment")
+		}
+		return payment, ErrNoWithholding
+	}
+	return payment, err
+}
+
+// GetWithholdingSummary totals the tax withheld on payments received from from up to but not
+// including to, per customer and ATC code
+func (r *PaymentRepository) GetWithholdingSummary(ctx context.Context, from, to time.Time) ([]models.WithholdingSummaryRow, error) {
+	rows := []models.WithholdingSummaryRow{}
+	query := `
+		SELECT
+			o.customer_id,
+			c.company_name,
+			c.tin,
+			COALESCE(p.atc_code, '') AS atc_code,
+			COUNT(*) AS payment_count,
+			SUM(p.amount + p.withholding_amount) AS gross,
+			SUM(p.withholding_amount) AS withheld,
+			COALESCE(SUM(p.withholding_amount) FILTER (WHERE p.form_2307_received_on IS NOT NULL), 0) AS received,
+			COALESCE(SUM(p.withholding_amount) FILTER (WHERE p.form_2307_received_on IS NULL), 0) AS outstanding
+		FROM order_payments p
+		JOIN orders o ON o.order_id = p.order_id
+		JOIN customers c ON c.customer_id = o.customer_id
+		WHERE p.withholding_amount > 0 AND p.paid_at >= $1 AND p.paid_at < $2
+		GROUP BY o.customer_id, c.company_name, c.tin, p.atc_code
+		ORDER BY c.company_name, o.customer_id, atc_code`
+	err := r.db.SelectContext(ctx, &rows, query, from, to)
+	return rows, err
+}
@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrCheckNotEditable is returned when changing the details of a check that is no longer on hand
+	ErrCheckNotEditable = conflict("only checks on hand can be edited")
+
+	// ErrCheckTransition is returned when a check cannot move to the requested status
+	ErrCheckTransition = conflict("check cannot move to this status")
+)
+
+// checkQuery selects checks with their customer's name
+const checkQuery = `
+	SELECT k.*, c.company_name
+	FROM post_dated_checks k
+	JOIN customers c ON c.customer_id = k.customer_id`
+
+// CheckFilter narrows a post-dated check query. MaturityFrom and MaturityTo are inclusive.
+type CheckFilter struct {
+	CustomerID   int
+	OrderID      int
+	Status       string
+	MaturityFrom *time.Time
+	MaturityTo   *time.Time
+}
+
+// PostDatedCheckRepository handles database operations for the post-dated check register
+type PostDatedCheckRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostDatedCheckRepository creates a new repository with the provided database connection
+func NewPostDatedCheckRepository(db *sqlx.DB) *PostDatedCheckRepository {
+	return &PostDatedCheckRepository{
+		db: db,
+	}
+}
+
+// GetAll retrieves checks matching the filter by maturity date
+func (r *PostDatedCheckRepository) GetAll(ctx context.Context, filter CheckFilter) ([]models.PostDatedCheck, error) {
+	checks := []models.PostDatedCheck{}
+
+	conditions := []string{}
+	args := []interface{}{}
+	if filter.CustomerID != 0 {
+		args = append(args, filter.CustomerID)
+		conditions = append(conditions, fmt.Sprintf("k.customer_id = $%d", len(args)))
+	}
+	if filter.OrderID != 0 {
+		args = append(args, filter.OrderID)
+		conditions = append(conditions, fmt.Sprintf("k.order_id = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("k.status = $%d", len(args)))
+	}
+	if filter.MaturityFrom != nil {
+		args = append(args, *filter.MaturityFrom)
+		conditions = append(conditions, fmt.Sprintf("k.maturity_date >= $%d", len(args)))
+	}
+	if filter.MaturityTo != nil {
+		args = append(args, *filter.MaturityTo)
+		conditions = append(conditions, fmt.Sprintf("k.maturity_date <= $%d", len(args)))
+	}
+
+	query := checkQuery + whereClause(conditions) + ` ORDER BY k.maturity_date, k.check_id`
+	err := r.db.SelectContext(ctx, &checks, query, args...)
+	return checks, err
+}
+
+// GetMaturing retrieves the checks on hand that mature on or before a day, overdue ones included
+func (r *PostDatedCheckRepository) GetMaturing(ctx context.Context, before time.Time) ([]models.PostDatedCheck, error) {
+	checks := []models.PostDatedCheck{}
+	query := checkQuery + ` WHERE k.status = 'on_hand' AND k.maturity_date <= $1 ORDER BY k.maturity_date, k.check_id`
+	err := r.db.SelectContext(ctx, &checks, query, before)
+	return checks, err
+}
+
+// GetUnreminded retrieves the maturing checks no reminder has been sent for yet
+func (r *PostDatedCheckRepository) GetUnreminded(ctx context.Context, before time.Time) ([]models.PostDatedCheck, error) {
+	checks := []models.PostDatedCheck{}
+	query := checkQuery + `
+		WHERE k.status = 'on_hand' AND k.maturity_date <= $1 AND k.reminder_sent_at IS NULL
+		ORDER BY k.maturity_date, k.check_id`
+	err := r.db.SelectContext(ctx, &checks, query, before)
+	return checks, err
+}
+
+// MarkReminded records that a maturity reminder was sent for the checks
+func (r *PostDatedCheckRepository) MarkReminded(ctx context.Context, ids []int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE post_dated_checks SET reminder_sent_at = NOW() WHERE check_id = ANY($1)`,
+		pq.Array(ids))
+	return err
+}
+
+// GetByID retrieves a check by ID
+func (r *PostDatedCheckRepository) GetByID(ctx context.Context, id int) (models.PostDatedCheck, error) {
+	var check models.PostDatedCheck
+	err := r.db.GetContext(ctx, &check, checkQuery+` WHERE k.check_id = $1`, id)
+	if err == sql.ErrNoRows {
+		return check, notFound("check")
+	}
+	return check, err
+}
+
+// Create registers a check received from a customer
+func (r *PostDatedCheckRepository) Create(ctx context.Context, check *models.PostDatedCheck) error {
+	query := `
+		INSERT INTO post_dated_checks (
+			customer_id, order_id, check_number, bank, amount, maturity_date, received_date, notes, created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING check_id`
+
+	var id int
+	err := r.db.QueryRowContext(ctx, query, check.CustomerID, check.OrderID, check.CheckNumber, check.Bank,
+		check.Amount, check.MaturityDate, check.ReceivedDate, check.Notes, check.CreatedBy).Scan(&id)
+	if err != nil {
+		return translateReferenceError(err)
+	}
+
+	*check, err = r.GetByID(ctx, id)
+	return err
+}
+
+// Update changes the details of a check still on hand. Moving the maturity date sends the
+// reminder again.
+func (r *PostDatedCheckRepository) Update(ctx context.Context, check *models.PostDatedCheck) error {
+	query := `
+		UPDATE post_dated_checks SET
+			customer_id = $1,
+			order_id = $2,
+			check_number = $3,
+			bank = $4,
+			amount = $5,
+			reminder_sent_at = CASE WHEN maturity_date = $6 THEN reminder_sent_at END,
+			maturity_date = $6,
+			received_date = $7,
+			notes = $8,
+			updated_at = NOW()
+		WHERE check_id = $9 AND status = 'on_hand'
+		RETURNING check_id`
+
+	var id int
+	err := r.db.QueryRowContext(ctx, query, check.CustomerID, check.OrderID, check.CheckNumber, check.Bank,
+		check.Amount, check.MaturityDate, check.ReceivedDate, check.Notes, check.CheckID).Scan(&id)
+	if err == sql.ErrNoRows {
+		if _, err := r.GetByID(ctx, check.CheckID); err != nil {
+			return err
+		}
+		return ErrCheckNotEditable
+	}
+	if err != nil {
+		return translateReferenceError(err)
+	}
+
+	*check, err = r.GetByID(ctx, check.CheckID)
+	return err
+}
+
+// UpdateStatus moves a check to a new status on a day. Clearing a check that pays for an
+// order records a check payment on the order in the same transaction; bouncing records the
+// reason and leaves the order unpaid.
+func (r *PostDatedCheckRepository) UpdateStatus(ctx context.Context, id int, status string, day time.Time, reason string, userID *int) (models.PostDatedCheck, error) {
+	var check models.PostDatedCheck
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return check, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	err = tx.GetContext(ctx, &check, `SELECT *, '' AS company_name FROM post_dated_checks WHERE check_id = $1 FOR UPDATE`, id)
+	if err == sql.ErrNoRows {
+		err = notFound("check")
+		return check, err
+	}
+	if err != nil {
+		return check, err
+	}
+	if !models.CanTransitionCheck(check.Status, status) {
+		err = ErrCheckTransition
+		return check, err
+	}
+
+	var paymentID *int
+	switch status {
+	case models.CheckDeposited:
+		_, err = tx.ExecContext(ctx, `
+			UPDATE post_dated_checks SET status = $1, deposited_date = $2, updated_at = NOW()
+			WHERE check_id = $3`, status, day, id)
+	case models.CheckCleared:
+		if check.OrderID != nil {
+			reference := check.Bank + " " + check.CheckNumber
+			payment := models.Payment{
+				OrderID:       *check.OrderID,
+				PaymentMethod: models.PaymentCheck,
+				Amount:        check.Amount,
+				ReferenceNo:   &reference,
+				PaidAt:        day,
+				ReceivedBy:    userID,
+				Notes:         fmt.Sprintf("Post-dated check #%d", check.CheckID),
+			}
+			if err = insertPayment(ctx, tx, &payment); err != nil {
+				return check, err
+			}
+			paymentID = &payment.PaymentID
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE post_dated_checks SET status = $1, cleared_date = $2, payment_id = $3, updated_at = NOW()
+			WHERE check_id = $4`, status, day, paymentID, id)
+	case models.CheckBounced:
+		_, err = tx.ExecContext(ctx, `
+			UPDATE post_dated_checks SET status = $1, bounced_date = $2, bounce_reason = $3, updated_at = NOW()
+			WHERE check_id = $4`, status, day, reason, id)
+	default:
+		_, err = tx.ExecContext(ctx, `
+			UPDATE post_dated_checks SET status = $1, updated_at = NOW()
+			WHERE check_id = $2`, status, id)
+	}
+	if err != nil {
+		return check, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return check, err
+	}
+
+	return r.GetByID(ctx, id)
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// PrintJobRepository handles database operations for print jobs
+type PrintJobRepository struct {
+	db *sqlx.DB
+}
+
+// NewPrintJobRepository creates a new repository with the provided database connection
+func NewPrintJobRepository(db *sqlx.DB) *PrintJobRepository {
+	return &PrintJobRepository{
+		db: db,
+	}
+}
+
+// GetRecent retrieves the most recent print jobs
+func (r *PrintJobRepository) GetRecent(ctx context.Context, limit int) ([]models.PrintJob, error) {
+	jobs := []models.PrintJob{}
+	query := `SELECT * FROM print_jobs ORDER BY created_at DESC LIMIT $1`
+	err := r.db.SelectContext(ctx, &jobs, query, limit)
+	return jobs, err
+}
+
+// GetByID retrieves a print job by ID
+func (r *PrintJobRepository) GetByID(ctx context.Context, id int) (models.PrintJob, error) {
+	var job models.PrintJob
+	query := `SELECT * FROM print_jobs WHERE print_job_id = $1`
+	err := r.db.GetContext(ctx, &job, query, id)
+	if err == sql.ErrNoRows {
+		return job, notFound("print job")
+	}
+	return job, err
+}
+
+// Create inserts a new print job into the database
+func (r *PrintJobRepository) Create(ctx context.Context, job *models.PrintJob) error {
+	job.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO print_jobs (
+			printer_name, document_type, order_id, copies, status, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6
+		) RETURNING print_job_id`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		job.PrinterName,
+		job.DocumentType,
+		job.OrderID,
+		job.Copies,
+		job.Status,
+		job.CreatedAt,
+	).Scan(&job.PrintJobID)
+
+	if err != nil {
+		// 23503 is the PostgreSQL error code for foreign_key_violation
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			return notFound("order")
+		}
+	}
+
+	return err
+}
+
+// UpdateStatus records the outcome of a print job
+func (r *PrintJobRepository) UpdateStatus(ctx context.Context, id int, status string, jobErr *string) error {
+	query := `
+		UPDATE print_jobs SET
+			status = $1,
+			error = $2,
+			printed_at = CASE WHEN $1 = 'Printed' THEN NOW() ELSE printed_at END
+		WHERE print_job_id = $3`
+
+	_, err := r.db.ExecContext(ctx, query, status, jobErr, id)
+	return err
+}
+
+// FailInterrupted marks jobs that were still pending when the server stopped as failed
+func (r *PrintJobRepository) FailInterrupted(ctx context.Context) (int64, error) {
+	query := `
+		UPDATE print_jobs SET
+			status = 'Failed',
+			error = 'Interrupted by server restart'
+		WHERE status IN ('Queued', 'Printing')`
+
+	result, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// ProductHistoryRepository handles database operations for product change history
+type ProductHistoryRepository struct {
+	db *sqlx.DB
+}
+
+// NewProductHistoryRepository creates a new repository with the provided database connection
+func NewProductHistoryRepository(db *sqlx.DB) *ProductHistoryRepository {
+	return &ProductHistoryRepository{
+		db: db,
+	}
+}
+
+// GetByProductID retrieves the change history of a product, newest first
+func (r *ProductHistoryRepository) GetByProductID(ctx context.Context, productID int) ([]models.ProductHistory, error) {
+	history := []models.ProductHistory{}
+	query := `SELECT * FROM product_history WHERE product_id = $1 ORDER BY changed_at DESC, product_history_id DESC`
+	err := r.db.SelectContext(ctx, &history, query, productID)
+	return history, err
+}
+
+// Create inserts a product history entry
+func (r *ProductHistoryRepository) Create(ctx context.Context, entry *models.ProductHistory) error {
+	if len(entry.ChangedFields) == 0 {
+		entry.ChangedFields = json.RawMessage(`[]`)
+	}
+	if len(entry.SpecsDiff) == 0 {
+		entry.SpecsDiff = json.RawMessage(`[]`)
+	}
+
+	query := `
+		INSERT INTO product_history (
+			product_id, action, changed_fields, specs_diff
+		) VALUES (
+			$1, $2, $3::jsonb, $4::jsonb
+		) RETURNING product_history_id, changed_at`
+
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		entry.ProductID,
+		entry.Action,
+		entry.ChangedFields,
+		entry.SpecsDiff,
+	).Scan(&entry.ProductHistoryID, &entry.ChangedAt)
+}
@@ -5,6 +5,9 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Cezzyy/SCMS/backend/internal/models"
@@ -12,9 +15,27 @@ import (
 	"github.com/lib/pq"
 )
 
+// ProductInUseError is returned when deleting a product that still has stock on hand, or
+// purging one that appears on quotations or orders. Such products should be discontinued
+// instead.
+type ProductInUseError struct {
+	ProductID   int                  `json:"product_id"`
+	Documents   []models.DocumentRef `json:"documents"`
+	StockOnHand int                  `json:"stock_on_hand"`
+}
+
+// Error describes why the product cannot be deleted
+func (e *ProductInUseError) Error() string {
+	if len(e.Documents) > 0 {
+		return fmt.Sprintf("product %d is referenced by %d quotations/orders", e.ProductID, len(e.Documents))
+	}
+	return fmt.Sprintf("product %d has %d units in stock", e.ProductID, e.StockOnHand)
+}
+
 // ProductRepository handles database operations for products
 type ProductRepository struct {
-	db *sqlx.DB
+	db    *sqlx.DB
+	cache Cache
 }
 
 // NewProductRepository creates a new repository with the provided database connection
@@ -24,31 +45,113 @@ func NewProductRepository(db *sqlx.DB) *ProductRepository {
 	}
 }
 
-// GetAll retrieves all products from the database
-func (r *ProductRepository) GetAll(ctx context.Context) ([]models.Product, error) {
+// UseCache keeps products read by ID in cache until they change
+func (r *ProductRepository) UseCache(cache Cache) {
+	r.cache = cache
+}
+
+// GetAll retrieves a page of active products and the total number of them
+func (r *ProductRepository) GetAll(ctx context.Context, page Page) ([]models.Product, int, error) {
 	products := []models.Product{}
 
 	// We don't need the technical_specs::jsonb cast anymore since json.RawMessage handles it
 	query := `
-		SELECT * FROM products ORDER BY product_name
+		SELECT * FROM products WHERE discontinued_at IS NULL AND deleted_at IS NULL ORDER BY product_name, product_id
 	`
 
-	err := r.db.SelectContext(ctx, &products, query)
+	total, err := selectPage(ctx, r.db, &products, page, query)
 	if err != nil {
-		return nil, errors.New("failed to retrieve products: " + err.Error())
+		return nil, 0, errors.New("failed to retrieve products: " + err.Error())
+	}
+
+	return products, total, nil
+}
+
+// GetDiscontinued retrieves a page of discontinued products and the total number of them
+func (r *ProductRepository) GetDiscontinued(ctx context.Context, page Page) ([]models.Product, int, error) {
+	products := []models.Product{}
+	query := `SELECT * FROM products WHERE discontinued_at IS NOT NULL AND deleted_at IS NULL ORDER BY product_name, product_id`
+	total, err := selectPage(ctx, r.db, &products, page, query)
+	return products, total, err
+}
+
+// GetDeleted retrieves a page of soft-deleted products, most recently deleted first, and the
+// total number of them
+func (r *ProductRepository) GetDeleted(ctx context.Context, page Page) ([]models.Product, int, error) {
+	products := []models.Product{}
+	query := `SELECT * FROM products WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC, product_id`
+	total, err := selectPage(ctx, r.db, &products, page, query)
+	return products, total, err
+}
+
+// GetDeletedIDs returns which of the given products are soft-deleted
+func (r *ProductRepository) GetDeletedIDs(ctx context.Context, ids []int) ([]int, error) {
+	deleted := []int{}
+	query := `SELECT product_id FROM products WHERE product_id = ANY($1) AND deleted_at IS NOT NULL ORDER BY product_id`
+	err := r.db.SelectContext(ctx, &deleted, query, pq.Array(ids))
+	return deleted, err
+}
+
+// GetDiscontinuedIDs returns which of the given products are discontinued
+func (r *ProductRepository) GetDiscontinuedIDs(ctx context.Context, ids []int) ([]int, error) {
+	discontinued := []int{}
+	query := `SELECT product_id FROM products WHERE product_id = ANY($1) AND discontinued_at IS NOT NULL ORDER BY product_id`
+	err := r.db.SelectContext(ctx, &discontinued, query, pq.Array(ids))
+	return discontinued, err
+}
+
+// GetByIDs retrieves the given products, including soft-deleted and discontinued ones, by ID;
+// products that do not exist are left out
+func (r *ProductRepository) GetByIDs(ctx context.Context, ids []int) (map[int]models.Product, error) {
+	rows := []models.Product{}
+	query := `SELECT * FROM products WHERE product_id = ANY($1)`
+	if err := r.db.SelectContext(ctx, &rows, query, pq.Array(ids)); err != nil {
+		return nil, err
 	}
 
+	products := make(map[int]models.Product, len(rows))
+	for _, product := range rows {
+		products[product.ProductID] = product
+	}
 	return products, nil
 }
 
-// GetByID retrieves a product by ID
+// GetWeights retrieves the unit weight of each product; products without a weight are left out
+func (r *ProductRepository) GetWeights(ctx context.Context, ids []int) (map[int]float64, error) {
+	rows := []struct {
+		ProductID int     `db:"product_id"`
+		WeightKg  float64 `db:"weight_kg"`
+	}{}
+	query := `SELECT product_id, weight_kg FROM products WHERE product_id = ANY($1) AND weight_kg IS NOT NULL`
+	if err := r.db.SelectContext(ctx, &rows, query, pq.Array(ids)); err != nil {
+		return nil, err
+	}
+
+	weights := make(map[int]float64, len(rows))
+	for _, row := range rows {
+		weights[row.ProductID] = row.WeightKg
+	}
+	return weights, nil
+}
+
+// GetByID retrieves a product by ID; soft-deleted products are not found
 func (r *ProductRepository) GetByID(ctx context.Context, id int) (models.Product, error) {
-	var product models.Product
-	query := `SELECT * FROM products WHERE product_id = $1`
+	product, err := r.GetByIDWithDeleted(ctx, id)
+	if err == nil && product.DeletedAt != nil {
+		return models.Product{}, notFound("product")
+	}
+	return product, err
+}
 
-	err := r.db.GetContext(ctx, &product, query, id)
+// GetByIDWithDeleted retrieves a product by ID even when soft-deleted, for showing the
+// product on historical documents
+func (r *ProductRepository) GetByIDWithDeleted(ctx context.Context, id int) (models.Product, error) {
+	var product models.Product
+	err := cachedGet(ctx, r.cache, productCacheKey(id), &product, func() error {
+		return r.db.GetContext(ctx, &product, `SELECT * FROM products WHERE product_id = $1`, id)
+	})
 	if err == sql.ErrNoRows {
-		return product, errors.New("product not found")
+		return product, notFound("product")
 	}
 
 	if err != nil {
@@ -58,8 +161,39 @@ func (r *ProductRepository) GetByID(ctx context.Context, id int) (models.Product
 	return product, nil
 }
 
+// forget drops the cached copies of changed products
+func (r *ProductRepository) forget(ctx context.Context, ids ...int) {
+	invalidate(ctx, r.cache, productCacheKey, ids...)
+}
+
 // Create inserts a new product into the database
 func (r *ProductRepository) Create(ctx context.Context, product *models.Product) error {
+	return insertProduct(ctx, r.db, product)
+}
+
+// CreateMany inserts products in one transaction, so either all of them are created or none
+func (r *ProductRepository) CreateMany(ctx context.Context, products []models.Product) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for i := range products {
+		if err = insertProduct(ctx, tx, &products[i]); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// insertProduct inserts a product using db, which may be a transaction
+func insertProduct(ctx context.Context, db sqlx.QueryerContext, product *models.Product) error {
 	now := time.Now()
 	product.CreatedAt = now
 	product.UpdatedAt = now
@@ -74,12 +208,14 @@ func (r *ProductRepository) Create(ctx context.Context, product *models.Product)
 	query := `
 		INSERT INTO products (
 			product_name, model, description, technical_specs, certifications,
-			safety_standards, warranty_period, price, created_at, updated_at
+			safety_standards, warranty_period, price, created_at, updated_at, category,
+			restricted, weight_kg, length_cm, width_cm, height_cm, currency
 		) VALUES (
-			$1, $2, $3, $4::jsonb, $5, $6, $7, $8, $9, $10
-		) RETURNING product_id, created_at, updated_at`
+			$1, $2, $3, $4::jsonb, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16,
+			COALESCE(NULLIF($17, ''), '` + models.BaseCurrency + `')
+		) RETURNING product_id, created_at, updated_at, currency`
 
-	err := r.db.QueryRowContext(
+	err := db.QueryRowxContext(
 		ctx,
 		query,
 		product.ProductName,
@@ -92,7 +228,14 @@ func (r *ProductRepository) Create(ctx context.Context, product *models.Product)
 		product.Price,
 		product.CreatedAt,
 		product.UpdatedAt,
-	).Scan(&product.ProductID, &product.CreatedAt, &product.UpdatedAt)
+		product.Category,
+		product.Restricted,
+		product.WeightKg,
+		product.LengthCm,
+		product.WidthCm,
+		product.HeightCm,
+		product.Currency,
+	).Scan(&product.ProductID, &product.CreatedAt, &product.UpdatedAt, &product.Currency)
 
 	if err != nil {
 		// Check for PostgreSQL-specific errors
@@ -101,6 +244,10 @@ func (r *ProductRepository) Create(ctx context.Context, product *models.Product)
 			if pqErr.Code == "23505" {
 				return ErrDuplicateKey
 			}
+			// 23503 is foreign_key_violation; the currency is the only reference
+			if pqErr.Code == "23503" {
+				return notFound("currency")
+			}
 		}
 		return err
 	}
@@ -128,9 +275,16 @@ func (r *ProductRepository) Update(ctx context.Context, product *models.Product)
 			safety_standards = $6,
 			warranty_period = $7,
 			price = $8,
-			updated_at = $9
-		WHERE product_id = $10
-		RETURNING updated_at`
+			updated_at = $9,
+			category = $11,
+			restricted = $12,
+			weight_kg = $13,
+			length_cm = $14,
+			width_cm = $15,
+			height_cm = $16,
+			currency = COALESCE(NULLIF($17, ''), currency)
+		WHERE product_id = $10 AND deleted_at IS NULL
+		RETURNING updated_at, currency`
 
 	result := r.db.QueryRowContext(
 		ctx,
@@ -145,11 +299,18 @@ func (r *ProductRepository) Update(ctx context.Context, product *models.Product)
 		product.Price,
 		product.UpdatedAt,
 		product.ProductID,
+		product.Category,
+		product.Restricted,
+		product.WeightKg,
+		product.LengthCm,
+		product.WidthCm,
+		product.HeightCm,
+		product.Currency,
 	)
 
-	err := result.Scan(&product.UpdatedAt)
+	err := result.Scan(&product.UpdatedAt, &product.Currency)
 	if err == sql.ErrNoRows {
-		return errors.New("product not found")
+		return notFound("product")
 	}
 
 	if err != nil {
@@ -158,46 +319,345 @@ func (r *ProductRepository) Update(ctx context.Context, product *models.Product)
 			if pqErr.Code == "23505" {
 				return ErrDuplicateKey
 			}
+			if pqErr.Code == "23503" {
+				return notFound("currency")
+			}
 		}
 		return err
 	}
 
+	r.forget(ctx, product.ProductID)
 	return nil
 }
 
-// Delete removes a product by ID
+// Delete soft-deletes a product by ID. Quotations and orders keep their line items; a
+// *ProductInUseError is returned while the product still has stock on hand.
 func (r *ProductRepository) Delete(ctx context.Context, id int) error {
-	// Using PostgreSQL's WITH clause for the deletion and getting count in one query
-	query := `
-		WITH deleted AS (
-			DELETE FROM products 
-			WHERE product_id = $1 
-			RETURNING product_id
-		)
-		SELECT COUNT(*) FROM deleted`
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var locked int
+	err = tx.QueryRowContext(ctx, `SELECT product_id FROM products WHERE product_id = $1 AND deleted_at IS NULL FOR UPDATE`, id).Scan(&locked)
+	if err == sql.ErrNoRows {
+		err = notFound("product")
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	inUse := &ProductInUseError{ProductID: id, Documents: []models.DocumentRef{}}
+	err = tx.QueryRowContext(ctx, `SELECT COALESCE(SUM(current_stock), 0) FROM inventory WHERE product_id = $1`, id).Scan(&inUse.StockOnHand)
+	if err != nil {
+		return err
+	}
+	if inUse.StockOnHand > 0 {
+		err = inUse
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE products SET deleted_at = NOW(), updated_at = NOW() WHERE product_id = $1`, id); err != nil {
+		return err
+	}
 
-	var count int
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&count)
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	r.forget(ctx, id)
+	return nil
+}
+
+// Restore brings back a soft-deleted product
+func (r *ProductRepository) Restore(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE products SET deleted_at = NULL, updated_at = NOW() WHERE product_id = $1 AND deleted_at IS NOT NULL`, id)
 	if err != nil {
 		return err
 	}
 
-	if count == 0 {
-		return errors.New("product not found")
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
 	}
 
+	if rowsAffected == 0 {
+		return notFound("deleted product")
+	}
+
+	r.forget(ctx, id)
 	return nil
 }
 
-// SearchProducts searches for products by name or description
-func (r *ProductRepository) SearchProducts(ctx context.Context, term string) ([]models.Product, error) {
+// Purge permanently removes a soft-deleted product together with its empty inventory record.
+// A *ProductInUseError is returned when the product appears on quotations or orders or still
+// has stock, so historical documents never lose their line items; products that were not
+// deleted first return ErrNotDeleted.
+func (r *ProductRepository) Purge(ctx context.Context, id int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Lock the product so no document can reference it between the check and the delete
+	var deleted bool
+	err = tx.QueryRowContext(ctx, `SELECT deleted_at IS NOT NULL FROM products WHERE product_id = $1 FOR UPDATE`, id).Scan(&deleted)
+	if err == sql.ErrNoRows {
+		err = notFound("product")
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		err = ErrNotDeleted
+		return err
+	}
+
+	inUse := &ProductInUseError{ProductID: id, Documents: []models.DocumentRef{}}
+	err = tx.SelectContext(ctx, &inUse.Documents, `
+		SELECT 'quotation' AS type, q.quotation_id AS id, q.status, q.quote_date AS date
+		FROM quotation_items qi
+		JOIN quotations q ON q.quotation_id = qi.quotation_id
+		WHERE qi.product_id = $1
+		UNION
+		SELECT 'order', o.order_id, o.status, o.order_date
+		FROM order_items oi
+		JOIN orders o ON o.order_id = oi.order_id
+		WHERE oi.product_id = $1
+		ORDER BY date DESC`, id)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx, `SELECT COALESCE(SUM(current_stock), 0) FROM inventory WHERE product_id = $1`, id).Scan(&inUse.StockOnHand)
+	if err != nil {
+		return err
+	}
+
+	if len(inUse.Documents) > 0 || inUse.StockOnHand > 0 {
+		err = inUse
+		return err
+	}
+
+	for _, table := range []string{"inventory", "product_certifications", "product_safety_standards", "customer_product_rules"} {
+		if _, err = tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE product_id = $1`, id); err != nil {
+			return err
+		}
+	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM products WHERE product_id = $1`, id); err != nil {
+		err = translateReferenceError(err)
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	r.forget(ctx, id)
+	return nil
+}
+
+// Discontinue marks a product as no longer sold while keeping it on historical documents
+func (r *ProductRepository) Discontinue(ctx context.Context, id int) error {
+	return r.setDiscontinued(ctx, id, `discontinued_at = COALESCE(discontinued_at, NOW())`)
+}
+
+// Reinstate makes a discontinued product available for sale again
+func (r *ProductRepository) Reinstate(ctx context.Context, id int) error {
+	return r.setDiscontinued(ctx, id, `discontinued_at = NULL`)
+}
+
+// setDiscontinued applies a discontinued_at assignment to a product
+func (r *ProductRepository) setDiscontinued(ctx context.Context, id int, assignment string) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE products SET `+assignment+`, updated_at = NOW() WHERE product_id = $1 AND deleted_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound("product")
+	}
+
+	r.forget(ctx, id)
+	return nil
+}
+
+// GetUpdatedSince retrieves products changed after the given time
+func (r *ProductRepository) GetUpdatedSince(ctx context.Context, since time.Time) ([]models.Product, error) {
+	products := []models.Product{}
+	query := `SELECT * FROM products WHERE updated_at > $1 AND deleted_at IS NULL ORDER BY updated_at`
+	err := r.db.SelectContext(ctx, &products, query, since)
+	return products, err
+}
+
+// GetDependencies reports the quotations, orders and inventory that reference each product.
+// Any quotation or order reference blocks deletion, as does inventory with stock on hand;
+// an empty inventory record is removed together with the product.
+func (r *ProductRepository) GetDependencies(ctx context.Context, ids []int) (map[int][]models.Dependency, error) {
+	rows := []dependencyRow{}
+	query := `
+		SELECT DISTINCT qi.product_id AS owner_id, 'quotations' AS entity, q.quotation_id AS ref_id,
+			CASE WHEN UPPER(q.status) IN ('PENDING', 'APPROVED') THEN 'open' ELSE 'closed' END AS state
+		FROM quotation_items qi
+		JOIN quotations q ON q.quotation_id = qi.quotation_id
+		WHERE qi.product_id = ANY($1)
+		UNION ALL
+		SELECT DISTINCT oi.product_id, 'orders', o.order_id,
+			CASE WHEN o.status IN ('Pending', 'Shipped') THEN 'open' ELSE 'closed' END
+		FROM order_items oi
+		JOIN orders o ON o.order_id = oi.order_id
+		WHERE oi.product_id = ANY($1)
+		UNION ALL
+		SELECT product_id, 'inventory', inventory_id,
+			CASE WHEN current_stock > 0 THEN 'stocked' ELSE 'empty' END
+		FROM inventory
+		WHERE product_id = ANY($1)
+		ORDER BY owner_id, entity, ref_id`
+
+	if err := r.db.SelectContext(ctx, &rows, query, pq.Array(ids)); err != nil {
+		return nil, errors.New("failed to check product dependencies: " + err.Error())
+	}
+
+	return groupDependencies(rows, map[string]dependencyRule{
+		"quotations/open":   {reason: "appears on open quotations", blocking: true},
+		"quotations/closed": {reason: "appears on historical quotations", blocking: true},
+		"orders/open":       {reason: "appears on open orders", blocking: true},
+		"orders/closed":     {reason: "appears on historical orders", blocking: true},
+		"inventory/stocked": {reason: "has stock on hand", blocking: true},
+		"inventory/empty":   {reason: "empty inventory record will be removed with the product", blocking: false},
+	}), nil
+}
+
+// Spec filter operators
+const (
+	SpecFilterEquals = "eq"
+	SpecFilterMin    = "min"
+	SpecFilterMax    = "max"
+)
+
+// SpecFilter matches one technical spec value. Equality filters match any of Values;
+// min and max filters compare the first value numerically.
+type SpecFilter struct {
+	Field  string
+	Op     string
+	Values []string
+}
+
+// ProductFilter narrows a product query by search term, category, technical specs and
+// whitelisted column filters, and sets its order
+type ProductFilter struct {
+	Search       string
+	Category     string
+	Discontinued bool
+	Specs        []SpecFilter
+	List         ListQuery
+}
+
+// Filter retrieves a page of products matching the filter and the total number of matches.
+// Spec equality is translated to JSONB containment and ranges to jsonpath predicates so both
+// can use the GIN index on technical_specs.
+func (r *ProductRepository) Filter(ctx context.Context, filter ProductFilter, page Page) ([]models.Product, int, error) {
+	products := []models.Product{}
+
+	conditions := []string{"discontinued_at IS NULL", "deleted_at IS NULL"}
+	if filter.Discontinued {
+		conditions[0] = "discontinued_at IS NOT NULL"
+	}
+	args := []interface{}{}
+
+	if filter.Search != "" {
+		args = append(args, "%"+filter.Search+"%")
+		conditions = append(conditions, fmt.Sprintf("(product_name ILIKE $%d OR description ILIKE $%d)", len(args), len(args)))
+	}
+	if filter.Category != "" {
+		args = append(args, filter.Category)
+		conditions = append(conditions, fmt.Sprintf("category = $%d", len(args)))
+	}
+
+	for _, spec := range filter.Specs {
+		if len(spec.Values) == 0 {
+			continue
+		}
+		switch spec.Op {
+		case SpecFilterEquals:
+			matches := []string{}
+			for _, value := range spec.Values {
+				for _, candidate := range specCandidates(value) {
+					doc, err := json.Marshal(map[string]interface{}{spec.Field: candidate})
+					if err != nil {
+						return nil, 0, err
+					}
+					args = append(args, string(doc))
+					matches = append(matches, fmt.Sprintf("technical_specs @> $%d::jsonb", len(args)))
+				}
+			}
+			conditions = append(conditions, "("+strings.Join(matches, " OR ")+")")
+		case SpecFilterMin, SpecFilterMax:
+			number, err := strconv.ParseFloat(spec.Values[0], 64)
+			if err != nil {
+				return nil, 0, fmt.Errorf("spec filter %s must be a number", spec.Field)
+			}
+			operator := ">="
+			if spec.Op == SpecFilterMax {
+				operator = "<="
+			}
+			path := fmt.Sprintf("$.%s %s %s", strconv.Quote(spec.Field), operator, strconv.FormatFloat(number, 'g', -1, 64))
+			args = append(args, path)
+			conditions = append(conditions, fmt.Sprintf("technical_specs @@ $%d::jsonpath", len(args)))
+		default:
+			return nil, 0, fmt.Errorf("unknown spec filter operator %q", spec.Op)
+		}
+	}
+
+	conditions, args, order := filter.List.apply(ProductListColumns, conditions, args)
+	query := "SELECT * FROM products" + whereClause(conditions) + " ORDER BY " + order
+	total, err := selectPage(ctx, r.db, &products, page, query, args...)
+	if err != nil {
+		return nil, 0, errors.New("failed to filter products: " + err.Error())
+	}
+
+	return products, total, nil
+}
+
+// specCandidates returns the JSON values a query string value could have been stored as,
+// so ?spec.phase=3 matches both {"phase": 3} and {"phase": "3"}
+func specCandidates(value string) []interface{} {
+	candidates := []interface{}{value}
+	if number, err := strconv.ParseFloat(value, 64); err == nil {
+		candidates = append(candidates, number)
+	}
+	if b, err := strconv.ParseBool(value); err == nil && (value == "true" || value == "false") {
+		candidates = append(candidates, b)
+	}
+	return candidates
+}
+
+// SearchProducts searches for products by name or description, returning a page of
+// matches and the total number of matches
+func (r *ProductRepository) SearchProducts(ctx context.Context, term string, page Page) ([]models.Product, int, error) {
 	products := []models.Product{}
 	query := `
 		SELECT * FROM products 
-		WHERE product_name ILIKE $1 OR description ILIKE $1
-		ORDER BY product_name`
+		WHERE (product_name ILIKE $1 OR description ILIKE $1)
+		AND discontinued_at IS NULL AND deleted_at IS NULL
+		ORDER BY product_name, product_id`
 
 	searchTerm := "%" + term + "%"
-	err := r.db.SelectContext(ctx, &products, query, searchTerm)
-	return products, err
+	total, err := selectPage(ctx, r.db, &products, page, query, searchTerm)
+	return products, total, err
 }
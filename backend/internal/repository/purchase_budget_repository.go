@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrBudgetOverlap is returned when a budget's period overlaps another budget of the same department
+var ErrBudgetOverlap = conflict("budget period overlaps another budget for this department")
+
+// budgetUsageQuery selects budgets with what their ordered, received and pending purchase
+// orders add up to, charges included
+const budgetUsageQuery = `
+	SELECT b.*,
+		COALESCE(u.consumed, 0) AS consumed,
+		COALESCE(u.pending, 0) AS pending,
+		b.amount - COALESCE(u.consumed, 0) AS remaining
+	FROM purchase_budgets b
+	LEFT JOIN (
+		SELECT po.budget_id,
+			SUM(po.total_amount + COALESCE(c.charges, 0)) FILTER (WHERE po.status IN ('Ordered', 'Received')) AS consumed,
+			SUM(po.total_amount + COALESCE(c.charges, 0)) FILTER (WHERE po.status = 'Pending Approval') AS pending
+		FROM purchase_orders po
+		LEFT JOIN (
+			SELECT purchase_order_id, SUM(amount) AS charges FROM purchase_order_charges GROUP BY purchase_order_id
+		) c ON c.purchase_order_id = po.purchase_order_id
+		WHERE po.budget_id IS NOT NULL
+		GROUP BY po.budget_id
+	) u ON u.budget_id = b.budget_id`
+
+// BudgetFilter narrows a budget query. On keeps only budgets whose period includes that day.
+type BudgetFilter struct {
+	Department string
+	On         *time.Time
+}
+
+// PurchaseBudgetRepository handles database operations for department purchasing budgets
+type PurchaseBudgetRepository struct {
+	db *sqlx.DB
+}
+
+// NewPurchaseBudgetRepository creates a new repository with the provided database connection
+func NewPurchaseBudgetRepository(db *sqlx.DB) *PurchaseBudgetRepository {
+	return &PurchaseBudgetRepository{
+		db: db,
+	}
+}
+
+// GetAll retrieves budgets matching the filter with their consumption, latest periods first
+func (r *PurchaseBudgetRepository) GetAll(ctx context.Context, filter BudgetFilter) ([]models.BudgetUsage, error) {
+	budgets := []models.BudgetUsage{}
+
+	conditions := []string{}
+	args := []interface{}{}
+	if filter.Department != "" {
+		args = append(args, filter.Department)
+		conditions = append(conditions, fmt.Sprintf("b.department = $%d", len(args)))
+	}
+	if filter.On != nil {
+		args = append(args, *filter.On)
+		conditions = append(conditions, fmt.Sprintf("$%d::date BETWEEN b.period_start AND b.period_end", len(args)))
+	}
+
+	query := budgetUsageQuery + whereClause(conditions) + ` ORDER BY b.period_start DESC, b.department`
+	err := r.db.SelectContext(ctx, &budgets, query, args...)
+	return budgets, err
+}
+
+// GetByID retrieves a budget with its consumption
+func (r *PurchaseBudgetRepository) GetByID(ctx context.Context, id int) (models.BudgetUsage, error) {
+	var budget models.BudgetUsage
+	err := r.db.GetContext(ctx, &budget, budgetUsageQuery+` WHERE b.budget_id = $1`, id)
+	if err == sql.ErrNoRows {
+		return budget, notFound("purchase budget")
+	}
+	return budget, err
+}
+
+// Create inserts a budget, refusing one that overlaps another budget of the same department
+func (r *PurchaseBudgetRepository) Create(ctx context.Context, budget *models.PurchaseBudget) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = checkBudgetOverlap(ctx, tx, budget); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO purchase_budgets (department, period_start, period_end, amount, notes, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING budget_id, created_at, updated_at`
+
+	err = tx.QueryRowContext(ctx, query, budget.Department, budget.PeriodStart, budget.PeriodEnd, budget.Amount, budget.Notes, budget.CreatedBy).
+		Scan(&budget.BudgetID, &budget.CreatedAt, &budget.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Update changes a budget's department, period, amount and notes. Purchase orders already
+// charged to it stay charged to it.
+func (r *PurchaseBudgetRepository) Update(ctx context.Context, budget *models.PurchaseBudget) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = checkBudgetOverlap(ctx, tx, budget); err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE purchase_budgets SET
+			department = $1,
+			period_start = $2,
+			period_end = $3,
+			amount = $4,
+			notes = $5,
+			updated_at = NOW()
+		WHERE budget_id = $6
+		RETURNING created_by, created_at, updated_at`
+
+	err = tx.QueryRowContext(ctx, query, budget.Department, budget.PeriodStart, budget.PeriodEnd, budget.Amount, budget.Notes, budget.BudgetID).
+		Scan(&budget.CreatedBy, &budget.CreatedAt, &budget.UpdatedAt)
+	if err == sql.ErrNoRows {
+		err = notFound("purchase budget")
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete removes a budget. Its purchase orders are no longer charged to any budget.
+func (r *PurchaseBudgetRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM purchase_budgets WHERE budget_id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound("purchase budget")
+	}
+
+	return nil
+}
+
+// checkBudgetOverlap returns ErrBudgetOverlap when another budget of the same department
+// covers any day of the budget's period
+func checkBudgetOverlap(ctx context.Context, tx *sqlx.Tx, budget *models.PurchaseBudget) error {
+	var overlapping bool
+	err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM purchase_budgets
+			WHERE department = $1 AND budget_id <> $2 AND period_start <= $4 AND period_end >= $3
+		)`, budget.Department, budget.BudgetID, budget.PeriodStart, budget.PeriodEnd).Scan(&overlapping)
+	if err != nil {
+		return err
+	}
+	if overlapping {
+		return ErrBudgetOverlap
+	}
+	return nil
+}
@@ -0,0 +1,620 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// ErrPurchaseOrderTransition is returned for a status change the purchase order's current status doesn't allow
+var ErrPurchaseOrderTransition = errors.New("purchase order status change not allowed")
+
+// ErrPurchaseOrderClosed is returned when changing the charges of a received, cancelled or rejected purchase order
+var ErrPurchaseOrderClosed = errors.New("purchase order is already received, cancelled or rejected")
+
+// ErrPurchaseOrderNotReceivable is returned when receiving a purchase order that isn't ordered
+var ErrPurchaseOrderNotReceivable = errors.New("only ordered purchase orders can be received")
+
+// BudgetExceededError is returned when placing a purchase order would exceed the remaining
+// budget of its department and overruns are rejected rather than escalated
+type BudgetExceededError struct {
+	BudgetID  int
+	Remaining float64
+	Amount    float64
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("purchase order of %.2f exceeds the %.2f remaining in budget %d", e.Amount, e.Remaining, e.BudgetID)
+}
+
+// MissingInventoryError is returned when received goods have no inventory record to go into
+type MissingInventoryError struct {
+	ProductID int
+}
+
+func (e *MissingInventoryError) Error() string {
+	return fmt.Sprintf("product %d has no inventory record", e.ProductID)
+}
+
+// ReceiptLine is the quantity received on a purchase order line and its landed cost
+type ReceiptLine struct {
+	PurchaseOrderItemID int
+	ProductID           int
+	Quantity            int
+	AllocatedCharges    float64
+	LandedUnitCost      float64
+}
+
+// purchaseOrderTransitions lists the statuses each status can be changed to by hand. Orders
+// are placed through PlaceOrder and approvals decided through DecideApproval instead, so the
+// budget is always checked.
+var purchaseOrderTransitions = map[string][]string{
+	models.PurchaseOrderDraft:           {models.PurchaseOrderCancelled},
+	models.PurchaseOrderPendingApproval: {models.PurchaseOrderCancelled},
+	models.PurchaseOrderOrdered:         {models.PurchaseOrderCancelled},
+}
+
+// PurchaseOrderFilter narrows a purchase order query
+type PurchaseOrderFilter struct {
+	SupplierID int
+	Status     string
+	Department string
+	BudgetID   int
+}
+
+// PurchaseOrderRepository handles database operations for purchase orders and their items
+type PurchaseOrderRepository struct {
+	db    *sqlx.DB
+	cache Cache
+}
+
+// NewPurchaseOrderRepository creates a new repository with the provided database connection
+func NewPurchaseOrderRepository(db *sqlx.DB) *PurchaseOrderRepository {
+	return &PurchaseOrderRepository{
+		db: db,
+	}
+}
+
+// UseCache sets the product cache, whose copies receipts invalidate as they change cost prices
+func (r *PurchaseOrderRepository) UseCache(cache Cache) {
+	r.cache = cache
+}
+
+// GetAll retrieves purchase orders matching the filter, newest first, without their items
+func (r *PurchaseOrderRepository) GetAll(ctx context.Context, filter PurchaseOrderFilter) ([]models.PurchaseOrder, error) {
+	orders := []models.PurchaseOrder{}
+
+	conditions := []string{}
+	args := []interface{}{}
+	if filter.SupplierID != 0 {
+		args = append(args, filter.SupplierID)
+		conditions = append(conditions, fmt.Sprintf("supplier_id = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filter.Department != "" {
+		args = append(args, filter.Department)
+		conditions = append(conditions, fmt.Sprintf("department = $%d", len(args)))
+	}
+	if filter.BudgetID != 0 {
+		args = append(args, filter.BudgetID)
+		conditions = append(conditions, fmt.Sprintf("budget_id = $%d", len(args)))
+	}
+
+	query := `SELECT * FROM purchase_orders`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY order_date DESC, purchase_order_id DESC"
+
+	err := r.db.SelectContext(ctx, &orders, query, args...)
+	return orders, err
+}
+
+// GetByID retrieves a purchase order with its items
+func (r *PurchaseOrderRepository) GetByID(ctx context.Context, id int) (models.PurchaseOrder, error) {
+	var order models.PurchaseOrder
+	query := `SELECT * FROM purchase_orders WHERE purchase_order_id = $1`
+	err := r.db.GetContext(ctx, &order, query, id)
+	if err == sql.ErrNoRows {
+		return order, notFound("purchase order")
+	}
+	if err != nil {
+		return order, err
+	}
+
+	order.Items = []models.PurchaseOrderItem{}
+	itemQuery := `SELECT * FROM purchase_order_items WHERE purchase_order_id = $1 ORDER BY purchase_order_item_id`
+	if err = r.db.SelectContext(ctx, &order.Items, itemQuery, id); err != nil {
+		return order, err
+	}
+
+	order.Charges = []models.PurchaseOrderCharge{}
+	chargeQuery := `SELECT * FROM purchase_order_charges WHERE purchase_order_id = $1 ORDER BY charge_id`
+	err = r.db.SelectContext(ctx, &order.Charges, chargeQuery, id)
+	return order, err
+}
+
+// Create inserts a purchase order with its items in a single transaction. The total is
+// calculated from the item line totals.
+func (r *PurchaseOrderRepository) Create(ctx context.Context, order *models.PurchaseOrder) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	query := `
+		INSERT INTO purchase_orders (supplier_id, status, expected_date, notes, department, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING purchase_order_id, order_date, created_at, updated_at`
+
+	err = tx.QueryRowContext(ctx, query, order.SupplierID, order.Status, order.ExpectedDate, order.Notes, order.Department, order.CreatedBy).
+		Scan(&order.PurchaseOrderID, &order.OrderDate, &order.CreatedAt, &order.UpdatedAt)
+	if err != nil {
+		err = translateReferenceError(err)
+		return err
+	}
+
+	itemQuery := `
+		INSERT INTO purchase_order_items (purchase_order_id, product_id, quantity, unit_cost, lead_time_days)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING purchase_order_item_id, line_total`
+
+	for i := range order.Items {
+		item := &order.Items[i]
+		item.PurchaseOrderID = order.PurchaseOrderID
+		err = tx.QueryRowContext(ctx, itemQuery, item.PurchaseOrderID, item.ProductID, item.Quantity, item.UnitCost, item.LeadTimeDays).
+			Scan(&item.PurchaseOrderItemID, &item.LineTotal)
+		if err != nil {
+			err = translateReferenceError(err)
+			return err
+		}
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE purchase_orders SET total_amount = (
+			SELECT COALESCE(SUM(line_total), 0) FROM purchase_order_items WHERE purchase_order_id = $1
+		)
+		WHERE purchase_order_id = $1
+		RETURNING total_amount`, order.PurchaseOrderID).Scan(&order.TotalAmount)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateStatus changes the status of a purchase order. Drafts, orders pending approval and
+// ordered purchase orders can be cancelled; anything else returns ErrPurchaseOrderTransition.
+func (r *PurchaseOrderRepository) UpdateStatus(ctx context.Context, id int, status string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var current string
+	err = tx.QueryRowContext(ctx, `SELECT status FROM purchase_orders WHERE purchase_order_id = $1 FOR UPDATE`, id).Scan(&current)
+	if err == sql.ErrNoRows {
+		return notFound("purchase order")
+	}
+	if err != nil {
+		return err
+	}
+
+	allowed := false
+	for _, next := range purchaseOrderTransitions[current] {
+		if next == status {
+			allowed = true
+		}
+	}
+	if !allowed {
+		err = ErrPurchaseOrderTransition
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE purchase_orders SET status = $1, updated_at = NOW() WHERE purchase_order_id = $2`, status, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// PlaceOrder places a draft purchase order, charging it to the budget of its department that
+// covers today. An order that fits what remains of the budget, or has no department or budget,
+// becomes Ordered. One that doesn't is escalated to Pending Approval when escalate is set and
+// otherwise refused with a BudgetExceededError. The budget row is locked so concurrent orders
+// can't both spend the same remainder. It returns the purchase order's new status.
+func (r *PurchaseOrderRepository) PlaceOrder(ctx context.Context, id int, escalate bool) (string, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var order models.PurchaseOrder
+	err = tx.GetContext(ctx, &order, `SELECT * FROM purchase_orders WHERE purchase_order_id = $1 FOR UPDATE`, id)
+	if err == sql.ErrNoRows {
+		err = notFound("purchase order")
+		return "", err
+	}
+	if err != nil {
+		return "", err
+	}
+	if order.Status != models.PurchaseOrderDraft {
+		err = ErrPurchaseOrderTransition
+		return "", err
+	}
+
+	status := models.PurchaseOrderOrdered
+	var budgetID *int
+	if order.Department != nil {
+		var budget models.PurchaseBudget
+		err = tx.GetContext(ctx, &budget, `
+			SELECT * FROM purchase_budgets
+			WHERE department = $1 AND CURRENT_DATE BETWEEN period_start AND period_end
+			FOR UPDATE`, *order.Department)
+		if err != nil && err != sql.ErrNoRows {
+			return "", err
+		}
+		if err == nil {
+			budgetID = &budget.BudgetID
+
+			var consumed, amount float64
+			err = tx.QueryRowContext(ctx, `
+				SELECT COALESCE(SUM(po.total_amount + COALESCE((
+					SELECT SUM(amount) FROM purchase_order_charges WHERE purchase_order_id = po.purchase_order_id
+				), 0)), 0)
+				FROM purchase_orders po
+				WHERE po.budget_id = $1 AND po.status IN ('Ordered', 'Received') AND po.purchase_order_id <> $2`,
+				budget.BudgetID, id).Scan(&consumed)
+			if err != nil {
+				return "", err
+			}
+			err = tx.QueryRowContext(ctx, `
+				SELECT $2::numeric + COALESCE(SUM(amount), 0) FROM purchase_order_charges WHERE purchase_order_id = $1`,
+				id, order.TotalAmount).Scan(&amount)
+			if err != nil {
+				return "", err
+			}
+
+			if remaining := budget.Amount - consumed; amount > remaining+0.005 {
+				if !escalate {
+					err = &BudgetExceededError{BudgetID: budget.BudgetID, Remaining: math.Round(remaining*100) / 100, Amount: amount}
+					return "", err
+				}
+				status = models.PurchaseOrderPendingApproval
+			}
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE purchase_orders SET status = $1, budget_id = $2, updated_at = NOW()
+		WHERE purchase_order_id = $3`, status, budgetID, id)
+	if err != nil {
+		return "", err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return "", err
+	}
+	return status, nil
+}
+
+// DecideApproval approves or rejects a purchase order pending approval, recording who decided
+// and why. Approved orders become Ordered and consume their budget; rejected ones are closed.
+func (r *PurchaseOrderRepository) DecideApproval(ctx context.Context, id int, approve bool, decidedBy *int, note string) error {
+	status := models.PurchaseOrderRejected
+	if approve {
+		status = models.PurchaseOrderOrdered
+	}
+
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE purchase_orders SET
+			status = $1,
+			approved_by = $2,
+			approved_at = NOW(),
+			approval_note = $3,
+			updated_at = NOW()
+		WHERE purchase_order_id = $4 AND status = 'Pending Approval'`, status, decidedBy, note, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected > 0 {
+		return nil
+	}
+
+	var exists bool
+	if err := r.db.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM purchase_orders WHERE purchase_order_id = $1)`, id); err != nil {
+		return err
+	}
+	if !exists {
+		return notFound("purchase order")
+	}
+	return ErrPurchaseOrderTransition
+}
+
+// GetOpenQuantities returns the quantities of each product on purchase orders that are
+// ordered but not yet received
+func (r *PurchaseOrderRepository) GetOpenQuantities(ctx context.Context, productIDs []int) (map[int]int, error) {
+	rows := []struct {
+		ProductID int `db:"product_id"`
+		Quantity  int `db:"quantity"`
+	}{}
+	query := `
+		SELECT poi.product_id, SUM(poi.quantity) AS quantity
+		FROM purchase_order_items poi
+		JOIN purchase_orders po ON po.purchase_order_id = poi.purchase_order_id
+		WHERE po.status IN ('Draft', 'Pending Approval', 'Ordered') AND poi.product_id = ANY($1)
+		GROUP BY poi.product_id`
+	if err := r.db.SelectContext(ctx, &rows, query, pq.Array(productIDs)); err != nil {
+		return nil, err
+	}
+
+	quantities := map[int]int{}
+	for _, row := range rows {
+		quantities[row.ProductID] = row.Quantity
+	}
+	return quantities, nil
+}
+
+// AddCharge adds a freight, duty or brokerage charge to a purchase order that hasn't been
+// received, cancelled or rejected
+func (r *PurchaseOrderRepository) AddCharge(ctx context.Context, charge *models.PurchaseOrderCharge) error {
+	query := `
+		INSERT INTO purchase_order_charges (purchase_order_id, charge_type, description, amount, allocation)
+		SELECT purchase_order_id, $2, $3, $4, $5
+		FROM purchase_orders
+		WHERE purchase_order_id = $1 AND status IN ('Draft', 'Pending Approval', 'Ordered')
+		RETURNING charge_id, created_at`
+
+	err := r.db.QueryRowContext(ctx, query, charge.PurchaseOrderID, charge.ChargeType, charge.Description, charge.Amount, charge.Allocation).
+		Scan(&charge.ChargeID, &charge.CreatedAt)
+	if err == sql.ErrNoRows {
+		return r.closedOrMissing(ctx, charge.PurchaseOrderID)
+	}
+	return err
+}
+
+// DeleteCharge removes a charge from a purchase order that hasn't been received, cancelled or rejected
+func (r *PurchaseOrderRepository) DeleteCharge(ctx context.Context, purchaseOrderID, chargeID int) error {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM purchase_order_charges c
+		USING purchase_orders po
+		WHERE c.charge_id = $1 AND c.purchase_order_id = $2
+			AND po.purchase_order_id = c.purchase_order_id AND po.status IN ('Draft', 'Pending Approval', 'Ordered')`, chargeID, purchaseOrderID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		if err := r.closedOrMissing(ctx, purchaseOrderID); err != nil {
+			return err
+		}
+		return notFound("purchase order charge")
+	}
+
+	return nil
+}
+
+// closedOrMissing explains why a purchase order's charges couldn't be changed. It returns
+// nil when the purchase order exists and is still open.
+func (r *PurchaseOrderRepository) closedOrMissing(ctx context.Context, id int) error {
+	var status string
+	err := r.db.GetContext(ctx, &status, `SELECT status FROM purchase_orders WHERE purchase_order_id = $1`, id)
+	if err == sql.ErrNoRows {
+		return notFound("purchase order")
+	}
+	if err != nil {
+		return err
+	}
+	if status != models.PurchaseOrderDraft && status != models.PurchaseOrderPendingApproval && status != models.PurchaseOrderOrdered {
+		return ErrPurchaseOrderClosed
+	}
+	return nil
+}
+
+// Receive records the goods received on an ordered purchase order in a single transaction.
+// Each line's received quantity and landed cost are saved, the quantity is added to stock
+// and logged as a stock receipt, a cost layer is recorded and the product's cost price is
+// updated under the given cost policy:
+//
+//   - moving_average blends the stock on hand at its current cost with the received units
+//     at their landed cost
+//   - fifo values the stock on hand from the newest cost layers, since the oldest units are
+//     sold first; stock not covered by any layer keeps the previous cost price
+func (r *PurchaseOrderRepository) Receive(ctx context.Context, id int, lines []ReceiptLine, policy string, receivedBy *int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var status string
+	err = tx.QueryRowContext(ctx, `SELECT status FROM purchase_orders WHERE purchase_order_id = $1 FOR UPDATE`, id).Scan(&status)
+	if err == sql.ErrNoRows {
+		return notFound("purchase order")
+	}
+	if err != nil {
+		return err
+	}
+	if status != models.PurchaseOrderOrdered {
+		err = ErrPurchaseOrderNotReceivable
+		return err
+	}
+
+	reference := fmt.Sprintf("PO-%d", id)
+	for _, line := range lines {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE purchase_order_items SET
+				received_quantity = $1,
+				allocated_charges = $2,
+				landed_unit_cost = $3
+			WHERE purchase_order_item_id = $4 AND purchase_order_id = $5`,
+			line.Quantity, line.AllocatedCharges, line.LandedUnitCost, line.PurchaseOrderItemID, id)
+		if err != nil {
+			return err
+		}
+
+		if line.Quantity == 0 {
+			continue
+		}
+
+		// Lock the inventory row and product so concurrent receipts value stock in order
+		var inventoryID, stock int
+		err = tx.QueryRowContext(ctx, `SELECT inventory_id, current_stock FROM inventory WHERE product_id = $1 FOR UPDATE`,
+			line.ProductID).Scan(&inventoryID, &stock)
+		if err == sql.ErrNoRows {
+			err = &MissingInventoryError{ProductID: line.ProductID}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		var costPrice *float64
+		err = tx.QueryRowContext(ctx, `SELECT cost_price FROM products WHERE product_id = $1 FOR UPDATE`, line.ProductID).Scan(&costPrice)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO cost_layers (product_id, purchase_order_item_id, quantity, unit_cost)
+			VALUES ($1, $2, $3, $4)`, line.ProductID, line.PurchaseOrderItemID, line.Quantity, line.LandedUnitCost)
+		if err != nil {
+			return err
+		}
+
+		var newCost float64
+		newCost, err = r.costAfterReceipt(ctx, tx, line, stock, costPrice, policy)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE products SET cost_price = $1 WHERE product_id = $2`, newCost, line.ProductID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO stock_receipts (inventory_id, product_id, quantity, reference, passed, received_by)
+			VALUES ($1, $2, $3, $4, TRUE, $5)`, inventoryID, line.ProductID, line.Quantity, reference, receivedBy)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE inventory SET
+				current_stock = current_stock + $1,
+				last_restock_date = NOW(),
+				updated_at = NOW()
+			WHERE inventory_id = $2`, line.Quantity, inventoryID)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE purchase_orders SET
+			status = $1,
+			received_at = NOW(),
+			received_by = $2,
+			updated_at = NOW()
+		WHERE purchase_order_id = $3`, models.PurchaseOrderReceived, receivedBy, id)
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	productIDs := make([]int, 0, len(lines))
+	for _, line := range lines {
+		if line.Quantity > 0 {
+			productIDs = append(productIDs, line.ProductID)
+		}
+	}
+	invalidate(ctx, r.cache, productCacheKey, productIDs...)
+	return nil
+}
+
+// costAfterReceipt works out a product's cost price once a receipt line is added to the
+// stock on hand. The line's cost layer must already be recorded.
+func (r *PurchaseOrderRepository) costAfterReceipt(ctx context.Context, tx *sqlx.Tx, line ReceiptLine, stock int, costPrice *float64, policy string) (float64, error) {
+	if stock < 0 {
+		stock = 0
+	}
+	previous := line.LandedUnitCost
+	if costPrice != nil {
+		previous = *costPrice
+	}
+
+	if policy != models.CostPolicyFIFO {
+		total := float64(stock)*previous + float64(line.Quantity)*line.LandedUnitCost
+		return math.Round(total/float64(stock+line.Quantity)*10000) / 10000, nil
+	}
+
+	layers := []struct {
+		Quantity int     `db:"quantity"`
+		UnitCost float64 `db:"unit_cost"`
+	}{}
+	query := `SELECT quantity, unit_cost FROM cost_layers WHERE product_id = $1 ORDER BY received_at DESC, layer_id DESC`
+	if err := tx.SelectContext(ctx, &layers, query, line.ProductID); err != nil {
+		return 0, err
+	}
+
+	onHand := stock + line.Quantity
+	remaining := onHand
+	value := 0.0
+	for _, layer := range layers {
+		if remaining == 0 {
+			break
+		}
+		quantity := layer.Quantity
+		if quantity > remaining {
+			quantity = remaining
+		}
+		value += float64(quantity) * layer.UnitCost
+		remaining -= quantity
+	}
+	value += float64(remaining) * previous
+
+	return math.Round(value/float64(onHand)*10000) / 10000, nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// QuantityBreakRepository handles database operations for products' quantity breaks
+type QuantityBreakRepository struct {
+	db *sqlx.DB
+}
+
+// NewQuantityBreakRepository creates a new repository with the provided database connection
+func NewQuantityBreakRepository(db *sqlx.DB) *QuantityBreakRepository {
+	return &QuantityBreakRepository{
+		db: db,
+	}
+}
+
+// GetByProduct retrieves a product's quantity breaks, smallest minimum quantity first
+func (r *QuantityBreakRepository) GetByProduct(ctx context.Context, productID int) ([]models.QuantityBreak, error) {
+	breaks := []models.QuantityBreak{}
+	query := `SELECT * FROM quantity_breaks WHERE product_id = $1 ORDER BY min_quantity`
+	err := r.db.SelectContext(ctx, &breaks, query, productID)
+	return breaks, err
+}
+
+// GetByProducts retrieves the quantity breaks of each product, smallest minimum quantity
+// first; products without breaks are left out
+func (r *QuantityBreakRepository) GetByProducts(ctx context.Context, ids []int) (map[int][]models.QuantityBreak, error) {
+	rows := []models.QuantityBreak{}
+	query := `SELECT * FROM quantity_breaks WHERE product_id = ANY($1) ORDER BY product_id, min_quantity`
+	if err := r.db.SelectContext(ctx, &rows, query, pq.Array(ids)); err != nil {
+		return nil, err
+	}
+
+	breaks := map[int][]models.QuantityBreak{}
+	for _, row := range rows {
+		breaks[row.ProductID] = append(breaks[row.ProductID], row)
+	}
+	return breaks, nil
+}
+
+// ReplaceForProduct replaces a product's quantity breaks with breaks. Two breaks with the
+// same minimum quantity are refused with ErrDuplicateKey.
+func (r *QuantityBreakRepository) ReplaceForProduct(ctx context.Context, productID int, breaks []models.QuantityBreak) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM quantity_breaks WHERE product_id = $1`, productID); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO quantity_breaks (product_id, min_quantity, discount_percent)
+		VALUES ($1, $2, $3)
+		RETURNING quantity_break_id, created_at`
+	for i := range breaks {
+		breaks[i].ProductID = productID
+		err := tx.QueryRowContext(ctx, query, productID, breaks[i].MinQuantity, breaks[i].DiscountPercent).
+			Scan(&breaks[i].QuantityBreakID, &breaks[i].CreatedAt)
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+				return ErrDuplicateKey
+			}
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// QuotationBuilderRepository handles database operations for quotation builder sessions.
+// Expired sessions are treated as not found even before they are deleted.
+type QuotationBuilderRepository struct {
+	db *sqlx.DB
+}
+
+// NewQuotationBuilderRepository creates a new repository with the provided database connection
+func NewQuotationBuilderRepository(db *sqlx.DB) *QuotationBuilderRepository {
+	return &QuotationBuilderRepository{
+		db: db,
+	}
+}
+
+// Create starts a builder session
+func (r *QuotationBuilderRepository) Create(ctx context.Context, session *models.QuotationBuilderSession) error {
+	query := `
+		INSERT INTO quotation_builder_sessions (
+			customer_id, currency, source, validity_date, created_by, expires_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6
+		) RETURNING builder_session_id, created_at, updated_at`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		session.CustomerID,
+		session.Currency,
+		session.Source,
+		session.ValidityDate,
+		session.CreatedBy,
+		session.ExpiresAt,
+	).Scan(&session.BuilderSessionID, &session.CreatedAt, &session.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	session.Lines = []models.QuotationBuilderLine{}
+	return nil
+}
+
+// GetByID retrieves a builder session with its lines in the order they were added
+func (r *QuotationBuilderRepository) GetByID(ctx context.Context, id int) (models.QuotationBuilderSession, error) {
+	var session models.QuotationBuilderSession
+	err := r.db.GetContext(ctx, &session, `SELECT * FROM quotation_builder_sessions WHERE builder_session_id = $1 AND expires_at > NOW()`, id)
+	if err == sql.ErrNoRows {
+		return session, notFound("quotation builder session")
+	}
+	if err != nil {
+		return session, err
+	}
+
+	session.Lines = []models.QuotationBuilderLine{}
+	err = r.db.SelectContext(ctx, &session.Lines, `SELECT * FROM quotation_builder_lines WHERE builder_session_id = $1 ORDER BY builder_line_id`, id)
+	return session, err
+}
+
+// AddLine adds a line to a session and keeps the session until expiresAt
+func (r *QuotationBuilderRepository) AddLine(ctx context.Context, line *models.QuotationBuilderLine, expiresAt time.Time) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := touchBuilderSession(ctx, tx, line.BuilderSessionID, expiresAt); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO quotation_builder_lines (
+			builder_session_id, product_id, quantity, unit_price, discount, discount_type, discount_value
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7
+		) RETURNING builder_line_id, created_at`
+
+	err = tx.QueryRowContext(
+		ctx,
+		query,
+		line.BuilderSessionID,
+		line.ProductID,
+		line.Quantity,
+		line.UnitPrice,
+		line.Discount,
+		line.DiscountType,
+		line.DiscountValue,
+	).Scan(&line.BuilderLineID, &line.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RemoveLine removes a line from a session and keeps the session until expiresAt
+func (r *QuotationBuilderRepository) RemoveLine(ctx context.Context, sessionID, lineID int, expiresAt time.Time) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := touchBuilderSession(ctx, tx, sessionID, expiresAt); err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM quotation_builder_lines WHERE builder_line_id = $1 AND builder_session_id = $2`, lineID, sessionID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return notFound("quotation builder line")
+	}
+	return tx.Commit()
+}
+
+// touchBuilderSession records a change to a session that has not expired and moves its
+// expiry to expiresAt
+func touchBuilderSession(ctx context.Context, tx *sqlx.Tx, id int, expiresAt time.Time) error {
+	result, err := tx.ExecContext(ctx, `
+		UPDATE quotation_builder_sessions SET updated_at = NOW(), expires_at = $2
+		WHERE builder_session_id = $1 AND expires_at > NOW()`, id, expiresAt)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return notFound("quotation builder session")
+	}
+	return nil
+}
+
+// Delete removes a session and its lines
+func (r *QuotationBuilderRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM quotation_builder_sessions WHERE builder_session_id = $1 AND expires_at > NOW()`, id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return notFound("quotation builder session")
+	}
+	return nil
+}
+
+// DeleteExpired removes abandoned sessions and returns how many there were
+func (r *QuotationBuilderRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM quotation_builder_sessions WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -3,7 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
-	"errors"
+	"strings"
 	"time"
 
 	"github.com/Cezzyy/SCMS/backend/internal/models"
@@ -23,12 +23,36 @@ func NewQuotationRepository(db *sqlx.DB) *QuotationRepository {
 	}
 }
 
-// GetAll retrieves all quotations from the database
-func (r *QuotationRepository) GetAll(ctx context.Context) ([]models.Quotation, error) {
+// GetAll retrieves a page of quotations matching the list query, newest first unless it
+// sorts otherwise, and the total number of matches
+func (r *QuotationRepository) GetAll(ctx context.Context, list ListQuery, page Page) ([]models.Quotation, int, error) {
 	quotations := []models.Quotation{}
-	query := `SELECT * FROM quotations ORDER BY quote_date DESC`
-	err := r.db.SelectContext(ctx, &quotations, query)
-	return quotations, err
+	conditions, args, order := list.apply(QuotationListColumns, nil, nil)
+	query := `SELECT * FROM quotations` + whereClause(conditions) + ` ORDER BY ` + order
+	total, err := selectPage(ctx, r.db, &quotations, page, query, args...)
+	return quotations, total, err
+}
+
+// QuotationSearchResult is a quotation matched by Search together with its customer's name
+type QuotationSearchResult struct {
+	models.Quotation
+	CompanyName string `db:"company_name" json:"company_name"`
+}
+
+// Search finds quotations by number (with or without a leading #), customer name or status,
+// returning a page of matches, newest first, and the total number of matches
+func (r *QuotationRepository) Search(ctx context.Context, term string, page Page) ([]QuotationSearchResult, int, error) {
+	quotations := []QuotationSearchResult{}
+	query := `
+		SELECT q.*, c.company_name
+		FROM quotations q
+		JOIN customers c ON c.customer_id = q.customer_id
+		WHERE CAST(q.quotation_id AS TEXT) = $2
+			OR c.company_name ILIKE $1
+			OR q.status ILIKE $1
+		ORDER BY q.quote_date DESC, q.quotation_id DESC`
+	total, err := selectPage(ctx, r.db, &quotations, page, query, "%"+term+"%", strings.TrimPrefix(term, "#"))
+	return quotations, total, err
 }
 
 // GetByID retrieves a quotation by ID
@@ -37,19 +61,11 @@ func (r *QuotationRepository) GetByID(ctx context.Context, id int) (models.Quota
 	query := `SELECT * FROM quotations WHERE quotation_id = $1`
 	err := r.db.GetContext(ctx, &quotation, query, id)
 	if err == sql.ErrNoRows {
-		return quotation, errors.New("quotation not found")
+		return quotation, notFound("quotation")
 	}
 	return quotation, err
 }
 
-// GetByCustomerID retrieves all quotations for a specific customer
-func (r *QuotationRepository) GetByCustomerID(ctx context.Context, customerID int) ([]models.Quotation, error) {
-	quotations := []models.Quotation{}
-	query := `SELECT * FROM quotations WHERE customer_id = $1 ORDER BY quote_date DESC`
-	err := r.db.SelectContext(ctx, &quotations, query, customerID)
-	return quotations, err
-}
-
 // Create inserts a new quotation into the database
 func (r *QuotationRepository) Create(ctx context.Context, quotation *models.Quotation) error {
 	tx, err := r.db.BeginTxx(ctx, nil)
@@ -65,14 +81,28 @@ func (r *QuotationRepository) Create(ctx context.Context, quotation *models.Quot
 	now := time.Now()
 	quotation.CreatedAt = now
 	quotation.UpdatedAt = now
+	if quotation.RequestReceivedAt.IsZero() {
+		quotation.RequestReceivedAt = now
+	}
+
+	documentNo, err := nextDocumentNo(ctx, tx, models.DocumentSeriesQuotation)
+	if err != nil {
+		return err
+	}
+	quotation.DocumentNo = &documentNo
 
 	query := `
 		INSERT INTO quotations (
 			customer_id, quote_date, validity_date, status, 
-			total_amount, created_at, updated_at
+			total_amount, created_at, updated_at, source, document_no,
+			vat_classification, vat_rate, net_amount, vat_amount, currency, exchange_rate,
+			request_received_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7
-		) RETURNING quotation_id, created_at, updated_at`
+			$1, $2, $3, $4, $5, $6, $7, $8, $9,
+			COALESCE(NULLIF($10, ''), (SELECT vat_classification FROM customers WHERE customer_id = $1)), $11, $12, $13,
+			COALESCE(NULLIF($14, ''), '` + models.BaseCurrency + `'), COALESCE(NULLIF($15::NUMERIC, 0), 1),
+			$16
+		) RETURNING quotation_id, created_at, updated_at, vat_classification, currency, exchange_rate`
 
 	err = tx.QueryRowContext(
 		ctx,
@@ -84,7 +114,16 @@ func (r *QuotationRepository) Create(ctx context.Context, quotation *models.Quot
 		quotation.TotalAmount,
 		quotation.CreatedAt,
 		quotation.UpdatedAt,
-	).Scan(&quotation.QuotationID, &quotation.CreatedAt, &quotation.UpdatedAt)
+		quotation.Source,
+		quotation.DocumentNo,
+		quotation.VATClassification,
+		quotation.VATRate,
+		quotation.NetAmount,
+		quotation.VATAmount,
+		quotation.Currency,
+		quotation.ExchangeRate,
+		quotation.RequestReceivedAt,
+	).Scan(&quotation.QuotationID, &quotation.CreatedAt, &quotation.UpdatedAt, &quotation.VATClassification, &quotation.Currency, &quotation.ExchangeRate)
 
 	if err != nil {
 		// Check for PostgreSQL-specific errors
@@ -100,7 +139,8 @@ func (r *QuotationRepository) Create(ctx context.Context, quotation *models.Quot
 	return tx.Commit()
 }
 
-// Update updates an existing quotation
+// Update updates an existing quotation. ErrStaleVersion is returned if the quotation has been
+// changed since quotation.Version.
 func (r *QuotationRepository) Update(ctx context.Context, quotation *models.Quotation) error {
 	quotation.UpdatedAt = time.Now()
 
@@ -111,9 +151,15 @@ func (r *QuotationRepository) Update(ctx context.Context, quotation *models.Quot
 			validity_date = $3,
 			status = $4,
 			total_amount = $5,
-			updated_at = $6
-		WHERE quotation_id = $7
-		RETURNING updated_at`
+			source = $6,
+			updated_at = $7,
+			version = version + 1,
+			vat_classification = COALESCE(NULLIF($10, ''), vat_classification),
+			vat_rate = $11,
+			net_amount = $12,
+			vat_amount = $13
+		WHERE quotation_id = $8 AND version = $9
+		RETURNING updated_at, version`
 
 	result := r.db.QueryRowContext(
 		ctx,
@@ -123,19 +169,34 @@ func (r *QuotationRepository) Update(ctx context.Context, quotation *models.Quot
 		quotation.ValidityDate,
 		quotation.Status,
 		quotation.TotalAmount,
+		quotation.Source,
 		quotation.UpdatedAt,
 		quotation.QuotationID,
+		quotation.Version,
+		quotation.VATClassification,
+		quotation.VATRate,
+		quotation.NetAmount,
+		quotation.VATAmount,
 	)
 
-	err := result.Scan(&quotation.UpdatedAt)
+	err := result.Scan(&quotation.UpdatedAt, &quotation.Version)
 	if err == sql.ErrNoRows {
-		return errors.New("quotation not found")
+		return r.staleOrNotFound(ctx, quotation.QuotationID)
 	}
 	return err
 }
 
-// Delete removes a quotation by ID
-func (r *QuotationRepository) Delete(ctx context.Context, id int) error {
+// staleOrNotFound explains why a versioned update matched no quotation
+func (r *QuotationRepository) staleOrNotFound(ctx context.Context, id int) error {
+	if _, err := r.GetByID(ctx, id); err != nil {
+		return err
+	}
+	return ErrStaleVersion
+}
+
+// Delete removes a quotation by ID, recording its quotation number as voided with the given
+// reason
+func (r *QuotationRepository) Delete(ctx context.Context, id int, reason string, deletedBy *int) error {
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
@@ -146,6 +207,23 @@ func (r *QuotationRepository) Delete(ctx context.Context, id int) error {
 		}
 	}()
 
+	// Revisions share their original's number, which is voided only with the original
+	var revision int
+	err = tx.GetContext(ctx, &revision, `SELECT revision FROM quotations WHERE quotation_id = $1`, id)
+	if err == sql.ErrNoRows {
+		err = notFound("quotation")
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if revision == 1 {
+		err = voidDocument(ctx, tx, models.DocumentSeriesQuotation, id, models.VoidDeleted, reason, deletedBy)
+		if err != nil {
+			return err
+		}
+	}
+
 	// First delete all quotation items associated with this quotation
 	_, err = tx.ExecContext(ctx, `DELETE FROM quotation_items WHERE quotation_id = $1`, id)
 	if err != nil {
@@ -164,7 +242,7 @@ func (r *QuotationRepository) Delete(ctx context.Context, id int) error {
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("quotation not found")
+		return notFound("quotation")
 	}
 
 	return tx.Commit()
@@ -182,10 +260,10 @@ func (r *QuotationRepository) GetQuotationItems(ctx context.Context, quotationID
 func (r *QuotationRepository) CreateQuotationItem(ctx context.Context, item *models.QuotationItem) error {
 	query := `
 		INSERT INTO quotation_items (
-			quotation_id, product_id, quantity, unit_price, discount
+			quotation_id, product_id, quantity, unit_price, discount, discount_type, discount_value
 		) VALUES (
-			$1, $2, $3, $4, $5
-		) RETURNING quotation_item_id`
+			$1, $2, $3, $4, $5, COALESCE(NULLIF($6, ''), 'none'), $7
+		) RETURNING quotation_item_id, discount_type`
 
 	err := r.db.QueryRowContext(
 		ctx,
@@ -195,7 +273,9 @@ func (r *QuotationRepository) CreateQuotationItem(ctx context.Context, item *mod
 		item.Quantity,
 		item.UnitPrice,
 		item.Discount,
-	).Scan(&item.QuotationItemID)
+		item.DiscountType,
+		item.DiscountValue,
+	).Scan(&item.QuotationItemID, &item.DiscountType)
 
 	return err
 }
@@ -208,8 +288,10 @@ func (r *QuotationRepository) UpdateQuotationItem(ctx context.Context, item *mod
 			product_id = $2,
 			quantity = $3,
 			unit_price = $4,
-			discount = $5
-		WHERE quotation_item_id = $6`
+			discount = $5,
+			discount_type = COALESCE(NULLIF($6, ''), 'none'),
+			discount_value = $7
+		WHERE quotation_item_id = $8`
 
 	result, err := r.db.ExecContext(
 		ctx,
@@ -219,6 +301,8 @@ func (r *QuotationRepository) UpdateQuotationItem(ctx context.Context, item *mod
 		item.Quantity,
 		item.UnitPrice,
 		item.Discount,
+		item.DiscountType,
+		item.DiscountValue,
 		item.QuotationItemID,
 	)
 	if err != nil {
@@ -231,7 +315,7 @@ func (r *QuotationRepository) UpdateQuotationItem(ctx context.Context, item *mod
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("quotation item not found")
+		return notFound("quotation item")
 	}
 
 	return nil
@@ -250,12 +334,36 @@ func (r *QuotationRepository) DeleteQuotationItem(ctx context.Context, id int) e
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("quotation item not found")
+		return notFound("quotation item")
 	}
 
 	return nil
 }
 
+// SetPromises saves the promise dates worked out again for a quotation's items
+func (r *QuotationRepository) SetPromises(ctx context.Context, items []models.QuotationItem) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	query := `
+		UPDATE quotation_items SET promise_date = $2, promise_source = $3, promised_at = $4
+		WHERE quotation_item_id = $1`
+	for _, item := range items {
+		if _, err = tx.ExecContext(ctx, query, item.QuotationItemID, item.PromiseDate, item.PromiseSource, item.PromisedAt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
 // GetFullQuotation retrieves a quotation along with all its items
 func (r *QuotationRepository) GetFullQuotation(ctx context.Context, id int) (models.Quotation, []models.QuotationItem, error) {
 	// Get the quotation
@@ -285,20 +393,97 @@ func (r *QuotationRepository) CreateQuotationWithItems(ctx context.Context, quot
 		}
 	}()
 
+	documentNo, err := nextDocumentNo(ctx, tx, models.DocumentSeriesQuotation)
+	if err != nil {
+		return err
+	}
+	quotation.DocumentNo = &documentNo
+
+	if err = insertQuotationWithItems(ctx, tx, quotation, items); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateRevision saves quotation and its items as the next revision of the chain revised
+// belongs to, under the same quotation number, and marks revised as replaced. Only the latest
+// revision can be revised: ErrStaleVersion is returned when revised has been replaced already
+// or changed since version.
+func (r *QuotationRepository) CreateRevision(ctx context.Context, revised models.Quotation, version int, quotation *models.Quotation, items []models.QuotationItem) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	query := `
+		UPDATE quotations SET
+			status = $1,
+			updated_at = NOW(),
+			version = version + 1
+		WHERE quotation_id = $2 AND status <> $1 AND version = $3`
+	result, err := tx.ExecContext(ctx, query, models.QuotationStatusRevised, revised.QuotationID, version)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		err = ErrStaleVersion
+		return err
+	}
+
+	chainID := revised.ChainID()
+	err = tx.GetContext(ctx, &quotation.Revision, `
+		SELECT MAX(revision) + 1 FROM quotations
+		WHERE COALESCE(original_quotation_id, quotation_id) = $1`, chainID)
+	if err != nil {
+		return err
+	}
+	quotation.OriginalQuotationID = &chainID
+	quotation.DocumentNo = revised.DocumentNo
+
+	if err = insertQuotationWithItems(ctx, tx, quotation, items); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			// Another revision of the chain was saved at the same time
+			err = ErrStaleVersion
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertQuotationWithItems inserts a numbered quotation and its items
+func insertQuotationWithItems(ctx context.Context, tx *sqlx.Tx, quotation *models.Quotation, items []models.QuotationItem) error {
 	now := time.Now()
 	quotation.CreatedAt = now
 	quotation.UpdatedAt = now
+	if quotation.RequestReceivedAt.IsZero() {
+		quotation.RequestReceivedAt = now
+	}
 
-	// Insert the quotation first
 	query := `
 		INSERT INTO quotations (
 			customer_id, quote_date, validity_date, status, 
-			total_amount, created_at, updated_at
+			total_amount, created_at, updated_at, source, document_no,
+			vat_classification, vat_rate, net_amount, vat_amount, currency, exchange_rate,
+			original_quotation_id, revision, request_received_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7
-		) RETURNING quotation_id, created_at, updated_at`
+			$1, $2, $3, $4, $5, $6, $7, $8, $9,
+			COALESCE(NULLIF($10, ''), (SELECT vat_classification FROM customers WHERE customer_id = $1)), $11, $12, $13,
+			COALESCE(NULLIF($14, ''), '` + models.BaseCurrency + `'), COALESCE(NULLIF($15::NUMERIC, 0), 1),
+			$16, COALESCE(NULLIF($17, 0), 1), $18
+		) RETURNING quotation_id, created_at, updated_at, vat_classification, currency, exchange_rate, revision`
 
-	err = tx.QueryRowContext(
+	err := tx.QueryRowContext(
 		ctx,
 		query,
 		quotation.CustomerID,
@@ -308,19 +493,30 @@ func (r *QuotationRepository) CreateQuotationWithItems(ctx context.Context, quot
 		quotation.TotalAmount,
 		quotation.CreatedAt,
 		quotation.UpdatedAt,
-	).Scan(&quotation.QuotationID, &quotation.CreatedAt, &quotation.UpdatedAt)
+		quotation.Source,
+		quotation.DocumentNo,
+		quotation.VATClassification,
+		quotation.VATRate,
+		quotation.NetAmount,
+		quotation.VATAmount,
+		quotation.Currency,
+		quotation.ExchangeRate,
+		quotation.OriginalQuotationID,
+		quotation.Revision,
+		quotation.RequestReceivedAt,
+	).Scan(&quotation.QuotationID, &quotation.CreatedAt, &quotation.UpdatedAt, &quotation.VATClassification, &quotation.Currency, &quotation.ExchangeRate, &quotation.Revision)
 
 	if err != nil {
 		return err
 	}
 
-	// Then insert all the items
 	itemQuery := `
 		INSERT INTO quotation_items (
-			quotation_id, product_id, quantity, unit_price, discount
+			quotation_id, product_id, quantity, unit_price, discount, discount_type, discount_value,
+			promise_date, promise_source, promised_at
 		) VALUES (
-			$1, $2, $3, $4, $5
-		) RETURNING quotation_item_id`
+			$1, $2, $3, $4, $5, COALESCE(NULLIF($6, ''), 'none'), $7, $8, $9, $10
+		) RETURNING quotation_item_id, discount_type`
 
 	for i := range items {
 		items[i].QuotationID = quotation.QuotationID
@@ -332,25 +528,74 @@ func (r *QuotationRepository) CreateQuotationWithItems(ctx context.Context, quot
 			items[i].Quantity,
 			items[i].UnitPrice,
 			items[i].Discount,
-		).Scan(&items[i].QuotationItemID)
+			items[i].DiscountType,
+			items[i].DiscountValue,
+			items[i].PromiseDate,
+			items[i].PromiseSource,
+			items[i].PromisedAt,
+		).Scan(&items[i].QuotationItemID, &items[i].DiscountType)
 
 		if err != nil {
 			return err
 		}
 	}
 
-	return tx.Commit()
+	return nil
+}
+
+// GetRevisions returns every revision of the chain a quotation belongs to, oldest first
+func (r *QuotationRepository) GetRevisions(ctx context.Context, id int) ([]models.Quotation, error) {
+	quotations := []models.Quotation{}
+	query := `
+		SELECT * FROM quotations
+		WHERE COALESCE(original_quotation_id, quotation_id) = (
+			SELECT COALESCE(original_quotation_id, quotation_id) FROM quotations WHERE quotation_id = $1
+		)
+		ORDER BY revision`
+	if err := r.db.SelectContext(ctx, &quotations, query, id); err != nil {
+		return nil, err
+	}
+	if len(quotations) == 0 {
+		return nil, notFound("quotation")
+	}
+	return quotations, nil
+}
+
+// Accept marks a revision as the one the customer accepted and approves it. ErrDuplicateKey
+// is returned when another revision of its chain was accepted already, and ErrStaleVersion
+// if the quotation has been changed since version.
+func (r *QuotationRepository) Accept(ctx context.Context, id int, version int) error {
+	query := `
+		UPDATE quotations SET
+			status = 'Approved',
+			accepted_at = NOW(),
+			updated_at = NOW(),
+			version = version + 1
+		WHERE quotation_id = $1 AND version = $2
+		RETURNING updated_at`
+
+	var updatedAt time.Time
+	err := r.db.QueryRowContext(ctx, query, id, version).Scan(&updatedAt)
+	if err == sql.ErrNoRows {
+		return r.staleOrNotFound(ctx, id)
+	}
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrDuplicateKey
+	}
+	return err
 }
 
-// UpdateStatus updates only the status of an existing quotation
-func (r *QuotationRepository) UpdateStatus(ctx context.Context, id int, status string) error {
+// UpdateStatus updates only the status of an existing quotation. ErrStaleVersion is returned
+// if the quotation has been changed since version.
+func (r *QuotationRepository) UpdateStatus(ctx context.Context, id int, status string, version int) error {
 	now := time.Now()
 
 	query := `
 		UPDATE quotations SET
 			status = $1,
-			updated_at = $2
-		WHERE quotation_id = $3
+			updated_at = $2,
+			version = version + 1
+		WHERE quotation_id = $3 AND version = $4
 		RETURNING updated_at`
 
 	result := r.db.QueryRowContext(
@@ -359,12 +604,57 @@ func (r *QuotationRepository) UpdateStatus(ctx context.Context, id int, status s
 		status,
 		now,
 		id,
+		version,
 	)
 
 	var updatedAt time.Time
 	err := result.Scan(&updatedAt)
 	if err == sql.ErrNoRows {
-		return errors.New("quotation not found")
+		return r.staleOrNotFound(ctx, id)
+	}
+	return err
+}
+
+// MarkSent records when a quotation was first sent to its customer. Quotations already marked
+// sent keep their first time, and ErrNotFound is returned for quotations that do not exist.
+func (r *QuotationRepository) MarkSent(ctx context.Context, id int, sentAt time.Time) error {
+	var marked time.Time
+	err := r.db.GetContext(ctx, &marked, `
+		UPDATE quotations SET sent_at = COALESCE(sent_at, $2)
+		WHERE quotation_id = $1
+		RETURNING sent_at`, id, sentAt)
+	if err == sql.ErrNoRows {
+		return notFound("quotation")
 	}
 	return err
 }
+
+// GetAwaitingResponse retrieves the Pending quotations that have not been sent yet with their
+// customer's name, those whose requests came in first first
+func (r *QuotationRepository) GetAwaitingResponse(ctx context.Context) ([]QuotationSearchResult, error) {
+	quotations := []QuotationSearchResult{}
+	query := `
+		SELECT q.*, c.company_name
+		FROM quotations q
+		JOIN customers c ON c.customer_id = q.customer_id
+		WHERE UPPER(q.status) = 'PENDING' AND q.sent_at IS NULL
+		ORDER BY q.request_received_at, q.quotation_id`
+	err := r.db.SelectContext(ctx, &quotations, query)
+	return quotations, err
+}
+
+// GetReceivedSince retrieves the quotations whose requests were received since the given
+// time that have been sent or are still Pending, with their customer's name, oldest request
+// first
+func (r *QuotationRepository) GetReceivedSince(ctx context.Context, since time.Time) ([]QuotationSearchResult, error) {
+	quotations := []QuotationSearchResult{}
+	query := `
+		SELECT q.*, c.company_name
+		FROM quotations q
+		JOIN customers c ON c.customer_id = q.customer_id
+		WHERE q.request_received_at >= $1
+			AND (q.sent_at IS NOT NULL OR UPPER(q.status) = 'PENDING')
+		ORDER BY q.request_received_at, q.quotation_id`
+	err := r.db.SelectContext(ctx, &quotations, query, since)
+	return quotations, err
+}
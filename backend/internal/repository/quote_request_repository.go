@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// QuoteRequestRepository handles database operations for quote requests from the public form
+type QuoteRequestRepository struct {
+	db *sqlx.DB
+}
+
+// NewQuoteRequestRepository creates a new repository with the provided database connection
+func NewQuoteRequestRepository(db *sqlx.DB) *QuoteRequestRepository {
+	return &QuoteRequestRepository{
+		db: db,
+	}
+}
+
+// Create saves a new quote request, linking it to the active customer with the same email
+// address when there is one
+func (r *QuoteRequestRepository) Create(ctx context.Context, request *models.QuoteRequest) error {
+	query := `
+		INSERT INTO quote_requests (company_name, contact_name, email, phone, message, status, customer_id, source_ip)
+		VALUES ($1, $2, $3, $4, $5, $6, (
+			SELECT customer_id FROM customers
+			WHERE LOWER(email) = LOWER($3) AND deleted_at IS NULL AND archived_at IS NULL
+			ORDER BY customer_id LIMIT 1
+		), $7)
+		RETURNING quote_request_id, customer_id, created_at`
+
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		request.CompanyName,
+		request.ContactName,
+		request.Email,
+		request.Phone,
+		request.Message,
+		request.Status,
+		request.SourceIP,
+	).Scan(&request.QuoteRequestID, &request.CustomerID, &request.CreatedAt)
+}
+
+// GetAll retrieves quote requests, newest first, optionally only those with a status
+func (r *QuoteRequestRepository) GetAll(ctx context.Context, status string, page Page) ([]models.QuoteRequest, int, error) {
+	requests := []models.QuoteRequest{}
+	query := `
+		SELECT * FROM quote_requests
+		WHERE $1 = '' OR status = $1
+		ORDER BY created_at DESC, quote_request_id DESC`
+	total, err := selectPage(ctx, r.db, &requests, page, query, status)
+	return requests, total, err
+}
+
+// GetByID retrieves a quote request by ID
+func (r *QuoteRequestRepository) GetByID(ctx context.Context, id int) (models.QuoteRequest, error) {
+	var request models.QuoteRequest
+	err := r.db.GetContext(ctx, &request, `SELECT * FROM quote_requests WHERE quote_request_id = $1`, id)
+	if err == sql.ErrNoRows {
+		return request, notFound("quote request")
+	}
+	return request, err
+}
+
+// UpdateStatus sets a quote request's status and who handled it. Requests a quotation has
+// been prepared for keep their status: ErrConflict is returned for them.
+func (r *QuoteRequestRepository) UpdateStatus(ctx context.Context, id int, status string, handledBy *int) (models.QuoteRequest, error) {
+	var request models.QuoteRequest
+	query := `
+		UPDATE quote_requests SET status = $2, handled_by = $3, handled_at = $4
+		WHERE quote_request_id = $1 AND status <> $5
+		RETURNING *`
+	err := r.db.GetContext(ctx, &request, query, id, status, handledBy, time.Now(), models.QuoteRequestQuoted)
+	if err == sql.ErrNoRows {
+		if _, getErr := r.GetByID(ctx, id); getErr != nil {
+			return request, getErr
+		}
+		return request, conflict("a quotation has already been prepared for this quote request")
+	}
+	return request, err
+}
+
+// MarkQuoted records the quotation prepared for a quote request and the customer it was for
+func (r *QuoteRequestRepository) MarkQuoted(ctx context.Context, id, quotationID, customerID int, handledBy *int) error {
+	query := `
+		UPDATE quote_requests SET status = $2, quotation_id = $3, customer_id = $4, handled_by = $5, handled_at = $6
+		WHERE quote_request_id = $1`
+	result, err := r.db.ExecContext(ctx, query, id, models.QuoteRequestQuoted, quotationID, customerID, handledBy, time.Now())
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return notFound("quote request")
+	}
+	return nil
+}
+
+// Delete removes a quote request, used when its attachments could not be stored
+func (r *QuoteRequestRepository) Delete(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM quote_requests WHERE quote_request_id = $1`, id)
+	return err
+}
@@ -0,0 +1,324 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// ReceivingReportFilter narrows the receiving inspection report
+type ReceivingReportFilter struct {
+	From       *time.Time
+	To         *time.Time
+	ProductID  int
+	FailedOnly bool
+}
+
+// ReceivingRepository handles database operations for receiving checklists and stock receipts
+type ReceivingRepository struct {
+	db *sqlx.DB
+}
+
+// NewReceivingRepository creates a new repository with the provided database connection
+func NewReceivingRepository(db *sqlx.DB) *ReceivingRepository {
+	return &ReceivingRepository{
+		db: db,
+	}
+}
+
+// GetChecklistItems retrieves every checklist item, including inactive ones
+func (r *ReceivingRepository) GetChecklistItems(ctx context.Context) ([]models.ReceivingChecklistItem, error) {
+	items := []models.ReceivingChecklistItem{}
+	query := `SELECT * FROM receiving_checklist_items ORDER BY category NULLS FIRST, sort_order, item_id`
+	err := r.db.SelectContext(ctx, &items, query)
+	return items, err
+}
+
+// GetChecklistForCategory retrieves the active items that apply to a product category:
+// the items for every product followed by the category's own items
+func (r *ReceivingRepository) GetChecklistForCategory(ctx context.Context, category *string) ([]models.ReceivingChecklistItem, error) {
+	items := []models.ReceivingChecklistItem{}
+	query := `
+		SELECT * FROM receiving_checklist_items
+		WHERE active AND (category IS NULL OR LOWER(category) = LOWER($1))
+		ORDER BY category NULLS FIRST, sort_order, item_id`
+	err := r.db.SelectContext(ctx, &items, query, category)
+	return items, err
+}
+
+// GetChecklistItemByID retrieves a checklist item by ID
+func (r *ReceivingRepository) GetChecklistItemByID(ctx context.Context, id int) (models.ReceivingChecklistItem, error) {
+	var item models.ReceivingChecklistItem
+	query := `SELECT * FROM receiving_checklist_items WHERE item_id = $1`
+	err := r.db.GetContext(ctx, &item, query, id)
+	if err == sql.ErrNoRows {
+		return item, notFound("checklist item")
+	}
+	return item, err
+}
+
+// CreateChecklistItem adds an item to the receiving checklist
+func (r *ReceivingRepository) CreateChecklistItem(ctx context.Context, item *models.ReceivingChecklistItem) error {
+	query := `
+		INSERT INTO receiving_checklist_items (
+			label, category, input_type, min_value, max_value, unit, required, active, sort_order
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9
+		) RETURNING item_id, created_at, updated_at`
+
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		item.Label,
+		item.Category,
+		item.InputType,
+		item.MinValue,
+		item.MaxValue,
+		item.Unit,
+		item.Required,
+		item.Active,
+		item.SortOrder,
+	).Scan(&item.ItemID, &item.CreatedAt, &item.UpdatedAt)
+}
+
+// UpdateChecklistItem updates a checklist item. Receipts keep the wording and limits they were recorded with.
+func (r *ReceivingRepository) UpdateChecklistItem(ctx context.Context, item *models.ReceivingChecklistItem) error {
+	query := `
+		UPDATE receiving_checklist_items SET
+			label = $1,
+			category = $2,
+			input_type = $3,
+			min_value = $4,
+			max_value = $5,
+			unit = $6,
+			required = $7,
+			active = $8,
+			sort_order = $9,
+			updated_at = NOW()
+		WHERE item_id = $10
+		RETURNING created_at, updated_at`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		item.Label,
+		item.Category,
+		item.InputType,
+		item.MinValue,
+		item.MaxValue,
+		item.Unit,
+		item.Required,
+		item.Active,
+		item.SortOrder,
+		item.ItemID,
+	).Scan(&item.CreatedAt, &item.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return notFound("checklist item")
+	}
+	return err
+}
+
+// DeleteChecklistItem removes a checklist item; answers already recorded are kept
+func (r *ReceivingRepository) DeleteChecklistItem(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM receiving_checklist_items WHERE item_id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound("checklist item")
+	}
+
+	return nil
+}
+
+// CreateReceipt records a stock receipt with its checklist answers in one transaction.
+// The received quantity is added to the inventory item only when the receipt passed.
+func (r *ReceivingRepository) CreateReceipt(ctx context.Context, receipt *models.StockReceipt) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Lock the inventory row so concurrent receipts and stock edits apply in order
+	err = tx.QueryRowContext(ctx, `SELECT product_id FROM inventory WHERE inventory_id = $1 FOR UPDATE`,
+		receipt.InventoryID).Scan(&receipt.ProductID)
+	if err == sql.ErrNoRows {
+		return notFound("inventory item")
+	}
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO stock_receipts (
+			inventory_id, product_id, quantity, reference, temperature_c, condition_notes, passed, received_by
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8
+		) RETURNING receipt_id, received_at`
+
+	err = tx.QueryRowContext(
+		ctx,
+		query,
+		receipt.InventoryID,
+		receipt.ProductID,
+		receipt.Quantity,
+		receipt.Reference,
+		receipt.TemperatureC,
+		receipt.ConditionNotes,
+		receipt.Passed,
+		receipt.ReceivedBy,
+	).Scan(&receipt.ReceiptID, &receipt.ReceivedAt)
+	if err != nil {
+		return err
+	}
+
+	checkQuery := `
+		INSERT INTO stock_receipt_checks (
+			receipt_id, item_id, label, input_type, min_value, max_value, unit, value, passed
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9
+		) RETURNING check_id`
+
+	for i := range receipt.Checks {
+		check := &receipt.Checks[i]
+		check.ReceiptID = receipt.ReceiptID
+		err = tx.QueryRowContext(
+			ctx,
+			checkQuery,
+			check.ReceiptID,
+			check.ItemID,
+			check.Label,
+			check.InputType,
+			check.MinValue,
+			check.MaxValue,
+			check.Unit,
+			check.Value,
+			check.Passed,
+		).Scan(&check.CheckID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if receipt.Passed {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE inventory SET
+				current_stock = current_stock + $1,
+				last_restock_date = $2,
+				updated_at = NOW()
+			WHERE inventory_id = $3`, receipt.Quantity, receipt.ReceivedAt, receipt.InventoryID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetReceiptsByInventory retrieves the receipts for an inventory item, newest first, with their checks
+func (r *ReceivingRepository) GetReceiptsByInventory(ctx context.Context, inventoryID int) ([]models.StockReceipt, error) {
+	receipts := []models.StockReceipt{}
+	query := `SELECT * FROM stock_receipts WHERE inventory_id = $1 ORDER BY received_at DESC, receipt_id DESC`
+	if err := r.db.SelectContext(ctx, &receipts, query, inventoryID); err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, len(receipts))
+	for i, receipt := range receipts {
+		ids[i] = receipt.ReceiptID
+	}
+	checks, err := r.getChecks(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range receipts {
+		receipts[i].Checks = checks[receipts[i].ReceiptID]
+	}
+	return receipts, nil
+}
+
+// GetReport retrieves receipts matching the filter with their product and checks, newest first
+func (r *ReceivingRepository) GetReport(ctx context.Context, filter ReceivingReportFilter) ([]models.ReceivingReportRow, error) {
+	conditions := []string{}
+	args := []interface{}{}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("sr.received_at >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("sr.received_at < $%d", len(args)))
+	}
+	if filter.ProductID != 0 {
+		args = append(args, filter.ProductID)
+		conditions = append(conditions, fmt.Sprintf("sr.product_id = $%d", len(args)))
+	}
+	if filter.FailedOnly {
+		conditions = append(conditions, "NOT sr.passed")
+	}
+
+	query := `
+		SELECT sr.*, p.product_name, p.category
+		FROM stock_receipts sr
+		JOIN products p ON p.product_id = sr.product_id`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY sr.received_at DESC, sr.receipt_id DESC`
+
+	rows := []models.ReceivingReportRow{}
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ReceiptID
+	}
+	checks, err := r.getChecks(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		rows[i].Checks = checks[rows[i].ReceiptID]
+	}
+	return rows, nil
+}
+
+// getChecks loads the checklist answers of receipts grouped by receipt ID
+func (r *ReceivingRepository) getChecks(ctx context.Context, receiptIDs []int) (map[int][]models.StockReceiptCheck, error) {
+	grouped := map[int][]models.StockReceiptCheck{}
+	for _, id := range receiptIDs {
+		grouped[id] = []models.StockReceiptCheck{}
+	}
+	if len(receiptIDs) == 0 {
+		return grouped, nil
+	}
+
+	checks := []models.StockReceiptCheck{}
+	query := `SELECT * FROM stock_receipt_checks WHERE receipt_id = ANY($1) ORDER BY receipt_id, check_id`
+	if err := r.db.SelectContext(ctx, &checks, query, pq.Array(receiptIDs)); err != nil {
+		return nil, err
+	}
+	for _, check := range checks {
+		grouped[check.ReceiptID] = append(grouped[check.ReceiptID], check)
+	}
+	return grouped, nil
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// ReferenceDataRepository loads the lists behind the frontend's dropdowns
+type ReferenceDataRepository struct {
+	db *sqlx.DB
+}
+
+// NewReferenceDataRepository creates a new repository with the provided database connection
+func NewReferenceDataRepository(db *sqlx.DB) *ReferenceDataRepository {
+	return &ReferenceDataRepository{
+		db: db,
+	}
+}
+
+// Get returns the fixed enumerations together with the reference lists stored in the database
+func (r *ReferenceDataRepository) Get(ctx context.Context) (models.ReferenceData, error) {
+	data := models.ReferenceData{
+		OrderStatuses:     models.OrderStatuses,
+		QuotationStatuses: models.QuotationStatuses,
+		DiscountTypes:     models.DiscountTypes,
+		OrderSources:      models.OrderSources,
+	}
+
+	lists := []struct {
+		dest  *[]string
+		query string
+	}{
+		{&data.Roles, `SELECT DISTINCT role FROM users WHERE role <> ''`},
+		{&data.Industries, `SELECT name FROM industries`},
+		{&data.Categories, `SELECT DISTINCT category FROM products WHERE category IS NOT NULL AND category <> ''
+			UNION SELECT category FROM spec_schemas`},
+		{&data.Units, `SELECT DISTINCT field->>'unit' FROM spec_schemas, jsonb_array_elements(fields) AS field
+			WHERE COALESCE(field->>'unit', '') <> ''`},
+		{&data.LoyaltyTiers, `SELECT tier FROM loyalty_tiers ORDER BY min_revenue`},
+		{&data.Currencies, `SELECT code FROM currencies ORDER BY code`},
+	}
+	for _, list := range lists {
+		*list.dest = []string{}
+		if err := r.db.SelectContext(ctx, list.dest, list.query); err != nil {
+			return data, err
+		}
+	}
+
+	// Loyalty tiers keep their revenue order and currencies are sorted by code; the other lists
+	// are sorted alphabetically
+	for _, list := range []*[]string{&data.Industries, &data.Categories, &data.Units} {
+		sort.Slice(*list, func(i, j int) bool { return strings.ToLower((*list)[i]) < strings.ToLower((*list)[j]) })
+	}
+	data.Roles = mergeRoles(data.Roles)
+
+	data.Certifications = []models.ReferenceOption{}
+	if err := r.db.SelectContext(ctx, &data.Certifications,
+		`SELECT certification_id AS id, code, name FROM certifications ORDER BY code`); err != nil {
+		return data, err
+	}
+
+	data.SafetyStandards = []models.ReferenceOption{}
+	if err := r.db.SelectContext(ctx, &data.SafetyStandards,
+		`SELECT safety_standard_id AS id, code, name FROM safety_standards ORDER BY code`); err != nil {
+		return data, err
+	}
+
+	return data, nil
+}
+
+// mergeRoles adds the roles access control knows about to those assigned to users
+func mergeRoles(assigned []string) []string {
+	seen := map[string]bool{}
+	roles := []string{}
+	for _, role := range append([]string{models.RoleAdmin, models.RoleViewer}, assigned...) {
+		key := strings.ToLower(role)
+		if !seen[key] {
+			seen[key] = true
+			roles = append(roles, role)
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
@@ -9,7 +9,8 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
-// ReportRepository handles database operations for reports and dashboard data
+// ReportRepository handles database operations for reports and dashboard data. Order amounts
+// are totalled in pesos at the exchange rate each order was written at.
 type ReportRepository struct {
 	db *sqlx.DB
 }
@@ -30,7 +31,7 @@ func (r *ReportRepository) GetSalesTrends(ctx context.Context, days int) ([]mode
 	query := `
 		SELECT 
 			TO_CHAR(order_date, 'YYYY-MM-DD') AS day,
-			COALESCE(SUM(total_amount), 0) AS total_amount
+			COALESCE(SUM(total_amount * exchange_rate), 0) AS total_amount
 		FROM 
 			orders
 		WHERE 
@@ -63,7 +64,7 @@ func (r *ReportRepository) GetTotalSales(ctx context.Context, days int) (float64
 
 	query := `
 		SELECT 
-			COALESCE(SUM(total_amount), 0) AS total_sales
+			COALESCE(SUM(total_amount * exchange_rate), 0) AS total_sales
 		FROM 
 			orders
 		WHERE 
@@ -176,12 +177,12 @@ func (r *ReportRepository) GetTopCustomers(ctx context.Context, limit int, days
 		SELECT 
 			c.customer_id,
 			c.company_name,
-			COALESCE(SUM(o.total_amount), 0) AS total_spent,
+			COALESCE(SUM(o.total_amount * o.exchange_rate), 0) AS total_spent,
 			COUNT(o.order_id) AS order_count,
 			(
 				SELECT co.first_name || ' ' || co.last_name 
 				FROM contacts co 
-				WHERE co.customer_id = c.customer_id 
+				WHERE co.customer_id = c.customer_id AND co.deleted_at IS NULL
 				LIMIT 1
 			) AS contact_name
 		FROM 
@@ -263,3 +264,181 @@ func (r *ReportRepository) GetDashboardSummary(ctx context.Context, days int) (m
 	fmt.Println("Successfully retrieved dashboard summary")
 	return summary, nil
 }
+
+// GetSalesByChannel retrieves order totals per sales channel for the specified number of days.
+// Cancelled orders are excluded and orders without a recorded channel are grouped as "unknown".
+func (r *ReportRepository) GetSalesByChannel(ctx context.Context, days int) ([]models.SalesByChannel, error) {
+	channels := []models.SalesByChannel{}
+
+	query := `
+		SELECT
+			COALESCE(source, 'unknown') AS source,
+			COUNT(*) AS order_count,
+			COALESCE(SUM(total_amount * exchange_rate), 0) AS total_amount,
+			COALESCE(ROUND(SUM(total_amount * exchange_rate) * 100 / NULLIF(SUM(SUM(total_amount * exchange_rate)) OVER (), 0), 2), 0) AS share
+		FROM
+			orders
+		WHERE
+			order_date >= CURRENT_DATE - $1 * INTERVAL '1 day'
+			AND status <> 'Cancelled'
+		GROUP BY
+			COALESCE(source, 'unknown')
+		ORDER BY
+			total_amount DESC`
+
+	err := r.db.SelectContext(ctx, &channels, query, days)
+	return channels, err
+}
+
+// GetSalesByPaymentMethod retrieves the payments received per payment method over the specified
+// number of days. Methods without payments in the period are listed with zero totals.
+func (r *ReportRepository) GetSalesByPaymentMethod(ctx context.Context, days int) ([]models.SalesByPaymentMethod, error) {
+	methods := []models.SalesByPaymentMethod{}
+
+	query := `
+		SELECT
+			m.code AS payment_method,
+			m.name,
+			COUNT(p.payment_id) AS payment_count,
+			COALESCE(SUM(p.amount * o.exchange_rate), 0) AS total_amount,
+			COALESCE(ROUND(SUM(p.amount * o.exchange_rate) * 100 / NULLIF(SUM(SUM(p.amount * o.exchange_rate)) OVER (), 0), 2), 0) AS share
+		FROM
+			payment_methods m
+		LEFT JOIN
+			(order_payments p JOIN orders o ON o.order_id = p.order_id) ON p.payment_method = m.code
+			AND p.paid_at >= CURRENT_DATE - $1 * INTERVAL '1 day'
+		GROUP BY
+			m.code, m.name, m.sort_order
+		ORDER BY
+			total_amount DESC, m.sort_order`
+
+	err := r.db.SelectContext(ctx, &methods, query, days)
+	return methods, err
+}
+
+// GetRevenueByIndustry retrieves order totals per customer industry for the specified number of days.
+// Cancelled orders are excluded and customers without an industry are grouped as "Unassigned".
+func (r *ReportRepository) GetRevenueByIndustry(ctx context.Context, days int) ([]models.IndustryRevenue, error) {
+	industries := []models.IndustryRevenue{}
+
+	query := `
+		SELECT
+			COALESCE(c.industry, 'Unassigned') AS industry,
+			COUNT(DISTINCT c.customer_id) AS customer_count,
+			COUNT(o.order_id) AS order_count,
+			COALESCE(SUM(o.total_amount * o.exchange_rate), 0) AS total_amount,
+			COALESCE(ROUND(SUM(o.total_amount * o.exchange_rate) * 100 / NULLIF(SUM(SUM(o.total_amount * o.exchange_rate)) OVER (), 0), 2), 0) AS share
+		FROM
+			orders o
+		INNER JOIN
+			customers c ON c.customer_id = o.customer_id
+		WHERE
+			o.order_date >= CURRENT_DATE - $1 * INTERVAL '1 day'
+			AND o.status <> 'Cancelled'
+		GROUP BY
+			COALESCE(c.industry, 'Unassigned')
+		ORDER BY
+			total_amount DESC`
+
+	err := r.db.SelectContext(ctx, &industries, query, days)
+	return industries, err
+}
+
+// GetSalesBookEntries retrieves the orders dated from from up to but not including to, other
+// than cancelled ones, with their customers' tax details and the VAT split recorded on each
+// in pesos, in date order
+func (r *ReportRepository) GetSalesBookEntries(ctx context.Context, from, to time.Time) ([]models.SalesBookEntry, error) {
+	entries := []models.SalesBookEntry{}
+	query := `
+		SELECT
+			o.order_id,
+			o.document_no,
+			o.order_date,
+			o.customer_id,
+			c.company_name,
+			c.tin,
+			c.address,
+			o.vat_classification,
+			ROUND(o.total_amount * o.exchange_rate, 2) AS total_amount,
+			ROUND(o.net_amount * o.exchange_rate, 2) AS net_amount,
+			ROUND(o.vat_amount * o.exchange_rate, 2) AS vat_amount
+		FROM orders o
+		JOIN customers c ON c.customer_id = o.customer_id
+		WHERE o.order_date >= $1 AND o.order_date < $2 AND o.status <> 'Cancelled'
+		ORDER BY o.order_date, o.order_id`
+	err := r.db.SelectContext(ctx, &entries, query, from, to)
+	return entries, err
+}
+
+// GetDocumentNumbering checks the numbers issued in a document series, or in every series
+// when series is empty: the numbers voided by cancelling or deleting a document, with their
+// reasons, and the runs of numbers that are neither held by a document nor voided. Cancelled
+// documents whose void was not recorded are listed as voided without a reason.
+func (r *ReportRepository) GetDocumentNumbering(ctx context.Context, series string) ([]models.SeriesNumbering, error) {
+	all := []models.DocumentSeries{}
+	query := `SELECT * FROM document_series WHERE $1 = '' OR series = $1 ORDER BY series`
+	if err := r.db.SelectContext(ctx, &all, query, series); err != nil {
+		return nil, err
+	}
+
+	report := make([]models.SeriesNumbering, 0, len(all))
+	for _, s := range all {
+		t, ok := numberedTables[s.Series]
+		if !ok {
+			continue
+		}
+		numbering := models.SeriesNumbering{DocumentSeries: s, Issued: s.NextNumber - 1}
+
+		query = `SELECT COUNT(*) FROM ` + t.table + ` WHERE document_no IS NOT NULL`
+		if err := r.db.GetContext(ctx, &numbering.Documents, query); err != nil {
+			return nil, err
+		}
+
+		numbering.Voided = []models.VoidedDocument{}
+		query = `SELECT * FROM voided_documents WHERE series = $1`
+		if t.cancelledStatus != "" {
+			query += `
+				UNION ALL
+				SELECT $1, d.document_no, d.` + t.key + `, 'cancelled', '', NULL, d.updated_at
+				FROM ` + t.table + ` d
+				WHERE d.status = $2 AND d.document_no IS NOT NULL AND NOT EXISTS (
+					SELECT 1 FROM voided_documents v WHERE v.series = $1 AND v.document_no = d.document_no
+				)`
+		} else {
+			query += ` AND $2 = ''`
+		}
+		if err := r.db.SelectContext(ctx, &numbering.Voided, `SELECT * FROM (`+query+`) v ORDER BY document_no`, s.Series, t.cancelledStatus); err != nil {
+			return nil, err
+		}
+
+		numbering.Gaps = []models.NumberGap{}
+		query = `
+			SELECT MIN(n) AS gap_from, MAX(n) AS gap_to, COUNT(*) AS count
+			FROM (
+				SELECT n, n - ROW_NUMBER() OVER (ORDER BY n) AS run
+				FROM (
+					SELECT generate_series(1, $2::INTEGER) AS n
+					EXCEPT SELECT document_no FROM ` + t.table + ` WHERE document_no IS NOT NULL
+					EXCEPT SELECT document_no FROM voided_documents WHERE series = $1
+				) missing
+			) runs
+			GROUP BY run
+			ORDER BY gap_from`
+		if err := r.db.SelectContext(ctx, &numbering.Gaps, query, s.Series, numbering.Issued); err != nil {
+			return nil, err
+		}
+
+		for i := range numbering.Voided {
+			numbering.Voided[i].Number = s.Format(numbering.Voided[i].DocumentNo)
+		}
+		for i, gap := range numbering.Gaps {
+			numbering.Gaps[i].Range = s.Format(gap.From)
+			if gap.To != gap.From {
+				numbering.Gaps[i].Range += " to " + s.Format(gap.To)
+			}
+		}
+		numbering.Gapless = len(numbering.Gaps) == 0
+		report = append(report, numbering)
+	}
+	return report, nil
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// ReportScheduleRepository handles database operations for scheduled reports
+type ReportScheduleRepository struct {
+	db *sqlx.DB
+}
+
+// NewReportScheduleRepository creates a new repository with the provided database connection
+func NewReportScheduleRepository(db *sqlx.DB) *ReportScheduleRepository {
+	return &ReportScheduleRepository{
+		db: db,
+	}
+}
+
+// GetAll retrieves every report schedule
+func (r *ReportScheduleRepository) GetAll(ctx context.Context) ([]models.ReportSchedule, error) {
+	schedules := []models.ReportSchedule{}
+	err := r.db.SelectContext(ctx, &schedules, `SELECT * FROM report_schedules ORDER BY report_schedule_id`)
+	return schedules, err
+}
+
+// GetByID retrieves a report schedule by ID
+func (r *ReportScheduleRepository) GetByID(ctx context.Context, id int) (models.ReportSchedule, error) {
+	var schedule models.ReportSchedule
+	err := r.db.GetContext(ctx, &schedule, `SELECT * FROM report_schedules WHERE report_schedule_id = $1`, id)
+	if err == sql.ErrNoRows {
+		return schedule, notFound("report schedule")
+	}
+	return schedule, err
+}
+
+// Create inserts a new report schedule
+func (r *ReportScheduleRepository) Create(ctx context.Context, schedule *models.ReportSchedule) error {
+	query := `
+		INSERT INTO report_schedules (
+			name, reports, recipients, frequency, weekday, day_of_month, hour, minute,
+			timezone, days, active, next_run_at, created_by
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+		) RETURNING report_schedule_id, created_at, updated_at`
+
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		schedule.Name,
+		schedule.Reports,
+		schedule.Recipients,
+		schedule.Frequency,
+		schedule.Weekday,
+		schedule.DayOfMonth,
+		schedule.Hour,
+		schedule.Minute,
+		schedule.Timezone,
+		schedule.Days,
+		schedule.Active,
+		schedule.NextRunAt,
+		schedule.CreatedBy,
+	).Scan(&schedule.ReportScheduleID, &schedule.CreatedAt, &schedule.UpdatedAt)
+}
+
+// Update changes a report schedule's reports, recipients, timing and whether it is active
+func (r *ReportScheduleRepository) Update(ctx context.Context, schedule *models.ReportSchedule) error {
+	query := `
+		UPDATE report_schedules SET
+			name = $1, reports = $2, recipients = $3, frequency = $4, weekday = $5,
+			day_of_month = $6, hour = $7, minute = $8, timezone = $9, days = $10,
+			active = $11, next_run_at = $12, updated_at = NOW()
+		WHERE report_schedule_id = $13
+		RETURNING updated_at`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		schedule.Name,
+		schedule.Reports,
+		schedule.Recipients,
+		schedule.Frequency,
+		schedule.Weekday,
+		schedule.DayOfMonth,
+		schedule.Hour,
+		schedule.Minute,
+		schedule.Timezone,
+		schedule.Days,
+		schedule.Active,
+		schedule.NextRunAt,
+		schedule.ReportScheduleID,
+	).Scan(&schedule.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return notFound("report schedule")
+	}
+	return err
+}
+
+// Delete removes a report schedule
+func (r *ReportScheduleRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM report_schedules WHERE report_schedule_id = $1`, id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return notFound("report schedule")
+	}
+	return nil
+}
+
+// GetDue retrieves the active schedules whose next run is at or before now
+func (r *ReportScheduleRepository) GetDue(ctx context.Context, now time.Time) ([]models.ReportSchedule, error) {
+	schedules := []models.ReportSchedule{}
+	query := `SELECT * FROM report_schedules WHERE active AND next_run_at <= $1 ORDER BY next_run_at`
+	err := r.db.SelectContext(ctx, &schedules, query, now)
+	return schedules, err
+}
+
+// Claim moves a due schedule's next run from due to next and reports whether it did. Only
+// one server claims each run, so a run is sent once when several are running.
+func (r *ReportScheduleRepository) Claim(ctx context.Context, id int, due, next time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE report_schedules SET next_run_at = $1
+		WHERE report_schedule_id = $2 AND active AND next_run_at = $3`, next, id, due)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected == 1, err
+}
+
+// RecordRun records when a schedule last ran and why it failed, clearing the reason when
+// runErr is nil
+func (r *ReportScheduleRepository) RecordRun(ctx context.Context, id int, ranAt time.Time, runErr error) error {
+	var lastError *string
+	if runErr != nil {
+		message := runErr.Error()
+		lastError = &message
+	}
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE report_schedules SET last_run_at = $1, last_error = $2
+		WHERE report_schedule_id = $3`, ranAt, lastError, id)
+	return err
+}
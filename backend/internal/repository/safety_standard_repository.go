@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// SafetyStandardRepository handles database operations for safety standards and their product links
+type SafetyStandardRepository struct {
+	db *sqlx.DB
+}
+
+// NewSafetyStandardRepository creates a new repository with the provided database connection
+func NewSafetyStandardRepository(db *sqlx.DB) *SafetyStandardRepository {
+	return &SafetyStandardRepository{
+		db: db,
+	}
+}
+
+// GetAll retrieves all safety standards ordered by code
+func (r *SafetyStandardRepository) GetAll(ctx context.Context) ([]models.SafetyStandard, error) {
+	standards := []models.SafetyStandard{}
+	query := `SELECT * FROM safety_standards ORDER BY code`
+	err := r.db.SelectContext(ctx, &standards, query)
+	return standards, err
+}
+
+// GetByID retrieves a safety standard by ID
+func (r *SafetyStandardRepository) GetByID(ctx context.Context, id int) (models.SafetyStandard, error) {
+	var standard models.SafetyStandard
+	query := `SELECT * FROM safety_standards WHERE safety_standard_id = $1`
+	err := r.db.GetContext(ctx, &standard, query, id)
+	if err == sql.ErrNoRows {
+		return standard, notFound("safety standard")
+	}
+	return standard, err
+}
+
+// Create inserts a new safety standard
+func (r *SafetyStandardRepository) Create(ctx context.Context, standard *models.SafetyStandard) error {
+	query := `
+		INSERT INTO safety_standards (code, name, issuing_body, description)
+		VALUES ($1, $2, $3, $4)
+		RETURNING safety_standard_id, created_at, updated_at`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		standard.Code,
+		standard.Name,
+		standard.IssuingBody,
+		standard.Description,
+	).Scan(&standard.SafetyStandardID, &standard.CreatedAt, &standard.UpdatedAt)
+
+	return translateReferenceError(err)
+}
+
+// Update updates an existing safety standard
+func (r *SafetyStandardRepository) Update(ctx context.Context, standard *models.SafetyStandard) error {
+	query := `
+		UPDATE safety_standards SET
+			code = $1,
+			name = $2,
+			issuing_body = $3,
+			description = $4,
+			updated_at = NOW()
+		WHERE safety_standard_id = $5
+		RETURNING created_at, updated_at`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		standard.Code,
+		standard.Name,
+		standard.IssuingBody,
+		standard.Description,
+		standard.SafetyStandardID,
+	).Scan(&standard.CreatedAt, &standard.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return notFound("safety standard")
+	}
+
+	return translateReferenceError(err)
+}
+
+// Delete removes a safety standard. Standards still linked to products cannot be deleted.
+func (r *SafetyStandardRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM safety_standards WHERE safety_standard_id = $1`, id)
+	if err != nil {
+		return translateReferenceError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound("safety standard")
+	}
+
+	return nil
+}
+
+// GetByProductID retrieves the safety standards a product complies with
+func (r *SafetyStandardRepository) GetByProductID(ctx context.Context, productID int) ([]models.ProductSafetyStandard, error) {
+	standards := []models.ProductSafetyStandard{}
+	query := `
+		SELECT ps.*, s.code, s.name
+		FROM product_safety_standards ps
+		JOIN safety_standards s ON s.safety_standard_id = ps.safety_standard_id
+		WHERE ps.product_id = $1
+		ORDER BY s.code`
+	err := r.db.SelectContext(ctx, &standards, query, productID)
+	return standards, err
+}
+
+// LinkProduct records that a product complies with a safety standard. Linking twice is a no-op.
+func (r *SafetyStandardRepository) LinkProduct(ctx context.Context, link *models.ProductSafetyStandard) error {
+	query := `
+		INSERT INTO product_safety_standards (product_id, safety_standard_id)
+		VALUES ($1, $2)
+		ON CONFLICT (product_id, safety_standard_id) DO UPDATE SET
+			updated_at = product_safety_standards.updated_at
+		RETURNING product_safety_standard_id, created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query, link.ProductID, link.SafetyStandardID).
+		Scan(&link.ProductSafetyStandardID, &link.CreatedAt, &link.UpdatedAt)
+
+	return translateReferenceError(err)
+}
+
+// UnlinkProduct removes a safety standard from a product
+func (r *SafetyStandardRepository) UnlinkProduct(ctx context.Context, productID, safetyStandardID int) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		`DELETE FROM product_safety_standards WHERE product_id = $1 AND safety_standard_id = $2`,
+		productID, safetyStandardID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound("product safety standard")
+	}
+
+	return nil
+}
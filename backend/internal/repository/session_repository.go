@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// SessionRepository handles database operations for login sessions
+type SessionRepository struct {
+	db *sqlx.DB
+}
+
+// NewSessionRepository creates a new repository with the provided database connection
+func NewSessionRepository(db *sqlx.DB) *SessionRepository {
+	return &SessionRepository{
+		db: db,
+	}
+}
+
+// GetActiveByTokenHash retrieves an unexpired session by token hash
+func (r *SessionRepository) GetActiveByTokenHash(ctx context.Context, tokenHash string) (models.Session, error) {
+	var session models.Session
+	query := `SELECT * FROM sessions WHERE token_hash = $1 AND expires_at > NOW()`
+	err := r.db.GetContext(ctx, &session, query, tokenHash)
+	if err == sql.ErrNoRows {
+		return session, notFound("session")
+	}
+	return session, err
+}
+
+// Create inserts a new session
+func (r *SessionRepository) Create(ctx context.Context, session *models.Session) error {
+	query := `
+		INSERT INTO sessions (
+			token_hash, user_id, ip_address, user_agent, expires_at
+		) VALUES (
+			$1, $2, $3, $4, $5
+		) RETURNING session_id, last_seen_at, created_at`
+
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		session.TokenHash,
+		session.UserID,
+		session.IPAddress,
+		session.UserAgent,
+		session.ExpiresAt,
+	).Scan(&session.SessionID, &session.LastSeenAt, &session.CreatedAt)
+}
+
+// Touch records that a session was used and slides its expiry forward, at most once a
+// minute to keep writes down
+func (r *SessionRepository) Touch(ctx context.Context, id int, expiresAt time.Time) error {
+	query := `
+		UPDATE sessions SET last_seen_at = NOW(), expires_at = GREATEST(expires_at, $2)
+		WHERE session_id = $1 AND last_seen_at < NOW() - INTERVAL '1 minute'`
+
+	_, err := r.db.ExecContext(ctx, query, id, expiresAt)
+	return err
+}
+
+// Extend moves the expiry of an active session forward
+func (r *SessionRepository) Extend(ctx context.Context, id int, expiresAt time.Time) (models.Session, error) {
+	var session models.Session
+	query := `
+		UPDATE sessions SET last_seen_at = NOW(), expires_at = GREATEST(expires_at, $2)
+		WHERE session_id = $1 AND expires_at > NOW()
+		RETURNING *`
+	err := r.db.GetContext(ctx, &session, query, id, expiresAt)
+	if err == sql.ErrNoRows {
+		return session, notFound("session")
+	}
+	return session, err
+}
+
+// DeleteByTokenHash removes a session, ending it immediately
+func (r *SessionRepository) DeleteByTokenHash(ctx context.Context, tokenHash string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM sessions WHERE token_hash = $1`, tokenHash)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound("session")
+	}
+
+	return nil
+}
+
+// DeleteByUserID removes every session of a user
+func (r *SessionRepository) DeleteByUserID(ctx context.Context, userID int) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = $1`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DeleteExpired removes sessions past their expiry
+func (r *SessionRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM sessions WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
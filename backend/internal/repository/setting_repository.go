@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// SettingRepository handles database operations for application settings
+type SettingRepository struct {
+	db *sqlx.DB
+}
+
+// NewSettingRepository creates a new repository with the provided database connection
+func NewSettingRepository(db *sqlx.DB) *SettingRepository {
+	return &SettingRepository{
+		db: db,
+	}
+}
+
+// Get retrieves a setting by key
+func (r *SettingRepository) Get(ctx context.Context, key string) (models.Setting, error) {
+	var setting models.Setting
+	query := `SELECT * FROM settings WHERE key = $1`
+	err := r.db.GetContext(ctx, &setting, query, key)
+	if err == sql.ErrNoRows {
+		return setting, notFound("setting")
+	}
+	return setting, err
+}
+
+// Set creates or replaces a setting
+func (r *SettingRepository) Set(ctx context.Context, setting *models.Setting) error {
+	query := `
+		INSERT INTO settings (key, value, updated_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE SET
+			value = EXCLUDED.value,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = NOW()
+		RETURNING updated_at`
+
+	return r.db.QueryRowContext(ctx, query, setting.Key, setting.Value, setting.UpdatedBy).Scan(&setting.UpdatedAt)
+}
+
+// GetByKeys retrieves the settings stored under any of the keys
+func (r *SettingRepository) GetByKeys(ctx context.Context, keys []string) ([]models.Setting, error) {
+	settings := []models.Setting{}
+	query := `SELECT * FROM settings WHERE key = ANY($1) ORDER BY key`
+	err := r.db.SelectContext(ctx, &settings, query, pq.Array(keys))
+	return settings, err
+}
+
+// Delete removes a setting so its default applies again
+func (r *SettingRepository) Delete(ctx context.Context, key string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM settings WHERE key = $1`, key)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return notFound("setting")
+	}
+	return nil
+}
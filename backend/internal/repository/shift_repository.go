@@ -0,0 +1,294 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrShiftAlreadyOpen is returned when a cashier opens a shift while another is still open
+	ErrShiftAlreadyOpen = conflict("cashier already has an open shift")
+
+	// ErrRegisterInUse is returned when a shift is opened on a register another open shift uses
+	ErrRegisterInUse = conflict("register is in use by another open shift")
+
+	// ErrShiftClosed is returned when closing a shift that is already closed
+	ErrShiftClosed = conflict("shift is already closed")
+)
+
+// ShiftFilter narrows a shift query. From and To bound when the shift was opened.
+type ShiftFilter struct {
+	UserID int
+	Status string
+	From   *time.Time
+	To     *time.Time
+}
+
+// ShiftRepository handles database operations for cashier shifts
+type ShiftRepository struct {
+	db *sqlx.DB
+}
+
+// NewShiftRepository creates a new repository with the provided database connection
+func NewShiftRepository(db *sqlx.DB) *ShiftRepository {
+	return &ShiftRepository{
+		db: db,
+	}
+}
+
+// GetAll retrieves shifts matching the filter, latest first
+func (r *ShiftRepository) GetAll(ctx context.Context, filter ShiftFilter) ([]models.CashierShift, error) {
+	shifts := []models.CashierShift{}
+
+	conditions := []string{}
+	args := []interface{}{}
+	if filter.UserID != 0 {
+		args = append(args, filter.UserID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("opened_at >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("opened_at < $%d", len(args)))
+	}
+
+	query := `SELECT * FROM cashier_shifts` + whereClause(conditions) + ` ORDER BY opened_at DESC`
+	err := r.db.SelectContext(ctx, &shifts, query, args...)
+	return shifts, err
+}
+
+// GetByID retrieves a shift by ID
+func (r *ShiftRepository) GetByID(ctx context.Context, id int) (models.CashierShift, error) {
+	var shift models.CashierShift
+	err := r.db.GetContext(ctx, &shift, `SELECT * FROM cashier_shifts WHERE shift_id = $1`, id)
+	if err == sql.ErrNoRows {
+		return shift, notFound("shift")
+	}
+	return shift, err
+}
+
+// GetOpenForUser retrieves the shift a cashier currently has open
+func (r *ShiftRepository) GetOpenForUser(ctx context.Context, userID int) (models.CashierShift, error) {
+	var shift models.CashierShift
+	err := r.db.GetContext(ctx, &shift, `SELECT * FROM cashier_shifts WHERE user_id = $1 AND status = 'open'`, userID)
+	if err == sql.ErrNoRows {
+		return shift, notFound("open shift")
+	}
+	return shift, err
+}
+
+// Open starts a shift with the opening float in the drawer
+func (r *ShiftRepository) Open(ctx context.Context, shift *models.CashierShift) error {
+	query := `
+		INSERT INTO cashier_shifts (user_id, register, opening_cash, opening_notes)
+		VALUES ($1, $2, $3, $4)
+		RETURNING shift_id, status, opened_at`
+
+	err := r.db.QueryRowContext(ctx, query, shift.UserID, shift.Register, shift.OpeningCash, shift.OpeningNotes).
+		Scan(&shift.ShiftID, &shift.Status, &shift.OpenedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			if pqErr.Constraint == "idx_cashier_shifts_open_register" {
+				return ErrRegisterInUse
+			}
+			return ErrShiftAlreadyOpen
+		}
+		return err
+	}
+	return nil
+}
+
+// Close ends a shift with the counted cash. The expected cash is the opening float plus the
+// shift's cash sales, and the variance is what was counted less what was expected.
+func (r *ShiftRepository) Close(ctx context.Context, shift *models.CashierShift) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var current models.CashierShift
+	err = tx.GetContext(ctx, &current, `SELECT * FROM cashier_shifts WHERE shift_id = $1 FOR UPDATE`, shift.ShiftID)
+	if err == sql.ErrNoRows {
+		err = notFound("shift")
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if current.Status == models.ShiftClosed {
+		err = ErrShiftClosed
+		return err
+	}
+
+	var cashSales float64
+	err = tx.GetContext(ctx, &cashSales, `
+		SELECT COALESCE(SUM(total_amount), 0) FROM orders
+		WHERE shift_id = $1 AND payment_method = $2`, shift.ShiftID, models.PaymentCash)
+	if err != nil {
+		return err
+	}
+
+	expected := math.Round((current.OpeningCash+cashSales)*100) / 100
+	variance := math.Round((*shift.CountedCash-expected)*100) / 100
+
+	query := `
+		UPDATE cashier_shifts SET
+			status = 'closed',
+			cash_counts = $1,
+			counted_cash = $2,
+			expected_cash = $3,
+			variance = $4,
+			closing_notes = $5,
+			closed_by = $6,
+			closed_at = NOW()
+		WHERE shift_id = $7
+		RETURNING *`
+
+	err = tx.GetContext(ctx, shift, query, shift.CashCounts, shift.CountedCash, expected, variance,
+		shift.ClosingNotes, shift.ClosedBy, shift.ShiftID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Report builds the Z-report of a shift from the counter sales linked to it
+func (r *ShiftRepository) Report(ctx context.Context, shift models.CashierShift) (models.ShiftReport, error) {
+	report := models.ShiftReport{
+		Shift:    shift,
+		Payments: []models.ShiftPaymentTotal{},
+	}
+
+	err := r.db.GetContext(ctx, &report.Cashier, `
+		SELECT TRIM(first_name || ' ' || last_name) FROM users WHERE user_id = $1`, shift.UserID)
+	if err != nil && err != sql.ErrNoRows {
+		return report, err
+	}
+
+	err = r.db.SelectContext(ctx, &report.Payments, `
+		SELECT payment_method,
+			COUNT(*) AS sales,
+			SUM(total_amount) AS total,
+			SUM(COALESCE(amount_paid, total_amount)) AS tendered
+		FROM orders
+		WHERE shift_id = $1 AND payment_method IS NOT NULL
+		GROUP BY payment_method
+		ORDER BY payment_method`, shift.ShiftID)
+	if err != nil {
+		return report, err
+	}
+
+	var items struct {
+		ItemsSold int     `db:"items_sold"`
+		Discounts float64 `db:"discounts"`
+	}
+	err = r.db.GetContext(ctx, &items, `
+		SELECT COALESCE(SUM(oi.quantity), 0) AS items_sold,
+			COALESCE(SUM(oi.discount), 0) AS discounts
+		FROM order_items oi
+		JOIN orders o ON o.order_id = oi.order_id
+		WHERE o.shift_id = $1 AND o.payment_method IS NOT NULL`, shift.ShiftID)
+	if err != nil {
+		return report, err
+	}
+	report.ItemsSold = items.ItemsSold
+	report.Discounts = items.Discounts
+
+	for _, payment := range report.Payments {
+		report.Sales += payment.Sales
+		report.TotalSales += payment.Total
+		if payment.PaymentMethod == models.PaymentCash {
+			report.CashSales = payment.Total
+		}
+	}
+	report.TotalSales = math.Round(report.TotalSales*100) / 100
+
+	// A closed shift reports the figures recorded when it was counted
+	if shift.ExpectedCash != nil {
+		report.ExpectedCash = *shift.ExpectedCash
+	} else {
+		report.ExpectedCash = math.Round((shift.OpeningCash+report.CashSales)*100) / 100
+	}
+	report.CountedCash = shift.CountedCash
+	report.Variance = shift.Variance
+
+	return report, nil
+}
+
+// DailyReport builds the closing report of the shifts opened on a day, oldest first
+func (r *ShiftRepository) DailyReport(ctx context.Context, day time.Time) (models.DailyClosingReport, error) {
+	from := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	to := from.AddDate(0, 0, 1)
+	report := models.DailyClosingReport{
+		Date:     from.Format("2006-01-02"),
+		Shifts:   []models.ShiftReport{},
+		Payments: []models.ShiftPaymentTotal{},
+	}
+
+	shifts, err := r.GetAll(ctx, ShiftFilter{From: &from, To: &to})
+	if err != nil {
+		return report, err
+	}
+
+	payments := map[string]*models.ShiftPaymentTotal{}
+	methods := []string{}
+	for i := len(shifts) - 1; i >= 0; i-- {
+		shiftReport, err := r.Report(ctx, shifts[i])
+		if err != nil {
+			return report, err
+		}
+		report.Shifts = append(report.Shifts, shiftReport)
+		report.Sales += shiftReport.Sales
+		report.TotalSales += shiftReport.TotalSales
+		if shiftReport.Variance != nil {
+			report.TotalVariance += *shiftReport.Variance
+		}
+		if shifts[i].Status == models.ShiftOpen {
+			report.OpenShifts++
+		}
+
+		for _, payment := range shiftReport.Payments {
+			total, ok := payments[payment.PaymentMethod]
+			if !ok {
+				total = &models.ShiftPaymentTotal{PaymentMethod: payment.PaymentMethod}
+				payments[payment.PaymentMethod] = total
+				methods = append(methods, payment.PaymentMethod)
+			}
+			total.Sales += payment.Sales
+			total.Total += payment.Total
+			total.Tendered += payment.Tendered
+		}
+	}
+
+	sort.Strings(methods)
+	for _, method := range methods {
+		report.Payments = append(report.Payments, *payments[method])
+	}
+	report.TotalSales = math.Round(report.TotalSales*100) / 100
+	report.TotalVariance = math.Round(report.TotalVariance*100) / 100
+
+	return report, nil
+}
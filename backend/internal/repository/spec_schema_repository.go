@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// SpecSchemaRepository handles database operations for per-category spec schemas
+type SpecSchemaRepository struct {
+	db *sqlx.DB
+}
+
+// NewSpecSchemaRepository creates a new repository with the provided database connection
+func NewSpecSchemaRepository(db *sqlx.DB) *SpecSchemaRepository {
+	return &SpecSchemaRepository{
+		db: db,
+	}
+}
+
+// GetAll retrieves every spec schema ordered by category
+func (r *SpecSchemaRepository) GetAll(ctx context.Context) ([]models.SpecSchema, error) {
+	schemas := []models.SpecSchema{}
+	query := `SELECT * FROM spec_schemas ORDER BY category`
+	err := r.db.SelectContext(ctx, &schemas, query)
+	return schemas, err
+}
+
+// GetByCategory retrieves the spec schema for a category
+func (r *SpecSchemaRepository) GetByCategory(ctx context.Context, category string) (models.SpecSchema, error) {
+	var schema models.SpecSchema
+	query := `SELECT * FROM spec_schemas WHERE category = $1`
+	err := r.db.GetContext(ctx, &schema, query, category)
+	if err == sql.ErrNoRows {
+		return schema, notFound("spec schema")
+	}
+	return schema, err
+}
+
+// Save creates or replaces the spec schema for a category
+func (r *SpecSchemaRepository) Save(ctx context.Context, schema *models.SpecSchema) error {
+	query := `
+		INSERT INTO spec_schemas (category, fields)
+		VALUES ($1, $2::jsonb)
+		ON CONFLICT (category) DO UPDATE SET
+			fields = EXCLUDED.fields,
+			updated_at = NOW()
+		RETURNING created_at, updated_at`
+
+	return r.db.QueryRowContext(ctx, query, schema.Category, schema.Fields).Scan(&schema.CreatedAt, &schema.UpdatedAt)
+}
+
+// Delete removes the spec schema for a category
+func (r *SpecSchemaRepository) Delete(ctx context.Context, category string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM spec_schemas WHERE category = $1`, category)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound("spec schema")
+	}
+
+	return nil
+}
@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// SupplierInvoiceFilter narrows a supplier invoice query
+type SupplierInvoiceFilter struct {
+	SupplierID      int
+	PurchaseOrderID int
+	MatchStatus     string
+}
+
+// DiscrepancyReportFilter narrows the purchasing discrepancy report
+type DiscrepancyReportFilter struct {
+	From       *time.Time
+	To         *time.Time
+	SupplierID int
+	Kind       string
+}
+
+// SupplierInvoiceRepository handles database operations for supplier invoices and their match results
+type SupplierInvoiceRepository struct {
+	db *sqlx.DB
+}
+
+// NewSupplierInvoiceRepository creates a new repository with the provided database connection
+func NewSupplierInvoiceRepository(db *sqlx.DB) *SupplierInvoiceRepository {
+	return &SupplierInvoiceRepository{
+		db: db,
+	}
+}
+
+// GetAll retrieves supplier invoices matching the filter, newest first, without their items
+func (r *SupplierInvoiceRepository) GetAll(ctx context.Context, filter SupplierInvoiceFilter) ([]models.SupplierInvoice, error) {
+	invoices := []models.SupplierInvoice{}
+
+	conditions := []string{}
+	args := []interface{}{}
+	if filter.SupplierID != 0 {
+		args = append(args, filter.SupplierID)
+		conditions = append(conditions, fmt.Sprintf("supplier_id = $%d", len(args)))
+	}
+	if filter.PurchaseOrderID != 0 {
+		args = append(args, filter.PurchaseOrderID)
+		conditions = append(conditions, fmt.Sprintf("purchase_order_id = $%d", len(args)))
+	}
+	if filter.MatchStatus != "" {
+		args = append(args, filter.MatchStatus)
+		conditions = append(conditions, fmt.Sprintf("match_status = $%d", len(args)))
+	}
+
+	query := `SELECT * FROM supplier_invoices` + whereClause(conditions) + ` ORDER BY invoice_date DESC, invoice_id DESC`
+	err := r.db.SelectContext(ctx, &invoices, query, args...)
+	return invoices, err
+}
+
+// GetByID retrieves a supplier invoice with its items and discrepancies
+func (r *SupplierInvoiceRepository) GetByID(ctx context.Context, id int) (models.SupplierInvoice, error) {
+	var invoice models.SupplierInvoice
+	query := `SELECT * FROM supplier_invoices WHERE invoice_id = $1`
+	err := r.db.GetContext(ctx, &invoice, query, id)
+	if err == sql.ErrNoRows {
+		return invoice, notFound("supplier invoice")
+	}
+	if err != nil {
+		return invoice, err
+	}
+
+	invoice.Items = []models.SupplierInvoiceItem{}
+	itemQuery := `SELECT * FROM supplier_invoice_items WHERE invoice_id = $1 ORDER BY invoice_item_id`
+	if err = r.db.SelectContext(ctx, &invoice.Items, itemQuery, id); err != nil {
+		return invoice, err
+	}
+
+	invoice.Discrepancies = []models.InvoiceDiscrepancy{}
+	discrepancyQuery := `SELECT * FROM supplier_invoice_discrepancies WHERE invoice_id = $1 ORDER BY discrepancy_id`
+	err = r.db.SelectContext(ctx, &invoice.Discrepancies, discrepancyQuery, id)
+	return invoice, err
+}
+
+// GetIDsByPurchaseOrder returns the IDs of the invoices billed against a purchase order
+func (r *SupplierInvoiceRepository) GetIDsByPurchaseOrder(ctx context.Context, purchaseOrderID int) ([]int, error) {
+	ids := []int{}
+	query := `SELECT invoice_id FROM supplier_invoices WHERE purchase_order_id = $1 ORDER BY invoice_id`
+	err := r.db.SelectContext(ctx, &ids, query, purchaseOrderID)
+	return ids, err
+}
+
+// GetInvoicedQuantities returns the quantity billed for each line of a purchase order across
+// all of its invoices
+func (r *SupplierInvoiceRepository) GetInvoicedQuantities(ctx context.Context, purchaseOrderID int) (map[int]int, error) {
+	rows := []struct {
+		PurchaseOrderItemID int `db:"purchase_order_item_id"`
+		Quantity            int `db:"quantity"`
+	}{}
+	query := `
+		SELECT sii.purchase_order_item_id, SUM(sii.quantity) AS quantity
+		FROM supplier_invoice_items sii
+		JOIN supplier_invoices si ON si.invoice_id = sii.invoice_id
+		WHERE si.purchase_order_id = $1 AND sii.purchase_order_item_id IS NOT NULL
+		GROUP BY sii.purchase_order_item_id`
+	if err := r.db.SelectContext(ctx, &rows, query, purchaseOrderID); err != nil {
+		return nil, err
+	}
+
+	quantities := map[int]int{}
+	for _, row := range rows {
+		quantities[row.PurchaseOrderItemID] = row.Quantity
+	}
+	return quantities, nil
+}
+
+// Create inserts a supplier invoice with its items in a single transaction
+func (r *SupplierInvoiceRepository) Create(ctx context.Context, invoice *models.SupplierInvoice) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	query := `
+		INSERT INTO supplier_invoices (
+			supplier_id, purchase_order_id, invoice_number, invoice_date, total_amount, notes, created_by
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7
+		) RETURNING invoice_id, match_status, created_at, updated_at`
+
+	err = tx.QueryRowContext(
+		ctx,
+		query,
+		invoice.SupplierID,
+		invoice.PurchaseOrderID,
+		invoice.InvoiceNumber,
+		invoice.InvoiceDate,
+		invoice.TotalAmount,
+		invoice.Notes,
+		invoice.CreatedBy,
+	).Scan(&invoice.InvoiceID, &invoice.MatchStatus, &invoice.CreatedAt, &invoice.UpdatedAt)
+	if err != nil {
+		err = translateReferenceError(err)
+		return err
+	}
+
+	itemQuery := `
+		INSERT INTO supplier_invoice_items (invoice_id, purchase_order_item_id, product_id, quantity, unit_price)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING invoice_item_id, line_total`
+
+	for i := range invoice.Items {
+		item := &invoice.Items[i]
+		item.InvoiceID = invoice.InvoiceID
+		err = tx.QueryRowContext(ctx, itemQuery, item.InvoiceID, item.PurchaseOrderItemID, item.ProductID, item.Quantity, item.UnitPrice).
+			Scan(&item.InvoiceItemID, &item.LineTotal)
+		if err != nil {
+			err = translateReferenceError(err)
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Delete removes a supplier invoice with its items and discrepancies
+func (r *SupplierInvoiceRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM supplier_invoices WHERE invoice_id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound("supplier invoice")
+	}
+
+	return nil
+}
+
+// SaveMatch records the outcome of matching an invoice, replacing its earlier discrepancies
+func (r *SupplierInvoiceRepository) SaveMatch(ctx context.Context, invoice *models.SupplierInvoice) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE supplier_invoices SET
+			match_status = $1,
+			matched_at = CASE WHEN $1 = 'pending' THEN NULL ELSE NOW() END,
+			updated_at = NOW()
+		WHERE invoice_id = $2
+		RETURNING matched_at, updated_at`, invoice.MatchStatus, invoice.InvoiceID).Scan(&invoice.MatchedAt, &invoice.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return notFound("supplier invoice")
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM supplier_invoice_discrepancies WHERE invoice_id = $1`, invoice.InvoiceID)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO supplier_invoice_discrepancies (
+			invoice_id, purchase_order_item_id, product_id, kind, ordered_quantity, received_quantity,
+			invoiced_quantity, po_unit_cost, invoiced_unit_price, amount_difference
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+		) RETURNING discrepancy_id, detected_at`
+
+	for i := range invoice.Discrepancies {
+		d := &invoice.Discrepancies[i]
+		d.InvoiceID = invoice.InvoiceID
+		err = tx.QueryRowContext(
+			ctx,
+			query,
+			d.InvoiceID,
+			d.PurchaseOrderItemID,
+			d.ProductID,
+			d.Kind,
+			d.OrderedQuantity,
+			d.ReceivedQuantity,
+			d.InvoicedQuantity,
+			d.POUnitCost,
+			d.InvoicedUnitPrice,
+			d.AmountDifference,
+		).Scan(&d.DiscrepancyID, &d.DetectedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetDiscrepancyReport retrieves the discrepancies of invoices dated within the filter's
+// range, newest invoices first
+func (r *SupplierInvoiceRepository) GetDiscrepancyReport(ctx context.Context, filter DiscrepancyReportFilter) ([]models.DiscrepancyReportRow, error) {
+	conditions := []string{}
+	args := []interface{}{}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("si.invoice_date >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("si.invoice_date <= $%d", len(args)))
+	}
+	if filter.SupplierID != 0 {
+		args = append(args, filter.SupplierID)
+		conditions = append(conditions, fmt.Sprintf("si.supplier_id = $%d", len(args)))
+	}
+	if filter.Kind != "" {
+		args = append(args, filter.Kind)
+		conditions = append(conditions, fmt.Sprintf("d.kind = $%d", len(args)))
+	}
+
+	query := `
+		SELECT d.*, si.invoice_number, si.purchase_order_id, si.supplier_id, s.name AS supplier_name, p.product_name
+		FROM supplier_invoice_discrepancies d
+		JOIN supplier_invoices si ON si.invoice_id = d.invoice_id
+		JOIN suppliers s ON s.supplier_id = si.supplier_id
+		LEFT JOIN products p ON p.product_id = d.product_id` + whereClause(conditions) + `
+		ORDER BY si.invoice_date DESC, si.invoice_id DESC, d.discrepancy_id`
+
+	rows := []models.DiscrepancyReportRow{}
+	err := r.db.SelectContext(ctx, &rows, query, args...)
+	return rows, err
+}
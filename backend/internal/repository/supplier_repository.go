@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// supplierPriceQuery selects supplier product prices with their supplier's name and status
+const supplierPriceQuery = `
+	SELECT sp.*, s.name AS supplier_name, s.active AS supplier_active
+	FROM supplier_products sp
+	JOIN suppliers s ON s.supplier_id = sp.supplier_id`
+
+// SupplierRepository handles database operations for suppliers and their product prices
+type SupplierRepository struct {
+	db *sqlx.DB
+}
+
+// NewSupplierRepository creates a new repository with the provided database connection
+func NewSupplierRepository(db *sqlx.DB) *SupplierRepository {
+	return &SupplierRepository{
+		db: db,
+	}
+}
+
+// GetAll retrieves all suppliers ordered by name
+func (r *SupplierRepository) GetAll(ctx context.Context) ([]models.Supplier, error) {
+	suppliers := []models.Supplier{}
+	query := `SELECT * FROM suppliers ORDER BY name`
+	err := r.db.SelectContext(ctx, &suppliers, query)
+	return suppliers, err
+}
+
+// GetByID retrieves a supplier by ID
+func (r *SupplierRepository) GetByID(ctx context.Context, id int) (models.Supplier, error) {
+	var supplier models.Supplier
+	query := `SELECT * FROM suppliers WHERE supplier_id = $1`
+	err := r.db.GetContext(ctx, &supplier, query, id)
+	if err == sql.ErrNoRows {
+		return supplier, notFound("supplier")
+	}
+	return supplier, err
+}
+
+// Create inserts a new supplier
+func (r *SupplierRepository) Create(ctx context.Context, supplier *models.Supplier) error {
+	query := `
+		INSERT INTO suppliers (name, contact_name, email, phone, address, active)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING supplier_id, created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query, supplier.Name, supplier.ContactName, supplier.Email, supplier.Phone, supplier.Address, supplier.Active).
+		Scan(&supplier.SupplierID, &supplier.CreatedAt, &supplier.UpdatedAt)
+
+	return translateReferenceError(err)
+}
+
+// Update updates an existing supplier
+func (r *SupplierRepository) Update(ctx context.Context, supplier *models.Supplier) error {
+	query := `
+		UPDATE suppliers SET
+			name = $1,
+			contact_name = $2,
+			email = $3,
+			phone = $4,
+			address = $5,
+			active = $6,
+			updated_at = NOW()
+		WHERE supplier_id = $7
+		RETURNING created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query, supplier.Name, supplier.ContactName, supplier.Email, supplier.Phone, supplier.Address, supplier.Active, supplier.SupplierID).
+		Scan(&supplier.CreatedAt, &supplier.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return notFound("supplier")
+	}
+
+	return translateReferenceError(err)
+}
+
+// Delete removes a supplier and its prices. Suppliers with purchase orders cannot be deleted; deactivate them instead.
+func (r *SupplierRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM suppliers WHERE supplier_id = $1`, id)
+	if err != nil {
+		return translateReferenceError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound("supplier")
+	}
+
+	return nil
+}
+
+// GetSupplierPrices retrieves the product prices of a supplier
+func (r *SupplierRepository) GetSupplierPrices(ctx context.Context, supplierID int) ([]models.SupplierPrice, error) {
+	prices := []models.SupplierPrice{}
+	query := supplierPriceQuery + ` WHERE sp.supplier_id = $1 ORDER BY sp.product_id`
+	err := r.db.SelectContext(ctx, &prices, query, supplierID)
+	return prices, err
+}
+
+// GetProductPrices retrieves every supplier's price for a product, cheapest first
+func (r *SupplierRepository) GetProductPrices(ctx context.Context, productID int) ([]models.SupplierPrice, error) {
+	prices := []models.SupplierPrice{}
+	query := supplierPriceQuery + ` WHERE sp.product_id = $1 ORDER BY sp.unit_cost, sp.lead_time_days, s.name`
+	err := r.db.SelectContext(ctx, &prices, query, productID)
+	return prices, err
+}
+
+// GetPricesForProducts retrieves the prices of active suppliers for several products, grouped by product
+func (r *SupplierRepository) GetPricesForProducts(ctx context.Context, productIDs []int) (map[int][]models.SupplierPrice, error) {
+	prices := []models.SupplierPrice{}
+	query := supplierPriceQuery + ` WHERE sp.product_id = ANY($1) AND s.active ORDER BY sp.product_id, sp.unit_cost, sp.lead_time_days, s.name`
+	if err := r.db.SelectContext(ctx, &prices, query, pq.Array(productIDs)); err != nil {
+		return nil, err
+	}
+
+	grouped := map[int][]models.SupplierPrice{}
+	for _, price := range prices {
+		grouped[price.ProductID] = append(grouped[price.ProductID], price)
+	}
+	return grouped, nil
+}
+
+// SavePrice creates or replaces a supplier's price for a product. Marking it preferred clears
+// the preferred flag on the product's other suppliers.
+func (r *SupplierRepository) SavePrice(ctx context.Context, price *models.SupplierProduct) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if price.Preferred {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE supplier_products SET preferred = FALSE, updated_at = NOW()
+			WHERE product_id = $1 AND supplier_id <> $2 AND preferred`, price.ProductID, price.SupplierID)
+		if err != nil {
+			return err
+		}
+	}
+
+	query := `
+		INSERT INTO supplier_products (
+			supplier_id, product_id, supplier_sku, unit_cost, lead_time_days, min_order_qty, preferred
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7
+		)
+		ON CONFLICT (supplier_id, product_id) DO UPDATE SET
+			supplier_sku = EXCLUDED.supplier_sku,
+			unit_cost = EXCLUDED.unit_cost,
+			lead_time_days = EXCLUDED.lead_time_days,
+			min_order_qty = EXCLUDED.min_order_qty,
+			preferred = EXCLUDED.preferred,
+			updated_at = NOW()
+		RETURNING updated_at`
+
+	err = tx.QueryRowContext(
+		ctx,
+		query,
+		price.SupplierID,
+		price.ProductID,
+		price.SupplierSKU,
+		price.UnitCost,
+		price.LeadTimeDays,
+		price.MinOrderQty,
+		price.Preferred,
+	).Scan(&price.UpdatedAt)
+	if err != nil {
+		err = translateReferenceError(err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeletePrice removes a supplier's price for a product
+func (r *SupplierRepository) DeletePrice(ctx context.Context, supplierID, productID int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM supplier_products WHERE supplier_id = $1 AND product_id = $2`, supplierID, productID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound("supplier price")
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// SyncRepository handles database operations for offline sync change tracking
+type SyncRepository struct {
+	db *sqlx.DB
+}
+
+// NewSyncRepository creates a new repository with the provided database connection
+func NewSyncRepository(db *sqlx.DB) *SyncRepository {
+	return &SyncRepository{
+		db: db,
+	}
+}
+
+// GetTombstonesSince retrieves rows deleted after the given time
+func (r *SyncRepository) GetTombstonesSince(ctx context.Context, since time.Time) ([]models.SyncTombstone, error) {
+	tombstones := []models.SyncTombstone{}
+	query := `SELECT * FROM sync_tombstones WHERE deleted_at > $1 ORDER BY deleted_at`
+	err := r.db.SelectContext(ctx, &tombstones, query, since)
+	return tombstones, err
+}
+
+// Cursor returns the time a client can pull changes from on its next sync. Rows are stamped
+// with the start of the transaction that wrote them, so a transaction still running can commit
+// rows older than the database clock; the cursor stays just before the oldest transaction in
+// progress so those rows are not skipped. Rows already pulled may come again.
+func (r *SyncRepository) Cursor(ctx context.Context) (time.Time, error) {
+	var cursor time.Time
+	query := `
+		SELECT COALESCE(MIN(xact_start), NOW()) - INTERVAL '1 microsecond'
+		FROM pg_stat_activity
+		WHERE datname = current_database() AND backend_type = 'client backend' AND xact_start IS NOT NULL`
+	err := r.db.QueryRowContext(ctx, query).Scan(&cursor)
+	return cursor, err
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// trashQueries select the deleted records of each type as trash items. Contacts of deleted
+// customers are left out; they come back when the customer is restored and go with it when
+// it is purged.
+var trashQueries = map[string]string{
+	models.TrashCustomer: `
+		SELECT 'customer' AS type, customer_id AS id, company_name AS name, NULL AS detail, deleted_at
+		FROM customers WHERE deleted_at IS NOT NULL`,
+	models.TrashContact: `
+		SELECT 'contact' AS type, co.contact_id AS id, co.first_name || ' ' || co.last_name AS name,
+			c.company_name AS detail, co.deleted_at
+		FROM contacts co
+		JOIN customers c ON c.customer_id = co.customer_id AND c.deleted_at IS NULL
+		WHERE co.deleted_at IS NOT NULL`,
+	models.TrashProduct: `
+		SELECT 'product' AS type, product_id AS id, product_name AS name, NULL AS detail, deleted_at
+		FROM products WHERE deleted_at IS NOT NULL`,
+}
+
+// TrashRepository lists deleted customers, contacts and products together
+type TrashRepository struct {
+	db *sqlx.DB
+}
+
+// NewTrashRepository creates a new repository with the provided database connection
+func NewTrashRepository(db *sqlx.DB) *TrashRepository {
+	return &TrashRepository{
+		db: db,
+	}
+}
+
+// GetAll retrieves a page of the trash, most recently deleted first, and the total number of
+// items in it. itemType limits it to one type of record; retention is how long items are kept.
+func (r *TrashRepository) GetAll(ctx context.Context, itemType string, retention time.Duration, page Page) ([]models.TrashItem, int, error) {
+	items := []models.TrashItem{}
+
+	types := models.TrashTypes
+	if itemType != "" {
+		types = []string{itemType}
+	}
+	query := ""
+	for _, t := range types {
+		source, ok := trashQueries[t]
+		if !ok {
+			return items, 0, fmt.Errorf("unsupported trash type: %s", t)
+		}
+		if query != "" {
+			query += "\n\t\tUNION ALL"
+		}
+		query += source
+	}
+
+	query = `
+		SELECT trash.*, trash.deleted_at + $1 * INTERVAL '1 second' AS purge_at
+		FROM (` + query + `
+		) AS trash
+		ORDER BY deleted_at DESC, type, id`
+	total, err := selectPage(ctx, r.db, &items, page, query, retention.Seconds())
+	return items, total, err
+}
+
+// GetExpiredIDs returns the records of a type deleted before the given time
+func (r *TrashRepository) GetExpiredIDs(ctx context.Context, itemType string, before time.Time) ([]int, error) {
+	source, ok := trashQueries[itemType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported trash type: %s", itemType)
+	}
+
+	ids := []int{}
+	query := `SELECT id FROM (` + source + `) AS trash WHERE deleted_at < $1 ORDER BY deleted_at`
+	err := r.db.SelectContext(ctx, &ids, query, before)
+	return ids, err
+}
+
+// DeleteArchivedBefore removes the archived copies of bulk-deleted records deleted before the
+// given time, after which they can no longer be restored, and returns how many there were
+func (r *TrashRepository) DeleteArchivedBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM deleted_records WHERE deleted_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
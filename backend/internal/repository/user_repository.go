@@ -3,7 +3,6 @@ package repository
 import (
 	"context"
 	"database/sql"
-	"errors"
 	"time"
 
 	"github.com/Cezzyy/SCMS/backend/internal/models"
@@ -37,7 +36,7 @@ func (r *UserRepository) GetByID(ctx context.Context, id int) (models.User, erro
 	query := `SELECT * FROM users WHERE user_id = $1`
 	err := r.db.GetContext(ctx, &user, query, id)
 	if err == sql.ErrNoRows {
-		return user, errors.New("user not found")
+		return user, notFound("user")
 	}
 	return user, err
 }
@@ -48,7 +47,7 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (models.U
 	query := `SELECT * FROM users WHERE email = $1`
 	err := r.db.GetContext(ctx, &user, query, email)
 	if err == sql.ErrNoRows {
-		return user, errors.New("user not found")
+		return user, notFound("user")
 	}
 	return user, err
 }
@@ -128,7 +127,7 @@ func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
 
 	err := result.Scan(&user.UpdatedAt)
 	if err == sql.ErrNoRows {
-		return errors.New("user not found")
+		return notFound("user")
 	}
 
 	if err != nil {
@@ -158,7 +157,7 @@ func (r *UserRepository) UpdatePassword(ctx context.Context, userID int, passwor
 	err := r.db.QueryRowContext(ctx, query, passwordHash, now, userID).Scan(&updatedAt)
 
 	if err == sql.ErrNoRows {
-		return errors.New("user not found")
+		return notFound("user")
 	}
 
 	return err
@@ -177,6 +176,31 @@ func (r *UserRepository) UpdateLastLogin(ctx context.Context, userID int) error
 	return err
 }
 
+// Lock blocks logins for a user until the given time
+func (r *UserRepository) Lock(ctx context.Context, userID int, until time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET locked_until = $1 WHERE user_id = $2`, until, userID)
+	return err
+}
+
+// Unlock clears a user's lockout
+func (r *UserRepository) Unlock(ctx context.Context, userID int) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE users SET locked_until = NULL WHERE user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound("user")
+	}
+
+	return nil
+}
+
 // Delete removes a user by ID
 func (r *UserRepository) Delete(ctx context.Context, id int) error {
 	// Using PostgreSQL's WITH clause for the deletion and getting count in one query
@@ -195,7 +219,7 @@ func (r *UserRepository) Delete(ctx context.Context, id int) error {
 	}
 
 	if count == 0 {
-		return errors.New("user not found")
+		return notFound("user")
 	}
 
 	return nil
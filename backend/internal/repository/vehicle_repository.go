@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// VehicleRepository handles database operations for delivery vehicles
+type VehicleRepository struct {
+	db *sqlx.DB
+}
+
+// NewVehicleRepository creates a new repository with the provided database connection
+func NewVehicleRepository(db *sqlx.DB) *VehicleRepository {
+	return &VehicleRepository{
+		db: db,
+	}
+}
+
+// GetAll retrieves all vehicles ordered by plate number
+func (r *VehicleRepository) GetAll(ctx context.Context) ([]models.Vehicle, error) {
+	vehicles := []models.Vehicle{}
+	query := `SELECT * FROM vehicles ORDER BY plate_number`
+	err := r.db.SelectContext(ctx, &vehicles, query)
+	return vehicles, err
+}
+
+// GetByID retrieves a vehicle by ID
+func (r *VehicleRepository) GetByID(ctx context.Context, id int) (models.Vehicle, error) {
+	var vehicle models.Vehicle
+	query := `SELECT * FROM vehicles WHERE vehicle_id = $1`
+	err := r.db.GetContext(ctx, &vehicle, query, id)
+	if err == sql.ErrNoRows {
+		return vehicle, notFound("vehicle")
+	}
+	return vehicle, err
+}
+
+// Create inserts a new vehicle
+func (r *VehicleRepository) Create(ctx context.Context, vehicle *models.Vehicle) error {
+	query := `
+		INSERT INTO vehicles (plate_number, description, capacity_kg, capacity_m3, active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING vehicle_id, created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query, vehicle.PlateNumber, vehicle.Description, vehicle.CapacityKg, vehicle.CapacityM3, vehicle.Active).
+		Scan(&vehicle.VehicleID, &vehicle.CreatedAt, &vehicle.UpdatedAt)
+
+	return translateReferenceError(err)
+}
+
+// Update updates an existing vehicle
+func (r *VehicleRepository) Update(ctx context.Context, vehicle *models.Vehicle) error {
+	query := `
+		UPDATE vehicles SET
+			plate_number = $1,
+			description = $2,
+			capacity_kg = $3,
+			capacity_m3 = $4,
+			active = $5,
+			updated_at = NOW()
+		WHERE vehicle_id = $6
+		RETURNING created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query, vehicle.PlateNumber, vehicle.Description, vehicle.CapacityKg, vehicle.CapacityM3, vehicle.Active, vehicle.VehicleID).
+		Scan(&vehicle.CreatedAt, &vehicle.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return notFound("vehicle")
+	}
+
+	return translateReferenceError(err)
+}
+
+// Delete removes a vehicle. Vehicles with delivery assignments cannot be deleted; deactivate them instead.
+func (r *VehicleRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM vehicles WHERE vehicle_id = $1`, id)
+	if err != nil {
+		return translateReferenceError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound("vehicle")
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// WatchRepository handles database operations for users' watches on records
+type WatchRepository struct {
+	db *sqlx.DB
+}
+
+// NewWatchRepository creates a new repository with the provided database connection
+func NewWatchRepository(db *sqlx.DB) *WatchRepository {
+	return &WatchRepository{
+		db: db,
+	}
+}
+
+// Watcher is a watch with the name and email address of the user who set it
+type Watcher struct {
+	models.RecordWatch
+	UserName  string `db:"user_name"`
+	UserEmail string `db:"user_email"`
+}
+
+// Save sets a user's watch on a record, replacing the fields and email setting of an
+// existing one
+func (r *WatchRepository) Save(ctx context.Context, watch *models.RecordWatch) error {
+	query := `
+		INSERT INTO record_watches (user_id, entity, entity_id, fields, email)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, entity, entity_id) DO UPDATE
+		SET fields = EXCLUDED.fields, email = EXCLUDED.email
+		RETURNING record_watch_id, created_at`
+
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		watch.UserID,
+		watch.Entity,
+		watch.EntityID,
+		watch.Fields,
+		watch.Email,
+	).Scan(&watch.RecordWatchID, &watch.CreatedAt)
+}
+
+// Delete removes a user's watch on a record
+func (r *WatchRepository) Delete(ctx context.Context, userID int, entity string, entityID int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM record_watches WHERE user_id = $1 AND entity = $2 AND entity_id = $3`, userID, entity, entityID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return notFound("watch")
+	}
+	return nil
+}
+
+// GetByUser retrieves a user's watches, newest first
+func (r *WatchRepository) GetByUser(ctx context.Context, userID int) ([]models.RecordWatch, error) {
+	watches := []models.RecordWatch{}
+	query := `SELECT * FROM record_watches WHERE user_id = $1 ORDER BY created_at DESC, record_watch_id DESC`
+	err := r.db.SelectContext(ctx, &watches, query, userID)
+	return watches, err
+}
+
+// GetWatchers retrieves the watches on a record with the users who set them
+func (r *WatchRepository) GetWatchers(ctx context.Context, entity string, entityID int) ([]Watcher, error) {
+	watchers := []Watcher{}
+	query := `
+		SELECT w.*, TRIM(u.first_name || ' ' || u.last_name) AS user_name, u.email AS user_email
+		FROM record_watches w
+		JOIN users u ON u.user_id = w.user_id
+		WHERE w.entity = $1 AND w.entity_id = $2
+		ORDER BY w.record_watch_id`
+	err := r.db.SelectContext(ctx, &watchers, query, entity, entityID)
+	return watchers, err
+}
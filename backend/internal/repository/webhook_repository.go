@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// WebhookRepository handles database operations for webhook endpoints and their deliveries
+type WebhookRepository struct {
+	db *sqlx.DB
+}
+
+// NewWebhookRepository creates a new repository with the provided database connection
+func NewWebhookRepository(db *sqlx.DB) *WebhookRepository {
+	return &WebhookRepository{
+		db: db,
+	}
+}
+
+// GetAll retrieves every webhook endpoint
+func (r *WebhookRepository) GetAll(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	endpoints := []models.WebhookEndpoint{}
+	err := r.db.SelectContext(ctx, &endpoints, `SELECT * FROM webhook_endpoints ORDER BY webhook_endpoint_id`)
+	return endpoints, err
+}
+
+// GetByID retrieves a webhook endpoint by ID
+func (r *WebhookRepository) GetByID(ctx context.Context, id int) (models.WebhookEndpoint, error) {
+	var endpoint models.WebhookEndpoint
+	err := r.db.GetContext(ctx, &endpoint, `SELECT * FROM webhook_endpoints WHERE webhook_endpoint_id = $1`, id)
+	if err == sql.ErrNoRows {
+		return endpoint, notFound("webhook endpoint")
+	}
+	return endpoint, err
+}
+
+// Create registers a webhook endpoint
+func (r *WebhookRepository) Create(ctx context.Context, endpoint *models.WebhookEndpoint) error {
+	query := `
+		INSERT INTO webhook_endpoints (url, secret, events, description, active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING webhook_endpoint_id, created_at, updated_at`
+
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		endpoint.URL,
+		endpoint.Secret,
+		endpoint.Events,
+		endpoint.Description,
+		endpoint.Active,
+	).Scan(&endpoint.WebhookEndpointID, &endpoint.CreatedAt, &endpoint.UpdatedAt)
+}
+
+// Update changes a webhook endpoint's URL, events, description and whether it is active
+func (r *WebhookRepository) Update(ctx context.Context, endpoint *models.WebhookEndpoint) error {
+	query := `
+		UPDATE webhook_endpoints SET
+			url = $1, events = $2, description = $3, active = $4, updated_at = NOW()
+		WHERE webhook_endpoint_id = $5
+		RETURNING updated_at`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		endpoint.URL,
+		endpoint.Events,
+		endpoint.Description,
+		endpoint.Active,
+		endpoint.WebhookEndpointID,
+	).Scan(&endpoint.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return notFound("webhook endpoint")
+	}
+	return err
+}
+
+// Delete removes a webhook endpoint and its delivery log
+func (r *WebhookRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webhook_endpoints WHERE webhook_endpoint_id = $1`, id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return notFound("webhook endpoint")
+	}
+	return nil
+}
+
+// CreateDeliveries queues an event for every active endpoint subscribed to it and returns
+// how many were queued
+func (r *WebhookRepository) CreateDeliveries(ctx context.Context, event, eventID string, payload json.RawMessage) (int64, error) {
+	query := `
+		INSERT INTO webhook_deliveries (webhook_endpoint_id, event, event_id, payload, status, next_attempt_at)
+		SELECT webhook_endpoint_id, $1, $2, $3::jsonb, $4, NOW() FROM webhook_endpoints
+		WHERE active AND events ? $1`
+
+	result, err := r.db.ExecContext(ctx, query, event, eventID, string(payload), models.WebhookDeliveryPending)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// GetDeliveries retrieves an endpoint's deliveries, newest first
+func (r *WebhookRepository) GetDeliveries(ctx context.Context, endpointID int, page Page) ([]models.WebhookDelivery, int, error) {
+	deliveries := []models.WebhookDelivery{}
+	query := `
+		SELECT * FROM webhook_deliveries
+		WHERE webhook_endpoint_id = $1
+		ORDER BY created_at DESC, webhook_delivery_id DESC`
+	total, err := selectPage(ctx, r.db, &deliveries, page, query, endpointID)
+	return deliveries, total, err
+}
+
+// GetDelivery retrieves one of an endpoint's deliveries
+func (r *WebhookRepository) GetDelivery(ctx context.Context, endpointID, id int) (models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.db.GetContext(ctx, &delivery, `
+		SELECT * FROM webhook_deliveries WHERE webhook_delivery_id = $1 AND webhook_endpoint_id = $2`, id, endpointID)
+	if err == sql.ErrNoRows {
+		return delivery, notFound("webhook delivery")
+	}
+	return delivery, err
+}
+
+// Redeliver queues another delivery of a delivery's event to the same endpoint, leaving the
+// original in the log
+func (r *WebhookRepository) Redeliver(ctx context.Context, delivery models.WebhookDelivery) (models.WebhookDelivery, error) {
+	var redelivery models.WebhookDelivery
+	query := `
+		INSERT INTO webhook_deliveries (webhook_endpoint_id, event, event_id, payload, status, next_attempt_at)
+		VALUES ($1, $2, $3, $4::jsonb, $5, NOW())
+		RETURNING *`
+	err := r.db.GetContext(ctx, &redelivery, query,
+		delivery.WebhookEndpointID, delivery.Event, delivery.EventID, string(delivery.Payload), models.WebhookDeliveryPending)
+	return redelivery, err
+}
+
+// WebhookTask is a due delivery with the endpoint it goes to
+type WebhookTask struct {
+	models.WebhookDelivery
+	URL    string `db:"url"`
+	Secret string `db:"secret"`
+}
+
+// ClaimDue takes up to limit pending deliveries that are due and holds them for lease, so
+// other servers sharing the database skip them while they are being sent
+func (r *WebhookRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]WebhookTask, error) {
+	tasks := []WebhookTask{}
+	query := `
+		WITH due AS (
+			SELECT webhook_delivery_id FROM webhook_deliveries
+			WHERE status = $1 AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d SET next_attempt_at = NOW() + $3 * INTERVAL '1 second'
+		FROM due, webhook_endpoints e
+		WHERE d.webhook_delivery_id = due.webhook_delivery_id AND e.webhook_endpoint_id = d.webhook_endpoint_id
+		RETURNING d.*, e.url, e.secret`
+	err := r.db.SelectContext(ctx, &tasks, query, models.WebhookDeliveryPending, limit, lease.Seconds())
+	return tasks, err
+}
+
+// RecordAttempt saves the outcome of sending a delivery: its status, attempts so far, when it
+// is next tried if still pending, and the endpoint's response or the error
+func (r *WebhookRepository) RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries SET
+			status = $1, attempts = $2, next_attempt_at = $3, response_status = $4,
+			response_body = $5, error = $6, delivered_at = $7
+		WHERE webhook_delivery_id = $8`
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.ResponseStatus,
+		delivery.ResponseBody,
+		delivery.Error,
+		delivery.DeliveredAt,
+		delivery.WebhookDeliveryID,
+	)
+	return err
+}
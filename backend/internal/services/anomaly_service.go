@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// AnomalyService periodically looks for unusual days and notifies every user of them: sales
+// far below their trend, and stock that disappeared without orders taking it. How far is
+// too far is set by the anomaly_* rules.
+type AnomalyService struct {
+	anomalyRepo      *repository.AnomalyRepository
+	notificationRepo *repository.NotificationRepository
+	rules            *RulesService
+	interval         time.Duration
+	stop             chan struct{}
+}
+
+// NewAnomalyService creates a new anomaly check that runs every ANOMALY_INTERVAL_HOURS hours
+// (default 24). Stock is compared with what it was on the previous run, so a shorter interval
+// catches drops sooner.
+func NewAnomalyService(anomalyRepo *repository.AnomalyRepository, notificationRepo *repository.NotificationRepository, rules *RulesService) *AnomalyService {
+	interval := time.Duration(envFloat("ANOMALY_INTERVAL_HOURS", 24) * float64(time.Hour))
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+
+	return &AnomalyService{
+		anomalyRepo:      anomalyRepo,
+		notificationRepo: notificationRepo,
+		rules:            rules,
+		interval:         interval,
+		stop:             make(chan struct{}),
+	}
+}
+
+// Start checks once at startup and then on every interval
+func (s *AnomalyService) Start() {
+	goBackground(func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.run()
+			select {
+			case <-ticker.C:
+			case <-s.stop:
+				return
+			}
+		}
+	})
+}
+
+// Stop ends the schedule after any run in progress has finished
+func (s *AnomalyService) Stop() {
+	close(s.stop)
+}
+
+// run checks sales and stock
+func (s *AnomalyService) run() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	s.checkSales(ctx)
+	s.checkStock(ctx)
+}
+
+// checkSales flags yesterday's sales when they fall more than anomaly_sales_sigma standard
+// deviations below the trend of the anomaly_sales_history_days days before
+func (s *AnomalyService) checkSales(ctx context.Context) {
+	sigma := s.rules.Float(ctx, models.RuleAnomalySalesSigma)
+	if sigma <= 0 {
+		return
+	}
+
+	sales, err := s.anomalyRepo.GetDailySales(ctx, s.rules.Int(ctx, models.RuleAnomalySalesHistoryDays)+1)
+	if err != nil {
+		log.Printf("Failed to load daily sales for the anomaly check: %v", err)
+		return
+	}
+	if len(sales) < 4 {
+		return
+	}
+
+	history := make([]float64, len(sales)-1)
+	for i, day := range sales[:len(sales)-1] {
+		history[i] = day.TotalAmount
+	}
+	yesterday := sales[len(sales)-1]
+	expected, deviation := salesTrend(history)
+
+	// A trend at or below zero, or days that never vary, say nothing of what a normal day is
+	if expected <= 0 || deviation == 0 || yesterday.TotalAmount >= expected-sigma*deviation {
+		return
+	}
+
+	s.raise(ctx, "Sales below trend", models.Anomaly{
+		Metric:   models.AnomalySalesDrop,
+		Day:      yesterday.Day,
+		Observed: yesterday.TotalAmount,
+		Expected: expected,
+		Message: fmt.Sprintf("Sales on %s were %.2f, %.1f standard deviations below the trend of %.2f",
+			yesterday.Day.Format("Jan 2, 2006"), yesterday.TotalAmount, (expected-yesterday.TotalAmount)/deviation, expected),
+	})
+}
+
+// checkStock flags products that lost at least anomaly_stock_drop_pct percent of their stock
+// since the last run without orders taking it. Stock is snapshotted on every run, even with
+// the check off, so turning it on does not flag changes made in the meantime.
+func (s *AnomalyService) checkStock(ctx context.Context) {
+	drops, err := s.anomalyRepo.CheckStock(ctx)
+	if err != nil {
+		log.Printf("Failed to check stock for anomalies: %v", err)
+		return
+	}
+
+	pct := s.rules.Float(ctx, models.RuleAnomalyStockDropPct)
+	if pct <= 0 {
+		return
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	for _, drop := range drops {
+		available := drop.PreviousStock + drop.Received
+		if float64(drop.Unexplained()) < pct/100*float64(available) {
+			continue
+		}
+
+		inventoryID := drop.InventoryID
+		s.raise(ctx, "Unexplained stock drop: "+drop.ProductName, models.Anomaly{
+			Metric:      models.AnomalyStockDrop,
+			InventoryID: &inventoryID,
+			Day:         today,
+			Observed:    float64(drop.CurrentStock),
+			Expected:    float64(drop.Expected()),
+			Message: fmt.Sprintf("Stock of %s fell to %d since %s, when orders and receipts leave %d: %d units are unaccounted for",
+				drop.ProductName, drop.CurrentStock, drop.TakenAt.Format("Jan 2, 2006 15:04"), drop.Expected(), drop.Unexplained()),
+		})
+	}
+}
+
+// raise records an anomaly and notifies every user of it, unless it was already flagged
+func (s *AnomalyService) raise(ctx context.Context, title string, anomaly models.Anomaly) {
+	isNew, err := s.anomalyRepo.Record(ctx, &anomaly)
+	if err != nil {
+		log.Printf("Failed to record %s anomaly: %v", anomaly.Metric, err)
+		return
+	}
+	if !isNew {
+		return
+	}
+
+	notification := models.Notification{
+		Type:    models.NotificationAnomaly,
+		Title:   title,
+		Message: anomaly.Message,
+	}
+	if anomaly.InventoryID != nil {
+		entity := models.AuditEntityInventory
+		notification.EntityType = &entity
+		notification.EntityID = anomaly.InventoryID
+	}
+	if _, err := s.notificationRepo.CreateForAllUsers(ctx, notification, nil); err != nil {
+		log.Printf("Failed to send notifications of %s anomaly %d: %v", anomaly.Metric, anomaly.AnomalyID, err)
+		return
+	}
+	log.Printf("Flagged %s anomaly %d: %s", anomaly.Metric, anomaly.AnomalyID, anomaly.Message)
+}
+
+// salesTrend fits a least squares line to a run of daily sales and returns the sales it
+// predicts for the following day, along with the standard deviation of the days around the
+// line
+func salesTrend(history []float64) (expected, deviation float64) {
+	n := float64(len(history))
+	var sumX, sumY, sumXY, sumXX float64
+	for i, y := range history {
+		x := float64(i)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	slope := (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+	intercept := (sumY - slope*sumX) / n
+
+	var squares float64
+	for i, y := range history {
+		residual := y - (intercept + slope*float64(i))
+		squares += residual * residual
+	}
+	// Two degrees of freedom go to fitting the line
+	return intercept + slope*n, math.Sqrt(squares / (n - 2))
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// ErrInvalidAPIKey is returned for unknown or revoked API keys
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// QuotaStatus describes where a key stands against today's quota
+type QuotaStatus struct {
+	Limit     int       `json:"limit"`
+	Used      int       `json:"used"`
+	Remaining int       `json:"remaining"`
+	ResetAt   time.Time `json:"resets_at"`
+	Exceeded  bool      `json:"exceeded"`
+}
+
+// APIKeyUsageReport summarizes a key's consumption for partners
+type APIKeyUsageReport struct {
+	APIKeyID   int                  `json:"api_key_id"`
+	Name       string               `json:"name"`
+	DailyQuota int                  `json:"daily_quota"`
+	Today      QuotaStatus          `json:"today"`
+	History    []models.APIKeyUsage `json:"history"`
+}
+
+// APIKeyService issues API keys and enforces their daily quotas
+type APIKeyService struct {
+	apiKeyRepo *repository.APIKeyRepository
+}
+
+// NewAPIKeyService creates a new API key service
+func NewAPIKeyService(apiKeyRepo *repository.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo: apiKeyRepo,
+	}
+}
+
+// Create issues a new API key and returns it with its secret. The secret is only
+// available at creation time; only its hash is stored.
+func (s *APIKeyService) Create(ctx context.Context, name string, dailyQuota int, scopes models.APIScopes) (*models.APIKey, string, error) {
+	secret, err := generateToken("sk_")
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := &models.APIKey{
+		Name:       name,
+		KeyHash:    hashToken(secret),
+		KeyPrefix:  secret[:11],
+		DailyQuota: dailyQuota,
+		Scopes:     scopes,
+	}
+	if err := s.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+
+	return key, secret, nil
+}
+
+// Authenticate resolves an API key secret to an active key
+func (s *APIKeyService) Authenticate(ctx context.Context, secret string) (*models.APIKey, error) {
+	key, err := s.apiKeyRepo.GetByKeyHash(ctx, hashToken(secret))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	if key.RevokedAt != nil {
+		return nil, ErrInvalidAPIKey
+	}
+	return &key, nil
+}
+
+// Consume counts a request against the key's daily quota and reports whether it is allowed.
+// Requests over the quota are still counted so partners can see how far over they went.
+func (s *APIKeyService) Consume(ctx context.Context, key *models.APIKey) (QuotaStatus, error) {
+	used, err := s.apiKeyRepo.IncrementUsage(ctx, key.APIKeyID)
+	if err != nil {
+		return QuotaStatus{}, err
+	}
+	if err := s.apiKeyRepo.Touch(ctx, key.APIKeyID); err != nil {
+		log.Printf("Failed to update last used for API key %d: %v", key.APIKeyID, err)
+	}
+	return quotaStatus(key.DailyQuota, used), nil
+}
+
+// Usage builds a usage report for the last number of days
+func (s *APIKeyService) Usage(ctx context.Context, id int, days int) (*APIKeyUsageReport, error) {
+	key, err := s.apiKeyRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.apiKeyRepo.GetUsage(ctx, id, days)
+	if err != nil {
+		return nil, err
+	}
+
+	today := time.Now().UTC().Format("2006-01-02")
+	todayUsed := 0
+	for i := range history {
+		history[i].Allowed, history[i].Rejected = splitUsage(key.DailyQuota, history[i].RequestCount)
+		if history[i].UsageDate.Format("2006-01-02") == today {
+			todayUsed = history[i].RequestCount
+		}
+	}
+
+	return &APIKeyUsageReport{
+		APIKeyID:   key.APIKeyID,
+		Name:       key.Name,
+		DailyQuota: key.DailyQuota,
+		Today:      quotaStatus(key.DailyQuota, todayUsed),
+		History:    history,
+	}, nil
+}
+
+// quotaStatus computes the quota state for a number of requests made today. A quota of 0 is unlimited.
+func quotaStatus(limit, used int) QuotaStatus {
+	now := time.Now().UTC()
+	status := QuotaStatus{
+		Limit:   limit,
+		Used:    used,
+		ResetAt: time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC),
+	}
+	if limit > 0 {
+		status.Remaining = limit - used
+		if status.Remaining < 0 {
+			status.Remaining = 0
+		}
+		status.Exceeded = used > limit
+	}
+	return status
+}
+
+// splitUsage splits a day's request count into allowed and rejected requests
+func splitUsage(limit, count int) (int, int) {
+	if limit <= 0 || count <= limit {
+		return count, 0
+	}
+	return limit, count - limit
+}
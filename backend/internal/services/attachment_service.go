@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+var (
+	// ErrAttachmentEmpty is returned for uploads without content
+	ErrAttachmentEmpty = errors.New("attachment is empty")
+
+	// ErrAttachmentType is returned for uploads whose content is not an allowed file type
+	ErrAttachmentType = errors.New("attachment must be a PNG, JPEG, WebP or GIF image or a PDF")
+)
+
+// attachmentTypes are the content types accepted for uploads, detected from the file content
+var attachmentTypes = []string{"image/png", "image/jpeg", "image/webp", "image/gif", "application/pdf"}
+
+// AttachmentTooLargeError is returned when an upload exceeds the size limit
+type AttachmentTooLargeError struct {
+	MaxBytes int64
+}
+
+// Error describes the size limit
+func (e *AttachmentTooLargeError) Error() string {
+	return fmt.Sprintf("attachment exceeds the %.1f MB limit", float64(e.MaxBytes)/(1<<20))
+}
+
+// AttachmentService stores files uploaded against business records
+type AttachmentService struct {
+	attachmentRepo *repository.AttachmentRepository
+	maxBytes       int64
+	onSave         func(attachment models.Attachment)
+}
+
+// NewAttachmentService creates a new attachment service. ATTACHMENT_MAX_MB sets the
+// largest accepted upload (default 5).
+func NewAttachmentService(attachmentRepo *repository.AttachmentRepository) *AttachmentService {
+	return &AttachmentService{
+		attachmentRepo: attachmentRepo,
+		maxBytes:       int64(envFloat("ATTACHMENT_MAX_MB", 5) * (1 << 20)),
+	}
+}
+
+// MaxBytes returns the largest upload accepted, in bytes
+func (s *AttachmentService) MaxBytes() int64 {
+	return s.maxBytes
+}
+
+// OnSave sets a function told of every attachment stored, such as
+// PreviewService.AttachmentSaved. It must be set before attachments are saved.
+func (s *AttachmentService) OnSave(fn func(attachment models.Attachment)) {
+	s.onSave = fn
+}
+
+// Save validates an uploaded file and stores it against a record. The content type is
+// detected from the content rather than trusted from the client.
+func (s *AttachmentService) Save(ctx context.Context, entity string, entityID int, kind string, file *multipart.FileHeader, uploadedBy *int) (*models.Attachment, error) {
+	if file.Size > s.maxBytes {
+		return nil, &AttachmentTooLargeError{MaxBytes: s.maxBytes}
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	// Read one byte past the limit so oversized content is caught even if the header lied
+	content, err := io.ReadAll(io.LimitReader(src, s.maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) == 0 {
+		return nil, ErrAttachmentEmpty
+	}
+	if int64(len(content)) > s.maxBytes {
+		return nil, &AttachmentTooLargeError{MaxBytes: s.maxBytes}
+	}
+
+	contentType := http.DetectContentType(content)
+	if !containsString(attachmentTypes, contentType) {
+		return nil, ErrAttachmentType
+	}
+
+	attachment := &models.Attachment{
+		Entity:      entity,
+		EntityID:    entityID,
+		Kind:        kind,
+		FileName:    attachmentFileName(file.Filename, kind),
+		ContentType: contentType,
+		SizeBytes:   len(content),
+		Content:     content,
+		UploadedBy:  uploadedBy,
+	}
+	if err := s.attachmentRepo.Create(ctx, attachment); err != nil {
+		return nil, err
+	}
+	if s.onSave != nil {
+		s.onSave(*attachment)
+	}
+	return attachment, nil
+}
+
+// Get retrieves an attachment with its content
+func (s *AttachmentService) Get(ctx context.Context, id int) (models.Attachment, error) {
+	return s.attachmentRepo.GetByID(ctx, id)
+}
+
+// List returns the attachments of a record without their content
+func (s *AttachmentService) List(ctx context.Context, entity string, entityID int) ([]models.Attachment, error) {
+	return s.attachmentRepo.GetByEntity(ctx, entity, entityID)
+}
+
+// Delete removes an attachment
+func (s *AttachmentService) Delete(ctx context.Context, id int) error {
+	return s.attachmentRepo.Delete(ctx, id)
+}
+
+// attachmentFileName strips any client path from an uploaded file name, falling back to the kind
+func attachmentFileName(name, kind string) string {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "" || name == "." || name == "/" {
+		return kind
+	}
+	return name
+}
+
+// containsString reports whether a list contains a value
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
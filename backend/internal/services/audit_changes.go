@@ -0,0 +1,108 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// auditIgnoredFields change on every write and are left out of a record's changes
+var auditIgnoredFields = map[string]bool{"updated_at": true, "version": true}
+
+// RecordChange is one audited change to a record: who made it and when, and the fields it
+// changed. Fields of nested objects are named by path, e.g. "quotation.total_amount"; fields
+// a record was created with have no From and fields of a deleted record no To.
+type RecordChange struct {
+	AuditLogID     int           `json:"audit_log_id"`
+	Action         string        `json:"action"`
+	UserID         *int          `json:"user_id,omitempty"`
+	UserName       *string       `json:"user_name,omitempty"`
+	ImpersonatorID *int          `json:"impersonator_id,omitempty"`
+	CreatedAt      time.Time     `json:"created_at"`
+	Changes        []FieldChange `json:"changes"`
+}
+
+// RecordChanges describes audit log entries as the fields each one changed
+func RecordChanges(entries []repository.AuditEntry) []RecordChange {
+	changes := make([]RecordChange, len(entries))
+	for i, entry := range entries {
+		changes[i] = RecordChange{
+			AuditLogID:     entry.AuditLogID,
+			Action:         entry.Action,
+			UserID:         entry.UserID,
+			UserName:       entry.UserName,
+			ImpersonatorID: entry.ImpersonatorID,
+			CreatedAt:      entry.CreatedAt,
+			Changes:        DiffSnapshots(entry.Before, entry.After),
+		}
+	}
+	return changes
+}
+
+// DiffSnapshots compares two JSON snapshots of a record field by field, descending into
+// nested objects, and returns the fields that differ sorted by name
+func DiffSnapshots(before, after json.RawMessage) []FieldChange {
+	fields := map[string][2]interface{}{}
+	flattenSnapshot(fields, "", decodeSnapshot(before), 0)
+	flattenSnapshot(fields, "", decodeSnapshot(after), 1)
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	changes := []FieldChange{}
+	for _, name := range names {
+		values := fields[name]
+		if reflect.DeepEqual(values[0], values[1]) {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: name, From: values[0], To: values[1]})
+	}
+	return changes
+}
+
+// decodeSnapshot decodes a snapshot keeping numbers as written
+func decodeSnapshot(raw json.RawMessage) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if decoder.Decode(&value) != nil {
+		return nil
+	}
+	return value
+}
+
+// flattenSnapshot stores the fields of a snapshot by path on one side of fields. Values
+// other than objects, lists included, are compared whole.
+func flattenSnapshot(fields map[string][2]interface{}, path string, value interface{}, side int) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		if path == "" {
+			return
+		}
+		values := fields[path]
+		values[side] = value
+		fields[path] = values
+		return
+	}
+
+	for key, child := range object {
+		if auditIgnoredFields[key] {
+			continue
+		}
+		name := key
+		if path != "" {
+			name = path + "." + key
+		}
+		flattenSnapshot(fields, name, child, side)
+	}
+}
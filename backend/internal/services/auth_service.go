@@ -3,78 +3,428 @@ package services
 import (
 	"context"
 	"errors"
+	"log"
+	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Cezzyy/SCMS/backend/internal/models"
 	"github.com/Cezzyy/SCMS/backend/internal/repository"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// SessionCookieName is the cookie that carries the session token
+const SessionCookieName = "session_id"
+
+// ErrInvalidSession is returned for unknown, expired or logged-out sessions
+var ErrInvalidSession = errors.New("invalid or expired session")
+
+// ErrInvalidCredentials is returned when an email and password do not match a user
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Error codes reported when a login is refused because of repeated failures
+const (
+	LockoutAccountLocked   = "account_locked"
+	LockoutTooManyAttempts = "too_many_attempts"
+)
+
+// loginAttemptRetention is how long login attempts are kept
+const loginAttemptRetention = 30 * 24 * time.Hour
+
+// LockoutError is returned when a login is refused because the account is locked
+// or the client address has failed too often
+type LockoutError struct {
+	Code    string
+	RetryAt time.Time
+}
+
+func (e *LockoutError) Error() string {
+	if e.Code == LockoutTooManyAttempts {
+		return "too many failed login attempts, try again later"
+	}
+	return "account is locked after repeated failed logins"
+}
+
 // AuthService handles authentication operations
 type AuthService struct {
-	userRepo *repository.UserRepository
+	userRepo         *repository.UserRepository
+	sessionRepo      *repository.SessionRepository
+	loginAttemptRepo *repository.LoginAttemptRepository
+	sessionTTL       time.Duration
+	sessionMaxAge    time.Duration
+	jwtSecret        []byte
+	jwtTTL           time.Duration
+	maxFailures      int
+	maxIPFailures    int
+	failureWindow    time.Duration
+	lockoutDuration  time.Duration
 }
 
-// NewAuthService creates a new authentication service
-func NewAuthService(userRepo *repository.UserRepository) *AuthService {
+// NewAuthService creates a new authentication service. Sessions expire after SESSION_TTL_HOURS
+// (default 24) without activity, and never outlive SESSION_MAX_LIFETIME_HOURS (default 168) after login.
+// When JWT_SECRET is set, logins also issue a signed bearer token valid for JWT_TTL_MINUTES (default 60).
+// An account is locked for LOGIN_LOCKOUT_MINUTES (default 15) after LOGIN_MAX_FAILURES (default 5) failed
+// logins within LOGIN_FAILURE_WINDOW_MINUTES (default 15); an address that fails LOGIN_MAX_FAILURES_PER_IP
+// (default 20) times within the window must wait until its oldest failure falls out of the window.
+func NewAuthService(
+	userRepo *repository.UserRepository,
+	sessionRepo *repository.SessionRepository,
+	loginAttemptRepo *repository.LoginAttemptRepository,
+) *AuthService {
+	sessionTTL := time.Duration(envFloat("SESSION_TTL_HOURS", 24) * float64(time.Hour))
+	if sessionTTL <= 0 {
+		sessionTTL = 24 * time.Hour
+	}
+
+	sessionMaxAge := time.Duration(envFloat("SESSION_MAX_LIFETIME_HOURS", 168) * float64(time.Hour))
+	if sessionMaxAge < sessionTTL {
+		sessionMaxAge = sessionTTL
+	}
+
+	jwtTTL := time.Duration(envFloat("JWT_TTL_MINUTES", 60) * float64(time.Minute))
+	if jwtTTL <= 0 {
+		jwtTTL = time.Hour
+	}
+
+	var jwtSecret []byte
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		jwtSecret = []byte(secret)
+	}
+
 	return &AuthService{
-		userRepo: userRepo,
+		userRepo:         userRepo,
+		sessionRepo:      sessionRepo,
+		loginAttemptRepo: loginAttemptRepo,
+		sessionTTL:       sessionTTL,
+		sessionMaxAge:    sessionMaxAge,
+		jwtSecret:        jwtSecret,
+		jwtTTL:           jwtTTL,
+		maxFailures:      int(envFloat("LOGIN_MAX_FAILURES", 5)),
+		maxIPFailures:    int(envFloat("LOGIN_MAX_FAILURES_PER_IP", 20)),
+		failureWindow:    time.Duration(envFloat("LOGIN_FAILURE_WINDOW_MINUTES", 15) * float64(time.Minute)),
+		lockoutDuration:  time.Duration(envFloat("LOGIN_LOCKOUT_MINUTES", 15) * float64(time.Minute)),
 	}
 }
 
 // LoginRequest contains the credentials submitted by the user
 type LoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email     string `json:"email" validate:"required"`
+	Password  string `json:"password" validate:"required"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
 }
 
-// AuthResponse contains user data and session information
+// AuthResponse contains user data and session information. The access token fields are
+// only set when JWT bearer authentication is enabled.
 type AuthResponse struct {
-	UserID    int       `json:"user_id"`
-	Email     string    `json:"email"`
-	FirstName string    `json:"first_name"`
-	LastName  string    `json:"last_name"`
-	Role      string    `json:"role"`
-	SessionID string    `json:"session_id"`
-	ExpiresAt time.Time `json:"expires_at"`
+	UserID          int        `json:"user_id"`
+	Email           string     `json:"email"`
+	FirstName       string     `json:"first_name"`
+	LastName        string     `json:"last_name"`
+	Role            string     `json:"role"`
+	SessionID       string     `json:"session_id"`
+	ExpiresAt       time.Time  `json:"expires_at"`
+	SessionDeadline time.Time  `json:"session_deadline"`
+	AccessToken     string     `json:"access_token,omitempty"`
+	TokenType       string     `json:"token_type,omitempty"`
+	TokenExpiresAt  *time.Time `json:"token_expires_at,omitempty"`
 }
 
-// Login authenticates a user and returns a session
-func (s *AuthService) Login(ctx context.Context, req LoginRequest) (*AuthResponse, error) {
+// Authenticate checks a user's email and password. Locked accounts are refused with a
+// *LockoutError, and failures count towards locking the account.
+func (s *AuthService) Authenticate(ctx context.Context, email, password string) (models.User, error) {
+	return s.authenticate(ctx, email, password, "")
+}
+
+// authenticate checks credentials and records the attempt against the email and address
+func (s *AuthService) authenticate(ctx context.Context, email, password, ipAddress string) (models.User, error) {
+	now := time.Now()
+
 	// Get user by email
-	user, err := s.userRepo.GetByEmail(ctx, req.Email)
+	user, err := s.userRepo.GetByEmail(ctx, email)
 	if err != nil {
-		return nil, errors.New("invalid credentials")
+		if !errors.Is(err, repository.ErrNotFound) {
+			return user, err
+		}
+		return user, s.recordFailure(ctx, nil, email, ipAddress, now)
+	}
+
+	if user.LockedUntil != nil && user.LockedUntil.After(now) {
+		return user, &LockoutError{Code: LockoutAccountLocked, RetryAt: *user.LockedUntil}
 	}
 
 	// Check password
-	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password))
+	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
+	if err != nil {
+		return user, s.recordFailure(ctx, &user, email, ipAddress, now)
+	}
+
+	if err := s.loginAttemptRepo.Record(ctx, attemptKey(email), ipAddress, true); err != nil {
+		log.Printf("Failed to record login attempt: %v", err)
+	}
+
+	return user, nil
+}
+
+// recordFailure stores a failed attempt and locks the account once it has failed too often.
+// It returns the error to report for the attempt.
+func (s *AuthService) recordFailure(ctx context.Context, user *models.User, email, ipAddress string, now time.Time) error {
+	key := attemptKey(email)
+	if err := s.loginAttemptRepo.Record(ctx, key, ipAddress, false); err != nil {
+		log.Printf("Failed to record login attempt: %v", err)
+	}
+
+	if user == nil || s.maxFailures <= 0 {
+		return ErrInvalidCredentials
+	}
+
+	failures, err := s.loginAttemptRepo.CountFailuresByEmail(ctx, key, now.Add(-s.failureWindow))
+	if err != nil {
+		return err
+	}
+	if failures < s.maxFailures {
+		return ErrInvalidCredentials
+	}
+
+	lockedUntil := now.Add(s.lockoutDuration)
+	if err := s.userRepo.Lock(ctx, user.UserID, lockedUntil); err != nil {
+		return err
+	}
+	log.Printf("Locked user %d after %d failed logins", user.UserID, failures)
+
+	return &LockoutError{Code: LockoutAccountLocked, RetryAt: lockedUntil}
+}
+
+// checkAddress refuses logins from an address that has failed too often within the window
+func (s *AuthService) checkAddress(ctx context.Context, ipAddress string) error {
+	if ipAddress == "" || s.maxIPFailures <= 0 {
+		return nil
+	}
+
+	failures, oldest, err := s.loginAttemptRepo.CountFailuresByIP(ctx, ipAddress, time.Now().Add(-s.failureWindow))
 	if err != nil {
-		return nil, errors.New("invalid credentials")
+		return err
+	}
+	if failures >= s.maxIPFailures {
+		return &LockoutError{Code: LockoutTooManyAttempts, RetryAt: oldest.Add(s.failureWindow)}
+	}
+	return nil
+}
+
+// UnlockUser clears a user's lockout and their failed login attempts
+func (s *AuthService) UnlockUser(ctx context.Context, userID int) (models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return user, err
+	}
+
+	if err := s.userRepo.Unlock(ctx, userID); err != nil {
+		return user, err
+	}
+	if err := s.loginAttemptRepo.ClearFailures(ctx, attemptKey(user.Email)); err != nil {
+		return user, err
+	}
+
+	user.LockedUntil = nil
+	return user, nil
+}
+
+// attemptKey normalises an email so attempts are counted regardless of case
+func attemptKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// SessionTTL is how long a session stays valid without activity
+func (s *AuthService) SessionTTL() time.Duration {
+	return s.sessionTTL
+}
+
+// SessionDeadline is the latest a session can be extended to, however active it is
+func (s *AuthService) SessionDeadline(session models.Session) time.Time {
+	return session.CreatedAt.Add(s.sessionMaxAge)
+}
+
+// slidingExpiry is the expiry a session gets when it is used now
+func (s *AuthService) slidingExpiry(session models.Session) time.Time {
+	expiresAt := time.Now().Add(s.sessionTTL)
+	if deadline := s.SessionDeadline(session); expiresAt.After(deadline) {
+		return deadline
+	}
+	return expiresAt
+}
+
+// Login authenticates a user and stores a new server-side session
+func (s *AuthService) Login(ctx context.Context, req LoginRequest) (*AuthResponse, error) {
+	if err := s.checkAddress(ctx, req.IPAddress); err != nil {
+		return nil, err
+	}
+
+	user, err := s.authenticate(ctx, req.Email, req.Password, req.IPAddress)
+	if err != nil {
+		return nil, err
 	}
 
 	// Update last login time
 	s.userRepo.UpdateLastLogin(ctx, user.UserID)
 
-	// Create simple session ID (in a real app, this would be more secure)
-	sessionID := generateSessionID()
-	expiresAt := time.Now().Add(24 * time.Hour)
+	// Clear out sessions that have already expired so the table doesn't grow without bound
+	if _, err := s.sessionRepo.DeleteExpired(ctx); err != nil {
+		log.Printf("Failed to delete expired sessions: %v", err)
+	}
+	if _, err := s.loginAttemptRepo.DeleteBefore(ctx, time.Now().Add(-loginAttemptRetention)); err != nil {
+		log.Printf("Failed to delete old login attempts: %v", err)
+	}
+
+	token, err := generateToken("sess_")
+	if err != nil {
+		return nil, err
+	}
 
-	return &AuthResponse{
+	session := models.Session{
+		TokenHash: hashToken(token),
 		UserID:    user.UserID,
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+		ExpiresAt: time.Now().Add(s.sessionTTL),
+	}
+	if err := s.sessionRepo.Create(ctx, &session); err != nil {
+		return nil, err
+	}
+
+	response := &AuthResponse{
+		UserID:          user.UserID,
+		Email:           user.Email,
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		Role:            user.Role,
+		SessionID:       token,
+		ExpiresAt:       session.ExpiresAt,
+		SessionDeadline: s.SessionDeadline(session),
+	}
+
+	if s.JWTEnabled() {
+		accessToken, expiresAt, err := s.IssueToken(user)
+		if err != nil {
+			return nil, err
+		}
+		response.AccessToken = accessToken
+		response.TokenType = "Bearer"
+		response.TokenExpiresAt = &expiresAt
+	}
+
+	return response, nil
+}
+
+// JWTEnabled reports whether bearer tokens are issued and accepted
+func (s *AuthService) JWTEnabled() bool {
+	return len(s.jwtSecret) > 0
+}
+
+// IssueToken signs a stateless access token for a user
+func (s *AuthService) IssueToken(user models.User) (string, time.Time, error) {
+	if !s.JWTEnabled() {
+		return "", time.Time{}, errors.New("JWT authentication is not configured")
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.jwtTTL)
+	token, err := signJWT(TokenClaims{
+		Subject:   strconv.Itoa(user.UserID),
 		Email:     user.Email,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
 		Role:      user.Role,
-		SessionID: sessionID,
-		ExpiresAt: expiresAt,
-	}, nil
+		Issuer:    jwtIssuer,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	}, s.jwtSecret)
+	return token, expiresAt, err
 }
 
-// Helper function to generate a simple session ID
-func generateSessionID() string {
-	// In a real app, use a more secure method like crypto/rand
-	return "sess_" + time.Now().Format("20060102150405") + "_" + strconv.Itoa(time.Now().Nanosecond())
+// ValidateToken verifies a bearer token and loads the user it was issued to. The token is
+// not checked against the session store; the user is loaded so role changes and deleted
+// accounts take effect before the token expires.
+func (s *AuthService) ValidateToken(ctx context.Context, token string) (models.User, error) {
+	var user models.User
+	if !s.JWTEnabled() {
+		return user, ErrInvalidToken
+	}
+
+	claims, err := parseJWT(token, s.jwtSecret, time.Now())
+	if err != nil {
+		return user, err
+	}
+
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return user, ErrInvalidToken
+	}
+
+	user, err = s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return user, ErrInvalidToken
+		}
+		return user, err
+	}
+
+	return user, nil
+}
+
+// ValidateSession resolves a session token to its session and user
+func (s *AuthService) ValidateSession(ctx context.Context, token string) (models.Session, models.User, error) {
+	var user models.User
+	if token == "" {
+		return models.Session{}, user, ErrInvalidSession
+	}
+
+	session, err := s.sessionRepo.GetActiveByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return session, user, ErrInvalidSession
+		}
+		return session, user, err
+	}
+
+	user, err = s.userRepo.GetByID(ctx, session.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return session, user, ErrInvalidSession
+		}
+		return session, user, err
+	}
+
+	if err := s.sessionRepo.Touch(ctx, session.SessionID, s.slidingExpiry(session)); err != nil {
+		log.Printf("Failed to update last seen for session %d: %v", session.SessionID, err)
+	}
+
+	return session, user, nil
+}
+
+// RefreshSession extends a session by the session TTL, up to its maximum lifetime
+func (s *AuthService) RefreshSession(ctx context.Context, token string) (models.Session, error) {
+	session, _, err := s.ValidateSession(ctx, token)
+	if err != nil {
+		return session, err
+	}
+
+	session, err = s.sessionRepo.Extend(ctx, session.SessionID, s.slidingExpiry(session))
+	if err != nil && errors.Is(err, repository.ErrNotFound) {
+		return session, ErrInvalidSession
+	}
+	return session, err
+}
+
+// Logout ends a session by deleting it
+func (s *AuthService) Logout(ctx context.Context, token string) error {
+	if token == "" {
+		return ErrInvalidSession
+	}
+
+	err := s.sessionRepo.DeleteByTokenHash(ctx, hashToken(token))
+	if err != nil && errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidSession
+	}
+	return err
 }
 
 // HashPassword hashes a password for storage
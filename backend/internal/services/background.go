@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+	"sync"
+)
+
+// background tracks work started outside a request, such as chat notifications, document
+// archiving and the print queue, so shutdown can wait for it to finish
+var background sync.WaitGroup
+
+// goBackground runs fn in a tracked goroutine
+func goBackground(fn func()) {
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		fn()
+	}()
+}
+
+// WaitForBackground blocks until all background work has finished or ctx is done. Call it
+// after the HTTP server has stopped accepting requests so no new work is started meanwhile.
+func WaitForBackground(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		background.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
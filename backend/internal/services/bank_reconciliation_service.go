@@ -0,0 +1,295 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// maxSuggestions is how many match suggestions are offered per statement line
+const maxSuggestions = 5
+
+// statementDateLayouts are the transaction date formats accepted in statement CSVs
+var statementDateLayouts = []string{"2006-01-02", "01/02/2006", "1/2/2006", "01/02/06", "Jan 2, 2006", "02-Jan-2006", "02 Jan 2006"}
+
+// statementColumns maps the column roles of a statement CSV to the header names banks use
+var statementColumns = map[string][]string{
+	"date":        {"date", "transaction date", "posting date", "value date", "txn date"},
+	"description": {"description", "details", "particulars", "narration", "memo"},
+	"reference":   {"reference", "reference no", "reference number", "ref", "ref no", "check no", "cheque no"},
+	"amount":      {"amount"},
+	"credit":      {"credit", "deposit", "deposits", "credit amount"},
+	"debit":       {"debit", "withdrawal", "withdrawals", "debit amount"},
+}
+
+// StatementFormatError reports a bank statement CSV that could not be read, naming the row
+// when the problem is in one
+type StatementFormatError struct {
+	Row     int
+	Message string
+}
+
+// Error describes the problem
+func (e *StatementFormatError) Error() string {
+	if e.Row > 0 {
+		return fmt.Sprintf("row %d: %s", e.Row, e.Message)
+	}
+	return e.Message
+}
+
+// BankReconciliationService reads bank statements and suggests which recorded payments their
+// deposits correspond to
+type BankReconciliationService struct {
+	statementRepo *repository.BankStatementRepository
+	rules         *RulesService
+}
+
+// NewBankReconciliationService creates a new reconciliation service. Payments of the same
+// amount are suggested when received within the bank_match_window_days rule's days of the
+// deposit.
+func NewBankReconciliationService(statementRepo *repository.BankStatementRepository, rules *RulesService) *BankReconciliationService {
+	return &BankReconciliationService{
+		statementRepo: statementRepo,
+		rules:         rules,
+	}
+}
+
+// ParseStatementCSV reads the transactions of a bank statement CSV. The header row names the
+// columns: a date, a description and optionally a reference, with either a signed amount or
+// separate credit and debit columns. Rows without an amount, such as balance lines, are
+// skipped. A *StatementFormatError is returned when the file cannot be read.
+func ParseStatementCSV(r io.Reader) ([]models.BankStatementLine, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, &StatementFormatError{Message: "the file is empty"}
+	}
+	if err != nil {
+		return nil, &StatementFormatError{Row: 1, Message: err.Error()}
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		for role, names := range statementColumns {
+			if _, found := columns[role]; found {
+				continue
+			}
+			for _, candidate := range names {
+				if name == candidate {
+					columns[role] = i
+				}
+			}
+		}
+	}
+	if _, ok := columns["date"]; !ok {
+		return nil, &StatementFormatError{Row: 1, Message: "no date column in the header"}
+	}
+	_, hasAmount := columns["amount"]
+	_, hasCredit := columns["credit"]
+	if !hasAmount && !hasCredit {
+		return nil, &StatementFormatError{Row: 1, Message: "no amount or credit column in the header"}
+	}
+
+	lines := []models.BankStatementLine{}
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, &StatementFormatError{Row: row, Message: err.Error()}
+		}
+		field := func(role string) string {
+			i, ok := columns[role]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		if strings.Join(record, "") == "" {
+			continue
+		}
+
+		var amount float64
+		var present bool
+		if hasAmount {
+			amount, present, err = parseStatementAmount(field("amount"))
+		} else {
+			var credit, debit float64
+			var hasCreditValue, hasDebitValue bool
+			credit, hasCreditValue, err = parseStatementAmount(field("credit"))
+			if err == nil {
+				debit, hasDebitValue, err = parseStatementAmount(field("debit"))
+			}
+			amount, present = credit-math.Abs(debit), hasCreditValue || hasDebitValue
+		}
+		if err != nil {
+			return nil, &StatementFormatError{Row: row, Message: err.Error()}
+		}
+		if !present || amount == 0 {
+			continue
+		}
+
+		date, err := parseStatementDate(field("date"))
+		if err != nil {
+			return nil, &StatementFormatError{Row: row, Message: err.Error()}
+		}
+
+		lines = append(lines, models.BankStatementLine{
+			LineNo:          len(lines) + 1,
+			TransactionDate: date,
+			Description:     field("description"),
+			Reference:       field("reference"),
+			Amount:          roundMoney(amount),
+		})
+	}
+
+	if len(lines) == 0 {
+		return nil, &StatementFormatError{Message: "the file has no transactions"}
+	}
+	return lines, nil
+}
+
+// parseStatementAmount reads an amount such as "1,250.00", "PHP 500" or "(300.00)", reporting
+// whether the field held one
+func parseStatementAmount(value string) (float64, bool, error) {
+	negative := strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")")
+	cleaned := strings.Map(func(r rune) rune {
+		if (r >= '0' && r <= '9') || r == '.' || r == '-' {
+			return r
+		}
+		return -1
+	}, value)
+	if cleaned == "" {
+		return 0, false, nil
+	}
+
+	amount, err := strconv.ParseFloat(cleaned, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid amount %q", value)
+	}
+	if negative {
+		amount = -math.Abs(amount)
+	}
+	return amount, true, nil
+}
+
+// parseStatementDate reads a transaction date in one of the accepted layouts
+func parseStatementDate(value string) (time.Time, error) {
+	for _, layout := range statementDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, expected e.g. YYYY-MM-DD or MM/DD/YYYY", value)
+}
+
+// Suggest proposes payments for each unmatched deposit on a statement, best first
+func (s *BankReconciliationService) Suggest(ctx context.Context, statementID int) ([]models.LineSuggestions, error) {
+	if _, err := s.statementRepo.GetByID(ctx, statementID); err != nil {
+		return nil, err
+	}
+
+	lines, err := s.statementRepo.GetUnmatchedDeposits(ctx, statementID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]models.LineSuggestions, 0, len(lines))
+	for _, line := range lines {
+		suggestions, err := s.suggestLine(ctx, line)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, models.LineSuggestions{Line: line, Suggestions: suggestions})
+	}
+	return results, nil
+}
+
+// SuggestLine proposes payments for one statement line, best first
+func (s *BankReconciliationService) SuggestLine(ctx context.Context, lineID int) (models.LineSuggestions, error) {
+	line, err := s.statementRepo.GetLine(ctx, lineID)
+	if err != nil {
+		return models.LineSuggestions{}, err
+	}
+	if line.PaymentID != nil {
+		return models.LineSuggestions{}, repository.ErrLineMatched
+	}
+	if line.Amount <= 0 {
+		return models.LineSuggestions{Line: line, Suggestions: []models.MatchSuggestion{}}, nil
+	}
+
+	suggestions, err := s.suggestLine(ctx, line)
+	return models.LineSuggestions{Line: line, Suggestions: suggestions}, err
+}
+
+// suggestLine scores the candidate payments for a deposit. An equal amount scores 50, the
+// payment's reference appearing on the line 30, and the dates up to 20, less 5 per day apart.
+func (s *BankReconciliationService) suggestLine(ctx context.Context, line models.BankStatementLine) ([]models.MatchSuggestion, error) {
+	window := time.Duration(s.rules.Int(ctx, models.RuleBankMatchWindowDays)) * 24 * time.Hour
+	text := line.Reference + " " + line.Description
+	candidates, err := s.statementRepo.GetCandidatePayments(ctx, line.Amount,
+		line.TransactionDate.Add(-window), line.TransactionDate.Add(window), text)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := []models.MatchSuggestion{}
+	for _, payment := range candidates {
+		suggestion := models.MatchSuggestion{Payment: payment, Reasons: []string{}}
+
+		if math.Abs(payment.Amount-line.Amount) < 0.005 {
+			suggestion.Score += 50
+			suggestion.Reasons = append(suggestion.Reasons, "same amount")
+		} else {
+			suggestion.Reasons = append(suggestion.Reasons, fmt.Sprintf("amount differs by %.2f", roundMoney(line.Amount-payment.Amount)))
+		}
+
+		if payment.ReferenceNo != nil && referenceOnLine(*payment.ReferenceNo, text) {
+			suggestion.Score += 30
+			suggestion.Reasons = append(suggestion.Reasons, "reference "+*payment.ReferenceNo+" on the statement")
+		}
+
+		paid := time.Date(payment.PaidAt.Year(), payment.PaidAt.Month(), payment.PaidAt.Day(), 0, 0, 0, 0, time.UTC)
+		days := int(math.Abs(line.TransactionDate.Sub(paid).Hours() / 24))
+		if days == 0 {
+			suggestion.Reasons = append(suggestion.Reasons, "same day")
+		} else {
+			suggestion.Reasons = append(suggestion.Reasons, fmt.Sprintf("%d day(s) apart", days))
+		}
+		if days < 4 {
+			suggestion.Score += 20 - 5*days
+		}
+
+		suggestions = append(suggestions, suggestion)
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].Score > suggestions[j].Score
+	})
+	if len(suggestions) > maxSuggestions {
+		suggestions = suggestions[:maxSuggestions]
+	}
+	return suggestions, nil
+}
+
+// referenceOnLine reports whether a payment reference appears in a statement line's text,
+// ignoring case
+func referenceOnLine(reference, text string) bool {
+	reference = strings.TrimSpace(reference)
+	return len(reference) >= 4 && strings.Contains(strings.ToLower(text), strings.ToLower(reference))
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// NewCacheFromEnv configures the cache for product and customer lookups from environment
+// variables, returning nil when caching is off
+//
+//	CACHE              off (default), memory or redis
+//	CACHE_REDIS_URL    redis://[:password@]host:port/db, for CACHE=redis
+//	CACHE_TTL_SECONDS  how long a cached copy is kept at most (default 300)
+//
+// The memory cache belongs to one server process, which only sees its own changes: run
+// several instances with CACHE=redis so they invalidate each other's copies.
+func NewCacheFromEnv() repository.Cache {
+	ttl := time.Duration(envInt("CACHE_TTL_SECONDS", 300)) * time.Second
+	if ttl <= 0 {
+		return nil
+	}
+
+	switch mode := strings.ToLower(strings.TrimSpace(os.Getenv("CACHE"))); mode {
+	case "", "off":
+		return nil
+	case "memory":
+		return NewMemoryCache(ttl)
+	case "redis":
+		cache, err := NewRedisCache(os.Getenv("CACHE_REDIS_URL"), ttl)
+		if err != nil {
+			log.Printf("Warning: invalid CACHE_REDIS_URL, caching disabled: %v", err)
+			return nil
+		}
+		return cache
+	default:
+		log.Printf("Warning: ignoring invalid CACHE=%q, caching disabled", mode)
+		return nil
+	}
+}
+
+// memoryEntry is a cached value and when it expires
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// MemoryCache keeps cached values in process memory for a fixed time
+type MemoryCache struct {
+	ttl       time.Duration
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	lastSweep time.Time
+}
+
+// NewMemoryCache creates an empty in-memory cache keeping values for ttl
+func NewMemoryCache(ttl time.Duration) *MemoryCache {
+	return &MemoryCache{
+		ttl:     ttl,
+		entries: map[string]memoryEntry{},
+	}
+}
+
+// Get returns the value cached under key unless it has expired
+func (c *MemoryCache) Get(ctx context.Context, key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || !time.Now().Before(entry.expires) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// Set caches a value under key
+func (c *MemoryCache) Set(ctx context.Context, key string, value []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	// Expired entries are dropped now and then so records read once don't stay forever
+	if now.Sub(c.lastSweep) > time.Minute {
+		for k, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		c.lastSweep = now
+	}
+
+	c.entries[key] = memoryEntry{value: value, expires: now.Add(c.ttl)}
+}
+
+// Delete drops the values cached under keys
+func (c *MemoryCache) Delete(ctx context.Context, keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		delete(c.entries, key)
+	}
+}
+
+// RedisCache keeps cached values in Redis so every server instance shares them and sees the
+// others' invalidations. Redis failures are logged and read as a miss.
+type RedisCache struct {
+	client *redisClient
+	ttl    time.Duration
+}
+
+// NewRedisCache creates a cache for a redis:// or rediss:// URL keeping values for ttl. The
+// connection is opened on first use.
+func NewRedisCache(rawURL string, ttl time.Duration) (*RedisCache, error) {
+	client, err := newRedisClient(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, err
+	}
+	return &RedisCache{client: client, ttl: ttl}, nil
+}
+
+// Get returns the value cached under key
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, bool) {
+	reply, err := c.client.do(ctx, "GET", "cache:"+key)
+	if err != nil {
+		log.Printf("Cache read failed for %s: %v", key, err)
+		return nil, false
+	}
+	value, ok := reply.(string)
+	if !ok {
+		return nil, false
+	}
+	return []byte(value), true
+}
+
+// Set caches a value under key
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte) {
+	_, err := c.client.do(ctx, "SET", "cache:"+key, string(value), "PX", strconv.FormatInt(c.ttl.Milliseconds(), 10))
+	if err != nil {
+		log.Printf("Cache write failed for %s: %v", key, err)
+	}
+}
+
+// Delete drops the values cached under keys
+func (c *RedisCache) Delete(ctx context.Context, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	args := []string{"DEL"}
+	for _, key := range keys {
+		args = append(args, "cache:"+key)
+	}
+	if _, err := c.client.do(ctx, args...); err != nil {
+		log.Printf("Cache invalidation failed for %s: %v", strings.Join(keys, ", "), err)
+	}
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrCaptchaFailed is returned when a captcha token is missing or was not accepted
+var ErrCaptchaFailed = errors.New("captcha verification failed")
+
+// CaptchaVerifier checks the tokens captcha widgets add to public forms with the provider's
+// siteverify endpoint. Cloudflare Turnstile, hCaptcha and Google reCAPTCHA all take the same
+// form post and answer with {"success": true|false}.
+type CaptchaVerifier struct {
+	verifyURL string
+	secret    string
+	client    *http.Client
+}
+
+// NewCaptchaVerifierFromEnv configures the verifier from environment variables
+//
+//	CAPTCHA_SECRET       the provider's secret key; captchas are not checked when unset
+//	CAPTCHA_VERIFY_URL   siteverify endpoint (default Cloudflare Turnstile's)
+func NewCaptchaVerifierFromEnv() *CaptchaVerifier {
+	return &CaptchaVerifier{
+		verifyURL: envOrDefault("CAPTCHA_VERIFY_URL", "https://challenges.cloudflare.com/turnstile/v0/siteverify"),
+		secret:    strings.TrimSpace(os.Getenv("CAPTCHA_SECRET")),
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Enabled reports whether captcha tokens are checked
+func (v *CaptchaVerifier) Enabled() bool {
+	return v.secret != ""
+}
+
+// Verify checks a token with the provider, returning ErrCaptchaFailed when it was not
+// accepted and another error when the provider could not be asked. Every token passes
+// while the verifier is not enabled.
+func (v *CaptchaVerifier) Verify(ctx context.Context, token, remoteIP string) error {
+	if !v.Enabled() {
+		return nil
+	}
+	if strings.TrimSpace(token) == "" {
+		return ErrCaptchaFailed
+	}
+
+	form := url.Values{"secret": {v.secret}, "response": {token}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.verifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("captcha provider unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("captcha provider returned %s", resp.Status)
+	}
+
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("invalid captcha provider response: %w", err)
+	}
+	if !result.Success {
+		return ErrCaptchaFailed
+	}
+	return nil
+}
@@ -0,0 +1,505 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+)
+
+// ChatEvent identifies a business event that can be pushed to a chat channel
+type ChatEvent string
+
+const (
+	// ChatEventLargeOrder fires when an order above the configured threshold is created
+	ChatEventLargeOrder ChatEvent = "order.large"
+	// ChatEventQuotationApproved fires when a quotation above the configured threshold is approved
+	ChatEventQuotationApproved ChatEvent = "quotation.approved"
+	// ChatEventStockOut fires when an inventory item reaches zero stock
+	ChatEventStockOut ChatEvent = "inventory.stock_out"
+	// ChatEventLowStock fires when an inventory item falls to its reorder level
+	ChatEventLowStock ChatEvent = "inventory.low_stock"
+	// ChatEventCheckMaturing fires when a post-dated check on hand is about to mature
+	ChatEventCheckMaturing ChatEvent = "check.maturing"
+	// ChatEventPDFFailing fires when a document fails to generate several times in a row
+	ChatEventPDFFailing ChatEvent = "pdf.failing"
+	// ChatEventQuoteRequest fires when a quote request comes in from the public form
+	ChatEventQuoteRequest ChatEvent = "quote_request.received"
+)
+
+// ChatFact is a single label/value pair shown on a chat card
+type ChatFact struct {
+	Label string
+	Value string
+}
+
+// ChatMessage is a connector-neutral card posted to a chat channel
+type ChatMessage struct {
+	Event ChatEvent
+	Title string
+	Text  string
+	Facts []ChatFact
+	Color string
+}
+
+// ChatConnector posts a formatted card to a single chat provider
+type ChatConnector interface {
+	Name() string
+	Post(ctx context.Context, msg ChatMessage) error
+}
+
+// ChatEventRule controls whether an event is sent and, for events with an amount, the
+// business rule holding the amount from which it is sent
+type ChatEventRule struct {
+	Enabled       bool
+	ThresholdRule string
+}
+
+// ChatNotifierConfig holds the connector and per-event settings. PDFFailureThreshold is how
+// many times in a row a document must fail to generate before it is reported.
+type ChatNotifierConfig struct {
+	SlackWebhookURL     string
+	TeamsWebhookURL     string
+	Rules               map[ChatEvent]ChatEventRule
+	PDFFailureThreshold int
+}
+
+// ChatNotifierConfigFromEnv builds the notifier configuration from environment variables
+//
+//	CHAT_SLACK_WEBHOOK_URL, CHAT_TEAMS_WEBHOOK_URL      incoming webhook URLs
+//	CHAT_EVENTS                                         comma separated event list (default: all)
+//	CHAT_PDF_FAILURE_THRESHOLD                          failures in a row before pdf.failing (default: 3)
+//
+// The minimum order and quotation totals are the large_order_alert_threshold and
+// quotation_approved_alert_threshold business rules.
+func ChatNotifierConfigFromEnv() ChatNotifierConfig {
+	enabled := map[ChatEvent]bool{
+		ChatEventLargeOrder:        true,
+		ChatEventQuotationApproved: true,
+		ChatEventStockOut:          true,
+		ChatEventLowStock:          true,
+		ChatEventCheckMaturing:     true,
+		ChatEventPDFFailing:        true,
+		ChatEventQuoteRequest:      true,
+	}
+	if events := strings.TrimSpace(os.Getenv("CHAT_EVENTS")); events != "" {
+		for event := range enabled {
+			enabled[event] = false
+		}
+		for _, event := range strings.Split(events, ",") {
+			enabled[ChatEvent(strings.TrimSpace(event))] = true
+		}
+	}
+
+	pdfFailureThreshold := int(envFloat("CHAT_PDF_FAILURE_THRESHOLD", 3))
+	if pdfFailureThreshold < 1 {
+		pdfFailureThreshold = 1
+	}
+
+	return ChatNotifierConfig{
+		SlackWebhookURL:     os.Getenv("CHAT_SLACK_WEBHOOK_URL"),
+		TeamsWebhookURL:     os.Getenv("CHAT_TEAMS_WEBHOOK_URL"),
+		PDFFailureThreshold: pdfFailureThreshold,
+		Rules: map[ChatEvent]ChatEventRule{
+			ChatEventLargeOrder: {
+				Enabled:       enabled[ChatEventLargeOrder],
+				ThresholdRule: models.RuleLargeOrderAlert,
+			},
+			ChatEventQuotationApproved: {
+				Enabled:       enabled[ChatEventQuotationApproved],
+				ThresholdRule: models.RuleQuotationApprovedAlert,
+			},
+			ChatEventStockOut: {
+				Enabled: enabled[ChatEventStockOut],
+			},
+			ChatEventLowStock: {
+				Enabled: enabled[ChatEventLowStock],
+			},
+			ChatEventCheckMaturing: {
+				Enabled: enabled[ChatEventCheckMaturing],
+			},
+			ChatEventPDFFailing: {
+				Enabled: enabled[ChatEventPDFFailing],
+			},
+			ChatEventQuoteRequest: {
+				Enabled: enabled[ChatEventQuoteRequest],
+			},
+		},
+	}
+}
+
+// envFloat reads a float environment variable, falling back to def when unset or invalid
+func envFloat(key string, def float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Warning: invalid value for %s: %q, using %v", key, value, def)
+		return def
+	}
+	return f
+}
+
+// ChatNotifier fans business events out to the configured chat connectors
+type ChatNotifier struct {
+	connectors []ChatConnector
+	rules      map[ChatEvent]ChatEventRule
+	thresholds *RulesService
+
+	pdfFailureThreshold int
+	pdfMu               sync.Mutex
+	pdfFailures         map[string]int
+}
+
+// NewChatNotifier creates a notifier with a connector for each configured webhook. Event
+// thresholds are read from the business rules.
+func NewChatNotifier(cfg ChatNotifierConfig, thresholds *RulesService) *ChatNotifier {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	var connectors []ChatConnector
+	if cfg.SlackWebhookURL != "" {
+		connectors = append(connectors, &SlackConnector{webhookURL: cfg.SlackWebhookURL, client: client})
+	}
+	if cfg.TeamsWebhookURL != "" {
+		connectors = append(connectors, &TeamsConnector{webhookURL: cfg.TeamsWebhookURL, client: client})
+	}
+
+	pdfFailureThreshold := cfg.PDFFailureThreshold
+	if pdfFailureThreshold < 1 {
+		pdfFailureThreshold = 1
+	}
+
+	return &ChatNotifier{
+		connectors:          connectors,
+		rules:               cfg.Rules,
+		thresholds:          thresholds,
+		pdfFailureThreshold: pdfFailureThreshold,
+		pdfFailures:         map[string]int{},
+	}
+}
+
+// NotifyOrderCreated posts a card when a newly created order exceeds the large order threshold
+func (n *ChatNotifier) NotifyOrderCreated(orderID int, customerName string, total float64) {
+	if !n.shouldSend(ChatEventLargeOrder, total) {
+		return
+	}
+	n.send(ChatMessage{
+		Event: ChatEventLargeOrder,
+		Title: fmt.Sprintf("Large order #%d created", orderID),
+		Text:  fmt.Sprintf("%s placed an order worth %s.", customerName, formatPeso(total)),
+		Facts: []ChatFact{
+			{Label: "Order", Value: fmt.Sprintf("#%d", orderID)},
+			{Label: "Customer", Value: customerName},
+			{Label: "Total", Value: formatPeso(total)},
+		},
+		Color: "2c5282",
+	})
+}
+
+// NotifyQuotationApproved posts a card when an approved quotation exceeds the configured threshold
+func (n *ChatNotifier) NotifyQuotationApproved(reference string, customerName string, total float64) {
+	if !n.shouldSend(ChatEventQuotationApproved, total) {
+		return
+	}
+	n.send(ChatMessage{
+		Event: ChatEventQuotationApproved,
+		Title: fmt.Sprintf("Quotation %s approved", reference),
+		Text:  fmt.Sprintf("Quotation %s for %s was approved (%s).", reference, customerName, formatPeso(total)),
+		Facts: []ChatFact{
+			{Label: "Quotation", Value: reference},
+			{Label: "Customer", Value: customerName},
+			{Label: "Total", Value: formatPeso(total)},
+		},
+		Color: "2f855a",
+	})
+}
+
+// NotifyStockOut posts a card when a product runs out of stock
+func (n *ChatNotifier) NotifyStockOut(productID int, productName string, reorderLevel int) {
+	if !n.shouldSend(ChatEventStockOut, 0) {
+		return
+	}
+	n.send(ChatMessage{
+		Event: ChatEventStockOut,
+		Title: fmt.Sprintf("Out of stock: %s", productName),
+		Text:  fmt.Sprintf("%s has no stock left and should be reordered.", productName),
+		Facts: []ChatFact{
+			{Label: "Product ID", Value: strconv.Itoa(productID)},
+			{Label: "Current stock", Value: "0"},
+			{Label: "Reorder level", Value: strconv.Itoa(reorderLevel)},
+		},
+		Color: "c53030",
+	})
+}
+
+// NotifyLowStock posts a card when a product falls to its reorder level
+func (n *ChatNotifier) NotifyLowStock(productID int, productName string, currentStock, reorderLevel int) {
+	if !n.shouldSend(ChatEventLowStock, 0) {
+		return
+	}
+	n.send(ChatMessage{
+		Event: ChatEventLowStock,
+		Title: fmt.Sprintf("Low stock: %s", productName),
+		Text:  fmt.Sprintf("%s is at or below its reorder level and should be reordered.", productName),
+		Facts: []ChatFact{
+			{Label: "Product ID", Value: strconv.Itoa(productID)},
+			{Label: "Current stock", Value: strconv.Itoa(currentStock)},
+			{Label: "Reorder level", Value: strconv.Itoa(reorderLevel)},
+		},
+		Color: "d69e2e",
+	})
+}
+
+// RecordPDFResult counts the documents of a template that failed to generate in a row and
+// posts a card once the count reaches the configured threshold. A document that generates
+// ends the run, so each run of failures is reported once.
+func (n *ChatNotifier) RecordPDFResult(templateName string, err error) {
+	n.pdfMu.Lock()
+	if err == nil {
+		delete(n.pdfFailures, templateName)
+		n.pdfMu.Unlock()
+		return
+	}
+	n.pdfFailures[templateName]++
+	failures := n.pdfFailures[templateName]
+	n.pdfMu.Unlock()
+
+	if failures != n.pdfFailureThreshold || !n.shouldSend(ChatEventPDFFailing, 0) {
+		return
+	}
+	message := err.Error()
+	if len(message) > 300 {
+		message = message[:300] + "…"
+	}
+	n.send(ChatMessage{
+		Event: ChatEventPDFFailing,
+		Title: fmt.Sprintf("PDF generation failing: %s", templateName),
+		Text:  fmt.Sprintf("The last %d documents from %s failed to generate.", failures, templateName),
+		Facts: []ChatFact{
+			{Label: "Template", Value: templateName},
+			{Label: "Failures in a row", Value: strconv.Itoa(failures)},
+			{Label: "Last error", Value: message},
+		},
+		Color: "c53030",
+	})
+}
+
+// NotifyCheckMaturing posts a card reminding that a post-dated check is due for deposit
+func (n *ChatNotifier) NotifyCheckMaturing(checkID int, checkNumber, bank, customerName string, amount float64, maturity time.Time) {
+	if !n.shouldSend(ChatEventCheckMaturing, 0) {
+		return
+	}
+	n.send(ChatMessage{
+		Event: ChatEventCheckMaturing,
+		Title: fmt.Sprintf("Check #%s matures %s", checkNumber, maturity.Format("Jan 2, 2006")),
+		Text:  fmt.Sprintf("%s check from %s is due for deposit.", bank, customerName),
+		Facts: []ChatFact{
+			{Label: "Check ID", Value: strconv.Itoa(checkID)},
+			{Label: "Customer", Value: customerName},
+			{Label: "Bank", Value: bank},
+			{Label: "Amount", Value: formatPeso(amount)},
+		},
+		Color: "d69e2e",
+	})
+}
+
+// NotifyQuoteRequest posts a card when a quote request comes in from the public form
+func (n *ChatNotifier) NotifyQuoteRequest(request models.QuoteRequest) {
+	if !n.shouldSend(ChatEventQuoteRequest, 0) {
+		return
+	}
+	message := request.Message
+	if len(message) > 300 {
+		message = message[:300] + "…"
+	}
+	customer := "New lead"
+	if request.CustomerID != nil {
+		customer = fmt.Sprintf("Customer #%d", *request.CustomerID)
+	}
+	n.send(ChatMessage{
+		Event: ChatEventQuoteRequest,
+		Title: fmt.Sprintf("Quote request from %s", request.CompanyName),
+		Text:  message,
+		Facts: []ChatFact{
+			{Label: "Request", Value: fmt.Sprintf("#%d", request.QuoteRequestID)},
+			{Label: "Contact", Value: fmt.Sprintf("%s <%s>", request.ContactName, request.Email)},
+			{Label: "Customer", Value: customer},
+			{Label: "Attachments", Value: strconv.Itoa(len(request.Attachments))},
+		},
+		Color: "2c5282",
+	})
+}
+
+// Enabled reports whether the event is sent to any connector
+func (n *ChatNotifier) Enabled(event ChatEvent) bool {
+	return len(n.connectors) > 0 && n.rules[event].Enabled
+}
+
+// shouldSend reports whether the event is enabled and the amount meets its threshold
+func (n *ChatNotifier) shouldSend(event ChatEvent, amount float64) bool {
+	if len(n.connectors) == 0 {
+		return false
+	}
+	rule, ok := n.rules[event]
+	if !ok || !rule.Enabled {
+		return false
+	}
+	if rule.ThresholdRule == "" {
+		return true
+	}
+	return amount >= n.thresholds.Float(context.Background(), rule.ThresholdRule)
+}
+
+// send posts the message to every connector in the background so requests are not delayed
+func (n *ChatNotifier) send(msg ChatMessage) {
+	for _, connector := range n.connectors {
+		goBackground(func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			defer cancel()
+			if err := connector.Post(ctx, msg); err != nil {
+				log.Printf("Failed to post %s notification to %s: %v", msg.Event, connector.Name(), err)
+			}
+		})
+	}
+}
+
+// SlackConnector posts Block Kit messages to a Slack incoming webhook
+type SlackConnector struct {
+	webhookURL string
+	client     *http.Client
+}
+
+// Name returns the connector name
+func (s *SlackConnector) Name() string {
+	return "slack"
+}
+
+// Post sends the message as a Slack attachment with section blocks
+func (s *SlackConnector) Post(ctx context.Context, msg ChatMessage) error {
+	fields := make([]map[string]string, 0, len(msg.Facts))
+	for _, fact := range msg.Facts {
+		fields = append(fields, map[string]string{
+			"type": "mrkdwn",
+			"text": fmt.Sprintf("*%s*\n%s", fact.Label, fact.Value),
+		})
+	}
+
+	blocks := []map[string]interface{}{
+		{
+			"type": "header",
+			"text": map[string]string{"type": "plain_text", "text": msg.Title},
+		},
+		{
+			"type": "section",
+			"text": map[string]string{"type": "mrkdwn", "text": msg.Text},
+		},
+	}
+	if len(fields) > 0 {
+		blocks = append(blocks, map[string]interface{}{
+			"type":   "section",
+			"fields": fields,
+		})
+	}
+
+	payload := map[string]interface{}{
+		"text": msg.Title,
+		"attachments": []map[string]interface{}{
+			{
+				"color":  "#" + msg.Color,
+				"blocks": blocks,
+			},
+		},
+	}
+
+	return postJSON(ctx, s.client, s.webhookURL, payload)
+}
+
+// TeamsConnector posts MessageCards to a Microsoft Teams incoming webhook
+type TeamsConnector struct {
+	webhookURL string
+	client     *http.Client
+}
+
+// Name returns the connector name
+func (t *TeamsConnector) Name() string {
+	return "teams"
+}
+
+// Post sends the message as a legacy MessageCard, which Teams connectors accept directly
+func (t *TeamsConnector) Post(ctx context.Context, msg ChatMessage) error {
+	facts := make([]map[string]string, 0, len(msg.Facts))
+	for _, fact := range msg.Facts {
+		facts = append(facts, map[string]string{
+			"name":  fact.Label,
+			"value": fact.Value,
+		})
+	}
+
+	payload := map[string]interface{}{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    msg.Title,
+		"themeColor": msg.Color,
+		"title":      msg.Title,
+		"sections": []map[string]interface{}{
+			{
+				"text":  msg.Text,
+				"facts": facts,
+			},
+		},
+	}
+
+	return postJSON(ctx, t.client, t.webhookURL, payload)
+}
+
+// postJSON sends a JSON payload and treats any non-2xx response as an error
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// formatPeso formats an amount with thousand separators and the peso sign
+func formatPeso(amount float64) string {
+	formattedAmount := fmt.Sprintf("%.2f", amount)
+	parts := strings.Split(formattedAmount, ".")
+	integerPart := parts[0]
+	negative := strings.HasPrefix(integerPart, "-")
+	integerPart = strings.TrimPrefix(integerPart, "-")
+	for i := len(integerPart) - 3; i > 0; i -= 3 {
+		integerPart = integerPart[:i] + "," + integerPart[i:]
+	}
+	if negative {
+		integerPart = "-" + integerPart
+	}
+	return "₱" + integerPart + "." + parts[1]
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// CheckReminderService periodically reminds the chat channel of post-dated checks that are
+// about to mature so they are deposited on time
+type CheckReminderService struct {
+	checkRepo *repository.PostDatedCheckRepository
+	notifier  *ChatNotifier
+	rules     *RulesService
+	interval  time.Duration
+	stop      chan struct{}
+}
+
+// NewCheckReminderService creates a new reminder service that looks the check_reminder_days
+// rule's days ahead every PDC_REMINDER_INTERVAL_HOURS hours (default 24)
+func NewCheckReminderService(checkRepo *repository.PostDatedCheckRepository, notifier *ChatNotifier, rules *RulesService) *CheckReminderService {
+	interval := time.Duration(envFloat("PDC_REMINDER_INTERVAL_HOURS", 24) * float64(time.Hour))
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+
+	return &CheckReminderService{
+		checkRepo: checkRepo,
+		notifier:  notifier,
+		rules:     rules,
+		interval:  interval,
+		stop:      make(chan struct{}),
+	}
+}
+
+// Days returns how many days ahead of maturity checks are reminded
+func (s *CheckReminderService) Days(ctx context.Context) int {
+	return s.rules.Int(ctx, models.RuleCheckReminderDays)
+}
+
+// Start sends reminders once at startup and then on every interval
+func (s *CheckReminderService) Start() {
+	goBackground(func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.run()
+			select {
+			case <-ticker.C:
+			case <-s.stop:
+				return
+			}
+		}
+	})
+}
+
+// Stop ends the schedule after any run in progress has finished
+func (s *CheckReminderService) Stop() {
+	close(s.stop)
+}
+
+// run reminds of each check maturing within the window once and logs the outcome. Checks
+// are left unmarked while chat notifications are off so they are reminded once enabled.
+func (s *CheckReminderService) run() {
+	if !s.notifier.Enabled(ChatEventCheckMaturing) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	checks, err := s.checkRepo.GetUnreminded(ctx, today.AddDate(0, 0, s.Days(ctx)))
+	if err != nil {
+		log.Printf("Failed to load maturing checks: %v", err)
+		return
+	}
+	if len(checks) == 0 {
+		return
+	}
+
+	ids := make([]int, 0, len(checks))
+	for _, check := range checks {
+		s.notifier.NotifyCheckMaturing(check.CheckID, check.CheckNumber, check.Bank, check.CompanyName, check.Amount, check.MaturityDate)
+		ids = append(ids, check.CheckID)
+	}
+	if err := s.checkRepo.MarkReminded(ctx, ids); err != nil {
+		log.Printf("Failed to mark check reminders as sent: %v", err)
+		return
+	}
+	log.Printf("Sent maturity reminders for %d post-dated checks", len(checks))
+}
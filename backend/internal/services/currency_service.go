@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+var (
+	// ErrUnknownCurrency is returned for a currency code that is not set up
+	ErrUnknownCurrency = errors.New("unknown currency")
+
+	// ErrNoExchangeRate is returned when no exchange rate of a currency had taken effect by
+	// the day asked about
+	ErrNoExchangeRate = errors.New("no exchange rate for the currency")
+
+	// ErrBaseCurrencyRate is returned when recording an exchange rate for the base currency,
+	// which is always 1
+	ErrBaseCurrencyRate = errors.New("the base currency has no exchange rate")
+)
+
+// CurrencyService looks up currencies and their exchange rates to the peso and converts
+// amounts between currencies
+type CurrencyService struct {
+	currencyRepo *repository.CurrencyRepository
+}
+
+// NewCurrencyService creates a new currency service
+func NewCurrencyService(currencyRepo *repository.CurrencyRepository) *CurrencyService {
+	return &CurrencyService{
+		currencyRepo: currencyRepo,
+	}
+}
+
+// normalizeCurrency upper-cases a currency code; an empty code is the base currency
+func normalizeCurrency(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return models.BaseCurrency
+	}
+	return code
+}
+
+// Currencies returns every currency that is set up
+func (s *CurrencyService) Currencies(ctx context.Context) ([]models.Currency, error) {
+	return s.currencyRepo.GetAll(ctx)
+}
+
+// Currency looks up a currency by code; an empty code is the base currency
+func (s *CurrencyService) Currency(ctx context.Context, code string) (models.Currency, error) {
+	currency, err := s.currencyRepo.GetByCode(ctx, normalizeCurrency(code))
+	if errors.Is(err, repository.ErrNotFound) {
+		return currency, ErrUnknownCurrency
+	}
+	return currency, err
+}
+
+// Rates returns the exchange rates recorded for a currency, latest first
+func (s *CurrencyService) Rates(ctx context.Context, code string) ([]models.ExchangeRate, error) {
+	currency, err := s.Currency(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	return s.currencyRepo.GetRates(ctx, currency.Code)
+}
+
+// SetRate records what one unit of a currency is worth in pesos from rate.EffectiveDate,
+// replacing a rate already recorded for that day
+func (s *CurrencyService) SetRate(ctx context.Context, rate *models.ExchangeRate) error {
+	currency, err := s.Currency(ctx, rate.CurrencyCode)
+	if err != nil {
+		return err
+	}
+	if currency.Code == models.BaseCurrency {
+		return ErrBaseCurrencyRate
+	}
+	rate.CurrencyCode = currency.Code
+	return s.currencyRepo.SetRate(ctx, rate)
+}
+
+// Rate returns what one unit of a currency was worth in pesos on a day: 1 for the peso,
+// otherwise the latest rate to have taken effect by then
+func (s *CurrencyService) Rate(ctx context.Context, code string, day time.Time) (float64, error) {
+	code = normalizeCurrency(code)
+	if code == models.BaseCurrency {
+		return 1, nil
+	}
+	if _, err := s.Currency(ctx, code); err != nil {
+		return 0, err
+	}
+	rate, err := s.currencyRepo.GetRateOn(ctx, code, day)
+	if errors.Is(err, repository.ErrNotFound) {
+		return 0, ErrNoExchangeRate
+	}
+	if err != nil {
+		return 0, err
+	}
+	return rate.Rate, nil
+}
+
+// Convert converts an amount from one currency to another at the rates of a day, through
+// the peso, rounded to the places of the currency converted to
+func (s *CurrencyService) Convert(ctx context.Context, amount float64, from, to string, day time.Time) (float64, error) {
+	if normalizeCurrency(from) == normalizeCurrency(to) {
+		return amount, nil
+	}
+	fromRate, err := s.Rate(ctx, from, day)
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := s.Rate(ctx, to, day)
+	if err != nil {
+		return 0, err
+	}
+	currency, err := s.Currency(ctx, to)
+	if err != nil {
+		return 0, err
+	}
+	return roundTo(amount*fromRate/toRate, currency.Decimals), nil
+}
+
+// ToBase converts an amount in a document's currency to pesos at the document's rate
+func ToBase(amount, exchangeRate float64) float64 {
+	if exchangeRate == 0 {
+		return amount
+	}
+	return roundMoney(amount * exchangeRate)
+}
+
+// FromBase converts an amount in pesos to a document's currency at the document's rate
+func FromBase(amount, exchangeRate float64) float64 {
+	if exchangeRate == 0 {
+		return amount
+	}
+	return roundMoney(amount / exchangeRate)
+}
+
+// roundTo rounds an amount to the given number of decimal places
+func roundTo(amount float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(amount*scale) / scale
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// dashboardEntry is a dashboard summary computed for one days window
+type dashboardEntry struct {
+	summary  models.DashboardSummary
+	computed time.Time
+}
+
+// DashboardService serves the dashboard summary, keeping each days window's summary for a
+// while since it takes several queries to compute and every open dashboard polls it
+type DashboardService struct {
+	reportRepo *repository.ReportRepository
+	ttl        time.Duration
+
+	mu        sync.Mutex
+	entries   map[int]dashboardEntry
+	lastSweep time.Time
+}
+
+// NewDashboardService creates a dashboard service keeping summaries for ttl; a ttl of zero
+// computes every summary afresh
+func NewDashboardService(reportRepo *repository.ReportRepository, ttl time.Duration) *DashboardService {
+	return &DashboardService{
+		reportRepo: reportRepo,
+		ttl:        ttl,
+		entries:    map[int]dashboardEntry{},
+	}
+}
+
+// NewDashboardServiceFromEnv configures the service from environment variables
+//
+//	DASHBOARD_CACHE_SECONDS  how long a dashboard summary is reused (default 60, 0 disables)
+func NewDashboardServiceFromEnv(reportRepo *repository.ReportRepository) *DashboardService {
+	return NewDashboardService(reportRepo, time.Duration(envInt("DASHBOARD_CACHE_SECONDS", 60))*time.Second)
+}
+
+// Summary returns the dashboard summary for the last days days, reusing the one computed
+// within the cache time unless refresh is set. Cached and CacheAgeSeconds tell how old it is.
+func (s *DashboardService) Summary(ctx context.Context, days int, refresh bool) (models.DashboardSummary, error) {
+	now := time.Now()
+	if !refresh && s.ttl > 0 {
+		s.mu.Lock()
+		entry, ok := s.entries[days]
+		s.mu.Unlock()
+		if ok && now.Sub(entry.computed) < s.ttl {
+			summary := entry.summary
+			summary.Cached = true
+			summary.CacheAgeSeconds = int(now.Sub(entry.computed).Seconds())
+			return summary, nil
+		}
+	}
+
+	summary, err := s.reportRepo.GetDashboardSummary(ctx, days)
+	if err != nil {
+		return summary, err
+	}
+
+	if s.ttl > 0 {
+		s.mu.Lock()
+		// Windows nobody has asked for lately are dropped now and then
+		if now.Sub(s.lastSweep) > s.ttl {
+			for d, entry := range s.entries {
+				if now.Sub(entry.computed) >= s.ttl {
+					delete(s.entries, d)
+				}
+			}
+			s.lastSweep = now
+		}
+		s.entries[days] = dashboardEntry{summary: summary, computed: now}
+		s.mu.Unlock()
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// Supported device types
+const (
+	DeviceTypeTablet  = "tablet"
+	DeviceTypeScanner = "scanner"
+	DeviceTypeOther   = "other"
+)
+
+// ErrInvalidDeviceToken is returned for unknown or revoked device tokens
+var ErrInvalidDeviceToken = errors.New("invalid device token")
+
+// DeviceService registers API client devices and authenticates their tokens
+type DeviceService struct {
+	deviceRepo *repository.DeviceRepository
+}
+
+// NewDeviceService creates a new device service
+func NewDeviceService(deviceRepo *repository.DeviceRepository) *DeviceService {
+	return &DeviceService{
+		deviceRepo: deviceRepo,
+	}
+}
+
+// Register creates a device and returns it with its token. The token is only
+// available at registration time; only its hash is stored.
+func (s *DeviceService) Register(ctx context.Context, name, deviceType string, registeredBy *int) (*models.Device, string, error) {
+	token, err := generateToken("dev_")
+	if err != nil {
+		return nil, "", err
+	}
+
+	device := &models.Device{
+		Name:         name,
+		DeviceType:   deviceType,
+		TokenHash:    hashToken(token),
+		TokenPrefix:  token[:12],
+		RegisteredBy: registeredBy,
+	}
+	if err := s.deviceRepo.Create(ctx, device); err != nil {
+		return nil, "", err
+	}
+
+	return device, token, nil
+}
+
+// Authenticate resolves a device token to an active device and records it as seen
+func (s *DeviceService) Authenticate(ctx context.Context, token, ip string) (*models.Device, error) {
+	device, err := s.deviceRepo.GetByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidDeviceToken
+		}
+		return nil, err
+	}
+	if device.RevokedAt != nil {
+		return nil, ErrInvalidDeviceToken
+	}
+
+	if err := s.deviceRepo.Touch(ctx, device.DeviceID, ip); err != nil {
+		log.Printf("Failed to update last seen for device %d: %v", device.DeviceID, err)
+	}
+
+	return &device, nil
+}
+
+// generateToken returns a random bearer token with a recognizable prefix
+func generateToken(prefix string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
+
+// hashToken hashes a bearer token for storage and lookup
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// DiscountLine is a quotation or order line to discount. Type and Value are the discount
+// entered on the line, if any; a line with no Type but an Amount is discounted by that
+// amount, as lines were before discounts had types.
+type DiscountLine struct {
+	ProductID int
+	Quantity  int
+	UnitPrice float64
+	Type      string
+	Value     float64
+	Amount    float64
+}
+
+// LineDiscount is the discount worked out for a line: how it was set, the percent or amount
+// it was set as, and the amount taken off the line
+type LineDiscount struct {
+	Type   string
+	Value  float64
+	Amount float64
+}
+
+// Entered reports whether the discount was entered on the line rather than applied by pricing
+func (d LineDiscount) Entered() bool {
+	return d.Type == models.DiscountPercent || d.Type == models.DiscountAmount
+}
+
+// DiscountService works out line discounts: percentage and fixed amount discounts entered on
+// a line, and for lines entered without one, the better of the product's quantity break and
+// the customer's tier discount
+type DiscountService struct {
+	breakRepo *repository.QuantityBreakRepository
+}
+
+// NewDiscountService creates a new discount service
+func NewDiscountService(breakRepo *repository.QuantityBreakRepository) *DiscountService {
+	return &DiscountService{
+		breakRepo: breakRepo,
+	}
+}
+
+// QuantityBreaks returns a product's quantity breaks, smallest minimum quantity first
+func (s *DiscountService) QuantityBreaks(ctx context.Context, productID int) ([]models.QuantityBreak, error) {
+	return s.breakRepo.GetByProduct(ctx, productID)
+}
+
+// SetQuantityBreaks replaces a product's quantity breaks
+func (s *DiscountService) SetQuantityBreaks(ctx context.Context, productID int, breaks []models.QuantityBreak) error {
+	return s.breakRepo.ReplaceForProduct(ctx, productID, breaks)
+}
+
+// Apply works out the discount of each line for a customer of tier. A quantity break wins
+// over a tier discount of the same percentage.
+func (s *DiscountService) Apply(ctx context.Context, tier models.LoyaltyTier, lines []DiscountLine) ([]LineDiscount, error) {
+	productIDs := make([]int, len(lines))
+	for i, line := range lines {
+		productIDs[i] = line.ProductID
+	}
+	breaks, err := s.breakRepo.GetByProducts(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	discounts := make([]LineDiscount, len(lines))
+	for i, line := range lines {
+		gross := float64(line.Quantity) * line.UnitPrice
+		discount := LineDiscount{Type: line.Type, Value: line.Value}
+		if discount.Type == "" && line.Amount > 0 {
+			discount = LineDiscount{Type: models.DiscountAmount, Value: line.Amount}
+		}
+
+		switch discount.Type {
+		case models.DiscountPercent:
+			discount.Amount = roundMoney(gross * discount.Value / 100)
+		case models.DiscountAmount:
+			discount.Amount = roundMoney(discount.Value)
+		default:
+			discount = LineDiscount{Type: models.DiscountNone}
+			if percent := quantityBreak(breaks[line.ProductID], line.Quantity); percent > 0 {
+				discount = LineDiscount{Type: models.DiscountQuantityBreak, Value: percent}
+			}
+			if tier.DiscountPercent > discount.Value {
+				discount = LineDiscount{Type: models.DiscountTier, Value: tier.DiscountPercent}
+			}
+			discount.Amount = roundMoney(gross * discount.Value / 100)
+		}
+		discounts[i] = discount
+	}
+	return discounts, nil
+}
+
+// quantityBreak returns the percentage of the break with the highest minimum quantity reached,
+// or 0 when the quantity reaches none; breaks are sorted by minimum quantity
+func quantityBreak(breaks []models.QuantityBreak, quantity int) float64 {
+	percent := 0.0
+	for _, b := range breaks {
+		if quantity < b.MinQuantity {
+			break
+		}
+		percent = b.DiscountPercent
+	}
+	return percent
+}
+
+// FormatDiscount describes a line's discount for documents: the percentage of percent, tier
+// and quantity break discounts, the amount of fixed discounts in the document's currency,
+// or "-" for a line without one
+func FormatDiscount(currency models.Currency, discountType string, value float64) string {
+	switch discountType {
+	case models.DiscountPercent, models.DiscountTier, models.DiscountQuantityBreak:
+		return strconv.FormatFloat(value, 'f', -1, 64) + "%"
+	case models.DiscountAmount:
+		if value > 0 {
+			return FormatCurrency(currency, value)
+		}
+	}
+	return "-"
+}
@@ -0,0 +1,418 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"golang.org/x/oauth2"
+)
+
+// Supported document archive providers
+const (
+	ArchiveProviderGoogleDrive = "gdrive"
+	ArchiveProviderOneDrive    = "onedrive"
+)
+
+// ErrUnknownArchiveProvider is returned for providers that are not configured
+var ErrUnknownArchiveProvider = errors.New("unknown or unconfigured archive provider")
+
+// ArchiveDocument describes a generated PDF to copy into the external archive
+type ArchiveDocument struct {
+	CustomerName string
+	Year         int
+	FileName     string
+	Content      []byte
+}
+
+// ArchiveStore uploads a document into a folder path on an external drive
+type ArchiveStore interface {
+	Upload(ctx context.Context, client *http.Client, folders []string, fileName string, content []byte) error
+}
+
+// ArchiveProviderStatus describes whether a provider is configured and connected
+type ArchiveProviderStatus struct {
+	Provider   string     `json:"provider"`
+	Configured bool       `json:"configured"`
+	Connected  bool       `json:"connected"`
+	RootFolder string     `json:"root_folder"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// archiveProvider bundles the OAuth configuration and upload logic for one provider
+type archiveProvider struct {
+	oauth      *oauth2.Config
+	store      ArchiveStore
+	rootFolder string
+}
+
+// DocumentArchiver copies generated PDFs into Google Drive and/or OneDrive
+// using the folder structure <root>/<Customer>/<Year>/<Document>.pdf
+type DocumentArchiver struct {
+	integrationRepo *repository.IntegrationRepository
+	providers       map[string]*archiveProvider
+}
+
+// NewDocumentArchiverFromEnv creates an archiver for every provider with client credentials in the environment
+//
+//	GDRIVE_CLIENT_ID, GDRIVE_CLIENT_SECRET, GDRIVE_REDIRECT_URL, GDRIVE_ROOT_FOLDER
+//	ONEDRIVE_CLIENT_ID, ONEDRIVE_CLIENT_SECRET, ONEDRIVE_REDIRECT_URL, ONEDRIVE_ROOT_FOLDER, ONEDRIVE_TENANT
+func NewDocumentArchiverFromEnv(integrationRepo *repository.IntegrationRepository) *DocumentArchiver {
+	archiver := &DocumentArchiver{
+		integrationRepo: integrationRepo,
+		providers:       map[string]*archiveProvider{},
+	}
+
+	if clientID := os.Getenv("GDRIVE_CLIENT_ID"); clientID != "" {
+		archiver.providers[ArchiveProviderGoogleDrive] = &archiveProvider{
+			oauth: &oauth2.Config{
+				ClientID:     clientID,
+				ClientSecret: os.Getenv("GDRIVE_CLIENT_SECRET"),
+				RedirectURL:  os.Getenv("GDRIVE_REDIRECT_URL"),
+				Scopes:       []string{"https://www.googleapis.com/auth/drive.file"},
+				Endpoint: oauth2.Endpoint{
+					AuthURL:  "https://accounts.google.com/o/oauth2/auth",
+					TokenURL: "https://oauth2.googleapis.com/token",
+				},
+			},
+			store:      &GoogleDriveStore{},
+			rootFolder: envOrDefault("GDRIVE_ROOT_FOLDER", "SCMS Documents"),
+		}
+	}
+
+	if clientID := os.Getenv("ONEDRIVE_CLIENT_ID"); clientID != "" {
+		tenant := envOrDefault("ONEDRIVE_TENANT", "common")
+		archiver.providers[ArchiveProviderOneDrive] = &archiveProvider{
+			oauth: &oauth2.Config{
+				ClientID:     clientID,
+				ClientSecret: os.Getenv("ONEDRIVE_CLIENT_SECRET"),
+				RedirectURL:  os.Getenv("ONEDRIVE_REDIRECT_URL"),
+				Scopes:       []string{"offline_access", "Files.ReadWrite"},
+				Endpoint: oauth2.Endpoint{
+					AuthURL:  "https://login.microsoftonline.com/" + tenant + "/oauth2/v2.0/authorize",
+					TokenURL: "https://login.microsoftonline.com/" + tenant + "/oauth2/v2.0/token",
+				},
+			},
+			store:      &OneDriveStore{},
+			rootFolder: envOrDefault("ONEDRIVE_ROOT_FOLDER", "SCMS Documents"),
+		}
+	}
+
+	return archiver
+}
+
+// envOrDefault returns the environment variable or def when it is unset
+func envOrDefault(key, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
+
+// Status lists every supported provider with its configuration and connection state
+func (a *DocumentArchiver) Status(ctx context.Context) ([]ArchiveProviderStatus, error) {
+	credentials, err := a.integrationRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	connected := map[string]models.IntegrationCredential{}
+	for _, credential := range credentials {
+		connected[credential.Provider] = credential
+	}
+
+	statuses := []ArchiveProviderStatus{}
+	for _, name := range []string{ArchiveProviderGoogleDrive, ArchiveProviderOneDrive} {
+		status := ArchiveProviderStatus{Provider: name}
+		if provider, ok := a.providers[name]; ok {
+			status.Configured = true
+			status.RootFolder = provider.rootFolder
+		}
+		if credential, ok := connected[name]; ok {
+			status.Connected = true
+			status.ExpiresAt = credential.ExpiresAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// AuthCodeURL returns the consent page URL used to connect a provider
+func (a *DocumentArchiver) AuthCodeURL(provider, state string) (string, error) {
+	p, ok := a.providers[provider]
+	if !ok {
+		return "", ErrUnknownArchiveProvider
+	}
+	// Offline access with forced consent so we always receive a refresh token
+	return p.oauth.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.SetAuthURLParam("prompt", "consent")), nil
+}
+
+// Connect exchanges an authorization code and stores the resulting token
+func (a *DocumentArchiver) Connect(ctx context.Context, provider, code string) error {
+	p, ok := a.providers[provider]
+	if !ok {
+		return ErrUnknownArchiveProvider
+	}
+
+	token, err := p.oauth.Exchange(ctx, code)
+	if err != nil {
+		return fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+
+	return a.saveToken(ctx, provider, token)
+}
+
+// Disconnect removes the stored credential for a provider
+func (a *DocumentArchiver) Disconnect(ctx context.Context, provider string) error {
+	return a.integrationRepo.Delete(ctx, provider)
+}
+
+// ArchiveAsync copies the document to every connected provider in the background
+func (a *DocumentArchiver) ArchiveAsync(doc ArchiveDocument) {
+	if len(a.providers) == 0 {
+		return
+	}
+	goBackground(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+		a.Archive(ctx, doc)
+	})
+}
+
+// Archive copies the document to every connected provider, logging failures per provider
+func (a *DocumentArchiver) Archive(ctx context.Context, doc ArchiveDocument) {
+	for name, provider := range a.providers {
+		credential, err := a.integrationRepo.GetByProvider(ctx, name)
+		if err != nil {
+			// Provider is configured but nobody has connected an account yet
+			continue
+		}
+
+		client, err := a.client(ctx, name, provider, credential)
+		if err != nil {
+			log.Printf("Failed to prepare %s client: %v", name, err)
+			continue
+		}
+
+		folders := []string{
+			provider.rootFolder,
+			sanitizeArchiveName(doc.CustomerName),
+			fmt.Sprintf("%d", doc.Year),
+		}
+		if err := provider.store.Upload(ctx, client, folders, sanitizeArchiveName(doc.FileName), doc.Content); err != nil {
+			log.Printf("Failed to archive %s to %s: %v", doc.FileName, name, err)
+			continue
+		}
+		log.Printf("Archived %s to %s under %s", doc.FileName, name, strings.Join(folders, "/"))
+	}
+}
+
+// client returns an HTTP client that refreshes and persists the provider token as needed
+func (a *DocumentArchiver) client(ctx context.Context, name string, provider *archiveProvider, credential models.IntegrationCredential) (*http.Client, error) {
+	token := &oauth2.Token{
+		AccessToken:  credential.AccessToken,
+		RefreshToken: credential.RefreshToken,
+		TokenType:    credential.TokenType,
+	}
+	if credential.ExpiresAt != nil {
+		token.Expiry = *credential.ExpiresAt
+	}
+
+	fresh, err := provider.oauth.TokenSource(ctx, token).Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh token: %w", err)
+	}
+	if fresh.AccessToken != token.AccessToken {
+		if err := a.saveToken(ctx, name, fresh); err != nil {
+			log.Printf("Failed to persist refreshed %s token: %v", name, err)
+		}
+	}
+
+	return oauth2.NewClient(ctx, oauth2.StaticTokenSource(fresh)), nil
+}
+
+// saveToken persists an OAuth token for a provider
+func (a *DocumentArchiver) saveToken(ctx context.Context, provider string, token *oauth2.Token) error {
+	credential := models.IntegrationCredential{
+		Provider:     provider,
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+		TokenType:    token.Type(),
+	}
+	if !token.Expiry.IsZero() {
+		expiry := token.Expiry
+		credential.ExpiresAt = &expiry
+	}
+	return a.integrationRepo.Save(ctx, &credential)
+}
+
+// sanitizeArchiveName strips characters that Drive and OneDrive reject in names
+func sanitizeArchiveName(name string) string {
+	replacer := strings.NewReplacer(
+		"/", "-", "\\", "-", ":", "-", "*", "", "?", "", "\"", "", "<", "", ">", "", "|", "",
+	)
+	cleaned := strings.TrimSpace(replacer.Replace(name))
+	cleaned = strings.Trim(cleaned, ".")
+	if cleaned == "" {
+		return "Unnamed"
+	}
+	return cleaned
+}
+
+// GoogleDriveStore uploads files through the Google Drive v3 API
+type GoogleDriveStore struct{}
+
+const googleDriveFolderMime = "application/vnd.google-apps.folder"
+
+// Upload creates missing folders and uploads the file into the last one
+func (s *GoogleDriveStore) Upload(ctx context.Context, client *http.Client, folders []string, fileName string, content []byte) error {
+	parentID := "root"
+	for _, folder := range folders {
+		id, err := s.ensureFolder(ctx, client, parentID, folder)
+		if err != nil {
+			return err
+		}
+		parentID = id
+	}
+
+	metadata, err := json.Marshal(map[string]interface{}{
+		"name":     fileName,
+		"parents":  []string{parentID},
+		"mimeType": "application/pdf",
+	})
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	metaHeader := textproto.MIMEHeader{}
+	metaHeader.Set("Content-Type", "application/json; charset=UTF-8")
+	metaPart, err := writer.CreatePart(metaHeader)
+	if err != nil {
+		return err
+	}
+	metaPart.Write(metadata)
+
+	fileHeader := textproto.MIMEHeader{}
+	fileHeader.Set("Content-Type", "application/pdf")
+	filePart, err := writer.CreatePart(fileHeader)
+	if err != nil {
+		return err
+	}
+	filePart.Write(content)
+	writer.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		"https://www.googleapis.com/upload/drive/v3/files?uploadType=multipart", &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "multipart/related; boundary="+writer.Boundary())
+
+	return doArchiveRequest(client, req, nil)
+}
+
+// ensureFolder returns the ID of the named folder under parentID, creating it when missing
+func (s *GoogleDriveStore) ensureFolder(ctx context.Context, client *http.Client, parentID, name string) (string, error) {
+	escaped := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(name)
+	query := fmt.Sprintf("name = '%s' and mimeType = '%s' and '%s' in parents and trashed = false",
+		escaped, googleDriveFolderMime, parentID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		"https://www.googleapis.com/drive/v3/files?fields=files(id)&q="+url.QueryEscape(query), nil)
+	if err != nil {
+		return "", err
+	}
+
+	var list struct {
+		Files []struct {
+			ID string `json:"id"`
+		} `json:"files"`
+	}
+	if err := doArchiveRequest(client, req, &list); err != nil {
+		return "", fmt.Errorf("failed to look up folder %q: %w", name, err)
+	}
+	if len(list.Files) > 0 {
+		return list.Files[0].ID, nil
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"name":     name,
+		"mimeType": googleDriveFolderMime,
+		"parents":  []string{parentID},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodPost,
+		"https://www.googleapis.com/drive/v3/files?fields=id", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := doArchiveRequest(client, req, &created); err != nil {
+		return "", fmt.Errorf("failed to create folder %q: %w", name, err)
+	}
+	return created.ID, nil
+}
+
+// OneDriveStore uploads files through the Microsoft Graph API
+type OneDriveStore struct{}
+
+// Upload writes the file by path; Graph creates intermediate folders automatically
+func (s *OneDriveStore) Upload(ctx context.Context, client *http.Client, folders []string, fileName string, content []byte) error {
+	segments := make([]string, 0, len(folders)+1)
+	for _, folder := range folders {
+		segments = append(segments, url.PathEscape(folder))
+	}
+	segments = append(segments, url.PathEscape(fileName))
+
+	endpoint := "https://graph.microsoft.com/v1.0/me/drive/root:/" + strings.Join(segments, "/") +
+		":/content?@microsoft.graph.conflictBehavior=replace"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/pdf")
+
+	return doArchiveRequest(client, req, nil)
+}
+
+// doArchiveRequest executes a request and optionally decodes a JSON response
+func doArchiveRequest(client *http.Client, req *http.Request, out interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"mime/multipart"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// JobScanDocument is the background job that reads an uploaded document scan; see
+// DocumentScanService.ScanJob
+const JobScanDocument = "document.scan"
+
+// scanJob is the payload of a JobScanDocument job
+type scanJob struct {
+	ScanID int `json:"scan_id"`
+}
+
+// DocumentScanService reads supplier invoices and customer purchase orders uploaded as
+// images or PDFs with the configured OCR provider, and drafts what they say for review: the
+// supplier or customer, document number, date, total and line items, matched to records
+// where the text names them.
+type DocumentScanService struct {
+	scanRepo          *repository.DocumentScanRepository
+	attachmentService *AttachmentService
+	jobQueue          *JobQueue
+	ocr               OCRProvider
+}
+
+// NewDocumentScanService creates a new document scan service. ocr is nil when OCR is
+// disabled; scans can then not be uploaded.
+func NewDocumentScanService(scanRepo *repository.DocumentScanRepository, attachmentService *AttachmentService, jobQueue *JobQueue, ocr OCRProvider) *DocumentScanService {
+	return &DocumentScanService{
+		scanRepo:          scanRepo,
+		attachmentService: attachmentService,
+		jobQueue:          jobQueue,
+		ocr:               ocr,
+	}
+}
+
+// Upload stores a scanned document and queues it to be read. The scan is removed again when
+// its file cannot be stored, returning the attachment service's error.
+func (s *DocumentScanService) Upload(ctx context.Context, kind string, file *multipart.FileHeader, uploadedBy *int) (models.DocumentScan, error) {
+	scan := models.DocumentScan{Kind: kind, Status: models.ScanProcessing, CreatedBy: uploadedBy}
+	if s.ocr == nil {
+		return scan, ErrOCRDisabled
+	}
+	if err := s.scanRepo.Create(ctx, &scan); err != nil {
+		return scan, err
+	}
+
+	attachment, err := s.attachmentService.Save(ctx, models.AuditEntityDocumentScan, scan.ScanID, models.AttachmentScan, file, uploadedBy)
+	if err != nil {
+		if delErr := s.scanRepo.Delete(ctx, scan.ScanID); delErr != nil {
+			log.Printf("Failed to remove document scan %d: %v", scan.ScanID, delErr)
+		}
+		return scan, err
+	}
+	if err := s.scanRepo.SetAttachment(ctx, scan.ScanID, attachment.AttachmentID); err != nil {
+		return scan, err
+	}
+	scan.AttachmentID = &attachment.AttachmentID
+
+	if _, err := s.jobQueue.Enqueue(ctx, JobScanDocument, scanJob{ScanID: scan.ScanID}); err != nil {
+		return scan, err
+	}
+	return scan, nil
+}
+
+// ScanJob reads the document scan a JobScanDocument job names and saves its draft for
+// review. Files that cannot be read are marked Failed rather than retried.
+func (s *DocumentScanService) ScanJob(ctx context.Context, payload json.RawMessage) error {
+	var job scanJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return fmt.Errorf("invalid document scan job: %w", err)
+	}
+
+	scan, err := s.scanRepo.GetByID(ctx, job.ScanID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if scan.Status != models.ScanProcessing {
+		return nil
+	}
+	if scan.AttachmentID == nil || s.ocr == nil {
+		return s.scanRepo.MarkFailed(ctx, scan.ScanID, "The document could not be read")
+	}
+
+	attachment, err := s.attachmentService.Get(ctx, *scan.AttachmentID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return s.scanRepo.MarkFailed(ctx, scan.ScanID, "The uploaded file has been removed")
+	}
+	if err != nil {
+		return err
+	}
+
+	text, err := s.ocr.Recognize(ctx, attachment.Content, attachment.ContentType)
+	if errors.Is(err, ErrOCRUnsupported) || errors.Is(err, ErrOCRDisabled) {
+		return s.scanRepo.MarkFailed(ctx, scan.ScanID, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(text) == "" {
+		return s.scanRepo.MarkFailed(ctx, scan.ScanID, "No text was found in the document")
+	}
+
+	scan.Provider = s.ocr.Name()
+	scan.Text = text
+	draft := ParseScanText(scan.Kind, text)
+	scan.DocumentNumber = draft.DocumentNumber
+	scan.DocumentDate = draft.DocumentDate
+	scan.TotalAmount = draft.TotalAmount
+	scan.Lines = draft.Lines
+	if err := s.match(ctx, &scan, draft); err != nil {
+		return err
+	}
+	return s.scanRepo.SaveDraft(ctx, &scan)
+}
+
+// match links a scan's draft to the supplier and purchase order or customer its text names,
+// and its lines to products
+func (s *DocumentScanService) match(ctx context.Context, scan *models.DocumentScan, draft ScanDraft) error {
+	var err error
+	switch scan.Kind {
+	case models.ScanSupplierInvoice:
+		if scan.SupplierID, err = s.scanRepo.MatchSupplier(ctx, scan.Text); err != nil {
+			return err
+		}
+		if scan.PurchaseOrderID, err = s.scanRepo.MatchPurchaseOrder(ctx, draft.PurchaseOrderRefs, scan.SupplierID); err != nil {
+			return err
+		}
+	case models.ScanCustomerPO:
+		if scan.CustomerID, err = s.scanRepo.MatchCustomer(ctx, scan.Text); err != nil {
+			return err
+		}
+	}
+
+	for i := range scan.Lines {
+		if scan.Lines[i].ProductID, err = s.scanRepo.MatchProduct(ctx, scan.Lines[i].Description, scan.SupplierID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScanDraft is what ParseScanText reads from a document's text. PurchaseOrderRefs are the
+// purchase order numbers it mentions, such as our PO-123 references on supplier invoices.
+type ScanDraft struct {
+	DocumentNumber    *string
+	DocumentDate      *time.Time
+	TotalAmount       *float64
+	Lines             models.ScanLines
+	PurchaseOrderRefs []int
+}
+
+var (
+	// "Invoice No: SI-0042" and "P.O. #: 7781", "Order Number 12": the numbers of supplier
+	// invoices and of customer purchase orders
+	scanNumberPatterns = map[string]*regexp.Regexp{
+		models.ScanSupplierInvoice: regexp.MustCompile(`(?i)\b(?:invoice|inv)\s*(?:no\.?|number|#)\s*[:#.]?\s*([A-Z0-9][A-Z0-9/-]*[0-9][A-Z0-9/-]*)`),
+		models.ScanCustomerPO:      regexp.MustCompile(`(?i)\b(?:p\.?\s?o\.?|purchase\s+order|order)\s*(?:no\.?|number|#)\s*[:#.]?\s*([A-Z0-9][A-Z0-9/-]*[0-9][A-Z0-9/-]*)`),
+	}
+
+	// "PO-123", "P.O. No. 123", "Purchase Order #123"
+	scanPORefPattern = regexp.MustCompile(`(?i)\b(?:p\.?\s?o\.?|purchase\s+order)\s*(?:no\.?|number|#)?\s*[:#.-]?\s*(\d{1,9})\b`)
+
+	scanDatePattern = regexp.MustCompile(`\b(\d{4}-\d{2}-\d{2}|\d{1,2}/\d{1,2}/\d{4}|[A-Za-z]{3,9}\.? \d{1,2},? \d{4}|\d{1,2} [A-Za-z]{3,9}\.? \d{4})\b`)
+
+	scanAmountPattern = regexp.MustCompile(`\d{1,3}(?:,\d{3})+(?:\.\d{2})?|\d+\.\d{2}`)
+
+	// "2  Safety Helmet, yellow   10 pcs   450.00   4,500.00": an optional line number, the
+	// description, quantity with an optional unit, unit price and line total
+	scanLinePattern = regexp.MustCompile(`^\s*(?:\d{1,3}[.)]?\s+)?(.*?[A-Za-z].*?)\s+(\d{1,6})\s*(?:pcs?|units?|ea|sets?|boxes|box|x)?\.?\s+(?:PHP|P|₱)?\s*(\d{1,3}(?:,\d{3})*(?:\.\d{1,2})?|\d+(?:\.\d{1,2})?)\s+(?:PHP|P|₱)?\s*(\d{1,3}(?:,\d{3})*(?:\.\d{1,2})?|\d+(?:\.\d{1,2})?)\s*$`)
+
+	scanTotalPattern    = regexp.MustCompile(`(?i)\b(grand\s+total|total\s+amount\s+due|amount\s+due|total\s+amount|total\s+due|total)\b`)
+	scanSubtotalPattern = regexp.MustCompile(`(?i)\b(sub\s*-?\s*total|vat|tax|discount|less)\b`)
+)
+
+// scanDateLayouts are the date formats read from documents; numeric dates are month first
+var scanDateLayouts = []string{"2006-01-02", "1/2/2006", "January 2, 2006", "January 2 2006", "Jan 2, 2006", "Jan 2 2006", "Jan. 2, 2006", "2 January 2006", "2 Jan 2006"}
+
+// ParseScanText drafts a document of a kind from its OCR text. Text is rarely read
+// perfectly, so what is found is only a starting point for review: lines are read from rows
+// ending in a quantity, unit price and line total, and the total from the last grand total,
+// amount due or total.
+func ParseScanText(kind, text string) ScanDraft {
+	draft := ScanDraft{Lines: models.ScanLines{}}
+	rows := strings.Split(strings.ReplaceAll(text, "\r", ""), "\n")
+
+	if m := scanNumberPatterns[kind].FindStringSubmatch(text); m != nil {
+		number := strings.TrimSpace(m[1])
+		draft.DocumentNumber = &number
+	}
+
+	// Prefer a date on a row that says it is the date, then the first date anywhere
+	for _, row := range rows {
+		if date := scanDate(row); date != nil && strings.Contains(strings.ToLower(row), "date") {
+			draft.DocumentDate = date
+			break
+		}
+	}
+	if draft.DocumentDate == nil {
+		draft.DocumentDate = scanDate(text)
+	}
+
+	seen := map[int]bool{}
+	for _, m := range scanPORefPattern.FindAllStringSubmatch(text, -1) {
+		if id, err := strconv.Atoi(m[1]); err == nil && !seen[id] {
+			seen[id] = true
+			draft.PurchaseOrderRefs = append(draft.PurchaseOrderRefs, id)
+		}
+	}
+
+	bestRank := len(scanTotalLabels)
+	for _, row := range rows {
+		if label := scanTotalPattern.FindString(row); label != "" && !scanSubtotalPattern.MatchString(row) {
+			amounts := scanAmountPattern.FindAllString(row, -1)
+			if len(amounts) == 0 {
+				continue
+			}
+			// Later rows win over earlier ones of the same kind; totals come last
+			if rank := scanTotalRank(label); rank <= bestRank {
+				bestRank = rank
+				total := scanAmount(amounts[len(amounts)-1])
+				draft.TotalAmount = &total
+			}
+			continue
+		}
+
+		m := scanLinePattern.FindStringSubmatch(row)
+		if m == nil {
+			continue
+		}
+		quantity, _ := strconv.Atoi(m[2])
+		unitPrice, lineTotal := scanAmount(m[3]), scanAmount(m[4])
+		if quantity == 0 {
+			continue
+		}
+		// When the unit price was misread but the line total was not, work it out instead
+		if math.Abs(float64(quantity)*unitPrice-lineTotal) > 0.01*lineTotal+0.05 {
+			unitPrice = math.Round(lineTotal/float64(quantity)*100) / 100
+		}
+		draft.Lines = append(draft.Lines, models.ScanLine{
+			Description: strings.TrimSpace(m[1]),
+			Quantity:    quantity,
+			UnitPrice:   unitPrice,
+		})
+	}
+	return draft
+}
+
+// scanTotalLabels ranks the labels of a document's total, most telling first
+var scanTotalLabels = []string{"grand total", "total amount due", "amount due", "total amount", "total due", "total"}
+
+// scanTotalRank returns where a total label ranks in scanTotalLabels
+func scanTotalRank(label string) int {
+	label = strings.Join(strings.Fields(strings.ToLower(label)), " ")
+	for i, l := range scanTotalLabels {
+		if l == label {
+			return i
+		}
+	}
+	return len(scanTotalLabels)
+}
+
+// scanDate returns the first date in a text, or nil
+func scanDate(text string) *time.Time {
+	for _, m := range scanDatePattern.FindAllString(text, -1) {
+		for _, layout := range scanDateLayouts {
+			if date, err := time.Parse(layout, m); err == nil {
+				return &date
+			}
+		}
+	}
+	return nil
+}
+
+// scanAmount parses an amount written with thousands separators
+func scanAmount(s string) float64 {
+	amount, _ := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+	return amount
+}
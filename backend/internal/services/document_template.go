@@ -0,0 +1,130 @@
+package services
+
+import (
+	"errors"
+	"html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrTemplateNotFound is returned for a document template that does not exist
+var ErrTemplateNotFound = errors.New("document template not found")
+
+// TemplateParseError is returned when saving a document template that does not parse, so
+// documents keep rendering with the last good version
+type TemplateParseError struct {
+	Err error
+}
+
+// Error implements error
+func (e *TemplateParseError) Error() string {
+	return e.Err.Error()
+}
+
+// DocumentTemplate is an HTML template documents are rendered from, named by its path in
+// the templates directory, such as "invoice/template.html". Content is left out of lists.
+type DocumentTemplate struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Content   string    `json:"content,omitempty"`
+}
+
+// templatePath resolves a template name to its file, refusing names that are not HTML files
+// inside the templates directory
+func (g *PDFGenerator) templatePath(name string) (string, error) {
+	name = filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) || filepath.Ext(name) != ".html" {
+		return "", ErrTemplateNotFound
+	}
+	return filepath.Join(g.templateDir, name), nil
+}
+
+// Templates lists the document templates, by name
+func (g *PDFGenerator) Templates() ([]DocumentTemplate, error) {
+	templates := []DocumentTemplate{}
+	err := filepath.WalkDir(g.templateDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Ext(path) != ".html" {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(g.templateDir, path)
+		if err != nil {
+			return err
+		}
+		templates = append(templates, DocumentTemplate{
+			Name:      filepath.ToSlash(name),
+			Size:      info.Size(),
+			UpdatedAt: info.ModTime(),
+		})
+		return nil
+	})
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, err
+}
+
+// Template reads a document template with its content
+func (g *PDFGenerator) Template(name string) (DocumentTemplate, error) {
+	path, err := g.templatePath(name)
+	if err != nil {
+		return DocumentTemplate{}, err
+	}
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && info.IsDir()) {
+		return DocumentTemplate{}, ErrTemplateNotFound
+	}
+	if err != nil {
+		return DocumentTemplate{}, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return DocumentTemplate{}, err
+	}
+	return DocumentTemplate{
+		Name:      filepath.ToSlash(filepath.Clean(filepath.FromSlash(name))),
+		Size:      info.Size(),
+		UpdatedAt: info.ModTime(),
+		Content:   string(content),
+	}, nil
+}
+
+// SaveTemplate replaces the content of an existing document template. The content must
+// parse, or a *TemplateParseError is returned and the template is left as it was. The file
+// is replaced in one step so documents rendered meanwhile see either version whole.
+func (g *PDFGenerator) SaveTemplate(name, content string) (DocumentTemplate, error) {
+	current, err := g.Template(name)
+	if err != nil {
+		return DocumentTemplate{}, err
+	}
+	if _, err := template.New(filepath.Base(current.Name)).Funcs(templateFuncs).Parse(content); err != nil {
+		return DocumentTemplate{}, &TemplateParseError{Err: err}
+	}
+
+	path, _ := g.templatePath(current.Name)
+	file, err := os.CreateTemp(filepath.Dir(path), ".template-*")
+	if err != nil {
+		return DocumentTemplate{}, err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(content); err != nil {
+		file.Close()
+		return DocumentTemplate{}, err
+	}
+	if err := file.Close(); err != nil {
+		return DocumentTemplate{}, err
+	}
+	if err := os.Chmod(file.Name(), 0o644); err != nil {
+		return DocumentTemplate{}, err
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return DocumentTemplate{}, err
+	}
+	return g.Template(current.Name)
+}
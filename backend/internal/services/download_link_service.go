@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// downloadTokenPrefix starts every download link token
+const downloadTokenPrefix = "dl_"
+
+// DownloadLinkService issues the links documents are shared by outside the app. A link's token
+// is 32 random bytes, so it cannot be guessed; only its hash is stored.
+type DownloadLinkService struct {
+	downloadLinkRepo *repository.DownloadLinkRepository
+}
+
+// NewDownloadLinkService creates a new download link service
+func NewDownloadLinkService(downloadLinkRepo *repository.DownloadLinkRepository) *DownloadLinkService {
+	return &DownloadLinkService{
+		downloadLinkRepo: downloadLinkRepo,
+	}
+}
+
+// Create issues a link to a document valid until expiresAt and returns it with its token.
+// The token is only available now.
+func (s *DownloadLinkService) Create(ctx context.Context, documentType string, documentID int, expiresAt time.Time, singleUse bool, createdBy *int) (*models.DownloadLink, string, error) {
+	token, err := generateToken(downloadTokenPrefix)
+	if err != nil {
+		return nil, "", err
+	}
+
+	link := &models.DownloadLink{
+		TokenHash:    hashToken(token),
+		DocumentType: documentType,
+		DocumentID:   documentID,
+		SingleUse:    singleUse,
+		ExpiresAt:    expiresAt,
+		CreatedBy:    createdBy,
+	}
+	if err := s.downloadLinkRepo.Create(ctx, link); err != nil {
+		return nil, "", err
+	}
+
+	return link, token, nil
+}
+
+// Use resolves a token to its link and counts the download. Unknown tokens are not found and
+// spent ones return repository.ErrDownloadLinkUnavailable.
+func (s *DownloadLinkService) Use(ctx context.Context, token string) (models.DownloadLink, error) {
+	token = strings.TrimSpace(token)
+	if !strings.HasPrefix(token, downloadTokenPrefix) {
+		return models.DownloadLink{}, &repository.NotFoundError{Entity: "download link"}
+	}
+	return s.downloadLinkRepo.Use(ctx, hashToken(token))
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTP connection security modes
+const (
+	SMTPStartTLS = "starttls"
+	SMTPTLS      = "tls"
+	SMTPNone     = "none"
+)
+
+// ErrEmailDisabled is returned when no SMTP server is configured
+var ErrEmailDisabled = errors.New("email is not configured")
+
+// JobSendEmail is the background job that sends an EmailMessage; see EmailService.SendJob
+const JobSendEmail = "email.send"
+
+// EmailConfig says which SMTP server mail is sent through and who it is from
+type EmailConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	Security string
+}
+
+// EmailConfigFromEnv builds the email configuration from environment variables
+//
+//	SMTP_HOST                    the SMTP server (default: email disabled)
+//	SMTP_PORT                    its port (default 587, or 465 with SMTP_SECURITY=tls)
+//	SMTP_USERNAME, SMTP_PASSWORD credentials, when the server requires them
+//	SMTP_FROM                    the sender, such as "CISC Sales <sales@example.com>"
+//	SMTP_SECURITY                starttls (default), tls for implicit TLS, or none
+func EmailConfigFromEnv() EmailConfig {
+	cfg := EmailConfig{
+		Host:     strings.TrimSpace(os.Getenv("SMTP_HOST")),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     strings.TrimSpace(os.Getenv("SMTP_FROM")),
+		Security: strings.ToLower(envOrDefault("SMTP_SECURITY", SMTPStartTLS)),
+	}
+	cfg.Port = 587
+	if cfg.Security == SMTPTLS {
+		cfg.Port = 465
+	}
+	if port, err := strconv.Atoi(os.Getenv("SMTP_PORT")); err == nil {
+		cfg.Port = port
+	}
+	return cfg
+}
+
+// EmailAttachment is a file attached to an email
+type EmailAttachment struct {
+	FileName    string
+	ContentType string
+	Content     []byte
+}
+
+// EmailMessage is a plain text email with optional attachments
+type EmailMessage struct {
+	To          []mail.Address
+	Subject     string
+	Text        string
+	Attachments []EmailAttachment
+}
+
+// EmailService sends email through the configured SMTP server
+type EmailService struct {
+	cfg  EmailConfig
+	from *mail.Address
+}
+
+// NewEmailService creates the email service. Without an SMTP host it sends nothing and
+// Send returns ErrEmailDisabled.
+func NewEmailService(cfg EmailConfig) (*EmailService, error) {
+	service := &EmailService{cfg: cfg}
+	if cfg.Host == "" {
+		return service, nil
+	}
+
+	switch cfg.Security {
+	case SMTPStartTLS, SMTPTLS, SMTPNone:
+	default:
+		return nil, fmt.Errorf("SMTP_SECURITY must be %s, %s or %s", SMTPStartTLS, SMTPTLS, SMTPNone)
+	}
+	if cfg.Port <= 0 || cfg.Port > 65535 {
+		return nil, fmt.Errorf("SMTP_PORT must be a port number")
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("SMTP_FROM must be an email address: %w", err)
+	}
+	service.from = from
+	return service, nil
+}
+
+// Enabled reports whether an SMTP server is configured
+func (s *EmailService) Enabled() bool {
+	return s.from != nil
+}
+
+// Sender is the address mail is sent from
+func (s *EmailService) Sender() string {
+	if s.from == nil {
+		return ""
+	}
+	return s.from.Address
+}
+
+// SendJob sends the EmailMessage a JobSendEmail job holds, so mail queued with JobQueue
+// is retried while the SMTP server is unreachable
+func (s *EmailService) SendJob(ctx context.Context, payload json.RawMessage) error {
+	var msg EmailMessage
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return fmt.Errorf("invalid email job: %w", err)
+	}
+	return s.Send(ctx, msg)
+}
+
+// Send delivers a message, returning once the SMTP server has accepted it
+func (s *EmailService) Send(ctx context.Context, msg EmailMessage) error {
+	if !s.Enabled() {
+		return ErrEmailDisabled
+	}
+	if len(msg.To) == 0 {
+		return errors.New("email has no recipients")
+	}
+	body, err := s.compose(msg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	dialer := &net.Dialer{Timeout: 15 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("connecting to %s: %w", addr, err)
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	tlsConfig := &tls.Config{ServerName: s.cfg.Host, MinVersion: tls.VersionTLS12}
+	if s.cfg.Security == SMTPTLS {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	client, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("greeting %s: %w", addr, err)
+	}
+	defer client.Close()
+
+	if s.cfg.Security == SMTPStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("%s does not support STARTTLS; set SMTP_SECURITY", addr)
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("starting TLS: %w", err)
+		}
+	}
+	if s.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return fmt.Errorf("authenticating: %w", err)
+		}
+	}
+
+	if err := client.Mail(s.from.Address); err != nil {
+		return err
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(to.Address); err != nil {
+			return fmt.Errorf("recipient %s refused: %w", to.Address, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// compose renders a message as MIME: a quoted-printable text part followed by the
+// base64-encoded attachments
+func (s *EmailService) compose(msg EmailMessage) ([]byte, error) {
+	var buf bytes.Buffer
+	parts := multipart.NewWriter(&buf)
+
+	to := make([]string, len(msg.To))
+	for i, address := range msg.To {
+		to[i] = address.String()
+	}
+	id := make([]byte, 16)
+	rand.Read(id)
+	domain := s.from.Address[strings.LastIndex(s.from.Address, "@")+1:]
+
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	header("From", s.from.String())
+	header("To", strings.Join(to, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", "<"+hex.EncodeToString(id)+"@"+domain+">")
+	header("MIME-Version", "1.0")
+	header("Content-Type", "multipart/mixed; boundary="+parts.Boundary())
+	buf.WriteString("\r\n")
+
+	text, err := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	encoder := quotedprintable.NewWriter(text)
+	if _, err := encoder.Write([]byte(msg.Text)); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	for _, attachment := range msg.Attachments {
+		contentType := attachment.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		part, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(contentType, map[string]string{"name": attachment.FileName})},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoded := base64.StdEncoding.EncodeToString(attachment.Content)
+		for len(encoded) > 76 {
+			part.Write([]byte(encoded[:76] + "\r\n"))
+			encoded = encoded[76:]
+		}
+		part.Write([]byte(encoded + "\r\n"))
+	}
+
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,307 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// ErrorReporterConfig says where errors are reported. DSN is the project's Sentry DSN, such
+// as https://key@glitchtip.example.com/3; reporting is off without one.
+type ErrorReporterConfig struct {
+	DSN         string
+	Environment string
+	Release     string
+}
+
+// ErrorReporterConfigFromEnv builds the reporter configuration from environment variables
+//
+//	SENTRY_DSN            the project DSN of a Sentry or GlitchTip server (default: not reported)
+//	SENTRY_ENVIRONMENT    the environment errors are tagged with (default production)
+//	SENTRY_RELEASE        the release errors are tagged with (default: the commit built from)
+func ErrorReporterConfigFromEnv() ErrorReporterConfig {
+	cfg := ErrorReporterConfig{
+		DSN:         strings.TrimSpace(os.Getenv("SENTRY_DSN")),
+		Environment: strings.TrimSpace(os.Getenv("SENTRY_ENVIRONMENT")),
+		Release:     strings.TrimSpace(os.Getenv("SENTRY_RELEASE")),
+	}
+	if cfg.Environment == "" {
+		cfg.Environment = "production"
+	}
+	if cfg.Release == "" {
+		cfg.Release = buildRevision()
+	}
+	return cfg
+}
+
+// buildRevision returns the commit the binary was built from, if the build recorded it
+func buildRevision() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" && len(setting.Value) >= 12 {
+			return setting.Value[:12]
+		}
+	}
+	return ""
+}
+
+// PanicError is a panic recovered while serving a request, with the stack it was raised on
+type PanicError struct {
+	Err   error
+	Stack []byte
+	pcs   []uintptr
+}
+
+// NewPanicError records a recovered panic. Call it from the function that recovered, so the
+// stack still holds the frames that panicked.
+func NewPanicError(err error, stack []byte) *PanicError {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(2, pcs)
+	return &PanicError{Err: err, Stack: stack, pcs: pcs[:n]}
+}
+
+func (e *PanicError) Error() string {
+	return "panic: " + e.Err.Error()
+}
+
+func (e *PanicError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorRequest is the request an error happened in, with secrets such as credentials and
+// cookies already left out
+type ErrorRequest struct {
+	Method    string
+	URL       string
+	Query     string
+	Route     string
+	Headers   map[string]string
+	IPAddress string
+}
+
+// ErrorEvent is an error to report: a panic, or a request that failed with a 5xx status
+type ErrorEvent struct {
+	Err       error
+	Status    int
+	Request   *ErrorRequest
+	UserID    int
+	UserEmail string
+}
+
+// ErrorReporter sends errors to a Sentry-compatible server, such as Sentry or GlitchTip,
+// tagged with the environment and release
+type ErrorReporter struct {
+	endpoint    string
+	auth        string
+	environment string
+	release     string
+	serverName  string
+	client      *http.Client
+}
+
+// NewErrorReporter creates a reporter for the configured DSN. Without a DSN the reporter
+// does nothing.
+func NewErrorReporter(cfg ErrorReporterConfig) (*ErrorReporter, error) {
+	reporter := &ErrorReporter{
+		environment: cfg.Environment,
+		release:     cfg.Release,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+	reporter.serverName, _ = os.Hostname()
+	if cfg.DSN == "" {
+		return reporter, nil
+	}
+
+	dsn, err := url.Parse(cfg.DSN)
+	if err != nil || dsn.User == nil || dsn.User.Username() == "" || dsn.Host == "" {
+		return nil, fmt.Errorf("SENTRY_DSN is not a valid DSN")
+	}
+	path := strings.TrimSuffix(dsn.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	projectID := path[slash+1:]
+	if projectID == "" {
+		return nil, fmt.Errorf("SENTRY_DSN has no project ID")
+	}
+
+	reporter.endpoint = fmt.Sprintf("%s://%s%s/api/%s/envelope/", dsn.Scheme, dsn.Host, path[:slash], projectID)
+	reporter.auth = "Sentry sentry_version=7, sentry_client=scms/1.0, sentry_key=" + dsn.User.Username()
+	return reporter, nil
+}
+
+// Enabled reports whether errors are sent anywhere
+func (r *ErrorReporter) Enabled() bool {
+	return r.endpoint != ""
+}
+
+// Report sends an error in the background so the failing request is not delayed further
+func (r *ErrorReporter) Report(event ErrorEvent) {
+	if !r.Enabled() {
+		return
+	}
+	payload, eventID := r.envelope(event)
+	goBackground(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		if err := r.send(ctx, payload); err != nil {
+			log.Printf("Failed to report error %s: %v", eventID, err)
+		}
+	})
+}
+
+// sentryFrame is a stack frame in Sentry's event format
+type sentryFrame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	Filename string `json:"filename"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+// envelope builds the Sentry envelope of an event and returns it with the event's ID
+func (r *ErrorReporter) envelope(event ErrorEvent) ([]byte, string) {
+	id := make([]byte, 16)
+	rand.Read(id)
+	eventID := hex.EncodeToString(id)
+
+	level := "error"
+	exception := map[string]interface{}{
+		"type":  fmt.Sprintf("%T", event.Err),
+		"value": event.Err.Error(),
+	}
+	extra := map[string]interface{}{}
+	if panicErr, ok := event.Err.(*PanicError); ok {
+		level = "fatal"
+		exception["type"] = "panic"
+		exception["value"] = panicErr.Err.Error()
+		exception["mechanism"] = map[string]interface{}{"type": "recover", "handled": false}
+		if frames := stackFrames(panicErr.pcs); len(frames) > 0 {
+			exception["stacktrace"] = map[string]interface{}{"frames": frames}
+		}
+		extra["stack"] = string(panicErr.Stack)
+	}
+
+	body := map[string]interface{}{
+		"event_id":    eventID,
+		"timestamp":   time.Now().UTC().Format(time.RFC3339Nano),
+		"platform":    "go",
+		"level":       level,
+		"logger":      "scms",
+		"server_name": r.serverName,
+		"environment": r.environment,
+		"exception":   map[string]interface{}{"values": []interface{}{exception}},
+		"extra":       extra,
+	}
+	if r.release != "" {
+		body["release"] = r.release
+	}
+
+	tags := map[string]string{}
+	if event.Status != 0 {
+		tags["status_code"] = fmt.Sprint(event.Status)
+	}
+	if request := event.Request; request != nil {
+		body["request"] = map[string]interface{}{
+			"method":       request.Method,
+			"url":          request.URL,
+			"query_string": request.Query,
+			"headers":      request.Headers,
+			"env":          map[string]string{"REMOTE_ADDR": request.IPAddress},
+		}
+		body["transaction"] = request.Method + " " + request.Route
+		tags["route"] = request.Route
+	}
+	body["tags"] = tags
+	if event.UserID != 0 {
+		user := map[string]interface{}{"id": fmt.Sprint(event.UserID), "email": event.UserEmail}
+		if event.Request != nil {
+			user["ip_address"] = event.Request.IPAddress
+		}
+		body["user"] = user
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.Encode(map[string]string{"event_id": eventID, "sent_at": time.Now().UTC().Format(time.RFC3339Nano)})
+	encoder.Encode(map[string]string{"type": "event"})
+	encoder.Encode(body)
+	return buf.Bytes(), eventID
+}
+
+// stackFrames turns program counters into Sentry frames, outermost call first as Sentry
+// expects, leaving out the recovery and the runtime's own panic handling
+func stackFrames(pcs []uintptr) []sentryFrame {
+	var frames []sentryFrame
+	callers := runtime.CallersFrames(pcs)
+	for {
+		frame, more := callers.Next()
+		if frame.Function == "runtime.gopanic" {
+			// Everything so far is the deferred recovery
+			frames = nil
+		}
+		if !strings.HasPrefix(frame.Function, "runtime.") {
+			module, function := splitFunction(frame.Function)
+			frames = append(frames, sentryFrame{
+				Function: function,
+				Module:   module,
+				Filename: frame.File[strings.LastIndex(frame.File, "/")+1:],
+				AbsPath:  frame.File,
+				Lineno:   frame.Line,
+				InApp:    strings.HasPrefix(module, "github.com/Cezzyy/SCMS"),
+			})
+		}
+		if !more {
+			break
+		}
+	}
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+	return frames
+}
+
+// splitFunction splits a qualified function name such as
+// github.com/Cezzyy/SCMS/backend/internal/handlers.(*OrderHandler).GetOrder into its package
+// and function
+func splitFunction(name string) (string, string) {
+	start := strings.LastIndex(name, "/") + 1
+	dot := strings.Index(name[start:], ".")
+	if dot < 0 {
+		return "", name
+	}
+	return name[:start+dot], name[start+dot+1:]
+}
+
+// send posts an envelope to the reporting server
+func (r *ErrorReporter) send(ctx context.Context, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", r.auth)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("reporting server returned status %d", resp.StatusCode)
+	}
+	return nil
+}
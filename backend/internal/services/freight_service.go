@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// ErrNoFreightRate is returned when no zone or weight bracket covers a shipment
+var ErrNoFreightRate = errors.New("no freight rate covers this shipment")
+
+// FreightItem is one line of a shipment
+type FreightItem struct {
+	ProductID int `json:"product_id"`
+	Quantity  int `json:"quantity" validate:"gt=0"`
+}
+
+// FreightEstimate explains how the freight charge for a shipment was worked out
+type FreightEstimate struct {
+	Zone                  string   `json:"zone"`
+	Province              string   `json:"province"`
+	WeightKg              float64  `json:"weight_kg"`
+	MinWeightKg           float64  `json:"min_weight_kg"`
+	MaxWeightKg           *float64 `json:"max_weight_kg,omitempty"`
+	BaseFee               float64  `json:"base_fee"`
+	PerKgFee              float64  `json:"per_kg_fee"`
+	Freight               float64  `json:"freight"`
+	ProductsWithoutWeight []int    `json:"products_without_weight"`
+}
+
+// FreightService estimates freight from the destination zone and the weight of the items
+type FreightService struct {
+	freightRepo *repository.FreightRepository
+	productRepo *repository.ProductRepository
+}
+
+// NewFreightService creates a new freight service
+func NewFreightService(freightRepo *repository.FreightRepository, productRepo *repository.ProductRepository) *FreightService {
+	return &FreightService{
+		freightRepo: freightRepo,
+		productRepo: productRepo,
+	}
+}
+
+// Estimate works out the freight for shipping items to a province. The charge is the
+// bracket's base fee plus its per-kg fee for each kg above the bracket minimum. Products
+// without a weight count as weightless and are listed in the estimate.
+func (s *FreightService) Estimate(ctx context.Context, province string, items []FreightItem) (*FreightEstimate, error) {
+	productIDs := make([]int, len(items))
+	for i, item := range items {
+		productIDs[i] = item.ProductID
+	}
+	weights, err := s.productRepo.GetWeights(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	estimate := &FreightEstimate{Province: province, ProductsWithoutWeight: []int{}}
+	for _, item := range items {
+		weight, ok := weights[item.ProductID]
+		if !ok {
+			if !containsInt(estimate.ProductsWithoutWeight, item.ProductID) {
+				estimate.ProductsWithoutWeight = append(estimate.ProductsWithoutWeight, item.ProductID)
+			}
+			continue
+		}
+		estimate.WeightKg += weight * float64(item.Quantity)
+	}
+
+	estimate.WeightKg = math.Round(estimate.WeightKg*1000) / 1000
+
+	zone, err := s.freightRepo.FindZone(ctx, province)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNoFreightRate
+		}
+		return nil, err
+	}
+	rate, err := s.freightRepo.FindRate(ctx, zone.Zone, estimate.WeightKg)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNoFreightRate
+		}
+		return nil, err
+	}
+
+	estimate.Zone = zone.Zone
+	estimate.MinWeightKg = rate.MinWeightKg
+	estimate.MaxWeightKg = rate.MaxWeightKg
+	estimate.BaseFee = rate.BaseFee
+	estimate.PerKgFee = rate.PerKgFee
+	estimate.Freight = roundMoney(rate.BaseFee + rate.PerKgFee*(estimate.WeightKg-rate.MinWeightKg))
+	return estimate, nil
+}
+
+// containsInt reports whether a list contains a value
+func containsInt(list []int, value int) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
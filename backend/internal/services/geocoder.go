@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// Supported geocoding providers
+const (
+	GeocoderNominatim = "nominatim"
+)
+
+var (
+	// ErrGeocodingDisabled is returned when no geocoding provider is configured
+	ErrGeocodingDisabled = errors.New("geocoding is not configured")
+
+	// ErrAddressNotFound is returned when the provider cannot place an address
+	ErrAddressNotFound = errors.New("address could not be geocoded")
+)
+
+// GeoPoint is a latitude/longitude pair
+type GeoPoint struct {
+	Latitude  float64 `json:"latitude" validate:"gte=-90,lte=90"`
+	Longitude float64 `json:"longitude" validate:"gte=-180,lte=180"`
+}
+
+// Geocoder resolves a single-line postal address to coordinates
+type Geocoder interface {
+	Geocode(ctx context.Context, address string) (GeoPoint, error)
+}
+
+// NewGeocoderFromEnv creates the provider named by GEOCODER, or nil when geocoding is disabled
+//
+//	GEOCODER=nominatim, GEOCODER_URL (default https://nominatim.openstreetmap.org),
+//	GEOCODER_COUNTRY (ISO codes such as "ph"), GEOCODER_USER_AGENT
+func NewGeocoderFromEnv() Geocoder {
+	switch strings.ToLower(os.Getenv("GEOCODER")) {
+	case GeocoderNominatim:
+		return &NominatimGeocoder{
+			baseURL:      strings.TrimRight(envOrDefault("GEOCODER_URL", "https://nominatim.openstreetmap.org"), "/"),
+			countryCodes: os.Getenv("GEOCODER_COUNTRY"),
+			userAgent:    envOrDefault("GEOCODER_USER_AGENT", "SCMS"),
+			client:       &http.Client{Timeout: 10 * time.Second},
+		}
+	default:
+		return nil
+	}
+}
+
+// NominatimGeocoder geocodes addresses with an OpenStreetMap Nominatim server
+type NominatimGeocoder struct {
+	baseURL      string
+	countryCodes string
+	userAgent    string
+	client       *http.Client
+}
+
+// Geocode looks up the best match for an address
+func (g *NominatimGeocoder) Geocode(ctx context.Context, address string) (GeoPoint, error) {
+	params := url.Values{}
+	params.Set("q", address)
+	params.Set("format", "json")
+	params.Set("limit", "1")
+	if g.countryCodes != "" {
+		params.Set("countrycodes", g.countryCodes)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.baseURL+"/search?"+params.Encode(), nil)
+	if err != nil {
+		return GeoPoint{}, err
+	}
+	req.Header.Set("User-Agent", g.userAgent)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return GeoPoint{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return GeoPoint{}, fmt.Errorf("geocoder returned status %d", resp.StatusCode)
+	}
+
+	var results []struct {
+		Lat string `json:"lat"`
+		Lon string `json:"lon"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return GeoPoint{}, err
+	}
+	if len(results) == 0 {
+		return GeoPoint{}, ErrAddressNotFound
+	}
+
+	lat, err := strconv.ParseFloat(results[0].Lat, 64)
+	if err != nil {
+		return GeoPoint{}, err
+	}
+	lng, err := strconv.ParseFloat(results[0].Lon, 64)
+	if err != nil {
+		return GeoPoint{}, err
+	}
+
+	return GeoPoint{Latitude: lat, Longitude: lng}, nil
+}
+
+// GeocodingService stores coordinates for customer addresses
+type GeocodingService struct {
+	geocoder     Geocoder
+	customerRepo *repository.CustomerRepository
+}
+
+// NewGeocodingService creates a geocoding service. A nil geocoder disables geocoding.
+func NewGeocodingService(geocoder Geocoder, customerRepo *repository.CustomerRepository) *GeocodingService {
+	return &GeocodingService{
+		geocoder:     geocoder,
+		customerRepo: customerRepo,
+	}
+}
+
+// Enabled reports whether a geocoding provider is configured
+func (s *GeocodingService) Enabled() bool {
+	return s.geocoder != nil
+}
+
+// GeocodeCustomer looks up the customer's address and stores the coordinates on the customer
+func (s *GeocodingService) GeocodeCustomer(ctx context.Context, customer *models.Customer) error {
+	if !s.Enabled() {
+		return ErrGeocodingDisabled
+	}
+
+	address := customer.FormattedAddress()
+	if address == "" && customer.Address != nil {
+		address = strings.TrimSpace(*customer.Address)
+	}
+	if address == "" {
+		return ErrAddressNotFound
+	}
+
+	point, err := s.geocoder.Geocode(ctx, address)
+	if err != nil {
+		return err
+	}
+
+	geocodedAt, err := s.customerRepo.SetLocation(ctx, customer.CustomerID, point.Latitude, point.Longitude)
+	if err != nil {
+		return err
+	}
+
+	customer.Latitude = &point.Latitude
+	customer.Longitude = &point.Longitude
+	customer.GeocodedAt = &geocodedAt
+	return nil
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// Impersonation errors
+var (
+	ErrNotAdmin                   = errors.New("only admins can impersonate users")
+	ErrCannotImpersonate          = errors.New("this user cannot be impersonated")
+	ErrInvalidImpersonationToken  = errors.New("invalid or expired impersonation token")
+	ErrImpersonationReasonMissing = errors.New("a reason is required to impersonate a user")
+)
+
+// ImpersonationRequest contains the admin's credentials and the user to act as
+type ImpersonationRequest struct {
+	AdminEmail      string `json:"admin_email" validate:"required"`
+	AdminPassword   string `json:"admin_password" validate:"required"`
+	TargetUserID    int    `json:"target_user_id" validate:"required"`
+	Reason          string `json:"reason"`
+	DurationMinutes int    `json:"duration_minutes"`
+}
+
+// ImpersonationResponse contains the scoped session acting as the target user
+type ImpersonationResponse struct {
+	Token           string      `json:"impersonation_token"`
+	ImpersonationID int         `json:"impersonation_id"`
+	ImpersonatorID  int         `json:"impersonator_id"`
+	User            models.User `json:"user"`
+	ExpiresAt       time.Time   `json:"expires_at"`
+}
+
+// ImpersonationContext is the resolved state of an active impersonation session
+type ImpersonationContext struct {
+	Session models.ImpersonationSession
+	User    models.User
+}
+
+// ImpersonationService issues and resolves time-limited sessions in which an admin acts as another user
+type ImpersonationService struct {
+	authService       *AuthService
+	userRepo          *repository.UserRepository
+	impersonationRepo *repository.ImpersonationRepository
+	auditRepo         *repository.AuditRepository
+	defaultTTL        time.Duration
+	maxTTL            time.Duration
+}
+
+// NewImpersonationService creates a new impersonation service. Sessions last
+// IMPERSONATION_TTL_MINUTES (default 30) and can never exceed 120 minutes.
+func NewImpersonationService(
+	authService *AuthService,
+	userRepo *repository.UserRepository,
+	impersonationRepo *repository.ImpersonationRepository,
+	auditRepo *repository.AuditRepository,
+) *ImpersonationService {
+	maxTTL := 120 * time.Minute
+	defaultTTL := time.Duration(envFloat("IMPERSONATION_TTL_MINUTES", 30)) * time.Minute
+	if defaultTTL <= 0 || defaultTTL > maxTTL {
+		defaultTTL = 30 * time.Minute
+	}
+
+	return &ImpersonationService{
+		authService:       authService,
+		userRepo:          userRepo,
+		impersonationRepo: impersonationRepo,
+		auditRepo:         auditRepo,
+		defaultTTL:        defaultTTL,
+		maxTTL:            maxTTL,
+	}
+}
+
+// Start re-authenticates the admin and issues a session acting as the target user
+func (s *ImpersonationService) Start(ctx context.Context, req ImpersonationRequest, ip string) (*ImpersonationResponse, error) {
+	admin, err := s.authService.Authenticate(ctx, req.AdminEmail, req.AdminPassword)
+	if err != nil {
+		return nil, err
+	}
+	if admin.Role != models.RoleAdmin {
+		return nil, ErrNotAdmin
+	}
+	if req.Reason == "" {
+		return nil, ErrImpersonationReasonMissing
+	}
+
+	target, err := s.userRepo.GetByID(ctx, req.TargetUserID)
+	if err != nil {
+		return nil, err
+	}
+	// Admins cannot impersonate themselves or other admins, so impersonation never grants more access
+	if target.UserID == admin.UserID || target.Role == models.RoleAdmin {
+		return nil, ErrCannotImpersonate
+	}
+
+	ttl := s.defaultTTL
+	if req.DurationMinutes > 0 {
+		ttl = time.Duration(req.DurationMinutes) * time.Minute
+	}
+	if ttl > s.maxTTL {
+		ttl = s.maxTTL
+	}
+
+	token, err := generateToken("imp_")
+	if err != nil {
+		return nil, err
+	}
+
+	session := models.ImpersonationSession{
+		TokenHash:    hashToken(token),
+		AdminUserID:  admin.UserID,
+		TargetUserID: target.UserID,
+		Reason:       req.Reason,
+		ExpiresAt:    time.Now().Add(ttl),
+	}
+	if err := s.impersonationRepo.Create(ctx, &session); err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, session, "impersonation.start", ip, map[string]interface{}{
+		"reason":     session.Reason,
+		"expires_at": session.ExpiresAt,
+	})
+
+	return &ImpersonationResponse{
+		Token:           token,
+		ImpersonationID: session.ImpersonationID,
+		ImpersonatorID:  admin.UserID,
+		User:            target,
+		ExpiresAt:       session.ExpiresAt,
+	}, nil
+}
+
+// Resolve returns the active session and impersonated user for a token
+func (s *ImpersonationService) Resolve(ctx context.Context, token string) (*ImpersonationContext, error) {
+	session, err := s.impersonationRepo.GetActiveByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidImpersonationToken
+		}
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, session.TargetUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ImpersonationContext{Session: session, User: user}, nil
+}
+
+// End closes an impersonation session before it expires
+func (s *ImpersonationService) End(ctx context.Context, token, ip string) error {
+	resolved, err := s.Resolve(ctx, token)
+	if err != nil {
+		return err
+	}
+	if err := s.impersonationRepo.End(ctx, resolved.Session.ImpersonationID); err != nil {
+		return err
+	}
+
+	s.audit(ctx, resolved.Session, "impersonation.end", ip, nil)
+	return nil
+}
+
+// audit records an impersonation lifecycle event
+func (s *ImpersonationService) audit(ctx context.Context, session models.ImpersonationSession, action, ip string, details map[string]interface{}) {
+	entry := models.AuditLog{
+		UserID:         &session.TargetUserID,
+		ImpersonatorID: &session.AdminUserID,
+		Action:         action,
+		Entity:         "impersonation_sessions",
+		EntityID:       strconv.Itoa(session.ImpersonationID),
+		IPAddress:      ip,
+	}
+	if details != nil {
+		if encoded, err := json.Marshal(details); err == nil {
+			entry.Details = encoded
+		}
+	}
+	if err := s.auditRepo.Create(ctx, &entry); err != nil {
+		log.Printf("Failed to write audit log for %s: %v", action, err)
+	}
+}
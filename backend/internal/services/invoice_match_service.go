@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"math"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// InvoiceMatchService runs the three-way match between supplier invoices, their purchase
+// orders and the goods received
+type InvoiceMatchService struct {
+	invoiceRepo       *repository.SupplierInvoiceRepository
+	purchaseOrderRepo *repository.PurchaseOrderRepository
+	rules             *RulesService
+}
+
+// NewInvoiceMatchService creates a new matching service. The invoice_price_tolerance_pct
+// rule is how far an invoiced price may differ from the purchase order, in percent, and
+// invoice_quantity_tolerance how many units quantities may differ by.
+func NewInvoiceMatchService(
+	invoiceRepo *repository.SupplierInvoiceRepository,
+	purchaseOrderRepo *repository.PurchaseOrderRepository,
+	rules *RulesService,
+) *InvoiceMatchService {
+	return &InvoiceMatchService{
+		invoiceRepo:       invoiceRepo,
+		purchaseOrderRepo: purchaseOrderRepo,
+		rules:             rules,
+	}
+}
+
+// Match compares an invoice with its purchase order and the goods received and records the
+// discrepancies found. Prices, unknown products and the invoice total are checked straight
+// away; quantities only once the purchase order is received, so until then an invoice
+// without other discrepancies stays pending. Billed quantities are compared per line across
+// all of the purchase order's invoices, so a delivery may be billed in parts.
+func (s *InvoiceMatchService) Match(ctx context.Context, invoiceID int) (*models.SupplierInvoice, error) {
+	invoice, err := s.invoiceRepo.GetByID(ctx, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	order, err := s.purchaseOrderRepo.GetByID(ctx, invoice.PurchaseOrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	received := order.Status == models.PurchaseOrderReceived || order.Status == models.PurchaseOrderCancelled
+	var invoiced map[int]int
+	if received {
+		invoiced, err = s.invoiceRepo.GetInvoicedQuantities(ctx, order.PurchaseOrderID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	priceTolerancePct := s.rules.Float(ctx, models.RuleInvoicePriceTolerancePct)
+	quantityTolerance := s.rules.Int(ctx, models.RuleInvoiceQuantityTolerance)
+
+	lines := map[int]models.PurchaseOrderItem{}
+	for _, item := range order.Items {
+		lines[item.PurchaseOrderItemID] = item
+	}
+
+	discrepancies := []models.InvoiceDiscrepancy{}
+	linesTotal := 0.0
+	checked := map[int]bool{}
+	for _, item := range invoice.Items {
+		linesTotal += item.LineTotal
+		productID := item.ProductID
+		quantity := item.Quantity
+		price := item.UnitPrice
+
+		line, onOrder := models.PurchaseOrderItem{}, false
+		if item.PurchaseOrderItemID != nil {
+			line, onOrder = lines[*item.PurchaseOrderItemID]
+		}
+		if !onOrder {
+			discrepancies = append(discrepancies, models.InvoiceDiscrepancy{
+				ProductID:         &productID,
+				Kind:              models.DiscrepancyNotOnPO,
+				InvoicedQuantity:  &quantity,
+				InvoicedUnitPrice: &price,
+				AmountDifference:  item.LineTotal,
+			})
+			continue
+		}
+
+		lineID := line.PurchaseOrderItemID
+		ordered := line.Quantity
+		cost := line.UnitCost
+		if math.Abs(price-cost) > math.Max(cost*priceTolerancePct/100, 0.005) {
+			discrepancies = append(discrepancies, models.InvoiceDiscrepancy{
+				PurchaseOrderItemID: &lineID,
+				ProductID:           &productID,
+				Kind:                models.DiscrepancyPrice,
+				OrderedQuantity:     &ordered,
+				InvoicedQuantity:    &quantity,
+				POUnitCost:          &cost,
+				InvoicedUnitPrice:   &price,
+				AmountDifference:    roundMoney((price - cost) * float64(quantity)),
+			})
+		}
+
+		// Quantities are compared once per line even if the invoice bills it on several rows
+		if !received || checked[lineID] {
+			continue
+		}
+		checked[lineID] = true
+
+		receivedQuantity := 0
+		if line.ReceivedQuantity != nil {
+			receivedQuantity = *line.ReceivedQuantity
+		}
+		billed := invoiced[lineID]
+
+		if abs(receivedQuantity-ordered) > quantityTolerance {
+			discrepancies = append(discrepancies, models.InvoiceDiscrepancy{
+				PurchaseOrderItemID: &lineID,
+				ProductID:           &productID,
+				Kind:                models.DiscrepancyReceiptQuantity,
+				OrderedQuantity:     &ordered,
+				ReceivedQuantity:    &receivedQuantity,
+				POUnitCost:          &cost,
+				AmountDifference:    roundMoney(float64(receivedQuantity-ordered) * cost),
+			})
+		}
+		if billed-receivedQuantity > quantityTolerance {
+			discrepancies = append(discrepancies, models.InvoiceDiscrepancy{
+				PurchaseOrderItemID: &lineID,
+				ProductID:           &productID,
+				Kind:                models.DiscrepancyInvoiceQuantity,
+				OrderedQuantity:     &ordered,
+				ReceivedQuantity:    &receivedQuantity,
+				InvoicedQuantity:    &billed,
+				InvoicedUnitPrice:   &price,
+				AmountDifference:    roundMoney(float64(billed-receivedQuantity) * price),
+			})
+		}
+	}
+
+	if difference := roundMoney(invoice.TotalAmount - linesTotal); math.Abs(difference) >= 0.01 {
+		discrepancies = append(discrepancies, models.InvoiceDiscrepancy{
+			Kind:             models.DiscrepancyTotal,
+			AmountDifference: difference,
+		})
+	}
+
+	invoice.Discrepancies = discrepancies
+	switch {
+	case len(discrepancies) > 0:
+		invoice.MatchStatus = models.InvoiceMatchDiscrepancy
+	case received:
+		invoice.MatchStatus = models.InvoiceMatchMatched
+	default:
+		invoice.MatchStatus = models.InvoiceMatchPending
+	}
+
+	if err := s.invoiceRepo.SaveMatch(ctx, &invoice); err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// MatchPurchaseOrder rematches every invoice billed against a purchase order, such as after
+// its goods are received
+func (s *InvoiceMatchService) MatchPurchaseOrder(ctx context.Context, purchaseOrderID int) error {
+	ids, err := s.invoiceRepo.GetIDsByPurchaseOrder(ctx, purchaseOrderID)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if _, err := s.Match(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	mathrand "math/rand"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+const (
+	jobTimeout    = 5 * time.Minute
+	jobLease      = 10 * time.Minute
+	jobFirstRetry = 30 * time.Second
+	jobMaxRetry   = 6 * time.Hour
+	jobRetention  = 7 * 24 * time.Hour
+)
+
+// JobHandler runs one job of the type it is registered for. A returned error fails the
+// attempt; the job is retried with backoff until its attempts run out.
+type JobHandler func(ctx context.Context, payload json.RawMessage) error
+
+// JobQueue runs background work stored in the database, so jobs queued before the server
+// stops run after it starts again. A pool of workers runs due jobs with the handler
+// registered for their type and retries failed ones with exponential backoff; jobs that
+// fail on every attempt are kept as Failed for an admin to retry or discard.
+type JobQueue struct {
+	jobRepo     *repository.JobRepository
+	handlers    map[string]JobHandler
+	workers     int
+	maxAttempts int
+	interval    time.Duration
+	wake        chan struct{}
+	stop        chan struct{}
+}
+
+// NewJobQueue creates a new queue run by JOB_WORKERS workers (default 4) that tries each job
+// up to JOB_MAX_ATTEMPTS times (default 5), waiting 30 seconds after the first failure and
+// twice as long after each one after that, up to 6 hours. Jobs that succeeded are deleted
+// after a week.
+func NewJobQueue(jobRepo *repository.JobRepository) *JobQueue {
+	workers := int(envFloat("JOB_WORKERS", 4))
+	if workers < 1 {
+		workers = 4
+	}
+	maxAttempts := int(envFloat("JOB_MAX_ATTEMPTS", 5))
+	if maxAttempts < 1 {
+		maxAttempts = 5
+	}
+
+	return &JobQueue{
+		jobRepo:     jobRepo,
+		handlers:    map[string]JobHandler{},
+		workers:     workers,
+		maxAttempts: maxAttempts,
+		interval:    5 * time.Second,
+		wake:        make(chan struct{}, workers),
+		stop:        make(chan struct{}),
+	}
+}
+
+// Register sets the handler for a type of job. Handlers must be registered before Start.
+func (q *JobQueue) Register(jobType string, handler JobHandler) {
+	q.handlers[jobType] = handler
+}
+
+// Enqueue queues a job to run as soon as a worker is free. payload is stored as JSON and
+// passed to the job's handler.
+func (q *JobQueue) Enqueue(ctx context.Context, jobType string, payload interface{}) (models.Job, error) {
+	return q.EnqueueAt(ctx, jobType, payload, time.Now())
+}
+
+// EnqueueAt queues a job to run at the given time
+func (q *JobQueue) EnqueueAt(ctx context.Context, jobType string, payload interface{}, runAt time.Time) (models.Job, error) {
+	job := models.Job{
+		Type:        jobType,
+		Status:      models.JobPending,
+		MaxAttempts: q.maxAttempts,
+		RunAt:       &runAt,
+	}
+	if _, ok := q.handlers[jobType]; !ok {
+		return job, fmt.Errorf("no handler is registered for %s jobs", jobType)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return job, fmt.Errorf("failed to encode %s job: %w", jobType, err)
+	}
+	job.Payload = data
+
+	if err := q.jobRepo.Create(ctx, &job); err != nil {
+		return job, err
+	}
+	if !runAt.After(time.Now()) {
+		q.nudge()
+	}
+	return job, nil
+}
+
+// Retry queues a failed job to run again with a fresh set of attempts
+func (q *JobQueue) Retry(ctx context.Context, id int) (models.Job, error) {
+	job, err := q.jobRepo.Retry(ctx, id)
+	if err != nil {
+		return job, err
+	}
+	q.nudge()
+	return job, nil
+}
+
+// nudge wakes a worker without waiting for its next interval
+func (q *JobQueue) nudge() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start starts the workers, which run due jobs at startup, whenever jobs are queued, and on
+// every interval, and a sweeper that deletes old jobs that succeeded
+func (q *JobQueue) Start() {
+	for i := 0; i < q.workers; i++ {
+		goBackground(func() {
+			ticker := time.NewTicker(q.interval)
+			defer ticker.Stop()
+
+			for {
+				q.work()
+				select {
+				case <-ticker.C:
+				case <-q.wake:
+				case <-q.stop:
+					return
+				}
+			}
+		})
+	}
+
+	goBackground(func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for {
+			q.sweep()
+			select {
+			case <-ticker.C:
+			case <-q.stop:
+				return
+			}
+		}
+	})
+}
+
+// Stop ends the workers after the jobs they are running have finished
+func (q *JobQueue) Stop() {
+	close(q.stop)
+}
+
+// work runs due jobs one at a time until none are left or the queue stops
+func (q *JobQueue) work() {
+	for {
+		select {
+		case <-q.stop:
+			return
+		default:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		jobs, err := q.jobRepo.ClaimDue(ctx, 1, jobLease)
+		cancel()
+		if err != nil {
+			log.Printf("Failed to load due jobs: %v", err)
+			return
+		}
+		if len(jobs) == 0 {
+			return
+		}
+		q.run(jobs[0])
+	}
+}
+
+// run runs one job and records the outcome, scheduling a retry when it failed and attempts
+// remain
+func (q *JobQueue) run(job models.Job) {
+	ctx, cancel := context.WithTimeout(context.Background(), jobTimeout)
+	defer cancel()
+
+	job.Attempts++
+	job.Error = nil
+
+	err := q.call(ctx, job)
+	now := time.Now()
+	switch {
+	case err == nil:
+		job.Status = models.JobSucceeded
+		job.RunAt = nil
+		job.FinishedAt = &now
+	case job.Attempts >= job.MaxAttempts:
+		message := err.Error()
+		job.Status = models.JobFailed
+		job.Error = &message
+		job.RunAt = nil
+		job.FinishedAt = &now
+		log.Printf("Job %d (%s) failed after %d attempts: %v", job.JobID, job.Type, job.Attempts, err)
+	default:
+		message := err.Error()
+		next := now.Add(jobBackoff(job.Attempts))
+		job.Status = models.JobPending
+		job.Error = &message
+		job.RunAt = &next
+	}
+
+	if err := q.jobRepo.RecordAttempt(context.Background(), &job); err != nil {
+		log.Printf("Failed to record job %d: %v", job.JobID, err)
+	}
+}
+
+// call runs a job's handler, turning a panic into an error so one bad job cannot stop a worker
+func (q *JobQueue) call(ctx context.Context, job models.Job) (err error) {
+	handler, ok := q.handlers[job.Type]
+	if !ok {
+		return fmt.Errorf("no handler is registered for %s jobs", job.Type)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return handler(ctx, job.Payload)
+}
+
+// sweep deletes the jobs that succeeded more than jobRetention ago
+func (q *JobQueue) sweep() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	deleted, err := q.jobRepo.DeleteSucceededBefore(ctx, time.Now().Add(-jobRetention))
+	if err != nil {
+		log.Printf("Failed to delete finished jobs: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("Deleted %d finished jobs", deleted)
+	}
+}
+
+// jobBackoff is how long to wait after a job's nth failed attempt: doubling from
+// jobFirstRetry up to jobMaxRetry, with up to a fifth added at random
+func jobBackoff(attempts int) time.Duration {
+	wait := time.Duration(float64(jobFirstRetry) * math.Pow(2, float64(attempts-1)))
+	if wait > jobMaxRetry || wait <= 0 {
+		wait = jobMaxRetry
+	}
+	return wait + time.Duration(mathrand.Int63n(int64(wait)/5+1))
+}
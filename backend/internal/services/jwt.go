@@ -0,0 +1,78 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// jwtIssuer is the iss claim on tokens issued by this server
+const jwtIssuer = "scms"
+
+// ErrInvalidToken is returned for bearer tokens that are malformed, wrongly signed or expired
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// jwtHeader is the fixed JOSE header; only HS256 is issued or accepted
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// TokenClaims are the claims carried by an access token
+type TokenClaims struct {
+	Subject   string `json:"sub"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	Issuer    string `json:"iss"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// signJWT encodes and signs claims as an HS256 JWT
+func signJWT(claims TokenClaims, secret []byte) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + jwtSignature(unsigned, secret), nil
+}
+
+// parseJWT verifies an HS256 JWT and returns its claims. The header must match the one
+// we issue exactly, so tokens claiming any other algorithm are rejected.
+func parseJWT(token string, secret []byte, now time.Time) (TokenClaims, error) {
+	var claims TokenClaims
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return claims, ErrInvalidToken
+	}
+
+	expected := jwtSignature(parts[0]+"."+parts[1], secret)
+	if !hmac.Equal([]byte(parts[2]), []byte(expected)) {
+		return claims, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return claims, ErrInvalidToken
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return claims, ErrInvalidToken
+	}
+
+	if claims.Issuer != jwtIssuer || claims.Subject == "" || now.Unix() >= claims.ExpiresAt {
+		return claims, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// jwtSignature computes the base64url HMAC-SHA256 signature of a signing input
+func jwtSignature(unsigned string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
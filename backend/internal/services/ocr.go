@@ -0,0 +1,264 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported OCR providers
+const (
+	OCRTesseract    = "tesseract"
+	OCRGoogleVision = "google_vision"
+)
+
+var (
+	// ErrOCRDisabled is returned when no OCR provider is configured
+	ErrOCRDisabled = errors.New("OCR is not configured")
+
+	// ErrOCRUnsupported is returned for files that cannot be read, such as PDFs when
+	// pdftoppm is not available
+	ErrOCRUnsupported = errors.New("text cannot be read from this file type")
+)
+
+// OCRProvider reads the text of a scanned document: an image, or each page of a PDF
+type OCRProvider interface {
+	Name() string
+	Recognize(ctx context.Context, content []byte, contentType string) (string, error)
+}
+
+// NewOCRProviderFromEnv creates the provider named by OCR, or nil when OCR is disabled
+//
+//	OCR=tesseract, TESSERACT_PATH (default: found in PATH), OCR_LANGUAGE (default eng)
+//	OCR=google_vision, OCR_API_KEY, OCR_URL (default Cloud Vision's images:annotate)
+//	OCR_MAX_PAGES   pages of a PDF that are read (default 5)
+//	PDFTOPPM_PATH   pdftoppm binary PDFs are rendered with (default: found in PATH)
+func NewOCRProviderFromEnv() OCRProvider {
+	pages := pdfPages{
+		pdftoppmPath: strings.TrimSpace(os.Getenv("PDFTOPPM_PATH")),
+		maxPages:     envInt("OCR_MAX_PAGES", 5),
+	}
+	if pages.pdftoppmPath == "" {
+		pages.pdftoppmPath, _ = exec.LookPath("pdftoppm")
+	}
+	if pages.maxPages < 1 {
+		pages.maxPages = 5
+	}
+
+	switch strings.ToLower(os.Getenv("OCR")) {
+	case OCRTesseract:
+		path := strings.TrimSpace(os.Getenv("TESSERACT_PATH"))
+		if path == "" {
+			path, _ = exec.LookPath("tesseract")
+		}
+		return &TesseractOCR{
+			path:     path,
+			language: envOrDefault("OCR_LANGUAGE", "eng"),
+			pages:    pages,
+		}
+	case OCRGoogleVision:
+		return &GoogleVisionOCR{
+			url:    envOrDefault("OCR_URL", "https://vision.googleapis.com/v1/images:annotate"),
+			apiKey: strings.TrimSpace(os.Getenv("OCR_API_KEY")),
+			pages:  pages,
+			client: &http.Client{Timeout: 60 * time.Second},
+		}
+	default:
+		return nil
+	}
+}
+
+// TesseractOCR reads documents with a local tesseract binary
+type TesseractOCR struct {
+	path     string
+	language string
+	pages    pdfPages
+}
+
+// Name identifies the provider on the scans it reads
+func (t *TesseractOCR) Name() string {
+	return OCRTesseract
+}
+
+// Recognize reads the text of an image or PDF
+func (t *TesseractOCR) Recognize(ctx context.Context, content []byte, contentType string) (string, error) {
+	if t.path == "" {
+		return "", fmt.Errorf("%w: tesseract was not found", ErrOCRDisabled)
+	}
+	return t.pages.recognize(ctx, content, contentType, func(ctx context.Context, image []byte) (string, error) {
+		ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+		defer cancel()
+
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, t.path, "stdin", "stdout", "-l", t.language)
+		cmd.Stdin = bytes.NewReader(image)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("tesseract failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return stdout.String(), nil
+	})
+}
+
+// GoogleVisionOCR reads documents with Google Cloud Vision's document text detection
+type GoogleVisionOCR struct {
+	url    string
+	apiKey string
+	pages  pdfPages
+	client *http.Client
+}
+
+// Name identifies the provider on the scans it reads
+func (g *GoogleVisionOCR) Name() string {
+	return OCRGoogleVision
+}
+
+// Recognize reads the text of an image or PDF
+func (g *GoogleVisionOCR) Recognize(ctx context.Context, content []byte, contentType string) (string, error) {
+	if g.apiKey == "" {
+		return "", fmt.Errorf("%w: OCR_API_KEY is not set", ErrOCRDisabled)
+	}
+	return g.pages.recognize(ctx, content, contentType, g.annotate)
+}
+
+// annotate sends one image to Cloud Vision and returns the text found in it
+func (g *GoogleVisionOCR) annotate(ctx context.Context, image []byte) (string, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"requests": []map[string]interface{}{{
+			"image":    map[string]string{"content": base64.StdEncoding.EncodeToString(image)},
+			"features": []map[string]string{{"type": "DOCUMENT_TEXT_DETECTION"}},
+		}},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.url+"?key="+url.QueryEscape(g.apiKey), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Responses []struct {
+			FullTextAnnotation struct {
+				Text string `json:"text"`
+			} `json:"fullTextAnnotation"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		} `json:"responses"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("cloud vision returned %s: %w", resp.Status, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("cloud vision returned %s", resp.Status)
+	}
+	if len(result.Responses) == 0 {
+		return "", nil
+	}
+	if e := result.Responses[0].Error; e != nil {
+		return "", fmt.Errorf("cloud vision: %s", e.Message)
+	}
+	return result.Responses[0].FullTextAnnotation.Text, nil
+}
+
+// pdfPages renders the pages of PDFs as images for OCR providers that only read images
+type pdfPages struct {
+	pdftoppmPath string
+	maxPages     int
+}
+
+// recognize reads an image with read, or each of the first pages of a PDF, joining the
+// pages' text with form feeds
+func (p pdfPages) recognize(ctx context.Context, content []byte, contentType string, read func(ctx context.Context, image []byte) (string, error)) (string, error) {
+	if strings.HasPrefix(contentType, "image/") {
+		return read(ctx, content)
+	}
+	if contentType != "application/pdf" {
+		return "", ErrOCRUnsupported
+	}
+
+	images, err := p.render(ctx, content)
+	if err != nil {
+		return "", err
+	}
+	texts := make([]string, len(images))
+	for i, image := range images {
+		if texts[i], err = read(ctx, image); err != nil {
+			return "", fmt.Errorf("page %d: %w", i+1, err)
+		}
+	}
+	return strings.Join(texts, "\f\n"), nil
+}
+
+// render returns the first pages of a PDF as 300 DPI PNGs
+func (p pdfPages) render(ctx context.Context, content []byte) ([][]byte, error) {
+	if p.pdftoppmPath == "" {
+		return nil, fmt.Errorf("%w: PDFs need pdftoppm", ErrOCRUnsupported)
+	}
+
+	dir, err := os.MkdirTemp("", "ocr")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "document.pdf")
+	if err := os.WriteFile(input, content, 0o600); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, p.pdftoppmPath, "-f", "1", "-l", strconv.Itoa(p.maxPages), "-r", "300", "-png",
+		input, filepath.Join(dir, "page"))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("pdftoppm failed: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	// Pages are written as page-1.png, page-01.png or page-001.png depending on the page count
+	files, err := filepath.Glob(filepath.Join(dir, "page-*.png"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return pageNumber(files[i]) < pageNumber(files[j])
+	})
+	images := make([][]byte, 0, len(files))
+	for _, file := range files {
+		image, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, image)
+	}
+	return images, nil
+}
+
+// pageNumber returns the page number in a file name pdftoppm wrote
+func pageNumber(file string) int {
+	name := strings.TrimSuffix(filepath.Base(file), ".png")
+	n, _ := strconv.Atoi(name[strings.LastIndex(name, "-")+1:])
+	return n
+}
@@ -0,0 +1,340 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+)
+
+// Supported payment gateways
+const (
+	PaymentGatewayStripe   = "stripe"
+	PaymentGatewayPayMongo = "paymongo"
+)
+
+// paymentSignatureTolerance is how far a signed timestamp may be from now, so a captured
+// request cannot be replayed later
+const paymentSignatureTolerance = 5 * time.Minute
+
+var (
+	// ErrUnknownPaymentGateway is returned for a request no configured gateway signed
+	ErrUnknownPaymentGateway = errors.New("request is not from a configured payment gateway")
+
+	// ErrInvalidPaymentSignature is returned when a gateway's signature does not match the body
+	ErrInvalidPaymentSignature = errors.New("invalid or expired payment gateway signature")
+)
+
+// GatewayPayment is a payment a gateway reported. Events that do not report a completed
+// payment are not Paid. Reference is the gateway's ID for the payment, the same in every
+// event about it. The payment is for the invoice or order the checkout was started with, as
+// given in its order_id or invoice_id metadata, and neither is set for events without that
+// metadata; Amount is in Currency's major unit.
+type GatewayPayment struct {
+	Provider  string
+	Event     string
+	Paid      bool
+	Reference string
+	OrderID   int
+	InvoiceID int
+	Amount    float64
+	Currency  string
+	Method    string
+	PaidAt    time.Time
+}
+
+// PaymentGateway verifies and reads the webhook events of one payment gateway
+type PaymentGateway interface {
+	// SignatureHeader is the request header the gateway signs its events in
+	SignatureHeader() string
+	// Parse checks an event's signature and returns the payment it reports
+	Parse(header http.Header, body []byte, now time.Time) (GatewayPayment, error)
+}
+
+// PaymentGateways reads payment events from every gateway with a webhook secret in the
+// environment, telling them apart by the header they sign with
+type PaymentGateways struct {
+	gateways map[string]PaymentGateway
+}
+
+// NewPaymentGatewaysFromEnv creates a gateway for every webhook secret in the environment
+//
+//	STRIPE_WEBHOOK_SECRET, PAYMONGO_WEBHOOK_SECRET
+func NewPaymentGatewaysFromEnv() *PaymentGateways {
+	gateways := &PaymentGateways{gateways: map[string]PaymentGateway{}}
+
+	if secret := os.Getenv("STRIPE_WEBHOOK_SECRET"); secret != "" {
+		gateways.Register(PaymentGatewayStripe, &StripeGateway{secret: secret})
+	}
+	if secret := os.Getenv("PAYMONGO_WEBHOOK_SECRET"); secret != "" {
+		gateways.Register(PaymentGatewayPayMongo, &PayMongoGateway{secret: secret})
+	}
+	return gateways
+}
+
+// Register adds or replaces the gateway for a provider
+func (g *PaymentGateways) Register(provider string, gateway PaymentGateway) {
+	g.gateways[provider] = gateway
+}
+
+// Parse verifies an event with the gateway that signed it and returns the payment it reports
+func (g *PaymentGateways) Parse(header http.Header, body []byte) (GatewayPayment, error) {
+	for provider, gateway := range g.gateways {
+		if header.Get(gateway.SignatureHeader()) == "" {
+			continue
+		}
+		payment, err := gateway.Parse(header, body, time.Now())
+		payment.Provider = provider
+		return payment, err
+	}
+	return GatewayPayment{}, ErrUnknownPaymentGateway
+}
+
+// signatureParts splits a "t=...,v1=...,v1=..." header into its values by key
+func signatureParts(header string) map[string][]string {
+	parts := map[string][]string{}
+	for _, part := range strings.Split(header, ",") {
+		if key, value, ok := strings.Cut(strings.TrimSpace(part), "="); ok {
+			parts[key] = append(parts[key], value)
+		}
+	}
+	return parts
+}
+
+// checkSignature checks that one of signatures is the hex HMAC-SHA256 of "<timestamp>.<body>"
+// under secret, and that timestamp is recent
+func checkSignature(secret, timestamp string, signatures []string, body []byte, now time.Time) error {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || math.Abs(now.Sub(time.Unix(unix, 0)).Seconds()) > paymentSignatureTolerance.Seconds() {
+		return ErrInvalidPaymentSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+	for _, signature := range signatures {
+		if given, err := hex.DecodeString(signature); err == nil && hmac.Equal(given, expected) {
+			return nil
+		}
+	}
+	return ErrInvalidPaymentSignature
+}
+
+// paymentTarget reads the order_id and invoice_id a checkout was started with
+func paymentTarget(payment *GatewayPayment, metadata map[string]string) error {
+	for key, target := range map[string]*int{"order_id": &payment.OrderID, "invoice_id": &payment.InvoiceID} {
+		value := strings.TrimSpace(metadata[key])
+		if value == "" {
+			continue
+		}
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			return fmt.Errorf("invalid %s metadata %q", key, value)
+		}
+		*target = id
+	}
+	return nil
+}
+
+// minorUnits converts an amount in a currency's smallest unit to its major unit. Gateways
+// send most currencies in hundredths; these have no minor unit.
+func minorUnits(amount int64, currency string) float64 {
+	switch strings.ToUpper(currency) {
+	case "JPY", "KRW", "VND", "CLP", "PYG", "ISK", "UGX", "XAF", "XOF":
+		return float64(amount)
+	}
+	return float64(amount) / 100
+}
+
+// StripeGateway reads Stripe payment_intent.succeeded and checkout.session.completed events
+type StripeGateway struct {
+	secret string
+}
+
+// SignatureHeader returns the header Stripe signs events in
+func (g *StripeGateway) SignatureHeader() string {
+	return "Stripe-Signature"
+}
+
+// stripeEvent is the part of a Stripe event a payment is read from
+type stripeEvent struct {
+	Type    string `json:"type"`
+	Created int64  `json:"created"`
+	Data    struct {
+		Object struct {
+			ID            string            `json:"id"`
+			Amount        int64             `json:"amount_received"`
+			AmountTotal   int64             `json:"amount_total"`
+			Currency      string            `json:"currency"`
+			PaymentIntent string            `json:"payment_intent"`
+			PaymentStatus string            `json:"payment_status"`
+			Metadata      map[string]string `json:"metadata"`
+		} `json:"object"`
+	} `json:"data"`
+}
+
+// Parse checks a Stripe event's signature and reads the payment it reports. Checkout sessions
+// are reported under their payment intent, so a session and its intent are one payment.
+func (g *StripeGateway) Parse(header http.Header, body []byte, now time.Time) (GatewayPayment, error) {
+	parts := signatureParts(header.Get(g.SignatureHeader()))
+	if len(parts["t"]) != 1 {
+		return GatewayPayment{}, ErrInvalidPaymentSignature
+	}
+	if err := checkSignature(g.secret, parts["t"][0], parts["v1"], body, now); err != nil {
+		return GatewayPayment{}, err
+	}
+
+	var event stripeEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return GatewayPayment{}, fmt.Errorf("invalid Stripe event: %w", err)
+	}
+	object := event.Data.Object
+	payment := GatewayPayment{
+		Event:     event.Type,
+		Reference: object.ID,
+		Currency:  strings.ToUpper(object.Currency),
+		Method:    models.PaymentCard,
+		PaidAt:    time.Unix(event.Created, 0),
+	}
+
+	switch event.Type {
+	case "payment_intent.succeeded":
+		payment.Amount = minorUnits(object.Amount, object.Currency)
+	case "checkout.session.completed":
+		if object.PaymentStatus != "paid" {
+			return payment, nil
+		}
+		if object.PaymentIntent != "" {
+			payment.Reference = object.PaymentIntent
+		}
+		payment.Amount = minorUnits(object.AmountTotal, object.Currency)
+	default:
+		return payment, nil
+	}
+	payment.Paid = true
+	return payment, paymentTarget(&payment, object.Metadata)
+}
+
+// PayMongoGateway reads PayMongo payment.paid and checkout_session.payment.paid events
+type PayMongoGateway struct {
+	secret string
+}
+
+// SignatureHeader returns the header PayMongo signs events in
+func (g *PayMongoGateway) SignatureHeader() string {
+	return "Paymongo-Signature"
+}
+
+// payMongoPayment is the part of a PayMongo payment resource a payment is read from
+type payMongoPayment struct {
+	ID         string `json:"id"`
+	Attributes struct {
+		Amount   int64             `json:"amount"`
+		Currency string            `json:"currency"`
+		PaidAt   int64             `json:"paid_at"`
+		Status   string            `json:"status"`
+		Metadata map[string]string `json:"metadata"`
+		Source   struct {
+			Type string `json:"type"`
+		} `json:"source"`
+	} `json:"attributes"`
+}
+
+// payMongoCheckout is the part of a PayMongo checkout session a payment is read from
+type payMongoCheckout struct {
+	Attributes struct {
+		Metadata map[string]string `json:"metadata"`
+		Payments []payMongoPayment `json:"payments"`
+	} `json:"attributes"`
+}
+
+// payMongoEvent is the part of a PayMongo event a payment is read from. The resource is a
+// payment or a checkout session, depending on the event's type.
+type payMongoEvent struct {
+	Data struct {
+		Attributes struct {
+			Type     string          `json:"type"`
+			LiveMode bool            `json:"livemode"`
+			Data     json.RawMessage `json:"data"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+// Parse checks a PayMongo event's signature, the live one for live events and the test one
+// otherwise, and reads the payment it reports
+func (g *PayMongoGateway) Parse(header http.Header, body []byte, now time.Time) (GatewayPayment, error) {
+	var event payMongoEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return GatewayPayment{}, fmt.Errorf("invalid PayMongo event: %w", err)
+	}
+
+	parts := signatureParts(header.Get(g.SignatureHeader()))
+	signatures := parts["te"]
+	if event.Data.Attributes.LiveMode {
+		signatures = parts["li"]
+	}
+	if len(parts["t"]) != 1 {
+		return GatewayPayment{}, ErrInvalidPaymentSignature
+	}
+	if err := checkSignature(g.secret, parts["t"][0], signatures, body, now); err != nil {
+		return GatewayPayment{}, err
+	}
+
+	payment := GatewayPayment{Event: event.Data.Attributes.Type}
+	var paid payMongoPayment
+	var metadata map[string]string
+
+	switch payment.Event {
+	case "payment.paid":
+		if err := json.Unmarshal(event.Data.Attributes.Data, &paid); err != nil {
+			return payment, fmt.Errorf("invalid PayMongo payment: %w", err)
+		}
+		metadata = paid.Attributes.Metadata
+	case "checkout_session.payment.paid":
+		var checkout payMongoCheckout
+		if err := json.Unmarshal(event.Data.Attributes.Data, &checkout); err != nil {
+			return payment, fmt.Errorf("invalid PayMongo checkout session: %w", err)
+		}
+		for _, p := range checkout.Attributes.Payments {
+			if p.Attributes.Status == "paid" {
+				paid = p
+				break
+			}
+		}
+		if paid.ID == "" {
+			return payment, nil
+		}
+		metadata = checkout.Attributes.Metadata
+	default:
+		return payment, nil
+	}
+
+	payment.Paid = true
+	payment.Reference = paid.ID
+	payment.Amount = minorUnits(paid.Attributes.Amount, paid.Attributes.Currency)
+	payment.Currency = strings.ToUpper(paid.Attributes.Currency)
+	payment.PaidAt = now
+	if paid.Attributes.PaidAt > 0 {
+		payment.PaidAt = time.Unix(paid.Attributes.PaidAt, 0)
+	}
+	switch paid.Attributes.Source.Type {
+	case "gcash":
+		payment.Method = models.PaymentGCash
+	case "card":
+		payment.Method = models.PaymentCard
+	default:
+		payment.Method = models.PaymentEWallet
+	}
+	return payment, paymentTarget(&payment, metadata)
+}
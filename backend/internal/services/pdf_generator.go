@@ -9,6 +9,8 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
 )
 
 // PDFGenerator handles the generation of PDF documents
@@ -16,6 +18,7 @@ type PDFGenerator struct {
 	templateDir     string
 	cssDir          string
 	wkhtmltopdfPath string
+	onResult        func(templateName string, err error)
 }
 
 // NewPDFGenerator creates a new PDF generator service
@@ -27,8 +30,52 @@ func NewPDFGenerator(templateDir, cssDir, wkhtmltopdfPath string) *PDFGenerator
 	}
 }
 
+// OnResult sets a function told the template and outcome of every document generated, such as
+// ChatNotifier.RecordPDFResult. It must be set before documents are generated.
+func (g *PDFGenerator) OnResult(fn func(templateName string, err error)) {
+	g.onResult = fn
+}
+
+// PDFOptions controls the page layout passed to wkhtmltopdf; zero values keep the defaults
+type PDFOptions struct {
+	PageWidth  string // e.g. "4in" or "100mm"
+	PageHeight string
+	Margin     string // applied to all four sides
+}
+
+// args converts the options to wkhtmltopdf command line flags
+func (o PDFOptions) args() []string {
+	var args []string
+	if o.PageWidth != "" && o.PageHeight != "" {
+		args = append(args, "--page-width", o.PageWidth, "--page-height", o.PageHeight)
+	}
+	if o.Margin != "" {
+		args = append(args,
+			"--margin-top", o.Margin,
+			"--margin-bottom", o.Margin,
+			"--margin-left", o.Margin,
+			"--margin-right", o.Margin,
+		)
+	}
+	return args
+}
+
 // GenerateFromTemplate generates a PDF from a template with given data
 func (g *PDFGenerator) GenerateFromTemplate(templateName string, cssName string, data interface{}) ([]byte, error) {
+	return g.GenerateFromTemplateWithOptions(templateName, cssName, data, PDFOptions{})
+}
+
+// GenerateFromTemplateWithOptions generates a PDF from a template using custom page options
+func (g *PDFGenerator) GenerateFromTemplateWithOptions(templateName string, cssName string, data interface{}, opts PDFOptions) ([]byte, error) {
+	content, err := g.generate(templateName, cssName, data, opts)
+	if g.onResult != nil {
+		g.onResult(templateName, err)
+	}
+	return content, err
+}
+
+// generate renders the template to HTML and converts it with wkhtmltopdf
+func (g *PDFGenerator) generate(templateName string, cssName string, data interface{}, opts PDFOptions) ([]byte, error) {
 	// Create a temporary directory for our files
 	log.Printf("Starting PDF generation for template: %s", templateName)
 	tempDir, err := os.MkdirTemp("", "pdf-generation")
@@ -73,107 +120,7 @@ func (g *PDFGenerator) GenerateFromTemplate(templateName string, cssName string,
 	// Load the template
 	log.Printf("Parsing template file")
 	// Create a new template with functions
-	tmpl := template.New(filepath.Base(templatePath)).Funcs(template.FuncMap{
-		"formatMoney": func(amount float64) string {
-			// Format with two decimal places
-			formattedAmount := fmt.Sprintf("%.2f", amount)
-
-			// Split into integer and decimal parts
-			parts := strings.Split(formattedAmount, ".")
-			integerPart := parts[0]
-			decimalPart := parts[1]
-
-			// Add thousand separators to integer part
-			for i := len(integerPart) - 3; i > 0; i -= 3 {
-				integerPart = integerPart[:i] + "," + integerPart[i:]
-			}
-
-			return integerPart + "." + decimalPart
-		},
-		"calculateDiscountPercent": func(quantity interface{}, unitPrice, discount interface{}) string {
-			// Output debug information
-			log.Printf("DEBUG: calculateDiscountPercent input - quantity: %v, unitPrice: %v, discount: %v", quantity, unitPrice, discount)
-
-			// Convert parameters to float64 safely
-			q := 0.0
-			up := 0.0
-			d := 0.0
-
-			// Convert quantity
-			switch v := quantity.(type) {
-			case int:
-				q = float64(v)
-			case float64:
-				q = v
-			case int64:
-				q = float64(v)
-			default:
-				log.Printf("DEBUG: Unknown quantity type: %T", quantity)
-			}
-
-			// Convert unit price
-			switch v := unitPrice.(type) {
-			case float64:
-				up = v
-			case int:
-				up = float64(v)
-			case string:
-				f, err := strconv.ParseFloat(v, 64)
-				if err == nil {
-					up = f
-				}
-			default:
-				log.Printf("DEBUG: Unknown unitPrice type: %T", unitPrice)
-			}
-
-			// Convert discount
-			switch v := discount.(type) {
-			case float64:
-				d = v
-			case int:
-				d = float64(v)
-			case string:
-				f, err := strconv.ParseFloat(v, 64)
-				if err == nil {
-					d = f
-				}
-			default:
-				log.Printf("DEBUG: Unknown discount type: %T", discount)
-			}
-
-			log.Printf("DEBUG: After conversion - q: %v, up: %v, d: %v", q, up, d)
-
-			// Check for zero values
-			if d <= 0 {
-				return "-"
-			}
-
-			// Calculate line total
-			lineBeforeDiscount := q * up
-			if lineBeforeDiscount <= 0 {
-				return "-"
-			}
-
-			// FIXED LOGIC: If discount seems to be a percentage already (0-100 range)
-			// and it's much smaller than the line total, treat it as a direct percentage
-			if d > 0 && d <= 100 && d < (lineBeforeDiscount*0.1) {
-				// Treat the value as a direct percentage (e.g., 50 means 50%)
-				log.Printf("DEBUG: Treating discount as a direct percentage: %v%%", d)
-				return fmt.Sprintf("%.1f%%", d)
-			}
-
-			// Otherwise calculate as monetary discount
-			percent := (d / lineBeforeDiscount) * 100
-			log.Printf("DEBUG: Calculated as monetary discount, percent: %v", percent)
-
-			// Format based on size
-			if percent < 0.1 {
-				return fmt.Sprintf("%.4f%%", percent)
-			} else {
-				return fmt.Sprintf("%.1f%%", percent)
-			}
-		},
-	})
+	tmpl := template.New(filepath.Base(templatePath)).Funcs(templateFuncs)
 
 	// Parse the template file
 	tmpl, err = tmpl.ParseFiles(templatePath)
@@ -218,6 +165,28 @@ func (g *PDFGenerator) GenerateFromTemplate(templateName string, cssName string,
 	}
 	log.Printf("Template executed successfully")
 
+	return g.convert(tempDir, htmlFilePath, opts)
+}
+
+// GenerateFromHTML converts a complete HTML document to a PDF with the default page layout,
+// for documents built without a template
+func (g *PDFGenerator) GenerateFromHTML(html string) ([]byte, error) {
+	tempDir, err := os.MkdirTemp("", "pdf-generation")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	htmlFilePath := filepath.Join(tempDir, "output.html")
+	if err := os.WriteFile(htmlFilePath, []byte(html), 0o600); err != nil {
+		return nil, fmt.Errorf("failed to create html file: %v", err)
+	}
+
+	return g.convert(tempDir, htmlFilePath, PDFOptions{})
+}
+
+// convert runs wkhtmltopdf on an HTML file, writing the PDF to tempDir, and returns its content
+func (g *PDFGenerator) convert(tempDir, htmlFilePath string, opts PDFOptions) ([]byte, error) {
 	// Create PDF file path
 	pdfFilePath := filepath.Join(tempDir, "output.pdf")
 	log.Printf("PDF output path: %s", pdfFilePath)
@@ -226,9 +195,12 @@ func (g *PDFGenerator) GenerateFromTemplate(templateName string, cssName string,
 	wkhtmltopdfArgs := []string{
 		"--quiet",                    // Reduce output noise
 		"--enable-local-file-access", // Allow access to local files (important for wkhtmltopdf)
-		htmlFilePath,                 // Input HTML file
-		pdfFilePath,                  // Output PDF file
 	}
+	wkhtmltopdfArgs = append(wkhtmltopdfArgs, opts.args()...)
+	wkhtmltopdfArgs = append(wkhtmltopdfArgs,
+		htmlFilePath, // Input HTML file
+		pdfFilePath,  // Output PDF file
+	)
 
 	log.Printf("Executing wkhtmltopdf: %s %s", g.wkhtmltopdfPath, strings.Join(wkhtmltopdfArgs, " "))
 	cmd := exec.Command(g.wkhtmltopdfPath, wkhtmltopdfArgs...)
@@ -251,6 +223,55 @@ func (g *PDFGenerator) GenerateFromTemplate(templateName string, cssName string,
 	return pdfContent, nil
 }
 
+// templateFuncs are the functions document templates can call
+var templateFuncs = template.FuncMap{
+	"add": func(a, b int) int {
+		return a + b
+	},
+	"formatMoney":    FormatMoney,
+	"formatCurrency": FormatCurrency,
+	"formatDiscount": FormatDiscount,
+	"formatPromise":  FormatPromise,
+}
+
+// FormatMoney formats an amount with two decimal places and thousand separators
+func FormatMoney(amount float64) string {
+	return formatAmount(amount, 2)
+}
+
+// FormatCurrency formats an amount in a currency with its symbol, its number of decimal
+// places and thousand separators, e.g. "$1,250.00" or "¥1,250"
+func FormatCurrency(currency models.Currency, amount float64) string {
+	formatted := formatAmount(amount, currency.Decimals)
+	if strings.HasPrefix(formatted, "-") {
+		return "-" + currency.Symbol + formatted[1:]
+	}
+	return currency.Symbol + formatted
+}
+
+// formatAmount formats an amount with the given decimal places and thousand separators
+func formatAmount(amount float64, decimals int) string {
+	// Format with the decimal places
+	formattedAmount := strconv.FormatFloat(amount, 'f', decimals, 64)
+
+	// Split into integer and decimal parts
+	integerPart, decimalPart, _ := strings.Cut(formattedAmount, ".")
+
+	// Add thousand separators to integer part, leaving any minus sign alone
+	sign := ""
+	if strings.HasPrefix(integerPart, "-") {
+		sign, integerPart = "-", integerPart[1:]
+	}
+	for i := len(integerPart) - 3; i > 0; i -= 3 {
+		integerPart = integerPart[:i] + "," + integerPart[i:]
+	}
+
+	if decimalPart == "" {
+		return sign + integerPart
+	}
+	return sign + integerPart + "." + decimalPart
+}
+
 // Detect attempts to find the wkhtmltopdf binary in standard locations
 func DetectWkhtmltopdfPath() string {
 	// Common locations for wkhtmltopdf
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // decoders for the image attachment types
+	"image/jpeg"
+	_ "image/png"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// JobGeneratePreview is the background job that makes a document's preview; see
+// PreviewService.GenerateJob
+const JobGeneratePreview = "preview.generate"
+
+// maxPreviewPixels is the largest image previews are made of, so a small file that decodes to
+// a huge image cannot exhaust memory
+const maxPreviewPixels = 50_000_000
+
+// ErrPreviewUnsupported is returned for files no preview can be made of
+var ErrPreviewUnsupported = errors.New("no preview can be made of this file type")
+
+// PreviewSource loads the file a document's preview is made of: the stored file of an
+// attachment, or the PDF rendered for a quotation, order or invoice
+type PreviewSource func(ctx context.Context, id int) (content []byte, contentType string, err error)
+
+// previewJob is the payload of a JobGeneratePreview job
+type previewJob struct {
+	Entity   string `json:"entity"`
+	EntityID int    `json:"entity_id"`
+}
+
+// PreviewService makes small JPEG previews of attachments and generated PDFs in background
+// jobs, so document cards can show them without downloading the whole file. Images are
+// scaled down and PDFs previewed by their first page, rendered with pdftoppm from poppler.
+type PreviewService struct {
+	previewRepo  *repository.DocumentPreviewRepository
+	jobQueue     *JobQueue
+	sources      map[string]PreviewSource
+	pdftoppmPath string
+	width        int
+}
+
+// NewPreviewServiceFromEnv creates a preview service that previews attachments, configured
+// from environment variables; sources for other documents are added with Source
+//
+//	PREVIEW_WIDTH   width of previews in pixels (default 320)
+//	PDFTOPPM_PATH   pdftoppm binary for PDF previews (default: found in PATH; PDFs get no
+//	                preview without it)
+func NewPreviewServiceFromEnv(previewRepo *repository.DocumentPreviewRepository, jobQueue *JobQueue, attachmentService *AttachmentService) *PreviewService {
+	width := envInt("PREVIEW_WIDTH", 320)
+	if width < 16 {
+		width = 320
+	}
+
+	pdftoppmPath := strings.TrimSpace(os.Getenv("PDFTOPPM_PATH"))
+	if pdftoppmPath == "" {
+		pdftoppmPath, _ = exec.LookPath("pdftoppm")
+	}
+	if pdftoppmPath == "" {
+		log.Printf("pdftoppm not found; PDFs will have no previews")
+	}
+
+	s := &PreviewService{
+		previewRepo:  previewRepo,
+		jobQueue:     jobQueue,
+		sources:      map[string]PreviewSource{},
+		pdftoppmPath: pdftoppmPath,
+		width:        width,
+	}
+	s.Source(models.DownloadAttachment, func(ctx context.Context, id int) ([]byte, string, error) {
+		attachment, err := attachmentService.Get(ctx, id)
+		return attachment.Content, attachment.ContentType, err
+	})
+	return s
+}
+
+// Source sets how the files of a type of document are loaded. Sources must be set before the
+// job queue starts.
+func (s *PreviewService) Source(entity string, source PreviewSource) {
+	s.sources[entity] = source
+}
+
+// Request queues a job to make a document's preview, unless one is already queued
+func (s *PreviewService) Request(ctx context.Context, entity string, id int) error {
+	requested, err := s.previewRepo.Request(ctx, entity, id)
+	if err != nil || !requested {
+		return err
+	}
+	_, err = s.jobQueue.Enqueue(ctx, JobGeneratePreview, previewJob{Entity: entity, EntityID: id})
+	return err
+}
+
+// AttachmentSaved queues the preview of a newly stored attachment; see AttachmentService.OnSave
+func (s *PreviewService) AttachmentSaved(attachment models.Attachment) {
+	if err := s.Request(context.Background(), models.DownloadAttachment, attachment.AttachmentID); err != nil {
+		log.Printf("Failed to queue preview of attachment %d: %v", attachment.AttachmentID, err)
+	}
+}
+
+// GenerateJob makes the preview a JobGeneratePreview job asks for. Documents no preview can
+// be made of are marked Unavailable rather than retried.
+func (s *PreviewService) GenerateJob(ctx context.Context, payload json.RawMessage) error {
+	var job previewJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return fmt.Errorf("invalid preview job: %w", err)
+	}
+
+	source, ok := s.sources[job.Entity]
+	if !ok {
+		return fmt.Errorf("no preview source for %s", job.Entity)
+	}
+
+	content, contentType, err := source(ctx, job.EntityID)
+	if errors.Is(err, repository.ErrNotFound) {
+		// The document has been deleted since; there is nothing to preview
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	preview, width, height, err := s.Thumbnail(ctx, content, contentType)
+	if errors.Is(err, ErrPreviewUnsupported) {
+		return s.previewRepo.MarkUnavailable(ctx, job.Entity, job.EntityID, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+	return s.previewRepo.Save(ctx, job.Entity, job.EntityID, preview, width, height)
+}
+
+// Thumbnail makes a JPEG preview of an image or of the first page of a PDF, returning it with
+// its size. ErrPreviewUnsupported is returned for other files, and for PDFs when pdftoppm is
+// not available.
+func (s *PreviewService) Thumbnail(ctx context.Context, content []byte, contentType string) ([]byte, int, int, error) {
+	if contentType == "application/pdf" {
+		page, err := s.firstPage(ctx, content)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		content = page
+	} else if !strings.HasPrefix(contentType, "image/") {
+		return nil, 0, 0, ErrPreviewUnsupported
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, 0, 0, ErrPreviewUnsupported
+	}
+	if config.Width*config.Height > maxPreviewPixels {
+		return nil, 0, 0, fmt.Errorf("%w: image is %dx%d pixels", ErrPreviewUnsupported, config.Width, config.Height)
+	}
+	src, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, 0, 0, ErrPreviewUnsupported
+	}
+
+	// Scale down to the preview width, never up, onto white so transparent images stay legible
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > s.width {
+		height = max(1, height*s.width/width)
+		width = s.width
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, 0, 0, err
+	}
+	return buf.Bytes(), width, height, nil
+}
+
+// firstPage renders the first page of a PDF as a PNG the preview width wide
+func (s *PreviewService) firstPage(ctx context.Context, content []byte) ([]byte, error) {
+	if s.pdftoppmPath == "" {
+		return nil, fmt.Errorf("%w: PDF previews need pdftoppm", ErrPreviewUnsupported)
+	}
+
+	dir, err := os.MkdirTemp("", "preview")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "document.pdf")
+	if err := os.WriteFile(input, content, 0o600); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	output := filepath.Join(dir, "page")
+	cmd := exec.CommandContext(ctx, s.pdftoppmPath, "-f", "1", "-l", "1", "-singlefile", "-png",
+		"-scale-to-x", strconv.Itoa(s.width), "-scale-to-y", "-1", input, output)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("pdftoppm failed: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return os.ReadFile(output + ".png")
+}
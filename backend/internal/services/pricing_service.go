@@ -0,0 +1,501 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// Reasons a delivery fee is waived
+const (
+	FreeDeliveryThreshold = "threshold"
+	FreeDeliveryQuota     = "quota"
+)
+
+// DiscountLimitError is returned when a line's discount is more than the largest discount
+// allowed, in percent of the line
+type DiscountLimitError struct {
+	ProductID  int
+	MaxPercent float64
+}
+
+// Error implements error
+func (e *DiscountLimitError) Error() string {
+	return fmt.Sprintf("the discount on product %d is more than %g%% of the line", e.ProductID, e.MaxPercent)
+}
+
+// PriceBreakdown explains how a customer's tier was applied to a quotation or order. Amounts
+// are in Currency, except the freight estimate, which is in pesos.
+type PriceBreakdown struct {
+	Currency                string           `json:"currency"`
+	ExchangeRate            float64          `json:"exchange_rate"`
+	Tier                    string           `json:"tier"`
+	DiscountPercent         float64          `json:"discount_percent"`
+	Subtotal                float64          `json:"subtotal"`
+	TierDiscount            float64          `json:"tier_discount"`
+	QuantityBreakDiscount   float64          `json:"quantity_break_discount"`
+	DeliveryFee             float64          `json:"delivery_fee"`
+	Freight                 *FreightEstimate `json:"freight,omitempty"`
+	FreeDeliveryReason      string           `json:"free_delivery_reason,omitempty"`
+	FreeDeliveriesRemaining int              `json:"free_deliveries_remaining"`
+	Total                   float64          `json:"total"`
+	VATClassification       string           `json:"vat_classification"`
+	VATRate                 float64          `json:"vat_rate"`
+	NetAmount               float64          `json:"net_amount"`
+	VATAmount               float64          `json:"vat_amount"`
+}
+
+// Flags raised on a quotation price preview
+const (
+	PriceFlagDiscountLimit      = "discount_limit"
+	PriceFlagBelowCost          = "below_cost"
+	PriceFlagUnavailableProduct = "unavailable_product"
+	PriceFlagRestrictedProduct  = "restricted_product"
+	PriceFlagArchivedCustomer   = "archived_customer"
+)
+
+// PriceFlag points out something on a previewed quotation that would be refused or needs a
+// second look, for the whole quotation or the line of ProductID
+type PriceFlag struct {
+	Code      string `json:"code"`
+	ProductID int    `json:"product_id,omitempty"`
+	Message   string `json:"message"`
+}
+
+// PreviewLine is a previewed quotation line with its price, discount and margin
+type PreviewLine struct {
+	ProductID     int      `json:"product_id"`
+	Quantity      int      `json:"quantity"`
+	UnitPrice     float64  `json:"unit_price"`
+	Discount      float64  `json:"discount"`
+	DiscountType  string   `json:"discount_type"`
+	DiscountValue float64  `json:"discount_value"`
+	LineTotal     float64  `json:"line_total"`
+	NetAmount     float64  `json:"net_amount"`
+	UnitCost      *float64 `json:"unit_cost,omitempty"`
+	Margin        *float64 `json:"margin,omitempty"`
+	MarginPercent *float64 `json:"margin_percent,omitempty"`
+}
+
+// QuotationPreview is the pricing of a draft quotation that has not been saved. Cost and
+// margin cover the lines whose products have a cost price.
+type QuotationPreview struct {
+	*PriceBreakdown
+	Lines         []PreviewLine `json:"lines"`
+	Cost          *float64      `json:"cost,omitempty"`
+	Margin        *float64      `json:"margin,omitempty"`
+	MarginPercent *float64      `json:"margin_percent,omitempty"`
+	Flags         []PriceFlag   `json:"flags"`
+}
+
+// PricingService applies line discounts and delivery charges
+type PricingService struct {
+	customerRepo   *repository.CustomerRepository
+	tierRepo       *repository.LoyaltyTierRepository
+	orderRepo      *repository.OrderRepository
+	freightService *FreightService
+	rules          *RulesService
+	currencies     *CurrencyService
+	discounts      *DiscountService
+}
+
+// NewPricingService creates a new pricing service. Delivery is charged from the freight
+// rate table; the delivery_fee rule applies when no rate covers the destination. Line
+// discounts are worked out by discounts, and those entered on a line are limited by the
+// max_discount_percent rule. Totals include VAT at the
+// vat_rate_pct rule for vatable customers. Documents are priced in their own currency; the
+// delivery charges and free delivery thresholds, which are in pesos, are converted at the
+// exchange rate of the day, which the document keeps.
+func NewPricingService(
+	customerRepo *repository.CustomerRepository,
+	tierRepo *repository.LoyaltyTierRepository,
+	orderRepo *repository.OrderRepository,
+	freightService *FreightService,
+	rules *RulesService,
+	currencies *CurrencyService,
+	discounts *DiscountService,
+) *PricingService {
+	return &PricingService{
+		customerRepo:   customerRepo,
+		tierRepo:       tierRepo,
+		orderRepo:      orderRepo,
+		freightService: freightService,
+		rules:          rules,
+		currencies:     currencies,
+		discounts:      discounts,
+	}
+}
+
+// exchangeRate resolves a document's currency and its exchange rate on the document's date,
+// or today for an undated draft. ErrUnknownCurrency or ErrNoExchangeRate is returned when
+// the currency cannot be priced in.
+func (s *PricingService) exchangeRate(ctx context.Context, currency string, date time.Time) (string, float64, error) {
+	if date.IsZero() {
+		date = time.Now()
+	}
+	currency = normalizeCurrency(currency)
+	rate, err := s.currencies.Rate(ctx, currency, date)
+	return currency, rate, err
+}
+
+// customerTier looks up a customer and their tier
+func (s *PricingService) customerTier(ctx context.Context, customerID int) (models.Customer, models.LoyaltyTier, error) {
+	customer, err := s.customerRepo.GetByID(ctx, customerID)
+	if err != nil {
+		return customer, models.LoyaltyTier{}, err
+	}
+	tier, err := s.tierRepo.GetByName(ctx, customer.Tier)
+	return customer, tier, err
+}
+
+// addDiscount adds a line's discount applied by pricing to the breakdown's totals
+func addDiscount(breakdown *PriceBreakdown, discount LineDiscount) {
+	switch discount.Type {
+	case models.DiscountTier:
+		breakdown.TierDiscount += discount.Amount
+	case models.DiscountQuantityBreak:
+		breakdown.QuantityBreakDiscount += discount.Amount
+	}
+}
+
+// checkDiscount returns a *DiscountLimitError when a line's discount is more than the
+// max_discount_percent rule allows
+func (s *PricingService) checkDiscount(ctx context.Context, productID, quantity int, unitPrice, discount float64) error {
+	maxPercent := s.rules.Float(ctx, models.RuleMaxDiscountPercent)
+	if discount > roundMoney(float64(quantity)*unitPrice*maxPercent/100) {
+		return &DiscountLimitError{ProductID: productID, MaxPercent: maxPercent}
+	}
+	return nil
+}
+
+// PriceOrder works out the discount of each order line and the delivery charge, and sets the
+// order total and exchange rate. A *DiscountLimitError is returned for a line discounted more
+// than allowed.
+func (s *PricingService) PriceOrder(ctx context.Context, order *models.Order, items []models.OrderItem) (*PriceBreakdown, error) {
+	customer, tier, err := s.customerTier(ctx, order.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	order.Currency, order.ExchangeRate, err = s.exchangeRate(ctx, order.Currency, order.OrderDate)
+	if err != nil {
+		return nil, err
+	}
+
+	breakdown := &PriceBreakdown{
+		Currency:        order.Currency,
+		ExchangeRate:    order.ExchangeRate,
+		Tier:            tier.Tier,
+		DiscountPercent: tier.DiscountPercent,
+	}
+	lines := make([]DiscountLine, len(items))
+	for i, item := range items {
+		lines[i] = DiscountLine{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			Type:      item.DiscountType,
+			Value:     item.DiscountValue,
+			Amount:    item.Discount,
+		}
+	}
+	discounts, err := s.discounts.Apply(ctx, tier, lines)
+	if err != nil {
+		return nil, err
+	}
+	for i, discount := range discounts {
+		if discount.Entered() {
+			if err := s.checkDiscount(ctx, items[i].ProductID, items[i].Quantity, items[i].UnitPrice, discount.Amount); err != nil {
+				return nil, err
+			}
+		}
+		addDiscount(breakdown, discount)
+		items[i].DiscountType = discount.Type
+		items[i].DiscountValue = discount.Value
+		items[i].Discount = discount.Amount
+		breakdown.Subtotal += float64(items[i].Quantity)*items[i].UnitPrice - items[i].Discount
+	}
+	breakdown.Subtotal = roundMoney(breakdown.Subtotal)
+	breakdown.TierDiscount = roundMoney(breakdown.TierDiscount)
+	breakdown.QuantityBreakDiscount = roundMoney(breakdown.QuantityBreakDiscount)
+
+	// Orders without a shipping address are collected and have no delivery charge
+	order.DeliveryFee = 0
+	order.FreeDeliveryReason = nil
+	if order.ShippingAddress != "" {
+		if err := s.applyDelivery(ctx, customer, tier, order, items, breakdown); err != nil {
+			return nil, err
+		}
+	}
+
+	breakdown.DeliveryFee = order.DeliveryFee
+	breakdown.Total = roundMoney(breakdown.Subtotal + order.DeliveryFee)
+	order.TotalAmount = breakdown.Total
+
+	s.splitVAT(ctx, customer.VATClassification, breakdown)
+	order.VATClassification = breakdown.VATClassification
+	order.VATRate = breakdown.VATRate
+	order.NetAmount = breakdown.NetAmount
+	order.VATAmount = breakdown.VATAmount
+	return breakdown, nil
+}
+
+// TaxOrder splits an order's total into its net amount and VAT under its customer's VAT
+// classification, for orders whose total was set without pricing them, such as by an edit.
+// An order without an exchange rate is given the rate of its date.
+func (s *PricingService) TaxOrder(ctx context.Context, order *models.Order) error {
+	customer, err := s.customerRepo.GetByIDWithDeleted(ctx, order.CustomerID)
+	if err != nil {
+		return err
+	}
+	if order.ExchangeRate == 0 {
+		order.Currency, order.ExchangeRate, err = s.exchangeRate(ctx, order.Currency, order.OrderDate)
+		if err != nil {
+			return err
+		}
+	}
+
+	breakdown := &PriceBreakdown{Total: order.TotalAmount}
+	s.splitVAT(ctx, customer.VATClassification, breakdown)
+	order.VATClassification = breakdown.VATClassification
+	order.VATRate = breakdown.VATRate
+	order.NetAmount = breakdown.NetAmount
+	order.VATAmount = breakdown.VATAmount
+	return nil
+}
+
+// splitVAT splits the VAT-inclusive total of a breakdown into its net amount and VAT. Sales
+// to exempt and zero-rated customers carry no VAT.
+func (s *PricingService) splitVAT(ctx context.Context, classification string, breakdown *PriceBreakdown) {
+	if classification == "" {
+		classification = models.VATVatable
+	}
+	breakdown.VATClassification = classification
+	breakdown.VATRate = 0
+	breakdown.NetAmount = breakdown.Total
+	breakdown.VATAmount = 0
+	if classification != models.VATVatable {
+		return
+	}
+
+	breakdown.VATRate = s.rules.Float(ctx, models.RuleVATRatePct)
+	breakdown.NetAmount = roundMoney(breakdown.Total / (1 + breakdown.VATRate/100))
+	breakdown.VATAmount = roundMoney(breakdown.Total - breakdown.NetAmount)
+}
+
+// applyDelivery waives the delivery fee when the order reaches the tier's threshold or the
+// customer has free deliveries left this month, and charges freight otherwise
+func (s *PricingService) applyDelivery(ctx context.Context, customer models.Customer, tier models.LoyaltyTier, order *models.Order, items []models.OrderItem, breakdown *PriceBreakdown) error {
+	if tier.FreeDeliveriesPerMonth > 0 {
+		now := time.Now()
+		monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		used, err := s.orderRepo.CountFreeDeliveries(ctx, order.CustomerID, FreeDeliveryQuota, monthStart)
+		if err != nil {
+			return err
+		}
+		breakdown.FreeDeliveriesRemaining = max(tier.FreeDeliveriesPerMonth-used, 0)
+	}
+
+	reason := ""
+	switch {
+	case tier.FreeDeliveryThreshold != nil && ToBase(breakdown.Subtotal, order.ExchangeRate) >= *tier.FreeDeliveryThreshold:
+		reason = FreeDeliveryThreshold
+	case breakdown.FreeDeliveriesRemaining > 0:
+		reason = FreeDeliveryQuota
+		breakdown.FreeDeliveriesRemaining--
+	}
+
+	if reason == "" {
+		return s.applyFreight(ctx, customer, order, items, breakdown)
+	}
+	order.FreeDeliveryReason = &reason
+	breakdown.FreeDeliveryReason = reason
+	return nil
+}
+
+// applyFreight charges the freight estimate for the customer's province, or the standard
+// delivery fee when no freight rate covers the shipment, in the order's currency
+func (s *PricingService) applyFreight(ctx context.Context, customer models.Customer, order *models.Order, items []models.OrderItem, breakdown *PriceBreakdown) error {
+	order.DeliveryFee = FromBase(s.rules.Float(ctx, models.RuleDeliveryFee), order.ExchangeRate)
+
+	province := ""
+	if customer.Province != nil {
+		province = *customer.Province
+	}
+	freightItems := make([]FreightItem, len(items))
+	for i, item := range items {
+		freightItems[i] = FreightItem{ProductID: item.ProductID, Quantity: item.Quantity}
+	}
+
+	estimate, err := s.freightService.Estimate(ctx, province, freightItems)
+	if err == ErrNoFreightRate {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	order.DeliveryFee = FromBase(estimate.Freight, order.ExchangeRate)
+	breakdown.Freight = estimate
+	return nil
+}
+
+// PriceQuotation works out the discount of each quotation line and sets the quotation total
+// and exchange rate. A *DiscountLimitError is returned for a line discounted more than
+// allowed.
+func (s *PricingService) PriceQuotation(ctx context.Context, quotation *models.Quotation, items []models.QuotationItem) (*PriceBreakdown, error) {
+	breakdown, limits, err := s.priceQuotation(ctx, quotation, items)
+	if err != nil {
+		return nil, err
+	}
+	if len(limits) > 0 {
+		return nil, limits[0]
+	}
+	return breakdown, nil
+}
+
+// priceQuotation prices quotation lines as PriceQuotation does, returning the lines
+// discounted more than allowed instead of refusing them
+func (s *PricingService) priceQuotation(ctx context.Context, quotation *models.Quotation, items []models.QuotationItem) (*PriceBreakdown, []*DiscountLimitError, error) {
+	customer, tier, err := s.customerTier(ctx, quotation.CustomerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	quotation.Currency, quotation.ExchangeRate, err = s.exchangeRate(ctx, quotation.Currency, quotation.QuoteDate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var limits []*DiscountLimitError
+	breakdown := &PriceBreakdown{
+		Currency:        quotation.Currency,
+		ExchangeRate:    quotation.ExchangeRate,
+		Tier:            tier.Tier,
+		DiscountPercent: tier.DiscountPercent,
+	}
+	lines := make([]DiscountLine, len(items))
+	for i, item := range items {
+		lines[i] = DiscountLine{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			Type:      item.DiscountType,
+			Value:     item.DiscountValue,
+			Amount:    item.Discount,
+		}
+	}
+	discounts, err := s.discounts.Apply(ctx, tier, lines)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, discount := range discounts {
+		if discount.Entered() {
+			err := s.checkDiscount(ctx, items[i].ProductID, items[i].Quantity, items[i].UnitPrice, discount.Amount)
+			if limit, ok := err.(*DiscountLimitError); ok {
+				limits = append(limits, limit)
+			}
+		}
+		addDiscount(breakdown, discount)
+		items[i].DiscountType = discount.Type
+		items[i].DiscountValue = discount.Value
+		items[i].Discount = discount.Amount
+		items[i].LineTotal = roundMoney(float64(items[i].Quantity)*items[i].UnitPrice - items[i].Discount)
+		breakdown.Subtotal += float64(items[i].Quantity)*items[i].UnitPrice - items[i].Discount
+	}
+	breakdown.Subtotal = roundMoney(breakdown.Subtotal)
+	breakdown.TierDiscount = roundMoney(breakdown.TierDiscount)
+	breakdown.QuantityBreakDiscount = roundMoney(breakdown.QuantityBreakDiscount)
+	breakdown.Total = breakdown.Subtotal
+
+	quotation.TotalAmount = breakdown.Total
+
+	s.splitVAT(ctx, customer.VATClassification, breakdown)
+	quotation.VATClassification = breakdown.VATClassification
+	quotation.VATRate = breakdown.VATRate
+	quotation.NetAmount = breakdown.NetAmount
+	quotation.VATAmount = breakdown.VATAmount
+	return breakdown, limits, nil
+}
+
+// PreviewQuotation prices draft quotation lines without saving anything: each line's
+// price, discount and margin over the products' cost prices, the quotation's totals and
+// VAT, and flags for lines that would be refused or sold below cost. Margins are worked
+// out on amounts net of VAT and left out for products without a cost price. Costs are in
+// pesos and are converted to the quotation's currency.
+func (s *PricingService) PreviewQuotation(ctx context.Context, quotation *models.Quotation, items []models.QuotationItem, costs map[int]float64) (*QuotationPreview, error) {
+	breakdown, limits, err := s.priceQuotation(ctx, quotation, items)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &QuotationPreview{
+		PriceBreakdown: breakdown,
+		Lines:          make([]PreviewLine, len(items)),
+		Flags:          []PriceFlag{},
+	}
+	for _, limit := range limits {
+		preview.Flags = append(preview.Flags, PriceFlag{
+			Code:      PriceFlagDiscountLimit,
+			ProductID: limit.ProductID,
+			Message:   fmt.Sprintf("Discount is more than the %g%% allowed", limit.MaxPercent),
+		})
+	}
+
+	var cost, costedNet float64
+	for i, item := range items {
+		line := PreviewLine{
+			ProductID:     item.ProductID,
+			Quantity:      item.Quantity,
+			UnitPrice:     item.UnitPrice,
+			Discount:      item.Discount,
+			DiscountType:  item.DiscountType,
+			DiscountValue: item.DiscountValue,
+			LineTotal:     item.LineTotal,
+			NetAmount:     roundMoney(item.LineTotal / (1 + breakdown.VATRate/100)),
+		}
+		if cost, ok := costs[item.ProductID]; ok {
+			unitCost := FromBase(cost, quotation.ExchangeRate)
+			lineCost := roundMoney(unitCost * float64(item.Quantity))
+			margin := roundMoney(line.NetAmount - lineCost)
+			line.UnitCost = &unitCost
+			line.Margin = &margin
+			line.MarginPercent = marginPercent(margin, line.NetAmount)
+			cost += lineCost
+			costedNet += line.NetAmount
+			if margin < 0 {
+				preview.Flags = append(preview.Flags, PriceFlag{
+					Code:      PriceFlagBelowCost,
+					ProductID: item.ProductID,
+					Message:   "Line is priced below cost",
+				})
+			}
+		}
+		preview.Lines[i] = line
+	}
+
+	if costedNet > 0 || cost > 0 {
+		cost = roundMoney(cost)
+		margin := roundMoney(costedNet - cost)
+		preview.Cost = &cost
+		preview.Margin = &margin
+		preview.MarginPercent = marginPercent(margin, costedNet)
+	}
+	return preview, nil
+}
+
+// marginPercent returns a margin in percent of the net amount it was made on, or nil when
+// nothing was charged
+func marginPercent(margin, net float64) *float64 {
+	if net == 0 {
+		return nil
+	}
+	percent := math.Round(margin/net*10000) / 100
+	return &percent
+}
+
+// roundMoney rounds an amount to cents
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,367 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// Print job statuses
+const (
+	PrintStatusQueued   = "Queued"
+	PrintStatusPrinting = "Printing"
+	PrintStatusPrinted  = "Printed"
+	PrintStatusFailed   = "Failed"
+)
+
+// ErrUnknownPrinter is returned when a job targets a printer that is not configured
+var ErrUnknownPrinter = errors.New("unknown printer")
+
+// ErrPrintQueueFull is returned when the in-memory print queue cannot accept more jobs
+var ErrPrintQueueFull = errors.New("print queue is full")
+
+// ErrPrintServiceStopped is returned for jobs submitted while the server is shutting down
+var ErrPrintServiceStopped = errors.New("print service is shutting down")
+
+// PrintDocument is the payload sent to a printer. Format is the MIME type of Content;
+// empty means PDF.
+type PrintDocument struct {
+	Title   string
+	Content []byte
+	Copies  int
+	Format  string
+}
+
+// raw reports whether the document is printer-ready data that must not be converted
+func (d PrintDocument) raw() bool {
+	return d.Format == MimeTypeESCPOS
+}
+
+// Printer sends a document to a physical printer
+type Printer interface {
+	Print(ctx context.Context, doc PrintDocument) error
+}
+
+// PrinterInfo describes a configured printer
+type PrinterInfo struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	URI  string `json:"uri"`
+}
+
+// configuredPrinter pairs a printer with its public description
+type configuredPrinter struct {
+	info    PrinterInfo
+	printer Printer
+}
+
+// queuedPrint is a rendered document waiting for the worker
+type queuedPrint struct {
+	jobID   int
+	printer Printer
+	doc     PrintDocument
+}
+
+// PrintService queues rendered documents and sends them to networked printers one at a time
+type PrintService struct {
+	jobRepo  *repository.PrintJobRepository
+	printers map[string]configuredPrinter
+	queue    chan queuedPrint
+
+	mu      sync.RWMutex // guards stopped against Enqueue sending on the closed queue
+	stopped bool
+}
+
+// NewPrintServiceFromEnv configures printers from the PRINTERS environment variable, a comma
+// separated list of name=uri pairs. Supported URIs are ipp://, ipps://, http(s):// (IPP) and
+// cups://<destination> (local CUPS queue via lp), e.g.
+//
+//	PRINTERS=warehouse=ipp://10.0.0.20:631/ipp/print,labels=cups://Zebra_ZD420
+func NewPrintServiceFromEnv(jobRepo *repository.PrintJobRepository) *PrintService {
+	service := &PrintService{
+		jobRepo:  jobRepo,
+		printers: map[string]configuredPrinter{},
+		queue:    make(chan queuedPrint, 100),
+	}
+
+	for _, entry := range strings.Split(os.Getenv("PRINTERS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, uri, ok := strings.Cut(entry, "=")
+		if !ok {
+			log.Printf("Warning: ignoring invalid printer entry %q", entry)
+			continue
+		}
+		name = strings.TrimSpace(name)
+		printer, printerType, err := newPrinter(strings.TrimSpace(uri))
+		if err != nil {
+			log.Printf("Warning: ignoring printer %s: %v", name, err)
+			continue
+		}
+		service.printers[name] = configuredPrinter{
+			info:    PrinterInfo{Name: name, Type: printerType, URI: strings.TrimSpace(uri)},
+			printer: printer,
+		}
+	}
+
+	return service
+}
+
+// newPrinter builds a printer implementation for a URI
+func newPrinter(uri string) (Printer, string, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid printer URI: %w", err)
+	}
+
+	switch parsed.Scheme {
+	case "cups":
+		destination := parsed.Host + parsed.Path
+		if destination == "" {
+			destination = parsed.Opaque
+		}
+		return &CUPSPrinter{destination: strings.Trim(destination, "/")}, "cups", nil
+	case "ipp", "http":
+		parsed.Scheme = "http"
+		if parsed.Port() == "" {
+			parsed.Host += ":631"
+		}
+		return &IPPPrinter{printerURI: uri, endpoint: parsed.String(), client: &http.Client{Timeout: 60 * time.Second}}, "ipp", nil
+	case "ipps", "https":
+		parsed.Scheme = "https"
+		if parsed.Port() == "" {
+			parsed.Host += ":631"
+		}
+		return &IPPPrinter{printerURI: uri, endpoint: parsed.String(), client: &http.Client{Timeout: 60 * time.Second}}, "ipp", nil
+	default:
+		return nil, "", fmt.Errorf("unsupported printer URI scheme %q", parsed.Scheme)
+	}
+}
+
+// Printers lists the configured printers sorted by name
+func (s *PrintService) Printers() []PrinterInfo {
+	printers := make([]PrinterInfo, 0, len(s.printers))
+	for _, p := range s.printers {
+		printers = append(printers, p.info)
+	}
+	sort.Slice(printers, func(i, j int) bool {
+		return printers[i].Name < printers[j].Name
+	})
+	return printers
+}
+
+// Start marks jobs interrupted by a previous shutdown as failed and starts the queue worker
+func (s *PrintService) Start() {
+	if count, err := s.jobRepo.FailInterrupted(context.Background()); err != nil {
+		log.Printf("Failed to clean up interrupted print jobs: %v", err)
+	} else if count > 0 {
+		log.Printf("Marked %d interrupted print jobs as failed", count)
+	}
+
+	goBackground(func() {
+		for item := range s.queue {
+			s.process(item)
+		}
+	})
+}
+
+// Stop closes the queue; the worker finishes the jobs already queued and then exits.
+// Jobs still queued when the process exits are marked failed by the next Start.
+func (s *PrintService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.stopped {
+		s.stopped = true
+		close(s.queue)
+	}
+}
+
+// Enqueue records the job and hands the rendered document to the queue worker
+func (s *PrintService) Enqueue(ctx context.Context, job *models.PrintJob, doc PrintDocument) error {
+	target, ok := s.printers[job.PrinterName]
+	if !ok {
+		return ErrUnknownPrinter
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.stopped {
+		return ErrPrintServiceStopped
+	}
+
+	job.Status = PrintStatusQueued
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return err
+	}
+
+	select {
+	case s.queue <- queuedPrint{jobID: job.PrintJobID, printer: target.printer, doc: doc}:
+		return nil
+	default:
+		message := ErrPrintQueueFull.Error()
+		job.Status = PrintStatusFailed
+		job.Error = &message
+		if err := s.jobRepo.UpdateStatus(ctx, job.PrintJobID, PrintStatusFailed, &message); err != nil {
+			log.Printf("Failed to update print job %d: %v", job.PrintJobID, err)
+		}
+		return ErrPrintQueueFull
+	}
+}
+
+// process sends a single queued document to its printer and records the outcome
+func (s *PrintService) process(item queuedPrint) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	if err := s.jobRepo.UpdateStatus(ctx, item.jobID, PrintStatusPrinting, nil); err != nil {
+		log.Printf("Failed to update print job %d: %v", item.jobID, err)
+	}
+
+	status := PrintStatusPrinted
+	var jobErr *string
+	if err := item.printer.Print(ctx, item.doc); err != nil {
+		log.Printf("Print job %d failed: %v", item.jobID, err)
+		message := err.Error()
+		status = PrintStatusFailed
+		jobErr = &message
+	}
+
+	if err := s.jobRepo.UpdateStatus(ctx, item.jobID, status, jobErr); err != nil {
+		log.Printf("Failed to update print job %d: %v", item.jobID, err)
+	}
+}
+
+// CUPSPrinter prints through the local CUPS scheduler using the lp command
+type CUPSPrinter struct {
+	destination string
+}
+
+// Print pipes the document into lp
+func (p *CUPSPrinter) Print(ctx context.Context, doc PrintDocument) error {
+	copies := doc.Copies
+	if copies < 1 {
+		copies = 1
+	}
+
+	args := []string{"-d", p.destination, "-n", strconv.Itoa(copies), "-t", doc.Title}
+	if doc.raw() {
+		args = append(args, "-o", "raw")
+	}
+	cmd := exec.CommandContext(ctx, "lp", args...)
+	cmd.Stdin = bytes.NewReader(doc.Content)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("lp failed: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// IPP attribute tags and operation codes used for Print-Job requests
+const (
+	ippOperationAttributesTag byte   = 0x01
+	ippJobAttributesTag       byte   = 0x02
+	ippEndOfAttributesTag     byte   = 0x03
+	ippTagInteger             byte   = 0x21
+	ippTagName                byte   = 0x42
+	ippTagURI                 byte   = 0x45
+	ippTagCharset             byte   = 0x47
+	ippTagNaturalLanguage     byte   = 0x48
+	ippTagMimeMediaType       byte   = 0x49
+	ippOperationPrintJob      uint16 = 0x0002
+)
+
+// IPPPrinter sends documents directly to an IPP-capable printer or print server
+type IPPPrinter struct {
+	printerURI string
+	endpoint   string
+	client     *http.Client
+}
+
+// Print submits a Print-Job operation with the document as document data; raw documents
+// are sent as application/octet-stream so the printer passes them through
+func (p *IPPPrinter) Print(ctx context.Context, doc PrintDocument) error {
+	copies := doc.Copies
+	if copies < 1 {
+		copies = 1
+	}
+
+	var body bytes.Buffer
+	body.Write([]byte{0x01, 0x01}) // IPP/1.1
+	binary.Write(&body, binary.BigEndian, ippOperationPrintJob)
+	binary.Write(&body, binary.BigEndian, uint32(time.Now().UnixNano()&0x7fffffff))
+
+	body.WriteByte(ippOperationAttributesTag)
+	writeIPPAttribute(&body, ippTagCharset, "attributes-charset", []byte("utf-8"))
+	writeIPPAttribute(&body, ippTagNaturalLanguage, "attributes-natural-language", []byte("en"))
+	writeIPPAttribute(&body, ippTagURI, "printer-uri", []byte(p.printerURI))
+	writeIPPAttribute(&body, ippTagName, "requesting-user-name", []byte("scms"))
+	writeIPPAttribute(&body, ippTagName, "job-name", []byte(doc.Title))
+	documentFormat := "application/pdf"
+	if doc.raw() {
+		documentFormat = "application/octet-stream"
+	}
+	writeIPPAttribute(&body, ippTagMimeMediaType, "document-format", []byte(documentFormat))
+
+	body.WriteByte(ippJobAttributesTag)
+	copiesValue := make([]byte, 4)
+	binary.BigEndian.PutUint32(copiesValue, uint32(copies))
+	writeIPPAttribute(&body, ippTagInteger, "copies", copiesValue)
+
+	body.WriteByte(ippEndOfAttributesTag)
+	body.Write(doc.Content)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/ipp")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("IPP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("IPP request failed with HTTP status %s", resp.Status)
+	}
+
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(resp.Body, header); err != nil {
+		return fmt.Errorf("invalid IPP response: %w", err)
+	}
+
+	// Status codes 0x0000-0x00FF are successful-ok variants
+	statusCode := binary.BigEndian.Uint16(header[2:4])
+	if statusCode > 0x00FF {
+		return fmt.Errorf("printer rejected job with IPP status 0x%04x", statusCode)
+	}
+	return nil
+}
+
+// writeIPPAttribute encodes a single-valued IPP attribute
+func writeIPPAttribute(buf *bytes.Buffer, tag byte, name string, value []byte) {
+	buf.WriteByte(tag)
+	binary.Write(buf, binary.BigEndian, uint16(len(name)))
+	buf.WriteString(name)
+	binary.Write(buf, binary.BigEndian, uint16(len(value)))
+	buf.Write(value)
+}
@@ -0,0 +1,289 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// specImportPrefix marks a column holding a single technical spec, e.g. "spec.amperage"
+const specImportPrefix = "spec."
+
+// specImportName limits spec column names to the characters allowed in spec schemas
+var specImportName = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// productImportFields are the product fields a spreadsheet column can be mapped to, besides
+// spec.<name> columns
+var productImportFields = map[string]bool{
+	"product_name": true, "model": true, "category": true, "description": true,
+	"technical_specs": true, "certifications": true, "safety_standards": true,
+	"warranty_period": true, "price": true, "restricted": true,
+	"weight_kg": true, "length_cm": true, "width_cm": true, "height_cm": true,
+}
+
+// productImportAliases are other header names recognised for product fields
+var productImportAliases = map[string]string{
+	"name":     "product_name",
+	"product":  "product_name",
+	"specs":    "technical_specs",
+	"warranty": "warranty_period",
+	"weight":   "weight_kg",
+	"length":   "length_cm",
+	"width":    "width_cm",
+	"height":   "height_cm",
+}
+
+// ParseProductImport reads the products in spreadsheet rows, the first of which is the
+// header. Columns are matched to product fields by their header, normalised to lower case
+// with underscores, or as given in mapping, which maps header names to field names; mapping a
+// header to "" ignores the column. A technical_specs column holds a JSON object, and
+// spec.<name> columns set single specs, converted to the type the category's spec schema
+// gives them. Blank rows are skipped; rows that cannot be read carry errors rather than
+// failing the import. A *SpreadsheetError is returned when the columns cannot be matched.
+func (s *ProductSpecService) ParseProductImport(ctx context.Context, rows [][]string, mapping map[string]string) ([]models.ProductImportRow, error) {
+	if len(rows) == 0 {
+		return nil, &SpreadsheetError{Message: "the file is empty"}
+	}
+
+	columns, err := productImportColumns(rows[0], mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	schemas := map[string]models.SpecFields{}
+	result := []models.ProductImportRow{}
+	for i, record := range rows[1:] {
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		row := models.ProductImportRow{Row: i + 2, Errors: []models.ImportError{}}
+		specs := map[string]string{}
+		for column, field := range columns {
+			if field == "" || column >= len(record) {
+				continue
+			}
+			value := strings.TrimSpace(record[column])
+			if strings.HasPrefix(field, specImportPrefix) {
+				if value != "" {
+					specs[strings.TrimPrefix(field, specImportPrefix)] = value
+				}
+				continue
+			}
+			if message := setProductField(&row.Product, field, value); message != "" {
+				row.Errors = append(row.Errors, models.ImportError{Field: field, Message: message})
+			}
+		}
+
+		if len(specs) > 0 {
+			var fields models.SpecFields
+			if row.Product.Category != nil {
+				category := *row.Product.Category
+				if _, loaded := schemas[category]; !loaded {
+					schema, err := s.specSchemaRepo.GetByCategory(ctx, category)
+					if err != nil && !errors.Is(err, repository.ErrNotFound) {
+						return nil, err
+					}
+					schemas[category] = schema.Fields
+				}
+				fields = schemas[category]
+			}
+			row.Errors = append(row.Errors, mergeImportedSpecs(&row.Product, specs, fields)...)
+		}
+
+		result = append(result, row)
+	}
+	return result, nil
+}
+
+// productImportColumns resolves the product field of each header column, "" for columns
+// that are ignored
+func productImportColumns(header []string, mapping map[string]string) ([]string, error) {
+	mapped := map[string]string{}
+	for name, field := range mapping {
+		mapped[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(field)
+	}
+
+	columns := make([]string, len(header))
+	seen := map[string]string{}
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		field, ok := mapped[strings.ToLower(name)]
+		if !ok {
+			field = strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(name))
+			if alias, isAlias := productImportAliases[field]; isAlias {
+				field = alias
+			}
+			if !productImportFields[field] && !strings.HasPrefix(field, specImportPrefix) {
+				field = ""
+			}
+		}
+		if field == "" {
+			continue
+		}
+
+		if strings.HasPrefix(field, specImportPrefix) {
+			if !specImportName.MatchString(strings.TrimPrefix(field, specImportPrefix)) {
+				return nil, &SpreadsheetError{Row: 1, Message: fmt.Sprintf("column %q: spec names may only contain letters, digits and underscores", name)}
+			}
+		} else if !productImportFields[field] {
+			return nil, &SpreadsheetError{Row: 1, Message: fmt.Sprintf("column %q is mapped to unknown field %q", name, field)}
+		}
+		if other, duplicate := seen[field]; duplicate {
+			return nil, &SpreadsheetError{Row: 1, Message: fmt.Sprintf("columns %q and %q are both mapped to %s", other, name, field)}
+		}
+		seen[field] = name
+		columns[i] = field
+	}
+
+	if _, ok := seen["product_name"]; !ok {
+		return nil, &SpreadsheetError{Row: 1, Message: "no column is mapped to product_name"}
+	}
+	return columns, nil
+}
+
+// setProductField sets a product field from its cell, returning a message when the value
+// cannot be read
+func setProductField(product *models.Product, field, value string) string {
+	optional := func() *string {
+		if value == "" {
+			return nil
+		}
+		return &value
+	}
+	measure := func() (*float64, string) {
+		if value == "" {
+			return nil, ""
+		}
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, "must be a number"
+		}
+		return &number, ""
+	}
+
+	var message string
+	switch field {
+	case "product_name":
+		product.ProductName = value
+	case "model":
+		product.Model = optional()
+	case "category":
+		product.Category = optional()
+	case "description":
+		product.Description = optional()
+	case "certifications":
+		product.Certifications = optional()
+	case "safety_standards":
+		product.SafetyStandards = optional()
+	case "technical_specs":
+		if value == "" {
+			return ""
+		}
+		var specs map[string]interface{}
+		if err := json.Unmarshal([]byte(value), &specs); err != nil || specs == nil {
+			return "must be a JSON object"
+		}
+		product.TechnicalSpecs = json.RawMessage(value)
+	case "warranty_period":
+		if value == "" {
+			return ""
+		}
+		months, err := strconv.Atoi(value)
+		if err != nil {
+			return "must be a whole number"
+		}
+		product.WarrantyPeriod = months
+	case "price":
+		if value == "" {
+			return ""
+		}
+		price, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", ""), 64)
+		if err != nil {
+			return "must be a number"
+		}
+		product.Price = price
+	case "restricted":
+		restricted, ok := parseImportBool(value)
+		if !ok {
+			return "must be yes or no"
+		}
+		product.Restricted = restricted
+	case "weight_kg":
+		product.WeightKg, message = measure()
+	case "length_cm":
+		product.LengthCm, message = measure()
+	case "width_cm":
+		product.WidthCm, message = measure()
+	case "height_cm":
+		product.HeightCm, message = measure()
+	}
+	return message
+}
+
+// mergeImportedSpecs adds spec column values to the product's technical specs, converting
+// each to the type its schema field gives it; without a schema field, numbers and yes/no
+// values are recognised and anything else is kept as text
+func mergeImportedSpecs(product *models.Product, values map[string]string, fields models.SpecFields) []models.ImportError {
+	types := map[string]string{}
+	for _, field := range fields {
+		types[field.Name] = field.Type
+	}
+
+	specs := map[string]interface{}{}
+	if len(product.TechnicalSpecs) > 0 {
+		json.Unmarshal(product.TechnicalSpecs, &specs)
+	}
+
+	errs := []models.ImportError{}
+	for name, value := range values {
+		label := specImportPrefix + name
+		switch types[name] {
+		case models.SpecTypeNumber, models.SpecTypeInteger:
+			number, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				errs = append(errs, models.ImportError{Field: label, Message: "must be a number"})
+				continue
+			}
+			specs[name] = number
+		case models.SpecTypeBoolean:
+			flag, ok := parseImportBool(value)
+			if !ok {
+				errs = append(errs, models.ImportError{Field: label, Message: "must be yes or no"})
+				continue
+			}
+			specs[name] = flag
+		case models.SpecTypeString, models.SpecTypeEnum:
+			specs[name] = value
+		default:
+			if number, err := strconv.ParseFloat(value, 64); err == nil {
+				specs[name] = number
+			} else if flag, ok := parseImportBool(value); ok {
+				specs[name] = flag
+			} else {
+				specs[name] = value
+			}
+		}
+	}
+
+	product.TechnicalSpecs, _ = json.Marshal(specs)
+	return errs
+}
+
+// parseImportBool reads yes/no, true/false, y/n and 1/0 in any case, with a blank cell as no
+func parseImportBool(value string) (bool, bool) {
+	switch strings.ToLower(value) {
+	case "yes", "y", "true", "1":
+		return true, true
+	case "no", "n", "false", "0", "":
+		return false, true
+	}
+	return false, false
+}
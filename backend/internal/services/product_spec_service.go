@@ -0,0 +1,321 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// SpecFieldError describes why a single technical spec value is invalid
+type SpecFieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ProductSpecService validates technical specs against category schemas and records product history
+type ProductSpecService struct {
+	specSchemaRepo     *repository.SpecSchemaRepository
+	productHistoryRepo *repository.ProductHistoryRepository
+}
+
+// NewProductSpecService creates a new product spec service
+func NewProductSpecService(
+	specSchemaRepo *repository.SpecSchemaRepository,
+	productHistoryRepo *repository.ProductHistoryRepository,
+) *ProductSpecService {
+	return &ProductSpecService{
+		specSchemaRepo:     specSchemaRepo,
+		productHistoryRepo: productHistoryRepo,
+	}
+}
+
+// ValidateSchema checks that a spec schema definition is well formed
+func ValidateSchema(fields models.SpecFields) []SpecFieldError {
+	errs := []SpecFieldError{}
+	seen := map[string]bool{}
+
+	for i, field := range fields {
+		name := strings.TrimSpace(field.Name)
+		label := fmt.Sprintf("fields[%d]", i)
+		if name == "" {
+			errs = append(errs, SpecFieldError{Field: label, Message: "name is required"})
+			continue
+		}
+		if seen[name] {
+			errs = append(errs, SpecFieldError{Field: name, Message: "duplicate field name"})
+		}
+		seen[name] = true
+
+		switch field.Type {
+		case models.SpecTypeString, models.SpecTypeNumber, models.SpecTypeInteger, models.SpecTypeBoolean:
+		case models.SpecTypeEnum:
+			if len(field.Options) == 0 {
+				errs = append(errs, SpecFieldError{Field: name, Message: "enum fields need at least one option"})
+			}
+		default:
+			errs = append(errs, SpecFieldError{Field: name, Message: "type must be string, number, integer, boolean or enum"})
+		}
+
+		if field.Min != nil && field.Max != nil && *field.Min > *field.Max {
+			errs = append(errs, SpecFieldError{Field: name, Message: "min cannot be greater than max"})
+		}
+	}
+
+	return errs
+}
+
+// Validate checks a product's technical specs against the schema for its category.
+// Products without a category, or in a category without a schema, are not checked.
+func (s *ProductSpecService) Validate(ctx context.Context, product *models.Product) ([]SpecFieldError, error) {
+	if product.Category == nil || *product.Category == "" {
+		return nil, nil
+	}
+
+	schema, err := s.specSchemaRepo.GetByCategory(ctx, *product.Category)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	specs := map[string]interface{}{}
+	if len(product.TechnicalSpecs) > 0 && string(product.TechnicalSpecs) != "null" {
+		if err := json.Unmarshal(product.TechnicalSpecs, &specs); err != nil {
+			return []SpecFieldError{{Field: "technical_specs", Message: "must be a JSON object"}}, nil
+		}
+	}
+
+	return validateSpecs(schema.Fields, specs), nil
+}
+
+// validateSpecs checks spec values against the schema fields
+func validateSpecs(fields models.SpecFields, specs map[string]interface{}) []SpecFieldError {
+	errs := []SpecFieldError{}
+	known := map[string]bool{}
+
+	for _, field := range fields {
+		known[field.Name] = true
+
+		value, present := specs[field.Name]
+		if !present || value == nil {
+			if field.Required {
+				errs = append(errs, SpecFieldError{Field: field.Name, Message: "is required"})
+			}
+			continue
+		}
+
+		if message := checkSpecValue(field, value); message != "" {
+			errs = append(errs, SpecFieldError{Field: field.Name, Message: message})
+		}
+	}
+
+	unknown := []string{}
+	for name := range specs {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		errs = append(errs, SpecFieldError{Field: name, Message: "is not part of the category spec schema"})
+	}
+
+	return errs
+}
+
+// checkSpecValue returns a message when a value does not match its field definition
+func checkSpecValue(field models.SpecField, value interface{}) string {
+	switch field.Type {
+	case models.SpecTypeString:
+		if _, ok := value.(string); !ok {
+			return "must be a string"
+		}
+	case models.SpecTypeBoolean:
+		if _, ok := value.(bool); !ok {
+			return "must be true or false"
+		}
+	case models.SpecTypeEnum:
+		str, ok := value.(string)
+		if !ok {
+			return "must be one of: " + strings.Join(field.Options, ", ")
+		}
+		for _, option := range field.Options {
+			if str == option {
+				return ""
+			}
+		}
+		return "must be one of: " + strings.Join(field.Options, ", ")
+	case models.SpecTypeNumber, models.SpecTypeInteger:
+		number, ok := value.(float64)
+		if !ok {
+			return "must be a number"
+		}
+		if field.Type == models.SpecTypeInteger && number != math.Trunc(number) {
+			return "must be a whole number"
+		}
+		if field.Min != nil && number < *field.Min {
+			return fmt.Sprintf("must be at least %v%s", *field.Min, unitSuffix(field.Unit))
+		}
+		if field.Max != nil && number > *field.Max {
+			return fmt.Sprintf("must be at most %v%s", *field.Max, unitSuffix(field.Unit))
+		}
+	}
+	return ""
+}
+
+// ValidateFilters checks spec filters against the schema for a category. Filters on unknown
+// fields are rejected, and range filters are only allowed on numeric fields.
+func (s *ProductSpecService) ValidateFilters(ctx context.Context, category string, filters []repository.SpecFilter) ([]SpecFieldError, error) {
+	schema, err := s.specSchemaRepo.GetByCategory(ctx, category)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	fields := map[string]models.SpecField{}
+	for _, field := range schema.Fields {
+		fields[field.Name] = field
+	}
+
+	errs := []SpecFieldError{}
+	for _, filter := range filters {
+		field, ok := fields[filter.Field]
+		if !ok {
+			errs = append(errs, SpecFieldError{Field: filter.Field, Message: "is not part of the category spec schema"})
+			continue
+		}
+		if filter.Op != repository.SpecFilterEquals && field.Type != models.SpecTypeNumber && field.Type != models.SpecTypeInteger {
+			errs = append(errs, SpecFieldError{Field: filter.Field, Message: "range filters require a numeric field"})
+		}
+	}
+
+	return errs, nil
+}
+
+// unitSuffix formats a unit for validation messages
+func unitSuffix(unit string) string {
+	if unit == "" {
+		return ""
+	}
+	return " " + unit
+}
+
+// DiffSpecs compares two technical spec objects key by key
+func DiffSpecs(oldSpecs, newSpecs json.RawMessage) []models.SpecChange {
+	before := decodeSpecs(oldSpecs)
+	after := decodeSpecs(newSpecs)
+
+	names := map[string]bool{}
+	for name := range before {
+		names[name] = true
+	}
+	for name := range after {
+		names[name] = true
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	changes := []models.SpecChange{}
+	for _, name := range sorted {
+		oldValue, hadOld := before[name]
+		newValue, hasNew := after[name]
+		if hadOld && hasNew && reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+
+		change := models.SpecChange{Field: name}
+		if hadOld {
+			change.Old, _ = json.Marshal(oldValue)
+		}
+		if hasNew {
+			change.New, _ = json.Marshal(newValue)
+		}
+		changes = append(changes, change)
+	}
+
+	return changes
+}
+
+// decodeSpecs decodes a spec object, treating anything that is not an object as empty
+func decodeSpecs(raw json.RawMessage) map[string]interface{} {
+	specs := map[string]interface{}{}
+	if len(raw) > 0 {
+		json.Unmarshal(raw, &specs)
+	}
+	return specs
+}
+
+// RecordChange writes a history entry for a created or updated product.
+// Pass a nil before for newly created products. Failures are logged, not returned.
+func (s *ProductSpecService) RecordChange(ctx context.Context, before *models.Product, after models.Product) {
+	entry := models.ProductHistory{
+		ProductID: after.ProductID,
+		Action:    "created",
+	}
+
+	var changed []string
+	var specsDiff []models.SpecChange
+	if before == nil {
+		specsDiff = DiffSpecs(nil, after.TechnicalSpecs)
+	} else {
+		entry.Action = "updated"
+		changed = changedProductFields(*before, after)
+		specsDiff = DiffSpecs(before.TechnicalSpecs, after.TechnicalSpecs)
+		if len(changed) == 0 && len(specsDiff) == 0 {
+			return
+		}
+	}
+
+	if changed == nil {
+		changed = []string{}
+	}
+	entry.ChangedFields, _ = json.Marshal(changed)
+	entry.SpecsDiff, _ = json.Marshal(specsDiff)
+
+	if err := s.productHistoryRepo.Create(ctx, &entry); err != nil {
+		log.Printf("Failed to record history for product %d: %v", after.ProductID, err)
+	}
+}
+
+// changedProductFields lists the JSON names of the product fields that differ, excluding
+// technical specs (reported separately) and timestamps
+func changedProductFields(before, after models.Product) []string {
+	changed := []string{}
+	compare := func(name string, a, b interface{}) {
+		if !reflect.DeepEqual(a, b) {
+			changed = append(changed, name)
+		}
+	}
+
+	compare("product_name", before.ProductName, after.ProductName)
+	compare("model", before.Model, after.Model)
+	compare("category", before.Category, after.Category)
+	compare("description", before.Description, after.Description)
+	compare("certifications", before.Certifications, after.Certifications)
+	compare("safety_standards", before.SafetyStandards, after.SafetyStandards)
+	compare("warranty_period", before.WarrantyPeriod, after.WarrantyPeriod)
+	compare("price", before.Price, after.Price)
+	compare("restricted", before.Restricted, after.Restricted)
+	compare("weight_kg", before.WeightKg, after.WeightKg)
+	compare("length_cm", before.LengthCm, after.LengthCm)
+	compare("width_cm", before.WidthCm, after.WidthCm)
+	compare("height_cm", before.HeightCm, after.HeightCm)
+
+	return changed
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// PromiseService works out the dates quotation lines can be delivered by. Lines are promised
+// from the stock available now, then from the purchase orders on their way, and failing both
+// from the lead time of the supplier the shortfall would be bought from.
+type PromiseService struct {
+	availabilityRepo *repository.AvailabilityRepository
+	supplierRepo     *repository.SupplierRepository
+	rules            *RulesService
+}
+
+// NewPromiseService creates a new promise service
+func NewPromiseService(availabilityRepo *repository.AvailabilityRepository, supplierRepo *repository.SupplierRepository, rules *RulesService) *PromiseService {
+	return &PromiseService{
+		availabilityRepo: availabilityRepo,
+		supplierRepo:     supplierRepo,
+		rules:            rules,
+	}
+}
+
+// Promise sets the promise date, source and time of each line as of now. Lines of the same
+// product are promised in order, each from what the lines before it leave, and every date
+// allows the promise_handling_days rule's days to deliver. Unavailable lines have no date.
+func (s *PromiseService) Promise(ctx context.Context, items []models.QuotationItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	productIDs := make([]int, len(items))
+	for i, item := range items {
+		productIDs[i] = item.ProductID
+	}
+	available, err := s.availabilityRepo.GetAvailable(ctx, productIDs)
+	if err != nil {
+		return err
+	}
+	incoming, err := s.availabilityRepo.GetIncoming(ctx, productIDs)
+	if err != nil {
+		return err
+	}
+	prices, err := s.supplierRepo.GetPricesForProducts(ctx, productIDs)
+	if err != nil {
+		return err
+	}
+	handlingDays := s.rules.Int(ctx, models.RulePromiseHandlingDays)
+
+	now := time.Now()
+	quoted := map[int]int{}
+	for i := range items {
+		item := &items[i]
+		quoted[item.ProductID] += item.Quantity
+
+		date, source := promiseDate(quoted[item.ProductID], available[item.ProductID], incoming[item.ProductID], prices[item.ProductID], now)
+		if date != nil {
+			delivered := date.AddDate(0, 0, handlingDays)
+			date = &delivered
+		}
+		item.PromiseDate = date
+		item.PromiseSource = &source
+		item.PromisedAt = &now
+	}
+	return nil
+}
+
+// promiseDate returns the day a product's stock covers quantity, everything quoted of it so
+// far, and where that stock comes from
+func promiseDate(quantity, available int, incoming []models.IncomingStock, prices []models.SupplierPrice, now time.Time) (*time.Time, string) {
+	today := expectedDate(now, 0)
+	if quantity <= available {
+		return &today, models.PromiseFromStock
+	}
+
+	supply := available
+	for _, line := range incoming {
+		supply += line.Quantity
+		if quantity <= supply {
+			// A purchase order that is late is promised as if it arrives today
+			date := line.ExpectedDate
+			if date.Before(today) {
+				date = today
+			}
+			return &date, models.PromiseFromIncoming
+		}
+	}
+
+	if price, ok := selectSupplier(prices, nil, now); ok {
+		date := expectedDate(now, price.LeadTimeDays)
+		return &date, models.PromiseFromLeadTime
+	}
+	return nil, models.PromiseUnavailable
+}
+
+// FormatPromise describes a quotation line's promise date for documents, or "On request" for
+// a line without one
+func FormatPromise(item models.QuotationItem) string {
+	if item.PromiseDate == nil {
+		return "On request"
+	}
+	return item.PromiseDate.Format("Jan 2, 2006")
+}
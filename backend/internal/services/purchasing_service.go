@@ -0,0 +1,477 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// ErrUnknownPurchaseOrderItem is returned when a receipt names a line that isn't on the purchase order
+var ErrUnknownPurchaseOrderItem = errors.New("item is not on this purchase order")
+
+// PurchaseItem is a product and quantity to purchase
+type PurchaseItem struct {
+	ProductID int `json:"product_id" validate:"required"`
+	Quantity  int `json:"quantity" validate:"gt=0"`
+}
+
+// SupplierOption is one supplier's offer for a product in a comparison
+type SupplierOption struct {
+	models.SupplierPrice
+	ExpectedDate time.Time `json:"expected_date"`
+	MeetsNeedBy  bool      `json:"meets_need_by"`
+	Cheapest     bool      `json:"cheapest"`
+	Fastest      bool      `json:"fastest"`
+	Selected     bool      `json:"selected"`
+}
+
+// SupplierComparison lists every supplier's offer for a product and which one would be used
+type SupplierComparison struct {
+	ProductID          int              `json:"product_id"`
+	NeedBy             *time.Time       `json:"need_by,omitempty"`
+	SelectedSupplierID *int             `json:"selected_supplier_id"`
+	Options            []SupplierOption `json:"options"`
+}
+
+// GeneratePurchaseOrdersResult holds the draft purchase orders created and the products no
+// active supplier sells
+type GeneratePurchaseOrdersResult struct {
+	PurchaseOrders []models.PurchaseOrder `json:"purchase_orders"`
+	Unassigned     []int                  `json:"unassigned_product_ids"`
+}
+
+// LandedCostLine is a purchase order line with its share of the order's charges
+type LandedCostLine struct {
+	PurchaseOrderItemID int     `json:"purchase_order_item_id"`
+	ProductID           int     `json:"product_id"`
+	Quantity            int     `json:"quantity"`
+	UnitCost            float64 `json:"unit_cost"`
+	GoodsTotal          float64 `json:"goods_total"`
+	AllocatedCharges    float64 `json:"allocated_charges"`
+	LandedTotal         float64 `json:"landed_total"`
+	LandedUnitCost      float64 `json:"landed_unit_cost"`
+}
+
+// LandedCost breaks a purchase order's charges down across its lines
+type LandedCost struct {
+	PurchaseOrderID int              `json:"purchase_order_id"`
+	GoodsTotal      float64          `json:"goods_total"`
+	ChargesTotal    float64          `json:"charges_total"`
+	LandedTotal     float64          `json:"landed_total"`
+	Lines           []LandedCostLine `json:"lines"`
+}
+
+// PurchasingService compares supplier prices, turns purchase needs into purchase orders and
+// works out landed costs when they are received
+type PurchasingService struct {
+	supplierRepo      *repository.SupplierRepository
+	purchaseOrderRepo *repository.PurchaseOrderRepository
+	inventoryRepo     *repository.InventoryRepository
+	productRepo       *repository.ProductRepository
+	settingRepo       *repository.SettingRepository
+	budgetOverrun     string
+}
+
+// NewPurchasingService creates a new purchasing service. PURCHASE_BUDGET_OVERRUN decides
+// what happens to purchase orders that would exceed their department's budget: "escalate"
+// (the default) holds them for an admin's approval and "reject" refuses them.
+func NewPurchasingService(
+	supplierRepo *repository.SupplierRepository,
+	purchaseOrderRepo *repository.PurchaseOrderRepository,
+	inventoryRepo *repository.InventoryRepository,
+	productRepo *repository.ProductRepository,
+	settingRepo *repository.SettingRepository,
+) *PurchasingService {
+	return &PurchasingService{
+		supplierRepo:      supplierRepo,
+		purchaseOrderRepo: purchaseOrderRepo,
+		inventoryRepo:     inventoryRepo,
+		productRepo:       productRepo,
+		settingRepo:       settingRepo,
+		budgetOverrun:     envOrDefault("PURCHASE_BUDGET_OVERRUN", models.BudgetOverrunEscalate),
+	}
+}
+
+// Compare lists every supplier's price for a product, flagging the cheapest and fastest
+// offers and the one automatic selection would use
+func (s *PurchasingService) Compare(ctx context.Context, productID int, needBy *time.Time) (*SupplierComparison, error) {
+	prices, err := s.supplierRepo.GetProductPrices(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	comparison := &SupplierComparison{ProductID: productID, NeedBy: needBy, Options: []SupplierOption{}}
+	cheapest, fastest := -1, -1
+	for i, price := range prices {
+		option := SupplierOption{
+			SupplierPrice: price,
+			ExpectedDate:  expectedDate(now, price.LeadTimeDays),
+		}
+		option.MeetsNeedBy = needBy == nil || !option.ExpectedDate.After(*needBy)
+		comparison.Options = append(comparison.Options, option)
+
+		if !price.SupplierActive {
+			continue
+		}
+		if cheapest < 0 || price.UnitCost < prices[cheapest].UnitCost {
+			cheapest = i
+		}
+		if fastest < 0 || price.LeadTimeDays < prices[fastest].LeadTimeDays {
+			fastest = i
+		}
+	}
+	if cheapest >= 0 {
+		comparison.Options[cheapest].Cheapest = true
+		comparison.Options[fastest].Fastest = true
+	}
+
+	if selected, ok := selectSupplier(prices, needBy, now); ok {
+		comparison.SelectedSupplierID = &selected.SupplierID
+		for i := range comparison.Options {
+			if comparison.Options[i].SupplierID == selected.SupplierID {
+				comparison.Options[i].Selected = true
+			}
+		}
+	}
+
+	return comparison, nil
+}
+
+// GeneratePurchaseOrders creates one draft purchase order per supplier for the given items,
+// picking each product's supplier automatically. Without items, every low-stock product is
+// ordered up to twice its reorder level, less what is already on open purchase orders.
+// Quantities are raised to the supplier's minimum order quantity.
+func (s *PurchasingService) GeneratePurchaseOrders(ctx context.Context, items []PurchaseItem, needBy *time.Time, department *string, createdBy *int) (*GeneratePurchaseOrdersResult, error) {
+	if len(items) == 0 {
+		var err error
+		items, err = s.lowStockItems(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := &GeneratePurchaseOrdersResult{PurchaseOrders: []models.PurchaseOrder{}, Unassigned: []int{}}
+	if len(items) == 0 {
+		return result, nil
+	}
+
+	productIDs := make([]int, len(items))
+	for i, item := range items {
+		productIDs[i] = item.ProductID
+	}
+	prices, err := s.supplierRepo.GetPricesForProducts(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	orders := map[int]*models.PurchaseOrder{}
+	supplierIDs := []int{}
+	for _, item := range items {
+		price, ok := selectSupplier(prices[item.ProductID], needBy, now)
+		if !ok {
+			if !containsInt(result.Unassigned, item.ProductID) {
+				result.Unassigned = append(result.Unassigned, item.ProductID)
+			}
+			continue
+		}
+
+		order, ok := orders[price.SupplierID]
+		if !ok {
+			order = &models.PurchaseOrder{
+				SupplierID: price.SupplierID,
+				Status:     models.PurchaseOrderDraft,
+				Notes:      "Generated from supplier prices",
+				Department: department,
+				CreatedBy:  createdBy,
+			}
+			orders[price.SupplierID] = order
+			supplierIDs = append(supplierIDs, price.SupplierID)
+		}
+
+		quantity := item.Quantity
+		if quantity < price.MinOrderQty {
+			quantity = price.MinOrderQty
+		}
+		order.Items = append(order.Items, models.PurchaseOrderItem{
+			ProductID:    item.ProductID,
+			Quantity:     quantity,
+			UnitCost:     price.UnitCost,
+			LeadTimeDays: price.LeadTimeDays,
+		})
+
+		expected := expectedDate(now, price.LeadTimeDays)
+		if order.ExpectedDate == nil || expected.After(*order.ExpectedDate) {
+			order.ExpectedDate = &expected
+		}
+	}
+
+	sort.Ints(supplierIDs)
+	for _, supplierID := range supplierIDs {
+		order := orders[supplierID]
+		if err := s.purchaseOrderRepo.Create(ctx, order); err != nil {
+			return nil, fmt.Errorf("creating purchase order for supplier %d: %w", supplierID, err)
+		}
+		result.PurchaseOrders = append(result.PurchaseOrders, *order)
+	}
+
+	return result, nil
+}
+
+// PlaceOrder places a draft purchase order against its department's budget, escalating or
+// refusing it when it would exceed the remaining budget. It returns the new status.
+func (s *PurchasingService) PlaceOrder(ctx context.Context, id int) (string, error) {
+	return s.purchaseOrderRepo.PlaceOrder(ctx, id, s.budgetOverrun != models.BudgetOverrunReject)
+}
+
+// LandedCost spreads a purchase order's charges across its lines. Received purchase
+// orders use the quantities received; others the quantities ordered.
+func (s *PurchasingService) LandedCost(ctx context.Context, order models.PurchaseOrder) (*LandedCost, error) {
+	quantities := map[int]int{}
+	for _, item := range order.Items {
+		quantities[item.PurchaseOrderItemID] = item.Quantity
+		if item.ReceivedQuantity != nil {
+			quantities[item.PurchaseOrderItemID] = *item.ReceivedQuantity
+		}
+	}
+	return s.allocateCharges(ctx, order, quantities)
+}
+
+// CostPolicy returns how received goods update product cost prices
+func (s *PurchasingService) CostPolicy(ctx context.Context) (string, error) {
+	setting, err := s.settingRepo.Get(ctx, models.SettingCostPolicy)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.CostPolicyMovingAverage, nil
+		}
+		return "", err
+	}
+	return setting.Value, nil
+}
+
+// SetCostPolicy changes how received goods update product cost prices. Cost prices already
+// recorded are kept; the new policy applies from the next receipt.
+func (s *PurchasingService) SetCostPolicy(ctx context.Context, policy string, updatedBy *int) (*models.Setting, error) {
+	setting := &models.Setting{Key: models.SettingCostPolicy, Value: policy, UpdatedBy: updatedBy}
+	if err := s.settingRepo.Set(ctx, setting); err != nil {
+		return nil, err
+	}
+	return setting, nil
+}
+
+// Receive records the goods received on an ordered purchase order. received maps purchase
+// order item IDs to the quantity received; lines left out are taken as received in full.
+// Charges are spread over the quantities received to give each line's landed unit cost,
+// and product cost prices are updated under the configured cost policy.
+func (s *PurchasingService) Receive(ctx context.Context, id int, received map[int]int, receivedBy *int) (*LandedCost, error) {
+	order, err := s.purchaseOrderRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != models.PurchaseOrderOrdered {
+		return nil, repository.ErrPurchaseOrderNotReceivable
+	}
+
+	quantities := map[int]int{}
+	for _, item := range order.Items {
+		quantities[item.PurchaseOrderItemID] = item.Quantity
+	}
+	for itemID, quantity := range received {
+		if _, ok := quantities[itemID]; !ok {
+			return nil, ErrUnknownPurchaseOrderItem
+		}
+		quantities[itemID] = quantity
+	}
+
+	landed, err := s.allocateCharges(ctx, order, quantities)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := s.CostPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]repository.ReceiptLine, len(landed.Lines))
+	for i, line := range landed.Lines {
+		lines[i] = repository.ReceiptLine{
+			PurchaseOrderItemID: line.PurchaseOrderItemID,
+			ProductID:           line.ProductID,
+			Quantity:            line.Quantity,
+			AllocatedCharges:    line.AllocatedCharges,
+			LandedUnitCost:      line.LandedUnitCost,
+		}
+	}
+	if err := s.purchaseOrderRepo.Receive(ctx, id, lines, policy, receivedBy); err != nil {
+		return nil, err
+	}
+
+	return landed, nil
+}
+
+// allocateCharges spreads each charge over the lines in proportion to their value, quantity
+// or weight. Charges by weight fall back to quantity when no line has a weight. Shares are
+// rounded to cents with the remainder on the last line so they add up to the charge.
+func (s *PurchasingService) allocateCharges(ctx context.Context, order models.PurchaseOrder, quantities map[int]int) (*LandedCost, error) {
+	productIDs := make([]int, len(order.Items))
+	for i, item := range order.Items {
+		productIDs[i] = item.ProductID
+	}
+	weights, err := s.productRepo.GetWeights(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	landed := &LandedCost{PurchaseOrderID: order.PurchaseOrderID, Lines: []LandedCostLine{}}
+	for _, item := range order.Items {
+		quantity := quantities[item.PurchaseOrderItemID]
+		line := LandedCostLine{
+			PurchaseOrderItemID: item.PurchaseOrderItemID,
+			ProductID:           item.ProductID,
+			Quantity:            quantity,
+			UnitCost:            item.UnitCost,
+			GoodsTotal:          roundMoney(float64(quantity) * item.UnitCost),
+		}
+		landed.GoodsTotal += line.GoodsTotal
+		landed.Lines = append(landed.Lines, line)
+	}
+
+	for _, charge := range order.Charges {
+		landed.ChargesTotal += charge.Amount
+
+		bases := make([]float64, len(landed.Lines))
+		total := 0.0
+		for i, line := range landed.Lines {
+			switch charge.Allocation {
+			case models.AllocateByQuantity:
+				bases[i] = float64(line.Quantity)
+			case models.AllocateByWeight:
+				bases[i] = weights[line.ProductID] * float64(line.Quantity)
+			default:
+				bases[i] = line.GoodsTotal
+			}
+			total += bases[i]
+		}
+		if total == 0 {
+			for i, line := range landed.Lines {
+				bases[i] = float64(line.Quantity)
+				total += bases[i]
+			}
+		}
+		if total == 0 {
+			continue
+		}
+
+		last := -1
+		allocated := 0.0
+		for i := range landed.Lines {
+			if bases[i] == 0 {
+				continue
+			}
+			share := roundMoney(charge.Amount * bases[i] / total)
+			landed.Lines[i].AllocatedCharges += share
+			allocated += share
+			last = i
+		}
+		landed.Lines[last].AllocatedCharges += roundMoney(charge.Amount - allocated)
+	}
+
+	for i := range landed.Lines {
+		line := &landed.Lines[i]
+		line.AllocatedCharges = roundMoney(line.AllocatedCharges)
+		line.LandedTotal = roundMoney(line.GoodsTotal + line.AllocatedCharges)
+		if line.Quantity > 0 {
+			line.LandedUnitCost = math.Round(line.LandedTotal/float64(line.Quantity)*10000) / 10000
+		}
+	}
+	landed.GoodsTotal = roundMoney(landed.GoodsTotal)
+	landed.ChargesTotal = roundMoney(landed.ChargesTotal)
+	landed.LandedTotal = roundMoney(landed.GoodsTotal + landed.ChargesTotal)
+
+	return landed, nil
+}
+
+// lowStockItems works out what to reorder for every product at or below its reorder level
+func (s *PurchasingService) lowStockItems(ctx context.Context) ([]PurchaseItem, error) {
+	lowStock, err := s.inventoryRepo.GetLowStockItems(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(lowStock) == 0 {
+		return nil, nil
+	}
+
+	productIDs := make([]int, len(lowStock))
+	for i, inventory := range lowStock {
+		productIDs[i] = inventory.ProductID
+	}
+	onOrder, err := s.purchaseOrderRepo.GetOpenQuantities(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []PurchaseItem{}
+	for _, inventory := range lowStock {
+		quantity := inventory.ReorderLevel*2 - inventory.CurrentStock - onOrder[inventory.ProductID]
+		if quantity > 0 {
+			items = append(items, PurchaseItem{ProductID: inventory.ProductID, Quantity: quantity})
+		}
+	}
+	return items, nil
+}
+
+// selectSupplier picks the supplier to buy a product from. Only active suppliers that can
+// deliver by needBy are considered, falling back to the fastest when none can. Among those,
+// the preferred supplier wins, then the lowest unit cost, then the shortest lead time.
+func selectSupplier(prices []models.SupplierPrice, needBy *time.Time, now time.Time) (models.SupplierPrice, bool) {
+	candidates := []models.SupplierPrice{}
+	var fastest *models.SupplierPrice
+	for i, price := range prices {
+		if !price.SupplierActive {
+			continue
+		}
+		if fastest == nil || price.LeadTimeDays < fastest.LeadTimeDays {
+			fastest = &prices[i]
+		}
+		if needBy == nil || !expectedDate(now, price.LeadTimeDays).After(*needBy) {
+			candidates = append(candidates, price)
+		}
+	}
+	if fastest == nil {
+		return models.SupplierPrice{}, false
+	}
+	if len(candidates) == 0 {
+		return *fastest, true
+	}
+
+	best := candidates[0]
+	for _, price := range candidates[1:] {
+		switch {
+		case price.Preferred != best.Preferred:
+			if price.Preferred {
+				best = price
+			}
+		case price.UnitCost != best.UnitCost:
+			if price.UnitCost < best.UnitCost {
+				best = price
+			}
+		case price.LeadTimeDays < best.LeadTimeDays:
+			best = price
+		}
+	}
+	return best, true
+}
+
+// expectedDate is the day an order placed now arrives given a lead time
+func expectedDate(now time.Time, leadTimeDays int) time.Time {
+	year, month, day := now.Date()
+	return time.Date(year, month, day+leadTimeDays, 0, 0, 0, 0, time.UTC)
+}
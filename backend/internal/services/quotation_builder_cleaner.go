@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// QuotationBuilderCleaner periodically deletes quotation builder sessions that were abandoned
+// before being submitted
+type QuotationBuilderCleaner struct {
+	builderRepo *repository.QuotationBuilderRepository
+	ttl         time.Duration
+	interval    time.Duration
+	stop        chan struct{}
+}
+
+// NewQuotationBuilderCleaner creates a new cleaner. Sessions expire QUOTATION_BUILDER_TTL_HOURS
+// hours (default 24) after their last change and are deleted hourly.
+func NewQuotationBuilderCleaner(builderRepo *repository.QuotationBuilderRepository) *QuotationBuilderCleaner {
+	ttl := time.Duration(envFloat("QUOTATION_BUILDER_TTL_HOURS", 24) * float64(time.Hour))
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+
+	return &QuotationBuilderCleaner{
+		builderRepo: builderRepo,
+		ttl:         ttl,
+		interval:    time.Hour,
+		stop:        make(chan struct{}),
+	}
+}
+
+// ExpiresAt returns when a session changed now is abandoned
+func (s *QuotationBuilderCleaner) ExpiresAt() time.Time {
+	return time.Now().Add(s.ttl)
+}
+
+// Start deletes abandoned sessions once at startup and then on every interval
+func (s *QuotationBuilderCleaner) Start() {
+	goBackground(func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.run()
+			select {
+			case <-ticker.C:
+			case <-s.stop:
+				return
+			}
+		}
+	})
+}
+
+// Stop ends the schedule after any run in progress has finished
+func (s *QuotationBuilderCleaner) Stop() {
+	close(s.stop)
+}
+
+func (s *QuotationBuilderCleaner) run() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	deleted, err := s.builderRepo.DeleteExpired(ctx)
+	if err != nil {
+		log.Printf("Failed to delete abandoned quotation builder sessions: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("Deleted %d abandoned quotation builder sessions", deleted)
+	}
+}
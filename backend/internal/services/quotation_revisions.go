@@ -0,0 +1,102 @@
+package services
+
+import (
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+)
+
+// Ways a quotation line can differ between two revisions
+const (
+	LineAdded   = "added"
+	LineRemoved = "removed"
+	LineChanged = "changed"
+)
+
+// FieldChange is a value that differs between two revisions
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// QuotationLineChange is a line added, removed or changed between two revisions. From is
+// missing for added lines and To for removed ones; Fields lists what changed on a line.
+type QuotationLineChange struct {
+	ProductID int                   `json:"product_id"`
+	Change    string                `json:"change"`
+	From      *models.QuotationItem `json:"from,omitempty"`
+	To        *models.QuotationItem `json:"to,omitempty"`
+	Fields    []FieldChange         `json:"fields,omitempty"`
+}
+
+// QuotationDiff is what changed from one revision of a quotation to another
+type QuotationDiff struct {
+	From    string                `json:"from"`
+	To      string                `json:"to"`
+	Changes []FieldChange         `json:"changes"`
+	Lines   []QuotationLineChange `json:"lines"`
+}
+
+// DiffQuotations compares two revisions of a quotation. Lines are matched by product, in
+// order when a product is on several lines.
+func DiffQuotations(from models.Quotation, fromItems []models.QuotationItem, to models.Quotation, toItems []models.QuotationItem) QuotationDiff {
+	diff := QuotationDiff{
+		From:    from.Reference(),
+		To:      to.Reference(),
+		Changes: []FieldChange{},
+		Lines:   []QuotationLineChange{},
+	}
+
+	diff.Changes = appendChange(diff.Changes, "validity_date", from.ValidityDate.Format("2006-01-02"), to.ValidityDate.Format("2006-01-02"))
+	diff.Changes = appendChange(diff.Changes, "status", from.Status, to.Status)
+	diff.Changes = appendChange(diff.Changes, "currency", from.Currency, to.Currency)
+	diff.Changes = appendChange(diff.Changes, "exchange_rate", from.ExchangeRate, to.ExchangeRate)
+	diff.Changes = appendChange(diff.Changes, "net_amount", from.NetAmount, to.NetAmount)
+	diff.Changes = appendChange(diff.Changes, "vat_amount", from.VATAmount, to.VATAmount)
+	diff.Changes = appendChange(diff.Changes, "total_amount", from.TotalAmount, to.TotalAmount)
+
+	// Pair the nth line of a product in one revision with its nth line in the other
+	unmatched := map[int][]int{}
+	for i, item := range fromItems {
+		unmatched[item.ProductID] = append(unmatched[item.ProductID], i)
+	}
+	for i := range toItems {
+		after := &toItems[i]
+		lines := unmatched[after.ProductID]
+		if len(lines) == 0 {
+			diff.Lines = append(diff.Lines, QuotationLineChange{ProductID: after.ProductID, Change: LineAdded, To: after})
+			continue
+		}
+		before := &fromItems[lines[0]]
+		unmatched[after.ProductID] = lines[1:]
+
+		var fields []FieldChange
+		fields = appendChange(fields, "quantity", before.Quantity, after.Quantity)
+		fields = appendChange(fields, "unit_price", before.UnitPrice, after.UnitPrice)
+		fields = appendChange(fields, "discount_type", before.DiscountType, after.DiscountType)
+		fields = appendChange(fields, "discount_value", before.DiscountValue, after.DiscountValue)
+		fields = appendChange(fields, "discount", before.Discount, after.Discount)
+		fields = appendChange(fields, "line_total", before.LineTotal, after.LineTotal)
+		fields = appendChange(fields, "promise_date", FormatPromise(*before), FormatPromise(*after))
+		if len(fields) > 0 {
+			diff.Lines = append(diff.Lines, QuotationLineChange{ProductID: after.ProductID, Change: LineChanged, From: before, To: after, Fields: fields})
+		}
+	}
+	for i := range fromItems {
+		before := &fromItems[i]
+		for _, line := range unmatched[before.ProductID] {
+			if line == i {
+				diff.Lines = append(diff.Lines, QuotationLineChange{ProductID: before.ProductID, Change: LineRemoved, From: before})
+			}
+		}
+	}
+
+	return diff
+}
+
+// appendChange adds a field to changes when its value differs between the revisions
+func appendChange(changes []FieldChange, field string, from, to interface{}) []FieldChange {
+	if from == to {
+		return changes
+	}
+	return append(changes, FieldChange{Field: field, From: from, To: to})
+}
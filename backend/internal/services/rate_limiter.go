@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit is a token bucket: a client may make Burst requests at once, and the bucket
+// refills at PerMinute requests a minute. A zero PerMinute disables the limit.
+type RateLimit struct {
+	PerMinute int
+	Burst     int
+}
+
+// Enabled reports whether the limit restricts anything
+func (l RateLimit) Enabled() bool {
+	return l.PerMinute > 0 && l.Burst > 0
+}
+
+// perMillisecond is the refill rate in tokens per millisecond
+func (l RateLimit) perMillisecond() float64 {
+	return float64(l.PerMinute) / float64(time.Minute/time.Millisecond)
+}
+
+// RateLimitResult is the state of a bucket after taking a request from it
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is how long until the next request is allowed, zero when one is
+	RetryAfter time.Duration
+	// ResetAfter is how long until the bucket is full again
+	ResetAfter time.Duration
+}
+
+// RateLimitStore keeps the token buckets. The memory store is per process; the Redis store is
+// shared by every instance of the server.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit RateLimit, now time.Time) (RateLimitResult, error)
+}
+
+// RateLimiter applies the login, API and public quote request limits
+type RateLimiter struct {
+	store        RateLimitStore
+	Login        RateLimit
+	API          RateLimit
+	QuoteRequest RateLimit
+}
+
+// NewRateLimiter creates a limiter with the given store and limits
+func NewRateLimiter(store RateLimitStore, login, api, quoteRequest RateLimit) *RateLimiter {
+	return &RateLimiter{
+		store:        store,
+		Login:        login,
+		API:          api,
+		QuoteRequest: quoteRequest,
+	}
+}
+
+// NewRateLimiterFromEnv configures the limiter from environment variables
+//
+//	RATE_LIMIT_REDIS_URL                 redis://[:password@]host:port/db; buckets are kept in memory when unset
+//	RATE_LIMIT_LOGIN_PER_MINUTE          login attempts per minute per IP (default 5, 0 disables)
+//	RATE_LIMIT_LOGIN_BURST               login attempts allowed at once (default 5)
+//	RATE_LIMIT_API_PER_MINUTE            API requests per minute per user, or per IP before login (default 300, 0 disables)
+//	RATE_LIMIT_API_BURST                 API requests allowed at once (default 100)
+//	RATE_LIMIT_QUOTE_REQUEST_PER_MINUTE  public quote requests per minute per IP (default 1, 0 disables)
+//	RATE_LIMIT_QUOTE_REQUEST_BURST       public quote requests allowed at once (default 3)
+func NewRateLimiterFromEnv() *RateLimiter {
+	var store RateLimitStore = NewMemoryRateLimitStore()
+	if redisURL := strings.TrimSpace(os.Getenv("RATE_LIMIT_REDIS_URL")); redisURL != "" {
+		redisStore, err := NewRedisRateLimitStore(redisURL)
+		if err != nil {
+			log.Printf("Warning: invalid RATE_LIMIT_REDIS_URL, keeping rate limits in memory: %v", err)
+		} else {
+			store = redisStore
+		}
+	}
+
+	return NewRateLimiter(store,
+		RateLimit{
+			PerMinute: envInt("RATE_LIMIT_LOGIN_PER_MINUTE", 5),
+			Burst:     envInt("RATE_LIMIT_LOGIN_BURST", 5),
+		},
+		RateLimit{
+			PerMinute: envInt("RATE_LIMIT_API_PER_MINUTE", 300),
+			Burst:     envInt("RATE_LIMIT_API_BURST", 100),
+		},
+		RateLimit{
+			PerMinute: envInt("RATE_LIMIT_QUOTE_REQUEST_PER_MINUTE", 1),
+			Burst:     envInt("RATE_LIMIT_QUOTE_REQUEST_BURST", 3),
+		},
+	)
+}
+
+// Take takes a request from the bucket of a key under a limit. Store failures let the
+// request through so an unreachable Redis does not take the API down.
+func (l *RateLimiter) Take(ctx context.Context, policy, key string, limit RateLimit) RateLimitResult {
+	if !limit.Enabled() {
+		return RateLimitResult{Allowed: true}
+	}
+
+	result, err := l.store.Take(ctx, "ratelimit:"+policy+":"+key, limit, time.Now())
+	if err != nil {
+		log.Printf("Rate limit store failed, allowing request: %v", err)
+		return RateLimitResult{Allowed: true}
+	}
+	return result
+}
+
+// bucketResult describes a bucket holding tokens after a request was or wasn't taken
+func bucketResult(limit RateLimit, tokens float64, allowed bool) RateLimitResult {
+	rate := limit.perMillisecond()
+	result := RateLimitResult{
+		Allowed:    allowed,
+		Limit:      limit.Burst,
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: time.Duration(math.Ceil((float64(limit.Burst)-tokens)/rate)) * time.Millisecond,
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration(math.Ceil((1-tokens)/rate)) * time.Millisecond
+	}
+	return result
+}
+
+// memoryBucket is a token bucket held in memory
+type memoryBucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time
+}
+
+// MemoryRateLimitStore keeps token buckets in process memory
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+// NewMemoryRateLimitStore creates an empty in-memory store
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets: map[string]*memoryBucket{},
+	}
+}
+
+// Take refills the key's bucket for the time elapsed and takes a token if one is left
+func (s *MemoryRateLimitStore) Take(ctx context.Context, key string, limit RateLimit, now time.Time) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Buckets that have refilled are the same as no bucket, so drop them now and then
+	if now.Sub(s.lastSweep) > time.Minute {
+		for k, bucket := range s.buckets {
+			if !now.Before(bucket.full) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	rate := limit.perMillisecond()
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = bucket
+	}
+
+	elapsed := float64(now.Sub(bucket.updated)) / float64(time.Millisecond)
+	if elapsed > 0 {
+		bucket.tokens = math.Min(float64(limit.Burst), bucket.tokens+elapsed*rate)
+		bucket.updated = now
+	}
+
+	allowed := bucket.tokens >= 1
+	if allowed {
+		bucket.tokens--
+	}
+
+	result := bucketResult(limit, bucket.tokens, allowed)
+	bucket.full = now.Add(result.ResetAfter)
+	return result, nil
+}
+
+// envInt reads an integer environment variable, falling back to def when unset or invalid
+func envInt(key string, def int) int {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		log.Printf("Warning: ignoring invalid %s=%q", key, value)
+		return def
+	}
+	return n
+}
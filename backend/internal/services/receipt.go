@@ -0,0 +1,206 @@
+package services
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Receipt paper widths in millimetres
+const (
+	ReceiptPaper58mm = 58
+	ReceiptPaper80mm = 80
+)
+
+// ReceiptColumns is the number of characters per line of the printer's standard font for
+// each supported paper width
+var ReceiptColumns = map[int]int{
+	ReceiptPaper58mm: 32,
+	ReceiptPaper80mm: 48,
+}
+
+// MimeTypeESCPOS identifies raw ESC/POS print data; printers receive it unconverted
+const MimeTypeESCPOS = "application/vnd.escpos"
+
+// ESC/POS control sequences used on receipts
+var (
+	escposInit        = []byte{0x1b, 0x40}             // ESC @: reset the printer
+	escposAlignLeft   = []byte{0x1b, 0x61, 0x00}       // ESC a 0
+	escposAlignCenter = []byte{0x1b, 0x61, 0x01}       // ESC a 1
+	escposBoldOn      = []byte{0x1b, 0x45, 0x01}       // ESC E 1
+	escposBoldOff     = []byte{0x1b, 0x45, 0x00}       // ESC E 0
+	escposFeedAndCut  = []byte{0x1d, 0x56, 0x42, 0x03} // GS V B 3: feed 3 lines, partial cut
+)
+
+// ReceiptLine is a product line on a sales receipt
+type ReceiptLine struct {
+	ProductName string
+	Quantity    int
+	UnitPrice   float64
+	Discount    float64
+	LineTotal   float64
+}
+
+// Receipt is a paid sale laid out for a receipt printer at the counter
+type Receipt struct {
+	Title         string
+	Subtitle      string
+	OrderID       int
+	Date          string
+	Customer      string
+	Lines         []ReceiptLine
+	Total         float64
+	PaymentMethod string
+	AmountPaid    float64
+	ChangeDue     float64
+	Footer        string
+}
+
+// Text lays the receipt out as plain text wrapped to the given number of columns
+func (r Receipt) Text(columns int) []byte {
+	var buf bytes.Buffer
+	r.write(&buf, columns, false)
+	return buf.Bytes()
+}
+
+// ESCPOS renders the receipt as ESC/POS commands for a thermal printer, ending with a
+// paper cut
+func (r Receipt) ESCPOS(columns int) []byte {
+	var buf bytes.Buffer
+	buf.Write(escposInit)
+	r.write(&buf, columns, true)
+	buf.Write(escposFeedAndCut)
+	return buf.Bytes()
+}
+
+// write lays out the receipt; with escpos set the header is centred and the total is bold
+// using printer commands rather than padding
+func (r Receipt) write(buf *bytes.Buffer, columns int, escpos bool) {
+	rule := strings.Repeat("-", columns) + "\n"
+
+	if escpos {
+		buf.Write(escposAlignCenter)
+		buf.Write(escposBoldOn)
+	}
+	for _, line := range wrapReceiptText(r.Title, columns) {
+		buf.WriteString(centerReceiptText(line, columns, escpos))
+	}
+	if escpos {
+		buf.Write(escposBoldOff)
+	}
+	if r.Subtitle != "" {
+		buf.WriteString(centerReceiptText(r.Subtitle, columns, escpos))
+	}
+	if escpos {
+		buf.Write(escposAlignLeft)
+	}
+	buf.WriteString(rule)
+
+	buf.WriteString(receiptRow("Order #", strconv.Itoa(r.OrderID), columns))
+	buf.WriteString(receiptRow("Date", r.Date, columns))
+	for i, line := range wrapReceiptText(r.Customer, columns-len("Customer ")) {
+		label := ""
+		if i == 0 {
+			label = "Customer"
+		}
+		buf.WriteString(receiptRow(label, line, columns))
+	}
+	buf.WriteString(rule)
+
+	for _, line := range r.Lines {
+		for _, name := range wrapReceiptText(line.ProductName, columns) {
+			buf.WriteString(name + "\n")
+		}
+		detail := "  " + strconv.Itoa(line.Quantity) + " x " + FormatMoney(line.UnitPrice)
+		if line.Discount != 0 {
+			detail += " less " + FormatMoney(line.Discount)
+		}
+		buf.WriteString(receiptRow(detail, FormatMoney(line.LineTotal), columns))
+	}
+	buf.WriteString(rule)
+
+	if escpos {
+		buf.Write(escposBoldOn)
+	}
+	buf.WriteString(receiptRow("TOTAL", FormatMoney(r.Total), columns))
+	if escpos {
+		buf.Write(escposBoldOff)
+	}
+	paid := "Paid"
+	if r.PaymentMethod != "" {
+		paid += " (" + r.PaymentMethod + ")"
+	}
+	buf.WriteString(receiptRow(paid, FormatMoney(r.AmountPaid), columns))
+	buf.WriteString(receiptRow("Change", FormatMoney(r.ChangeDue), columns))
+	buf.WriteString(rule)
+
+	if r.Footer != "" {
+		if escpos {
+			buf.Write(escposAlignCenter)
+		}
+		for _, line := range wrapReceiptText(r.Footer, columns) {
+			buf.WriteString(centerReceiptText(line, columns, escpos))
+		}
+		if escpos {
+			buf.Write(escposAlignLeft)
+		}
+	}
+}
+
+// receiptRow puts label on the left and value on the right of a line, dropping to a second
+// line when both do not fit
+func receiptRow(label, value string, columns int) string {
+	gap := columns - utf8.RuneCountInString(label) - utf8.RuneCountInString(value)
+	if gap < 1 {
+		return label + "\n" + strings.Repeat(" ", max(columns-utf8.RuneCountInString(value), 0)) + value + "\n"
+	}
+	return label + strings.Repeat(" ", gap) + value + "\n"
+}
+
+// centerReceiptText centres a line with spaces, or leaves it to the printer's alignment
+func centerReceiptText(text string, columns int, escpos bool) string {
+	if escpos {
+		return text + "\n"
+	}
+	pad := (columns - utf8.RuneCountInString(text)) / 2
+	if pad < 0 {
+		pad = 0
+	}
+	return strings.Repeat(" ", pad) + text + "\n"
+}
+
+// wrapReceiptText breaks text into lines of at most columns characters at word boundaries,
+// splitting words longer than a line
+func wrapReceiptText(text string, columns int) []string {
+	if columns < 1 {
+		columns = 1
+	}
+
+	var lines []string
+	current := ""
+	for _, word := range strings.Fields(text) {
+		for utf8.RuneCountInString(word) > columns {
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			runes := []rune(word)
+			lines = append(lines, string(runes[:columns]))
+			word = string(runes[columns:])
+		}
+		switch {
+		case current == "":
+			current = word
+		case utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) <= columns:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	if current != "" || len(lines) == 0 {
+		lines = append(lines, current)
+	}
+	return lines
+}
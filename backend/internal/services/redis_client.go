@@ -0,0 +1,198 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redisTimeout bounds each round trip to Redis
+const redisTimeout = 2 * time.Second
+
+// redisClient is a single connection to Redis, shared by the rate limit store and the cache.
+// It speaks just enough of the Redis protocol to send commands and read their replies.
+type redisClient struct {
+	addr     string
+	useTLS   bool
+	password string
+	username string
+	db       int
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// newRedisClient creates a client for a redis:// or rediss:// URL. The connection is opened
+// on first use.
+func newRedisClient(rawURL string) (*redisClient, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+
+	client := &redisClient{
+		addr:   u.Host,
+		useTLS: u.Scheme == "rediss",
+	}
+	if u.Port() == "" {
+		client.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		client.username = u.User.Username()
+		client.password, _ = u.User.Password()
+		// redis://:password@host has no username
+		if _, ok := u.User.Password(); !ok {
+			client.password, client.username = client.username, ""
+		}
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		client.db, err = strconv.Atoi(db)
+		if err != nil {
+			return nil, fmt.Errorf("invalid database %q", db)
+		}
+	}
+
+	return client, nil
+}
+
+// do sends a command and reads its reply, reconnecting when the connection has failed
+func (s *redisClient) do(ctx context.Context, args ...string) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		if err := s.connect(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	reply, err := s.roundTrip(ctx, args...)
+	var redisErr redisError
+	if err != nil && !errors.As(err, &redisErr) {
+		s.conn.Close()
+		s.conn = nil
+	}
+	return reply, err
+}
+
+// connect dials Redis and authenticates and selects the database
+func (s *redisClient) connect(ctx context.Context) error {
+	dialer := &net.Dialer{Timeout: redisTimeout}
+	var conn net.Conn
+	var err error
+	if s.useTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer}).DialContext(ctx, "tcp", s.addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", s.addr)
+	}
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+	s.reader = bufio.NewReader(conn)
+
+	var setup [][]string
+	if s.password != "" {
+		if s.username != "" {
+			setup = append(setup, []string{"AUTH", s.username, s.password})
+		} else {
+			setup = append(setup, []string{"AUTH", s.password})
+		}
+	}
+	if s.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(s.db)})
+	}
+	for _, command := range setup {
+		if _, err := s.roundTrip(ctx, command...); err != nil {
+			conn.Close()
+			s.conn = nil
+			return fmt.Errorf("redis %s failed: %w", command[0], err)
+		}
+	}
+	return nil
+}
+
+// roundTrip writes a command as an array of bulk strings and reads one reply
+func (s *redisClient) roundTrip(ctx context.Context, args ...string) (interface{}, error) {
+	deadline := time.Now().Add(redisTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	s.conn.SetDeadline(deadline)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(s.conn, b.String()); err != nil {
+		return nil, err
+	}
+
+	return readRedisReply(s.reader)
+}
+
+// redisError is an error reply from Redis; the connection is still usable after one
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// readRedisReply reads a reply: a status or bulk string as string, an integer as int64,
+// an array as []interface{} and a nil bulk string or array as nil
+func readRedisReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		values := make([]interface{}, n)
+		for i := range values {
+			if values[i], err = readRedisReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("redis: unexpected reply %q", line)
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// redisTokenBucket refills and takes from a bucket atomically. The bucket is a hash of the
+// token count and the time it was last updated, and expires once it would be full again.
+//
+//	KEYS[1] bucket key, ARGV[1] tokens per millisecond, ARGV[2] burst, ARGV[3] now in ms
+const redisTokenBucket = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(bucket[1]) or burst
+local updated = tonumber(bucket[2]) or now
+if now > updated then
+	tokens = math.min(burst, tokens + (now - updated) * rate)
+	updated = now
+end
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', tostring(updated))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate) + 1000)
+return {allowed, tostring(tokens)}
+`
+
+// RedisRateLimitStore keeps token buckets in Redis so every server instance shares them,
+// running the bucket script over one connection
+type RedisRateLimitStore struct {
+	client *redisClient
+}
+
+// NewRedisRateLimitStore creates a store for a redis:// or rediss:// URL. The connection is
+// opened on first use.
+func NewRedisRateLimitStore(rawURL string) (*RedisRateLimitStore, error) {
+	client, err := newRedisClient(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisRateLimitStore{client: client}, nil
+}
+
+// Take runs the bucket script for the key
+func (s *RedisRateLimitStore) Take(ctx context.Context, key string, limit RateLimit, now time.Time) (RateLimitResult, error) {
+	reply, err := s.client.do(ctx, "EVAL", redisTokenBucket, "1", key,
+		strconv.FormatFloat(limit.perMillisecond(), 'g', -1, 64),
+		strconv.Itoa(limit.Burst),
+		strconv.FormatInt(now.UnixMilli(), 10),
+	)
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 2 {
+		return RateLimitResult{}, fmt.Errorf("unexpected reply %v", reply)
+	}
+	allowed, _ := values[0].(int64)
+	text, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return RateLimitResult{}, fmt.Errorf("unexpected token count %q", text)
+	}
+
+	return bucketResult(limit, tokens, allowed == 1), nil
+}
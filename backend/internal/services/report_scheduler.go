@@ -0,0 +1,280 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// scheduledTopCustomers is how many customers the scheduled top customers report lists
+const scheduledTopCustomers = 20
+
+// ReportScheduler emails reports on the schedules admins define: every minute it sends the
+// schedules that are due, each with the CSV exports of its reports attached
+type ReportScheduler struct {
+	scheduleRepo *repository.ReportScheduleRepository
+	reportRepo   *repository.ReportRepository
+	emailService *EmailService
+	interval     time.Duration
+	stop         chan struct{}
+}
+
+// NewReportScheduler creates a new report scheduler
+func NewReportScheduler(scheduleRepo *repository.ReportScheduleRepository, reportRepo *repository.ReportRepository, emailService *EmailService) *ReportScheduler {
+	return &ReportScheduler{
+		scheduleRepo: scheduleRepo,
+		reportRepo:   reportRepo,
+		emailService: emailService,
+		interval:     time.Minute,
+		stop:         make(chan struct{}),
+	}
+}
+
+// Start sends the due schedules once at startup and then on every interval
+func (s *ReportScheduler) Start() {
+	goBackground(func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.run()
+			select {
+			case <-ticker.C:
+			case <-s.stop:
+				return
+			}
+		}
+	})
+}
+
+// Stop ends the schedule after any run in progress has finished
+func (s *ReportScheduler) Stop() {
+	close(s.stop)
+}
+
+// run sends each due schedule and moves it to its next run. Schedules missed while the
+// server was down are sent once, not once per missed run. Nothing is sent while email is
+// off, leaving the schedules due until it is configured.
+func (s *ReportScheduler) run() {
+	if !s.emailService.Enabled() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	now := time.Now()
+	schedules, err := s.scheduleRepo.GetDue(ctx, now)
+	if err != nil {
+		log.Printf("Failed to load due report schedules: %v", err)
+		return
+	}
+
+	for _, schedule := range schedules {
+		next, err := NextReportRun(schedule, now)
+		if err != nil {
+			log.Printf("Failed to schedule report %d: %v", schedule.ReportScheduleID, err)
+			continue
+		}
+		claimed, err := s.scheduleRepo.Claim(ctx, schedule.ReportScheduleID, schedule.NextRunAt, next)
+		if err != nil {
+			log.Printf("Failed to claim report schedule %d: %v", schedule.ReportScheduleID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		sendErr := s.Send(ctx, schedule)
+		if sendErr != nil {
+			log.Printf("Failed to send scheduled report %d (%s): %v", schedule.ReportScheduleID, schedule.Name, sendErr)
+		}
+		if err := s.scheduleRepo.RecordRun(ctx, schedule.ReportScheduleID, now, sendErr); err != nil {
+			log.Printf("Failed to record run of report schedule %d: %v", schedule.ReportScheduleID, err)
+		}
+	}
+}
+
+// Send generates a schedule's reports and emails them to its recipients now, leaving its
+// next run as it is
+func (s *ReportScheduler) Send(ctx context.Context, schedule models.ReportSchedule) error {
+	recipients := make([]mail.Address, 0, len(schedule.Recipients))
+	for _, recipient := range schedule.Recipients {
+		address, err := mail.ParseAddress(recipient)
+		if err != nil {
+			return fmt.Errorf("invalid recipient %q: %w", recipient, err)
+		}
+		recipients = append(recipients, *address)
+	}
+
+	attachments := make([]EmailAttachment, 0, len(schedule.Reports))
+	titles := make([]string, 0, len(schedule.Reports))
+	for _, report := range schedule.Reports {
+		title, attachment, err := s.export(ctx, report, schedule.Days)
+		if err != nil {
+			return fmt.Errorf("failed to generate %s report: %w", report, err)
+		}
+		attachments = append(attachments, attachment)
+		titles = append(titles, "- "+title)
+	}
+
+	location, _ := scheduleLocation(schedule.Timezone)
+	return s.emailService.Send(ctx, EmailMessage{
+		To:          recipients,
+		Subject:     fmt.Sprintf("%s - %s", schedule.Name, time.Now().In(location).Format("Jan 2, 2006")),
+		Text:        "Attached are the reports scheduled as \"" + schedule.Name + "\":\n\n" + strings.Join(titles, "\n") + "\n",
+		Attachments: attachments,
+	})
+}
+
+// export generates a report as a CSV attachment laid out like its export route, returning
+// its title for the email
+func (s *ReportScheduler) export(ctx context.Context, report string, days int) (string, EmailAttachment, error) {
+	var title, fileName string
+	var rows [][]string
+
+	switch report {
+	case models.ScheduledSalesTrends:
+		trends, err := s.reportRepo.GetSalesTrends(ctx, days)
+		if err != nil {
+			return "", EmailAttachment{}, err
+		}
+		title, fileName = "Sales Trends", fmt.Sprintf("sales_trends_%d_days", days)
+		rows = append(rows, []string{"Date", "Total Sales"})
+		for _, trend := range trends {
+			rows = append(rows, []string{trend.Day, csvAmount(trend.TotalAmount)})
+		}
+	case models.ScheduledLowStock:
+		items, err := s.reportRepo.GetLowStockItems(ctx)
+		if err != nil {
+			return "", EmailAttachment{}, err
+		}
+		title, fileName = "Low Stock Items", "low_stock_items"
+		rows = append(rows, []string{"ID", "Product ID", "Product Name", "Current Stock", "Reorder Level", "Unit Price"})
+		for _, item := range items {
+			rows = append(rows, []string{
+				strconv.Itoa(item.ID), strconv.Itoa(item.ProductID), item.ProductName,
+				strconv.Itoa(item.CurrentStock), strconv.Itoa(item.ReorderLevel), csvAmount(item.UnitPrice),
+			})
+		}
+	case models.ScheduledTopCustomers:
+		customers, err := s.reportRepo.GetTopCustomers(ctx, scheduledTopCustomers, days)
+		if err != nil {
+			return "", EmailAttachment{}, err
+		}
+		title, fileName = "Top Customers", fmt.Sprintf("top_customers_%d_days", days)
+		rows = append(rows, []string{"Customer ID", "Company Name", "Contact Name", "Total Spent", "Order Count"})
+		for _, customer := range customers {
+			rows = append(rows, []string{
+				strconv.Itoa(customer.ID), customer.Name, customer.ContactName,
+				csvAmount(customer.TotalSpent), strconv.Itoa(customer.OrderCount),
+			})
+		}
+	case models.ScheduledSalesByChannel:
+		channels, err := s.reportRepo.GetSalesByChannel(ctx, days)
+		if err != nil {
+			return "", EmailAttachment{}, err
+		}
+		title, fileName = "Sales by Channel", fmt.Sprintf("sales_by_channel_%d_days", days)
+		rows = append(rows, []string{"Channel", "Order Count", "Total Sales", "Share (%)"})
+		for _, channel := range channels {
+			rows = append(rows, []string{
+				channel.Source, strconv.Itoa(channel.OrderCount), csvAmount(channel.TotalAmount), csvAmount(channel.Share),
+			})
+		}
+	case models.ScheduledRevenueByIndustry:
+		industries, err := s.reportRepo.GetRevenueByIndustry(ctx, days)
+		if err != nil {
+			return "", EmailAttachment{}, err
+		}
+		title, fileName = "Revenue by Industry", fmt.Sprintf("revenue_by_industry_%d_days", days)
+		rows = append(rows, []string{"Industry", "Customer Count", "Order Count", "Total Sales", "Share (%)"})
+		for _, industry := range industries {
+			rows = append(rows, []string{
+				industry.Industry, strconv.Itoa(industry.CustomerCount), strconv.Itoa(industry.OrderCount),
+				csvAmount(industry.TotalAmount), csvAmount(industry.Share),
+			})
+		}
+	default:
+		return "", EmailAttachment{}, fmt.Errorf("unknown report %q", report)
+	}
+
+	if report != models.ScheduledLowStock {
+		title += fmt.Sprintf(" (last %d days)", days)
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.WriteAll(rows); err != nil {
+		return "", EmailAttachment{}, err
+	}
+	return title, EmailAttachment{
+		FileName:    fileName + ".csv",
+		ContentType: "text/csv",
+		Content:     buf.Bytes(),
+	}, nil
+}
+
+// csvAmount writes an amount with two decimals, as the CSV exports do
+func csvAmount(amount float64) string {
+	return fmt.Sprintf("%.2f", amount)
+}
+
+// scheduleLocation loads a schedule's time zone, the server's when it is empty
+func scheduleLocation(timezone string) (*time.Location, error) {
+	if timezone == "" {
+		return time.Local, nil
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Local, fmt.Errorf("unknown time zone %q", timezone)
+	}
+	return location, nil
+}
+
+// NextReportRun returns the first time after after that a schedule runs
+func NextReportRun(schedule models.ReportSchedule, after time.Time) (time.Time, error) {
+	location, err := scheduleLocation(schedule.Timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	local := after.In(location)
+	switch schedule.Frequency {
+	case models.ScheduleDaily:
+		next := time.Date(local.Year(), local.Month(), local.Day(), schedule.Hour, schedule.Minute, 0, 0, location)
+		if !next.After(after) {
+			next = next.AddDate(0, 0, 1)
+		}
+		return next, nil
+	case models.ScheduleWeekly:
+		if schedule.Weekday == nil {
+			return time.Time{}, fmt.Errorf("weekly schedules need a weekday")
+		}
+		next := time.Date(local.Year(), local.Month(), local.Day(), schedule.Hour, schedule.Minute, 0, 0, location)
+		next = next.AddDate(0, 0, (*schedule.Weekday-int(local.Weekday())+7)%7)
+		if !next.After(after) {
+			next = next.AddDate(0, 0, 7)
+		}
+		return next, nil
+	case models.ScheduleMonthly:
+		if schedule.DayOfMonth == nil {
+			return time.Time{}, fmt.Errorf("monthly schedules need a day_of_month")
+		}
+		next := time.Date(local.Year(), local.Month(), *schedule.DayOfMonth, schedule.Hour, schedule.Minute, 0, 0, location)
+		if !next.After(after) {
+			next = next.AddDate(0, 1, 0)
+		}
+		return next, nil
+	}
+	return time.Time{}, fmt.Errorf("unknown frequency %q", schedule.Frequency)
+}
@@ -0,0 +1,324 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// ErrUnknownRule is returned for a business rule key that is not defined
+var ErrUnknownRule = errors.New("unknown business rule")
+
+// RuleValueError is returned when a value is not valid for a business rule
+type RuleValueError struct {
+	Key     string
+	Message string
+}
+
+// Error implements error
+func (e *RuleValueError) Error() string {
+	return fmt.Sprintf("%s %s", e.Key, e.Message)
+}
+
+// ruleDefinition describes a business rule: its type, default and bounds
+type ruleDefinition struct {
+	key         string
+	kind        string
+	description string
+	def         float64
+	min         float64
+	max         *float64
+}
+
+// RulesService serves the business rules the other services enforce: typed thresholds with
+// defaults in code, overridden by admins in the settings table. Overrides are cached for
+// RULES_CACHE_SECONDS seconds (default 60), so a change made on another instance applies
+// within that time; changes made here apply at once.
+type RulesService struct {
+	settingRepo *repository.SettingRepository
+	definitions []ruleDefinition
+	ttl         time.Duration
+
+	mu        sync.RWMutex // guards overrides and loadedAt
+	overrides map[string]models.Setting
+	loadedAt  time.Time
+}
+
+// NewRulesService creates a new rules service. Defaults are taken from the environment
+// variables that configured each threshold before it became a rule, where one exists.
+func NewRulesService(settingRepo *repository.SettingRepository) *RulesService {
+	percent := 100.0
+	return &RulesService{
+		settingRepo: settingRepo,
+		ttl:         time.Duration(envInt("RULES_CACHE_SECONDS", 60)) * time.Second,
+		definitions: []ruleDefinition{
+			{
+				key:         models.RuleDeliveryFee,
+				kind:        models.RuleTypeNumber,
+				description: "Delivery fee charged when no freight rate covers the destination",
+				def:         envFloat("DELIVERY_FEE", 500),
+			},
+			{
+				key:         models.RuleVATRatePct,
+				kind:        models.RuleTypeNumber,
+				description: "VAT rate included in the prices charged to vatable customers, in percent",
+				def:         envFloat("VAT_RATE_PCT", 12),
+				max:         &percent,
+			},
+			{
+				key:         models.RuleMaxDiscountPercent,
+				kind:        models.RuleTypeNumber,
+				description: "Largest discount allowed on a quotation or order line, in percent of the line",
+				def:         100,
+				max:         &percent,
+			},
+			{
+				key:         models.RuleQuotationValidityDays,
+				kind:        models.RuleTypeInt,
+				description: "Days a quotation is valid when no validity date is given",
+				def:         30,
+			},
+			{
+				key:         models.RuleLargeOrderAlert,
+				kind:        models.RuleTypeNumber,
+				description: "Order total from which the chat channel is told of a new order",
+				def:         envFloat("CHAT_LARGE_ORDER_THRESHOLD", 100000),
+			},
+			{
+				key:         models.RuleQuotationApprovedAlert,
+				kind:        models.RuleTypeNumber,
+				description: "Quotation total from which the chat channel is told of an approval",
+				def:         envFloat("CHAT_QUOTATION_APPROVED_THRESHOLD", 100000),
+			},
+			{
+				key:         models.RuleCheckReminderDays,
+				kind:        models.RuleTypeInt,
+				description: "Days ahead of maturity post-dated checks are reminded",
+				def:         float64(envInt("PDC_REMINDER_DAYS", 3)),
+			},
+			{
+				key:         models.RuleInvoicePriceTolerancePct,
+				kind:        models.RuleTypeNumber,
+				description: "How far a supplier invoice price may differ from the purchase order, in percent",
+				def:         envFloat("INVOICE_PRICE_TOLERANCE_PCT", 1),
+				max:         &percent,
+			},
+			{
+				key:         models.RuleInvoiceQuantityTolerance,
+				kind:        models.RuleTypeInt,
+				description: "How many units supplier invoice quantities may differ from those received",
+				def:         float64(envInt("INVOICE_QUANTITY_TOLERANCE", 0)),
+			},
+			{
+				key:         models.RuleBankMatchWindowDays,
+				kind:        models.RuleTypeInt,
+				description: "Days either side of a deposit that payments of the same amount are suggested",
+				def:         float64(envInt("BANK_MATCH_DATE_WINDOW_DAYS", 3)),
+			},
+			{
+				key:         models.RuleQuotationResponseHours,
+				kind:        models.RuleTypeNumber,
+				description: "Hours within which a quotation should be sent once its request is received",
+				def:         envFloat("QUOTATION_RESPONSE_HOURS", 24),
+				min:         1,
+			},
+			{
+				key:         models.RuleQuotationSLAWarningHours,
+				kind:        models.RuleTypeNumber,
+				description: "Hours before its response is due that an unsent quotation is flagged as at risk",
+				def:         envFloat("QUOTATION_SLA_WARNING_HOURS", 4),
+			},
+			{
+				key:         models.RuleAnomalySalesSigma,
+				kind:        models.RuleTypeNumber,
+				description: "Standard deviations below its trend a day's sales must fall to be flagged; 0 turns the check off",
+				def:         envFloat("ANOMALY_SALES_SIGMA", 3),
+			},
+			{
+				key:         models.RuleAnomalySalesHistoryDays,
+				kind:        models.RuleTypeInt,
+				description: "Days of sales the trend a day's sales are compared with is fitted to",
+				def:         float64(envInt("ANOMALY_SALES_HISTORY_DAYS", 28)),
+				min:         7,
+			},
+			{
+				key:         models.RuleAnomalyStockDropPct,
+				kind:        models.RuleTypeNumber,
+				description: "Share of a product's stock that must disappear without orders taking it to be flagged, in percent; 0 turns the check off",
+				def:         envFloat("ANOMALY_STOCK_DROP_PCT", 20),
+				max:         &percent,
+			},
+			{
+				key:         models.RulePromiseHandlingDays,
+				kind:        models.RuleTypeInt,
+				description: "Days quotation lines are promised after their stock is in hand, to pick, pack and deliver them",
+				def:         float64(envInt("PROMISE_HANDLING_DAYS", 1)),
+			},
+		},
+	}
+}
+
+// definition looks up a rule's definition by key
+func (s *RulesService) definition(key string) (ruleDefinition, bool) {
+	for _, def := range s.definitions {
+		if def.key == key {
+			return def, true
+		}
+	}
+	return ruleDefinition{}, false
+}
+
+// load returns the admins' overrides, reading them again once the cache has expired
+func (s *RulesService) load(ctx context.Context) (map[string]models.Setting, error) {
+	s.mu.RLock()
+	overrides, loadedAt := s.overrides, s.loadedAt
+	s.mu.RUnlock()
+	if overrides != nil && time.Since(loadedAt) < s.ttl {
+		return overrides, nil
+	}
+
+	keys := make([]string, len(s.definitions))
+	for i, def := range s.definitions {
+		keys[i] = def.key
+	}
+	settings, err := s.settingRepo.GetByKeys(ctx, keys)
+	if err != nil {
+		return overrides, err
+	}
+
+	overrides = make(map[string]models.Setting, len(settings))
+	for _, setting := range settings {
+		overrides[setting.Key] = setting
+	}
+	s.mu.Lock()
+	s.overrides, s.loadedAt = overrides, time.Now()
+	s.mu.Unlock()
+	return overrides, nil
+}
+
+// invalidate drops the cached overrides so the next read loads them again
+func (s *RulesService) invalidate() {
+	s.mu.Lock()
+	s.overrides = nil
+	s.mu.Unlock()
+}
+
+// rule describes a rule with its current value. An override that no longer parses, such as
+// one edited by hand, is ignored.
+func (s *RulesService) rule(def ruleDefinition, overrides map[string]models.Setting) models.BusinessRule {
+	rule := models.BusinessRule{
+		Key:         def.key,
+		Type:        def.kind,
+		Description: def.description,
+		Value:       def.def,
+		Default:     def.def,
+		Min:         def.min,
+		Max:         def.max,
+	}
+	setting, ok := overrides[def.key]
+	if !ok {
+		return rule
+	}
+	value, err := strconv.ParseFloat(setting.Value, 64)
+	if err != nil {
+		log.Printf("Warning: ignoring invalid value %q of business rule %s", setting.Value, def.key)
+		return rule
+	}
+	updatedAt := setting.UpdatedAt
+	rule.Value = value
+	rule.Overridden = true
+	rule.UpdatedBy = setting.UpdatedBy
+	rule.UpdatedAt = &updatedAt
+	return rule
+}
+
+// Rules returns every business rule with its current value
+func (s *RulesService) Rules(ctx context.Context) ([]models.BusinessRule, error) {
+	overrides, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]models.BusinessRule, len(s.definitions))
+	for i, def := range s.definitions {
+		rules[i] = s.rule(def, overrides)
+	}
+	return rules, nil
+}
+
+// Rule returns a business rule with its current value
+func (s *RulesService) Rule(ctx context.Context, key string) (models.BusinessRule, error) {
+	def, ok := s.definition(key)
+	if !ok {
+		return models.BusinessRule{}, ErrUnknownRule
+	}
+	overrides, err := s.load(ctx)
+	if err != nil {
+		return models.BusinessRule{}, err
+	}
+	return s.rule(def, overrides), nil
+}
+
+// Set overrides a business rule's value. The value must be of the rule's type and within
+// its bounds, or a *RuleValueError is returned.
+func (s *RulesService) Set(ctx context.Context, key string, value float64, updatedBy *int) (models.BusinessRule, error) {
+	def, ok := s.definition(key)
+	if !ok {
+		return models.BusinessRule{}, ErrUnknownRule
+	}
+	switch {
+	case def.kind == models.RuleTypeInt && value != math.Trunc(value):
+		return models.BusinessRule{}, &RuleValueError{Key: key, Message: "must be a whole number"}
+	case value < def.min:
+		return models.BusinessRule{}, &RuleValueError{Key: key, Message: fmt.Sprintf("must be %g or more", def.min)}
+	case def.max != nil && value > *def.max:
+		return models.BusinessRule{}, &RuleValueError{Key: key, Message: fmt.Sprintf("must be %g or less", *def.max)}
+	}
+
+	setting := &models.Setting{Key: key, Value: strconv.FormatFloat(value, 'f', -1, 64), UpdatedBy: updatedBy}
+	if err := s.settingRepo.Set(ctx, setting); err != nil {
+		return models.BusinessRule{}, err
+	}
+	s.invalidate()
+	return s.rule(def, map[string]models.Setting{key: *setting}), nil
+}
+
+// Reset removes the override of a business rule so its default applies again
+func (s *RulesService) Reset(ctx context.Context, key string) (models.BusinessRule, error) {
+	def, ok := s.definition(key)
+	if !ok {
+		return models.BusinessRule{}, ErrUnknownRule
+	}
+	if err := s.settingRepo.Delete(ctx, key); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return models.BusinessRule{}, err
+	}
+	s.invalidate()
+	return s.rule(def, nil), nil
+}
+
+// Float returns the current value of a business rule. Pricing and matching carry on with the
+// last known value, or the default, when the settings cannot be read.
+func (s *RulesService) Float(ctx context.Context, key string) float64 {
+	def, ok := s.definition(key)
+	if !ok {
+		log.Printf("Warning: unknown business rule %s", key)
+		return 0
+	}
+	overrides, err := s.load(ctx)
+	if err != nil {
+		log.Printf("Failed to load business rules: %v", err)
+	}
+	return s.rule(def, overrides).Value
+}
+
+// Int returns the current value of a whole-number business rule
+func (s *RulesService) Int(ctx context.Context, key string) int {
+	return int(s.Float(ctx, key))
+}
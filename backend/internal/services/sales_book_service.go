@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// SalesBookService prepares the monthly sales book the accountant files with the BIR
+type SalesBookService struct {
+	reportRepo *repository.ReportRepository
+	rules      *RulesService
+}
+
+// NewSalesBookService creates a new sales book service
+func NewSalesBookService(reportRepo *repository.ReportRepository, rules *RulesService) *SalesBookService {
+	return &SalesBookService{
+		reportRepo: reportRepo,
+		rules:      rules,
+	}
+}
+
+// Build prepares the sales book for the month starting at month, breaking each sale down by
+// the VAT classification it was priced under
+func (s *SalesBookService) Build(ctx context.Context, month time.Time) (models.SalesBook, error) {
+	book := models.SalesBook{Month: month.Format("2006-01"), VATRate: s.rules.Float(ctx, models.RuleVATRatePct)}
+
+	entries, err := s.reportRepo.GetSalesBookEntries(ctx, month, month.AddDate(0, 1, 0))
+	if err != nil {
+		return book, err
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+		switch entry.VATClassification {
+		case models.VATExempt:
+			entry.ExemptSales = entry.Gross
+			entry.VatableSales, entry.OutputVAT = 0, 0
+		case models.VATZeroRated:
+			entry.ZeroRatedSales = entry.Gross
+			entry.VatableSales, entry.OutputVAT = 0, 0
+		}
+
+		book.Gross += entry.Gross
+		book.VatableSales += entry.VatableSales
+		book.OutputVAT += entry.OutputVAT
+		book.ExemptSales += entry.ExemptSales
+		book.ZeroRatedSales += entry.ZeroRatedSales
+	}
+
+	book.Entries = entries
+	book.Gross = roundMoney(book.Gross)
+	book.VatableSales = roundMoney(book.VatableSales)
+	book.OutputVAT = roundMoney(book.OutputVAT)
+	book.ExemptSales = roundMoney(book.ExemptSales)
+	book.ZeroRatedSales = roundMoney(book.ZeroRatedSales)
+	return book, nil
+}
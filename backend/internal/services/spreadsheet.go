@@ -0,0 +1,233 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// maxSpreadsheetRows is the highest row number read from a worksheet
+const maxSpreadsheetRows = 100000
+
+// SpreadsheetError reports an uploaded spreadsheet that could not be read, naming the row
+// when the problem is in one
+type SpreadsheetError struct {
+	Row     int
+	Message string
+}
+
+// Error describes the problem
+func (e *SpreadsheetError) Error() string {
+	if e.Row > 0 {
+		return fmt.Sprintf("row %d: %s", e.Row, e.Message)
+	}
+	return e.Message
+}
+
+// ReadSpreadsheet reads the rows of a CSV file or of the first worksheet of an Excel (.xlsx)
+// workbook, chosen by the file name's extension. Cells are returned as text; Excel numbers
+// are returned as stored, without their display format. A *SpreadsheetError is returned when
+// the file cannot be read.
+func ReadSpreadsheet(fileName string, data []byte) ([][]string, error) {
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".csv":
+		return readCSV(data)
+	case ".xlsx":
+		return readXLSX(data)
+	}
+	return nil, &SpreadsheetError{Message: "unsupported file type, expected .csv or .xlsx"}
+}
+
+// readCSV reads the records of a CSV file, dropping a leading byte order mark
+func readCSV(data []byte) ([][]string, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows := [][]string{}
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, &SpreadsheetError{Row: row, Message: err.Error()}
+		}
+		rows = append(rows, record)
+	}
+}
+
+// xlsxWorkbook lists a workbook's sheets in tab order
+type xlsxWorkbook struct {
+	Sheets []struct {
+		RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+// xlsxRelationships maps relationship IDs to the parts they point to
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxText is a string item, either plain or made up of formatted runs
+type xlsxText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+// String joins the item's text
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.Text)
+	}
+	return b.String()
+}
+
+// xlsxSharedStrings is the workbook's table of strings referenced by cells
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+// xlsxSheet is the cell data of a worksheet
+type xlsxSheet struct {
+	Rows []struct {
+		Number int `xml:"r,attr"`
+		Cells  []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readXLSX reads the cells of the first worksheet of an .xlsx workbook, leaving empty
+// strings for skipped rows and cells
+func readXLSX(data []byte) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, &SpreadsheetError{Message: "the file is not a valid .xlsx workbook"}
+	}
+	parts := map[string]*zip.File{}
+	for _, file := range archive.File {
+		parts[file.Name] = file
+	}
+	decode := func(name string, v interface{}) error {
+		file, ok := parts[name]
+		if !ok {
+			return &SpreadsheetError{Message: "the workbook has no " + name}
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return &SpreadsheetError{Message: "the workbook is damaged"}
+		}
+		defer rc.Close()
+		if err := xml.NewDecoder(rc).Decode(v); err != nil {
+			return &SpreadsheetError{Message: "the workbook is damaged"}
+		}
+		return nil
+	}
+
+	var workbook xlsxWorkbook
+	if err := decode("xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	var rels xlsxRelationships
+	if err := decode("xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	if len(workbook.Sheets) == 0 {
+		return nil, &SpreadsheetError{Message: "the workbook has no worksheets"}
+	}
+	sheetPath := ""
+	for _, rel := range rels.Relationships {
+		if rel.ID == workbook.Sheets[0].RelID {
+			if strings.HasPrefix(rel.Target, "/") {
+				sheetPath = strings.TrimPrefix(rel.Target, "/")
+			} else {
+				sheetPath = path.Join("xl", rel.Target)
+			}
+		}
+	}
+
+	var shared xlsxSharedStrings
+	if _, ok := parts["xl/sharedStrings.xml"]; ok {
+		if err := decode("xl/sharedStrings.xml", &shared); err != nil {
+			return nil, err
+		}
+	}
+	var sheet xlsxSheet
+	if err := decode(sheetPath, &sheet); err != nil {
+		return nil, err
+	}
+
+	rows := [][]string{}
+	for _, row := range sheet.Rows {
+		number := row.Number
+		if number == 0 {
+			number = len(rows) + 1
+		}
+		if number > maxSpreadsheetRows {
+			return nil, &SpreadsheetError{Row: number, Message: fmt.Sprintf("the worksheet has more than %d rows", maxSpreadsheetRows)}
+		}
+		for len(rows) < number {
+			rows = append(rows, []string{})
+		}
+
+		record := []string{}
+		for _, cell := range row.Cells {
+			column := xlsxColumn(cell.Ref)
+			if column < 0 {
+				column = len(record)
+			}
+			for len(record) <= column {
+				record = append(record, "")
+			}
+
+			value := cell.Value
+			switch cell.Type {
+			case "s":
+				index, err := strconv.Atoi(cell.Value)
+				if err != nil || index < 0 || index >= len(shared.Items) {
+					return nil, &SpreadsheetError{Row: number, Message: "invalid shared string in cell " + cell.Ref}
+				}
+				value = shared.Items[index].String()
+			case "inlineStr":
+				value = cell.Inline.String()
+			case "b":
+				value = strconv.FormatBool(cell.Value == "1")
+			}
+			record[column] = value
+		}
+		rows[number-1] = record
+	}
+	return rows, nil
+}
+
+// xlsxColumn returns the zero-based column of a cell reference such as "C7", or -1 when
+// there is none
+func xlsxColumn(ref string) int {
+	column := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		column = column*26 + int(r-'A'+1)
+	}
+	return column - 1
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// TierService periodically reassigns customer loyalty tiers from their trailing revenue
+type TierService struct {
+	tierRepo *repository.LoyaltyTierRepository
+	interval time.Duration
+	stop     chan struct{}
+}
+
+// NewTierService creates a new tier service that recalculates every
+// TIER_RECALC_INTERVAL_HOURS hours (default 24)
+func NewTierService(tierRepo *repository.LoyaltyTierRepository) *TierService {
+	interval := time.Duration(envFloat("TIER_RECALC_INTERVAL_HOURS", 24) * float64(time.Hour))
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+
+	return &TierService{
+		tierRepo: tierRepo,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Recalculate reassigns every customer's tier now
+func (s *TierService) Recalculate(ctx context.Context) (int64, error) {
+	return s.tierRepo.Recalculate(ctx)
+}
+
+// Start recalculates tiers once at startup and then on every interval
+func (s *TierService) Start() {
+	goBackground(func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.run()
+			select {
+			case <-ticker.C:
+			case <-s.stop:
+				return
+			}
+		}
+	})
+}
+
+// Stop ends the schedule after any recalculation in progress has finished
+func (s *TierService) Stop() {
+	close(s.stop)
+}
+
+// run performs one scheduled recalculation and logs the outcome
+func (s *TierService) run() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	changed, err := s.Recalculate(ctx)
+	if err != nil {
+		log.Printf("Failed to recalculate customer tiers: %v", err)
+		return
+	}
+	if changed > 0 {
+		log.Printf("Recalculated loyalty tiers for %d customers", changed)
+	}
+}
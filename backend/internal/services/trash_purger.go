@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// TrashPurger periodically removes deleted customers, contacts and products for good once
+// they have been in the trash for the retention period. Records that cannot be purged, such
+// as customers with orders, stay in the trash and can still be restored.
+type TrashPurger struct {
+	trashRepo    *repository.TrashRepository
+	customerRepo *repository.CustomerRepository
+	contactRepo  *repository.ContactRepository
+	productRepo  *repository.ProductRepository
+	retention    time.Duration
+	interval     time.Duration
+	stop         chan struct{}
+}
+
+// NewTrashPurger creates a new purger. Records are kept TRASH_RETENTION_DAYS days (default 30)
+// after they are deleted and purged hourly.
+func NewTrashPurger(
+	trashRepo *repository.TrashRepository,
+	customerRepo *repository.CustomerRepository,
+	contactRepo *repository.ContactRepository,
+	productRepo *repository.ProductRepository,
+) *TrashPurger {
+	retention := time.Duration(envFloat("TRASH_RETENTION_DAYS", 30) * float64(24*time.Hour))
+	if retention <= 0 {
+		retention = 30 * 24 * time.Hour
+	}
+
+	return &TrashPurger{
+		trashRepo:    trashRepo,
+		customerRepo: customerRepo,
+		contactRepo:  contactRepo,
+		productRepo:  productRepo,
+		retention:    retention,
+		interval:     time.Hour,
+		stop:         make(chan struct{}),
+	}
+}
+
+// Retention returns how long deleted records are kept
+func (s *TrashPurger) Retention() time.Duration {
+	return s.retention
+}
+
+// Start purges expired records once at startup and then on every interval
+func (s *TrashPurger) Start() {
+	goBackground(func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.run()
+			select {
+			case <-ticker.C:
+			case <-s.stop:
+				return
+			}
+		}
+	})
+}
+
+// Stop ends the schedule after any run in progress has finished
+func (s *TrashPurger) Stop() {
+	close(s.stop)
+}
+
+func (s *TrashPurger) run() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	before := time.Now().Add(-s.retention)
+	purges := map[string]func(context.Context, int) error{
+		models.TrashCustomer: s.customerRepo.Purge,
+		models.TrashContact:  s.contactRepo.Purge,
+		models.TrashProduct:  s.productRepo.Purge,
+	}
+
+	for _, itemType := range models.TrashTypes {
+		ids, err := s.trashRepo.GetExpiredIDs(ctx, itemType, before)
+		if err != nil {
+			log.Printf("Failed to find expired %s records in the trash: %v", itemType, err)
+			continue
+		}
+
+		purged, kept := 0, 0
+		for _, id := range ids {
+			err := purges[itemType](ctx, id)
+			var inUse *repository.ProductInUseError
+			switch {
+			case err == nil:
+				purged++
+			case errors.Is(err, repository.ErrNotFound), err == repository.ErrNotDeleted:
+				// Restored or purged since it was listed
+			case errors.As(err, &inUse), errors.Is(err, repository.ErrConflict):
+				kept++
+			default:
+				log.Printf("Failed to purge %s %d from the trash: %v", itemType, id, err)
+			}
+		}
+		if purged > 0 {
+			log.Printf("Purged %d %s records from the trash; %d are still referenced and were kept", purged, itemType, kept)
+		}
+	}
+
+	archived, err := s.trashRepo.DeleteArchivedBefore(ctx, before)
+	if err != nil {
+		log.Printf("Failed to purge bulk-deleted records: %v", err)
+		return
+	}
+	if archived > 0 {
+		log.Printf("Purged %d bulk-deleted records", archived)
+	}
+}
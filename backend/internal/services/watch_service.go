@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// WatchService notifies users watching a customer or quotation when someone else changes
+// one of its watched fields or deletes it. Changes are read from the audit log as entries
+// are written, so every audited change to a record is covered.
+type WatchService struct {
+	watchRepo        *repository.WatchRepository
+	notificationRepo *repository.NotificationRepository
+	emailService     *EmailService
+	jobs             *JobQueue
+}
+
+// NewWatchService creates a new watch service. Emails are queued as jobs so they are retried
+// while the SMTP server is unreachable.
+func NewWatchService(watchRepo *repository.WatchRepository, notificationRepo *repository.NotificationRepository, emailService *EmailService, jobs *JobQueue) *WatchService {
+	return &WatchService{
+		watchRepo:        watchRepo,
+		notificationRepo: notificationRepo,
+		emailService:     emailService,
+		jobs:             jobs,
+	}
+}
+
+// RecordChanged notifies the watchers of the record an audit log entry is about in the
+// background, so the change itself is not delayed
+func (s *WatchService) RecordChanged(entry models.AuditLog) {
+	if _, ok := models.WatchableFields[entry.Entity]; !ok {
+		return
+	}
+	entityID, err := strconv.Atoi(entry.EntityID)
+	if err != nil {
+		return
+	}
+
+	goBackground(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		s.notifyWatchers(ctx, entry, entityID)
+	})
+}
+
+func (s *WatchService) notifyWatchers(ctx context.Context, entry models.AuditLog, entityID int) {
+	deleted := entry.Action == models.AuditDelete || entry.Action == "purge"
+	if !deleted && (len(entry.Before) == 0 || len(entry.After) == 0) {
+		// Creations and entries without both snapshots change no watched field
+		return
+	}
+
+	watchers, err := s.watchRepo.GetWatchers(ctx, entry.Entity, entityID)
+	if err != nil {
+		log.Printf("Failed to find watchers of %s %d: %v", entry.Entity, entityID, err)
+		return
+	}
+	if len(watchers) == 0 {
+		return
+	}
+
+	snapshot := entry.After
+	if deleted {
+		snapshot = entry.Before
+	}
+	label := watchedRecordLabel(entry.Entity, entityID, snapshot)
+	changes := DiffSnapshots(entry.Before, entry.After)
+
+	for _, watcher := range watchers {
+		if entry.UserID != nil && *entry.UserID == watcher.UserID {
+			continue
+		}
+
+		title := label + " was deleted"
+		message := fmt.Sprintf("%s you are watching was deleted.", label)
+		if !deleted {
+			watched := watchedChanges(changes, watcher.WatchedFields())
+			if len(watched) == 0 {
+				continue
+			}
+			title = label + " changed"
+			message = strings.Join(watched, "; ")
+		}
+
+		entity := entry.Entity
+		notification := models.Notification{
+			UserID:     watcher.UserID,
+			Type:       models.NotificationWatchedChange,
+			Title:      title,
+			Message:    message,
+			EntityType: &entity,
+			EntityID:   &entityID,
+		}
+		if err := s.notificationRepo.Create(ctx, &notification); err != nil {
+			log.Printf("Failed to notify user %d of a change to %s %d: %v", watcher.UserID, entry.Entity, entityID, err)
+		}
+
+		if watcher.Email && s.emailService.Enabled() {
+			_, err := s.jobs.Enqueue(ctx, JobSendEmail, EmailMessage{
+				To:      []mail.Address{{Name: watcher.UserName, Address: watcher.UserEmail}},
+				Subject: title,
+				Text:    message + "\n\nYou are receiving this because you are watching " + label + ".\n",
+			})
+			if err != nil {
+				log.Printf("Failed to queue email to user %d about a change to %s %d: %v", watcher.UserID, entry.Entity, entityID, err)
+			}
+		}
+	}
+}
+
+// watchedChanges describes the changes to the watched fields as "field: old → new"
+func watchedChanges(changes []FieldChange, fields []string) []string {
+	described := []string{}
+	for _, change := range changes {
+		for _, field := range fields {
+			if change.Field == field {
+				described = append(described, fmt.Sprintf("%s: %s → %s", field, watchedValue(change.From), watchedValue(change.To)))
+				break
+			}
+		}
+	}
+	return described
+}
+
+// watchedValue formats a snapshot value for a notification
+func watchedValue(value interface{}) string {
+	if value == nil {
+		return "(none)"
+	}
+	return fmt.Sprint(value)
+}
+
+// watchedRecordLabel names a record in notifications, from its snapshot when it has one
+func watchedRecordLabel(entity string, entityID int, snapshot json.RawMessage) string {
+	switch entity {
+	case models.AuditEntityCustomer:
+		var customer models.Customer
+		if json.Unmarshal(snapshot, &customer) == nil && customer.CompanyName != "" {
+			return "Customer " + customer.CompanyName
+		}
+		return fmt.Sprintf("Customer #%d", entityID)
+	case models.AuditEntityQuotation:
+		var quotation models.Quotation
+		if json.Unmarshal(snapshot, &quotation) == nil && quotation.QuotationID != 0 {
+			return "Quotation " + quotation.Reference()
+		}
+		return fmt.Sprintf("Quotation #%d", entityID)
+	}
+	return fmt.Sprintf("%s #%d", entity, entityID)
+}
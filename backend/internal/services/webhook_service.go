@@ -0,0 +1,273 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	mathrand "math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Cezzyy/SCMS/backend/internal/models"
+	"github.com/Cezzyy/SCMS/backend/internal/repository"
+)
+
+// Webhook request headers. The signature is "t=<unix time>,v1=<hex HMAC-SHA256>" where the
+// HMAC is keyed with the endpoint's secret over "<unix time>.<body>"; receivers should
+// recompute it and reject requests whose time is too far from their own.
+const (
+	WebhookSignatureHeader = "X-SCMS-Signature"
+	WebhookEventHeader     = "X-SCMS-Event"
+	WebhookEventIDHeader   = "X-SCMS-Event-ID"
+	WebhookDeliveryHeader  = "X-SCMS-Delivery"
+)
+
+const (
+	webhookBatchSize    = 20
+	webhookLease        = 2 * time.Minute
+	webhookFirstRetry   = 30 * time.Second
+	webhookMaxRetry     = 6 * time.Hour
+	webhookResponseSize = 2048
+)
+
+// WebhookPayload is the body of every webhook callback
+type WebhookPayload struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// WebhookService queues business events for the endpoints subscribed to them and sends them
+// as signed callbacks. Deliveries are stored before they are sent, so those still pending
+// when the server stops are sent after it starts again; failed attempts are retried with
+// exponential backoff.
+type WebhookService struct {
+	webhookRepo *repository.WebhookRepository
+	client      *http.Client
+	maxAttempts int
+	interval    time.Duration
+	wake        chan struct{}
+	stop        chan struct{}
+}
+
+// NewWebhookService creates a new webhook service that tries each delivery up to
+// WEBHOOK_MAX_ATTEMPTS times (default 8), waiting 30 seconds after the first failure and
+// twice as long after each one after that, up to 6 hours
+func NewWebhookService(webhookRepo *repository.WebhookRepository) *WebhookService {
+	maxAttempts := int(envFloat("WEBHOOK_MAX_ATTEMPTS", 8))
+	if maxAttempts < 1 {
+		maxAttempts = 8
+	}
+
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		client:      &http.Client{Timeout: 15 * time.Second},
+		maxAttempts: maxAttempts,
+		interval:    10 * time.Second,
+		wake:        make(chan struct{}, 1),
+		stop:        make(chan struct{}),
+	}
+}
+
+// GenerateSecret returns a new random signing secret for an endpoint
+func (s *WebhookService) GenerateSecret() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(raw), nil
+}
+
+// Publish queues an event for every endpoint subscribed to it. Failures are logged rather
+// than returned so an unreachable webhook never fails the change that caused the event.
+func (s *WebhookService) Publish(ctx context.Context, event string, data interface{}) {
+	raw := make([]byte, 16)
+	rand.Read(raw)
+	eventID := "evt_" + hex.EncodeToString(raw)
+	payload, err := json.Marshal(WebhookPayload{
+		ID:        eventID,
+		Event:     event,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		log.Printf("Failed to encode %s webhook: %v", event, err)
+		return
+	}
+
+	queued, err := s.webhookRepo.CreateDeliveries(ctx, event, eventID, payload)
+	if err != nil {
+		log.Printf("Failed to queue %s webhook: %v", event, err)
+		return
+	}
+	if queued > 0 {
+		s.nudge()
+	}
+}
+
+// Redeliver queues a delivery's event to be sent to its endpoint again
+func (s *WebhookService) Redeliver(ctx context.Context, delivery models.WebhookDelivery) (models.WebhookDelivery, error) {
+	redelivery, err := s.webhookRepo.Redeliver(ctx, delivery)
+	if err != nil {
+		return redelivery, err
+	}
+	s.nudge()
+	return redelivery, nil
+}
+
+// nudge wakes the sender without waiting for its next interval
+func (s *WebhookService) nudge() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start sends due deliveries at startup, whenever events are published, and on every interval
+func (s *WebhookService) Start() {
+	goBackground(func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.run()
+			select {
+			case <-ticker.C:
+			case <-s.wake:
+			case <-s.stop:
+				return
+			}
+		}
+	})
+}
+
+// Stop ends the schedule after any run in progress has finished
+func (s *WebhookService) Stop() {
+	close(s.stop)
+}
+
+// run sends every delivery that is due, a batch at a time
+func (s *WebhookService) run() {
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		tasks, err := s.webhookRepo.ClaimDue(ctx, webhookBatchSize, webhookLease)
+		cancel()
+		if err != nil {
+			log.Printf("Failed to load due webhooks: %v", err)
+			return
+		}
+		if len(tasks) == 0 {
+			return
+		}
+
+		var wg sync.WaitGroup
+		for _, task := range tasks {
+			wg.Add(1)
+			go func(task repository.WebhookTask) {
+				defer wg.Done()
+				s.deliver(task)
+			}(task)
+		}
+		wg.Wait()
+
+		if len(tasks) < webhookBatchSize {
+			return
+		}
+	}
+}
+
+// deliver sends one delivery and records the outcome, scheduling a retry when it failed and
+// attempts remain
+func (s *WebhookService) deliver(task repository.WebhookTask) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	delivery := task.WebhookDelivery
+	delivery.Attempts++
+	delivery.ResponseStatus = nil
+	delivery.ResponseBody = nil
+	delivery.Error = nil
+
+	status, body, err := s.post(ctx, task)
+	if status != 0 {
+		delivery.ResponseStatus = &status
+		delivery.ResponseBody = &body
+	}
+	switch {
+	case err == nil:
+		now := time.Now()
+		delivery.Status = models.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+	case delivery.Attempts >= s.maxAttempts:
+		message := err.Error()
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.Error = &message
+		delivery.NextAttemptAt = nil
+		log.Printf("Webhook delivery %d to %s failed after %d attempts: %v", delivery.WebhookDeliveryID, task.URL, delivery.Attempts, err)
+	default:
+		message := err.Error()
+		next := time.Now().Add(webhookBackoff(delivery.Attempts))
+		delivery.Status = models.WebhookDeliveryPending
+		delivery.Error = &message
+		delivery.NextAttemptAt = &next
+	}
+
+	if err := s.webhookRepo.RecordAttempt(ctx, &delivery); err != nil {
+		log.Printf("Failed to record webhook delivery %d: %v", delivery.WebhookDeliveryID, err)
+	}
+}
+
+// post sends a delivery's payload, signed with the endpoint's secret. Any response other
+// than 2xx is an error; the status and the start of the body are returned when there is one.
+func (s *WebhookService) post(ctx context.Context, task repository.WebhookTask) (int, string, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(task.Secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(task.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, task.URL, bytes.NewReader(task.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "SCMS-Webhooks/1.0")
+	req.Header.Set(WebhookEventHeader, task.Event)
+	req.Header.Set(WebhookEventIDHeader, task.EventID)
+	req.Header.Set(WebhookDeliveryHeader, strconv.Itoa(task.WebhookDeliveryID))
+	req.Header.Set(WebhookSignatureHeader, "t="+timestamp+",v1="+hex.EncodeToString(mac.Sum(nil)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseSize))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, string(body), fmt.Errorf("endpoint responded %s", resp.Status)
+	}
+	return resp.StatusCode, string(body), nil
+}
+
+// webhookBackoff is how long to wait after a delivery's nth failed attempt: doubling from
+// webhookFirstRetry up to webhookMaxRetry, with up to a fifth added at random so retries to
+// a recovering endpoint are spread out
+func webhookBackoff(attempts int) time.Duration {
+	wait := time.Duration(float64(webhookFirstRetry) * math.Pow(2, float64(attempts-1)))
+	if wait > webhookMaxRetry || wait <= 0 {
+		wait = webhookMaxRetry
+	}
+	return wait + time.Duration(mathrand.Int63n(int64(wait)/5+1))
+}
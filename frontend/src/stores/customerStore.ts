@@ -124,8 +124,10 @@ export const useCustomerStore = defineStore('customer', {
         }
 
         return response.data;
-      } catch (err) {
-        this.error = 'Failed to update customer';
+      } catch (err: any) {
+        this.error = err.response?.data?.code === 'stale_version'
+          ? 'This customer was changed by someone else; reload it and try again'
+          : 'Failed to update customer';
         console.error(err);
         throw err;
       } finally {
@@ -236,9 +236,13 @@ export const useQuotationStore = defineStore('quotation', {
         this.loading = true;
         this.error = null;
 
+        // Send the version the quotation was loaded at so someone else's change is not overwritten
+        const loaded = this.currentQuotation?.quotation_id === id
+          ? this.currentQuotation
+          : this.quotations.find(q => q.quotation_id === id);
         const response = await apiClient.post<Quotation>(
           `/api/quotations/${id}/status`, 
-          { status }
+          { status, version: loaded?.version }
         );
 
         // Update quotation in the list
@@ -251,13 +255,16 @@ export const useQuotationStore = defineStore('quotation', {
         if (this.currentQuotation?.quotation_id === id) {
           this.currentQuotation = {
             ...this.currentQuotation,
-            status: response.data.status
+            status: response.data.status,
+            version: response.data.version
           };
         }
 
         return response.data;
-      } catch (err) {
-        this.error = 'Failed to update quotation status';
+      } catch (err: any) {
+        this.error = err.response?.data?.code === 'stale_version'
+          ? 'This quotation was changed by someone else; reload it and try again'
+          : 'Failed to update quotation status';
         console.error(err);
         throw err;
       } finally {
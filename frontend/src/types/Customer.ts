@@ -6,14 +6,16 @@ export interface Customer {
   phone?: string;
   email?: string;
   website?: string;
+  version: number;
   created_at: string;
   updated_at: string;
 }
 
-export interface CustomerCreate extends Omit<Customer, 'customer_id' | 'created_at' | 'updated_at'> {
+export interface CustomerCreate extends Omit<Customer, 'customer_id' | 'version' | 'created_at' | 'updated_at'> {
   customer_id?: number;
 }
 
 export interface CustomerUpdate extends CustomerCreate {
   customer_id: number;
+  version: number;
 } 
\ No newline at end of file
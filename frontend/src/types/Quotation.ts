@@ -5,6 +5,7 @@ export interface Quotation {
   validity_date: string;
   status: 'Pending' | 'Approved' | 'Rejected' | 'Expired';
   total_amount: number;
+  version: number;
   created_at: string;
   updated_at: string;
 }